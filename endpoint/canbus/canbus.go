@@ -0,0 +1,521 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package canbus 提供 SocketCAN 总线端点
+// 端点从 SocketCAN 接口读取 CAN 帧，可以按 DBC 文件把信号解码为带名称的物理值，帧按标识符或者 DBC 报文名称路由到规则链，
+// 规则链可以通过 x/canbusWrite 节点发送原始帧或者按 DBC 编码的报文
+package canbus
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "canbus"
+
+// 元数据key
+const (
+	KeyCanId     = "canId"
+	KeyInterface = "interface"
+	KeyMessage   = "message"
+)
+
+// MsgTypeFrame DBC 中没有定义的帧的消息类型
+const MsgTypeFrame = "CAN"
+
+// reconnectInterval 接口读取失败后重新打开的间隔
+var reconnectInterval = 5 * time.Second
+
+// Endpoint 别名
+type Endpoint = CanBus
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// buses 运行中的端点，key 为端点 Id，供 x/canbusWrite 节点查找
+var buses sync.Map
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+// FrameData 帧和解码后的信号
+type FrameData struct {
+	Id       uint32 `json:"id"`
+	Extended bool   `json:"extended"`
+	Rtr      bool   `json:"rtr"`
+	// Data 数据，十六进制
+	Data string `json:"data"`
+	// Message DBC 报文名称
+	Message string `json:"message,omitempty"`
+	// Signals 信号名称->物理值
+	Signals map[string]float64 `json:"signals,omitempty"`
+	// Units 信号名称->单位
+	Units map[string]string `json:"units,omitempty"`
+	// Labels 有值描述的信号名称->描述
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type RequestMessage struct {
+	headers   textproto.MIMEHeader
+	body      []byte
+	iface     string
+	frame     Frame
+	frameData *FrameData
+	msg       *types.RuleMsg
+	err       error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body, _ = json.Marshal(r.frameData)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return formatId(r.frame.Id)
+}
+
+// GetParam 获取标识符、接口和 DBC 报文名称
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyCanId:
+		return formatId(r.frame.Id)
+	case KeyInterface:
+		return r.iface
+	case KeyMessage:
+		return r.frameData.Message
+	default:
+		return ""
+	}
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 DBC 报文名称，没有定义的帧为 CAN，标识符、接口和报文名称放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyCanId, formatId(r.frame.Id))
+		metadata.PutValue(KeyInterface, r.iface)
+		msgType := MsgTypeFrame
+		if r.frameData.Message != "" {
+			msgType = r.frameData.Message
+			metadata.PutValue(KeyMessage, r.frameData.Message)
+		}
+		ruleMsg := types.NewMsg(0, msgType, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage CAN 帧不需要响应
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// CanBusConfig SocketCAN 端点配置
+type CanBusConfig struct {
+	// Interface SocketCAN 接口名称，eg. can0、vcan0
+	Interface string `json:"interface" label:"Interface" desc:"SocketCAN interface name, eg. can0, vcan0" required:"true"`
+	// Dbc DBC 文件路径，为空不解码信号
+	Dbc string `json:"dbc" label:"DBC File" desc:"DBC file path used to decode signals, empty routes raw frames"`
+	// DbcOnly 只路由 DBC 中定义的帧
+	DbcOnly bool `json:"dbcOnly" label:"DBC Only" desc:"Route only frames defined in the DBC file"`
+}
+
+// CanBus SocketCAN 端点
+// 路由的 from 为 CAN 标识符，支持 0x123、291、范围 0x100-0x1FF 和 DBC 报文名称，为空或者 * 匹配所有帧，帧发送到所有匹配的路由
+type CanBus struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     CanBusConfig
+	db         *Database
+	// matchers 路由 Id->匹配规则，由 RouterStorage 的锁保护
+	matchers map[string]frameMatcher
+	// mu 保护 bus
+	mu      sync.Mutex
+	bus     Bus
+	started bool
+	closed  chan struct{}
+}
+
+// Type 组件类型
+func (x *CanBus) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *CanBus) New() types.Node {
+	return &CanBus{
+		Config: CanBusConfig{
+			Interface: "can0",
+		},
+	}
+}
+
+// Init 初始化
+func (x *CanBus) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if strings.TrimSpace(x.Config.Interface) == "" {
+		return errors.New("can interface is empty")
+	}
+	if x.Config.Dbc != "" {
+		if x.db, err = LoadDBC(x.Config.Dbc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (x *CanBus) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *CanBus) Desc() string {
+	return "SocketCAN endpoint routing CAN frames with DBC decoded signals to rule chains by CAN id or message name"
+}
+
+// Category returns the component category
+func (x *CanBus) Category() string {
+	return "endpoint"
+}
+
+func (x *CanBus) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "SocketCAN endpoint routing CAN frames with DBC decoded signals to rule chains by CAN id or message name",
+		RouterForm: &types.RouterForm{
+			From: &types.RouterFormField{
+				Path: types.ComponentFormField{
+					Name:  "path",
+					Type:  "string",
+					Label: "CAN ID",
+					Desc:  "CAN id, id range or DBC message name to route, eg. 0x123, 0x100-0x1FF or EngineData, empty or * routes all frames",
+				},
+			},
+		},
+	}
+}
+
+func (x *CanBus) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	buses.CompareAndDelete(x.Id(), x)
+	if !x.started {
+		return nil
+	}
+	x.started = false
+	close(x.closed)
+	if x.bus != nil {
+		_ = x.bus.Close()
+		x.bus = nil
+	}
+	return nil
+}
+
+func (x *CanBus) Id() string {
+	return x.Config.Interface
+}
+
+func (x *CanBus) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	var from string
+	if f := router.GetFrom(); f != nil {
+		from = f.ToString()
+	}
+	matcher, err := parseMatcher(from)
+	if err != nil {
+		return "", err
+	}
+	if matcher.name != "" && x.db != nil {
+		if _, ok := x.db.LookupName(matcher.name); !ok {
+			return "", fmt.Errorf("message %s not found in dbc", matcher.name)
+		}
+	}
+	x.CheckAndSetRouterId(router)
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpointApi.Router)
+	}
+	if x.matchers == nil {
+		x.matchers = make(map[string]frameMatcher)
+	}
+	x.RouterStorage[router.GetId()] = router
+	x.matchers[router.GetId()] = matcher
+	return router.GetId(), nil
+}
+
+func (x *CanBus) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if _, ok := x.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(x.RouterStorage, routerId)
+	delete(x.matchers, routerId)
+	return nil
+}
+
+// routersOf 帧匹配的路由
+func (x *CanBus) routersOf(frame Frame, message *Message) []endpointApi.Router {
+	x.RLock()
+	defer x.RUnlock()
+	var routers []endpointApi.Router
+	for id, r := range x.RouterStorage {
+		if x.matchers[id].match(frame, message) {
+			routers = append(routers, r)
+		}
+	}
+	return routers
+}
+
+// Start 打开 CAN 接口，读取失败后自动重新打开
+func (x *CanBus) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.started {
+		return nil
+	}
+	bus, err := openBus(x.Config.Interface)
+	if err != nil {
+		return err
+	}
+	x.bus, x.started, x.closed = bus, true, make(chan struct{})
+	go x.readLoop(bus, x.closed)
+	buses.Store(x.Id(), x)
+	x.Printf("started CAN bus endpoint on %s", x.Config.Interface)
+	return nil
+}
+
+// readLoop 读取帧，失败后重新打开接口，直到端点关闭
+func (x *CanBus) readLoop(bus Bus, closed chan struct{}) {
+	for {
+		frame, err := bus.ReadFrame()
+		if err == nil {
+			x.onFrame(frame)
+			continue
+		}
+		select {
+		case <-closed:
+			return
+		default:
+		}
+		x.Printf("can bus %s read error: %v, reopening", x.Config.Interface, err)
+		_ = bus.Close()
+		for bus = nil; bus == nil; {
+			select {
+			case <-closed:
+				return
+			case <-time.After(reconnectInterval):
+			}
+			if b, err := openBus(x.Config.Interface); err == nil {
+				x.mu.Lock()
+				if !x.started {
+					x.mu.Unlock()
+					_ = b.Close()
+					return
+				}
+				x.bus, bus = b, b
+				x.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (x *CanBus) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// Decode 按 DBC 解码帧，没有配置 DBC 或者没有定义的帧只输出原始数据
+func (x *CanBus) Decode(frame Frame) (*FrameData, *Message) {
+	data := &FrameData{Id: frame.Id, Extended: frame.Extended, Rtr: frame.Rtr, Data: hex.EncodeToString(frame.Data)}
+	if x.db == nil {
+		return data, nil
+	}
+	message, ok := x.db.Lookup(frame.Id, frame.Extended)
+	if !ok {
+		return data, nil
+	}
+	data.Message = message.Name
+	if frame.Rtr {
+		return data, message
+	}
+	var labels map[string]string
+	data.Signals, labels = message.Decode(frame.Data)
+	for _, s := range message.Signals {
+		if _, ok := data.Signals[s.Name]; ok && s.Unit != "" {
+			if data.Units == nil {
+				data.Units = make(map[string]string)
+			}
+			data.Units[s.Name] = s.Unit
+		}
+	}
+	if len(labels) > 0 {
+		data.Labels = labels
+	}
+	return data, message
+}
+
+// onFrame 解码帧并交给匹配的路由
+func (x *CanBus) onFrame(frame Frame) {
+	frameData, message := x.Decode(frame)
+	if x.Config.DbcOnly && message == nil {
+		return
+	}
+	routers := x.routersOf(frame, message)
+	if len(routers) == 0 {
+		return
+	}
+	request := &RequestMessage{iface: x.Config.Interface, frame: frame, frameData: frameData}
+	for _, router := range routers {
+		exchange := &endpointApi.Exchange{
+			In:  request,
+			Out: &ResponseMessage{},
+		}
+		x.DoProcess(context.Background(), router, exchange)
+	}
+}
+
+// Database 加载的 DBC，没有配置返回 nil
+func (x *CanBus) Database() *Database {
+	return x.db
+}
+
+// Send 发送帧
+func (x *CanBus) Send(frame Frame) error {
+	if err := frame.check(); err != nil {
+		return err
+	}
+	x.mu.Lock()
+	bus := x.bus
+	x.mu.Unlock()
+	if bus == nil {
+		return fmt.Errorf("can bus not started: %s", x.Id())
+	}
+	return bus.WriteFrame(frame)
+}
+
+// lookupBus 查找运行中的端点
+func lookupBus(id string) (*CanBus, bool) {
+	if v, ok := buses.Load(id); ok {
+		return v.(*CanBus), true
+	}
+	return nil, false
+}
+
+// formatId 标识符格式：0x123
+func formatId(id uint32) string {
+	return fmt.Sprintf("0x%X", id)
+}
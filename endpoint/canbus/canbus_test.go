@@ -0,0 +1,243 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canbus
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testBus 测试用的 CAN 总线，receive 模拟总线上的帧，written 记录发送的帧
+type testBus struct {
+	frames  chan Frame
+	closed  chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	written []Frame
+}
+
+func newTestBus() *testBus {
+	return &testBus{frames: make(chan Frame, 10), closed: make(chan struct{})}
+}
+
+func (b *testBus) ReadFrame() (Frame, error) {
+	select {
+	case frame := <-b.frames:
+		return frame, nil
+	case <-b.closed:
+		return Frame{}, errors.New("bus closed")
+	}
+}
+
+func (b *testBus) WriteFrame(frame Frame) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.written = append(b.written, frame)
+	return nil
+}
+
+func (b *testBus) Close() error {
+	b.once.Do(func() {
+		close(b.closed)
+	})
+	return nil
+}
+
+func (b *testBus) sent() []Frame {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Frame(nil), b.written...)
+}
+
+func TestCanBusEndpoint(t *testing.T) {
+	var opened []*testBus
+	var mu sync.Mutex
+	openBus = func(name string) (Bus, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if name != "vcan0" {
+			return nil, errors.New("no such device")
+		}
+		bus := newTestBus()
+		opened = append(opened, bus)
+		return bus, nil
+	}
+	defer func() {
+		openBus = openSocketCAN
+	}()
+	reconnectInterval = time.Millisecond * 100
+	current := func() *testBus {
+		mu.Lock()
+		defer mu.Unlock()
+		return opened[len(opened)-1]
+	}
+
+	dbcFile := filepath.Join(t.TempDir(), "test.dbc")
+	assert.Nil(t, os.WriteFile(dbcFile, []byte(testDBC), 0644))
+
+	config := engine.NewConfig()
+	_, err := engine.New("canbus-test01", []byte(`{
+		"ruleChain": {"id": "canbus-test01", "name": "canbus-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("canbus-test01")
+
+	assert.NotNil(t, (&CanBus{}).New().Init(config, types.Configuration{"dbc": "/not/exists.dbc"}))
+	ep := (&CanBus{}).New().(*CanBus)
+	assert.Equal(t, Type, ep.Type())
+	assert.Nil(t, ep.Init(config, types.Configuration{"interface": "vcan1"}))
+	assert.NotNil(t, ep.Start())
+
+	ep = (&CanBus{}).New().(*CanBus)
+	err = ep.Init(config, types.Configuration{"interface": "vcan0", "dbc": dbcFile})
+	assert.Nil(t, err)
+
+	engineData := make(chan types.RuleMsg, 10)
+	all := make(chan types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("EngineData").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		engineData <- *exchange.In.GetMsg()
+		return true
+	}).To("chain:canbus-test01").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("0x000-0x7FF").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		all <- *exchange.In.GetMsg()
+		return true
+	}).To("chain:canbus-test01").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("Unknown").To("chain:canbus-test01").End())
+	assert.NotNil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	receive := func(ch chan types.RuleMsg) types.RuleMsg {
+		select {
+		case msg := <-ch:
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("frame not routed")
+		}
+		return types.RuleMsg{}
+	}
+	current().frames <- Frame{Id: 0x100, Data: []byte{0x70, 0x17, 0x82, 0x03, 0x1f, 0x40, 0xff, 0x9c}}
+	msg := receive(engineData)
+	assert.Equal(t, "EngineData", msg.Type)
+	assert.Equal(t, "0x100", msg.Metadata.GetValue(KeyCanId))
+	assert.Equal(t, "vcan0", msg.Metadata.GetValue(KeyInterface))
+	var data FrameData
+	assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &data))
+	assert.Equal(t, 1500.0, data.Signals["EngineSpeed"])
+	assert.Equal(t, "rpm", data.Units["EngineSpeed"])
+	assert.Equal(t, "Drive", data.Labels["Gear"])
+	receive(all)
+
+	// DBC 中没有定义的帧
+	current().frames <- Frame{Id: 0x321, Data: []byte{1, 2}}
+	msg = receive(all)
+	assert.Equal(t, MsgTypeFrame, msg.Type)
+	assert.Equal(t, `{"id":801,"extended":false,"rtr":false,"data":"0102"}`, msg.GetData())
+	// 扩展帧不在范围内
+	current().frames <- Frame{Id: 0x18FEF1FE, Extended: true, Data: []byte{1, 0x34, 0x12}}
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 0, len(all))
+
+	// 规则链发送帧
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	rawNode, err := test.CreateAndInitNode("x/canbusWrite", types.Configuration{"interface": "vcan0"}, Registry)
+	assert.Nil(t, err)
+	dbcNode, err := test.CreateAndInitNode("x/canbusWrite", types.Configuration{"interface": "vcan0", "message": "${metadata.message}"}, Registry)
+	assert.Nil(t, err)
+	notFoundNode, err := test.CreateAndInitNode("x/canbusWrite", types.Configuration{"interface": "vcan9"}, Registry)
+	assert.Nil(t, err)
+
+	newMsg := func(msgType, message, data string) test.Msg {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyMessage, message)
+		return test.Msg{
+			MetaData:   metadata,
+			DataType:   types.JSON,
+			MsgType:    msgType,
+			Data:       data,
+			AfterSleep: time.Millisecond * 100,
+		}
+	}
+	test.NodeOnMsg(t, rawNode, []test.Msg{
+		newMsg("RAW", "", `{"id": "0x123", "data": "0102"}`),
+		newMsg("EXTENDED", "", `{"id": 419361278, "data": "01", "extended": true}`),
+		newMsg("INVALID_ID", "", `{"id": "0x800", "data": "01"}`),
+		newMsg("INVALID", "", `0102`),
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		switch msg.Type {
+		case "RAW", "EXTENDED":
+			assert.Equal(t, types.Success, relationType)
+		default:
+			assert.Equal(t, types.Failure, relationType)
+		}
+	})
+	test.NodeOnMsg(t, dbcNode, []test.Msg{
+		newMsg("SIGNALS", "EngineData", `{"EngineSpeed": 1500, "Gear": "Drive"}`),
+		newMsg("HEX", "0x7DF", `02 01 0C`),
+		newMsg("UNKNOWN_SIGNAL", "EngineData", `{"Speed": 1}`),
+		newMsg("UNKNOWN_MESSAGE", "Brake", `{}`),
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		switch msg.Type {
+		case "SIGNALS":
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "0x100", msg.Metadata.GetValue(KeyCanId))
+		case "HEX":
+			assert.Equal(t, types.Success, relationType)
+		default:
+			assert.Equal(t, types.Failure, relationType)
+		}
+	})
+	test.NodeOnMsg(t, notFoundNode, []test.Msg{newMsg("RAW", "", `{"id": "0x123", "data": "0102"}`)}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+	})
+	assert.Equal(t, []Frame{
+		{Id: 0x123, Data: []byte{1, 2}},
+		{Id: 0x18FEF1FE, Extended: true, Data: []byte{1}},
+		{Id: 0x100, Data: []byte{0x70, 0x17, 0, 0x03, 0, 0, 0, 0}},
+		{Id: 0x7DF, Data: []byte{2, 1, 0x0c}},
+	}, current().sent())
+
+	// 读取失败后重新打开接口
+	_ = current().Close()
+	time.Sleep(time.Millisecond * 300)
+	mu.Lock()
+	assert.Equal(t, 2, len(opened))
+	mu.Unlock()
+	current().frames <- Frame{Id: 0x321, Data: []byte{3}}
+	msg = receive(all)
+	assert.Equal(t, `{"id":801,"extended":false,"rtr":false,"data":"03"}`, msg.GetData())
+
+	ep.Destroy()
+	_, ok := lookupBus(ep.Id())
+	assert.False(t, ok)
+}
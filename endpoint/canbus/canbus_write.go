@@ -0,0 +1,169 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canbus
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/cast"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteNodeConfiguration 节点配置
+type WriteNodeConfiguration struct {
+	// Interface SocketCAN 端点 Id，与 endpoint/canbus 的 interface 配置一致，eg. can0
+	Interface string `json:"interface" label:"Interface" desc:"SocketCAN endpoint id, same as the interface of endpoint/canbus" required:"true"`
+	// Message DBC 报文名称或者 CAN 标识符，可以使用 ${metadata.key} 或者 ${msg.key} 变量，为空时消息负荷为帧
+	Message string `json:"message" label:"Message" desc:"DBC message name or CAN id, supports ${metadata.key} and ${msg.key} variables, empty means the message payload is a frame"`
+	// Extended 标识符为扩展帧
+	Extended bool `json:"extended" label:"Extended" desc:"Send the CAN id as an extended frame"`
+}
+
+// WriteNode 通过 SocketCAN 端点发送 CAN 帧，支持 3 种负荷：
+//   - message 为 DBC 报文名称：msg.Data 为信号名称->物理值，eg. {"TargetSpeed": 1500, "Mode": "Auto"}，按 DBC 编码
+//   - message 为 CAN 标识符：msg.Data 为十六进制数据或者二进制数据
+//   - message 为空：msg.Data 为帧，eg. {"id": "0x123", "data": "0102", "extended": false}
+//
+// 发送成功，流转到`Success`链，否则流转到`Failure`链
+type WriteNode struct {
+	//节点配置
+	Config          WriteNodeConfiguration
+	messageTemplate str.Template
+}
+
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteNodeConfiguration{
+			Interface: "can0",
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/canbusWrite"
+}
+
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.messageTemplate = str.NewTemplate(x.Config.Message)
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	bus, ok := lookupBus(x.Config.Interface)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("can bus not found: %s", x.Config.Interface))
+		return
+	}
+	message := strings.TrimSpace(x.messageTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg)))
+	frame, err := x.frame(bus.Database(), message, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if err = bus.Send(frame); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KeyCanId, formatId(frame.Id))
+	if db := bus.Database(); db != nil {
+		if m, ok := db.Lookup(frame.Id, frame.Extended); ok {
+			msg.Metadata.PutValue(KeyMessage, m.Name)
+		}
+	}
+	ctx.TellSuccess(msg)
+}
+
+// frame 按配置和消息负荷构造帧
+func (x *WriteNode) frame(db *Database, message string, msg types.RuleMsg) (Frame, error) {
+	if message == "" {
+		var v struct {
+			Id       interface{} `json:"id"`
+			Data     string      `json:"data"`
+			Extended bool        `json:"extended"`
+			Rtr      bool        `json:"rtr"`
+		}
+		if err := json.Unmarshal([]byte(msg.GetData()), &v); err != nil {
+			return Frame{}, fmt.Errorf("can frame payload must be a json object: %w", err)
+		}
+		id, err := ParseId(cast.ToString(v.Id))
+		if err != nil {
+			return Frame{}, err
+		}
+		data, err := hex.DecodeString(strings.ReplaceAll(v.Data, " ", ""))
+		if err != nil {
+			return Frame{}, err
+		}
+		return Frame{Id: id, Extended: v.Extended, Rtr: v.Rtr, Data: data}, nil
+	}
+	var m *Message
+	id, err := ParseId(message)
+	if db != nil {
+		if err == nil {
+			m, _ = db.Lookup(id, x.Config.Extended)
+		} else {
+			m, _ = db.LookupName(message)
+		}
+	}
+	if m != nil {
+		var values map[string]interface{}
+		if err := json.Unmarshal([]byte(msg.GetData()), &values); err != nil {
+			return Frame{}, fmt.Errorf("signals of message %s must be a json object: %w", m.Name, err)
+		}
+		data, err := m.Encode(values)
+		if err != nil {
+			return Frame{}, err
+		}
+		return Frame{Id: m.Id, Extended: m.Extended, Data: data}, nil
+	}
+	if err != nil {
+		return Frame{}, fmt.Errorf("message %s not found in dbc", message)
+	}
+	var data []byte
+	if msg.DataType == types.BINARY {
+		data = msg.GetBytes()
+	} else if data, err = hex.DecodeString(strings.Join(strings.Fields(msg.GetData()), "")); err != nil {
+		return Frame{}, err
+	}
+	return Frame{Id: id, Extended: x.Config.Extended, Data: data}, nil
+}
+
+// Destroy 清理资源
+func (x *WriteNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Send a raw or DBC encoded CAN frame through the SocketCAN endpoint. Routes to Success/Failure"
+}
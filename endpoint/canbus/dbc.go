@@ -0,0 +1,403 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canbus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// dbcExtendedFlag DBC 文件中扩展帧标识符的标志位
+const dbcExtendedFlag = 0x80000000
+
+// Signal DBC 信号
+type Signal struct {
+	Name string
+	// Start 起始位，Intel 字节序为最低位，Motorola 字节序为最高位
+	Start  int
+	Length int
+	// BigEndian Motorola 字节序（@0）
+	BigEndian bool
+	Signed    bool
+	// Float IEEE 浮点数，由 SIG_VALTYPE_ 定义
+	Float  bool
+	Factor float64
+	Offset float64
+	Min    float64
+	Max    float64
+	Unit   string
+	// Multiplexer 多路复用器信号
+	Multiplexer bool
+	// MultiplexValue 被复用的信号只在复用器等于该值时有效，-1 表示不被复用
+	MultiplexValue int
+	// Values 原始值->描述，由 VAL_ 定义
+	Values map[int64]string
+}
+
+// Message DBC 报文
+type Message struct {
+	Id       uint32
+	Extended bool
+	Name     string
+	Length   int
+	Sender   string
+	Signals  []*Signal
+}
+
+// Database DBC 数据库
+type Database struct {
+	messages map[uint32]*Message
+	names    map[string]*Message
+}
+
+var (
+	dbcMessage   = regexp.MustCompile(`^BO_\s+(\d+)\s+(\w+)\s*:\s*(\d+)\s+(\w+)`)
+	dbcSignal    = regexp.MustCompile(`^SG_\s+(\w+)\s*(M|m\d+)?\s*:\s*(\d+)\|(\d+)@([01])([+-])\s*\(([^,]+),([^)]+)\)\s*\[([^|]*)\|([^\]]*)\]\s*"([^"]*)"`)
+	dbcValues    = regexp.MustCompile(`^VAL_\s+(\d+)\s+(\w+)\s+(.*);`)
+	dbcValuePair = regexp.MustCompile(`(-?\d+)\s+"([^"]*)"`)
+	dbcValueType = regexp.MustCompile(`^SIG_VALTYPE_\s+(\d+)\s+(\w+)\s*:\s*([12])\s*;`)
+)
+
+// LoadDBC 加载 DBC 文件
+func LoadDBC(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDBC(f)
+}
+
+// ParseDBC 解析 DBC，支持报文、信号、多路复用、值描述和浮点信号，忽略其他定义
+func ParseDBC(r io.Reader) (*Database, error) {
+	db := &Database{messages: make(map[uint32]*Message), names: make(map[string]*Message)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var current *Message
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "BO_ "):
+			m := dbcMessage.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("dbc line %d: invalid message: %s", n, line)
+			}
+			id, _ := strconv.ParseUint(m[1], 10, 32)
+			length, _ := strconv.Atoi(m[3])
+			current = &Message{Id: uint32(id) &^ dbcExtendedFlag, Extended: id&dbcExtendedFlag != 0, Name: m[2], Length: length, Sender: m[4]}
+			db.messages[uint32(id)] = current
+			db.names[current.Name] = current
+		case strings.HasPrefix(line, "SG_ "):
+			if current == nil {
+				return nil, fmt.Errorf("dbc line %d: signal without message", n)
+			}
+			s, err := parseSignal(line)
+			if err != nil {
+				return nil, fmt.Errorf("dbc line %d: %w", n, err)
+			}
+			current.Signals = append(current.Signals, s)
+		case strings.HasPrefix(line, "VAL_ "):
+			m := dbcValues.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			if s := db.signal(m[1], m[2]); s != nil {
+				s.Values = make(map[int64]string)
+				for _, pair := range dbcValuePair.FindAllStringSubmatch(m[3], -1) {
+					v, _ := strconv.ParseInt(pair[1], 10, 64)
+					s.Values[v] = pair[2]
+				}
+			}
+		case strings.HasPrefix(line, "SIG_VALTYPE_ "):
+			if m := dbcValueType.FindStringSubmatch(line); m != nil {
+				if s := db.signal(m[1], m[2]); s != nil {
+					s.Float = true
+				}
+			}
+		case line == "":
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func parseSignal(line string) (*Signal, error) {
+	m := dbcSignal.FindStringSubmatch(line)
+	if m == nil {
+		return nil, fmt.Errorf("invalid signal: %s", line)
+	}
+	s := &Signal{Name: m[1], BigEndian: m[5] == "0", Signed: m[6] == "-", Unit: m[11], MultiplexValue: -1}
+	switch {
+	case m[2] == "M":
+		s.Multiplexer = true
+	case m[2] != "":
+		s.MultiplexValue, _ = strconv.Atoi(m[2][1:])
+	}
+	s.Start, _ = strconv.Atoi(m[3])
+	s.Length, _ = strconv.Atoi(m[4])
+	var err error
+	for i, p := range []*float64{&s.Factor, &s.Offset, &s.Min, &s.Max} {
+		if *p, err = strconv.ParseFloat(strings.TrimSpace(m[7+i]), 64); err != nil {
+			return nil, fmt.Errorf("invalid signal %s: %w", s.Name, err)
+		}
+	}
+	if s.Length < 1 || s.Length > 64 || s.Start > 63 {
+		return nil, fmt.Errorf("invalid signal %s: start %d length %d", s.Name, s.Start, s.Length)
+	}
+	return s, nil
+}
+
+// signal 查找报文的信号
+func (db *Database) signal(id, name string) *Signal {
+	v, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil
+	}
+	if m, ok := db.messages[uint32(v)]; ok {
+		return m.Signal(name)
+	}
+	return nil
+}
+
+// Lookup 按帧的标识符查找报文
+func (db *Database) Lookup(id uint32, extended bool) (*Message, bool) {
+	if extended {
+		id |= dbcExtendedFlag
+	}
+	m, ok := db.messages[id]
+	return m, ok
+}
+
+// LookupName 按名称查找报文
+func (db *Database) LookupName(name string) (*Message, bool) {
+	m, ok := db.names[name]
+	return m, ok
+}
+
+// Signal 按名称查找信号
+func (m *Message) Signal(name string) *Signal {
+	for _, s := range m.Signals {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Decode 解码信号的物理值，有值描述的信号同时输出描述；被复用的信号只解码当前复用器值对应的信号，超出数据长度的信号忽略
+func (m *Message) Decode(data []byte) (values map[string]float64, labels map[string]string) {
+	values, labels = make(map[string]float64), make(map[string]string)
+	mux := -1
+	for _, s := range m.Signals {
+		if s.Multiplexer {
+			if raw, ok := s.raw(data); ok {
+				mux = int(raw)
+			}
+		}
+	}
+	for _, s := range m.Signals {
+		if s.MultiplexValue >= 0 && s.MultiplexValue != mux {
+			continue
+		}
+		raw, ok := s.raw(data)
+		if !ok {
+			continue
+		}
+		values[s.Name] = s.physical(raw)
+		if label, ok := s.Values[s.rawInt(raw)]; ok {
+			labels[s.Name] = label
+		}
+	}
+	return values, labels
+}
+
+// Encode 按信号编码数据，值可以是数字、布尔值或者值描述，没有给出的信号为 0；被复用的信号只编码复用器值对应的信号
+func (m *Message) Encode(values map[string]interface{}) ([]byte, error) {
+	for name := range values {
+		if m.Signal(name) == nil {
+			return nil, fmt.Errorf("signal %s not found in message %s", name, m.Name)
+		}
+	}
+	data := make([]byte, m.Length)
+	mux := -1
+	for _, s := range m.Signals {
+		if s.Multiplexer {
+			if v, ok := values[s.Name]; ok {
+				f, err := s.value(v)
+				if err != nil {
+					return nil, err
+				}
+				mux = int(f)
+			}
+		}
+	}
+	for _, s := range m.Signals {
+		v, ok := values[s.Name]
+		if !ok {
+			continue
+		}
+		if s.MultiplexValue >= 0 && s.MultiplexValue != mux {
+			return nil, fmt.Errorf("signal %s requires multiplexer value %d", s.Name, s.MultiplexValue)
+		}
+		f, err := s.value(v)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := s.toRaw(f)
+		if err != nil {
+			return nil, err
+		}
+		if !s.insert(data, raw) {
+			return nil, fmt.Errorf("signal %s exceeds message %s length %d", s.Name, m.Name, m.Length)
+		}
+	}
+	return data, nil
+}
+
+func mask(length int) uint64 {
+	if length >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<uint(length) - 1
+}
+
+// shift 信号在 64 位整数中的位移，Intel 字节序按小端，Motorola 字节序按大端
+func (s *Signal) shift(size int) (int, bool) {
+	if s.BigEndian {
+		msb := s.Start/8*8 + 7 - s.Start%8
+		return 64 - msb - s.Length, msb+s.Length <= size*8
+	}
+	return s.Start, s.Start+s.Length <= size*8
+}
+
+func (s *Signal) raw(data []byte) (uint64, bool) {
+	shift, ok := s.shift(len(data))
+	if !ok || shift < 0 {
+		return 0, false
+	}
+	var buf [8]byte
+	copy(buf[:], data)
+	var v uint64
+	if s.BigEndian {
+		v = binary.BigEndian.Uint64(buf[:])
+	} else {
+		v = binary.LittleEndian.Uint64(buf[:])
+	}
+	return (v >> uint(shift)) & mask(s.Length), true
+}
+
+func (s *Signal) insert(data []byte, raw uint64) bool {
+	shift, ok := s.shift(len(data))
+	if !ok || shift < 0 {
+		return false
+	}
+	var buf [8]byte
+	copy(buf[:], data)
+	m := mask(s.Length) << uint(shift)
+	raw = (raw << uint(shift)) & m
+	if s.BigEndian {
+		binary.BigEndian.PutUint64(buf[:], binary.BigEndian.Uint64(buf[:])&^m|raw)
+	} else {
+		binary.LittleEndian.PutUint64(buf[:], binary.LittleEndian.Uint64(buf[:])&^m|raw)
+	}
+	copy(data, buf[:])
+	return true
+}
+
+// rawInt 有符号信号的原始值按补码扩展
+func (s *Signal) rawInt(raw uint64) int64 {
+	if s.Signed && s.Length < 64 && raw&(1<<uint(s.Length-1)) != 0 {
+		return int64(raw | ^mask(s.Length))
+	}
+	return int64(raw)
+}
+
+// physical 物理值=原始值*因子+偏移
+func (s *Signal) physical(raw uint64) float64 {
+	var v float64
+	switch {
+	case s.Float && s.Length == 32:
+		v = float64(math.Float32frombits(uint32(raw)))
+	case s.Float && s.Length == 64:
+		v = math.Float64frombits(raw)
+	case s.Signed:
+		v = float64(s.rawInt(raw))
+	default:
+		v = float64(raw)
+	}
+	return v*s.Factor + s.Offset
+}
+
+// value 信号的物理值，字符串按值描述查找原始值
+func (s *Signal) value(v interface{}) (float64, error) {
+	if label, ok := v.(string); ok {
+		for raw, l := range s.Values {
+			if l == label {
+				return float64(raw)*s.Factor + s.Offset, nil
+			}
+		}
+	}
+	if b, ok := v.(bool); ok {
+		if b {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	f, err := cast.ToFloat64E(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value of signal %s: %v", s.Name, v)
+	}
+	return f, nil
+}
+
+// toRaw 物理值转换为原始值，并检查范围
+func (s *Signal) toRaw(f float64) (uint64, error) {
+	if s.Factor == 0 {
+		return 0, fmt.Errorf("signal %s factor is 0", s.Name)
+	}
+	v := (f - s.Offset) / s.Factor
+	switch {
+	case s.Float && s.Length == 32:
+		return uint64(math.Float32bits(float32(v))), nil
+	case s.Float && s.Length == 64:
+		return math.Float64bits(v), nil
+	}
+	v = math.Round(v)
+	min, max := 0.0, math.Ldexp(1, s.Length)-1
+	if s.Signed {
+		min, max = -math.Ldexp(1, s.Length-1), math.Ldexp(1, s.Length-1)-1
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %v out of range for signal %s", f, s.Name)
+	}
+	if v < 0 {
+		return uint64(int64(v)) & mask(s.Length), nil
+	}
+	return uint64(v), nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canbus
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+const testDBC = `VERSION ""
+
+NS_ :
+	CM_
+	VAL_
+
+BS_:
+
+BU_: ECU Dashboard
+
+BO_ 256 EngineData: 8 ECU
+ SG_ EngineSpeed : 0|16@1+ (0.25,0) [0|16383.75] "rpm" Dashboard
+ SG_ CoolantTemp : 16|8@1+ (1,-40) [-40|215] "degC" Dashboard
+ SG_ Gear : 24|3@1+ (1,0) [0|7] "" Dashboard
+ SG_ Throttle : 39|12@0+ (0.1,0) [0|100] "%" Dashboard
+ SG_ Torque : 55|16@0- (1,0) [-32768|32767] "Nm" Dashboard
+
+BO_ 2566844926 MuxData: 8 ECU
+ SG_ Mux M : 0|8@1+ (1,0) [0|255] "" Dashboard
+ SG_ ValueA m1 : 8|16@1+ (1,0) [0|65535] "" Dashboard
+ SG_ ValueB m2 : 8|32@1- (1,0) [0|0] "" Dashboard
+
+CM_ SG_ 256 EngineSpeed "Engine speed";
+VAL_ 256 Gear 0 "Park" 1 "Reverse" 2 "Neutral" 3 "Drive" ;
+SIG_VALTYPE_ 2566844926 ValueB : 1;
+`
+
+func TestDBC(t *testing.T) {
+	db, err := ParseDBC(strings.NewReader(testDBC))
+	assert.Nil(t, err)
+	engine, ok := db.Lookup(0x100, false)
+	assert.True(t, ok)
+	assert.Equal(t, "EngineData", engine.Name)
+	assert.Equal(t, 5, len(engine.Signals))
+	assert.Equal(t, "Drive", engine.Signal("Gear").Values[3])
+	_, ok = db.Lookup(0x100, true)
+	assert.False(t, ok)
+	mux, ok := db.LookupName("MuxData")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(0x18FEF1FE), mux.Id)
+	assert.True(t, mux.Extended)
+	assert.True(t, mux.Signal("ValueB").Float)
+
+	data, _ := hex.DecodeString("70178203" + "1f40ff9c")
+	values, labels := engine.Decode(data)
+	assert.Equal(t, map[string]float64{"EngineSpeed": 1500, "CoolantTemp": 90, "Gear": 3, "Throttle": 50, "Torque": -100}, values)
+	assert.Equal(t, map[string]string{"Gear": "Drive"}, labels)
+	encoded, err := engine.Encode(map[string]interface{}{"EngineSpeed": 1500, "CoolantTemp": 90, "Gear": "Drive", "Throttle": 50.0, "Torque": -100})
+	assert.Nil(t, err)
+	assert.Equal(t, data, encoded)
+
+	// 数据不完整时只解码完整的信号
+	values, _ = engine.Decode(data[:3])
+	assert.Equal(t, map[string]float64{"EngineSpeed": 1500, "CoolantTemp": 90}, values)
+
+	// 多路复用
+	encoded, err = mux.Encode(map[string]interface{}{"Mux": 1, "ValueA": 0x1234})
+	assert.Nil(t, err)
+	assert.Equal(t, "0134120000000000", hex.EncodeToString(encoded))
+	values, _ = mux.Decode(encoded)
+	assert.Equal(t, map[string]float64{"Mux": 1, "ValueA": 0x1234}, values)
+	encoded, err = mux.Encode(map[string]interface{}{"Mux": 2, "ValueB": 1.5})
+	assert.Nil(t, err)
+	assert.Equal(t, "020000c03f000000", hex.EncodeToString(encoded))
+	values, _ = mux.Decode(encoded)
+	assert.Equal(t, map[string]float64{"Mux": 2, "ValueB": 1.5}, values)
+
+	for _, values := range []map[string]interface{}{{"Unknown": 1}, {"Gear": 8}, {"Torque": 40000}, {"Gear": "Sport"}} {
+		_, err = engine.Encode(values)
+		assert.NotNil(t, err, values)
+	}
+	_, err = mux.Encode(map[string]interface{}{"Mux": 2, "ValueA": 1})
+	assert.NotNil(t, err)
+
+	_, err = ParseDBC(strings.NewReader("BO_ 1 Broken: 8 ECU\n SG_ Value : 0|x@1+ (1,0) [0|0] \"\" X\n"))
+	assert.NotNil(t, err)
+	_, err = ParseDBC(strings.NewReader(" SG_ Value : 0|8@1+ (1,0) [0|0] \"\" X\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParseMatcher(t *testing.T) {
+	frame := Frame{Id: 0x123}
+	for from, want := range map[string]bool{"": true, "*": true, "0x123": true, "291": true, "0x124": false, "0x100-0x1FF": true, "0x200-0x2FF": false, "EngineData": false} {
+		m, err := parseMatcher(from)
+		assert.Nil(t, err, from)
+		assert.Equal(t, want, m.match(frame, nil), from)
+	}
+	m, _ := parseMatcher("EngineData")
+	assert.True(t, m.match(frame, &Message{Name: "EngineData"}))
+	for _, from := range []string{"0x1FF-0x100", "0xZZ", "0x100-", "0x20000000"} {
+		_, err := parseMatcher(from)
+		assert.NotNil(t, err, from)
+	}
+	assert.Equal(t, "123#0102", Frame{Id: 0x123, Data: []byte{1, 2}}.String())
+	assert.Equal(t, "18FEF1FE#R", Frame{Id: 0x18FEF1FE, Extended: true, Rtr: true}.String())
+	assert.NotNil(t, Frame{Id: 0x800}.check())
+	assert.NotNil(t, Frame{Id: 1, Data: make([]byte, 9)}.check())
+}
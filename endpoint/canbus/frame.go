@@ -0,0 +1,126 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canbus
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CAN 标识符的范围
+const (
+	maxStandardId = 0x7ff
+	maxExtendedId = 0x1fffffff
+)
+
+// Frame CAN 帧，只支持经典 CAN，数据不超过 8 字节
+type Frame struct {
+	Id       uint32
+	Extended bool
+	// Rtr 远程帧
+	Rtr  bool
+	Data []byte
+}
+
+// String 格式：123#0102 或者 扩展帧 18FEF100#01，远程帧 123#R
+func (f Frame) String() string {
+	id := fmt.Sprintf("%03X", f.Id)
+	if f.Extended {
+		id = fmt.Sprintf("%08X", f.Id)
+	}
+	if f.Rtr {
+		return id + "#R"
+	}
+	return id + "#" + strings.ToUpper(hex.EncodeToString(f.Data))
+}
+
+// check 校验标识符和数据长度
+func (f Frame) check() error {
+	if len(f.Data) > 8 {
+		return fmt.Errorf("can frame data too long: %d", len(f.Data))
+	}
+	if f.Extended && f.Id > maxExtendedId || !f.Extended && f.Id > maxStandardId {
+		return fmt.Errorf("invalid can id: 0x%X", f.Id)
+	}
+	return nil
+}
+
+// ParseId 解析 CAN 标识符，支持十进制和 0x 开头的十六进制
+func ParseId(s string) (uint32, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 0, 32)
+	if err != nil || v > maxExtendedId {
+		return 0, fmt.Errorf("invalid can id: %s", s)
+	}
+	return uint32(v), nil
+}
+
+// frameMatcher 路由匹配的帧：所有、标识符范围或者 DBC 报文名称
+type frameMatcher struct {
+	all      bool
+	name     string
+	min, max uint32
+}
+
+func (m frameMatcher) match(frame Frame, message *Message) bool {
+	switch {
+	case m.all:
+		return true
+	case m.name != "":
+		return message != nil && message.Name == m.name
+	}
+	return frame.Id >= m.min && frame.Id <= m.max
+}
+
+// parseMatcher 解析路由的 from：为空或者 * 匹配所有帧，0x123、291 匹配标识符，0x100-0x1FF 匹配范围，其他为 DBC 报文名称
+func parseMatcher(from string) (frameMatcher, error) {
+	from = strings.TrimSpace(from)
+	if from == "" || from == "*" {
+		return frameMatcher{all: true}, nil
+	}
+	if lo, hi, ok := strings.Cut(from, "-"); ok {
+		min, err := ParseId(lo)
+		if err != nil {
+			return frameMatcher{}, err
+		}
+		max, err := ParseId(hi)
+		if err != nil || max < min {
+			return frameMatcher{}, fmt.Errorf("invalid can id range: %s", from)
+		}
+		return frameMatcher{min: min, max: max}, nil
+	}
+	if from[0] >= '0' && from[0] <= '9' {
+		id, err := ParseId(from)
+		if err != nil {
+			return frameMatcher{}, err
+		}
+		return frameMatcher{min: id, max: id}, nil
+	}
+	return frameMatcher{name: from}, nil
+}
+
+// Bus CAN 总线
+type Bus interface {
+	// ReadFrame 读取一帧，总线关闭后返回错误
+	ReadFrame() (Frame, error)
+	WriteFrame(frame Frame) error
+	Close() error
+}
+
+// openBus 打开 CAN 接口，测试时可以替换
+var openBus = openSocketCAN
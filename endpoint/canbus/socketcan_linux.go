@@ -0,0 +1,116 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// SocketCAN can_frame 的标志位
+const (
+	canEffFlag = 0x80000000
+	canRtrFlag = 0x40000000
+	canErrFlag = 0x20000000
+	frameSize  = 16
+)
+
+// socketCAN Linux SocketCAN 原始套接字
+type socketCAN struct {
+	file *os.File
+}
+
+// openSocketCAN 打开 SocketCAN 接口，eg. can0、vcan0
+func openSocketCAN(name string) (Bus, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
+	if err != nil {
+		return nil, fmt.Errorf("open socketcan %s: %w", name, err)
+	}
+	if err = unix.Bind(fd, &unix.SockaddrCAN{Ifindex: iface.Index}); err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("bind socketcan %s: %w", name, err)
+	}
+	// 非阻塞模式交给运行时的网络轮询器，关闭时可以中断读取
+	if err = unix.SetNonblock(fd, true); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	return &socketCAN{file: os.NewFile(uintptr(fd), name)}, nil
+}
+
+func (s *socketCAN) ReadFrame() (Frame, error) {
+	buf := make([]byte, frameSize)
+	for {
+		n, err := s.file.Read(buf)
+		if err != nil {
+			return Frame{}, err
+		}
+		if n != frameSize {
+			continue
+		}
+		id := binary.LittleEndian.Uint32(buf[0:4])
+		// 忽略错误帧
+		if id&canErrFlag != 0 {
+			continue
+		}
+		frame := Frame{Extended: id&canEffFlag != 0, Rtr: id&canRtrFlag != 0}
+		if frame.Extended {
+			frame.Id = id & maxExtendedId
+		} else {
+			frame.Id = id & maxStandardId
+		}
+		length := int(buf[4])
+		if length > 8 {
+			length = 8
+		}
+		if !frame.Rtr {
+			frame.Data = append([]byte(nil), buf[8:8+length]...)
+		}
+		return frame, nil
+	}
+}
+
+func (s *socketCAN) WriteFrame(frame Frame) error {
+	if err := frame.check(); err != nil {
+		return err
+	}
+	buf := make([]byte, frameSize)
+	id := frame.Id
+	if frame.Extended {
+		id |= canEffFlag
+	}
+	if frame.Rtr {
+		id |= canRtrFlag
+	}
+	binary.LittleEndian.PutUint32(buf[0:4], id)
+	buf[4] = byte(len(frame.Data))
+	copy(buf[8:], frame.Data)
+	_, err := s.file.Write(buf)
+	return err
+}
+
+func (s *socketCAN) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,416 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package certmanager 提供证书生命周期管理端点
+// 端点把配置的证书注册到全局证书管理器，定期检查证书文件和有效期，证书文件修改后重新加载，
+// 组件通过 tls.certName 引用证书，不需要重启端点即可使用新证书。过期告警、重新加载和自动续期（ACME、EST）事件转换成消息交给指定的规则链处理
+package certmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/certmgr"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "certManager"
+
+// 元数据key
+const (
+	KeyName     = "name"
+	KeyDaysLeft = "daysLeft"
+	KeyNotAfter = "notAfter"
+	KeyError    = "error"
+)
+
+// DefaultQueueSize 默认事件队列长度
+const DefaultQueueSize = 256
+
+// Endpoint 别名
+type Endpoint = CertManager
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	event   certmgr.Event
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body, r.err = json.Marshal(r.event.Status)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.event.Status.Name
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为事件类型，例如：CERT_EXPIRING、CERT_RENEWED，消息体为证书状态，证书名称、剩余天数、过期时间和错误放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyName, r.event.Status.Name)
+		metadata.PutValue(KeyDaysLeft, strconv.Itoa(r.event.Status.DaysLeft))
+		metadata.PutValue(KeyNotAfter, r.event.Status.NotAfter.Format(time.RFC3339))
+		if r.event.Error != "" {
+			metadata.PutValue(KeyError, r.event.Error)
+		}
+		ruleMsg := types.NewMsg(0, r.event.Type, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 不支持响应
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// CertManagerConfig 配置
+type CertManagerConfig struct {
+	// Certificates 管理的证书，eg.
+	//
+	//	[{"name": "opcua", "certFile": "/etc/iot/opcua.pem", "keyFile": "/etc/iot/opcua.key", "warnDays": 30,
+	//	  "renew": {"type": "est", "days": 20, "options": {"server": "https://est.example.com/.well-known/est", "caFile": "/etc/iot/est-ca.pem"}}}]
+	Certificates []certmgr.Config `json:"certificates" label:"Certificates" desc:"Managed certificates: name, certFile, keyFile, warnDays and optional renew {type: acme|est, days, options}" required:"true"`
+	// CheckInterval 检查证书文件和有效期的间隔，默认 1m
+	CheckInterval string `json:"checkInterval" label:"Check interval" desc:"Interval to check certificate files and expiry, eg. 1m"`
+	// Events 只处理这些事件，为空处理所有事件
+	Events []string `json:"events" label:"Events" desc:"Only route these events: CERT_RELOADED, CERT_RELOAD_FAILED, CERT_EXPIRING, CERT_EXPIRED, CERT_RENEWED, CERT_RENEW_FAILED. Empty routes all events"`
+	// QueueSize 事件队列长度，规则链处理不及时队列满后丢弃新事件
+	QueueSize int `json:"queueSize" label:"Queue size" desc:"Pending event queue size, new events are dropped when the queue is full"`
+}
+
+// CertManager 证书生命周期管理端点
+type CertManager struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     CertManagerConfig
+	// 路由实例
+	Router   endpointApi.Router
+	interval time.Duration
+	// mu 保护 cancel 和 done
+	mu     sync.Mutex
+	cancel func()
+	// done 关闭后处理事件的协程退出
+	done chan struct{}
+}
+
+// Type 组件类型
+func (x *CertManager) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *CertManager) New() types.Node {
+	return &CertManager{
+		Config: CertManagerConfig{
+			CheckInterval: "1m",
+			QueueSize:     DefaultQueueSize,
+		},
+	}
+}
+
+// Init 初始化
+func (x *CertManager) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if len(x.Config.Certificates) == 0 {
+		return errors.New("certificates can not be empty")
+	}
+	names := make(map[string]bool)
+	for _, c := range x.Config.Certificates {
+		if c.Name == "" || c.CertFile == "" || c.KeyFile == "" {
+			return errors.New("certificate requires name, certFile and keyFile")
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate certificate: %s", c.Name)
+		}
+		names[c.Name] = true
+	}
+	for _, event := range x.Config.Events {
+		if !certmgr.IsEvent(event) {
+			return errors.New("unsupported certificate event: " + event)
+		}
+	}
+	x.interval = certmgr.DefaultCheckInterval
+	if x.Config.CheckInterval != "" {
+		if x.interval, err = time.ParseDuration(x.Config.CheckInterval); err != nil {
+			return err
+		}
+		if x.interval <= 0 {
+			return errors.New("checkInterval must be positive")
+		}
+	}
+	if x.Config.QueueSize <= 0 {
+		x.Config.QueueSize = DefaultQueueSize
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (x *CertManager) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *CertManager) Desc() string {
+	return "Certificate lifecycle endpoint watching cert/key files, hot reloading them for tls.certName, routing expiry warnings and ACME/EST renewal events"
+}
+
+// Category returns the component category
+func (x *CertManager) Category() string {
+	return "endpoint"
+}
+
+func (x *CertManager) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Certificate lifecycle endpoint watching cert/key files, hot reloading them for tls.certName, routing expiry warnings and ACME/EST renewal events",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+// Close 停止检查并从全局证书管理器移除证书
+func (x *CertManager) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.cancel != nil {
+		x.cancel()
+		x.cancel = nil
+		close(x.done)
+		manager := certmgr.Default()
+		manager.Stop()
+		for _, c := range x.Config.Certificates {
+			manager.Remove(c.Name)
+		}
+	}
+	return nil
+}
+
+func (x *CertManager) Id() string {
+	return Type
+}
+
+func (x *CertManager) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Router = router
+	return router.GetId(), nil
+}
+
+func (x *CertManager) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	x.Router = nil
+	return nil
+}
+
+// Start 把证书注册到全局证书管理器并启动检查，证书加载失败返回错误
+// 事件在检查协程中产生，先放入队列，由单独的协程交给规则链处理
+func (x *CertManager) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.cancel != nil {
+		return nil
+	}
+	manager := certmgr.Default()
+	for i, c := range x.Config.Certificates {
+		if err := manager.Add(c); err != nil {
+			for _, added := range x.Config.Certificates[:i] {
+				manager.Remove(added.Name)
+			}
+			return err
+		}
+	}
+	names := make(map[string]bool, len(x.Config.Certificates))
+	for _, c := range x.Config.Certificates {
+		names[c.Name] = true
+	}
+	events := make(chan certmgr.Event, x.Config.QueueSize)
+	x.done = make(chan struct{})
+	x.cancel = manager.Subscribe(func(event certmgr.Event) {
+		if !names[event.Status.Name] || !match(x.Config.Events, event.Type) {
+			return
+		}
+		select {
+		case events <- event:
+		default:
+			x.Printf("certificate event queue is full, drop %s event of %s", event.Type, event.Status.Name)
+		}
+	})
+	go x.serve(events, x.done)
+	manager.Start(x.interval)
+	return nil
+}
+
+func (x *CertManager) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+func (x *CertManager) serve(events <-chan certmgr.Event, done <-chan struct{}) {
+	for {
+		select {
+		case event := <-events:
+			x.onEvent(event)
+		case <-done:
+			return
+		}
+	}
+}
+
+// onEvent 转换成消息交给路由处理
+func (x *CertManager) onEvent(event certmgr.Event) {
+	x.RLock()
+	router := x.Router
+	x.RUnlock()
+	if router == nil {
+		return
+	}
+	exchange := &endpointApi.Exchange{
+		In:  &RequestMessage{event: event},
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(context.Background(), router, exchange)
+}
+
+// match list 为空或者包含 v
+func match(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
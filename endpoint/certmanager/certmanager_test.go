@@ -0,0 +1,138 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certmanager
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/certmgr"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// writeCert 写入自签名证书和私钥
+func writeCert(t *testing.T, certFile, keyFile string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "charger"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+type received struct {
+	msgType  string
+	metadata map[string]string
+	status   certmgr.Status
+}
+
+func TestCertManagerEndpoint(t *testing.T) {
+	ep := (&CertManager{}).New().(*CertManager)
+	assert.Equal(t, Type, ep.Type())
+
+	config := engine.NewConfig()
+	_, err := engine.New("certmanager-test01", []byte(`{
+		"ruleChain": {"id": "certmanager-test01", "name": "certmanager-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("certmanager-test01")
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ocpp.pem"), filepath.Join(dir, "ocpp.key")
+	certificates := []map[string]interface{}{{"name": "ocpp", "certFile": certFile, "keyFile": keyFile, "warnDays": 10}}
+
+	// 每次初始化使用新的实例，避免上一次失败的初始化留下的状态影响结果
+	for _, c := range []types.Configuration{
+		{},
+		{"certificates": certificates, "events": []string{"CERT_LOST"}},
+		{"certificates": certificates, "checkInterval": "-1s"},
+		{"certificates": append(certificates, certificates[0])},
+	} {
+		assert.NotNil(t, (&CertManager{}).New().Init(config, c))
+	}
+	assert.Nil(t, ep.Init(config, types.Configuration{"certificates": certificates, "checkInterval": "20ms"}))
+
+	events := make(chan received, 10)
+	router := impl.NewRouter().From("").To("chain:certmanager-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		var status certmgr.Status
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &status))
+		events <- received{msgType: msg.Type, metadata: msg.Metadata.Values(), status: status}
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+
+	// 证书文件不存在
+	assert.NotNil(t, ep.Start())
+
+	writeCert(t, certFile, keyFile, time.Now().Add(5*24*time.Hour+time.Hour))
+	assert.Nil(t, ep.Start())
+	next := func() received {
+		select {
+		case r := <-events:
+			return r
+		case <-time.After(2 * time.Second):
+			t.Fatal("no certificate event")
+			return received{}
+		}
+	}
+	r := next()
+	assert.Equal(t, certmgr.EventExpiring, r.msgType)
+	assert.Equal(t, "ocpp", r.metadata[KeyName])
+	assert.Equal(t, "5", r.metadata[KeyDaysLeft])
+	assert.Equal(t, "CN=charger", r.status.Subject)
+	cert, err := certmgr.Certificate("ocpp")
+	assert.Nil(t, err)
+	assert.NotNil(t, cert)
+
+	// 证书文件更新后重新加载
+	writeCert(t, certFile, keyFile, time.Now().Add(365*24*time.Hour))
+	future := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(certFile, future, future))
+	assert.Nil(t, os.Chtimes(keyFile, future, future))
+	r = next()
+	assert.Equal(t, certmgr.EventReloaded, r.msgType)
+	assert.Equal(t, 364, r.status.DaysLeft)
+
+	ep.Destroy()
+	_, err = certmgr.Certificate("ocpp")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,491 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dnp3outstation 提供内嵌 DNP3 TCP 从站端点
+// 端点对上级 SCADA 主站提供二进制输入、计数器、模拟量等点位和变化事件，规则链可以通过 x/dnp3OutstationUpdate 节点更新点位，
+// 主站下发的 CROB 和模拟量输出命令会转换成消息交给规则链处理
+package dnp3outstation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dnp3Node "github.com/rulego/rulego-components-iot/external/dnp3"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "dnp3Outstation"
+
+// DNP3_COMMAND_MSG_TYPE 主站执行控制命令时产生的消息类型
+const DNP3_COMMAND_MSG_TYPE = "DNP3_COMMAND"
+
+// 元数据key
+const (
+	KeyFunction   = "function"
+	KeyMasterAddr = "masterAddr"
+	KeyClientAddr = "clientAddr"
+)
+
+// Endpoint 别名
+type Endpoint = Dnp3Outstation
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// outstations 运行中的从站，key 为端点 Id，供 x/dnp3OutstationUpdate 节点查找
+var outstations sync.Map
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	event   dnp3Node.CommandEvent
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body, r.err = json.Marshal(r.event)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.event.ClientAddr
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 DNP3_COMMAND，功能码、主站链路地址和来源地址放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyFunction, r.event.Function)
+		metadata.PutValue(KeyMasterAddr, strconv.Itoa(int(r.event.MasterAddr)))
+		metadata.PutValue(KeyClientAddr, r.event.ClientAddr)
+		ruleMsg := types.NewMsg(0, DNP3_COMMAND_MSG_TYPE, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 不支持响应，命令在交给规则链之前已经回复主站
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// Tag 点位名称映射，规则链可以按名称更新点位
+type Tag struct {
+	Name string `json:"name"`
+	// Type 点位类型：binaryInput、binaryOutput、counter、analogInput、analogOutput
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+// Dnp3OutstationConfig 内嵌 DNP3 从站配置
+type Dnp3OutstationConfig struct {
+	// Server 监听地址，格式：host:port，端口默认 20000
+	Server string `json:"server" label:"Server" desc:"TCP listen address, format: host:port, port defaults to 20000" required:"true"`
+	// LocalAddr 从站链路地址
+	LocalAddr uint16 `json:"localAddr" label:"Outstation address" desc:"Link address of this outstation"`
+	// RemoteAddr 主站链路地址，0 表示接受任意主站
+	RemoteAddr uint16 `json:"remoteAddr" label:"Master address" desc:"Link address of the master, 0 accepts any master"`
+	// 各类点位的数量，索引从 0 开始
+	BinaryInputs  int `json:"binaryInputs" label:"Binary Inputs" desc:"Number of binary inputs, indexes start at 0"`
+	BinaryOutputs int `json:"binaryOutputs" label:"Binary Outputs" desc:"Number of binary outputs controlled by CROB"`
+	Counters      int `json:"counters" label:"Counters" desc:"Number of counters"`
+	AnalogInputs  int `json:"analogInputs" label:"Analog Inputs" desc:"Number of analog inputs"`
+	AnalogOutputs int `json:"analogOutputs" label:"Analog Outputs" desc:"Number of analog outputs"`
+	// AnalogVariation 模拟量输入的静态变体：1 int32、2 int16、3 int32 无标志、4 int16 无标志、5 float32、6 float64
+	AnalogVariation uint8 `json:"analogVariation" label:"Analog Variation" desc:"Static variation of analog inputs: 1 int32, 2 int16, 3 int32 without flag, 4 int16 without flag, 5 float32, 6 float64"`
+	// 各类点位变化事件的等级，0 表示不产生事件
+	BinaryInputClass uint8 `json:"binaryInputClass" label:"Binary Input Class" desc:"Event class of binary input changes, 0 disables events"`
+	CounterClass     uint8 `json:"counterClass" label:"Counter Class" desc:"Event class of counter changes, 0 disables events"`
+	AnalogInputClass uint8 `json:"analogInputClass" label:"Analog Input Class" desc:"Event class of analog input changes, 0 disables events"`
+	// AnalogDeadband 模拟量输入变化超过死区时产生事件
+	AnalogDeadband float64 `json:"analogDeadband" label:"Analog Deadband" desc:"Analog input events are generated when the change exceeds the deadband"`
+	// MaxEvents 事件缓冲区大小
+	MaxEvents int `json:"maxEvents" label:"Max Events" desc:"Event buffer size, the oldest events are dropped on overflow"`
+	// SelectTimeout 选择后执行的超时，单位秒
+	SelectTimeout int `json:"selectTimeout" label:"Select Timeout" desc:"Select before operate timeout in seconds"`
+	// Timeout 发送和等待确认的超时，单位秒
+	Timeout int `json:"timeout" label:"Timeout" desc:"Send and confirm timeout in seconds"`
+	// Tags 点位名称映射
+	Tags []Tag `json:"tags" label:"Tags" desc:"Point name mappings, allows updating points by name"`
+	// Values 点位初始值，点位名称->值
+	Values map[string]interface{} `json:"values" label:"Values" desc:"Initial point values, tag name to value"`
+}
+
+// Dnp3Outstation 内嵌 DNP3 TCP 从站端点
+type Dnp3Outstation struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	// 从站配置
+	Config Dnp3OutstationConfig
+	// 路由实例
+	Router endpointApi.Router
+	// 点位名称->点位
+	tags map[string]Tag
+	// mu 保护从站实例
+	mu         sync.Mutex
+	outstation *dnp3Node.Outstation
+	listener   net.Listener
+}
+
+// Type 组件类型
+func (x *Dnp3Outstation) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *Dnp3Outstation) New() types.Node {
+	return &Dnp3Outstation{
+		Config: Dnp3OutstationConfig{
+			Server:           ":20000",
+			LocalAddr:        dnp3Node.DefaultOutstationAddr,
+			BinaryInputs:     10,
+			BinaryOutputs:    10,
+			Counters:         10,
+			AnalogInputs:     10,
+			AnalogOutputs:    10,
+			AnalogVariation:  5,
+			BinaryInputClass: 1,
+			CounterClass:     3,
+			AnalogInputClass: 2,
+			MaxEvents:        dnp3Node.DefaultMaxEvents,
+			SelectTimeout:    5,
+			Timeout:          5,
+		},
+	}
+}
+
+// Init 初始化
+func (x *Dnp3Outstation) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	x.tags = make(map[string]Tag, len(x.Config.Tags))
+	for _, t := range x.Config.Tags {
+		if t.Name == "" {
+			return errors.New("dnp3 tag name is empty")
+		}
+		if _, ok := x.tags[t.Name]; ok {
+			return fmt.Errorf("duplicate dnp3 tag: %s", t.Name)
+		}
+		x.tags[t.Name] = t
+	}
+	// 提前检查配置，启动时再创建从站
+	_, err = dnp3Node.NewOutstation(x.outstationConfig())
+	return err
+}
+
+func (x *Dnp3Outstation) outstationConfig() dnp3Node.OutstationConfig {
+	return dnp3Node.OutstationConfig{
+		LocalAddr:        x.Config.LocalAddr,
+		RemoteAddr:       x.Config.RemoteAddr,
+		BinaryInputs:     x.Config.BinaryInputs,
+		BinaryOutputs:    x.Config.BinaryOutputs,
+		Counters:         x.Config.Counters,
+		AnalogInputs:     x.Config.AnalogInputs,
+		AnalogOutputs:    x.Config.AnalogOutputs,
+		AnalogVariation:  x.Config.AnalogVariation,
+		BinaryInputClass: x.Config.BinaryInputClass,
+		CounterClass:     x.Config.CounterClass,
+		AnalogInputClass: x.Config.AnalogInputClass,
+		AnalogDeadband:   x.Config.AnalogDeadband,
+		MaxEvents:        x.Config.MaxEvents,
+		SelectTimeout:    time.Duration(x.Config.SelectTimeout) * time.Second,
+		Timeout:          time.Duration(x.Config.Timeout) * time.Second,
+	}
+}
+
+// Destroy 销毁
+func (x *Dnp3Outstation) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *Dnp3Outstation) Desc() string {
+	return "Embedded DNP3 TCP outstation endpoint serving points and events to SCADA masters and routing their control commands"
+}
+
+// Category returns the component category
+func (x *Dnp3Outstation) Category() string {
+	return "endpoint"
+}
+
+func (x *Dnp3Outstation) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Embedded DNP3 TCP outstation endpoint serving points and events to SCADA masters and routing their control commands",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+func (x *Dnp3Outstation) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	outstations.CompareAndDelete(x.Id(), x)
+	var err error
+	if x.outstation != nil {
+		err = x.outstation.Close()
+		x.outstation = nil
+		x.listener = nil
+	}
+	return err
+}
+
+func (x *Dnp3Outstation) Id() string {
+	return x.Config.Server
+}
+
+func (x *Dnp3Outstation) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Router = router
+	return router.GetId(), nil
+}
+
+func (x *Dnp3Outstation) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	x.Router = nil
+	return nil
+}
+
+func (x *Dnp3Outstation) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.outstation != nil {
+		return nil
+	}
+	// 从站关闭后不能再次使用，每次启动创建新的点位数据库
+	outstation, err := dnp3Node.NewOutstation(x.outstationConfig())
+	if err != nil {
+		return err
+	}
+	outstation.OnCommand = x.onCommand
+	for name, value := range x.Config.Values {
+		t, ok := x.tags[name]
+		if !ok {
+			return fmt.Errorf("dnp3 tag not found: %s", name)
+		}
+		if err = outstation.Update(t.Type, t.Index, value); err != nil {
+			return err
+		}
+	}
+	l, err := net.Listen("tcp", listenAddr(x.Config.Server))
+	if err != nil {
+		return err
+	}
+	x.outstation, x.listener = outstation, l
+	go func() {
+		_ = outstation.Serve(l)
+	}()
+	outstations.Store(x.Id(), x)
+	x.Printf("started DNP3 outstation on %s", l.Addr())
+	return nil
+}
+
+// listenAddr 补全端口的监听地址
+func listenAddr(server string) string {
+	server = strings.TrimPrefix(server, "tcp://")
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, dnp3Node.DefaultPort)
+	}
+	return server
+}
+
+// Addr 实际监听的地址，未启动返回 nil
+func (x *Dnp3Outstation) Addr() net.Addr {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.listener == nil {
+		return nil
+	}
+	return x.listener.Addr()
+}
+
+func (x *Dnp3Outstation) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// current 运行中的从站，未启动返回错误
+func (x *Dnp3Outstation) current() (*dnp3Node.Outstation, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.outstation == nil {
+		return nil, fmt.Errorf("dnp3 outstation not started: %s", x.Id())
+	}
+	return x.outstation, nil
+}
+
+// Update 按点位类型和索引更新点位，变化时按配置的等级产生事件
+func (x *Dnp3Outstation) Update(pointType string, index int, value interface{}) error {
+	outstation, err := x.current()
+	if err != nil {
+		return err
+	}
+	return outstation.Update(pointType, index, value)
+}
+
+// SetTag 按点位名称更新点位
+func (x *Dnp3Outstation) SetTag(name string, value interface{}) error {
+	t, ok := x.tags[name]
+	if !ok {
+		return fmt.Errorf("dnp3 tag not found: %s", name)
+	}
+	return x.Update(t.Type, t.Index, value)
+}
+
+// Get 获取点位当前值
+func (x *Dnp3Outstation) Get(pointType string, index int) (dnp3Node.Point, error) {
+	outstation, err := x.current()
+	if err != nil {
+		return dnp3Node.Point{}, err
+	}
+	return outstation.Get(pointType, index)
+}
+
+// onCommand 主站执行控制命令后转换成消息交给路由处理
+func (x *Dnp3Outstation) onCommand(event dnp3Node.CommandEvent) {
+	x.RLock()
+	router := x.Router
+	x.RUnlock()
+	if router == nil {
+		return
+	}
+	exchange := &endpointApi.Exchange{
+		In:  &RequestMessage{event: event},
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(context.Background(), router, exchange)
+}
+
+// lookupOutstation 查找运行中的从站
+func lookupOutstation(id string) (*Dnp3Outstation, bool) {
+	if v, ok := outstations.Load(id); ok {
+		return v.(*Dnp3Outstation), true
+	}
+	return nil, false
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3outstation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	dnp3Node "github.com/rulego/rulego-components-iot/external/dnp3"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDnp3OutstationEndpoint(t *testing.T) {
+	ep := (&Dnp3Outstation{}).New().(*Dnp3Outstation)
+	assert.Equal(t, Type, ep.Type())
+
+	config := engine.NewConfig()
+	_, err := engine.New("dnp3-outstation-test01", []byte(`{
+		"ruleChain": {"id": "dnp3-outstation-test01", "name": "dnp3-outstation-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("dnp3-outstation-test01")
+
+	err = ep.Init(config, types.Configuration{
+		"server":        "127.0.0.1:0",
+		"binaryInputs":  2,
+		"binaryOutputs": 2,
+		"counters":      1,
+		"analogInputs":  2,
+		"analogOutputs": 1,
+		"tags": []map[string]interface{}{
+			{"name": "breaker", "type": "binaryInput", "index": 0},
+			{"name": "voltage", "type": "analogInput", "index": 1},
+		},
+		"values": map[string]interface{}{"voltage": 230.5},
+	})
+	assert.Nil(t, err)
+
+	events := make(chan dnp3Node.CommandEvent, 1)
+	router := impl.NewRouter().From("").To("chain:dnp3-outstation-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		assert.Equal(t, DNP3_COMMAND_MSG_TYPE, msg.Type)
+		assert.Equal(t, dnp3Node.ModeDirectOperate, msg.Metadata.GetValue(KeyFunction))
+		var event dnp3Node.CommandEvent
+		_ = json.Unmarshal([]byte(msg.GetData()), &event)
+		events <- event
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	p, err := ep.Get(dnp3Node.AnalogInput, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 230.5, p.Value)
+
+	// 规则链更新点位
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&UpdateNode{})
+	node, err := test.CreateAndInitNode("x/dnp3OutstationUpdate", types.Configuration{
+		"server": ep.Id(),
+	}, Registry)
+	assert.Nil(t, err)
+	test.NodeOnMsg(t, node, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `{"breaker": true}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `[{"type": "counter", "index": 0, "value": 42}]`,
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "NOT_FOUND",
+			Data:       `{"notExist": 1}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "NOT_FOUND" {
+			assert.Equal(t, types.Failure, relationType)
+		} else {
+			assert.Equal(t, types.Success, relationType)
+		}
+	})
+
+	master := dnp3Node.NewMaster(dnp3Node.MasterConfig{
+		Server:     ep.Addr().String(),
+		LocalAddr:  dnp3Node.DefaultMasterAddr,
+		RemoteAddr: dnp3Node.DefaultOutstationAddr,
+		Timeout:    time.Second,
+	})
+	defer master.Close()
+
+	resp, err := master.Read(1, 0, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resp.Points))
+	assert.Equal(t, true, resp.Points[0].Value)
+
+	resp, err = master.Read(20, 0, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resp.Points))
+	assert.Equal(t, uint32(42), resp.Points[0].Value)
+
+	// 主站下发 CROB
+	_, err = master.Operate(dnp3Node.ModeDirectOperate, dnp3Node.Command{Type: dnp3Node.BinaryOutput, Index: 1, Code: "latchOn"})
+	assert.Nil(t, err)
+	select {
+	case event := <-events:
+		assert.Equal(t, 1, len(event.Commands))
+		assert.Equal(t, "latchOn", event.Commands[0].Code)
+	case <-time.After(time.Second):
+		t.Fatal("command event not received")
+	}
+	p, err = ep.Get(dnp3Node.BinaryOutput, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, true, p.Value)
+
+	// 关闭后不能再更新
+	ep.Destroy()
+	_, ok := lookupOutstation(ep.Id())
+	assert.False(t, ok)
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3outstation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&UpdateNode{})
+}
+
+// UpdateNodeConfiguration 节点配置
+type UpdateNodeConfiguration struct {
+	// Server 内嵌从站端点 Id，与 endpoint/dnp3Outstation 的 server 配置一致，eg. :20000
+	Server string `json:"server" label:"Server" desc:"Embedded DNP3 outstation id, same as the server of endpoint/dnp3Outstation" required:"true"`
+}
+
+// PointUpdate 按点位类型和索引更新的数据
+type PointUpdate struct {
+	// Type 点位类型：binaryInput、binaryOutput、counter、analogInput、analogOutput
+	Type  string      `json:"type"`
+	Index int         `json:"index"`
+	Value interface{} `json:"value"`
+}
+
+// UpdateNode 更新内嵌 DNP3 从站的点位，变化的点位按从站配置的等级产生事件
+// 消息负荷 msg.Data 为点位名称->值的对象：{"breaker": true, "voltage": 230.5}
+// 或者按类型和索引更新的数组：
+//
+//	[
+//	  {"type": "analogInput", "index": 0, "value": 12.5},
+//	  {"type": "binaryInput", "index": 1, "value": true}
+//	]
+//
+// 更新成功，流转到`Success`链，否则流程转到`Failure`链
+type UpdateNode struct {
+	//节点配置
+	Config UpdateNodeConfiguration
+}
+
+func (x *UpdateNode) New() types.Node {
+	return &UpdateNode{
+		Config: UpdateNodeConfiguration{
+			Server: ":20000",
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *UpdateNode) Type() string {
+	return "x/dnp3OutstationUpdate"
+}
+
+func (x *UpdateNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	return maps.Map2Struct(configuration, &x.Config)
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *UpdateNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	outstation, ok := lookupOutstation(x.Config.Server)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("dnp3 outstation not found: %s", x.Config.Server))
+		return
+	}
+	data := strings.TrimSpace(msg.GetData())
+	if strings.HasPrefix(data, "[") {
+		var updates []PointUpdate
+		if err := json.Unmarshal([]byte(data), &updates); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		for _, u := range updates {
+			if err := outstation.Update(u.Type, u.Index, u.Value); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+		}
+	} else {
+		values := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(data), &values); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		for name, v := range values {
+			if err := outstation.SetTag(name, v); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+		}
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+func (x *UpdateNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *UpdateNode) Desc() string {
+	return "Update points of the embedded DNP3 outstation endpoint. Routes to Success/Failure"
+}
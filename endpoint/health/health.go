@@ -0,0 +1,366 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package health 提供连接健康事件端点
+// 端点订阅所有协议客户端上报的连接状态变化，把 CONNECT、DISCONNECT、RECONNECT 生命周期事件转换成消息交给指定的规则链处理
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/textproto"
+	"sync"
+
+	healthMonitor "github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "health"
+
+// 元数据key
+const (
+	KeyComponent = "component"
+	KeyTarget    = "target"
+	KeyState     = "state"
+)
+
+// DefaultQueueSize 默认事件队列长度
+const DefaultQueueSize = 256
+
+// Endpoint 别名
+type Endpoint = Health
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	event   healthMonitor.Event
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body, r.err = json.Marshal(r.event.Status)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.event.Status.Target
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为事件类型：CONNECT、DISCONNECT 或者 RECONNECT，消息体为连接状态，组件、目标和状态放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyComponent, r.event.Status.Component)
+		metadata.PutValue(KeyTarget, r.event.Status.Target)
+		metadata.PutValue(KeyState, r.event.Status.State)
+		ruleMsg := types.NewMsg(0, r.event.Type, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 不支持响应
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// HealthConfig 配置
+type HealthConfig struct {
+	// Components 只处理这些组件的事件，eg. s7、modbus、opcua，为空处理所有组件
+	Components []string `json:"components" label:"Components" desc:"Only route events of these components, eg. s7, modbus, opcua. Empty routes all components"`
+	// Events 只处理这些事件，可选：CONNECT、DISCONNECT、RECONNECT，为空处理所有事件
+	Events []string `json:"events" label:"Events" desc:"Only route these events: CONNECT, DISCONNECT, RECONNECT. Empty routes all events"`
+	// QueueSize 事件队列长度，规则链处理不及时队列满后丢弃新事件
+	QueueSize int `json:"queueSize" label:"Queue size" desc:"Pending event queue size, new events are dropped when the queue is full"`
+}
+
+// Health 连接健康事件端点
+type Health struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     HealthConfig
+	// 路由实例
+	Router endpointApi.Router
+	// mu 保护 cancel 和 done
+	mu     sync.Mutex
+	cancel func()
+	// done 关闭后处理事件的协程退出
+	done chan struct{}
+}
+
+// Type 组件类型
+func (x *Health) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *Health) New() types.Node {
+	return &Health{
+		Config: HealthConfig{
+			QueueSize: DefaultQueueSize,
+		},
+	}
+}
+
+// Init 初始化
+func (x *Health) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	for _, event := range x.Config.Events {
+		switch event {
+		case healthMonitor.EventConnect, healthMonitor.EventDisconnect, healthMonitor.EventReconnect:
+		default:
+			return errors.New("unsupported health event: " + event)
+		}
+	}
+	if x.Config.QueueSize <= 0 {
+		x.Config.QueueSize = DefaultQueueSize
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (x *Health) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *Health) Desc() string {
+	return "Connection health endpoint routing CONNECT, DISCONNECT and RECONNECT events of protocol clients"
+}
+
+// Category returns the component category
+func (x *Health) Category() string {
+	return "endpoint"
+}
+
+func (x *Health) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Connection health endpoint routing CONNECT, DISCONNECT and RECONNECT events of protocol clients",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+func (x *Health) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.cancel != nil {
+		x.cancel()
+		x.cancel = nil
+		close(x.done)
+	}
+	return nil
+}
+
+func (x *Health) Id() string {
+	return Type
+}
+
+func (x *Health) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Router = router
+	return router.GetId(), nil
+}
+
+func (x *Health) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	x.Router = nil
+	return nil
+}
+
+// Start 订阅全局健康状态监控
+// 事件在上报的协程中产生，先放入队列，由单独的协程交给规则链处理，避免阻塞协议客户端
+func (x *Health) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.cancel != nil {
+		return nil
+	}
+	events := make(chan healthMonitor.Event, x.Config.QueueSize)
+	x.done = make(chan struct{})
+	x.cancel = healthMonitor.Subscribe(func(event healthMonitor.Event) {
+		if !x.accept(event) {
+			return
+		}
+		select {
+		case events <- event:
+		default:
+			x.Printf("health event queue is full, drop %s event of %s %s", event.Type, event.Status.Component, event.Status.Target)
+		}
+	})
+	go x.serve(events, x.done)
+	return nil
+}
+
+func (x *Health) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// accept 按组件和事件类型过滤
+func (x *Health) accept(event healthMonitor.Event) bool {
+	return match(x.Config.Components, event.Status.Component) && match(x.Config.Events, event.Type)
+}
+
+func (x *Health) serve(events <-chan healthMonitor.Event, done <-chan struct{}) {
+	for {
+		select {
+		case event := <-events:
+			x.onEvent(event)
+		case <-done:
+			return
+		}
+	}
+}
+
+// onEvent 转换成消息交给路由处理
+func (x *Health) onEvent(event healthMonitor.Event) {
+	x.RLock()
+	router := x.Router
+	x.RUnlock()
+	if router == nil {
+		return
+	}
+	exchange := &endpointApi.Exchange{
+		In:  &RequestMessage{event: event},
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(context.Background(), router, exchange)
+}
+
+// match list 为空或者包含 v
+func match(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
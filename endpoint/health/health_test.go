@@ -0,0 +1,111 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	healthMonitor "github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+)
+
+type received struct {
+	msgType  string
+	metadata map[string]string
+	status   healthMonitor.Status
+}
+
+func TestHealthEndpoint(t *testing.T) {
+	ep := (&Health{}).New().(*Health)
+	assert.Equal(t, Type, ep.Type())
+
+	config := engine.NewConfig()
+	_, err := engine.New("health-test01", []byte(`{
+		"ruleChain": {"id": "health-test01", "name": "health-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("health-test01")
+
+	assert.NotNil(t, ep.Init(config, types.Configuration{"events": []string{"CLOSED"}}))
+	err = ep.Init(config, types.Configuration{
+		"components": []string{"health-test"},
+		"events":     []string{healthMonitor.EventConnect, healthMonitor.EventDisconnect, healthMonitor.EventReconnect},
+	})
+	assert.Nil(t, err)
+
+	events := make(chan received, 10)
+	router := impl.NewRouter().From("").To("chain:health-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		var status healthMonitor.Status
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &status))
+		events <- received{msgType: msg.Type, metadata: msg.Metadata.Values(), status: status}
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	next := func() received {
+		select {
+		case r := <-events:
+			return r
+		case <-time.After(2 * time.Second):
+			t.Fatal("no health event")
+			return received{}
+		}
+	}
+
+	target := "127.0.0.1:102"
+	defer healthMonitor.Remove("health-test", target)
+	// 其他组件的事件被过滤
+	healthMonitor.Connected("health-other", target)
+	defer healthMonitor.Remove("health-other", target)
+
+	healthMonitor.Connected("health-test", target)
+	r := next()
+	assert.Equal(t, healthMonitor.EventConnect, r.msgType)
+	assert.Equal(t, "health-test", r.metadata[KeyComponent])
+	assert.Equal(t, target, r.metadata[KeyTarget])
+	assert.Equal(t, healthMonitor.StateConnected, r.metadata[KeyState])
+
+	healthMonitor.Disconnected("health-test", target, errors.New("connection reset"))
+	r = next()
+	assert.Equal(t, healthMonitor.EventDisconnect, r.msgType)
+	assert.Equal(t, healthMonitor.StateError, r.status.State)
+	assert.Equal(t, "connection reset", r.status.LastError)
+
+	healthMonitor.Report("health-test", target, 3*time.Millisecond, nil)
+	r = next()
+	assert.Equal(t, healthMonitor.EventReconnect, r.msgType)
+	assert.Equal(t, uint64(1), r.status.Reconnects)
+	assert.Equal(t, 3.0, r.status.RTT)
+
+	select {
+	case r = <-events:
+		t.Fatalf("unexpected event %s", r.msgType)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
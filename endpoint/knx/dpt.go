@@ -0,0 +1,327 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// GroupAddress 组地址
+type GroupAddress uint16
+
+// ParseGroupAddress 解析组地址，支持 3 级 main/middle/sub、2 级 main/sub 和数字
+func ParseGroupAddress(s string) (GroupAddress, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	values := make([]int, len(parts))
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid knx group address: %s", s)
+		}
+		values[i] = v
+	}
+	switch {
+	case len(values) == 3 && values[0] <= 31 && values[1] <= 7 && values[2] <= 255:
+		return GroupAddress(values[0]<<11 | values[1]<<8 | values[2]), nil
+	case len(values) == 2 && values[0] <= 31 && values[1] <= 2047:
+		return GroupAddress(values[0]<<11 | values[1]), nil
+	case len(values) == 1 && values[0] <= 0xffff:
+		return GroupAddress(values[0]), nil
+	}
+	return 0, fmt.Errorf("invalid knx group address: %s", s)
+}
+
+// String 3 级格式：main/middle/sub
+func (g GroupAddress) String() string {
+	return fmt.Sprintf("%d/%d/%d", g>>11, g>>8&0x07, g&0xff)
+}
+
+// IndividualAddress 个体地址
+type IndividualAddress uint16
+
+// String 格式：area.line.device
+func (a IndividualAddress) String() string {
+	return fmt.Sprintf("%d.%d.%d", a>>12, a>>8&0x0f, a&0xff)
+}
+
+// dptUnits 数据点类型的单位
+var dptUnits = map[string]string{
+	"5.001": "%", "5.003": "°",
+	"7.001": "pulses", "7.002": "ms", "7.005": "s", "7.006": "min", "7.007": "h", "7.012": "mA", "7.013": "lux",
+	"8.002": "ms", "8.005": "s", "8.010": "%",
+	"9.001": "°C", "9.002": "K", "9.003": "K/h", "9.004": "lux", "9.005": "m/s", "9.006": "Pa", "9.007": "%",
+	"9.008": "ppm", "9.020": "mV", "9.021": "mA", "9.024": "kW", "9.025": "l/h", "9.027": "°F", "9.028": "km/h",
+	"12.001": "pulses", "13.001": "pulses", "13.010": "Wh", "13.013": "kWh",
+	"14.019": "A", "14.027": "V", "14.033": "Hz", "14.056": "W", "14.068": "°C",
+}
+
+// DPTUnit 数据点类型的单位，没有返回空
+func DPTUnit(dpt string) string {
+	return dptUnits[normalizeDPT(dpt)]
+}
+
+// normalizeDPT 去掉 DPT 前缀，eg. DPT9.001->9.001
+func normalizeDPT(dpt string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(dpt)), "dpt")
+}
+
+// mainType 数据点类型的主类型，eg. 9.001->9
+func mainType(dpt string) (int, error) {
+	main, _, _ := strings.Cut(dpt, ".")
+	v, err := strconv.Atoi(main)
+	if err != nil {
+		return 0, fmt.Errorf("invalid knx datapoint type: %s", dpt)
+	}
+	return v, nil
+}
+
+// DecodeDPT 按数据点类型解码数据，不超过 6 位的类型（1、2、3）数据为 1 字节
+func DecodeDPT(dpt string, data []byte) (interface{}, error) {
+	dpt = normalizeDPT(dpt)
+	main, err := mainType(dpt)
+	if err != nil {
+		return nil, err
+	}
+	size, ok := dptSizes[main]
+	if !ok {
+		return nil, fmt.Errorf("unsupported knx datapoint type: %s", dpt)
+	}
+	if size == 0 {
+		size = 1
+	}
+	if main == 16 {
+		if len(data) < 1 {
+			return nil, errShortData
+		}
+	} else if len(data) < size {
+		return nil, errShortData
+	}
+	switch main {
+	case 1:
+		return data[0]&0x01 != 0, nil
+	case 2:
+		return map[string]interface{}{"control": data[0]&0x02 != 0, "value": data[0]&0x01 != 0}, nil
+	case 3:
+		return map[string]interface{}{"increase": data[0]&0x08 != 0, "step": int(data[0] & 0x07)}, nil
+	case 5:
+		switch dpt {
+		case "5.001":
+			return math.Round(float64(data[0])*100/255*10) / 10, nil
+		case "5.003":
+			return math.Round(float64(data[0]) * 360 / 255), nil
+		}
+		return int(data[0]), nil
+	case 6:
+		return int(int8(data[0])), nil
+	case 7:
+		return int(binary.BigEndian.Uint16(data)), nil
+	case 8:
+		return int(int16(binary.BigEndian.Uint16(data))), nil
+	case 9:
+		return decodeFloat16(binary.BigEndian.Uint16(data)), nil
+	case 10:
+		return fmt.Sprintf("%02d:%02d:%02d", data[0]&0x1f, data[1]&0x3f, data[2]&0x3f), nil
+	case 11:
+		year := 2000 + int(data[2]&0x7f)
+		if year >= 2090 {
+			year -= 100
+		}
+		return fmt.Sprintf("%04d-%02d-%02d", year, data[1]&0x0f, data[0]&0x1f), nil
+	case 12:
+		return int64(binary.BigEndian.Uint32(data)), nil
+	case 13:
+		return int64(int32(binary.BigEndian.Uint32(data))), nil
+	case 14:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	case 16:
+		return strings.TrimRight(string(data), "\x00"), nil
+	case 17:
+		return int(data[0] & 0x3f), nil
+	case 18:
+		return map[string]interface{}{"learn": data[0]&0x80 != 0, "scene": int(data[0] & 0x3f)}, nil
+	case 20:
+		return int(data[0]), nil
+	case 232:
+		return fmt.Sprintf("#%02x%02x%02x", data[0], data[1], data[2]), nil
+	}
+	return nil, fmt.Errorf("unsupported knx datapoint type: %s", dpt)
+}
+
+// dptSizes 主类型的数据长度，0 表示不超过 6 位，放在 APCI 中
+var dptSizes = map[int]int{
+	1: 0, 2: 0, 3: 0, 5: 1, 6: 1, 7: 2, 8: 2, 9: 2, 10: 3, 11: 3, 12: 4, 13: 4, 14: 4, 16: 14, 17: 1, 18: 1, 20: 1, 232: 3,
+}
+
+var errShortData = errors.New("knx: short data")
+
+// EncodeDPT 按数据点类型编码值，small 表示数据不超过 6 位
+func EncodeDPT(dpt string, value interface{}) (data []byte, small bool, err error) {
+	dpt = normalizeDPT(dpt)
+	main, err := mainType(dpt)
+	if err != nil {
+		return nil, false, err
+	}
+	switch main {
+	case 1:
+		b, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, false, err
+		}
+		if b {
+			return []byte{1}, true, nil
+		}
+		return []byte{0}, true, nil
+	case 2:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("knx dpt 2 value must be {\"control\": true, \"value\": true}")
+		}
+		control, _ := cast.ToBoolE(m["control"])
+		on, _ := cast.ToBoolE(m["value"])
+		var b byte
+		if control {
+			b |= 0x02
+		}
+		if on {
+			b |= 0x01
+		}
+		return []byte{b}, true, nil
+	case 3:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("knx dpt 3 value must be {\"increase\": true, \"step\": 1}")
+		}
+		increase, _ := cast.ToBoolE(m["increase"])
+		step, err := cast.ToInt64E(m["step"])
+		if err != nil || step < 0 || step > 7 {
+			return nil, false, fmt.Errorf("invalid knx dpt 3 step: %v", m["step"])
+		}
+		b := byte(step)
+		if increase {
+			b |= 0x08
+		}
+		return []byte{b}, true, nil
+	case 10, 11:
+		s := cast.ToString(value)
+		if main == 10 {
+			t, err := time.Parse("15:04:05", s)
+			if err != nil {
+				return nil, false, err
+			}
+			return []byte{byte(t.Hour()), byte(t.Minute()), byte(t.Second())}, false, nil
+		}
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, false, err
+		}
+		return []byte{byte(t.Day()), byte(t.Month()), byte(t.Year() % 100)}, false, nil
+	case 16:
+		s := cast.ToString(value)
+		if len(s) > 14 || !utf8.ValidString(s) {
+			return nil, false, fmt.Errorf("knx dpt 16 string too long: %s", s)
+		}
+		b := make([]byte, 14)
+		copy(b, s)
+		return b, false, nil
+	case 232:
+		s := strings.TrimPrefix(cast.ToString(value), "#")
+		v, err := strconv.ParseUint(s, 16, 32)
+		if err != nil || len(s) != 6 {
+			return nil, false, fmt.Errorf("invalid knx rgb value: %v", value)
+		}
+		return []byte{byte(v >> 16), byte(v >> 8), byte(v)}, false, nil
+	}
+	f, err := cast.ToFloat64E(value)
+	if err != nil {
+		return nil, false, err
+	}
+	switch main {
+	case 5:
+		switch dpt {
+		case "5.001":
+			f = math.Round(f * 255 / 100)
+		case "5.003":
+			f = math.Round(f * 255 / 360)
+		}
+		return checkRange(dpt, f, 0, 255, func(v int64) []byte { return []byte{byte(v)} })
+	case 6:
+		return checkRange(dpt, f, -128, 127, func(v int64) []byte { return []byte{byte(v)} })
+	case 7:
+		return checkRange(dpt, f, 0, 0xffff, func(v int64) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) })
+	case 8:
+		return checkRange(dpt, f, math.MinInt16, math.MaxInt16, func(v int64) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) })
+	case 9:
+		raw, err := encodeFloat16(f)
+		if err != nil {
+			return nil, false, err
+		}
+		return binary.BigEndian.AppendUint16(nil, raw), false, nil
+	case 12:
+		return checkRange(dpt, f, 0, math.MaxUint32, func(v int64) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) })
+	case 13:
+		return checkRange(dpt, f, math.MinInt32, math.MaxInt32, func(v int64) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) })
+	case 14:
+		return binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(f))), false, nil
+	case 17, 18, 20:
+		return checkRange(dpt, f, 0, 255, func(v int64) []byte { return []byte{byte(v)} })
+	}
+	return nil, false, fmt.Errorf("unsupported knx datapoint type: %s", dpt)
+}
+
+// checkRange 校验整数值的范围
+func checkRange(dpt string, f, min, max float64, encode func(v int64) []byte) ([]byte, bool, error) {
+	if f != math.Trunc(f) || f < min || f > max {
+		return nil, false, fmt.Errorf("value %v out of range for knx dpt %s", f, dpt)
+	}
+	return encode(int64(f)), false, nil
+}
+
+// decodeFloat16 2 字节浮点数：0.01 * M * 2^E，M 为 12 位补码
+func decodeFloat16(raw uint16) float64 {
+	m := int(raw & 0x07ff)
+	if raw&0x8000 != 0 {
+		m -= 2048
+	}
+	e := int(raw >> 11 & 0x0f)
+	return math.Round(float64(m)*math.Pow(2, float64(e))) / 100
+}
+
+func encodeFloat16(f float64) (uint16, error) {
+	m := math.Round(f * 100)
+	e := 0
+	for m < -2048 || m > 2047 {
+		m = math.Round(m / 2)
+		e++
+	}
+	if e > 15 {
+		return 0, fmt.Errorf("value %v out of range for knx dpt 9", f)
+	}
+	raw := uint16(e) << 11
+	if m < 0 {
+		raw |= 0x8000
+	}
+	return raw | uint16(int(m)&0x07ff), nil
+}
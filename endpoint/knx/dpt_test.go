@@ -0,0 +1,113 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knx
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestGroupAddress(t *testing.T) {
+	ga, err := ParseGroupAddress("1/2/3")
+	assert.Nil(t, err)
+	assert.Equal(t, GroupAddress(0x0a03), ga)
+	assert.Equal(t, "1/2/3", ga.String())
+	ga, err = ParseGroupAddress("1/515")
+	assert.Nil(t, err)
+	assert.Equal(t, "1/2/3", ga.String())
+	ga, err = ParseGroupAddress("2563")
+	assert.Nil(t, err)
+	assert.Equal(t, "1/2/3", ga.String())
+	assert.Equal(t, "31/7/255", GroupAddress(0xffff).String())
+	for _, s := range []string{"", "1/2/", "32/0/0", "1/8/0", "1/2/256", "1/2048", "a/b/c", "-1/0/0"} {
+		_, err = ParseGroupAddress(s)
+		assert.NotNil(t, err, s)
+	}
+	assert.Equal(t, "1.1.250", IndividualAddress(0x11fa).String())
+}
+
+func TestDPT(t *testing.T) {
+	tests := []struct {
+		dpt   string
+		value interface{}
+		data  []byte
+		small bool
+		out   interface{}
+	}{
+		{dpt: "1.001", value: true, data: []byte{1}, small: true, out: true},
+		{dpt: "DPT1.001", value: "false", data: []byte{0}, small: true, out: false},
+		{dpt: "2.001", value: map[string]interface{}{"control": true, "value": false}, data: []byte{2}, small: true},
+		{dpt: "3.007", value: map[string]interface{}{"increase": true, "step": 3}, data: []byte{0x0b}, small: true},
+		{dpt: "5.001", value: 100, data: []byte{0xff}, out: 100.0},
+		{dpt: "5.001", value: 50, data: []byte{0x80}, out: 50.2},
+		{dpt: "5.003", value: 180, data: []byte{0x80}, out: 181.0},
+		{dpt: "5.010", value: 42, data: []byte{42}, out: 42},
+		{dpt: "6.010", value: -5, data: []byte{0xfb}, out: -5},
+		{dpt: "7.001", value: 1000, data: []byte{0x03, 0xe8}, out: 1000},
+		{dpt: "8.001", value: -1000, data: []byte{0xfc, 0x18}, out: -1000},
+		{dpt: "9.001", value: 21, data: []byte{0x0c, 0x1a}, out: 21.0},
+		{dpt: "9.001", value: "-5.5", data: []byte{0x85, 0xda}, out: -5.5},
+		{dpt: "9.004", value: 0, data: []byte{0x00, 0x00}, out: 0.0},
+		{dpt: "10.001", value: "13:45:10", data: []byte{13, 45, 10}, out: "13:45:10"},
+		{dpt: "11.001", value: "2024-03-15", data: []byte{15, 3, 24}, out: "2024-03-15"},
+		{dpt: "12.001", value: 4000000000, data: []byte{0xee, 0x6b, 0x28, 0x00}, out: int64(4000000000)},
+		{dpt: "13.010", value: -2, data: []byte{0xff, 0xff, 0xff, 0xfe}, out: int64(-2)},
+		{dpt: "14.056", value: 1.5, data: []byte{0x3f, 0xc0, 0x00, 0x00}, out: 1.5},
+		{dpt: "16.000", value: "KNX is OK", data: []byte("KNX is OK\x00\x00\x00\x00\x00"), out: "KNX is OK"},
+		{dpt: "17.001", value: 12, data: []byte{12}, out: 12},
+		{dpt: "18.001", value: 0x85, data: []byte{0x85}},
+		{dpt: "20.102", value: 1, data: []byte{1}, out: 1},
+		{dpt: "232.600", value: "#ff8000", data: []byte{0xff, 0x80, 0x00}, out: "#ff8000"},
+	}
+	for _, tt := range tests {
+		data, small, err := EncodeDPT(tt.dpt, tt.value)
+		assert.Nil(t, err, tt.dpt)
+		assert.Equal(t, tt.data, data, tt.dpt)
+		assert.Equal(t, tt.small, small, tt.dpt)
+		if tt.out == nil {
+			continue
+		}
+		v, err := DecodeDPT(tt.dpt, data)
+		assert.Nil(t, err, tt.dpt)
+		assert.Equal(t, tt.out, v, tt.dpt)
+	}
+
+	v, err := DecodeDPT("18.001", []byte{0x85})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"learn": true, "scene": 5}, v)
+	v, err = DecodeDPT("3.007", []byte{0x0b})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"increase": true, "step": 3}, v)
+	assert.Equal(t, "°C", DPTUnit("DPT9.001"))
+	assert.Equal(t, "", DPTUnit("1.001"))
+
+	for _, tt := range []struct {
+		dpt   string
+		value interface{}
+	}{
+		{"5.010", 256}, {"6.010", -129}, {"7.001", 1.5}, {"9.001", 700000}, {"16.000", "more than 14 chars"},
+		{"232.600", "red"}, {"1.001", "on?"}, {"99.001", 1}, {"x", 1}, {"2.001", true},
+	} {
+		_, _, err = EncodeDPT(tt.dpt, tt.value)
+		assert.NotNil(t, err, tt.dpt)
+	}
+	_, err = DecodeDPT("9.001", []byte{0x0c})
+	assert.NotNil(t, err)
+	_, err = DecodeDPT("99.001", []byte{0x0c})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,516 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package knx 提供 KNXnet/IP 隧道端点
+// 端点通过隧道连接 KNX IP 接口，总线上的组报文按组地址路由到规则链，数据按配置的数据点类型（DPT）解码，
+// 规则链可以通过 x/knxWrite 节点写组地址，控制照明、遮阳、暖通等楼宇设备
+package knx
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "knx"
+
+// 元数据key
+const (
+	KeyGroupAddress = "groupAddress"
+	KeySource       = "source"
+	KeyCommand      = "command"
+	KeyDpt          = "dpt"
+)
+
+// reconnectInterval 隧道断开后重新连接的间隔
+var reconnectInterval = 5 * time.Second
+
+// Endpoint 别名
+type Endpoint = Knx
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// tunnels 运行中的端点，key 为端点 Id，供 x/knxWrite 节点查找
+var tunnels sync.Map
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers  textproto.MIMEHeader
+	body     []byte
+	telegram Telegram
+	dpt      string
+	value    interface{}
+	msg      *types.RuleMsg
+	err      error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		data := map[string]interface{}{
+			"value": r.value,
+			"raw":   hex.EncodeToString(r.telegram.Data),
+		}
+		if r.dpt != "" {
+			data["dpt"] = r.dpt
+			if unit := DPTUnit(r.dpt); unit != "" {
+				data["unit"] = unit
+			}
+		}
+		r.body, _ = json.Marshal(data)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.telegram.Destination.String()
+}
+
+// GetParam 获取组地址、来源地址、命令和数据点类型
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyGroupAddress:
+		return r.telegram.Destination.String()
+	case KeySource:
+		return r.telegram.Source.String()
+	case KeyCommand:
+		return r.telegram.Command
+	case KeyDpt:
+		return r.dpt
+	default:
+		return ""
+	}
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为命令，eg. GroupValueWrite，组地址、来源地址和数据点类型放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyGroupAddress, r.telegram.Destination.String())
+		metadata.PutValue(KeySource, r.telegram.Source.String())
+		metadata.PutValue(KeyCommand, r.telegram.Command)
+		if r.dpt != "" {
+			metadata.PutValue(KeyDpt, r.dpt)
+		}
+		ruleMsg := types.NewMsg(0, r.telegram.Command, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 组报文不需要响应
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// KnxConfig KNXnet/IP 隧道配置
+type KnxConfig struct {
+	// Server KNX IP 接口地址，格式：host:port，端口默认 3671
+	Server string `json:"server" label:"Server" desc:"KNX IP interface address, format: host:port, port defaults to 3671" required:"true"`
+	// Dpts 组地址->数据点类型，eg. {"1/2/3": "9.001"}，未配置的组地址输出原始数据
+	Dpts map[string]string `json:"dpts" label:"Datapoint Types" desc:"Datapoint type by group address, eg. {\"1/2/3\": \"9.001\"}, unconfigured group addresses output raw data"`
+	// Timeout 连接和写入确认超时，单位秒
+	Timeout int `json:"timeout" label:"Timeout" desc:"Connect and write confirmation timeout in seconds"`
+}
+
+// Knx KNXnet/IP 隧道端点
+// 路由的 from 为组地址，支持 1/2/3、通配 1/2/* 和 1/*，为空或者 * 匹配所有组地址，报文发送到所有匹配的路由
+type Knx struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     KnxConfig
+	// dpts 解析后的组地址->数据点类型
+	dpts map[GroupAddress]string
+	// mu 保护 tunnel
+	mu      sync.Mutex
+	tunnel  *Tunnel
+	started bool
+	closed  chan struct{}
+}
+
+// Type 组件类型
+func (x *Knx) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *Knx) New() types.Node {
+	return &Knx{
+		Config: KnxConfig{
+			Server:  "192.168.1.10:3671",
+			Timeout: 5,
+		},
+	}
+}
+
+// Init 初始化
+func (x *Knx) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	x.dpts = make(map[GroupAddress]string, len(x.Config.Dpts))
+	for address, dpt := range x.Config.Dpts {
+		ga, err := ParseGroupAddress(address)
+		if err != nil {
+			return err
+		}
+		if _, err = DecodeDPT(dpt, make([]byte, 14)); err != nil {
+			return err
+		}
+		x.dpts[ga] = normalizeDPT(dpt)
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (x *Knx) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *Knx) Desc() string {
+	return "KNXnet/IP tunnelling endpoint routing group telegrams decoded by datapoint type to rule chains by group address"
+}
+
+// Category returns the component category
+func (x *Knx) Category() string {
+	return "endpoint"
+}
+
+func (x *Knx) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "KNXnet/IP tunnelling endpoint routing group telegrams decoded by datapoint type to rule chains by group address",
+		RouterForm: &types.RouterForm{
+			From: &types.RouterFormField{
+				Path: types.ComponentFormField{
+					Name:  "path",
+					Type:  "string",
+					Label: "Group Address",
+					Desc:  "Group address to route, eg. 1/2/3, 1/2/* or 1/*, empty or * routes all group addresses",
+				},
+			},
+		},
+	}
+}
+
+func (x *Knx) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	tunnels.CompareAndDelete(x.Id(), x)
+	if !x.started {
+		return nil
+	}
+	x.started = false
+	close(x.closed)
+	if x.tunnel != nil {
+		_ = x.tunnel.Close()
+		x.tunnel = nil
+	}
+	return nil
+}
+
+func (x *Knx) Id() string {
+	return x.Config.Server
+}
+
+func (x *Knx) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	if pattern := patternOf(router); pattern != "*" && !strings.HasSuffix(pattern, "/*") {
+		if _, err := ParseGroupAddress(pattern); err != nil {
+			return "", err
+		}
+	}
+	x.CheckAndSetRouterId(router)
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpointApi.Router)
+	}
+	x.RouterStorage[router.GetId()] = router
+	return router.GetId(), nil
+}
+
+func (x *Knx) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if _, ok := x.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(x.RouterStorage, routerId)
+	return nil
+}
+
+// patternOf 路由的组地址，匹配所有组地址时为 *
+func patternOf(router endpointApi.Router) string {
+	if from := router.GetFrom(); from != nil {
+		if pattern := strings.TrimSpace(from.ToString()); pattern != "" {
+			return pattern
+		}
+	}
+	return "*"
+}
+
+// matchGroupAddress 组地址是否匹配路由，通配符只能在最后一级
+func matchGroupAddress(pattern string, ga GroupAddress) bool {
+	if pattern == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(ga.String(), prefix)
+	}
+	if v, err := ParseGroupAddress(pattern); err == nil {
+		return v == ga
+	}
+	return false
+}
+
+// routersOf 组地址匹配的路由
+func (x *Knx) routersOf(ga GroupAddress) []endpointApi.Router {
+	x.RLock()
+	defer x.RUnlock()
+	var routers []endpointApi.Router
+	for _, r := range x.RouterStorage {
+		if matchGroupAddress(patternOf(r), ga) {
+			routers = append(routers, r)
+		}
+	}
+	return routers
+}
+
+// Start 建立隧道，隧道断开后自动重新连接
+func (x *Knx) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.started {
+		return nil
+	}
+	tunnel, err := DialTunnel(x.Config.Server, x.timeout(), x.onTelegram)
+	if err != nil {
+		return err
+	}
+	x.tunnel, x.started, x.closed = tunnel, true, make(chan struct{})
+	go x.keepalive(tunnel, x.closed)
+	tunnels.Store(x.Id(), x)
+	x.Printf("started KNXnet/IP tunnel to %s, individual address %s", x.Config.Server, tunnel.Address)
+	return nil
+}
+
+func (x *Knx) timeout() time.Duration {
+	if x.Config.Timeout <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(x.Config.Timeout) * time.Second
+}
+
+// keepalive 隧道断开后重新连接，直到端点关闭
+func (x *Knx) keepalive(tunnel *Tunnel, closed chan struct{}) {
+	for {
+		select {
+		case <-closed:
+			return
+		case <-tunnel.Done():
+		}
+		x.Printf("knx tunnel to %s disconnected, reconnecting", x.Config.Server)
+		for {
+			select {
+			case <-closed:
+				return
+			case <-time.After(reconnectInterval):
+			}
+			t, err := DialTunnel(x.Config.Server, x.timeout(), x.onTelegram)
+			if err != nil {
+				continue
+			}
+			x.mu.Lock()
+			if !x.started {
+				x.mu.Unlock()
+				_ = t.Close()
+				return
+			}
+			x.tunnel, tunnel = t, t
+			x.mu.Unlock()
+			break
+		}
+	}
+}
+
+func (x *Knx) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// onTelegram 按数据点类型解码组报文并交给匹配的路由
+func (x *Knx) onTelegram(telegram Telegram) {
+	routers := x.routersOf(telegram.Destination)
+	if len(routers) == 0 {
+		return
+	}
+	request := &RequestMessage{telegram: telegram, dpt: x.dpts[telegram.Destination]}
+	switch {
+	case telegram.Command == CommandRead:
+	case request.dpt != "":
+		value, err := DecodeDPT(request.dpt, telegram.Data)
+		if err != nil {
+			x.Printf("knx decode %s as %s error: %v", telegram.Destination, request.dpt, err)
+		}
+		request.value = value
+	case telegram.Small:
+		request.value = int(telegram.Data[0])
+	default:
+		request.value = hex.EncodeToString(telegram.Data)
+	}
+	for _, router := range routers {
+		exchange := &endpointApi.Exchange{
+			In:  request,
+			Out: &ResponseMessage{},
+		}
+		x.DoProcess(context.Background(), router, exchange)
+	}
+}
+
+// DptOf 组地址配置的数据点类型
+func (x *Knx) DptOf(ga GroupAddress) string {
+	return x.dpts[ga]
+}
+
+// Send 向总线发送组报文，等待接口确认
+func (x *Knx) Send(telegram Telegram) error {
+	x.mu.Lock()
+	tunnel := x.tunnel
+	x.mu.Unlock()
+	if tunnel == nil {
+		return fmt.Errorf("knx tunnel not started: %s", x.Id())
+	}
+	return tunnel.Send(telegram)
+}
+
+// lookupTunnel 查找运行中的端点
+func lookupTunnel(id string) (*Knx, bool) {
+	if v, ok := tunnels.Load(id); ok {
+		return v.(*Knx), true
+	}
+	return nil, false
+}
+
+// parseValue 消息中的值，JSON 值按 JSON 解析，其他作为字符串
+func parseValue(s string) interface{} {
+	s = strings.TrimSpace(s)
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		return v
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,351 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knx
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testInterface 测试用的 KNX IP 接口，确认所有写入的组报文，可以向隧道发送总线上的组报文
+type testInterface struct {
+	conn *net.UDPConn
+	mu   sync.Mutex
+	// client 隧道客户端地址
+	client *net.UDPAddr
+	seq    byte
+	// sent 客户端发送的组报文
+	sent []Telegram
+	// noConfirm 为 true 时确认帧设置错误标志
+	noConfirm bool
+	// connects 收到的连接请求数
+	connects int
+}
+
+func newTestInterface(t *testing.T) *testInterface {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	s := &testInterface{conn: conn}
+	go s.serve()
+	return s
+}
+
+func (s *testInterface) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *testInterface) close() {
+	_ = s.conn.Close()
+}
+
+func (s *testInterface) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		service, body, ok := parseFrame(buf[:n])
+		if !ok {
+			continue
+		}
+		s.mu.Lock()
+		switch service {
+		case serviceConnectRequest:
+			s.client = addr
+			s.connects++
+			s.seq = 0
+			_, _ = s.conn.WriteToUDP(frame(serviceConnectResponse, []byte{0x07, 0x00}, hpaiNAT, []byte{0x04, 0x04, 0x11, 0xfa}), addr)
+		case serviceTunnellingRequest:
+			_, _ = s.conn.WriteToUDP(frame(serviceTunnellingAck, []byte{0x04, body[1], body[2], 0x00}), addr)
+			if _, telegram, _, ok := decodeCEMI(body[4:]); ok {
+				s.sent = append(s.sent, telegram)
+			}
+			con := append([]byte(nil), body[4:]...)
+			con[0] = cemiLDataCon
+			if s.noConfirm {
+				con[2+int(con[1])] |= 0x01
+			}
+			s.tunnel(con)
+		case serviceConnectionStateRequest:
+			_, _ = s.conn.WriteToUDP(frame(serviceConnectionStateResponse, []byte{body[0], 0x00}), addr)
+		case serviceDisconnectRequest:
+			_, _ = s.conn.WriteToUDP(frame(serviceDisconnectResponse, []byte{body[0], 0x00}), addr)
+			s.client = nil
+		}
+		s.mu.Unlock()
+	}
+}
+
+// tunnel 向客户端发送隧道请求，不等待确认
+func (s *testInterface) tunnel(cemi []byte) {
+	if s.client == nil {
+		return
+	}
+	_, _ = s.conn.WriteToUDP(frame(serviceTunnellingRequest, []byte{0x04, 0x07, s.seq, 0x00}, cemi), s.client)
+	s.seq++
+}
+
+// indicate 发送总线上的组报文
+func (s *testInterface) indicate(source IndividualAddress, ga GroupAddress, command string, data []byte, small bool) {
+	cemi, _ := Telegram{Destination: ga, Command: command, Data: data, Small: small}.encodeCEMI()
+	cemi[0] = cemiLDataInd
+	binary.BigEndian.PutUint16(cemi[4:6], uint16(source))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tunnel(cemi)
+}
+
+// disconnect 接口主动断开隧道
+func (s *testInterface) disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		_, _ = s.conn.WriteToUDP(frame(serviceDisconnectRequest, []byte{0x07, 0x00}, hpaiNAT), s.client)
+	}
+}
+
+func (s *testInterface) telegrams() []Telegram {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Telegram(nil), s.sent...)
+}
+
+func mustGroupAddress(s string) GroupAddress {
+	ga, _ := ParseGroupAddress(s)
+	return ga
+}
+
+func TestTunnel(t *testing.T) {
+	server := newTestInterface(t)
+	defer server.close()
+
+	telegrams := make(chan Telegram, 10)
+	tunnel, err := DialTunnel(server.addr(), time.Second, func(telegram Telegram) {
+		telegrams <- telegram
+	})
+	assert.Nil(t, err)
+	defer tunnel.Close()
+	assert.Equal(t, "1.1.250", tunnel.Address.String())
+
+	// 写组地址，等待确认
+	assert.Nil(t, tunnel.Send(Telegram{Destination: mustGroupAddress("1/2/3"), Command: CommandWrite, Data: []byte{1}, Small: true}))
+	assert.Nil(t, tunnel.Send(Telegram{Destination: mustGroupAddress("3/0/1"), Command: CommandWrite, Data: []byte{0x0c, 0x1a}}))
+	assert.Nil(t, tunnel.Send(Telegram{Destination: mustGroupAddress("3/0/1"), Command: CommandRead}))
+	sent := server.telegrams()
+	assert.Equal(t, 3, len(sent))
+	assert.Equal(t, Telegram{Source: 0, Destination: mustGroupAddress("1/2/3"), Command: CommandWrite, Data: []byte{1}, Small: true}, sent[0])
+	assert.Equal(t, []byte{0x0c, 0x1a}, sent[1].Data)
+	assert.Equal(t, CommandRead, sent[2].Command)
+
+	server.mu.Lock()
+	server.noConfirm = true
+	server.mu.Unlock()
+	assert.NotNil(t, tunnel.Send(Telegram{Destination: mustGroupAddress("1/2/3"), Command: CommandWrite, Data: []byte{0}, Small: true}))
+	assert.NotNil(t, tunnel.Send(Telegram{Destination: mustGroupAddress("1/2/3"), Command: "GroupValueDelete"}))
+
+	// 总线上的组报文
+	server.indicate(0x1105, mustGroupAddress("3/0/1"), CommandResponse, []byte{0x0c, 0x1a}, false)
+	select {
+	case telegram := <-telegrams:
+		assert.Equal(t, "1.1.5", telegram.Source.String())
+		assert.Equal(t, "3/0/1", telegram.Destination.String())
+		assert.Equal(t, CommandResponse, telegram.Command)
+		assert.Equal(t, []byte{0x0c, 0x1a}, telegram.Data)
+	case <-time.After(time.Second):
+		t.Fatal("telegram not received")
+	}
+
+	// 接口断开隧道
+	server.disconnect()
+	select {
+	case <-tunnel.Done():
+	case <-time.After(time.Second):
+		t.Fatal("tunnel not closed")
+	}
+	assert.Equal(t, ErrClosed, tunnel.Send(Telegram{Destination: mustGroupAddress("1/2/3"), Command: CommandRead}))
+
+	// 接口没有响应
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	defer conn.Close()
+	_, err = DialTunnel(conn.LocalAddr().String(), time.Millisecond*100, nil)
+	assert.NotNil(t, err)
+}
+
+func TestKnxEndpoint(t *testing.T) {
+	server := newTestInterface(t)
+	defer server.close()
+	reconnectInterval = time.Millisecond * 100
+
+	ep := (&Knx{}).New().(*Knx)
+	assert.Equal(t, Type, ep.Type())
+
+	config := engine.NewConfig()
+	_, err := engine.New("knx-test01", []byte(`{
+		"ruleChain": {"id": "knx-test01", "name": "knx-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("knx-test01")
+
+	assert.NotNil(t, (&Knx{}).New().Init(config, types.Configuration{"dpts": map[string]string{"1/2/3": "99.001"}}))
+	assert.NotNil(t, (&Knx{}).New().Init(config, types.Configuration{"dpts": map[string]string{"1/2/x": "1.001"}}))
+	err = ep.Init(config, types.Configuration{
+		"server":  server.addr(),
+		"dpts":    map[string]string{"1/2/3": "1.001", "3/0/1": "DPT9.001"},
+		"timeout": 1,
+	})
+	assert.Nil(t, err)
+
+	msgs := make(chan types.RuleMsg, 10)
+	all := make(chan types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("3/*").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msgs <- *exchange.In.GetMsg()
+		return true
+	}).To("chain:knx-test01").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("*").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		all <- *exchange.In.GetMsg()
+		return true
+	}).To("chain:knx-test01").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("1/x").To("chain:knx-test01").End())
+	assert.NotNil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	receive := func(ch chan types.RuleMsg) types.RuleMsg {
+		select {
+		case msg := <-ch:
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("telegram not routed")
+		}
+		return types.RuleMsg{}
+	}
+	server.indicate(0x1105, mustGroupAddress("3/0/1"), CommandWrite, []byte{0x0c, 0x1a}, false)
+	msg := receive(msgs)
+	assert.Equal(t, CommandWrite, msg.Type)
+	assert.Equal(t, "3/0/1", msg.Metadata.GetValue(KeyGroupAddress))
+	assert.Equal(t, "1.1.5", msg.Metadata.GetValue(KeySource))
+	assert.Equal(t, "9.001", msg.Metadata.GetValue(KeyDpt))
+	var data map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &data))
+	assert.Equal(t, map[string]interface{}{"value": 21.0, "raw": "0c1a", "dpt": "9.001", "unit": "°C"}, data)
+	receive(all)
+
+	// 没有配置数据点类型的组地址
+	server.indicate(0x1105, mustGroupAddress("5/1/20"), CommandWrite, []byte{0x2a}, true)
+	msg = receive(all)
+	assert.Equal(t, `{"raw":"2a","value":42}`, msg.GetData())
+	server.indicate(0x1105, mustGroupAddress("5/1/20"), CommandRead, nil, false)
+	msg = receive(all)
+	assert.Equal(t, CommandRead, msg.Type)
+	assert.Equal(t, `{"raw":"00","value":null}`, msg.GetData())
+	assert.Equal(t, 0, len(msgs))
+
+	// 规则链写组地址
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	_, err = test.CreateAndInitNode("x/knxWrite", types.Configuration{"server": ep.Id(), "groupAddress": ""}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/knxWrite", types.Configuration{"server": ep.Id(), "command": "delete"}, Registry)
+	assert.NotNil(t, err)
+	node, err := test.CreateAndInitNode("x/knxWrite", types.Configuration{
+		"server": ep.Id(),
+		"dpt":    "${metadata.dpt}",
+	}, Registry)
+	assert.Nil(t, err)
+	readNode, err := test.CreateAndInitNode("x/knxWrite", types.Configuration{
+		"server":       ep.Id(),
+		"groupAddress": "3/0/1",
+		"command":      "read",
+	}, Registry)
+	assert.Nil(t, err)
+	notFoundNode, err := test.CreateAndInitNode("x/knxWrite", types.Configuration{"server": "127.0.0.1:1"}, Registry)
+	assert.Nil(t, err)
+
+	newMsg := func(msgType, ga, dpt, data string) test.Msg {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyGroupAddress, ga)
+		metadata.PutValue(KeyDpt, dpt)
+		return test.Msg{
+			MetaData:   metadata,
+			DataType:   types.JSON,
+			MsgType:    msgType,
+			Data:       data,
+			AfterSleep: time.Millisecond * 100,
+		}
+	}
+	test.NodeOnMsg(t, node, []test.Msg{
+		newMsg("LIGHT_ON", "1/2/3", "", "true"),
+		newMsg("SETPOINT", "3/0/2", "9.001", "21.5"),
+		newMsg("NO_DPT", "3/0/2", "", "21.5"),
+		newMsg("INVALID", "1/2/3", "5.001", "on"),
+		newMsg("INVALID_GA", "1/2/x", "1.001", "true"),
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		switch msg.Type {
+		case "LIGHT_ON":
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "1.001", msg.Metadata.GetValue(KeyDpt))
+		case "SETPOINT":
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, CommandWrite, msg.Metadata.GetValue(KeyCommand))
+		default:
+			assert.Equal(t, types.Failure, relationType)
+		}
+	})
+	test.NodeOnMsg(t, readNode, []test.Msg{newMsg("READ", "", "", "")}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+	test.NodeOnMsg(t, notFoundNode, []test.Msg{newMsg("LIGHT_ON", "1/2/3", "", "true")}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+	})
+	sent := server.telegrams()
+	assert.Equal(t, 3, len(sent))
+	assert.Equal(t, []byte{1}, sent[0].Data)
+	data2, _, _ := EncodeDPT("9.001", 21.5)
+	assert.Equal(t, data2, sent[1].Data)
+	assert.Equal(t, CommandRead, sent[2].Command)
+
+	// 接口断开后重新连接
+	server.disconnect()
+	time.Sleep(time.Millisecond * 500)
+	server.mu.Lock()
+	connects := server.connects
+	server.mu.Unlock()
+	assert.Equal(t, 2, connects)
+	server.indicate(0x1105, mustGroupAddress("1/2/3"), CommandWrite, []byte{1}, true)
+	msg = receive(all)
+	assert.Equal(t, `{"dpt":"1.001","raw":"01","value":true}`, msg.GetData())
+
+	ep.Destroy()
+	_, ok := lookupTunnel(ep.Id())
+	assert.False(t, ok)
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// commands 节点配置的命令->组报文命令
+var commands = map[string]string{
+	"write":    CommandWrite,
+	"read":     CommandRead,
+	"response": CommandResponse,
+}
+
+// WriteNodeConfiguration 节点配置
+type WriteNodeConfiguration struct {
+	// Server KNX 隧道端点 Id，与 endpoint/knx 的 server 配置一致，eg. 192.168.1.10:3671
+	Server string `json:"server" label:"Server" desc:"KNX tunnel endpoint id, same as the server of endpoint/knx" required:"true"`
+	// GroupAddress 组地址，eg. 1/2/3，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	GroupAddress string `json:"groupAddress" label:"Group Address" desc:"Group address, eg. 1/2/3, supports ${metadata.key} and ${msg.key} variables" required:"true"`
+	// Dpt 数据点类型，eg. 1.001、9.001，可以使用变量，为空使用端点配置的数据点类型
+	Dpt string `json:"dpt" label:"Datapoint Type" desc:"Datapoint type, eg. 1.001, 9.001, supports variables, empty uses the datapoint type configured in the endpoint"`
+	// Value 写入的值，可以使用变量，为空使用消息负荷
+	Value string `json:"value" label:"Value" desc:"Value to write, supports variables, empty uses the message payload"`
+	// Command 命令：write、read、response，默认 write
+	Command string `json:"command" label:"Command" desc:"Command: write, read or response, default write" component:"{\"type\":\"select\",\"options\":[{\"label\":\"write\",\"value\":\"write\"},{\"label\":\"read\",\"value\":\"read\"},{\"label\":\"response\",\"value\":\"response\"}]}"`
+}
+
+// WriteNode 通过 KNX 隧道端点向总线发送组报文，值按数据点类型编码，eg. DPT 1.001 写 true 开灯，DPT 9.001 写 21.5 设置温度
+// read 命令只发送读请求，设备的响应报文由端点路由到规则链
+// 接口确认后消息流转到`Success`链；端点未启动、编码失败或者未确认，流转到`Failure`链
+type WriteNode struct {
+	//节点配置
+	Config               WriteNodeConfiguration
+	command              string
+	groupAddressTemplate str.Template
+	dptTemplate          str.Template
+	valueTemplate        str.Template
+}
+
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteNodeConfiguration{
+			Server:       "192.168.1.10:3671",
+			GroupAddress: "${metadata.groupAddress}",
+			Command:      "write",
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/knxWrite"
+}
+
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.GroupAddress) == "" {
+		return errors.New("knx group address is empty")
+	}
+	command := strings.ToLower(strings.TrimSpace(x.Config.Command))
+	if command == "" {
+		command = "write"
+	}
+	var ok bool
+	if x.command, ok = commands[command]; !ok {
+		return fmt.Errorf("unsupported knx command: %s", x.Config.Command)
+	}
+	x.groupAddressTemplate = str.NewTemplate(x.Config.GroupAddress)
+	x.dptTemplate = str.NewTemplate(x.Config.Dpt)
+	x.valueTemplate = str.NewTemplate(x.Config.Value)
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	tunnel, ok := lookupTunnel(x.Config.Server)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("knx tunnel not found: %s", x.Config.Server))
+		return
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	ga, err := ParseGroupAddress(x.groupAddressTemplate.Execute(evn))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	telegram := Telegram{Destination: ga, Command: x.command}
+	dpt := tunnel.DptOf(ga)
+	if x.command != CommandRead {
+		if v := strings.TrimSpace(x.dptTemplate.Execute(evn)); v != "" {
+			dpt = normalizeDPT(v)
+		}
+		if dpt == "" {
+			ctx.TellFailure(msg, fmt.Errorf("no datapoint type for knx group address %s", ga))
+			return
+		}
+		value := msg.GetData()
+		if x.Config.Value != "" {
+			value = x.valueTemplate.Execute(evn)
+		}
+		if telegram.Data, telegram.Small, err = EncodeDPT(dpt, parseValue(value)); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	if err = tunnel.Send(telegram); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KeyGroupAddress, ga.String())
+	msg.Metadata.PutValue(KeyCommand, x.command)
+	if dpt != "" {
+		msg.Metadata.PutValue(KeyDpt, dpt)
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+func (x *WriteNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Write a KNX group address through the KNX tunnel endpoint, encoding the value by datapoint type. Routes to Success/Failure"
+}
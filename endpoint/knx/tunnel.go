@@ -0,0 +1,427 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// KNXnet/IP 服务类型
+const (
+	serviceConnectRequest          = 0x0205
+	serviceConnectResponse         = 0x0206
+	serviceConnectionStateRequest  = 0x0207
+	serviceConnectionStateResponse = 0x0208
+	serviceDisconnectRequest       = 0x0209
+	serviceDisconnectResponse      = 0x020a
+	serviceTunnellingRequest       = 0x0420
+	serviceTunnellingAck           = 0x0421
+	headerSize                     = 6
+	protocolVersion                = 0x10
+)
+
+// cEMI 消息码
+const (
+	cemiLDataReq = 0x11
+	cemiLDataInd = 0x29
+	cemiLDataCon = 0x2e
+)
+
+// 组通信命令
+const (
+	CommandRead     = "GroupValueRead"
+	CommandResponse = "GroupValueResponse"
+	CommandWrite    = "GroupValueWrite"
+)
+
+var apciCommands = map[uint16]string{0x000: CommandRead, 0x040: CommandResponse, 0x080: CommandWrite}
+
+var (
+	// DefaultPort KNXnet/IP 默认端口
+	DefaultPort = "3671"
+	// HeartbeatInterval 连接状态检查的周期
+	HeartbeatInterval = 60 * time.Second
+	// ErrClosed 隧道已经关闭
+	ErrClosed = errors.New("knx tunnel is closed")
+)
+
+// Telegram 组报文
+type Telegram struct {
+	// Source 发送方个体地址
+	Source IndividualAddress
+	// Destination 目标组地址
+	Destination GroupAddress
+	// Command 命令：GroupValueRead、GroupValueResponse、GroupValueWrite
+	Command string
+	// Data 数据，Small 为 true 时只有 1 字节，为不超过 6 位的值
+	Data  []byte
+	Small bool
+}
+
+// encodeCEMI 编码 L_Data.req
+func (t Telegram) encodeCEMI() ([]byte, error) {
+	var apci uint16
+	for k, v := range apciCommands {
+		if v == t.Command {
+			apci = k
+		}
+	}
+	if apci == 0 && t.Command != CommandRead {
+		return nil, fmt.Errorf("unsupported knx command: %s", t.Command)
+	}
+	// 标准帧、不重复、广播、低优先级，组地址、跳数 6
+	b := []byte{cemiLDataReq, 0x00, 0xbc, 0xe0, 0x00, 0x00, byte(t.Destination >> 8), byte(t.Destination)}
+	switch {
+	case t.Command == CommandRead:
+		b = append(b, 1, 0x00, byte(apci))
+	case t.Small:
+		b = append(b, 1, 0x00, byte(apci)|t.Data[0]&0x3f)
+	default:
+		b = append(b, byte(1+len(t.Data)), 0x00, byte(apci))
+		b = append(b, t.Data...)
+	}
+	return b, nil
+}
+
+// decodeCEMI 解码 L_Data 的组报文，ok 为 false 表示不是组报文
+func decodeCEMI(b []byte) (code byte, t Telegram, confirmError bool, ok bool) {
+	if len(b) < 2 || len(b) < 2+int(b[1])+9 {
+		return 0, t, false, false
+	}
+	code = b[0]
+	b = b[2+int(b[1]):]
+	ctrl1, ctrl2 := b[0], b[1]
+	if ctrl2&0x80 == 0 {
+		return code, t, false, false
+	}
+	t.Source = IndividualAddress(binary.BigEndian.Uint16(b[2:4]))
+	t.Destination = GroupAddress(binary.BigEndian.Uint16(b[4:6]))
+	length := int(b[6])
+	if len(b) < 8+length || length < 1 {
+		return code, t, false, false
+	}
+	apci := (uint16(b[7])<<8 | uint16(b[8])) & 0x03c0
+	command, found := apciCommands[apci]
+	if !found {
+		return code, t, false, false
+	}
+	t.Command = command
+	if length == 1 {
+		t.Data, t.Small = []byte{b[8] & 0x3f}, true
+	} else {
+		t.Data = append([]byte(nil), b[9:8+length]...)
+	}
+	return code, t, ctrl1&0x01 != 0, true
+}
+
+// frame 编码 KNXnet/IP 帧
+func frame(service uint16, body ...[]byte) []byte {
+	n := headerSize
+	for _, b := range body {
+		n += len(b)
+	}
+	out := []byte{headerSize, protocolVersion, byte(service >> 8), byte(service), byte(n >> 8), byte(n)}
+	for _, b := range body {
+		out = append(out, b...)
+	}
+	return out
+}
+
+// hpaiNAT NAT 模式的主机协议地址信息，服务端使用请求的来源地址
+var hpaiNAT = []byte{0x08, 0x01, 0, 0, 0, 0, 0, 0}
+
+// Tunnel KNXnet/IP 隧道连接，使用 NAT 模式，收到的组报文通过 OnTelegram 回调
+type Tunnel struct {
+	conn    *net.UDPConn
+	timeout time.Duration
+	channel byte
+	// Address 接口分配的个体地址
+	Address IndividualAddress
+	// onTelegram 在读取协程中调用
+	onTelegram func(Telegram)
+	// mu 保证发送按顺序等待确认
+	mu      sync.Mutex
+	seqSend byte
+	// lastRecv 最后收到的序号，用于丢弃重复的报文
+	lastRecv int
+	acks     chan byte
+	confirms chan bool
+	states   chan byte
+	done     chan struct{}
+	once     sync.Once
+}
+
+// DialTunnel 连接 KNX IP 接口并建立隧道
+func DialTunnel(server string, timeout time.Duration, onTelegram func(Telegram)) (*Tunnel, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, DefaultPort)
+	}
+	addr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tunnel{
+		conn:       conn,
+		timeout:    timeout,
+		onTelegram: onTelegram,
+		lastRecv:   -1,
+		acks:       make(chan byte, 1),
+		confirms:   make(chan bool, 1),
+		states:     make(chan byte, 1),
+		done:       make(chan struct{}),
+	}
+	if err = t.connect(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	go t.readLoop()
+	go t.heartbeat()
+	return t, nil
+}
+
+// connect 发送 CONNECT_REQUEST，请求隧道链路层连接
+func (t *Tunnel) connect() error {
+	if _, err := t.conn.Write(frame(serviceConnectRequest, hpaiNAT, hpaiNAT, []byte{0x04, 0x04, 0x02, 0x00})); err != nil {
+		return err
+	}
+	_ = t.conn.SetReadDeadline(time.Now().Add(t.timeout))
+	defer t.conn.SetReadDeadline(time.Time{})
+	buf := make([]byte, 512)
+	for {
+		n, err := t.conn.Read(buf)
+		if err != nil {
+			return err
+		}
+		service, body, ok := parseFrame(buf[:n])
+		if !ok || service != serviceConnectResponse || len(body) < 2 {
+			continue
+		}
+		if body[1] != 0 {
+			return fmt.Errorf("knx tunnel connect failed: %s", statusText(body[1]))
+		}
+		t.channel = body[0]
+		if len(body) >= 14 {
+			t.Address = IndividualAddress(binary.BigEndian.Uint16(body[12:14]))
+		}
+		return nil
+	}
+}
+
+func statusText(status byte) string {
+	switch status {
+	case 0x21:
+		return "connection id not found"
+	case 0x22:
+		return "connection type not supported"
+	case 0x23:
+		return "connection option not supported"
+	case 0x24:
+		return "no more connections"
+	case 0x26:
+		return "connection state error"
+	case 0x29:
+		return "tunnelling layer not supported"
+	default:
+		return fmt.Sprintf("status 0x%02x", status)
+	}
+}
+
+// parseFrame 解析 KNXnet/IP 帧头
+func parseFrame(b []byte) (uint16, []byte, bool) {
+	if len(b) < headerSize || b[0] != headerSize || b[1] != protocolVersion {
+		return 0, nil, false
+	}
+	n := int(binary.BigEndian.Uint16(b[4:6]))
+	if n < headerSize || n > len(b) {
+		return 0, nil, false
+	}
+	return binary.BigEndian.Uint16(b[2:4]), b[headerSize:n], true
+}
+
+// Done 隧道关闭时关闭的通道
+func (t *Tunnel) Done() <-chan struct{} {
+	return t.done
+}
+
+// Close 发送 DISCONNECT_REQUEST 并关闭隧道
+func (t *Tunnel) Close() error {
+	select {
+	case <-t.done:
+	default:
+		_, _ = t.conn.Write(frame(serviceDisconnectRequest, []byte{t.channel, 0x00}, hpaiNAT))
+	}
+	t.shutdown()
+	return nil
+}
+
+func (t *Tunnel) shutdown() {
+	t.once.Do(func() {
+		close(t.done)
+		_ = t.conn.Close()
+	})
+}
+
+func (t *Tunnel) readLoop() {
+	defer t.shutdown()
+	buf := make([]byte, 512)
+	for {
+		n, err := t.conn.Read(buf)
+		if err != nil {
+			return
+		}
+		service, body, ok := parseFrame(buf[:n])
+		if !ok {
+			continue
+		}
+		switch service {
+		case serviceTunnellingRequest:
+			if len(body) < 4 || body[1] != t.channel {
+				continue
+			}
+			seq := body[2]
+			_, _ = t.conn.Write(frame(serviceTunnellingAck, []byte{0x04, t.channel, seq, 0x00}))
+			if int(seq) == t.lastRecv {
+				continue
+			}
+			t.lastRecv = int(seq)
+			code, telegram, confirmError, ok := decodeCEMI(body[4:])
+			switch {
+			case code == cemiLDataCon:
+				select {
+				case t.confirms <- !confirmError:
+				default:
+				}
+			case ok && code == cemiLDataInd && t.onTelegram != nil:
+				t.onTelegram(telegram)
+			}
+		case serviceTunnellingAck:
+			if len(body) >= 4 && body[1] == t.channel {
+				select {
+				case t.acks <- body[2]:
+				default:
+				}
+			}
+		case serviceConnectionStateResponse:
+			if len(body) >= 2 && body[0] == t.channel {
+				select {
+				case t.states <- body[1]:
+				default:
+				}
+			}
+		case serviceDisconnectRequest:
+			_, _ = t.conn.Write(frame(serviceDisconnectResponse, []byte{t.channel, 0x00}))
+			return
+		}
+	}
+}
+
+// heartbeat 定期检查连接状态，3 次没有响应或者状态错误时关闭隧道
+func (t *Tunnel) heartbeat() {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		}
+		alive := false
+		for i := 0; i < 3 && !alive; i++ {
+			_, _ = t.conn.Write(frame(serviceConnectionStateRequest, []byte{t.channel, 0x00}, hpaiNAT))
+			select {
+			case status := <-t.states:
+				if status != 0 {
+					t.shutdown()
+					return
+				}
+				alive = true
+			case <-time.After(10 * time.Second):
+			case <-t.done:
+				return
+			}
+		}
+		if !alive {
+			t.shutdown()
+			return
+		}
+	}
+}
+
+// Send 发送组报文，等待隧道确认和 L_Data.con
+func (t *Tunnel) Send(telegram Telegram) error {
+	cemi, err := telegram.encodeCEMI()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return ErrClosed
+	case <-t.confirms:
+	default:
+	}
+	seq := t.seqSend
+	request := frame(serviceTunnellingRequest, []byte{0x04, t.channel, seq, 0x00}, cemi)
+	acked := false
+	// 没有确认时重发一次
+	for i := 0; i < 2 && !acked; i++ {
+		if _, err = t.conn.Write(request); err != nil {
+			return err
+		}
+		timer := time.NewTimer(time.Second)
+		for waiting := true; waiting; {
+			select {
+			case ackSeq := <-t.acks:
+				if ackSeq == seq {
+					acked, waiting = true, false
+				}
+			case <-timer.C:
+				waiting = false
+			case <-t.done:
+				timer.Stop()
+				return ErrClosed
+			}
+		}
+		timer.Stop()
+	}
+	if !acked {
+		t.shutdown()
+		return errors.New("knx tunnelling request not acknowledged")
+	}
+	t.seqSend++
+	select {
+	case ok := <-t.confirms:
+		if !ok {
+			return fmt.Errorf("knx %s to %s not confirmed", telegram.Command, telegram.Destination)
+		}
+		return nil
+	case <-time.After(t.timeout):
+		return fmt.Errorf("knx %s to %s confirm timeout", telegram.Command, telegram.Destination)
+	case <-t.done:
+		return ErrClosed
+	}
+}
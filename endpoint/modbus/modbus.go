@@ -0,0 +1,594 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	modbusNode "github.com/rulego/rulego-components-iot/external/modbus"
+	"github.com/rulego/rulego-components-iot/pkg/buffer"
+	"github.com/rulego/rulego-components-iot/pkg/poll"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "modbus"
+const MODBUS_DATA_MSG_TYPE = "MODBUS_DATA"
+
+// 元数据key
+const (
+	KeyServer   = "server"
+	KeyUnitId   = "unitId"
+	KeyUnitName = "unitName"
+)
+
+// Endpoint 别名
+type Endpoint = Modbus
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	// data 数据块读取结果 []ReadResult 或者点位工程值 map[string]interface{}
+	data       interface{}
+	metadata   *types.Metadata
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *RequestMessage) Body() []byte {
+	b, err := json.Marshal(r.data)
+	if err != nil {
+		log.Println(err)
+	}
+	return b
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := r.metadata
+		if metadata == nil {
+			metadata = types.NewMetadata()
+		}
+		//默认指定是JSON格式，如果不是该类型，请在process函数中修改
+		ruleMsg := types.NewMsg(0, MODBUS_DATA_MSG_TYPE, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+type ResponseMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		//默认指定是JSON格式，如果不是该类型，请在process函数中修改
+		ruleMsg := types.NewMsg(0, MODBUS_DATA_MSG_TYPE, types.JSON, types.NewMetadata(), string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// ModbusConfig Modbus 轮询配置
+type ModbusConfig struct {
+	// Server 服务器地址，eg. tcp://127.0.0.1:502 或者串口 rtu:///dev/ttyUSB0
+	Server string `json:"server" label:"Server" desc:"Modbus server address, format: tcp://host:port or rtu:///dev/ttyUSB0" required:"true" ref:"primary"`
+	// UnitId 从机编号
+	UnitId uint8 `json:"unitId" label:"Unit ID" desc:"Modbus slave unit ID"`
+	// Framing 帧格式：tcp(默认，按 server 协议), rtu-over-tcp(TCP/UDP 透传 RTU 帧), ascii(Modbus ASCII，支持串口和 TCP)
+	Framing string `json:"framing" label:"Framing" desc:"Frame format: tcp (default, by server scheme), rtu-over-tcp (RTU frames over TCP/UDP), ascii (Modbus ASCII over serial or TCP)"`
+	// Interval 轮询间隔，支持 cron 表达式或者固定周期
+	// example: @every 10s, 10s (every 10 seconds) 0 0 0 * * * (triggers at midnight)
+	Interval string `json:"interval" label:"Interval" desc:"Poll interval, supports cron expression or fixed period, e.g. @every 10s, 10s"`
+	// Reads 每次轮询读取的数据块
+	Reads []modbusNode.ReadRequest `json:"reads" label:"Reads" desc:"Blocks to read on each poll"`
+	// Tags 寄存器点位映射，配置后按点位读取并输出以点位名称为 key 的工程值，忽略 reads
+	Tags []modbusNode.Tag `json:"tags" label:"Tags" desc:"Register mappings decoded to engineering values keyed by tag name, overrides reads"`
+	// Profile 设备配置文件名称或者文件路径，配置文件的点位与 tags 合并，同名点位以 tags 为准
+	Profile string `json:"profile" label:"Profile" desc:"Device profile name (e.g. schneider-pm5xxx) or .json/.yaml file path, its tags are merged with tags"`
+	// MaxGap 合并点位读取时允许的最大地址间隔，单位寄存器，0 表示只合并连续的点位
+	MaxGap         uint16                    `json:"maxGap" label:"Max Gap" desc:"Max address gap in registers when coalescing tag reads, 0 only merges contiguous tags"`
+	TcpConfig      modbusNode.TcpConfig      `json:"tcpConfig" label:"TCP Config" desc:"TCP connection configuration"`
+	RtuConfig      modbusNode.RtuConfig      `json:"rtuConfig" label:"RTU Config" desc:"RTU serial configuration"`
+	EncodingConfig modbusNode.EncodingConfig `json:"encodingConfig" label:"Encoding Config" desc:"Data encoding configuration"`
+	// Retry 重试策略和请求间隔
+	Retry modbusNode.RetryConfig `json:"retry" label:"Retry" desc:"Retry count, backoff, retryable exception codes and min interval between requests"`
+	// ShutdownTimeout 停机时等待正在执行的轮询完成的最大秒数
+	ShutdownTimeout int `json:"shutdownTimeout" label:"Shutdown Timeout" desc:"Max seconds to wait for in-flight polls on shutdown before closing the connection, default 10"`
+	// Buffer 磁盘缓存，路由处理失败时缓存轮询数据，处理恢复后按顺序重放
+	Buffer buffer.Config `json:"buffer" label:"Store and Forward" desc:"Disk buffer for polled data when routing fails, replayed in order after recovery"`
+	// Units 同一个 TCP 网关后面的多个从机，通过共享连接按顺序轮询，每个从机单独输出一条消息，忽略 unitId
+	Units []Unit `json:"units" label:"Units" desc:"Slaves behind one TCP gateway polled in turn over the shared connection, one message per unit. Units without reads, tags and profile use the endpoint ones"`
+	// UnitBackoff 从机读取失败后最多跳过的轮询次数，连续失败时从 1 次开始翻倍，0 表示不跳过
+	UnitBackoff int `json:"unitBackoff" label:"Unit Backoff" desc:"Max polls to skip a failing unit, doubled from 1 on consecutive failures so a dead unit does not delay the others, 0 never skips"`
+}
+
+// Modbus Modbus 轮询端点
+// 按 interval 定时读取 reads 配置的数据块，读取结果转换为 RuleMsg 交给路由处理，消息负荷格式同 x/modbusRead 节点：
+//
+//	[
+//	  {"name": "temperature", "unitId": 1, "area": "holdingRegister", "address": 100, "dataType": "float32", "values": [21.5, 22]}
+//	]
+//
+// 配置 tags 时按点位映射读取，消息负荷为以点位名称为 key 的工程值：
+//
+//	{"temperature": 21.5, "pressure": 1.013}
+//
+// 消息类型为 MODBUS_DATA，元数据包含 server 和 unitId。相同 server 和 unitId 的端点和节点共享一个连接，
+// 同一 RTU 串口上的所有从机共享一个连接
+//
+// 配置 units 时通过同一个连接按顺序轮询网关后面的多个从机，每个从机单独输出一条消息，元数据还包含 unitName。
+// 一个从机读取失败不影响其他从机，配置 unitBackoff 后失败的从机在之后的轮询中被跳过，避免超时拖慢其他从机
+//
+// 配置 buffer.dir 后，路由处理失败（路由需要 Wait 才能获取规则链的处理结果）的数据写入磁盘缓存，
+// 下一次处理成功后按写入顺序重放，重放消息的元数据 bufferedAt 为缓存时间（Unix 毫秒）
+type Modbus struct {
+	impl.BaseEndpoint
+	base.SharedNode[*modbusNode.SharedConn]
+	// GracefulShutdown 提供优雅停机功能
+	base.GracefulShutdown
+	// Pauser 暂停和恢复轮询，见 poll.Pausable
+	poll.Pauser
+	RuleConfig types.Config
+	// modbus 轮询配置
+	Config ModbusConfig
+	// 路由实例
+	Router endpointApi.Router
+	// 定时任务实例
+	cronTask *cron.Cron
+	// 定时任务id
+	taskId cron.EntryID
+	// cronLock 保护定时任务
+	cronLock sync.Mutex
+	// units 轮询的从机
+	units []*unitPoller
+	// queue 磁盘缓存，未配置为 nil
+	queue *buffer.Queue
+}
+
+// Type 组件类型
+func (x *Modbus) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *Modbus) New() types.Node {
+	return &Modbus{
+		Config: ModbusConfig{
+			Server:   modbusNode.DefaultServer,
+			UnitId:   modbusNode.DefaultUnitId,
+			Interval: "@every 10s",
+			TcpConfig: modbusNode.TcpConfig{
+				Timeout: 5,
+			},
+			RtuConfig: modbusNode.RtuConfig{
+				Speed:    modbusNode.DefaultSpeed,
+				DataBits: modbusNode.DefaultDataBits,
+				Parity:   modbusNode.DefaultParity,
+				StopBits: modbusNode.DefaultStopBits,
+			},
+			EncodingConfig: modbusNode.EncodingConfig{
+				Endianness: uint(modbusNode.DefaultEndianness),
+				WordOrder:  uint(modbusNode.DefaultWordOrder),
+			},
+			ShutdownTimeout: 10,
+		},
+	}
+}
+
+// Init 初始化
+func (x *Modbus) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.units, err = compileUnits(x.Config); err != nil {
+		return err
+	}
+	if err = modbusNode.CheckFraming(x.Config.Server, x.Config.Framing); err != nil {
+		return err
+	}
+	if err = x.Config.Retry.Validate(); err != nil {
+		return err
+	}
+	if _, err = poll.ParseSchedule(x.Config.Interval, poll.ScheduleOptions{}); err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+
+	// 初始化优雅停机功能
+	x.GracefulShutdown.InitGracefulShutdown(x.RuleConfig.Logger, x.shutdownTimeout())
+
+	if x.queue != nil {
+		_ = x.queue.Close()
+		x.queue = nil
+	}
+	if x.Config.Buffer.Enabled() {
+		if x.queue, err = buffer.Open(x.Config.Buffer); err != nil {
+			return err
+		}
+	}
+	return x.initSharedNode()
+}
+
+// Destroy 销毁
+func (x *Modbus) Destroy() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+// Desc returns the component description
+func (x *Modbus) Desc() string {
+	return "Modbus TCP/RTU endpoint for polling coils and registers on a schedule and routing the decoded values"
+}
+
+// Category returns the component category
+func (x *Modbus) Category() string {
+	return "endpoint"
+}
+
+func (x *Modbus) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Modbus TCP/RTU endpoint for polling coils and registers on a schedule and routing the decoded values",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+// GracefulStop 为 Modbus 端点提供优雅停机
+func (x *Modbus) GracefulStop() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+func (x *Modbus) Close() error {
+	x.cronLock.Lock()
+	if x.taskId != 0 && x.cronTask != nil {
+		x.cronTask.Remove(x.taskId)
+	}
+	var cronStopped context.Context
+	if x.cronTask != nil {
+		cronStopped = x.cronTask.Stop()
+	}
+	x.cronLock.Unlock()
+	// 等待正在执行的轮询完成后再释放连接
+	x.drain(cronStopped)
+	// SharedNode 通过 InitWithClose 中的清理函数释放共享连接
+	_ = x.SharedNode.Close()
+	if x.queue != nil {
+		_ = x.queue.Close()
+	}
+	return nil
+}
+
+// drain 等待正在执行的定时读取和 DoProcess 完成，超时后取消停机上下文强制中断
+func (x *Modbus) drain(cronStopped context.Context) {
+	timeout := x.shutdownTimeout()
+	deadline := time.Now().Add(timeout)
+	if cronStopped != nil {
+		select {
+		case <-cronStopped.Done():
+		case <-time.After(timeout):
+		}
+	}
+	if x.GracefulShutdown.GetActiveOperations() <= 0 {
+		return
+	}
+	if !x.GracefulShutdown.WaitForActiveOperations(time.Until(deadline)) {
+		x.Printf("graceful shutdown timeout after %v, forcing context cancellation", timeout)
+		x.GracefulShutdown.ForceStop()
+		x.GracefulShutdown.WaitForActiveOperations(500 * time.Millisecond)
+	}
+}
+
+// shutdownTimeout 优雅停机超时时间
+func (x *Modbus) shutdownTimeout() time.Duration {
+	if x.Config.ShutdownTimeout > 0 {
+		return time.Duration(x.Config.ShutdownTimeout) * time.Second
+	}
+	return base.DefaultShutdownTimeout
+}
+
+func (x *Modbus) Id() string {
+	return x.Config.Server
+}
+
+func (x *Modbus) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.Router = router
+	poll.Register(router.GetId(), x)
+	return router.GetId(), nil
+}
+
+func (x *Modbus) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		poll.Unregister(x.Router.GetId(), x)
+	}
+	x.Router = nil
+	return nil
+}
+
+func (x *Modbus) Start() error {
+	if !x.SharedNode.IsInit() {
+		if err := x.initSharedNode(); err != nil {
+			return err
+		}
+	}
+	x.cronLock.Lock()
+	defer x.cronLock.Unlock()
+	if x.cronTask != nil {
+		x.cronTask.Stop()
+	}
+	x.cronTask = cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger)), cron.WithLogger(cron.DefaultLogger))
+	schedule, err := poll.ParseSchedule(x.Config.Interval, poll.ScheduleOptions{})
+	if err != nil {
+		return err
+	}
+	x.taskId = x.cronTask.Schedule(schedule, cron.FuncJob(x.onTick))
+	x.cronTask.Start()
+	return nil
+}
+
+// onTick 定时读取数据块
+func (x *Modbus) onTick() {
+	// 暂停期间不读取设备
+	if x.Router != nil && !x.Paused() {
+		_ = x.poll(x.Router)
+	}
+}
+
+func (x *Modbus) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// poll 按顺序读取每个从机的数据块并交给路由处理，从机的任意数据块读取失败则丢弃该从机本次的结果，不影响其他从机
+func (x *Modbus) poll(router endpointApi.Router) error {
+	// 增加活跃操作计数
+	x.GracefulShutdown.IncrementActiveOperations()
+	defer x.GracefulShutdown.DecrementActiveOperations()
+	// 停机过程中不再发起新的读取
+	if x.GracefulShutdown.IsShuttingDown() {
+		return nil
+	}
+
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		x.Printf("get shared connection error %v ", err)
+		return err
+	}
+	var errs []error
+	for _, unit := range x.units {
+		if x.GracefulShutdown.IsShuttingDown() {
+			break
+		}
+		if !unit.due() {
+			continue
+		}
+		if err = x.pollUnit(router, conn, unit); err != nil {
+			errs = append(errs, err)
+		}
+		unit.done(err, x.Config.UnitBackoff)
+	}
+	return errors.Join(errs...)
+}
+
+// pollUnit 读取一个从机
+func (x *Modbus) pollUnit(router endpointApi.Router, conn *modbusNode.SharedConn, unit *unitPoller) error {
+	var results interface{}
+	var err error
+	if unit.plan != nil {
+		results, err = modbusNode.ReadTags(conn, x.RuleConfig.Logger, unit.UnitId, x.Config.EncodingConfig, x.Config.Retry, unit.plan)
+	} else {
+		results, err = modbusNode.ReadBlocks(conn, x.RuleConfig.Logger, unit.UnitId, x.Config.EncodingConfig, x.Config.Retry, unit.Reads)
+	}
+	if err != nil {
+		x.Printf("poll modbus unit %d error %v ", unit.UnitId, err)
+		return err
+	}
+	metadata := types.NewMetadata()
+	metadata.PutValue(KeyServer, x.Config.Server)
+	metadata.PutValue(KeyUnitId, strconv.Itoa(int(unit.UnitId)))
+	if unit.Name != "" {
+		metadata.PutValue(KeyUnitName, unit.Name)
+	}
+	x.deliver(router, &RequestMessage{data: results, metadata: metadata})
+	return nil
+}
+
+// deliver 交给路由处理，配置了磁盘缓存时处理失败的数据写入缓存，处理成功后重放缓存的数据
+func (x *Modbus) deliver(router endpointApi.Router, request *RequestMessage) {
+	var entry buffer.Entry
+	if x.queue != nil {
+		entry = newEntry(request.GetMsg())
+	}
+	exchange := &endpointApi.Exchange{
+		In:  request,
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+	if x.queue == nil {
+		return
+	}
+	if err := exchange.Out.GetError(); err != nil {
+		if err := x.queue.PushEntry(entry); err != nil {
+			x.Printf("buffer modbus data error %v ", err)
+		}
+		return
+	}
+	_, err := x.queue.ReplayEntries(func(record buffer.Record, entry buffer.Entry) error {
+		if x.GracefulShutdown.IsShuttingDown() {
+			return context.Canceled
+		}
+		metadata := types.BuildMetadata(entry.Metadata)
+		metadata.PutValue(buffer.KeyBufferedAt, strconv.FormatInt(record.Time.UnixMilli(), 10))
+		msg := types.NewMsg(0, entry.Type, types.JSON, metadata, entry.Data)
+		exchange := &endpointApi.Exchange{
+			In:  &RequestMessage{msg: &msg},
+			Out: &ResponseMessage{},
+		}
+		x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+		return exchange.Out.GetError()
+	})
+	if err != nil && err != context.Canceled {
+		x.Printf("replay buffered modbus data error %v ", err)
+	}
+}
+
+// newEntry 复制消息的类型、元数据和负荷用于缓存
+func newEntry(msg *types.RuleMsg) buffer.Entry {
+	metadata := make(map[string]string)
+	for k, v := range msg.Metadata.Values() {
+		metadata[k] = v
+	}
+	return buffer.Entry{Type: msg.Type, Metadata: metadata, Data: msg.GetData()}
+}
+
+// initSharedNode 初始化共享连接，相同 server 和 unitId 的组件共用一个连接
+func (x *Modbus) initSharedNode() error {
+	return x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, false, func() (*modbusNode.SharedConn, error) {
+		conn := modbusNode.AcquireConn(modbusNode.ClientConfig{
+			Server:    x.Config.Server,
+			UnitId:    x.Config.UnitId,
+			Framing:   x.Config.Framing,
+			TcpConfig: x.Config.TcpConfig,
+			RtuConfig: x.Config.RtuConfig,
+		})
+		if _, err := conn.Open(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *modbusNode.SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
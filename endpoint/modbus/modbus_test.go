@@ -0,0 +1,383 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/simonvetter/modbus"
+
+	modbusNode "github.com/rulego/rulego-components-iot/external/modbus"
+	"github.com/rulego/rulego-components-iot/pkg/buffer"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+)
+
+// testHandler 测试用的 Modbus 从站，只提供保持寄存器和线圈
+type testHandler struct {
+	coils   []bool
+	holding []uint16
+	// units 模拟网关后面的从机的保持寄存器，不存在的从机返回网关目标设备无响应
+	units map[uint8][]uint16
+	// requests 每个从机收到的保持寄存器请求数量
+	requests sync.Map
+}
+
+func (h *testHandler) HandleCoils(req *modbus.CoilsRequest) ([]bool, error) {
+	if int(req.Addr)+int(req.Quantity) > len(h.coils) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	return h.coils[req.Addr : req.Addr+req.Quantity], nil
+}
+
+func (h *testHandler) HandleDiscreteInputs(req *modbus.DiscreteInputsRequest) ([]bool, error) {
+	return nil, modbus.ErrIllegalFunction
+}
+
+func (h *testHandler) HandleHoldingRegisters(req *modbus.HoldingRegistersRequest) ([]uint16, error) {
+	if h.units != nil {
+		n, _ := h.requests.LoadOrStore(req.UnitId, new(int32))
+		atomic.AddInt32(n.(*int32), 1)
+		holding, ok := h.units[req.UnitId]
+		if !ok {
+			return nil, modbus.ErrGWTargetFailedToRespond
+		}
+		if int(req.Addr)+int(req.Quantity) > len(holding) {
+			return nil, modbus.ErrIllegalDataAddress
+		}
+		return holding[req.Addr : req.Addr+req.Quantity], nil
+	}
+	if int(req.Addr)+int(req.Quantity) > len(h.holding) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	return h.holding[req.Addr : req.Addr+req.Quantity], nil
+}
+
+func (h *testHandler) HandleInputRegisters(req *modbus.InputRegistersRequest) ([]uint16, error) {
+	return nil, modbus.ErrIllegalFunction
+}
+
+func TestModbusEndpoint(t *testing.T) {
+	t.Run("New", func(t *testing.T) {
+		ep := (&Modbus{}).New().(*Modbus)
+		if ep.Config.Interval != "@every 10s" {
+			t.Errorf("期望默认间隔为 '@every 10s', 实际为 '%s'", ep.Config.Interval)
+		}
+		if ep.Type() != Type {
+			t.Errorf("期望类型为 '%s', 实际为 '%s'", Type, ep.Type())
+		}
+	})
+
+	t.Run("InitInvalid", func(t *testing.T) {
+		config := engine.NewConfig()
+		ep := (&Modbus{}).New().(*Modbus)
+		if err := ep.Init(config, types.Configuration{"server": "tcp://localhost:15022"}); err == nil {
+			t.Fatalf("reads 为空应该返回错误")
+		}
+		ep = (&Modbus{}).New().(*Modbus)
+		err := ep.Init(config, types.Configuration{
+			"server": "tcp://localhost:15022",
+			"reads":  []map[string]interface{}{{"area": "holdingRegister", "address": 0, "quantity": 1, "dataType": "string"}},
+		})
+		if err == nil {
+			t.Fatalf("非法数据类型应该返回错误")
+		}
+	})
+
+	t.Run("Schedule", func(t *testing.T) {
+		reads := []map[string]interface{}{{"area": "holdingRegister", "address": 0, "quantity": 1}}
+		for _, interval := range []string{"5ms", "@every 1ms", "every second"} {
+			ep := &Modbus{}
+			if err := ep.Init(engine.NewConfig(), types.Configuration{"server": "tcp://localhost:15020", "interval": interval, "reads": reads}); err == nil {
+				t.Errorf("期望轮询间隔 '%s' 初始化失败", interval)
+			}
+		}
+	})
+
+	t.Run("Poll", func(t *testing.T) {
+		handler := &testHandler{
+			coils:   []bool{true, false},
+			holding: []uint16{10, 20, 30},
+		}
+		server, err := modbus.NewServer(&modbus.ServerConfiguration{
+			URL:        "tcp://localhost:15022",
+			Timeout:    10 * time.Second,
+			MaxClients: 5,
+		}, handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer server.Stop()
+
+		config := engine.NewConfig()
+		_, err = engine.New("modbus-test01", []byte(`{
+			"ruleChain": {"id": "modbus-test01", "name": "modbus-test01"},
+			"metadata": {"nodes": []}
+		}`), engine.WithConfig(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer engine.Del("modbus-test01")
+
+		ep := (&Modbus{}).New().(*Modbus)
+		err = ep.Init(config, types.Configuration{
+			"server":   "tcp://localhost:15022",
+			"unitId":   1,
+			"interval": "200ms",
+			"reads": []map[string]interface{}{
+				{"name": "status", "area": "coil", "address": 0, "quantity": 2},
+				{"name": "level", "area": "holdingRegister", "address": 0, "quantity": 3},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var count int32
+		var results []modbusNode.ReadResult
+		var metadata *types.Metadata
+		router := impl.NewRouter().From("").To("chain:modbus-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msg := exchange.In.GetMsg()
+			if atomic.AddInt32(&count, 1) == 1 {
+				_ = json.Unmarshal([]byte(msg.GetData()), &results)
+				metadata = msg.Metadata
+			}
+			return true
+		}).End()
+		if _, err = ep.AddRouter(router); err != nil {
+			t.Fatal(err)
+		}
+		if err = ep.Start(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Second)
+		ep.Destroy()
+
+		if atomic.LoadInt32(&count) < 2 {
+			t.Fatalf("期望至少轮询 2 次, 实际为 %d", count)
+		}
+		after := atomic.LoadInt32(&count)
+		time.Sleep(500 * time.Millisecond)
+		if atomic.LoadInt32(&count) != after {
+			t.Errorf("停机后不应该继续轮询")
+		}
+		if len(results) != 2 || results[0].Name != "status" || results[1].Name != "level" {
+			t.Fatalf("轮询结果错误: %+v", results)
+		}
+		if results[0].Values[0] != true || results[1].Values[2] != float64(30) {
+			t.Errorf("轮询结果值错误: %+v", results)
+		}
+		if metadata.GetValue(KeyServer) != "tcp://localhost:15022" || metadata.GetValue(KeyUnitId) != "1" {
+			t.Errorf("元数据错误: %v", metadata.Values())
+		}
+	})
+
+	t.Run("Units", func(t *testing.T) {
+		handler := &testHandler{units: map[uint8][]uint16{1: {11, 12}, 2: {21, 22}}}
+		server, err := modbus.NewServer(&modbus.ServerConfiguration{
+			URL:        "tcp://localhost:15024",
+			Timeout:    10 * time.Second,
+			MaxClients: 5,
+		}, handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer server.Stop()
+
+		config := engine.NewConfig()
+		_, err = engine.New("modbus-test03", []byte(`{
+			"ruleChain": {"id": "modbus-test03", "name": "modbus-test03"},
+			"metadata": {"nodes": []}
+		}`), engine.WithConfig(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer engine.Del("modbus-test03")
+
+		ep := (&Modbus{}).New().(*Modbus)
+		err = ep.Init(config, types.Configuration{
+			"server":      "tcp://localhost:15024",
+			"interval":    "100ms",
+			"unitBackoff": 8,
+			"retry":       map[string]interface{}{"maxRetries": -1},
+			"tags":        []map[string]interface{}{{"name": "level", "address": 0}},
+			"units": []map[string]interface{}{
+				{"unitId": 1, "name": "meter-1"},
+				{"unitId": 2, "tags": []map[string]interface{}{{"name": "flow", "address": 1}}},
+				{"unitId": 3, "name": "offline"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var lock sync.Mutex
+		values := map[string]map[string]interface{}{}
+		names := map[string]string{}
+		router := impl.NewRouter().From("").To("chain:modbus-test03").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msg := exchange.In.GetMsg()
+			var data map[string]interface{}
+			_ = json.Unmarshal([]byte(msg.GetData()), &data)
+			lock.Lock()
+			values[msg.Metadata.GetValue(KeyUnitId)] = data
+			names[msg.Metadata.GetValue(KeyUnitId)] = msg.Metadata.GetValue(KeyUnitName)
+			lock.Unlock()
+			return true
+		}).End()
+		if _, err = ep.AddRouter(router); err != nil {
+			t.Fatal(err)
+		}
+		if err = ep.Start(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Second)
+		ep.Destroy()
+
+		lock.Lock()
+		defer lock.Unlock()
+		if values["1"]["level"] != float64(11) || names["1"] != "meter-1" {
+			t.Errorf("从机 1 的结果错误: %v %v", values["1"], names["1"])
+		}
+		if values["2"]["flow"] != float64(22) || values["2"]["level"] != nil {
+			t.Errorf("从机 2 的结果错误: %v", values["2"])
+		}
+		if _, ok := values["3"]; ok {
+			t.Errorf("离线的从机不应该输出结果")
+		}
+		// 离线的从机按退避跳过轮询，其他从机照常轮询
+		polled := func(unitId uint8) int32 {
+			n, _ := handler.requests.Load(unitId)
+			if n == nil {
+				return 0
+			}
+			return atomic.LoadInt32(n.(*int32))
+		}
+		if polled(1) < 5 || polled(3) == 0 || polled(3) > polled(1)/2 {
+			t.Errorf("期望离线的从机被跳过, 从机 1 请求 %d 次, 从机 3 请求 %d 次", polled(1), polled(3))
+		}
+
+		ep = &Modbus{}
+		err = ep.Init(engine.NewConfig(), types.Configuration{
+			"server": "tcp://localhost:15024",
+			"units":  []map[string]interface{}{{"unitId": 1}, {"unitId": 1}},
+			"reads":  []map[string]interface{}{{"area": "holdingRegister", "address": 0, "quantity": 1}},
+		})
+		if err == nil {
+			t.Errorf("期望重复的从机编号初始化失败")
+		}
+		ep = &Modbus{}
+		err = ep.Init(engine.NewConfig(), types.Configuration{
+			"server": "tcp://localhost:15024",
+			"units":  []map[string]interface{}{{"unitId": 1}},
+		})
+		if err == nil {
+			t.Errorf("期望从机没有读取配置时初始化失败")
+		}
+	})
+
+	t.Run("StoreAndForward", func(t *testing.T) {
+		handler := &testHandler{holding: []uint16{10, 20, 30}}
+		server, err := modbus.NewServer(&modbus.ServerConfiguration{
+			URL:        "tcp://localhost:15023",
+			Timeout:    10 * time.Second,
+			MaxClients: 5,
+		}, handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer server.Stop()
+
+		ep := (&Modbus{}).New().(*Modbus)
+		err = ep.Init(engine.NewConfig(), types.Configuration{
+			"server": "tcp://localhost:15023",
+			"unitId": 1,
+			"tags":   []map[string]interface{}{{"name": "level", "address": 0}},
+			"buffer": map[string]interface{}{"dir": t.TempDir()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ep.Destroy()
+
+		var lock sync.Mutex
+		failing := true
+		var received []*types.RuleMsg
+		router := impl.NewRouter().From("").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			lock.Lock()
+			defer lock.Unlock()
+			if failing {
+				exchange.Out.SetError(errors.New("uplink down"))
+				return false
+			}
+			received = append(received, exchange.In.GetMsg())
+			return true
+		}).End()
+
+		// 处理失败的数据写入缓存
+		for i := 0; i < 2; i++ {
+			handler.holding[0] = uint16(i)
+			if err = ep.poll(router); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if ep.queue.Len() != 2 {
+			t.Fatalf("期望缓存 2 条数据, 实际为 %d", ep.queue.Len())
+		}
+
+		// 恢复后先处理本次数据，再按顺序重放缓存的数据
+		lock.Lock()
+		failing = false
+		lock.Unlock()
+		handler.holding[0] = 2
+		if err = ep.poll(router); err != nil {
+			t.Fatal(err)
+		}
+		if ep.queue.Len() != 0 {
+			t.Fatalf("期望缓存已清空, 实际为 %d", ep.queue.Len())
+		}
+		if len(received) != 3 {
+			t.Fatalf("期望处理 3 条数据, 实际为 %d", len(received))
+		}
+		for i, expected := range []string{`{"level":2}`, `{"level":0}`, `{"level":1}`} {
+			if received[i].GetData() != expected {
+				t.Errorf("第 %d 条数据期望为 %s, 实际为 %s", i, expected, received[i].GetData())
+			}
+		}
+		if received[0].Metadata.GetValue(buffer.KeyBufferedAt) != "" {
+			t.Errorf("实时数据不应该包含缓存时间")
+		}
+		if received[1].Type != MODBUS_DATA_MSG_TYPE || received[1].Metadata.GetValue(KeyServer) != "tcp://localhost:15023" || received[1].Metadata.GetValue(buffer.KeyBufferedAt) == "" {
+			t.Errorf("重放的消息错误: %s %v", received[1].Type, received[1].Metadata.Values())
+		}
+	})
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	modbusNode "github.com/rulego/rulego-components-iot/external/modbus"
+)
+
+// maxBackoffShift 连续失败时跳过的轮询次数最多翻倍的次数
+const maxBackoffShift = 10
+
+// Unit 网关后面的一个从机，多个从机通过同一个 TCP 连接按顺序轮询
+// reads、tags 和 profile 都为空时使用端点的配置，适用于同型号的多台设备
+type Unit struct {
+	// UnitId 从机编号
+	UnitId uint8 `json:"unitId"`
+	// Name 从机名称，写入元数据 unitName
+	Name string `json:"name"`
+	// Reads 该从机读取的数据块
+	Reads []modbusNode.ReadRequest `json:"reads"`
+	// Tags 该从机的寄存器点位映射，配置后忽略 reads
+	Tags []modbusNode.Tag `json:"tags"`
+	// Profile 该从机的设备配置文件名称或者文件路径
+	Profile string `json:"profile"`
+}
+
+// unitPoller 从机的读取计划和失败状态
+type unitPoller struct {
+	Unit
+	// plan 点位读取计划，没有点位为 nil
+	plan *modbusNode.TagPlan
+	// mu 保护失败状态，定时任务可能重叠执行
+	mu sync.Mutex
+	// failures 连续失败次数
+	failures int
+	// skip 剩余跳过的轮询次数
+	skip int
+}
+
+// compileUnits 编译从机列表，没有配置 units 时使用端点的 unitId、reads 和 tags 作为唯一的从机
+func compileUnits(config ModbusConfig) ([]*unitPoller, error) {
+	units := config.Units
+	if len(units) == 0 {
+		units = []Unit{{UnitId: config.UnitId}}
+	}
+	pollers := make([]*unitPoller, 0, len(units))
+	seen := make(map[uint8]bool, len(units))
+	for _, u := range units {
+		if seen[u.UnitId] {
+			return nil, fmt.Errorf("duplicate modbus unit id: %d", u.UnitId)
+		}
+		seen[u.UnitId] = true
+		if len(u.Reads) == 0 && len(u.Tags) == 0 && u.Profile == "" {
+			u.Reads, u.Tags, u.Profile = config.Reads, config.Tags, config.Profile
+		}
+		p, err := compileUnit(u, config.MaxGap)
+		if err != nil {
+			if len(config.Units) == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("unit %d: %w", u.UnitId, err)
+		}
+		pollers = append(pollers, p)
+	}
+	return pollers, nil
+}
+
+// compileUnit 合并设备配置文件、解析点位引用并校验
+func compileUnit(u Unit, maxGap uint16) (*unitPoller, error) {
+	var err error
+	if u.Tags, err = modbusNode.ApplyProfile(u.Profile, u.Tags); err != nil {
+		return nil, err
+	}
+	if len(u.Reads) == 0 && len(u.Tags) == 0 {
+		return nil, errors.New("modbus reads and tags cannot both be empty")
+	}
+	for _, r := range u.Reads {
+		if err = r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if u.Tags, err = modbusNode.ResolveTags(u.Tags); err != nil {
+		return nil, err
+	}
+	if err = modbusNode.ValidateTags(u.Tags); err != nil {
+		return nil, err
+	}
+	p := &unitPoller{Unit: u}
+	if len(u.Tags) > 0 {
+		p.plan = modbusNode.NewTagPlan(u.Tags, maxGap)
+	}
+	return p, nil
+}
+
+// due 本次轮询是否读取该从机，处于退避期间时减少剩余跳过次数
+func (p *unitPoller) due() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.skip > 0 {
+		p.skip--
+		return false
+	}
+	return true
+}
+
+// done 记录读取结果，失败后跳过的轮询次数随连续失败次数翻倍，最多 backoff 次，backoff 为 0 不跳过
+func (p *unitPoller) done(err error, backoff int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.failures, p.skip = 0, 0
+		return
+	}
+	p.failures++
+	if backoff <= 0 {
+		return
+	}
+	shift := p.failures - 1
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	p.skip = min(1<<shift, backoff)
+}
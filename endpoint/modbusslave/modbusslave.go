@@ -0,0 +1,635 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package modbusslave 提供内嵌 Modbus TCP 从站端点
+// 端点对外暴露配置的寄存器表，规则链可以通过 x/modbusSlaveWrite 节点更新寄存器供 SCADA 轮询，
+// 外部主站对线圈和保持寄存器的写入会转换成消息交给规则链处理
+package modbusslave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/simonvetter/modbus"
+
+	modbusNode "github.com/rulego/rulego-components-iot/external/modbus"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/cast"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "modbusSlave"
+
+// MODBUS_WRITE_MSG_TYPE 外部主站写入线圈或者保持寄存器时产生的消息类型
+const MODBUS_WRITE_MSG_TYPE = "MODBUS_WRITE"
+
+// 默认每个数据区的大小
+const DefaultAreaSize = 100
+
+// Endpoint 别名
+type Endpoint = ModbusSlave
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// slaves 运行中的从站，key 为端点 Id，供 x/modbusSlaveWrite 节点查找
+var slaves sync.Map
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+// WriteEvent 外部主站写入事件
+type WriteEvent struct {
+	UnitId uint8 `json:"unitId"`
+	// Area 数据区：coil, holdingRegister
+	Area    string        `json:"area"`
+	Address uint16        `json:"address"`
+	Values  []interface{} `json:"values"`
+	// Tags 写入范围内的点位工程值
+	Tags       map[string]interface{} `json:"tags,omitempty"`
+	ClientAddr string                 `json:"clientAddr"`
+	Timestamp  time.Time              `json:"timestamp"`
+}
+
+type RequestMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	event      WriteEvent
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body, r.err = json.Marshal(r.event)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.event.ClientAddr
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue("unitId", strconv.Itoa(int(r.event.UnitId)))
+		metadata.PutValue("area", r.event.Area)
+		metadata.PutValue("address", strconv.Itoa(int(r.event.Address)))
+		metadata.PutValue("clientAddr", r.event.ClientAddr)
+		ruleMsg := types.NewMsg(0, MODBUS_WRITE_MSG_TYPE, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+type ResponseMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// ModbusSlaveConfig 内嵌 Modbus 从站配置
+type ModbusSlaveConfig struct {
+	// Server 监听地址，eg. tcp://0.0.0.0:502
+	Server string `json:"server" label:"Server" desc:"Listen address, format: tcp://host:port" required:"true"`
+	// UnitId 从机编号，0 表示响应所有从机编号
+	UnitId uint8 `json:"unitId" label:"Unit ID" desc:"Slave unit ID, 0 responds to any unit ID"`
+	// MaxClients 最大客户端连接数
+	MaxClients uint `json:"maxClients" label:"Max Clients" desc:"Max concurrent client connections"`
+	// Timeout 客户端空闲超时，单位秒
+	Timeout int `json:"timeout" label:"Timeout" desc:"Idle client timeout in seconds"`
+	// Coils 线圈数量，地址从 0 开始
+	Coils int `json:"coils" label:"Coils" desc:"Number of coils, addresses start at 0"`
+	// DiscreteInputs 离散输入数量
+	DiscreteInputs int `json:"discreteInputs" label:"Discrete Inputs" desc:"Number of discrete inputs"`
+	// HoldingRegisters 保持寄存器数量
+	HoldingRegisters int `json:"holdingRegisters" label:"Holding Registers" desc:"Number of holding registers"`
+	// InputRegisters 输入寄存器数量
+	InputRegisters int `json:"inputRegisters" label:"Input Registers" desc:"Number of input registers"`
+	// Tags 寄存器点位映射，可以按点位名称更新寄存器，写入事件中会包含受影响点位的工程值
+	Tags []modbusNode.Tag `json:"tags" label:"Tags" desc:"Register mappings, allows updating registers by tag name and adds tag values to write events"`
+	// Values 点位初始值，点位名称->工程值
+	Values         map[string]interface{}    `json:"values" label:"Values" desc:"Initial tag values, tag name to engineering value"`
+	EncodingConfig modbusNode.EncodingConfig `json:"encodingConfig" label:"Encoding Config" desc:"Default byte order of tags"`
+}
+
+// ModbusSlave 内嵌 Modbus TCP 从站端点
+type ModbusSlave struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	// 从站配置
+	Config ModbusSlaveConfig
+	// 路由实例
+	Router endpointApi.Router
+	// 服务器实例
+	srv *modbus.ModbusServer
+	// 点位名称->点位
+	tags map[string]modbusNode.Tag
+	// mu 保护服务器实例
+	mu sync.Mutex
+	// regLock 保护寄存器表
+	regLock        sync.RWMutex
+	coils          []bool
+	discreteInputs []bool
+	holding        []uint16
+	inputs         []uint16
+}
+
+// Type 组件类型
+func (x *ModbusSlave) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *ModbusSlave) New() types.Node {
+	return &ModbusSlave{
+		Config: ModbusSlaveConfig{
+			Server:           "tcp://0.0.0.0:502",
+			MaxClients:       10,
+			Timeout:          30,
+			Coils:            DefaultAreaSize,
+			DiscreteInputs:   DefaultAreaSize,
+			HoldingRegisters: DefaultAreaSize,
+			InputRegisters:   DefaultAreaSize,
+			EncodingConfig: modbusNode.EncodingConfig{
+				Endianness: uint(modbusNode.DefaultEndianness),
+				WordOrder:  uint(modbusNode.DefaultWordOrder),
+			},
+		},
+	}
+}
+
+// Init 初始化
+func (x *ModbusSlave) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	x.Logger = ruleConfig.Logger
+	for _, size := range []int{x.Config.Coils, x.Config.DiscreteInputs, x.Config.HoldingRegisters, x.Config.InputRegisters} {
+		if size < 0 || size > 65536 {
+			return fmt.Errorf("invalid modbus area size: %d", size)
+		}
+	}
+	if err = modbusNode.ValidateTags(x.Config.Tags); err != nil {
+		return err
+	}
+	x.tags = make(map[string]modbusNode.Tag, len(x.Config.Tags))
+	for _, t := range x.Config.Tags {
+		x.tags[t.Name] = t
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (x *ModbusSlave) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *ModbusSlave) Desc() string {
+	return "Embedded Modbus TCP slave endpoint exposing a register map that rule chains can update and external masters can write"
+}
+
+// Category returns the component category
+func (x *ModbusSlave) Category() string {
+	return "endpoint"
+}
+
+func (x *ModbusSlave) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Embedded Modbus TCP slave endpoint exposing a register map that rule chains can update and external masters can write",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+func (x *ModbusSlave) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	slaves.CompareAndDelete(x.Id(), x)
+	var err error
+	if x.srv != nil {
+		err = x.srv.Stop()
+		x.srv = nil
+	}
+	return err
+}
+
+func (x *ModbusSlave) Id() string {
+	return x.Config.Server
+}
+
+func (x *ModbusSlave) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Router = router
+	return router.GetId(), nil
+}
+
+func (x *ModbusSlave) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	x.Router = nil
+	return nil
+}
+
+func (x *ModbusSlave) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.srv != nil {
+		return nil
+	}
+	x.regLock.Lock()
+	x.coils = make([]bool, x.Config.Coils)
+	x.discreteInputs = make([]bool, x.Config.DiscreteInputs)
+	x.holding = make([]uint16, x.Config.HoldingRegisters)
+	x.inputs = make([]uint16, x.Config.InputRegisters)
+	x.regLock.Unlock()
+	for name, value := range x.Config.Values {
+		if err := x.SetTag(name, value); err != nil {
+			return err
+		}
+	}
+
+	srv, err := modbus.NewServer(&modbus.ServerConfiguration{
+		URL:        x.Config.Server,
+		Timeout:    time.Duration(x.Config.Timeout) * time.Second,
+		MaxClients: x.Config.MaxClients,
+	}, &requestHandler{x: x})
+	if err != nil {
+		return err
+	}
+	if err = srv.Start(); err != nil {
+		return err
+	}
+	x.srv = srv
+	slaves.Store(x.Id(), x)
+	x.Printf("started Modbus slave on %s", x.Id())
+	return nil
+}
+
+func (x *ModbusSlave) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// SetTag 按点位名称更新寄存器
+func (x *ModbusSlave) SetTag(name string, value interface{}) error {
+	t, ok := x.tags[name]
+	if !ok {
+		return fmt.Errorf("modbus tag not found: %s", name)
+	}
+	raw, err := t.Encode(value, x.Config.EncodingConfig)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		values = append(values, uint16(raw[i])<<8|uint16(raw[i+1]))
+	}
+	return x.SetRegisters(tagArea(t), t.Address, values)
+}
+
+// GetTag 获取点位当前工程值
+func (x *ModbusSlave) GetTag(name string) (interface{}, error) {
+	t, ok := x.tags[name]
+	if !ok {
+		return nil, fmt.Errorf("modbus tag not found: %s", name)
+	}
+	width, _ := t.Width()
+	x.regLock.RLock()
+	defer x.regLock.RUnlock()
+	regs := x.holding
+	if tagArea(t) == modbusNode.AreaInputRegister {
+		regs = x.inputs
+	}
+	if int(t.Address)+int(width) > len(regs) {
+		return nil, fmt.Errorf("modbus tag %s out of range", name)
+	}
+	return t.Decode(registerBytes(regs[int(t.Address):int(t.Address)+int(width)]), x.Config.EncodingConfig)
+}
+
+// SetRegisters 从 address 开始更新数据区，线圈和离散输入的值转换为 bool，寄存器的值转换为 uint16
+func (x *ModbusSlave) SetRegisters(area string, address uint16, values []interface{}) error {
+	x.regLock.Lock()
+	defer x.regLock.Unlock()
+	switch area {
+	case modbusNode.AreaCoil, modbusNode.AreaDiscreteInput:
+		bits := x.coils
+		if area == modbusNode.AreaDiscreteInput {
+			bits = x.discreteInputs
+		}
+		if int(address)+len(values) > len(bits) {
+			return fmt.Errorf("modbus %s address %d out of range", area, address)
+		}
+		for i, v := range values {
+			bits[int(address)+i] = cast.ToBool(v)
+		}
+	case modbusNode.AreaHoldingRegister, modbusNode.AreaInputRegister:
+		regs := x.holding
+		if area == modbusNode.AreaInputRegister {
+			regs = x.inputs
+		}
+		if int(address)+len(values) > len(regs) {
+			return fmt.Errorf("modbus %s address %d out of range", area, address)
+		}
+		for i, v := range values {
+			regs[int(address)+i] = uint16(cast.ToInt64(v))
+		}
+	default:
+		return fmt.Errorf("unsupported modbus area: %s", area)
+	}
+	return nil
+}
+
+// onWrite 外部主站写入后转换成消息交给路由处理
+func (x *ModbusSlave) onWrite(event WriteEvent) {
+	x.RLock()
+	router := x.Router
+	x.RUnlock()
+	if router == nil {
+		return
+	}
+	exchange := &endpointApi.Exchange{
+		In:  &RequestMessage{event: event},
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(context.Background(), router, exchange)
+}
+
+// writtenTags 返回写入范围内保持寄存器点位的工程值，调用方需持有 regLock
+func (x *ModbusSlave) writtenTags(address, quantity uint16) map[string]interface{} {
+	var values map[string]interface{}
+	for _, t := range x.Config.Tags {
+		if tagArea(t) != modbusNode.AreaHoldingRegister {
+			continue
+		}
+		width, _ := t.Width()
+		end := int(t.Address) + int(width)
+		if end <= int(address) || int(t.Address) >= int(address)+int(quantity) || end > len(x.holding) {
+			continue
+		}
+		v, err := t.Decode(registerBytes(x.holding[t.Address:end]), x.Config.EncodingConfig)
+		if err != nil {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]interface{})
+		}
+		values[t.Name] = v
+	}
+	return values
+}
+
+// acceptUnit 是否响应该从机编号
+func (x *ModbusSlave) acceptUnit(unitId uint8) bool {
+	return x.Config.UnitId == 0 || x.Config.UnitId == unitId
+}
+
+// requestHandler 处理主站请求
+type requestHandler struct {
+	x *ModbusSlave
+}
+
+func (h *requestHandler) HandleCoils(req *modbus.CoilsRequest) ([]bool, error) {
+	x := h.x
+	if !x.acceptUnit(req.UnitId) {
+		return nil, modbus.ErrGWTargetFailedToRespond
+	}
+	if !req.IsWrite {
+		x.regLock.RLock()
+		defer x.regLock.RUnlock()
+		return readBits(x.coils, req.Addr, req.Quantity)
+	}
+	x.regLock.Lock()
+	if int(req.Addr)+int(req.Quantity) > len(x.coils) {
+		x.regLock.Unlock()
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	copy(x.coils[req.Addr:], req.Args)
+	x.regLock.Unlock()
+	values := make([]interface{}, 0, len(req.Args))
+	for _, v := range req.Args {
+		values = append(values, v)
+	}
+	x.onWrite(WriteEvent{
+		UnitId:     req.UnitId,
+		Area:       modbusNode.AreaCoil,
+		Address:    req.Addr,
+		Values:     values,
+		ClientAddr: req.ClientAddr,
+		Timestamp:  time.Now(),
+	})
+	return nil, nil
+}
+
+func (h *requestHandler) HandleDiscreteInputs(req *modbus.DiscreteInputsRequest) ([]bool, error) {
+	x := h.x
+	if !x.acceptUnit(req.UnitId) {
+		return nil, modbus.ErrGWTargetFailedToRespond
+	}
+	x.regLock.RLock()
+	defer x.regLock.RUnlock()
+	return readBits(x.discreteInputs, req.Addr, req.Quantity)
+}
+
+func (h *requestHandler) HandleHoldingRegisters(req *modbus.HoldingRegistersRequest) ([]uint16, error) {
+	x := h.x
+	if !x.acceptUnit(req.UnitId) {
+		return nil, modbus.ErrGWTargetFailedToRespond
+	}
+	if !req.IsWrite {
+		x.regLock.RLock()
+		defer x.regLock.RUnlock()
+		return readRegisters(x.holding, req.Addr, req.Quantity)
+	}
+	x.regLock.Lock()
+	if int(req.Addr)+int(req.Quantity) > len(x.holding) {
+		x.regLock.Unlock()
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	copy(x.holding[req.Addr:], req.Args)
+	tags := x.writtenTags(req.Addr, req.Quantity)
+	x.regLock.Unlock()
+	values := make([]interface{}, 0, len(req.Args))
+	for _, v := range req.Args {
+		values = append(values, v)
+	}
+	x.onWrite(WriteEvent{
+		UnitId:     req.UnitId,
+		Area:       modbusNode.AreaHoldingRegister,
+		Address:    req.Addr,
+		Values:     values,
+		Tags:       tags,
+		ClientAddr: req.ClientAddr,
+		Timestamp:  time.Now(),
+	})
+	return nil, nil
+}
+
+func (h *requestHandler) HandleInputRegisters(req *modbus.InputRegistersRequest) ([]uint16, error) {
+	x := h.x
+	if !x.acceptUnit(req.UnitId) {
+		return nil, modbus.ErrGWTargetFailedToRespond
+	}
+	x.regLock.RLock()
+	defer x.regLock.RUnlock()
+	return readRegisters(x.inputs, req.Addr, req.Quantity)
+}
+
+func readBits(bits []bool, addr, quantity uint16) ([]bool, error) {
+	if int(addr)+int(quantity) > len(bits) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	return append([]bool(nil), bits[int(addr):int(addr)+int(quantity)]...), nil
+}
+
+func readRegisters(regs []uint16, addr, quantity uint16) ([]uint16, error) {
+	if int(addr)+int(quantity) > len(regs) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	return append([]uint16(nil), regs[int(addr):int(addr)+int(quantity)]...), nil
+}
+
+// registerBytes 把寄存器转换为线上字节顺序
+func registerBytes(regs []uint16) []byte {
+	b := make([]byte, 0, len(regs)*2)
+	for _, r := range regs {
+		b = append(b, byte(r>>8), byte(r))
+	}
+	return b
+}
+
+// tagArea 点位数据区，默认保持寄存器
+func tagArea(t modbusNode.Tag) string {
+	if t.Area == "" {
+		return modbusNode.AreaHoldingRegister
+	}
+	return t.Area
+}
+
+// lookupSlave 根据端点 Id 查找运行中的从站
+func lookupSlave(id string) (*ModbusSlave, bool) {
+	if v, ok := slaves.Load(id); ok {
+		return v.(*ModbusSlave), true
+	}
+	return nil, false
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbusslave
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/simonvetter/modbus"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestModbusSlaveEndpoint(t *testing.T) {
+	ep := (&ModbusSlave{}).New().(*ModbusSlave)
+	assert.Equal(t, Type, ep.Type())
+
+	config := engine.NewConfig()
+	_, err := engine.New("modbus-slave-test01", []byte(`{
+		"ruleChain": {"id": "modbus-slave-test01", "name": "modbus-slave-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("modbus-slave-test01")
+
+	err = ep.Init(config, types.Configuration{
+		"server":           "tcp://localhost:15024",
+		"unitId":           1,
+		"holdingRegisters": 10,
+		"inputRegisters":   10,
+		"tags": []map[string]interface{}{
+			{"name": "setpoint", "address": 0, "dataType": "float32"},
+			{"name": "temperature", "area": "inputRegister", "address": 0, "dataType": "int16", "scale": 0.1},
+		},
+		"values": map[string]interface{}{"setpoint": 20.5},
+	})
+	assert.Nil(t, err)
+
+	events := make(chan WriteEvent, 1)
+	router := impl.NewRouter().From("").To("chain:modbus-slave-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		assert.Equal(t, MODBUS_WRITE_MSG_TYPE, msg.Type)
+		var event WriteEvent
+		_ = json.Unmarshal([]byte(msg.GetData()), &event)
+		events <- event
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	v, err := ep.GetTag("setpoint")
+	assert.Nil(t, err)
+	assert.Equal(t, float32(20.5), v)
+
+	// 规则链更新输入寄存器
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	node, err := test.CreateAndInitNode("x/modbusSlaveWrite", types.Configuration{
+		"server": ep.Id(),
+	}, Registry)
+	assert.Nil(t, err)
+	test.NodeOnMsg(t, node, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `{"temperature": 36.5}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "NOT_FOUND",
+			Data:       `{"notExist": 1}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "NOT_FOUND" {
+			assert.Equal(t, types.Failure, relationType)
+		} else {
+			assert.Equal(t, types.Success, relationType)
+		}
+	})
+
+	client, err := modbus.NewClient(&modbus.ClientConfiguration{URL: "tcp://localhost:15024", Timeout: time.Second})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Open())
+	defer client.Close()
+	_ = client.SetUnitId(1)
+
+	regs, err := client.ReadRegisters(0, 1, modbus.INPUT_REGISTER)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(365), regs[0])
+
+	// 外部主站写入保持寄存器
+	assert.Nil(t, client.WriteFloat32(0, 42.5))
+	select {
+	case event := <-events:
+		assert.Equal(t, "holdingRegister", event.Area)
+		assert.Equal(t, uint16(0), event.Address)
+		assert.Equal(t, 2, len(event.Values))
+		assert.Equal(t, 42.5, event.Tags["setpoint"])
+	case <-time.After(time.Second):
+		t.Fatal("write event not received")
+	}
+
+	// 其他从机编号不响应
+	_ = client.SetUnitId(2)
+	_, err = client.ReadRegisters(0, 1, modbus.HOLDING_REGISTER)
+	assert.NotNil(t, err)
+}
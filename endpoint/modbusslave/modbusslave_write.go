@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbusslave
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteNodeConfiguration 节点配置
+type WriteNodeConfiguration struct {
+	// Server 内嵌从站端点 Id，与 endpoint/modbusSlave 的 server 配置一致，eg. tcp://0.0.0.0:502
+	Server string `json:"server" label:"Server" desc:"Embedded Modbus slave id, same as the server of endpoint/modbusSlave" required:"true"`
+}
+
+// RegisterWrite 按地址更新的数据
+type RegisterWrite struct {
+	// Area 数据区：coil, discreteInput, holdingRegister, inputRegister
+	Area    string        `json:"area"`
+	Address uint16        `json:"address"`
+	Values  []interface{} `json:"values"`
+}
+
+// WriteNode 更新内嵌 Modbus 从站的寄存器
+// 消息负荷 msg.Data 为点位名称->工程值的对象：{"temperature": 25.5, "running": 1}
+// 或者按地址更新的数组：
+//
+//	[
+//	  {"area": "inputRegister", "address": 0, "values": [100, 200]},
+//	  {"area": "discreteInput", "address": 0, "values": [true, false]}
+//	]
+//
+// 更新成功，流转到`Success`链，否则流程转到`Failure`链
+type WriteNode struct {
+	//节点配置
+	Config WriteNodeConfiguration
+}
+
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteNodeConfiguration{
+			Server: "tcp://0.0.0.0:502",
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/modbusSlaveWrite"
+}
+
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	return maps.Map2Struct(configuration, &x.Config)
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	slave, ok := lookupSlave(x.Config.Server)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("modbus slave not found: %s", x.Config.Server))
+		return
+	}
+	data := strings.TrimSpace(msg.GetData())
+	if strings.HasPrefix(data, "[") {
+		var writes []RegisterWrite
+		if err := json.Unmarshal([]byte(data), &writes); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		for _, w := range writes {
+			if err := slave.SetRegisters(w.Area, w.Address, w.Values); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+		}
+	} else {
+		values := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(data), &values); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		for name, v := range values {
+			if err := slave.SetTag(name, v); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+		}
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+func (x *WriteNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Update registers of the embedded Modbus slave endpoint. Routes to Success/Failure"
+}
@@ -0,0 +1,491 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mtconnect 提供 MTConnect 代理轮询端点
+// 端点启动后请求代理的 current 获取所有数据项的当前值和下一个序号，之后按 interval 从该序号开始请求 sample，
+// 观测值按设备转换为数据项->值的消息交给路由处理。代理重启（instanceId 变化）或者序号超出缓冲区时重新请求 current
+package mtconnect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/rulego/rulego-components-iot/pkg/poll"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "mtconnect"
+const MTCONNECT_DATA_MSG_TYPE = "MTCONNECT_DATA"
+
+// 元数据key
+const (
+	KeyServer     = "server"
+	KeyDevice     = "device"
+	KeyInstanceId = "instanceId"
+	KeySequence   = "sequence"
+	// KeyRequest 产生消息的请求：current 或者 sample
+	KeyRequest = "request"
+)
+
+// 消息负荷的 key
+const (
+	KeyByDataItemId = "dataItemId"
+	KeyByName       = "name"
+)
+
+// maxSamplesPerTick 每次轮询最多连续请求 sample 的次数，追赶积压的观测值
+const maxSamplesPerTick = 10
+
+// Endpoint 别名
+type Endpoint = MTConnect
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	// data 数据项->值
+	data       map[string]interface{}
+	metadata   *types.Metadata
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *RequestMessage) Body() []byte {
+	b, err := json.Marshal(r.data)
+	if err != nil {
+		log.Println(err)
+	}
+	return b
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := r.metadata
+		if metadata == nil {
+			metadata = types.NewMetadata()
+		}
+		ruleMsg := types.NewMsg(0, MTCONNECT_DATA_MSG_TYPE, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+type ResponseMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		ruleMsg := types.NewMsg(0, MTCONNECT_DATA_MSG_TYPE, types.JSON, types.NewMetadata(), string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// MTConnectConfig MTConnect 轮询配置
+type MTConnectConfig struct {
+	// Server 代理地址，eg. http://agent:5000
+	Server string `json:"server" label:"Server" desc:"MTConnect agent address, eg. http://agent:5000" required:"true"`
+	// Device 设备名称或者 uuid，为空请求代理的所有设备
+	Device string `json:"device" label:"Device" desc:"Device name or uuid, empty requests all devices of the agent"`
+	// Path XPath 过滤，eg. //DataItem[@category="SAMPLE"]
+	Path string `json:"path" label:"Path" desc:"XPath filter of data items, eg. //DataItem[@category=\"SAMPLE\"]"`
+	// Interval 轮询间隔，支持 cron 表达式或者固定周期
+	// example: @every 1s, 1s
+	Interval string `json:"interval" label:"Interval" desc:"Poll interval, supports cron expression or fixed period, e.g. @every 1s, 1s"`
+	// Count 每次 sample 请求的最大观测值数量
+	Count int `json:"count" label:"Count" desc:"Max observations per sample request"`
+	// Key 消息负荷的 key：dataItemId 或者 name，数据项没有名称时使用 dataItemId
+	Key string `json:"key" label:"Key" desc:"Key of the message payload: dataItemId or name, falls back to dataItemId when the data item has no name" component:"{\"type\":\"select\",\"options\":[{\"label\":\"dataItemId\",\"value\":\"dataItemId\"},{\"label\":\"name\",\"value\":\"name\"}]}"`
+	// Timeout 请求超时，单位秒
+	Timeout int `json:"timeout" label:"Timeout" desc:"Request timeout in seconds"`
+}
+
+// MTConnect MTConnect 代理轮询端点
+// 每个设备每次轮询产生一个消息，消息负荷为数据项->值，同一个数据项有多个观测值时取序号最大的值：
+//
+//	{"Xact": -0.5, "execution": "ACTIVE", "system": "normal", "Sspeed": null}
+//
+// 数值转换为数字，UNAVAILABLE 为 null，条件为 normal、warning、fault。
+// 消息类型为 MTCONNECT_DATA，元数据包含 server、device、instanceId、sequence（下一个序号）和 request
+type MTConnect struct {
+	impl.BaseEndpoint
+	// GracefulShutdown 提供优雅停机功能
+	base.GracefulShutdown
+	// Pauser 暂停和恢复轮询，见 poll.Pausable
+	poll.Pauser
+	RuleConfig types.Config
+	Config     MTConnectConfig
+	// 路由实例
+	Router endpointApi.Router
+	agent  *Agent
+	// 定时任务实例
+	cronTask *cron.Cron
+	// 定时任务id
+	taskId cron.EntryID
+	// cronLock 保护定时任务
+	cronLock sync.Mutex
+	// pollLock 保证轮询按顺序执行，保护 instanceId、next 和 synced
+	pollLock   sync.Mutex
+	instanceId uint64
+	next       uint64
+	synced     bool
+}
+
+// Type 组件类型
+func (x *MTConnect) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *MTConnect) New() types.Node {
+	return &MTConnect{
+		Config: MTConnectConfig{
+			Server:   "http://127.0.0.1:5000",
+			Interval: "@every 1s",
+			Count:    1000,
+			Key:      KeyByDataItemId,
+			Timeout:  10,
+		},
+	}
+}
+
+// Init 初始化
+func (x *MTConnect) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Server == "" {
+		return errors.New("mtconnect agent address cannot be empty")
+	}
+	if x.Config.Key != KeyByDataItemId && x.Config.Key != KeyByName {
+		return errors.New("mtconnect key must be dataItemId or name")
+	}
+	if x.Config.Count <= 0 {
+		x.Config.Count = 1000
+	}
+	timeout := time.Duration(x.Config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	x.agent = &Agent{
+		Server: x.Config.Server,
+		Device: x.Config.Device,
+		Path:   x.Config.Path,
+		Client: &http.Client{Timeout: timeout},
+	}
+	x.RuleConfig = ruleConfig
+	x.GracefulShutdown.InitGracefulShutdown(x.RuleConfig.Logger, base.DefaultShutdownTimeout)
+	return nil
+}
+
+// Destroy 销毁
+func (x *MTConnect) Destroy() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+// Desc returns the component description
+func (x *MTConnect) Desc() string {
+	return "MTConnect endpoint polling an agent's current and sample requests with sequence tracking and routing data item values"
+}
+
+// Category returns the component category
+func (x *MTConnect) Category() string {
+	return "endpoint"
+}
+
+func (x *MTConnect) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "MTConnect endpoint polling an agent's current and sample requests with sequence tracking and routing data item values",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+// GracefulStop 为 MTConnect 端点提供优雅停机
+func (x *MTConnect) GracefulStop() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+func (x *MTConnect) Close() error {
+	x.cronLock.Lock()
+	if x.taskId != 0 && x.cronTask != nil {
+		x.cronTask.Remove(x.taskId)
+	}
+	if x.cronTask != nil {
+		<-x.cronTask.Stop().Done()
+		x.cronTask = nil
+	}
+	x.cronLock.Unlock()
+	return nil
+}
+
+func (x *MTConnect) Id() string {
+	return x.Config.Server
+}
+
+func (x *MTConnect) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.Router = router
+	poll.Register(router.GetId(), x)
+	return router.GetId(), nil
+}
+
+func (x *MTConnect) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		poll.Unregister(x.Router.GetId(), x)
+	}
+	x.Router = nil
+	return nil
+}
+
+func (x *MTConnect) Start() error {
+	x.cronLock.Lock()
+	defer x.cronLock.Unlock()
+	if x.cronTask != nil {
+		x.cronTask.Stop()
+	}
+	x.cronTask = cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger), cron.SkipIfStillRunning(cron.DefaultLogger)), cron.WithLogger(cron.DefaultLogger))
+	eid, err := x.cronTask.AddFunc(schedule(x.Config.Interval), x.onTick)
+	if err != nil {
+		return err
+	}
+	x.taskId = eid
+	x.cronTask.Start()
+	return nil
+}
+
+// onTick 定时轮询代理
+func (x *MTConnect) onTick() {
+	// 暂停期间不读取设备
+	if x.Router != nil && !x.Paused() {
+		_ = x.poll(x.Router)
+	}
+}
+
+func (x *MTConnect) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// poll 没有同步序号时请求 current，否则从下一个序号开始请求 sample，直到追上代理的最新序号
+func (x *MTConnect) poll(router endpointApi.Router) error {
+	x.GracefulShutdown.IncrementActiveOperations()
+	defer x.GracefulShutdown.DecrementActiveOperations()
+	if x.GracefulShutdown.IsShuttingDown() {
+		return nil
+	}
+	x.pollLock.Lock()
+	defer x.pollLock.Unlock()
+	ctx := x.GracefulShutdown.GetShutdownContext()
+	if !x.synced {
+		return x.current(ctx, router)
+	}
+	for i := 0; i < maxSamplesPerTick; i++ {
+		streams, err := x.agent.Sample(ctx, x.next, x.Config.Count)
+		if IsOutOfRange(err) {
+			x.Printf("mtconnect sequence %d out of range, resync from current", x.next)
+			return x.current(ctx, router)
+		}
+		if err != nil {
+			x.Printf("poll mtconnect sample error %v ", err)
+			return err
+		}
+		if streams.Header.InstanceId != x.instanceId {
+			x.Printf("mtconnect agent %s restarted, instance %d -> %d", x.Config.Server, x.instanceId, streams.Header.InstanceId)
+			return x.current(ctx, router)
+		}
+		x.next = streams.Header.NextSequence
+		x.process(router, streams, "sample")
+		if streams.Header.NextSequence > streams.Header.LastSequence {
+			break
+		}
+	}
+	return nil
+}
+
+// current 请求当前值并同步序号
+func (x *MTConnect) current(ctx context.Context, router endpointApi.Router) error {
+	x.synced = false
+	streams, err := x.agent.Current(ctx)
+	if err != nil {
+		x.Printf("poll mtconnect current error %v ", err)
+		return err
+	}
+	x.instanceId, x.next, x.synced = streams.Header.InstanceId, streams.Header.NextSequence, true
+	x.process(router, streams, "current")
+	return nil
+}
+
+// process 按设备把观测值转换为消息并交给路由处理
+func (x *MTConnect) process(router endpointApi.Router, streams *Streams, request string) {
+	var devices []string
+	data := make(map[string]map[string]interface{})
+	sequences := make(map[string]uint64)
+	for _, o := range streams.Observations {
+		key := o.DataItemId
+		if x.Config.Key == KeyByName && o.Name != "" {
+			key = o.Name
+		}
+		values, ok := data[o.Device]
+		if !ok {
+			values = make(map[string]interface{})
+			data[o.Device] = values
+			devices = append(devices, o.Device)
+		}
+		if seq, ok := sequences[o.Device+"\x00"+key]; ok && seq > o.Sequence {
+			continue
+		}
+		sequences[o.Device+"\x00"+key] = o.Sequence
+		values[key] = o.Value
+	}
+	for _, device := range devices {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyServer, x.Config.Server)
+		metadata.PutValue(KeyDevice, device)
+		metadata.PutValue(KeyInstanceId, strconv.FormatUint(streams.Header.InstanceId, 10))
+		metadata.PutValue(KeySequence, strconv.FormatUint(streams.Header.NextSequence, 10))
+		metadata.PutValue(KeyRequest, request)
+		exchange := &endpointApi.Exchange{
+			In:  &RequestMessage{data: data[device], metadata: metadata},
+			Out: &ResponseMessage{},
+		}
+		x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+	}
+}
+
+// schedule 把固定周期转换为 cron 表达式，eg. 10s -> @every 10s
+func schedule(interval string) string {
+	if _, err := time.ParseDuration(interval); err == nil {
+		return "@every " + interval
+	}
+	return interval
+}
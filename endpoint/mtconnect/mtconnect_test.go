@@ -0,0 +1,136 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtconnect
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMTConnectEndpoint(t *testing.T) {
+	agent := newTestAgent()
+	defer agent.Close()
+	agent.add(1, 2, 3)
+
+	config := engine.NewConfig()
+	_, err := engine.New("mtconnect-test01", []byte(`{
+		"ruleChain": {"id": "mtconnect-test01", "name": "mtconnect-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("mtconnect-test01")
+
+	ep := (&MTConnect{}).New().(*MTConnect)
+	assert.Equal(t, Type, ep.Type())
+	assert.NotNil(t, ep.Init(config, types.Configuration{"server": agent.URL, "key": "type"}))
+	ep = (&MTConnect{}).New().(*MTConnect)
+	err = ep.Init(config, types.Configuration{
+		"server":   agent.URL,
+		"interval": "1s",
+		"count":    2,
+		"key":      "name",
+	})
+	assert.Nil(t, err)
+
+	var lock sync.Mutex
+	var messages []map[string]interface{}
+	var metadata []*types.Metadata
+	router := impl.NewRouter().From("").To("chain:mtconnect-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		data := make(map[string]interface{})
+		_ = json.Unmarshal([]byte(msg.GetData()), &data)
+		lock.Lock()
+		messages = append(messages, data)
+		metadata = append(metadata, msg.Metadata)
+		lock.Unlock()
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(router)
+	assert.NotNil(t, err)
+
+	last := func() (map[string]interface{}, *types.Metadata) {
+		lock.Lock()
+		defer lock.Unlock()
+		if len(messages) == 0 {
+			return nil, nil
+		}
+		return messages[len(messages)-1], metadata[len(metadata)-1]
+	}
+	count := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(messages)
+	}
+
+	// 第一次轮询请求 current
+	assert.Nil(t, ep.poll(router))
+	data, md := last()
+	assert.Equal(t, map[string]interface{}{"Xact": 3.0}, data)
+	assert.Equal(t, "current", md.GetValue(KeyRequest))
+	assert.Equal(t, "VMC-3Axis", md.GetValue(KeyDevice))
+	assert.Equal(t, "1", md.GetValue(KeyInstanceId))
+	assert.Equal(t, "4", md.GetValue(KeySequence))
+
+	// 没有新的观测值时不产生消息
+	assert.Nil(t, ep.poll(router))
+	assert.Equal(t, 1, count())
+
+	// 每次请求 2 个，连续请求直到追上最新序号，同一个数据项取序号最大的值
+	agent.add(4, 5, 6, 7, 8)
+	assert.Nil(t, ep.poll(router))
+	assert.Equal(t, 4, count())
+	data, md = last()
+	assert.Equal(t, map[string]interface{}{"Xact": 8.0}, data)
+	assert.Equal(t, "sample", md.GetValue(KeyRequest))
+	assert.Equal(t, "9", md.GetValue(KeySequence))
+
+	// 序号超出缓冲区时重新请求 current
+	agent.add(9, 10, 11)
+	agent.discard(9)
+	assert.Nil(t, ep.poll(router))
+	data, md = last()
+	assert.Equal(t, map[string]interface{}{"Xact": 11.0}, data)
+	assert.Equal(t, "current", md.GetValue(KeyRequest))
+
+	// 代理重启
+	agent.restart(100)
+	assert.Nil(t, ep.poll(router))
+	data, md = last()
+	assert.Equal(t, map[string]interface{}{"Xact": 100.0}, data)
+	assert.Equal(t, "current", md.GetValue(KeyRequest))
+	assert.Equal(t, "2", md.GetValue(KeyInstanceId))
+	assert.Equal(t, "2", md.GetValue(KeySequence))
+
+	// 定时轮询
+	assert.Nil(t, ep.Start())
+	agent.add(101)
+	time.Sleep(time.Millisecond * 1500)
+	ep.Destroy()
+	data, md = last()
+	assert.Equal(t, map[string]interface{}{"Xact": 101.0}, data)
+	assert.Equal(t, "sample", md.GetValue(KeyRequest))
+}
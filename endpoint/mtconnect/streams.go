@@ -0,0 +1,258 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtconnect
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Unavailable 数据项不可用时的值
+const Unavailable = "UNAVAILABLE"
+
+// 观测值的类别
+const (
+	CategorySample    = "SAMPLE"
+	CategoryEvent     = "EVENT"
+	CategoryCondition = "CONDITION"
+)
+
+// Header MTConnectStreams 的头
+type Header struct {
+	InstanceId    uint64 `xml:"instanceId,attr"`
+	Sender        string `xml:"sender,attr"`
+	Version       string `xml:"version,attr"`
+	CreationTime  string `xml:"creationTime,attr"`
+	BufferSize    uint64 `xml:"bufferSize,attr"`
+	NextSequence  uint64 `xml:"nextSequence,attr"`
+	FirstSequence uint64 `xml:"firstSequence,attr"`
+	LastSequence  uint64 `xml:"lastSequence,attr"`
+}
+
+// Observation 观测值
+type Observation struct {
+	Device    string
+	Component string
+	Category  string
+	// Type 元素名称，eg. Position、Execution，条件为 Normal、Warning、Fault、Unavailable
+	Type       string
+	DataItemId string
+	Name       string
+	SubType    string
+	Timestamp  string
+	Sequence   uint64
+	// Value 数值转换为 float64，UNAVAILABLE 为 nil，条件为 normal、warning、fault 或者 nil
+	Value interface{}
+}
+
+// Streams 解析后的 MTConnectStreams
+type Streams struct {
+	Header       Header
+	Observations []Observation
+}
+
+// Error 代理返回的 MTConnectError
+type Error struct {
+	InstanceId uint64
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("mtconnect error %s: %s", e.Code, e.Message)
+}
+
+// IsOutOfRange 请求的序号已经不在代理的缓冲区中
+func IsOutOfRange(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == "OUT_OF_RANGE"
+}
+
+type streamsDocument struct {
+	XMLName xml.Name
+	Header  Header `xml:"Header"`
+	Devices []struct {
+		Name       string `xml:"name,attr"`
+		Uuid       string `xml:"uuid,attr"`
+		Components []struct {
+			Component string          `xml:"component,attr"`
+			Name      string          `xml:"name,attr"`
+			Samples   observationList `xml:"Samples"`
+			Events    observationList `xml:"Events"`
+			Condition observationList `xml:"Condition"`
+		} `xml:"ComponentStream"`
+	} `xml:"Streams>DeviceStream"`
+	Errors []errorElement `xml:"Errors>Error"`
+	Error  *errorElement  `xml:"Error"`
+}
+
+type errorElement struct {
+	Code    string `xml:"errorCode,attr"`
+	Message string `xml:",chardata"`
+}
+
+type observationList struct {
+	Items []struct {
+		XMLName    xml.Name
+		DataItemId string `xml:"dataItemId,attr"`
+		Name       string `xml:"name,attr"`
+		SubType    string `xml:"subType,attr"`
+		Timestamp  string `xml:"timestamp,attr"`
+		Sequence   uint64 `xml:"sequence,attr"`
+		Value      string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// ParseStreams 解析 current、sample 响应，代理返回 MTConnectError 时返回 *Error
+func ParseStreams(r io.Reader) (*Streams, error) {
+	var doc streamsDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid mtconnect response: %w", err)
+	}
+	if doc.XMLName.Local == "MTConnectError" {
+		e := &Error{InstanceId: doc.Header.InstanceId}
+		if len(doc.Errors) > 0 {
+			e.Code, e.Message = doc.Errors[0].Code, strings.TrimSpace(doc.Errors[0].Message)
+		} else if doc.Error != nil {
+			e.Code, e.Message = doc.Error.Code, strings.TrimSpace(doc.Error.Message)
+		}
+		return nil, e
+	}
+	if doc.XMLName.Local != "MTConnectStreams" {
+		return nil, fmt.Errorf("unexpected mtconnect document: %s", doc.XMLName.Local)
+	}
+	streams := &Streams{Header: doc.Header}
+	for _, d := range doc.Devices {
+		for _, c := range d.Components {
+			component := c.Name
+			if component == "" {
+				component = c.Component
+			}
+			for _, list := range []struct {
+				category string
+				items    observationList
+			}{{CategorySample, c.Samples}, {CategoryEvent, c.Events}, {CategoryCondition, c.Condition}} {
+				for _, item := range list.items.Items {
+					o := Observation{
+						Device:     d.Name,
+						Component:  component,
+						Category:   list.category,
+						Type:       item.XMLName.Local,
+						DataItemId: item.DataItemId,
+						Name:       item.Name,
+						SubType:    item.SubType,
+						Timestamp:  item.Timestamp,
+						Sequence:   item.Sequence,
+					}
+					if list.category == CategoryCondition {
+						o.Value = conditionValue(item.XMLName.Local)
+					} else {
+						o.Value = observationValue(strings.TrimSpace(item.Value))
+					}
+					streams.Observations = append(streams.Observations, o)
+				}
+			}
+		}
+	}
+	return streams, nil
+}
+
+// observationValue 数值转换为 float64，UNAVAILABLE 为 nil
+func observationValue(s string) interface{} {
+	if s == Unavailable {
+		return nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// conditionValue 条件的状态
+func conditionValue(state string) interface{} {
+	if state == "Unavailable" {
+		return nil
+	}
+	return strings.ToLower(state)
+}
+
+// Agent MTConnect 代理的 HTTP 客户端
+type Agent struct {
+	// Server 代理地址，eg. http://agent:5000
+	Server string
+	// Device 设备名称或者 uuid，为空请求所有设备
+	Device string
+	// Path XPath 过滤，为空不过滤
+	Path   string
+	Client *http.Client
+}
+
+// Current 请求当前值
+func (a *Agent) Current(ctx context.Context) (*Streams, error) {
+	return a.get(ctx, "current", nil)
+}
+
+// Sample 从 from 开始请求最多 count 个观测值
+func (a *Agent) Sample(ctx context.Context, from uint64, count int) (*Streams, error) {
+	return a.get(ctx, "sample", url.Values{
+		"from":  {strconv.FormatUint(from, 10)},
+		"count": {strconv.Itoa(count)},
+	})
+}
+
+func (a *Agent) get(ctx context.Context, request string, query url.Values) (*Streams, error) {
+	u := strings.TrimSuffix(a.Server, "/")
+	if a.Device != "" {
+		u += "/" + url.PathEscape(a.Device)
+	}
+	u += "/" + request
+	if a.Path != "" {
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("path", a.Path)
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// 代理的错误响应也是 MTConnectError 文档
+	streams, err := ParseStreams(resp.Body)
+	if err != nil && resp.StatusCode != http.StatusOK && !errors.As(err, new(*Error)) {
+		return nil, fmt.Errorf("mtconnect agent %s: %s", u, resp.Status)
+	}
+	return streams, err
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtconnect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+const testStreams = `<?xml version="1.0" encoding="UTF-8"?>
+<MTConnectStreams xmlns="urn:mtconnect.org:MTConnectStreams:1.7">
+  <Header creationTime="2024-03-15T08:30:00Z" sender="agent" instanceId="1649" version="1.7.0.3" bufferSize="131072" nextSequence="105" firstSequence="1" lastSequence="104"/>
+  <Streams>
+    <DeviceStream name="VMC-3Axis" uuid="000">
+      <ComponentStream component="Linear" name="X" componentId="x">
+        <Samples>
+          <Position dataItemId="Xact" timestamp="2024-03-15T08:29:59.1Z" name="Xact" sequence="101" subType="ACTUAL">-0.5</Position>
+          <Position dataItemId="Xact" timestamp="2024-03-15T08:29:59.5Z" name="Xact" sequence="103" subType="ACTUAL">-0.25</Position>
+          <Load dataItemId="Xload" timestamp="2024-03-15T08:29:59.1Z" sequence="102">UNAVAILABLE</Load>
+        </Samples>
+        <Condition>
+          <Normal dataItemId="Xtravel" timestamp="2024-03-15T08:29:59.1Z" sequence="10" type="POSITION"/>
+        </Condition>
+      </ComponentStream>
+      <ComponentStream component="Controller" name="controller" componentId="cn1">
+        <Events>
+          <Execution dataItemId="exec" name="execution" timestamp="2024-03-15T08:29:59.1Z" sequence="104">ACTIVE</Execution>
+          <Program dataItemId="pgm" timestamp="2024-03-15T08:29:59.1Z" sequence="20">O1234</Program>
+        </Events>
+        <Condition>
+          <Fault dataItemId="system" timestamp="2024-03-15T08:29:59.1Z" sequence="30" type="SYSTEM" nativeCode="1001">Spindle overload</Fault>
+        </Condition>
+      </ComponentStream>
+    </DeviceStream>
+  </Streams>
+</MTConnectStreams>`
+
+func TestParseStreams(t *testing.T) {
+	streams, err := ParseStreams(strings.NewReader(testStreams))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1649), streams.Header.InstanceId)
+	assert.Equal(t, uint64(105), streams.Header.NextSequence)
+	assert.Equal(t, uint64(104), streams.Header.LastSequence)
+	assert.Equal(t, 7, len(streams.Observations))
+	assert.Equal(t, Observation{
+		Device: "VMC-3Axis", Component: "X", Category: CategorySample, Type: "Position", DataItemId: "Xact", Name: "Xact",
+		SubType: "ACTUAL", Timestamp: "2024-03-15T08:29:59.1Z", Sequence: 101, Value: -0.5,
+	}, streams.Observations[0])
+	assert.Nil(t, streams.Observations[2].Value)
+	assert.Equal(t, "normal", streams.Observations[3].Value)
+	assert.Equal(t, CategoryEvent, streams.Observations[4].Category)
+	assert.Equal(t, "ACTIVE", streams.Observations[4].Value)
+	assert.Equal(t, "O1234", streams.Observations[5].Value)
+	assert.Equal(t, "fault", streams.Observations[6].Value)
+
+	_, err = ParseStreams(strings.NewReader(`<MTConnectError xmlns="urn:mtconnect.org:MTConnectError:1.7">
+		<Header instanceId="1649"/>
+		<Errors><Error errorCode="OUT_OF_RANGE">'from' must be greater than 1</Error></Errors>
+	</MTConnectError>`))
+	assert.True(t, IsOutOfRange(err))
+	assert.Equal(t, "mtconnect error OUT_OF_RANGE: 'from' must be greater than 1", err.Error())
+	_, err = ParseStreams(strings.NewReader(`<MTConnectError><Error errorCode="NO_DEVICE">Could not find the device</Error></MTConnectError>`))
+	assert.NotNil(t, err)
+	assert.False(t, IsOutOfRange(err))
+	_, err = ParseStreams(strings.NewReader(`<MTConnectDevices/>`))
+	assert.NotNil(t, err)
+	_, err = ParseStreams(strings.NewReader(`not xml`))
+	assert.NotNil(t, err)
+}
+
+// testAgent 测试用的 MTConnect 代理，缓冲区保存数据项 Xact 的所有观测值
+type testAgent struct {
+	*httptest.Server
+	mu         sync.Mutex
+	instanceId int
+	first      int
+	values     []float64
+	requests   []string
+}
+
+func newTestAgent() *testAgent {
+	a := &testAgent{instanceId: 1, first: 1}
+	a.Server = httptest.NewServer(http.HandlerFunc(a.serve))
+	return a
+}
+
+// add 添加观测值
+func (a *testAgent) add(values ...float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.values = append(a.values, values...)
+}
+
+// restart 代理重启，清空缓冲区
+func (a *testAgent) restart(values ...float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.instanceId++
+	a.first = 1
+	a.values = values
+}
+
+// discard 丢弃缓冲区中最早的 n 个观测值
+func (a *testAgent) discard(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.first += n
+	a.values = a.values[n:]
+}
+
+func (a *testAgent) serve(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests = append(a.requests, r.URL.Path+"?"+r.URL.RawQuery)
+	next := a.first + len(a.values)
+	header := fmt.Sprintf(`<Header instanceId="%d" firstSequence="%d" lastSequence="%d" nextSequence="%%d"/>`, a.instanceId, a.first, next-1)
+	var observations []string
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/current"):
+		if len(a.values) > 0 {
+			observations = append(observations, fmt.Sprintf(`<Position dataItemId="Xact" name="Xact" sequence="%d">%v</Position>`, next-1, a.values[len(a.values)-1]))
+		}
+	case strings.HasSuffix(r.URL.Path, "/sample"):
+		var from, count int
+		_, _ = fmt.Sscan(r.URL.Query().Get("from"), &from)
+		_, _ = fmt.Sscan(r.URL.Query().Get("count"), &count)
+		if from < a.first || from > next {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `<MTConnectError>%s<Errors><Error errorCode="OUT_OF_RANGE">out of range</Error></Errors></MTConnectError>`, fmt.Sprintf(header, next))
+			return
+		}
+		for seq := from; seq < next && seq < from+count; seq++ {
+			observations = append(observations, fmt.Sprintf(`<Position dataItemId="Xact" name="Xact" sequence="%d">%v</Position>`, seq, a.values[seq-a.first]))
+		}
+		next = from + len(observations)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = fmt.Fprintf(w, `<MTConnectStreams>%s<Streams><DeviceStream name="VMC-3Axis"><ComponentStream component="Linear" name="X"><Samples>%s</Samples></ComponentStream></DeviceStream></Streams></MTConnectStreams>`,
+		fmt.Sprintf(header, next), strings.Join(observations, ""))
+}
+
+func TestAgent(t *testing.T) {
+	agent := newTestAgent()
+	defer agent.Close()
+	agent.add(1, 2, 3)
+
+	client := &Agent{Server: agent.URL + "/", Device: "VMC-3Axis", Path: `//DataItem[@type="POSITION"]`}
+	streams, err := client.Current(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), streams.Header.NextSequence)
+	assert.Equal(t, 3.0, streams.Observations[0].Value)
+	streams, err = client.Sample(context.Background(), 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), streams.Header.NextSequence)
+	assert.Equal(t, 1, len(streams.Observations))
+	assert.Equal(t, 2.0, streams.Observations[0].Value)
+	_, err = client.Sample(context.Background(), 10, 1)
+	assert.True(t, IsOutOfRange(err))
+	assert.Equal(t, "/VMC-3Axis/current?path=%2F%2FDataItem%5B%40type%3D%22POSITION%22%5D", agent.requests[0])
+
+	_, err = (&Agent{Server: "http://127.0.0.1:1"}).Current(context.Background())
+	assert.NotNil(t, err)
+}
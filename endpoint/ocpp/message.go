@@ -0,0 +1,168 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ocpp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// OCPP-J 消息类型
+const (
+	MessageTypeCall       = 2
+	MessageTypeCallResult = 3
+	MessageTypeCallError  = 4
+)
+
+// 子协议
+const (
+	ProtocolOCPP16  = "ocpp1.6"
+	ProtocolOCPP201 = "ocpp2.0.1"
+)
+
+// CALLERROR 错误码，FormationViolation 用于 OCPP 1.6，FormatViolation 用于 OCPP 2.0.1
+const (
+	ErrorNotImplemented                = "NotImplemented"
+	ErrorNotSupported                  = "NotSupported"
+	ErrorInternalError                 = "InternalError"
+	ErrorProtocolError                 = "ProtocolError"
+	ErrorSecurityError                 = "SecurityError"
+	ErrorFormationViolation            = "FormationViolation"
+	ErrorFormatViolation               = "FormatViolation"
+	ErrorPropertyConstraintViolation   = "PropertyConstraintViolation"
+	ErrorOccurrenceConstraintViolation = "OccurrenceConstraintViolation"
+	ErrorTypeConstraintViolation       = "TypeConstraintViolation"
+	ErrorGenericError                  = "GenericError"
+)
+
+// Message OCPP-J 消息
+//
+//	CALL:       [2, "<messageId>", "<action>", {payload}]
+//	CALLRESULT: [3, "<messageId>", {payload}]
+//	CALLERROR:  [4, "<messageId>", "<errorCode>", "<errorDescription>", {errorDetails}]
+type Message struct {
+	Type   int
+	Id     string
+	Action string
+	// Payload CALL 和 CALLRESULT 的负荷
+	Payload json.RawMessage
+	// Error CALLERROR 的错误
+	Error *CallError
+}
+
+// CallError CALLERROR 错误
+type CallError struct {
+	Code        string
+	Description string
+	Details     json.RawMessage
+}
+
+func (e *CallError) Error() string {
+	if e.Description == "" {
+		return "ocpp call error: " + e.Code
+	}
+	return fmt.Sprintf("ocpp call error: %s, %s", e.Code, e.Description)
+}
+
+// ParseMessage 解析 OCPP-J 消息
+func ParseMessage(data []byte) (*Message, error) {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if len(fields) < 3 {
+		return nil, errors.New("ocpp message must be an array of at least 3 elements")
+	}
+	m := &Message{}
+	if err := json.Unmarshal(fields[0], &m.Type); err != nil {
+		return nil, fmt.Errorf("invalid ocpp message type: %s", fields[0])
+	}
+	if err := json.Unmarshal(fields[1], &m.Id); err != nil {
+		return nil, fmt.Errorf("invalid ocpp message id: %s", fields[1])
+	}
+	switch m.Type {
+	case MessageTypeCall:
+		if len(fields) != 4 {
+			return m, errors.New("ocpp call must have 4 elements")
+		}
+		if err := json.Unmarshal(fields[2], &m.Action); err != nil || m.Action == "" {
+			return m, fmt.Errorf("invalid ocpp action: %s", fields[2])
+		}
+		m.Payload = fields[3]
+	case MessageTypeCallResult:
+		m.Payload = fields[2]
+	case MessageTypeCallError:
+		if len(fields) < 4 {
+			return m, errors.New("ocpp call error must have at least 4 elements")
+		}
+		m.Error = &CallError{}
+		if err := json.Unmarshal(fields[2], &m.Error.Code); err != nil {
+			return m, fmt.Errorf("invalid ocpp error code: %s", fields[2])
+		}
+		_ = json.Unmarshal(fields[3], &m.Error.Description)
+		if len(fields) > 4 {
+			m.Error.Details = fields[4]
+		}
+	default:
+		return m, fmt.Errorf("unknown ocpp message type: %d", m.Type)
+	}
+	if m.Type != MessageTypeCallError && !isObject(m.Payload) {
+		return m, errors.New("ocpp payload must be a json object")
+	}
+	return m, nil
+}
+
+// MarshalJSON 编码成 OCPP-J 数组
+func (m *Message) MarshalJSON() ([]byte, error) {
+	switch m.Type {
+	case MessageTypeCall:
+		return json.Marshal([]interface{}{m.Type, m.Id, m.Action, payloadOf(m.Payload)})
+	case MessageTypeCallResult:
+		return json.Marshal([]interface{}{m.Type, m.Id, payloadOf(m.Payload)})
+	case MessageTypeCallError:
+		e := m.Error
+		if e == nil {
+			e = &CallError{Code: ErrorGenericError}
+		}
+		return json.Marshal([]interface{}{m.Type, m.Id, e.Code, e.Description, payloadOf(e.Details)})
+	default:
+		return nil, fmt.Errorf("unknown ocpp message type: %d", m.Type)
+	}
+}
+
+// payloadOf 空负荷使用 {}
+func payloadOf(payload json.RawMessage) json.RawMessage {
+	if len(payload) == 0 {
+		return json.RawMessage("{}")
+	}
+	return payload
+}
+
+// isObject 是否 JSON 对象
+func isObject(data json.RawMessage) bool {
+	var v map[string]interface{}
+	return json.Unmarshal(data, &v) == nil && v != nil
+}
+
+// formatViolation 无法解析消息时使用的错误码
+func formatViolation(protocol string) string {
+	if protocol == ProtocolOCPP16 {
+		return ErrorFormationViolation
+	}
+	return ErrorFormatViolation
+}
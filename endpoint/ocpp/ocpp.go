@@ -0,0 +1,606 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ocpp 提供 OCPP 1.6J/2.0.1 中央系统端点
+// 充电桩通过 WebSocket 连接 ws://host:port/<path>/<chargePointId>（配置 tls 时为 wss），发送的 BootNotification、StatusNotification、MeterValues 等
+// CALL 按 action 路由到规则链，规则链可以通过 x/ocppCall 节点向指定的充电桩发送 RemoteStartTransaction 等 CALL
+// 开启 sessions 后跟踪充电桩的连接、心跳、连接器状态、交易和未完成的 CALL，并把连接和交易的变化作为事件路由到规则链
+package ocpp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "ocpp"
+
+// 元数据key
+const (
+	KeyChargePointId = "chargePointId"
+	KeyAction        = "action"
+	KeyMessageId     = "messageId"
+	KeyProtocol      = "protocol"
+	KeyRemoteAddr    = "remoteAddr"
+	KeyTransactionId = "transactionId"
+	KeyConnectorId   = "connectorId"
+	KeyIdTag         = "idTag"
+)
+
+// Endpoint 别名
+type Endpoint = Ocpp
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// centralSystems 运行中的中央系统，key 为端点 Id，供 x/ocppCall 节点查找
+var centralSystems sync.Map
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	cp      *ChargePoint
+	call    *Message
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body = r.call.Payload
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.cp.Id
+}
+
+// GetParam 获取充电桩 Id、action、消息 Id 和子协议
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyChargePointId:
+		return r.cp.Id
+	case KeyAction:
+		return r.call.Action
+	case KeyMessageId:
+		return r.call.Id
+	case KeyProtocol:
+		return r.cp.Protocol
+	default:
+		return ""
+	}
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 CALL 的 action，eg. BootNotification，充电桩 Id、消息 Id、子协议和来源地址放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyChargePointId, r.cp.Id)
+		metadata.PutValue(KeyAction, r.call.Action)
+		metadata.PutValue(KeyMessageId, r.call.Id)
+		metadata.PutValue(KeyProtocol, r.cp.Protocol)
+		metadata.PutValue(KeyRemoteAddr, r.cp.RemoteAddr)
+		ruleMsg := types.NewMsg(0, r.call.Action, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage CALL 的响应，body 为 CALLRESULT 的负荷，错误回复 CALLERROR
+// 路由使用 Wait() 并在规则链处理后设置 body，否则回复默认的响应
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	// mu 不等待规则链时，规则链结束可能晚于回复
+	mu   sync.Mutex
+	body []byte
+	msg  *types.RuleMsg
+	err  error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.body = body
+}
+
+// SetError set error，*CallError 回复对应的错误码，其他错误回复 InternalError
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// OcppConfig OCPP 中央系统配置
+type OcppConfig struct {
+	// Server 监听地址，格式：host:port
+	Server string `json:"server" label:"Server" desc:"WebSocket listen address, format: host:port" required:"true"`
+	// Path 路径前缀，充电桩连接 ws://host:port/<path>/<chargePointId>
+	Path string `json:"path" label:"Path" desc:"URL path prefix, charge points connect to ws://host:port/<path>/<chargePointId>"`
+	// Protocols 支持的子协议，按优先级排列：ocpp1.6、ocpp2.0.1，为空支持所有子协议
+	Protocols []string `json:"protocols" label:"Protocols" desc:"Supported OCPP subprotocols in order of preference: ocpp1.6, ocpp2.0.1. Empty supports both"`
+	// HeartbeatInterval 默认 BootNotification 响应中的心跳间隔，单位秒
+	HeartbeatInterval int `json:"heartbeatInterval" label:"Heartbeat Interval" desc:"Heartbeat interval in seconds returned in the default BootNotification response"`
+	// Timeout 等待充电桩响应的超时，单位秒
+	Timeout int `json:"timeout" label:"Timeout" desc:"Timeout in seconds waiting for charge point responses"`
+	// TLS 配置了证书时使用 wss，配置了 CA 证书时要求充电桩提供客户端证书（OCPP 安全配置 3）
+	TLS tlsconfig.Config `json:"tls" label:"TLS" desc:"Serve wss when a certificate is set, a CA file requires charge point client certificates (security profile 3)"`
+	// Sessions 跟踪充电桩会话和交易，交易相关的 CALL 在元数据中补充 transactionId、connectorId 和 idTag，
+	// 并路由 ChargePointConnected、ChargePointDisconnected、TransactionStarted 和 TransactionEnded 事件
+	Sessions bool `json:"sessions" label:"Sessions" desc:"Track charge point sessions, heartbeats, transactions and pending calls, add transactionId/connectorId/idTag metadata to transaction calls and route ChargePointConnected, ChargePointDisconnected, TransactionStarted and TransactionEnded events"`
+}
+
+// Ocpp OCPP 1.6J/2.0.1 中央系统端点
+// 路由的 from 为 CALL 的 action，为空或者 * 匹配所有 action，没有匹配的路由时回复 NotImplemented
+type Ocpp struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     OcppConfig
+	// transactionId 默认 StartTransaction 响应分配的交易 Id
+	transactionId int64
+	// sessions 会话管理器，没有开启会话跟踪时为 nil
+	sessions *SessionManager
+	// mu 保护 server 和 listener
+	mu       sync.Mutex
+	server   *Server
+	listener net.Listener
+}
+
+// Type 组件类型
+func (x *Ocpp) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *Ocpp) New() types.Node {
+	return &Ocpp{
+		Config: OcppConfig{
+			Server:            ":9000",
+			Path:              "/ocpp",
+			HeartbeatInterval: 300,
+			Timeout:           30,
+		},
+	}
+}
+
+// Init 初始化
+func (x *Ocpp) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if len(x.Config.Protocols) == 0 {
+		x.Config.Protocols = []string{ProtocolOCPP16, ProtocolOCPP201}
+	}
+	for _, p := range x.Config.Protocols {
+		if p != ProtocolOCPP16 && p != ProtocolOCPP201 {
+			return fmt.Errorf("unsupported ocpp protocol: %s", p)
+		}
+	}
+	if x.Config.TLS.Enabled() {
+		if x.Config.TLS.CertFile == "" {
+			return errors.New("ocpp tls requires certFile and keyFile")
+		}
+		if err = x.Config.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+	x.transactionId = time.Now().Unix()
+	x.sessions = nil
+	if x.Config.Sessions {
+		x.sessions = NewSessionManager()
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (x *Ocpp) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *Ocpp) Desc() string {
+	return "OCPP 1.6J/2.0.1 central system endpoint routing charge point calls to rule chains by action"
+}
+
+// Category returns the component category
+func (x *Ocpp) Category() string {
+	return "endpoint"
+}
+
+func (x *Ocpp) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "OCPP 1.6J/2.0.1 central system endpoint routing charge point calls to rule chains by action",
+		RouterForm: &types.RouterForm{
+			From: &types.RouterFormField{
+				Path: types.ComponentFormField{
+					Name:  "path",
+					Type:  "string",
+					Label: "Action",
+					Desc:  "OCPP action or session event to route, eg. BootNotification, TransactionEnded, empty or * routes all actions",
+				},
+			},
+		},
+	}
+}
+
+func (x *Ocpp) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	centralSystems.CompareAndDelete(x.Id(), x)
+	var err error
+	if x.server != nil {
+		err = x.server.Close()
+		x.server = nil
+		x.listener = nil
+	}
+	return err
+}
+
+func (x *Ocpp) Id() string {
+	return x.Config.Server
+}
+
+func (x *Ocpp) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpointApi.Router)
+	}
+	action := actionOf(router)
+	for _, r := range x.RouterStorage {
+		if actionOf(r) == action {
+			return "", fmt.Errorf("duplicate router for action: %s", action)
+		}
+	}
+	x.RouterStorage[router.GetId()] = router
+	return router.GetId(), nil
+}
+
+func (x *Ocpp) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if _, ok := x.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(x.RouterStorage, routerId)
+	return nil
+}
+
+// actionOf 路由的 action，匹配所有 action 时为 *
+func actionOf(router endpointApi.Router) string {
+	if from := router.GetFrom(); from != nil {
+		if action := from.ToString(); action != "" {
+			return action
+		}
+	}
+	return "*"
+}
+
+// routerOf 查找 action 的路由，优先使用 action 相同的路由
+func (x *Ocpp) routerOf(action string) endpointApi.Router {
+	x.RLock()
+	defer x.RUnlock()
+	var all endpointApi.Router
+	for _, r := range x.RouterStorage {
+		switch actionOf(r) {
+		case action:
+			return r
+		case "*":
+			all = r
+		}
+	}
+	return all
+}
+
+func (x *Ocpp) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.server != nil {
+		return nil
+	}
+	l, err := net.Listen("tcp", x.Config.Server)
+	if err != nil {
+		return err
+	}
+	if x.Config.TLS.Enabled() {
+		tlsConfig, err := x.Config.TLS.ServerConfig()
+		if err != nil {
+			_ = l.Close()
+			return err
+		}
+		l = tls.NewListener(l, tlsConfig)
+	}
+	server := &Server{
+		Path:      x.Config.Path,
+		Protocols: x.Config.Protocols,
+		Timeout:   time.Duration(x.Config.Timeout) * time.Second,
+		OnCall:    x.onCall,
+		OnConnect: func(cp *ChargePoint) {
+			x.Printf("ocpp charge point %s connected from %s using %s", cp.Id, cp.RemoteAddr, cp.Protocol)
+			if x.sessions != nil {
+				x.emit(cp, EventChargePointConnected, x.sessions.connect(cp), nil)
+			}
+		},
+		OnDisconnect: func(cp *ChargePoint) {
+			x.Printf("ocpp charge point %s disconnected", cp.Id)
+			if x.sessions != nil {
+				if session, ok := x.sessions.disconnect(cp); ok {
+					x.emit(cp, EventChargePointDisconnected, session, nil)
+				}
+			}
+		},
+	}
+	x.server, x.listener = server, l
+	go func() {
+		_ = server.Serve(l)
+	}()
+	centralSystems.Store(x.Id(), x)
+	x.Printf("started OCPP central system on %s", l.Addr())
+	return nil
+}
+
+// Addr 实际监听的地址，未启动返回 nil
+func (x *Ocpp) Addr() net.Addr {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.listener == nil {
+		return nil
+	}
+	return x.listener.Addr()
+}
+
+func (x *Ocpp) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// current 运行中的服务，未启动返回错误
+func (x *Ocpp) current() (*Server, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.server == nil {
+		return nil, fmt.Errorf("ocpp central system not started: %s", x.Id())
+	}
+	return x.server, nil
+}
+
+// Call 向充电桩发送 CALL 并等待 CALLRESULT 的负荷
+func (x *Ocpp) Call(chargePointId, action string, payload json.RawMessage) (json.RawMessage, error) {
+	server, err := x.current()
+	if err != nil {
+		return nil, err
+	}
+	return server.Call(chargePointId, action, payload)
+}
+
+// ChargePoints 已连接的充电桩 Id
+func (x *Ocpp) ChargePoints() []string {
+	server, err := x.current()
+	if err != nil {
+		return nil
+	}
+	return server.ChargePoints()
+}
+
+// Sessions 会话管理器，没有开启会话跟踪时为 nil
+func (x *Ocpp) Sessions() *SessionManager {
+	return x.sessions
+}
+
+// onCall 处理充电桩的 CALL，开启会话跟踪时根据响应更新会话
+func (x *Ocpp) onCall(cp *ChargePoint, call *Message) (json.RawMessage, error) {
+	resp, err := x.process(cp, call)
+	if err == nil && x.sessions != nil {
+		if events := x.sessions.observe(cp, call, resp); len(events) > 0 {
+			// 异步路由交易事件，不延迟对充电桩的响应
+			go func() {
+				for _, e := range events {
+					x.emit(cp, e.Type, e.Transaction, map[string]string{
+						KeyTransactionId: e.Transaction.Id,
+						KeyConnectorId:   strconv.Itoa(e.Transaction.ConnectorId),
+						KeyIdTag:         e.Transaction.IdTag,
+					})
+				}
+			}()
+		}
+	}
+	return resp, err
+}
+
+// emit 路由会话事件，消息类型为事件名称，没有匹配的路由时忽略
+func (x *Ocpp) emit(cp *ChargePoint, event string, v interface{}, values map[string]string) {
+	router := x.routerOf(event)
+	if router == nil {
+		return
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		x.Printf("ocpp marshal %s event err: %v", event, err)
+		return
+	}
+	request := &RequestMessage{cp: cp, call: &Message{Type: MessageTypeCall, Action: event, Payload: body}}
+	msg := request.GetMsg()
+	for k, v := range values {
+		if v != "" {
+			msg.Metadata.PutValue(k, v)
+		}
+	}
+	x.DoProcess(context.Background(), router, &endpointApi.Exchange{In: request, Out: &ResponseMessage{}})
+}
+
+// process 充电桩的 CALL 交给路由处理，规则链设置了响应时使用规则链的响应，否则回复默认的响应
+func (x *Ocpp) process(cp *ChargePoint, call *Message) (json.RawMessage, error) {
+	router := x.routerOf(call.Action)
+	if router == nil {
+		// 会话跟踪的 CALL 没有路由时回复默认的响应
+		if x.sessions != nil && sessionActions[call.Action] {
+			return x.defaultResponse(cp.Protocol, call.Action)
+		}
+		return nil, &CallError{Code: ErrorNotImplemented, Description: "no router for action " + call.Action}
+	}
+	request := &RequestMessage{cp: cp, call: call}
+	if x.sessions != nil {
+		msg := request.GetMsg()
+		for k, v := range x.sessions.annotate(cp, call) {
+			msg.Metadata.PutValue(k, v)
+		}
+	}
+	response := &ResponseMessage{}
+	exchange := &endpointApi.Exchange{
+		In:  request,
+		Out: response,
+	}
+	x.DoProcess(context.Background(), router, exchange)
+	if err := response.GetError(); err != nil {
+		return nil, err
+	}
+	if body := response.Body(); len(body) > 0 {
+		return body, nil
+	}
+	return x.defaultResponse(cp.Protocol, call.Action)
+}
+
+// defaultResponse 接受充电桩的请求，BootNotification 返回配置的心跳间隔
+func (x *Ocpp) defaultResponse(protocol, action string) (json.RawMessage, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	var resp map[string]interface{}
+	switch action {
+	case "BootNotification":
+		resp = map[string]interface{}{"currentTime": now, "interval": x.Config.HeartbeatInterval, "status": "Accepted"}
+	case "Heartbeat":
+		resp = map[string]interface{}{"currentTime": now}
+	case "Authorize":
+		if protocol == ProtocolOCPP16 {
+			resp = map[string]interface{}{"idTagInfo": map[string]interface{}{"status": "Accepted"}}
+		} else {
+			resp = map[string]interface{}{"idTokenInfo": map[string]interface{}{"status": "Accepted"}}
+		}
+	case "StartTransaction":
+		resp = map[string]interface{}{
+			"idTagInfo":     map[string]interface{}{"status": "Accepted"},
+			"transactionId": atomic.AddInt64(&x.transactionId, 1),
+		}
+	default:
+		resp = map[string]interface{}{}
+	}
+	return json.Marshal(resp)
+}
+
+// lookupCentralSystem 查找运行中的中央系统
+func lookupCentralSystem(id string) (*Ocpp, bool) {
+	if v, ok := centralSystems.Load(id); ok {
+		return v.(*Ocpp), true
+	}
+	return nil, false
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ocpp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&CallNode{})
+}
+
+// CallNodeConfiguration 节点配置
+type CallNodeConfiguration struct {
+	// Server 中央系统端点 Id，与 endpoint/ocpp 的 server 配置一致，eg. :9000
+	Server string `json:"server" label:"Server" desc:"OCPP central system endpoint id, same as the server of endpoint/ocpp" required:"true"`
+	// ChargePointId 充电桩 Id，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	ChargePointId string `json:"chargePointId" label:"Charge Point Id" desc:"Target charge point id, supports ${metadata.key} and ${msg.key} variables" required:"true"`
+	// Action CALL 的 action，eg. RemoteStartTransaction，可以使用变量
+	Action string `json:"action" label:"Action" desc:"OCPP action to call, eg. RemoteStartTransaction, supports variables" required:"true"`
+}
+
+// CallNode 向连接到 OCPP 中央系统端点的充电桩发送 CALL，消息负荷 msg.Data 为 CALL 的负荷，eg.
+//
+//	{"connectorId": 1, "idTag": "04E91C5A"}
+//
+// 充电桩回复 CALLRESULT 后，msg.Data 替换为响应的负荷，流转到`Success`链；
+// 充电桩回复 CALLERROR、未连接或者超时，流转到`Failure`链
+type CallNode struct {
+	//节点配置
+	Config                CallNodeConfiguration
+	chargePointIdTemplate str.Template
+	actionTemplate        str.Template
+}
+
+func (x *CallNode) New() types.Node {
+	return &CallNode{
+		Config: CallNodeConfiguration{
+			Server:        ":9000",
+			ChargePointId: "${metadata.chargePointId}",
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *CallNode) Type() string {
+	return "x/ocppCall"
+}
+
+func (x *CallNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Action) == "" {
+		return errors.New("ocpp action is empty")
+	}
+	x.chargePointIdTemplate = str.NewTemplate(x.Config.ChargePointId)
+	x.actionTemplate = str.NewTemplate(x.Config.Action)
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *CallNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	centralSystem, ok := lookupCentralSystem(x.Config.Server)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("ocpp central system not found: %s", x.Config.Server))
+		return
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	chargePointId, action := x.chargePointIdTemplate.Execute(evn), x.actionTemplate.Execute(evn)
+	if chargePointId == "" || action == "" {
+		ctx.TellFailure(msg, errors.New("ocpp charge point id or action is empty"))
+		return
+	}
+	payload := json.RawMessage(strings.TrimSpace(msg.GetData()))
+	resp, err := centralSystem.Call(chargePointId, action, payload)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KeyChargePointId, chargePointId)
+	msg.Metadata.PutValue(KeyAction, action)
+	msg.SetData(string(resp))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+func (x *CallNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *CallNode) Desc() string {
+	return "Send an OCPP CALL to a charge point connected to the OCPP central system endpoint. Routes to Success/Failure"
+}
@@ -0,0 +1,409 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ocpp
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testChargePoint 测试用的充电桩
+type testChargePoint struct {
+	*websocket.Conn
+	seq int
+}
+
+func dialChargePoint(t *testing.T, addr, id string, protocols ...string) (*testChargePoint, error) {
+	dialer := websocket.Dialer{Subprotocols: protocols, HandshakeTimeout: time.Second}
+	conn, _, err := dialer.Dial(fmt.Sprintf("ws://%s/ocpp/%s", addr, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &testChargePoint{Conn: conn}, nil
+}
+
+// call 发送 CALL 并读取响应
+func (cp *testChargePoint) call(t *testing.T, action string, payload string) *Message {
+	cp.seq++
+	id := fmt.Sprintf("cp-%d", cp.seq)
+	assert.Nil(t, cp.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`[2,"%s","%s",%s]`, id, action, payload))))
+	m := cp.read(t)
+	assert.Equal(t, id, m.Id)
+	return m
+}
+
+func (cp *testChargePoint) read(t *testing.T) *Message {
+	_ = cp.SetReadDeadline(time.Now().Add(time.Second * 2))
+	_, data, err := cp.ReadMessage()
+	assert.Nil(t, err)
+	m, err := ParseMessage(data)
+	assert.Nil(t, err)
+	return m
+}
+
+func TestParseMessage(t *testing.T) {
+	m, err := ParseMessage([]byte(`[2, "19223201", "BootNotification", {"chargePointVendor": "VendorX", "chargePointModel": "SingleSocketCharger"}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, MessageTypeCall, m.Type)
+	assert.Equal(t, "19223201", m.Id)
+	assert.Equal(t, "BootNotification", m.Action)
+	data, err := m.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `[2,"19223201","BootNotification",{"chargePointVendor":"VendorX","chargePointModel":"SingleSocketCharger"}]`, string(data))
+
+	m, err = ParseMessage([]byte(`[4, "162376037", "NotSupported", "SetDisplayMessageRequest not implemented", {}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, &CallError{Code: ErrorNotSupported, Description: "SetDisplayMessageRequest not implemented", Details: json.RawMessage("{}")}, m.Error)
+
+	data, err = (&Message{Type: MessageTypeCallResult, Id: "1"}).MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `[3,"1",{}]`, string(data))
+
+	for _, s := range []string{`{}`, `[2, "1"]`, `[2, "1", "Heartbeat"]`, `[2, 1, "Heartbeat", {}]`, `[2, "1", "Heartbeat", []]`, `[5, "1", {}]`} {
+		_, err = ParseMessage([]byte(s))
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestOcppEndpoint(t *testing.T) {
+	ep := (&Ocpp{}).New().(*Ocpp)
+	assert.Equal(t, Type, ep.Type())
+
+	config := engine.NewConfig()
+	_, err := engine.New("ocpp-test01", []byte(`{
+		"ruleChain": {"id": "ocpp-test01", "name": "ocpp-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("ocpp-test01")
+
+	assert.NotNil(t, (&Ocpp{}).New().Init(config, types.Configuration{"protocols": []string{"ocpp1.5"}}))
+	err = ep.Init(config, types.Configuration{
+		"server":            "127.0.0.1:0",
+		"heartbeatInterval": 60,
+		"timeout":           1,
+	})
+	assert.Nil(t, err)
+
+	boots := make(chan types.RuleMsg, 1)
+	_, err = ep.AddRouter(impl.NewRouter().From("BootNotification").To("chain:ocpp-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		boots <- *exchange.In.GetMsg()
+		return true
+	}).End())
+	assert.Nil(t, err)
+	// 在规则链之前设置响应
+	_, err = ep.AddRouter(impl.NewRouter().From("Authorize").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetBody([]byte(`{"idTagInfo": {"status": "Blocked"}}`))
+		return true
+	}).To("chain:ocpp-test01").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("StatusNotification").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetError(&CallError{Code: ErrorPropertyConstraintViolation, Description: "unknown connector"})
+		return true
+	}).To("chain:ocpp-test01").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("Authorize").To("chain:ocpp-test01").End())
+	assert.NotNil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+	addr := ep.Addr().String()
+
+	// 需要协商子协议
+	_, err = dialChargePoint(t, addr, "CP001")
+	assert.NotNil(t, err)
+	cp, err := dialChargePoint(t, addr, "CP001", "ocpp2.0.1", "ocpp1.6")
+	assert.Nil(t, err)
+	defer cp.Close()
+	assert.Equal(t, ProtocolOCPP16, cp.Subprotocol())
+
+	// 没有路由设置响应时使用默认的响应
+	m := cp.call(t, "BootNotification", `{"chargePointVendor": "VendorX", "chargePointModel": "SingleSocketCharger"}`)
+	assert.Equal(t, MessageTypeCallResult, m.Type)
+	var boot struct {
+		Status   string `json:"status"`
+		Interval int    `json:"interval"`
+	}
+	assert.Nil(t, json.Unmarshal(m.Payload, &boot))
+	assert.Equal(t, "Accepted", boot.Status)
+	assert.Equal(t, 60, boot.Interval)
+	select {
+	case msg := <-boots:
+		assert.Equal(t, "BootNotification", msg.Type)
+		assert.Equal(t, "CP001", msg.Metadata.GetValue(KeyChargePointId))
+		assert.Equal(t, ProtocolOCPP16, msg.Metadata.GetValue(KeyProtocol))
+		assert.Equal(t, "cp-1", msg.Metadata.GetValue(KeyMessageId))
+	case <-time.After(time.Second):
+		t.Fatal("boot notification not routed")
+	}
+
+	m = cp.call(t, "Authorize", `{"idTag": "04E91C5A"}`)
+	var authorize map[string]interface{}
+	assert.Nil(t, json.Unmarshal(m.Payload, &authorize))
+	assert.Equal(t, map[string]interface{}{"idTagInfo": map[string]interface{}{"status": "Blocked"}}, authorize)
+
+	m = cp.call(t, "StatusNotification", `{"connectorId": 9, "errorCode": "NoError", "status": "Available"}`)
+	assert.Equal(t, MessageTypeCallError, m.Type)
+	assert.Equal(t, ErrorPropertyConstraintViolation, m.Error.Code)
+
+	m = cp.call(t, "DataTransfer", `{"vendorId": "VendorX"}`)
+	assert.Equal(t, ErrorNotImplemented, m.Error.Code)
+
+	// 无法解析的消息
+	assert.Nil(t, cp.WriteMessage(websocket.TextMessage, []byte(`[2, "cp-x", "Heartbeat"]`)))
+	m = cp.read(t)
+	assert.Equal(t, "cp-x", m.Id)
+	assert.Equal(t, ErrorFormationViolation, m.Error.Code)
+	assert.Nil(t, cp.WriteMessage(websocket.TextMessage, []byte(`not json`)))
+	m = cp.read(t)
+	assert.Equal(t, "-1", m.Id)
+
+	assert.Equal(t, []string{"CP001"}, ep.ChargePoints())
+
+	// 规则链向充电桩发送 CALL
+	go func() {
+		for {
+			_, data, err := cp.ReadMessage()
+			if err != nil {
+				return
+			}
+			call, err := ParseMessage(data)
+			if err != nil {
+				return
+			}
+			var resp *Message
+			switch call.Action {
+			case "RemoteStartTransaction":
+				resp = &Message{Type: MessageTypeCallResult, Id: call.Id, Payload: json.RawMessage(`{"status":"Accepted"}`)}
+			case "Reset":
+				resp = &Message{Type: MessageTypeCallError, Id: call.Id, Error: &CallError{Code: ErrorNotSupported}}
+			default:
+				// 不回复，等待超时
+				continue
+			}
+			data, _ = resp.MarshalJSON()
+			_ = cp.WriteMessage(websocket.TextMessage, data)
+		}
+	}()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&CallNode{})
+	_, err = test.CreateAndInitNode("x/ocppCall", types.Configuration{"server": ep.Id()}, Registry)
+	assert.NotNil(t, err)
+	node, err := test.CreateAndInitNode("x/ocppCall", types.Configuration{
+		"server": ep.Id(),
+		"action": "${metadata.action}",
+	}, Registry)
+	assert.Nil(t, err)
+
+	newMsg := func(msgType, chargePointId, action, data string) test.Msg {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyChargePointId, chargePointId)
+		metadata.PutValue(KeyAction, action)
+		return test.Msg{
+			MetaData:   metadata,
+			DataType:   types.JSON,
+			MsgType:    msgType,
+			Data:       data,
+			AfterSleep: time.Millisecond * 100,
+		}
+	}
+	test.NodeOnMsg(t, node, []test.Msg{
+		newMsg("START", "CP001", "RemoteStartTransaction", `{"connectorId": 1, "idTag": "04E91C5A"}`),
+		newMsg("RESET", "CP001", "Reset", `{"type": "Soft"}`),
+		newMsg("NOT_CONNECTED", "CP002", "Reset", `{"type": "Soft"}`),
+		newMsg("INVALID", "CP001", "Reset", `[1, 2]`),
+		newMsg("TIMEOUT", "CP001", "ClearCache", ``),
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		switch msg.Type {
+		case "START":
+			assert.Equal(t, types.Success, relationType)
+			var result map[string]interface{}
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+			assert.Equal(t, map[string]interface{}{"status": "Accepted"}, result)
+		case "RESET":
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, &CallError{Code: ErrorNotSupported, Details: json.RawMessage("{}")}, err)
+		default:
+			assert.Equal(t, types.Failure, relationType)
+		}
+	})
+
+	// 同一个充电桩重新连接时断开原有连接
+	cp2, err := dialChargePoint(t, addr, "CP001", "ocpp2.0.1")
+	assert.Nil(t, err)
+	defer cp2.Close()
+	m = cp2.call(t, "Authorize", `{"idToken": {"idToken": "04E91C5A", "type": "ISO14443"}}`)
+	assert.Equal(t, MessageTypeCallResult, m.Type)
+	assert.Equal(t, []string{"CP001"}, ep.ChargePoints())
+
+	// 关闭后断开所有充电桩
+	ep.Destroy()
+	_, ok := lookupCentralSystem(ep.Id())
+	assert.False(t, ok)
+	_ = cp2.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = cp2.ReadMessage()
+	assert.NotNil(t, err)
+}
+
+func TestSessionManager(t *testing.T) {
+	m := NewSessionManager()
+	call := func(action, payload string) *Message {
+		return &Message{Type: MessageTypeCall, Id: "1", Action: action, Payload: json.RawMessage(payload)}
+	}
+	cp := &ChargePoint{Id: "CP001", Protocol: ProtocolOCPP16, pending: map[string]*pendingCall{}}
+	session := m.connect(cp)
+	assert.True(t, session.Connected)
+
+	m.observe(cp, call("BootNotification", `{"chargePointVendor": "VendorX"}`), json.RawMessage(`{"status": "Accepted", "interval": 60}`))
+	m.observe(cp, call("Heartbeat", `{}`), json.RawMessage(`{}`))
+	m.observe(cp, call("StatusNotification", `{"connectorId": 1, "status": "Charging"}`), json.RawMessage(`{}`))
+	events := m.observe(cp, call("StartTransaction", `{"connectorId": 1, "idTag": "04E91C5A", "meterStart": 1000, "timestamp": "2025-01-01T08:00:00Z"}`),
+		json.RawMessage(`{"idTagInfo": {"status": "Accepted"}, "transactionId": 42}`))
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, EventTransactionStarted, events[0].Type)
+	assert.Equal(t, "42", events[0].Transaction.Id)
+	assert.Equal(t, "Accepted", events[0].Transaction.IdTagStatus)
+
+	meterValues := call("MeterValues", `{"connectorId": 1, "transactionId": 42, "meterValue": [{"sampledValue": [{"value": "1.5", "unit": "kWh"}, {"value": "16", "measurand": "Current.Import"}]}]}`)
+	assert.Equal(t, map[string]string{KeyTransactionId: "42", KeyConnectorId: "1", KeyIdTag: "04E91C5A"}, m.annotate(cp, meterValues))
+	m.observe(cp, meterValues, json.RawMessage(`{}`))
+	transaction, ok := m.Transaction("CP001", "42")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1500), transaction.MeterValue)
+
+	cp.pending["m1"] = &pendingCall{PendingCall: PendingCall{MessageId: "m1", Action: "Reset"}}
+	session, ok = m.Session("CP001")
+	assert.True(t, ok)
+	assert.Equal(t, "Accepted", session.BootStatus)
+	assert.Equal(t, 60, session.HeartbeatInterval)
+	assert.NotNil(t, session.LastHeartbeat)
+	assert.Equal(t, map[string]string{"1": "Charging"}, session.Connectors)
+	assert.Equal(t, 1, len(session.Transactions))
+	assert.Equal(t, []PendingCall{{MessageId: "m1", Action: "Reset"}}, session.PendingCalls)
+
+	// 断开后保留进行中的交易，被新的连接替换时不认为断开
+	cp2 := &ChargePoint{Id: "CP001", Protocol: ProtocolOCPP16, pending: map[string]*pendingCall{}}
+	m.connect(cp2)
+	_, ok = m.disconnect(cp)
+	assert.False(t, ok)
+	session, ok = m.disconnect(cp2)
+	assert.True(t, ok)
+	assert.False(t, session.Connected)
+	assert.Equal(t, 1, len(session.Transactions))
+	m.connect(cp2)
+
+	events = m.observe(cp2, call("StopTransaction", `{"transactionId": 42, "meterStop": 3500, "timestamp": "2025-01-01T09:00:00Z"}`), json.RawMessage(`{}`))
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, EventTransactionEnded, events[0].Type)
+	assert.Equal(t, float64(2500), events[0].Transaction.Energy)
+	assert.Equal(t, "Local", events[0].Transaction.StopReason)
+	_, ok = m.Transaction("CP001", "42")
+	assert.False(t, ok)
+
+	// OCPP 2.0.1 的交易 Id 由充电桩分配
+	cp3 := &ChargePoint{Id: "CP002", Protocol: ProtocolOCPP201, pending: map[string]*pendingCall{}}
+	m.connect(cp3)
+	m.observe(cp3, call("StatusNotification", `{"timestamp": "2025-01-01T08:00:00Z", "connectorStatus": "Occupied", "evseId": 1, "connectorId": 1}`), json.RawMessage(`{}`))
+	events = m.observe(cp3, call("TransactionEvent", `{"eventType": "Started", "timestamp": "2025-01-01T08:00:00Z", "triggerReason": "Authorized", "seqNo": 0,
+		"transactionInfo": {"transactionId": "tx-1"}, "evse": {"id": 1, "connectorId": 1}, "idToken": {"idToken": "04E91C5A", "type": "ISO14443"},
+		"meterValue": [{"timestamp": "2025-01-01T08:00:00Z", "sampledValue": [{"value": 2, "unitOfMeasure": {"unit": "kWh"}}]}]}`), json.RawMessage(`{}`))
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, Transaction{Id: "tx-1", ChargePointId: "CP002", EvseId: 1, ConnectorId: 1, IdTag: "04E91C5A", MeterStart: 2000, MeterValue: 2000,
+		StartTime: time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)}, events[0].Transaction)
+	events = m.observe(cp3, call("TransactionEvent", `{"eventType": "Ended", "timestamp": "2025-01-01T09:00:00Z", "triggerReason": "StopAuthorized", "seqNo": 1,
+		"transactionInfo": {"transactionId": "tx-1", "stoppedReason": "Remote"},
+		"meterValue": [{"timestamp": "2025-01-01T09:00:00Z", "sampledValue": [{"value": 12.5, "measurand": "Energy.Active.Import.Register", "unitOfMeasure": {"unit": "Wh", "multiplier": 3}}]}]}`), json.RawMessage(`{}`))
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, float64(10500), events[0].Transaction.Energy)
+	assert.Equal(t, "Remote", events[0].Transaction.StopReason)
+
+	sessions := m.Sessions()
+	assert.Equal(t, 2, len(sessions))
+	assert.Equal(t, "CP002", sessions[1].ChargePointId)
+	assert.Equal(t, map[string]string{"1/1": "Occupied"}, sessions[1].Connectors)
+}
+
+func TestOcppSessions(t *testing.T) {
+	config := engine.NewConfig()
+	_, err := engine.New("ocpp-test02", []byte(`{
+		"ruleChain": {"id": "ocpp-test02", "name": "ocpp-test02"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("ocpp-test02")
+
+	ep := (&Ocpp{}).New().(*Ocpp)
+	assert.Nil(t, ep.Init(config, types.Configuration{"server": "127.0.0.1:0", "sessions": true}))
+	events := make(chan types.RuleMsg, 4)
+	for _, event := range []string{EventChargePointConnected, EventTransactionEnded, "StopTransaction"} {
+		_, err = ep.AddRouter(impl.NewRouter().From(event).To("chain:ocpp-test02").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			events <- *exchange.In.GetMsg()
+			return true
+		}).End())
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	cp, err := dialChargePoint(t, ep.Addr().String(), "CP001", "ocpp1.6")
+	assert.Nil(t, err)
+	defer cp.Close()
+	msg := <-events
+	assert.Equal(t, EventChargePointConnected, msg.Type)
+	assert.Equal(t, "CP001", msg.Metadata.GetValue(KeyChargePointId))
+
+	// 没有路由的会话 CALL 回复默认的响应
+	m := cp.call(t, "Heartbeat", `{}`)
+	assert.Equal(t, MessageTypeCallResult, m.Type)
+	m = cp.call(t, "StartTransaction", `{"connectorId": 1, "idTag": "04E91C5A", "meterStart": 100, "timestamp": "2025-01-01T08:00:00Z"}`)
+	var start struct {
+		TransactionId int64 `json:"transactionId"`
+	}
+	assert.Nil(t, json.Unmarshal(m.Payload, &start))
+	transactionId := fmt.Sprint(start.TransactionId)
+	session, ok := ep.Sessions().Session("CP001")
+	assert.True(t, ok)
+	assert.NotNil(t, session.LastHeartbeat)
+	assert.Equal(t, transactionId, session.Transactions[0].Id)
+
+	cp.call(t, "StopTransaction", fmt.Sprintf(`{"transactionId": %s, "meterStop": 600, "timestamp": "2025-01-01T09:00:00Z"}`, transactionId))
+	msg = <-events
+	assert.Equal(t, "StopTransaction", msg.Type)
+	assert.Equal(t, transactionId, msg.Metadata.GetValue(KeyTransactionId))
+	assert.Equal(t, "04E91C5A", msg.Metadata.GetValue(KeyIdTag))
+	select {
+	case msg = <-events:
+		assert.Equal(t, EventTransactionEnded, msg.Type)
+		assert.Equal(t, "1", msg.Metadata.GetValue(KeyConnectorId))
+		var transaction Transaction
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &transaction))
+		assert.Equal(t, float64(500), transaction.Energy)
+	case <-time.After(time.Second):
+		t.Fatal("transaction ended event not routed")
+	}
+	assert.Nil(t, (&Ocpp{}).New().(*Ocpp).Sessions())
+}
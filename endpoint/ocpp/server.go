@@ -0,0 +1,389 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ocpp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultTimeout 等待充电桩响应和发送的默认超时
+const DefaultTimeout = 30 * time.Second
+
+// maxMessageSize 单个 OCPP-J 消息的最大长度
+const maxMessageSize = 1 << 20
+
+// ErrChargePointNotConnected 充电桩未连接
+var ErrChargePointNotConnected = errors.New("ocpp charge point not connected")
+
+// CallHandler 处理充电桩发送的 CALL，返回 CALLRESULT 的负荷；返回 *CallError 时回复对应的 CALLERROR，其他错误回复 InternalError
+type CallHandler func(cp *ChargePoint, call *Message) (json.RawMessage, error)
+
+// Server OCPP-J 中央系统的 WebSocket 服务，充电桩连接 ws://host:port/<path>/<chargePointId>
+type Server struct {
+	// Path 路径前缀，eg. /ocpp
+	Path string
+	// Protocols 支持的子协议，按优先级排列
+	Protocols []string
+	// Timeout 等待充电桩响应和发送的超时
+	Timeout time.Duration
+	// OnCall 处理充电桩发送的 CALL
+	OnCall CallHandler
+	// OnConnect 充电桩连接后调用，可以为空
+	OnConnect func(cp *ChargePoint)
+	// OnDisconnect 充电桩断开后调用，可以为空
+	OnDisconnect func(cp *ChargePoint)
+
+	mu           sync.Mutex
+	chargePoints map[string]*ChargePoint
+	httpServer   *http.Server
+	closed       bool
+}
+
+// Serve 在 l 上接受充电桩的连接，直到 Close
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	s.httpServer = &http.Server{Handler: s}
+	httpServer := s.httpServer
+	s.mu.Unlock()
+	return httpServer.Serve(l)
+}
+
+// Close 停止服务并断开所有充电桩
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	httpServer := s.httpServer
+	chargePoints := s.chargePoints
+	s.chargePoints = nil
+	s.mu.Unlock()
+	var err error
+	if httpServer != nil {
+		err = httpServer.Close()
+	}
+	// 升级后的连接已经被接管，不会随 http.Server 关闭
+	for _, cp := range chargePoints {
+		cp.Close()
+	}
+	return err
+}
+
+// ChargePoint 获取已连接的充电桩
+func (s *Server) ChargePoint(id string) (*ChargePoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.chargePoints[id]
+	return cp, ok
+}
+
+// ChargePoints 已连接的充电桩 Id
+func (s *Server) ChargePoints() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.chargePoints))
+	for id := range s.chargePoints {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Call 向充电桩发送 CALL 并等待 CALLRESULT，充电桩回复 CALLERROR 时返回 *CallError
+func (s *Server) Call(chargePointId, action string, payload json.RawMessage) (json.RawMessage, error) {
+	cp, ok := s.ChargePoint(chargePointId)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChargePointNotConnected, chargePointId)
+	}
+	return cp.Call(action, payload)
+}
+
+// ServeHTTP 升级 WebSocket 连接，路径的最后一段为充电桩 Id，需要协商 OCPP 子协议
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSuffix(s.Path, "/") + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, prefix)
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	protocol := s.selectProtocol(websocket.Subprotocols(r))
+	if protocol == "" {
+		http.Error(w, "unsupported ocpp subprotocol", http.StatusBadRequest)
+		return
+	}
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{protocol},
+		// 充电桩不是浏览器，不检查 Origin
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(maxMessageSize)
+	cp := &ChargePoint{
+		Id:         id,
+		Protocol:   protocol,
+		RemoteAddr: r.RemoteAddr,
+		server:     s,
+		conn:       conn,
+		pending:    map[string]*pendingCall{},
+		done:       make(chan struct{}),
+	}
+	if !s.register(cp) {
+		cp.Close()
+		return
+	}
+	if s.OnConnect != nil {
+		s.OnConnect(cp)
+	}
+	cp.readLoop()
+	s.unregister(cp)
+	if s.OnDisconnect != nil {
+		s.OnDisconnect(cp)
+	}
+}
+
+// selectProtocol 按服务端的优先级选择充电桩提供的子协议
+func (s *Server) selectProtocol(offered []string) string {
+	for _, p := range s.Protocols {
+		for _, o := range offered {
+			if strings.EqualFold(p, o) {
+				return o
+			}
+		}
+	}
+	return ""
+}
+
+// register 注册充电桩，同一个 Id 重新连接时断开原有连接
+func (s *Server) register(cp *ChargePoint) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+	if s.chargePoints == nil {
+		s.chargePoints = map[string]*ChargePoint{}
+	}
+	old := s.chargePoints[cp.Id]
+	s.chargePoints[cp.Id] = cp
+	s.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	return true
+}
+
+func (s *Server) unregister(cp *ChargePoint) {
+	s.mu.Lock()
+	if s.chargePoints[cp.Id] == cp {
+		delete(s.chargePoints, cp.Id)
+	}
+	s.mu.Unlock()
+	cp.Close()
+}
+
+func (s *Server) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return s.Timeout
+}
+
+// ChargePoint 已连接的充电桩
+type ChargePoint struct {
+	// Id 充电桩 Id，连接路径的最后一段
+	Id string
+	// Protocol 协商的子协议：ocpp1.6 或者 ocpp2.0.1
+	Protocol string
+	// RemoteAddr 充电桩的地址
+	RemoteAddr string
+
+	server *Server
+	conn   *websocket.Conn
+	// writeMu 保护 conn 的写入
+	writeMu sync.Mutex
+	// callMu 中央系统同一时间只能有一个未完成的 CALL
+	callMu sync.Mutex
+	// mu 保护 pending
+	mu        sync.Mutex
+	pending   map[string]*pendingCall
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// pendingCall 等待响应的 CALL
+type pendingCall struct {
+	PendingCall
+	ch chan *Message
+}
+
+// PendingCalls 等待充电桩响应的 CALL
+func (cp *ChargePoint) PendingCalls() []PendingCall {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	var calls []PendingCall
+	for _, p := range cp.pending {
+		calls = append(calls, p.PendingCall)
+	}
+	return calls
+}
+
+// Close 断开连接
+func (cp *ChargePoint) Close() {
+	cp.closeOnce.Do(func() {
+		close(cp.done)
+		_ = cp.conn.Close()
+	})
+}
+
+// Call 发送 CALL 并等待 CALLRESULT，充电桩回复 CALLERROR 时返回 *CallError
+func (cp *ChargePoint) Call(action string, payload json.RawMessage) (json.RawMessage, error) {
+	if len(payload) > 0 && !isObject(payload) {
+		return nil, errors.New("ocpp payload must be a json object")
+	}
+	cp.callMu.Lock()
+	defer cp.callMu.Unlock()
+
+	id, err := newMessageId()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *Message, 1)
+	cp.mu.Lock()
+	cp.pending[id] = &pendingCall{PendingCall: PendingCall{MessageId: id, Action: action, SentAt: time.Now()}, ch: ch}
+	cp.mu.Unlock()
+	defer func() {
+		cp.mu.Lock()
+		delete(cp.pending, id)
+		cp.mu.Unlock()
+	}()
+	if err = cp.send(&Message{Type: MessageTypeCall, Id: id, Action: action, Payload: payload}); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(cp.server.timeout())
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		if resp.Type == MessageTypeCallError {
+			return nil, resp.Error
+		}
+		return resp.Payload, nil
+	case <-cp.done:
+		return nil, fmt.Errorf("%w: %s", ErrChargePointNotConnected, cp.Id)
+	case <-timer.C:
+		return nil, fmt.Errorf("ocpp %s %s: timeout after %v", cp.Id, action, cp.server.timeout())
+	}
+}
+
+func (cp *ChargePoint) send(m *Message) error {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	cp.writeMu.Lock()
+	defer cp.writeMu.Unlock()
+	_ = cp.conn.SetWriteDeadline(time.Now().Add(cp.server.timeout()))
+	if err = cp.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		cp.Close()
+	}
+	return err
+}
+
+// readLoop 读取消息直到连接断开，CALL 在单独的协程中处理，不阻塞响应的接收
+func (cp *ChargePoint) readLoop() {
+	for {
+		messageType, data, err := cp.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+		m, err := ParseMessage(data)
+		if err != nil {
+			// 无法解析的 CALL 回复错误，无法获取消息 Id 时使用 -1
+			if m == nil || m.Type == MessageTypeCall || m.Id == "" {
+				id := "-1"
+				if m != nil && m.Id != "" {
+					id = m.Id
+				}
+				_ = cp.send(&Message{Type: MessageTypeCallError, Id: id, Error: &CallError{Code: formatViolation(cp.Protocol), Description: err.Error()}})
+			}
+			continue
+		}
+		switch m.Type {
+		case MessageTypeCall:
+			go cp.handleCall(m)
+		default:
+			cp.mu.Lock()
+			p, ok := cp.pending[m.Id]
+			cp.mu.Unlock()
+			if ok {
+				p.ch <- m
+			}
+		}
+	}
+}
+
+// handleCall 处理 CALL 并回复
+func (cp *ChargePoint) handleCall(m *Message) {
+	var payload json.RawMessage
+	err := &CallError{Code: ErrorNotImplemented, Description: "no handler for action " + m.Action}
+	if handler := cp.server.OnCall; handler != nil {
+		p, e := handler(cp, m)
+		switch {
+		case e == nil && len(p) > 0 && !isObject(p):
+			err = &CallError{Code: ErrorInternalError, Description: "response payload must be a json object"}
+		case e == nil:
+			payload, err = p, nil
+		case !errors.As(e, &err):
+			err = &CallError{Code: ErrorInternalError, Description: e.Error()}
+		}
+	}
+	if err != nil {
+		_ = cp.send(&Message{Type: MessageTypeCallError, Id: m.Id, Error: err})
+		return
+	}
+	_ = cp.send(&Message{Type: MessageTypeCallResult, Id: m.Id, Payload: payload})
+}
+
+// newMessageId 随机的消息 Id
+func newMessageId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
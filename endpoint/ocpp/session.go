@@ -0,0 +1,508 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ocpp
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 会话事件，开启会话跟踪后作为消息类型路由到 from 为事件名称或者 * 的路由
+const (
+	// EventChargePointConnected 充电桩连接，消息负荷为会话
+	EventChargePointConnected = "ChargePointConnected"
+	// EventChargePointDisconnected 充电桩断开，消息负荷为会话
+	EventChargePointDisconnected = "ChargePointDisconnected"
+	// EventTransactionStarted 交易开始，消息负荷为交易
+	EventTransactionStarted = "TransactionStarted"
+	// EventTransactionEnded 交易结束，消息负荷为交易
+	EventTransactionEnded = "TransactionEnded"
+)
+
+// measurandEnergy 默认的测量值，电表读数
+const measurandEnergy = "Energy.Active.Import.Register"
+
+// sessionActions 会话管理器处理的充电桩 CALL
+var sessionActions = map[string]bool{
+	"BootNotification":   true,
+	"Heartbeat":          true,
+	"StatusNotification": true,
+	"StartTransaction":   true,
+	"StopTransaction":    true,
+	"MeterValues":        true,
+	"TransactionEvent":   true,
+}
+
+// Session 充电桩会话的快照
+type Session struct {
+	ChargePointId string `json:"chargePointId"`
+	Protocol      string `json:"protocol"`
+	RemoteAddr    string `json:"remoteAddr"`
+	Connected     bool   `json:"connected"`
+	// ConnectedAt 最近一次连接的时间
+	ConnectedAt time.Time `json:"connectedAt"`
+	// DisconnectedAt 断开的时间，在线时为空
+	DisconnectedAt *time.Time `json:"disconnectedAt,omitempty"`
+	// LastSeen 最近一次收到 CALL 的时间
+	LastSeen time.Time `json:"lastSeen"`
+	// LastHeartbeat 最近一次 Heartbeat 的时间
+	LastHeartbeat *time.Time `json:"lastHeartbeat,omitempty"`
+	// Boot 最近一次 BootNotification 的负荷
+	Boot json.RawMessage `json:"boot,omitempty"`
+	// BootStatus BootNotification 响应的状态，eg. Accepted
+	BootStatus string `json:"bootStatus,omitempty"`
+	// HeartbeatInterval BootNotification 响应的心跳间隔，单位秒
+	HeartbeatInterval int `json:"heartbeatInterval,omitempty"`
+	// Connectors 连接器状态，OCPP 1.6 的 key 为 connectorId，OCPP 2.0.1 为 evseId/connectorId
+	Connectors map[string]string `json:"connectors,omitempty"`
+	// Transactions 进行中的交易
+	Transactions []Transaction `json:"transactions,omitempty"`
+	// PendingCalls 中央系统发送的等待响应的 CALL
+	PendingCalls []PendingCall `json:"pendingCalls,omitempty"`
+}
+
+// Transaction 充电交易，电表读数单位为 Wh
+type Transaction struct {
+	Id            string `json:"transactionId"`
+	ChargePointId string `json:"chargePointId"`
+	EvseId        int    `json:"evseId,omitempty"`
+	ConnectorId   int    `json:"connectorId"`
+	IdTag         string `json:"idTag,omitempty"`
+	// IdTagStatus 授权状态，OCPP 1.6 为 StartTransaction 响应的 idTagInfo.status
+	IdTagStatus string  `json:"idTagStatus,omitempty"`
+	MeterStart  float64 `json:"meterStart"`
+	// MeterValue 最近一次的电表读数
+	MeterValue float64    `json:"meterValue"`
+	StartTime  time.Time  `json:"startTime"`
+	StopTime   *time.Time `json:"stopTime,omitempty"`
+	StopReason string     `json:"stopReason,omitempty"`
+	// Energy 交易结束时的充电量
+	Energy float64 `json:"energy,omitempty"`
+}
+
+// PendingCall 等待充电桩响应的 CALL
+type PendingCall struct {
+	MessageId string    `json:"messageId"`
+	Action    string    `json:"action"`
+	SentAt    time.Time `json:"sentAt"`
+}
+
+// sessionEvent 会话状态变化产生的事件
+type sessionEvent struct {
+	Type        string
+	Transaction Transaction
+}
+
+type session struct {
+	Session
+	cp           *ChargePoint
+	transactions map[string]*Transaction
+}
+
+// snapshot 会话的副本，交易按开始时间排列
+func (s *session) snapshot() Session {
+	v := s.Session
+	if s.Connectors != nil {
+		v.Connectors = make(map[string]string, len(s.Connectors))
+		for k, status := range s.Connectors {
+			v.Connectors[k] = status
+		}
+	}
+	v.Transactions = nil
+	for _, t := range s.transactions {
+		v.Transactions = append(v.Transactions, *t)
+	}
+	sort.Slice(v.Transactions, func(i, j int) bool {
+		return v.Transactions[i].StartTime.Before(v.Transactions[j].StartTime)
+	})
+	if s.cp != nil {
+		v.PendingCalls = s.cp.PendingCalls()
+	}
+	return v
+}
+
+// SessionManager 跟踪充电桩的连接、心跳、连接器状态、交易和未完成的 CALL
+// 充电桩断开后保留会话和进行中的交易，重新连接后继续跟踪
+type SessionManager struct {
+	mu       sync.RWMutex
+	sessions map[string]*session
+}
+
+// NewSessionManager 创建会话管理器
+func NewSessionManager() *SessionManager {
+	return &SessionManager{sessions: map[string]*session{}}
+}
+
+// Session 获取充电桩的会话
+func (m *SessionManager) Session(chargePointId string) (Session, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[chargePointId]
+	if !ok {
+		return Session{}, false
+	}
+	return s.snapshot(), true
+}
+
+// Sessions 所有充电桩的会话，按充电桩 Id 排列
+func (m *SessionManager) Sessions() []Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		list = append(list, s.snapshot())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ChargePointId < list[j].ChargePointId
+	})
+	return list
+}
+
+// Transaction 获取充电桩进行中的交易
+func (m *SessionManager) Transaction(chargePointId, transactionId string) (Transaction, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if s, ok := m.sessions[chargePointId]; ok {
+		if t, ok := s.transactions[transactionId]; ok {
+			return *t, true
+		}
+	}
+	return Transaction{}, false
+}
+
+// connect 充电桩连接，同一个 Id 重新连接时沿用原有的会话
+func (m *SessionManager) connect(cp *ChargePoint) Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[cp.Id]
+	if !ok {
+		s = &session{Session: Session{ChargePointId: cp.Id}, transactions: map[string]*Transaction{}}
+		m.sessions[cp.Id] = s
+	}
+	now := time.Now()
+	s.cp = cp
+	s.Protocol, s.RemoteAddr = cp.Protocol, cp.RemoteAddr
+	s.Connected, s.ConnectedAt, s.DisconnectedAt, s.LastSeen = true, now, nil, now
+	return s.snapshot()
+}
+
+// disconnect 充电桩断开，连接已经被新的连接替换时返回 false
+func (m *SessionManager) disconnect(cp *ChargePoint) (Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[cp.Id]
+	if !ok || s.cp != cp {
+		return Session{}, false
+	}
+	now := time.Now()
+	s.cp = nil
+	s.Connected, s.DisconnectedAt = false, &now
+	return s.snapshot(), true
+}
+
+// annotate 充电桩 CALL 关联的交易信息，放在消息元数据中
+func (m *SessionManager) annotate(cp *ChargePoint, call *Message) map[string]string {
+	id := transactionIdOf(call)
+	if id == "" {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[cp.Id]
+	if !ok {
+		return nil
+	}
+	t, ok := s.transactions[id]
+	if !ok {
+		return nil
+	}
+	values := map[string]string{
+		KeyTransactionId: t.Id,
+		KeyConnectorId:   strconv.Itoa(t.ConnectorId),
+	}
+	if t.IdTag != "" {
+		values[KeyIdTag] = t.IdTag
+	}
+	return values
+}
+
+// observe 根据充电桩的 CALL 和中央系统的响应更新会话，返回交易事件
+func (m *SessionManager) observe(cp *ChargePoint, call *Message, response json.RawMessage) []sessionEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[cp.Id]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	s.LastSeen = now
+	switch call.Action {
+	case "BootNotification":
+		var resp struct {
+			Status   string `json:"status"`
+			Interval int    `json:"interval"`
+		}
+		_ = json.Unmarshal(response, &resp)
+		s.Boot, s.BootStatus, s.HeartbeatInterval = call.Payload, resp.Status, resp.Interval
+	case "Heartbeat":
+		s.LastHeartbeat = &now
+	case "StatusNotification":
+		var req struct {
+			ConnectorId     int    `json:"connectorId"`
+			Status          string `json:"status"`
+			EvseId          int    `json:"evseId"`
+			ConnectorStatus string `json:"connectorStatus"`
+		}
+		if json.Unmarshal(call.Payload, &req) != nil {
+			return nil
+		}
+		if s.Connectors == nil {
+			s.Connectors = map[string]string{}
+		}
+		if cp.Protocol == ProtocolOCPP16 {
+			s.Connectors[strconv.Itoa(req.ConnectorId)] = req.Status
+		} else {
+			s.Connectors[strconv.Itoa(req.EvseId)+"/"+strconv.Itoa(req.ConnectorId)] = req.ConnectorStatus
+		}
+	case "StartTransaction":
+		return s.startTransaction16(call, response, now)
+	case "StopTransaction":
+		return s.stopTransaction16(call, now)
+	case "MeterValues":
+		var req struct {
+			TransactionId json.RawMessage `json:"transactionId"`
+			MeterValue    []meterValue    `json:"meterValue"`
+		}
+		if json.Unmarshal(call.Payload, &req) != nil {
+			return nil
+		}
+		if t, ok := s.transactions[idString(req.TransactionId)]; ok {
+			if v, ok := energyOf(req.MeterValue); ok {
+				t.MeterValue = v
+			}
+		}
+	case "TransactionEvent":
+		return s.transactionEvent(call, now)
+	}
+	return nil
+}
+
+// startTransaction16 OCPP 1.6 交易开始，交易 Id 由中央系统在响应中分配
+func (s *session) startTransaction16(call *Message, response json.RawMessage, now time.Time) []sessionEvent {
+	var req struct {
+		ConnectorId int     `json:"connectorId"`
+		IdTag       string  `json:"idTag"`
+		MeterStart  float64 `json:"meterStart"`
+		Timestamp   string  `json:"timestamp"`
+	}
+	var resp struct {
+		TransactionId json.RawMessage `json:"transactionId"`
+		IdTagInfo     struct {
+			Status string `json:"status"`
+		} `json:"idTagInfo"`
+	}
+	if json.Unmarshal(call.Payload, &req) != nil || json.Unmarshal(response, &resp) != nil {
+		return nil
+	}
+	id := idString(resp.TransactionId)
+	if id == "" {
+		return nil
+	}
+	t := &Transaction{
+		Id:            id,
+		ChargePointId: s.ChargePointId,
+		ConnectorId:   req.ConnectorId,
+		IdTag:         req.IdTag,
+		IdTagStatus:   resp.IdTagInfo.Status,
+		MeterStart:    req.MeterStart,
+		MeterValue:    req.MeterStart,
+		StartTime:     timeOf(req.Timestamp, now),
+	}
+	s.transactions[id] = t
+	return []sessionEvent{{Type: EventTransactionStarted, Transaction: *t}}
+}
+
+// stopTransaction16 OCPP 1.6 交易结束
+func (s *session) stopTransaction16(call *Message, now time.Time) []sessionEvent {
+	var req struct {
+		TransactionId json.RawMessage `json:"transactionId"`
+		MeterStop     float64         `json:"meterStop"`
+		Timestamp     string          `json:"timestamp"`
+		Reason        string          `json:"reason"`
+	}
+	if json.Unmarshal(call.Payload, &req) != nil {
+		return nil
+	}
+	t, ok := s.transactions[idString(req.TransactionId)]
+	if !ok {
+		return nil
+	}
+	t.MeterValue = req.MeterStop
+	// 没有原因时为 Local
+	reason := req.Reason
+	if reason == "" {
+		reason = "Local"
+	}
+	return s.endTransaction(t, timeOf(req.Timestamp, now), reason)
+}
+
+// transactionEvent OCPP 2.0.1 的 TransactionEvent，交易 Id 由充电桩分配，未知交易的 Updated 事件视为交易开始
+func (s *session) transactionEvent(call *Message, now time.Time) []sessionEvent {
+	var req struct {
+		EventType       string `json:"eventType"`
+		Timestamp       string `json:"timestamp"`
+		TransactionInfo struct {
+			TransactionId string `json:"transactionId"`
+			StoppedReason string `json:"stoppedReason"`
+		} `json:"transactionInfo"`
+		Evse *struct {
+			Id          int `json:"id"`
+			ConnectorId int `json:"connectorId"`
+		} `json:"evse"`
+		IdToken *struct {
+			IdToken string `json:"idToken"`
+		} `json:"idToken"`
+		MeterValue []meterValue `json:"meterValue"`
+	}
+	if json.Unmarshal(call.Payload, &req) != nil || req.TransactionInfo.TransactionId == "" {
+		return nil
+	}
+	id := req.TransactionInfo.TransactionId
+	var events []sessionEvent
+	t, known := s.transactions[id]
+	if !known {
+		if req.EventType == "Ended" {
+			return nil
+		}
+		t = &Transaction{Id: id, ChargePointId: s.ChargePointId, StartTime: timeOf(req.Timestamp, now)}
+		if v, ok := energyOf(req.MeterValue); ok {
+			t.MeterStart = v
+		}
+		s.transactions[id] = t
+	}
+	// evse 和 idToken 只在变化后的第一个事件中发送
+	if req.Evse != nil {
+		t.EvseId, t.ConnectorId = req.Evse.Id, req.Evse.ConnectorId
+	}
+	if req.IdToken != nil && req.IdToken.IdToken != "" {
+		t.IdTag = req.IdToken.IdToken
+	}
+	if v, ok := energyOf(req.MeterValue); ok {
+		t.MeterValue = v
+	}
+	if !known {
+		events = append(events, sessionEvent{Type: EventTransactionStarted, Transaction: *t})
+	}
+	if req.EventType == "Ended" {
+		reason := req.TransactionInfo.StoppedReason
+		if reason == "" {
+			reason = "Local"
+		}
+		events = append(events, s.endTransaction(t, timeOf(req.Timestamp, now), reason)...)
+	}
+	return events
+}
+
+// endTransaction 结束交易并计算充电量
+func (s *session) endTransaction(t *Transaction, stopTime time.Time, reason string) []sessionEvent {
+	delete(s.transactions, t.Id)
+	t.StopTime, t.StopReason = &stopTime, reason
+	t.Energy = t.MeterValue - t.MeterStart
+	return []sessionEvent{{Type: EventTransactionEnded, Transaction: *t}}
+}
+
+// meterValue OCPP 1.6 的读数为字符串，OCPP 2.0.1 为数字
+type meterValue struct {
+	SampledValue []struct {
+		Value         json.RawMessage `json:"value"`
+		Measurand     string          `json:"measurand"`
+		Unit          string          `json:"unit"`
+		UnitOfMeasure *struct {
+			Unit       string `json:"unit"`
+			Multiplier int    `json:"multiplier"`
+		} `json:"unitOfMeasure"`
+	} `json:"sampledValue"`
+}
+
+// energyOf 最后一个电表读数，单位转换为 Wh
+func energyOf(values []meterValue) (float64, bool) {
+	var energy float64
+	var found bool
+	for _, mv := range values {
+		for _, sv := range mv.SampledValue {
+			if sv.Measurand != "" && sv.Measurand != measurandEnergy {
+				continue
+			}
+			v, err := strconv.ParseFloat(idString(sv.Value), 64)
+			if err != nil {
+				continue
+			}
+			unit := sv.Unit
+			if sv.UnitOfMeasure != nil {
+				unit = sv.UnitOfMeasure.Unit
+				v *= math.Pow10(sv.UnitOfMeasure.Multiplier)
+			}
+			if unit == "kWh" {
+				v *= 1000
+			}
+			energy, found = v, true
+		}
+	}
+	return energy, found
+}
+
+// transactionIdOf 充电桩 CALL 中的交易 Id
+func transactionIdOf(call *Message) string {
+	var req struct {
+		TransactionId   json.RawMessage `json:"transactionId"`
+		TransactionInfo struct {
+			TransactionId string `json:"transactionId"`
+		} `json:"transactionInfo"`
+	}
+	if json.Unmarshal(call.Payload, &req) != nil {
+		return ""
+	}
+	if req.TransactionInfo.TransactionId != "" {
+		return req.TransactionInfo.TransactionId
+	}
+	return idString(req.TransactionId)
+}
+
+// idString JSON 数字或者字符串转换为字符串
+func idString(raw json.RawMessage) string {
+	s := strings.TrimSpace(string(raw))
+	if s == "null" {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+// timeOf 解析 RFC3339 时间，无法解析时使用 now
+func timeOf(s string, now time.Time) time.Time {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	return now
+}
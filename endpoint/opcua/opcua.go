@@ -21,6 +21,8 @@ import (
 	"encoding/json"
 	"log"
 	"net/textproto"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/gopcua/opcua"
@@ -28,6 +30,7 @@ import (
 	"github.com/robfig/cron/v3"
 
 	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/poll"
 	"github.com/rulego/rulego/api/types"
 	endpointApi "github.com/rulego/rulego/api/types/endpoint"
 	"github.com/rulego/rulego/components/base"
@@ -44,11 +47,23 @@ type Endpoint = OpcUa
 
 var _ endpointApi.Endpoint = (*Endpoint)(nil)
 
+// endpoints 已添加路由的端点，key 为路由 Id
+// 多个端点可以连接同一个服务器，所以不使用服务器地址作为 key
+var endpoints sync.Map
+
 // 注册组件
 func init() {
 	_ = endpoint.Registry.Register(&Endpoint{})
 }
 
+// lookupEndpoint 根据路由 Id 查找端点
+func lookupEndpoint(id string) (*OpcUa, bool) {
+	if v, ok := endpoints.Load(id); ok {
+		return v.(*OpcUa), true
+	}
+	return nil, false
+}
+
 type RequestMessage struct {
 	headers    textproto.MIMEHeader
 	body       []byte
@@ -56,10 +71,14 @@ type RequestMessage struct {
 	msg        *types.RuleMsg
 	statusCode int
 	err        error
+	// output 负荷格式，为 nil 输出读取结果数组
+	output *outputFormat
+	// readTime 读取时间
+	readTime time.Time
 }
 
 func (r *RequestMessage) Body() []byte {
-	b, err := json.Marshal(r.data)
+	b, err := json.Marshal(r.output.build(r.data, r.readTime))
 	if err != nil {
 		log.Println(err)
 	}
@@ -180,10 +199,10 @@ func (r *ResponseMessage) GetError() error {
 type OpcUaConfig struct {
 	//OPC UA Server Endpoint, eg. opc.tcp://localhost:4840
 	Server string `json:"server" label:"Server" desc:"OPC UA server endpoint, format: opc.tcp://host:port" required:"true" ref:"primary"`
-	//Security Policy URL or one of None, Basic128Rsa15, Basic256, Basic256Sha256
-	Policy string `json:"policy" label:"Security Policy" desc:"Security policy: None, Basic128Rsa15, Basic256, Basic256Sha256"`
-	//Security Mode: one of None, Sign, SignAndEncrypt
-	Mode string `json:"mode" label:"Security Mode" desc:"Security mode: None, Sign, SignAndEncrypt"`
+	//Security Policy URL or one of auto, None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss, deprecated Basic128Rsa15, Basic256
+	Policy string `json:"policy" label:"Security Policy" desc:"Security policy: auto (strongest supported by client and server), None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss. Basic128Rsa15 and Basic256 are deprecated"`
+	//Security Mode: one of auto, None, Sign, SignAndEncrypt
+	Mode string `json:"mode" label:"Security Mode" desc:"Security mode: auto, None, Sign, SignAndEncrypt"`
 	//Authentication Mode: one of Anonymous, UserName, Certificate
 	Auth string `json:"auth" label:"Auth Mode" desc:"Authentication mode: Anonymous, UserName, Certificate"`
 	//Authentication Username
@@ -196,9 +215,34 @@ type OpcUaConfig struct {
 	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"Client private key file path" ref:"shared"`
 	//Interval to read, supports cron expressions
 	//example: @every 1m (every 1 minute) 0 0 0 * * * (triggers at midnight)
-	Interval string `json:"interval" label:"Interval" desc:"Read interval, supports cron expression, e.g. @every 1m"`
+	//fixed periods read at a fixed rate with drift compensation, down to 10ms, eg. 200ms
+	Interval string `json:"interval" label:"Interval" desc:"Read interval, supports cron expression, e.g. @every 1m. Fixed periods read at a fixed rate without drift, down to 10ms, e.g. 200ms"`
 	//NodeIds to read, eg. ns=2;s=Channel1.Device1.Tag1
 	NodeIds []string `json:"nodeIds" label:"Node IDs" desc:"OPC UA node IDs to read, e.g. ns=2;s=Channel1.Device1.Tag1"`
+	//BatchSize max node ids per read request, 0 means use server MaxNodesPerRead limit
+	BatchSize int `json:"batchSize" label:"Batch Size" desc:"Max node IDs per read request, 0 uses server MaxNodesPerRead limit"`
+	//Timeout read request timeout in seconds, 0 means no timeout
+	Timeout int `json:"timeout" label:"Timeout" desc:"Read request timeout in seconds, 0 means no timeout"`
+	//MaxAge max age of cached value in milliseconds, 0 means read the latest value from device
+	MaxAge float64 `json:"maxAge" label:"Max Age" desc:"Max age of cached value in milliseconds, 0 reads the latest value from device"`
+	//TimestampsToReturn one of Source, Server, Both, Neither
+	TimestampsToReturn string `json:"timestampsToReturn" label:"Timestamps To Return" desc:"Timestamps to return: Source, Server, Both, Neither"`
+	//Enrich whether to add unit, min, max and description of each node to the read results, read once and cached
+	Enrich bool `json:"enrich" label:"Enrich" desc:"Add unit, min, max and description of each node to the read results, read once and cached"`
+	//Array output of array and matrix values: JSON array, one value per index or an index range
+	Array opcuaClient.ArrayOptions `json:"array" label:"Array" desc:"Output of array and matrix values: JSON array (default), explode to one value per index, and an optional index range"`
+	//RegisterNodes whether to register node ids once after connecting and use the registered node ids for cyclic reads
+	RegisterNodes bool `json:"registerNodes" label:"Register Nodes" desc:"Register node IDs once after connecting and use the registered IDs for cyclic reads"`
+	//ShutdownTimeout max seconds to wait for in-flight reads and DoProcess calls on shutdown before closing the session
+	ShutdownTimeout int `json:"shutdownTimeout" label:"Shutdown Timeout" desc:"Max seconds to wait for in-flight reads on shutdown before closing the session, default 10"`
+	//Backpressure overlap policy when the rule chain is slower than the read interval: allow, skip, queue or dropOldest
+	Backpressure poll.Config `json:"backpressure" label:"Backpressure" desc:"Overlap policy and max in-flight reads when the rule chain is slower than the read interval"`
+	//Schedule wall clock alignment and random start offset of the read interval, spreads the load of many endpoints with the same interval
+	Schedule poll.ScheduleOptions `json:"schedule" label:"Schedule" desc:"Wall clock alignment and random start offset of the read interval to spread the load of many endpoints"`
+	//AutoDiscover browse a root folder at startup and on a schedule, add matching variables to the read list and emit OPC_UA_INVENTORY messages on changes
+	AutoDiscover AutoDiscoverConfig `json:"autoDiscover" label:"Auto Discover" desc:"Browse a root folder at startup and on a schedule, add matching variables to the read list and emit OPC_UA_INVENTORY messages on changes"`
+	//Output payload format of the read results: array, object keyed by node or a custom template
+	Output OutputOptions `json:"output" label:"Output" desc:"Payload format of the read results: array (default), object keyed by node, or a template with device, timestamp and values"`
 }
 
 func (c OpcUaConfig) GetServer() string {
@@ -232,6 +276,8 @@ type OpcUa struct {
 	// GracefulShutdown provides graceful shutdown capabilities
 	// GracefulShutdown 提供优雅停机功能
 	base.GracefulShutdown
+	// Pauser 暂停和恢复轮询，见 poll.Pausable
+	poll.Pauser
 	RuleConfig types.Config
 	// opcua client相关配置
 	Config OpcUaConfig
@@ -241,6 +287,32 @@ type OpcUa struct {
 	cronTask *cron.Cron
 	// 定时任务id
 	taskId cron.EntryID
+	// reloadLock 保护热更新的点位列表和读取间隔
+	reloadLock sync.RWMutex
+	// registerLock 保护已注册点位
+	registerLock sync.Mutex
+	// registeredClient 注册点位时使用的客户端，客户端重连后需要重新注册
+	registeredClient *opcua.Client
+	// registeredFor 注册时使用的点位列表
+	registeredFor []string
+	// registeredNodeIds 注册后的点位列表，顺序与 registeredFor 一致
+	registeredNodeIds []string
+	// registerFailed registeredClient 注册失败，使用配置的点位读取
+	registerFailed bool
+	// limiter 背压控制，限制同时交给规则链处理的读取结果，由 reloadLock 保护
+	limiter *poll.Limiter
+	// autoDiscover 自动发现配置，未开启为 nil
+	autoDiscover *autoDiscover
+	// discoverTaskId 重新浏览的定时任务id
+	discoverTaskId cron.EntryID
+	// discoverLock 避免同时执行多次浏览
+	discoverLock sync.Mutex
+	// discovered 自动发现的点位，由 reloadLock 保护
+	discovered []opcuaClient.BrowsedVariable
+	// discoveredOnce 是否已经完成过一次浏览，由 reloadLock 保护
+	discoveredOnce bool
+	// output 读取结果的负荷格式
+	output *outputFormat
 }
 
 // Type 组件类型
@@ -252,11 +324,14 @@ func (x *OpcUa) Type() string {
 func (x *OpcUa) New() types.Node {
 	return &OpcUa{
 		Config: OpcUaConfig{
-			Interval: "@every 1m",
-			Server:   "opc.tcp://localhost:4840",
-			Policy:   "None",
-			Mode:     "none",
-			Auth:     "anonymous",
+			Interval:           "@every 1m",
+			Server:             "opc.tcp://localhost:4840",
+			Policy:             "None",
+			Mode:               "none",
+			Auth:               "anonymous",
+			MaxAge:             opcuaClient.DefaultMaxAge,
+			TimestampsToReturn: opcuaClient.DefaultTimestampsToReturn,
+			ShutdownTimeout:    10,
 		},
 	}
 }
@@ -264,16 +339,41 @@ func (x *OpcUa) New() types.Node {
 // Init 初始化
 func (x *OpcUa) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if _, err = opcuaClient.ParseTimestampsToReturn(x.Config.TimestampsToReturn); err != nil {
+		return err
+	}
+	if err = x.Config.Backpressure.Validate(); err != nil {
+		return err
+	}
+	if err = x.Config.Schedule.Validate(); err != nil {
+		return err
+	}
+	if err = x.Config.Array.Validate(); err != nil {
+		return err
+	}
+	if err = opcuaClient.ValidateSecurity(x.Config.Policy, x.Config.Mode); err != nil {
+		return err
+	}
+	if x.autoDiscover, err = x.Config.AutoDiscover.compile(); err != nil {
+		return err
+	}
+	if x.output, err = x.Config.Output.compile(x.Config.Server); err != nil {
+		return err
+	}
 	x.RuleConfig = ruleConfig
+	x.limiter = x.newLimiter()
 
-	// 初始化优雅停机功能 - 使用合理的默认超时(10秒)
-	x.GracefulShutdown.InitGracefulShutdown(x.RuleConfig.Logger, 10*time.Second)
+	// 初始化优雅停机功能
+	x.GracefulShutdown.InitGracefulShutdown(x.RuleConfig.Logger, x.shutdownTimeout())
 
 	_ = x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, true, func() (*opcua.Client, error) {
 		return x.initClient()
 	}, func(client *opcua.Client) error {
 		if client != nil {
-			return client.Close(context.Background())
+			return opcuaClient.CloseClient(client)
 		}
 		return nil
 	})
@@ -315,18 +415,59 @@ func (x *OpcUa) GracefulStop() {
 }
 
 func (x *OpcUa) Close() error {
+	if x.Router != nil {
+		endpoints.CompareAndDelete(x.Router.GetId(), x)
+	}
+	x.reloadLock.Lock()
 	if x.taskId != 0 && x.cronTask != nil {
 		x.cronTask.Remove(x.taskId)
 	}
+	var cronStopped context.Context
 	if x.cronTask != nil {
-		x.cronTask.Stop()
+		cronStopped = x.cronTask.Stop()
+	}
+	// 丢弃排队的读取结果，正在处理的由 drain 等待
+	if x.limiter != nil {
+		x.limiter.Close()
 	}
+	x.reloadLock.Unlock()
+	// 等待正在执行的读取完成后再关闭会话
+	x.drain(cronStopped)
+	x.unregisterNodes()
 	// SharedNode 会通过 InitWithClose 中的清理函数来管理客户端的关闭
 	// SharedNode manages client closure through the cleanup function in InitWithClose
 	_ = x.SharedNode.Close()
 	return nil
 }
 
+// drain 等待正在执行的定时读取和 DoProcess 完成，超时后取消停机上下文强制中断
+func (x *OpcUa) drain(cronStopped context.Context) {
+	timeout := x.shutdownTimeout()
+	deadline := time.Now().Add(timeout)
+	if cronStopped != nil {
+		select {
+		case <-cronStopped.Done():
+		case <-time.After(timeout):
+		}
+	}
+	if x.GracefulShutdown.GetActiveOperations() <= 0 {
+		return
+	}
+	if !x.GracefulShutdown.WaitForActiveOperations(time.Until(deadline)) {
+		x.Printf("graceful shutdown timeout after %v, forcing context cancellation", timeout)
+		x.GracefulShutdown.ForceStop()
+		x.GracefulShutdown.WaitForActiveOperations(500 * time.Millisecond)
+	}
+}
+
+// shutdownTimeout 优雅停机超时时间
+func (x *OpcUa) shutdownTimeout() time.Duration {
+	if x.Config.ShutdownTimeout > 0 {
+		return time.Duration(x.Config.ShutdownTimeout) * time.Second
+	}
+	return base.DefaultShutdownTimeout
+}
+
 func (x *OpcUa) Id() string {
 	return x.Config.Server
 }
@@ -339,12 +480,18 @@ func (x *OpcUa) AddRouter(router endpointApi.Router, params ...interface{}) (str
 		return "", errors.New("duplicate router")
 	}
 	x.Router = router
+	poll.Register(router.GetId(), x)
+	endpoints.Store(router.GetId(), x)
 	return router.GetId(), nil
 }
 
 func (x *OpcUa) RemoveRouter(routerId string, params ...interface{}) error {
 	x.Lock()
 	defer x.Unlock()
+	if x.Router != nil {
+		endpoints.CompareAndDelete(x.Router.GetId(), x)
+		poll.Unregister(x.Router.GetId(), x)
+	}
 	x.Router = nil
 	return nil
 }
@@ -356,23 +503,85 @@ func (x *OpcUa) Start() error {
 			return x.initClient()
 		}, func(client *opcua.Client) error {
 			if client != nil {
-				return client.Close(context.Background())
+				return opcuaClient.CloseClient(client)
 			}
 			return nil
 		})
 	}
+	x.reloadLock.Lock()
+	defer x.reloadLock.Unlock()
 	if x.cronTask != nil {
 		x.cronTask.Stop()
 	}
+	// 替换 Init 或者上一次 Start 创建的背压控制
+	if x.limiter != nil {
+		x.limiter.Close()
+	}
+	x.limiter = x.newLimiter()
 	x.cronTask = cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger)), cron.WithLogger(cron.DefaultLogger))
-	eid, err := x.cronTask.AddFunc(x.Config.Interval, func() {
-		if x.Router != nil {
-			_ = x.readNodes(x.Router)
+	schedule, err := poll.ParseSchedule(x.Config.Interval, x.Config.Schedule)
+	if err != nil {
+		return err
+	}
+	x.taskId = x.cronTask.Schedule(schedule, cron.FuncJob(x.onTick))
+	if err = x.startDiscover(); err != nil {
+		return err
+	}
+	x.cronTask.Start()
+	return nil
+}
+
+// onTick 定时读取点位
+func (x *OpcUa) onTick() {
+	// 暂停期间不读取设备
+	if x.Router != nil && !x.Paused() {
+		_ = x.readNodes(x.Router)
+	}
+}
+
+// Reload 热更新点位列表和读取间隔，不会断开共享客户端连接
+// nodeIds 为 nil 表示不修改点位列表，interval 为空表示不修改读取间隔
+// 读取间隔无效时返回错误，配置保持不变
+func (x *OpcUa) Reload(nodeIds []string, interval string) error {
+	x.reloadLock.Lock()
+	defer x.reloadLock.Unlock()
+	if interval != "" && interval != x.Config.Interval && x.cronTask != nil {
+		schedule, err := poll.ParseSchedule(interval, x.Config.Schedule)
+		if err != nil {
+			return err
 		}
+		eid := x.cronTask.Schedule(schedule, cron.FuncJob(x.onTick))
+		x.cronTask.Remove(x.taskId)
+		x.taskId = eid
+	}
+	if interval != "" {
+		x.Config.Interval = interval
+	}
+	if nodeIds != nil {
+		x.Config.NodeIds = append([]string(nil), nodeIds...)
+	}
+	return nil
+}
+
+// newLimiter 按背压配置创建限制器，跳过或者丢弃的读取计入指标
+func (x *OpcUa) newLimiter() *poll.Limiter {
+	return poll.NewLimiter(x.Config.Backpressure, func(n int) {
+		opcuaClient.RecordPollsDropped(x.Type(), x.Config.Server, n)
 	})
-	x.taskId = eid
-	x.cronTask.Start()
-	return err
+}
+
+// getLimiter 获取背压控制
+func (x *OpcUa) getLimiter() *poll.Limiter {
+	x.reloadLock.RLock()
+	defer x.reloadLock.RUnlock()
+	return x.limiter
+}
+
+// getNodeIds 获取当前点位列表，包括自动发现的点位
+func (x *OpcUa) getNodeIds() []string {
+	x.reloadLock.RLock()
+	defer x.reloadLock.RUnlock()
+	return mergeNodeIds(x.Config.NodeIds, x.discovered)
 }
 
 func (x *OpcUa) Printf(format string, v ...interface{}) {
@@ -385,6 +594,15 @@ func (x *OpcUa) readNodes(router endpointApi.Router) error {
 	// 增加活跃操作计数
 	x.GracefulShutdown.IncrementActiveOperations()
 	defer x.GracefulShutdown.DecrementActiveOperations()
+	// 停机过程中不再发起新的读取
+	if x.GracefulShutdown.IsShuttingDown() {
+		return nil
+	}
+	limiter := x.getLimiter()
+	// skip 策略下上一次的结果还在处理，跳过本次读取
+	if limiter != nil && !limiter.Admit() {
+		return nil
+	}
 
 	client, err := x.SharedNode.GetSafely()
 	if err != nil {
@@ -392,22 +610,115 @@ func (x *OpcUa) readNodes(router endpointApi.Router) error {
 		return err
 	}
 
-	data, _, err := opcuaClient.Read(client, x.Config.NodeIds)
+	configNodeIds := x.getNodeIds()
+	nodeIds := configNodeIds
+	if x.Config.RegisterNodes {
+		if client, nodeIds, err = x.getRegisteredNodeIds(client, configNodeIds); err != nil {
+			x.Printf("get shared client error %v ", err)
+			return err
+		}
+	}
+	start := time.Now()
+	// 停机时中止未完成的读取
+	data, resp, err := opcuaClient.ReadWithOptionsContext(x.GracefulShutdown.GetShutdownContext(), client, nodeIds, opcuaClient.ReadOptions{
+		BatchSize:          x.Config.BatchSize,
+		Timeout:            time.Duration(x.Config.Timeout) * time.Second,
+		MaxAge:             x.Config.MaxAge,
+		TimestampsToReturn: x.Config.TimestampsToReturn,
+		Enrich:             x.Config.Enrich,
+	})
+	opcuaClient.RecordRead(x.Type(), x.Config.Server, start, resp, err)
 	if err != nil {
 		x.Printf("read nodes error %v ", err)
 		return err
 	}
+	// 注册后的点位还原为配置的点位
+	for i := range data {
+		if i < len(configNodeIds) {
+			data[i].NodeId = configNodeIds[i]
+		}
+	}
+	// 数组和矩阵按配置输出，展开后数据条数可能多于点位数
+	if data, err = x.Config.Array.Apply(data); err != nil {
+		x.Printf("read nodes error %v ", err)
+		return err
+	}
 	exchange := &endpointApi.Exchange{
-		In: &RequestMessage{data: data},
+		In: &RequestMessage{data: data, output: x.output, readTime: start},
 		Out: &ResponseMessage{
 			data: data,
 		}}
 
-	x.DoProcess(context.Background(), router, exchange)
+	process := func() {
+		x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+	}
+	if limiter == nil {
+		process()
+	} else {
+		limiter.Do(process)
+	}
 	return nil
 }
 
+// getRegisteredNodeIds 获取注册后的点位，客户端或者点位列表变化时重新注册
+// 注册失败时服务器可能已经关闭了会话（eg. 不支持 RegisterNodes 的服务器），丢弃共享客户端并重新连接，
+// 返回新的客户端和配置的点位，该客户端不再尝试注册
+func (x *OpcUa) getRegisteredNodeIds(client *opcua.Client, nodeIds []string) (*opcua.Client, []string, error) {
+	x.registerLock.Lock()
+	defer x.registerLock.Unlock()
+	if x.registeredClient == client && slices.Equal(x.registeredFor, nodeIds) && (x.registerFailed || len(x.registeredNodeIds) > 0) {
+		if x.registerFailed {
+			return client, nodeIds, nil
+		}
+		return client, x.registeredNodeIds, nil
+	}
+	if x.registeredClient == client {
+		x.unregisterNodesLocked()
+	}
+	ctx, cancel := opcuaClient.WithTimeout(x.GracefulShutdown.GetShutdownContext(), time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+	registered, err := opcuaClient.RegisterNodes(ctx, client, nodeIds)
+	if err != nil {
+		x.Printf("register nodes error %v, reconnect and read configured node ids", err)
+		x.registeredClient, x.registeredFor, x.registeredNodeIds, x.registerFailed = nil, nil, nil, false
+		_ = x.SharedNode.Close()
+		if client, err = x.SharedNode.GetSafely(); err != nil {
+			return nil, nil, err
+		}
+		x.registeredClient, x.registeredFor, x.registerFailed = client, nodeIds, true
+		return client, nodeIds, nil
+	}
+	x.registeredClient = client
+	x.registeredFor = nodeIds
+	x.registeredNodeIds = registered
+	x.registerFailed = false
+	return client, registered, nil
+}
+
+// unregisterNodes 注销已注册的点位
+func (x *OpcUa) unregisterNodes() {
+	x.registerLock.Lock()
+	defer x.registerLock.Unlock()
+	x.unregisterNodesLocked()
+}
+
+func (x *OpcUa) unregisterNodesLocked() {
+	if x.registeredClient != nil && len(x.registeredNodeIds) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := opcuaClient.UnregisterNodes(ctx, x.registeredClient, x.registeredNodeIds); err != nil {
+			x.Printf("unregister nodes error %v ", err)
+		}
+		cancel()
+	}
+	x.registeredClient = nil
+	x.registeredFor = nil
+	x.registeredNodeIds = nil
+	x.registerFailed = false
+}
+
 // initClient 初始化客户端
 func (x *OpcUa) initClient() (*opcua.Client, error) {
-	return opcuaClient.DefaultHolder(x.Config).NewOpcUaClient()
+	holder := opcuaClient.DefaultHolder(x.Config)
+	holder.Component = x.Type()
+	return holder.NewOpcUaClient()
 }
@@ -0,0 +1,256 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+)
+
+// OPC_UA_INVENTORY_MSG_TYPE 自动发现的点位变化时发送的消息类型
+const OPC_UA_INVENTORY_MSG_TYPE = "OPC_UA_INVENTORY"
+
+// AutoDiscoverConfig 自动发现点位配置
+type AutoDiscoverConfig struct {
+	// Enabled 是否开启自动发现
+	Enabled bool `json:"enabled" label:"Enabled" desc:"Browse the root folder and add matching variables to the read list"`
+	// Root 浏览的起点节点，默认 Objects 文件夹 i=85
+	Root string `json:"root" label:"Root" desc:"Node id of the folder to browse, default i=85 (Objects)"`
+	// Include 匹配浏览路径的正则表达式，eg. ^Objects/Line1/.*，为空匹配所有变量
+	Include string `json:"include" label:"Include" desc:"Regular expression matched against the browse path, e.g. ^Objects/Line1/. Empty matches all variables"`
+	// Exclude 排除浏览路径的正则表达式
+	Exclude string `json:"exclude" label:"Exclude" desc:"Regular expression of browse paths to exclude"`
+	// DataTypes 只添加这些数据类型的变量，eg. Double、Int32，为空不限制
+	DataTypes []string `json:"dataTypes" label:"Data Types" desc:"Only add variables of these data types, e.g. Double, Int32. Empty adds all"`
+	// MaxDepth 最大浏览深度，默认 10
+	MaxDepth int `json:"maxDepth" label:"Max Depth" desc:"Max browse depth below the root, default 10"`
+	// MaxNodes 最多添加的变量数量，默认 1000
+	MaxNodes int `json:"maxNodes" label:"Max Nodes" desc:"Max number of discovered variables, default 1000"`
+	// Interval 重新浏览的间隔，支持 cron 表达式，为空只在启动时浏览
+	Interval string `json:"interval" label:"Interval" desc:"Rediscover interval, supports cron expression, e.g. @every 10m. Empty browses only at startup"`
+}
+
+// autoDiscover 编译后的自动发现配置
+type autoDiscover struct {
+	AutoDiscoverConfig
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// compile 校验并编译自动发现配置，未开启返回 nil
+func (c AutoDiscoverConfig) compile() (*autoDiscover, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	d := &autoDiscover{AutoDiscoverConfig: c}
+	var err error
+	if c.Include != "" {
+		if d.include, err = regexp.Compile(c.Include); err != nil {
+			return nil, fmt.Errorf("invalid autoDiscover include: %w", err)
+		}
+	}
+	if c.Exclude != "" {
+		if d.exclude, err = regexp.Compile(c.Exclude); err != nil {
+			return nil, fmt.Errorf("invalid autoDiscover exclude: %w", err)
+		}
+	}
+	if c.Interval != "" {
+		if _, err = cron.ParseStandard(c.Interval); err != nil {
+			return nil, fmt.Errorf("invalid autoDiscover interval: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// match 浏览路径是否匹配
+func (d *autoDiscover) match(path string) bool {
+	if d.include != nil && !d.include.MatchString(path) {
+		return false
+	}
+	return d.exclude == nil || !d.exclude.MatchString(path)
+}
+
+// Inventory 自动发现的点位变化，作为 OPC_UA_INVENTORY 消息的负荷
+type Inventory struct {
+	// Root 浏览的起点节点
+	Root string `json:"root"`
+	// Added 新增的点位
+	Added []opcuaClient.BrowsedVariable `json:"added"`
+	// Removed 删除的点位
+	Removed []opcuaClient.BrowsedVariable `json:"removed"`
+	// Total 当前自动发现的点位数量
+	Total int `json:"total"`
+	// Truncated 变量数量达到 maxNodes，部分变量没有添加
+	Truncated bool `json:"truncated"`
+}
+
+// Discovered 返回当前自动发现的点位
+func (x *OpcUa) Discovered() []opcuaClient.BrowsedVariable {
+	x.reloadLock.RLock()
+	defer x.reloadLock.RUnlock()
+	return append([]opcuaClient.BrowsedVariable(nil), x.discovered...)
+}
+
+// startDiscover 启动时浏览一次，并按间隔重新浏览，调用方持有 reloadLock
+func (x *OpcUa) startDiscover() error {
+	if x.autoDiscover == nil {
+		return nil
+	}
+	if x.autoDiscover.Interval != "" {
+		schedule, err := cron.ParseStandard(x.autoDiscover.Interval)
+		if err != nil {
+			return err
+		}
+		x.discoverTaskId = x.cronTask.Schedule(schedule, cron.FuncJob(x.onDiscover))
+	}
+	go x.onDiscover()
+	return nil
+}
+
+// onDiscover 浏览服务器并更新自动发现的点位，上一次浏览没有结束时跳过
+func (x *OpcUa) onDiscover() {
+	if !x.discoverLock.TryLock() {
+		return
+	}
+	defer x.discoverLock.Unlock()
+	if err := x.discover(); err != nil {
+		x.Printf("opcua auto discover error %v ", err)
+	}
+}
+
+// discover 浏览服务器，按路径和数据类型过滤变量，点位有变化时替换自动发现的点位并发送清单消息
+// 浏览失败时保留原有的点位
+func (x *OpcUa) discover() error {
+	x.GracefulShutdown.IncrementActiveOperations()
+	defer x.GracefulShutdown.DecrementActiveOperations()
+	if x.GracefulShutdown.IsShuttingDown() {
+		return nil
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		return err
+	}
+	d := x.autoDiscover
+	ctx, cancel := opcuaClient.WithTimeout(x.GracefulShutdown.GetShutdownContext(), time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+	variables, truncated, err := opcuaClient.BrowseVariables(ctx, client, d.Root, opcuaClient.BrowseOptions{
+		MaxDepth: d.MaxDepth,
+		MaxNodes: d.MaxNodes,
+		Match:    d.match,
+	})
+	if err != nil {
+		return err
+	}
+	found := variables[:0]
+	for _, v := range variables {
+		if opcuaClient.MatchDataType(v.DataType, d.DataTypes) {
+			found = append(found, v)
+		}
+	}
+
+	x.reloadLock.Lock()
+	inventory := diffInventory(x.discovered, found)
+	if x.discoveredOnce && len(inventory.Added) == 0 && len(inventory.Removed) == 0 {
+		x.reloadLock.Unlock()
+		return nil
+	}
+	x.discovered = found
+	x.discoveredOnce = true
+	router := x.Router
+	x.reloadLock.Unlock()
+
+	root := d.Root
+	if root == "" {
+		root = opcuaClient.DefaultBrowseRoot
+	}
+	inventory.Root = root
+	inventory.Total = len(found)
+	inventory.Truncated = truncated
+	if router != nil {
+		x.sendInventory(router, inventory)
+	}
+	return nil
+}
+
+// diffInventory 比较新旧点位，按 nodeId 判断新增和删除
+func diffInventory(old, found []opcuaClient.BrowsedVariable) Inventory {
+	inventory := Inventory{Added: []opcuaClient.BrowsedVariable{}, Removed: []opcuaClient.BrowsedVariable{}}
+	oldIds := make(map[string]bool, len(old))
+	for _, v := range old {
+		oldIds[v.NodeId] = true
+	}
+	foundIds := make(map[string]bool, len(found))
+	for _, v := range found {
+		foundIds[v.NodeId] = true
+		if !oldIds[v.NodeId] {
+			inventory.Added = append(inventory.Added, v)
+		}
+	}
+	for _, v := range old {
+		if !foundIds[v.NodeId] {
+			inventory.Removed = append(inventory.Removed, v)
+		}
+	}
+	return inventory
+}
+
+// sendInventory 发送点位清单消息
+func (x *OpcUa) sendInventory(router endpointApi.Router, inventory Inventory) {
+	b, err := json.Marshal(inventory)
+	if err != nil {
+		x.Printf("opcua auto discover error %v ", err)
+		return
+	}
+	msg := types.NewMsg(0, OPC_UA_INVENTORY_MSG_TYPE, types.JSON, types.NewMetadata(), string(b))
+	in := &RequestMessage{}
+	in.SetMsg(&msg)
+	exchange := &endpointApi.Exchange{
+		In:  in,
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+}
+
+// mergeNodeIds 配置的点位加上自动发现的点位，重复的点位只保留一个
+func mergeNodeIds(nodeIds []string, discovered []opcuaClient.BrowsedVariable) []string {
+	if len(discovered) == 0 {
+		return nodeIds
+	}
+	merged := make([]string, 0, len(nodeIds)+len(discovered))
+	seen := make(map[string]bool, len(nodeIds)+len(discovered))
+	for _, nodeId := range nodeIds {
+		if !seen[nodeId] {
+			seen[nodeId] = true
+			merged = append(merged, nodeId)
+		}
+	}
+	for _, v := range discovered {
+		if !seen[v.NodeId] {
+			seen[v.NodeId] = true
+			merged = append(merged, v.NodeId)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// OutputFormatArray 读取结果数组，默认格式
+	OutputFormatArray = "array"
+	// OutputFormatObject 以点位为 key 的对象，eg. {"ns=2;s=Tag1": 1.5}
+	OutputFormatObject = "object"
+	// OutputFormatTemplate 按模板输出自定义结构
+	OutputFormatTemplate = "template"
+)
+
+const (
+	// OutputKeyNodeId 使用点位 ID 作为 key，默认
+	OutputKeyNodeId = "nodeId"
+	// OutputKeyTag 使用 tag:<名称> 读取时的点位名称作为 key，没有名称使用点位 ID
+	OutputKeyTag = "tag"
+	// OutputKeyDisplayName 使用显示名称作为 key，没有显示名称使用点位 ID
+	OutputKeyDisplayName = "displayName"
+)
+
+// OutputOptions 读取结果消息的负荷格式
+type OutputOptions struct {
+	// Format 负荷格式：array（默认）、object、template
+	Format string `json:"format" label:"Format" desc:"Payload format: array (default, list of read results), object (node value keyed by node), template (custom structure)"`
+	// Key object 格式和模板中 ${values} 的 key：nodeId（默认）、tag、displayName
+	Key string `json:"key" label:"Key" desc:"Key of the node values in object format and ${values}: nodeId (default), tag, displayName"`
+	// Device 设备标识，模板中使用 ${device}
+	Device string `json:"device" label:"Device" desc:"Device identifier used by ${device} in the template"`
+	// Template 模板，JSON 对象或者 JSON 字符串。字符串值只有一个变量时替换为变量的原始类型，否则按字符串替换。
+	// 可用变量：${device}、${server}、${timestamp}（RFC3339）、${timestampMs}、${values}（按 Key 分组的点位值）、${data}（读取结果数组）、${count}
+	Template interface{} `json:"template" label:"Template" desc:"Template as JSON object or JSON string. A string holding a single variable is replaced by the raw value. Variables: ${device}, ${server}, ${timestamp}, ${timestampMs}, ${values}, ${data}, ${count}"`
+}
+
+// outputFormat 编译后的负荷格式
+type outputFormat struct {
+	format   string
+	key      string
+	device   string
+	server   string
+	template interface{}
+}
+
+// compile 校验配置并编译负荷格式
+func (o OutputOptions) compile(server string) (*outputFormat, error) {
+	f := &outputFormat{format: o.Format, key: o.Key, device: o.Device, server: server}
+	if f.format == "" {
+		f.format = OutputFormatArray
+	}
+	if f.key == "" {
+		f.key = OutputKeyNodeId
+	}
+	switch f.key {
+	case OutputKeyNodeId, OutputKeyTag, OutputKeyDisplayName:
+	default:
+		return nil, fmt.Errorf("unsupported output key %s", f.key)
+	}
+	switch f.format {
+	case OutputFormatArray, OutputFormatObject:
+	case OutputFormatTemplate:
+		tmpl := o.Template
+		if s, ok := tmpl.(string); ok {
+			if strings.TrimSpace(s) == "" {
+				return nil, fmt.Errorf("output template is required")
+			}
+			if err := json.Unmarshal([]byte(s), &tmpl); err != nil {
+				return nil, fmt.Errorf("invalid output template: %w", err)
+			}
+		}
+		if tmpl == nil {
+			return nil, fmt.Errorf("output template is required")
+		}
+		f.template = tmpl
+	default:
+		return nil, fmt.Errorf("unsupported output format %s", f.format)
+	}
+	return f, nil
+}
+
+// keyOf 点位值的 key
+func (f *outputFormat) keyOf(d opcuaClient.Data) string {
+	switch f.key {
+	case OutputKeyTag:
+		if d.Tag != "" {
+			return d.Tag
+		}
+	case OutputKeyDisplayName:
+		if d.DisplayName != "" {
+			return d.DisplayName
+		}
+	}
+	return d.NodeId
+}
+
+// values 按 key 分组的点位值，数组展开后同一个点位的多个值加上下标后缀
+func (f *outputFormat) values(data []opcuaClient.Data) map[string]interface{} {
+	values := make(map[string]interface{}, len(data))
+	for _, d := range data {
+		key := f.keyOf(d)
+		if len(d.ArrayIndex) > 0 {
+			parts := make([]string, len(d.ArrayIndex))
+			for i, index := range d.ArrayIndex {
+				parts[i] = fmt.Sprint(index)
+			}
+			key += "[" + strings.Join(parts, ",") + "]"
+		}
+		values[key] = d.Value
+	}
+	return values
+}
+
+// build 构造负荷，readTime 为读取时间
+func (f *outputFormat) build(data []opcuaClient.Data, readTime time.Time) interface{} {
+	if f == nil || f.format == OutputFormatArray {
+		return data
+	}
+	values := f.values(data)
+	if f.format == OutputFormatObject {
+		return values
+	}
+	if data == nil {
+		data = []opcuaClient.Data{}
+	}
+	vars := map[string]interface{}{
+		"device":      f.device,
+		"server":      f.server,
+		"timestamp":   readTime.Format(time.RFC3339Nano),
+		"timestampMs": readTime.UnixMilli(),
+		"values":      values,
+		"data":        data,
+		"count":       len(data),
+	}
+	return render(f.template, vars)
+}
+
+// render 递归替换模板中的变量
+func render(tmpl interface{}, vars map[string]interface{}) interface{} {
+	switch v := tmpl.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = render(item, vars)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = render(item, vars)
+		}
+		return out
+	case string:
+		if name, ok := singleVar(v); ok {
+			if value, ok := vars[name]; ok {
+				return value
+			}
+		}
+		if strings.Contains(v, "${") {
+			return str.NewTemplate(v).Execute(vars)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// singleVar 字符串是否只有一个变量，eg. ${values}
+func singleVar(s string) (string, bool) {
+	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") && strings.Count(s, "${") == 1 {
+		return strings.TrimSpace(s[2 : len(s)-1]), true
+	}
+	return "", false
+}
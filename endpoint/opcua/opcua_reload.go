@@ -0,0 +1,106 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&ReloadNode{})
+}
+
+// ReloadNodeConfiguration 节点配置
+type ReloadNodeConfiguration struct {
+	// RouterId endpoint/opcua 的路由 Id
+	RouterId string `json:"routerId" label:"Router ID" desc:"Router id of the endpoint/opcua to reload" required:"true"`
+}
+
+// ReloadRequest 热更新请求
+type ReloadRequest struct {
+	// NodeIds 新的点位列表，为空不修改
+	NodeIds []string `json:"nodeIds"`
+	// Interval 新的读取间隔，为空不修改
+	Interval string `json:"interval"`
+}
+
+// ReloadNode 热更新 endpoint/opcua 的点位列表和读取间隔，不会断开客户端连接
+// 消息负荷 msg.Data 格式：
+//
+//	{
+//	  "nodeIds": ["ns=3;i=1003", "ns=3;i=1005"],
+//	  "interval": "@every 5s"
+//	}
+//
+// 更新成功，流转到`Success`链，否则流程转到`Failure`链
+type ReloadNode struct {
+	//节点配置
+	Config ReloadNodeConfiguration
+}
+
+func (x *ReloadNode) New() types.Node {
+	return &ReloadNode{
+		Config: ReloadNodeConfiguration{},
+	}
+}
+
+// Type 返回组件类型
+func (x *ReloadNode) Type() string {
+	return "x/opcuaReload"
+}
+
+func (x *ReloadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	return maps.Map2Struct(configuration, &x.Config)
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *ReloadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	ep, ok := lookupEndpoint(x.Config.RouterId)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("opcua endpoint not found: %s", x.Config.RouterId))
+		return
+	}
+	var req ReloadRequest
+	if err := json.Unmarshal([]byte(msg.GetData()), &req); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var nodeIds []string
+	if len(req.NodeIds) > 0 {
+		nodeIds = req.NodeIds
+	}
+	if err := ep.Reload(nodeIds, req.Interval); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+func (x *ReloadNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *ReloadNode) Desc() string {
+	return "Hot reload node ids and interval of the OPC-UA endpoint without reconnecting. Routes to Success/Failure"
+}
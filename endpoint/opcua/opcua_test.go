@@ -17,15 +17,21 @@
 package opcua
 
 import (
+	"encoding/json"
 	"os"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/rulego/rulego-components-iot/endpoint/opcuaserver"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/poll"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/api/types/endpoint"
 	"github.com/rulego/rulego/endpoint/impl"
 	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
 )
 
 func TestOpcUaEndpoint(t *testing.T) {
@@ -99,6 +105,59 @@ func TestOpcUaEndpoint(t *testing.T) {
 		}
 	})
 
+	t.Run("Backpressure", func(t *testing.T) {
+		ep := &OpcUa{}
+		err := ep.Init(engine.NewConfig(), types.Configuration{
+			"server":       "opc.tcp://127.0.0.1:53530",
+			"backpressure": map[string]interface{}{"overlap": "latest"},
+		})
+		if err == nil {
+			t.Error("期望不支持的重叠策略返回错误")
+		}
+		err = ep.Init(engine.NewConfig(), types.Configuration{
+			"server":       "opc.tcp://127.0.0.1:53530",
+			"backpressure": map[string]interface{}{"overlap": "dropOldest", "queueSize": 5},
+		})
+		if err != nil {
+			t.Fatalf("Init() 失败: %v", err)
+		}
+		if ep.Config.Backpressure.Overlap != poll.OverlapDropOldest || ep.Config.Backpressure.QueueSize != 5 {
+			t.Errorf("背压配置错误: %+v", ep.Config.Backpressure)
+		}
+	})
+
+	t.Run("Schedule", func(t *testing.T) {
+		ep := &OpcUa{}
+		err := ep.Init(engine.NewConfig(), types.Configuration{
+			"server":   "opc.tcp://127.0.0.1:53530",
+			"schedule": map[string]interface{}{"jitter": "soon"},
+		})
+		if err == nil {
+			t.Error("期望无效的抖动返回错误")
+		}
+		err = ep.Init(engine.NewConfig(), types.Configuration{
+			"server":   "opc.tcp://127.0.0.1:53530",
+			"interval": "@every 30s",
+			"schedule": map[string]interface{}{"align": true, "jitter": "5s"},
+		})
+		if err != nil {
+			t.Fatalf("Init() 失败: %v", err)
+		}
+		if err = ep.Start(); err != nil {
+			t.Fatalf("Start() 失败: %v", err)
+		}
+		defer ep.Close()
+		// 对齐到 30 秒的整数倍后最多延后 5 秒
+		next := ep.cronTask.Entry(ep.taskId).Schedule.Next(time.Now())
+		offset := next.Sub(next.Truncate(30 * time.Second))
+		if offset >= 5*time.Second {
+			t.Errorf("下次读取时间 %v 没有对齐", next)
+		}
+		if err = ep.Reload(nil, "@every soon"); err == nil {
+			t.Error("期望无效的读取间隔返回错误")
+		}
+	})
+
 	t.Run("Id", func(t *testing.T) {
 		ep := &OpcUa{}
 		config := engine.NewConfig()
@@ -688,3 +747,318 @@ func isOpcUaServerAvailable() bool {
 	// 如果需要，我们可以在这里实现快速连接测试
 	return false // Set to true if you have a local OPC UA server for testing
 }
+
+func TestOpcUaRegisterNodes(t *testing.T) {
+	srv := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := srv.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48405,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Double", "value": 20.5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Init() 失败: %v", err)
+	}
+	if err = srv.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer srv.Destroy()
+
+	ep := (&OpcUa{}).New().(*OpcUa)
+	err = ep.Init(engine.NewConfig(), types.Configuration{
+		"server":        srv.Id(),
+		"nodeIds":       []string{"ns=1;s=Temperature"},
+		"registerNodes": true,
+	})
+	if err != nil {
+		t.Fatalf("Init() 失败: %v", err)
+	}
+	defer ep.Destroy()
+
+	client, err := ep.SharedNode.GetSafely()
+	if err != nil {
+		t.Fatalf("GetSafely() 失败: %v", err)
+	}
+	// 内嵌服务器不支持 RegisterNodes 并关闭会话，应重新连接并回退使用配置的点位
+	client, nodeIds, err := ep.getRegisteredNodeIds(client, ep.Config.NodeIds)
+	if err != nil {
+		t.Fatalf("getRegisteredNodeIds() 失败: %v", err)
+	}
+	if len(nodeIds) != 1 || nodeIds[0] != "ns=1;s=Temperature" {
+		t.Errorf("期望回退到配置的点位, 实际为 %v", nodeIds)
+	}
+	// 新的客户端不再尝试注册
+	if again, _, _ := ep.getRegisteredNodeIds(client, ep.Config.NodeIds); again != client {
+		t.Errorf("期望继续使用重新连接的客户端")
+	}
+	data, _, err := opcuaClient.ReadWithOptions(client, nodeIds, opcuaClient.DefaultReadOptions())
+	if err != nil {
+		t.Fatalf("ReadWithOptions() 失败: %v", err)
+	}
+	if len(data) != 1 || data[0].Value != 20.5 {
+		t.Errorf("期望读取值为 20.5, 实际为 %v", data)
+	}
+	// 显示名称缓存后再次读取结果一致
+	for i := 0; i < 2; i++ {
+		data, _, err = opcuaClient.ReadWithOptions(client, nodeIds, opcuaClient.DefaultReadOptions())
+		if err != nil {
+			t.Fatalf("ReadWithOptions() 失败: %v", err)
+		}
+		if len(data) != 1 || data[0].DisplayName != "Temperature" {
+			t.Errorf("期望显示名称为 Temperature, 实际为 %v", data)
+		}
+	}
+}
+
+func TestOpcUaReload(t *testing.T) {
+	srv := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := srv.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48406,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Double", "value": 20.5},
+			{"name": "Humidity", "dataType": "Double", "value": 60},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Init() 失败: %v", err)
+	}
+	if err = srv.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer srv.Destroy()
+
+	ep := (&OpcUa{}).New().(*OpcUa)
+	err = ep.Init(engine.NewConfig(), types.Configuration{
+		"server":   srv.Id(),
+		"interval": "@every 1m",
+		"nodeIds":  []string{"ns=1;s=Temperature"},
+	})
+	if err != nil {
+		t.Fatalf("Init() 失败: %v", err)
+	}
+	if err = ep.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer ep.Destroy()
+	client, _ := ep.SharedNode.GetSafely()
+	// 连接同一个服务器的另一个端点不影响查找
+	other := (&OpcUa{}).New().(*OpcUa)
+	err = other.Init(engine.NewConfig(), types.Configuration{
+		"server":   srv.Id(),
+		"interval": "@every 1m",
+	})
+	if err != nil {
+		t.Fatalf("Init() 失败: %v", err)
+	}
+	_, _ = ep.AddRouter(impl.NewRouter().SetId("reload-router").From("").End())
+	_, _ = other.AddRouter(impl.NewRouter().SetId("other-router").From("").End())
+	defer other.RemoveRouter("other-router")
+
+	if err = ep.Reload(nil, "invalid"); err == nil {
+		t.Error("无效的读取间隔应该返回错误")
+	}
+	if ep.Config.Interval != "@every 1m" {
+		t.Errorf("读取间隔不应该被修改, 实际为 '%s'", ep.Config.Interval)
+	}
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReloadNode{})
+	node, err := test.CreateAndInitNode("x/opcuaReload", types.Configuration{
+		"routerId": "reload-router",
+	}, Registry)
+	if err != nil {
+		t.Fatalf("CreateAndInitNode() 失败: %v", err)
+	}
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `{"nodeIds": ["ns=1;s=Temperature", "ns=1;s=Humidity"], "interval": "@every 2s"}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		if relationType != types.Success {
+			t.Errorf("期望 Success, 实际为 %s: %v", relationType, err)
+		}
+	})
+	if ep.Config.Interval != "@every 2s" {
+		t.Errorf("期望读取间隔为 '@every 2s', 实际为 '%s'", ep.Config.Interval)
+	}
+	if len(ep.getNodeIds()) != 2 {
+		t.Errorf("期望 NodeIds 长度为 2, 实际为 %d", len(ep.getNodeIds()))
+	}
+	if other.Config.Interval != "@every 1m" {
+		t.Errorf("其他端点的读取间隔不应该被修改, 实际为 '%s'", other.Config.Interval)
+	}
+	// 热更新不应该断开客户端连接
+	if c, _ := ep.SharedNode.GetSafely(); c != client {
+		t.Error("热更新后客户端连接不应该变化")
+	}
+}
+
+func TestOpcUaAutoDiscover(t *testing.T) {
+	srv := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := srv.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48419,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Double", "value": 20.5},
+			{"name": "Humidity", "dataType": "Double", "value": 60},
+			{"name": "Running", "dataType": "Boolean", "value": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Init() 失败: %v", err)
+	}
+	if err = srv.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer srv.Destroy()
+
+	ep := (&OpcUa{}).New().(*OpcUa)
+	if err = ep.Init(engine.NewConfig(), types.Configuration{
+		"server":       srv.Id(),
+		"autoDiscover": map[string]interface{}{"enabled": true, "include": "("},
+	}); err == nil {
+		t.Error("无效的正则表达式应该返回错误")
+	}
+	ep = (&OpcUa{}).New().(*OpcUa)
+	err = ep.Init(engine.NewConfig(), types.Configuration{
+		"server":  srv.Id(),
+		"nodeIds": []string{"ns=1;s=Temperature"},
+		"autoDiscover": map[string]interface{}{
+			"enabled":   true,
+			"include":   "^Objects/urn:rulego:opcua/",
+			"dataTypes": []string{"double"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Init() 失败: %v", err)
+	}
+	defer ep.Destroy()
+
+	var lock sync.Mutex
+	var inventories []Inventory
+	router := impl.NewRouter().SetId("discover-router").From("").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		if msg.Type == OPC_UA_INVENTORY_MSG_TYPE {
+			var inventory Inventory
+			_ = json.Unmarshal([]byte(msg.GetData()), &inventory)
+			lock.Lock()
+			inventories = append(inventories, inventory)
+			lock.Unlock()
+		}
+		return true
+	}).End()
+	_, _ = ep.AddRouter(router)
+
+	if err = ep.discover(); err != nil {
+		t.Fatalf("discover() 失败: %v", err)
+	}
+	discovered := ep.Discovered()
+	if len(discovered) != 2 || discovered[0].NodeId != "ns=1;s=Temperature" || discovered[1].DataType != "Double" {
+		t.Errorf("期望发现 Temperature 和 Humidity, 实际为 %v", discovered)
+	}
+	// 配置的点位和自动发现的点位合并去重
+	if nodeIds := ep.getNodeIds(); len(nodeIds) != 2 || nodeIds[1] != "ns=1;s=Humidity" {
+		t.Errorf("期望点位为 Temperature 和 Humidity, 实际为 %v", nodeIds)
+	}
+	// 点位没有变化时不发送清单
+	if err = ep.discover(); err != nil {
+		t.Fatalf("discover() 失败: %v", err)
+	}
+	ep.autoDiscover.DataTypes = []string{"Boolean"}
+	if err = ep.discover(); err != nil {
+		t.Fatalf("discover() 失败: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(inventories) != 2 {
+		t.Fatalf("期望 2 个清单消息, 实际为 %d", len(inventories))
+	}
+	if len(inventories[0].Added) != 2 || inventories[0].Root != "i=85" || inventories[0].Total != 2 {
+		t.Errorf("第一次浏览的清单错误: %+v", inventories[0])
+	}
+	if len(inventories[1].Added) != 1 || inventories[1].Added[0].BrowseName != "Running" || len(inventories[1].Removed) != 2 {
+		t.Errorf("第二次变化的清单错误: %+v", inventories[1])
+	}
+}
+
+func TestOpcUaOutput(t *testing.T) {
+	readTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := []opcuaClient.Data{
+		{NodeId: "ns=2;s=Temp", DisplayName: "Temp", Tag: "line1.temp", Value: 21.5},
+		{NodeId: "ns=2;s=Run", DisplayName: "Run", Value: true},
+		{NodeId: "ns=2;s=Arr", Value: int32(7), ArrayIndex: []int{1}},
+	}
+	body := func(options OutputOptions) map[string]interface{} {
+		output, err := options.compile("opc.tcp://plc:4840")
+		if err != nil {
+			t.Fatalf("compile() 失败: %v", err)
+		}
+		req := &RequestMessage{data: data, output: output, readTime: readTime}
+		var result map[string]interface{}
+		if err = json.Unmarshal(req.Body(), &result); err != nil {
+			t.Fatalf("负荷不是 JSON 对象: %s", req.Body())
+		}
+		return result
+	}
+
+	// 默认输出读取结果数组
+	var list []opcuaClient.Data
+	if err := json.Unmarshal((&RequestMessage{data: data}).Body(), &list); err != nil || len(list) != 3 {
+		t.Errorf("期望数组格式, 实际为 %v %v", list, err)
+	}
+
+	values := body(OutputOptions{Format: OutputFormatObject})
+	if values["ns=2;s=Temp"] != 21.5 || values["ns=2;s=Run"] != true || values["ns=2;s=Arr[1]"] != 7.0 {
+		t.Errorf("object 格式错误: %v", values)
+	}
+	values = body(OutputOptions{Format: OutputFormatObject, Key: OutputKeyTag})
+	if values["line1.temp"] != 21.5 || values["ns=2;s=Run"] != true {
+		t.Errorf("tag 作为 key 错误: %v", values)
+	}
+
+	result := body(OutputOptions{
+		Format:   OutputFormatTemplate,
+		Key:      OutputKeyDisplayName,
+		Device:   "boiler-1",
+		Template: `{"device":"${device}","ts":"${timestampMs}","time":"${timestamp}","values":"${values}","meta":{"source":"${server}/${device}","count":"${count}"}}`,
+	})
+	if result["device"] != "boiler-1" || result["ts"] != float64(readTime.UnixMilli()) || result["time"] != "2025-01-02T03:04:05Z" {
+		t.Errorf("模板变量替换错误: %v", result)
+	}
+	if values, ok := result["values"].(map[string]interface{}); !ok || values["Temp"] != 21.5 || values["Run"] != true {
+		t.Errorf("模板 values 错误: %v", result["values"])
+	}
+	if meta, ok := result["meta"].(map[string]interface{}); !ok || meta["source"] != "opc.tcp://plc:4840/boiler-1" || meta["count"] != 3.0 {
+		t.Errorf("模板嵌套结构错误: %v", result["meta"])
+	}
+
+	// 模板也可以是 JSON 对象
+	result = body(OutputOptions{Format: OutputFormatTemplate, Template: map[string]interface{}{"items": []interface{}{"${data}"}}})
+	if items, ok := result["items"].([]interface{}); !ok || len(items) != 1 || len(items[0].([]interface{})) != 3 {
+		t.Errorf("模板数组错误: %v", result)
+	}
+
+	for _, options := range []OutputOptions{
+		{Format: "csv"},
+		{Format: OutputFormatObject, Key: "browseName"},
+		{Format: OutputFormatTemplate},
+		{Format: OutputFormatTemplate, Template: "{invalid"},
+	} {
+		if _, err := options.compile(""); err == nil {
+			t.Errorf("期望配置 %+v 校验失败", options)
+		}
+	}
+	ep := &OpcUa{}
+	if err := ep.Init(engine.NewConfig(), types.Configuration{"output": map[string]interface{}{"format": "csv"}}); err == nil {
+		t.Error("期望不支持的负荷格式初始化失败")
+	}
+}
@@ -0,0 +1,567 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package opcuaserver 提供内嵌 OPC UA 服务器端点
+// 端点对外暴露配置的变量节点，规则链可以通过 x/opcuaServerWrite 节点更新变量值，
+// 外部 OPC UA 客户端对变量的写入会转换成消息交给规则链处理
+package opcuaserver
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/server/attrs"
+	"github.com/gopcua/opcua/ua"
+
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "opcuaServer"
+
+// OPC_UA_WRITE_MSG_TYPE 外部客户端写入变量时产生的消息类型
+const OPC_UA_WRITE_MSG_TYPE = "OPC_UA_WRITE"
+
+// Endpoint 别名
+type Endpoint = OpcUaServer
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// servers 运行中的服务器，key 为端点 Id，供 x/opcuaServerWrite 节点查找
+var servers sync.Map
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+// WriteEvent 外部客户端写入事件
+type WriteEvent struct {
+	NodeId      string      `json:"nodeId"`
+	DisplayName string      `json:"displayName"`
+	Value       interface{} `json:"value"`
+	Timestamp   time.Time   `json:"timestamp"`
+}
+
+type RequestMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	event      WriteEvent
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body, r.err = json.Marshal(r.event)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.event.NodeId
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue("nodeId", r.event.NodeId)
+		metadata.PutValue("displayName", r.event.DisplayName)
+		ruleMsg := types.NewMsg(0, OPC_UA_WRITE_MSG_TYPE, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+type ResponseMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// Variable 暴露的变量节点配置
+type Variable struct {
+	// Name 变量名称，作为字符串 NodeId 的标识符，例如 ns=1;s=Temperature
+	Name string `json:"name" label:"Name" desc:"Variable name, used as string node id identifier"`
+	// DisplayName 显示名称，为空则使用 Name
+	DisplayName string `json:"displayName" label:"Display Name" desc:"Display name, defaults to name"`
+	// Description 描述
+	Description string `json:"description" label:"Description" desc:"Variable description"`
+	// DataType 数据类型：Boolean, SByte, Byte, Int16, UInt16, Int32, UInt32, Int64, UInt64, Float, Double, String, DateTime
+	DataType string `json:"dataType" label:"Data Type" desc:"Data type: Boolean, Int16, Int32, Float, Double, String, etc."`
+	// Value 初始值
+	Value interface{} `json:"value" label:"Value" desc:"Initial value"`
+	// Writable 是否允许外部客户端写入
+	Writable bool `json:"writable" label:"Writable" desc:"Allow external OPC UA clients to write"`
+}
+
+// OpcUaServerConfig 内嵌 OPC UA 服务器配置
+type OpcUaServerConfig struct {
+	// Host 监听主机名，客户端通过该主机名连接，例如 0.0.0.0 或 localhost
+	Host string `json:"host" label:"Host" desc:"Listen host name, e.g. 0.0.0.0 or localhost" required:"true"`
+	// Port 监听端口
+	Port int `json:"port" label:"Port" desc:"Listen port, default 4840" required:"true"`
+	// ServerName 服务器名称
+	ServerName string `json:"serverName" label:"Server Name" desc:"OPC UA server application name"`
+	// Namespace 变量所在命名空间 URI
+	Namespace string `json:"namespace" label:"Namespace" desc:"Namespace URI for exposed variables"`
+	// CertFile 服务器证书文件，为空则只提供 None 安全策略
+	CertFile string `json:"certFile" label:"Cert File" desc:"Server certificate file path, empty means security policy None only"`
+	// CertKeyFile 服务器证书私钥文件
+	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"Server private key file path"`
+	// Variables 暴露的变量列表
+	Variables []Variable `json:"variables" label:"Variables" desc:"Variables exposed by the server"`
+}
+
+// OpcUaServer 内嵌 OPC UA 服务器端点
+type OpcUaServer struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	// 服务器配置
+	Config OpcUaServerConfig
+	// 路由实例
+	Router endpointApi.Router
+	// 服务器实例
+	srv *server.Server
+	// 变量所在命名空间
+	nodeNS *server.NodeNameSpace
+	// 变量名称->节点
+	variables map[string]*server.Node
+	// 变量名称->数据类型
+	dataTypes map[string]string
+	cancel    context.CancelFunc
+	mu        sync.RWMutex
+}
+
+// Type 组件类型
+func (x *OpcUaServer) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *OpcUaServer) New() types.Node {
+	return &OpcUaServer{
+		Config: OpcUaServerConfig{
+			Host:       "0.0.0.0",
+			Port:       4840,
+			ServerName: "RuleGo OPC UA Server",
+			Namespace:  "urn:rulego:opcua",
+		},
+	}
+}
+
+// Init 初始化
+func (x *OpcUaServer) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	x.RuleConfig = ruleConfig
+	x.Logger = ruleConfig.Logger
+	return err
+}
+
+// Destroy 销毁
+func (x *OpcUaServer) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *OpcUaServer) Desc() string {
+	return "Embedded OPC-UA server endpoint exposing variables that rule chains can update and external clients can write"
+}
+
+// Category returns the component category
+func (x *OpcUaServer) Category() string {
+	return "endpoint"
+}
+
+func (x *OpcUaServer) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Embedded OPC-UA server endpoint exposing variables that rule chains can update and external clients can write",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+func (x *OpcUaServer) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	servers.CompareAndDelete(x.Id(), x)
+	if x.cancel != nil {
+		x.cancel()
+		x.cancel = nil
+	}
+	var err error
+	if x.srv != nil {
+		err = x.srv.Close()
+		x.srv = nil
+	}
+	x.nodeNS = nil
+	x.variables = nil
+	return err
+}
+
+func (x *OpcUaServer) Id() string {
+	return fmt.Sprintf("opc.tcp://%s:%d", x.Config.Host, x.Config.Port)
+}
+
+func (x *OpcUaServer) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Router = router
+	return router.GetId(), nil
+}
+
+func (x *OpcUaServer) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	x.Router = nil
+	return nil
+}
+
+func (x *OpcUaServer) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.srv != nil {
+		return nil
+	}
+	opts, err := x.serverOptions()
+	if err != nil {
+		return err
+	}
+	srv := server.New(opts...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := srv.Start(ctx); err != nil {
+		cancel()
+		return err
+	}
+
+	nodeNS := server.NewNodeNameSpace(srv, x.Config.Namespace)
+	rootNS, err := srv.Namespace(0)
+	if err != nil {
+		cancel()
+		_ = srv.Close()
+		return err
+	}
+	rootNS.Objects().AddRef(nodeNS.Objects(), id.HasComponent, true)
+
+	variables := make(map[string]*server.Node, len(x.Config.Variables))
+	dataTypes := make(map[string]string, len(x.Config.Variables))
+	for _, v := range x.Config.Variables {
+		if v.Name == "" {
+			continue
+		}
+		n, err := newVariableNode(nodeNS.ID(), v)
+		if err != nil {
+			cancel()
+			_ = srv.Close()
+			return err
+		}
+		nodeNS.AddNode(n)
+		nodeNS.Objects().AddRef(n, id.HasComponent, true)
+		variables[v.Name] = n
+		dataTypes[v.Name] = v.DataType
+	}
+
+	x.srv = srv
+	x.nodeNS = nodeNS
+	x.variables = variables
+	x.dataTypes = dataTypes
+	x.cancel = cancel
+	servers.Store(x.Id(), x)
+
+	go x.watchExternalWrites(ctx, nodeNS)
+	x.Printf("started OPC UA server on %s", x.Id())
+	return nil
+}
+
+func (x *OpcUaServer) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// SetValue 更新变量值并通知订阅的客户端
+// name 可以是变量名称，也可以是完整的 NodeId，例如 ns=1;s=Temperature
+func (x *OpcUaServer) SetValue(name string, value interface{}) error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if x.nodeNS == nil {
+		return errors.New("opcua server is not started")
+	}
+	n, dataType := x.lookup(name)
+	if n == nil {
+		return fmt.Errorf("variable not found: %s", name)
+	}
+	v, err := opcuaClient.NewVariant(value, dataType)
+	if err != nil {
+		return err
+	}
+	dv := &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp | ua.DataValueServerTimestamp,
+		Value:           v,
+		SourceTimestamp: time.Now(),
+		ServerTimestamp: time.Now(),
+	}
+	_ = n.SetAttribute(ua.AttributeIDValue, dv)
+	x.nodeNS.ChangeNotification(n.ID())
+	return nil
+}
+
+// GetValue 获取变量当前值
+func (x *OpcUaServer) GetValue(name string) (interface{}, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if x.nodeNS == nil {
+		return nil, errors.New("opcua server is not started")
+	}
+	n, _ := x.lookup(name)
+	if n == nil {
+		return nil, fmt.Errorf("variable not found: %s", name)
+	}
+	if dv := n.Value(); dv != nil && dv.Value != nil {
+		return dv.Value.Value(), nil
+	}
+	return nil, nil
+}
+
+// lookup 根据变量名称或 NodeId 查找节点
+func (x *OpcUaServer) lookup(name string) (*server.Node, string) {
+	if n, ok := x.variables[name]; ok {
+		return n, x.dataTypes[name]
+	}
+	if nid, err := ua.ParseNodeID(name); err == nil {
+		if n := x.nodeNS.Node(nid); n != nil {
+			return n, x.dataTypes[nid.StringID()]
+		}
+	}
+	return nil, ""
+}
+
+// watchExternalWrites 监听外部客户端写入，转换成消息交给路由处理
+func (x *OpcUaServer) watchExternalWrites(ctx context.Context, nodeNS *server.NodeNameSpace) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case nid := <-nodeNS.ExternalNotification:
+			x.onExternalWrite(nodeNS, nid)
+		}
+	}
+}
+
+func (x *OpcUaServer) onExternalWrite(nodeNS *server.NodeNameSpace, nid *ua.NodeID) {
+	x.RLock()
+	router := x.Router
+	x.RUnlock()
+	if router == nil || nid == nil {
+		return
+	}
+	n := nodeNS.Node(nid)
+	if n == nil {
+		return
+	}
+	event := WriteEvent{
+		NodeId:      nid.String(),
+		DisplayName: n.DisplayName().Text,
+		Timestamp:   time.Now(),
+	}
+	if dv := n.Value(); dv != nil && dv.Value != nil {
+		event.Value = dv.Value.Value()
+	}
+	exchange := &endpointApi.Exchange{
+		In:  &RequestMessage{event: event},
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(context.Background(), router, exchange)
+}
+
+// serverOptions 构建服务器选项
+func (x *OpcUaServer) serverOptions() ([]server.Option, error) {
+	opts := []server.Option{
+		server.EndPoint(x.Config.Host, x.Config.Port),
+		server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+	}
+	if x.Config.ServerName != "" {
+		opts = append(opts, server.ServerName(x.Config.ServerName))
+	}
+	if x.Config.CertFile != "" && x.Config.CertKeyFile != "" {
+		c, err := tls.LoadX509KeyPair(x.Config.CertFile, x.Config.CertKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		pk, ok := c.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("invalid private key, RSA key is required")
+		}
+		opts = append(opts,
+			server.PrivateKey(pk),
+			server.Certificate(c.Certificate[0]),
+			server.EnableSecurity("Basic256Sha256", ua.MessageSecurityModeSign),
+			server.EnableSecurity("Basic256Sha256", ua.MessageSecurityModeSignAndEncrypt),
+		)
+	}
+	return opts, nil
+}
+
+// newVariableNode 根据配置创建变量节点
+func newVariableNode(ns uint16, v Variable) (*server.Node, error) {
+	variant, err := opcuaClient.NewVariant(v.Value, v.DataType)
+	if err != nil {
+		return nil, fmt.Errorf("variable %s: %w", v.Name, err)
+	}
+	displayName := v.DisplayName
+	if displayName == "" {
+		displayName = v.Name
+	}
+	access := byte(ua.AccessLevelTypeCurrentRead)
+	if v.Writable {
+		access |= byte(ua.AccessLevelTypeCurrentWrite)
+	}
+	dv := &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp | ua.DataValueServerTimestamp,
+		Value:           variant,
+		SourceTimestamp: time.Now(),
+		ServerTimestamp: time.Now(),
+	}
+	n := server.NewNode(
+		ua.NewStringNodeID(ns, v.Name),
+		map[ua.AttributeID]*ua.DataValue{
+			ua.AttributeIDNodeClass:       server.DataValueFromValue(uint32(ua.NodeClassVariable)),
+			ua.AttributeIDBrowseName:      server.DataValueFromValue(attrs.BrowseName(v.Name)),
+			ua.AttributeIDDisplayName:     server.DataValueFromValue(attrs.DisplayName(displayName, "")),
+			ua.AttributeIDDescription:     server.DataValueFromValue(&ua.LocalizedText{EncodingMask: ua.LocalizedTextText, Text: v.Description}),
+			ua.AttributeIDDataType:        server.DataValueFromValue(ua.NewNumericExpandedNodeID(0, uint32(variant.Type()))),
+			ua.AttributeIDAccessLevel:     server.DataValueFromValue(access),
+			ua.AttributeIDUserAccessLevel: server.DataValueFromValue(access),
+		},
+		nil,
+		func() *ua.DataValue { return dv },
+	)
+	return n, nil
+}
+
+// lookupServer 根据端点 Id 查找运行中的服务器
+func lookupServer(id string) (*OpcUaServer, bool) {
+	if v, ok := servers.Load(id); ok {
+		return v.(*OpcUaServer), true
+	}
+	return nil, false
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestOpcUaServerEndpoint(t *testing.T) {
+	t.Run("New", func(t *testing.T) {
+		ep := (&OpcUaServer{}).New().(*OpcUaServer)
+		assert.Equal(t, "0.0.0.0", ep.Config.Host)
+		assert.Equal(t, 4840, ep.Config.Port)
+		assert.Equal(t, Type, ep.Type())
+	})
+
+	t.Run("StartAndSetValue", func(t *testing.T) {
+		ep := (&OpcUaServer{}).New().(*OpcUaServer)
+		err := ep.Init(engine.NewConfig(), types.Configuration{
+			"host":      "localhost",
+			"port":      48401,
+			"namespace": "urn:rulego:test",
+			"variables": []map[string]interface{}{
+				{"name": "Temperature", "dataType": "Double", "value": 20.5},
+				{"name": "Count", "dataType": "Int32", "value": 1, "writable": true},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "opc.tcp://localhost:48401", ep.Id())
+
+		err = ep.Start()
+		assert.Nil(t, err)
+		defer ep.Destroy()
+
+		v, err := ep.GetValue("Temperature")
+		assert.Nil(t, err)
+		assert.Equal(t, 20.5, v)
+
+		err = ep.SetValue("Count", float64(10))
+		assert.Nil(t, err)
+		v, err = ep.GetValue("Count")
+		assert.Nil(t, err)
+		assert.Equal(t, int32(10), v)
+
+		err = ep.SetValue("NotExist", 1)
+		assert.NotNil(t, err)
+
+		srv, ok := lookupServer(ep.Id())
+		assert.True(t, ok)
+		assert.Equal(t, ep, srv)
+	})
+}
+
+func TestWriteNode(t *testing.T) {
+	ep := (&OpcUaServer{}).New().(*OpcUaServer)
+	_ = ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48402,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Float", "value": 0},
+		},
+	})
+	err := ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	node, err := test.CreateAndInitNode("x/opcuaServerWrite", types.Configuration{
+		"server": ep.Id(),
+	}, Registry)
+	assert.Nil(t, err)
+
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `{"Temperature": 36.5}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+	v, err := ep.GetValue("Temperature")
+	assert.Nil(t, err)
+	assert.Equal(t, float32(36.5), v)
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaserver
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rulego/rulego"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteNodeConfiguration 节点配置
+type WriteNodeConfiguration struct {
+	// Server 内嵌服务器端点 Id，格式：opc.tcp://host:port，与 endpoint/opcuaServer 的 host、port 配置一致
+	Server string `json:"server" label:"Server" desc:"Embedded OPC UA server id, format: opc.tcp://host:port" required:"true"`
+}
+
+// WriteNode 更新内嵌 OPC UA 服务器变量值
+// 消息负荷 msg.Data 格式：
+//
+//	[
+//	  {
+//	    "nodeId": "Temperature",
+//	    "value": 25.5
+//	  }
+//	]
+//
+// 或者变量名称->值的对象：{"Temperature": 25.5, "Running": true}
+// 更新成功，流转到`Success`链，否则流程转到`Failure`链
+type WriteNode struct {
+	//节点配置
+	Config WriteNodeConfiguration
+}
+
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteNodeConfiguration{
+			Server: "opc.tcp://0.0.0.0:4840",
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/opcuaServerWrite"
+}
+
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	return maps.Map2Struct(configuration, &x.Config)
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	srv, ok := lookupServer(x.Config.Server)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("opcua server not found: %s", x.Config.Server))
+		return
+	}
+	values, err := parseValues([]byte(msg.GetData()))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	for _, d := range values {
+		if err := srv.SetValue(d.NodeId, d.Value); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+func (x *WriteNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Update variables of the embedded OPC-UA server endpoint. Routes to Success/Failure"
+}
+
+// parseValues 解析数组或对象格式的写入数据
+func parseValues(data []byte) ([]opcuaClient.Data, error) {
+	values := make([]opcuaClient.Data, 0)
+	if err := json.Unmarshal(data, &values); err == nil {
+		return values, nil
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		values = append(values, opcuaClient.Data{NodeId: k, Value: v})
+	}
+	return values, nil
+}
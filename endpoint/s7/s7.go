@@ -0,0 +1,665 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	s7Node "github.com/rulego/rulego-components-iot/external/s7"
+	"github.com/rulego/rulego-components-iot/pkg/buffer"
+	"github.com/rulego/rulego-components-iot/pkg/poll"
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "s7"
+const S7_DATA_MSG_TYPE = "S7_DATA"
+
+// 元数据key
+const (
+	KeyServer = "server"
+)
+
+// Endpoint 别名
+type Endpoint = S7
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	// data 点位名称->值
+	data       map[string]interface{}
+	metadata   *types.Metadata
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *RequestMessage) Body() []byte {
+	b, err := json.Marshal(r.data)
+	if err != nil {
+		log.Println(err)
+	}
+	return b
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := r.metadata
+		if metadata == nil {
+			metadata = types.NewMetadata()
+		}
+		//默认指定是JSON格式，如果不是该类型，请在process函数中修改
+		ruleMsg := types.NewMsg(0, S7_DATA_MSG_TYPE, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+type ResponseMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		//默认指定是JSON格式，如果不是该类型，请在process函数中修改
+		ruleMsg := types.NewMsg(0, S7_DATA_MSG_TYPE, types.JSON, types.NewMetadata(), string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// Tag 点位表中的一个点位
+type Tag struct {
+	// Name 点位名称，作为输出的 key，地址为符号时为空使用符号名称
+	Name string `json:"name"`
+	// Address 变量地址，eg. DB1.DBD20:REAL，见 x/s7Read，也可以是符号表中的符号 Motor1.Speed 或者点位注册表的引用 tag:<名称>
+	// 引用点位时名称为空使用点位名称，scale 和 offset 都为 0 时使用点位的缩放系数和偏移量
+	Address string `json:"address"`
+	// Scale 缩放系数，工程值 = 原始值 * scale + offset，scale 和 offset 都为 0 时输出原始值，只对数值类型有效
+	Scale float64 `json:"scale,omitempty"`
+	// Offset 偏移量
+	Offset float64 `json:"offset,omitempty"`
+}
+
+// value 按缩放系数和偏移量换算工程值
+func (t Tag) value(v interface{}) interface{} {
+	if t.Scale == 0 && t.Offset == 0 {
+		return v
+	}
+	var f float64
+	switch n := v.(type) {
+	case byte:
+		f = float64(n)
+	case int8:
+		f = float64(n)
+	case uint16:
+		f = float64(n)
+	case int16:
+		f = float64(n)
+	case uint32:
+		f = float64(n)
+	case int32:
+		f = float64(n)
+	case float32:
+		f = float64(n)
+	case uint64:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	case float64:
+		f = n
+	default:
+		return v
+	}
+	scale := t.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return f*scale + t.Offset
+}
+
+// S7Config S7 轮询配置
+type S7Config struct {
+	// Server PLC 地址，格式：host:port，端口默认 102
+	Server string `json:"server" label:"Server" desc:"PLC address, format: host:port, port defaults to 102" required:"true" ref:"primary"`
+	// Rack 机架号
+	Rack int `json:"rack" label:"Rack" desc:"PLC rack number"`
+	// Slot 槽号，S7-300/400 一般为 2，S7-1200/1500 一般为 1
+	Slot int `json:"slot" label:"Slot" desc:"PLC slot number, usually 2 for S7-300/400 and 1 for S7-1200/1500"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Interval 轮询间隔，支持 cron 表达式或者固定周期
+	// example: @every 10s, 10s (every 10 seconds) 0 0 0 * * * (triggers at midnight)
+	// 固定周期按固定频率读取，没有漂移，最小 10ms，eg. 200ms
+	Interval string `json:"interval" label:"Interval" desc:"Poll interval, supports cron expression or fixed period, e.g. @every 10s, 10s. Fixed periods poll at a fixed rate without drift, down to 10ms, e.g. 200ms"`
+	// Tags 点位表
+	Tags []Tag `json:"tags" label:"Tags" desc:"Tag table, each tag maps a name to a PLC address with optional scale and offset" required:"true"`
+	// Symbols 符号表文件或者目录，点位地址可以使用符号名称，见 x/s7Read
+	Symbols []string `json:"symbols" label:"Symbol Tables" desc:"TIA Portal exports to resolve symbolic addresses such as Motor1.Speed: PLC tag tables (.xlsx, .csv, .sdf) or data block sources (.db, .scl, .awl), files or directories"`
+	// OnChange 只输出值变化的点位，没有点位变化时不产生消息
+	OnChange bool `json:"onChange" label:"On Change" desc:"Only emit tags whose value changed since the last poll, no message when nothing changed"`
+	// ShutdownTimeout 停机时等待正在执行的轮询完成的最大秒数
+	ShutdownTimeout int `json:"shutdownTimeout" label:"Shutdown Timeout" desc:"Max seconds to wait for in-flight polls on shutdown before closing the connection, default 10"`
+	// Buffer 磁盘缓存，路由处理失败时缓存轮询数据，处理恢复后按顺序重放
+	Buffer buffer.Config `json:"buffer" label:"Store and Forward" desc:"Disk buffer for polled data when routing fails, replayed in order after recovery"`
+}
+
+// S7 西门子 S7 轮询端点
+// 按 interval 定时读取点位表，所有点位按 PDU 大小合并为尽可能少的请求，读取结果转换为 RuleMsg 交给路由处理，
+// 消息负荷为以点位名称为 key 的工程值：
+//
+//	{"temperature": 21.5, "running": true}
+//
+// 开启 onChange 时只输出值变化的点位，第一次轮询输出所有点位，引用点位注册表的点位按点位的死区判断是否变化。
+// 消息类型为 S7_DATA，元数据包含 server。相同 server、rack 和 slot 的端点和节点共享一个连接
+//
+// 配置 buffer.dir 后，路由处理失败（路由需要 Wait 才能获取规则链的处理结果）的数据写入磁盘缓存，
+// 下一次处理成功后按写入顺序重放，重放消息的元数据 bufferedAt 为缓存时间（Unix 毫秒）
+type S7 struct {
+	impl.BaseEndpoint
+	base.SharedNode[*s7Node.SharedConn]
+	// GracefulShutdown 提供优雅停机功能
+	base.GracefulShutdown
+	// Pauser 暂停和恢复轮询，见 poll.Pausable
+	poll.Pauser
+	RuleConfig types.Config
+	// s7 轮询配置
+	Config S7Config
+	// 路由实例
+	Router endpointApi.Router
+	// 定时任务实例
+	cronTask *cron.Cron
+	// 定时任务id
+	taskId cron.EntryID
+	// cronLock 保护定时任务
+	cronLock sync.Mutex
+	// tags 点位引用解析后的点位表
+	tags []Tag
+	// addresses 点位解析后的地址，顺序与 tags 一致
+	addresses []*s7Node.Address
+	// refs 引用点位注册表的点位，key 为点位名称
+	refs map[string]*tags.Tag
+	// lastLock 保护 lastValues
+	lastLock sync.Mutex
+	// lastValues 上一次输出的工程值，用于 onChange 模式
+	lastValues map[string]interface{}
+	// queue 磁盘缓存，未配置为 nil
+	queue *buffer.Queue
+}
+
+// Type 组件类型
+func (x *S7) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *S7) New() types.Node {
+	return &S7{
+		Config: S7Config{
+			Server:          s7Node.DefaultServer,
+			Rack:            0,
+			Slot:            1,
+			Timeout:         5,
+			Interval:        "@every 10s",
+			ShutdownTimeout: 10,
+		},
+	}
+}
+
+// Init 初始化
+func (x *S7) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if len(x.Config.Tags) == 0 {
+		return errors.New("s7 tags cannot be empty")
+	}
+	if _, err = poll.ParseSchedule(x.Config.Interval, poll.ScheduleOptions{}); err != nil {
+		return err
+	}
+	symbols, err := s7Node.LoadSymbols(x.Config.Symbols)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]struct{}, len(x.Config.Tags))
+	x.tags = make([]Tag, 0, len(x.Config.Tags))
+	x.addresses = make([]*s7Node.Address, 0, len(x.Config.Tags))
+	x.refs = make(map[string]*tags.Tag)
+	for _, tag := range x.Config.Tags {
+		address, ref, err := tags.Resolve(tags.ProtocolS7, tag.Address)
+		if err != nil {
+			return err
+		}
+		if ref != nil {
+			if tag.Name == "" {
+				tag.Name = ref.Name
+			}
+			if tag.Scale == 0 && tag.Offset == 0 {
+				tag.Scale, tag.Offset = ref.Scale, ref.Offset
+			}
+			tag.Address = address
+			x.refs[tag.Name] = ref
+		}
+		if symbol, ok := symbols.Lookup(tag.Address); ok && tag.Name == "" {
+			tag.Name = symbol.Name
+		}
+		if tag.Name == "" {
+			return fmt.Errorf("s7 tag name cannot be empty, address: %s", tag.Address)
+		}
+		if _, ok := names[tag.Name]; ok {
+			return fmt.Errorf("duplicate s7 tag name: %s", tag.Name)
+		}
+		names[tag.Name] = struct{}{}
+		a, err := symbols.ParseAddress(tag.Address)
+		if err != nil {
+			return err
+		}
+		x.tags = append(x.tags, tag)
+		x.addresses = append(x.addresses, a)
+	}
+	x.RuleConfig = ruleConfig
+
+	// 初始化优雅停机功能
+	x.GracefulShutdown.InitGracefulShutdown(x.RuleConfig.Logger, x.shutdownTimeout())
+
+	if x.queue != nil {
+		_ = x.queue.Close()
+		x.queue = nil
+	}
+	if x.Config.Buffer.Enabled() {
+		if x.queue, err = buffer.Open(x.Config.Buffer); err != nil {
+			return err
+		}
+	}
+	return x.initSharedNode()
+}
+
+// Destroy 销毁
+func (x *S7) Destroy() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+// Desc returns the component description
+func (x *S7) Desc() string {
+	return "Siemens S7 endpoint for polling a tag table on a schedule and routing the tag values"
+}
+
+// Category returns the component category
+func (x *S7) Category() string {
+	return "endpoint"
+}
+
+func (x *S7) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Siemens S7 endpoint for polling a tag table on a schedule and routing the tag values",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+// GracefulStop 为 S7 端点提供优雅停机
+func (x *S7) GracefulStop() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+func (x *S7) Close() error {
+	x.cronLock.Lock()
+	if x.taskId != 0 && x.cronTask != nil {
+		x.cronTask.Remove(x.taskId)
+	}
+	var cronStopped context.Context
+	if x.cronTask != nil {
+		cronStopped = x.cronTask.Stop()
+	}
+	x.cronLock.Unlock()
+	// 等待正在执行的轮询完成后再释放连接
+	x.drain(cronStopped)
+	// SharedNode 通过 InitWithClose 中的清理函数释放共享连接
+	_ = x.SharedNode.Close()
+	if x.queue != nil {
+		_ = x.queue.Close()
+	}
+	return nil
+}
+
+// drain 等待正在执行的定时读取和 DoProcess 完成，超时后取消停机上下文强制中断
+func (x *S7) drain(cronStopped context.Context) {
+	timeout := x.shutdownTimeout()
+	deadline := time.Now().Add(timeout)
+	if cronStopped != nil {
+		select {
+		case <-cronStopped.Done():
+		case <-time.After(timeout):
+		}
+	}
+	if x.GracefulShutdown.GetActiveOperations() <= 0 {
+		return
+	}
+	if !x.GracefulShutdown.WaitForActiveOperations(time.Until(deadline)) {
+		x.Printf("graceful shutdown timeout after %v, forcing context cancellation", timeout)
+		x.GracefulShutdown.ForceStop()
+		x.GracefulShutdown.WaitForActiveOperations(500 * time.Millisecond)
+	}
+}
+
+// shutdownTimeout 优雅停机超时时间
+func (x *S7) shutdownTimeout() time.Duration {
+	if x.Config.ShutdownTimeout > 0 {
+		return time.Duration(x.Config.ShutdownTimeout) * time.Second
+	}
+	return base.DefaultShutdownTimeout
+}
+
+func (x *S7) Id() string {
+	return x.Config.Server
+}
+
+func (x *S7) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.Router = router
+	poll.Register(router.GetId(), x)
+	return router.GetId(), nil
+}
+
+func (x *S7) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		poll.Unregister(x.Router.GetId(), x)
+	}
+	x.Router = nil
+	return nil
+}
+
+func (x *S7) Start() error {
+	if !x.SharedNode.IsInit() {
+		if err := x.initSharedNode(); err != nil {
+			return err
+		}
+	}
+	x.cronLock.Lock()
+	defer x.cronLock.Unlock()
+	if x.cronTask != nil {
+		x.cronTask.Stop()
+	}
+	x.cronTask = cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger)), cron.WithLogger(cron.DefaultLogger))
+	schedule, err := poll.ParseSchedule(x.Config.Interval, poll.ScheduleOptions{})
+	if err != nil {
+		return err
+	}
+	x.taskId = x.cronTask.Schedule(schedule, cron.FuncJob(x.onTick))
+	x.cronTask.Start()
+	return nil
+}
+
+// onTick 定时读取点位表
+func (x *S7) onTick() {
+	// 暂停期间不读取设备
+	if x.Router != nil && !x.Paused() {
+		_ = x.poll(x.Router)
+	}
+}
+
+func (x *S7) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// poll 读取点位表并交给路由处理，任意点位读取失败则丢弃本次结果
+func (x *S7) poll(router endpointApi.Router) error {
+	// 增加活跃操作计数
+	x.GracefulShutdown.IncrementActiveOperations()
+	defer x.GracefulShutdown.DecrementActiveOperations()
+	// 停机过程中不再发起新的读取
+	if x.GracefulShutdown.IsShuttingDown() {
+		return nil
+	}
+
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		x.Printf("get shared connection error %v ", err)
+		return err
+	}
+	var values []interface{}
+	err = conn.Do(func(client *s7Node.Client) error {
+		values, err = client.ReadItems(x.addresses)
+		return err
+	})
+	if err != nil {
+		x.Printf("poll s7 error %v ", err)
+		return err
+	}
+	data := make(map[string]interface{}, len(values))
+	for i, tag := range x.tags {
+		data[tag.Name] = tag.value(values[i])
+	}
+	if x.Config.OnChange {
+		if data = x.changed(data); len(data) == 0 {
+			return nil
+		}
+	}
+	metadata := types.NewMetadata()
+	metadata.PutValue(KeyServer, x.Config.Server)
+	x.deliver(router, &RequestMessage{data: data, metadata: metadata})
+	return nil
+}
+
+// deliver 交给路由处理，配置了磁盘缓存时处理失败的数据写入缓存，处理成功后重放缓存的数据
+func (x *S7) deliver(router endpointApi.Router, request *RequestMessage) {
+	var entry buffer.Entry
+	if x.queue != nil {
+		entry = newEntry(request.GetMsg())
+	}
+	exchange := &endpointApi.Exchange{
+		In:  request,
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+	if x.queue == nil {
+		return
+	}
+	if err := exchange.Out.GetError(); err != nil {
+		if err := x.queue.PushEntry(entry); err != nil {
+			x.Printf("buffer s7 data error %v ", err)
+		}
+		return
+	}
+	_, err := x.queue.ReplayEntries(func(record buffer.Record, entry buffer.Entry) error {
+		if x.GracefulShutdown.IsShuttingDown() {
+			return context.Canceled
+		}
+		metadata := types.BuildMetadata(entry.Metadata)
+		metadata.PutValue(buffer.KeyBufferedAt, strconv.FormatInt(record.Time.UnixMilli(), 10))
+		msg := types.NewMsg(0, entry.Type, types.JSON, metadata, entry.Data)
+		exchange := &endpointApi.Exchange{
+			In:  &RequestMessage{msg: &msg},
+			Out: &ResponseMessage{},
+		}
+		x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+		return exchange.Out.GetError()
+	})
+	if err != nil && err != context.Canceled {
+		x.Printf("replay buffered s7 data error %v ", err)
+	}
+}
+
+// newEntry 复制消息的类型、元数据和负荷用于缓存
+func newEntry(msg *types.RuleMsg) buffer.Entry {
+	metadata := make(map[string]string)
+	for k, v := range msg.Metadata.Values() {
+		metadata[k] = v
+	}
+	return buffer.Entry{Type: msg.Type, Metadata: metadata, Data: msg.GetData()}
+}
+
+// changed 返回与上一次输出相比值变化的点位，并记录输出的值
+// 引用点位注册表的点位与上一次输出的值比较死区，避免缓慢变化一直不输出
+func (x *S7) changed(data map[string]interface{}) map[string]interface{} {
+	x.lastLock.Lock()
+	defer x.lastLock.Unlock()
+	if x.lastValues == nil {
+		x.lastValues = make(map[string]interface{}, len(data))
+	}
+	result := make(map[string]interface{}, len(data))
+	for name, v := range data {
+		if last, ok := x.lastValues[name]; ok {
+			if ref := x.refs[name]; ref != nil && !ref.Exceeds(last, v) || ref == nil && last == v {
+				continue
+			}
+		}
+		result[name] = v
+		x.lastValues[name] = v
+	}
+	return result
+}
+
+// initSharedNode 初始化共享连接，相同 server、rack 和 slot 的组件共用一个连接
+func (x *S7) initSharedNode() error {
+	config := s7Node.ClientConfig{
+		Server:  x.Config.Server,
+		Rack:    x.Config.Rack,
+		Slot:    x.Config.Slot,
+		Timeout: time.Duration(x.Config.Timeout) * time.Second,
+	}
+	return x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), config.Key(), false, func() (*s7Node.SharedConn, error) {
+		conn := s7Node.AcquireConn(config)
+		if _, err := conn.Open(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *s7Node.SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+)
+
+// testPLC 测试用的 S7 PLC，只提供 DB1 的按字节读取
+type testPLC struct {
+	listener net.Listener
+	mu       sync.Mutex
+	db1      []byte
+}
+
+func startTestPLC(t *testing.T) *testPLC {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plc := &testPLC{listener: listener, db1: make([]byte, 16)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go plc.serve(conn)
+		}
+	}()
+	return plc
+}
+
+func (p *testPLC) set(offset int, value uint16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	binary.BigEndian.PutUint16(p.db1[offset:], value)
+}
+
+func (p *testPLC) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.BigEndian.Uint16(header[2:])-4)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		var resp []byte
+		if payload[1] == 0xe0 {
+			resp = append([]byte(nil), payload...)
+			resp[1] = 0xd0
+		} else {
+			s7 := payload[3:]
+			param := s7[10 : 10+binary.BigEndian.Uint16(s7[6:8])]
+			var respParam, respData []byte
+			if param[0] == 0xf0 {
+				respParam = []byte{0xf0, 0, 0, 1, 0, 1, 0x01, 0xe0}
+			} else {
+				respParam = []byte{0x04, param[1]}
+				p.mu.Lock()
+				for i := 0; i < int(param[1]); i++ {
+					spec := param[2+i*12:]
+					length := int(binary.BigEndian.Uint16(spec[4:6]))
+					start := (int(spec[9])<<16 | int(spec[10])<<8 | int(spec[11])) / 8
+					if len(respData)%2 == 1 {
+						respData = append(respData, 0)
+					}
+					respData = append(respData, 0xff, 0x04, byte(length*8>>8), byte(length*8))
+					respData = append(respData, p.db1[start:start+length]...)
+				}
+				p.mu.Unlock()
+			}
+			resp = []byte{0x02, 0xf0, 0x80, 0x32, 0x03, 0, 0, s7[4], s7[5],
+				byte(len(respParam) >> 8), byte(len(respParam)), byte(len(respData) >> 8), byte(len(respData)), 0, 0}
+			resp = append(append(resp, respParam...), respData...)
+		}
+		packet := []byte{0x03, 0, 0, 0}
+		binary.BigEndian.PutUint16(packet[2:], uint16(4+len(resp)))
+		if _, err := conn.Write(append(packet, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func TestS7Endpoint(t *testing.T) {
+	t.Run("New", func(t *testing.T) {
+		ep := (&S7{}).New().(*S7)
+		if ep.Config.Interval != "@every 10s" {
+			t.Errorf("期望默认间隔为 '@every 10s', 实际为 '%s'", ep.Config.Interval)
+		}
+		if ep.Type() != Type {
+			t.Errorf("期望类型为 '%s', 实际为 '%s'", Type, ep.Type())
+		}
+	})
+
+	t.Run("InitInvalid", func(t *testing.T) {
+		config := engine.NewConfig()
+		ep := (&S7{}).New().(*S7)
+		if err := ep.Init(config, types.Configuration{"server": "127.0.0.1:1102"}); err == nil {
+			t.Fatalf("tags 为空应该返回错误")
+		}
+		ep = (&S7{}).New().(*S7)
+		err := ep.Init(config, types.Configuration{
+			"server": "127.0.0.1:1102",
+			"tags": []map[string]interface{}{
+				{"name": "a", "address": "DB1.DBW0"},
+				{"name": "a", "address": "DB1.DBW2"},
+			},
+		})
+		if err == nil {
+			t.Fatalf("重复的点位名称应该返回错误")
+		}
+		ep = (&S7{}).New().(*S7)
+		err = ep.Init(config, types.Configuration{
+			"server":   "127.0.0.1:1102",
+			"interval": "5ms",
+			"tags":     []map[string]interface{}{{"name": "a", "address": "DB1.DBW0"}},
+		})
+		if err == nil {
+			t.Fatalf("小于最小间隔应该返回错误")
+		}
+	})
+
+	t.Run("TagValue", func(t *testing.T) {
+		tag := Tag{Scale: 0.1, Offset: -10}
+		if v := tag.value(int16(250)); v != float64(15) {
+			t.Errorf("期望 15, 实际为 %v", v)
+		}
+		if v := tag.value(true); v != true {
+			t.Errorf("非数值类型不应该换算, 实际为 %v", v)
+		}
+		if v := (Tag{}).value(uint16(3)); v != uint16(3) {
+			t.Errorf("未配置缩放应该输出原始值, 实际为 %v", v)
+		}
+	})
+
+	t.Run("Poll", func(t *testing.T) {
+		plc := startTestPLC(t)
+		defer plc.listener.Close()
+		plc.set(0, 250)
+		plc.set(2, 7)
+
+		config := engine.NewConfig()
+		_, err := engine.New("s7-test01", []byte(`{
+			"ruleChain": {"id": "s7-test01", "name": "s7-test01"},
+			"metadata": {"nodes": []}
+		}`), engine.WithConfig(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer engine.Del("s7-test01")
+
+		ep := (&S7{}).New().(*S7)
+		err = ep.Init(config, types.Configuration{
+			"server":   plc.listener.Addr().String(),
+			"interval": "200ms",
+			"onChange": true,
+			"tags": []map[string]interface{}{
+				{"name": "temperature", "address": "DB1.DBW0:INT", "scale": 0.1},
+				{"name": "count", "address": "DB1.DBW2"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var lock sync.Mutex
+		var messages []map[string]interface{}
+		var metadata *types.Metadata
+		router := impl.NewRouter().From("").To("chain:s7-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msg := exchange.In.GetMsg()
+			data := make(map[string]interface{})
+			_ = json.Unmarshal([]byte(msg.GetData()), &data)
+			lock.Lock()
+			messages = append(messages, data)
+			metadata = msg.Metadata
+			lock.Unlock()
+			return true
+		}).End()
+		if _, err = ep.AddRouter(router); err != nil {
+			t.Fatal(err)
+		}
+		if err = ep.Start(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(700 * time.Millisecond)
+		plc.set(2, 8)
+		time.Sleep(500 * time.Millisecond)
+		ep.Destroy()
+
+		lock.Lock()
+		defer lock.Unlock()
+		// 只在第一次轮询和值变化时产生消息
+		if len(messages) != 2 {
+			t.Fatalf("期望 2 条消息, 实际为 %d: %v", len(messages), messages)
+		}
+		if messages[0]["temperature"] != float64(25) || messages[0]["count"] != float64(7) {
+			t.Errorf("第一次轮询结果错误: %v", messages[0])
+		}
+		if len(messages[1]) != 1 || messages[1]["count"] != float64(8) {
+			t.Errorf("变化的点位错误: %v", messages[1])
+		}
+		if metadata.GetValue(KeyServer) != plc.listener.Addr().String() {
+			t.Errorf("元数据错误: %v", metadata.Values())
+		}
+	})
+}
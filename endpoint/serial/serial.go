@@ -0,0 +1,472 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package serial 提供串口端点
+// 端点持续读取串口，按分隔符、固定长度、静默超时或者 STX/ETX（可带校验）把字节流拆分为帧，帧按前缀路由到规则链，
+// 规则链可以通过 x/serialWrite 节点经同一个串口写回数据，适用于电子秤、扫码枪和老式仪表等只有原始串口协议的设备
+package serial
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	serialNode "github.com/rulego/rulego-components-iot/external/serial"
+	"github.com/rulego/rulego-components-iot/pkg/framing"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "serial"
+
+// 元数据key
+const (
+	KeyPort   = "port"
+	KeyLength = "length"
+)
+
+// MsgTypeFrame 帧的消息类型
+const MsgTypeFrame = "SERIAL"
+
+// 帧的消息格式
+const (
+	DataTypeText   = serialNode.DataTypeText
+	DataTypeBinary = serialNode.DataTypeBinary
+	DataTypeHex    = serialNode.DataTypeHex
+	DataTypeBase64 = serialNode.DataTypeBase64
+)
+
+// readTick 读取超时，用于检查关闭和帧间静默
+const readTick = 50 * time.Millisecond
+
+// reconnectInterval 串口读取失败后重新打开的间隔
+var reconnectInterval = 5 * time.Second
+
+// Endpoint 别名
+type Endpoint = Serial
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// ports 运行中的端点，key 为端点 Id，供 x/serialWrite 节点查找
+var ports sync.Map
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers  textproto.MIMEHeader
+	body     []byte
+	port     string
+	dataType string
+	msg      *types.RuleMsg
+	err      error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.port
+}
+
+// GetParam 获取串口名称和帧长度
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyPort:
+		return r.port
+	case KeyLength:
+		return strconv.Itoa(len(r.body))
+	default:
+		return ""
+	}
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 SERIAL，帧按 dataType 转换为文本、二进制、十六进制或者 base64，串口名称和帧长度放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyPort, r.port)
+		metadata.PutValue(KeyLength, strconv.Itoa(len(r.body)))
+		var ruleMsg types.RuleMsg
+		switch r.dataType {
+		case DataTypeBinary:
+			ruleMsg = types.NewMsgFromBytes(0, MsgTypeFrame, types.BINARY, metadata, r.body)
+		case DataTypeHex:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, hex.EncodeToString(r.body))
+		case DataTypeBase64:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, base64.StdEncoding.EncodeToString(r.body))
+		default:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, string(r.body))
+		}
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 串口帧不需要响应，写回使用 x/serialWrite 节点
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// SerialConfig 串口端点配置
+type SerialConfig struct {
+	serialNode.SharedSerialConfig `json:",squash"`
+	framing.Config                `json:",squash"`
+	// DataType 帧的消息格式：text、binary、hex、base64
+	DataType string `json:"dataType" label:"Data Type" desc:"Message data type of frames: text, binary, hex or base64"`
+}
+
+// Serial 串口端点
+// 路由的 from 为帧的前缀，eg. $GP、ST，为空或者 * 匹配所有帧，帧发送到所有匹配的路由
+type Serial struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     SerialConfig
+	// framer 用于检查配置和写回时封装帧，读取协程使用自己的分帧器
+	framer framing.Framer
+	// mu 保护 port
+	mu      sync.Mutex
+	port    *serialNode.SafeSerialPort
+	started bool
+	closed  chan struct{}
+}
+
+// Type 组件类型
+func (x *Serial) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *Serial) New() types.Node {
+	return &Serial{
+		Config: SerialConfig{
+			SharedSerialConfig: serialNode.SharedSerialConfig{
+				BaudRate: 9600, DataBits: 8, StopBits: serialNode.StopBits1, Parity: serialNode.ParityNone, DTR: true,
+			},
+			Config: framing.Config{
+				Framing:   framing.Delimiter,
+				Delimiter: "\\r\\n",
+				Checksum:  framing.ChecksumNone,
+				MaxLength: framing.DefaultMaxLength,
+			},
+			DataType: DataTypeText,
+		},
+	}
+}
+
+// Init 初始化
+func (x *Serial) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if strings.TrimSpace(x.Config.Port) == "" {
+		return errors.New("serial port is empty")
+	}
+	switch x.Config.DataType {
+	case "":
+		x.Config.DataType = DataTypeText
+	case DataTypeText, DataTypeBinary, DataTypeHex, DataTypeBase64:
+	default:
+		return fmt.Errorf("unsupported serial data type: %s", x.Config.DataType)
+	}
+	x.framer, err = framing.New(x.Config.Config)
+	return err
+}
+
+// Destroy 销毁
+func (x *Serial) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *Serial) Desc() string {
+	return "Serial port endpoint splitting the byte stream into delimiter, fixed length, timeout or STX/ETX frames and routing them to rule chains"
+}
+
+// Category returns the component category
+func (x *Serial) Category() string {
+	return "endpoint"
+}
+
+func (x *Serial) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "Serial port endpoint splitting the byte stream into delimiter, fixed length, timeout or STX/ETX frames and routing them to rule chains",
+		RouterForm: &types.RouterForm{
+			From: &types.RouterFormField{
+				Path: types.ComponentFormField{
+					Name:  "path",
+					Type:  "string",
+					Label: "Prefix",
+					Desc:  "Frame prefix to route, eg. ST or $GP, empty or * routes all frames",
+				},
+			},
+		},
+	}
+}
+
+func (x *Serial) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	ports.CompareAndDelete(x.Id(), x)
+	if !x.started {
+		return nil
+	}
+	x.started = false
+	close(x.closed)
+	return x.port.Close()
+}
+
+func (x *Serial) Id() string {
+	return x.Config.Port
+}
+
+func (x *Serial) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpointApi.Router)
+	}
+	x.RouterStorage[router.GetId()] = router
+	return router.GetId(), nil
+}
+
+func (x *Serial) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if _, ok := x.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(x.RouterStorage, routerId)
+	return nil
+}
+
+// routersOf 前缀匹配帧的路由
+func (x *Serial) routersOf(frame []byte) []endpointApi.Router {
+	x.RLock()
+	defer x.RUnlock()
+	var routers []endpointApi.Router
+	for _, r := range x.RouterStorage {
+		var prefix string
+		if from := r.GetFrom(); from != nil {
+			prefix = from.ToString()
+		}
+		if prefix == "" || prefix == "*" || bytes.HasPrefix(frame, []byte(prefix)) {
+			routers = append(routers, r)
+		}
+	}
+	return routers
+}
+
+// Start 打开串口并开始读取，读取失败后自动重新打开
+func (x *Serial) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.started {
+		return nil
+	}
+	port := &serialNode.SafeSerialPort{Config: x.Config.SharedSerialConfig}
+	// 设置读取超时时打开串口
+	if err := port.SetReadTimeout(readTick); err != nil {
+		return err
+	}
+	framer, err := framing.New(x.Config.Config)
+	if err != nil {
+		_ = port.Close()
+		return err
+	}
+	x.port, x.started, x.closed = port, true, make(chan struct{})
+	go x.readLoop(port, framer, x.closed)
+	ports.Store(x.Id(), x)
+	x.Printf("started serial endpoint on %s", x.Config.Port)
+	return nil
+}
+
+// readLoop 读取并分帧，失败后重新打开串口，直到端点关闭
+func (x *Serial) readLoop(port *serialNode.SafeSerialPort, framer framing.Framer, closed chan struct{}) {
+	idle := time.Duration(x.Config.Timeout) * time.Millisecond
+	buf := make([]byte, 1024)
+	last := time.Now()
+	for {
+		n, err := port.Read(buf)
+		select {
+		case <-closed:
+			// 关闭时读取可能重新打开了串口
+			_ = port.Close()
+			return
+		default:
+		}
+		if err != nil {
+			x.Printf("serial %s read error: %v, reopening", x.Config.Port, err)
+			framer.Reset()
+			select {
+			case <-closed:
+				return
+			case <-time.After(reconnectInterval):
+			}
+			_ = port.SetReadTimeout(readTick)
+			continue
+		}
+		if n > 0 {
+			last = time.Now()
+			framer.Feed(buf[:n], x.onFrame)
+		} else if idle > 0 && framer.Pending() && time.Since(last) >= idle {
+			if frame := framer.Idle(); frame != nil {
+				x.onFrame(frame, nil)
+			}
+		}
+	}
+}
+
+func (x *Serial) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// onFrame 帧交给匹配的路由，校验错误和超长的帧只记录日志
+func (x *Serial) onFrame(frame []byte, err error) {
+	if err != nil {
+		x.Printf("serial %s: %v", x.Config.Port, err)
+		return
+	}
+	for _, router := range x.routersOf(frame) {
+		exchange := &endpointApi.Exchange{
+			In:  &RequestMessage{port: x.Config.Port, body: frame, dataType: x.Config.DataType},
+			Out: &ResponseMessage{},
+		}
+		x.DoProcess(context.Background(), router, exchange)
+	}
+}
+
+// Write 写入数据，raw 为 false 时按分帧方式封装
+func (x *Serial) Write(data []byte, raw bool) error {
+	x.mu.Lock()
+	port := x.port
+	started := x.started
+	x.mu.Unlock()
+	if !started {
+		return fmt.Errorf("serial endpoint not started: %s", x.Id())
+	}
+	if !raw {
+		data = x.framer.Encode(data)
+	}
+	_, err := port.Write(data)
+	return err
+}
+
+// lookupPort 查找运行中的端点
+func lookupPort(id string) (*Serial, bool) {
+	if v, ok := ports.Load(id); ok {
+		return v.(*Serial), true
+	}
+	return nil, false
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serial
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	serialNode "github.com/rulego/rulego-components-iot/external/serial"
+	"github.com/rulego/rulego-components-iot/pkg/framing"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"go.bug.st/serial"
+)
+
+// testPort 测试用的串口，rx 模拟设备发送的数据，读取超时返回 0
+type testPort struct {
+	rx      chan []byte
+	timeout time.Duration
+	mu      sync.Mutex
+	tx      bytes.Buffer
+	closed  bool
+}
+
+func (p *testPort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	closed, timeout := p.closed, p.timeout
+	p.mu.Unlock()
+	if closed {
+		return 0, errors.New("port closed")
+	}
+	select {
+	case data := <-p.rx:
+		return copy(b, data), nil
+	case <-time.After(timeout):
+		return 0, nil
+	}
+}
+
+func (p *testPort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tx.Write(b)
+}
+
+func (p *testPort) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *testPort) SetReadTimeout(t time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeout = t
+	return nil
+}
+
+func (p *testPort) SetDTR(dtr bool) error {
+	return nil
+}
+
+func (p *testPort) SetRTS(rts bool) error {
+	return nil
+}
+
+func (p *testPort) ResetInputBuffer() error {
+	return nil
+}
+
+func (p *testPort) ResetOutputBuffer() error {
+	return nil
+}
+
+func (p *testPort) written() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tx.String()
+}
+
+func TestSerialEndpoint(t *testing.T) {
+	port := &testPort{rx: make(chan []byte, 10), timeout: readTick}
+	serialNode.SetSerialOpener(func(name string, mode *serial.Mode) (serialNode.ISerialPort, error) {
+		if name != "/dev/ttyTEST" {
+			return nil, errors.New("no such port")
+		}
+		return port, nil
+	})
+
+	config := engine.NewConfig()
+	_, err := engine.New("serial-test01", []byte(`{
+		"ruleChain": {"id": "serial-test01", "name": "serial-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("serial-test01")
+
+	for _, configuration := range []types.Configuration{
+		{"port": ""},
+		{"port": "/dev/ttyTEST", "dataType": "json"},
+		{"port": "/dev/ttyTEST", "framing": framing.Fixed},
+	} {
+		assert.NotNil(t, (&Serial{}).New().Init(config, configuration))
+	}
+	ep := (&Serial{}).New().(*Serial)
+	assert.Nil(t, ep.Init(config, types.Configuration{"port": "/dev/ttyMISSING"}))
+	assert.NotNil(t, ep.Start())
+
+	ep = (&Serial{}).New().(*Serial)
+	assert.Nil(t, ep.Init(config, types.Configuration{
+		"port":     "/dev/ttyTEST",
+		"framing":  framing.StxEtx,
+		"checksum": framing.ChecksumXor,
+		"dataType": DataTypeText,
+	}))
+	frames := make(chan types.RuleMsg, 10)
+	all := make(chan types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("ST").To("chain:serial-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		frames <- *exchange.In.GetMsg()
+		return true
+	}).End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("").To("chain:serial-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		all <- *exchange.In.GetMsg()
+		return true
+	}).End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	framer, _ := framing.New(ep.Config.Config)
+	frame := framer.Encode([]byte("ST,+12.5kg"))
+	port.rx <- frame[:4]
+	port.rx <- frame[4:]
+	port.rx <- framer.Encode([]byte("US,+0.0kg"))
+	select {
+	case msg := <-frames:
+		assert.Equal(t, MsgTypeFrame, msg.Type)
+		assert.Equal(t, "ST,+12.5kg", msg.GetData())
+		assert.Equal(t, "/dev/ttyTEST", msg.Metadata.GetValue(KeyPort))
+		assert.Equal(t, "10", msg.Metadata.GetValue(KeyLength))
+	case <-time.After(time.Second):
+		t.Fatal("frame not routed")
+	}
+	for _, expected := range []string{"ST,+12.5kg", "US,+0.0kg"} {
+		select {
+		case msg := <-all:
+			assert.Equal(t, expected, msg.GetData())
+		case <-time.After(time.Second):
+			t.Fatal("frame not routed")
+		}
+	}
+
+	// 写回按端点的分帧方式封装
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	_, err = test.CreateAndInitNode("x/serialWrite", types.Configuration{"port": "/dev/ttyTEST", "dataType": "json"}, Registry)
+	assert.NotNil(t, err)
+	node, err := test.CreateAndInitNode("x/serialWrite", types.Configuration{"port": "/dev/ttyTEST"}, Registry)
+	assert.Nil(t, err)
+	rawNode, err := test.CreateAndInitNode("x/serialWrite", types.Configuration{"port": "/dev/ttyTEST", "dataType": DataTypeHex, "raw": true}, Registry)
+	assert.Nil(t, err)
+	missingNode, err := test.CreateAndInitNode("x/serialWrite", types.Configuration{"port": "/dev/ttyMISSING"}, Registry)
+	assert.Nil(t, err)
+
+	// send 发送消息并等待回调完成，写入的顺序和断言依赖上一个消息的结果
+	send := func(node types.Node, data, relation string) {
+		done := make(chan struct{})
+		test.NodeOnMsg(t, node, []test.Msg{{MetaData: types.NewMetadata(), DataType: types.TEXT, MsgType: "TEST", Data: data}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer close(done)
+			assert.Equal(t, relation, relationType)
+		})
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for serial write node")
+		}
+	}
+	send(node, "T", types.Success)
+	send(rawNode, "05 06", types.Success)
+	send(missingNode, "T", types.Failure)
+	assert.Equal(t, "\x02T\x03\x57\x05\x06", port.written())
+
+	ep.Destroy()
+	_, ok := lookupPort("/dev/ttyTEST")
+	assert.False(t, ok)
+	assert.NotNil(t, ep.Write([]byte("T"), false))
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serial
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteNodeConfiguration 节点配置
+type WriteNodeConfiguration struct {
+	// Port 串口端点 Id，与 endpoint/serial 的 port 配置一致，eg. /dev/ttyUSB0
+	Port string `json:"port" label:"Port" desc:"Serial endpoint id, same as the port of endpoint/serial" required:"true"`
+	// Data 发送的内容，可以使用 ${metadata.key} 或者 ${msg.key} 变量，为空使用 msg.Data
+	Data string `json:"data" label:"Data" desc:"Data to send, supports ${metadata.key} and ${msg.key} variables, empty uses msg.Data"`
+	// DataType 发送内容的格式：text、hex、base64，binary 使用消息的二进制负荷
+	DataType string `json:"dataType" label:"Data Type" desc:"Data type: text, hex, base64 or binary (raw msg bytes)"`
+	// Raw 不按端点的分帧方式封装，原样写入
+	Raw bool `json:"raw" label:"Raw" desc:"Write the data as is without adding the endpoint delimiter or STX/ETX and checksum"`
+}
+
+// WriteNode 通过串口端点写回数据，默认按端点的分帧方式封装：分隔符分帧时追加分隔符，STX/ETX 分帧时加上 STX、ETX 和校验。
+// 写入成功，流转到`Success`链，否则流转到`Failure`链
+type WriteNode struct {
+	//节点配置
+	Config       WriteNodeConfiguration
+	dataTemplate str.Template
+}
+
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteNodeConfiguration{
+			Port:     "/dev/ttyUSB0",
+			DataType: DataTypeText,
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/serialWrite"
+}
+
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	switch x.Config.DataType {
+	case "":
+		x.Config.DataType = DataTypeText
+	case DataTypeText, DataTypeBinary, DataTypeHex, DataTypeBase64:
+	default:
+		return fmt.Errorf("unsupported serial data type: %s", x.Config.DataType)
+	}
+	if x.Config.Data != "" {
+		x.dataTemplate = str.NewTemplate(x.Config.Data)
+	}
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	port, ok := lookupPort(x.Config.Port)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("serial endpoint not found: %s", x.Config.Port))
+		return
+	}
+	data, err := x.data(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if err = port.Write(data, x.Config.Raw); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// data 按配置的格式解析发送的内容
+func (x *WriteNode) data(ctx types.RuleContext, msg types.RuleMsg) ([]byte, error) {
+	if x.Config.DataType == DataTypeBinary && x.dataTemplate == nil {
+		return msg.GetBytes(), nil
+	}
+	text := msg.GetData()
+	if x.dataTemplate != nil {
+		text = x.dataTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	switch x.Config.DataType {
+	case DataTypeHex:
+		return hex.DecodeString(strings.Join(strings.Fields(text), ""))
+	case DataTypeBase64:
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	default:
+		return []byte(text), nil
+	}
+}
+
+// Destroy 清理资源
+func (x *WriteNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Write data back through the serial endpoint using its framing. Routes to Success/Failure"
+}
@@ -0,0 +1,553 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package snmptrap 提供 SNMP trap 接收端点
+// 端点接收网络设备、UPS 等发送的 v1/v2c/v3 trap 和 inform，回复 inform 的确认，并把变量绑定转换成 JSON 消息交给规则链处理
+package snmptrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	snmpNode "github.com/rulego/rulego-components-iot/external/snmp"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "snmpTrap"
+
+// 消息类型
+const (
+	SNMP_TRAP_MSG_TYPE   = "SNMP_TRAP"
+	SNMP_INFORM_MSG_TYPE = "SNMP_INFORM"
+)
+
+// 元数据key
+const (
+	KeyVersion    = "version"
+	KeyTrapOID    = "trapOid"
+	KeyTrapName   = "trapName"
+	KeySourceAddr = "sourceAddr"
+	KeyUserName   = "userName"
+)
+
+// Endpoint 别名
+type Endpoint = SnmpTrap
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+// Variable 变量绑定
+type Variable struct {
+	OID string `json:"oid"`
+	// Name OID 的符号名称，开启 resolveNames 时输出，eg. ifDescr.3
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// TrapEvent 收到的 trap 或者 inform
+type TrapEvent struct {
+	Version string `json:"version"`
+	// Community v1/v2c 团体名
+	Community string `json:"community,omitempty"`
+	// UserName v3 用户名
+	UserName string `json:"userName,omitempty"`
+	// TrapOID snmpTrapOID.0，v1 trap 按 RFC 3584 转换
+	TrapOID string `json:"trapOid"`
+	// TrapName trap OID 的符号名称，开启 resolveNames 时输出，eg. linkDown
+	TrapName string `json:"trapName,omitempty"`
+	// Uptime 发送方的 sysUpTime.0，单位 0.01 秒
+	Uptime uint32 `json:"uptime"`
+	// 以下字段只用于 v1 trap
+	Enterprise   string `json:"enterprise,omitempty"`
+	AgentAddr    string `json:"agentAddr,omitempty"`
+	GenericTrap  *int   `json:"genericTrap,omitempty"`
+	SpecificTrap *int   `json:"specificTrap,omitempty"`
+	// Variables 变量绑定，不包含 sysUpTime.0 和 snmpTrapOID.0
+	Variables  []Variable `json:"variables"`
+	SourceAddr string     `json:"sourceAddr"`
+	Timestamp  time.Time  `json:"timestamp"`
+	inform     bool
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	event   TrapEvent
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body, r.err = json.Marshal(r.event)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.event.SourceAddr
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 SNMP_TRAP 或者 SNMP_INFORM，版本、trap OID、来源地址和 v3 用户名放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyVersion, r.event.Version)
+		metadata.PutValue(KeyTrapOID, r.event.TrapOID)
+		if r.event.TrapName != "" {
+			metadata.PutValue(KeyTrapName, r.event.TrapName)
+		}
+		metadata.PutValue(KeySourceAddr, r.event.SourceAddr)
+		if r.event.UserName != "" {
+			metadata.PutValue(KeyUserName, r.event.UserName)
+		}
+		msgType := SNMP_TRAP_MSG_TYPE
+		if r.event.inform {
+			msgType = SNMP_INFORM_MSG_TYPE
+		}
+		ruleMsg := types.NewMsg(0, msgType, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 不支持响应，inform 在交给规则链之前已经确认
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// SnmpTrapConfig 配置
+type SnmpTrapConfig struct {
+	// Server 监听地址，格式：host:port，端口默认 162
+	Server string `json:"server" label:"Server" desc:"UDP listen address, format: host:port, port defaults to 162" required:"true"`
+	// Community 接受的 v1/v2c 团体名，为空接受所有团体名
+	Community string `json:"community" label:"Community" desc:"Accepted v1/v2c community, empty accepts any community"`
+	// EngineID 本地 SNMPv3 引擎ID，十六进制，作为 inform 的权威引擎，为空随机生成
+	EngineID string `json:"engineId" label:"Engine ID" desc:"Local SNMPv3 engine id in hex, the authoritative engine for informs. Empty generates a random id"`
+	// Users 接受的 SNMPv3 用户，只接受和用户安全级别一致的报文
+	Users []snmpNode.SecurityConfig `json:"users" label:"Users" desc:"SNMPv3 USM users accepted for traps and informs, messages must use the user's security level"`
+	// Mibs 加载的 MIB 文件或者目录，补充内置的 OID 名称
+	Mibs []string `json:"mibs" label:"MIB files" desc:"MIB files or directories to load, extending the built-in OID names"`
+	// ResolveNames 输出 trap OID 和变量 OID 的符号名称
+	ResolveNames bool `json:"resolveNames" label:"Resolve names" desc:"Add symbolic names of the trap OID and variable OIDs, eg. linkDown and ifIndex.2"`
+}
+
+// SnmpTrap SNMP trap 接收端点
+type SnmpTrap struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     SnmpTrapConfig
+	// 路由实例
+	Router endpointApi.Router
+	// engine v3 引擎
+	engine *snmpNode.Engine
+	// mib OID 名称
+	mib *snmpNode.MIB
+	// mu 保护 conn
+	mu   sync.Mutex
+	conn *net.UDPConn
+}
+
+// Type 组件类型
+func (x *SnmpTrap) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *SnmpTrap) New() types.Node {
+	return &SnmpTrap{
+		Config: SnmpTrapConfig{
+			Server: ":162",
+		},
+	}
+}
+
+// Init 初始化
+func (x *SnmpTrap) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	// 初始化失败时不保留之前的引擎
+	x.engine = nil
+	engineID, err := snmpNode.ParseEngineID(x.Config.EngineID)
+	if err != nil {
+		return err
+	}
+	users := make([]snmpNode.User, 0, len(x.Config.Users))
+	for _, config := range x.Config.Users {
+		user, err := config.User()
+		if err != nil {
+			return err
+		}
+		users = append(users, user)
+	}
+	if x.Config.ResolveNames {
+		if x.mib, err = snmpNode.LoadMIB(x.Config.Mibs...); err != nil {
+			return err
+		}
+	}
+	engine, err := snmpNode.NewEngine(engineID, 1, users)
+	if err != nil {
+		return err
+	}
+	x.engine = engine
+	return nil
+}
+
+// Destroy 销毁
+func (x *SnmpTrap) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *SnmpTrap) Desc() string {
+	return "SNMP trap receiver endpoint for v1/v2c/v3 traps and informs, converting varbinds into JSON messages"
+}
+
+// Category returns the component category
+func (x *SnmpTrap) Category() string {
+	return "endpoint"
+}
+
+func (x *SnmpTrap) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "SNMP trap receiver endpoint for v1/v2c/v3 traps and informs, converting varbinds into JSON messages",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+func (x *SnmpTrap) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var err error
+	if x.conn != nil {
+		err = x.conn.Close()
+		x.conn = nil
+	}
+	return err
+}
+
+func (x *SnmpTrap) Id() string {
+	return x.Config.Server
+}
+
+func (x *SnmpTrap) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Router = router
+	return router.GetId(), nil
+}
+
+func (x *SnmpTrap) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	x.Router = nil
+	return nil
+}
+
+func (x *SnmpTrap) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conn != nil {
+		return nil
+	}
+	if x.engine == nil && len(x.Config.Users) > 0 {
+		return errors.New("snmp trap endpoint is not initialized: no SNMPv3 engine")
+	}
+	addr, err := net.ResolveUDPAddr("udp", snmpNode.WithPort(x.Config.Server, snmpNode.DefaultTrapPort))
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	x.conn = conn
+	go x.serve(conn)
+	x.Printf("started SNMP trap receiver on %s", conn.LocalAddr())
+	return nil
+}
+
+// Addr 实际监听的地址，未启动返回 nil
+func (x *SnmpTrap) Addr() net.Addr {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conn == nil {
+		return nil
+	}
+	return x.conn.LocalAddr()
+}
+
+func (x *SnmpTrap) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+func (x *SnmpTrap) serve(conn *net.UDPConn) {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		x.handle(conn, data, addr)
+	}
+}
+
+// handle 解码报文，验证团体名或者 USM 用户，确认 inform 后交给路由处理
+func (x *SnmpTrap) handle(conn *net.UDPConn, data []byte, addr *net.UDPAddr) {
+	p, err := snmpNode.Unmarshal(data)
+	if err != nil {
+		x.Printf("snmp trap from %s: %v", addr, err)
+		return
+	}
+	var reply []byte
+	if p.Version == snmpNode.Version3 {
+		// inform 需要确认，带有 reportable 标志，本引擎是权威引擎；trap 的权威引擎是发送方
+		if x.engine == nil {
+			x.Printf("snmp trap from %s: SNMPv3 is not configured", addr)
+			return
+		}
+		authoritative := p.Flags&snmpNode.ReportableFlag != 0
+		var keys *snmpNode.Keys
+		if keys, err = x.engine.Open(p, data, authoritative); err != nil {
+			var report *snmpNode.ReportError
+			if errors.As(err, &report) && authoritative {
+				if reply, err = x.engine.Report(p, report, keys); err == nil {
+					_, _ = conn.WriteToUDP(reply, addr)
+				}
+			} else {
+				x.Printf("snmp trap from %s: %v", addr, err)
+			}
+			return
+		}
+		if p.PDU.Type == snmpNode.InformRequest {
+			reply, err = x.engine.Response(p, ackPDU(p.PDU), keys)
+		}
+	} else {
+		if x.Config.Community != "" && p.Community != x.Config.Community {
+			x.Printf("snmp trap from %s: unknown community", addr)
+			return
+		}
+		if p.PDU.Type == snmpNode.InformRequest {
+			reply, err = (&snmpNode.Packet{Version: p.Version, Community: p.Community, PDU: ackPDU(p.PDU)}).Marshal(nil)
+		}
+	}
+	if err != nil {
+		x.Printf("snmp inform from %s: %v", addr, err)
+		return
+	}
+	switch p.PDU.Type {
+	case snmpNode.Trap, snmpNode.SNMPv2Trap, snmpNode.InformRequest:
+	default:
+		return
+	}
+	if reply != nil {
+		_, _ = conn.WriteToUDP(reply, addr)
+	}
+	event := newTrapEvent(p, addr)
+	if x.mib != nil {
+		event.resolveNames(x.mib)
+	}
+	x.onTrap(event)
+}
+
+// onTrap 转换成消息交给路由处理
+func (x *SnmpTrap) onTrap(event TrapEvent) {
+	x.RLock()
+	router := x.Router
+	x.RUnlock()
+	if router == nil {
+		return
+	}
+	exchange := &endpointApi.Exchange{
+		In:  &RequestMessage{event: event},
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(context.Background(), router, exchange)
+}
+
+// ackPDU inform 的确认，回复相同的变量绑定
+func ackPDU(pdu snmpNode.PDU) snmpNode.PDU {
+	return snmpNode.PDU{Type: snmpNode.GetResponse, RequestID: pdu.RequestID, Variables: pdu.Variables}
+}
+
+func newTrapEvent(p *snmpNode.Packet, addr *net.UDPAddr) TrapEvent {
+	event := TrapEvent{
+		Version:    p.Version.String(),
+		SourceAddr: addr.String(),
+		Timestamp:  time.Now(),
+		inform:     p.PDU.Type == snmpNode.InformRequest,
+		Variables:  []Variable{},
+	}
+	if p.Version == snmpNode.Version3 {
+		event.UserName = p.Security.UserName
+	} else {
+		event.Community = p.Community
+	}
+	variables := p.PDU.Variables
+	if p.PDU.Type == snmpNode.Trap {
+		generic, specific := p.PDU.GenericTrap, p.PDU.SpecificTrap
+		event.Enterprise = p.PDU.Enterprise
+		event.AgentAddr = p.PDU.AgentAddress
+		event.GenericTrap, event.SpecificTrap = &generic, &specific
+		event.Uptime = p.PDU.Timestamp
+		// RFC 3584 3.1
+		if generic < 6 {
+			event.TrapOID = snmpNode.SnmpTrapsOID + "." + strconv.Itoa(generic+1)
+		} else {
+			event.TrapOID = p.PDU.Enterprise + ".0." + strconv.Itoa(specific)
+		}
+	} else {
+		for len(variables) > 0 {
+			if v := variables[0]; v.OID == snmpNode.SysUpTimeOID {
+				event.Uptime, _ = v.Value.(uint32)
+			} else if v.OID == snmpNode.SnmpTrapOID {
+				event.TrapOID, _ = v.Value.(string)
+			} else {
+				break
+			}
+			variables = variables[1:]
+		}
+	}
+	for _, v := range variables {
+		event.Variables = append(event.Variables, Variable{OID: v.OID, Type: v.Type.String(), Value: v.JSONValue()})
+	}
+	return event
+}
+
+// resolveNames 填充 trap OID 和变量 OID 的符号名称
+func (e *TrapEvent) resolveNames(mib *snmpNode.MIB) {
+	if e.TrapOID != "" {
+		e.TrapName = mib.Name(e.TrapOID)
+	}
+	for i := range e.Variables {
+		e.Variables[i].Name = mib.Name(e.Variables[i].OID)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmptrap
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+
+	snmpNode "github.com/rulego/rulego-components-iot/external/snmp"
+)
+
+type received struct {
+	msgType  string
+	metadata map[string]string
+	event    TrapEvent
+}
+
+func TestSnmpTrapEndpoint(t *testing.T) {
+	ep := (&SnmpTrap{}).New().(*SnmpTrap)
+	assert.Equal(t, Type, ep.Type())
+
+	config := engine.NewConfig()
+	_, err := engine.New("snmp-trap-test01", []byte(`{
+		"ruleChain": {"id": "snmp-trap-test01", "name": "snmp-trap-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("snmp-trap-test01")
+
+	assert.NotNil(t, ep.Init(config, types.Configuration{"engineId": "zz"}))
+	ep = (&SnmpTrap{}).New().(*SnmpTrap)
+	assert.NotNil(t, ep.Init(config, types.Configuration{
+		"server": "127.0.0.1:0",
+		"users":  []map[string]interface{}{{"userName": "trap", "authProtocol": "SHA", "authPassword": "short"}},
+	}))
+	// 没有 v3 引擎时拒绝启动
+	assert.NotNil(t, ep.Start())
+	ep = (&SnmpTrap{}).New().(*SnmpTrap)
+	err = ep.Init(config, types.Configuration{
+		"server":    "127.0.0.1:0",
+		"community": "public",
+		"users": []map[string]interface{}{
+			{"userName": "trap", "authProtocol": "SHA256", "authPassword": "authpass", "privProtocol": "AES", "privPassword": "privpass"},
+			{"userName": "noauth"},
+		},
+	})
+	assert.Nil(t, err)
+
+	events := make(chan received, 10)
+	router := impl.NewRouter().From("").To("chain:snmp-trap-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		var event TrapEvent
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &event))
+		events <- received{msgType: msg.Type, metadata: msg.Metadata.Values(), event: event}
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+	server := ep.Addr().String()
+
+	next := func() received {
+		select {
+		case r := <-events:
+			return r
+		case <-time.After(3 * time.Second):
+			t.Fatal("no trap received")
+		}
+		return received{}
+	}
+	variables := []snmpNode.Variable{
+		{OID: "1.3.6.1.2.1.2.2.1.1.2", Type: snmpNode.Integer, Value: int64(2)},
+		{OID: "1.3.6.1.2.1.2.2.1.2.2", Type: snmpNode.OctetString, Value: []byte("eth1")},
+	}
+
+	// 错误的团体名被丢弃
+	client, err := snmpNode.NewClient(snmpNode.ClientConfig{Server: server, Version: snmpNode.Version2c, Community: "private", Timeout: 200 * time.Millisecond})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Trap("1.3.6.1.6.3.1.1.5.3", variables))
+	assert.Equal(t, snmpNode.ErrTimeout, client.Inform("1.3.6.1.6.3.1.1.5.3", variables))
+	_ = client.Close()
+
+	// v2c trap 和 inform
+	client, err = snmpNode.NewClient(snmpNode.ClientConfig{Server: server, Version: snmpNode.Version2c, Community: "public", Timeout: time.Second})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Trap("1.3.6.1.6.3.1.1.5.3", variables))
+	r := next()
+	assert.Equal(t, SNMP_TRAP_MSG_TYPE, r.msgType)
+	assert.Equal(t, "2c", r.metadata[KeyVersion])
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", r.metadata[KeyTrapOID])
+	assert.Equal(t, r.event.SourceAddr, r.metadata[KeySourceAddr])
+	assert.Equal(t, "public", r.event.Community)
+	assert.Equal(t, 2, len(r.event.Variables))
+	assert.Equal(t, Variable{OID: "1.3.6.1.2.1.2.2.1.2.2", Type: "OctetString", Value: "eth1"}, r.event.Variables[1])
+
+	assert.Nil(t, client.Inform("1.3.6.1.4.1.8072.2.3.0.1", nil))
+	r = next()
+	assert.Equal(t, SNMP_INFORM_MSG_TYPE, r.msgType)
+	assert.Equal(t, "1.3.6.1.4.1.8072.2.3.0.1", r.event.TrapOID)
+	assert.Equal(t, 0, len(r.event.Variables))
+	_ = client.Close()
+
+	// v3 authPriv trap 和 inform
+	client, err = snmpNode.NewClient(snmpNode.ClientConfig{Server: server, Version: snmpNode.Version3, Timeout: time.Second,
+		User: snmpNode.User{UserName: "trap", AuthProtocol: snmpNode.SHA256, AuthPassword: "authpass", PrivProtocol: snmpNode.AES, PrivPassword: "privpass"}})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Trap("1.3.6.1.6.3.1.1.5.4", variables))
+	r = next()
+	assert.Equal(t, SNMP_TRAP_MSG_TYPE, r.msgType)
+	assert.Equal(t, "3", r.metadata[KeyVersion])
+	assert.Equal(t, "trap", r.metadata[KeyUserName])
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.4", r.event.TrapOID)
+	assert.Equal(t, float64(2), r.event.Variables[0].Value)
+	assert.Nil(t, client.Inform("1.3.6.1.6.3.1.1.5.4", variables))
+	r = next()
+	assert.Equal(t, SNMP_INFORM_MSG_TYPE, r.msgType)
+	assert.Equal(t, "trap", r.event.UserName)
+	_ = client.Close()
+
+	// 安全级别不一致
+	client, _ = snmpNode.NewClient(snmpNode.ClientConfig{Server: server, Version: snmpNode.Version3, Timeout: 200 * time.Millisecond,
+		User: snmpNode.User{UserName: "trap", AuthProtocol: snmpNode.SHA256, AuthPassword: "authpass"}})
+	assert.NotNil(t, client.Inform("1.3.6.1.6.3.1.1.5.4", variables))
+	_ = client.Close()
+
+	// v1 trap 转换成 v2 trap OID
+	conn, err := net.Dial("udp", server)
+	assert.Nil(t, err)
+	defer conn.Close()
+	for _, pdu := range []snmpNode.PDU{
+		{Type: snmpNode.Trap, Enterprise: "1.3.6.1.4.1.318", AgentAddress: "192.168.1.10", GenericTrap: 2, Timestamp: 500, Variables: variables},
+		{Type: snmpNode.Trap, Enterprise: "1.3.6.1.4.1.318", AgentAddress: "192.168.1.10", GenericTrap: 6, SpecificTrap: 5},
+	} {
+		data, err := (&snmpNode.Packet{Version: snmpNode.Version1, Community: "public", PDU: pdu}).Marshal(nil)
+		assert.Nil(t, err)
+		_, err = conn.Write(data)
+		assert.Nil(t, err)
+	}
+	r = next()
+	assert.Equal(t, "1", r.metadata[KeyVersion])
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", r.event.TrapOID)
+	assert.Equal(t, "1.3.6.1.4.1.318", r.event.Enterprise)
+	assert.Equal(t, "192.168.1.10", r.event.AgentAddr)
+	assert.Equal(t, uint32(500), r.event.Uptime)
+	assert.Equal(t, 2, *r.event.GenericTrap)
+	assert.Equal(t, 2, len(r.event.Variables))
+	r = next()
+	assert.Equal(t, "1.3.6.1.4.1.318.0.5", r.event.TrapOID)
+	assert.Equal(t, 5, *r.event.SpecificTrap)
+
+	select {
+	case r = <-events:
+		t.Fatalf("unexpected trap: %+v", r.event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestResolveNames(t *testing.T) {
+	ep := (&SnmpTrap{}).New().(*SnmpTrap)
+	config := engine.NewConfig()
+	assert.NotNil(t, ep.Init(config, types.Configuration{"resolveNames": true, "mibs": []string{"/nonexistent/mibs"}}))
+	ep = (&SnmpTrap{}).New().(*SnmpTrap)
+	assert.Nil(t, ep.Init(config, types.Configuration{"resolveNames": true}))
+
+	event := TrapEvent{
+		TrapOID:   "1.3.6.1.6.3.1.1.5.3",
+		Variables: []Variable{{OID: "1.3.6.1.2.1.2.2.1.1.2"}, {OID: "1.3.6.1.4.1.318.1"}},
+	}
+	event.resolveNames(ep.mib)
+	assert.Equal(t, "linkDown", event.TrapName)
+	assert.Equal(t, "ifIndex.2", event.Variables[0].Name)
+	assert.Equal(t, "enterprises.318.1", event.Variables[1].Name)
+	msg := (&RequestMessage{event: event}).GetMsg()
+	assert.Equal(t, "linkDown", msg.Metadata.GetValue(KeyTrapName))
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", msg.Metadata.GetValue(KeyTrapOID))
+}
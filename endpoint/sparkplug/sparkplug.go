@@ -0,0 +1,612 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	sparkplugNode "github.com/rulego/rulego-components-iot/external/sparkplug"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/mqtt"
+	"github.com/rulego/rulego/utils/runtime"
+)
+
+const Type = types.EndpointTypePrefix + "sparkplug"
+
+// DefaultTopic 默认订阅的主题
+const DefaultTopic = sparkplugNode.Namespace + "/#"
+
+// rebirthInterval 同一个边缘节点两次请求重新发送出生证明的最小间隔
+const rebirthInterval = 10 * time.Second
+
+// 元数据key
+const (
+	KeyTopic       = "topic"
+	KeyGroupId     = "groupId"
+	KeyMessageType = "messageType"
+	KeyEdgeNodeId  = "edgeNodeId"
+	KeyDeviceId    = "deviceId"
+	KeyHostId      = "hostId"
+	// KeyStale 过期 NDEATH（bdSeq 与当前 NBIRTH 不一致）的元数据key，值为 true
+	KeyStale = "stale"
+)
+
+// 在线状态事件的消息类型
+const (
+	MsgTypeOnline  = "ONLINE"
+	MsgTypeOffline = "OFFLINE"
+)
+
+// Endpoint 别名
+type Endpoint = Sparkplug
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	// from 主题，在线状态事件没有 MQTT 消息，使用引起状态变化的消息主题
+	from    string
+	body    []byte
+	request paho.Message
+	topic   sparkplugNode.Topic
+	// payload 解码后的负荷，STATE 消息为 nil
+	payload *sparkplugNode.Payload
+	msg     *types.RuleMsg
+	err     error
+}
+
+// Body 解码后的负荷，STATE 消息为原始负荷
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		if r.payload == nil {
+			r.body = r.request.Payload()
+		} else {
+			r.body, r.err = json.Marshal(r.payload)
+		}
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	r.headers.Set(KeyTopic, r.From())
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	if r.request == nil {
+		return r.from
+	}
+	return r.request.Topic()
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 Sparkplug 消息类型，eg. DDATA，主题的各部分放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyTopic, r.request.Topic())
+		metadata.PutValue(KeyMessageType, r.topic.MessageType)
+		if r.topic.MessageType == sparkplugNode.STATE {
+			metadata.PutValue(KeyHostId, r.topic.HostId)
+		} else {
+			metadata.PutValue(KeyGroupId, r.topic.GroupId)
+			metadata.PutValue(KeyEdgeNodeId, r.topic.EdgeNodeId)
+			if r.topic.DeviceId != "" {
+				metadata.PutValue(KeyDeviceId, r.topic.DeviceId)
+			}
+		}
+		ruleMsg := types.NewMsg(0, r.topic.MessageType, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 不支持响应，需要下发命令使用 x/sparkplugPublish 或者 x/mqttClient
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// SparkplugConfig 配置
+type SparkplugConfig struct {
+	// Server MQTT 服务器地址，格式：host:port
+	Server string `json:"server" label:"Server" desc:"MQTT broker address, format: host:port" required:"true" ref:"primary"`
+	// Username 用户名
+	Username string `json:"username" label:"Username" desc:"MQTT authentication username" ref:"shared"`
+	// Password 密码
+	Password string `json:"password" label:"Password" desc:"MQTT authentication password" ref:"shared"`
+	// QOS 订阅的 QoS
+	QOS uint8 `json:"qos" label:"QoS" desc:"QoS level: 0(at most once), 1(at least once), 2(exactly once)"`
+	// ClientID 客户端ID，为空随机生成
+	ClientID string `json:"clientId" label:"Client ID" desc:"MQTT client unique identifier, default is random"`
+	// CleanSession 是否清除会话
+	CleanSession bool `json:"cleanSession" label:"Clean Session" desc:"Whether to clear previous session state"`
+	// CAFile CA 证书文件
+	CAFile string `json:"caFile" label:"CA File" desc:"CA certificate file path for TLS" ref:"shared"`
+	// CertFile 客户端证书文件
+	CertFile string `json:"certFile" label:"Cert File" desc:"TLS client certificate file path" ref:"shared"`
+	// CertKeyFile 客户端私钥文件
+	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"TLS client private key file path" ref:"shared"`
+	// RequestRebirth 收到无法解析别名的数据时，是否向边缘节点发送 NCMD 请求重新发送出生证明
+	RequestRebirth bool `json:"requestRebirth" label:"Request rebirth" desc:"Send an NCMD rebirth request when data arrives with unknown aliases"`
+	// HostId 主要主机应用标识，不为空时发布保留的 STATE 出生证明，并以 STATE 死亡证明作为遗嘱
+	HostId string `json:"hostId" label:"Host ID" desc:"Primary host application id, publishes retained STATE birth/death on spBv1.0/STATE/<hostId>"`
+	// AvailabilityEvents 边缘节点或者设备上线、离线时，是否额外产生 ONLINE/OFFLINE 消息
+	AvailabilityEvents bool `json:"availabilityEvents" label:"Availability events" desc:"Emit ONLINE/OFFLINE messages when edge nodes or devices go online (NBIRTH/DBIRTH) or offline (NDEATH/DDEATH)"`
+}
+
+// Sparkplug MQTT Sparkplug B 接入端点，订阅 spBv1.0/# 或者路由 from 指定的主题，
+// 解码 protobuf 负荷（NBIRTH/DBIRTH/NDATA/DDATA/NDEATH/DDEATH/NCMD/DCMD），
+// 按边缘节点维护出生证明中的别名->指标名称，补全数据消息中只有别名的指标名称和数据类型。
+// 消息类型为 Sparkplug 消息类型，msg.Data 为解码后的负荷：
+//
+//	{"timestamp": 1700000000000, "seq": 3, "metrics": [{"name": "temperature", "alias": 1, "dataType": "Float", "value": 21.5}]}
+//
+// 元数据包含 topic、groupId、messageType、edgeNodeId 和 deviceId。STATE 消息的 msg.Data 为原始负荷，元数据包含 hostId
+//
+// 按 NBIRTH/DBIRTH/NDEATH/DDEATH 跟踪边缘节点和设备的在线状态，NDEATH 的 bdSeq 与当前 NBIRTH 不一致时为上一个会话的遗嘱，
+// 不清除别名，元数据 stale 为 true。开启 availabilityEvents 时状态变化额外产生 ONLINE/OFFLINE 消息，节点离线时它的设备也离线：
+//
+//	{"groupId": "plant", "edgeNodeId": "edge1", "deviceId": "pump", "online": false, "reason": "NDEATH", "timestamp": 1700000000000}
+//
+// 配置 hostId 时作为主要主机应用，通过独立的 MQTT 连接发布保留的 STATE 出生证明 {"online": true, "timestamp": ...}，
+// 并以 {"online": false} 作为遗嘱，边缘节点据此判断主机应用是否在线
+type Sparkplug struct {
+	impl.BaseEndpoint
+	base.SharedNode[*mqtt.Client]
+	base.GracefulShutdown
+	RuleConfig types.Config
+	Config     SparkplugConfig
+	// aliases 边缘节点的别名表
+	aliases *sparkplugNode.AliasTable
+	// status 边缘节点和设备的在线状态
+	status *sparkplugNode.StatusTracker
+	// host 主要主机应用，配置 hostId 时启动
+	host *sparkplugNode.HostApplication
+	// rebirthLock 保护 rebirths
+	rebirthLock sync.Mutex
+	// rebirths 边缘节点最后一次请求重新发送出生证明的时间
+	rebirths map[string]time.Time
+	started  bool
+}
+
+// Type 组件类型
+func (x *Sparkplug) Type() string {
+	return Type
+}
+
+// Category returns the component category
+func (x *Sparkplug) Category() string {
+	return "endpoint"
+}
+
+// Def returns the component definition including description and router form metadata.
+func (x *Sparkplug) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "MQTT Sparkplug B endpoint decoding birth, data and death payloads with alias resolution per edge node",
+		RouterForm: &types.RouterForm{
+			From: &types.RouterFormField{
+				Path: types.ComponentFormField{
+					Name:  "path",
+					Type:  "string",
+					Label: "Topic",
+					Desc:  "Sparkplug topic filter to subscribe, default spBv1.0/#",
+				},
+			},
+		},
+	}
+}
+
+func (x *Sparkplug) New() types.Node {
+	return &Sparkplug{
+		Config: SparkplugConfig{
+			Server: "127.0.0.1:1883",
+		},
+	}
+}
+
+// Init 初始化
+func (x *Sparkplug) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if x.Config.HostId != "" {
+		if err = x.hostConfig().Validate(); err != nil {
+			return err
+		}
+	}
+	x.aliases = sparkplugNode.NewAliasTable()
+	x.status = sparkplugNode.NewStatusTracker()
+	x.rebirths = map[string]time.Time{}
+	x.GracefulShutdown.InitGracefulShutdown(x.RuleConfig.Logger, 10*time.Second)
+	return x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, false, func() (*mqtt.Client, error) {
+		return x.initClient()
+	}, func(client *mqtt.Client) error {
+		if client != nil {
+			return client.Close()
+		}
+		return nil
+	})
+}
+
+// Destroy 销毁
+func (x *Sparkplug) Destroy() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+// GracefulStop 优雅停机
+func (x *Sparkplug) GracefulStop() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+func (x *Sparkplug) Close() error {
+	if x.host != nil {
+		_ = x.host.Close()
+		x.host = nil
+	}
+	return x.SharedNode.Close()
+}
+
+func (x *Sparkplug) Id() string {
+	return x.Config.Server
+}
+
+func (x *Sparkplug) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	x.CheckAndSetRouterId(router)
+	x.saveRouter(router)
+	if x.started {
+		client, err := x.SharedNode.GetSafely()
+		if err != nil {
+			return "", err
+		}
+		client.RegisterHandler(x.newHandler(router))
+	}
+	return router.GetId(), nil
+}
+
+func (x *Sparkplug) RemoveRouter(routerId string, params ...interface{}) error {
+	router := x.deleteRouter(routerId)
+	if router == nil {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	client, _ := x.SharedNode.GetSafely()
+	if client != nil {
+		return client.UnregisterHandler(topicOf(router))
+	}
+	return nil
+}
+
+func (x *Sparkplug) Start() error {
+	if x.started {
+		return nil
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		return err
+	}
+	// 先发布 STATE 出生证明，再订阅边缘节点的消息
+	if x.Config.HostId != "" && x.host == nil {
+		if x.host, err = sparkplugNode.NewHostApplication(x.hostConfig()); err != nil {
+			return err
+		}
+	}
+	x.RLock()
+	routers := make([]endpointApi.Router, 0, len(x.RouterStorage))
+	for _, v := range x.RouterStorage {
+		routers = append(routers, v)
+	}
+	x.RUnlock()
+	for _, router := range routers {
+		client.RegisterHandler(x.newHandler(router))
+	}
+	x.started = true
+	return nil
+}
+
+// 存储路由
+func (x *Sparkplug) saveRouter(routers ...endpointApi.Router) {
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpointApi.Router)
+	}
+	for _, item := range routers {
+		x.RouterStorage[item.GetId()] = item
+	}
+}
+
+// 从存储器中删除路由
+func (x *Sparkplug) deleteRouter(id string) endpointApi.Router {
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage != nil {
+		if router, ok := x.RouterStorage[id]; ok {
+			delete(x.RouterStorage, id)
+			return router
+		}
+	}
+	return nil
+}
+
+// topicOf 路由订阅的主题，为空使用 spBv1.0/#
+func topicOf(router endpointApi.Router) string {
+	if from := router.GetFrom(); from != nil {
+		if topic := from.ToString(); topic != "" && topic != "*" {
+			return topic
+		}
+	}
+	return DefaultTopic
+}
+
+func (x *Sparkplug) newHandler(router endpointApi.Router) mqtt.Handler {
+	return mqtt.Handler{
+		Topic:  topicOf(router),
+		Qos:    x.Config.QOS,
+		Handle: x.handler(router),
+	}
+}
+
+func (x *Sparkplug) handler(router endpointApi.Router) func(c paho.Client, data paho.Message) {
+	return func(c paho.Client, data paho.Message) {
+		defer func() {
+			//捕捉异常
+			if e := recover(); e != nil {
+				x.Printf("sparkplug endpoint handler err :\n%v", runtime.Stack())
+			}
+		}()
+		if err := x.GracefulShutdown.CheckShutdownSignal(); err != nil {
+			return
+		}
+		x.GracefulShutdown.IncrementActiveOperations()
+		defer x.GracefulShutdown.DecrementActiveOperations()
+
+		request, changes, err := x.decode(data)
+		if err != nil {
+			x.Printf("sparkplug endpoint decode %s err: %v", data.Topic(), err)
+			return
+		}
+		exchange := &endpointApi.Exchange{
+			In:  request,
+			Out: &ResponseMessage{},
+		}
+		x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+		if !x.Config.AvailabilityEvents {
+			return
+		}
+		for _, change := range changes {
+			exchange = &endpointApi.Exchange{
+				In:  newAvailabilityRequest(data.Topic(), change),
+				Out: &ResponseMessage{},
+			}
+			x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+		}
+	}
+}
+
+// newAvailabilityRequest 在线状态事件的消息，消息类型为 ONLINE 或者 OFFLINE，元数据 messageType 为引起状态变化的消息类型
+func newAvailabilityRequest(topic string, change sparkplugNode.Availability) *RequestMessage {
+	msgType := MsgTypeOffline
+	if change.Online {
+		msgType = MsgTypeOnline
+	}
+	metadata := types.NewMetadata()
+	metadata.PutValue(KeyTopic, topic)
+	metadata.PutValue(KeyMessageType, change.Reason)
+	metadata.PutValue(KeyGroupId, change.GroupId)
+	metadata.PutValue(KeyEdgeNodeId, change.EdgeNodeId)
+	if change.DeviceId != "" {
+		metadata.PutValue(KeyDeviceId, change.DeviceId)
+	}
+	body, err := json.Marshal(change)
+	ruleMsg := types.NewMsg(0, msgType, types.JSON, metadata, string(body))
+	return &RequestMessage{from: topic, body: body, msg: &ruleMsg, err: err}
+}
+
+// decode 解析主题和负荷，更新在线状态并补全别名，返回在线状态变化
+func (x *Sparkplug) decode(data paho.Message) (*RequestMessage, []sparkplugNode.Availability, error) {
+	topic, err := sparkplugNode.ParseTopic(data.Topic())
+	if err != nil {
+		return nil, nil, err
+	}
+	request := &RequestMessage{request: data, topic: topic}
+	// STATE 消息为 JSON 或者字符串，不需要解码
+	if topic.MessageType == sparkplugNode.STATE {
+		return request, nil, nil
+	}
+	if request.payload, err = sparkplugNode.DecodePayload(data.Payload()); err != nil {
+		return nil, nil, err
+	}
+	changes, stale := x.status.Apply(topic, request.payload)
+	if stale {
+		// 上一个会话的遗嘱，不清除当前会话的别名
+		request.GetMsg().Metadata.PutValue(KeyStale, "true")
+		return request, nil, nil
+	}
+	if unknown := x.aliases.Apply(topic, request.payload); unknown && x.Config.RequestRebirth {
+		x.requestRebirth(topic)
+	}
+	return request, changes, nil
+}
+
+// requestRebirth 向边缘节点发送 NCMD 请求重新发送出生证明，同一个节点有最小间隔
+func (x *Sparkplug) requestRebirth(topic sparkplugNode.Topic) {
+	key := topic.NodeKey()
+	x.rebirthLock.Lock()
+	if last, ok := x.rebirths[key]; ok && time.Since(last) < rebirthInterval {
+		x.rebirthLock.Unlock()
+		return
+	}
+	x.rebirths[key] = time.Now()
+	x.rebirthLock.Unlock()
+
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		x.Printf("sparkplug endpoint rebirth %s err: %v", key, err)
+		return
+	}
+	payload, err := sparkplugNode.EncodePayload(sparkplugNode.RebirthPayload())
+	if err != nil {
+		return
+	}
+	cmd := sparkplugNode.Topic{GroupId: topic.GroupId, MessageType: sparkplugNode.NCMD, EdgeNodeId: topic.EdgeNodeId}
+	if err = client.Publish(cmd.String(), 0, payload); err != nil {
+		x.Printf("sparkplug endpoint rebirth %s err: %v", key, err)
+	}
+}
+
+func (x *Sparkplug) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// hostConfig 主要主机应用配置，客户端ID为端点的客户端ID加 -state 后缀
+func (x *Sparkplug) hostConfig() sparkplugNode.HostConfig {
+	clientId := x.Config.ClientID
+	if clientId != "" {
+		clientId += "-state"
+	}
+	return sparkplugNode.HostConfig{
+		Server:      x.Config.Server,
+		Username:    x.Config.Username,
+		Password:    x.Config.Password,
+		ClientID:    clientId,
+		CAFile:      x.Config.CAFile,
+		CertFile:    x.Config.CertFile,
+		CertKeyFile: x.Config.CertKeyFile,
+		HostId:      x.Config.HostId,
+	}
+}
+
+// initClient 初始化客户端
+func (x *Sparkplug) initClient() (*mqtt.Client, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 4*time.Second)
+	defer cancel()
+	return mqtt.NewClient(ctx, mqtt.Config{
+		Server:       x.Config.Server,
+		Username:     x.Config.Username,
+		Password:     x.Config.Password,
+		QOS:          x.Config.QOS,
+		ClientID:     x.Config.ClientID,
+		CleanSession: x.Config.CleanSession,
+		CAFile:       x.Config.CAFile,
+		CertFile:     x.Config.CertFile,
+		CertKeyFile:  x.Config.CertKeyFile,
+	})
+}
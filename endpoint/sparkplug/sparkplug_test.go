@@ -0,0 +1,250 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"encoding/json"
+	"testing"
+
+	sparkplugNode "github.com/rulego/rulego-components-iot/external/sparkplug"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+)
+
+// testMessage 测试用的 MQTT 消息
+type testMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *testMessage) Duplicate() bool   { return false }
+func (m *testMessage) Qos() byte         { return 0 }
+func (m *testMessage) Retained() bool    { return false }
+func (m *testMessage) Topic() string     { return m.topic }
+func (m *testMessage) MessageID() uint16 { return 0 }
+func (m *testMessage) Payload() []byte   { return m.payload }
+func (m *testMessage) Ack()              {}
+
+func newTestMessage(t *testing.T, topic string, p *sparkplugNode.Payload) *testMessage {
+	data, err := sparkplugNode.EncodePayload(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testMessage{topic: topic, payload: data}
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestSparkplugEndpoint(t *testing.T) {
+	t.Run("New", func(t *testing.T) {
+		ep := (&Sparkplug{}).New().(*Sparkplug)
+		if ep.Config.Server != "127.0.0.1:1883" {
+			t.Errorf("期望默认地址为 '127.0.0.1:1883', 实际为 '%s'", ep.Config.Server)
+		}
+		if ep.Type() != Type {
+			t.Errorf("期望类型为 '%s', 实际为 '%s'", Type, ep.Type())
+		}
+	})
+
+	t.Run("Topic", func(t *testing.T) {
+		if topic := topicOf(impl.NewRouter().From("").End()); topic != DefaultTopic {
+			t.Errorf("期望默认主题为 '%s', 实际为 '%s'", DefaultTopic, topic)
+		}
+		if topic := topicOf(impl.NewRouter().From("spBv1.0/plant/#").End()); topic != "spBv1.0/plant/#" {
+			t.Errorf("期望主题为 'spBv1.0/plant/#', 实际为 '%s'", topic)
+		}
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		config := engine.NewConfig()
+		_, err := engine.New("sparkplug-test01", []byte(`{
+			"ruleChain": {"id": "sparkplug-test01", "name": "sparkplug-test01"},
+			"metadata": {"nodes": []}
+		}`), engine.WithConfig(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer engine.Del("sparkplug-test01")
+
+		ep := (&Sparkplug{}).New().(*Sparkplug)
+		if err = ep.Init(config, types.Configuration{"server": "127.0.0.1:1883"}); err != nil {
+			t.Fatal(err)
+		}
+		defer ep.Destroy()
+
+		var messages []types.RuleMsg
+		router := impl.NewRouter().From("").To("chain:sparkplug-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			messages = append(messages, *exchange.In.GetMsg())
+			return false
+		}).End()
+		if _, err = ep.AddRouter(router); err != nil {
+			t.Fatal(err)
+		}
+		handle := ep.handler(router)
+
+		handle(nil, newTestMessage(t, "spBv1.0/plant/NBIRTH/edge1", &sparkplugNode.Payload{
+			Timestamp: 1700000000000,
+			Seq:       uint64Ptr(0),
+			Metrics: []sparkplugNode.Metric{
+				{Name: "temperature", Alias: uint64Ptr(1), DataType: sparkplugNode.Int16, Value: 20},
+			},
+		}))
+		handle(nil, newTestMessage(t, "spBv1.0/plant/DBIRTH/edge1/pump", &sparkplugNode.Payload{
+			Seq: uint64Ptr(1),
+			Metrics: []sparkplugNode.Metric{
+				{Name: "speed", Alias: uint64Ptr(2), DataType: sparkplugNode.Double, Value: 0},
+			},
+		}))
+		// 数据消息只有别名，没有名称
+		data, _ := sparkplugNode.EncodePayload(&sparkplugNode.Payload{
+			Seq: uint64Ptr(2),
+			Metrics: []sparkplugNode.Metric{
+				{Alias: uint64Ptr(1), DataType: sparkplugNode.Int16, Value: -3},
+			},
+		})
+		handle(nil, &testMessage{topic: "spBv1.0/plant/NDATA/edge1", payload: data})
+		handle(nil, newTestMessage(t, "spBv1.0/plant/DDATA/edge1/pump", &sparkplugNode.Payload{
+			Seq:     uint64Ptr(3),
+			Metrics: []sparkplugNode.Metric{{Alias: uint64Ptr(2), DataType: sparkplugNode.Double, Value: 12.5}},
+		}))
+		handle(nil, &testMessage{topic: "spBv1.0/STATE/scada", payload: []byte(`{"online":true,"timestamp":1}`)})
+		// 无效的主题和负荷不产生消息
+		handle(nil, &testMessage{topic: "spBv1.0/plant/XDATA/edge1", payload: data})
+		handle(nil, &testMessage{topic: "spBv1.0/plant/NDATA/edge1", payload: []byte{0x12, 0x10}})
+
+		if len(messages) != 5 {
+			t.Fatalf("期望 5 条消息, 实际为 %d", len(messages))
+		}
+		if messages[0].Type != sparkplugNode.NBIRTH || messages[0].Metadata.GetValue(KeyEdgeNodeId) != "edge1" {
+			t.Errorf("NBIRTH 消息错误: %v", messages[0])
+		}
+		if messages[1].Metadata.GetValue(KeyDeviceId) != "pump" {
+			t.Errorf("DBIRTH 元数据错误: %v", messages[1].Metadata.Values())
+		}
+
+		var payload sparkplugNode.Payload
+		if err = json.Unmarshal([]byte(messages[2].GetData()), &payload); err != nil {
+			t.Fatal(err)
+		}
+		if messages[2].Type != sparkplugNode.NDATA || payload.Metrics[0].Name != "temperature" || payload.Metrics[0].Value != float64(-3) {
+			t.Errorf("别名应该补全为指标名称: %s", messages[2].GetData())
+		}
+		if err = json.Unmarshal([]byte(messages[3].GetData()), &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Metrics[0].Name != "speed" || payload.Metrics[0].Value != 12.5 || *payload.Seq != 3 {
+			t.Errorf("设备别名应该补全为指标名称: %s", messages[3].GetData())
+		}
+		if messages[4].Type != sparkplugNode.STATE || messages[4].Metadata.GetValue(KeyHostId) != "scada" ||
+			messages[4].GetData() != `{"online":true,"timestamp":1}` {
+			t.Errorf("STATE 消息错误: %v", messages[4])
+		}
+	})
+
+	t.Run("Availability", func(t *testing.T) {
+		config := engine.NewConfig()
+		_, err := engine.New("sparkplug-test02", []byte(`{
+			"ruleChain": {"id": "sparkplug-test02", "name": "sparkplug-test02"},
+			"metadata": {"nodes": []}
+		}`), engine.WithConfig(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer engine.Del("sparkplug-test02")
+
+		ep := (&Sparkplug{}).New().(*Sparkplug)
+		if err = ep.Init(config, types.Configuration{"server": "127.0.0.1:1883", "availabilityEvents": true}); err != nil {
+			t.Fatal(err)
+		}
+		defer ep.Destroy()
+
+		var messages []types.RuleMsg
+		router := impl.NewRouter().From("").To("chain:sparkplug-test02").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			messages = append(messages, *exchange.In.GetMsg())
+			return false
+		}).End()
+		if _, err = ep.AddRouter(router); err != nil {
+			t.Fatal(err)
+		}
+		handle := ep.handler(router)
+		bdSeq := func(v uint64) []sparkplugNode.Metric {
+			return []sparkplugNode.Metric{{Name: sparkplugNode.BdSeqMetric, DataType: sparkplugNode.UInt64, Value: v}}
+		}
+
+		handle(nil, newTestMessage(t, "spBv1.0/plant/NBIRTH/edge1", &sparkplugNode.Payload{
+			Seq: uint64Ptr(0),
+			Metrics: append(bdSeq(2),
+				sparkplugNode.Metric{Name: "temperature", Alias: uint64Ptr(1), DataType: sparkplugNode.Int16, Value: 20}),
+		}))
+		handle(nil, newTestMessage(t, "spBv1.0/plant/DBIRTH/edge1/pump", &sparkplugNode.Payload{Seq: uint64Ptr(1)}))
+		// 上一个会话的遗嘱不清除别名
+		handle(nil, newTestMessage(t, "spBv1.0/plant/NDEATH/edge1", &sparkplugNode.Payload{Metrics: bdSeq(1)}))
+		handle(nil, newTestMessage(t, "spBv1.0/plant/NDATA/edge1", &sparkplugNode.Payload{
+			Seq:     uint64Ptr(2),
+			Metrics: []sparkplugNode.Metric{{Alias: uint64Ptr(1), DataType: sparkplugNode.Int16, Value: 5}},
+		}))
+		handle(nil, newTestMessage(t, "spBv1.0/plant/NDEATH/edge1", &sparkplugNode.Payload{Metrics: bdSeq(2)}))
+
+		var msgTypes []string
+		for _, msg := range messages {
+			msgTypes = append(msgTypes, msg.Type)
+		}
+		expected := []string{sparkplugNode.NBIRTH, MsgTypeOnline, sparkplugNode.DBIRTH, MsgTypeOnline,
+			sparkplugNode.NDEATH, sparkplugNode.NDATA, sparkplugNode.NDEATH, MsgTypeOffline, MsgTypeOffline}
+		if len(msgTypes) != len(expected) {
+			t.Fatalf("期望消息类型 %v, 实际为 %v", expected, msgTypes)
+		}
+		for i := range expected {
+			if msgTypes[i] != expected[i] {
+				t.Fatalf("期望消息类型 %v, 实际为 %v", expected, msgTypes)
+			}
+		}
+		if messages[4].Metadata.GetValue(KeyStale) != "true" || messages[6].Metadata.GetValue(KeyStale) != "" {
+			t.Errorf("过期 NDEATH 的元数据错误: %v", messages[4].Metadata.Values())
+		}
+		var payload sparkplugNode.Payload
+		if err := json.Unmarshal([]byte(messages[5].GetData()), &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Metrics[0].Name != "temperature" {
+			t.Errorf("过期 NDEATH 后别名应该仍然有效: %s", messages[5].GetData())
+		}
+		var change sparkplugNode.Availability
+		if err := json.Unmarshal([]byte(messages[7].GetData()), &change); err != nil {
+			t.Fatal(err)
+		}
+		if change.DeviceId != "pump" || change.Online || change.Reason != sparkplugNode.NDEATH ||
+			messages[7].Metadata.GetValue(KeyDeviceId) != "pump" || messages[7].Metadata.GetValue(KeyMessageType) != sparkplugNode.NDEATH {
+			t.Errorf("设备离线事件错误: %v", messages[7])
+		}
+		var nodeChange sparkplugNode.Availability
+		if err := json.Unmarshal([]byte(messages[8].GetData()), &nodeChange); err != nil {
+			t.Fatal(err)
+		}
+		if nodeChange.DeviceId != "" || nodeChange.Online || nodeChange.BdSeq == nil || *nodeChange.BdSeq != 2 {
+			t.Errorf("节点离线事件错误: %s", messages[8].GetData())
+		}
+
+		if err = (&Sparkplug{}).New().Init(config, types.Configuration{"hostId": "scada", "certFile": "client.pem"}); err == nil {
+			t.Error("期望证书和私钥不成对时初始化失败")
+		}
+	})
+}
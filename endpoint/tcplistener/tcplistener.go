@@ -0,0 +1,580 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tcplistener 提供 TCP 原始报文监听端点
+// 端点接受设备的 TCP 连接，按配置的分帧方式把每个连接的字节流拆分为帧，帧按前缀路由到规则链，
+// 元数据带上设备的远程地址，规则链设置的响应直接写回同一个连接，适用于 DTU、私有协议网关等主动连接上来的设备
+package tcplistener
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/framing"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "tcpListener"
+
+// 元数据key
+const (
+	KeyRemoteAddr = "remoteAddr"
+	KeyLocalAddr  = "localAddr"
+	KeyLength     = "length"
+)
+
+// MsgTypeFrame 帧的消息类型
+const MsgTypeFrame = "TCP"
+
+// 帧的消息格式
+const (
+	DataTypeText   = "text"
+	DataTypeBinary = "binary"
+	DataTypeHex    = "hex"
+	DataTypeBase64 = "base64"
+)
+
+// readTick 配置了帧间静默时间时的读取超时，用于检查未完成的帧
+const readTick = 50 * time.Millisecond
+
+// writeTimeout 写回响应的超时
+const writeTimeout = 5 * time.Second
+
+// Endpoint 别名
+type Endpoint = TcpListener
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers  textproto.MIMEHeader
+	body     []byte
+	conn     *conn
+	dataType string
+	msg      *types.RuleMsg
+	err      error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.conn.remoteAddr
+}
+
+// GetParam 获取远程地址、本地地址和帧长度
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyRemoteAddr:
+		return r.conn.remoteAddr
+	case KeyLocalAddr:
+		return r.conn.localAddr
+	case KeyLength:
+		return strconv.Itoa(len(r.body))
+	default:
+		return ""
+	}
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 TCP，帧按 dataType 转换为文本、二进制、十六进制或者 base64，地址和帧长度放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyRemoteAddr, r.conn.remoteAddr)
+		metadata.PutValue(KeyLocalAddr, r.conn.localAddr)
+		metadata.PutValue(KeyLength, strconv.Itoa(len(r.body)))
+		var ruleMsg types.RuleMsg
+		switch r.dataType {
+		case DataTypeBinary:
+			ruleMsg = types.NewMsgFromBytes(0, MsgTypeFrame, types.BINARY, metadata, r.body)
+		case DataTypeHex:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, hex.EncodeToString(r.body))
+		case DataTypeBase64:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, base64.StdEncoding.EncodeToString(r.body))
+		default:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, string(r.body))
+		}
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 响应写回帧所在的连接
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	conn    *conn
+	// encode 按分帧方式封装响应，为 nil 原样写入
+	encode func(payload []byte) []byte
+	msg    *types.RuleMsg
+	err    error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return r.conn.remoteAddr
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+// SetBody 把响应写回设备，eg. ACK，写入失败记录在 GetError
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+	if len(body) == 0 {
+		return
+	}
+	if r.encode != nil {
+		body = r.encode(body)
+	}
+	if err := r.conn.write(body); err != nil {
+		r.err = err
+	}
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// TcpListenerConfig TCP 监听端点配置
+type TcpListenerConfig struct {
+	// Server 监听地址，格式：host:port
+	Server         string `json:"server" label:"Server" desc:"TCP listen address, format: host:port" required:"true"`
+	framing.Config `json:",squash"`
+	// DataType 帧的消息格式：text、binary、hex、base64
+	DataType string `json:"dataType" label:"Data Type" desc:"Message data type of frames: text, binary, hex or base64"`
+	// RawResponse 响应原样写入，不按分帧方式封装
+	RawResponse bool `json:"rawResponse" label:"Raw Response" desc:"Write responses as is without adding the delimiter, STX/ETX and checksum or length field"`
+	// IdleTimeout 连接超过多少秒没有数据时断开，0 不断开
+	IdleTimeout int `json:"idleTimeout" label:"Idle Timeout" desc:"Close connections without data for this many seconds, 0 keeps them open"`
+	// MaxConnections 最大连接数，0 不限制
+	MaxConnections int `json:"maxConnections" label:"Max Connections" desc:"Maximum number of device connections, 0 is unlimited"`
+}
+
+// TcpListener TCP 监听端点
+// 路由的 from 为帧的前缀，为空或者 * 匹配所有帧，帧发送到所有匹配的路由
+type TcpListener struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     TcpListenerConfig
+	// framer 用于检查配置和封装响应，每个连接使用自己的分帧器
+	framer framing.Framer
+	// mu 保护 listener 和 conns
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[string]*conn
+}
+
+// conn 设备连接
+type conn struct {
+	net.Conn
+	remoteAddr string
+	localAddr  string
+	// mu 保护写入，多个规则链可能同时写回响应
+	mu sync.Mutex
+}
+
+// write 写入数据
+func (c *conn) write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := c.Write(data)
+	return err
+}
+
+// Type 组件类型
+func (x *TcpListener) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *TcpListener) New() types.Node {
+	return &TcpListener{
+		Config: TcpListenerConfig{
+			Server: ":9000",
+			Config: framing.Config{
+				Framing:   framing.Delimiter,
+				Delimiter: "\\n",
+				Checksum:  framing.ChecksumNone,
+				MaxLength: framing.DefaultMaxLength,
+			},
+			DataType: DataTypeText,
+		},
+	}
+}
+
+// Init 初始化
+func (x *TcpListener) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if strings.TrimSpace(x.Config.Server) == "" {
+		return errors.New("tcp listen address is empty")
+	}
+	switch x.Config.DataType {
+	case "":
+		x.Config.DataType = DataTypeText
+	case DataTypeText, DataTypeBinary, DataTypeHex, DataTypeBase64:
+	default:
+		return fmt.Errorf("unsupported tcp data type: %s", x.Config.DataType)
+	}
+	x.framer, err = framing.New(x.Config.Config)
+	return err
+}
+
+// Destroy 销毁
+func (x *TcpListener) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *TcpListener) Desc() string {
+	return "TCP listener endpoint accepting device connections, splitting each stream into frames and writing chain responses back to the connection"
+}
+
+// Category returns the component category
+func (x *TcpListener) Category() string {
+	return "endpoint"
+}
+
+func (x *TcpListener) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "TCP listener endpoint accepting device connections, splitting each stream into frames and writing chain responses back to the connection",
+		RouterForm: &types.RouterForm{
+			From: &types.RouterFormField{
+				Path: types.ComponentFormField{
+					Name:  "path",
+					Type:  "string",
+					Label: "Prefix",
+					Desc:  "Frame prefix to route, empty or * routes all frames",
+				},
+			},
+		},
+	}
+}
+
+// Close 停止监听并断开所有连接
+func (x *TcpListener) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var err error
+	if x.listener != nil {
+		err = x.listener.Close()
+		x.listener = nil
+	}
+	for _, c := range x.conns {
+		_ = c.Close()
+	}
+	x.conns = nil
+	return err
+}
+
+func (x *TcpListener) Id() string {
+	return x.Config.Server
+}
+
+func (x *TcpListener) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpointApi.Router)
+	}
+	x.RouterStorage[router.GetId()] = router
+	return router.GetId(), nil
+}
+
+func (x *TcpListener) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if _, ok := x.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(x.RouterStorage, routerId)
+	return nil
+}
+
+// routersOf 前缀匹配帧的路由
+func (x *TcpListener) routersOf(frame []byte) []endpointApi.Router {
+	x.RLock()
+	defer x.RUnlock()
+	var routers []endpointApi.Router
+	for _, r := range x.RouterStorage {
+		var prefix string
+		if from := r.GetFrom(); from != nil {
+			prefix = from.ToString()
+		}
+		if prefix == "" || prefix == "*" || bytes.HasPrefix(frame, []byte(prefix)) {
+			routers = append(routers, r)
+		}
+	}
+	return routers
+}
+
+func (x *TcpListener) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", x.Config.Server)
+	if err != nil {
+		return err
+	}
+	x.listener, x.conns = listener, make(map[string]*conn)
+	go x.accept(listener)
+	x.Printf("started TCP listener on %s", listener.Addr())
+	return nil
+}
+
+// Addr 实际监听的地址，未启动返回 nil
+func (x *TcpListener) Addr() net.Addr {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.listener == nil {
+		return nil
+	}
+	return x.listener.Addr()
+}
+
+// RemoteAddrs 已连接设备的远程地址
+func (x *TcpListener) RemoteAddrs() []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	addrs := make([]string, 0, len(x.conns))
+	for addr := range x.conns {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// Write 向指定远程地址的连接写入数据，raw 为 false 时按分帧方式封装
+func (x *TcpListener) Write(remoteAddr string, data []byte, raw bool) error {
+	x.mu.Lock()
+	c, ok := x.conns[remoteAddr]
+	x.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("tcp connection not found: %s", remoteAddr)
+	}
+	if !raw {
+		data = x.framer.Encode(data)
+	}
+	return c.write(data)
+}
+
+func (x *TcpListener) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+func (x *TcpListener) accept(listener net.Listener) {
+	for {
+		nc, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			x.Printf("tcp listener %s accept error: %v", x.Config.Server, err)
+			time.Sleep(readTick)
+			continue
+		}
+		c := &conn{Conn: nc, remoteAddr: nc.RemoteAddr().String(), localAddr: nc.LocalAddr().String()}
+		if !x.addConn(c) {
+			_ = nc.Close()
+			continue
+		}
+		go x.serve(c)
+	}
+}
+
+// addConn 保存连接，端点已经关闭或者超过最大连接数返回 false
+func (x *TcpListener) addConn(c *conn) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conns == nil {
+		return false
+	}
+	if x.Config.MaxConnections > 0 && len(x.conns) >= x.Config.MaxConnections {
+		x.Printf("tcp listener %s: too many connections, rejected %s", x.Config.Server, c.remoteAddr)
+		return false
+	}
+	x.conns[c.remoteAddr] = c
+	return true
+}
+
+func (x *TcpListener) removeConn(c *conn) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conns[c.remoteAddr] == c {
+		delete(x.conns, c.remoteAddr)
+	}
+}
+
+// serve 读取并分帧，连接断开、空闲超时或者端点关闭时结束
+func (x *TcpListener) serve(c *conn) {
+	defer func() {
+		x.removeConn(c)
+		_ = c.Close()
+	}()
+	framer, err := framing.New(x.Config.Config)
+	if err != nil {
+		return
+	}
+	silence := time.Duration(x.Config.Timeout) * time.Millisecond
+	idle := time.Duration(x.Config.IdleTimeout) * time.Second
+	tick := idle
+	if silence > 0 {
+		tick = readTick
+	}
+	emit := func(frame []byte, err error) {
+		x.onFrame(c, frame, err)
+	}
+	buf := make([]byte, 4096)
+	last := time.Now()
+	for {
+		if tick > 0 {
+			_ = c.SetReadDeadline(time.Now().Add(tick))
+		}
+		n, err := c.Read(buf)
+		if n > 0 {
+			last = time.Now()
+			framer.Feed(buf[:n], emit)
+		}
+		if err == nil {
+			continue
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			if silence > 0 && framer.Pending() && time.Since(last) >= silence {
+				if frame := framer.Idle(); frame != nil {
+					emit(frame, nil)
+				}
+			}
+			if idle > 0 && time.Since(last) >= idle {
+				x.Printf("tcp connection %s idle, closing", c.remoteAddr)
+				return
+			}
+			continue
+		}
+		// 设备发送后关闭连接时结束 timeout 分帧的最后一帧
+		if frame := framer.Idle(); frame != nil {
+			emit(frame, nil)
+		}
+		return
+	}
+}
+
+// onFrame 帧交给匹配的路由，校验错误和超长的帧只记录日志
+func (x *TcpListener) onFrame(c *conn, frame []byte, err error) {
+	if err != nil {
+		x.Printf("tcp connection %s: %v", c.remoteAddr, err)
+		return
+	}
+	var encode func(payload []byte) []byte
+	if !x.Config.RawResponse {
+		encode = x.framer.Encode
+	}
+	for _, router := range x.routersOf(frame) {
+		exchange := &endpointApi.Exchange{
+			In:  &RequestMessage{conn: c, body: frame, dataType: x.Config.DataType},
+			Out: &ResponseMessage{conn: c, encode: encode},
+		}
+		x.DoProcess(context.Background(), router, exchange)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcplistener
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/framing"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestTcpListenerEndpoint(t *testing.T) {
+	config := engine.NewConfig()
+	_, err := engine.New("tcp-listener-test01", []byte(`{
+		"ruleChain": {"id": "tcp-listener-test01", "name": "tcp-listener-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("tcp-listener-test01")
+
+	for _, configuration := range []types.Configuration{
+		{"server": ""},
+		{"server": "127.0.0.1:0", "dataType": "json"},
+		{"server": "127.0.0.1:0", "framing": framing.LengthField},
+	} {
+		assert.NotNil(t, (&TcpListener{}).New().Init(config, configuration))
+	}
+
+	ep := (&TcpListener{}).New().(*TcpListener)
+	assert.Equal(t, Type, ep.Type())
+	assert.Nil(t, ep.Init(config, types.Configuration{
+		"server":         "127.0.0.1:0",
+		"delimiter":      "\\r\\n",
+		"maxConnections": 1,
+	}))
+	frames := make(chan types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("$").To("chain:tcp-listener-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		frames <- *msg
+		// 回复 ACK，响应按分隔符封装
+		exchange.Out.SetBody([]byte("ACK" + msg.GetData()[1:]))
+		return true
+	}).End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	device, err := net.Dial("tcp", ep.Addr().String())
+	assert.Nil(t, err)
+	defer device.Close()
+	_, err = device.Write([]byte("$01,23.5\r\nignored\r\n$02,"))
+	assert.Nil(t, err)
+	_, err = device.Write([]byte("24.0\r\n"))
+	assert.Nil(t, err)
+	reader := bufio.NewReader(device)
+	for _, expected := range []string{"$01,23.5", "$02,24.0"} {
+		select {
+		case msg := <-frames:
+			assert.Equal(t, MsgTypeFrame, msg.Type)
+			assert.Equal(t, expected, msg.GetData())
+			assert.Equal(t, device.LocalAddr().String(), msg.Metadata.GetValue(KeyRemoteAddr))
+			assert.Equal(t, ep.Addr().String(), msg.Metadata.GetValue(KeyLocalAddr))
+			assert.Equal(t, "8", msg.Metadata.GetValue(KeyLength))
+		case <-time.After(time.Second):
+			t.Fatal("frame not routed")
+		}
+		_ = device.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		assert.Equal(t, "ACK"+expected[1:]+"\r\n", line)
+	}
+
+	// 超过最大连接数的连接被断开
+	other, err := net.Dial("tcp", ep.Addr().String())
+	assert.Nil(t, err)
+	defer other.Close()
+	_ = other.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = other.Read(make([]byte, 1))
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []string{device.LocalAddr().String()}, ep.RemoteAddrs())
+	assert.Nil(t, ep.Write(device.LocalAddr().String(), []byte("PING"), false))
+	assert.Nil(t, ep.Write(device.LocalAddr().String(), []byte("RAW\n"), true))
+	assert.NotNil(t, ep.Write("127.0.0.1:1", []byte("PING"), false))
+	line, err := reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "PING\r\n", line)
+	line, err = reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "RAW\n", line)
+
+	// 端点关闭时断开设备连接
+	ep.Destroy()
+	_, err = reader.ReadString('\n')
+	assert.NotNil(t, err)
+	assert.Nil(t, ep.Addr())
+}
+
+func TestTcpListenerTimeoutFraming(t *testing.T) {
+	config := engine.NewConfig()
+	_, err := engine.New("tcp-listener-test02", []byte(`{
+		"ruleChain": {"id": "tcp-listener-test02", "name": "tcp-listener-test02"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("tcp-listener-test02")
+
+	ep := (&TcpListener{}).New().(*TcpListener)
+	assert.Nil(t, ep.Init(config, types.Configuration{
+		"server":      "127.0.0.1:0",
+		"framing":     framing.Timeout,
+		"timeout":     100,
+		"dataType":    DataTypeHex,
+		"rawResponse": true,
+		"idleTimeout": 1,
+	}))
+	frames := make(chan types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("").To("chain:tcp-listener-test02").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		frames <- *exchange.In.GetMsg()
+		exchange.Out.SetBody([]byte{0x06})
+		return true
+	}).End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	device, err := net.Dial("tcp", ep.Addr().String())
+	assert.Nil(t, err)
+	defer device.Close()
+	_, _ = device.Write([]byte{0x68, 0x01})
+	time.Sleep(20 * time.Millisecond)
+	_, _ = device.Write([]byte{0x02, 0x16})
+	select {
+	case msg := <-frames:
+		assert.Equal(t, "68010216", msg.GetData())
+	case <-time.After(time.Second):
+		t.Fatal("frame not routed")
+	}
+	buf := make([]byte, 2)
+	_ = device.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := device.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x06}, buf[:n])
+
+	// 空闲超时后断开连接
+	_ = device.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, err = device.Read(buf)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(ep.RemoteAddrs()))
+}
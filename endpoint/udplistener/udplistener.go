@@ -0,0 +1,478 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package udplistener 提供 UDP 原始报文监听端点
+// 端点接收设备发送的数据报，默认每个数据报为一帧，也可以配置分帧方式把一个数据报拆分为多个帧或者检查 STX/ETX 和校验，
+// 帧按前缀路由到规则链，元数据带上设备的远程地址，规则链设置的响应发回数据报的来源地址
+package udplistener
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rulego/rulego-components-iot/pkg/framing"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "udpListener"
+
+// 元数据key
+const (
+	KeyRemoteAddr = "remoteAddr"
+	KeyLocalAddr  = "localAddr"
+	KeyLength     = "length"
+)
+
+// MsgTypeFrame 帧的消息类型
+const MsgTypeFrame = "UDP"
+
+// 帧的消息格式
+const (
+	DataTypeText   = "text"
+	DataTypeBinary = "binary"
+	DataTypeHex    = "hex"
+	DataTypeBase64 = "base64"
+)
+
+// Endpoint 别名
+type Endpoint = UdpListener
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	remoteAddr *net.UDPAddr
+	localAddr  string
+	dataType   string
+	msg        *types.RuleMsg
+	err        error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.remoteAddr.String()
+}
+
+// GetParam 获取远程地址、本地地址和帧长度
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyRemoteAddr:
+		return r.remoteAddr.String()
+	case KeyLocalAddr:
+		return r.localAddr
+	case KeyLength:
+		return strconv.Itoa(len(r.body))
+	default:
+		return ""
+	}
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 消息类型为 UDP，帧按 dataType 转换为文本、二进制、十六进制或者 base64，地址和帧长度放在元数据中
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyRemoteAddr, r.remoteAddr.String())
+		metadata.PutValue(KeyLocalAddr, r.localAddr)
+		metadata.PutValue(KeyLength, strconv.Itoa(len(r.body)))
+		var ruleMsg types.RuleMsg
+		switch r.dataType {
+		case DataTypeBinary:
+			ruleMsg = types.NewMsgFromBytes(0, MsgTypeFrame, types.BINARY, metadata, r.body)
+		case DataTypeHex:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, hex.EncodeToString(r.body))
+		case DataTypeBase64:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, base64.StdEncoding.EncodeToString(r.body))
+		default:
+			ruleMsg = types.NewMsg(0, MsgTypeFrame, types.TEXT, metadata, string(r.body))
+		}
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 响应发回数据报的来源地址
+type ResponseMessage struct {
+	headers    textproto.MIMEHeader
+	body       []byte
+	conn       *net.UDPConn
+	remoteAddr *net.UDPAddr
+	// encode 按分帧方式封装响应，为 nil 原样发送
+	encode func(payload []byte) []byte
+	msg    *types.RuleMsg
+	err    error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return r.remoteAddr.String()
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+// SetBody 把响应发回设备，eg. ACK，发送失败记录在 GetError
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+	if len(body) == 0 {
+		return
+	}
+	if r.encode != nil {
+		body = r.encode(body)
+	}
+	if _, err := r.conn.WriteToUDP(body, r.remoteAddr); err != nil {
+		r.err = err
+	}
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// UdpListenerConfig UDP 监听端点配置
+type UdpListenerConfig struct {
+	// Server 监听地址，格式：host:port
+	Server         string `json:"server" label:"Server" desc:"UDP listen address, format: host:port" required:"true"`
+	framing.Config `json:",squash"`
+	// DataType 帧的消息格式：text、binary、hex、base64
+	DataType string `json:"dataType" label:"Data Type" desc:"Message data type of frames: text, binary, hex or base64"`
+	// RawResponse 响应原样发送，不按分帧方式封装
+	RawResponse bool `json:"rawResponse" label:"Raw Response" desc:"Send responses as is without adding the delimiter, STX/ETX and checksum or length field"`
+}
+
+// UdpListener UDP 监听端点
+// framing 为空时每个数据报为一帧，否则每个数据报单独分帧，不完整的帧在数据报结束时丢弃。
+// 路由的 from 为帧的前缀，为空或者 * 匹配所有帧，帧发送到所有匹配的路由
+type UdpListener struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     UdpListenerConfig
+	// framer 为 nil 时不分帧
+	framer framing.Framer
+	// mu 保护 conn
+	mu   sync.Mutex
+	conn *net.UDPConn
+}
+
+// Type 组件类型
+func (x *UdpListener) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *UdpListener) New() types.Node {
+	return &UdpListener{
+		Config: UdpListenerConfig{
+			Server: ":9000",
+			Config: framing.Config{
+				MaxLength: framing.DefaultMaxLength,
+			},
+			DataType: DataTypeText,
+		},
+	}
+}
+
+// Init 初始化
+func (x *UdpListener) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if strings.TrimSpace(x.Config.Server) == "" {
+		return errors.New("udp listen address is empty")
+	}
+	switch x.Config.DataType {
+	case "":
+		x.Config.DataType = DataTypeText
+	case DataTypeText, DataTypeBinary, DataTypeHex, DataTypeBase64:
+	default:
+		return fmt.Errorf("unsupported udp data type: %s", x.Config.DataType)
+	}
+	x.framer = nil
+	if x.Config.Framing != "" {
+		x.framer, err = framing.New(x.Config.Config)
+	}
+	return err
+}
+
+// Destroy 销毁
+func (x *UdpListener) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *UdpListener) Desc() string {
+	return "UDP listener endpoint routing device datagrams or the frames inside them to rule chains and sending chain responses back to the sender"
+}
+
+// Category returns the component category
+func (x *UdpListener) Category() string {
+	return "endpoint"
+}
+
+func (x *UdpListener) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "UDP listener endpoint routing device datagrams or the frames inside them to rule chains and sending chain responses back to the sender",
+		RouterForm: &types.RouterForm{
+			From: &types.RouterFormField{
+				Path: types.ComponentFormField{
+					Name:  "path",
+					Type:  "string",
+					Label: "Prefix",
+					Desc:  "Frame prefix to route, empty or * routes all frames",
+				},
+			},
+		},
+	}
+}
+
+func (x *UdpListener) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var err error
+	if x.conn != nil {
+		err = x.conn.Close()
+		x.conn = nil
+	}
+	return err
+}
+
+func (x *UdpListener) Id() string {
+	return x.Config.Server
+}
+
+func (x *UdpListener) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.CheckAndSetRouterId(router)
+	x.Lock()
+	defer x.Unlock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpointApi.Router)
+	}
+	x.RouterStorage[router.GetId()] = router
+	return router.GetId(), nil
+}
+
+func (x *UdpListener) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	if _, ok := x.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(x.RouterStorage, routerId)
+	return nil
+}
+
+// routersOf 前缀匹配帧的路由
+func (x *UdpListener) routersOf(frame []byte) []endpointApi.Router {
+	x.RLock()
+	defer x.RUnlock()
+	var routers []endpointApi.Router
+	for _, r := range x.RouterStorage {
+		var prefix string
+		if from := r.GetFrom(); from != nil {
+			prefix = from.ToString()
+		}
+		if prefix == "" || prefix == "*" || bytes.HasPrefix(frame, []byte(prefix)) {
+			routers = append(routers, r)
+		}
+	}
+	return routers
+}
+
+func (x *UdpListener) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conn != nil {
+		return nil
+	}
+	addr, err := net.ResolveUDPAddr("udp", x.Config.Server)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	x.conn = conn
+	go x.serve(conn)
+	x.Printf("started UDP listener on %s", conn.LocalAddr())
+	return nil
+}
+
+// Addr 实际监听的地址，未启动返回 nil
+func (x *UdpListener) Addr() net.Addr {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conn == nil {
+		return nil
+	}
+	return x.conn.LocalAddr()
+}
+
+// Write 向指定地址发送数据，raw 为 false 时按分帧方式封装
+func (x *UdpListener) Write(remoteAddr string, data []byte, raw bool) error {
+	x.mu.Lock()
+	conn := x.conn
+	x.mu.Unlock()
+	if conn == nil {
+		return fmt.Errorf("udp listener not started: %s", x.Id())
+	}
+	addr, err := net.ResolveUDPAddr("udp", remoteAddr)
+	if err != nil {
+		return err
+	}
+	if !raw && x.framer != nil {
+		data = x.framer.Encode(data)
+	}
+	_, err = conn.WriteToUDP(data, addr)
+	return err
+}
+
+func (x *UdpListener) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+func (x *UdpListener) serve(conn *net.UDPConn) {
+	buf := make([]byte, 65535)
+	localAddr := conn.LocalAddr().String()
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		emit := func(frame []byte, err error) {
+			x.onFrame(conn, addr, localAddr, frame, err)
+		}
+		if x.framer == nil {
+			emit(data, nil)
+			continue
+		}
+		// 数据报之间不拼接，数据报结束时 timeout 分帧结束一帧
+		x.framer.Reset()
+		x.framer.Feed(data, emit)
+		if frame := x.framer.Idle(); frame != nil {
+			emit(frame, nil)
+		}
+	}
+}
+
+// onFrame 帧交给匹配的路由，校验错误和超长的帧只记录日志
+func (x *UdpListener) onFrame(conn *net.UDPConn, addr *net.UDPAddr, localAddr string, frame []byte, err error) {
+	if err != nil {
+		x.Printf("udp datagram from %s: %v", addr, err)
+		return
+	}
+	var encode func(payload []byte) []byte
+	if !x.Config.RawResponse && x.framer != nil {
+		encode = x.framer.Encode
+	}
+	for _, router := range x.routersOf(frame) {
+		exchange := &endpointApi.Exchange{
+			In:  &RequestMessage{remoteAddr: addr, localAddr: localAddr, body: frame, dataType: x.Config.DataType},
+			Out: &ResponseMessage{conn: conn, remoteAddr: addr, encode: encode},
+		}
+		x.DoProcess(context.Background(), router, exchange)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udplistener
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/framing"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestUdpListenerEndpoint(t *testing.T) {
+	config := engine.NewConfig()
+	_, err := engine.New("udp-listener-test01", []byte(`{
+		"ruleChain": {"id": "udp-listener-test01", "name": "udp-listener-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("udp-listener-test01")
+
+	for _, configuration := range []types.Configuration{
+		{"server": ""},
+		{"server": "127.0.0.1:0", "dataType": "json"},
+		{"server": "127.0.0.1:0", "framing": framing.Fixed},
+	} {
+		assert.NotNil(t, (&UdpListener{}).New().Init(config, configuration))
+	}
+
+	ep := (&UdpListener{}).New().(*UdpListener)
+	assert.Equal(t, Type, ep.Type())
+	assert.Nil(t, ep.Init(config, types.Configuration{
+		"server":   "127.0.0.1:0",
+		"dataType": DataTypeHex,
+	}))
+	frames := make(chan types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("").To("chain:udp-listener-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		frames <- *exchange.In.GetMsg()
+		exchange.Out.SetBody([]byte{0x06})
+		return true
+	}).End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	device, err := net.Dial("udp", ep.Addr().String())
+	assert.Nil(t, err)
+	defer device.Close()
+	_, err = device.Write([]byte{0xaa, 0x01, 0x02})
+	assert.Nil(t, err)
+	select {
+	case msg := <-frames:
+		assert.Equal(t, MsgTypeFrame, msg.Type)
+		assert.Equal(t, "aa0102", msg.GetData())
+		assert.Equal(t, device.LocalAddr().String(), msg.Metadata.GetValue(KeyRemoteAddr))
+		assert.Equal(t, ep.Addr().String(), msg.Metadata.GetValue(KeyLocalAddr))
+		assert.Equal(t, "3", msg.Metadata.GetValue(KeyLength))
+	case <-time.After(time.Second):
+		t.Fatal("datagram not routed")
+	}
+	buf := make([]byte, 16)
+	_ = device.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := device.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x06}, buf[:n])
+
+	assert.Nil(t, ep.Write(device.LocalAddr().String(), []byte("PING"), false))
+	n, err = device.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "PING", string(buf[:n]))
+
+	ep.Destroy()
+	assert.Nil(t, ep.Addr())
+	assert.NotNil(t, ep.Write(device.LocalAddr().String(), []byte("PING"), false))
+}
+
+func TestUdpListenerFraming(t *testing.T) {
+	config := engine.NewConfig()
+	_, err := engine.New("udp-listener-test02", []byte(`{
+		"ruleChain": {"id": "udp-listener-test02", "name": "udp-listener-test02"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("udp-listener-test02")
+
+	ep := (&UdpListener{}).New().(*UdpListener)
+	assert.Nil(t, ep.Init(config, types.Configuration{
+		"server":   "127.0.0.1:0",
+		"framing":  framing.StxEtx,
+		"checksum": framing.ChecksumXor,
+	}))
+	frames := make(chan types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("R").To("chain:udp-listener-test02").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		frames <- *exchange.In.GetMsg()
+		exchange.Out.SetBody([]byte("OK"))
+		return true
+	}).End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+
+	device, err := net.Dial("udp", ep.Addr().String())
+	assert.Nil(t, err)
+	defer device.Close()
+	framer, _ := framing.New(ep.Config.Config)
+	// 一个数据报包含两个帧和一个校验错误的帧，不完整的帧不会和下一个数据报拼接
+	datagram := append(framer.Encode([]byte("R1")), framer.Encode([]byte("S2"))...)
+	datagram = append(datagram, []byte("\x02R3\x03\x00")...)
+	datagram = append(datagram, framer.Encode([]byte("R4"))...)
+	_, _ = device.Write(append(datagram, 0x02, 'R'))
+	_, _ = device.Write([]byte("5\x03\x36"))
+	var received []string
+	for len(received) < 2 {
+		select {
+		case msg := <-frames:
+			received = append(received, msg.GetData())
+		case <-time.After(time.Second):
+			t.Fatal("frame not routed")
+		}
+	}
+	assert.Equal(t, []string{"R1", "R4"}, received)
+	select {
+	case msg := <-frames:
+		t.Fatalf("unexpected frame: %s", msg.GetData())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 响应按 STX/ETX 和校验封装
+	buf := make([]byte, 16)
+	_ = device.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := device.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, framer.Encode([]byte("OK")), buf[:n])
+}
@@ -0,0 +1,502 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wits0 提供 WITS0（WITS level 0）钻井数据接收端点
+// 端点通过串口或者 TCP 连接录井/钻井仪表系统，持续读取 && 和 !! 之间的 ASCII 数据帧，
+// 每一帧按数据项编码（eg. 0108）转换为通道名称->值的消息交给路由处理，连接断开后自动重新连接
+package wits0
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/serial"
+
+	"github.com/rulego/rulego-components-iot/external/wits"
+	"github.com/rulego/rulego/api/types"
+	endpointApi "github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const Type = types.EndpointTypePrefix + "wits0"
+
+// MsgTypeWITS0 帧的消息类型
+const MsgTypeWITS0 = "WITS0"
+
+// 元数据key
+const (
+	KeyServer = "server"
+	// KeyRecords 帧包含的记录号，逗号分隔，eg. 1,8
+	KeyRecords = "records"
+)
+
+// SerialScheme 串口地址前缀，eg. serial:///dev/ttyS0
+const SerialScheme = "serial://"
+
+// readTick 串口读取超时，用于检查关闭
+const readTick = 100 * time.Millisecond
+
+// maxLineLength 一行的最大长度
+const maxLineLength = 4096
+
+// reconnectInterval 连接失败或者断开后重新连接的间隔
+var reconnectInterval = 5 * time.Second
+
+// Endpoint 别名
+type Endpoint = WITS0
+
+var _ endpointApi.Endpoint = (*Endpoint)(nil)
+
+// 注册组件
+func init() {
+	_ = endpoint.Registry.Register(&Endpoint{})
+}
+
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	// data 通道名称->值
+	data     map[string]interface{}
+	metadata *types.Metadata
+	msg      *types.RuleMsg
+	err      error
+}
+
+func (r *RequestMessage) Body() []byte {
+	b, err := json.Marshal(r.data)
+	if err != nil {
+		log.Println(err)
+	}
+	return b
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	return r.metadata.GetValue(KeyServer)
+}
+
+// GetParam 获取元数据
+func (r *RequestMessage) GetParam(key string) string {
+	return r.metadata.GetValue(key)
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		ruleMsg := types.NewMsg(0, MsgTypeWITS0, types.JSON, r.metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage WITS0 是单向数据流，不需要响应
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError set error
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+// GetError get error
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// WITS0Config WITS0 端点配置
+type WITS0Config struct {
+	// Server 数据源地址，TCP 格式：tcp://host:port 或者 host:port，串口格式：serial:///dev/ttyS0
+	Server string `json:"server" label:"Server" desc:"WITS0 source, tcp://host:port or serial:///dev/ttyS0" required:"true"`
+	// BaudRate 串口波特率，8N1
+	BaudRate int `json:"baudRate" label:"Baud Rate" desc:"Serial baud rate, 8 data bits, no parity, 1 stop bit"`
+	// Channels 数据项编码到通道名称的映射，eg. {"0108": "bitDepth"}，没有配置的记录 01 数据项使用标准名称，其他使用 4 位编码
+	Channels map[string]string `json:"channels" label:"Channels" desc:"Map of 4 digit item codes to channel names, eg. {\"0108\": \"bitDepth\"}, unmapped record 01 items use standard names and others keep the code"`
+	// Nulls 表示无效值的数字，转换为 null
+	Nulls []float64 `json:"nulls" label:"Null Values" desc:"Numbers meaning absent values, converted to null"`
+	// Heartbeat 发送心跳帧的间隔，单位秒，0 不发送
+	Heartbeat int `json:"heartbeat" label:"Heartbeat" desc:"Interval in seconds to send heartbeat frames to the source, 0 disables"`
+}
+
+// WITS0 WITS0 接收端点
+// 每一帧产生一个消息，消息负荷为通道名称->值：
+//
+//	{"bitDepth": 1520.3, "holeDepth": 1522.5, "rpmAvg": 120, "mwdInclination": null}
+//
+// 数值转换为数字，无效值为 null。消息类型为 WITS0，元数据包含 server 和 records（帧包含的记录号）
+type WITS0 struct {
+	impl.BaseEndpoint
+	RuleConfig types.Config
+	Config     WITS0Config
+	// 路由实例
+	Router endpointApi.Router
+	// mu 保护 started、closed 和 conn
+	mu      sync.Mutex
+	started bool
+	closed  chan struct{}
+	conn    io.ReadWriteCloser
+}
+
+// Type 组件类型
+func (x *WITS0) Type() string {
+	return Type
+}
+
+// New 创建组件实例
+func (x *WITS0) New() types.Node {
+	return &WITS0{
+		Config: WITS0Config{
+			Server:   "tcp://127.0.0.1:5000",
+			BaudRate: 9600,
+			Nulls:    []float64{-8888, -9999},
+		},
+	}
+}
+
+// Init 初始化
+func (x *WITS0) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Server) == "" {
+		return errors.New("wits0 server address cannot be empty")
+	}
+	for code := range x.Config.Channels {
+		if len(code) != 4 {
+			return fmt.Errorf("invalid wits0 item code: %s", code)
+		}
+		if _, err := strconv.Atoi(code); err != nil {
+			return fmt.Errorf("invalid wits0 item code: %s", code)
+		}
+	}
+	if x.Config.BaudRate <= 0 {
+		x.Config.BaudRate = 9600
+	}
+	x.RuleConfig = ruleConfig
+	return nil
+}
+
+// Destroy 销毁
+func (x *WITS0) Destroy() {
+	_ = x.Close()
+}
+
+// Desc returns the component description
+func (x *WITS0) Desc() string {
+	return "WITS0 endpoint reading drilling data frames over serial or TCP and routing channel values as tagged JSON"
+}
+
+// Category returns the component category
+func (x *WITS0) Category() string {
+	return "endpoint"
+}
+
+func (x *WITS0) Def() types.ComponentForm {
+	return types.ComponentForm{
+		Desc: "WITS0 endpoint reading drilling data frames over serial or TCP and routing channel values as tagged JSON",
+		RouterForm: &types.RouterForm{
+			Hide: true,
+		},
+	}
+}
+
+func (x *WITS0) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.started {
+		return nil
+	}
+	x.started = false
+	close(x.closed)
+	if x.conn != nil {
+		_ = x.conn.Close()
+	}
+	return nil
+}
+
+func (x *WITS0) Id() string {
+	return x.Config.Server
+}
+
+func (x *WITS0) AddRouter(router endpointApi.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router cannot be nil")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.Router != nil {
+		return "", errors.New("duplicate router")
+	}
+	x.Router = router
+	return router.GetId(), nil
+}
+
+func (x *WITS0) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	defer x.Unlock()
+	x.Router = nil
+	return nil
+}
+
+// Start 连接数据源并开始读取，失败后自动重新连接
+func (x *WITS0) Start() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.started {
+		return nil
+	}
+	x.started, x.closed = true, make(chan struct{})
+	go x.readLoop(x.closed)
+	x.Printf("started wits0 endpoint on %s", x.Config.Server)
+	return nil
+}
+
+func (x *WITS0) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// readLoop 连接并读取，失败后等待 reconnectInterval 重新连接，直到端点关闭
+func (x *WITS0) readLoop(closed chan struct{}) {
+	for {
+		conn, err := x.dial(closed)
+		if err == nil {
+			err = x.read(conn, closed)
+		}
+		select {
+		case <-closed:
+			return
+		default:
+		}
+		x.Printf("wits0 %s error: %v, reconnecting", x.Config.Server, err)
+		select {
+		case <-closed:
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+// dial 打开串口或者连接 TCP，端点已经关闭时关闭连接
+func (x *WITS0) dial(closed chan struct{}) (io.ReadWriteCloser, error) {
+	var conn io.ReadWriteCloser
+	if strings.HasPrefix(x.Config.Server, SerialScheme) {
+		port, err := serial.Open(&serial.Config{
+			Address:  strings.TrimPrefix(x.Config.Server, SerialScheme),
+			BaudRate: x.Config.BaudRate,
+			DataBits: 8,
+			StopBits: 1,
+			Parity:   "N",
+			Timeout:  readTick,
+		})
+		if err != nil {
+			return nil, err
+		}
+		conn = &serialConn{Port: port, closed: closed}
+	} else {
+		c, err := net.DialTimeout("tcp", strings.TrimPrefix(x.Config.Server, "tcp://"), reconnectInterval)
+		if err != nil {
+			return nil, err
+		}
+		conn = c
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	select {
+	case <-closed:
+		_ = conn.Close()
+		return nil, errors.New("wits0 endpoint closed")
+	default:
+	}
+	x.conn = conn
+	return conn, nil
+}
+
+// read 按行读取并解码，直到连接断开
+func (x *WITS0) read(conn io.ReadWriteCloser, closed chan struct{}) error {
+	defer conn.Close()
+	if x.Config.Heartbeat > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go x.heartbeat(conn, done)
+	}
+	var decoder wits.Decoder
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 256), maxLineLength)
+	for scanner.Scan() {
+		items, ok, err := decoder.Feed(scanner.Text())
+		if err != nil {
+			x.Printf("wits0 %s: %v", x.Config.Server, err)
+			continue
+		}
+		if ok {
+			x.onFrame(items)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// heartbeat 定时发送心跳帧，写入失败关闭连接
+func (x *WITS0) heartbeat(conn io.ReadWriteCloser, done chan struct{}) {
+	ticker := time.NewTicker(time.Duration(x.Config.Heartbeat) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if _, err := conn.Write([]byte(wits.Heartbeat)); err != nil {
+				x.Printf("wits0 %s heartbeat error: %v", x.Config.Server, err)
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// onFrame 一帧转换为消息交给路由处理
+func (x *WITS0) onFrame(items []wits.Item) {
+	x.RLock()
+	router := x.Router
+	x.RUnlock()
+	if router == nil {
+		return
+	}
+	seen := make(map[int]bool)
+	var records []int
+	for _, item := range items {
+		if !seen[item.Record] {
+			seen[item.Record] = true
+			records = append(records, item.Record)
+		}
+	}
+	sort.Ints(records)
+	recordList := make([]string, len(records))
+	for i, record := range records {
+		recordList[i] = strconv.Itoa(record)
+	}
+	metadata := types.NewMetadata()
+	metadata.PutValue(KeyServer, x.Config.Server)
+	metadata.PutValue(KeyRecords, strings.Join(recordList, ","))
+	exchange := &endpointApi.Exchange{
+		In:  &RequestMessage{data: wits.Channels(x.Config.Channels).Tag(items, x.Config.Nulls), metadata: metadata},
+		Out: &ResponseMessage{},
+	}
+	x.DoProcess(context.Background(), router, exchange)
+}
+
+// serialConn 串口读取超时时重试，直到端点关闭
+type serialConn struct {
+	serial.Port
+	closed chan struct{}
+}
+
+func (c *serialConn) Read(p []byte) (int, error) {
+	for {
+		n, err := c.Port.Read(p)
+		if n > 0 || !errors.Is(err, serial.ErrTimeout) {
+			return n, err
+		}
+		select {
+		case <-c.closed:
+			return 0, io.EOF
+		default:
+		}
+	}
+}
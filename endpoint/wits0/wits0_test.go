@@ -0,0 +1,132 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wits0
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestWITS0Endpoint(t *testing.T) {
+	reconnectInterval = 100 * time.Millisecond
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+	heartbeats := make(chan string, 10)
+	go func() {
+		for i := 0; ; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					if strings.HasPrefix(scanner.Text(), "0111") {
+						heartbeats <- scanner.Text()
+					}
+				}
+			}()
+			if i == 0 {
+				// 第一次连接发送不完整的帧后断开
+				_, _ = conn.Write([]byte("&&\r\n01081520.30\r\n0110 1522.5\r\n0120-8888\r\n!!\r\n&&\r\n0108"))
+				time.Sleep(time.Millisecond * 300)
+				_ = conn.Close()
+			} else {
+				_, _ = conn.Write([]byte("0812 3.5\n0108 1530\n!!\n"))
+			}
+		}
+	}()
+
+	config := engine.NewConfig()
+	_, err = engine.New("wits0-test01", []byte(`{
+		"ruleChain": {"id": "wits0-test01", "name": "wits0-test01"},
+		"metadata": {"nodes": []}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("wits0-test01")
+
+	ep := (&WITS0{}).New().(*WITS0)
+	assert.Equal(t, Type, ep.Type())
+	assert.NotNil(t, ep.Init(config, types.Configuration{"server": ""}))
+	assert.NotNil(t, ep.Init(config, types.Configuration{"server": "tcp://127.0.0.1:1", "channels": map[string]string{"812": "x"}}))
+	ep = (&WITS0{}).New().(*WITS0)
+	err = ep.Init(config, types.Configuration{
+		"server":    "tcp://" + ln.Addr().String(),
+		"channels":  map[string]string{"0812": "mwdInclination"},
+		"heartbeat": 1,
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	var lock sync.Mutex
+	var messages []map[string]interface{}
+	var metadata []*types.Metadata
+	router := impl.NewRouter().From("").To("chain:wits0-test01").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		assert.Equal(t, MsgTypeWITS0, msg.Type)
+		data := make(map[string]interface{})
+		_ = json.Unmarshal([]byte(msg.GetData()), &data)
+		lock.Lock()
+		messages = append(messages, data)
+		metadata = append(metadata, msg.Metadata)
+		lock.Unlock()
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(router)
+	assert.NotNil(t, err)
+	assert.Nil(t, ep.Start())
+
+	select {
+	case heartbeat := <-heartbeats:
+		assert.Equal(t, "0111-9999", heartbeat)
+	case <-time.After(3 * time.Second):
+		t.Fatal("no heartbeat")
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		lock.Lock()
+		n := len(messages)
+		lock.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 2, len(messages))
+	assert.Equal(t, map[string]interface{}{"bitDepth": 1520.3, "holeDepth": 1522.5, "rpmAvg": nil}, messages[0])
+	assert.Equal(t, "1", metadata[0].GetValue(KeyRecords))
+	// 重新连接后丢弃了上一个连接未结束的帧
+	assert.Equal(t, map[string]interface{}{"mwdInclination": 3.5, "bitDepth": 1530.0}, messages[1])
+	assert.Equal(t, "1,8", metadata[1].GetValue(KeyRecords))
+	assert.Equal(t, "tcp://"+ln.Addr().String(), metadata[1].GetValue(KeyServer))
+}
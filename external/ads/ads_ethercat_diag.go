@@ -0,0 +1,414 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// RelationAlert 存在 EtherCAT 故障时额外触发的关系
+const RelationAlert = "Alert"
+
+// EtherCAT 故障类型
+const (
+	// FaultMasterState 主站不在要求的状态
+	FaultMasterState = "masterState"
+	// FaultSlaveLost 从站丢失：主站报告从站不存在、从站数量少于期望值或者上次存在的从站不再出现
+	FaultSlaveLost = "slaveLost"
+	// FaultWireBreak 断线：链路缺失或者有链路但没有通信
+	FaultWireBreak = "wireBreak"
+	// FaultStateError 从站处于错误状态或者初始化失败
+	FaultStateError = "stateError"
+	// FaultNotOperational 从站不在要求的状态
+	FaultNotOperational = "notOperational"
+	// FaultCrcErrors 两次读取之间 CRC 错误计数的增量达到阈值
+	FaultCrcErrors = "crcErrors"
+)
+
+// KeyFaultCount 元数据中故障数量的 key
+const KeyFaultCount = "faultCount"
+
+func init() {
+	_ = rulego.Registry.Register(&EtherCATDiagNode{})
+}
+
+// EtherCATDiagConfiguration EtherCAT 诊断节点配置
+type EtherCATDiagConfiguration struct {
+	// Server TwinCAT 路由器地址，格式：host:port，端口默认 48898
+	Server string `json:"server" label:"Server" desc:"TwinCAT router address, format: host:port, port defaults to 48898" required:"true" ref:"primary"`
+	// TargetNetId EtherCAT 主站设备的 AMS Net ID，见 TwinCAT 中 EtherCAT 设备的 EtherCAT 页，eg. 5.1.2.3.3.1
+	TargetNetId string `json:"targetNetId" label:"Device AMS Net ID" desc:"AMS Net ID of the EtherCAT master device, see the EtherCAT tab of the device in TwinCAT, e.g. 5.1.2.3.3.1" required:"true"`
+	// TargetPort 主站的 AMS 端口
+	TargetPort int `json:"targetPort" label:"Master AMS Port" desc:"AMS port of the EtherCAT master, 65535"`
+	// SourceNetId 客户端的 AMS Net ID，需要在 TwinCAT 中添加对应的路由，为空使用本机 IP 地址加 .1.1
+	SourceNetId string `json:"sourceNetId" label:"Source AMS Net ID" desc:"AMS Net ID of this client, a matching route is required in TwinCAT, empty uses the local IP plus .1.1"`
+	// SourcePort 客户端的 AMS 端口
+	SourcePort int `json:"sourcePort" label:"Source AMS Port" desc:"AMS port of this client"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// ExpectedSlaves 期望的从站数量，主站配置的从站少于该值时报告 slaveLost，0 不检查
+	ExpectedSlaves int `json:"expectedSlaves" label:"Expected Slaves" desc:"Expected number of slaves, fewer slaves report slaveLost, 0 disables the check"`
+	// RequiredState 主站和从站要求的状态：INIT、PREOP、SAFEOP、OP
+	RequiredState string `json:"requiredState" label:"Required State" desc:"State the master and slaves must be in: INIT, PREOP, SAFEOP or OP"`
+	// MaxCrcErrors 两次读取之间 CRC 错误计数增量的阈值，达到时报告 crcErrors，0 不读取 CRC 错误计数
+	MaxCrcErrors int `json:"maxCrcErrors" label:"Max CRC Errors" desc:"CRC error increase between two reads that reports crcErrors, 0 skips reading CRC counters"`
+	// Objects 通过 CoE 读取的从站对象
+	Objects []CoEObject `json:"objects" label:"CoE Objects" desc:"Slave object dictionary entries read through CoE, e.g. diagnosis objects"`
+}
+
+// CoEObject 通过 CoE 读取的从站对象
+type CoEObject struct {
+	// Name 作为输出的 key
+	Name string `json:"name"`
+	// Slave 从站的 EtherCAT 地址，eg. 1001
+	Slave int `json:"slave"`
+	// Index 对象索引，支持十六进制，eg. 0x10F3
+	Index string `json:"index"`
+	// SubIndex 子索引
+	SubIndex int `json:"subIndex"`
+	// DataType 数据类型，eg. UINT、UDINT、STRING(20)，见 ads 的数据类型
+	DataType string `json:"dataType"`
+}
+
+// Fault EtherCAT 故障
+type Fault struct {
+	// Address 从站的 EtherCAT 地址，主站和数量的故障为 0
+	Address uint16 `json:"address,omitempty"`
+	// Fault 故障类型，见 FaultSlaveLost 等
+	Fault string `json:"fault"`
+	// Detail 故障说明
+	Detail string `json:"detail"`
+}
+
+// Diagnosis EtherCAT 诊断结果
+type Diagnosis struct {
+	// MasterState 主站的状态
+	MasterState string `json:"masterState"`
+	// SlaveCount 主站配置的从站数量
+	SlaveCount int `json:"slaveCount"`
+	// Slaves 从站的状态
+	Slaves []SlaveState `json:"slaves"`
+	// Faults 故障，没有故障时为空数组
+	Faults []Fault `json:"faults"`
+	// Objects CoE 对象名称->值
+	Objects map[string]interface{} `json:"objects,omitempty"`
+	// ObjectErrors 读取失败的 CoE 对象名称->错误
+	ObjectErrors map[string]string `json:"objectErrors,omitempty"`
+}
+
+// coeObject 解析后的 CoE 对象
+type coeObject struct {
+	name     string
+	slave    uint16
+	index    uint16
+	subIndex uint8
+	symbol   *Symbol
+}
+
+// EtherCATDiagNode 倍福 EtherCAT 诊断节点，通过 TwinCAT EtherCAT 主站的 ADS 接口读取主站状态、
+// 从站的 AL 状态、链路状态和端口 CRC 错误计数，并通过主站的 CoE 网关读取配置的从站对象，结果重新赋值到msg.Data：
+//
+//	{
+//	  "masterState": "OP",
+//	  "slaveCount": 2,
+//	  "slaves": [
+//	    {"address": 1001, "state": "OP", "error": false, "crcErrors": [0, 0, 0, 0]},
+//	    {"address": 1002, "state": "INIT", "error": true, "missingLink": true, "ports": ["A"], "crcErrors": [3, 0, 0, 0]}
+//	  ],
+//	  "faults": [
+//	    {"address": 1002, "fault": "wireBreak", "detail": "missing link on port A"}
+//	  ],
+//	  "objects": {"diagMessages": 2}
+//	}
+//
+// 故障类型见 FaultSlaveLost 等，CRC 错误按两次读取之间的增量判断，上次存在的从站不再出现时报告 slaveLost。
+// 故障数量放在元数据 faultCount。CoE 对象读取失败不影响诊断，错误放在 objectErrors。
+// 相同主站的节点共享一个连接。读取成功，流转到`Success`链，存在故障时同时流转到`Alert`链；读取失败，流转到`Failure`链
+type EtherCATDiagNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config EtherCATDiagConfiguration
+	// requiredState 要求的状态
+	requiredState byte
+	objects       []coeObject
+	// mu 保护上次读取的结果
+	mu sync.Mutex
+	// crcErrors 上次读取的从站地址->CRC 错误计数，也用于发现丢失的从站
+	crcErrors map[uint16][]uint32
+}
+
+// Type 返回组件类型
+func (x *EtherCATDiagNode) Type() string {
+	return "x/adsEthercatDiag"
+}
+
+// New 默认参数
+func (x *EtherCATDiagNode) New() types.Node {
+	return &EtherCATDiagNode{
+		Config: EtherCATDiagConfiguration{
+			Server:        DefaultServer,
+			TargetPort:    MasterPort,
+			SourcePort:    DefaultSourcePort,
+			Timeout:       5,
+			RequiredState: "OP",
+			MaxCrcErrors:  1,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *EtherCATDiagNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.TargetNetId == "" {
+		return fmt.Errorf("ethercat device ams net id is required")
+	}
+	if x.Config.RequiredState == "" {
+		x.Config.RequiredState = "OP"
+	}
+	if x.requiredState, err = ParseState(x.Config.RequiredState); err != nil {
+		return err
+	}
+	if x.Config.ExpectedSlaves < 0 || x.Config.MaxCrcErrors < 0 {
+		return fmt.Errorf("invalid expected slaves or max crc errors")
+	}
+	if x.objects, err = parseCoEObjects(x.Config.Objects); err != nil {
+		return err
+	}
+	x.crcErrors = map[uint16][]uint32{}
+	config, err := newClientConfig(x.Config.Server, x.Config.TargetNetId, x.Config.TargetPort, x.Config.SourceNetId, x.Config.SourcePort, x.Config.Timeout)
+	if err != nil {
+		return err
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), config)
+}
+
+// OnMsg 处理消息
+func (x *EtherCATDiagNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var diagnosis *Diagnosis
+	err = conn.Do(func(client *Client) error {
+		diagnosis, err = x.read(client)
+		return err
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(diagnosis)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	msg.Metadata.PutValue(KeyFaultCount, strconv.Itoa(len(diagnosis.Faults)))
+	if len(diagnosis.Faults) == 0 {
+		ctx.TellSuccess(msg)
+	} else {
+		ctx.TellNext(msg, types.Success, RelationAlert)
+	}
+}
+
+// Destroy 销毁组件
+func (x *EtherCATDiagNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *EtherCATDiagNode) Desc() string {
+	return "Beckhoff EtherCAT diagnostics through the TwinCAT master ADS interface: master and slave AL states, link states, CRC errors and CoE objects, reporting lost slaves and wire breaks. Routes to Success/Alert/Failure"
+}
+
+// read 读取主站和从站状态并判断故障
+func (x *EtherCATDiagNode) read(client *Client) (*Diagnosis, error) {
+	masterState, err := client.EtherCATMasterState()
+	if err != nil {
+		return nil, err
+	}
+	slaves, err := client.EtherCATSlaves()
+	if err != nil {
+		return nil, err
+	}
+	if x.Config.MaxCrcErrors > 0 {
+		for i := range slaves {
+			if slaves[i].NotPresent {
+				continue
+			}
+			if slaves[i].CrcErrors, err = client.EtherCATCrcErrors(slaves[i].Address); err != nil {
+				return nil, err
+			}
+		}
+	}
+	diagnosis := &Diagnosis{
+		MasterState: StateName(masterState),
+		SlaveCount:  len(slaves),
+		Slaves:      slaves,
+		Faults:      []Fault{},
+	}
+	if diagnosis.Slaves == nil {
+		diagnosis.Slaves = []SlaveState{}
+	}
+	if masterState&stateMask != x.requiredState {
+		diagnosis.Faults = append(diagnosis.Faults, Fault{
+			Fault:  FaultMasterState,
+			Detail: fmt.Sprintf("master is in %s, required %s", StateName(masterState), StateName(x.requiredState)),
+		})
+	}
+	if x.Config.ExpectedSlaves > 0 && len(slaves) < x.Config.ExpectedSlaves {
+		diagnosis.Faults = append(diagnosis.Faults, Fault{
+			Fault:  FaultSlaveLost,
+			Detail: fmt.Sprintf("%d of %d slaves found", len(slaves), x.Config.ExpectedSlaves),
+		})
+	}
+	x.mu.Lock()
+	previous := x.crcErrors
+	x.crcErrors = make(map[uint16][]uint32, len(slaves))
+	for _, s := range slaves {
+		diagnosis.Faults = append(diagnosis.Faults, x.slaveFaults(s, previous[s.Address])...)
+		x.crcErrors[s.Address] = s.CrcErrors
+		delete(previous, s.Address)
+	}
+	x.mu.Unlock()
+	for address := range previous {
+		diagnosis.Faults = append(diagnosis.Faults, Fault{Address: address, Fault: FaultSlaveLost, Detail: "slave disappeared"})
+	}
+	for _, o := range x.objects {
+		value, err := o.read(client)
+		if err != nil {
+			if diagnosis.ObjectErrors == nil {
+				diagnosis.ObjectErrors = map[string]string{}
+			}
+			diagnosis.ObjectErrors[o.name] = err.Error()
+			continue
+		}
+		if diagnosis.Objects == nil {
+			diagnosis.Objects = map[string]interface{}{}
+		}
+		diagnosis.Objects[o.name] = value
+	}
+	return diagnosis, nil
+}
+
+// slaveFaults 判断从站的故障，previous 为上次读取的 CRC 错误计数
+func (x *EtherCATDiagNode) slaveFaults(s SlaveState, previous []uint32) []Fault {
+	var faults []Fault
+	if s.NotPresent {
+		return append(faults, Fault{Address: s.Address, Fault: FaultSlaveLost, Detail: "slave not present"})
+	}
+	if s.MissingLink || s.LinkWithoutComm {
+		detail := "link without communication"
+		if s.MissingLink {
+			detail = "missing link"
+		}
+		if len(s.Ports) > 0 {
+			detail += " on port " + strings.Join(s.Ports, ",")
+		}
+		faults = append(faults, Fault{Address: s.Address, Fault: FaultWireBreak, Detail: detail})
+	}
+	if s.Error || s.InitCmdError || s.InvalidVprs {
+		var reasons []string
+		if s.Error {
+			reasons = append(reasons, "error flag set")
+		}
+		if s.InitCmdError {
+			reasons = append(reasons, "init command failed")
+		}
+		if s.InvalidVprs {
+			reasons = append(reasons, "vendor, product or revision mismatch")
+		}
+		faults = append(faults, Fault{Address: s.Address, Fault: FaultStateError, Detail: strings.Join(reasons, ", ")})
+	}
+	if !s.Disabled && s.Code() != x.requiredState {
+		faults = append(faults, Fault{
+			Address: s.Address,
+			Fault:   FaultNotOperational,
+			Detail:  fmt.Sprintf("slave is in %s, required %s", s.State, StateName(x.requiredState)),
+		})
+	}
+	if previous != nil && x.Config.MaxCrcErrors > 0 {
+		var increase uint32
+		for i, v := range s.CrcErrors {
+			// 计数被清零时按当前值计算
+			if i < len(previous) && v >= previous[i] {
+				increase += v - previous[i]
+			} else {
+				increase += v
+			}
+		}
+		if increase >= uint32(x.Config.MaxCrcErrors) {
+			faults = append(faults, Fault{Address: s.Address, Fault: FaultCrcErrors, Detail: fmt.Sprintf("%d new crc errors", increase)})
+		}
+	}
+	return faults
+}
+
+// read 通过 CoE 读取对象的值
+func (o coeObject) read(client *Client) (interface{}, error) {
+	data, err := client.ReadCoE(o.slave, o.index, o.subIndex, o.symbol.Size)
+	if err != nil {
+		return nil, err
+	}
+	// 字符串对象可能比配置的长度短
+	if o.symbol.DataType == DataTypeString && len(data) < o.symbol.Size {
+		data = append(data, make([]byte, o.symbol.Size-len(data))...)
+	}
+	return o.symbol.Decode(data)
+}
+
+// parseCoEObjects 解析 CoE 对象
+func parseCoEObjects(objects []CoEObject) ([]coeObject, error) {
+	result := make([]coeObject, 0, len(objects))
+	for _, o := range objects {
+		if o.Slave <= 0 || o.Slave > 0xffff {
+			return nil, fmt.Errorf("invalid ethercat slave address: %d", o.Slave)
+		}
+		index, err := strconv.ParseUint(strings.TrimSpace(o.Index), 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coe index: %s", o.Index)
+		}
+		if o.SubIndex < 0 || o.SubIndex > 0xff {
+			return nil, fmt.Errorf("invalid coe sub index: %d", o.SubIndex)
+		}
+		name := o.Name
+		if name == "" {
+			name = fmt.Sprintf("%d:0x%04X:%d", o.Slave, index, o.SubIndex)
+		}
+		sym := &Symbol{Name: name}
+		if err = sym.setType(o.DataType); err != nil {
+			return nil, err
+		}
+		result = append(result, coeObject{name: name, slave: uint16(o.Slave), index: uint16(index), subIndex: uint8(o.SubIndex), symbol: sym})
+	}
+	return result, nil
+}
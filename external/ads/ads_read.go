@@ -0,0 +1,250 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// Server TwinCAT 路由器地址，格式：host:port，端口默认 48898
+	Server string `json:"server" label:"Server" desc:"TwinCAT router address, format: host:port, port defaults to 48898" required:"true" ref:"primary"`
+	// TargetNetId 目标 AMS Net ID，为空使用 server 的 IP 地址加 .1.1
+	TargetNetId string `json:"targetNetId" label:"Target AMS Net ID" desc:"AMS Net ID of the TwinCAT runtime, empty uses the server IP plus .1.1"`
+	// TargetPort 目标 AMS 端口，TwinCAT 3 第1个 PLC 运行时为 851，TwinCAT 2 为 801
+	TargetPort int `json:"targetPort" label:"Target AMS Port" desc:"AMS port of the PLC runtime, 851 for the first TwinCAT 3 runtime, 801 for TwinCAT 2"`
+	// SourceNetId 客户端的 AMS Net ID，需要在 TwinCAT 中添加对应的路由，为空使用本机 IP 地址加 .1.1
+	SourceNetId string `json:"sourceNetId" label:"Source AMS Net ID" desc:"AMS Net ID of this client, a matching route is required in TwinCAT, empty uses the local IP plus .1.1"`
+	// SourcePort 客户端的 AMS 端口
+	SourcePort int `json:"sourcePort" label:"Source AMS Port" desc:"AMS port of this client"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Items 读取的变量，为空则使用消息负荷 msg.Data 中的变量
+	Items []Item `json:"items" label:"Items" desc:"Symbols to read, empty uses the symbols in msg.Data"`
+}
+
+// Item 读写的变量
+type Item struct {
+	// Name 变量名称，作为输出的 key，为空使用变量
+	Name string `json:"name,omitempty"`
+	// Symbol PLC 变量，eg. MAIN.temperature 或者 MAIN.temperature:REAL，见 ParseSymbol
+	Symbol string `json:"symbol"`
+}
+
+// ReadNode 倍福 ADS 读取节点，通过变量名称读取 TwinCAT 2/3 PLC 运行时的变量
+// 变量来自配置 items 或者消息负荷 msg.Data，格式：
+//
+//	[
+//	  {"name": "temperature", "symbol": "MAIN.temperature"},
+//	  {"name": "running", "symbol": "GVL.running:BOOL"}
+//	]
+//
+// 也可以是变量数组：["MAIN.temperature", "GVL.running"]。
+// 变量通过名称获取句柄后按句柄读取，未指定数据类型时通过 PLC 的符号信息获取，句柄和类型按连接缓存，
+// PLC 在线修改导致句柄失效时重新获取。结果以变量名称为 key 重新赋值到msg.Data：
+//
+//	{"temperature": 21.5, "running": true}
+//
+// 相同目标的节点共享一个连接。所有变量读取成功，流转到`Success`链，否则流转到`Failure`链
+type ReadNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config ReadConfiguration
+	// symbols 配置的变量解析后的结果
+	symbols []*Symbol
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/adsRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Server:     DefaultServer,
+			TargetPort: DefaultTargetPort,
+			SourcePort: DefaultSourcePort,
+			Timeout:    5,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.symbols, err = parseItems(x.Config.Items); err != nil {
+		return err
+	}
+	config, err := newClientConfig(x.Config.Server, x.Config.TargetNetId, x.Config.TargetPort, x.Config.SourceNetId, x.Config.SourcePort, x.Config.Timeout)
+	if err != nil {
+		return err
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), config)
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items, symbols := x.Config.Items, x.symbols
+	if len(items) == 0 {
+		var err error
+		if items, err = parseMsgItems(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if symbols, err = parseItems(items); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var values []interface{}
+	err = conn.Do(func(client *Client) error {
+		values, err = client.ReadSymbols(symbols)
+		return err
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{}, len(items))
+	for i, item := range items {
+		result[item.key()] = values[i]
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "Beckhoff ADS reader for TwinCAT PLC symbols by name, resolving handles and data types from the runtime. Routes to Success/Failure"
+}
+
+// key 输出的 key，名称为空使用变量
+func (i Item) key() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return i.Symbol
+}
+
+// newClientConfig 校验 AMS 地址并创建客户端配置
+func newClientConfig(server, targetNetId string, targetPort int, sourceNetId string, sourcePort int, timeout int64) (ClientConfig, error) {
+	for _, id := range []string{targetNetId, sourceNetId} {
+		if id == "" {
+			continue
+		}
+		if _, err := ParseNetId(id); err != nil {
+			return ClientConfig{}, err
+		}
+	}
+	for _, port := range []int{targetPort, sourcePort} {
+		if port < 0 || port > 0xffff {
+			return ClientConfig{}, fmt.Errorf("invalid ams port: %d", port)
+		}
+	}
+	return ClientConfig{
+		Server:      server,
+		TargetNetId: targetNetId,
+		TargetPort:  uint16(targetPort),
+		SourceNetId: sourceNetId,
+		SourcePort:  uint16(sourcePort),
+		Timeout:     time.Duration(timeout) * time.Second,
+	}, nil
+}
+
+// initSharedConn 初始化共享连接，相同目标的组件共用一个连接
+func initSharedConn(node *base.SharedNode[*SharedConn], ruleConfig types.Config, nodeType string, config ClientConfig) error {
+	return node.InitWithClose(ruleConfig, nodeType, config.Key(), ruleConfig.NodeClientInitNow, func() (*SharedConn, error) {
+		conn := AcquireConn(config)
+		if _, err := conn.Client(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
+
+// parseItems 解析变量
+func parseItems(items []Item) ([]*Symbol, error) {
+	symbols := make([]*Symbol, 0, len(items))
+	for _, item := range items {
+		s, err := ParseSymbol(item.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, s)
+	}
+	return symbols, nil
+}
+
+// parseMsgItems 解析消息负荷中的变量，支持变量对象数组或者变量名称数组
+func parseMsgItems(data string) ([]Item, error) {
+	data = strings.TrimSpace(data)
+	var items []Item
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		var list []string
+		if json.Unmarshal([]byte(data), &list) != nil {
+			return nil, err
+		}
+		items = nil
+		for _, symbol := range list {
+			items = append(items, Item{Symbol: symbol})
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no ads symbols")
+	}
+	return items, nil
+}
@@ -0,0 +1,368 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const (
+	// KeySymbol 数据变化消息中变量的元数据key
+	KeySymbol = "symbol"
+	// RelationSubscribed 订阅创建成功后触发消息流转的关系
+	RelationSubscribed = "Subscribed"
+	// DataMsgType 数据变化消息的类型
+	DataMsgType = "ADS_DATA"
+	// ModeOnChange 值变化时通知
+	ModeOnChange = "onChange"
+	// ModeCyclic 按周期通知
+	ModeCyclic = "cyclic"
+)
+
+// resubscribeInterval 检查共享连接是否重连的间隔
+var resubscribeInterval = 5 * time.Second
+
+func init() {
+	_ = rulego.Registry.Register(&SubscribeNode{})
+}
+
+// SubscribeConfiguration 订阅节点配置
+type SubscribeConfiguration struct {
+	// Server TwinCAT 路由器地址，格式：host:port，端口默认 48898
+	Server string `json:"server" label:"Server" desc:"TwinCAT router address, format: host:port, port defaults to 48898" required:"true" ref:"primary"`
+	// TargetNetId 目标 AMS Net ID，为空使用 server 的 IP 地址加 .1.1
+	TargetNetId string `json:"targetNetId" label:"Target AMS Net ID" desc:"AMS Net ID of the TwinCAT runtime, empty uses the server IP plus .1.1"`
+	// TargetPort 目标 AMS 端口，TwinCAT 3 第1个 PLC 运行时为 851，TwinCAT 2 为 801
+	TargetPort int `json:"targetPort" label:"Target AMS Port" desc:"AMS port of the PLC runtime, 851 for the first TwinCAT 3 runtime, 801 for TwinCAT 2"`
+	// SourceNetId 客户端的 AMS Net ID，需要在 TwinCAT 中添加对应的路由，为空使用本机 IP 地址加 .1.1
+	SourceNetId string `json:"sourceNetId" label:"Source AMS Net ID" desc:"AMS Net ID of this client, a matching route is required in TwinCAT, empty uses the local IP plus .1.1"`
+	// SourcePort 客户端的 AMS 端口
+	SourcePort int `json:"sourcePort" label:"Source AMS Port" desc:"AMS port of this client"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Items 订阅的变量，为空则使用消息负荷 msg.Data 中的变量
+	Items []Item `json:"items" label:"Items" desc:"Symbols to subscribe, empty uses the symbols in msg.Data"`
+	// Mode 通知方式：onChange 值变化时通知，cyclic 按周期通知
+	Mode string `json:"mode" label:"Mode" desc:"Notification mode: onChange or cyclic"`
+	// CycleTime 检查变化或者通知的周期，单位毫秒
+	CycleTime int `json:"cycleTime" label:"Cycle Time" desc:"Cycle in milliseconds to check for changes (onChange) or to notify (cyclic)"`
+	// MaxDelay 通知的最大延迟，单位毫秒，PLC 在该时间内合并多个通知
+	MaxDelay int `json:"maxDelay" label:"Max Delay" desc:"Max delay in milliseconds before the PLC sends buffered notifications"`
+}
+
+// SubscribeNode 倍福 ADS 订阅节点，通过 ADS 设备通知订阅 TwinCAT PLC 变量的变化
+// 收到消息后创建订阅，变量来自配置 items 或者消息负荷 msg.Data，格式同 x/adsRead。
+// 每个变量的通知作为新消息通过`Success`链发送到当前规则链，元数据 symbol 为变量名称，消息负荷格式：
+//
+//	{
+//	  "name": "temperature",
+//	  "symbol": "MAIN.temperature",
+//	  "value": 21.5,
+//	  "sourceTime": "2025-01-01T00:00:00Z",
+//	  "timestamp": "2025-01-01T00:00:00Z"
+//	}
+//
+// 再次收到消息会删除原有通知并按新的变量重新订阅，节点销毁时删除通知。共享连接重连后使用新的连接重新添加通知。
+// 订阅创建成功，触发消息流转到`Subscribed`链，失败流转到`Failure`链，订阅过程中的错误作为新消息流转到`Failure`链
+type SubscribeNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config SubscribeConfiguration
+	// mu 保护当前订阅
+	mu sync.Mutex
+	// cancel 停止当前订阅的通知处理协程，订阅由通知处理协程持有，退出时删除通知
+	cancel context.CancelFunc
+	// done 当前订阅的通知处理协程退出后关闭
+	done chan struct{}
+}
+
+// Type 返回组件类型
+func (x *SubscribeNode) Type() string {
+	return "x/adsSubscribe"
+}
+
+// New 默认参数
+func (x *SubscribeNode) New() types.Node {
+	return &SubscribeNode{
+		Config: SubscribeConfiguration{
+			Server:     DefaultServer,
+			TargetPort: DefaultTargetPort,
+			SourcePort: DefaultSourcePort,
+			Timeout:    5,
+			Mode:       ModeOnChange,
+			CycleTime:  100,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *SubscribeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if _, err = parseItems(x.Config.Items); err != nil {
+		return err
+	}
+	if _, err = x.transMode(); err != nil {
+		return err
+	}
+	config, err := newClientConfig(x.Config.Server, x.Config.TargetNetId, x.Config.TargetPort, x.Config.SourceNetId, x.Config.SourcePort, x.Config.Timeout)
+	if err != nil {
+		return err
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), config)
+}
+
+// OnMsg 处理消息
+func (x *SubscribeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items := x.Config.Items
+	if len(items) == 0 {
+		var err error
+		if items, err = parseMsgItems(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	symbols, err := parseItems(items)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if err = x.subscribe(ctx, conn, items, symbols); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellNext(msg, RelationSubscribed)
+}
+
+// sample 一个变量的通知
+type sample struct {
+	index     int
+	timestamp time.Time
+	data      []byte
+}
+
+// subscription 通知处理协程持有的订阅
+type subscription struct {
+	conn    *SharedConn
+	client  *Client
+	items   []Item
+	symbols []*Symbol
+	// resolved 确定数据类型后的变量
+	resolved      []*Symbol
+	notifications []uint32
+	done          <-chan struct{}
+	// mu 保护 queue
+	mu sync.Mutex
+	// queue 待处理的通知，回调在连接的读取协程中执行，不能阻塞，所以不使用有界的 channel
+	queue []sample
+	// signal 有新的通知
+	signal chan struct{}
+}
+
+// push 添加一个通知，在连接的读取协程中调用
+func (s *subscription) push(v sample) {
+	s.mu.Lock()
+	s.queue = append(s.queue, v)
+	s.mu.Unlock()
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// drain 取出所有待处理的通知
+func (s *subscription) drain() []sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := s.queue
+	s.queue = nil
+	return queue
+}
+
+// subscribe 删除原有订阅，创建新的订阅
+func (x *SubscribeNode) subscribe(ctx types.RuleContext, conn *SharedConn, items []Item, symbols []*Symbol) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.unsubscribeLocked()
+
+	c, cancel := context.WithCancel(context.Background())
+	s := &subscription{
+		conn:    conn,
+		items:   items,
+		symbols: symbols,
+		done:    c.Done(),
+		signal:  make(chan struct{}, 1),
+	}
+	if err := x.addNotifications(s); err != nil {
+		cancel()
+		return err
+	}
+	done := make(chan struct{})
+	x.cancel = cancel
+	x.done = done
+	// 数据变化消息使用订阅自己的 context，不受触发消息结束或超时的影响
+	notifyCtx := ctx.SetContext(c)
+	go func() {
+		defer close(done)
+		x.run(notifyCtx, s)
+	}()
+	return nil
+}
+
+// addNotifications 使用共享连接当前的客户端添加所有变量的通知，失败时删除已经添加的通知并清空客户端
+func (x *SubscribeNode) addNotifications(s *subscription) error {
+	mode, _ := x.transMode()
+	client, err := s.conn.Client()
+	if err != nil {
+		return err
+	}
+	s.client = client
+	s.resolved = make([]*Symbol, len(s.symbols))
+	s.notifications = nil
+	for i, sym := range s.symbols {
+		index := i
+		if s.resolved[i], err = client.Resolve(sym); err == nil {
+			var notification uint32
+			notification, err = client.AddNotification(s.resolved[i], mode,
+				time.Duration(x.Config.CycleTime)*time.Millisecond, time.Duration(x.Config.MaxDelay)*time.Millisecond,
+				func(timestamp time.Time, data []byte) {
+					s.push(sample{index: index, timestamp: timestamp, data: data})
+				})
+			if err == nil {
+				s.notifications = append(s.notifications, notification)
+			}
+		}
+		if err != nil {
+			// 下次检查时重试
+			s.deleteNotifications()
+			s.client = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteNotifications 删除已经添加的通知，连接已经断开时不需要删除
+func (s *subscription) deleteNotifications() {
+	if s.client != nil && !s.client.Closed() {
+		for _, notification := range s.notifications {
+			_ = s.client.DeleteNotification(notification)
+		}
+	}
+	s.notifications = nil
+}
+
+// run 处理通知，把变量的值发送到规则链，退出时删除通知
+func (x *SubscribeNode) run(ctx types.RuleContext, s *subscription) {
+	defer s.deleteNotifications()
+	ticker := time.NewTicker(resubscribeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.signal:
+			for _, v := range s.drain() {
+				x.handleSample(ctx, s, v)
+			}
+		case <-ticker.C:
+			// 连接断开后通知随旧连接失效，使用共享连接重建的客户端重新添加
+			if s.client == nil || s.client.Closed() {
+				if err := x.addNotifications(s); err != nil {
+					ctx.TellFailure(ctx.NewMsg(DataMsgType, types.NewMetadata(), ""), err)
+				}
+			}
+		}
+	}
+}
+
+// handleSample 发送一个变量的值
+func (x *SubscribeNode) handleSample(ctx types.RuleContext, s *subscription, v sample) {
+	item, sym := s.items[v.index], s.resolved[v.index]
+	value, err := sym.Decode(v.data)
+	if err != nil {
+		ctx.TellFailure(ctx.NewMsg(DataMsgType, types.NewMetadata(), ""), err)
+		return
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"name":       item.key(),
+		"symbol":     sym.Name,
+		"value":      value,
+		"sourceTime": v.timestamp,
+		"timestamp":  time.Now(),
+	})
+	if err != nil {
+		return
+	}
+	metadata := types.NewMetadata()
+	metadata.PutValue(KeySymbol, sym.Name)
+	ctx.TellNext(ctx.NewMsg(DataMsgType, metadata, string(b)), types.Success)
+}
+
+// unsubscribeLocked 停止当前订阅并等待通知处理协程退出，调用前需要持有 mu
+func (x *SubscribeNode) unsubscribeLocked() {
+	if x.cancel != nil {
+		x.cancel()
+		x.cancel = nil
+	}
+	if x.done != nil {
+		<-x.done
+		x.done = nil
+	}
+}
+
+// transMode ADS 传输模式
+func (x *SubscribeNode) transMode() (uint32, error) {
+	switch {
+	case x.Config.Mode == "" || strings.EqualFold(x.Config.Mode, ModeOnChange):
+		return TransModeOnChange, nil
+	case strings.EqualFold(x.Config.Mode, ModeCyclic):
+		return TransModeCyclic, nil
+	default:
+		return 0, fmt.Errorf("unsupported ads notification mode: %s", x.Config.Mode)
+	}
+}
+
+// Destroy 删除通知并释放连接
+func (x *SubscribeNode) Destroy() {
+	x.mu.Lock()
+	x.unsubscribeLocked()
+	x.mu.Unlock()
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *SubscribeNode) Desc() string {
+	return "Beckhoff ADS subscriber for TwinCAT PLC symbols using device notifications, each change is sent as a new message. Routes to Subscribed/Success/Failure"
+}
@@ -0,0 +1,491 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testSymbol 测试 PLC 的变量
+type testSymbol struct {
+	typeName string
+	data     []byte
+}
+
+// testNotification 测试 PLC 的设备通知
+type testNotification struct {
+	conn   *testConn
+	symbol string
+	header []byte
+}
+
+// testConn 测试 PLC 的连接，响应和通知可能并发写入
+type testConn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+func (c *testConn) send(frame []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = c.Write(frame)
+}
+
+// testPLC 测试用的 TwinCAT 运行时，实现变量句柄、符号信息、按句柄读写和设备通知
+type testPLC struct {
+	listener net.Listener
+	mu       sync.Mutex
+	symbols  map[string]*testSymbol
+	// handles 变量句柄->变量名称，在线修改后清空
+	handles       map[uint32]string
+	nextHandle    uint32
+	notifications map[uint32]*testNotification
+	// handleRequests 获取句柄的请求数量
+	handleRequests int
+	// source 最后一个请求的源 AMS 地址
+	source []byte
+}
+
+func startTestPLC(t *testing.T) *testPLC {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	plc := &testPLC{
+		listener:      listener,
+		handles:       map[uint32]string{},
+		notifications: map[uint32]*testNotification{},
+		symbols: map[string]*testSymbol{
+			"MAIN.temperature": {typeName: "REAL", data: binary.LittleEndian.AppendUint32(nil, math.Float32bits(21.5))},
+			"MAIN.running":     {typeName: "BOOL", data: []byte{1}},
+			"MAIN.counter":     {typeName: "DINT", data: binary.LittleEndian.AppendUint32(nil, 7)},
+			"MAIN.name":        {typeName: "STRING(20)", data: append([]byte("hello"), make([]byte, 16)...)},
+			"GVL.point":        {typeName: "ST_Point", data: make([]byte, 8)},
+		},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go plc.serve(&testConn{Conn: conn})
+		}
+	}()
+	return plc
+}
+
+func (p *testPLC) server() string {
+	return p.listener.Addr().String()
+}
+
+// onlineChange 模拟 PLC 在线修改，原有句柄失效
+func (p *testPLC) onlineChange() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handles = map[uint32]string{}
+}
+
+func (p *testPLC) serve(conn *testConn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, amsTCPHeaderSize+amsHeaderSize)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		data := make([]byte, binary.LittleEndian.Uint32(header[2:6])-amsHeaderSize)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return
+		}
+		ams := header[amsTCPHeaderSize:]
+		// 响应交换源地址和目标地址
+		respHeader := make([]byte, amsHeaderSize)
+		copy(respHeader[0:8], ams[8:16])
+		copy(respHeader[8:16], ams[0:8])
+		copy(respHeader[16:18], ams[16:18])
+		binary.LittleEndian.PutUint16(respHeader[18:20], flagResponse)
+		copy(respHeader[28:32], ams[28:32])
+		p.mu.Lock()
+		p.source = append([]byte(nil), ams[8:16]...)
+		p.mu.Unlock()
+		resp, next := p.handle(conn, binary.LittleEndian.Uint16(ams[16:18]), data, respHeader)
+		conn.send(frameOf(respHeader, resp))
+		if next != nil {
+			conn.send(next)
+		}
+	}
+}
+
+// frameOf 组装 AMS/TCP 报文
+func frameOf(header, data []byte) []byte {
+	header = append([]byte(nil), header...)
+	binary.LittleEndian.PutUint32(header[20:24], uint32(len(data)))
+	frame := binary.LittleEndian.AppendUint32([]byte{0, 0}, uint32(amsHeaderSize+len(data)))
+	frame = append(frame, header...)
+	return append(frame, data...)
+}
+
+// result ADS 结果码和数据
+func result(code uint32, data ...[]byte) []byte {
+	resp := binary.LittleEndian.AppendUint32(nil, code)
+	for _, d := range data {
+		resp = append(resp, d...)
+	}
+	return resp
+}
+
+func withLength(data []byte) []byte {
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data...)
+}
+
+// handle 处理一个请求，返回响应数据和需要在响应之后发送的报文
+func (p *testPLC) handle(conn *testConn, command uint16, data []byte, respHeader []byte) ([]byte, []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if command == cmdDeleteNotification {
+		delete(p.notifications, binary.LittleEndian.Uint32(data))
+		return result(0), nil
+	}
+	group, offset := binary.LittleEndian.Uint32(data[0:]), binary.LittleEndian.Uint32(data[4:])
+	switch command {
+	case cmdReadWrite:
+		name := string(data[16:])
+		sym, ok := p.symbols[name]
+		if !ok {
+			return result(0x710), nil
+		}
+		switch group {
+		case groupHandleByName:
+			p.handleRequests++
+			p.nextHandle++
+			p.handles[p.nextHandle] = name
+			return result(0, withLength(binary.LittleEndian.AppendUint32(nil, p.nextHandle))), nil
+		case groupInfoByNameEx:
+			info := make([]byte, 30)
+			binary.LittleEndian.PutUint32(info[12:], uint32(len(sym.data)))
+			binary.LittleEndian.PutUint16(info[24:], uint16(len(name)))
+			binary.LittleEndian.PutUint16(info[26:], uint16(len(sym.typeName)))
+			info = append(info[:28], append([]byte(name), 0)...)
+			info = append(info, append([]byte(sym.typeName), 0)...)
+			info = append(info, 0)
+			return result(0, withLength(info)), nil
+		}
+	case cmdRead:
+		name, ok := p.handles[offset]
+		if group != groupValueByHandle || !ok {
+			return result(0x710), nil
+		}
+		return result(0, withLength(p.symbols[name].data)), nil
+	case cmdWrite:
+		if group == groupReleaseHandle {
+			delete(p.handles, binary.LittleEndian.Uint32(data[12:]))
+			return result(0), nil
+		}
+		name, ok := p.handles[offset]
+		if group != groupValueByHandle || !ok {
+			return result(0x710), nil
+		}
+		copy(p.symbols[name].data, data[12:])
+		for handle, n := range p.notifications {
+			if n.symbol == name {
+				go n.conn.send(p.notification(n, handle))
+			}
+		}
+		return result(0), nil
+	case cmdAddNotification:
+		name, ok := p.handles[offset]
+		if group != groupValueByHandle || !ok {
+			return result(0x710), nil
+		}
+		header := append([]byte(nil), respHeader...)
+		binary.LittleEndian.PutUint16(header[16:18], cmdNotification)
+		binary.LittleEndian.PutUint16(header[18:20], flagRequest)
+		p.nextHandle++
+		n := &testNotification{conn: conn, symbol: name, header: header}
+		p.notifications[p.nextHandle] = n
+		// 添加后在响应之后立即发送当前值
+		return result(0, binary.LittleEndian.AppendUint32(nil, p.nextHandle)), p.notification(n, p.nextHandle)
+	}
+	return result(0x701), nil
+}
+
+// notification 设备通知报文，调用前需要持有 mu
+func (p *testPLC) notification(n *testNotification, handle uint32) []byte {
+	value := p.symbols[n.symbol].data
+	stamp := binary.LittleEndian.AppendUint64(nil, uint64(time.Now().UnixNano()/100+filetimeOffset))
+	stamp = binary.LittleEndian.AppendUint32(stamp, 1)
+	stamp = binary.LittleEndian.AppendUint32(stamp, handle)
+	stamp = binary.LittleEndian.AppendUint32(stamp, uint32(len(value)))
+	stamp = append(stamp, value...)
+	data := binary.LittleEndian.AppendUint32(nil, uint32(4+len(stamp)))
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	return frameOf(n.header, append(data, stamp...))
+}
+
+func (p *testPLC) notificationCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.notifications)
+}
+
+func TestReadWriteNode(t *testing.T) {
+	plc := startTestPLC(t)
+	defer plc.listener.Close()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	Registry.Add(&WriteNode{})
+
+	// 非法变量和 AMS 地址
+	_, err := test.CreateAndInitNode("x/adsRead", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"symbol": "MAIN.x:FOO"}},
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/adsRead", types.Configuration{
+		"server":      plc.server(),
+		"targetNetId": "1.2.3.4",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/adsRead", types.Configuration{
+		"server":      plc.server(),
+		"targetNetId": "5.1.2.3.1.1",
+		"sourceNetId": "10.0.0.1.1.1",
+		"sourcePort":  30000,
+		"items": []map[string]interface{}{
+			{"name": "temperature", "symbol": "MAIN.temperature"},
+			{"name": "running", "symbol": "MAIN.running:BOOL"},
+			{"name": "counter", "symbol": "MAIN.counter"},
+			{"name": "model", "symbol": "MAIN.name"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgs := []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}
+	test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 21.5, values["temperature"])
+		assert.Equal(t, true, values["running"])
+		assert.Equal(t, float64(7), values["counter"])
+		assert.Equal(t, "hello", values["model"])
+	})
+	plc.mu.Lock()
+	assert.Equal(t, []byte{10, 0, 0, 1, 1, 1, 0x30, 0x75}, plc.source)
+	assert.Equal(t, 4, plc.handleRequests)
+	plc.mu.Unlock()
+
+	// 句柄按连接缓存，在线修改后重新获取
+	plc.onlineChange()
+	test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+	plc.mu.Lock()
+	assert.Equal(t, 8, plc.handleRequests)
+	plc.mu.Unlock()
+
+	writer, err := test.CreateAndInitNode("x/adsWrite", types.Configuration{
+		"server": plc.server(),
+	}, Registry)
+	assert.Nil(t, err)
+	defer writer.Destroy()
+	templateWriter, err := test.CreateAndInitNode("x/adsWrite", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"symbol": "MAIN.temperature", "value": "${metadata.setpoint}"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer templateWriter.Destroy()
+
+	test.NodeOnMsg(t, writer, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "WRITE",
+			Data:       `{"MAIN.running": false, "MAIN.counter": -100, "MAIN.name": "world"}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "NOT_FOUND",
+			Data:       `{"MAIN.missing": 1}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "UNSUPPORTED",
+			Data:       `{"GVL.point": 1}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		switch msg.Type {
+		case "NOT_FOUND":
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, &Error{Symbol: "MAIN.missing", Code: 0x710}, err)
+		case "UNSUPPORTED":
+			assert.Equal(t, types.Failure, relationType)
+		default:
+			assert.Equal(t, types.Success, relationType)
+		}
+	})
+	metadata := types.NewMetadata()
+	metadata.PutValue("setpoint", "42.5")
+	test.NodeOnMsg(t, templateWriter, []test.Msg{{
+		MetaData:   metadata,
+		DataType:   types.JSON,
+		MsgType:    "WRITE",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+
+	test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 42.5, values["temperature"])
+		assert.Equal(t, false, values["running"])
+		assert.Equal(t, float64(-100), values["counter"])
+		assert.Equal(t, "world", values["model"])
+	})
+}
+
+func TestSubscribeNode(t *testing.T) {
+	plc := startTestPLC(t)
+	defer plc.listener.Close()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&SubscribeNode{})
+	_, err := test.CreateAndInitNode("x/adsSubscribe", types.Configuration{
+		"server": plc.server(),
+		"mode":   "fast",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/adsSubscribe", types.Configuration{
+		"server": plc.server(),
+	}, Registry)
+	assert.Nil(t, err)
+
+	var lock sync.Mutex
+	var values []float64
+	var subscribed int32
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "TEST",
+		Data:       `[{"name": "count", "symbol": "MAIN.counter"}]`,
+		AfterSleep: time.Millisecond * 300,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		if relationType == RelationSubscribed {
+			assert.Equal(t, "TEST", msg.Type)
+			atomic.AddInt32(&subscribed, 1)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, DataMsgType, msg.Type)
+		assert.Equal(t, "MAIN.counter", msg.Metadata.GetValue(KeySymbol))
+		var d struct {
+			Name  string  `json:"name"`
+			Value float64 `json:"value"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &d))
+		assert.Equal(t, "count", d.Name)
+		lock.Lock()
+		values = append(values, d.Value)
+		lock.Unlock()
+	})
+
+	// 其他连接写入后收到变化通知
+	client, err := Dial(ClientConfig{Server: plc.server(), SourcePort: 30001})
+	assert.Nil(t, err)
+	defer client.Close()
+	s, _ := ParseSymbol("MAIN.counter")
+	assert.Nil(t, client.WriteSymbol(s, 8))
+	time.Sleep(time.Millisecond * 300)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&subscribed))
+	lock.Lock()
+	assert.Equal(t, []float64{7, 8}, values)
+	lock.Unlock()
+	assert.Equal(t, 1, plc.notificationCount())
+
+	// 销毁后删除通知
+	node.Destroy()
+	assert.Equal(t, 0, plc.notificationCount())
+	assert.Nil(t, client.WriteSymbol(s, 9))
+	time.Sleep(time.Millisecond * 100)
+	lock.Lock()
+	assert.Equal(t, 2, len(values))
+	lock.Unlock()
+}
+
+func TestSubscribeResubscribe(t *testing.T) {
+	plc := startTestPLC(t)
+	defer plc.listener.Close()
+
+	conn := AcquireConn(ClientConfig{Server: plc.server(), Timeout: time.Second})
+	defer conn.Release()
+	old, err := conn.Client()
+	assert.Nil(t, err)
+
+	x := &SubscribeNode{Config: SubscribeConfiguration{Mode: ModeOnChange, CycleTime: 100}}
+	s := &subscription{
+		conn:    conn,
+		items:   []Item{{Symbol: "MAIN.counter"}},
+		symbols: []*Symbol{{Name: "MAIN.counter", raw: "MAIN.counter"}},
+		done:    make(chan struct{}),
+		signal:  make(chan struct{}, 1),
+	}
+	assert.Nil(t, x.addNotifications(s))
+	assert.True(t, s.client == old)
+	<-s.signal
+	assert.Equal(t, 1, len(s.drain()))
+
+	// 模拟连接断开后使用共享连接重建的客户端重新添加通知
+	_ = old.Close()
+	assert.Nil(t, x.addNotifications(s))
+	assert.True(t, s.client != old)
+	<-s.signal
+	samples := s.drain()
+	assert.Equal(t, 1, len(samples))
+	assert.True(t, bytes.Equal([]byte{7, 0, 0, 0}, samples[0].data))
+	s.deleteNotifications()
+	assert.Equal(t, 1, plc.notificationCount())
+}
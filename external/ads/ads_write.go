@@ -0,0 +1,182 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteConfiguration 写入节点配置
+type WriteConfiguration struct {
+	// Server TwinCAT 路由器地址，格式：host:port，端口默认 48898
+	Server string `json:"server" label:"Server" desc:"TwinCAT router address, format: host:port, port defaults to 48898" required:"true" ref:"primary"`
+	// TargetNetId 目标 AMS Net ID，为空使用 server 的 IP 地址加 .1.1
+	TargetNetId string `json:"targetNetId" label:"Target AMS Net ID" desc:"AMS Net ID of the TwinCAT runtime, empty uses the server IP plus .1.1"`
+	// TargetPort 目标 AMS 端口，TwinCAT 3 第1个 PLC 运行时为 851，TwinCAT 2 为 801
+	TargetPort int `json:"targetPort" label:"Target AMS Port" desc:"AMS port of the PLC runtime, 851 for the first TwinCAT 3 runtime, 801 for TwinCAT 2"`
+	// SourceNetId 客户端的 AMS Net ID，需要在 TwinCAT 中添加对应的路由，为空使用本机 IP 地址加 .1.1
+	SourceNetId string `json:"sourceNetId" label:"Source AMS Net ID" desc:"AMS Net ID of this client, a matching route is required in TwinCAT, empty uses the local IP plus .1.1"`
+	// SourcePort 客户端的 AMS 端口
+	SourcePort int `json:"sourcePort" label:"Source AMS Port" desc:"AMS port of this client"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Items 写入的变量，为空则使用消息负荷 msg.Data 中的变量
+	Items []WriteItem `json:"items" label:"Items" desc:"Symbols to write, empty uses the symbol-value object in msg.Data"`
+}
+
+// WriteItem 写入的变量
+type WriteItem struct {
+	// Symbol PLC 变量，eg. MAIN.setpoint 或者 MAIN.setpoint:REAL，见 ParseSymbol
+	Symbol string `json:"symbol"`
+	// Value 写入的值，允许使用 ${} 占位符变量
+	Value string `json:"value"`
+}
+
+// WriteNode 倍福 ADS 写入节点，通过变量名称写入 TwinCAT 2/3 PLC 运行时的变量
+// 变量来自配置 items，值允许使用 ${} 占位符变量，或者消息负荷 msg.Data 中变量->值的对象：
+//
+//	{"MAIN.setpoint": 21.5, "GVL.start": true, "MAIN.recipe:STRING(20)": "A1"}
+//
+// 未指定数据类型时通过 PLC 的符号信息获取，相同目标的节点共享一个连接。
+// 所有变量写入成功，流转到`Success`链，否则流转到`Failure`链
+type WriteNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config WriteConfiguration
+	// symbols 配置的变量解析后的结果
+	symbols []*Symbol
+	// valueTemplates 配置的变量值模板
+	valueTemplates []str.Template
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/adsWrite"
+}
+
+// New 默认参数
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteConfiguration{
+			Server:     DefaultServer,
+			TargetPort: DefaultTargetPort,
+			SourcePort: DefaultSourcePort,
+			Timeout:    5,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.symbols = nil
+	x.valueTemplates = nil
+	for _, item := range x.Config.Items {
+		s, err := ParseSymbol(item.Symbol)
+		if err != nil {
+			return err
+		}
+		x.symbols = append(x.symbols, s)
+		x.valueTemplates = append(x.valueTemplates, str.NewTemplate(item.Value))
+	}
+	config, err := newClientConfig(x.Config.Server, x.Config.TargetNetId, x.Config.TargetPort, x.Config.SourceNetId, x.Config.SourcePort, x.Config.Timeout)
+	if err != nil {
+		return err
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), config)
+}
+
+// OnMsg 处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	symbols, values, err := x.getValues(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	err = conn.Do(func(client *Client) error {
+		for i, s := range symbols {
+			if err := client.WriteSymbol(s, values[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// getValues 获取写入的变量和值
+func (x *WriteNode) getValues(ctx types.RuleContext, msg types.RuleMsg) ([]*Symbol, []interface{}, error) {
+	if len(x.symbols) > 0 {
+		evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		values := make([]interface{}, 0, len(x.symbols))
+		for i := range x.symbols {
+			values = append(values, x.valueTemplates[i].Execute(evn))
+		}
+		return x.symbols, values, nil
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(msg.GetData()), &data); err != nil {
+		return nil, nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("no ads symbols to write")
+	}
+	symbols := make([]*Symbol, 0, len(data))
+	values := make([]interface{}, 0, len(data))
+	for symbol, value := range data {
+		s, err := ParseSymbol(symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		symbols = append(symbols, s)
+		values = append(values, value)
+	}
+	return symbols, values, nil
+}
+
+// Destroy 销毁组件
+func (x *WriteNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Beckhoff ADS writer for TwinCAT PLC symbols by name, resolving handles and data types from the runtime. Routes to Success/Failure"
+}
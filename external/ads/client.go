@@ -0,0 +1,679 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPort AMS/TCP 端口
+	DefaultPort = "48898"
+	// DefaultServer 默认 TwinCAT 地址
+	DefaultServer = "127.0.0.1:48898"
+	// DefaultTargetPort TwinCAT 3 第1个 PLC 运行时的 AMS 端口，TwinCAT 2 为 801
+	DefaultTargetPort = 851
+	// DefaultSourcePort 客户端的 AMS 端口
+	DefaultSourcePort = 32905
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 5 * time.Second
+)
+
+// ADS 命令
+const (
+	cmdRead               = 2
+	cmdWrite              = 3
+	cmdAddNotification    = 6
+	cmdDeleteNotification = 7
+	cmdNotification       = 8
+	cmdReadWrite          = 9
+)
+
+// ADS 索引组
+const (
+	groupHandleByName  = 0xf003
+	groupValueByHandle = 0xf005
+	groupReleaseHandle = 0xf006
+	groupInfoByNameEx  = 0xf009
+)
+
+// 通知的传输模式
+const (
+	// TransModeCyclic 按周期发送
+	TransModeCyclic = 3
+	// TransModeOnChange 值变化时发送，周期为检查变化的周期
+	TransModeOnChange = 4
+)
+
+const (
+	amsTCPHeaderSize = 6
+	amsHeaderSize    = 32
+	flagRequest      = 0x0004
+	flagResponse     = 0x0005
+	maxFrameSize     = 16 * 1024 * 1024
+	// filetimeOffset 1601-01-01 到 1970-01-01 的 100 纳秒数
+	filetimeOffset = 116444736000000000
+)
+
+// ErrClosed 连接已经关闭
+var ErrClosed = errors.New("ads connection is closed")
+
+// Error ADS 错误码
+type Error struct {
+	Symbol string
+	Code   uint32
+}
+
+func (e *Error) Error() string {
+	var reason string
+	switch e.Code {
+	case 0x006:
+		reason = "target port not found"
+	case 0x007:
+		reason = "target machine not found, check the route"
+	case 0x701:
+		reason = "service not supported"
+	case 0x702:
+		reason = "invalid index group"
+	case 0x703:
+		reason = "invalid index offset"
+	case 0x704:
+		reason = "reading or writing not permitted"
+	case 0x705:
+		reason = "parameter size not correct"
+	case 0x706, 0x70b:
+		reason = "invalid parameter value"
+	case 0x707:
+		reason = "device not in ready state"
+	case 0x710:
+		reason = "symbol not found"
+	case 0x711:
+		reason = "symbol version invalid"
+	case 0x714:
+		reason = "invalid notification handle"
+	case 0x716:
+		reason = "no more notification handles"
+	case 0x745:
+		reason = "timeout"
+	default:
+		reason = "error"
+	}
+	if e.Symbol != "" {
+		return fmt.Sprintf("ads %s: %s (0x%x)", e.Symbol, reason, e.Code)
+	}
+	return fmt.Sprintf("ads: %s (0x%x)", reason, e.Code)
+}
+
+// AmsAddr AMS 地址
+type AmsAddr struct {
+	NetId [6]byte
+	Port  uint16
+}
+
+// ParseNetId 解析 AMS Net ID，格式：a.b.c.d.e.f
+func ParseNetId(s string) ([6]byte, error) {
+	var id [6]byte
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) != 6 {
+		return id, fmt.Errorf("invalid ams net id: %s", s)
+	}
+	for i, p := range parts {
+		v, err := strconv.ParseUint(p, 10, 8)
+		if err != nil {
+			return id, fmt.Errorf("invalid ams net id: %s", s)
+		}
+		id[i] = byte(v)
+	}
+	return id, nil
+}
+
+// ClientConfig 创建 ADS 客户端的配置
+type ClientConfig struct {
+	// Server TwinCAT 路由器地址，格式：host:port，端口默认 48898
+	Server string
+	// TargetNetId 目标 AMS Net ID，为空使用 server 的 IP 地址加 .1.1
+	TargetNetId string
+	// TargetPort 目标 AMS 端口
+	TargetPort uint16
+	// SourceNetId 客户端的 AMS Net ID，需要在 TwinCAT 中添加对应的路由，为空使用本机 IP 地址加 .1.1
+	SourceNetId string
+	// SourcePort 客户端的 AMS 端口
+	SourcePort uint16
+	// Timeout 连接和请求超时
+	Timeout time.Duration
+}
+
+// Key 共享连接的 key，格式：server/targetNetId:targetPort/sourceNetId:sourcePort
+func (c ClientConfig) Key() string {
+	return fmt.Sprintf("%s/%s:%d/%s:%d", c.address(), c.TargetNetId, c.TargetPort, c.SourceNetId, c.SourcePort)
+}
+
+// address 补全端口的路由器地址
+func (c ClientConfig) address() string {
+	server := strings.TrimPrefix(c.Server, "tcp://")
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, DefaultPort)
+	}
+	return server
+}
+
+// netIdOf 配置的 Net ID，为空使用 IP 地址加 .1.1
+func netIdOf(s string, addr net.Addr) ([6]byte, error) {
+	if s != "" {
+		return ParseNetId(s)
+	}
+	if a, ok := addr.(*net.TCPAddr); ok {
+		if ip := a.IP.To4(); ip != nil {
+			return [6]byte{ip[0], ip[1], ip[2], ip[3], 1, 1}, nil
+		}
+	}
+	return [6]byte{}, fmt.Errorf("ams net id is required for %s", addr)
+}
+
+// response 请求的响应
+type response struct {
+	data []byte
+	err  error
+}
+
+// call 等待响应的请求
+type call struct {
+	ch chan response
+	// onResponse 在读取协程中处理成功的响应，用于在后续的设备通知到达前注册回调
+	onResponse func(data []byte)
+}
+
+// NotificationFunc 设备通知的回调，在连接的读取协程中调用，不能阻塞
+type NotificationFunc func(timestamp time.Time, data []byte)
+
+// Client ADS 客户端，一个 AMS/TCP 连接上可以并发请求，设备通知由读取协程分发
+// 连接断开时关闭客户端，由调用方重新建立
+type Client struct {
+	conn    net.Conn
+	target  AmsAddr
+	source  AmsAddr
+	timeout time.Duration
+	// writeMu 保护连接写入
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	// invokeId 请求的 invoke id
+	invokeId uint32
+	// pending invoke id->等待响应的请求
+	pending map[uint32]*call
+	// notifications 通知句柄->回调
+	notifications map[uint32]NotificationFunc
+	// handles 变量名称->变量句柄
+	handles map[string]uint32
+	// symbols 变量名称->PLC 符号信息获取的变量
+	symbols map[string]*Symbol
+	closed  bool
+	done    chan struct{}
+}
+
+// Dial 连接 TwinCAT 路由器
+func Dial(config ClientConfig) (*Client, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	conn, err := net.DialTimeout("tcp", config.address(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:          conn,
+		timeout:       timeout,
+		target:        AmsAddr{Port: config.TargetPort},
+		source:        AmsAddr{Port: config.SourcePort},
+		pending:       map[uint32]*call{},
+		notifications: map[uint32]NotificationFunc{},
+		handles:       map[string]uint32{},
+		symbols:       map[string]*Symbol{},
+		done:          make(chan struct{}),
+	}
+	if c.target.Port == 0 {
+		c.target.Port = DefaultTargetPort
+	}
+	if c.source.Port == 0 {
+		c.source.Port = DefaultSourcePort
+	}
+	if c.target.NetId, err = netIdOf(config.TargetNetId, conn.RemoteAddr()); err == nil {
+		c.source.NetId, err = netIdOf(config.SourceNetId, conn.LocalAddr())
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// Closed 连接是否已经关闭
+func (c *Client) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close 关闭连接，等待中的请求返回 ErrClosed
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// readLoop 读取响应和设备通知，连接断开时关闭客户端
+func (c *Client) readLoop() {
+	defer func() { _ = c.Close() }()
+	header := make([]byte, amsTCPHeaderSize+amsHeaderSize)
+	for {
+		if _, err := io.ReadFull(c.conn, header[:amsTCPHeaderSize]); err != nil {
+			return
+		}
+		length := binary.LittleEndian.Uint32(header[2:6])
+		if length < amsHeaderSize || length > maxFrameSize {
+			return
+		}
+		if _, err := io.ReadFull(c.conn, header[amsTCPHeaderSize:]); err != nil {
+			return
+		}
+		data := make([]byte, length-amsHeaderSize)
+		if _, err := io.ReadFull(c.conn, data); err != nil {
+			return
+		}
+		ams := header[amsTCPHeaderSize:]
+		command := binary.LittleEndian.Uint16(ams[16:18])
+		flags := binary.LittleEndian.Uint16(ams[18:20])
+		errorCode := binary.LittleEndian.Uint32(ams[24:28])
+		invokeId := binary.LittleEndian.Uint32(ams[28:32])
+		if command == cmdNotification && flags == flagRequest {
+			c.dispatch(data)
+			continue
+		}
+		c.mu.Lock()
+		pending, ok := c.pending[invokeId]
+		delete(c.pending, invokeId)
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		if errorCode != 0 {
+			pending.ch <- response{err: &Error{Code: errorCode}}
+			continue
+		}
+		if pending.onResponse != nil && len(data) >= 4 && binary.LittleEndian.Uint32(data) == 0 {
+			pending.onResponse(data[4:])
+		}
+		pending.ch <- response{data: data}
+	}
+}
+
+// dispatch 分发设备通知
+func (c *Client) dispatch(data []byte) {
+	if len(data) < 8 {
+		return
+	}
+	stamps := int(binary.LittleEndian.Uint32(data[4:8]))
+	r := bytes.NewReader(data[8:])
+	for i := 0; i < stamps; i++ {
+		var stamp struct {
+			Timestamp uint64
+			Samples   uint32
+		}
+		if binary.Read(r, binary.LittleEndian, &stamp) != nil {
+			return
+		}
+		timestamp := time.Unix(0, int64(stamp.Timestamp-filetimeOffset)*100)
+		for j := 0; j < int(stamp.Samples); j++ {
+			var sample struct {
+				Handle uint32
+				Size   uint32
+			}
+			if binary.Read(r, binary.LittleEndian, &sample) != nil || int(sample.Size) > r.Len() {
+				return
+			}
+			value := make([]byte, sample.Size)
+			_, _ = io.ReadFull(r, value)
+			c.mu.Lock()
+			fn := c.notifications[sample.Handle]
+			c.mu.Unlock()
+			if fn != nil {
+				fn(timestamp, value)
+			}
+		}
+	}
+}
+
+// request 发送请求并等待响应，返回去掉 ADS 结果码的数据
+func (c *Client) request(command uint16, data []byte) ([]byte, error) {
+	return c.requestWith(command, data, nil)
+}
+
+// requestWith 发送请求并等待响应，onResponse 在读取协程中处理成功的响应
+func (c *Client) requestWith(command uint16, data []byte, onResponse func(data []byte)) ([]byte, error) {
+	return c.requestTo(c.target.Port, command, data, onResponse)
+}
+
+// requestTo 向目标 Net ID 的指定 AMS 端口发送请求并等待响应
+func (c *Client) requestTo(port uint16, command uint16, data []byte, onResponse func(data []byte)) ([]byte, error) {
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	c.invokeId++
+	invokeId := c.invokeId
+	c.pending[invokeId] = &call{ch: ch, onResponse: onResponse}
+	c.mu.Unlock()
+
+	frame := make([]byte, amsTCPHeaderSize+amsHeaderSize, amsTCPHeaderSize+amsHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(frame[2:6], uint32(amsHeaderSize+len(data)))
+	ams := frame[amsTCPHeaderSize:]
+	copy(ams[0:6], c.target.NetId[:])
+	binary.LittleEndian.PutUint16(ams[6:8], port)
+	copy(ams[8:14], c.source.NetId[:])
+	binary.LittleEndian.PutUint16(ams[14:16], c.source.Port)
+	binary.LittleEndian.PutUint16(ams[16:18], command)
+	binary.LittleEndian.PutUint16(ams[18:20], flagRequest)
+	binary.LittleEndian.PutUint32(ams[20:24], uint32(len(data)))
+	binary.LittleEndian.PutUint32(ams[28:32], invokeId)
+	frame = append(frame, data...)
+
+	c.writeMu.Lock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write(frame)
+	c.writeMu.Unlock()
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		if resp.err != nil {
+			return nil, resp.err
+		}
+		if len(resp.data) < 4 {
+			return nil, fmt.Errorf("ads: short response")
+		}
+		if code := binary.LittleEndian.Uint32(resp.data); code != 0 {
+			return nil, &Error{Code: code}
+		}
+		return resp.data[4:], nil
+	case <-c.done:
+		return nil, ErrClosed
+	case <-timer.C:
+		c.mu.Lock()
+		delete(c.pending, invokeId)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("ads: request timeout after %v", c.timeout)
+	}
+}
+
+// Read 按索引组和索引偏移读取
+func (c *Client) Read(group, offset uint32, length int) ([]byte, error) {
+	return c.ReadPort(c.target.Port, group, offset, length)
+}
+
+// ReadPort 按索引组和索引偏移读取目标 Net ID 上其它 AMS 端口的数据，
+// eg. EtherCAT 主站设备上端口为从站地址的 CoE 对象
+func (c *Client) ReadPort(port uint16, group, offset uint32, length int) ([]byte, error) {
+	req := make([]byte, 12)
+	binary.LittleEndian.PutUint32(req[0:], group)
+	binary.LittleEndian.PutUint32(req[4:], offset)
+	binary.LittleEndian.PutUint32(req[8:], uint32(length))
+	resp, err := c.requestTo(port, cmdRead, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return readData(resp)
+}
+
+// Write 按索引组和索引偏移写入
+func (c *Client) Write(group, offset uint32, data []byte) error {
+	req := make([]byte, 12, 12+len(data))
+	binary.LittleEndian.PutUint32(req[0:], group)
+	binary.LittleEndian.PutUint32(req[4:], offset)
+	binary.LittleEndian.PutUint32(req[8:], uint32(len(data)))
+	_, err := c.request(cmdWrite, append(req, data...))
+	return err
+}
+
+// ReadWrite 写入数据并读取结果
+func (c *Client) ReadWrite(group, offset uint32, readLength int, data []byte) ([]byte, error) {
+	req := make([]byte, 16, 16+len(data))
+	binary.LittleEndian.PutUint32(req[0:], group)
+	binary.LittleEndian.PutUint32(req[4:], offset)
+	binary.LittleEndian.PutUint32(req[8:], uint32(readLength))
+	binary.LittleEndian.PutUint32(req[12:], uint32(len(data)))
+	resp, err := c.request(cmdReadWrite, append(req, data...))
+	if err != nil {
+		return nil, err
+	}
+	return readData(resp)
+}
+
+// readData 解析读取响应中的长度和数据
+func readData(resp []byte) ([]byte, error) {
+	if len(resp) < 4 {
+		return nil, fmt.Errorf("ads: short response")
+	}
+	length := int(binary.LittleEndian.Uint32(resp))
+	if 4+length > len(resp) {
+		return nil, fmt.Errorf("ads: short response")
+	}
+	return resp[4 : 4+length], nil
+}
+
+// Handle 获取变量句柄，句柄按变量名称缓存
+func (c *Client) Handle(name string) (uint32, error) {
+	c.mu.Lock()
+	handle, ok := c.handles[name]
+	c.mu.Unlock()
+	if ok {
+		return handle, nil
+	}
+	resp, err := c.ReadWrite(groupHandleByName, 0, 4, []byte(name))
+	if err != nil {
+		return 0, withSymbol(err, name)
+	}
+	if len(resp) < 4 {
+		return 0, fmt.Errorf("ads %s: invalid handle response", name)
+	}
+	handle = binary.LittleEndian.Uint32(resp)
+	c.mu.Lock()
+	c.handles[name] = handle
+	c.mu.Unlock()
+	return handle, nil
+}
+
+// releaseHandle 释放变量句柄，PLC 在线修改后句柄失效时调用
+func (c *Client) releaseHandle(name string) {
+	c.mu.Lock()
+	handle, ok := c.handles[name]
+	delete(c.handles, name)
+	c.mu.Unlock()
+	if ok {
+		_ = c.Write(groupReleaseHandle, 0, binary.LittleEndian.AppendUint32(nil, handle))
+	}
+}
+
+// Resolve 确定变量的数据类型，未指定数据类型的变量通过 PLC 的符号信息获取，结果按变量名称缓存
+func (c *Client) Resolve(sym *Symbol) (*Symbol, error) {
+	if sym.resolved() {
+		return sym, nil
+	}
+	c.mu.Lock()
+	resolved, ok := c.symbols[sym.Name]
+	c.mu.Unlock()
+	if ok {
+		return resolved, nil
+	}
+	// 符号信息：长度、索引组、索引偏移、大小、数据类型、标志、名称长度、类型名称长度、注释长度、名称、类型名称、注释
+	resp, err := c.ReadWrite(groupInfoByNameEx, 0, 0xffff, []byte(sym.Name))
+	if err != nil {
+		return nil, withSymbol(err, sym.Name)
+	}
+	if len(resp) < 30 {
+		return nil, fmt.Errorf("ads %s: invalid symbol info", sym.Name)
+	}
+	size := int(binary.LittleEndian.Uint32(resp[12:16]))
+	nameLength := int(binary.LittleEndian.Uint16(resp[24:26]))
+	typeLength := int(binary.LittleEndian.Uint16(resp[26:28]))
+	if 30+nameLength+typeLength > len(resp) {
+		return nil, fmt.Errorf("ads %s: invalid symbol info", sym.Name)
+	}
+	typeName := string(resp[29+nameLength : 29+nameLength+typeLength])
+	resolved = &Symbol{Name: sym.Name, raw: sym.raw}
+	if err = resolved.setType(typeName); err != nil {
+		return nil, err
+	}
+	if resolved.Size != size {
+		return nil, fmt.Errorf("ads %s: unexpected size %d of %s", sym.Name, size, typeName)
+	}
+	c.mu.Lock()
+	c.symbols[sym.Name] = resolved
+	c.mu.Unlock()
+	return resolved, nil
+}
+
+// withHandle 使用变量句柄执行 fn，PLC 在线修改后句柄失效时重新获取句柄并重试一次
+func (c *Client) withHandle(sym *Symbol, fn func(handle uint32) error) error {
+	for retry := 0; ; retry++ {
+		handle, err := c.Handle(sym.Name)
+		if err != nil {
+			return err
+		}
+		err = fn(handle)
+		var adsErr *Error
+		if retry == 0 && errors.As(err, &adsErr) && (adsErr.Code == 0x710 || adsErr.Code == 0x711) {
+			c.releaseHandle(sym.Name)
+			continue
+		}
+		return withSymbol(err, sym.Name)
+	}
+}
+
+// ReadSymbols 按变量名称读取多个变量，返回的值与 symbols 一一对应
+func (c *Client) ReadSymbols(symbols []*Symbol) ([]interface{}, error) {
+	values := make([]interface{}, len(symbols))
+	for i, s := range symbols {
+		sym, err := c.Resolve(s)
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		err = c.withHandle(sym, func(handle uint32) error {
+			data, err = c.Read(groupValueByHandle, handle, sym.Size)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if values[i], err = sym.Decode(data); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// WriteSymbol 按变量名称写入一个变量，value 为编码前的值
+func (c *Client) WriteSymbol(s *Symbol, value interface{}) error {
+	sym, err := c.Resolve(s)
+	if err != nil {
+		return err
+	}
+	data, err := sym.Encode(value)
+	if err != nil {
+		return err
+	}
+	return c.withHandle(sym, func(handle uint32) error {
+		return c.Write(groupValueByHandle, handle, data)
+	})
+}
+
+// AddNotification 添加变量的设备通知，mode 为 TransModeCyclic 或者 TransModeOnChange，
+// cycle 为发送或者检查变化的周期，maxDelay 为最大延迟。返回通知句柄，不再使用时需要调用 DeleteNotification
+func (c *Client) AddNotification(s *Symbol, mode uint32, cycle, maxDelay time.Duration, fn NotificationFunc) (uint32, error) {
+	sym, err := c.Resolve(s)
+	if err != nil {
+		return 0, err
+	}
+	var notification uint32
+	err = c.withHandle(sym, func(handle uint32) error {
+		req := make([]byte, 40)
+		binary.LittleEndian.PutUint32(req[0:], groupValueByHandle)
+		binary.LittleEndian.PutUint32(req[4:], handle)
+		binary.LittleEndian.PutUint32(req[8:], uint32(sym.Size))
+		binary.LittleEndian.PutUint32(req[12:], mode)
+		// 单位为 100 纳秒
+		binary.LittleEndian.PutUint32(req[16:], uint32(maxDelay/100))
+		binary.LittleEndian.PutUint32(req[20:], uint32(cycle/100))
+		// 添加后 PLC 立即发送第一个通知，在读取协程中注册回调
+		resp, err := c.requestWith(cmdAddNotification, req, func(data []byte) {
+			if len(data) >= 4 {
+				c.mu.Lock()
+				c.notifications[binary.LittleEndian.Uint32(data)] = fn
+				c.mu.Unlock()
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if len(resp) < 4 {
+			return fmt.Errorf("ads: short response")
+		}
+		notification = binary.LittleEndian.Uint32(resp)
+		return nil
+	})
+	return notification, err
+}
+
+// DeleteNotification 删除设备通知
+func (c *Client) DeleteNotification(notification uint32) error {
+	c.mu.Lock()
+	delete(c.notifications, notification)
+	c.mu.Unlock()
+	_, err := c.request(cmdDeleteNotification, binary.LittleEndian.AppendUint32(nil, notification))
+	return err
+}
+
+// withSymbol 为 ADS 错误添加变量名称
+func withSymbol(err error, name string) error {
+	var adsErr *Error
+	if errors.As(err, &adsErr) && adsErr.Symbol == "" {
+		return &Error{Symbol: name, Code: adsErr.Code}
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import "github.com/rulego/rulego-components-iot/pkg/sharedconn"
+
+// healthComponent 上报健康状态使用的组件名称
+const healthComponent = "ads"
+
+// conns 全局共享连接，key 见 ClientConfig.Key
+var conns = sharedconn.NewPool[*Client](healthComponent, ErrClosed)
+
+// SharedConn 按 TwinCAT 路由器和 AMS 地址共享的 ADS 连接
+// 相同目标的多个节点共用一个连接，避免每个节点占用一个 AMS/TCP 连接
+// 连接的超时配置以第一个获取连接的组件为准
+type SharedConn = sharedconn.Conn[*Client]
+
+// AcquireConn 获取 PLC 对应的共享连接，不存在则创建，引用计数加1
+// 连接在第一次使用时打开，不再使用时需要调用 Release
+func AcquireConn(config ClientConfig) *SharedConn {
+	return conns.Acquire(config.Key(), func() (*Client, error) {
+		return Dial(config)
+	})
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// MasterPort EtherCAT 主站设备的 AMS 端口，从站的 AMS 端口为从站的 EtherCAT 地址
+const MasterPort = 0xffff
+
+// EtherCAT 主站设备的 ADS 索引组，Net ID 为 TwinCAT 中 EtherCAT 设备的 Net ID，eg. 5.1.2.3.3.1
+const (
+	// groupMasterState 主站状态，WORD
+	groupMasterState = 0x03
+	// groupSlaveCount 从站数量，WORD
+	groupSlaveCount = 0x06
+	// groupSlaveAddresses 从站地址，每个从站一个 WORD
+	groupSlaveAddresses = 0x07
+	// groupSlaveStates 从站状态，每个从站两个字节：AL 状态、链路状态
+	groupSlaveStates = 0x09
+	// groupSlaveCrc 从站端口 A-D 的 CRC 错误计数，索引偏移为从站地址，每个端口一个 DWORD
+	groupSlaveCrc = 0x12
+	// groupCoESdo 从站的 CoE SDO 上传，索引偏移为 index<<16|subIndex
+	groupCoESdo = 0xf302
+)
+
+// EtherCAT 状态机的状态，AL 状态的低4位
+const (
+	StateInit   = 0x01
+	StatePreOp  = 0x02
+	StateBoot   = 0x03
+	StateSafeOp = 0x04
+	StateOp     = 0x08
+	stateMask   = 0x0f
+)
+
+// AL 状态的标志位
+const (
+	// stateError 从站处于错误状态
+	stateError = 0x10
+	// stateInvalidVprs 厂商、产品、版本号与配置不一致
+	stateInvalidVprs = 0x20
+	// stateInitCmdError 初始化命令执行失败
+	stateInitCmdError = 0x40
+	// stateDisabled 从站已禁用
+	stateDisabled = 0x80
+)
+
+// 链路状态的标志位
+const (
+	// linkNotPresent 从站不存在
+	linkNotPresent = 0x01
+	// linkWithoutComm 有物理链路但没有通信
+	linkWithoutComm = 0x02
+	// linkMissing 应有的链路缺失，即断线
+	linkMissing = 0x04
+	// linkAdditional 存在配置之外的链路
+	linkAdditional = 0x08
+)
+
+// linkPorts 链路状态中出问题的端口
+var linkPorts = []struct {
+	flag byte
+	name string
+}{{0x10, "A"}, {0x20, "B"}, {0x40, "C"}, {0x80, "D"}}
+
+// stateNames 状态名称
+var stateNames = map[byte]string{
+	StateInit:   "INIT",
+	StatePreOp:  "PREOP",
+	StateBoot:   "BOOT",
+	StateSafeOp: "SAFEOP",
+	StateOp:     "OP",
+}
+
+// StateName 返回 EtherCAT 状态名称，eg. OP，未知的状态返回 UNKNOWN(0x..)
+func StateName(state byte) string {
+	if name, ok := stateNames[state&stateMask]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN(0x%x)", state&stateMask)
+}
+
+// ParseState 解析状态名称，不区分大小写
+func ParseState(name string) (byte, error) {
+	for state, n := range stateNames {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return state, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid ethercat state: %s", name)
+}
+
+// SlaveState EtherCAT 从站的状态
+type SlaveState struct {
+	// Address 从站的 EtherCAT 地址，eg. 1001
+	Address uint16 `json:"address"`
+	// State 状态机的状态，eg. OP
+	State string `json:"state"`
+	// Error 从站处于错误状态，AL 状态码需要通过从站诊断读取
+	Error bool `json:"error"`
+	// InvalidVprs 厂商、产品、版本号与配置不一致
+	InvalidVprs bool `json:"invalidVprs,omitempty"`
+	// InitCmdError 初始化命令执行失败
+	InitCmdError bool `json:"initCmdError,omitempty"`
+	// Disabled 从站已禁用
+	Disabled bool `json:"disabled,omitempty"`
+	// NotPresent 从站不存在
+	NotPresent bool `json:"notPresent,omitempty"`
+	// LinkWithoutComm 有物理链路但没有通信
+	LinkWithoutComm bool `json:"linkWithoutComm,omitempty"`
+	// MissingLink 应有的链路缺失
+	MissingLink bool `json:"missingLink,omitempty"`
+	// AdditionalLink 存在配置之外的链路
+	AdditionalLink bool `json:"additionalLink,omitempty"`
+	// Ports 链路状态异常的端口，eg. ["B"]
+	Ports []string `json:"ports,omitempty"`
+	// CrcErrors 端口 A-D 的 CRC 错误计数
+	CrcErrors []uint32 `json:"crcErrors,omitempty"`
+	// code AL 状态
+	code byte
+}
+
+// DecodeSlaveState 解码主站返回的从站 AL 状态和链路状态
+func DecodeSlaveState(address uint16, state, link byte) SlaveState {
+	s := SlaveState{
+		Address:         address,
+		State:           StateName(state),
+		Error:           state&stateError != 0,
+		InvalidVprs:     state&stateInvalidVprs != 0,
+		InitCmdError:    state&stateInitCmdError != 0,
+		Disabled:        state&stateDisabled != 0,
+		NotPresent:      link&linkNotPresent != 0,
+		LinkWithoutComm: link&linkWithoutComm != 0,
+		MissingLink:     link&linkMissing != 0,
+		AdditionalLink:  link&linkAdditional != 0,
+		code:            state & stateMask,
+	}
+	for _, p := range linkPorts {
+		if link&p.flag != 0 {
+			s.Ports = append(s.Ports, p.name)
+		}
+	}
+	return s
+}
+
+// LinkOk 链路是否正常
+func (s SlaveState) LinkOk() bool {
+	return !s.NotPresent && !s.LinkWithoutComm && !s.MissingLink
+}
+
+// Code 状态机的状态，见 StateOp 等
+func (s SlaveState) Code() byte {
+	return s.code
+}
+
+// EtherCATMasterState 读取 EtherCAT 主站的状态，连接的目标端口需要为 MasterPort
+func (c *Client) EtherCATMasterState() (byte, error) {
+	data, err := c.Read(groupMasterState, 0, 2)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 2 {
+		return 0, fmt.Errorf("ads: short ethercat master state")
+	}
+	return byte(binary.LittleEndian.Uint16(data)), nil
+}
+
+// EtherCATSlaves 读取 EtherCAT 主站配置的所有从站的地址、AL 状态和链路状态，连接的目标端口需要为 MasterPort
+func (c *Client) EtherCATSlaves() ([]SlaveState, error) {
+	data, err := c.Read(groupSlaveCount, 0, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, fmt.Errorf("ads: short ethercat slave count")
+	}
+	count := int(binary.LittleEndian.Uint16(data))
+	if count == 0 {
+		return nil, nil
+	}
+	addresses, err := c.Read(groupSlaveAddresses, 0, count*2)
+	if err != nil {
+		return nil, err
+	}
+	states, err := c.Read(groupSlaveStates, 0, count*2)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) < count*2 || len(states) < count*2 {
+		return nil, fmt.Errorf("ads: short ethercat slave states")
+	}
+	slaves := make([]SlaveState, count)
+	for i := range slaves {
+		slaves[i] = DecodeSlaveState(binary.LittleEndian.Uint16(addresses[i*2:]), states[i*2], states[i*2+1])
+	}
+	return slaves, nil
+}
+
+// EtherCATCrcErrors 读取从站端口 A-D 的 CRC 错误计数，连接的目标端口需要为 MasterPort
+func (c *Client) EtherCATCrcErrors(address uint16) ([]uint32, error) {
+	data, err := c.Read(groupSlaveCrc, uint32(address), 16)
+	if err != nil {
+		return nil, err
+	}
+	counters := make([]uint32, len(data)/4)
+	for i := range counters {
+		counters[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+	return counters, nil
+}
+
+// ReadCoE 通过主站的 CoE 网关读取从站的对象字典，address 为从站的 EtherCAT 地址
+func (c *Client) ReadCoE(address uint16, index uint16, subIndex uint8, length int) ([]byte, error) {
+	return c.ReadPort(address, groupCoESdo, uint32(index)<<16|uint32(subIndex), length)
+}
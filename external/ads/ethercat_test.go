@@ -0,0 +1,269 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testMaster 测试用的 EtherCAT 主站设备，实现主站的诊断索引组和从站的 CoE 读取
+type testMaster struct {
+	listener net.Listener
+	mu       sync.Mutex
+	state    byte
+	// slaves 从站地址、AL 状态、链路状态
+	slaves [][3]uint16
+	crc    map[uint16][]uint32
+	// coe 从站地址->索引偏移->数据
+	coe map[uint16]map[uint32][]byte
+}
+
+func startTestMaster(t *testing.T) *testMaster {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	m := &testMaster{
+		listener: listener,
+		state:    StateOp,
+		slaves:   [][3]uint16{{1001, StateOp, 0}, {1002, StateOp, 0}},
+		crc:      map[uint16][]uint32{1001: {0, 0, 0, 0}, 1002: {0, 0, 0, 0}},
+		coe: map[uint16]map[uint32][]byte{
+			1001: {0x10f30002: {5}, 0x10080000: []byte("EL1008")},
+		},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	return m
+}
+
+func (m *testMaster) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, amsTCPHeaderSize+amsHeaderSize)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		data := make([]byte, binary.LittleEndian.Uint32(header[2:6])-amsHeaderSize)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return
+		}
+		ams := header[amsTCPHeaderSize:]
+		respHeader := make([]byte, amsHeaderSize)
+		copy(respHeader[0:8], ams[8:16])
+		copy(respHeader[8:16], ams[0:8])
+		copy(respHeader[16:18], ams[16:18])
+		binary.LittleEndian.PutUint16(respHeader[18:20], flagResponse)
+		copy(respHeader[28:32], ams[28:32])
+		resp := result(0x701)
+		if binary.LittleEndian.Uint16(ams[16:18]) == cmdRead {
+			resp = m.read(binary.LittleEndian.Uint16(ams[6:8]), binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint32(data[4:]))
+		}
+		_, _ = conn.Write(frameOf(respHeader, resp))
+	}
+}
+
+// read 处理读取请求，port 为目标 AMS 端口
+func (m *testMaster) read(port uint16, group, offset uint32) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if port != MasterPort {
+		value, ok := m.coe[port][offset]
+		if group != groupCoESdo || !ok {
+			return result(0x6)
+		}
+		return result(0, withLength(value))
+	}
+	var data []byte
+	switch group {
+	case groupMasterState:
+		data = binary.LittleEndian.AppendUint16(nil, uint16(m.state))
+	case groupSlaveCount:
+		data = binary.LittleEndian.AppendUint16(nil, uint16(len(m.slaves)))
+	case groupSlaveAddresses:
+		for _, s := range m.slaves {
+			data = binary.LittleEndian.AppendUint16(data, s[0])
+		}
+	case groupSlaveStates:
+		for _, s := range m.slaves {
+			data = append(data, byte(s[1]), byte(s[2]))
+		}
+	case groupSlaveCrc:
+		counters, ok := m.crc[uint16(offset)]
+		if !ok {
+			return result(0x703)
+		}
+		for _, v := range counters {
+			data = binary.LittleEndian.AppendUint32(data, v)
+		}
+	default:
+		return result(0x702)
+	}
+	return result(0, withLength(data))
+}
+
+func TestDecodeSlaveState(t *testing.T) {
+	s := DecodeSlaveState(1001, StateOp, 0)
+	assert.Equal(t, "OP", s.State)
+	assert.True(t, s.LinkOk())
+	assert.False(t, s.Error)
+
+	s = DecodeSlaveState(1002, StateSafeOp|stateError, linkMissing|0x20)
+	assert.Equal(t, "SAFEOP", s.State)
+	assert.Equal(t, byte(StateSafeOp), s.Code())
+	assert.True(t, s.Error)
+	assert.True(t, s.MissingLink)
+	assert.False(t, s.LinkOk())
+	assert.Equal(t, []string{"B"}, s.Ports)
+
+	assert.Equal(t, "UNKNOWN(0x7)", StateName(0x07))
+	state, err := ParseState("preop")
+	assert.Nil(t, err)
+	assert.Equal(t, byte(StatePreOp), state)
+	_, err = ParseState("RUN")
+	assert.NotNil(t, err)
+}
+
+func TestEtherCATDiagNode(t *testing.T) {
+	master := startTestMaster(t)
+	defer master.listener.Close()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&EtherCATDiagNode{})
+
+	// 缺少设备 Net ID、非法状态和 CoE 对象
+	_, err := test.CreateAndInitNode("x/adsEthercatDiag", types.Configuration{
+		"server": master.listener.Addr().String(),
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/adsEthercatDiag", types.Configuration{
+		"server":        master.listener.Addr().String(),
+		"targetNetId":   "5.1.2.3.3.1",
+		"requiredState": "RUN",
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/adsEthercatDiag", types.Configuration{
+		"server":      master.listener.Addr().String(),
+		"targetNetId": "5.1.2.3.3.1",
+		"objects":     []map[string]interface{}{{"slave": 1001, "index": "0xZZ", "dataType": "USINT"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/adsEthercatDiag", types.Configuration{
+		"server":         master.listener.Addr().String(),
+		"targetNetId":    "5.1.2.3.3.1",
+		"expectedSlaves": 2,
+		"objects": []map[string]interface{}{
+			{"name": "newMessages", "slave": 1001, "index": "0x10F3", "subIndex": 2, "dataType": "USINT"},
+			{"name": "deviceName", "slave": 1001, "index": "0x1008", "dataType": "STRING(20)"},
+			{"name": "missing", "slave": 1002, "index": "0x10F3", "subIndex": 2, "dataType": "USINT"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	// run 发送一条消息，返回触发的关系、诊断结果和输出消息
+	run := func() (map[string]int, Diagnosis, types.RuleMsg) {
+		var mu sync.Mutex
+		relations := map[string]int{}
+		var diagnosis Diagnosis
+		var out types.RuleMsg
+		msgs := []test.Msg{{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "DIAG",
+			Data:       `{}`,
+			AfterSleep: time.Millisecond * 200,
+		}}
+		test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			relations[relationType]++
+			out = msg
+			_ = json.Unmarshal([]byte(msg.GetData()), &diagnosis)
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		return relations, diagnosis, out
+	}
+
+	// 正常
+	relations, diagnosis, msg := run()
+	assert.Equal(t, map[string]int{types.Success: 1}, relations)
+	assert.Equal(t, "OP", diagnosis.MasterState)
+	assert.Equal(t, 2, diagnosis.SlaveCount)
+	assert.Equal(t, 0, len(diagnosis.Faults))
+	assert.Equal(t, "0", msg.Metadata.GetValue(KeyFaultCount))
+	assert.Equal(t, []uint32{0, 0, 0, 0}, diagnosis.Slaves[0].CrcErrors)
+	assert.Equal(t, float64(5), diagnosis.Objects["newMessages"])
+	assert.Equal(t, "EL1008", diagnosis.Objects["deviceName"])
+	assert.NotEqual(t, "", diagnosis.ObjectErrors["missing"])
+
+	// 1002 断线并进入错误状态，1001 的 CRC 错误计数增加
+	master.mu.Lock()
+	master.slaves[1] = [3]uint16{1002, StateInit | stateError, linkMissing | 0x10}
+	master.crc[1001] = []uint32{0, 2, 0, 0}
+	master.mu.Unlock()
+	relations, diagnosis, _ = run()
+	assert.Equal(t, map[string]int{types.Success: 1, RelationAlert: 1}, relations)
+	faults := map[string]Fault{}
+	for _, f := range diagnosis.Faults {
+		faults[f.Fault] = f
+	}
+	assert.Equal(t, 4, len(diagnosis.Faults))
+	assert.Equal(t, uint16(1002), faults[FaultWireBreak].Address)
+	assert.Equal(t, "missing link on port A", faults[FaultWireBreak].Detail)
+	assert.Equal(t, uint16(1002), faults[FaultStateError].Address)
+	assert.Equal(t, uint16(1002), faults[FaultNotOperational].Address)
+	assert.Equal(t, uint16(1001), faults[FaultCrcErrors].Address)
+
+	// CRC 错误按增量判断，1002 从主站中消失
+	master.mu.Lock()
+	master.slaves = master.slaves[:1]
+	master.mu.Unlock()
+	relations, diagnosis, _ = run()
+	assert.Equal(t, map[string]int{types.Success: 1, RelationAlert: 1}, relations)
+	assert.Equal(t, 2, len(diagnosis.Faults))
+	for _, f := range diagnosis.Faults {
+		assert.Equal(t, FaultSlaveLost, f.Fault)
+	}
+
+	// 主站不在 OP
+	master.mu.Lock()
+	master.state = StateSafeOp
+	master.slaves = [][3]uint16{{1001, StateOp, 0}, {1002, StateOp, 0}}
+	master.mu.Unlock()
+	relations, diagnosis, msg = run()
+	assert.Equal(t, map[string]int{types.Success: 1, RelationAlert: 1}, relations)
+	assert.Equal(t, "1", msg.Metadata.GetValue(KeyFaultCount))
+	assert.Equal(t, FaultMasterState, diagnosis.Faults[0].Fault)
+}
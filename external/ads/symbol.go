@@ -0,0 +1,246 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// 数据类型，与 TwinCAT 的 IEC 61131-3 类型名称一致
+const (
+	DataTypeBool   = "BOOL"
+	DataTypeByte   = "BYTE"
+	DataTypeUSInt  = "USINT"
+	DataTypeSInt   = "SINT"
+	DataTypeWord   = "WORD"
+	DataTypeUInt   = "UINT"
+	DataTypeInt    = "INT"
+	DataTypeDWord  = "DWORD"
+	DataTypeUDInt  = "UDINT"
+	DataTypeDInt   = "DINT"
+	DataTypeReal   = "REAL"
+	DataTypeLWord  = "LWORD"
+	DataTypeULInt  = "ULINT"
+	DataTypeLInt   = "LINT"
+	DataTypeLReal  = "LREAL"
+	DataTypeTime   = "TIME"
+	DataTypeString = "STRING"
+)
+
+// defaultStringLength STRING 未指定长度时的字符数
+const defaultStringLength = 80
+
+// typeSizes 数据类型占用的字节数
+var typeSizes = map[string]int{
+	DataTypeBool:  1,
+	DataTypeByte:  1,
+	DataTypeUSInt: 1,
+	DataTypeSInt:  1,
+	DataTypeWord:  2,
+	DataTypeUInt:  2,
+	DataTypeInt:   2,
+	DataTypeDWord: 4,
+	DataTypeUDInt: 4,
+	DataTypeDInt:  4,
+	DataTypeReal:  4,
+	DataTypeTime:  4,
+	DataTypeLWord: 8,
+	DataTypeULInt: 8,
+	DataTypeLInt:  8,
+	DataTypeLReal: 8,
+}
+
+var stringTypePattern = regexp.MustCompile(`^STRING(?:[(\[](\d+)[)\]])?$`)
+
+// Symbol PLC 变量
+type Symbol struct {
+	// Name 变量名称，eg. MAIN.temperature、GVL.values[1]
+	Name string
+	// DataType 数据类型，为空表示需要通过 PLC 的符号信息获取
+	DataType string
+	// Size 占用的字节数
+	Size int
+	raw  string
+}
+
+// ParseSymbol 解析变量，格式：<变量名称>[:<数据类型>]，eg. MAIN.temperature:REAL、GVL.name:STRING(20)
+// 未指定数据类型时，首次读写前通过 PLC 的符号信息获取，见 Client.Resolve
+func ParseSymbol(s string) (*Symbol, error) {
+	raw := strings.TrimSpace(s)
+	name, dataType, _ := strings.Cut(raw, ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("ads symbol name can not be empty: %s", raw)
+	}
+	sym := &Symbol{Name: name, raw: raw}
+	if dataType == "" {
+		return sym, nil
+	}
+	if err := sym.setType(dataType); err != nil {
+		return nil, err
+	}
+	return sym, nil
+}
+
+// setType 设置数据类型和大小
+func (s *Symbol) setType(dataType string) error {
+	dataType = strings.ToUpper(strings.TrimSpace(dataType))
+	if m := stringTypePattern.FindStringSubmatch(dataType); m != nil {
+		length := defaultStringLength
+		if m[1] != "" {
+			var err error
+			if length, err = strconv.Atoi(m[1]); err != nil || length == 0 || length > 255 {
+				return fmt.Errorf("invalid ads string length: %s", dataType)
+			}
+		}
+		// 以 0 结束
+		s.DataType, s.Size = DataTypeString, length+1
+		return nil
+	}
+	size, ok := typeSizes[dataType]
+	if !ok {
+		return fmt.Errorf("unsupported ads data type %s of %s", dataType, s.Name)
+	}
+	s.DataType, s.Size = dataType, size
+	return nil
+}
+
+// String 返回原始变量
+func (s *Symbol) String() string {
+	return s.raw
+}
+
+// resolved 是否已经确定数据类型
+func (s *Symbol) resolved() bool {
+	return s.DataType != ""
+}
+
+// Decode 把 PLC 字节（小端序）解码为值
+func (s *Symbol) Decode(data []byte) (interface{}, error) {
+	if len(data) < s.Size {
+		return nil, fmt.Errorf("ads symbol %s: short data", s.Name)
+	}
+	switch s.DataType {
+	case DataTypeBool:
+		return data[0] != 0, nil
+	case DataTypeByte, DataTypeUSInt:
+		return data[0], nil
+	case DataTypeSInt:
+		return int8(data[0]), nil
+	case DataTypeWord, DataTypeUInt:
+		return binary.LittleEndian.Uint16(data), nil
+	case DataTypeInt:
+		return int16(binary.LittleEndian.Uint16(data)), nil
+	case DataTypeDWord, DataTypeUDInt:
+		return binary.LittleEndian.Uint32(data), nil
+	case DataTypeDInt:
+		return int32(binary.LittleEndian.Uint32(data)), nil
+	case DataTypeTime:
+		return (time.Duration(binary.LittleEndian.Uint32(data)) * time.Millisecond).String(), nil
+	case DataTypeReal:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data)), nil
+	case DataTypeLWord, DataTypeULInt:
+		return binary.LittleEndian.Uint64(data), nil
+	case DataTypeLInt:
+		return int64(binary.LittleEndian.Uint64(data)), nil
+	case DataTypeLReal:
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+	case DataTypeString:
+		str := data[:s.Size]
+		for i, b := range str {
+			if b == 0 {
+				str = str[:i]
+				break
+			}
+		}
+		return string(str), nil
+	default:
+		return nil, fmt.Errorf("unsupported ads data type %s of %s", s.DataType, s.Name)
+	}
+}
+
+// Encode 把值编码为 PLC 字节（小端序）
+func (s *Symbol) Encode(value interface{}) ([]byte, error) {
+	data := make([]byte, s.Size)
+	switch s.DataType {
+	case DataTypeBool:
+		v, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, fmt.Errorf("ads symbol %s: %w", s.Name, err)
+		}
+		if v {
+			data[0] = 1
+		}
+		return data, nil
+	case DataTypeString:
+		str := cast.ToString(value)
+		if len(str) > s.Size-1 {
+			str = str[:s.Size-1]
+		}
+		copy(data, str)
+		return data, nil
+	case DataTypeTime:
+		// 支持 Go 的 duration 格式或者毫秒数
+		if str, ok := value.(string); ok {
+			if d, err := time.ParseDuration(str); err == nil {
+				binary.LittleEndian.PutUint32(data, uint32(d/time.Millisecond))
+				return data, nil
+			}
+		}
+	case DataTypeLWord, DataTypeULInt, DataTypeLInt:
+		// 64位整数不经过 float64 转换，避免丢失精度
+		v, err := cast.ToInt64E(value)
+		if err != nil {
+			return nil, fmt.Errorf("ads symbol %s: %w", s.Name, err)
+		}
+		binary.LittleEndian.PutUint64(data, uint64(v))
+		return data, nil
+	}
+	v, err := cast.ToFloat64E(value)
+	if err != nil {
+		return nil, fmt.Errorf("ads symbol %s: %w", s.Name, err)
+	}
+	switch s.DataType {
+	case DataTypeByte, DataTypeUSInt:
+		data[0] = byte(int64(v))
+	case DataTypeSInt:
+		data[0] = byte(int8(v))
+	case DataTypeWord, DataTypeUInt:
+		binary.LittleEndian.PutUint16(data, uint16(int64(v)))
+	case DataTypeInt:
+		binary.LittleEndian.PutUint16(data, uint16(int16(v)))
+	case DataTypeDWord, DataTypeUDInt, DataTypeTime:
+		binary.LittleEndian.PutUint32(data, uint32(int64(v)))
+	case DataTypeDInt:
+		binary.LittleEndian.PutUint32(data, uint32(int32(v)))
+	case DataTypeReal:
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(v)))
+	case DataTypeLReal:
+		binary.LittleEndian.PutUint64(data, math.Float64bits(v))
+	default:
+		return nil, fmt.Errorf("unsupported ads data type %s of %s", s.DataType, s.Name)
+	}
+	return data, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ads
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseSymbol(t *testing.T) {
+	s, err := ParseSymbol("MAIN.temperature:REAL")
+	assert.Nil(t, err)
+	assert.Equal(t, "MAIN.temperature", s.Name)
+	assert.Equal(t, DataTypeReal, s.DataType)
+	assert.Equal(t, 4, s.Size)
+
+	s, err = ParseSymbol(" GVL.values[1] ")
+	assert.Nil(t, err)
+	assert.Equal(t, "GVL.values[1]", s.Name)
+	assert.False(t, s.resolved())
+
+	s, err = ParseSymbol("MAIN.name:string(20)")
+	assert.Nil(t, err)
+	assert.Equal(t, DataTypeString, s.DataType)
+	assert.Equal(t, 21, s.Size)
+
+	s, err = ParseSymbol("MAIN.name:STRING")
+	assert.Nil(t, err)
+	assert.Equal(t, 81, s.Size)
+
+	for _, v := range []string{"", ":REAL", "MAIN.x:FOO", "MAIN.x:STRING(0)", "MAIN.x:STRING(300)"} {
+		_, err = ParseSymbol(v)
+		assert.NotNil(t, err, v)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	cases := []struct {
+		symbol string
+		value  interface{}
+		want   interface{}
+	}{
+		{"x:BOOL", true, true},
+		{"x:BYTE", 200, byte(200)},
+		{"x:SINT", -3, int8(-3)},
+		{"x:INT", -100, int16(-100)},
+		{"x:WORD", 0xabcd, uint16(0xabcd)},
+		{"x:DINT", -70000, int32(-70000)},
+		{"x:UDINT", 70000, uint32(70000)},
+		{"x:REAL", 21.5, float32(21.5)},
+		{"x:LINT", "-9007199254740993", int64(-9007199254740993)},
+		{"x:LREAL", 1.25, 1.25},
+		{"x:TIME", "1.5s", "1.5s"},
+		{"x:TIME", 250, "250ms"},
+		{"x:STRING(4)", "hello", "hell"},
+	}
+	for _, c := range cases {
+		s, err := ParseSymbol(c.symbol)
+		assert.Nil(t, err)
+		data, err := s.Encode(c.value)
+		assert.Nil(t, err)
+		assert.Equal(t, s.Size, len(data))
+		v, err := s.Decode(data)
+		assert.Nil(t, err)
+		assert.Equal(t, c.want, v, c.symbol)
+	}
+
+	s, _ := ParseSymbol("x:DINT")
+	data, _ := s.Encode(0x12345678)
+	assert.Equal(t, []byte{0x78, 0x56, 0x34, 0x12}, data)
+	_, err := s.Encode("abc")
+	assert.NotNil(t, err)
+}
+
+func TestParseNetId(t *testing.T) {
+	id, err := ParseNetId("5.1.204.160.1.1")
+	assert.Nil(t, err)
+	assert.Equal(t, [6]byte{5, 1, 204, 160, 1, 1}, id)
+	for _, v := range []string{"", "5.1.204.160.1", "5.1.204.160.1.256", "a.b.c.d.e.f"} {
+		_, err = ParseNetId(v)
+		assert.NotNil(t, err, v)
+	}
+}
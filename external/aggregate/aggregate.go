@@ -0,0 +1,231 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package aggregate 提供时序数据降采样节点
+// 节点按时间窗口缓存点位值，窗口结束时输出每个点位的最小值、最大值、平均值、最后值、数量和标准差等聚合值，
+// 用于在上传到云端之前降低 OPC UA 高频订阅等数据源的数据量
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// AggregateMsgType 聚合结果消息的类型
+	AggregateMsgType = "AGGREGATE"
+	// KeyWindowStart 窗口开始时间元数据key，RFC3339 格式
+	KeyWindowStart = "windowStart"
+	// KeyWindowEnd 窗口结束时间元数据key，RFC3339 格式
+	KeyWindowEnd = "windowEnd"
+	// KeyGroup 分组元数据key
+	KeyGroup = "group"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&AggregateNode{})
+}
+
+// AggregateConfiguration 降采样节点配置
+type AggregateConfiguration struct {
+	// Window 窗口长度，eg. 10s、1m
+	Window string `json:"window" label:"Window" desc:"Window length, eg. 10s, 1m" required:"true"`
+	// Align 窗口按整点对齐，eg. 1m 的窗口从每分钟的0秒开始，否则从收到第一条消息开始
+	Align bool `json:"align" label:"Align" desc:"Align windows to wall clock multiples of the window length, otherwise windows start at the first message"`
+	// Aggregates 输出的聚合值：min、max、avg、sum、first、last、count、stddev，为空输出 min、max、avg、last、count
+	Aggregates []string `json:"aggregates" label:"Aggregates" desc:"Aggregates to emit: min, max, avg, sum, first, last, count, stddev. Empty emits min, max, avg, last, count"`
+	// NameField 数组格式中点位名称的字段
+	NameField string `json:"nameField" label:"Name Field" desc:"Field of the tag name when msg.Data is an array, eg. nodeId for OPC UA read results"`
+	// ValueField 数组格式中点位值的字段
+	ValueField string `json:"valueField" label:"Value Field" desc:"Field of the tag value when msg.Data is an array"`
+	// GroupBy 分组，每个分组输出一条消息，eg. ${metadata.deviceId}，为空不分组。支持 ${metadata.key} 占位符
+	GroupBy string `json:"groupBy" label:"Group By" desc:"Group key, one message is emitted per group, eg. ${metadata.deviceId}. Empty disables grouping"`
+}
+
+// AggregateNode 时序数据降采样节点，按时间窗口累计 msg.Data 中的点位值，窗口结束时输出聚合值。
+// msg.Data 支持两种格式：对象，每个数值字段是一个点位；数组，每个元素的 nameField 字段为点位名称，valueField 字段为值，eg. OPC UA 读取和订阅结果。
+// 收到的消息被缓存，不再流转；窗口结束时每个分组作为新消息通过`Success`链发送到当前规则链，没有数据的窗口不输出，消息负荷格式：
+//
+//	{
+//	  "ns=2;s=Temperature": {"min": 20.1, "max": 22.4, "avg": 21.3, "last": 21.9, "count": 600}
+//	}
+//
+// 元数据 windowStart 和 windowEnd 为窗口的开始和结束时间，group 为分组。节点销毁时丢弃未结束窗口的数据。
+// 消息中没有点位值，流转到`Failure`链
+type AggregateNode struct {
+	//节点配置
+	Config        AggregateConfiguration
+	window        time.Duration
+	aggregates    []string
+	groupTemplate str.Template
+	// mu 保护当前窗口
+	mu      sync.Mutex
+	current *Window
+	// start 当前窗口的开始时间
+	start time.Time
+	// stop 关闭后输出协程退出，nil 表示输出协程尚未启动
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Type 返回组件类型
+func (x *AggregateNode) Type() string {
+	return "x/aggregate"
+}
+
+// New 默认参数
+func (x *AggregateNode) New() types.Node {
+	return &AggregateNode{
+		Config: AggregateConfiguration{
+			Window:     "1m",
+			Align:      true,
+			NameField:  "nodeId",
+			ValueField: "value",
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *AggregateNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.window, err = time.ParseDuration(x.Config.Window); err != nil {
+		return err
+	}
+	if x.window <= 0 {
+		return errors.New("window must be greater than 0")
+	}
+	x.aggregates = x.Config.Aggregates
+	if len(x.aggregates) == 0 {
+		x.aggregates = DefaultAggregates
+	}
+	if err = CheckAggregates(x.aggregates); err != nil {
+		return err
+	}
+	if x.Config.NameField == "" {
+		x.Config.NameField = "nodeId"
+	}
+	if x.Config.ValueField == "" {
+		x.Config.ValueField = "value"
+	}
+	x.groupTemplate = str.NewTemplate(x.Config.GroupBy)
+	x.current = NewWindow()
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *AggregateNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	samples, err := ParseSamples([]byte(msg.GetData()), x.Config.NameField, x.Config.ValueField)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	group := ""
+	if x.Config.GroupBy != "" {
+		group = x.groupTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, s := range samples {
+		x.current.Add(group, s.Tag, s.Value)
+	}
+	if x.stop == nil {
+		now := time.Now()
+		x.start = now
+		if x.Config.Align {
+			x.start = now.Truncate(x.window)
+		}
+		x.stop = make(chan struct{})
+		x.done = make(chan struct{})
+		// 聚合结果消息使用独立的 context，不受触发消息结束或超时的影响
+		go x.run(ctx.SetContext(context.Background()), x.stop, x.done)
+	}
+}
+
+// run 每个窗口结束时输出聚合结果
+func (x *AggregateNode) run(ctx types.RuleContext, stop, done chan struct{}) {
+	defer close(done)
+	x.mu.Lock()
+	end := x.start.Add(x.window)
+	x.mu.Unlock()
+	timer := time.NewTimer(time.Until(end))
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+			x.emit(ctx, end)
+			end = end.Add(x.window)
+			// 处理过慢错过的窗口不再单独输出
+			for !end.After(time.Now()) {
+				end = end.Add(x.window)
+			}
+			timer.Reset(time.Until(end))
+		}
+	}
+}
+
+// emit 结束当前窗口，每个分组输出一条消息
+func (x *AggregateNode) emit(ctx types.RuleContext, end time.Time) {
+	x.mu.Lock()
+	w, start := x.current, x.start
+	x.current = NewWindow()
+	x.start = end
+	x.mu.Unlock()
+	for _, group := range w.Groups() {
+		data, err := json.Marshal(w.Result(group, x.aggregates))
+		if err != nil {
+			ctx.TellFailure(ctx.NewMsg(AggregateMsgType, types.NewMetadata(), ""), err)
+			continue
+		}
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyWindowStart, start.Format(time.RFC3339Nano))
+		metadata.PutValue(KeyWindowEnd, end.Format(time.RFC3339Nano))
+		if x.Config.GroupBy != "" {
+			metadata.PutValue(KeyGroup, group)
+		}
+		ctx.TellNext(ctx.NewMsg(AggregateMsgType, metadata, string(data)), types.Success)
+	}
+}
+
+// Destroy 停止输出，丢弃未结束窗口的数据
+func (x *AggregateNode) Destroy() {
+	x.mu.Lock()
+	stop, done := x.stop, x.done
+	x.stop = nil
+	x.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Desc returns the component description
+func (x *AggregateNode) Desc() string {
+	return "Time-series downsampling node buffering tag values over a window and emitting min/max/avg/last/count/stddev per tag. Routes to Success/Failure"
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregate
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestAggregateNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&AggregateNode{})
+
+	_, err := test.CreateAndInitNode("x/aggregate", types.Configuration{"window": "soon"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/aggregate", types.Configuration{"aggregates": []string{"median"}}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/aggregate", types.Configuration{
+		"window":     "500ms",
+		"align":      false,
+		"aggregates": []string{"min", "max", "avg", "count"},
+		"groupBy":    "${metadata.deviceId}",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	line1 := types.NewMetadata()
+	line1.PutValue("deviceId", "line1")
+	line2 := types.NewMetadata()
+	line2.PutValue("deviceId", "line2")
+
+	var lock sync.Mutex
+	results := make(map[string]map[string]map[string]float64)
+	failures := 0
+	test.NodeOnMsg(t, node, []test.Msg{
+		{MetaData: line1, DataType: types.JSON, MsgType: "OPC_UA_DATA", Data: `[{"nodeId":"T1","value":20},{"nodeId":"T2","value":1}]`},
+		{MetaData: line1, DataType: types.JSON, MsgType: "OPC_UA_DATA", Data: `[{"nodeId":"T1","value":24}]`},
+		{MetaData: line2, DataType: types.JSON, MsgType: "TELEMETRY", Data: `{"T1":5}`},
+		{MetaData: line2, DataType: types.JSON, MsgType: "TEXT", Data: `{"model":"PLC1"}`, AfterSleep: time.Millisecond * 800},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if relationType == types.Failure {
+			assert.Equal(t, "TEXT", msg.Type)
+			failures++
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, AggregateMsgType, msg.Type)
+		assert.True(t, msg.Metadata.GetValue(KeyWindowStart) != "")
+		assert.True(t, msg.Metadata.GetValue(KeyWindowEnd) != "")
+		var result map[string]map[string]float64
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		results[msg.Metadata.GetValue(KeyGroup)] = result
+	})
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, map[string]float64{"min": 20, "max": 24, "avg": 22, "count": 2}, results["line1"]["T1"])
+	assert.Equal(t, 1.0, results["line1"]["T2"]["count"])
+	assert.Equal(t, 5.0, results["line2"]["T1"]["avg"])
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// 聚合函数
+const (
+	AggMin    = "min"
+	AggMax    = "max"
+	AggAvg    = "avg"
+	AggSum    = "sum"
+	AggFirst  = "first"
+	AggLast   = "last"
+	AggCount  = "count"
+	AggStddev = "stddev"
+)
+
+// DefaultAggregates 默认输出的聚合值
+var DefaultAggregates = []string{AggMin, AggMax, AggAvg, AggLast, AggCount}
+
+// CheckAggregates 检查聚合函数名称
+func CheckAggregates(aggregates []string) error {
+	for _, agg := range aggregates {
+		switch agg {
+		case AggMin, AggMax, AggAvg, AggSum, AggFirst, AggLast, AggCount, AggStddev:
+		default:
+			return fmt.Errorf("unsupported aggregate: %s", agg)
+		}
+	}
+	return nil
+}
+
+// Stats 一个点位在窗口内的统计，方差使用 Welford 算法累计
+type Stats struct {
+	Count int
+	Sum   float64
+	Min   float64
+	Max   float64
+	First float64
+	Last  float64
+	mean  float64
+	m2    float64
+}
+
+// Add 累计一个值
+func (s *Stats) Add(v float64) {
+	if s.Count == 0 {
+		s.Min, s.Max, s.First = v, v, v
+	} else {
+		s.Min = math.Min(s.Min, v)
+		s.Max = math.Max(s.Max, v)
+	}
+	s.Count++
+	s.Sum += v
+	s.Last = v
+	delta := v - s.mean
+	s.mean += delta / float64(s.Count)
+	s.m2 += delta * (v - s.mean)
+}
+
+// Avg 平均值
+func (s *Stats) Avg() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Stddev 总体标准差
+func (s *Stats) Stddev() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return math.Sqrt(math.Max(s.m2, 0) / float64(s.Count))
+}
+
+// Result 按聚合函数输出，key 为聚合函数名称
+func (s *Stats) Result(aggregates []string) map[string]interface{} {
+	result := make(map[string]interface{}, len(aggregates))
+	for _, agg := range aggregates {
+		switch agg {
+		case AggMin:
+			result[agg] = s.Min
+		case AggMax:
+			result[agg] = s.Max
+		case AggAvg:
+			result[agg] = s.Avg()
+		case AggSum:
+			result[agg] = s.Sum
+		case AggFirst:
+			result[agg] = s.First
+		case AggLast:
+			result[agg] = s.Last
+		case AggCount:
+			result[agg] = s.Count
+		case AggStddev:
+			result[agg] = s.Stddev()
+		}
+	}
+	return result
+}
+
+// Window 按分组和点位累计的窗口，非并发安全
+type Window struct {
+	groups map[string]map[string]*Stats
+}
+
+// NewWindow 创建窗口
+func NewWindow() *Window {
+	return &Window{groups: make(map[string]map[string]*Stats)}
+}
+
+// Add 累计分组 group 中点位 tag 的值
+func (w *Window) Add(group, tag string, v float64) {
+	tags, ok := w.groups[group]
+	if !ok {
+		tags = make(map[string]*Stats)
+		w.groups[group] = tags
+	}
+	s, ok := tags[tag]
+	if !ok {
+		s = &Stats{}
+		tags[tag] = s
+	}
+	s.Add(v)
+}
+
+// Len 分组数量
+func (w *Window) Len() int {
+	return len(w.groups)
+}
+
+// Groups 按名称排序的分组
+func (w *Window) Groups() []string {
+	groups := make([]string, 0, len(w.groups))
+	for group := range w.groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// Result 分组中每个点位的聚合值，key 为点位名称
+func (w *Window) Result(group string, aggregates []string) map[string]map[string]interface{} {
+	tags := w.groups[group]
+	result := make(map[string]map[string]interface{}, len(tags))
+	for tag, s := range tags {
+		result[tag] = s.Result(aggregates)
+	}
+	return result
+}
+
+// Sample 点位值
+type Sample struct {
+	Tag   string
+	Value float64
+}
+
+// ErrNoSamples 消息中没有数值
+var ErrNoSamples = errors.New("no numeric values in message")
+
+// ParseSamples 从 JSON 中提取点位值，支持两种格式：
+//   - 对象：每个数值或者布尔字段是一个点位，字段名为点位名称，eg. {"temperature":21.5,"running":true}
+//   - 数组：每个元素是一个对象，nameField 字段为点位名称，valueField 字段为值，eg. OPC UA 读取结果 [{"nodeId":"ns=2;s=T1","value":21.5}]
+//
+// 布尔值转换为 0 或者 1，数值字符串转换为数值，其他类型的值忽略
+func ParseSamples(data []byte, nameField, valueField string) ([]Sample, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if f, ok := toFloat(value); ok {
+				samples = append(samples, Sample{Tag: name, Value: f})
+			}
+		}
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i].Tag < samples[j].Tag
+		})
+	case []interface{}:
+		for _, item := range v {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, ok := obj[nameField].(string)
+			if !ok || name == "" {
+				continue
+			}
+			if f, ok := toFloat(obj[valueField]); ok {
+				samples = append(samples, Sample{Tag: name, Value: f})
+			}
+		}
+	}
+	if len(samples) == 0 {
+		return nil, ErrNoSamples
+	}
+	return samples, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestWindow(t *testing.T) {
+	assert.Nil(t, CheckAggregates(DefaultAggregates))
+	assert.NotNil(t, CheckAggregates([]string{"median"}))
+
+	w := NewWindow()
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		w.Add("", "t1", v)
+	}
+	w.Add("", "t2", 1)
+	w.Add("line2", "t1", 3)
+	assert.Equal(t, []string{"", "line2"}, w.Groups())
+
+	result := w.Result("", []string{AggMin, AggMax, AggAvg, AggSum, AggFirst, AggLast, AggCount, AggStddev})
+	assert.Equal(t, 2, len(result))
+	t1 := result["t1"]
+	assert.Equal(t, 2.0, t1[AggMin])
+	assert.Equal(t, 9.0, t1[AggMax])
+	assert.Equal(t, 5.0, t1[AggAvg])
+	assert.Equal(t, 40.0, t1[AggSum])
+	assert.Equal(t, 2.0, t1[AggFirst])
+	assert.Equal(t, 9.0, t1[AggLast])
+	assert.Equal(t, 8, t1[AggCount])
+	assert.True(t, math.Abs(t1[AggStddev].(float64)-2) < 1e-9)
+	assert.Equal(t, 0.0, result["t2"][AggStddev])
+}
+
+func TestParseSamples(t *testing.T) {
+	samples, err := ParseSamples([]byte(`{"temperature":21.5,"running":true,"pressure":"1.2","model":"PLC1","nan":"NaN"}`), "nodeId", "value")
+	assert.Nil(t, err)
+	assert.Equal(t, []Sample{{Tag: "pressure", Value: 1.2}, {Tag: "running", Value: 1}, {Tag: "temperature", Value: 21.5}}, samples)
+
+	samples, err = ParseSamples([]byte(`[{"nodeId":"ns=2;s=T1","value":21.5},{"nodeId":"ns=2;s=S1","value":"ok"},{"value":1},3]`), "nodeId", "value")
+	assert.Nil(t, err)
+	assert.Equal(t, []Sample{{Tag: "ns=2;s=T1", Value: 21.5}}, samples)
+
+	_, err = ParseSamples([]byte(`{"model":"PLC1"}`), "nodeId", "value")
+	assert.Equal(t, ErrNoSamples, err)
+	_, err = ParseSamples([]byte(`not json`), "nodeId", "value")
+	assert.NotNil(t, err)
+}
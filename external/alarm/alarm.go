@@ -0,0 +1,426 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package alarm 把各协议的报警和事件转换为统一的报警格式，
+// 规则链可以不区分协议处理报警的过滤、升级、通知和确认
+package alarm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+)
+
+// 报警来源格式
+const (
+	FormatAuto   = "auto"
+	FormatOPCUA  = "opcua"
+	FormatBACnet = "bacnet"
+	FormatIEC104 = "iec104"
+)
+
+// 报警状态
+const (
+	StateActive   = "active"
+	StateInactive = "inactive"
+)
+
+// 确认状态，none 表示协议没有确认机制或者报警不需要确认
+const (
+	AckStateAcked   = "acked"
+	AckStateUnacked = "unacked"
+	AckStateNone    = "none"
+)
+
+// 报警等级，按严重程度 1-1000 划分
+const (
+	LevelCritical = "critical"
+	LevelHigh     = "high"
+	LevelMedium   = "medium"
+	LevelLow      = "low"
+	LevelInfo     = "info"
+)
+
+// DefaultSeverity 来源没有严重程度时使用的默认值
+const DefaultSeverity = 500
+
+// ErrUnknownFormat 无法识别报警来源格式
+var ErrUnknownFormat = errors.New("unknown alarm format")
+
+// Alarm 统一的报警格式
+type Alarm struct {
+	// ID 事件 ID，OPC UA 为 EventId，其他协议为空
+	ID string `json:"id,omitempty"`
+	// Format 来源格式：opcua、bacnet、iec104
+	Format string `json:"format"`
+	// Source 报警源，OPC UA 为 SourceName，BACnet 为事件对象 eg. analog-input:1，IEC-104 为信息对象地址或者配置的名称
+	Source string `json:"source"`
+	// SourceNode 报警源所在的节点，OPC UA 为 SourceNode，BACnet 为发起的设备 eg. device:100，IEC-104 为空
+	SourceNode string `json:"sourceNode,omitempty"`
+	// Name 报警名称，OPC UA 为 ConditionName 或者 EventType，BACnet 为事件类型，IEC-104 为配置的名称或者类型标识
+	Name string `json:"name,omitempty"`
+	// Message 报警消息
+	Message string `json:"message,omitempty"`
+	// Severity 严重程度 1-1000，OPC UA 的刻度，BACnet 优先级 0-255 反向映射
+	Severity int `json:"severity"`
+	// Level 按严重程度划分的等级：critical（801-1000）、high（601-800）、medium（401-600）、low（201-400）、info（1-200）
+	Level string `json:"level"`
+	// State 报警状态：active、inactive
+	State string `json:"state"`
+	// AckState 确认状态：acked、unacked、none
+	AckState string `json:"ackState"`
+	// Time 报警发生时间，Unix 毫秒，来源没有时标时为接收时间
+	Time int64 `json:"time"`
+	// ReceiveTime 接收时间，Unix 毫秒
+	ReceiveTime int64 `json:"receiveTime"`
+	// Quality 归一化的质量和协议原始质量码
+	quality.Quality
+	// Raw 原始事件，IEC-104 为点位
+	Raw interface{} `json:"raw,omitempty"`
+}
+
+// SeverityLevel 严重程度对应的等级
+func SeverityLevel(severity int) string {
+	switch {
+	case severity > 800:
+		return LevelCritical
+	case severity > 600:
+		return LevelHigh
+	case severity > 400:
+		return LevelMedium
+	case severity > 200:
+		return LevelLow
+	default:
+		return LevelInfo
+	}
+}
+
+// setSeverity 设置严重程度和等级，限制在 1-1000
+func (a *Alarm) setSeverity(severity int) {
+	if severity < 1 {
+		severity = 1
+	} else if severity > 1000 {
+		severity = 1000
+	}
+	a.Severity, a.Level = severity, SeverityLevel(severity)
+}
+
+// Detect 识别报警来源格式，无法识别返回空字符串
+func Detect(event map[string]interface{}) string {
+	fields := normalizeKeys(event)
+	switch {
+	case has(fields, "eventobjectidentifier", "tostate", "notifytype"):
+		return FormatBACnet
+	case has(fields, "points"):
+		return FormatIEC104
+	case has(fields, "eventid", "eventtype", "sourcename", "activestate", "activestateid", "conditionname"):
+		return FormatOPCUA
+	case has(fields, "address") && strings.HasPrefix(strings.ToUpper(text(fields["type"])), "M_"):
+		return FormatIEC104
+	}
+	return ""
+}
+
+// FromOPCUA 转换 OPC UA 报警和条件（A&C）事件，字段为事件过滤的选择字段，不区分大小写：
+// EventId、EventType、SourceName、SourceNode、ConditionName、Message、Severity、Time、ReceiveTime、
+// ActiveState（或者 ActiveState/Id）、AckedState（或者 AckedState/Id）、Quality。
+// 状态可以是布尔值、"Active"/"Inactive" 等文本或者 {"Id": true} 对象，时间可以是 RFC 3339 或者 Unix 毫秒。
+// 没有 ActiveState 的普通事件为 active，没有 AckedState 的为 none
+func FromOPCUA(event map[string]interface{}, now time.Time) Alarm {
+	fields := normalizeKeys(event)
+	a := Alarm{
+		Format:      FormatOPCUA,
+		ID:          text(first(fields, "eventid")),
+		Source:      text(first(fields, "sourcename")),
+		SourceNode:  text(first(fields, "sourcenode")),
+		Name:        text(first(fields, "conditionname", "eventtype")),
+		Message:     text(first(fields, "message")),
+		State:       StateActive,
+		AckState:    AckStateNone,
+		ReceiveTime: now.UnixMilli(),
+		Quality:     quality.GoodQuality,
+		Raw:         event,
+	}
+	severity, ok := number(first(fields, "severity"))
+	if !ok {
+		severity = DefaultSeverity
+	}
+	a.setSeverity(int(severity))
+	if active, ok := state(first(fields, "activestateid", "activestate")); ok && !active {
+		a.State = StateInactive
+	}
+	if acked, ok := state(first(fields, "ackedstateid", "ackedstate")); ok {
+		a.AckState = AckStateUnacked
+		if acked {
+			a.AckState = AckStateAcked
+		}
+	}
+	if status, ok := number(first(fields, "quality")); ok {
+		a.Quality = quality.FromOPCUA(uint32(status))
+	}
+	a.Time = timestamp(first(fields, "time"), now)
+	a.ReceiveTime = timestamp(first(fields, "receivetime"), now)
+	return a
+}
+
+// FromBACnet 转换 BACnet 事件通知（ConfirmedEventNotification/UnconfirmedEventNotification），字段名不区分大小写和连字符：
+// eventObjectIdentifier、initiatingDeviceIdentifier、eventType、messageText、priority、notifyType、ackRequired、
+// fromState、toState、timeStamp。对象标识可以是 "analog-input:1"、"analog-input,1" 或者 {"type","instance"} 对象。
+// toState 为 normal 时报警状态为 inactive，其他状态为 active，fault 的质量为 BAD。
+// 优先级 0-255（越小越紧急）反向映射为严重程度 1000-1。notifyType 为 ack-notification 或者 ackRequired 为 true 时分别为 acked、unacked，
+// 不需要确认的为 none
+func FromBACnet(event map[string]interface{}, now time.Time) Alarm {
+	fields := normalizeKeys(event)
+	a := Alarm{
+		Format:      FormatBACnet,
+		Source:      objectID(first(fields, "eventobjectidentifier", "objectidentifier")),
+		SourceNode:  objectID(first(fields, "initiatingdeviceidentifier", "deviceidentifier")),
+		Name:        text(first(fields, "eventtype")),
+		Message:     text(first(fields, "messagetext", "message")),
+		State:       StateActive,
+		AckState:    AckStateNone,
+		ReceiveTime: now.UnixMilli(),
+		Quality:     quality.GoodQuality,
+		Raw:         event,
+	}
+	severity := DefaultSeverity
+	if priority, ok := number(first(fields, "priority")); ok {
+		priority = math.Max(0, math.Min(255, priority))
+		severity = int(math.Round(1000 - priority*999/255))
+	}
+	a.setSeverity(severity)
+	switch toState := strings.ToLower(text(first(fields, "tostate"))); toState {
+	case "normal", "0":
+		a.State = StateInactive
+	case "fault", "1":
+		a.Quality = quality.Quality{Level: quality.Bad}
+	}
+	if notifyType := strings.ToLower(text(first(fields, "notifytype"))); notifyType == "ack-notification" || notifyType == "2" {
+		a.AckState = AckStateAcked
+	} else if ack, _ := state(first(fields, "ackrequired")); ack {
+		a.AckState = AckStateUnacked
+	}
+	a.Time = timestamp(first(fields, "timestamp"), now)
+	return a
+}
+
+// IEC104Point IEC-104 报警点位配置
+type IEC104Point struct {
+	// Address 信息对象地址
+	Address uint32 `json:"address"`
+	// Source 报警源，为空使用信息对象地址
+	Source string `json:"source,omitempty"`
+	// Name 报警名称，为空使用类型标识
+	Name string `json:"name,omitempty"`
+	// Message 报警消息
+	Message string `json:"message,omitempty"`
+	// Severity 严重程度 1-1000，0 使用节点的默认值
+	Severity int `json:"severity,omitempty"`
+	// Invert 值为 OFF 时报警，用于常闭触点
+	Invert bool `json:"invert,omitempty"`
+}
+
+// FromIEC104 转换 IEC-104 单点信息（M_SP_*）和双点信息（M_DP_*）为报警，event 为 x/iec104Client 的
+// {"points": [...]} 或者单个点位。单点值为 true、双点值为 2（ON）时报警为 active，双点的中间状态和不确定状态的质量为 UNCERTAIN。
+// points 不为空时只转换配置的信息对象地址，否则转换所有单点和双点信息，其他类型的点位被忽略。
+// 协议没有确认机制，确认状态为 none
+func FromIEC104(event map[string]interface{}, points []IEC104Point, severity int, now time.Time) []Alarm {
+	fields := normalizeKeys(event)
+	list := []interface{}{event}
+	if v, ok := fields["points"].([]interface{}); ok {
+		list = v
+	}
+	configured := make(map[uint32]IEC104Point, len(points))
+	for _, p := range points {
+		configured[p.Address] = p
+	}
+	var alarms []Alarm
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		point := normalizeKeys(m)
+		typeId := strings.ToUpper(text(point["type"]))
+		single, double := strings.HasPrefix(typeId, "M_SP_"), strings.HasPrefix(typeId, "M_DP_")
+		if !single && !double {
+			continue
+		}
+		address, _ := number(point["address"])
+		config, ok := configured[uint32(address)]
+		if len(configured) > 0 && !ok {
+			continue
+		}
+		code, _ := number(point["quality"])
+		a := Alarm{
+			Format:      FormatIEC104,
+			Source:      config.Source,
+			Name:        config.Name,
+			Message:     config.Message,
+			State:       StateInactive,
+			AckState:    AckStateNone,
+			ReceiveTime: now.UnixMilli(),
+			Quality:     quality.FromIEC104(uint8(code)),
+			Raw:         m,
+		}
+		if a.Source == "" {
+			a.Source = strconv.FormatUint(uint64(address), 10)
+		}
+		if a.Name == "" {
+			a.Name = typeId
+		}
+		if config.Severity > 0 {
+			a.setSeverity(config.Severity)
+		} else {
+			a.setSeverity(severity)
+		}
+		var active bool
+		if single {
+			active, _ = state(point["value"])
+		} else {
+			v, _ := number(point["value"])
+			active = v == 2
+			if (v == 0 || v == 3) && a.Quality.Level == quality.Good {
+				a.Quality.Level = quality.Uncertain
+			}
+		}
+		if active != config.Invert {
+			a.State = StateActive
+		}
+		a.Time = timestamp(point["timestamp"], now)
+		alarms = append(alarms, a)
+	}
+	return alarms
+}
+
+// normalizeKeys key 转换为小写并去掉连字符、下划线、空格和 /
+func normalizeKeys(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	replacer := strings.NewReplacer("-", "", "_", "", " ", "", "/", "")
+	for k, v := range m {
+		result[replacer.Replace(strings.ToLower(k))] = v
+	}
+	return result
+}
+
+func has(fields map[string]interface{}, keys ...string) bool {
+	return first(fields, keys...) != nil
+}
+
+// first 第一个存在的字段
+func first(fields map[string]interface{}, keys ...string) interface{} {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// text 转换为字符串，LocalizedText 对象使用 text 字段
+func text(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case map[string]interface{}:
+		return text(first(normalizeKeys(value), "text", "value"))
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case json.Number:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// number 转换为数值
+func number(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case float64:
+		return value, true
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return f, err == nil
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// state 解析布尔状态，支持布尔值、数值、Active/Inactive、Acknowledged/Unacknowledged 等文本和 {"Id": true} 对象
+func state(v interface{}) (bool, bool) {
+	switch value := v.(type) {
+	case bool:
+		return value, true
+	case map[string]interface{}:
+		fields := normalizeKeys(value)
+		if id := first(fields, "id"); id != nil {
+			return state(id)
+		}
+		return state(first(fields, "text", "value"))
+	case string:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "true", "1", "on", "active", "acknowledged", "acked", "confirmed":
+			return true, true
+		case "false", "0", "off", "inactive", "unacknowledged", "unacked", "unconfirmed":
+			return false, true
+		}
+		return false, false
+	}
+	if f, ok := number(v); ok {
+		return f != 0, true
+	}
+	return false, false
+}
+
+// objectID BACnet 对象标识，统一为 <类型>:<实例号>
+func objectID(v interface{}) string {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		fields := normalizeKeys(value)
+		return fmt.Sprintf("%s:%s", text(first(fields, "type", "objecttype")), text(first(fields, "instance", "objectinstance")))
+	case string:
+		return strings.Replace(strings.TrimSpace(value), ",", ":", 1)
+	}
+	return text(v)
+}
+
+// timestamp 解析 RFC 3339 或者 Unix 毫秒时间，无法解析使用 now
+func timestamp(v interface{}, now time.Time) int64 {
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s)); err == nil {
+			return t.UnixMilli()
+		}
+	}
+	if ms, ok := number(v); ok && ms > 0 {
+		return int64(ms)
+	}
+	return now.UnixMilli()
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alarm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// AlarmMsgType 统一报警消息的类型
+	AlarmMsgType = "ALARM"
+	// KeyAlarmState 报警状态元数据key：active、inactive
+	KeyAlarmState = "alarmState"
+	// KeyAlarmLevel 报警等级元数据key：critical、high、medium、low、info
+	KeyAlarmLevel = "alarmLevel"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&NormalizeNode{})
+}
+
+// NormalizeConfiguration 报警归一化节点配置
+type NormalizeConfiguration struct {
+	// Format 报警来源格式：auto、opcua、bacnet、iec104，auto 按字段自动识别
+	Format string `json:"format" label:"Format" desc:"Alarm payload format: auto, opcua, bacnet or iec104. auto detects the format from the fields"`
+	// SourceNode 报警源所在的节点，不为空时覆盖来源的值，支持 ${metadata.key} 和 ${msg.key} 占位符，eg. ${metadata.server}
+	SourceNode string `json:"sourceNode" label:"Source Node" desc:"Overrides the node the alarm source belongs to, eg. ${metadata.server}. Supports ${metadata.key} and ${msg.key} placeholders"`
+	// DefaultSeverity 来源没有严重程度时使用的严重程度 1-1000，eg. IEC-104 点位
+	DefaultSeverity int `json:"defaultSeverity" label:"Default Severity" desc:"Severity 1-1000 used when the source has none, eg. IEC-104 points"`
+	// Points IEC-104 报警点位，为空转换所有单点和双点信息
+	Points []IEC104Point `json:"points" label:"IEC-104 Points" desc:"IEC-104 alarm points with source, name, message and severity. Empty converts all single and double points"`
+	// KeepRaw 输出原始事件到 raw 字段
+	KeepRaw bool `json:"keepRaw" label:"Keep Raw" desc:"Keep the original event in the raw field"`
+}
+
+// NormalizeNode 报警归一化节点，把 OPC UA A&C 事件、BACnet 事件通知和 IEC-104 单点/双点信息转换为统一的报警格式：
+//
+//	{"format": "opcua", "id": "...", "source": "Boiler1", "sourceNode": "ns=2;s=Boiler1", "name": "HighTemperature",
+//	 "message": "Temperature high", "severity": 800, "level": "high", "state": "active", "ackState": "unacked",
+//	 "time": 1700000000000, "receiveTime": 1700000000050, "qualityLevel": "GOOD", "qualityCode": 0}
+//
+// 各协议字段的转换规则见 FromOPCUA、FromBACnet、FromIEC104。
+// 每个报警输出一条消息，消息类型为 ALARM，元数据 alarmState 和 alarmLevel 为报警状态和等级，流转到`Success`链；
+// 一条消息包含多个 IEC-104 点位时输出多条消息。无法识别的格式或者没有报警，流转到`Failure`链
+type NormalizeNode struct {
+	//节点配置
+	Config             NormalizeConfiguration
+	sourceNodeTemplate str.Template
+}
+
+// Type 返回组件类型
+func (x *NormalizeNode) Type() string {
+	return "x/alarmNormalize"
+}
+
+// New 默认参数
+func (x *NormalizeNode) New() types.Node {
+	return &NormalizeNode{
+		Config: NormalizeConfiguration{
+			Format:          FormatAuto,
+			DefaultSeverity: DefaultSeverity,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *NormalizeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	switch x.Config.Format {
+	case "":
+		x.Config.Format = FormatAuto
+	case FormatAuto, FormatOPCUA, FormatBACnet, FormatIEC104:
+	default:
+		return fmt.Errorf("unsupported alarm format: %s", x.Config.Format)
+	}
+	if x.Config.DefaultSeverity == 0 {
+		x.Config.DefaultSeverity = DefaultSeverity
+	}
+	if x.Config.DefaultSeverity < 1 || x.Config.DefaultSeverity > 1000 {
+		return errors.New("defaultSeverity must be between 1 and 1000")
+	}
+	for _, p := range x.Config.Points {
+		if p.Severity < 0 || p.Severity > 1000 {
+			return fmt.Errorf("severity of iec104 point %d must be between 1 and 1000", p.Address)
+		}
+	}
+	x.sourceNodeTemplate = nil
+	if x.Config.SourceNode != "" {
+		x.sourceNodeTemplate = str.NewTemplate(x.Config.SourceNode)
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *NormalizeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	alarms, err := x.Normalize([]byte(msg.GetData()), time.Now())
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var sourceNode string
+	if x.sourceNodeTemplate != nil {
+		sourceNode = x.sourceNodeTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	outputs := make([]types.RuleMsg, 0, len(alarms))
+	for i := range alarms {
+		if x.sourceNodeTemplate != nil {
+			alarms[i].SourceNode = sourceNode
+		}
+		b, err := json.Marshal(alarms[i])
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		out := msg
+		if i > 0 {
+			out = msg.Copy()
+		}
+		out.Type = AlarmMsgType
+		out.Metadata.PutValue(KeyAlarmState, alarms[i].State)
+		out.Metadata.PutValue(KeyAlarmLevel, alarms[i].Level)
+		out.SetData(str.ToString(b))
+		outputs = append(outputs, out)
+	}
+	for _, out := range outputs {
+		ctx.TellSuccess(out)
+	}
+}
+
+// Normalize 转换消息负荷中的报警，格式为 auto 时按字段自动识别
+func (x *NormalizeNode) Normalize(data []byte, now time.Time) ([]Alarm, error) {
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	format := x.Config.Format
+	if format == FormatAuto {
+		if format = Detect(event); format == "" {
+			return nil, ErrUnknownFormat
+		}
+	}
+	var alarms []Alarm
+	switch format {
+	case FormatOPCUA:
+		alarms = []Alarm{FromOPCUA(event, now)}
+	case FormatBACnet:
+		alarms = []Alarm{FromBACnet(event, now)}
+	case FormatIEC104:
+		if alarms = FromIEC104(event, x.Config.Points, x.Config.DefaultSeverity, now); len(alarms) == 0 {
+			return nil, errors.New("no iec104 alarm points in payload")
+		}
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if !x.Config.KeepRaw {
+		for i := range alarms {
+			alarms[i].Raw = nil
+		}
+	}
+	return alarms, nil
+}
+
+// Destroy 销毁组件
+func (x *NormalizeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *NormalizeNode) Desc() string {
+	return "Converts OPC UA A&C events, BACnet event notifications and IEC-104 single/double points into a common alarm schema with source, severity, state, ack state and timestamps. Routes to Success/Failure"
+}
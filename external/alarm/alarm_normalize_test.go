@@ -0,0 +1,82 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alarm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestNormalizeNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&NormalizeNode{})
+
+	_, err := test.CreateAndInitNode("x/alarmNormalize", types.Configuration{"format": "modbus"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/alarmNormalize", types.Configuration{"defaultSeverity": 2000}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/alarmNormalize", types.Configuration{
+		"sourceNode": "${metadata.server}",
+		"points":     []map[string]interface{}{{"address": 1001, "name": "Trip"}, {"address": 1002, "name": "Door"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("server", "rtu1")
+	var relations []string
+	var alarms []Alarm
+	var states []string
+	// 节点在 OnMsg 返回前完成回调，同步处理保证消息的顺序
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		relations = append(relations, relationType)
+		if relationType == types.Success {
+			assert.Equal(t, AlarmMsgType, msg.Type)
+			var a Alarm
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &a))
+			alarms = append(alarms, a)
+			states = append(states, msg.Metadata.GetValue(KeyAlarmState))
+		}
+	})
+	for _, m := range []struct {
+		msgType string
+		data    string
+	}{
+		{"OPC_UA_EVENT", `{"SourceName":"Boiler1","Severity":700,"ActiveState":"Active","AckedState":"Acknowledged"}`},
+		{"IEC104", `{"points":[{"type":"M_SP_NA_1","address":1001,"value":true},{"type":"M_SP_NA_1","address":1002,"value":false},{"type":"M_SP_NA_1","address":1003,"value":true}]}`},
+		{"IEC104", `{"points":[{"type":"M_SP_NA_1","address":1003,"value":true}]}`},
+		{"DATA", `{"temperature":21.5}`},
+	} {
+		node.OnMsg(ctx, types.NewMsg(0, m.msgType, types.JSON, metadata.Copy(), m.data))
+	}
+
+	assert.Equal(t, []string{types.Success, types.Success, types.Success, types.Failure, types.Failure}, relations)
+	assert.Equal(t, []string{StateActive, StateActive, StateInactive}, states)
+	assert.Equal(t, "Boiler1", alarms[0].Source)
+	assert.Equal(t, AckStateAcked, alarms[0].AckState)
+	assert.Equal(t, LevelHigh, alarms[0].Level)
+	assert.Equal(t, "rtu1", alarms[0].SourceNode)
+	assert.Nil(t, alarms[0].Raw)
+	assert.Equal(t, "Trip", alarms[1].Name)
+	assert.Equal(t, "Door", alarms[2].Name)
+	assert.Equal(t, "rtu1", alarms[2].SourceNode)
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alarm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// event 解析 JSON 事件
+func event(t *testing.T, s string) map[string]interface{} {
+	var m map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(s), &m))
+	return m
+}
+
+func TestFromOPCUA(t *testing.T) {
+	now := time.UnixMilli(1700000000500)
+	e := event(t, `{"EventId":"AQID","EventType":"i=9341","SourceName":"Boiler1","SourceNode":"ns=2;s=Boiler1",
+		"ConditionName":"HighTemperature","Message":{"Locale":"en","Text":"Temperature high"},"Severity":800,
+		"Time":"2023-11-14T22:13:20Z","ActiveState/Id":true,"AckedState":"Unacknowledged"}`)
+	assert.Equal(t, FormatOPCUA, Detect(e))
+	a := FromOPCUA(e, now)
+	assert.Equal(t, "AQID", a.ID)
+	assert.Equal(t, "Boiler1", a.Source)
+	assert.Equal(t, "ns=2;s=Boiler1", a.SourceNode)
+	assert.Equal(t, "HighTemperature", a.Name)
+	assert.Equal(t, "Temperature high", a.Message)
+	assert.Equal(t, 800, a.Severity)
+	assert.Equal(t, LevelHigh, a.Level)
+	assert.Equal(t, StateActive, a.State)
+	assert.Equal(t, AckStateUnacked, a.AckState)
+	assert.Equal(t, int64(1700000000000), a.Time)
+	assert.Equal(t, int64(1700000000500), a.ReceiveTime)
+	assert.True(t, a.Quality.IsGood())
+
+	// 没有条件名称和确认状态的事件
+	a = FromOPCUA(event(t, `{"eventType":"i=2041","activeState":{"id":false},"severity":1200,"quality":2147483648}`), now)
+	assert.Equal(t, "i=2041", a.Name)
+	assert.Equal(t, StateInactive, a.State)
+	assert.Equal(t, AckStateNone, a.AckState)
+	assert.Equal(t, 1000, a.Severity)
+	assert.Equal(t, LevelCritical, a.Level)
+	assert.True(t, a.Quality.IsBad())
+	assert.Equal(t, now.UnixMilli(), a.Time)
+}
+
+func TestFromBACnet(t *testing.T) {
+	now := time.UnixMilli(1700000000500)
+	e := event(t, `{"process-identifier":1,"initiating-device-identifier":"device,100",
+		"event-object-identifier":{"type":"analog-input","instance":3},"time-stamp":1700000000000,
+		"notification-class":1,"priority":100,"event-type":"out-of-range","message-text":"Level high",
+		"notify-type":"alarm","ack-required":true,"from-state":"normal","to-state":"high-limit"}`)
+	assert.Equal(t, FormatBACnet, Detect(e))
+	a := FromBACnet(e, now)
+	assert.Equal(t, "analog-input:3", a.Source)
+	assert.Equal(t, "device:100", a.SourceNode)
+	assert.Equal(t, "out-of-range", a.Name)
+	assert.Equal(t, "Level high", a.Message)
+	assert.Equal(t, 608, a.Severity)
+	assert.Equal(t, LevelHigh, a.Level)
+	assert.Equal(t, StateActive, a.State)
+	assert.Equal(t, AckStateUnacked, a.AckState)
+	assert.Equal(t, int64(1700000000000), a.Time)
+
+	a = FromBACnet(event(t, `{"eventObjectIdentifier":"binary-input:1","toState":"normal","notifyType":"ack-notification","priority":0}`), now)
+	assert.Equal(t, StateInactive, a.State)
+	assert.Equal(t, AckStateAcked, a.AckState)
+	assert.Equal(t, 1000, a.Severity)
+
+	a = FromBACnet(event(t, `{"eventObjectIdentifier":"binary-input:1","toState":"fault","priority":255}`), now)
+	assert.Equal(t, StateActive, a.State)
+	assert.Equal(t, AckStateNone, a.AckState)
+	assert.Equal(t, 1, a.Severity)
+	assert.Equal(t, quality.Bad, a.Quality.Level)
+}
+
+func TestFromIEC104(t *testing.T) {
+	now := time.UnixMilli(1700000000500)
+	e := event(t, `{"points":[
+		{"type":"M_SP_TB_1","address":1001,"value":true,"quality":0,"cause":3,"timestamp":1700000000000},
+		{"type":"M_SP_NA_1","address":1002,"value":false,"quality":128,"cause":20},
+		{"type":"M_DP_NA_1","address":1003,"value":3,"quality":0,"cause":20},
+		{"type":"M_ME_NC_1","address":2001,"value":21.5,"quality":0,"cause":20}]}`)
+	assert.Equal(t, FormatIEC104, Detect(e))
+	alarms := FromIEC104(e, nil, 300, now)
+	assert.Equal(t, 3, len(alarms))
+	assert.Equal(t, "1001", alarms[0].Source)
+	assert.Equal(t, "M_SP_TB_1", alarms[0].Name)
+	assert.Equal(t, StateActive, alarms[0].State)
+	assert.Equal(t, AckStateNone, alarms[0].AckState)
+	assert.Equal(t, LevelLow, alarms[0].Level)
+	assert.Equal(t, int64(1700000000000), alarms[0].Time)
+	assert.Equal(t, StateInactive, alarms[1].State)
+	assert.True(t, alarms[1].Quality.IsBad())
+	assert.Equal(t, now.UnixMilli(), alarms[1].Time)
+	assert.Equal(t, StateInactive, alarms[2].State)
+	assert.Equal(t, quality.Uncertain, alarms[2].Quality.Level)
+
+	// 只转换配置的点位
+	alarms = FromIEC104(e, []IEC104Point{{Address: 1002, Source: "Breaker1", Name: "Trip", Severity: 900, Invert: true}}, 300, now)
+	assert.Equal(t, 1, len(alarms))
+	assert.Equal(t, "Breaker1", alarms[0].Source)
+	assert.Equal(t, "Trip", alarms[0].Name)
+	assert.Equal(t, StateActive, alarms[0].State)
+	assert.Equal(t, LevelCritical, alarms[0].Level)
+
+	// 单个点位
+	single := event(t, `{"type":"M_DP_TB_1","address":7,"value":2,"quality":0}`)
+	assert.Equal(t, FormatIEC104, Detect(single))
+	alarms = FromIEC104(single, nil, 500, now)
+	assert.Equal(t, 1, len(alarms))
+	assert.Equal(t, StateActive, alarms[0].State)
+
+	assert.Equal(t, "", Detect(event(t, `{"temperature":21.5}`)))
+}
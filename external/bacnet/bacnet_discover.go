@@ -0,0 +1,147 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&DiscoverNode{})
+}
+
+// DiscoverConfiguration 设备发现节点配置
+type DiscoverConfiguration struct {
+	// LocalAddress 本地监听地址，为空使用随机端口。设备的 I-Am 以广播方式回复时需要监听 47808 端口
+	LocalAddress string `json:"localAddress" label:"Local address" desc:"Local UDP address, empty uses a random port. Use :47808 if devices broadcast their I-Am replies. Connect directly to an MS/TP bus with mstp:///dev/ttyUSB0?mac=3&baud=38400&maxMaster=127&maxInfoFrames=1 or through a serial server with mstp+tcp://host:port?mac=3"`
+	// Broadcast 广播地址
+	Broadcast string `json:"broadcast" label:"Broadcast" desc:"Broadcast address for Who-Is" required:"true"`
+	// LowLimit 查找的设备实例号下限，和 highLimit 同时为空表示查找所有设备
+	LowLimit *uint32 `json:"lowLimit,omitempty" label:"Low limit" desc:"Lowest device instance to discover, empty together with highLimit discovers all devices"`
+	// HighLimit 查找的设备实例号上限
+	HighLimit *uint32 `json:"highLimit,omitempty" label:"High limit" desc:"Highest device instance to discover"`
+	// Timeout 等待 I-Am 回复的时间，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Time to wait for I-Am replies in seconds"`
+	// Retries 重复广播的次数，用于丢包较多的网络
+	Retries int `json:"retries" label:"Retries" desc:"Extra Who-Is broadcasts for lossy networks"`
+	// ReadObjectList 是否读取每个设备的对象列表
+	ReadObjectList bool `json:"readObjectList" label:"Read object list" desc:"Whether to read the object list of each discovered device"`
+}
+
+// DiscoverNode BACnet/IP 设备发现节点，广播 Who-Is 并收集等待时间内回复 I-Am 的设备，
+// 结果按设备实例号排序，重新赋值到msg.Data：
+//
+//	[
+//	  {"deviceInstance": 1001, "address": "192.168.1.20:47808", "maxApdu": 1476, "segmentation": 3, "vendorId": 15,
+//	   "objects": ["device:1001", "analog-input:1"]}
+//	]
+//
+// objects 只有开启 readObjectList 才会读取。没有发现设备时输出空数组。
+// 发现成功，流转到`Success`链，否则流转到`Failure`链
+type DiscoverNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config DiscoverConfiguration
+}
+
+// Type 返回组件类型
+func (x *DiscoverNode) Type() string {
+	return "x/bacnetDiscover"
+}
+
+// New 默认参数
+func (x *DiscoverNode) New() types.Node {
+	return &DiscoverNode{
+		Config: DiscoverConfiguration{
+			Broadcast: DefaultBroadcast,
+			Timeout:   3,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DiscoverNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if (x.Config.LowLimit == nil) != (x.Config.HighLimit == nil) {
+		return fmt.Errorf("lowLimit and highLimit must be set together")
+	}
+	if x.Config.LowLimit != nil && *x.Config.LowLimit > *x.Config.HighLimit {
+		return fmt.Errorf("lowLimit must not be greater than highLimit")
+	}
+	return initClient(&x.SharedNode, ruleConfig, x.Type(), ClientConfig{
+		LocalAddress: x.Config.LocalAddress,
+		Broadcast:    x.Config.Broadcast,
+		Timeout:      time.Duration(x.Config.Timeout) * time.Second,
+		Retries:      x.Config.Retries,
+	})
+}
+
+// OnMsg 处理消息
+func (x *DiscoverNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	wait := time.Duration(x.Config.Timeout) * time.Second
+	if wait <= 0 {
+		wait = DefaultTimeout
+	}
+	devices, err := client.WhoIs(x.Config.LowLimit, x.Config.HighLimit, wait)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if x.Config.ReadObjectList {
+		for i := range devices {
+			objects, err := client.ReadObjectList(devices[i].Addr(), devices[i].Instance)
+			if err != nil {
+				ctx.TellFailure(msg, fmt.Errorf("device %d: %w", devices[i].Instance, err))
+				return
+			}
+			devices[i].Objects = objects
+		}
+	}
+	bytes, err := json.Marshal(devices)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *DiscoverNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *DiscoverNode) Desc() string {
+	return "BACnet/IP Who-Is discovery returning device instance, address, vendor and optionally the object list. Routes to Success/Failure"
+}
@@ -0,0 +1,320 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// Server 设备地址，格式：host:port，端口默认 47808，为空则通过 Who-Is 按设备实例号查找
+	Server string `json:"server" label:"Server" desc:"Device address, format: host:port, port defaults to 47808. Devices behind a BACnet/IP to MS/TP router: net:mac@router-host:port, eg. 5:12@192.168.1.10. Devices on a directly connected MS/TP bus: mstp:mac. Empty discovers the device by instance with Who-Is" ref:"primary"`
+	// DeviceInstance 设备实例号，server 为空时用于查找设备地址
+	DeviceInstance uint32 `json:"deviceInstance" label:"Device instance" desc:"Device instance number, used to discover the device address when server is empty"`
+	// LocalAddress 本地监听地址，为空使用随机端口。设备的 I-Am 以广播方式回复时需要监听 47808 端口
+	LocalAddress string `json:"localAddress" label:"Local address" desc:"Local UDP address, empty uses a random port. Use :47808 if devices broadcast their I-Am replies. Connect directly to an MS/TP bus with mstp:///dev/ttyUSB0?mac=3&baud=38400&maxMaster=127&maxInfoFrames=1 or through a serial server with mstp+tcp://host:port?mac=3"`
+	// Broadcast 广播地址，用于 Who-Is 查找设备
+	Broadcast string `json:"broadcast" label:"Broadcast" desc:"Broadcast address for Who-Is discovery"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Request timeout in seconds"`
+	// Retries 超时重试次数
+	Retries int `json:"retries" label:"Retries" desc:"Retries after a request timeout"`
+	// Objects 读取的对象属性，为空则使用消息负荷 msg.Data 中的对象属性
+	Objects []Object `json:"objects" label:"Objects" desc:"Object properties to read, empty uses the objects in msg.Data"`
+	// Quality 同时读取对象的 reliability 和 status-flags，值输出为 {"value": 21.5, "qualityLevel": "GOOD", "qualityCode": 0}
+	Quality bool `json:"quality" label:"Quality" desc:"Also read reliability and status-flags, values are output as {value, qualityLevel, qualityCode}"`
+}
+
+// Value 开启 quality 时输出的值，qualityCode 为对象的 reliability
+type Value struct {
+	Value interface{} `json:"value"`
+	quality.Quality
+}
+
+// Object 读取的对象属性
+type Object struct {
+	// Name 名称，作为输出的 key，为空使用 objectType:instance:property
+	Name string `json:"name,omitempty"`
+	// ObjectType 对象类型，名称或者编号，eg. analog-input、0
+	ObjectType string `json:"objectType"`
+	// Instance 对象实例号
+	Instance uint32 `json:"instance"`
+	// Property 属性，名称或者编号，默认 present-value
+	Property string `json:"property,omitempty"`
+	// ArrayIndex 数组下标，为空读取整个属性
+	ArrayIndex *uint32 `json:"arrayIndex,omitempty"`
+}
+
+// ReadNode BACnet/IP 读取节点，通过 ReadProperty 读取楼宇自控设备的对象属性
+// 对象属性来自配置 objects 或者消息负荷 msg.Data，格式：
+//
+//	[
+//	  {"name": "temperature", "objectType": "analog-input", "instance": 1},
+//	  {"name": "fan", "objectType": "binary-output", "instance": 3, "property": "present-value"}
+//	]
+//
+// 设备地址来自配置 server，为空则按 deviceInstance 广播 Who-Is 查找，查找到的地址会被缓存。
+// 结果以名称为 key 重新赋值到msg.Data，数组和列表属性输出为数组：
+//
+//	{"temperature": 21.5, "fan": 1}
+//
+// 开启 quality 时额外读取对象的 reliability 和 status-flags，按 fault、out-of-service、overridden 归一化为 GOOD/UNCERTAIN/BAD：
+//
+//	{"temperature": {"value": 21.5, "qualityLevel": "GOOD", "qualityCode": 0}}
+//
+// 所有属性读取成功，流转到`Success`链，否则流转到`Failure`链
+type ReadNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config ReadConfiguration
+	// properties 配置的对象属性
+	properties []ObjectProperty
+	// server 配置的设备地址
+	server net.Addr
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/bacnetRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Broadcast: DefaultBroadcast,
+			Timeout:   3,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.properties, err = parseObjects(x.Config.Objects); err != nil {
+		return err
+	}
+	if x.server, err = resolveServer(x.Config.Server); err != nil {
+		return err
+	}
+	return initClient(&x.SharedNode, ruleConfig, x.Type(), ClientConfig{
+		LocalAddress: x.Config.LocalAddress,
+		Broadcast:    x.Config.Broadcast,
+		Timeout:      time.Duration(x.Config.Timeout) * time.Second,
+		Retries:      x.Config.Retries,
+	})
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	objects, properties := x.Config.Objects, x.properties
+	if len(objects) == 0 {
+		var err error
+		if objects, err = parseReadObjects(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if properties, err = parseObjects(objects); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	addr, err := deviceAddress(client, x.server, x.Config.DeviceInstance)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{}, len(objects))
+	for i, object := range objects {
+		values, err := client.ReadProperty(addr, properties[i])
+		if err != nil {
+			// 设备地址变化后重新查找
+			if x.server == nil && errors.Is(err, ErrTimeout) {
+				client.ForgetDevice(x.Config.DeviceInstance)
+			}
+			ctx.TellFailure(msg, fmt.Errorf("%s: %w", object.key(), err))
+			return
+		}
+		var value interface{} = values
+		if len(values) == 1 {
+			value = values[0]
+		}
+		if x.Config.Quality {
+			q, err := readQuality(client, addr, properties[i])
+			if err != nil {
+				ctx.TellFailure(msg, fmt.Errorf("%s: %w", object.key(), err))
+				return
+			}
+			value = Value{Value: value, Quality: q}
+		}
+		result[object.key()] = value
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "BACnet/IP ReadProperty reader for object properties by device, object type/instance and property id. Routes to Success/Failure"
+}
+
+// readQuality 读取对象的 reliability 和 status-flags，设备不支持的属性（可选属性）忽略
+func readQuality(client *Client, addr net.Addr, p ObjectProperty) (quality.Quality, error) {
+	var reliability uint32
+	values, err := readOptional(client, addr, ObjectProperty{ObjectType: p.ObjectType, Instance: p.Instance, Property: PropertyReliability})
+	if err != nil {
+		return quality.Quality{}, err
+	}
+	if len(values) == 1 {
+		if v, ok := values[0].(uint64); ok {
+			reliability = uint32(v)
+		}
+	}
+	var flags []bool
+	values, err = readOptional(client, addr, ObjectProperty{ObjectType: p.ObjectType, Instance: p.Instance, Property: PropertyStatusFlags})
+	if err != nil {
+		return quality.Quality{}, err
+	}
+	if len(values) == 1 {
+		flags, _ = values[0].([]bool)
+	}
+	return quality.FromBACnet(reliability, flags), nil
+}
+
+// readOptional 读取可选属性，设备返回 Error、Reject 或者 Abort 时返回空值
+func readOptional(client *Client, addr net.Addr, p ObjectProperty) ([]interface{}, error) {
+	values, err := client.ReadProperty(addr, p)
+	var e *Error
+	if errors.As(err, &e) {
+		return nil, nil
+	}
+	return values, err
+}
+
+// initClient 初始化共享客户端
+func initClient(node *base.SharedNode[*Client], ruleConfig types.Config, nodeType string, config ClientConfig) error {
+	return node.InitWithClose(ruleConfig, nodeType, config.LocalAddress, ruleConfig.NodeClientInitNow, func() (*Client, error) {
+		return NewClient(config)
+	}, func(client *Client) error {
+		if client != nil {
+			return client.Close()
+		}
+		return nil
+	})
+}
+
+// deviceAddress 设备地址，未配置 server 则通过 Who-Is 按设备实例号查找
+func deviceAddress(client *Client, server net.Addr, deviceInstance uint32) (net.Addr, error) {
+	if server != nil {
+		return server, nil
+	}
+	return client.FindDevice(deviceInstance)
+}
+
+// resolveServer 解析配置的设备地址，为空返回 nil，格式见 ParseAddress
+func resolveServer(server string) (net.Addr, error) {
+	if server == "" {
+		return nil, nil
+	}
+	return ParseAddress(server)
+}
+
+// key 输出的 key，名称为空使用 objectType:instance:property
+func (o Object) key() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	property := o.Property
+	if property == "" {
+		property = "present-value"
+	}
+	return fmt.Sprintf("%s:%d:%s", o.ObjectType, o.Instance, property)
+}
+
+// parseObjects 解析对象类型和属性
+func parseObjects(objects []Object) ([]ObjectProperty, error) {
+	properties := make([]ObjectProperty, 0, len(objects))
+	for _, object := range objects {
+		objectType, err := ParseObjectType(object.ObjectType)
+		if err != nil {
+			return nil, err
+		}
+		property := uint32(PropertyPresentValue)
+		if object.Property != "" {
+			if property, err = ParseProperty(object.Property); err != nil {
+				return nil, err
+			}
+		}
+		properties = append(properties, ObjectProperty{
+			ObjectType: objectType,
+			Instance:   object.Instance,
+			Property:   property,
+			ArrayIndex: object.ArrayIndex,
+		})
+	}
+	return properties, nil
+}
+
+// parseReadObjects 解析消息负荷中的对象属性
+func parseReadObjects(data string) ([]Object, error) {
+	var list []interface{}
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		return nil, err
+	}
+	var objects []Object
+	if err := maps.Map2Struct(list, &objects); err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no bacnet objects to read")
+	}
+	return objects, nil
+}
@@ -0,0 +1,510 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/conntest"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testDevice 测试用的 BACnet/IP 设备，只实现 Who-Is、ReadProperty、WriteProperty 和 ReadRange
+type testDevice struct {
+	conn     *net.UDPConn
+	instance uint32
+	// network 和 mac 不为 0 时模拟 BACnet/IP 到 MS/TP 路由器后面的设备
+	network uint16
+	mac     byte
+	mu      sync.Mutex
+	// values 属性值，key 为对象标识<<32 | 属性，值为数组元素的应用标签编码
+	values map[uint64][][]byte
+	// segmented 读取整个数组时返回 Abort，模拟需要分段的响应
+	segmented bool
+	// writes 收到的写入请求
+	writes []testWrite
+	// logs trend-log 的记录，key 为对象标识
+	logs map[uint32][]testRecord
+	// sequence ReadRange 响应是否包含记录序号
+	sequence bool
+}
+
+// testWrite 收到的写入请求
+type testWrite struct {
+	id       uint32
+	property uint32
+	// index 数组下标，0 表示写入整个属性
+	index    uint32
+	value    []byte
+	priority int
+}
+
+func startTestDevice(t *testing.T, instance uint32) *testDevice {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	d := &testDevice{conn: conn, instance: instance, values: map[uint64][][]byte{}, logs: map[uint32][]testRecord{}}
+	go d.serve()
+	return d
+}
+
+func (d *testDevice) addr() string {
+	return d.conn.LocalAddr().String()
+}
+
+func (d *testDevice) set(objectType uint16, instance, property uint32, value ...[]byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.values[uint64(encodeObjectId(objectType, instance))<<32|uint64(property)] = value
+}
+
+func (d *testDevice) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, src, err := d.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		npdu, _, ok := parseBVLC(buf[:n])
+		if !ok || !d.routed(npdu) {
+			continue
+		}
+		apdu, _, _, ok := parseNPDU(npdu)
+		if !ok {
+			continue
+		}
+		resp := d.handle(apdu)
+		if resp == nil {
+			continue
+		}
+		packet := []byte{bvlcType, bvlcOriginalUnicast, 0, 0, npduVersion, 0}
+		if d.network != 0 {
+			packet[5] = npduSource
+			packet = append(packet, byte(d.network>>8), byte(d.network), 1, d.mac)
+		}
+		packet = append(packet, resp...)
+		binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+		_, _ = d.conn.WriteToUDP(packet, src)
+	}
+}
+
+// routed 路由器后面的设备只接收目的地址为自身或者全局广播的请求
+func (d *testDevice) routed(npdu []byte) bool {
+	if d.network == 0 {
+		return true
+	}
+	if len(npdu) < 5 || npdu[1]&npduDestination == 0 {
+		return false
+	}
+	dnet := binary.BigEndian.Uint16(npdu[2:])
+	return dnet == globalNetwork || dnet == d.network && npdu[4] == 1 && len(npdu) > 5 && npdu[5] == d.mac
+}
+
+// handle 处理请求的 APDU，返回响应的 APDU，不需要响应返回 nil
+func (d *testDevice) handle(apdu []byte) []byte {
+	var resp []byte
+	switch {
+	case apdu[0]>>4 == pduUnconfirmedRequest && apdu[1] == serviceWhoIs:
+		iam := []byte{0xc4, 0, 0, 0, 0, 0x22, 0x05, 0xc4, 0x91, 0x03, 0x21, 0x0f}
+		binary.BigEndian.PutUint32(iam[1:], encodeObjectId(objectTypeDevice, d.instance))
+		resp = append([]byte{pduUnconfirmedRequest << 4, serviceIAm}, iam...)
+	case apdu[0]>>4 == pduConfirmedRequest && apdu[3] == serviceReadProperty:
+		invokeId := apdu[2]
+		request := apdu[4:]
+		id := binary.BigEndian.Uint32(request[1:5])
+		// 通配的设备实例号按自身的设备对象响应
+		if id == encodeObjectId(objectTypeDevice, wildcardDeviceInstance) {
+			id = encodeObjectId(objectTypeDevice, d.instance)
+		}
+		h, size, _ := decodeTag(request[5:])
+		end := 5 + size + h.length
+		property := uint32(decodeUnsigned(request[5+size : end]))
+		index := -1
+		if len(request) > end {
+			h, size, _ = decodeTag(request[end:])
+			index = int(decodeUnsigned(request[end+size : end+size+h.length]))
+		}
+		d.mu.Lock()
+		elements, ok := d.values[uint64(id)<<32|uint64(property)]
+		segmented := d.segmented
+		d.mu.Unlock()
+		var value []byte
+		switch {
+		case !ok:
+			// unknown-object
+			resp = []byte{pduError << 4, invokeId, serviceReadProperty, 0x91, 0x01, 0x91, 0x1f}
+		case index < 0 && segmented && len(elements) > 1:
+			// segmentation-not-supported
+			resp = []byte{pduAbort << 4, invokeId, 0x04}
+		case index == 0:
+			value = []byte{0x21, byte(len(elements))}
+		case index > 0:
+			value = elements[index-1]
+		default:
+			for _, e := range elements {
+				value = append(value, e...)
+			}
+		}
+		if value == nil {
+			break
+		}
+		resp = []byte{pduComplexAck << 4, invokeId, serviceReadProperty}
+		resp = append(resp, request...)
+		resp = append(resp, 0x3e)
+		resp = append(resp, value...)
+		resp = append(resp, 0x3f)
+	case apdu[0]>>4 == pduConfirmedRequest && apdu[3] == serviceWriteProperty:
+		invokeId := apdu[2]
+		request := apdu[4:]
+		w := testWrite{id: binary.BigEndian.Uint32(request[1:5]), priority: -1}
+		h, size, _ := decodeTag(request[5:])
+		w.property = uint32(decodeUnsigned(request[5+size : 5+size+h.length]))
+		start := 5 + size + h.length
+		if h, size, _ = decodeTag(request[start:]); !h.opening {
+			w.index = uint32(decodeUnsigned(request[start+size : start+size+h.length]))
+			start += size + h.length
+		}
+		start++
+		_, n, _ := decodeValues(request[start:])
+		w.value = append([]byte(nil), request[start:start+n]...)
+		if end := start + n + 1; end < len(request) {
+			w.priority = int(request[end+1])
+		}
+		d.mu.Lock()
+		_, ok := d.values[uint64(w.id)<<32|uint64(PropertyPresentValue)]
+		if ok {
+			d.writes = append(d.writes, w)
+		}
+		d.mu.Unlock()
+		if !ok {
+			// write-access-denied
+			resp = []byte{pduError << 4, invokeId, serviceWriteProperty, 0x91, 0x02, 0x91, 0x28}
+			break
+		}
+		resp = []byte{pduSimpleAck << 4, invokeId, serviceWriteProperty}
+	case apdu[0]>>4 == pduConfirmedRequest && apdu[3] == serviceReadRange:
+		resp = d.readRange(apdu[2], apdu[4:])
+	}
+	return resp
+}
+
+func TestReadNode(t *testing.T) {
+	device := startTestDevice(t, 1001)
+	defer device.conn.Close()
+	device.set(0, 1, PropertyPresentValue, []byte{0x44, 0x41, 0xac, 0x00, 0x00})
+	device.set(4, 3, PropertyPresentValue, []byte{0x91, 0x01})
+	device.set(0, 1, 77, []byte{0x75, 0x05, 0x00, 'r', 'o', 'o', 'm'})
+	device.set(8, 1001, 76, []byte{0xc4, 0x02, 0x00, 0x03, 0xe9}, []byte{0xc4, 0x00, 0x00, 0x00, 0x01})
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+
+	// 非法对象类型
+	_, err := test.CreateAndInitNode("x/bacnetRead", types.Configuration{
+		"server":  device.addr(),
+		"objects": []map[string]interface{}{{"objectType": "unknown", "instance": 1}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/bacnetRead", types.Configuration{
+		"server": device.addr(),
+		"objects": []map[string]interface{}{
+			{"name": "temperature", "objectType": "analog-input", "instance": 1},
+			{"name": "fan", "objectType": "binaryOutput", "instance": 3, "property": "presentValue"},
+			{"objectType": 0, "instance": 1, "property": "object-name"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	// 通过 Who-Is 查找设备
+	discoverNode, err := test.CreateAndInitNode("x/bacnetRead", types.Configuration{
+		"deviceInstance": 1001,
+		"broadcast":      device.addr(),
+		"timeout":        1,
+	}, Registry)
+	assert.Nil(t, err)
+	defer discoverNode.Destroy()
+
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 21.5, values["temperature"])
+		assert.Equal(t, float64(1), values["fan"])
+		assert.Equal(t, "room", values["0:1:object-name"])
+	})
+
+	// analog-input 1 out-of-service，binary-output 3 reliability 为 unreliable-other
+	device.set(0, 1, PropertyStatusFlags, []byte{0x82, 0x04, 0x10})
+	device.set(4, 3, PropertyReliability, []byte{0x91, 0x07})
+	qualityNode, err := test.CreateAndInitNode("x/bacnetRead", types.Configuration{
+		"server":  device.addr(),
+		"quality": true,
+		"objects": []map[string]interface{}{
+			{"name": "temperature", "objectType": "analog-input", "instance": 1},
+			{"name": "fan", "objectType": "binary-output", "instance": 3},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer qualityNode.Destroy()
+
+	test.NodeOnMsg(t, qualityNode, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]Value)
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 21.5, values["temperature"].Value)
+		assert.Equal(t, quality.Uncertain, values["temperature"].Level)
+		assert.Equal(t, quality.Bad, values["fan"].Level)
+		assert.Equal(t, uint32(7), values["fan"].Code)
+	})
+
+	test.NodeOnMsg(t, discoverNode, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "READ",
+			Data:       `[{"name": "objects", "objectType": "device", "instance": 1001, "property": "object-list"}]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "UNKNOWN_OBJECT",
+			Data:       `[{"objectType": "analog-value", "instance": 9}]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "UNKNOWN_OBJECT" {
+			assert.Equal(t, types.Failure, relationType)
+			assert.NotNil(t, err)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, []interface{}{"device:1001", "analog-input:1"}, values["objects"])
+	})
+}
+
+func TestDiscoverNode(t *testing.T) {
+	device := startTestDevice(t, 1001)
+	defer device.conn.Close()
+	device.mu.Lock()
+	device.segmented = true
+	device.mu.Unlock()
+	device.set(8, 1001, 76, []byte{0xc4, 0x02, 0x00, 0x03, 0xe9}, []byte{0xc4, 0x00, 0x00, 0x00, 0x01})
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DiscoverNode{})
+
+	// 范围必须同时提供
+	_, err := test.CreateAndInitNode("x/bacnetDiscover", types.Configuration{
+		"broadcast": device.addr(),
+		"lowLimit":  1,
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/bacnetDiscover", types.Configuration{
+		"broadcast":      device.addr(),
+		"timeout":        1,
+		"readObjectList": true,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	rangeNode, err := test.CreateAndInitNode("x/bacnetDiscover", types.Configuration{
+		"broadcast": device.addr(),
+		"timeout":   1,
+		"lowLimit":  2000,
+		"highLimit": 3000,
+	}, Registry)
+	assert.Nil(t, err)
+	defer rangeNode.Destroy()
+
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "DISCOVER",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 1500,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var devices []map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &devices))
+		assert.Equal(t, 1, len(devices))
+		assert.Equal(t, float64(1001), devices[0]["deviceInstance"])
+		assert.Equal(t, device.addr(), devices[0]["address"])
+		assert.Equal(t, float64(15), devices[0]["vendorId"])
+		// 需要分段的对象列表按下标读取
+		assert.Equal(t, []interface{}{"device:1001", "analog-input:1"}, devices[0]["objects"])
+	})
+
+	test.NodeOnMsg(t, rangeNode, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "DISCOVER",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 1500,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "[]", msg.GetData())
+	})
+}
+
+func TestWriteNode(t *testing.T) {
+	device := startTestDevice(t, 1001)
+	defer device.conn.Close()
+	device.set(1, 1, PropertyPresentValue, []byte{0x44, 0, 0, 0, 0})
+	device.set(4, 3, PropertyPresentValue, []byte{0x91, 0x00})
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+
+	// 非法优先级
+	_, err := test.CreateAndInitNode("x/bacnetWrite", types.Configuration{
+		"server":   device.addr(),
+		"priority": 17,
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/bacnetWrite", types.Configuration{
+		"server":   device.addr(),
+		"priority": 8,
+		"items": []map[string]interface{}{
+			{"objectType": "analog-output", "instance": 1, "value": "${metadata.setpoint}"},
+			{"objectType": "binary-output", "instance": 3, "value": "active", "priority": 1},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgNode, err := test.CreateAndInitNode("x/bacnetWrite", types.Configuration{
+		"server": device.addr(),
+	}, Registry)
+	assert.Nil(t, err)
+	defer msgNode.Destroy()
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("setpoint", "21.5")
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   metadata,
+		DataType:   types.JSON,
+		MsgType:    "WRITE",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+
+	test.NodeOnMsg(t, msgNode, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "RELINQUISH",
+			Data:       `[{"objectType": "analog-output", "instance": 1, "value": null, "priority": 8}]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "DENIED",
+			Data:       `[{"objectType": "analog-output", "instance": 9, "value": 1}]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "DENIED" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+	})
+
+	device.mu.Lock()
+	defer device.mu.Unlock()
+	assert.Equal(t, 3, len(device.writes))
+	assert.Equal(t, testWrite{id: encodeObjectId(1, 1), property: PropertyPresentValue,
+		value: []byte{0x44, 0x41, 0xac, 0x00, 0x00}, priority: 8}, device.writes[0])
+	assert.Equal(t, testWrite{id: encodeObjectId(4, 3), property: PropertyPresentValue,
+		value: []byte{0x91, 0x01}, priority: 1}, device.writes[1])
+	// 写入 Null 释放优先级
+	assert.Equal(t, testWrite{id: encodeObjectId(1, 1), property: PropertyPresentValue,
+		value: []byte{0x00}, priority: 8}, device.writes[2])
+}
+
+func TestClientTimeout(t *testing.T) {
+	// 没有设备响应的地址
+	silent, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	defer silent.Close()
+
+	client, err := NewClient(ClientConfig{Timeout: 100 * time.Millisecond, Retries: 1})
+	assert.Nil(t, err)
+	defer client.Close()
+	start := time.Now()
+	_, err = client.ReadProperty(silent.LocalAddr().(*net.UDPAddr), ObjectProperty{Instance: 1, Property: PropertyPresentValue})
+	assert.True(t, err != nil && time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestConnectionTest(t *testing.T) {
+	device := startTestDevice(t, 1001)
+	defer device.conn.Close()
+	device.set(0, 1, PropertyPresentValue, []byte{0x44, 0x41, 0xac, 0x00, 0x00})
+	device.set(8, 1001, 77, []byte{0x74, 0x00, 'a', 'h', 'u'})
+
+	result, err := conntest.TestConnection(context.Background(), "x/bacnetRead", map[string]interface{}{
+		"server":  device.addr(),
+		"objects": []map[string]interface{}{{"name": "temperature", "objectType": "analog-input", "instance": 1}},
+	})
+	assert.Nil(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "device ahu at "+device.addr(), result.Steps[1].Message)
+	assert.Equal(t, map[string]interface{}{"temperature": float32(21.5)}, result.Sample)
+
+	// 通过 Who-Is 查找设备，样本对象不存在
+	result, _ = conntest.TestConnection(context.Background(), "x/bacnetWrite", map[string]interface{}{
+		"deviceInstance":   1001,
+		"broadcast":        device.addr(),
+		"timeout":          1,
+		conntest.KeySample: map[string]interface{}{"objectType": "analog-input", "instance": 2},
+	})
+	assert.False(t, result.Success)
+	assert.Equal(t, 3, len(result.Steps))
+	assert.True(t, result.Steps[1].Success)
+	assert.Equal(t, conntest.StepRead, result.Steps[2].Name)
+	assert.Equal(t, "read: bacnet error: unknown object", result.Error)
+}
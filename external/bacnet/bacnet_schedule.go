@@ -0,0 +1,339 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// schedule 节点的操作
+const (
+	ScheduleActionRead  = "read"
+	ScheduleActionWrite = "write"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ScheduleNode{})
+}
+
+// ScheduleConfiguration schedule 节点配置
+type ScheduleConfiguration struct {
+	// Server 设备地址，格式：host:port，端口默认 47808，为空则通过 Who-Is 按设备实例号查找
+	Server string `json:"server" label:"Server" desc:"Device address, format: host:port, port defaults to 47808. Devices behind a BACnet/IP to MS/TP router: net:mac@router-host:port, eg. 5:12@192.168.1.10. Devices on a directly connected MS/TP bus: mstp:mac. Empty discovers the device by instance with Who-Is" ref:"primary"`
+	// DeviceInstance 设备实例号，server 为空时用于查找设备地址
+	DeviceInstance uint32 `json:"deviceInstance" label:"Device instance" desc:"Device instance number, used to discover the device address when server is empty"`
+	// LocalAddress 本地监听地址，为空使用随机端口
+	LocalAddress string `json:"localAddress" label:"Local address" desc:"Local UDP address, empty uses a random port. Use :47808 if devices broadcast their I-Am replies. Connect directly to an MS/TP bus with mstp:///dev/ttyUSB0?mac=3&baud=38400&maxMaster=127&maxInfoFrames=1 or through a serial server with mstp+tcp://host:port?mac=3"`
+	// Broadcast 广播地址，用于 Who-Is 查找设备
+	Broadcast string `json:"broadcast" label:"Broadcast" desc:"Broadcast address for Who-Is discovery"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Request timeout in seconds"`
+	// Retries 超时重试次数
+	Retries int `json:"retries" label:"Retries" desc:"Retries after a request timeout"`
+	// Instance schedule 对象实例号
+	Instance uint32 `json:"instance" label:"Instance" desc:"Schedule object instance"`
+	// Action 操作：read 读取日程，write 把 msg.Data 中的日程写入设备
+	Action string `json:"action" label:"Action" desc:"read reads the schedule, write writes the schedule in msg.Data"`
+	// ValueType 写入日程值的类型：real、unsigned、enumerated、boolean 等，为空为 real
+	ValueType string `json:"valueType" label:"Value type" desc:"Type of the scheduled values when writing: real, double, unsigned, signed, enumerated, boolean. Empty uses real"`
+}
+
+// Schedule schedule 对象的日程
+type Schedule struct {
+	// PresentValue 当前生效的值
+	PresentValue interface{} `json:"presentValue,omitempty"`
+	// ScheduleDefault 没有日程时的默认值
+	ScheduleDefault interface{} `json:"scheduleDefault,omitempty"`
+	// WeeklySchedule 周日程，key 为星期名称 monday-sunday
+	WeeklySchedule map[string][]TimeValue `json:"weeklySchedule,omitempty"`
+	// EffectivePeriod 日程生效的日期范围
+	EffectivePeriod []interface{} `json:"effectivePeriod,omitempty"`
+	// ExceptionSchedule 例外日程，按原始结构输出
+	ExceptionSchedule []interface{} `json:"exceptionSchedule,omitempty"`
+}
+
+// ScheduleWrite 写入的日程，weeklySchedule 为 7 天的数组时写入整个周日程，
+// 为星期名称（或者 1-7）到时间-值对的对象时只写入这些天
+type ScheduleWrite struct {
+	WeeklySchedule  json.RawMessage `json:"weeklySchedule,omitempty"`
+	ScheduleDefault interface{}     `json:"scheduleDefault,omitempty"`
+}
+
+// ScheduleNode BACnet/IP schedule 节点，读取或者写入 schedule 对象的周日程和默认值，用于设定值排程
+// action 为 read 时读取 present-value、schedule-default、weekly-schedule、effective-period 和 exception-schedule，结果赋值到msg.Data：
+//
+//	{
+//	  "presentValue": 21, "scheduleDefault": 16,
+//	  "weeklySchedule": {"monday": [{"time": "08:00:00", "value": 21}, {"time": "18:00:00", "value": null}], ...}
+//	}
+//
+// action 为 write 时把msg.Data中的日程写入设备，weeklySchedule 为 7 天的数组时写入整个周日程，
+// 为星期名称到时间-值对的对象时按数组下标只写入这些天，value 为 null 表示恢复 schedule-default：
+//
+//	{"weeklySchedule": {"monday": [{"time": "07:30", "value": 22}, {"time": "17:00", "value": null}]}, "scheduleDefault": 16}
+//
+// 周日程超过一个 APDU 时按天逐个读取。操作成功，流转到`Success`链，否则流转到`Failure`链
+type ScheduleNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config ScheduleConfiguration
+	// server 配置的设备地址
+	server net.Addr
+}
+
+// Type 返回组件类型
+func (x *ScheduleNode) Type() string {
+	return "x/bacnetSchedule"
+}
+
+// New 默认参数
+func (x *ScheduleNode) New() types.Node {
+	return &ScheduleNode{
+		Config: ScheduleConfiguration{
+			Broadcast: DefaultBroadcast,
+			Timeout:   3,
+			Action:    ScheduleActionRead,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ScheduleNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.Config.Action = strings.ToLower(strings.TrimSpace(x.Config.Action))
+	if x.Config.Action == "" {
+		x.Config.Action = ScheduleActionRead
+	}
+	if x.Config.Action != ScheduleActionRead && x.Config.Action != ScheduleActionWrite {
+		return fmt.Errorf("unsupported schedule action: %s", x.Config.Action)
+	}
+	if _, err = EncodeValue(x.Config.ValueType, 0, 0); err != nil {
+		return err
+	}
+	if x.server, err = resolveServer(x.Config.Server); err != nil {
+		return err
+	}
+	return initClient(&x.SharedNode, ruleConfig, x.Type(), ClientConfig{
+		LocalAddress: x.Config.LocalAddress,
+		Broadcast:    x.Config.Broadcast,
+		Timeout:      time.Duration(x.Config.Timeout) * time.Second,
+		Retries:      x.Config.Retries,
+	})
+}
+
+// OnMsg 处理消息
+func (x *ScheduleNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var write ScheduleWrite
+	if x.Config.Action == ScheduleActionWrite {
+		if err := json.Unmarshal([]byte(msg.GetData()), &write); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	addr, err := deviceAddress(client, x.server, x.Config.DeviceInstance)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if x.Config.Action == ScheduleActionWrite {
+		err = x.write(client, addr, write)
+	} else {
+		var schedule *Schedule
+		if schedule, err = x.read(client, addr); err == nil {
+			var bytes []byte
+			if bytes, err = json.Marshal(schedule); err == nil {
+				msg.SetData(str.ToString(bytes))
+			}
+		}
+	}
+	if err != nil {
+		// 设备地址变化后重新查找
+		if x.server == nil && errors.Is(err, ErrTimeout) {
+			client.ForgetDevice(x.Config.DeviceInstance)
+		}
+		ctx.TellFailure(msg, fmt.Errorf("schedule:%d: %w", x.Config.Instance, err))
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ScheduleNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ScheduleNode) Desc() string {
+	return "BACnet/IP schedule object reader and writer for weekly schedules and schedule default. Routes to Success/Failure"
+}
+
+// property schedule 对象的属性
+func (x *ScheduleNode) property(property uint32) ObjectProperty {
+	return ObjectProperty{ObjectType: objectTypes["schedule"], Instance: x.Config.Instance, Property: property}
+}
+
+// read 读取日程，可选属性不存在时忽略
+func (x *ScheduleNode) read(client *Client, addr net.Addr) (*Schedule, error) {
+	schedule := &Schedule{}
+	values, err := client.ReadProperty(addr, x.property(PropertyPresentValue))
+	if err != nil {
+		return nil, err
+	}
+	schedule.PresentValue = singleValue(values)
+	if values, err = readOptional(client, addr, x.property(PropertyScheduleDefault)); err != nil {
+		return nil, err
+	}
+	schedule.ScheduleDefault = singleValue(values)
+	if schedule.WeeklySchedule, err = x.readWeeklySchedule(client, addr); err != nil {
+		return nil, err
+	}
+	if schedule.EffectivePeriod, err = readOptional(client, addr, x.property(PropertyEffectivePeriod)); err != nil {
+		return nil, err
+	}
+	if schedule.ExceptionSchedule, err = readOptional(client, addr, x.property(PropertyExceptionSchedule)); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// readWeeklySchedule 读取周日程，超过一个 APDU 时设备会要求分段，这时按天逐个读取
+func (x *ScheduleNode) readWeeklySchedule(client *Client, addr net.Addr) (map[string][]TimeValue, error) {
+	p := x.property(PropertyWeeklySchedule)
+	values, err := client.ReadProperty(addr, p)
+	var e *Error
+	switch {
+	case err == nil:
+	case errors.As(err, &e) && e.Kind == "error":
+		// 没有周日程的 schedule 对象
+		return nil, nil
+	case errors.As(err, &e) || errors.Is(err, ErrSegmented):
+		values = nil
+		for i := uint32(1); i <= 7; i++ {
+			index := i
+			p.ArrayIndex = &index
+			day, err := client.ReadProperty(addr, p)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, day...)
+		}
+	default:
+		return nil, err
+	}
+	days, err := DecodeWeeklySchedule(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(days) != 7 {
+		return nil, fmt.Errorf("bacnet weekly schedule requires 7 days, got %d", len(days))
+	}
+	weekly := make(map[string][]TimeValue, len(days))
+	for i, day := range days {
+		weekly[weekdays[i]] = day
+	}
+	return weekly, nil
+}
+
+// write 写入周日程和默认值
+func (x *ScheduleNode) write(client *Client, addr net.Addr, write ScheduleWrite) error {
+	if len(write.WeeklySchedule) == 0 && write.ScheduleDefault == nil {
+		return errors.New("no bacnet schedule to write")
+	}
+	if len(write.WeeklySchedule) > 0 {
+		if err := x.writeWeeklySchedule(client, addr, write.WeeklySchedule); err != nil {
+			return err
+		}
+	}
+	if write.ScheduleDefault != nil {
+		value, err := EncodeValue(x.Config.ValueType, 0, write.ScheduleDefault)
+		if err != nil {
+			return err
+		}
+		return client.WriteProperty(addr, x.property(PropertyScheduleDefault), value, 0)
+	}
+	return nil
+}
+
+// writeWeeklySchedule 数组写入整个周日程，对象按数组下标只写入指定的天
+func (x *ScheduleNode) writeWeeklySchedule(client *Client, addr net.Addr, data json.RawMessage) error {
+	p := x.property(PropertyWeeklySchedule)
+	var week [][]TimeValue
+	if err := json.Unmarshal(data, &week); err == nil {
+		value, err := EncodeWeeklySchedule(week, x.Config.ValueType)
+		if err != nil {
+			return err
+		}
+		return client.WriteProperty(addr, p, value, 0)
+	}
+	var days map[string][]TimeValue
+	if err := json.Unmarshal(data, &days); err != nil {
+		return fmt.Errorf("invalid weekly schedule: %w", err)
+	}
+	// 先检查全部的天，避免只写入了一部分
+	indexes := make(map[uint32][]byte, len(days))
+	for name, day := range days {
+		index, err := ParseWeekday(name)
+		if err != nil {
+			return err
+		}
+		if indexes[index], err = EncodeDailySchedule(day, x.Config.ValueType); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for i := uint32(1); i <= 7; i++ {
+		value, ok := indexes[i]
+		if !ok {
+			continue
+		}
+		index := i
+		p.ArrayIndex = &index
+		if err := client.WriteProperty(addr, p, value, 0); err != nil {
+			return fmt.Errorf("%s: %w", weekdays[i-1], err)
+		}
+	}
+	return nil
+}
+
+// singleValue 单个值的属性返回值本身
+func singleValue(values []interface{}) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestWeeklySchedule(t *testing.T) {
+	for s, v := range map[string]uint32{"monday": 1, "Fri": 5, "7": 7, "SUNDAY": 7} {
+		day, err := ParseWeekday(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, v, day, s)
+	}
+	_, err := ParseWeekday("funday")
+	assert.NotNil(t, err)
+	_, err = ParseWeekday("8")
+	assert.NotNil(t, err)
+
+	day, err := EncodeDailySchedule([]TimeValue{{Time: "07:30", Value: 22}, {Time: "17:00:00.50", Value: nil}}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x0e, 0xb4, 7, 30, 0, 0, 0x44, 0x41, 0xb0, 0, 0, 0xb4, 17, 0, 0, 50, 0x00, 0x0f}, day)
+	_, err = EncodeDailySchedule([]TimeValue{{Time: "25:00", Value: 1}}, "")
+	assert.NotNil(t, err)
+	_, err = EncodeDailySchedule([]TimeValue{{Time: "08:00", Value: "on"}}, ValueTypeReal)
+	assert.NotNil(t, err)
+	_, err = EncodeWeeklySchedule([][]TimeValue{{}}, "")
+	assert.NotNil(t, err)
+
+	week := make([][]TimeValue, 7)
+	week[0] = []TimeValue{{Time: "08:00:00", Value: uint64(1)}, {Time: "18:00:00", Value: uint64(0)}}
+	encoded, err := EncodeWeeklySchedule(week, ValueTypeEnumerated)
+	assert.Nil(t, err)
+	values, _, err := decodeValues(encoded)
+	assert.Nil(t, err)
+	decoded, err := DecodeWeeklySchedule(values)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, len(decoded))
+	assert.Equal(t, week[0], decoded[0])
+	assert.Equal(t, 0, len(decoded[6]))
+}
+
+func TestScheduleNode(t *testing.T) {
+	device := startTestDevice(t, 1001)
+	defer device.conn.Close()
+	device.set(17, 1, PropertyPresentValue, []byte{0x44, 0x41, 0xa8, 0x00, 0x00})
+	device.set(17, 1, PropertyScheduleDefault, []byte{0x44, 0x41, 0x80, 0x00, 0x00})
+	week := [][]byte{{0x0e, 0xb4, 8, 0, 0, 0, 0x44, 0x41, 0xa8, 0, 0, 0xb4, 18, 0, 0, 0, 0x00, 0x0f}}
+	for i := 1; i < 7; i++ {
+		week = append(week, []byte{0x0e, 0x0f})
+	}
+	device.set(17, 1, PropertyWeeklySchedule, week...)
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ScheduleNode{})
+
+	_, err := test.CreateAndInitNode("x/bacnetSchedule", types.Configuration{"action": "delete"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/bacnetSchedule", types.Configuration{"valueType": "float"}, Registry)
+	assert.NotNil(t, err)
+
+	readNode, err := test.CreateAndInitNode("x/bacnetSchedule", types.Configuration{
+		"server":   device.addr(),
+		"instance": 1,
+	}, Registry)
+	assert.Nil(t, err)
+	defer readNode.Destroy()
+
+	read := func() {
+		test.NodeOnMsg(t, readNode, []test.Msg{{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "READ",
+			Data:       `{}`,
+			AfterSleep: time.Millisecond * 200,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			var schedule Schedule
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &schedule))
+			assert.Equal(t, 21.0, schedule.PresentValue)
+			assert.Equal(t, 16.0, schedule.ScheduleDefault)
+			assert.Equal(t, 7, len(schedule.WeeklySchedule))
+			assert.Equal(t, []TimeValue{{Time: "08:00:00", Value: 21.0}, {Time: "18:00:00", Value: nil}}, schedule.WeeklySchedule["monday"])
+			assert.Equal(t, []TimeValue{}, schedule.WeeklySchedule["sunday"])
+		})
+	}
+	read()
+	// 周日程超过一个 APDU 时按天读取
+	device.mu.Lock()
+	device.segmented = true
+	device.mu.Unlock()
+	read()
+
+	writeNode, err := test.CreateAndInitNode("x/bacnetSchedule", types.Configuration{
+		"server":   device.addr(),
+		"instance": 1,
+		"action":   "write",
+	}, Registry)
+	assert.Nil(t, err)
+	defer writeNode.Destroy()
+
+	test.NodeOnMsg(t, writeNode, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "DAYS",
+			Data:       `{"weeklySchedule": {"fri": [{"time": "07:30", "value": 22}], "monday": []}, "scheduleDefault": 18}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "WEEK",
+			Data:       `{"weeklySchedule": [[], [], [], [], [], [], [{"time": "10:00", "value": 20}]]}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "INVALID",
+			Data:       `{"weeklySchedule": {"funday": []}}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "INVALID" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+	})
+
+	device.mu.Lock()
+	defer device.mu.Unlock()
+	id := encodeObjectId(17, 1)
+	assert.Equal(t, []testWrite{
+		{id: id, property: PropertyWeeklySchedule, index: 1, value: []byte{0x0e, 0x0f}, priority: -1},
+		{id: id, property: PropertyWeeklySchedule, index: 5, value: []byte{0x0e, 0xb4, 7, 30, 0, 0, 0x44, 0x41, 0xb0, 0, 0, 0x0f}, priority: -1},
+		{id: id, property: PropertyScheduleDefault, value: []byte{0x44, 0x41, 0x90, 0, 0}, priority: -1},
+		{id: id, property: PropertyWeeklySchedule, value: []byte{0x0e, 0x0f, 0x0e, 0x0f, 0x0e, 0x0f, 0x0e, 0x0f, 0x0e, 0x0f, 0x0e, 0x0f,
+			0x0e, 0xb4, 10, 0, 0, 0, 0x44, 0x41, 0xa0, 0, 0, 0x0f}, priority: -1},
+	}, device.writes)
+}
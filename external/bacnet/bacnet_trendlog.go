@@ -0,0 +1,296 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&TrendLogNode{})
+}
+
+// TrendLogConfiguration trend-log 读取节点配置
+type TrendLogConfiguration struct {
+	// Server 设备地址，格式：host:port，端口默认 47808，为空则通过 Who-Is 按设备实例号查找
+	Server string `json:"server" label:"Server" desc:"Device address, format: host:port, port defaults to 47808. Devices behind a BACnet/IP to MS/TP router: net:mac@router-host:port, eg. 5:12@192.168.1.10. Devices on a directly connected MS/TP bus: mstp:mac. Empty discovers the device by instance with Who-Is" ref:"primary"`
+	// DeviceInstance 设备实例号，server 为空时用于查找设备地址
+	DeviceInstance uint32 `json:"deviceInstance" label:"Device instance" desc:"Device instance number, used to discover the device address when server is empty"`
+	// LocalAddress 本地监听地址，为空使用随机端口
+	LocalAddress string `json:"localAddress" label:"Local address" desc:"Local UDP address, empty uses a random port. Use :47808 if devices broadcast their I-Am replies. Connect directly to an MS/TP bus with mstp:///dev/ttyUSB0?mac=3&baud=38400&maxMaster=127&maxInfoFrames=1 or through a serial server with mstp+tcp://host:port?mac=3"`
+	// Broadcast 广播地址，用于 Who-Is 查找设备
+	Broadcast string `json:"broadcast" label:"Broadcast" desc:"Broadcast address for Who-Is discovery"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Request timeout in seconds"`
+	// Retries 超时重试次数
+	Retries int `json:"retries" label:"Retries" desc:"Retries after a request timeout"`
+	// ObjectType 对象类型，默认 trend-log
+	ObjectType string `json:"objectType" label:"Object type" desc:"Object type, defaults to trend-log"`
+	// Instance 对象实例号
+	Instance uint32 `json:"instance" label:"Instance" desc:"Trend log object instance"`
+	// By 读取范围的方式：position、sequence、time，默认 position
+	By string `json:"by" label:"Range by" desc:"Range type: position (1-based record index), sequence (record sequence number) or time"`
+	// Reference 起始位置、序号或者时间（RFC3339 或者 2006-01-02 15:04:05），允许使用 ${} 占位符变量
+	Reference string `json:"reference" label:"Reference" desc:"Start position, sequence number or time (RFC3339 or 2006-01-02 15:04:05), allows ${} placeholders. Empty reads from the first record, or the latest records when count is negative"`
+	// Count 每次请求读取的记录数，负数表示从起始位置向前读取
+	Count int32 `json:"count" label:"Count" desc:"Records per request, negative reads backwards from the reference. Keep the response within one APDU"`
+	// MaxRecords 最多读取的记录数，超过 count 时根据 moreItems 继续读取，0 表示只请求一次
+	MaxRecords int `json:"maxRecords" label:"Max records" desc:"Max records to read following moreItems with further requests, 0 sends a single request"`
+	// TimeZone 设备所在时区，IANA 名称，为空使用本地时区
+	TimeZone string `json:"timeZone" label:"Time zone" desc:"Device time zone (IANA name) used for record timestamps and time references, empty uses local time"`
+}
+
+// TrendLogNode BACnet/IP trend-log 读取节点，通过 ReadRange 按位置、序号或者时间读取 log-buffer 中的历史记录，用于能耗报表等场景
+// 结果赋值到msg.Data，记录的值类型见 LogRecord：
+//
+//	{
+//	  "firstItem": true, "lastItem": false, "moreItems": true, "firstSequenceNumber": 1,
+//	  "records": [{"sequenceNumber": 1, "timestamp": "2025-01-02T08:00:00+08:00", "kind": "value", "value": 21.5, "statusFlags": [false,false,false,false]}]
+//	}
+//
+// reference 为空时从第一条记录读取，count 为负数时读取最新的记录。
+// 设备不支持分段响应时 count 需要保证响应不超过一个 APDU，maxRecords 大于 count 时按最后一条记录的序号（或者位置、时间）继续读取。
+// 读取成功，流转到`Success`链，否则流转到`Failure`链
+type TrendLogNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config TrendLogConfiguration
+	// objectType 解析后的对象类型
+	objectType uint16
+	// by 解析后的读取方式
+	by string
+	// referenceTemplate 起始位置模板
+	referenceTemplate str.Template
+	// location 设备所在时区
+	location *time.Location
+	// server 配置的设备地址
+	server net.Addr
+}
+
+// Type 返回组件类型
+func (x *TrendLogNode) Type() string {
+	return "x/bacnetTrendLog"
+}
+
+// New 默认参数
+func (x *TrendLogNode) New() types.Node {
+	return &TrendLogNode{
+		Config: TrendLogConfiguration{
+			Broadcast:  DefaultBroadcast,
+			Timeout:    3,
+			ObjectType: "trend-log",
+			By:         RangeByPosition,
+			Count:      DefaultRangeCount,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *TrendLogNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.objectType, err = ParseObjectType(x.Config.ObjectType); err != nil {
+		return err
+	}
+	if x.by, err = ParseRangeBy(x.Config.By); err != nil {
+		return err
+	}
+	if x.Config.Count == 0 {
+		return errors.New("count must not be 0")
+	}
+	if x.Config.MaxRecords < 0 {
+		return errors.New("maxRecords must not be negative")
+	}
+	x.location = time.Local
+	if x.Config.TimeZone != "" {
+		if x.location, err = time.LoadLocation(x.Config.TimeZone); err != nil {
+			return err
+		}
+	}
+	x.referenceTemplate = str.NewTemplate(x.Config.Reference)
+	if x.server, err = resolveServer(x.Config.Server); err != nil {
+		return err
+	}
+	return initClient(&x.SharedNode, ruleConfig, x.Type(), ClientConfig{
+		LocalAddress: x.Config.LocalAddress,
+		Broadcast:    x.Config.Broadcast,
+		Timeout:      time.Duration(x.Config.Timeout) * time.Second,
+		Retries:      x.Config.Retries,
+	})
+}
+
+// OnMsg 处理消息
+func (x *TrendLogNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	reference := strings.TrimSpace(x.referenceTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg)))
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	addr, err := deviceAddress(client, x.server, x.Config.DeviceInstance)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result, err := x.read(client, addr, reference)
+	if err != nil {
+		// 设备地址变化后重新查找
+		if x.server == nil && errors.Is(err, ErrTimeout) {
+			client.ForgetDevice(x.Config.DeviceInstance)
+		}
+		ctx.TellFailure(msg, fmt.Errorf("%s:%d: %w", ObjectTypeName(x.objectType), x.Config.Instance, err))
+		return
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *TrendLogNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *TrendLogNode) Desc() string {
+	return "BACnet/IP ReadRange reader for trend log buffers by position, sequence number or time. Routes to Success/Failure"
+}
+
+// read 读取记录，maxRecords 大于 0 时根据 moreItems 继续读取
+func (x *TrendLogNode) read(client *Client, addr net.Addr, reference string) (*RangeResult, error) {
+	r := RangeRequest{ObjectType: x.objectType, Instance: x.Config.Instance, By: x.by, Count: x.Config.Count}
+	if err := x.parseReference(client, addr, &r, reference); err != nil {
+		return nil, err
+	}
+	var result *RangeResult
+	for {
+		page, err := client.ReadRange(addr, r, x.location)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = page
+		} else if r.Count > 0 {
+			result.Records = append(result.Records, page.Records...)
+			result.LastItem, result.MoreItems = page.LastItem, page.MoreItems
+		} else {
+			result.Records = append(page.Records, result.Records...)
+			result.FirstItem, result.MoreItems = page.FirstItem, page.MoreItems
+		}
+		if !page.MoreItems || len(page.Records) == 0 || len(result.Records) >= x.Config.MaxRecords || !nextRange(&r, page) {
+			break
+		}
+	}
+	if limit := x.Config.MaxRecords; limit > 0 && len(result.Records) > limit {
+		if r.Count > 0 {
+			result.Records = result.Records[:limit]
+		} else {
+			result.Records = result.Records[len(result.Records)-limit:]
+		}
+		result.MoreItems = true
+	}
+	if len(result.Records) > 0 && result.Records[0].SequenceNumber != nil {
+		result.FirstSequenceNumber = result.Records[0].SequenceNumber
+	}
+	return result, nil
+}
+
+// parseReference 解析起始位置、序号或者时间，为空时从第一条记录读取，count 为负数时读取最新的记录
+func (x *TrendLogNode) parseReference(client *Client, addr net.Addr, r *RangeRequest, reference string) error {
+	switch {
+	case r.By == RangeByTime && reference == "":
+		if r.Count > 0 {
+			r.Time = time.Date(1900, 1, 1, 0, 0, 0, 0, x.location)
+		} else {
+			r.Time = time.Now().In(x.location)
+		}
+	case r.By == RangeByTime:
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+			if t, err := time.ParseInLocation(layout, reference, x.location); err == nil {
+				r.Time = t.In(x.location)
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid bacnet range time: %s", reference)
+	case reference != "":
+		v, err := strconv.ParseUint(reference, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid bacnet range reference: %s", reference)
+		}
+		r.Reference = uint32(v)
+	case r.By == RangeBySequence:
+		return errors.New("bacnet range by sequence requires a reference")
+	case r.Count > 0:
+		r.Reference = 1
+	default:
+		// 从最后一条记录向前读取
+		values, err := client.ReadProperty(addr, ObjectProperty{ObjectType: r.ObjectType, Instance: r.Instance, Property: PropertyRecordCount})
+		if err != nil {
+			return err
+		}
+		if len(values) != 1 {
+			return errShortData
+		}
+		count, _ := values[0].(uint64)
+		r.Reference = uint32(count)
+	}
+	return nil
+}
+
+// nextRange 根据已经读取的记录计算下一次请求的起始位置，没有可读取的记录返回 false
+func nextRange(r *RangeRequest, page *RangeResult) bool {
+	edge, step := page.Records[len(page.Records)-1], int64(1)
+	if r.Count < 0 {
+		edge, step = page.Records[0], -1
+	}
+	switch {
+	case edge.SequenceNumber != nil:
+		next := int64(*edge.SequenceNumber) + step
+		if next < 0 {
+			return false
+		}
+		r.By, r.Reference = RangeBySequence, uint32(next)
+	case r.By == RangeByPosition:
+		next := int64(r.Reference) + step*int64(len(page.Records))
+		if next < 1 {
+			return false
+		}
+		r.Reference = uint32(next)
+	default:
+		// 按时间读取时不包括起始时间的记录
+		r.Time = edge.Timestamp
+	}
+	return true
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testRecord 测试设备的 trend-log 记录
+type testRecord struct {
+	time  time.Time
+	value float32
+}
+
+// readRange 按位置、序号（与位置相同）或者时间返回记录，每个响应最多 3 条记录
+func (d *testDevice) readRange(invokeId byte, request []byte) []byte {
+	id := binary.BigEndian.Uint32(request[1:5])
+	h, size, _ := decodeTag(request[5:])
+	offset := 5 + size + h.length
+	by := request[offset] >> 4
+	offset++
+	var reference int
+	var at time.Time
+	if by == 7 {
+		at = decodeDateTime(request[offset+1:offset+5], request[offset+6:offset+10], time.UTC)
+		offset += 10
+	} else {
+		h, size, _ = decodeTag(request[offset:])
+		reference = int(decodeUnsigned(request[offset+size : offset+size+h.length]))
+		offset += size + h.length
+	}
+	h, size, _ = decodeTag(request[offset:])
+	v, _ := decodeApplication(h, request[offset+size:offset+size+h.length])
+	count := int(v.(int64))
+
+	d.mu.Lock()
+	records := d.logs[id]
+	sequence := d.sequence
+	d.mu.Unlock()
+	// 满足条件的记录下标范围 [start, end)
+	start, end := 0, 0
+	switch {
+	case by == 7 && count > 0:
+		for start < len(records) && !records[start].time.After(at) {
+			start++
+		}
+		end = len(records)
+	case by == 7:
+		for end < len(records) && records[end].time.Before(at) {
+			end++
+		}
+	case count > 0:
+		start, end = reference-1, len(records)
+	default:
+		end = reference
+	}
+	more := false
+	if end-start > int(math.Abs(float64(count))) || end-start > 3 {
+		n := int(math.Min(math.Abs(float64(count)), 3))
+		if count > 0 {
+			end = start + n
+		} else {
+			start = end - n
+		}
+		more = true
+	}
+	resp := []byte{pduComplexAck << 4, invokeId, serviceReadRange}
+	resp = append(resp, request[:5]...)
+	resp = append(resp, encodeContextUnsigned(1, PropertyLogBuffer)...)
+	flags := byte(0)
+	if start == 0 {
+		flags |= 0x80
+	}
+	if end == len(records) {
+		flags |= 0x40
+	}
+	if more {
+		flags |= 0x20
+	}
+	resp = append(resp, 0x3a, 0x05, flags)
+	resp = append(resp, encodeContextUnsigned(4, uint32(end-start))...)
+	resp = append(resp, 0x5e)
+	for _, r := range records[start:end] {
+		resp = append(resp, 0x0e)
+		resp = append(resp, encodeDateTime(r.time)...)
+		resp = append(resp, 0x0f, 0x1e, 0x2c, 0, 0, 0, 0, 0x1f, 0x2a, 0x04, 0x00)
+		binary.BigEndian.PutUint32(resp[len(resp)-8:], math.Float32bits(r.value))
+	}
+	resp = append(resp, 0x5f)
+	if sequence && end > start {
+		resp = append(resp, encodeContextUnsigned(6, uint32(start+1))...)
+	}
+	return resp
+}
+
+func TestDecodeLogRecords(t *testing.T) {
+	data := []byte{
+		0x0e, 0xa4, 125, 1, 2, 4, 0xb4, 8, 30, 0, 0, 0x0f, // 2025-01-02 08:30:00
+		0x1e, 0x0a, 0x05, 0x40, 0x1f, // log-status buffer-purged
+		0x0e, 0xa4, 125, 1, 2, 4, 0xb4, 8, 45, 0, 50, 0x0f,
+		0x1e, 0x19, 0x01, 0x1f, // boolean true
+		0x2a, 0x04, 0x40, // status-flags fault
+		0x0e, 0xa4, 125, 1, 2, 4, 0xb4, 9, 0, 0, 0, 0x0f,
+		0x1e, 0x8e, 0x91, 0x02, 0x91, 0x20, 0x8f, 0x1f, // failure unknown-property
+		0x0e, 0xa4, 125, 1, 2, 4, 0xb4, 9, 15, 0, 0, 0x0f,
+		0x1e, 0x59, 0xfe, 0x1f, // signed -2
+		0x5f,
+	}
+	records, size, err := decodeLogRecords(data, time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, len(data)-1, size)
+	assert.Equal(t, 4, len(records))
+	assert.Equal(t, time.Date(2025, 1, 2, 8, 30, 0, 0, time.UTC), records[0].Timestamp)
+	assert.Equal(t, LogKindStatus, records[0].Kind)
+	assert.Equal(t, []bool{false, true, false}, records[0].Value)
+	assert.Equal(t, time.Date(2025, 1, 2, 8, 45, 0, 5e8, time.UTC), records[1].Timestamp)
+	assert.Equal(t, true, records[1].Value)
+	assert.Equal(t, []bool{false, true, false, false}, records[1].StatusFlags)
+	assert.Equal(t, LogKindFailure, records[2].Kind)
+	assert.Equal(t, "bacnet error: unknown property", records[2].Error)
+	assert.Equal(t, int64(-2), records[3].Value)
+
+	// 请求编码
+	request, err := encodeReadRange(RangeRequest{ObjectType: 20, Instance: 1, By: RangeByTime,
+		Time: time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC), Count: -10})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x0c, 0x05, 0x00, 0x00, 0x01, 0x19, 0x83,
+		0x7e, 0xa4, 125, 1, 5, 7, 0xb4, 12, 0, 0, 0, 0x31, 0xf6, 0x7f}, request)
+	_, err = encodeReadRange(RangeRequest{Count: 0})
+	assert.NotNil(t, err)
+	_, err = ParseRangeBy("index")
+	assert.NotNil(t, err)
+}
+
+func TestTrendLogNode(t *testing.T) {
+	device := startTestDevice(t, 1001)
+	defer device.conn.Close()
+	start := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	var records []testRecord
+	for i := 0; i < 8; i++ {
+		records = append(records, testRecord{time: start.Add(time.Duration(i) * 15 * time.Minute), value: float32(20 + i)})
+	}
+	device.mu.Lock()
+	device.logs[encodeObjectId(20, 1)] = records
+	device.mu.Unlock()
+	device.set(20, 1, PropertyRecordCount, []byte{0x21, 0x08})
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&TrendLogNode{})
+
+	_, err := test.CreateAndInitNode("x/bacnetTrendLog", types.Configuration{"by": "index"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/bacnetTrendLog", types.Configuration{"timeZone": "Nowhere/City"}, Registry)
+	assert.NotNil(t, err)
+
+	read := func(config types.Configuration, metadata map[string]string) RangeResult {
+		config["server"] = device.addr()
+		config["instance"] = 1
+		config["timeZone"] = "UTC"
+		node, err := test.CreateAndInitNode("x/bacnetTrendLog", config, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		results := make(chan RangeResult, 1)
+		test.NodeOnMsg(t, node, []test.Msg{{
+			MetaData: types.BuildMetadata(metadata),
+			DataType: types.JSON,
+			MsgType:  "READ",
+			Data:     `{}`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			var result RangeResult
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+			results <- result
+		})
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for trend log result")
+			return RangeResult{}
+		}
+	}
+	values := func(result RangeResult) []interface{} {
+		var list []interface{}
+		for _, r := range result.Records {
+			list = append(list, r.Value)
+		}
+		return list
+	}
+
+	// 按位置读取一页
+	result := read(types.Configuration{"count": 2}, nil)
+	assert.Equal(t, []interface{}{20.0, 21.0}, values(result))
+	assert.True(t, result.FirstItem && result.MoreItems)
+	assert.Equal(t, start, result.Records[0].Timestamp)
+
+	// 按位置翻页读取全部记录
+	result = read(types.Configuration{"count": 10, "maxRecords": 100}, nil)
+	assert.Equal(t, 8, len(result.Records))
+	assert.True(t, result.FirstItem && result.LastItem && !result.MoreItems)
+
+	// 读取最新的记录
+	result = read(types.Configuration{"count": -3, "maxRecords": 5}, nil)
+	assert.Equal(t, []interface{}{23.0, 24.0, 25.0, 26.0, 27.0}, values(result))
+	assert.True(t, result.LastItem && result.MoreItems)
+
+	// 按时间读取，不包括起始时间的记录
+	result = read(types.Configuration{"by": "time", "reference": "${metadata.since}", "count": 2}, map[string]string{
+		"since": "2025-01-02 01:00:00",
+	})
+	assert.Equal(t, []interface{}{25.0, 26.0}, values(result))
+
+	// 按序号读取
+	device.mu.Lock()
+	device.sequence = true
+	device.mu.Unlock()
+	result = read(types.Configuration{"by": "sequence", "reference": "6", "count": 5, "maxRecords": 5}, nil)
+	assert.Equal(t, []interface{}{25.0, 26.0, 27.0}, values(result))
+	assert.Equal(t, uint64(6), *result.FirstSequenceNumber)
+	assert.Equal(t, uint64(8), *result.Records[2].SequenceNumber)
+	assert.True(t, result.LastItem && !result.MoreItems)
+}
@@ -0,0 +1,273 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteConfiguration 写入节点配置
+type WriteConfiguration struct {
+	// Server 设备地址，格式：host:port，端口默认 47808，为空则通过 Who-Is 按设备实例号查找
+	Server string `json:"server" label:"Server" desc:"Device address, format: host:port, port defaults to 47808. Devices behind a BACnet/IP to MS/TP router: net:mac@router-host:port, eg. 5:12@192.168.1.10. Devices on a directly connected MS/TP bus: mstp:mac. Empty discovers the device by instance with Who-Is" ref:"primary"`
+	// DeviceInstance 设备实例号，server 为空时用于查找设备地址
+	DeviceInstance uint32 `json:"deviceInstance" label:"Device instance" desc:"Device instance number, used to discover the device address when server is empty"`
+	// LocalAddress 本地监听地址，为空使用随机端口
+	LocalAddress string `json:"localAddress" label:"Local address" desc:"Local UDP address, empty uses a random port. Use :47808 if devices broadcast their I-Am replies. Connect directly to an MS/TP bus with mstp:///dev/ttyUSB0?mac=3&baud=38400&maxMaster=127&maxInfoFrames=1 or through a serial server with mstp+tcp://host:port?mac=3"`
+	// Broadcast 广播地址，用于 Who-Is 查找设备
+	Broadcast string `json:"broadcast" label:"Broadcast" desc:"Broadcast address for Who-Is discovery"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Request timeout in seconds"`
+	// Retries 超时重试次数
+	Retries int `json:"retries" label:"Retries" desc:"Retries after a request timeout"`
+	// Priority 默认的命令优先级 1-16，0 表示不指定优先级
+	Priority int `json:"priority" label:"Priority" desc:"Default command priority 1-16, 0 writes without priority"`
+	// Items 写入的对象属性，为空则使用消息负荷 msg.Data 中的对象属性
+	Items []WriteItem `json:"items" label:"Items" desc:"Object properties to write, empty uses the objects in msg.Data"`
+}
+
+// WriteItem 写入的对象属性
+type WriteItem struct {
+	// ObjectType 对象类型，名称或者编号，eg. analog-output、1
+	ObjectType string `json:"objectType"`
+	// Instance 对象实例号
+	Instance uint32 `json:"instance"`
+	// Property 属性，名称或者编号，默认 present-value
+	Property string `json:"property,omitempty"`
+	// ArrayIndex 数组下标，为空写入整个属性
+	ArrayIndex *uint32 `json:"arrayIndex,omitempty"`
+	// ValueType 值类型：real、double、unsigned、signed、enumerated、boolean、string、null，为空按对象类型推断
+	ValueType string `json:"valueType,omitempty"`
+	// Value 写入的值，允许使用 ${} 占位符变量，null 表示释放该优先级的命令
+	Value string `json:"value"`
+	// Priority 命令优先级 1-16，0 使用节点配置的优先级
+	Priority int `json:"priority,omitempty"`
+}
+
+// WriteNode BACnet/IP 写入节点，通过 WriteProperty 写入对象属性，支持命令优先级。
+// 对象属性来自配置 items，值允许使用 ${} 占位符变量，或者消息负荷 msg.Data，格式：
+//
+//	[
+//	  {"objectType": "analog-output", "instance": 1, "value": 21.5, "priority": 8},
+//	  {"objectType": "binary-output", "instance": 3, "value": null, "priority": 8}
+//	]
+//
+// 可命令对象（analog/binary/multi-state output 等）的 present-value 按优先级写入 priority-array，
+// 生效值为优先级最高的非 Null 值；写入 null 释放该优先级的命令，让低优先级的命令或者 relinquish-default 生效。
+// 值类型为空时，analog 对象按 real，binary 对象按 enumerated（支持 active/inactive、true/false），multi-state 对象按 unsigned 编码。
+// 所有属性写入成功，流转到`Success`链，否则流转到`Failure`链
+type WriteNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config WriteConfiguration
+	// properties 配置的对象属性
+	properties []ObjectProperty
+	// valueTemplates 配置的值模板
+	valueTemplates []str.Template
+	// server 配置的设备地址
+	server net.Addr
+}
+
+// writeRequest 一个属性的写入请求
+type writeRequest struct {
+	property ObjectProperty
+	value    []byte
+	priority uint8
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/bacnetWrite"
+}
+
+// New 默认参数
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteConfiguration{
+			Broadcast: DefaultBroadcast,
+			Timeout:   3,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if err = checkPriority(x.Config.Priority); err != nil {
+		return err
+	}
+	x.properties = nil
+	x.valueTemplates = nil
+	for _, item := range x.Config.Items {
+		p, err := item.objectProperty()
+		if err != nil {
+			return err
+		}
+		if err = checkPriority(item.Priority); err != nil {
+			return err
+		}
+		x.properties = append(x.properties, p)
+		x.valueTemplates = append(x.valueTemplates, str.NewTemplate(item.Value))
+	}
+	if x.server, err = resolveServer(x.Config.Server); err != nil {
+		return err
+	}
+	return initClient(&x.SharedNode, ruleConfig, x.Type(), ClientConfig{
+		LocalAddress: x.Config.LocalAddress,
+		Broadcast:    x.Config.Broadcast,
+		Timeout:      time.Duration(x.Config.Timeout) * time.Second,
+		Retries:      x.Config.Retries,
+	})
+}
+
+// OnMsg 处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	requests, err := x.getRequests(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	addr, err := deviceAddress(client, x.server, x.Config.DeviceInstance)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	for _, r := range requests {
+		if err = client.WriteProperty(addr, r.property, r.value, r.priority); err != nil {
+			// 设备地址变化后重新查找
+			if x.server == nil && errors.Is(err, ErrTimeout) {
+				client.ForgetDevice(x.Config.DeviceInstance)
+			}
+			ctx.TellFailure(msg, fmt.Errorf("%s:%d: %w", ObjectTypeName(r.property.ObjectType), r.property.Instance, err))
+			return
+		}
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *WriteNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "BACnet/IP WriteProperty writer with command priority 1-16 and relinquish by writing null. Routes to Success/Failure"
+}
+
+// getRequests 获取写入请求，值按对象类型或者配置的值类型编码
+func (x *WriteNode) getRequests(ctx types.RuleContext, msg types.RuleMsg) ([]writeRequest, error) {
+	if len(x.properties) > 0 {
+		evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		requests := make([]writeRequest, 0, len(x.properties))
+		for i, item := range x.Config.Items {
+			r, err := x.newRequest(item, x.properties[i], x.valueTemplates[i].Execute(evn))
+			if err != nil {
+				return nil, err
+			}
+			requests = append(requests, r)
+		}
+		return requests, nil
+	}
+	var list []map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &list); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no bacnet objects to write")
+	}
+	requests := make([]writeRequest, 0, len(list))
+	for _, m := range list {
+		// 值可以是任意 JSON 类型，单独取出，避免 null 被转换为空字符串
+		value := m["value"]
+		delete(m, "value")
+		var item WriteItem
+		if err := maps.Map2Struct(m, &item); err != nil {
+			return nil, err
+		}
+		if err := checkPriority(item.Priority); err != nil {
+			return nil, err
+		}
+		p, err := item.objectProperty()
+		if err != nil {
+			return nil, err
+		}
+		r, err := x.newRequest(item, p, value)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+// newRequest 编码写入的值，优先级为空使用节点配置的优先级
+func (x *WriteNode) newRequest(item WriteItem, p ObjectProperty, value interface{}) (writeRequest, error) {
+	encoded, err := EncodeValue(item.ValueType, p.ObjectType, value)
+	if err != nil {
+		return writeRequest{}, fmt.Errorf("%s:%d: %w", item.ObjectType, item.Instance, err)
+	}
+	priority := item.Priority
+	if priority == 0 {
+		priority = x.Config.Priority
+	}
+	return writeRequest{property: p, value: encoded, priority: uint8(priority)}, nil
+}
+
+// objectProperty 解析对象类型和属性
+func (i WriteItem) objectProperty() (ObjectProperty, error) {
+	properties, err := parseObjects([]Object{{
+		ObjectType: i.ObjectType,
+		Instance:   i.Instance,
+		Property:   i.Property,
+		ArrayIndex: i.ArrayIndex,
+	}})
+	if err != nil {
+		return ObjectProperty{}, err
+	}
+	return properties[0], nil
+}
+
+// checkPriority 检查命令优先级，0 表示不指定优先级
+func checkPriority(priority int) error {
+	if priority < 0 || priority > 16 {
+		return fmt.Errorf("bacnet priority must be 1-16, got %d", priority)
+	}
+	return nil
+}
@@ -0,0 +1,571 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPort BACnet/IP 端口
+	DefaultPort = 47808
+	// DefaultBroadcast 默认广播地址，用于 Who-Is 查找设备
+	DefaultBroadcast = "255.255.255.255:47808"
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 3 * time.Second
+)
+
+// BACnet/IP 协议常量
+const (
+	bvlcType              = 0x81
+	bvlcForwarded         = 0x04
+	bvlcOriginalUnicast   = 0x0a
+	bvlcOriginalBroadcast = 0x0b
+	npduVersion           = 0x01
+	npduExpectingReply    = 0x04
+	pduConfirmedRequest   = 0x00
+	pduUnconfirmedRequest = 0x01
+	pduSimpleAck          = 0x02
+	pduComplexAck         = 0x03
+	pduError              = 0x05
+	pduReject             = 0x06
+	pduAbort              = 0x07
+	serviceIAm            = 0x00
+	serviceWhoIs          = 0x08
+	serviceReadProperty   = 0x0c
+	serviceWriteProperty  = 0x0f
+	// maxAPDUAccepted 请求中声明的最大 APDU：1476 字节，不接收分段
+	maxAPDUAccepted    = 0x05
+	objectTypeDevice   = 8
+	propertyObjectList = 76
+)
+
+// ErrClosed 客户端已经关闭
+var ErrClosed = errors.New("bacnet client is closed")
+
+// ErrTimeout 等待设备响应超时
+var ErrTimeout = errors.New("bacnet request timeout")
+
+// ErrSegmented 设备返回了分段的响应
+var ErrSegmented = errors.New("bacnet: segmented response is not supported")
+
+// Error 设备返回的 Error、Reject 或者 Abort
+type Error struct {
+	// Kind error、reject 或者 abort
+	Kind string
+	// Class 错误类别，只有 error 有效
+	Class uint64
+	// Code 错误码，reject 和 abort 为原因
+	Code uint64
+}
+
+func (e *Error) Error() string {
+	if e.Kind != "error" {
+		return fmt.Sprintf("bacnet %s: reason=%d", e.Kind, e.Code)
+	}
+	switch {
+	case e.Class == 1 && e.Code == 31:
+		return "bacnet error: unknown object"
+	case e.Class == 2 && e.Code == 32:
+		return "bacnet error: unknown property"
+	case e.Class == 2 && e.Code == 42:
+		return "bacnet error: invalid array index"
+	case e.Class == 2 && e.Code == 40:
+		return "bacnet error: write access denied"
+	case e.Class == 2 && e.Code == 9:
+		return "bacnet error: invalid data type"
+	case e.Class == 2 && e.Code == 37:
+		return "bacnet error: value out of range"
+	default:
+		return fmt.Sprintf("bacnet error: class=%d code=%d", e.Class, e.Code)
+	}
+}
+
+// ClientConfig 创建 BACnet 客户端的配置
+type ClientConfig struct {
+	// LocalAddress 本地监听地址，为空使用随机端口
+	// 如果设备的 I-Am 以广播方式回复，需要监听 47808 端口
+	// 以 mstp:// 或者 mstp+tcp:// 开头时通过串口直连 MS/TP 网络，见 MSTPConfig
+	LocalAddress string
+	// Broadcast 广播地址，用于 Who-Is 查找设备
+	Broadcast string
+	// Timeout 请求超时
+	Timeout time.Duration
+	// Retries 超时重试次数
+	Retries int
+}
+
+// ObjectProperty 读取的对象属性
+type ObjectProperty struct {
+	ObjectType uint16
+	Instance   uint32
+	Property   uint32
+	// ArrayIndex 数组下标，nil 表示读取整个属性
+	ArrayIndex *uint32
+}
+
+// Client BACnet 客户端，可以并发调用
+// 通过 BACnet/IP 或者串口直连的 MS/TP 访问设备，经路由器可以访问远程网络上的设备，见 Address
+// 响应按 invoke id 分发，同一时刻最多 256 个未完成的请求
+type Client struct {
+	link    datalink
+	timeout time.Duration
+	retries int
+
+	mu       sync.Mutex
+	invokeId byte
+	pending  map[byte]chan []byte
+	// devices 通过 I-Am 发现的设备地址
+	devices map[uint32]net.Addr
+	// listeners 接收 I-Am 的回调，key 为注册序号
+	listeners  map[int]func(Device)
+	listenerId int
+	closed     bool
+}
+
+// Device 通过 I-Am 发现的设备
+type Device struct {
+	// Instance 设备实例号
+	Instance uint32 `json:"deviceInstance"`
+	// Address 设备地址，格式：host:port，远程网络上的设备见 Address
+	Address string `json:"address"`
+	// MaxAPDU 设备可以接收的最大 APDU
+	MaxAPDU uint64 `json:"maxApdu"`
+	// Segmentation 设备支持的分段方式，0:双向 1:发送 2:接收 3:不支持
+	Segmentation uint64 `json:"segmentation"`
+	// VendorId 厂商编号
+	VendorId uint64 `json:"vendorId"`
+	// Objects 设备的对象列表，只有需要时才读取
+	Objects []interface{} `json:"objects,omitempty"`
+
+	addr net.Addr
+}
+
+// Addr 设备地址，BACnet/IP 设备为 *net.UDPAddr，远程网络和 MS/TP 上的设备为 *Address
+func (d Device) Addr() net.Addr {
+	return d.addr
+}
+
+// NewClient 打开数据链路并创建客户端
+func NewClient(config ClientConfig) (*Client, error) {
+	var link datalink
+	var err error
+	if isMSTPLink(config.LocalAddress) {
+		link, err = newMSTPLink(config.LocalAddress)
+	} else {
+		link, err = newIPLink(config.LocalAddress, config.Broadcast)
+	}
+	if err != nil {
+		return nil, err
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c := &Client{
+		link:      link,
+		timeout:   timeout,
+		retries:   config.Retries,
+		pending:   map[byte]chan []byte{},
+		devices:   map[uint32]net.Addr{},
+		listeners: map[int]func(Device){},
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// ResolveAddress 解析设备地址，端口默认 47808
+func ResolveAddress(address string) (*net.UDPAddr, error) {
+	address = strings.TrimPrefix(address, "udp://")
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, strconv.Itoa(DefaultPort))
+	}
+	return net.ResolveUDPAddr("udp4", address)
+}
+
+// LocalAddr 本地地址
+func (c *Client) LocalAddr() net.Addr {
+	return c.link.localAddr()
+}
+
+// Close 关闭客户端，未完成的请求返回 ErrClosed
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	return c.link.close()
+}
+
+// ReadProperty 读取对象的属性，返回属性值列表，数组和列表属性有多个值
+func (c *Client) ReadProperty(addr net.Addr, p ObjectProperty) ([]interface{}, error) {
+	request := encodeContextObjectId(0, p.ObjectType, p.Instance)
+	request = append(request, encodeContextUnsigned(1, p.Property)...)
+	if p.ArrayIndex != nil {
+		request = append(request, encodeContextUnsigned(2, *p.ArrayIndex)...)
+	}
+	apdu, err := c.confirmed(addr, serviceReadProperty, request)
+	if err != nil {
+		return nil, err
+	}
+	return decodeReadPropertyAck(apdu)
+}
+
+// WriteProperty 写入对象的属性，value 为应用标签编码的值，见 EncodeValue
+// priority 为命令优先级 1-16，0 表示不指定优先级。写入 Null 释放该优先级的命令
+func (c *Client) WriteProperty(addr net.Addr, p ObjectProperty, value []byte, priority uint8) error {
+	if priority > 16 {
+		return fmt.Errorf("bacnet priority must be 1-16, got %d", priority)
+	}
+	request := encodeContextObjectId(0, p.ObjectType, p.Instance)
+	request = append(request, encodeContextUnsigned(1, p.Property)...)
+	if p.ArrayIndex != nil {
+		request = append(request, encodeContextUnsigned(2, *p.ArrayIndex)...)
+	}
+	request = append(request, 0x3e)
+	request = append(request, value...)
+	request = append(request, 0x3f)
+	if priority > 0 {
+		request = append(request, encodeContextUnsigned(4, uint32(priority))...)
+	}
+	_, err := c.confirmed(addr, serviceWriteProperty, request)
+	return err
+}
+
+// FindDevice 通过 Who-Is 广播查找设备地址，结果会被缓存
+func (c *Client) FindDevice(deviceInstance uint32) (net.Addr, error) {
+	c.mu.Lock()
+	if addr, ok := c.devices[deviceInstance]; ok {
+		c.mu.Unlock()
+		return addr, nil
+	}
+	c.mu.Unlock()
+	ch := make(chan net.Addr, 1)
+	remove := c.listen(func(device Device) {
+		if device.Instance == deviceInstance {
+			select {
+			case ch <- device.addr:
+			default:
+			}
+		}
+	})
+	defer remove()
+
+	request := encodeWhoIs(&deviceInstance, &deviceInstance)
+	for i := 0; i <= c.retries; i++ {
+		if err := c.send(request, globalBroadcast, false); err != nil {
+			return nil, err
+		}
+		select {
+		case addr := <-ch:
+			return addr, nil
+		case <-time.After(c.timeout):
+		}
+	}
+	return nil, fmt.Errorf("bacnet device %d not found: %w", deviceInstance, ErrTimeout)
+}
+
+// WhoIs 广播 Who-Is，返回等待时间内回复 I-Am 的设备，按设备实例号排序
+// lowLimit 和 highLimit 为查找的设备实例号范围，都为 nil 表示查找所有设备
+func (c *Client) WhoIs(lowLimit, highLimit *uint32, wait time.Duration) ([]Device, error) {
+	var lock sync.Mutex
+	found := map[uint32]Device{}
+	remove := c.listen(func(device Device) {
+		if lowLimit != nil && device.Instance < *lowLimit || highLimit != nil && device.Instance > *highLimit {
+			return
+		}
+		lock.Lock()
+		found[device.Instance] = device
+		lock.Unlock()
+	})
+	defer remove()
+
+	request := encodeWhoIs(lowLimit, highLimit)
+	for i := 0; i <= c.retries; i++ {
+		if err := c.send(request, globalBroadcast, false); err != nil {
+			return nil, err
+		}
+		time.Sleep(wait)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	devices := make([]Device, 0, len(found))
+	for _, device := range found {
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Instance < devices[j].Instance
+	})
+	return devices, nil
+}
+
+// ReadObjectList 读取设备的对象列表
+// 对象列表超过一个 APDU 时设备会要求分段，这时按数组下标逐个读取
+func (c *Client) ReadObjectList(addr net.Addr, deviceInstance uint32) ([]interface{}, error) {
+	p := ObjectProperty{ObjectType: objectTypeDevice, Instance: deviceInstance, Property: propertyObjectList}
+	values, err := c.ReadProperty(addr, p)
+	if err == nil || errors.Is(err, ErrTimeout) || errors.Is(err, ErrClosed) {
+		return values, err
+	}
+	index := uint32(0)
+	p.ArrayIndex = &index
+	length, err := c.ReadProperty(addr, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(length) != 1 {
+		return nil, errShortData
+	}
+	count, _ := length[0].(uint64)
+	objects := make([]interface{}, 0, count)
+	for i := uint32(1); i <= uint32(count); i++ {
+		index := i
+		p.ArrayIndex = &index
+		value, err := c.ReadProperty(addr, p)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, value...)
+	}
+	return objects, nil
+}
+
+// ForgetDevice 删除缓存的设备地址，设备地址变化时重新查找
+func (c *Client) ForgetDevice(deviceInstance uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.devices, deviceInstance)
+}
+
+// listen 注册 I-Am 回调，返回取消注册的函数
+func (c *Client) listen(fn func(Device)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listenerId++
+	id := c.listenerId
+	c.listeners[id] = fn
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.listeners, id)
+	}
+}
+
+// encodeWhoIs 编码 Who-Is 请求的 APDU
+func encodeWhoIs(lowLimit, highLimit *uint32) []byte {
+	request := []byte{pduUnconfirmedRequest << 4, serviceWhoIs}
+	// 范围必须同时提供
+	if lowLimit != nil && highLimit != nil {
+		request = append(request, encodeContextUnsigned(0, *lowLimit)...)
+		request = append(request, encodeContextUnsigned(1, *highLimit)...)
+	}
+	return request
+}
+
+// confirmed 发送确认请求并等待响应，超时按配置重试
+func (c *Client) confirmed(addr net.Addr, service byte, request []byte) ([]byte, error) {
+	invokeId, ch, err := c.register()
+	if err != nil {
+		return nil, err
+	}
+	defer c.unregister(invokeId)
+
+	apdu := []byte{pduConfirmedRequest << 4, c.link.maxAPDU(), invokeId, service}
+	apdu = append(apdu, request...)
+	for i := 0; i <= c.retries; i++ {
+		if err = c.send(apdu, addr, true); err != nil {
+			return nil, err
+		}
+		select {
+		case apdu, ok := <-ch:
+			if !ok {
+				return nil, ErrClosed
+			}
+			return checkResponse(apdu, service)
+		case <-time.After(c.timeout):
+		}
+	}
+	return nil, fmt.Errorf("bacnet device %s: %w", addr, ErrTimeout)
+}
+
+// register 分配一个未使用的 invoke id
+func (c *Client) register() (byte, chan []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, nil, ErrClosed
+	}
+	for i := 0; i < 256; i++ {
+		c.invokeId++
+		if _, ok := c.pending[c.invokeId]; !ok {
+			ch := make(chan []byte, 1)
+			c.pending[c.invokeId] = ch
+			return c.invokeId, ch, nil
+		}
+	}
+	return 0, nil, errors.New("bacnet: too many pending requests")
+}
+
+func (c *Client) unregister(invokeId byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, invokeId)
+}
+
+// send 加上 NPDU 头发送 APDU
+func (c *Client) send(apdu []byte, addr net.Addr, expectingReply bool) error {
+	npdu := append(encodeNPDU(addr, expectingReply), apdu...)
+	return c.link.send(npdu, addr, expectingReply)
+}
+
+// readLoop 接收数据包，把响应分发给等待的请求
+func (c *Client) readLoop() {
+	for {
+		npdu, src, err := c.link.receive()
+		if err != nil {
+			c.mu.Lock()
+			c.closed = true
+			for id, ch := range c.pending {
+				close(ch)
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+		apdu, snet, sadr, ok := parseNPDU(npdu)
+		if !ok || len(apdu) < 2 {
+			continue
+		}
+		if snet != 0 {
+			// 经路由器转发，回复时需要指定目的网络
+			src = &Address{Router: routerOf(src), Net: snet, MAC: sadr}
+		}
+		switch apdu[0] >> 4 {
+		case pduUnconfirmedRequest:
+			if apdu[1] == serviceIAm {
+				c.onIAm(apdu[2:], src)
+			}
+		case pduSimpleAck, pduComplexAck, pduError, pduReject, pduAbort:
+			c.mu.Lock()
+			if ch, ok := c.pending[apdu[1]]; ok {
+				select {
+				case ch <- append([]byte(nil), apdu...):
+				default:
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// onIAm 记录设备地址并通知注册的回调
+func (c *Client) onIAm(data []byte, src net.Addr) {
+	device, ok := decodeIAm(data)
+	if !ok {
+		return
+	}
+	device.addr = src
+	device.Address = src.String()
+	c.mu.Lock()
+	c.devices[device.Instance] = src
+	listeners := make([]func(Device), 0, len(c.listeners))
+	for _, fn := range c.listeners {
+		listeners = append(listeners, fn)
+	}
+	c.mu.Unlock()
+	for _, fn := range listeners {
+		fn(device)
+	}
+}
+
+// decodeIAm 解码 I-Am：设备标识、最大 APDU、分段方式和厂商编号
+func decodeIAm(data []byte) (Device, bool) {
+	if len(data) < 5 || data[0] != tagObjectId<<4|4 {
+		return Device{}, false
+	}
+	id := binary.BigEndian.Uint32(data[1:5])
+	if id>>22 != objectTypeDevice {
+		return Device{}, false
+	}
+	values, _, err := decodeValues(data[5:])
+	if err != nil || len(values) < 3 {
+		return Device{}, false
+	}
+	device := Device{Instance: id & 0x3fffff}
+	device.MaxAPDU, _ = values[0].(uint64)
+	device.Segmentation, _ = values[1].(uint64)
+	device.VendorId, _ = values[2].(uint64)
+	return device, true
+}
+
+// checkResponse 检查响应类型，Error、Reject 和 Abort 转换为 Error
+func checkResponse(apdu []byte, service byte) ([]byte, error) {
+	if len(apdu) < 3 {
+		return nil, errShortData
+	}
+	switch apdu[0] >> 4 {
+	case pduSimpleAck, pduComplexAck:
+		if apdu[0]&0x08 != 0 {
+			return nil, ErrSegmented
+		}
+		if apdu[2] != service {
+			return nil, errors.New("bacnet: unexpected response service")
+		}
+		return apdu[3:], nil
+	case pduError:
+		values, _, err := decodeValues(apdu[3:])
+		if err != nil || len(values) < 2 {
+			return nil, &Error{Kind: "error"}
+		}
+		class, _ := values[0].(uint64)
+		code, _ := values[1].(uint64)
+		return nil, &Error{Kind: "error", Class: class, Code: code}
+	case pduReject:
+		return nil, &Error{Kind: "reject", Code: uint64(apdu[2])}
+	default:
+		return nil, &Error{Kind: "abort", Code: uint64(apdu[2])}
+	}
+}
+
+// decodeReadPropertyAck 解码 ReadProperty 响应中的属性值
+func decodeReadPropertyAck(data []byte) ([]interface{}, error) {
+	offset := 0
+	for offset < len(data) {
+		h, n, err := decodeTag(data[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += n
+		if h.opening && h.number == 3 {
+			values, _, err := decodeValues(data[offset:])
+			return values, err
+		}
+		offset += h.length
+	}
+	return nil, errShortData
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/conntest"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// wildcardDeviceInstance 通配的设备实例号，设备按自身的设备对象响应
+const wildcardDeviceInstance = 4194303
+
+// propertyObjectName object-name 属性
+const propertyObjectName = 77
+
+// 注册连接测试，连接配置相同的组件共用
+func init() {
+	for _, componentType := range []string{
+		"x/bacnetRead",
+		"x/bacnetWrite",
+		"x/bacnetSchedule",
+		"x/bacnetTrendLog",
+	} {
+		_ = conntest.Register(componentType, TestConnection)
+	}
+}
+
+// TestConnection 测试 BACnet/IP 连接配置，依次执行：
+//   - config：解析设备地址和样本对象
+//   - connect：监听本地 UDP 端口并确认设备可达。配置了 server 时读取设备对象的 object-name，
+//     否则按 deviceInstance 广播 Who-Is 查找设备，诊断信息为设备名称和地址
+//   - read：读取样本对象属性，样本为配置的 testSample（格式同 objects 的对象属性），
+//     为空使用 objects 的第一个对象属性，都为空时跳过
+//
+// 测试使用独立的客户端，结束后关闭。localAddress 的端口已经被其他组件监听时测试会失败，
+// 可以把 localAddress 置空使用随机端口测试
+func TestConnection(ctx context.Context, config map[string]interface{}) *conntest.Result {
+	c := (&ReadNode{}).New().(*ReadNode).Config
+	if err := maps.Map2Struct(config, &c); err != nil {
+		return conntest.NewResult("", "").Fail(err)
+	}
+	target := c.Server
+	if target == "" {
+		target = fmt.Sprintf("device:%d", c.DeviceInstance)
+	}
+	result := conntest.NewResult("", target)
+	var server net.Addr
+	var sample *Object
+	var property ObjectProperty
+	result.Run(conntest.StepConfig, func() (string, error) {
+		var err error
+		if server, err = resolveServer(c.Server); err != nil {
+			return "", err
+		}
+		if sample, err = sampleObject(config, c.Objects); err != nil || sample == nil {
+			return "", err
+		}
+		properties, err := parseObjects([]Object{*sample})
+		if err != nil {
+			return "", err
+		}
+		property = properties[0]
+		return "", nil
+	})
+	if !result.Success {
+		return result
+	}
+
+	var client *Client
+	var addr net.Addr
+	result.Run(conntest.StepConnect, func() (string, error) {
+		var err error
+		client, err = NewClient(ClientConfig{
+			LocalAddress: c.LocalAddress,
+			Broadcast:    c.Broadcast,
+			Timeout:      conntest.Timeout(ctx, time.Duration(c.Timeout)*time.Second),
+			Retries:      c.Retries,
+		})
+		if err != nil {
+			return "", err
+		}
+		instance := uint32(wildcardDeviceInstance)
+		if server == nil {
+			instance = c.DeviceInstance
+		}
+		if addr, err = deviceAddress(client, server, c.DeviceInstance); err != nil {
+			return "", err
+		}
+		values, err := client.ReadProperty(addr, ObjectProperty{ObjectType: objectTypeDevice, Instance: instance, Property: propertyObjectName})
+		if err != nil {
+			return "", err
+		}
+		if len(values) == 1 {
+			return fmt.Sprintf("device %v at %s", values[0], addr), nil
+		}
+		return fmt.Sprintf("device at %s", addr), nil
+	})
+	if client != nil {
+		defer func() {
+			_ = client.Close()
+		}()
+	}
+	if sample == nil {
+		result.Skip(conntest.StepRead, "no sample object configured")
+		return result
+	}
+	result.Run(conntest.StepRead, func() (string, error) {
+		values, err := client.ReadProperty(addr, property)
+		if err != nil {
+			return "", err
+		}
+		var value interface{} = values
+		if len(values) == 1 {
+			value = values[0]
+		}
+		result.Sample = map[string]interface{}{sample.key(): value}
+		return sample.key(), nil
+	})
+	return result
+}
+
+// sampleObject 样本对象属性，testSample 为空时使用 objects 的第一个对象属性，都为空返回 nil
+func sampleObject(config map[string]interface{}, objects []Object) (*Object, error) {
+	if v := conntest.Sample(config); v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var object Object
+		if err = json.Unmarshal(b, &object); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", conntest.KeySample, err)
+		}
+		return &object, nil
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+	return &objects[0], nil
+}
@@ -0,0 +1,327 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+)
+
+// NPDU 控制位
+const (
+	npduNetworkMessage = 0x80
+	npduDestination    = 0x20
+	npduSource         = 0x08
+	// maxHopCount 路由的最大跳数
+	maxHopCount = 0xff
+	// globalNetwork 全局广播的网络号
+	globalNetwork = 0xffff
+)
+
+// MSTPPrefix 直连 MS/TP 设备地址的前缀，eg. mstp:12
+const MSTPPrefix = "mstp:"
+
+// globalBroadcast 全局广播，经路由器转发到所有网络，Who-Is 可以找到 MS/TP 等远程网络上的设备
+var globalBroadcast = &Address{Net: globalNetwork}
+
+// Address 远程网络或者 MS/TP 上的设备地址
+//
+// 经 BACnet/IP 到 MS/TP 路由器访问时 Router 为路由器地址，Net 为 MS/TP 网络号，MAC 为设备的 MAC 地址，格式：net:mac@host:port；
+// 通过串口直连 MS/TP 时 Router 为空，Net 为 0，MAC 为设备的 MAC 地址，格式：mstp:mac
+type Address struct {
+	// Router BACnet/IP 路由器地址，直连 MS/TP 时为空
+	Router *net.UDPAddr
+	// Net 远程网络号，0 表示本地网络
+	Net uint16
+	// MAC 设备的 MAC 地址，MS/TP 为 1 个字节，为空表示广播
+	MAC []byte
+}
+
+// Network 地址类型
+func (a *Address) Network() string {
+	return "bacnet"
+}
+
+// String 地址，格式见 ParseAddress
+func (a *Address) String() string {
+	mac := formatMAC(a.MAC)
+	if a.Router != nil {
+		return fmt.Sprintf("%d:%s@%s", a.Net, mac, a.Router)
+	}
+	if a.Net != 0 {
+		return fmt.Sprintf("%d:%s", a.Net, mac)
+	}
+	return MSTPPrefix + mac
+}
+
+// ParseAddress 解析设备地址：
+//   - host:port BACnet/IP 设备，端口默认 47808
+//   - net:mac@host:port 经 BACnet/IP 路由器访问远程网络（eg. MS/TP）上的设备，eg. 5:12@192.168.1.10
+//   - mstp:mac 通过串口直连的 MS/TP 设备，eg. mstp:12
+//
+// MAC 为 0-255 的十进制数（MS/TP）或者十六进制字节
+func ParseAddress(address string) (net.Addr, error) {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, MSTPPrefix) {
+		mac, err := parseMAC(strings.TrimPrefix(address, MSTPPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if len(mac) != 1 || mac[0] == mstpBroadcast {
+			return nil, fmt.Errorf("invalid bacnet mstp address: %s", address)
+		}
+		return &Address{MAC: mac}, nil
+	}
+	route, router, ok := strings.Cut(address, "@")
+	if !ok {
+		addr, err := ResolveAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		return addr, nil
+	}
+	network, mac, ok := strings.Cut(route, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid bacnet remote address %s, format: net:mac@host:port", address)
+	}
+	n, err := strconv.ParseUint(network, 10, 16)
+	if err != nil || n == 0 || n == globalNetwork {
+		return nil, fmt.Errorf("invalid bacnet network number: %s", network)
+	}
+	a := &Address{Net: uint16(n)}
+	if a.MAC, err = parseMAC(mac); err != nil {
+		return nil, err
+	}
+	if len(a.MAC) == 0 {
+		return nil, fmt.Errorf("invalid bacnet remote address %s, mac is required", address)
+	}
+	if a.Router, err = ResolveAddress(router); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// parseMAC 0-255 的十进制数为 1 个字节，否则按十六进制解析
+func parseMAC(s string) ([]byte, error) {
+	if v, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return []byte{byte(v)}, nil
+	}
+	mac, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	if err != nil || len(mac) > 7 {
+		return nil, fmt.Errorf("invalid bacnet mac address: %s", s)
+	}
+	return mac, nil
+}
+
+// formatMAC 1 个字节的 MAC 为十进制数，否则为十六进制
+func formatMAC(mac []byte) string {
+	if len(mac) == 1 {
+		return strconv.Itoa(int(mac[0]))
+	}
+	return hex.EncodeToString(mac)
+}
+
+// routerOf 来源地址对应的 BACnet/IP 地址，直连 MS/TP 为 nil
+func routerOf(addr net.Addr) *net.UDPAddr {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a
+	case *Address:
+		return a.Router
+	}
+	return nil
+}
+
+// encodeNPDU 编码 NPDU 头，远程网络的地址加上目的网络号和 MAC
+func encodeNPDU(dst net.Addr, expectingReply bool) []byte {
+	control := byte(0)
+	if expectingReply {
+		control |= npduExpectingReply
+	}
+	a, ok := dst.(*Address)
+	if !ok || a.Net == 0 {
+		return []byte{npduVersion, control}
+	}
+	b := []byte{npduVersion, control | npduDestination, byte(a.Net >> 8), byte(a.Net), byte(len(a.MAC))}
+	b = append(b, a.MAC...)
+	return append(b, maxHopCount)
+}
+
+// parseNPDU 解析 NPDU，返回 APDU 和经路由器转发时的来源网络号和 MAC，网络层消息返回 false
+func parseNPDU(b []byte) (apdu []byte, snet uint16, sadr []byte, ok bool) {
+	if len(b) < 2 || b[0] != npduVersion {
+		return nil, 0, nil, false
+	}
+	control := b[1]
+	offset := 2
+	if control&npduNetworkMessage != 0 {
+		return nil, 0, nil, false
+	}
+	if control&npduDestination != 0 {
+		if len(b) < offset+3 {
+			return nil, 0, nil, false
+		}
+		offset += 3 + int(b[offset+2])
+	}
+	if control&npduSource != 0 {
+		if len(b) < offset+3 {
+			return nil, 0, nil, false
+		}
+		snet = binary.BigEndian.Uint16(b[offset:])
+		length := int(b[offset+2])
+		if len(b) < offset+3+length {
+			return nil, 0, nil, false
+		}
+		sadr = append([]byte(nil), b[offset+3:offset+3+length]...)
+		offset += 3 + length
+	}
+	if control&npduDestination != 0 {
+		offset++
+	}
+	if len(b) <= offset {
+		return nil, 0, nil, false
+	}
+	return b[offset:], snet, sadr, true
+}
+
+// datalink 数据链路层，发送和接收 NPDU
+type datalink interface {
+	// send 发送 NPDU，dst 为 nil 或者没有 MAC 的远程地址时在本地网络广播
+	send(npdu []byte, dst net.Addr, expectingReply bool) error
+	// receive 接收 NPDU 和数据链路层的来源地址，链路关闭时返回错误
+	receive() ([]byte, net.Addr, error)
+	// localAddr 本地地址
+	localAddr() net.Addr
+	// maxAPDU 请求中声明的最大 APDU
+	maxAPDU() byte
+	close() error
+}
+
+// ipLink BACnet/IP 数据链路，NPDU 加上 BVLC 头通过 UDP 发送
+type ipLink struct {
+	conn      *net.UDPConn
+	broadcast *net.UDPAddr
+	// source 原始报文抓取的来源，见 pkg/capture
+	source string
+}
+
+// newIPLink 监听本地 UDP 端口
+func newIPLink(localAddress, broadcast string) (*ipLink, error) {
+	local, err := net.ResolveUDPAddr("udp4", localAddress)
+	if err != nil {
+		return nil, err
+	}
+	if broadcast == "" {
+		broadcast = DefaultBroadcast
+	}
+	broadcastAddr, err := ResolveAddress(broadcast)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", local)
+	if err != nil {
+		return nil, err
+	}
+	return &ipLink{
+		conn:      conn,
+		broadcast: broadcastAddr,
+		source:    capture.Source("bacnet", conn.LocalAddr().String()),
+	}, nil
+}
+
+func (l *ipLink) send(npdu []byte, dst net.Addr, expectingReply bool) error {
+	addr, function := l.broadcast, byte(bvlcOriginalBroadcast)
+	if router := routerOf(dst); router != nil {
+		addr, function = router, bvlcOriginalUnicast
+	}
+	packet := append([]byte{bvlcType, function, 0, 0}, npdu...)
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+	n, err := l.conn.WriteToUDP(packet, addr)
+	if n > 0 {
+		capture.Record(l.source, capture.DirectionTx, l.conn.LocalAddr(), addr, packet[:n])
+	}
+	return err
+}
+
+func (l *ipLink) receive() ([]byte, net.Addr, error) {
+	buf := make([]byte, 1500)
+	for {
+		n, src, err := l.conn.ReadFromUDP(buf)
+		if n > 0 {
+			capture.Record(l.source, capture.DirectionRx, l.conn.LocalAddr(), src, buf[:n])
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		npdu, source, ok := parseBVLC(buf[:n])
+		if !ok {
+			continue
+		}
+		if source != nil {
+			src = source
+		}
+		return append([]byte(nil), npdu...), src, nil
+	}
+}
+
+func (l *ipLink) localAddr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+func (l *ipLink) maxAPDU() byte {
+	return maxAPDUAccepted
+}
+
+func (l *ipLink) close() error {
+	return l.conn.Close()
+}
+
+// parseBVLC 解析 BVLC，返回 NPDU
+// 经 BBMD 转发的数据包同时返回原始的来源地址
+func parseBVLC(b []byte) (npdu []byte, source *net.UDPAddr, ok bool) {
+	if len(b) < 4 || b[0] != bvlcType {
+		return nil, nil, false
+	}
+	length := int(binary.BigEndian.Uint16(b[2:]))
+	if length > len(b) {
+		return nil, nil, false
+	}
+	b = b[:length]
+	offset := 4
+	switch b[1] {
+	case bvlcOriginalUnicast, bvlcOriginalBroadcast:
+	case bvlcForwarded:
+		if len(b) < 10 {
+			return nil, nil, false
+		}
+		source = &net.UDPAddr{IP: net.IP(append([]byte(nil), b[4:8]...)), Port: int(binary.BigEndian.Uint16(b[8:10]))}
+		offset = 10
+	default:
+		return nil, nil, false
+	}
+	return b[offset:], source, true
+}
+
+// errLinkClosed 数据链路已经关闭
+var errLinkClosed = errors.New("bacnet datalink is closed")
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	addr, err := ParseAddress("127.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:47808", addr.String())
+
+	addr, err = ParseAddress("5:12@127.0.0.1:47809")
+	assert.Nil(t, err)
+	remote := addr.(*Address)
+	assert.Equal(t, uint16(5), remote.Net)
+	assert.Equal(t, []byte{12}, remote.MAC)
+	assert.Equal(t, 47809, remote.Router.Port)
+	assert.Equal(t, "5:12@127.0.0.1:47809", addr.String())
+
+	addr, err = ParseAddress("7:c0a8010abac0@127.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "7:c0a8010abac0@127.0.0.1:47808", addr.String())
+
+	addr, err = ParseAddress("mstp:12")
+	assert.Nil(t, err)
+	assert.Equal(t, &Address{MAC: []byte{12}}, addr)
+	assert.Equal(t, "mstp:12", addr.String())
+
+	for _, address := range []string{"mstp:255", "mstp:x", "0:12@127.0.0.1", "65535:12@127.0.0.1", "5@127.0.0.1", "5:@127.0.0.1"} {
+		_, err = ParseAddress(address)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestNPDU(t *testing.T) {
+	// 本地网络
+	assert.Equal(t, []byte{npduVersion, npduExpectingReply}, encodeNPDU(&net.UDPAddr{}, true))
+	assert.Equal(t, []byte{npduVersion, 0}, encodeNPDU(&Address{MAC: []byte{12}}, false))
+	// 远程网络和全局广播
+	npdu := encodeNPDU(&Address{Net: 5, MAC: []byte{12}}, true)
+	assert.Equal(t, []byte{npduVersion, npduDestination | npduExpectingReply, 0, 5, 1, 12, maxHopCount}, npdu)
+	assert.Equal(t, []byte{npduVersion, npduDestination, 0xff, 0xff, 0, maxHopCount}, encodeNPDU(globalBroadcast, false))
+
+	apdu, snet, sadr, ok := parseNPDU(append(npdu, 0x30, 0x01))
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0x30, 0x01}, apdu)
+	assert.Equal(t, uint16(0), snet)
+	assert.Equal(t, 0, len(sadr))
+
+	apdu, snet, sadr, ok = parseNPDU([]byte{npduVersion, npduSource, 0, 5, 1, 12, 0x30, 0x01})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0x30, 0x01}, apdu)
+	assert.Equal(t, uint16(5), snet)
+	assert.Equal(t, []byte{12}, sadr)
+
+	// 网络层消息
+	_, _, _, ok = parseNPDU([]byte{npduVersion, npduNetworkMessage, 0x00})
+	assert.False(t, ok)
+}
+
+func TestRouter(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	device := &testDevice{conn: conn, instance: 2001, network: 5, mac: 12, values: map[uint64][][]byte{}}
+	go device.serve()
+	defer device.conn.Close()
+	device.set(0, 1, PropertyPresentValue, []byte{0x44, 0x41, 0xac, 0x00, 0x00})
+	remote := "5:12@" + device.addr()
+
+	client, err := NewClient(ClientConfig{Broadcast: device.addr(), Timeout: 200 * time.Millisecond})
+	assert.Nil(t, err)
+	defer client.Close()
+
+	// Who-Is 全局广播，I-Am 带来源网络
+	devices, err := client.WhoIs(nil, nil, 200*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(devices))
+	assert.Equal(t, remote, devices[0].Address)
+	addr, err := client.FindDevice(2001)
+	assert.Nil(t, err)
+	assert.Equal(t, remote, addr.String())
+
+	// 没有目的网络的请求路由器不转发
+	_, err = client.ReadProperty(routerOf(addr), ObjectProperty{Instance: 1, Property: PropertyPresentValue})
+	assert.NotNil(t, err)
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	node, err := test.CreateAndInitNode("x/bacnetRead", types.Configuration{
+		"server":  remote,
+		"objects": []map[string]interface{}{{"name": "temperature", "objectType": "analog-input", "instance": 1}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 21.5, values["temperature"])
+	})
+}
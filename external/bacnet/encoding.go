@@ -0,0 +1,528 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// 应用标签
+const (
+	tagNull            = 0
+	tagBoolean         = 1
+	tagUnsigned        = 2
+	tagSigned          = 3
+	tagReal            = 4
+	tagDouble          = 5
+	tagOctetString     = 6
+	tagCharacterString = 7
+	tagBitString       = 8
+	tagEnumerated      = 9
+	tagDate            = 10
+	tagTime            = 11
+	tagObjectId        = 12
+)
+
+// 写入值的类型，为空则按对象类型推断
+const (
+	ValueTypeNull       = "null"
+	ValueTypeBoolean    = "boolean"
+	ValueTypeUnsigned   = "unsigned"
+	ValueTypeSigned     = "signed"
+	ValueTypeReal       = "real"
+	ValueTypeDouble     = "double"
+	ValueTypeString     = "string"
+	ValueTypeEnumerated = "enumerated"
+)
+
+// errShortData 数据不完整
+var errShortData = errors.New("bacnet: short data")
+
+// objectTypes 对象类型名称
+var objectTypes = map[string]uint16{
+	"analog-input":           0,
+	"analog-output":          1,
+	"analog-value":           2,
+	"binary-input":           3,
+	"binary-output":          4,
+	"binary-value":           5,
+	"calendar":               6,
+	"command":                7,
+	"device":                 8,
+	"event-enrollment":       9,
+	"file":                   10,
+	"group":                  11,
+	"loop":                   12,
+	"multi-state-input":      13,
+	"multi-state-output":     14,
+	"notification-class":     15,
+	"program":                16,
+	"schedule":               17,
+	"averaging":              18,
+	"multi-state-value":      19,
+	"trend-log":              20,
+	"accumulator":            23,
+	"pulse-converter":        24,
+	"integer-value":          45,
+	"large-analog-value":     46,
+	"positive-integer-value": 48,
+}
+
+// PropertyPresentValue present-value 属性
+const PropertyPresentValue = 85
+
+// 质量相关的属性
+const (
+	PropertyReliability = 103
+	PropertyStatusFlags = 111
+)
+
+// properties 属性名称
+var properties = map[string]uint32{
+	"buffer-size":        126,
+	"cov-increment":      22,
+	"description":        28,
+	"effective-period":   32,
+	"event-state":        36,
+	"exception-schedule": 38,
+	"firmware-revision":  44,
+	"log-buffer":         131,
+	"log-enable":         133,
+	"log-interval":       134,
+	"max-pres-value":     65,
+	"min-pres-value":     69,
+	"model-name":         70,
+	"object-identifier":  75,
+	"object-list":        76,
+	"object-name":        77,
+	"object-type":        79,
+	"out-of-service":     81,
+	"present-value":      85,
+	"priority-array":     87,
+	"record-count":       141,
+	"relinquish-default": 104,
+	"reliability":        103,
+	"schedule-default":   174,
+	"start-time":         142,
+	"status-flags":       111,
+	"stop-time":          143,
+	"system-status":      112,
+	"total-record-count": 145,
+	"units":              117,
+	"vendor-identifier":  120,
+	"vendor-name":        121,
+	"weekly-schedule":    123,
+}
+
+// ParseObjectType 解析对象类型，支持名称（eg. analog-input, analogInput）或者编号
+func ParseObjectType(s string) (uint16, error) {
+	if v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 10); err == nil {
+		return uint16(v), nil
+	}
+	if v, ok := objectTypes[normalizeName(s)]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("unsupported bacnet object type: %s", s)
+}
+
+// ParseProperty 解析属性，支持名称（eg. present-value, presentValue）或者编号
+func ParseProperty(s string) (uint32, error) {
+	if v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 22); err == nil {
+		return uint32(v), nil
+	}
+	if v, ok := properties[normalizeName(s)]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("unsupported bacnet property: %s", s)
+}
+
+// ObjectTypeName 对象类型名称，未知类型返回编号
+func ObjectTypeName(t uint16) string {
+	for name, v := range objectTypes {
+		if v == t {
+			return name
+		}
+	}
+	return strconv.Itoa(int(t))
+}
+
+// normalizeName 把 analogInput、analog_input、ANALOG-INPUT 统一为 analog-input
+func normalizeName(s string) string {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(s) {
+		switch {
+		case r == '_' || r == ' ':
+			b.WriteByte('-')
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && !strings.HasSuffix(b.String(), "-") && strings.ToUpper(s) != s {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r + 'a' - 'A')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// encodeObjectId 对象标识：类型占高10位，实例号占低22位
+func encodeObjectId(objectType uint16, instance uint32) uint32 {
+	return uint32(objectType)<<22 | instance&0x3fffff
+}
+
+// encodeContextUnsigned 编码上下文标签的无符号整数，使用最少的字节
+func encodeContextUnsigned(tag byte, v uint32) []byte {
+	var value []byte
+	switch {
+	case v < 0x100:
+		value = []byte{byte(v)}
+	case v < 0x10000:
+		value = []byte{byte(v >> 8), byte(v)}
+	case v < 0x1000000:
+		value = []byte{byte(v >> 16), byte(v >> 8), byte(v)}
+	default:
+		value = []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	}
+	return append([]byte{tag<<4 | 0x08 | byte(len(value))}, value...)
+}
+
+// encodeContextObjectId 编码上下文标签的对象标识
+func encodeContextObjectId(tag byte, objectType uint16, instance uint32) []byte {
+	b := []byte{tag<<4 | 0x08 | 4, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(b[1:], encodeObjectId(objectType, instance))
+	return b
+}
+
+// tagHeader 标签头
+type tagHeader struct {
+	number  byte
+	context bool
+	// opening/closing 上下文构造标签的开始和结束
+	opening bool
+	closing bool
+	// length 数据长度，应用标签 Boolean 的值也存放在这里
+	length int
+}
+
+// decodeTag 解码标签头，返回标签头和标签头占用的字节数
+func decodeTag(b []byte) (tagHeader, int, error) {
+	if len(b) == 0 {
+		return tagHeader{}, 0, errShortData
+	}
+	h := tagHeader{number: b[0] >> 4, context: b[0]&0x08 != 0}
+	n := 1
+	if h.number == 0x0f {
+		if len(b) < 2 {
+			return h, 0, errShortData
+		}
+		h.number = b[1]
+		n++
+	}
+	lvt := int(b[0] & 0x07)
+	switch {
+	case h.context && lvt == 6:
+		h.opening = true
+	case h.context && lvt == 7:
+		h.closing = true
+	case lvt == 5:
+		if len(b) < n+1 {
+			return h, 0, errShortData
+		}
+		switch b[n] {
+		case 254:
+			if len(b) < n+3 {
+				return h, 0, errShortData
+			}
+			h.length = int(binary.BigEndian.Uint16(b[n+1:]))
+			n += 3
+		case 255:
+			if len(b) < n+5 {
+				return h, 0, errShortData
+			}
+			h.length = int(binary.BigEndian.Uint32(b[n+1:]))
+			n += 5
+		default:
+			h.length = int(b[n])
+			n++
+		}
+	default:
+		h.length = lvt
+	}
+	return h, n, nil
+}
+
+// decodeValues 解码应用标签编码的值，直到数据结束或者遇到结束标签
+// 返回解码的值和占用的字节数
+func decodeValues(b []byte) ([]interface{}, int, error) {
+	var values []interface{}
+	offset := 0
+	for offset < len(b) {
+		h, n, err := decodeTag(b[offset:])
+		if err != nil {
+			return nil, 0, err
+		}
+		if h.closing {
+			return values, offset, nil
+		}
+		if h.context {
+			// 结构化的值，例如 priority-array 中的上下文标签，按原始字节输出
+			if h.opening {
+				inner, size, err := decodeValues(b[offset+n:])
+				if err != nil {
+					return nil, 0, err
+				}
+				values = append(values, inner)
+				offset += n + size + 1
+				continue
+			}
+			if offset+n+h.length > len(b) {
+				return nil, 0, errShortData
+			}
+			values = append(values, hex.EncodeToString(b[offset+n:offset+n+h.length]))
+			offset += n + h.length
+			continue
+		}
+		length := h.length
+		if h.number == tagBoolean {
+			length = 0
+		}
+		if offset+n+length > len(b) {
+			return nil, 0, errShortData
+		}
+		v, err := decodeApplication(h, b[offset+n:offset+n+length])
+		if err != nil {
+			return nil, 0, err
+		}
+		values = append(values, v)
+		offset += n + length
+	}
+	return values, offset, nil
+}
+
+// decodeApplication 解码一个应用标签的值
+func decodeApplication(h tagHeader, data []byte) (interface{}, error) {
+	switch h.number {
+	case tagNull:
+		return nil, nil
+	case tagBoolean:
+		return h.length != 0, nil
+	case tagUnsigned, tagEnumerated:
+		return decodeUnsigned(data), nil
+	case tagSigned:
+		v := decodeUnsigned(data)
+		// 符号扩展
+		shift := 64 - 8*uint(len(data))
+		return int64(v<<shift) >> shift, nil
+	case tagReal:
+		if len(data) != 4 {
+			return nil, errShortData
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(data)), nil
+	case tagDouble:
+		if len(data) != 8 {
+			return nil, errShortData
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	case tagOctetString:
+		return hex.EncodeToString(data), nil
+	case tagCharacterString:
+		if len(data) == 0 {
+			return "", nil
+		}
+		// 第1个字节为字符集，0 为 UTF-8，其他字符集按原始字节输出
+		return string(data[1:]), nil
+	case tagBitString:
+		if len(data) == 0 {
+			return []bool{}, nil
+		}
+		unused := int(data[0])
+		bits := make([]bool, 0, (len(data)-1)*8)
+		for i, b := range data[1:] {
+			count := 8
+			if i == len(data)-2 {
+				count -= unused
+			}
+			for j := 0; j < count; j++ {
+				bits = append(bits, b&(0x80>>j) != 0)
+			}
+		}
+		return bits, nil
+	case tagDate:
+		if len(data) != 4 {
+			return nil, errShortData
+		}
+		return fmt.Sprintf("%04d-%02d-%02d", 1900+int(data[0]), data[1], data[2]), nil
+	case tagTime:
+		if len(data) != 4 {
+			return nil, errShortData
+		}
+		return fmt.Sprintf("%02d:%02d:%02d.%02d", data[0], data[1], data[2], data[3]), nil
+	case tagObjectId:
+		if len(data) != 4 {
+			return nil, errShortData
+		}
+		id := binary.BigEndian.Uint32(data)
+		return fmt.Sprintf("%s:%d", ObjectTypeName(uint16(id>>22)), id&0x3fffff), nil
+	default:
+		return nil, fmt.Errorf("unsupported bacnet application tag: %d", h.number)
+	}
+}
+
+// decodeUnsigned 解码大端序无符号整数
+func decodeUnsigned(data []byte) uint64 {
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// EncodeValue 按应用标签编码写入的值，valueType 为空时按对象类型推断：
+// analog 对象为 real，binary 对象为 enumerated，multi-state 对象为 unsigned，其他为 real。
+// value 为 nil 或者 "null" 时编码为 Null，用于释放优先级
+func EncodeValue(valueType string, objectType uint16, value interface{}) ([]byte, error) {
+	if s, ok := value.(string); value == nil || ok && strings.EqualFold(s, ValueTypeNull) {
+		valueType = ValueTypeNull
+	}
+	if valueType == "" {
+		valueType = defaultValueType(objectType)
+	}
+	switch strings.ToLower(valueType) {
+	case ValueTypeNull:
+		return []byte{tagNull << 4}, nil
+	case ValueTypeBoolean:
+		v, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, err
+		}
+		if v {
+			return []byte{tagBoolean<<4 | 1}, nil
+		}
+		return []byte{tagBoolean << 4}, nil
+	case ValueTypeUnsigned, ValueTypeEnumerated:
+		tag := byte(tagUnsigned)
+		if strings.EqualFold(valueType, ValueTypeEnumerated) {
+			tag = tagEnumerated
+		}
+		v, err := toUnsigned(value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeApplication(tag, unsignedBytes(v)), nil
+	case ValueTypeSigned:
+		v, err := cast.ToInt64E(value)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(v))
+		// 去掉多余的符号扩展字节
+		for len(b) > 1 && (b[0] == 0 && b[1]&0x80 == 0 || b[0] == 0xff && b[1]&0x80 != 0) {
+			b = b[1:]
+		}
+		return encodeApplication(tagSigned, b), nil
+	case ValueTypeReal:
+		v, err := cast.ToFloat64E(value)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, math.Float32bits(float32(v)))
+		return encodeApplication(tagReal, b), nil
+	case ValueTypeDouble:
+		v, err := cast.ToFloat64E(value)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, math.Float64bits(v))
+		return encodeApplication(tagDouble, b), nil
+	case ValueTypeString:
+		// 字符集 0 为 UTF-8
+		return encodeApplication(tagCharacterString, append([]byte{0}, cast.ToString(value)...)), nil
+	default:
+		return nil, fmt.Errorf("unsupported bacnet value type: %s", valueType)
+	}
+}
+
+// defaultValueType 对象 present-value 的类型
+func defaultValueType(objectType uint16) string {
+	switch objectType {
+	case objectTypes["binary-input"], objectTypes["binary-output"], objectTypes["binary-value"]:
+		return ValueTypeEnumerated
+	case objectTypes["multi-state-input"], objectTypes["multi-state-output"], objectTypes["multi-state-value"],
+		objectTypes["positive-integer-value"]:
+		return ValueTypeUnsigned
+	case objectTypes["integer-value"]:
+		return ValueTypeSigned
+	case objectTypes["large-analog-value"]:
+		return ValueTypeDouble
+	default:
+		return ValueTypeReal
+	}
+}
+
+// toUnsigned 转换为无符号整数，binary 对象支持 active/inactive 和布尔值
+func toUnsigned(value interface{}) (uint32, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "active", "true", "on":
+			return 1, nil
+		case "inactive", "false", "off":
+			return 0, nil
+		}
+	}
+	v, err := cast.ToInt64E(value)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 || v > math.MaxUint32 {
+		return 0, fmt.Errorf("bacnet unsigned value out of range: %d", v)
+	}
+	return uint32(v), nil
+}
+
+// unsignedBytes 大端序无符号整数，使用最少的字节
+func unsignedBytes(v uint32) []byte {
+	return encodeContextUnsigned(0, v)[1:]
+}
+
+// encodeApplication 编码应用标签，长度大于4使用扩展长度
+func encodeApplication(tag byte, data []byte) []byte {
+	switch {
+	case len(data) <= 4:
+		return append([]byte{tag<<4 | byte(len(data))}, data...)
+	case len(data) < 254:
+		return append([]byte{tag<<4 | 5, byte(len(data))}, data...)
+	default:
+		return append([]byte{tag<<4 | 5, 254, byte(len(data) >> 8), byte(len(data))}, data...)
+	}
+}
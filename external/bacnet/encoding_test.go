@@ -0,0 +1,119 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseNames(t *testing.T) {
+	for _, s := range []string{"analog-input", "analogInput", "ANALOG_INPUT", "0"} {
+		v, err := ParseObjectType(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, uint16(0), v, s)
+	}
+	v, err := ParseObjectType("multiStateValue")
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(19), v)
+	_, err = ParseObjectType("unknown")
+	assert.NotNil(t, err)
+
+	p, err := ParseProperty("presentValue")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(PropertyPresentValue), p)
+	p, err = ParseProperty("77")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(77), p)
+	_, err = ParseProperty("no-such-property")
+	assert.NotNil(t, err)
+}
+
+func TestDecodeValues(t *testing.T) {
+	data := []byte{
+		0x44, 0x41, 0xac, 0x00, 0x00, // Real 21.5
+		0x31, 0xfe, // Signed -2
+		0x11,       // Boolean true
+		0x21, 0x07, // Unsigned 7
+		0x75, 0x04, 0x00, 'a', 'b', 'c', // CharacterString "abc"
+		0x82, 0x04, 0xa0, // BitString 1010
+		0x91, 0x02, // Enumerated 2
+		0xc4, 0x00, 0x00, 0x00, 0x05, // ObjectIdentifier analog-input:5
+		0x00, // Null
+		0x3f, // 结束标签
+		0x21, 0x09,
+	}
+	values, n, err := decodeValues(data)
+	assert.Nil(t, err)
+	assert.Equal(t, len(data)-3, n)
+	assert.Equal(t, 9, len(values))
+	assert.Equal(t, float32(21.5), values[0])
+	assert.Equal(t, int64(-2), values[1])
+	assert.Equal(t, true, values[2])
+	assert.Equal(t, uint64(7), values[3])
+	assert.Equal(t, "abc", values[4])
+	assert.Equal(t, []bool{true, false, true, false}, values[5])
+	assert.Equal(t, uint64(2), values[6])
+	assert.Equal(t, "analog-input:5", values[7])
+	assert.Nil(t, values[8])
+
+	// 数据不完整
+	_, _, err = decodeValues([]byte{0x44, 0x41})
+	assert.NotNil(t, err)
+}
+
+func TestEncodeValue(t *testing.T) {
+	cases := []struct {
+		valueType  string
+		objectType uint16
+		value      interface{}
+		expected   []byte
+	}{
+		{"", 1, 21.5, []byte{0x44, 0x41, 0xac, 0x00, 0x00}},
+		{"", 4, "active", []byte{0x91, 0x01}},
+		{"", 5, false, []byte{0x91, 0x00}},
+		{"", 14, 3, []byte{0x21, 0x03}},
+		{"", 1, nil, []byte{0x00}},
+		{"", 1, "null", []byte{0x00}},
+		{"signed", 0, -2, []byte{0x31, 0xfe}},
+		{"signed", 0, 128, []byte{0x32, 0x00, 0x80}},
+		{"unsigned", 0, 300, []byte{0x22, 0x01, 0x2c}},
+		{"boolean", 0, "true", []byte{0x11}},
+		{"double", 0, 1, []byte{0x55, 0x08, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0}},
+		{"string", 0, "abc", []byte{0x74, 0x00, 'a', 'b', 'c'}},
+		{"string", 0, "abcd", []byte{0x75, 0x05, 0x00, 'a', 'b', 'c', 'd'}},
+	}
+	for _, c := range cases {
+		v, err := EncodeValue(c.valueType, c.objectType, c.value)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, v, c.valueType, c.value)
+	}
+	_, err := EncodeValue("unsigned", 0, -1)
+	assert.NotNil(t, err)
+	_, err = EncodeValue("real", 0, "abc")
+	assert.NotNil(t, err)
+	_, err = EncodeValue("unknown", 0, 1)
+	assert.NotNil(t, err)
+}
+
+func TestEncodeContext(t *testing.T) {
+	assert.Equal(t, []byte{0x09, 0x05}, encodeContextUnsigned(0, 5))
+	assert.Equal(t, []byte{0x1a, 0x01, 0x00}, encodeContextUnsigned(1, 256))
+	assert.Equal(t, []byte{0x0c, 0x00, 0x00, 0x00, 0x01}, encodeContextObjectId(0, 0, 1))
+	assert.Equal(t, []byte{0x0c, 0x02, 0x00, 0x00, 0x0a}, encodeContextObjectId(0, objectTypeDevice, 10))
+}
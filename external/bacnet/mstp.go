@@ -0,0 +1,677 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/serial"
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+)
+
+const (
+	// MSTPScheme 通过串口直连 MS/TP 网络，eg. mstp:///dev/ttyUSB0?mac=3
+	MSTPScheme = "mstp://"
+	// MSTPTCPScheme 通过串口服务器（透明传输）连接 MS/TP 网络，eg. mstp+tcp://192.168.1.20:4001?mac=3
+	MSTPTCPScheme = "mstp+tcp://"
+	// DefaultMSTPBaudRate MS/TP 默认波特率
+	DefaultMSTPBaudRate = 38400
+	// DefaultMaxMaster 默认最大主站地址
+	DefaultMaxMaster = 127
+)
+
+// MS/TP 帧类型
+const (
+	mstpToken                 = 0x00
+	mstpPollForMaster         = 0x01
+	mstpReplyToPollForMaster  = 0x02
+	mstpTestRequest           = 0x03
+	mstpTestResponse          = 0x04
+	mstpDataExpectingReply    = 0x05
+	mstpDataNotExpectingReply = 0x06
+	mstpReplyPostponed        = 0x07
+)
+
+// MS/TP 协议常量
+const (
+	mstpPreamble1 = 0x55
+	mstpPreamble2 = 0xff
+	mstpBroadcast = 0xff
+	// mstpMaxData 数据帧最大长度
+	mstpMaxData = 501
+	// mstpMaxAPDU 请求中声明的最大 APDU：480 字节
+	mstpMaxAPDU = 0x03
+	// mstpHeaderResidue 包含 CRC 计算的帧头 CRC 余数
+	mstpHeaderResidue = 0x55
+	// mstpDataResidue 包含 CRC 计算的数据 CRC 余数
+	mstpDataResidue = 0xf0b8
+	// mstpPoll 每传递多少次令牌轮询一次新的主站
+	mstpPoll = 50
+	// mstpRetryToken 下一个站点没有使用令牌时重发的次数
+	mstpRetryToken = 1
+	// mstpReplyTimeout 等待数据帧回复或者 Reply Postponed 的时间
+	mstpReplyTimeout = 255 * time.Millisecond
+	// mstpUsageTimeout 等待下一个站点使用令牌或者回复 Poll For Master 的时间
+	// 取标准允许的较大值，兼容串口服务器的延时
+	mstpUsageTimeout = 50 * time.Millisecond
+	// mstpSlot 没有令牌时每个站点的等待时间
+	mstpSlot = 10 * time.Millisecond
+	// mstpNoToken 没有令牌时生成令牌之前的静默时间
+	mstpNoToken = 500 * time.Millisecond
+)
+
+// errMSTPFrame 无效的 MS/TP 帧，丢弃后继续接收
+var errMSTPFrame = errors.New("bacnet: invalid mstp frame")
+
+// MSTPConfig 直连 MS/TP 网络的配置，从本地地址解析：
+//   - mstp:///dev/ttyUSB0?mac=3&baud=38400&maxMaster=127&maxInfoFrames=1 串口，Windows：mstp://COM3?mac=3
+//   - mstp+tcp://host:port?mac=3 串口服务器，参数同上
+type MSTPConfig struct {
+	// Port 串口或者串口服务器地址
+	Port string
+	// TCP 是否通过串口服务器连接
+	TCP bool
+	// MAC 本站的 MAC 地址，0-127，不能和总线上的其他主站重复
+	MAC byte
+	// BaudRate 波特率，默认 38400
+	BaudRate int
+	// MaxMaster 总线上最大的主站地址，默认 127，减小可以加快查找下一个主站
+	MaxMaster byte
+	// MaxInfoFrames 每次持有令牌时最多发送的数据帧数，默认 1
+	MaxInfoFrames int
+}
+
+// isMSTPLink 本地地址是否为 MS/TP 数据链路
+func isMSTPLink(localAddress string) bool {
+	return strings.HasPrefix(localAddress, MSTPScheme) || strings.HasPrefix(localAddress, MSTPTCPScheme)
+}
+
+// ParseMSTPConfig 解析 MS/TP 本地地址，格式见 MSTPConfig
+func ParseMSTPConfig(localAddress string) (MSTPConfig, error) {
+	u, err := url.Parse(localAddress)
+	if err != nil {
+		return MSTPConfig{}, err
+	}
+	config := MSTPConfig{
+		BaudRate:      DefaultMSTPBaudRate,
+		MaxMaster:     DefaultMaxMaster,
+		MaxInfoFrames: 1,
+	}
+	switch u.Scheme {
+	case "mstp":
+		config.Port = u.Host + u.Path
+	case "mstp+tcp":
+		config.Port = u.Host
+		config.TCP = true
+	default:
+		return MSTPConfig{}, fmt.Errorf("invalid bacnet mstp address: %s", localAddress)
+	}
+	if config.Port == "" {
+		return MSTPConfig{}, fmt.Errorf("bacnet mstp port is required: %s", localAddress)
+	}
+	query := u.Query()
+	mac, err := strconv.ParseUint(query.Get("mac"), 10, 8)
+	if err != nil || mac > DefaultMaxMaster {
+		return MSTPConfig{}, fmt.Errorf("bacnet mstp mac must be 0-127: %s", localAddress)
+	}
+	config.MAC = byte(mac)
+	if v := query.Get("baud"); v != "" {
+		if config.BaudRate, err = strconv.Atoi(v); err != nil || config.BaudRate <= 0 {
+			return MSTPConfig{}, fmt.Errorf("invalid bacnet mstp baud rate: %s", v)
+		}
+	}
+	if v := query.Get("maxMaster"); v != "" {
+		maxMaster, err := strconv.ParseUint(v, 10, 8)
+		if err != nil || maxMaster > DefaultMaxMaster || byte(maxMaster) < config.MAC {
+			return MSTPConfig{}, fmt.Errorf("bacnet mstp maxMaster must be mac-127: %s", v)
+		}
+		config.MaxMaster = byte(maxMaster)
+	}
+	if v := query.Get("maxInfoFrames"); v != "" {
+		if config.MaxInfoFrames, err = strconv.Atoi(v); err != nil || config.MaxInfoFrames <= 0 {
+			return MSTPConfig{}, fmt.Errorf("invalid bacnet mstp maxInfoFrames: %s", v)
+		}
+	}
+	return config, nil
+}
+
+// mstpFrame MS/TP 帧
+type mstpFrame struct {
+	kind byte
+	dst  byte
+	src  byte
+	data []byte
+}
+
+// mstpOutgoing 等待令牌发送的数据帧
+type mstpOutgoing struct {
+	npdu           []byte
+	dst            byte
+	expectingReply bool
+}
+
+// mstpLink MS/TP 数据链路，作为主站参与令牌传递
+//
+// 简化的主站状态机：回复 Poll For Master；持有令牌时发送队列中的数据帧，需要回复的等待回复后把令牌传递给下一个站点；
+// 总线静默时生成令牌，没有其他主站时作为唯一主站。客户端不处理设备发来的确认请求，只回复 Reply Postponed
+type mstpLink struct {
+	config MSTPConfig
+	port   io.ReadWriteCloser
+	local  *Address
+	// source 原始报文抓取的来源，只记录数据帧
+	source string
+
+	frames   chan mstpFrame
+	outgoing chan mstpOutgoing
+	received chan mstpFrame
+	done     chan struct{}
+	once     sync.Once
+	mu       sync.Mutex
+	err      error
+
+	// 以下字段只在 run 中使用
+	ns         byte
+	ps         byte
+	soleMaster bool
+	tokenCount int
+	pending    *mstpOutgoing
+	unread     *mstpFrame
+}
+
+// newMSTPLink 打开串口或者连接串口服务器，开始参与令牌传递
+func newMSTPLink(localAddress string) (*mstpLink, error) {
+	config, err := ParseMSTPConfig(localAddress)
+	if err != nil {
+		return nil, err
+	}
+	var port io.ReadWriteCloser
+	if config.TCP {
+		if port, err = net.DialTimeout("tcp", config.Port, DefaultTimeout); err != nil {
+			return nil, err
+		}
+	} else {
+		if port, err = serial.Open(&serial.Config{
+			Address:  config.Port,
+			BaudRate: config.BaudRate,
+			DataBits: 8,
+			StopBits: 1,
+			Parity:   "N",
+			Timeout:  100 * time.Millisecond,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	l := &mstpLink{
+		config:   config,
+		local:    &Address{MAC: []byte{config.MAC}},
+		source:   capture.Source("bacnet", MSTPPrefix+config.Port),
+		frames:   make(chan mstpFrame, 16),
+		outgoing: make(chan mstpOutgoing, 32),
+		received: make(chan mstpFrame, 16),
+		done:     make(chan struct{}),
+		ns:       config.MAC,
+		ps:       config.MAC,
+	}
+	l.port = &mstpPort{ReadWriteCloser: port, done: l.done}
+	go l.readFrames()
+	go l.run()
+	return l, nil
+}
+
+func (l *mstpLink) send(npdu []byte, dst net.Addr, expectingReply bool) error {
+	if len(npdu) > mstpMaxData {
+		return fmt.Errorf("bacnet mstp frame too long: %d", len(npdu))
+	}
+	mac := byte(mstpBroadcast)
+	switch a := dst.(type) {
+	case nil:
+	case *Address:
+		if a.Router != nil {
+			return fmt.Errorf("bacnet/ip router %s is not reachable from mstp", a.Router)
+		}
+		// 远程网络的数据帧广播，由 MS/TP 上的路由器转发
+		if a.Net == 0 && len(a.MAC) == 1 {
+			mac = a.MAC[0]
+		}
+	default:
+		return fmt.Errorf("bacnet/ip address %s is not reachable from mstp", dst)
+	}
+	select {
+	case l.outgoing <- mstpOutgoing{npdu: npdu, dst: mac, expectingReply: expectingReply && mac != mstpBroadcast}:
+		return nil
+	case <-l.done:
+		return l.closedErr()
+	}
+}
+
+func (l *mstpLink) receive() ([]byte, net.Addr, error) {
+	select {
+	case f := <-l.received:
+		return f.data, &Address{MAC: []byte{f.src}}, nil
+	case <-l.done:
+		return nil, nil, l.closedErr()
+	}
+}
+
+func (l *mstpLink) localAddr() net.Addr {
+	return l.local
+}
+
+func (l *mstpLink) maxAPDU() byte {
+	return mstpMaxAPDU
+}
+
+func (l *mstpLink) close() error {
+	return l.fail(errLinkClosed)
+}
+
+// fail 关闭数据链路，记录关闭的原因
+func (l *mstpLink) fail(err error) error {
+	var closeErr error
+	l.once.Do(func() {
+		l.mu.Lock()
+		l.err = err
+		l.mu.Unlock()
+		close(l.done)
+		closeErr = l.port.Close()
+	})
+	return closeErr
+}
+
+func (l *mstpLink) closedErr() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// readFrames 接收 MS/TP 帧，丢弃 CRC 错误的帧
+func (l *mstpLink) readFrames() {
+	r := bufio.NewReader(l.port)
+	for {
+		f, err := readMSTPFrame(r)
+		if errors.Is(err, errMSTPFrame) {
+			continue
+		}
+		if err != nil {
+			_ = l.fail(err)
+			return
+		}
+		select {
+		case l.frames <- f:
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// run 主站状态机
+func (l *mstpLink) run() {
+	for {
+		f, ok := l.next(mstpNoToken + mstpSlot*time.Duration(l.config.MAC))
+		select {
+		case <-l.done:
+			return
+		default:
+		}
+		if !ok {
+			// 总线静默，生成令牌
+			l.findSuccessor()
+			l.useToken()
+			continue
+		}
+		if f.kind == mstpToken && f.dst == l.config.MAC {
+			l.useToken()
+			continue
+		}
+		l.handle(f)
+	}
+}
+
+// next 等待下一帧，忽略本站发出的回显，超时或者关闭返回 false
+func (l *mstpLink) next(timeout time.Duration) (mstpFrame, bool) {
+	if f := l.unread; f != nil {
+		l.unread = nil
+		return *f, true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case f := <-l.frames:
+			if f.src == l.config.MAC {
+				continue
+			}
+			return f, true
+		case <-timer.C:
+			return mstpFrame{}, false
+		case <-l.done:
+			return mstpFrame{}, false
+		}
+	}
+}
+
+// handle 处理发给本站或者广播的帧
+func (l *mstpLink) handle(f mstpFrame) {
+	if f.dst != l.config.MAC && f.dst != mstpBroadcast {
+		return
+	}
+	switch f.kind {
+	case mstpPollForMaster:
+		if f.dst == l.config.MAC {
+			l.write(mstpReplyToPollForMaster, f.src, nil)
+		}
+	case mstpTestRequest:
+		if f.dst == l.config.MAC {
+			l.write(mstpTestResponse, f.src, f.data)
+		}
+	case mstpDataExpectingReply:
+		l.deliver(f)
+		if f.dst == l.config.MAC {
+			l.write(mstpReplyPostponed, f.src, nil)
+		}
+	case mstpDataNotExpectingReply:
+		l.deliver(f)
+	}
+}
+
+// deliver 把数据帧交给客户端
+func (l *mstpLink) deliver(f mstpFrame) {
+	capture.Record(l.source, capture.DirectionRx, l.local, &Address{MAC: []byte{f.src}}, encodeMSTPFrame(f.kind, f.dst, f.src, f.data))
+	select {
+	case l.received <- f:
+	case <-l.done:
+	}
+}
+
+// useToken 持有令牌时发送数据帧，然后传递令牌。唯一主站时一直持有令牌，直到有其他主站出现
+func (l *mstpLink) useToken() {
+	for {
+		for i := 0; i < l.config.MaxInfoFrames; i++ {
+			out, ok := l.dequeue()
+			if !ok {
+				break
+			}
+			l.sendData(out)
+		}
+		if l.passToken() || !l.idle() {
+			return
+		}
+	}
+}
+
+// dequeue 取出下一个待发送的数据帧
+func (l *mstpLink) dequeue() (mstpOutgoing, bool) {
+	if out := l.pending; out != nil {
+		l.pending = nil
+		return *out, true
+	}
+	select {
+	case out := <-l.outgoing:
+		return out, true
+	default:
+		return mstpOutgoing{}, false
+	}
+}
+
+// idle 唯一主站空闲时等待待发送的数据，收到其他站点的帧时放弃令牌返回 false
+func (l *mstpLink) idle() bool {
+	timer := time.NewTimer(mstpSlot)
+	defer timer.Stop()
+	select {
+	case out := <-l.outgoing:
+		l.pending = &out
+		return true
+	case <-timer.C:
+		return true
+	case f := <-l.frames:
+		if f.src != l.config.MAC {
+			l.unread = &f
+			l.soleMaster = false
+		}
+		return f.src == l.config.MAC
+	case <-l.done:
+		return false
+	}
+}
+
+// sendData 发送数据帧，需要回复时等待回复或者 Reply Postponed
+func (l *mstpLink) sendData(out mstpOutgoing) {
+	kind := byte(mstpDataNotExpectingReply)
+	if out.expectingReply {
+		kind = mstpDataExpectingReply
+	}
+	frame := l.write(kind, out.dst, out.npdu)
+	capture.Record(l.source, capture.DirectionTx, l.local, &Address{MAC: []byte{out.dst}}, frame)
+	if !out.expectingReply {
+		return
+	}
+	deadline := time.Now().Add(mstpReplyTimeout)
+	for {
+		f, ok := l.next(time.Until(deadline))
+		if !ok {
+			return
+		}
+		l.handle(f)
+		if f.src == out.dst && f.dst == l.config.MAC {
+			return
+		}
+	}
+}
+
+// passToken 把令牌传递给下一个站点，没有其他主站时返回 false
+func (l *mstpLink) passToken() bool {
+	l.tokenCount++
+	if l.tokenCount >= mstpPoll {
+		l.tokenCount = 0
+		l.pollGap()
+	}
+	if l.ns == l.config.MAC && !l.soleMaster {
+		l.findSuccessor()
+	}
+	if l.soleMaster {
+		return false
+	}
+	for i := 0; i <= mstpRetryToken; i++ {
+		l.write(mstpToken, l.ns, nil)
+		if l.tokenUsed() {
+			return true
+		}
+	}
+	// 下一个站点没有使用令牌，重新查找
+	l.findSuccessor()
+	if l.soleMaster {
+		return false
+	}
+	l.write(mstpToken, l.ns, nil)
+	return true
+}
+
+// tokenUsed 下一个站点是否开始使用令牌，收到的帧留给 run 处理
+func (l *mstpLink) tokenUsed() bool {
+	f, ok := l.next(mstpUsageTimeout)
+	if ok {
+		l.unread = &f
+	}
+	return ok
+}
+
+// findSuccessor 从本站的下一个地址开始轮询，查找下一个主站
+func (l *mstpLink) findSuccessor() {
+	for s := l.nextStation(l.config.MAC); s != l.config.MAC; s = l.nextStation(s) {
+		if l.poll(s) {
+			l.ns, l.ps, l.soleMaster = s, l.config.MAC, false
+			return
+		}
+	}
+	l.ns, l.ps, l.soleMaster = l.config.MAC, l.config.MAC, true
+}
+
+// pollGap 轮询本站和下一个主站之间的一个地址，发现新的主站时作为下一个站点
+func (l *mstpLink) pollGap() {
+	ps := l.nextStation(l.ps)
+	if ps == l.ns {
+		if ps = l.nextStation(l.config.MAC); ps == l.ns {
+			return
+		}
+	}
+	l.ps = ps
+	if l.poll(ps) {
+		l.ns, l.ps, l.soleMaster = ps, l.config.MAC, false
+	}
+}
+
+// poll 发送 Poll For Master，等待回复
+func (l *mstpLink) poll(station byte) bool {
+	l.write(mstpPollForMaster, station, nil)
+	deadline := time.Now().Add(mstpUsageTimeout)
+	for {
+		f, ok := l.next(time.Until(deadline))
+		if !ok {
+			return false
+		}
+		if f.kind == mstpReplyToPollForMaster && f.src == station && f.dst == l.config.MAC {
+			return true
+		}
+		l.handle(f)
+	}
+}
+
+func (l *mstpLink) nextStation(station byte) byte {
+	return byte((int(station) + 1) % (int(l.config.MaxMaster) + 1))
+}
+
+// write 发送帧，写入失败时关闭数据链路
+func (l *mstpLink) write(kind, dst byte, data []byte) []byte {
+	frame := encodeMSTPFrame(kind, dst, l.config.MAC, data)
+	if _, err := l.port.Write(frame); err != nil {
+		_ = l.fail(err)
+	}
+	return frame
+}
+
+// mstpPort 串口读取超时时重试，直到数据链路关闭
+type mstpPort struct {
+	io.ReadWriteCloser
+	done chan struct{}
+}
+
+func (p *mstpPort) Read(b []byte) (int, error) {
+	for {
+		n, err := p.ReadWriteCloser.Read(b)
+		if n > 0 || !errors.Is(err, serial.ErrTimeout) {
+			return n, err
+		}
+		select {
+		case <-p.done:
+			return 0, errLinkClosed
+		default:
+		}
+	}
+}
+
+// encodeMSTPFrame 编码 MS/TP 帧：前导码、帧类型、目的地址、源地址、数据长度、帧头 CRC、数据和数据 CRC
+func encodeMSTPFrame(kind, dst, src byte, data []byte) []byte {
+	frame := make([]byte, 8, 8+len(data)+2)
+	frame[0], frame[1] = mstpPreamble1, mstpPreamble2
+	frame[2], frame[3], frame[4] = kind, dst, src
+	frame[5], frame[6] = byte(len(data)>>8), byte(len(data))
+	crc := byte(0xff)
+	for _, b := range frame[2:7] {
+		crc = crcHeader(b, crc)
+	}
+	frame[7] = ^crc
+	if len(data) == 0 {
+		return frame
+	}
+	crc16 := uint16(0xffff)
+	for _, b := range data {
+		crc16 = crcData(b, crc16)
+	}
+	crc16 = ^crc16
+	frame = append(frame, data...)
+	return append(frame, byte(crc16), byte(crc16>>8))
+}
+
+// readMSTPFrame 读取一帧，CRC 错误返回 errMSTPFrame
+func readMSTPFrame(r *bufio.Reader) (mstpFrame, error) {
+	// 查找前导码
+	prev := byte(0)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return mstpFrame{}, err
+		}
+		if prev == mstpPreamble1 && b == mstpPreamble2 {
+			break
+		}
+		prev = b
+	}
+	header := make([]byte, 6)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return mstpFrame{}, err
+	}
+	crc := byte(0xff)
+	for _, b := range header {
+		crc = crcHeader(b, crc)
+	}
+	if crc != mstpHeaderResidue {
+		return mstpFrame{}, errMSTPFrame
+	}
+	f := mstpFrame{kind: header[0], dst: header[1], src: header[2]}
+	length := int(header[3])<<8 | int(header[4])
+	if length == 0 {
+		return f, nil
+	}
+	if length > mstpMaxData {
+		return mstpFrame{}, errMSTPFrame
+	}
+	data := make([]byte, length+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return mstpFrame{}, err
+	}
+	crc16 := uint16(0xffff)
+	for _, b := range data {
+		crc16 = crcData(b, crc16)
+	}
+	if crc16 != mstpDataResidue {
+		return mstpFrame{}, errMSTPFrame
+	}
+	f.data = data[:length]
+	return f, nil
+}
+
+// crcHeader 帧头 CRC-8，多项式 x^8 + x^7 + 1
+func crcHeader(b, crc byte) byte {
+	v := uint16(b ^ crc)
+	v = v ^ v<<1 ^ v<<2 ^ v<<3 ^ v<<4 ^ v<<5 ^ v<<6 ^ v<<7
+	return byte(v&0xfe ^ v>>8&1)
+}
+
+// crcData 数据 CRC-16，多项式 x^16 + x^12 + x^5 + 1
+func crcData(b byte, crc uint16) uint16 {
+	low := crc&0xff ^ uint16(b)
+	return crc>>8 ^ low<<8 ^ low<<3 ^ low<<12 ^ low>>4 ^ low&0x0f ^ (low&0x0f)<<7
+}
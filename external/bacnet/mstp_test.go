@@ -0,0 +1,155 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testMaster 测试用的 MS/TP 主站，通过 TCP 模拟串口服务器，请求由 testDevice 处理
+type testMaster struct {
+	listener net.Listener
+	device   *testDevice
+	mac      byte
+}
+
+func startTestMaster(t *testing.T, mac byte, device *testDevice) *testMaster {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	m := &testMaster{listener: listener, device: device, mac: mac}
+	go m.serve()
+	return m
+}
+
+func (m *testMaster) serve() {
+	conn, err := m.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	// queue 持有令牌时发送的数据帧
+	var queue [][]byte
+	for {
+		f, err := readMSTPFrame(r)
+		if errors.Is(err, errMSTPFrame) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if f.dst != m.mac && f.dst != mstpBroadcast {
+			continue
+		}
+		switch f.kind {
+		case mstpPollForMaster:
+			_, _ = conn.Write(encodeMSTPFrame(mstpReplyToPollForMaster, f.src, m.mac, nil))
+		case mstpToken:
+			for _, frame := range queue {
+				_, _ = conn.Write(frame)
+			}
+			queue = nil
+			time.Sleep(time.Millisecond)
+			_, _ = conn.Write(encodeMSTPFrame(mstpToken, f.src, m.mac, nil))
+		case mstpDataExpectingReply, mstpDataNotExpectingReply:
+			apdu, _, _, ok := parseNPDU(f.data)
+			if !ok {
+				continue
+			}
+			resp := m.device.handle(apdu)
+			if resp == nil {
+				continue
+			}
+			npdu := append([]byte{npduVersion, 0}, resp...)
+			if f.kind == mstpDataExpectingReply {
+				// 立即回复
+				_, _ = conn.Write(encodeMSTPFrame(mstpDataNotExpectingReply, f.src, m.mac, npdu))
+			} else {
+				queue = append(queue, encodeMSTPFrame(mstpDataNotExpectingReply, mstpBroadcast, m.mac, npdu))
+			}
+		}
+	}
+}
+
+func TestMSTPFrame(t *testing.T) {
+	// 标准附录 G 的例子
+	assert.Equal(t, []byte{0x55, 0xff, 0x00, 0x10, 0x05, 0x00, 0x00, 0x8c}, encodeMSTPFrame(mstpToken, 0x10, 0x05, nil))
+	frame := encodeMSTPFrame(mstpDataNotExpectingReply, 0x10, 0x05, []byte{0x01, 0x22, 0x30})
+	assert.Equal(t, []byte{0x10, 0xbd}, frame[len(frame)-2:])
+
+	// 前面的噪声和 CRC 错误的帧被丢弃
+	broken := append([]byte(nil), frame...)
+	broken[9]++
+	r := bufio.NewReader(bytes.NewReader(append(append([]byte{0x00, 0x55, 0x55}, broken...), frame...)))
+	_, err := readMSTPFrame(r)
+	assert.True(t, errors.Is(err, errMSTPFrame))
+	f, err := readMSTPFrame(r)
+	assert.Nil(t, err)
+	assert.Equal(t, mstpFrame{kind: mstpDataNotExpectingReply, dst: 0x10, src: 0x05, data: []byte{0x01, 0x22, 0x30}}, f)
+}
+
+func TestParseMSTPConfig(t *testing.T) {
+	config, err := ParseMSTPConfig("mstp:///dev/ttyUSB0?mac=3&baud=19200&maxMaster=31&maxInfoFrames=2")
+	assert.Nil(t, err)
+	assert.Equal(t, MSTPConfig{Port: "/dev/ttyUSB0", MAC: 3, BaudRate: 19200, MaxMaster: 31, MaxInfoFrames: 2}, config)
+
+	config, err = ParseMSTPConfig("mstp+tcp://192.168.1.20:4001?mac=0")
+	assert.Nil(t, err)
+	assert.Equal(t, MSTPConfig{Port: "192.168.1.20:4001", TCP: true, BaudRate: DefaultMSTPBaudRate, MaxMaster: DefaultMaxMaster, MaxInfoFrames: 1}, config)
+
+	for _, address := range []string{"mstp:///dev/ttyUSB0", "mstp:///dev/ttyUSB0?mac=128", "mstp:///dev/ttyUSB0?mac=3&maxMaster=2", "mstp+tcp://?mac=3"} {
+		_, err = ParseMSTPConfig(address)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestMSTP(t *testing.T) {
+	device := &testDevice{instance: 3001, values: map[uint64][][]byte{}}
+	device.set(0, 1, PropertyPresentValue, []byte{0x44, 0x41, 0xac, 0x00, 0x00})
+	master := startTestMaster(t, 2, device)
+	defer master.listener.Close()
+
+	client, err := NewClient(ClientConfig{
+		LocalAddress: "mstp+tcp://" + master.listener.Addr().String() + "?mac=1&maxMaster=3",
+		Timeout:      2 * time.Second,
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+	assert.Equal(t, "mstp:1", client.LocalAddr().String())
+
+	// 总线静默时生成令牌，找到下一个主站后发送 Who-Is
+	devices, err := client.WhoIs(nil, nil, 1500*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(devices))
+	assert.Equal(t, "mstp:2", devices[0].Address)
+
+	addr, err := ParseAddress("mstp:2")
+	assert.Nil(t, err)
+	values, err := client.ReadProperty(addr, ObjectProperty{Instance: 1, Property: PropertyPresentValue})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{float32(21.5)}, values)
+
+	_, err = client.ReadProperty(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: DefaultPort}, ObjectProperty{Instance: 1, Property: PropertyPresentValue})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// schedule 对象的属性
+const (
+	PropertyEffectivePeriod   = 32
+	PropertyExceptionSchedule = 38
+	PropertyWeeklySchedule    = 123
+	PropertyScheduleDefault   = 174
+)
+
+// weekdays 星期名称，weekly-schedule 的数组下标从 1（周一）开始
+var weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// TimeValue 日程中的时间-值对，从 time 开始生效直到下一个时间
+type TimeValue struct {
+	// Time 时间，格式：hh:mm、hh:mm:ss 或者 hh:mm:ss.hh
+	Time string `json:"time"`
+	// Value 生效的值，null 表示恢复 schedule-default
+	Value interface{} `json:"value"`
+}
+
+// ParseWeekday 解析星期，支持名称（monday、Mon）或者数组下标 1-7
+func ParseWeekday(s string) (uint32, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if v, err := strconv.ParseUint(s, 10, 8); err == nil && v >= 1 && v <= 7 {
+		return uint32(v), nil
+	}
+	for i, name := range weekdays {
+		if len(s) >= 3 && strings.HasPrefix(name, s) {
+			return uint32(i + 1), nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported weekday: %s", s)
+}
+
+// DecodeWeeklySchedule 把 ReadProperty 读取的 weekly-schedule 转换为每天的时间-值对，下标 0 为周一
+// 读取单天（数组下标 1-7）时只有一个元素
+func DecodeWeeklySchedule(values []interface{}) ([][]TimeValue, error) {
+	days := make([][]TimeValue, 0, len(values))
+	for _, v := range values {
+		day, ok := v.([]interface{})
+		if !ok || len(day)%2 != 0 {
+			return nil, fmt.Errorf("bacnet: invalid daily schedule")
+		}
+		entries := make([]TimeValue, 0, len(day)/2)
+		for i := 0; i < len(day); i += 2 {
+			t, ok := day[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("bacnet: invalid daily schedule time")
+			}
+			entries = append(entries, TimeValue{Time: strings.TrimSuffix(t, ".00"), Value: day[i+1]})
+		}
+		days = append(days, entries)
+	}
+	return days, nil
+}
+
+// EncodeDailySchedule 编码一天的日程 BACnetDailySchedule，值按 valueType 编码，
+// valueType 为空时按 schedule-default 的常见类型 real 编码，null 编码为 Null
+func EncodeDailySchedule(entries []TimeValue, valueType string) ([]byte, error) {
+	b := []byte{0x0e}
+	for _, e := range entries {
+		t, err := encodeTime(e.Time)
+		if err != nil {
+			return nil, err
+		}
+		value, err := EncodeValue(valueType, 0, e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Time, err)
+		}
+		b = append(b, t...)
+		b = append(b, value...)
+	}
+	return append(b, 0x0f), nil
+}
+
+// EncodeWeeklySchedule 编码 weekly-schedule，必须为 7 天，下标 0 为周一
+func EncodeWeeklySchedule(days [][]TimeValue, valueType string) ([]byte, error) {
+	if len(days) != 7 {
+		return nil, fmt.Errorf("bacnet weekly schedule requires 7 days, got %d", len(days))
+	}
+	var b []byte
+	for i, day := range days {
+		encoded, err := EncodeDailySchedule(day, valueType)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", weekdays[i], err)
+		}
+		b = append(b, encoded...)
+	}
+	return b, nil
+}
+
+// encodeTime 编码 Time 应用标签，支持 hh:mm、hh:mm:ss 和 hh:mm:ss.hh
+func encodeTime(s string) ([]byte, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid schedule time: %s", s)
+	}
+	fields := []string{parts[0], parts[1], "0", "0"}
+	if len(parts) == 3 {
+		sec := strings.SplitN(parts[2], ".", 2)
+		fields[2] = sec[0]
+		if len(sec) == 2 {
+			fields[3] = sec[1]
+		}
+	}
+	limits := []uint64{23, 59, 59, 99}
+	b := []byte{tagTime<<4 | 4, 0, 0, 0, 0}
+	for i, f := range fields {
+		v, err := strconv.ParseUint(f, 10, 8)
+		if err != nil || v > limits[i] {
+			return nil, fmt.Errorf("invalid schedule time: %s", s)
+		}
+		b[i+1] = byte(v)
+	}
+	return b, nil
+}
@@ -0,0 +1,337 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bacnet
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	serviceReadRange = 0x1a
+	// PropertyLogBuffer trend-log 对象的 log-buffer 属性
+	PropertyLogBuffer = 131
+	// PropertyRecordCount trend-log 对象的 record-count 属性
+	PropertyRecordCount = 141
+	// DefaultRangeCount 每次 ReadRange 读取的默认记录数，保证响应不超过一个 APDU
+	DefaultRangeCount = 50
+)
+
+// ReadRange 读取范围的方式
+const (
+	// RangeByPosition 按记录位置（从 1 开始）读取
+	RangeByPosition = "position"
+	// RangeBySequence 按记录序号读取
+	RangeBySequence = "sequence"
+	// RangeByTime 按记录时间读取
+	RangeByTime = "time"
+)
+
+// 日志记录的类型
+const (
+	LogKindValue      = "value"
+	LogKindStatus     = "status"
+	LogKindFailure    = "failure"
+	LogKindTimeChange = "timeChange"
+)
+
+// RangeRequest ReadRange 请求
+type RangeRequest struct {
+	ObjectType uint16
+	Instance   uint32
+	// Property 读取的列表属性，0 为 log-buffer
+	Property uint32
+	// By 读取范围的方式：position、sequence、time
+	By string
+	// Reference position 和 sequence 方式的起始位置或者序号
+	Reference uint32
+	// Time time 方式的起始时间
+	Time time.Time
+	// Count 读取的记录数，负数表示从起始位置向前读取
+	Count int32
+}
+
+// RangeResult ReadRange 响应
+type RangeResult struct {
+	// FirstItem 包含列表的第一条记录
+	FirstItem bool `json:"firstItem"`
+	// LastItem 包含列表的最后一条记录
+	LastItem bool `json:"lastItem"`
+	// MoreItems 还有满足条件但没有返回的记录
+	MoreItems bool `json:"moreItems"`
+	// FirstSequenceNumber 第一条记录的序号，设备不支持序号时为 nil
+	FirstSequenceNumber *uint64 `json:"firstSequenceNumber,omitempty"`
+	// Records 日志记录
+	Records []LogRecord `json:"records"`
+}
+
+// LogRecord trend-log 的日志记录
+type LogRecord struct {
+	// SequenceNumber 记录序号，设备不支持序号时为 nil
+	SequenceNumber *uint64 `json:"sequenceNumber,omitempty"`
+	// Timestamp 记录时间，按设备所在时区解析
+	Timestamp time.Time `json:"timestamp"`
+	// Kind 记录类型：value、status、failure、timeChange
+	Kind string `json:"kind"`
+	// Value 记录的值，status 为 log-status 标志（log-disabled, buffer-purged, log-interrupted），
+	// timeChange 为时钟调整的秒数
+	Value interface{} `json:"value"`
+	// Error failure 记录的错误
+	Error string `json:"error,omitempty"`
+	// StatusFlags 被记录对象的 status-flags：in-alarm, fault, overridden, out-of-service
+	StatusFlags []bool `json:"statusFlags,omitempty"`
+}
+
+// ParseRangeBy 解析读取范围的方式，为空为 position
+func ParseRangeBy(by string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(by)) {
+	case "", RangeByPosition:
+		return RangeByPosition, nil
+	case RangeBySequence:
+		return RangeBySequence, nil
+	case RangeByTime:
+		return RangeByTime, nil
+	default:
+		return "", fmt.Errorf("unsupported bacnet range type: %s", by)
+	}
+}
+
+// ReadRange 按位置、序号或者时间读取 trend-log 等对象的列表属性
+// 不支持分段响应，count 需要保证响应不超过一个 APDU，记录较多时根据 MoreItems 多次读取
+func (c *Client) ReadRange(addr net.Addr, r RangeRequest, loc *time.Location) (*RangeResult, error) {
+	request, err := encodeReadRange(r)
+	if err != nil {
+		return nil, err
+	}
+	apdu, err := c.confirmed(addr, serviceReadRange, request)
+	if err != nil {
+		return nil, err
+	}
+	return decodeReadRangeAck(apdu, loc)
+}
+
+// encodeReadRange 编码 ReadRange 请求
+func encodeReadRange(r RangeRequest) ([]byte, error) {
+	if r.Count == 0 {
+		return nil, fmt.Errorf("bacnet range count must not be 0")
+	}
+	property := r.Property
+	if property == 0 {
+		property = PropertyLogBuffer
+	}
+	request := encodeContextObjectId(0, r.ObjectType, r.Instance)
+	request = append(request, encodeContextUnsigned(1, property)...)
+	count, _ := EncodeValue(ValueTypeSigned, 0, r.Count)
+	by, err := ParseRangeBy(r.By)
+	if err != nil {
+		return nil, err
+	}
+	var tag byte
+	var reference []byte
+	switch by {
+	case RangeByPosition:
+		tag, reference = 3, encodeApplication(tagUnsigned, unsignedBytes(r.Reference))
+	case RangeBySequence:
+		tag, reference = 6, encodeApplication(tagUnsigned, unsignedBytes(r.Reference))
+	default:
+		tag, reference = 7, encodeDateTime(r.Time)
+	}
+	request = append(request, tag<<4|0x0e)
+	request = append(request, reference...)
+	request = append(request, count...)
+	return append(request, tag<<4|0x0f), nil
+}
+
+// encodeDateTime 编码 BACnetDateTime：Date 和 Time 应用标签
+func encodeDateTime(t time.Time) []byte {
+	// 星期：1 为周一，7 为周日
+	weekday := byte(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return []byte{
+		tagDate<<4 | 4, byte(t.Year() - 1900), byte(t.Month()), byte(t.Day()), weekday,
+		tagTime<<4 | 4, byte(t.Hour()), byte(t.Minute()), byte(t.Second()), byte(t.Nanosecond() / 1e7),
+	}
+}
+
+// decodeDateTime 解码 Date 和 Time 的原始字节，通配符（255）按 0 处理
+func decodeDateTime(date, tm []byte, loc *time.Location) time.Time {
+	field := func(b byte) int {
+		if b == 0xff {
+			return 0
+		}
+		return int(b)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.Date(1900+field(date[0]), time.Month(field(date[1])), field(date[2]),
+		field(tm[0]), field(tm[1]), field(tm[2]), field(tm[3])*1e7, loc)
+}
+
+// decodeReadRangeAck 解码 ReadRange 响应
+func decodeReadRangeAck(data []byte, loc *time.Location) (*RangeResult, error) {
+	result := &RangeResult{Records: []LogRecord{}}
+	offset := 0
+	for offset < len(data) {
+		h, n, err := decodeTag(data[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += n
+		if h.opening && h.number == 5 {
+			records, size, err := decodeLogRecords(data[offset:], loc)
+			if err != nil {
+				return nil, err
+			}
+			result.Records = records
+			offset += size + 1
+			continue
+		}
+		if offset+h.length > len(data) {
+			return nil, errShortData
+		}
+		value := data[offset : offset+h.length]
+		offset += h.length
+		switch h.number {
+		case 3:
+			v, _ := decodeApplication(tagHeader{number: tagBitString}, value)
+			if flags, _ := v.([]bool); len(flags) >= 3 {
+				result.FirstItem, result.LastItem, result.MoreItems = flags[0], flags[1], flags[2]
+			}
+		case 6:
+			first := decodeUnsigned(value)
+			result.FirstSequenceNumber = &first
+		}
+	}
+	if result.FirstSequenceNumber != nil {
+		for i := range result.Records {
+			seq := *result.FirstSequenceNumber + uint64(i)
+			result.Records[i].SequenceNumber = &seq
+		}
+	}
+	return result, nil
+}
+
+// decodeLogRecords 解码 itemData 中的 BACnetLogRecord 列表，直到结束标签
+// 返回解码的记录和占用的字节数
+func decodeLogRecords(b []byte, loc *time.Location) ([]LogRecord, int, error) {
+	records := []LogRecord{}
+	offset := 0
+	var record *LogRecord
+	for offset < len(b) {
+		h, n, err := decodeTag(b[offset:])
+		if err != nil {
+			return nil, 0, err
+		}
+		if h.closing {
+			return records, offset, nil
+		}
+		offset += n
+		switch {
+		case h.opening && h.number == 0:
+			// timestamp：Date 和 Time 应用标签，每个 5 个字节
+			if offset+11 > len(b) || b[offset] != tagDate<<4|4 || b[offset+5] != tagTime<<4|4 {
+				return nil, 0, errShortData
+			}
+			records = append(records, LogRecord{Timestamp: decodeDateTime(b[offset+1:offset+5], b[offset+6:offset+10], loc)})
+			record = &records[len(records)-1]
+			offset += 11
+		case h.opening && h.number == 1 && record != nil:
+			size, err := decodeLogDatum(b[offset:], record)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += size + 1
+		case h.number == 2 && !h.opening && record != nil:
+			if offset+h.length > len(b) {
+				return nil, 0, errShortData
+			}
+			v, _ := decodeApplication(tagHeader{number: tagBitString}, b[offset:offset+h.length])
+			record.StatusFlags, _ = v.([]bool)
+			offset += h.length
+		default:
+			return nil, 0, fmt.Errorf("bacnet: unexpected tag %d in log record", h.number)
+		}
+	}
+	return nil, 0, errShortData
+}
+
+// logDatumTags logDatum 上下文标签对应的应用标签
+var logDatumTags = map[byte]byte{
+	0: tagBitString,
+	2: tagReal,
+	3: tagEnumerated,
+	4: tagUnsigned,
+	5: tagSigned,
+	6: tagBitString,
+	7: tagNull,
+	9: tagReal,
+}
+
+// decodeLogDatum 解码 logDatum，返回占用的字节数（不包括结束标签）
+func decodeLogDatum(b []byte, record *LogRecord) (int, error) {
+	h, n, err := decodeTag(b)
+	if err != nil {
+		return 0, err
+	}
+	if h.opening {
+		// failure 和 any-value 为结构化的值
+		values, size, err := decodeValues(b[n:])
+		if err != nil {
+			return 0, err
+		}
+		if h.number == 8 {
+			record.Kind = LogKindFailure
+			class, code := uint64(0), uint64(0)
+			if len(values) >= 2 {
+				class, _ = values[0].(uint64)
+				code, _ = values[1].(uint64)
+			}
+			record.Error = (&Error{Kind: "error", Class: class, Code: code}).Error()
+		} else {
+			record.Kind, record.Value = LogKindValue, values
+		}
+		return n + size + 1, nil
+	}
+	if n+h.length > len(b) {
+		return 0, errShortData
+	}
+	data := b[n : n+h.length]
+	switch h.number {
+	case 0:
+		record.Kind = LogKindStatus
+	case 9:
+		record.Kind = LogKindTimeChange
+	default:
+		record.Kind = LogKindValue
+	}
+	if h.number == 1 {
+		// 上下文标签的 Boolean 值占一个字节
+		record.Value = len(data) > 0 && data[0] != 0
+	} else if tag, ok := logDatumTags[h.number]; ok {
+		if record.Value, err = decodeApplication(tagHeader{number: tag, length: h.length}, data); err != nil {
+			return 0, err
+		}
+	} else {
+		return 0, fmt.Errorf("bacnet: unsupported log datum %d", h.number)
+	}
+	return n + h.length, nil
+}
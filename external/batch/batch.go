@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package batch 提供批量收集节点
+// 节点缓存收到的消息，数量、字节数或者等待时间达到阈值时合并为一个数组消息输出，
+// 用于减少高频轮询数据通过 MQTT、HTTP 上传时的发布次数
+package batch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 输出原因
+const (
+	// ReasonCount 数量达到上限
+	ReasonCount = "count"
+	// ReasonBytes 字节数达到上限
+	ReasonBytes = "bytes"
+	// ReasonAge 第一条消息的等待时间达到上限
+	ReasonAge = "age"
+	// ReasonShutdown 节点销毁
+	ReasonShutdown = "shutdown"
+)
+
+// Batch 一批消息
+type Batch struct {
+	// Group 分组
+	Group string
+	// Items 消息，每个元素是一个 JSON 值
+	Items [][]byte
+	// Start 第一条消息加入的时间
+	Start time.Time
+	// Reason 输出原因，见 ReasonCount 等
+	Reason string
+	// size 数组格式的字节数
+	size int
+}
+
+// Size 合并为 JSON 数组后的字节数
+func (b *Batch) Size() int {
+	return b.size
+}
+
+// JSON 合并为 JSON 数组
+func (b *Batch) JSON() []byte {
+	data := make([]byte, 0, b.size)
+	data = append(data, '[')
+	for i, item := range b.Items {
+		if i > 0 {
+			data = append(data, ',')
+		}
+		data = append(data, item...)
+	}
+	return append(data, ']')
+}
+
+// add 加入一条消息
+func (b *Batch) add(item []byte) {
+	b.size += sizeOf(item, len(b.Items) == 0)
+	b.Items = append(b.Items, item)
+}
+
+// sizeOf 加入一条消息增加的字节数，空批次包含 [] 的2个字节，其余需要1个字节的逗号
+func sizeOf(item []byte, first bool) int {
+	if first {
+		return len(item) + 2
+	}
+	return len(item) + 1
+}
+
+// Accumulator 按分组累计消息，可以并发调用
+// 数量或者字节数达到上限时由 Add 返回需要输出的批次，等待时间达到上限的批次通过 Expired 获取
+type Accumulator struct {
+	// maxCount 每批最大数量，0 不限制
+	maxCount int
+	// maxBytes 每批合并后的最大字节数，0 不限制
+	maxBytes int
+	// maxAge 第一条消息的最长等待时间，0 不限制
+	maxAge  time.Duration
+	mu      sync.Mutex
+	batches map[string]*Batch
+}
+
+// NewAccumulator 创建累计器
+func NewAccumulator(maxCount, maxBytes int, maxAge time.Duration) *Accumulator {
+	return &Accumulator{
+		maxCount: maxCount,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		batches:  map[string]*Batch{},
+	}
+}
+
+// Add 把消息加入分组的批次，返回需要输出的批次。
+// 加入后超过字节数上限时先输出已有的批次，单条消息超过上限时单独输出
+func (a *Accumulator) Add(group string, item []byte, now time.Time) []*Batch {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var full []*Batch
+	b, ok := a.batches[group]
+	if ok && a.maxBytes > 0 && b.size+sizeOf(item, false) > a.maxBytes {
+		b.Reason = ReasonBytes
+		full = append(full, b)
+		ok = false
+	}
+	if !ok {
+		b = &Batch{Group: group, Start: now}
+		a.batches[group] = b
+	}
+	b.add(item)
+	switch {
+	case a.maxCount > 0 && len(b.Items) >= a.maxCount:
+		b.Reason = ReasonCount
+	case a.maxBytes > 0 && b.size >= a.maxBytes:
+		b.Reason = ReasonBytes
+	default:
+		return full
+	}
+	delete(a.batches, group)
+	return append(full, b)
+}
+
+// Expired 返回并移除等待时间达到上限的批次，按开始时间排序
+func (a *Accumulator) Expired(now time.Time) []*Batch {
+	if a.maxAge <= 0 {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var expired []*Batch
+	for group, b := range a.batches {
+		if !now.Before(b.Start.Add(a.maxAge)) {
+			b.Reason = ReasonAge
+			expired = append(expired, b)
+			delete(a.batches, group)
+		}
+	}
+	sortByStart(expired)
+	return expired
+}
+
+// Next 下一个批次等待时间达到上限的时间，没有批次或者不限制等待时间时返回 false
+func (a *Accumulator) Next() (time.Time, bool) {
+	if a.maxAge <= 0 {
+		return time.Time{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var next time.Time
+	for _, b := range a.batches {
+		if next.IsZero() || b.Start.Before(next) {
+			next = b.Start
+		}
+	}
+	if next.IsZero() {
+		return next, false
+	}
+	return next.Add(a.maxAge), true
+}
+
+// Drain 返回并移除所有批次，按开始时间排序，reason 为输出原因
+func (a *Accumulator) Drain(reason string) []*Batch {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	batches := make([]*Batch, 0, len(a.batches))
+	for _, b := range a.batches {
+		b.Reason = reason
+		batches = append(batches, b)
+	}
+	a.batches = map[string]*Batch{}
+	sortByStart(batches)
+	return batches
+}
+
+// Len 缓存的消息数量
+func (a *Accumulator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var n int
+	for _, b := range a.batches {
+		n += len(b.Items)
+	}
+	return n
+}
+
+// sortByStart 按开始时间排序
+func sortByStart(batches []*Batch) {
+	sort.SliceStable(batches, func(i, j int) bool {
+		return batches[i].Start.Before(batches[j].Start)
+	})
+}
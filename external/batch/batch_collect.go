@@ -0,0 +1,248 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// BatchMsgType 批量消息的类型
+	BatchMsgType = "BATCH"
+	// KeyBatchCount 批次中消息数量元数据key
+	KeyBatchCount = "batchCount"
+	// KeyBatchBytes 批次合并后字节数元数据key
+	KeyBatchBytes = "batchBytes"
+	// KeyBatchReason 输出原因元数据key：count、bytes、age、shutdown
+	KeyBatchReason = "batchReason"
+	// KeyGroup 分组元数据key
+	KeyGroup = "group"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&BatchCollectNode{})
+}
+
+// BatchCollectConfiguration 批量收集节点配置
+type BatchCollectConfiguration struct {
+	// MaxCount 每批最大消息数量，0 不限制
+	MaxCount int `json:"maxCount" label:"Max Count" desc:"Flush when the batch holds this many messages, 0 means no limit"`
+	// MaxBytes 每批合并后的最大字节数，0 不限制
+	MaxBytes int `json:"maxBytes" label:"Max Bytes" desc:"Flush before the JSON array payload would exceed this many bytes, 0 means no limit"`
+	// MaxAge 第一条消息的最长等待时间，eg. 5s，为空不限制
+	MaxAge string `json:"maxAge" label:"Max Age" desc:"Flush when the first message of the batch is older than this, eg. 5s. Empty means no limit"`
+	// IncludeMetadata 每个元素包含消息的时间、类型和元数据，否则只包含 msg.Data
+	IncludeMetadata bool `json:"includeMetadata" label:"Include Metadata" desc:"Wrap each message as {ts, type, metadata, data}, otherwise only msg.Data is collected"`
+	// GroupBy 分组，每个分组独立收集，eg. ${metadata.deviceId}，为空不分组。支持 ${metadata.key} 占位符
+	GroupBy string `json:"groupBy" label:"Group By" desc:"Group key, each group is batched separately, eg. ${metadata.deviceId}. Empty disables grouping"`
+	// FlushOnDestroy 节点销毁时输出未满的批次，否则丢弃
+	FlushOnDestroy bool `json:"flushOnDestroy" label:"Flush On Destroy" desc:"Flush pending batches when the node is destroyed, otherwise they are dropped"`
+}
+
+// item 批次中的一个元素
+type item struct {
+	Ts       int64           `json:"ts"`
+	Type     string          `json:"type"`
+	Metadata *types.Metadata `json:"metadata"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// BatchCollectNode 批量收集节点，缓存收到的消息，数量、字节数或者等待时间达到上限时合并为一个 JSON 数组输出。
+// msg.Data 是 JSON 时作为 JSON 值加入数组，否则作为字符串加入。includeMetadata 为 true 时每个元素的格式：
+//
+//	{"ts": 1700000000000, "type": "TELEMETRY", "metadata": {"deviceId": "line1"}, "data": {"temperature": 21.5}}
+//
+// 收到的消息被缓存，不再流转；批次作为新消息通过`Success`链发送到当前规则链，消息类型为 BATCH，
+// 元数据 batchCount、batchBytes 为消息数量和字节数，batchReason 为输出原因：count、bytes、age、shutdown，group 为分组。
+// 加入后超过字节数上限时先输出已有的批次，单条消息超过上限时单独输出。flushOnDestroy 为 true 时节点销毁前输出未满的批次
+type BatchCollectNode struct {
+	//节点配置
+	Config        BatchCollectConfiguration
+	maxAge        time.Duration
+	groupTemplate str.Template
+	accumulator   *Accumulator
+	// mu 保护输出协程
+	mu sync.Mutex
+	// ctx 输出批次使用的上下文，收到第一条消息时设置
+	ctx types.RuleContext
+	// wake 通知输出协程重新计算等待时间
+	wake chan struct{}
+	// stop 关闭后输出协程退出，nil 表示输出协程尚未启动
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Type 返回组件类型
+func (x *BatchCollectNode) Type() string {
+	return "x/batchCollect"
+}
+
+// New 默认参数
+func (x *BatchCollectNode) New() types.Node {
+	return &BatchCollectNode{
+		Config: BatchCollectConfiguration{
+			MaxCount:       100,
+			MaxBytes:       256 * 1024,
+			MaxAge:         "5s",
+			FlushOnDestroy: true,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *BatchCollectNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.MaxCount < 0 || x.Config.MaxBytes < 0 {
+		return errors.New("maxCount and maxBytes can not be negative")
+	}
+	x.maxAge = 0
+	if x.Config.MaxAge != "" {
+		if x.maxAge, err = time.ParseDuration(x.Config.MaxAge); err != nil {
+			return err
+		}
+		if x.maxAge < 0 {
+			return errors.New("maxAge can not be negative")
+		}
+	}
+	if x.Config.MaxCount == 0 && x.Config.MaxBytes == 0 && x.maxAge == 0 {
+		return errors.New("one of maxCount, maxBytes or maxAge is required")
+	}
+	x.groupTemplate = str.NewTemplate(x.Config.GroupBy)
+	x.accumulator = NewAccumulator(x.Config.MaxCount, x.Config.MaxBytes, x.maxAge)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *BatchCollectNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	data, err := x.itemOf(msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	group := ""
+	if x.Config.GroupBy != "" {
+		group = x.groupTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	x.mu.Lock()
+	if x.stop == nil {
+		// 批次消息使用独立的 context，不受触发消息结束或超时的影响
+		x.ctx = ctx.SetContext(context.Background())
+		x.wake = make(chan struct{}, 1)
+		x.stop = make(chan struct{})
+		x.done = make(chan struct{})
+		go x.run(x.ctx, x.wake, x.stop, x.done)
+	}
+	out, wake := x.ctx, x.wake
+	x.mu.Unlock()
+	x.emit(out, x.accumulator.Add(group, data, time.Now()))
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 输出等待时间达到上限的批次
+func (x *BatchCollectNode) run(ctx types.RuleContext, wake, stop, done chan struct{}) {
+	defer close(done)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		var timeout <-chan time.Time
+		if next, ok := x.accumulator.Next(); ok {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(time.Until(next))
+			timeout = timer.C
+		}
+		select {
+		case <-stop:
+			return
+		case <-wake:
+		case <-timeout:
+			x.emit(ctx, x.accumulator.Expired(time.Now()))
+		}
+	}
+}
+
+// emit 每个批次输出一条消息
+func (x *BatchCollectNode) emit(ctx types.RuleContext, batches []*Batch) {
+	for _, b := range batches {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyBatchCount, strconv.Itoa(len(b.Items)))
+		metadata.PutValue(KeyBatchBytes, strconv.Itoa(b.Size()))
+		metadata.PutValue(KeyBatchReason, b.Reason)
+		if x.Config.GroupBy != "" {
+			metadata.PutValue(KeyGroup, b.Group)
+		}
+		ctx.TellNext(ctx.NewMsg(BatchMsgType, metadata, str.ToString(b.JSON())), types.Success)
+	}
+}
+
+// itemOf 消息在批次中的元素
+func (x *BatchCollectNode) itemOf(msg types.RuleMsg) ([]byte, error) {
+	data := []byte(msg.GetData())
+	if !json.Valid(data) {
+		var err error
+		if data, err = json.Marshal(msg.GetData()); err != nil {
+			return nil, err
+		}
+	}
+	if !x.Config.IncludeMetadata {
+		return data, nil
+	}
+	return json.Marshal(item{Ts: msg.Ts, Type: msg.Type, Metadata: msg.Metadata, Data: data})
+}
+
+// Destroy 停止输出，flushOnDestroy 为 true 时输出未满的批次
+func (x *BatchCollectNode) Destroy() {
+	x.mu.Lock()
+	ctx, stop, done := x.ctx, x.stop, x.done
+	x.stop = nil
+	x.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+	batches := x.accumulator.Drain(ReasonShutdown)
+	if x.Config.FlushOnDestroy {
+		x.emit(ctx, batches)
+	}
+}
+
+// Desc returns the component description
+func (x *BatchCollectNode) Desc() string {
+	return "Buffers messages and flushes them as one JSON array when a max count, max bytes or max age is reached, flushing pending batches on shutdown. Routes to Success/Failure"
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package batch
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestBatchCollectNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&BatchCollectNode{})
+
+	_, err := test.CreateAndInitNode("x/batchCollect", types.Configuration{"maxAge": "soon"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/batchCollect", types.Configuration{"maxCount": 0, "maxBytes": 0, "maxAge": ""}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/batchCollect", types.Configuration{
+		"maxCount": 3,
+		"maxAge":   "300ms",
+		"groupBy":  "${metadata.deviceId}",
+	}, Registry)
+	assert.Nil(t, err)
+
+	line1 := types.NewMetadata()
+	line1.PutValue("deviceId", "line1")
+	line2 := types.NewMetadata()
+	line2.PutValue("deviceId", "line2")
+
+	var lock sync.Mutex
+	var batches []types.RuleMsg
+	// 消息同步发送保证顺序，按等待时间输出的批次在后台协程回调
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, BatchMsgType, msg.Type)
+		batches = append(batches, msg)
+	})
+	count := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(batches)
+	}
+	node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, line1, `{"t":1}`))
+	node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, line1, `{"t":2}`))
+	node.OnMsg(ctx, types.NewMsg(0, "TEXT", types.TEXT, line2, `ok`))
+	node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, line1, `{"t":3}`))
+	node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, line1, `{"t":4}`))
+	deadline := time.Now().Add(5 * time.Second)
+	for count() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for batches by age")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, line2, `[1,2]`))
+	// 销毁时输出未满的批次
+	node.Destroy()
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 4, len(batches))
+	assert.Equal(t, `[{"t":1},{"t":2},{"t":3}]`, batches[0].GetData())
+	assert.Equal(t, ReasonCount, batches[0].Metadata.GetValue(KeyBatchReason))
+	assert.Equal(t, "3", batches[0].Metadata.GetValue(KeyBatchCount))
+	assert.Equal(t, "line1", batches[0].Metadata.GetValue(KeyGroup))
+
+	// 按等待时间输出，顺序为第一条消息的时间
+	assert.Equal(t, `["ok"]`, batches[1].GetData())
+	assert.Equal(t, ReasonAge, batches[1].Metadata.GetValue(KeyBatchReason))
+	assert.Equal(t, `[{"t":4}]`, batches[2].GetData())
+	assert.Equal(t, ReasonAge, batches[2].Metadata.GetValue(KeyBatchReason))
+
+	assert.Equal(t, `[[1,2]]`, batches[3].GetData())
+	assert.Equal(t, ReasonShutdown, batches[3].Metadata.GetValue(KeyBatchReason))
+	assert.Equal(t, "7", batches[3].Metadata.GetValue(KeyBatchBytes))
+}
+
+func TestBatchCollectMetadata(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&BatchCollectNode{})
+
+	node, err := test.CreateAndInitNode("x/batchCollect", types.Configuration{
+		"maxCount":        2,
+		"includeMetadata": true,
+		"flushOnDestroy":  false,
+	}, Registry)
+	assert.Nil(t, err)
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("deviceId", "line1")
+	var lock sync.Mutex
+	var batches []types.RuleMsg
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		batches = append(batches, msg)
+	})
+	node.OnMsg(ctx, types.NewMsg(1700000000000, "TELEMETRY", types.JSON, metadata, `{"t":1}`))
+	node.OnMsg(ctx, types.NewMsg(1700000001000, "TELEMETRY", types.JSON, metadata, `{"t":2}`))
+	node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, metadata, `{"t":3}`))
+	// 不输出未满的批次
+	node.Destroy()
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 1, len(batches))
+	var items []struct {
+		Ts       int64             `json:"ts"`
+		Type     string            `json:"type"`
+		Metadata map[string]string `json:"metadata"`
+		Data     map[string]int    `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(batches[0].GetData()), &items))
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, int64(1700000001000), items[1].Ts)
+	assert.Equal(t, "TELEMETRY", items[1].Type)
+	assert.Equal(t, "line1", items[0].Metadata["deviceId"])
+	assert.Equal(t, 2, items[1].Data["t"])
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package batch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestAccumulator(t *testing.T) {
+	now := time.Now()
+	a := NewAccumulator(3, 0, time.Second)
+	assert.Equal(t, 0, len(a.Add("", []byte(`1`), now)))
+	assert.Equal(t, 0, len(a.Add("", []byte(`{"a":2}`), now)))
+	assert.Equal(t, 0, len(a.Add("line2", []byte(`"x"`), now.Add(time.Millisecond))))
+	assert.Equal(t, 3, a.Len())
+	next, ok := a.Next()
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Second), next)
+
+	// 数量达到上限
+	batches := a.Add("", []byte(`3`), now)
+	assert.Equal(t, 1, len(batches))
+	assert.Equal(t, ReasonCount, batches[0].Reason)
+	assert.Equal(t, `[1,{"a":2},3]`, string(batches[0].JSON()))
+	assert.Equal(t, len(batches[0].JSON()), batches[0].Size())
+
+	// 等待时间达到上限
+	assert.Equal(t, 0, len(a.Expired(now.Add(time.Second-time.Millisecond))))
+	batches = a.Expired(now.Add(time.Second + time.Millisecond))
+	assert.Equal(t, 1, len(batches))
+	assert.Equal(t, "line2", batches[0].Group)
+	assert.Equal(t, ReasonAge, batches[0].Reason)
+	_, ok = a.Next()
+	assert.False(t, ok)
+}
+
+func TestAccumulatorBytes(t *testing.T) {
+	now := time.Now()
+	a := NewAccumulator(0, 10, 0)
+	assert.Equal(t, 0, len(a.Add("", []byte(`1234`), now)))
+	// [1234,5678] 为 11 个字节，先输出已有的批次
+	batches := a.Add("", []byte(`5678`), now)
+	assert.Equal(t, 1, len(batches))
+	assert.Equal(t, `[1234]`, string(batches[0].JSON()))
+	assert.Equal(t, ReasonBytes, batches[0].Reason)
+
+	// 单条消息超过上限时先输出已有的批次，再单独输出
+	batches = a.Add("", []byte(`"0123456789"`), now)
+	assert.Equal(t, 2, len(batches))
+	assert.Equal(t, `[5678]`, string(batches[0].JSON()))
+	assert.Equal(t, `["0123456789"]`, string(batches[1].JSON()))
+	assert.Equal(t, 0, a.Len())
+
+	// 不限制等待时间
+	assert.Equal(t, 0, len(a.Add("", []byte(`1`), now)))
+	assert.Equal(t, 0, len(a.Expired(now.Add(time.Hour))))
+	batches = a.Drain(ReasonShutdown)
+	assert.Equal(t, 1, len(batches))
+	assert.Equal(t, ReasonShutdown, batches[0].Reason)
+	assert.Equal(t, 0, a.Len())
+}
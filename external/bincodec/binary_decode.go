@@ -0,0 +1,151 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bincodec
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 文本负荷的编码
+const (
+	EncodingBinary = "binary"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&DecodeNode{})
+}
+
+// DecodeConfiguration 二进制解码节点配置
+type DecodeConfiguration struct {
+	// Schema 字段定义
+	Schema Schema `json:"schema" label:"Schema" desc:"Field schema: byteOrder and fields with name, offset, type, length, bit, size, scale, valueOffset" required:"true"`
+	// Encoding 非二进制消息负荷的编码：hex（默认）、base64
+	Encoding string `json:"encoding" label:"Encoding" desc:"Encoding of text payloads: hex (default), base64. Binary messages are decoded as is"`
+}
+
+// DecodeNode 二进制负荷解码节点，按字段定义把 Modbus、CAN、私有 UDP 协议等设备的原始字节转换为 JSON，
+// 不需要编写 JS 脚本。msg.Data 为二进制数据或者十六进制、base64 字符串，字段定义例如：
+//
+//	{
+//	  "byteOrder": "big",
+//	  "fields": [
+//	    {"name": "temperature", "offset": 0, "type": "int16", "scale": 0.1},
+//	    {"name": "alarm", "offset": 2, "type": "bool", "bit": 7},
+//	    {"name": "mode", "offset": 2, "type": "bits", "bit": 0, "length": 3},
+//	    {"name": "serial", "offset": 3, "type": "string", "length": 8}
+//	  ]
+//	}
+//
+// 解码结果重新赋值到msg.Data：{"temperature": 21.5, "alarm": true, "mode": 2, "serial": "A1B2C3"}。
+// 解码成功，流转到`Success`链，否则流转到`Failure`链
+type DecodeNode struct {
+	//节点配置
+	Config DecodeConfiguration
+}
+
+// Type 返回组件类型
+func (x *DecodeNode) Type() string {
+	return "x/binaryDecode"
+}
+
+// New 默认参数
+func (x *DecodeNode) New() types.Node {
+	return &DecodeNode{
+		Config: DecodeConfiguration{
+			Encoding: EncodingHex,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DecodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if err := checkEncoding(x.Config.Encoding, false); err != nil {
+		return err
+	}
+	return x.Config.Schema.Validate()
+}
+
+// OnMsg 处理消息
+func (x *DecodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data []byte
+	if msg.DataType == types.BINARY {
+		data = msg.GetBytes()
+	} else {
+		var err error
+		if data, err = decodeText(msg.GetData(), x.Config.Encoding); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	values, err := x.Config.Schema.Decode(data)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *DecodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *DecodeNode) Desc() string {
+	return "Binary payload decoder driven by a declarative field schema with offsets, types, byte order, bitfields and scaling, producing JSON. Routes to Success/Failure"
+}
+
+// checkEncoding 校验编码，allowBinary 表示是否支持 binary
+func checkEncoding(encoding string, allowBinary bool) error {
+	switch strings.ToLower(encoding) {
+	case "", EncodingHex, EncodingBase64:
+		return nil
+	case EncodingBinary:
+		if allowBinary {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported encoding: %s", encoding)
+}
+
+// decodeText 解码十六进制或者 base64 字符串，十六进制忽略空白字符
+func decodeText(s string, encoding string) ([]byte, error) {
+	if strings.ToLower(encoding) == EncodingBase64 {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	}
+	return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+}
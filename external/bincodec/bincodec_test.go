@@ -0,0 +1,119 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bincodec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+var testSchema = map[string]interface{}{
+	"byteOrder": "big",
+	"fields": []map[string]interface{}{
+		{"name": "temperature", "offset": 0, "type": "int16", "scale": 0.1},
+		{"name": "alarm", "offset": 2, "type": "bool", "bit": 0},
+		{"name": "model", "offset": 3, "type": "string", "length": 4},
+	},
+}
+
+func TestBinaryNodes(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DecodeNode{})
+	Registry.Add(&EncodeNode{})
+
+	_, err := test.CreateAndInitNode("x/binaryDecode", types.Configuration{}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/binaryDecode", types.Configuration{"schema": testSchema, "encoding": "binary"}, Registry)
+	assert.NotNil(t, err)
+
+	decoder, err := test.CreateAndInitNode("x/binaryDecode", types.Configuration{"schema": testSchema}, Registry)
+	assert.Nil(t, err)
+	encoder, err := test.CreateAndInitNode("x/binaryEncode", types.Configuration{"schema": testSchema}, Registry)
+	assert.Nil(t, err)
+	hexEncoder, err := test.CreateAndInitNode("x/binaryEncode", types.Configuration{"schema": testSchema, "encoding": "hex"}, Registry)
+	assert.Nil(t, err)
+
+	test.NodeOnMsg(t, decoder, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.TEXT,
+			MsgType:    "UPLINK",
+			Data:       "FF 9C 01 50 4C 43 31",
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.TEXT,
+			MsgType:    "SHORT",
+			Data:       "FF9C",
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "SHORT" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.JSON, msg.DataType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, -10.0, values["temperature"])
+		assert.Equal(t, true, values["alarm"])
+		assert.Equal(t, "PLC1", values["model"])
+	})
+
+	test.NodeOnMsg(t, encoder, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "COMMAND",
+		Data:       `{"temperature": -10, "alarm": true, "model": "PLC1"}`,
+		AfterSleep: time.Millisecond * 100,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.BINARY, msg.DataType)
+		assert.Equal(t, []byte{0xFF, 0x9C, 0x01, 'P', 'L', 'C', '1'}, msg.GetBytes())
+	})
+
+	test.NodeOnMsg(t, hexEncoder, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "COMMAND",
+			Data:       `{"temperature": 21.5}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "INVALID",
+			Data:       `{"temperature": 5000}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "INVALID" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "00d70000000000", msg.GetData())
+	})
+}
@@ -0,0 +1,463 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bincodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 字段类型
+const (
+	TypeUint8   = "uint8"
+	TypeInt8    = "int8"
+	TypeUint16  = "uint16"
+	TypeInt16   = "int16"
+	TypeUint32  = "uint32"
+	TypeInt32   = "int32"
+	TypeUint64  = "uint64"
+	TypeInt64   = "int64"
+	TypeFloat32 = "float32"
+	TypeFloat64 = "float64"
+	// TypeBool 单个位
+	TypeBool = "bool"
+	// TypeBits 位域，无符号整数
+	TypeBits = "bits"
+	// TypeString 定长字符串，末尾的 0 字节忽略
+	TypeString = "string"
+	// TypeBytes 定长字节，输出十六进制字符串
+	TypeBytes = "bytes"
+)
+
+// 字节序
+const (
+	ByteOrderBig    = "big"
+	ByteOrderLittle = "little"
+)
+
+// Field 字段定义
+type Field struct {
+	// Name 字段名称，作为 JSON 的 key
+	Name string `json:"name"`
+	// Offset 字段在负荷中的起始字节
+	Offset int `json:"offset"`
+	// Type 字段类型：uint8, int8, uint16, int16, uint32, int32, uint64, int64, float32, float64, bool, bits, string, bytes
+	Type string `json:"type"`
+	// Length string 和 bytes 为字节数，bits 为位数（默认 1）
+	Length int `json:"length,omitempty"`
+	// Bit bool 和 bits 的起始位，从容器最低位开始计数
+	Bit int `json:"bit,omitempty"`
+	// Size bool 和 bits 的容器字节数：1, 2, 4, 8，默认 1，多字节容器按字节序解析
+	Size int `json:"size,omitempty"`
+	// ByteOrder 字节序：big, little，为空使用 schema 的字节序
+	ByteOrder string `json:"byteOrder,omitempty"`
+	// Scale 缩放系数，工程值 = 原始值 * scale + valueOffset，scale 和 valueOffset 都为 0 时输出原始值
+	Scale float64 `json:"scale,omitempty"`
+	// ValueOffset 偏移量
+	ValueOffset float64 `json:"valueOffset,omitempty"`
+}
+
+// Schema 二进制负荷的字段定义
+type Schema struct {
+	// ByteOrder 默认字节序：big, little，默认 big
+	ByteOrder string `json:"byteOrder,omitempty"`
+	// Length 编码输出的字节数，0 表示按字段计算
+	Length int `json:"length,omitempty"`
+	// Fields 字段列表
+	Fields []Field `json:"fields"`
+}
+
+// Validate 校验字段定义
+func (s Schema) Validate() error {
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("binary schema fields cannot be empty")
+	}
+	if _, err := parseByteOrder(s.ByteOrder); err != nil {
+		return err
+	}
+	names := make(map[string]struct{}, len(s.Fields))
+	for _, f := range s.Fields {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[f.Name]; ok {
+			return fmt.Errorf("duplicate binary field name: %s", f.Name)
+		}
+		names[f.Name] = struct{}{}
+	}
+	if s.Length > 0 && s.Length < s.size() {
+		return fmt.Errorf("binary schema length %d is less than the fields size %d", s.Length, s.size())
+	}
+	return nil
+}
+
+// Validate 校验字段
+func (f Field) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("binary field name cannot be empty, offset: %d", f.Offset)
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("field %s: offset cannot be negative", f.Name)
+	}
+	if _, err := parseByteOrder(f.ByteOrder); err != nil {
+		return fmt.Errorf("field %s: %w", f.Name, err)
+	}
+	switch f.Type {
+	case TypeUint8, TypeInt8, TypeUint16, TypeInt16, TypeUint32, TypeInt32, TypeUint64, TypeInt64, TypeFloat32, TypeFloat64:
+	case TypeBool, TypeBits:
+		size := f.containerSize()
+		if size != 1 && size != 2 && size != 4 && size != 8 {
+			return fmt.Errorf("field %s: unsupported bit container size: %d", f.Name, size)
+		}
+		if f.Bit < 0 || f.Bit+f.bits() > size*8 {
+			return fmt.Errorf("field %s: bits %d-%d out of %d byte container", f.Name, f.Bit, f.Bit+f.bits()-1, size)
+		}
+	case TypeString, TypeBytes:
+		if f.Length <= 0 {
+			return fmt.Errorf("field %s: length must be greater than 0", f.Name)
+		}
+	default:
+		return fmt.Errorf("field %s: unsupported type: %s", f.Name, f.Type)
+	}
+	return nil
+}
+
+// Decode 按字段定义把二进制负荷解码为字段名称->值
+func (s Schema) Decode(data []byte) (map[string]interface{}, error) {
+	order, _ := parseByteOrder(s.ByteOrder)
+	result := make(map[string]interface{}, len(s.Fields))
+	for _, f := range s.Fields {
+		end := f.Offset + f.width()
+		if end > len(data) {
+			return nil, fmt.Errorf("field %s: need %d bytes, payload has %d", f.Name, end, len(data))
+		}
+		v, err := f.decode(data[f.Offset:end], f.order(order))
+		if err != nil {
+			return nil, err
+		}
+		result[f.Name] = v
+	}
+	return result, nil
+}
+
+// Encode 按字段定义把字段名称->值编码为二进制负荷，缺少的字段填充 0
+func (s Schema) Encode(values map[string]interface{}) ([]byte, error) {
+	order, _ := parseByteOrder(s.ByteOrder)
+	length := s.Length
+	if length == 0 {
+		length = s.size()
+	}
+	data := make([]byte, length)
+	for _, f := range s.Fields {
+		v, ok := values[f.Name]
+		if !ok || v == nil {
+			continue
+		}
+		if err := f.encode(data[f.Offset:f.Offset+f.width()], f.order(order), v); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return data, nil
+}
+
+// size 字段占用的字节数
+func (s Schema) size() int {
+	n := 0
+	for _, f := range s.Fields {
+		if end := f.Offset + f.width(); end > n {
+			n = end
+		}
+	}
+	return n
+}
+
+// width 字段占用的字节数
+func (f Field) width() int {
+	switch f.Type {
+	case TypeUint8, TypeInt8:
+		return 1
+	case TypeUint16, TypeInt16:
+		return 2
+	case TypeUint32, TypeInt32, TypeFloat32:
+		return 4
+	case TypeUint64, TypeInt64, TypeFloat64:
+		return 8
+	case TypeBool, TypeBits:
+		return f.containerSize()
+	default:
+		return f.Length
+	}
+}
+
+func (f Field) containerSize() int {
+	if f.Size == 0 {
+		return 1
+	}
+	return f.Size
+}
+
+// bits 位域的位数
+func (f Field) bits() int {
+	if f.Type == TypeBool || f.Length <= 0 {
+		return 1
+	}
+	return f.Length
+}
+
+func (f Field) order(def binary.ByteOrder) binary.ByteOrder {
+	if f.ByteOrder == "" {
+		return def
+	}
+	order, _ := parseByteOrder(f.ByteOrder)
+	return order
+}
+
+func (f Field) decode(b []byte, order binary.ByteOrder) (interface{}, error) {
+	var v interface{}
+	switch f.Type {
+	case TypeUint8:
+		v = b[0]
+	case TypeInt8:
+		v = int8(b[0])
+	case TypeUint16:
+		v = order.Uint16(b)
+	case TypeInt16:
+		v = int16(order.Uint16(b))
+	case TypeUint32:
+		v = order.Uint32(b)
+	case TypeInt32:
+		v = int32(order.Uint32(b))
+	case TypeUint64:
+		v = order.Uint64(b)
+	case TypeInt64:
+		v = int64(order.Uint64(b))
+	case TypeFloat32:
+		v = math.Float32frombits(order.Uint32(b))
+	case TypeFloat64:
+		v = math.Float64frombits(order.Uint64(b))
+	case TypeBool:
+		return readContainer(b, order)>>uint(f.Bit)&1 == 1, nil
+	case TypeBits:
+		v = readContainer(b, order) >> uint(f.Bit) & mask(f.bits())
+	case TypeString:
+		return string(bytes.TrimRight(b, "\x00")), nil
+	case TypeBytes:
+		return hex.EncodeToString(b), nil
+	default:
+		return nil, fmt.Errorf("field %s: unsupported type: %s", f.Name, f.Type)
+	}
+	return f.scaled(v), nil
+}
+
+func (f Field) encode(b []byte, order binary.ByteOrder, value interface{}) error {
+	switch f.Type {
+	case TypeBool:
+		on, err := toBool(value)
+		if err != nil {
+			return err
+		}
+		container := readContainer(b, order) &^ (1 << uint(f.Bit))
+		if on {
+			container |= 1 << uint(f.Bit)
+		}
+		writeUint(b, order, container)
+		return nil
+	case TypeString:
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		if len(s) > len(b) {
+			return fmt.Errorf("string length %d exceeds %d", len(s), len(b))
+		}
+		copy(b, s)
+		return nil
+	case TypeBytes:
+		s, _ := value.(string)
+		raw, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+		if err != nil {
+			return err
+		}
+		if len(raw) > len(b) {
+			return fmt.Errorf("bytes length %d exceeds %d", len(raw), len(b))
+		}
+		copy(b, raw)
+		return nil
+	}
+	f64, err := toFloat64(value)
+	if err != nil {
+		return err
+	}
+	f64 = f.unscaled(f64)
+	switch f.Type {
+	case TypeFloat32:
+		order.PutUint32(b, math.Float32bits(float32(f64)))
+		return nil
+	case TypeFloat64:
+		order.PutUint64(b, math.Float64bits(f64))
+		return nil
+	}
+	f64 = math.Round(f64)
+	switch f.Type {
+	case TypeUint8, TypeUint16, TypeUint32, TypeUint64, TypeBits:
+		bits := f.width() * 8
+		if f.Type == TypeBits {
+			bits = f.bits()
+		}
+		if f64 < 0 || f64 > math.Ldexp(1, bits)-1 {
+			return fmt.Errorf("value %v out of range for %s", value, f.Type)
+		}
+		u := uint64(f64)
+		if f.Type == TypeBits {
+			m := mask(f.bits()) << uint(f.Bit)
+			writeUint(b, order, readContainer(b, order)&^m|u<<uint(f.Bit))
+			return nil
+		}
+		writeUint(b, order, u)
+	default:
+		bits := f.width() * 8
+		if f64 < -math.Ldexp(1, bits-1) || f64 > math.Ldexp(1, bits-1)-1 {
+			return fmt.Errorf("value %v out of range for %s", value, f.Type)
+		}
+		writeUint(b, order, uint64(int64(f64)))
+	}
+	return nil
+}
+
+// scaled 按缩放系数和偏移量换算工程值
+func (f Field) scaled(v interface{}) interface{} {
+	if f.Scale == 0 && f.ValueOffset == 0 {
+		return v
+	}
+	f64, _ := toFloat64(v)
+	scale := f.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return f64*scale + f.ValueOffset
+}
+
+// unscaled 把工程值换算为原始值
+func (f Field) unscaled(v float64) float64 {
+	if f.Scale == 0 && f.ValueOffset == 0 {
+		return v
+	}
+	scale := f.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return (v - f.ValueOffset) / scale
+}
+
+func parseByteOrder(s string) (binary.ByteOrder, error) {
+	switch strings.ToLower(s) {
+	case "", ByteOrderBig:
+		return binary.BigEndian, nil
+	case ByteOrderLittle:
+		return binary.LittleEndian, nil
+	default:
+		return nil, fmt.Errorf("unsupported byte order: %s", s)
+	}
+}
+
+func mask(bits int) uint64 {
+	if bits >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<uint(bits) - 1
+}
+
+func readContainer(b []byte, order binary.ByteOrder) uint64 {
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(order.Uint16(b))
+	case 4:
+		return uint64(order.Uint32(b))
+	default:
+		return order.Uint64(b)
+	}
+}
+
+func writeUint(b []byte, order binary.ByteOrder, v uint64) {
+	switch len(b) {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		order.PutUint16(b, uint16(v))
+	case 4:
+		order.PutUint32(b, uint32(v))
+	default:
+		order.PutUint64(b, v)
+	}
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int8:
+		return float64(n), nil
+	case int16:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case uint8:
+		return float64(n), nil
+	case uint16:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	default:
+		return 0, fmt.Errorf("value %v is not a number", v)
+	}
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(b))
+	default:
+		f, err := toFloat64(v)
+		return f != 0, err
+	}
+}
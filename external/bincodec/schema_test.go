@@ -0,0 +1,106 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bincodec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema{
+		Fields: []Field{
+			{Name: "temperature", Offset: 0, Type: TypeInt16, Scale: 0.1},
+			{Name: "alarm", Offset: 2, Type: TypeBool, Bit: 7},
+			{Name: "mode", Offset: 2, Type: TypeBits, Length: 3},
+			{Name: "flags", Offset: 3, Type: TypeBits, Bit: 4, Length: 8, Size: 2, ByteOrder: ByteOrderLittle},
+			{Name: "counter", Offset: 5, Type: TypeUint32, ByteOrder: ByteOrderLittle},
+			{Name: "pressure", Offset: 9, Type: TypeFloat32},
+			{Name: "serial", Offset: 13, Type: TypeString, Length: 6},
+			{Name: "raw", Offset: 19, Type: TypeBytes, Length: 2},
+			{Name: "level", Offset: 21, Type: TypeUint8, Scale: 0.5, ValueOffset: -10},
+		},
+	}
+	assert.Nil(t, schema.Validate())
+	data := []byte{
+		0x00, 0xD7, // 215 -> 21.5
+		0x82,       // alarm=1, mode=2
+		0xA0, 0x0B, // little endian 0x0BA0, bits 4-11 = 0xBA
+		0x01, 0x02, 0x00, 0x00, // 513
+		0x3F, 0xC0, 0x00, 0x00, // 1.5
+		'A', 'B', 'C', '1', 0x00, 0x00,
+		0xDE, 0xAD,
+		60, // 60*0.5-10 = 20
+	}
+	values, err := schema.Decode(data)
+	assert.Nil(t, err)
+	assert.True(t, math.Abs(values["temperature"].(float64)-21.5) < 1e-9)
+	assert.Equal(t, true, values["alarm"])
+	assert.Equal(t, uint64(2), values["mode"])
+	assert.Equal(t, uint64(0xBA), values["flags"])
+	assert.Equal(t, uint32(513), values["counter"])
+	assert.Equal(t, float32(1.5), values["pressure"])
+	assert.Equal(t, "ABC1", values["serial"])
+	assert.Equal(t, "dead", values["raw"])
+	assert.Equal(t, float64(20), values["level"])
+
+	// 编码后与原始字节一致
+	encoded, err := schema.Encode(map[string]interface{}{
+		"temperature": 21.5,
+		"alarm":       true,
+		"mode":        2,
+		"flags":       "186",
+		"counter":     513,
+		"pressure":    1.5,
+		"serial":      "ABC1",
+		"raw":         "dead",
+		"level":       20,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, data, encoded)
+
+	// 缺少的字段填充 0，负数和 schema 长度
+	schema2 := Schema{ByteOrder: ByteOrderLittle, Length: 6, Fields: []Field{{Name: "v", Offset: 1, Type: TypeInt16}}}
+	assert.Nil(t, schema2.Validate())
+	encoded, err = schema2.Encode(map[string]interface{}{"v": -2})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0, 0xFE, 0xFF, 0, 0, 0}, encoded)
+	encoded, err = schema2.Encode(map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, make([]byte, 6), encoded)
+
+	// 超出范围
+	_, err = schema.Encode(map[string]interface{}{"mode": 8})
+	assert.NotNil(t, err)
+	_, err = schema.Encode(map[string]interface{}{"level": -20})
+	assert.NotNil(t, err)
+	_, err = schema.Encode(map[string]interface{}{"serial": "TOO-LONG"})
+	assert.NotNil(t, err)
+	// 负荷长度不足
+	_, err = schema.Decode(data[:10])
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, Schema{}.Validate())
+	assert.NotNil(t, Schema{Fields: []Field{{Name: "a", Type: "int24"}}}.Validate())
+	assert.NotNil(t, Schema{Fields: []Field{{Name: "a", Type: TypeBits, Bit: 6, Length: 3}}}.Validate())
+	assert.NotNil(t, Schema{Fields: []Field{{Name: "a", Type: TypeString}}}.Validate())
+	assert.NotNil(t, Schema{Fields: []Field{{Name: "a", Type: TypeUint8}, {Name: "a", Type: TypeUint8, Offset: 1}}}.Validate())
+	assert.NotNil(t, Schema{ByteOrder: "middle", Fields: []Field{{Name: "a", Type: TypeUint8}}}.Validate())
+	assert.NotNil(t, Schema{Length: 1, Fields: []Field{{Name: "a", Type: TypeUint16}}}.Validate())
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cayenne
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/lpp"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 文本负荷的编码
+const (
+	EncodingBinary = "binary"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+// 解码结果的格式
+const (
+	// FormatObject 类型名称_通道->值
+	FormatObject = "object"
+	// FormatList 数据通道数组
+	FormatList = "list"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&DecodeNode{})
+}
+
+// DecodeConfiguration Cayenne LPP 解码节点配置
+type DecodeConfiguration struct {
+	// Encoding 非二进制消息负荷的编码：hex（默认）、base64
+	Encoding string `json:"encoding" label:"Encoding" desc:"Encoding of text payloads: hex (default), base64. Binary messages are decoded as is"`
+	// Format 输出格式：object（默认）、list
+	Format string `json:"format" label:"Format" desc:"Output format: object keyed by type_channel (default) or list of {channel, type, value}"`
+}
+
+// DecodeNode Cayenne LPP 解码节点，把设备上行的 Cayenne LPP 负荷解码为 JSON，不依赖 LoRaWAN 网络服务器，
+// 也可以用于 NB-IoT、UDP 等传输 LPP 负荷的设备。支持标准数据类型和 ElectronicCats 扩展类型（电压、电流、功率、能量、GPS、颜色等）。
+// msg.Data 为二进制数据或者十六进制、base64 字符串，format 为 object 时输出：{"temperature_3": 27.2, "gps_1": {"latitude": 42.3519, "longitude": -87.9094, "altitude": 10}}，
+// 为 list 时输出：[{"channel": 3, "type": "temperature", "value": 27.2}]。
+// 解码成功，流转到`Success`链，否则流转到`Failure`链
+type DecodeNode struct {
+	//节点配置
+	Config DecodeConfiguration
+}
+
+// Type 返回组件类型
+func (x *DecodeNode) Type() string {
+	return "x/cayenneLppDecode"
+}
+
+// New 默认参数
+func (x *DecodeNode) New() types.Node {
+	return &DecodeNode{
+		Config: DecodeConfiguration{
+			Encoding: EncodingHex,
+			Format:   FormatObject,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DecodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	switch x.Config.Format {
+	case "", FormatObject, FormatList:
+	default:
+		return fmt.Errorf("unsupported format: %s", x.Config.Format)
+	}
+	return checkEncoding(x.Config.Encoding, false)
+}
+
+// OnMsg 处理消息
+func (x *DecodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data []byte
+	if msg.DataType == types.BINARY {
+		data = msg.GetBytes()
+	} else {
+		var err error
+		if data, err = decodeText(msg.GetData(), x.Config.Encoding); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	items, err := lpp.Decode(data)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var value interface{} = lpp.ToMap(items)
+	if x.Config.Format == FormatList {
+		if items == nil {
+			items = []lpp.Item{}
+		}
+		value = items
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *DecodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *DecodeNode) Desc() string {
+	return "Cayenne LPP decoder for standard and extended data types, outputting JSON keyed by type_channel or as a channel list. Routes to Success/Failure"
+}
+
+// checkEncoding 校验编码，allowBinary 表示是否支持 binary
+func checkEncoding(encoding string, allowBinary bool) error {
+	switch strings.ToLower(encoding) {
+	case "", EncodingHex, EncodingBase64:
+		return nil
+	case EncodingBinary:
+		if allowBinary {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported encoding: %s", encoding)
+}
+
+// decodeText 解码十六进制或者 base64 字符串，十六进制忽略空白字符
+func decodeText(s string, encoding string) ([]byte, error) {
+	if strings.ToLower(encoding) == EncodingBase64 {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	}
+	return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+}
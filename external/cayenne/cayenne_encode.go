@@ -0,0 +1,122 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cayenne
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/lpp"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&EncodeNode{})
+}
+
+// EncodeConfiguration Cayenne LPP 编码节点配置
+type EncodeConfiguration struct {
+	// Encoding 输出的编码：binary（默认）、hex、base64
+	Encoding string `json:"encoding" label:"Encoding" desc:"Output encoding: binary (default), hex, base64"`
+}
+
+// EncodeNode Cayenne LPP 编码节点，把 msg.Data 中的 JSON 编码为 Cayenne LPP 负荷，用于构造下行消息或者模拟设备上行。
+// msg.Data 可以是 类型名称_通道->值 的对象，eg. {"digital_out_1": 1, "analog_out_2": 3.3}，按通道排序编码；
+// 也可以是数据通道数组，eg. [{"channel": 1, "type": "digital_out", "value": 1}]，按数组顺序编码，type 也可以是类型编号。
+// 值按分辨率四舍五入并检查范围，多分量类型的值为对象。编码结果重新赋值到msg.Data，encoding 为 binary 时消息数据类型为 BINARY。
+// 编码成功，流转到`Success`链，否则流转到`Failure`链
+type EncodeNode struct {
+	//节点配置
+	Config EncodeConfiguration
+}
+
+// Type 返回组件类型
+func (x *EncodeNode) Type() string {
+	return "x/cayenneLppEncode"
+}
+
+// New 默认参数
+func (x *EncodeNode) New() types.Node {
+	return &EncodeNode{
+		Config: EncodeConfiguration{
+			Encoding: EncodingBinary,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *EncodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	return checkEncoding(x.Config.Encoding, true)
+}
+
+// OnMsg 处理消息
+func (x *EncodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items, err := parseItems(msg.GetData())
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	data, err := lpp.Encode(items)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	switch strings.ToLower(x.Config.Encoding) {
+	case EncodingHex:
+		msg.SetDataType(types.TEXT)
+		msg.SetData(hex.EncodeToString(data))
+	case EncodingBase64:
+		msg.SetDataType(types.TEXT)
+		msg.SetData(base64.StdEncoding.EncodeToString(data))
+	default:
+		msg.SetDataType(types.BINARY)
+		msg.SetBytes(data)
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *EncodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *EncodeNode) Desc() string {
+	return "Cayenne LPP encoder building uplink or downlink payloads from JSON keyed by type_channel or a channel list, with range checks. Routes to Success/Failure"
+}
+
+// parseItems 解析 类型名称_通道->值 的对象或者数据通道数组
+func parseItems(data string) ([]lpp.Item, error) {
+	if strings.HasPrefix(strings.TrimSpace(data), "[") {
+		var items []lpp.Item
+		if err := json.Unmarshal([]byte(data), &items); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+	values := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return nil, err
+	}
+	return lpp.FromMap(values)
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cayenne
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestCayenneNodes(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DecodeNode{})
+	Registry.Add(&EncodeNode{})
+
+	_, err := test.CreateAndInitNode("x/cayenneLppDecode", types.Configuration{"encoding": "binary"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/cayenneLppDecode", types.Configuration{"format": "csv"}, Registry)
+	assert.NotNil(t, err)
+
+	decoder, err := test.CreateAndInitNode("x/cayenneLppDecode", types.Configuration{}, Registry)
+	assert.Nil(t, err)
+	listDecoder, err := test.CreateAndInitNode("x/cayenneLppDecode", types.Configuration{"encoding": "base64", "format": "list"}, Registry)
+	assert.Nil(t, err)
+	encoder, err := test.CreateAndInitNode("x/cayenneLppEncode", types.Configuration{}, Registry)
+	assert.Nil(t, err)
+	hexEncoder, err := test.CreateAndInitNode("x/cayenneLppEncode", types.Configuration{"encoding": "hex"}, Registry)
+	assert.Nil(t, err)
+
+	test.NodeOnMsg(t, decoder, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.TEXT,
+			MsgType:    "UPLINK",
+			Data:       "03 67 01 10 01 74 01 8b",
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.TEXT,
+			MsgType:    "SHORT",
+			Data:       "03 67 01",
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "SHORT" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.JSON, msg.DataType)
+		assert.Equal(t, `{"temperature_3":27.2,"voltage_1":3.95}`, msg.GetData())
+	})
+
+	test.NodeOnMsg(t, listDecoder, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.TEXT,
+		MsgType:    "UPLINK",
+		Data:       "A2cBEAVnAQM=",
+		AfterSleep: time.Millisecond * 100,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, `[{"channel":3,"type":"temperature","value":27.2},{"channel":5,"type":"temperature","value":25.9}]`, msg.GetData())
+	})
+
+	test.NodeOnMsg(t, encoder, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "DOWNLINK",
+		Data:       `{"digital_out_1": 1, "analog_out_2": 3.3}`,
+		AfterSleep: time.Millisecond * 100,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.BINARY, msg.DataType)
+		assert.Equal(t, []byte{0x01, 0x01, 0x01, 0x02, 0x03, 0x01, 0x4a}, msg.GetBytes())
+	})
+
+	test.NodeOnMsg(t, hexEncoder, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "DOWNLINK",
+			Data:       `[{"channel": 7, "type": "switch", "value": true}, {"channel": 1, "type": 135, "value": {"r": 255, "g": 0, "b": 16}}]`,
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "INVALID",
+			Data:       `{"temperature_1": 5000}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "INVALID" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "078e010187ff0010", msg.GetData())
+	})
+}
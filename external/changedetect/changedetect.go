@@ -0,0 +1,151 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package changedetect 提供值变化检测节点
+// 节点按 key 记录每个点位上次转发的值，只转发值的变化超过死区或者质量变化的消息，
+// 用于过滤轮询读取等数据源中没有变化的重复值
+package changedetect
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 死区类型
+const (
+	// DeadbandAbsolute 绝对值死区
+	DeadbandAbsolute = "absolute"
+	// DeadbandPercent 上次转发值的百分比死区
+	DeadbandPercent = "percent"
+)
+
+// KeyChangeReason 转发原因元数据key：first、value、quality、heartbeat
+const KeyChangeReason = "changeReason"
+
+func init() {
+	_ = rulego.Registry.Register(&ChangeDetectNode{})
+}
+
+// ChangeDetectConfiguration 变化检测节点配置
+type ChangeDetectConfiguration struct {
+	// Key 点位的 key，支持 ${metadata.key} 和 ${msg.key} 占位符，eg. OPC UA 订阅的 ${metadata.nodeId}
+	Key string `json:"key" label:"Key" desc:"Key of the tag the state is kept for, eg. ${metadata.nodeId}. Supports ${metadata.key} and ${msg.key} placeholders" required:"true"`
+	// ValueField msg.Data 为对象时值的字段，否则整个 msg.Data 作为值
+	ValueField string `json:"valueField" label:"Value Field" desc:"Field of the value when msg.Data is an object, otherwise the whole msg.Data is the value"`
+	// QualityField msg.Data 为对象时质量的字段，为空不检测质量变化
+	QualityField string `json:"qualityField" label:"Quality Field" desc:"Field of the quality when msg.Data is an object, quality changes are always forwarded. Empty disables quality detection"`
+	// Deadband 数值变化的死区，变化超过死区才转发，0 表示任何变化都转发。非数值的值不相等即转发
+	Deadband float64 `json:"deadband" label:"Deadband" desc:"Numeric values are forwarded only when they change by more than the deadband, 0 forwards any change"`
+	// DeadbandType 死区类型：absolute 绝对值，percent 上次转发值的百分比
+	DeadbandType string `json:"deadbandType" label:"Deadband Type" desc:"absolute or percent of the last forwarded value"`
+	// Heartbeat 心跳间隔，值没有变化但距离上次转发超过心跳间隔时也转发，eg. 5m，为空不启用
+	Heartbeat string `json:"heartbeat" label:"Heartbeat" desc:"Forward an unchanged value when the last forward is older than this, eg. 5m. Empty disables heartbeats"`
+}
+
+// ChangeDetectNode 值变化检测节点，按 key 记录上次转发的值和质量。
+// 第一次收到的值、变化超过死区的值、质量变化的值以及超过心跳间隔的值流转到`True`链，元数据 changeReason 为转发原因：
+// first、value、quality、heartbeat；没有变化的值流转到`False`链。
+// 死区与上次转发的值比较，缓慢漂移的值累计超过死区后也会转发。心跳在收到消息时检查，没有消息时不会主动输出。
+// 消息中没有值，流转到`Failure`链
+type ChangeDetectNode struct {
+	//节点配置
+	Config      ChangeDetectConfiguration
+	keyTemplate str.Template
+	detector    *Detector
+}
+
+// Type 返回组件类型
+func (x *ChangeDetectNode) Type() string {
+	return "x/changeDetect"
+}
+
+// New 默认参数
+func (x *ChangeDetectNode) New() types.Node {
+	return &ChangeDetectNode{
+		Config: ChangeDetectConfiguration{
+			Key:          "${metadata.nodeId}",
+			ValueField:   "value",
+			QualityField: "qualityLevel",
+			DeadbandType: DeadbandAbsolute,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ChangeDetectNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.ValueField == "" {
+		x.Config.ValueField = "value"
+	}
+	if x.Config.Deadband < 0 {
+		return errors.New("deadband can not be negative")
+	}
+	var percent bool
+	switch x.Config.DeadbandType {
+	case "", DeadbandAbsolute:
+	case DeadbandPercent:
+		percent = true
+	default:
+		return errors.New("deadbandType must be absolute or percent")
+	}
+	var heartbeat time.Duration
+	if x.Config.Heartbeat != "" {
+		if heartbeat, err = time.ParseDuration(x.Config.Heartbeat); err != nil {
+			return err
+		}
+		if heartbeat < 0 {
+			return errors.New("heartbeat can not be negative")
+		}
+	}
+	x.keyTemplate = str.NewTemplate(x.Config.Key)
+	x.detector = NewDetector(x.Config.Deadband, percent, heartbeat)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *ChangeDetectNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	value, quality, err := ParseValue([]byte(msg.GetData()), x.Config.ValueField, x.Config.QualityField)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	key := x.keyTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	reason, changed := x.detector.Check(key, value, quality, time.Now())
+	if !changed {
+		ctx.TellNext(msg, types.False)
+		return
+	}
+	msg.Metadata.PutValue(KeyChangeReason, reason)
+	ctx.TellNext(msg, types.True)
+}
+
+// Destroy 销毁组件
+func (x *ChangeDetectNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *ChangeDetectNode) Desc() string {
+	return "Forwards a value only when it changes beyond a deadband, its quality changes or a heartbeat is due, keeping the last value per key. Routes to True/False/Failure"
+}
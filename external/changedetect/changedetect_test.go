@@ -0,0 +1,71 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package changedetect
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestChangeDetectNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ChangeDetectNode{})
+
+	_, err := test.CreateAndInitNode("x/changeDetect", types.Configuration{"deadbandType": "relative"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/changeDetect", types.Configuration{"heartbeat": "often"}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/changeDetect", types.Configuration{
+		"deadband": 0.5,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	// 每条消息使用独立的元数据，避免转发原因写入后影响后面的消息
+	meta := func(nodeId string) *types.Metadata {
+		metadata := types.NewMetadata()
+		metadata.PutValue("nodeId", nodeId)
+		return metadata
+	}
+
+	var relations, reasons []string
+	// 节点在 OnMsg 返回前完成回调，同步处理保证消息的顺序
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		relations = append(relations, relationType)
+		reasons = append(reasons, msg.Metadata.GetValue(KeyChangeReason))
+	})
+	for _, m := range []struct {
+		nodeId string
+		data   string
+	}{
+		{"ns=2;s=T1", `{"value":20,"qualityLevel":"GOOD"}`},
+		{"ns=2;s=T1", `{"value":20.2,"qualityLevel":"GOOD"}`},
+		{"ns=2;s=T2", `{"value":20.2,"qualityLevel":"GOOD"}`},
+		{"ns=2;s=T1", `{"value":21,"qualityLevel":"GOOD"}`},
+		{"ns=2;s=T1", `{"value":21,"qualityLevel":"BAD"}`},
+		{"ns=2;s=T1", `{"nodeId":"ns=2;s=T1"}`},
+	} {
+		node.OnMsg(ctx, types.NewMsg(0, "OPC_UA_DATA", types.JSON, meta(m.nodeId), m.data))
+	}
+
+	assert.Equal(t, []string{types.True, types.False, types.True, types.True, types.True, types.Failure}, relations)
+	assert.Equal(t, []string{ReasonFirst, "", ReasonFirst, ReasonValue, ReasonQuality, ""}, reasons)
+}
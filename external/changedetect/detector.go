@@ -0,0 +1,152 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package changedetect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// 转发原因
+const (
+	// ReasonFirst 第一次收到该 key 的值
+	ReasonFirst = "first"
+	// ReasonValue 值的变化超过死区
+	ReasonValue = "value"
+	// ReasonQuality 质量变化
+	ReasonQuality = "quality"
+	// ReasonHeartbeat 值没有变化，但距离上次转发超过心跳间隔
+	ReasonHeartbeat = "heartbeat"
+)
+
+// ErrNoValue 消息中没有值
+var ErrNoValue = errors.New("value not found")
+
+// state 每个 key 上次转发的值
+type state struct {
+	value     interface{}
+	quality   string
+	forwarded time.Time
+}
+
+// Detector 按 key 记录上次转发的值，判断新的值是否需要转发，可以并发调用
+// 死区与上次转发的值比较，缓慢漂移的值累计超过死区后也会转发
+type Detector struct {
+	// deadband 数值变化的死区，0 表示任何变化都转发
+	deadband float64
+	// percent 死区为上次转发值的百分比
+	percent bool
+	// heartbeat 值没有变化时的最长转发间隔，0 表示不启用
+	heartbeat time.Duration
+	mu        sync.Mutex
+	states    map[string]*state
+}
+
+// NewDetector 创建变化检测器
+func NewDetector(deadband float64, percent bool, heartbeat time.Duration) *Detector {
+	return &Detector{
+		deadband:  deadband,
+		percent:   percent,
+		heartbeat: heartbeat,
+		states:    map[string]*state{},
+	}
+}
+
+// Check 判断 key 的新值是否需要转发，需要转发时记录为上次转发的值并返回转发原因
+func (d *Detector) Check(key string, value interface{}, quality string, now time.Time) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.states[key]
+	if !ok {
+		d.states[key] = &state{value: value, quality: quality, forwarded: now}
+		return ReasonFirst, true
+	}
+	var reason string
+	switch {
+	case s.quality != quality:
+		reason = ReasonQuality
+	case d.changed(s.value, value):
+		reason = ReasonValue
+	case d.heartbeat > 0 && now.Sub(s.forwarded) >= d.heartbeat:
+		reason = ReasonHeartbeat
+	default:
+		return "", false
+	}
+	s.value, s.quality, s.forwarded = value, quality, now
+	return reason, true
+}
+
+// changed 数值按死区比较，其他类型比较是否相等
+func (d *Detector) changed(last, value interface{}) bool {
+	a, ok1 := last.(float64)
+	b, ok2 := value.(float64)
+	if !ok1 || !ok2 {
+		return !reflect.DeepEqual(last, value)
+	}
+	deadband := d.deadband
+	if d.percent {
+		deadband = math.Abs(a) * d.deadband / 100
+	}
+	if deadband <= 0 {
+		return a != b
+	}
+	return math.Abs(b-a) > deadband
+}
+
+// Remove 删除 key 的状态，下一个值作为第一次收到的值转发
+func (d *Detector) Remove(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.states, key)
+}
+
+// Len 记录的 key 数量
+func (d *Detector) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.states)
+}
+
+// ParseValue 解析 msg.Data 中的值和质量
+// msg.Data 为对象时取 valueField 和 qualityField 字段（qualityField 为空或者字段不存在时质量为空），
+// 否则整个 msg.Data 作为值。数值统一为 float64
+func ParseValue(data []byte, valueField, qualityField string) (interface{}, string, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, "", err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v, "", nil
+	}
+	value, ok := m[valueField]
+	if !ok {
+		return nil, "", ErrNoValue
+	}
+	var quality string
+	if qualityField != "" {
+		if q, ok := m[qualityField]; ok && q != nil {
+			quality = fmt.Sprint(q)
+		}
+	}
+	return value, quality, nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package changedetect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDetector(t *testing.T) {
+	now := time.Now()
+	d := NewDetector(0.5, false, time.Minute)
+	reason, ok := d.Check("T1", 20.0, "GOOD", now)
+	assert.True(t, ok)
+	assert.Equal(t, ReasonFirst, reason)
+	// 在死区内
+	_, ok = d.Check("T1", 20.3, "GOOD", now.Add(time.Second))
+	assert.False(t, ok)
+	// 与上次转发的值比较，累计漂移超过死区
+	reason, ok = d.Check("T1", 20.6, "GOOD", now.Add(2*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, ReasonValue, reason)
+	reason, ok = d.Check("T1", 20.6, "UNCERTAIN", now.Add(3*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, ReasonQuality, reason)
+	_, ok = d.Check("T1", 20.6, "UNCERTAIN", now.Add(30*time.Second))
+	assert.False(t, ok)
+	reason, ok = d.Check("T1", 20.6, "UNCERTAIN", now.Add(63*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, ReasonHeartbeat, reason)
+
+	// 非数值
+	_, ok = d.Check("S1", "RUN", "", now)
+	assert.True(t, ok)
+	_, ok = d.Check("S1", "RUN", "", now)
+	assert.False(t, ok)
+	reason, ok = d.Check("S1", "STOP", "", now)
+	assert.True(t, ok)
+	assert.Equal(t, ReasonValue, reason)
+	assert.Equal(t, 2, d.Len())
+	d.Remove("S1")
+	reason, _ = d.Check("S1", "STOP", "", now)
+	assert.Equal(t, ReasonFirst, reason)
+
+	// 百分比死区
+	d = NewDetector(10, true, 0)
+	d.Check("P", 100.0, "", now)
+	_, ok = d.Check("P", 109.0, "", now)
+	assert.False(t, ok)
+	_, ok = d.Check("P", 111.0, "", now)
+	assert.True(t, ok)
+	// 没有心跳
+	_, ok = d.Check("P", 111.0, "", now.Add(time.Hour))
+	assert.False(t, ok)
+}
+
+func TestParseValue(t *testing.T) {
+	value, quality, err := ParseValue([]byte(`{"nodeId":"T1","value":21.5,"qualityLevel":"GOOD"}`), "value", "qualityLevel")
+	assert.Nil(t, err)
+	assert.Equal(t, 21.5, value)
+	assert.Equal(t, "GOOD", quality)
+
+	value, quality, err = ParseValue([]byte(`{"value":true,"quality":0}`), "value", "quality")
+	assert.Nil(t, err)
+	assert.Equal(t, true, value)
+	assert.Equal(t, "0", quality)
+
+	value, _, err = ParseValue([]byte(`42`), "value", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 42.0, value)
+
+	_, _, err = ParseValue([]byte(`{"v":1}`), "value", "")
+	assert.Equal(t, ErrNoValue, err)
+	_, _, err = ParseValue([]byte(`{`), "value", "")
+	assert.True(t, err != nil)
+}
@@ -0,0 +1,464 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/dtls/v3"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+)
+
+const (
+	// DefaultPort coap 默认端口
+	DefaultPort = 5683
+	// DefaultSecurePort coaps 默认端口
+	DefaultSecurePort = 5684
+	// DefaultAckTimeout 确认超时，重传间隔从 ACK_TIMEOUT 到 ACK_TIMEOUT*1.5 之间随机，之后每次翻倍
+	DefaultAckTimeout = 2 * time.Second
+	// DefaultMaxRetransmit 最大重传次数
+	DefaultMaxRetransmit = 4
+	// DefaultTimeout 请求超时
+	DefaultTimeout = 10 * time.Second
+	// DefaultBlockSize 默认块大小
+	DefaultBlockSize = 1024
+	// maxBodySize 分块传输的响应负荷的最大长度
+	maxBodySize = 8 << 20
+	// maxDatagramSize 接收缓冲区大小
+	maxDatagramSize = 64 << 10
+)
+
+var (
+	// ErrClosed 客户端已关闭
+	ErrClosed = errors.New("coap: client closed")
+	// ErrTimeout 请求超时
+	ErrTimeout = errors.New("coap: request timeout")
+	// ErrReset 服务端拒绝了消息
+	ErrReset = errors.New("coap: message reset by peer")
+)
+
+// ClientConfig 客户端配置
+type ClientConfig struct {
+	// Server 服务端地址，格式：coap://host:port、coaps://host:port 或者 host:port
+	Server string
+	// AckTimeout 确认超时
+	AckTimeout time.Duration
+	// MaxRetransmit 可靠消息的最大重传次数
+	MaxRetransmit int
+	// Timeout 请求超时，包括分块传输的每个块
+	Timeout time.Duration
+	// PSKIdentity DTLS PSK 身份，coaps 时使用
+	PSKIdentity string
+	// PSK DTLS 预共享密钥，coaps 时使用
+	PSK []byte
+	// TLS DTLS 证书配置，coaps 且 PSK 为空时使用证书模式
+	TLS tlsconfig.Config
+}
+
+// Request 请求
+type Request struct {
+	Method Code
+	// URI 路径和查询参数，eg. /sensors/temp?unit=c
+	URI string
+	// Confirmable 是否使用可靠消息，否则使用非可靠消息
+	Confirmable bool
+	// ContentFormat 负荷的内容格式，-1 表示不指定
+	ContentFormat int
+	// Accept 期望的响应内容格式，-1 表示不指定
+	Accept  int
+	Payload []byte
+	// BlockSize 分块传输的块大小 16-1024，0 使用 1024
+	BlockSize int
+}
+
+// Response 响应，分块传输的负荷已经合并
+type Response struct {
+	Code Code
+	// ContentFormat 负荷的内容格式，-1 表示没有指定
+	ContentFormat int
+	Options       []Option
+	Payload       []byte
+}
+
+// packetConn 数据报连接，UDP 或者 DTLS
+type packetConn interface {
+	Read(b []byte) (int, error)
+	Write(b []byte) (int, error)
+	Close() error
+}
+
+// Client CoAP 客户端，一个客户端对应一个服务端，可以并发请求
+type Client struct {
+	conn   packetConn
+	config ClientConfig
+	mu     sync.Mutex
+	// messageID 下一个消息ID
+	messageID uint16
+	// responses 按 token 等待的响应
+	responses map[string]chan *Message
+	// acks 按消息ID等待的确认或者重置
+	acks   map[uint16]chan *Message
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewClient 创建客户端，coaps 时完成 DTLS 握手
+func NewClient(config ClientConfig) (*Client, error) {
+	secure, address, err := ParseServer(config.Server)
+	if err != nil {
+		return nil, err
+	}
+	if config.AckTimeout <= 0 {
+		config.AckTimeout = DefaultAckTimeout
+	}
+	if config.MaxRetransmit < 0 {
+		config.MaxRetransmit = 0
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	raddr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	var conn packetConn
+	if secure {
+		// DTLS 使用 WriteTo 发送，不能使用已连接的 UDP
+		var dtlsConfig *dtls.Config
+		if len(config.PSK) > 0 || !config.TLS.Enabled() {
+			dtlsConfig, err = pskConfig(config.PSKIdentity, config.PSK)
+		} else {
+			host, _, _ := net.SplitHostPort(address)
+			dtlsConfig, err = certificateConfig(config.TLS, host)
+		}
+		if err != nil {
+			return nil, err
+		}
+		udp, err := net.ListenUDP("udp", nil)
+		if err != nil {
+			return nil, err
+		}
+		if conn, err = dialDTLS(udp, raddr, dtlsConfig, config.Timeout); err != nil {
+			_ = udp.Close()
+			return nil, err
+		}
+	} else if conn, err = net.DialUDP("udp", nil, raddr); err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:      conn,
+		config:    config,
+		messageID: uint16(mrand.Intn(0x10000)),
+		responses: map[string]chan *Message{},
+		acks:      map[uint16]chan *Message{},
+		closed:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// ParseServer 解析服务端地址，返回是否使用 DTLS 和 host:port
+func ParseServer(server string) (bool, string, error) {
+	secure := false
+	switch {
+	case strings.HasPrefix(server, "coaps://"):
+		secure = true
+		server = strings.TrimPrefix(server, "coaps://")
+	case strings.HasPrefix(server, "coap://"):
+		server = strings.TrimPrefix(server, "coap://")
+	}
+	server = strings.TrimSuffix(server, "/")
+	if server == "" {
+		return false, "", errors.New("coap server can not be empty")
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		port := DefaultPort
+		if secure {
+			port = DefaultSecurePort
+		}
+		server = net.JoinHostPort(strings.Trim(server, "[]"), strconv.Itoa(port))
+	}
+	return secure, server, nil
+}
+
+// Close 关闭客户端
+func (c *Client) Close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.closed)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// Do 发送请求，负荷超过块大小时使用 Block1 分块发送，响应使用 Block2 分块时自动获取后续的块
+func (c *Client) Do(req Request) (*Response, error) {
+	blockSize := req.BlockSize
+	if blockSize == 0 {
+		blockSize = DefaultBlockSize
+	}
+	szx, err := BlockSZX(blockSize)
+	if err != nil {
+		return nil, err
+	}
+	base := Message{Code: req.Method}
+	if !req.Confirmable {
+		base.Type = NonConfirmable
+	}
+	base.SetPathAndQuery(req.URI)
+	if req.ContentFormat >= 0 && len(req.Payload) > 0 {
+		base.SetUintOption(ContentFormat, uint32(req.ContentFormat))
+	}
+	if req.Accept >= 0 {
+		base.SetUintOption(Accept, uint32(req.Accept))
+	}
+
+	resp, err := c.sendBlock1(base, req.Payload, szx)
+	if err != nil {
+		return nil, err
+	}
+	payload := resp.Payload
+	// 获取后续的块
+	for {
+		v, ok := resp.UintOption(Block2)
+		if !ok {
+			break
+		}
+		block := decodeBlock(v)
+		if !block.More {
+			break
+		}
+		next := Block{Num: block.Num + 1, SZX: block.SZX}
+		if len(payload) != int(next.Num)*next.Size() {
+			return nil, fmt.Errorf("coap: unexpected block2 %d of size %d", block.Num, block.Size())
+		}
+		msg := base.clone()
+		msg.SetUintOption(Block2, next.encode())
+		if resp, err = c.exchange(msg); err != nil {
+			return nil, err
+		}
+		if resp.Code.Class() != 2 {
+			break
+		}
+		payload = append(payload, resp.Payload...)
+		if len(payload) > maxBodySize {
+			return nil, errors.New("coap: response body too large")
+		}
+	}
+	response := &Response{Code: resp.Code, ContentFormat: noContentType, Options: resp.Options, Payload: payload}
+	if v, ok := resp.UintOption(ContentFormat); ok {
+		response.ContentFormat = int(v)
+	}
+	return response, nil
+}
+
+// sendBlock1 发送请求，负荷超过块大小时分块发送，服务端要求更小的块时按服务端的块大小继续
+func (c *Client) sendBlock1(base Message, payload []byte, szx uint8) (*Message, error) {
+	if len(payload) <= 1<<(szx+4) {
+		msg := base.clone()
+		msg.Payload = payload
+		return c.exchange(msg)
+	}
+	offset := 0
+	for {
+		block := Block{SZX: szx}
+		size := block.Size()
+		block.Num = uint32(offset / size)
+		end := offset + size
+		if end >= len(payload) {
+			end = len(payload)
+		} else {
+			block.More = true
+		}
+		msg := base.clone()
+		msg.SetUintOption(Block1, block.encode())
+		if block.Num == 0 {
+			msg.SetUintOption(Size1, uint32(len(payload)))
+		}
+		msg.Payload = payload[offset:end]
+		resp, err := c.exchange(msg)
+		if err != nil {
+			return nil, err
+		}
+		if !block.More || resp.Code.Class() != 2 {
+			return resp, nil
+		}
+		offset = end
+		if v, ok := resp.UintOption(Block1); ok {
+			if ack := decodeBlock(v); ack.SZX < szx {
+				szx = ack.SZX
+			}
+		}
+	}
+}
+
+// clone 复制消息，选项切片独立
+func (m Message) clone() *Message {
+	m.Options = append([]Option(nil), m.Options...)
+	return &m
+}
+
+// exchange 发送一个请求并等待响应，可靠消息在确认超时后重传
+func (c *Client) exchange(msg *Message) (*Message, error) {
+	token := make([]byte, 4)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	msg.Token = token
+	respCh := make(chan *Message, 1)
+	ackCh := make(chan *Message, 1)
+	c.mu.Lock()
+	msg.MessageID = c.messageID
+	c.messageID++
+	c.responses[string(token)] = respCh
+	if msg.Type == Confirmable {
+		c.acks[msg.MessageID] = ackCh
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.responses, string(token))
+		delete(c.acks, msg.MessageID)
+		c.mu.Unlock()
+	}()
+
+	data, err := msg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err = c.write(data); err != nil {
+		return nil, err
+	}
+	deadline := time.NewTimer(c.config.Timeout)
+	defer deadline.Stop()
+	var retransmit <-chan time.Time
+	var timer *time.Timer
+	interval := c.config.AckTimeout + time.Duration(mrand.Int63n(int64(c.config.AckTimeout)/2+1))
+	if msg.Type == Confirmable {
+		timer = time.NewTimer(interval)
+		defer timer.Stop()
+		retransmit = timer.C
+	}
+	retries := 0
+	for {
+		select {
+		case <-c.closed:
+			return nil, ErrClosed
+		case <-deadline.C:
+			return nil, ErrTimeout
+		case resp := <-respCh:
+			return resp, nil
+		case ack := <-ackCh:
+			if ack.Type == Reset {
+				return nil, ErrReset
+			}
+			// 捎带响应
+			if ack.Code != Empty {
+				return ack, nil
+			}
+			// 空确认，等待单独的响应
+			retransmit = nil
+		case <-retransmit:
+			if retries >= c.config.MaxRetransmit {
+				return nil, ErrTimeout
+			}
+			retries++
+			if err = c.write(data); err != nil {
+				return nil, err
+			}
+			interval *= 2
+			timer.Reset(interval)
+		}
+	}
+}
+
+func (c *Client) write(data []byte) error {
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// readLoop 接收消息，按消息ID分发确认，按 token 分发响应，可靠的单独响应需要回复确认
+func (c *Client) readLoop() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			select {
+			case <-c.closed:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+				_ = c.Close()
+				return
+			}
+			// 连接的 UDP 收到 ICMP 端口不可达等错误，继续接收
+			continue
+		}
+		msg, err := Unmarshal(buf[:n])
+		if err != nil {
+			continue
+		}
+		switch msg.Type {
+		case Acknowledgement, Reset:
+			c.mu.Lock()
+			ch, ok := c.acks[msg.MessageID]
+			c.mu.Unlock()
+			if ok {
+				deliver(ch, msg)
+			}
+		default:
+			c.mu.Lock()
+			ch, ok := c.responses[string(msg.Token)]
+			c.mu.Unlock()
+			if msg.Type == Confirmable {
+				reply := &Message{Type: Acknowledgement, MessageID: msg.MessageID}
+				if !ok {
+					reply.Type = Reset
+				}
+				if data, err := reply.Marshal(); err == nil {
+					_ = c.write(data)
+				}
+			}
+			if ok && msg.Code != Empty {
+				deliver(ch, msg)
+			}
+		}
+	}
+}
+
+// deliver 非阻塞发送，重复的消息直接丢弃
+func deliver(ch chan *Message, msg *Message) {
+	select {
+	case ch <- msg:
+	default:
+	}
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 存在到metadata key
+const (
+	// StatusMetadataKey 响应状态，eg. 2.05 Content
+	StatusMetadataKey = "status"
+	// StatusCodeMetadataKey 响应码，eg. 2.05
+	StatusCodeMetadataKey = "statusCode"
+	// ContentFormatMetadataKey 响应的内容格式编号
+	ContentFormatMetadataKey = "contentFormat"
+	// ErrorBodyMetadataKey 错误响应的负荷
+	ErrorBodyMetadataKey = "errorBody"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ClientNode{})
+}
+
+// ClientConfiguration 节点配置
+type ClientConfiguration struct {
+	// Server 服务端地址，格式：coap://host:port 或者 coaps://host:port，端口默认 5683/5684
+	Server string `json:"server" label:"Server" desc:"CoAP server, format: coap://host:port or coaps://host:port for DTLS, port defaults to 5683/5684" required:"true" ref:"primary"`
+	// Method 请求方法：GET、POST、PUT、DELETE、FETCH、PATCH、IPATCH，允许使用 ${} 占位符变量
+	Method string `json:"method" label:"Method" desc:"Request method: GET, POST, PUT, DELETE, FETCH, PATCH or IPATCH. Supports ${} placeholders"`
+	// Path 资源路径和查询参数，eg. /sensors/${metadata.id}?unit=c，允许使用 ${} 占位符变量
+	Path string `json:"path" label:"Path" desc:"Resource path with optional query, e.g. /sensors/${metadata.id}?unit=c. Supports ${} placeholders"`
+	// Confirmable 是否使用可靠消息，否则使用非可靠消息
+	Confirmable bool `json:"confirmable" label:"Confirmable" desc:"Send confirmable requests that are retransmitted until acknowledged, otherwise non-confirmable"`
+	// ContentFormat 请求负荷的内容格式，名称或者编号，eg. json、text/plain、42，为空按消息数据类型推断
+	ContentFormat string `json:"contentFormat" label:"Content format" desc:"Request content format name or number, e.g. json, text/plain, 42. Empty derives it from the msg data type"`
+	// Accept 期望的响应内容格式，名称或者编号，为空不指定
+	Accept string `json:"accept" label:"Accept" desc:"Accepted response content format name or number, empty omits the option"`
+	// Payload 请求负荷，允许使用 ${} 占位符变量，为空使用 msg.Data
+	Payload string `json:"payload" label:"Payload" desc:"Request payload, supports ${} placeholders. Empty sends msg.Data for methods with a body"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Request timeout in seconds, also used for the DTLS handshake"`
+	// BlockSize 分块传输的块大小 16-1024
+	BlockSize int `json:"blockSize" label:"Block size" desc:"Block-wise transfer block size 16-1024"`
+	// PSKIdentity DTLS PSK 身份
+	PSKIdentity string `json:"pskIdentity" label:"PSK identity" desc:"DTLS PSK identity for coaps" ref:"shared"`
+	// PSK DTLS 预共享密钥
+	PSK string `json:"psk" label:"PSK" desc:"DTLS pre-shared key for coaps" ref:"shared"`
+	// TLS DTLS 证书配置，coaps 且 psk 为空时使用证书模式
+	TLS tlsconfig.Config `json:"tls" label:"TLS" desc:"DTLS certificate settings for coaps, used when psk is empty"`
+}
+
+// ClientNode CoAP 客户端节点，向设备的 CoAP 资源发送请求，支持可靠/非可靠消息、Block1/Block2 分块传输和 DTLS PSK（TLS_PSK_WITH_AES_128_CCM_8）或者证书。
+// 请求方法、路径和负荷允许使用 ${} 占位符变量，负荷为空时 POST、PUT、FETCH、PATCH、IPATCH 使用 msg.Data。
+// 响应负荷替换 msg.Data，响应码放在元数据 status 和 statusCode 中，内容格式放在元数据 contentFormat 中。
+// 响应码为 2.xx，流转到`Success`链，否则流转到`Failure`链，错误响应的负荷放在元数据 errorBody 中
+type ClientNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config          ClientConfiguration
+	methodTemplate  str.Template
+	pathTemplate    str.Template
+	payloadTemplate str.Template
+	contentFormat   int
+	accept          int
+}
+
+// Type 返回组件类型
+func (x *ClientNode) Type() string {
+	return "x/coapClient"
+}
+
+// New 默认参数
+func (x *ClientNode) New() types.Node {
+	return &ClientNode{
+		Config: ClientConfiguration{
+			Server:      "coap://127.0.0.1:5683",
+			Method:      "GET",
+			Path:        "/",
+			Confirmable: true,
+			Timeout:     10,
+			BlockSize:   DefaultBlockSize,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	secure, _, err := ParseServer(x.Config.Server)
+	if err != nil {
+		return err
+	}
+	if secure && x.Config.PSK == "" && !x.Config.TLS.Enabled() {
+		return fmt.Errorf("coaps requires a psk or tls certificates")
+	}
+	if err = x.Config.TLS.Validate(); err != nil {
+		return err
+	}
+	if x.Config.BlockSize == 0 {
+		x.Config.BlockSize = DefaultBlockSize
+	}
+	if _, err = BlockSZX(x.Config.BlockSize); err != nil {
+		return err
+	}
+	if x.contentFormat, err = ParseContentFormat(x.Config.ContentFormat); err != nil {
+		return err
+	}
+	if x.accept, err = ParseContentFormat(x.Config.Accept); err != nil {
+		return err
+	}
+	x.methodTemplate = str.NewTemplate(x.Config.Method)
+	x.pathTemplate = str.NewTemplate(x.Config.Path)
+	x.payloadTemplate = str.NewTemplate(x.Config.Payload)
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*Client, error) {
+		return NewClient(ClientConfig{
+			Server:        x.Config.Server,
+			MaxRetransmit: DefaultMaxRetransmit,
+			Timeout:       time.Duration(x.Config.Timeout) * time.Second,
+			PSKIdentity:   x.Config.PSKIdentity,
+			PSK:           []byte(x.Config.PSK),
+			TLS:           x.Config.TLS,
+		})
+	}, func(client *Client) error {
+		if client != nil {
+			return client.Close()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *ClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	method, err := ParseMethod(strings.TrimSpace(x.methodTemplate.Execute(evn)))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	req := Request{
+		Method:        method,
+		URI:           x.pathTemplate.Execute(evn),
+		Confirmable:   x.Config.Confirmable,
+		ContentFormat: x.contentFormat,
+		Accept:        x.accept,
+		BlockSize:     x.Config.BlockSize,
+	}
+	if x.Config.Payload != "" {
+		req.Payload = []byte(x.payloadTemplate.Execute(evn))
+	} else if method != GET && method != DELETE {
+		req.Payload = msg.GetBytes()
+	}
+	if req.ContentFormat < 0 && len(req.Payload) > 0 {
+		req.ContentFormat = contentFormatOf(msg.DataType)
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(StatusMetadataKey, strings.TrimSpace(resp.Code.String()+" "+resp.Code.Name()))
+	msg.Metadata.PutValue(StatusCodeMetadataKey, resp.Code.String())
+	if resp.ContentFormat >= 0 {
+		msg.Metadata.PutValue(ContentFormatMetadataKey, strconv.Itoa(resp.ContentFormat))
+	}
+	if resp.Code.Class() != 2 {
+		msg.Metadata.PutValue(ErrorBodyMetadataKey, string(resp.Payload))
+		ctx.TellFailure(msg, fmt.Errorf("coap response %s %s", resp.Code, resp.Code.Name()))
+		return
+	}
+	msg.SetDataType(dataTypeOf(resp.ContentFormat))
+	msg.SetBytes(resp.Payload)
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ClientNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ClientNode) Desc() string {
+	return "CoAP client with confirmable/non-confirmable requests, block-wise transfer and DTLS PSK or certificates. Routes to Success on 2.xx responses, otherwise Failure"
+}
+
+// contentFormatOf 按消息数据类型推断请求的内容格式
+func contentFormatOf(dataType types.DataType) int {
+	switch dataType {
+	case types.JSON:
+		return AppJSON
+	case types.BINARY:
+		return OctetStream
+	default:
+		return TextPlain
+	}
+}
+
+// dataTypeOf 按响应的内容格式确定消息数据类型
+func dataTypeOf(contentFormat int) types.DataType {
+	switch contentFormat {
+	case AppJSON, AppSenmlJSON, AppLwm2mJSON:
+		return types.JSON
+	case TextPlain, LinkFormat, AppXML, noContentType:
+		return types.TEXT
+	default:
+		return types.BINARY
+	}
+}
@@ -0,0 +1,327 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testServer 测试用的 CoAP 服务端
+type testServer struct {
+	conn *net.UDPConn
+	mu   sync.Mutex
+	// received 收到的请求
+	received []*Message
+	// dropped 丢弃第一个可靠请求的次数，用于测试重传
+	dropped int
+	// uploaded Block1 上传的负荷
+	uploaded []byte
+}
+
+var largeBody = []byte(strings.Repeat("0123456789abcdef", 10))
+
+func newTestServer(t *testing.T) *testServer {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{conn: conn}
+	go s.serve()
+	return s
+}
+
+func (s *testServer) addr() string {
+	return "coap://" + s.conn.LocalAddr().String()
+}
+
+func (s *testServer) requests() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.received...)
+}
+
+func (s *testServer) serve() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req, err := Unmarshal(buf[:n])
+		if err != nil || req.Code == Empty {
+			continue
+		}
+		s.mu.Lock()
+		if req.Path() == "/retry" && s.dropped == 0 {
+			s.dropped++
+			s.mu.Unlock()
+			continue
+		}
+		s.received = append(s.received, req)
+		s.mu.Unlock()
+		s.handle(req, addr)
+	}
+}
+
+// handle 按路径返回不同的响应
+func (s *testServer) handle(req *Message, addr *net.UDPAddr) {
+	resp := &Message{Type: Acknowledgement, MessageID: req.MessageID, Token: req.Token, Code: Content}
+	if req.Type == NonConfirmable {
+		resp.Type = NonConfirmable
+		resp.MessageID = req.MessageID + 1000
+	}
+	switch req.Path() {
+	case "/temp", "/retry":
+		resp.SetUintOption(ContentFormat, AppJSON)
+		resp.Payload = []byte(`{"temp":21.5}`)
+	case "/echo":
+		resp.Code = Changed
+		if cf, ok := req.UintOption(ContentFormat); ok {
+			resp.SetUintOption(ContentFormat, cf)
+		}
+		resp.Payload = req.Payload
+	case "/large":
+		block := Block{SZX: 4}
+		if v, ok := req.UintOption(Block2); ok {
+			block = decodeBlock(v)
+		}
+		start := int(block.Num) * block.Size()
+		end := start + block.Size()
+		if end < len(largeBody) {
+			block.More = true
+		} else {
+			end = len(largeBody)
+		}
+		resp.SetUintOption(Block2, block.encode())
+		resp.Payload = largeBody[start:end]
+	case "/upload":
+		v, _ := req.UintOption(Block1)
+		block := decodeBlock(v)
+		s.mu.Lock()
+		s.uploaded = append(s.uploaded, req.Payload...)
+		s.mu.Unlock()
+		// 要求更小的块
+		if block.SZX > 3 {
+			block.SZX = 3
+		}
+		resp.SetUintOption(Block1, block.encode())
+		resp.Code = Continue
+		if !block.More {
+			resp.Code = Changed
+		}
+	case "/separate":
+		ack := &Message{Type: Acknowledgement, MessageID: req.MessageID}
+		s.send(ack, addr)
+		resp.Type = Confirmable
+		resp.MessageID = req.MessageID + 2000
+		resp.Payload = []byte("later")
+	default:
+		resp.Code = NotFound
+		resp.Payload = []byte("no such resource")
+	}
+	s.send(resp, addr)
+}
+
+func (s *testServer) send(msg *Message, addr *net.UDPAddr) {
+	data, _ := msg.Marshal()
+	_, _ = s.conn.WriteToUDP(data, addr)
+}
+
+func (s *testServer) Close() {
+	_ = s.conn.Close()
+}
+
+func TestClient(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	client, err := NewClient(ClientConfig{Server: server.addr(), AckTimeout: 50 * time.Millisecond, MaxRetransmit: DefaultMaxRetransmit, Timeout: 2 * time.Second})
+	assert.Nil(t, err)
+	defer client.Close()
+
+	// 捎带响应
+	resp, err := client.Do(Request{Method: GET, URI: "/temp?unit=c", Confirmable: true, ContentFormat: -1, Accept: AppJSON})
+	assert.Nil(t, err)
+	assert.Equal(t, Content, resp.Code)
+	assert.Equal(t, AppJSON, resp.ContentFormat)
+	assert.Equal(t, `{"temp":21.5}`, string(resp.Payload))
+	req := server.requests()[0]
+	query, _ := req.Option(URIQuery)
+	assert.Equal(t, "unit=c", string(query))
+	accept, _ := req.UintOption(Accept)
+	assert.Equal(t, uint32(AppJSON), accept)
+
+	// 非可靠消息
+	resp, err = client.Do(Request{Method: GET, URI: "/temp", ContentFormat: -1, Accept: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"temp":21.5}`, string(resp.Payload))
+
+	// Block2
+	resp, err = client.Do(Request{Method: GET, URI: "/large", Confirmable: true, ContentFormat: -1, Accept: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, largeBody, resp.Payload)
+
+	// Block1，服务端要求更小的块
+	resp, err = client.Do(Request{Method: PUT, URI: "/upload", Confirmable: true, ContentFormat: OctetStream, Accept: -1, Payload: largeBody, BlockSize: 64})
+	assert.Nil(t, err)
+	assert.Equal(t, Changed, resp.Code)
+	server.mu.Lock()
+	assert.True(t, bytes.Equal(largeBody, server.uploaded))
+	server.mu.Unlock()
+
+	// 单独的响应
+	resp, err = client.Do(Request{Method: GET, URI: "/separate", Confirmable: true, ContentFormat: -1, Accept: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, "later", string(resp.Payload))
+
+	// 重传
+	resp, err = client.Do(Request{Method: GET, URI: "/retry", Confirmable: true, ContentFormat: -1, Accept: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, Content, resp.Code)
+
+	resp, err = client.Do(Request{Method: GET, URI: "/missing", Confirmable: true, ContentFormat: -1, Accept: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, NotFound, resp.Code)
+	assert.Equal(t, "no such resource", string(resp.Payload))
+
+	_, err = client.Do(Request{Method: GET, URI: "/", BlockSize: 2048})
+	assert.NotNil(t, err)
+}
+
+func TestClientTimeout(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	defer conn.Close()
+	client, err := NewClient(ClientConfig{Server: conn.LocalAddr().String(), AckTimeout: 10 * time.Millisecond, MaxRetransmit: 2, Timeout: 2 * time.Second})
+	assert.Nil(t, err)
+	defer client.Close()
+	_, err = client.Do(Request{Method: GET, URI: "/", Confirmable: true, ContentFormat: -1, Accept: -1})
+	assert.Equal(t, ErrTimeout, err)
+
+	_ = client.Close()
+	_, err = client.Do(Request{Method: GET, URI: "/", Confirmable: true, ContentFormat: -1, Accept: -1})
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestClientNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ClientNode{})
+
+	_, err := test.CreateAndInitNode("x/coapClient", types.Configuration{
+		"server": "coaps://127.0.0.1",
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/coapClient", types.Configuration{
+		"blockSize": 2048,
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/coapClient", types.Configuration{
+		"contentFormat": "image/png",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	server := newTestServer(t)
+	defer server.Close()
+
+	node, err := test.CreateAndInitNode("x/coapClient", types.Configuration{
+		"server": server.addr(),
+		"method": "${metadata.method}",
+		"path":   "/${metadata.resource}",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	// send 发送消息并等待回调完成，之后的断言依赖回调的结果
+	send := func(msg test.Msg, callback func(msg types.RuleMsg, relationType string, err error)) {
+		done := make(chan struct{})
+		test.NodeOnMsg(t, node, []test.Msg{msg}, func(msg types.RuleMsg, relationType string, err error) {
+			defer close(done)
+			callback(msg, relationType, err)
+		})
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for coap client node")
+		}
+	}
+
+	metaData := types.NewMetadata()
+	metaData.PutValue("method", "GET")
+	metaData.PutValue("resource", "temp")
+	send(test.Msg{
+		MetaData: metaData,
+		DataType: types.TEXT,
+		MsgType:  "TEST",
+		Data:     "ignored",
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, `{"temp":21.5}`, msg.GetData())
+		assert.Equal(t, types.JSON, msg.DataType)
+		assert.Equal(t, "2.05 Content", msg.Metadata.GetValue(StatusMetadataKey))
+		assert.Equal(t, "2.05", msg.Metadata.GetValue(StatusCodeMetadataKey))
+		assert.Equal(t, "50", msg.Metadata.GetValue(ContentFormatMetadataKey))
+	})
+
+	// POST 使用 msg.Data，内容格式按数据类型推断
+	metaData = types.NewMetadata()
+	metaData.PutValue("method", "POST")
+	metaData.PutValue("resource", "echo")
+	send(test.Msg{
+		MetaData: metaData,
+		DataType: types.JSON,
+		MsgType:  "TEST",
+		Data:     `{"on":true}`,
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, `{"on":true}`, msg.GetData())
+		assert.Equal(t, "2.04", msg.Metadata.GetValue(StatusCodeMetadataKey))
+		assert.Equal(t, "50", msg.Metadata.GetValue(ContentFormatMetadataKey))
+	})
+
+	metaData = types.NewMetadata()
+	metaData.PutValue("method", "GET")
+	metaData.PutValue("resource", "missing")
+	send(test.Msg{
+		MetaData: metaData,
+		DataType: types.TEXT,
+		MsgType:  "TEST",
+		Data:     "",
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "4.04", msg.Metadata.GetValue(StatusCodeMetadataKey))
+		assert.Equal(t, "no such resource", msg.Metadata.GetValue(ErrorBodyMetadataKey))
+	})
+
+	metaData = types.NewMetadata()
+	metaData.PutValue("method", "HEAD")
+	send(test.Msg{
+		MetaData: metaData,
+		DataType: types.TEXT,
+		MsgType:  "TEST",
+		Data:     "",
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pion/dtls/v3"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+)
+
+// dialDTLS 在 UDP 上完成 DTLS 1.2 握手，握手重传、记录加解密和重放检测由 pion/dtls 实现
+func dialDTLS(conn net.PacketConn, raddr net.Addr, config *dtls.Config, timeout time.Duration) (*dtls.Conn, error) {
+	dc, err := dtls.Client(conn, raddr, config)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err = dc.HandshakeContext(ctx); err != nil {
+		_ = dc.Close()
+		return nil, fmt.Errorf("coap: dtls handshake failed: %w", err)
+	}
+	return dc, nil
+}
+
+// pskConfig PSK 模式，只协商 TLS_PSK_WITH_AES_128_CCM_8（RFC 7252 要求 PSK 模式必须支持的加密套件）
+func pskConfig(identity string, psk []byte) (*dtls.Config, error) {
+	if len(psk) == 0 {
+		return nil, errors.New("coap: dtls psk can not be empty")
+	}
+	return &dtls.Config{
+		PSK: func([]byte) ([]byte, error) {
+			return psk, nil
+		},
+		PSKIdentityHint: []byte(identity),
+		CipherSuites:    []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_CCM_8},
+	}, nil
+}
+
+// certificateConfig 证书模式（RFC 7252 X.509 模式），证书在每次建立连接时加载，证书文件更新后重新连接即可生效
+// host 为服务端主机名，没有配置 serverName 时用于校验服务端证书。DTLS 只支持 1.2，忽略 minVersion
+func certificateConfig(c tlsconfig.Config, host string) (*dtls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	cert, err := c.LoadCertificate()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := c.LoadCAPool()
+	if err != nil {
+		return nil, err
+	}
+	suites, _ := tlsconfig.ParseCipherSuites(c.CipherSuites)
+	config := &dtls.Config{
+		RootCAs:            pool,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	for _, id := range suites {
+		config.CipherSuites = append(config.CipherSuites, dtls.CipherSuiteID(id))
+	}
+	return config, nil
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/dtls/v3"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// dtlsTestServer 测试用的 CoAP over DTLS 服务端，/temp 返回固定负荷
+type dtlsTestServer struct {
+	listener net.Listener
+	// requests 收到的请求数量
+	requests int32
+}
+
+func newDTLSTestServer(t *testing.T, psk []byte) *dtlsTestServer {
+	listener, err := dtls.Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, &dtls.Config{
+		PSK: func([]byte) ([]byte, error) {
+			return psk, nil
+		},
+		PSKIdentityHint: []byte("server"),
+		CipherSuites:    []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_CCM_8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &dtlsTestServer{listener: listener}
+	go s.serve()
+	return s
+}
+
+func (s *dtlsTestServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *dtlsTestServer) handle(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		req, err := Unmarshal(buf[:n])
+		if err != nil || req.Code == Empty {
+			continue
+		}
+		atomic.AddInt32(&s.requests, 1)
+		resp := &Message{Type: Acknowledgement, MessageID: req.MessageID, Token: req.Token, Code: Content, Payload: []byte(`{"temp":21.5}`)}
+		data, _ := resp.Marshal()
+		_, _ = conn.Write(data)
+	}
+}
+
+func (s *dtlsTestServer) addr() *net.UDPAddr {
+	return s.listener.Addr().(*net.UDPAddr)
+}
+
+func (s *dtlsTestServer) Close() {
+	_ = s.listener.Close()
+}
+
+// replayProxy 转发客户端和服务端之间的数据报，客户端发往服务端的数据报发送两次，模拟重放
+type replayProxy struct {
+	conn   *net.UDPConn
+	server *net.UDPAddr
+	mu     sync.Mutex
+	client *net.UDPAddr
+}
+
+func newReplayProxy(t *testing.T, server *net.UDPAddr) *replayProxy {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &replayProxy{conn: conn, server: server}
+	go p.serve()
+	return p
+}
+
+func (p *replayProxy) serve() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := p.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if addr.String() == p.server.String() {
+			p.mu.Lock()
+			client := p.client
+			p.mu.Unlock()
+			if client != nil {
+				_, _ = p.conn.WriteToUDP(buf[:n], client)
+			}
+			continue
+		}
+		p.mu.Lock()
+		p.client = addr
+		p.mu.Unlock()
+		_, _ = p.conn.WriteToUDP(buf[:n], p.server)
+		_, _ = p.conn.WriteToUDP(buf[:n], p.server)
+	}
+}
+
+func (p *replayProxy) addr() string {
+	return "coaps://" + p.conn.LocalAddr().String()
+}
+
+func (p *replayProxy) Close() {
+	_ = p.conn.Close()
+}
+
+func TestDTLSClient(t *testing.T) {
+	server := newDTLSTestServer(t, []byte("secret"))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{Server: "coaps://" + server.addr().String(), PSKIdentity: "device", PSK: []byte("secret"), Timeout: 2 * time.Second})
+	assert.Nil(t, err)
+	resp, err := client.Do(Request{Method: GET, URI: "/temp", Confirmable: true, ContentFormat: -1, Accept: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, Content, resp.Code)
+	assert.Equal(t, `{"temp":21.5}`, string(resp.Payload))
+	_ = client.Close()
+
+	// 预共享密钥不一致时握手失败
+	_, err = NewClient(ClientConfig{Server: "coaps://" + server.addr().String(), PSKIdentity: "device", PSK: []byte("wrong"), Timeout: time.Second})
+	assert.NotNil(t, err)
+	_, err = NewClient(ClientConfig{Server: "coaps://" + server.addr().String(), PSKIdentity: "device", Timeout: time.Second})
+	assert.NotNil(t, err)
+}
+
+func TestDTLSReplay(t *testing.T) {
+	server := newDTLSTestServer(t, []byte("secret"))
+	defer server.Close()
+	proxy := newReplayProxy(t, server.addr())
+	defer proxy.Close()
+
+	client, err := NewClient(ClientConfig{Server: proxy.addr(), PSKIdentity: "device", PSK: []byte("secret"), Timeout: 2 * time.Second})
+	assert.Nil(t, err)
+	defer client.Close()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Do(Request{Method: GET, URI: "/temp", Confirmable: true, ContentFormat: -1, Accept: -1})
+		assert.Nil(t, err)
+		assert.Equal(t, Content, resp.Code)
+	}
+	time.Sleep(100 * time.Millisecond)
+	// 重放的记录被丢弃，服务端每个请求只处理一次
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.requests))
+}
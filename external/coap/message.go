@@ -0,0 +1,461 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type 消息类型
+type Type uint8
+
+const (
+	Confirmable     Type = 0
+	NonConfirmable  Type = 1
+	Acknowledgement Type = 2
+	Reset           Type = 3
+)
+
+// Code 请求方法或者响应码，格式：class.detail
+type Code uint8
+
+// 请求方法
+const (
+	Empty  Code = 0
+	GET    Code = 1
+	POST   Code = 2
+	PUT    Code = 3
+	DELETE Code = 4
+	FETCH  Code = 5
+	PATCH  Code = 6
+	IPATCH Code = 7
+)
+
+// 响应码
+const (
+	Created                  Code = 0x41
+	Deleted                  Code = 0x42
+	Valid                    Code = 0x43
+	Changed                  Code = 0x44
+	Content                  Code = 0x45
+	Continue                 Code = 0x5f
+	BadRequest               Code = 0x80
+	Unauthorized             Code = 0x81
+	BadOption                Code = 0x82
+	Forbidden                Code = 0x83
+	NotFound                 Code = 0x84
+	MethodNotAllowed         Code = 0x85
+	NotAcceptable            Code = 0x86
+	RequestEntityIncomplete  Code = 0x88
+	PreconditionFailed       Code = 0x8c
+	RequestEntityTooLarge    Code = 0x8d
+	UnsupportedContentFormat Code = 0x8f
+	InternalServerError      Code = 0xa0
+	NotImplemented           Code = 0xa1
+	BadGateway               Code = 0xa2
+	ServiceUnavailable       Code = 0xa3
+	GatewayTimeout           Code = 0xa4
+)
+
+var methodNames = map[Code]string{
+	GET: "GET", POST: "POST", PUT: "PUT", DELETE: "DELETE",
+	FETCH: "FETCH", PATCH: "PATCH", IPATCH: "IPATCH",
+}
+
+var codeNames = map[Code]string{
+	Created: "Created", Deleted: "Deleted", Valid: "Valid", Changed: "Changed", Content: "Content",
+	Continue: "Continue", BadRequest: "Bad Request", Unauthorized: "Unauthorized", BadOption: "Bad Option",
+	Forbidden: "Forbidden", NotFound: "Not Found", MethodNotAllowed: "Method Not Allowed",
+	NotAcceptable: "Not Acceptable", RequestEntityIncomplete: "Request Entity Incomplete",
+	PreconditionFailed: "Precondition Failed", RequestEntityTooLarge: "Request Entity Too Large",
+	UnsupportedContentFormat: "Unsupported Content-Format", InternalServerError: "Internal Server Error",
+	NotImplemented: "Not Implemented", BadGateway: "Bad Gateway", ServiceUnavailable: "Service Unavailable",
+	GatewayTimeout: "Gateway Timeout",
+}
+
+// Class 响应码类别，2 成功，4 客户端错误，5 服务端错误
+func (c Code) Class() uint8 {
+	return uint8(c) >> 5
+}
+
+// String 格式：2.05
+func (c Code) String() string {
+	return fmt.Sprintf("%d.%02d", c.Class(), uint8(c)&0x1f)
+}
+
+// Name 方法名称或者响应码描述
+func (c Code) Name() string {
+	if name, ok := methodNames[c]; ok {
+		return name
+	}
+	return codeNames[c]
+}
+
+// ParseMethod 解析请求方法，不区分大小写
+func ParseMethod(method string) (Code, error) {
+	for code, name := range methodNames {
+		if strings.EqualFold(name, method) {
+			return code, nil
+		}
+	}
+	return Empty, fmt.Errorf("unsupported coap method: %s", method)
+}
+
+// OptionID 选项编号
+type OptionID uint16
+
+const (
+	IfMatch       OptionID = 1
+	URIHost       OptionID = 3
+	ETag          OptionID = 4
+	IfNoneMatch   OptionID = 5
+	Observe       OptionID = 6
+	URIPort       OptionID = 7
+	LocationPath  OptionID = 8
+	URIPath       OptionID = 11
+	ContentFormat OptionID = 12
+	MaxAge        OptionID = 14
+	URIQuery      OptionID = 15
+	Accept        OptionID = 17
+	LocationQuery OptionID = 20
+	Block2        OptionID = 23
+	Block1        OptionID = 27
+	Size2         OptionID = 28
+	ProxyURI      OptionID = 35
+	Size1         OptionID = 60
+)
+
+// 常用的内容格式
+const (
+	TextPlain     = 0
+	LinkFormat    = 40
+	AppXML        = 41
+	OctetStream   = 42
+	AppExi        = 47
+	AppJSON       = 50
+	AppCBOR       = 60
+	AppSenmlJSON  = 110
+	AppSenmlCBOR  = 112
+	AppLwm2mTLV   = 11542
+	AppLwm2mJSON  = 11543
+	AppLwm2mCBOR  = 11544
+	noContentType = -1
+)
+
+var contentFormatNames = map[string]int{
+	"text": TextPlain, "text/plain": TextPlain, "link-format": LinkFormat, "application/link-format": LinkFormat,
+	"xml": AppXML, "application/xml": AppXML, "octet-stream": OctetStream, "application/octet-stream": OctetStream,
+	"exi": AppExi, "application/exi": AppExi, "json": AppJSON, "application/json": AppJSON,
+	"cbor": AppCBOR, "application/cbor": AppCBOR, "senml+json": AppSenmlJSON, "application/senml+json": AppSenmlJSON,
+	"senml+cbor": AppSenmlCBOR, "application/senml+cbor": AppSenmlCBOR,
+	"lwm2m+tlv": AppLwm2mTLV, "application/vnd.oma.lwm2m+tlv": AppLwm2mTLV,
+	"lwm2m+json": AppLwm2mJSON, "application/vnd.oma.lwm2m+json": AppLwm2mJSON,
+	"lwm2m+cbor": AppLwm2mCBOR, "application/vnd.oma.lwm2m+cbor": AppLwm2mCBOR,
+}
+
+// ParseContentFormat 解析内容格式，支持名称（json、application/json）或者编号，空字符串返回 -1
+func ParseContentFormat(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return noContentType, nil
+	}
+	if v, err := strconv.ParseUint(s, 10, 16); err == nil {
+		return int(v), nil
+	}
+	if v, ok := contentFormatNames[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	return noContentType, fmt.Errorf("unsupported coap content format: %s", s)
+}
+
+// Option 选项
+type Option struct {
+	ID    OptionID
+	Value []byte
+}
+
+// Message CoAP 消息，RFC 7252
+type Message struct {
+	Type      Type
+	Code      Code
+	MessageID uint16
+	Token     []byte
+	// Options 选项，编码时按编号排序
+	Options []Option
+	Payload []byte
+}
+
+var (
+	errShortMessage = errors.New("coap: message too short")
+	errBadOption    = errors.New("coap: invalid option")
+)
+
+// Option 返回第一个指定编号的选项
+func (m *Message) Option(id OptionID) ([]byte, bool) {
+	for _, o := range m.Options {
+		if o.ID == id {
+			return o.Value, true
+		}
+	}
+	return nil, false
+}
+
+// UintOption 返回无符号整数选项
+func (m *Message) UintOption(id OptionID) (uint32, bool) {
+	b, ok := m.Option(id)
+	if !ok {
+		return 0, false
+	}
+	return decodeUint(b), true
+}
+
+// AddOption 添加选项
+func (m *Message) AddOption(id OptionID, value []byte) {
+	m.Options = append(m.Options, Option{ID: id, Value: value})
+}
+
+// SetUintOption 设置无符号整数选项，替换已有的同编号选项
+func (m *Message) SetUintOption(id OptionID, v uint32) {
+	m.RemoveOption(id)
+	m.AddOption(id, encodeUint(v))
+}
+
+// RemoveOption 删除指定编号的选项
+func (m *Message) RemoveOption(id OptionID) {
+	options := m.Options[:0]
+	for _, o := range m.Options {
+		if o.ID != id {
+			options = append(options, o)
+		}
+	}
+	m.Options = options
+}
+
+// SetPathAndQuery 按 / 和 & 拆分路径和查询参数，eg. /sensors/temp?unit=c
+func (m *Message) SetPathAndQuery(uri string) {
+	m.RemoveOption(URIPath)
+	m.RemoveOption(URIQuery)
+	path, query, _ := strings.Cut(uri, "?")
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			m.AddOption(URIPath, []byte(segment))
+		}
+	}
+	if query != "" {
+		for _, q := range strings.Split(query, "&") {
+			if q != "" {
+				m.AddOption(URIQuery, []byte(q))
+			}
+		}
+	}
+}
+
+// Path 返回路径，eg. /sensors/temp
+func (m *Message) Path() string {
+	var b strings.Builder
+	for _, o := range m.Options {
+		if o.ID == URIPath {
+			b.WriteByte('/')
+			b.Write(o.Value)
+		}
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+// Marshal 编码消息
+func (m *Message) Marshal() ([]byte, error) {
+	if len(m.Token) > 8 {
+		return nil, errors.New("coap: token length must be 0-8")
+	}
+	buf := make([]byte, 0, 4+len(m.Token)+len(m.Payload)+16)
+	buf = append(buf, 0x40|byte(m.Type)<<4|byte(len(m.Token)), byte(m.Code), byte(m.MessageID>>8), byte(m.MessageID))
+	buf = append(buf, m.Token...)
+	options := make([]Option, len(m.Options))
+	copy(options, m.Options)
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].ID < options[j].ID
+	})
+	var last OptionID
+	for _, o := range options {
+		delta := int(o.ID - last)
+		last = o.ID
+		length := len(o.Value)
+		if length > 0xffff+269 {
+			return nil, errBadOption
+		}
+		d, dExt := optionNibble(delta)
+		l, lExt := optionNibble(length)
+		buf = append(buf, d<<4|l)
+		buf = append(buf, dExt...)
+		buf = append(buf, lExt...)
+		buf = append(buf, o.Value...)
+	}
+	if len(m.Payload) > 0 {
+		buf = append(buf, 0xff)
+		buf = append(buf, m.Payload...)
+	}
+	return buf, nil
+}
+
+// optionNibble 选项增量和长度的半字节编码，大于 12 使用扩展字节
+func optionNibble(v int) (byte, []byte) {
+	switch {
+	case v < 13:
+		return byte(v), nil
+	case v < 269:
+		return 13, []byte{byte(v - 13)}
+	default:
+		v -= 269
+		return 14, []byte{byte(v >> 8), byte(v)}
+	}
+}
+
+// Unmarshal 解码消息
+func Unmarshal(data []byte) (*Message, error) {
+	if len(data) < 4 {
+		return nil, errShortMessage
+	}
+	if data[0]>>6 != 1 {
+		return nil, errors.New("coap: unsupported version")
+	}
+	tokenLen := int(data[0] & 0x0f)
+	if tokenLen > 8 || len(data) < 4+tokenLen {
+		return nil, errShortMessage
+	}
+	m := &Message{
+		Type:      Type(data[0] >> 4 & 0x03),
+		Code:      Code(data[1]),
+		MessageID: uint16(data[2])<<8 | uint16(data[3]),
+		Token:     append([]byte(nil), data[4:4+tokenLen]...),
+	}
+	data = data[4+tokenLen:]
+	var id int
+	for len(data) > 0 {
+		if data[0] == 0xff {
+			if len(data) == 1 {
+				return nil, errors.New("coap: empty payload after marker")
+			}
+			m.Payload = append([]byte(nil), data[1:]...)
+			break
+		}
+		delta, length := int(data[0]>>4), int(data[0]&0x0f)
+		data = data[1:]
+		var err error
+		if delta, data, err = optionExt(delta, data); err != nil {
+			return nil, err
+		}
+		if length, data, err = optionExt(length, data); err != nil {
+			return nil, err
+		}
+		if len(data) < length {
+			return nil, errShortMessage
+		}
+		id += delta
+		m.Options = append(m.Options, Option{ID: OptionID(id), Value: append([]byte(nil), data[:length]...)})
+		data = data[length:]
+	}
+	return m, nil
+}
+
+// optionExt 解码选项增量或者长度的扩展字节
+func optionExt(v int, data []byte) (int, []byte, error) {
+	switch v {
+	case 13:
+		if len(data) < 1 {
+			return 0, nil, errShortMessage
+		}
+		return int(data[0]) + 13, data[1:], nil
+	case 14:
+		if len(data) < 2 {
+			return 0, nil, errShortMessage
+		}
+		return int(data[0])<<8 | int(data[1]) + 269, data[2:], nil
+	case 15:
+		return 0, nil, errBadOption
+	}
+	return v, data, nil
+}
+
+// encodeUint 无符号整数选项使用最短的大端编码，0 编码为空
+func encodeUint(v uint32) []byte {
+	switch {
+	case v == 0:
+		return nil
+	case v < 1<<8:
+		return []byte{byte(v)}
+	case v < 1<<16:
+		return []byte{byte(v >> 8), byte(v)}
+	case v < 1<<24:
+		return []byte{byte(v >> 16), byte(v >> 8), byte(v)}
+	default:
+		return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	}
+}
+
+func decodeUint(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+// Block Block1/Block2 选项，RFC 7959
+type Block struct {
+	// Num 块序号
+	Num uint32
+	// More 是否还有后续的块
+	More bool
+	// SZX 块大小的指数，块大小为 2^(SZX+4)，0-6
+	SZX uint8
+}
+
+// Size 块大小
+func (b Block) Size() int {
+	return 1 << (b.SZX + 4)
+}
+
+func (b Block) encode() uint32 {
+	v := b.Num<<4 | uint32(b.SZX&0x07)
+	if b.More {
+		v |= 0x08
+	}
+	return v
+}
+
+func decodeBlock(v uint32) Block {
+	return Block{Num: v >> 4, More: v&0x08 != 0, SZX: uint8(v & 0x07)}
+}
+
+// BlockSZX 返回不大于 size 的最大块大小指数，size 范围 16-1024
+func BlockSZX(size int) (uint8, error) {
+	if size < 16 || size > 1024 {
+		return 0, fmt.Errorf("coap block size must be 16-1024, got %d", size)
+	}
+	var szx uint8
+	for 1<<(szx+5) <= size && szx < 6 {
+		szx++
+	}
+	return szx, nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMessage(t *testing.T) {
+	m := &Message{Type: Confirmable, Code: GET, MessageID: 0x1234, Token: []byte{1, 2}}
+	m.SetPathAndQuery("/sensors/temp?unit=c&precision=2")
+	m.SetUintOption(Accept, AppJSON)
+	// 扩展的选项增量和长度
+	m.AddOption(ProxyURI, []byte(strings.Repeat("a", 300)))
+	m.Payload = []byte("hi")
+	data, err := m.Marshal()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x42, 0x01, 0x12, 0x34, 1, 2}, data[:6])
+
+	decoded, err := Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, Confirmable, decoded.Type)
+	assert.Equal(t, GET, decoded.Code)
+	assert.Equal(t, uint16(0x1234), decoded.MessageID)
+	assert.Equal(t, []byte{1, 2}, decoded.Token)
+	assert.Equal(t, "/sensors/temp", decoded.Path())
+	v, ok := decoded.UintOption(Accept)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(AppJSON), v)
+	proxy, _ := decoded.Option(ProxyURI)
+	assert.Equal(t, 300, len(proxy))
+	assert.Equal(t, []byte("hi"), decoded.Payload)
+	var queries []string
+	for _, o := range decoded.Options {
+		if o.ID == URIQuery {
+			queries = append(queries, string(o.Value))
+		}
+	}
+	assert.Equal(t, []string{"unit=c", "precision=2"}, queries)
+
+	for _, b := range [][]byte{
+		{0x40},
+		{0x80, 0x01, 0, 0},
+		{0x49, 0x01, 0, 0},
+		{0x40, 0x01, 0, 0, 0xff},
+		{0x40, 0x01, 0, 0, 0xf1, 0},
+		{0x40, 0x01, 0, 0, 0x13, 'a'},
+	} {
+		_, err = Unmarshal(b)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "2.05", Content.String())
+	assert.Equal(t, "4.04", NotFound.String())
+	assert.Equal(t, "Not Found", NotFound.Name())
+	assert.Equal(t, uint8(5), InternalServerError.Class())
+	method, err := ParseMethod("put")
+	assert.Nil(t, err)
+	assert.Equal(t, PUT, method)
+	_, err = ParseMethod("HEAD")
+	assert.NotNil(t, err)
+
+	format, err := ParseContentFormat("application/json")
+	assert.Nil(t, err)
+	assert.Equal(t, AppJSON, format)
+	format, _ = ParseContentFormat("42")
+	assert.Equal(t, OctetStream, format)
+	format, _ = ParseContentFormat("")
+	assert.Equal(t, -1, format)
+	_, err = ParseContentFormat("image/png")
+	assert.NotNil(t, err)
+}
+
+func TestBlock(t *testing.T) {
+	b := Block{Num: 5, More: true, SZX: 2}
+	assert.Equal(t, 64, b.Size())
+	assert.Equal(t, b, decodeBlock(b.encode()))
+	szx, err := BlockSZX(1024)
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(6), szx)
+	szx, _ = BlockSZX(100)
+	assert.Equal(t, uint8(2), szx)
+	_, err = BlockSZX(2048)
+	assert.NotNil(t, err)
+
+	_, address, _ := ParseServer("coaps://example.com")
+	assert.Equal(t, "example.com:5684", address)
+	secure, address, _ := ParseServer("127.0.0.1:15683")
+	assert.False(t, secure)
+	assert.Equal(t, "127.0.0.1:15683", address)
+}
@@ -0,0 +1,408 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dlms
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// A-XDR 数据类型标签
+const (
+	tagNull               = 0
+	tagArray              = 1
+	tagStructure          = 2
+	tagBoolean            = 3
+	tagBitString          = 4
+	tagDoubleLong         = 5
+	tagDoubleLongUnsigned = 6
+	tagOctetString        = 9
+	tagVisibleString      = 10
+	tagUTF8String         = 12
+	tagBCD                = 13
+	tagInteger            = 15
+	tagLong               = 16
+	tagUnsigned           = 17
+	tagLongUnsigned       = 18
+	tagLong64             = 20
+	tagLong64Unsigned     = 21
+	tagEnum               = 22
+	tagFloat32            = 23
+	tagFloat64            = 24
+	tagDateTime           = 25
+	tagDate               = 26
+	tagTime               = 27
+)
+
+// deviationUnspecified 未指定时区偏差
+const deviationUnspecified = -0x8000
+
+var errShortData = errors.New("dlms: short data")
+
+// Structure 结构，解码后的成员
+type Structure []interface{}
+
+// decoder A-XDR 解码
+type decoder struct {
+	b   []byte
+	pos int
+}
+
+// DecodeData 解码 A-XDR 编码的数据，数组为 []interface{}，结构为 Structure，octet-string 为 []byte，
+// date-time 为 time.Time，日期和时间为字符串，位串为 "0101" 格式的字符串
+func DecodeData(b []byte) (interface{}, error) {
+	d := &decoder{b: b}
+	return d.decode()
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.b) {
+		return nil, errShortData
+	}
+	b := d.b[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// length A-XDR 长度
+func (d *decoder) length() (int, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	if b[0] < 0x80 {
+		return int(b[0]), nil
+	}
+	n := int(b[0] & 0x7f)
+	if n == 0 || n > 4 {
+		return 0, fmt.Errorf("dlms: invalid length of %d bytes", n)
+	}
+	if b, err = d.next(n); err != nil {
+		return 0, err
+	}
+	length := 0
+	for _, v := range b {
+		length = length<<8 | int(v)
+	}
+	return length, nil
+}
+
+func (d *decoder) decode() (interface{}, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	tag := b[0]
+	switch tag {
+	case tagNull:
+		return nil, nil
+	case tagArray, tagStructure:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		if n > len(d.b)-d.pos {
+			return nil, errShortData
+		}
+		values := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			v, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		if tag == tagStructure {
+			return Structure(values), nil
+		}
+		return values, nil
+	case tagBitString:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		if b, err = d.next((n + 7) / 8); err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			if b[i/8]&(0x80>>(i%8)) != 0 {
+				sb.WriteByte('1')
+			} else {
+				sb.WriteByte('0')
+			}
+		}
+		return sb.String(), nil
+	case tagOctetString, tagVisibleString, tagUTF8String:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		if b, err = d.next(n); err != nil {
+			return nil, err
+		}
+		if tag == tagOctetString {
+			return append([]byte(nil), b...), nil
+		}
+		return string(b), nil
+	}
+	size, ok := fixedSizes[tag]
+	if !ok {
+		return nil, fmt.Errorf("dlms: unsupported data type %d", tag)
+	}
+	if b, err = d.next(size); err != nil {
+		return nil, err
+	}
+	switch tag {
+	case tagBoolean:
+		return b[0] != 0, nil
+	case tagDoubleLong:
+		return int32(binary.BigEndian.Uint32(b)), nil
+	case tagDoubleLongUnsigned:
+		return binary.BigEndian.Uint32(b), nil
+	case tagBCD, tagInteger:
+		return int8(b[0]), nil
+	case tagLong:
+		return int16(binary.BigEndian.Uint16(b)), nil
+	case tagUnsigned, tagEnum:
+		return b[0], nil
+	case tagLongUnsigned:
+		return binary.BigEndian.Uint16(b), nil
+	case tagLong64:
+		return int64(binary.BigEndian.Uint64(b)), nil
+	case tagLong64Unsigned:
+		return binary.BigEndian.Uint64(b), nil
+	case tagFloat32:
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), nil
+	case tagFloat64:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case tagDateTime:
+		if t, ok := DecodeDateTime(b); ok {
+			return t, nil
+		}
+		return hex.EncodeToString(b), nil
+	case tagDate:
+		return formatDate(b), nil
+	default:
+		return formatTime(b), nil
+	}
+}
+
+// fixedSizes 定长类型的字节数
+var fixedSizes = map[byte]int{
+	tagBoolean:            1,
+	tagDoubleLong:         4,
+	tagDoubleLongUnsigned: 4,
+	tagBCD:                1,
+	tagInteger:            1,
+	tagLong:               2,
+	tagUnsigned:           1,
+	tagLongUnsigned:       2,
+	tagLong64:             8,
+	tagLong64Unsigned:     8,
+	tagEnum:               1,
+	tagFloat32:            4,
+	tagFloat64:            8,
+	tagDateTime:           12,
+	tagDate:               5,
+	tagTime:               4,
+}
+
+// DecodeDateTime 解码 12 字节的 date-time，年、月、日、时、分未指定时返回 false
+// 时区偏差为本地时间相对 UTC 的分钟数取反，未指定时使用本机时区
+func DecodeDateTime(b []byte) (time.Time, bool) {
+	if len(b) != 12 {
+		return time.Time{}, false
+	}
+	year := binary.BigEndian.Uint16(b[0:2])
+	month, day, hour, minute, second, hundredths := b[2], b[3], b[5], b[6], b[7], b[8]
+	if year == 0xffff || month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 {
+		return time.Time{}, false
+	}
+	if second > 59 {
+		second = 0
+	}
+	if hundredths > 99 {
+		hundredths = 0
+	}
+	loc := time.Local
+	if deviation := int16(binary.BigEndian.Uint16(b[9:11])); deviation != deviationUnspecified {
+		loc = time.FixedZone("", -int(deviation)*60)
+	}
+	return time.Date(int(year), time.Month(month), int(day), int(hour), int(minute), int(second), int(hundredths)*10000000, loc), true
+}
+
+// DateTimeValue 把 12 字节 octet-string 形式的 date-time（eg. 时钟、采集时间）转换为 time.Time，
+// JSON 输出为 RFC3339 格式，无法解码时按 JSONValue 转换
+func DateTimeValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		if t, ok := DecodeDateTime(b); ok {
+			return t
+		}
+	}
+	return JSONValue(v)
+}
+
+// EncodeDateTime 编码成 12 字节的 UTC date-time
+func EncodeDateTime(t time.Time) []byte {
+	t = t.UTC()
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[0:2], uint16(t.Year()))
+	b[2], b[3] = byte(t.Month()), byte(t.Day())
+	// 星期一为 1，星期日为 7
+	b[4] = byte((int(t.Weekday())+6)%7 + 1)
+	b[5], b[6], b[7] = byte(t.Hour()), byte(t.Minute()), byte(t.Second())
+	b[8] = byte(t.Nanosecond() / 10000000)
+	return b
+}
+
+func formatDate(b []byte) string {
+	year := binary.BigEndian.Uint16(b[0:2])
+	if year == 0xffff || b[2] > 12 || b[3] > 31 {
+		return hex.EncodeToString(b)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", year, b[2], b[3])
+}
+
+func formatTime(b []byte) string {
+	if b[0] > 23 || b[1] > 59 {
+		return hex.EncodeToString(b)
+	}
+	second := b[2]
+	if second > 59 {
+		second = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", b[0], b[1], second)
+}
+
+// JSONValue 转换成 JSON 友好的值，octet-string 全部为可打印字符时转换为字符串，否则为十六进制字符串
+func JSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		if isPrintable(v) {
+			return string(v)
+		}
+		return hex.EncodeToString(v)
+	case Structure:
+		return JSONValue([]interface{}(v))
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			values = append(values, JSONValue(item))
+		}
+		return values
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+func isPrintable(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// encoder A-XDR 编码
+type encoder struct {
+	b []byte
+}
+
+// length A-XDR 长度
+func (e *encoder) length(n int) {
+	switch {
+	case n < 0x80:
+		e.b = append(e.b, byte(n))
+	case n <= 0xff:
+		e.b = append(e.b, 0x81, byte(n))
+	default:
+		e.b = append(e.b, 0x82, byte(n>>8), byte(n))
+	}
+}
+
+func (e *encoder) octetString(v []byte) {
+	e.b = append(e.b, tagOctetString)
+	e.length(len(v))
+	e.b = append(e.b, v...)
+}
+
+func (e *encoder) structure(n int) {
+	e.b = append(e.b, tagStructure)
+	e.length(n)
+}
+
+func (e *encoder) array(n int) {
+	e.b = append(e.b, tagArray)
+	e.length(n)
+}
+
+func (e *encoder) longUnsigned(v uint16) {
+	e.b = append(e.b, tagLongUnsigned, byte(v>>8), byte(v))
+}
+
+func (e *encoder) integer(v int8) {
+	e.b = append(e.b, tagInteger, byte(v))
+}
+
+// toFloat 数值转换为 float64
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// toInt 整数转换为 int
+func toInt(v interface{}) (int, bool) {
+	f, ok := toFloat(v)
+	if !ok || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dlms
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseObis(t *testing.T) {
+	for _, s := range []string{"1.0.1.8.0.255", "1-0:1.8.0.255", "1-0:1.8.0*255", "1-0:1.8.0"} {
+		o, err := ParseObis(s)
+		assert.Nil(t, err)
+		assert.Equal(t, "1.0.1.8.0.255", o.String())
+	}
+	for _, s := range []string{"", "1.0.1.8", "1-0:1.8.0.256", "a.b.c.d.e.f"} {
+		_, err := ParseObis(s)
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, "Wh", UnitName(30))
+	assert.Equal(t, "", UnitName(255))
+}
+
+func TestDateTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 8, 15, 30, 0, time.UTC)
+	b := EncodeDateTime(now)
+	assert.Equal(t, 12, len(b))
+	// 2024-03-10 为星期日
+	assert.Equal(t, byte(7), b[4])
+	v, ok := DecodeDateTime(b)
+	assert.True(t, ok)
+	assert.True(t, v.Equal(now))
+
+	// 东八区的时区偏差为 -480
+	b[9], b[10] = 0xfe, 0x20
+	v, ok = DecodeDateTime(b)
+	assert.True(t, ok)
+	assert.True(t, v.Equal(now.Add(-8*time.Hour)))
+
+	b[0], b[1] = 0xff, 0xff
+	_, ok = DecodeDateTime(b)
+	assert.False(t, ok)
+}
+
+func TestDecodeData(t *testing.T) {
+	b := []byte{
+		0x02, 0x05,
+		0x06, 0x00, 0x00, 0x30, 0x39,
+		0x0f, 0xfe,
+		0x09, 0x03, 'A', 'B', 'C',
+		0x01, 0x02, 0x12, 0x00, 0x01, 0x03, 0x01,
+		0x04, 0x04, 0xa0,
+	}
+	v, err := DecodeData(b)
+	assert.Nil(t, err)
+	st, ok := v.(Structure)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(12345), st[0])
+	assert.Equal(t, int8(-2), st[1])
+	assert.Equal(t, "ABC", JSONValue(st[2]))
+	assert.Equal(t, []interface{}{uint16(1), true}, st[3])
+	assert.Equal(t, "1010", st[4])
+	assert.Equal(t, 123.45, scale(st[0], -2))
+	assert.Equal(t, 12345000.0, scale(st[0], 3))
+
+	_, err = DecodeData(b[:10])
+	assert.NotNil(t, err)
+	_, err = DecodeData([]byte{0x40})
+	assert.NotNil(t, err)
+}
+
+func TestHDLCFrame(t *testing.T) {
+	assert.Equal(t, uint16(0x906e), crc16([]byte("123456789")))
+	assert.Equal(t, []byte{0x03}, encodeServerAddress(1, 0))
+	assert.Equal(t, []byte{0x02, 0x23}, encodeServerAddress(1, 17))
+	assert.Equal(t, []byte{0x00, 0x02, 0x02, 0x21}, encodeServerAddress(1, 144))
+
+	f := &hdlcFrame{dest: []byte{0x03}, src: []byte{0x21}, control: hdlcSNRM}
+	b := f.marshal()
+	assert.Equal(t, []byte{0x7e, 0xa0, 0x07, 0x03, 0x21, 0x93, 0x0f, 0x01, 0x7e}, b)
+
+	f = &hdlcFrame{segmented: true, dest: []byte{0x02, 0x23}, src: []byte{0x21}, control: 0x10, info: []byte{1, 2, 3}}
+	// 帧之间的多余标志和无关字节被跳过
+	r := bufio.NewReader(bytes.NewReader(append([]byte{0x7e, 0x00}, f.marshal()...)))
+	got, err := readFrame(r)
+	assert.Nil(t, err)
+	assert.True(t, got.segmented)
+	assert.Equal(t, f.dest, got.dest)
+	assert.Equal(t, f.src, got.src)
+	assert.Equal(t, f.info, got.info)
+
+	b = f.marshal()
+	b[len(b)-3] ^= 0xff
+	_, err = unmarshalHDLC(b[1 : len(b)-1])
+	assert.NotNil(t, err)
+}
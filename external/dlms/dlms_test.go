@@ -0,0 +1,484 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dlms
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+const (
+	testSystemTitle       = "4d4d4d0000000001"
+	testServerSystemTitle = "4b464d1020304050"
+	testAuthenticationKey = "d0d1d2d3d4d5d6d7d8d9dadbdcdddedf"
+	testBlockCipherKey    = "000102030405060708090a0b0c0d0e0f"
+)
+
+// testTime 负荷曲线第一行的时间
+var testTime = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+// testMeter 模拟电表，支持 TCP wrapper 和 HDLC 传输
+type testMeter struct {
+	listener net.Listener
+	hdlc     bool
+	// mechanism 要求的认证机制
+	mechanism byte
+	password  string
+	// blockSize 超过该长度的响应使用块传输
+	blockSize int
+	objects   map[string][]byte
+	mu        sync.Mutex
+	// access 最后一次选择性访问参数
+	access   []byte
+	connects int
+	conns    []net.Conn
+}
+
+// meterSession 一个连接的状态
+type meterSession struct {
+	clientTitle []byte
+	ctos, stoc  []byte
+	associated  bool
+	// blocks 块传输未发送的数据
+	blocks []byte
+	block  uint32
+}
+
+func startTestMeter(t *testing.T, hdlc bool, mechanism byte) *testMeter {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	m := &testMeter{listener: l, hdlc: hdlc, mechanism: mechanism, password: "12345678", blockSize: 64, objects: map[string][]byte{}}
+	m.set(ClassRegister, "1.0.1.8.0.255", 2, []byte{0x06, 0x00, 0x00, 0x30, 0x39})
+	m.set(ClassRegister, "1.0.1.8.0.255", 3, []byte{0x02, 0x02, 0x0f, 0xff, 0x16, 0x1e})
+	m.set(ClassExtendedRegister, "1.0.1.6.0.255", 2, []byte{0x12, 0x00, 0x64})
+	m.set(ClassExtendedRegister, "1.0.1.6.0.255", 3, []byte{0x02, 0x02, 0x0f, 0x00, 0x16, 0x1b})
+	m.set(ClassExtendedRegister, "1.0.1.6.0.255", 5, append([]byte{0x09, 0x0c}, EncodeDateTime(testTime)...))
+	m.set(ClassClock, "0.0.1.0.0.255", 2, append([]byte{0x09, 0x0c}, EncodeDateTime(testTime)...))
+	m.set(ClassData, "0.0.96.1.0.255", 2, []byte{0x09, 0x08, '1', '2', '3', '4', '5', '6', '7', '8'})
+	// 负荷曲线：时钟和有功电能两列，每 15 分钟一行
+	e := encoder{}
+	e.array(2)
+	for _, o := range []struct {
+		classId uint16
+		obis    Obis
+	}{{ClassClock, ClockObis}, {ClassRegister, Obis{1, 0, 1, 8, 0, 255}}} {
+		e.structure(4)
+		e.longUnsigned(o.classId)
+		e.octetString(o.obis[:])
+		e.integer(2)
+		e.longUnsigned(0)
+	}
+	m.set(ClassProfileGeneric, "1.0.99.1.0.255", 3, e.b)
+	e = encoder{}
+	e.array(10)
+	for i := 0; i < 10; i++ {
+		e.structure(2)
+		e.octetString(EncodeDateTime(testTime.Add(time.Duration(i) * 15 * time.Minute)))
+		e.b = append(e.b, tagDoubleLongUnsigned)
+		e.b = binary.BigEndian.AppendUint32(e.b, uint32(12345+i))
+	}
+	m.set(ClassProfileGeneric, "1.0.99.1.0.255", 2, e.b)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			m.mu.Lock()
+			m.connects++
+			m.conns = append(m.conns, conn)
+			m.mu.Unlock()
+			go m.serve(conn)
+		}
+	}()
+	return m
+}
+
+func (m *testMeter) set(classId uint16, obis string, attribute int8, data []byte) {
+	m.objects[fmt.Sprintf("%d/%s/%d", classId, obis, attribute)] = data
+}
+
+func (m *testMeter) server() string {
+	return m.listener.Addr().String()
+}
+
+// dropConns 断开所有连接
+func (m *testMeter) dropConns() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, conn := range m.conns {
+		_ = conn.Close()
+	}
+	m.conns = nil
+}
+
+func (m *testMeter) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	s := &meterSession{}
+	if !m.hdlc {
+		for {
+			header := make([]byte, 8)
+			if _, err := io.ReadFull(r, header); err != nil {
+				return
+			}
+			apdu := make([]byte, binary.BigEndian.Uint16(header[6:]))
+			if _, err := io.ReadFull(r, apdu); err != nil {
+				return
+			}
+			resp := m.handle(s, apdu)
+			out := append([]byte{0, 1}, header[4:6]...)
+			out = append(out, header[2:4]...)
+			out = binary.BigEndian.AppendUint16(out, uint16(len(resp)))
+			if _, err := conn.Write(append(out, resp...)); err != nil {
+				return
+			}
+		}
+	}
+	// 服务端接收 32 字节、发送 40 字节的信息字段，请求和响应都需要分段
+	const maxInfo = 40
+	var ns, nr byte
+	var apdu []byte
+	send := func(f *hdlcFrame, req *hdlcFrame) {
+		f.dest, f.src = req.src, req.dest
+		_, _ = conn.Write(f.marshal())
+	}
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		switch {
+		case f.control == hdlcSNRM:
+			ns, nr = 0, 0
+			send(&hdlcFrame{control: hdlcUA, info: []byte{0x81, 0x80, 0x0c, 0x05, 0x01, maxInfo, 0x06, 0x01, 0x20, 0x07, 0x01, 0x01, 0x08, 0x01, 0x01}}, f)
+		case f.control == hdlcDISC:
+			send(&hdlcFrame{control: hdlcUA}, f)
+			return
+		case f.control&1 == 0:
+			nr = (f.control>>1 + 1) & 7
+			apdu = append(apdu, f.info...)
+			if f.segmented {
+				send(&hdlcFrame{control: nr<<5 | hdlcPF | hdlcRR}, f)
+				continue
+			}
+			resp := append(append([]byte(nil), llcResponse...), m.handle(s, apdu[len(llcRequest):])...)
+			apdu = nil
+			for len(resp) > 0 {
+				n := len(resp)
+				if n > maxInfo {
+					n = maxInfo
+				}
+				send(&hdlcFrame{segmented: n < len(resp), control: nr<<5 | hdlcPF | ns<<1, info: resp[:n]}, f)
+				ns = (ns + 1) & 7
+				if resp = resp[n:]; len(resp) > 0 {
+					// 等待客户端的 RR
+					if _, err = readFrame(r); err != nil {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+func (m *testMeter) handle(s *meterSession, apdu []byte) []byte {
+	switch apdu[0] {
+	case tagAARQ:
+		return m.associate(s, apdu)
+	case tagRLRQ:
+		return []byte{0x63, 0x03, 0x80, 0x01, 0x00}
+	case tagActionRequest:
+		config := ClientConfig{AuthenticationKey: testAuthenticationKey, BlockCipherKey: testBlockCipherKey}
+		v, err := DecodeData(apdu[13:])
+		reply, _ := v.([]byte)
+		if err != nil || len(reply) != 17 {
+			return []byte{tagActionResponse, 0x01, invokeIdAndPriority, 0x03}
+		}
+		want, _ := gmacReply(config, s.stoc, s.clientTitle, binary.BigEndian.Uint32(reply[1:5]))
+		if !bytes.Equal(want, reply) {
+			return []byte{tagActionResponse, 0x01, invokeIdAndPriority, 0x03}
+		}
+		s.associated = true
+		title, _ := decodeHex("", testServerSystemTitle, 8)
+		own, _ := gmacReply(config, s.ctos, title, 1)
+		e := encoder{b: []byte{tagActionResponse, 0x01, invokeIdAndPriority, 0x00, 0x01, 0x00}}
+		e.octetString(own)
+		return e.b
+	case tagGetRequest:
+		if !s.associated {
+			return []byte{tagExceptionResponse, 0x01, 0x02}
+		}
+		if apdu[1] == 0x02 {
+			return m.nextBlock(s)
+		}
+		obis := Obis{}
+		copy(obis[:], apdu[5:11])
+		key := fmt.Sprintf("%d/%s/%d", binary.BigEndian.Uint16(apdu[3:5]), obis, apdu[11])
+		data, ok := m.objects[key]
+		if !ok {
+			return []byte{tagGetResponse, 0x01, invokeIdAndPriority, 0x01, 0x04}
+		}
+		if apdu[12] == 0x01 {
+			m.mu.Lock()
+			m.access = append([]byte(nil), apdu[13:]...)
+			m.mu.Unlock()
+		}
+		if len(data) > m.blockSize {
+			s.blocks, s.block = data, 0
+			return m.nextBlock(s)
+		}
+		return append([]byte{tagGetResponse, 0x01, invokeIdAndPriority, 0x00}, data...)
+	}
+	return []byte{tagExceptionResponse, 0x01, 0x02}
+}
+
+func (m *testMeter) nextBlock(s *meterSession) []byte {
+	n := len(s.blocks)
+	if n > m.blockSize {
+		n = m.blockSize
+	}
+	s.block++
+	last := byte(0)
+	if n == len(s.blocks) {
+		last = 1
+	}
+	e := encoder{b: []byte{tagGetResponse, 0x02, invokeIdAndPriority, last}}
+	e.b = binary.BigEndian.AppendUint32(e.b, s.block)
+	e.b = append(e.b, 0x00)
+	e.length(n)
+	e.b = append(e.b, s.blocks[:n]...)
+	s.blocks = s.blocks[n:]
+	return e.b
+}
+
+func (m *testMeter) associate(s *meterSession, apdu []byte) []byte {
+	_, body, _, _ := parseTLV(apdu)
+	var mechanism byte
+	var value []byte
+	for len(body) > 0 {
+		tag, v, rest, err := parseTLV(body)
+		if err != nil {
+			break
+		}
+		body = rest
+		switch tag {
+		case 0xa6:
+			_, s.clientTitle, _, _ = parseTLV(v)
+		case 0x8b:
+			mechanism = v[len(v)-1]
+		case 0xac:
+			_, value, _, _ = parseTLV(v)
+		}
+	}
+	result, diagnostic := byte(0), byte(0)
+	var extra []byte
+	switch {
+	case mechanism != m.mechanism:
+		result, diagnostic = 1, 11
+	case mechanism == 1 && string(value) != m.password:
+		result, diagnostic = 1, 13
+	case mechanism >= 2:
+		diagnostic = diagnosticAuthRequired
+		s.ctos, s.stoc = value, []byte("0123456789abcdef")
+		title, _ := decodeHex("", testServerSystemTitle, 8)
+		extra = tlv(extra, 0xa4, tlv(nil, 0x04, title))
+		extra = tlv(extra, 0xaa, tlv(nil, 0x80, s.stoc))
+	default:
+		s.associated = true
+	}
+	b := append([]byte(nil), applicationContextLN...)
+	b = append(b, 0xa2, 0x03, 0x02, 0x01, result)
+	b = append(b, 0xa3, 0x05, 0xa1, 0x03, 0x02, 0x01, diagnostic)
+	b = append(b, extra...)
+	b = tlv(b, 0xbe, tlv(nil, 0x04, []byte{0x08, 0x00, 0x06, 0x5f, 0x1f, 0x04, 0x00, 0x00, 0x7e, 0x1f, 0x04, 0x00, 0x00, 0x07}))
+	return tlv(nil, tagAARE, b)
+}
+
+func TestClient(t *testing.T) {
+	meter := startTestMeter(t, true, 5)
+	defer meter.listener.Close()
+
+	config := ClientConfig{
+		Server:            meter.server(),
+		Transport:         TransportHDLC,
+		PhysicalAddress:   17,
+		Authentication:    AuthHighGMAC,
+		SystemTitle:       testSystemTitle,
+		AuthenticationKey: testAuthenticationKey,
+		BlockCipherKey:    testBlockCipherKey,
+		Timeout:           time.Second,
+	}
+	client, err := Dial(config)
+	assert.Nil(t, err)
+	defer client.Close()
+	assert.Equal(t, 1024, client.maxPdu)
+
+	register, err := client.ReadRegister(ClassRegister, Obis{1, 0, 1, 8, 0, 255})
+	assert.Nil(t, err)
+	assert.Equal(t, 1234.5, register["value"])
+	assert.Equal(t, "Wh", register["unit"])
+
+	register, err = client.ReadRegister(ClassExtendedRegister, Obis{1, 0, 1, 6, 0, 255})
+	assert.Nil(t, err)
+	assert.Equal(t, 100.0, register["value"])
+	assert.Equal(t, "W", register["unit"])
+
+	v, err := client.ReadData(ClassClock, ClockObis, 2)
+	assert.Nil(t, err)
+	assert.True(t, v.(time.Time).Equal(testTime))
+
+	// 块传输和按时间范围读取
+	rows, err := client.ReadProfile(Obis{1, 0, 99, 1, 0, 255}, testTime, testTime.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(rows))
+	assert.True(t, rows[1]["0.0.1.0.0.255"].(time.Time).Equal(testTime.Add(15*time.Minute)))
+	assert.Equal(t, 1234.6, rows[1]["1.0.1.8.0.255"])
+	meter.mu.Lock()
+	assert.Equal(t, byte(0x01), meter.access[0])
+	assert.Equal(t, EncodeDateTime(testTime), meter.access[23:35])
+	meter.mu.Unlock()
+
+	_, err = client.ReadData(ClassData, Obis{0, 0, 96, 1, 9, 255}, 2)
+	assert.Equal(t, "dlms 0.0.96.1.9.255: object undefined (4)", err.Error())
+	assert.False(t, client.Closed())
+
+	// 密钥错误，服务端拒绝认证
+	config.AuthenticationKey = testBlockCipherKey
+	_, err = Dial(config)
+	assert.NotNil(t, err)
+}
+
+func TestReadNode(t *testing.T) {
+	meter := startTestMeter(t, false, 1)
+	defer meter.listener.Close()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+
+	// 非法认证方式、密钥和 OBIS
+	for _, config := range []types.Configuration{
+		{"server": meter.server(), "authentication": "high"},
+		{"server": meter.server(), "systemTitle": "0102"},
+		{"server": meter.server(), "items": []map[string]interface{}{{"obis": "1.8.0"}}},
+		{"server": meter.server(), "items": []map[string]interface{}{{"obis": "1.0.99.1.0.255", "classId": 7, "from": "yesterday"}}},
+	} {
+		_, err := test.CreateAndInitNode("x/dlmsRead", config, Registry)
+		assert.NotNil(t, err)
+	}
+
+	node, err := test.CreateAndInitNode("x/dlmsRead", types.Configuration{
+		"server":         meter.server(),
+		"authentication": "low",
+		"password":       "12345678",
+		"items": []map[string]interface{}{
+			{"name": "energy", "obis": "1-0:1.8.0.255"},
+			{"name": "serial", "obis": "0-0:96.1.0.255", "classId": 1},
+			{"name": "profile", "obis": "1-0:99.1.0.255", "classId": 7},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgs := []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}
+	test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var values struct {
+			Energy struct {
+				Value float64 `json:"value"`
+				Unit  string  `json:"unit"`
+			} `json:"energy"`
+			Serial  string                   `json:"serial"`
+			Profile []map[string]interface{} `json:"profile"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 1234.5, values.Energy.Value)
+		assert.Equal(t, "Wh", values.Energy.Unit)
+		assert.Equal(t, "12345678", values.Serial)
+		assert.Equal(t, 10, len(values.Profile))
+		assert.Equal(t, "2024-03-10T00:00:00Z", values.Profile[0]["0.0.1.0.0.255"])
+	})
+
+	// 对象来自消息负荷，连接断开后重新连接
+	meter.dropConns()
+	node2, err := test.CreateAndInitNode("x/dlmsRead", types.Configuration{
+		"server":         meter.server(),
+		"authentication": "low",
+		"password":       "12345678",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node2.Destroy()
+	msgs = []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `["1-0:1.8.0.255", "1-0:2.8.0.255"]`,
+		AfterSleep: time.Millisecond * 200,
+	}, {
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `[{"name": "power", "obis": "1-0:1.6.0.255", "classId": 4}]`,
+		AfterSleep: time.Millisecond * 200,
+	}}
+	test.NodeOnMsg(t, node2, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		if relationType == types.Failure {
+			assert.Equal(t, "dlms 1.0.2.8.0.255: object undefined (4)", err.Error())
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		var values map[string]map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 100.0, values["power"]["value"])
+		assert.Equal(t, "2024-03-10T00:00:00Z", values["power"]["captureTime"])
+	})
+	meter.mu.Lock()
+	assert.Equal(t, 2, meter.connects)
+	meter.mu.Unlock()
+
+	// 密码错误
+	node3, err := test.CreateAndInitNode("x/dlmsRead", types.Configuration{
+		"server":         meter.server(),
+		"clientAddress":  1,
+		"authentication": "low",
+		"password":       "wrong",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node3.Destroy()
+	test.NodeOnMsg(t, node3, msgs[:1], func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "dlms: association rejected, result 1, diagnostic 13", err.Error())
+	})
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dlms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 接口类
+const (
+	ClassData             = 1
+	ClassRegister         = 3
+	ClassExtendedRegister = 4
+	ClassProfileGeneric   = 7
+	ClassClock            = 8
+	ClassAssociationLN    = 15
+)
+
+// Obis OBIS 代码 A-B:C.D.E.F，对象的逻辑名称
+type Obis [6]byte
+
+// ClockObis 时钟对象，负荷曲线按时钟列选择范围
+var ClockObis = Obis{0, 0, 1, 0, 0, 255}
+
+// associationObis 当前关联对象，用于 HLS 认证
+var associationObis = Obis{0, 0, 40, 0, 0, 255}
+
+// ParseObis 解析 OBIS 代码，支持 1.0.1.8.0.255、1-0:1.8.0.255、1-0:1.8.0*255 和 1-0:1.8.0，F 组默认为 255
+func ParseObis(s string) (Obis, error) {
+	var o Obis
+	fields := strings.FieldsFunc(strings.TrimSpace(s), func(r rune) bool {
+		return r == '.' || r == '-' || r == ':' || r == '*'
+	})
+	if len(fields) == 5 {
+		fields = append(fields, "255")
+	}
+	if len(fields) != 6 {
+		return o, fmt.Errorf("invalid obis code: %s", s)
+	}
+	for i, f := range fields {
+		v, err := strconv.ParseUint(f, 10, 8)
+		if err != nil {
+			return o, fmt.Errorf("invalid obis code: %s", s)
+		}
+		o[i] = byte(v)
+	}
+	return o, nil
+}
+
+// String 格式：A.B.C.D.E.F
+func (o Obis) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d.%d.%d", o[0], o[1], o[2], o[3], o[4], o[5])
+}
+
+// units 单位枚举，见 IEC 62056-62 (DLMS UA Blue Book)
+var units = map[int]string{
+	1: "a", 2: "mo", 3: "wk", 4: "d", 5: "h", 6: "min", 7: "s", 8: "°", 9: "°C", 10: "currency",
+	11: "m", 12: "m/s", 13: "m³", 14: "m³", 15: "m³/h", 16: "m³/h", 17: "m³/d", 18: "m³/d", 19: "l", 20: "kg",
+	21: "N", 22: "Nm", 23: "Pa", 24: "bar", 25: "J", 26: "J/h", 27: "W", 28: "VA", 29: "var", 30: "Wh",
+	31: "VAh", 32: "varh", 33: "A", 34: "C", 35: "V", 36: "V/m", 37: "F", 38: "Ω", 39: "Ωm²/m", 40: "Wb",
+	41: "T", 42: "A/m", 43: "H", 44: "Hz", 45: "1/(Wh)", 46: "1/(varh)", 47: "1/(VAh)", 48: "V²h", 49: "A²h", 50: "kg/s",
+	51: "S", 52: "K", 53: "1/(V²h)", 54: "1/(A²h)", 55: "1/m³", 56: "%", 57: "Ah",
+	60: "Wh/m³", 61: "J/m³", 62: "Mol %", 63: "g/m³", 64: "Pa s", 65: "J/kg",
+	70: "dBm", 71: "dBμV", 72: "dB",
+}
+
+// UnitName 单位名称，无单位或者未知单位返回空
+func UnitName(unit int) string {
+	return units[unit]
+}
@@ -0,0 +1,457 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dlms
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/goburrow/serial"
+)
+
+// 传输方式
+const (
+	// TransportHDLC HDLC 帧，用于串口和透传串口的 TCP 网关
+	TransportHDLC = "hdlc"
+	// TransportWrapper IEC 62056-47 TCP/UDP wrapper，用于以太网和蜂窝网络的电表
+	TransportWrapper = "wrapper"
+)
+
+// transport 传输层，发送请求 APDU 并接收响应 APDU
+type transport interface {
+	// open 建立链路层连接
+	open() error
+	// request 发送请求并接收完整的响应
+	request(apdu []byte) ([]byte, error)
+	// close 断开链路层连接并关闭物理链路
+	close() error
+}
+
+// link 物理链路，TCP 连接或者串口
+type link struct {
+	rw      io.ReadWriteCloser
+	timeout time.Duration
+	// deadline 当前请求的截止时间，串口只支持单次读取超时，按截止时间重试
+	deadline time.Time
+	reader   *bufio.Reader
+}
+
+func newLink(rw io.ReadWriteCloser, timeout time.Duration) *link {
+	l := &link{rw: rw, timeout: timeout}
+	l.reader = bufio.NewReader(linkReader{l})
+	return l
+}
+
+// begin 开始一次收发，重置截止时间
+func (l *link) begin() {
+	l.deadline = time.Now().Add(l.timeout)
+	if conn, ok := l.rw.(net.Conn); ok {
+		_ = conn.SetDeadline(l.deadline)
+	}
+}
+
+func (l *link) write(b []byte) error {
+	_, err := l.rw.Write(b)
+	return err
+}
+
+func (l *link) close() error {
+	return l.rw.Close()
+}
+
+// linkReader 串口读取超时时在截止时间之前重试
+type linkReader struct {
+	l *link
+}
+
+func (r linkReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.l.rw.Read(p)
+		if n > 0 || !errors.Is(err, serial.ErrTimeout) || time.Now().After(r.l.deadline) {
+			return n, err
+		}
+	}
+}
+
+// HDLC 控制字段
+const (
+	hdlcSNRM   = 0x93
+	hdlcDISC   = 0x53
+	hdlcUA     = 0x73
+	hdlcDM     = 0x1f
+	hdlcFRMR   = 0x97
+	hdlcFlag   = 0x7e
+	hdlcPF     = 0x10
+	hdlcRR     = 0x01
+	hdlcFormat = 0xa0
+	// hdlcSegment 帧格式中的分段标志
+	hdlcSegment = 0x08
+)
+
+// DefaultMaxInfoLength 默认的 HDLC 信息字段最大长度
+const DefaultMaxInfoLength = 128
+
+// LLC 头
+var (
+	llcRequest  = []byte{0xe6, 0xe6, 0x00}
+	llcResponse = []byte{0xe6, 0xe7, 0x00}
+)
+
+// hdlcFrame 一个 HDLC 帧
+type hdlcFrame struct {
+	segmented bool
+	dest      []byte
+	src       []byte
+	control   byte
+	info      []byte
+}
+
+// hdlc IEC 62056-46 HDLC 传输
+type hdlc struct {
+	link   *link
+	client []byte
+	server []byte
+	// ns、nr 发送和接收序号
+	ns, nr byte
+	// maxInfoTx、maxInfoRx 发送和接收的信息字段最大长度，由 SNRM/UA 协商
+	maxInfoTx, maxInfoRx int
+}
+
+func newHDLC(l *link, clientAddress, logicalAddress, physicalAddress int) *hdlc {
+	return &hdlc{
+		link:      l,
+		client:    encodeHDLCAddress(clientAddress),
+		server:    encodeServerAddress(logicalAddress, physicalAddress),
+		maxInfoTx: DefaultMaxInfoLength,
+		maxInfoRx: DefaultMaxInfoLength,
+	}
+}
+
+// encodeHDLCAddress 编码单字节地址，最低位为结束标志
+func encodeHDLCAddress(address int) []byte {
+	return []byte{byte(address<<1) | 1}
+}
+
+// encodeServerAddress 编码服务端地址：逻辑设备地址为高位，物理地址为低位
+// 物理地址为 0 时只使用 1 字节的逻辑设备地址，地址超过 127 时使用 4 字节地址
+func encodeServerAddress(logical, physical int) []byte {
+	switch {
+	case physical == 0 && logical < 0x80:
+		return []byte{byte(logical<<1) | 1}
+	case logical < 0x80 && physical < 0x80:
+		return []byte{byte(logical << 1), byte(physical<<1) | 1}
+	default:
+		return []byte{byte(logical>>7) << 1, byte(logical&0x7f) << 1, byte(physical>>7) << 1, byte(physical&0x7f)<<1 | 1}
+	}
+}
+
+// crc16 CRC-16/X-25
+func crc16(b []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, v := range b {
+		crc ^= uint16(v)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return ^crc
+}
+
+// marshal 编码帧，包括起止标志
+func (f *hdlcFrame) marshal() []byte {
+	length := 2 + len(f.dest) + len(f.src) + 1 + 2
+	if len(f.info) > 0 {
+		length += 2 + len(f.info)
+	}
+	format := uint16(hdlcFormat)<<8 | uint16(length)
+	if f.segmented {
+		format |= hdlcSegment << 8
+	}
+	b := []byte{hdlcFlag, byte(format >> 8), byte(format)}
+	b = append(b, f.dest...)
+	b = append(b, f.src...)
+	b = append(b, f.control)
+	if len(f.info) > 0 {
+		b = binary.LittleEndian.AppendUint16(b, crc16(b[1:]))
+		b = append(b, f.info...)
+	}
+	b = binary.LittleEndian.AppendUint16(b, crc16(b[1:]))
+	return append(b, hdlcFlag)
+}
+
+// readAddress 读取地址，最低位为 1 的字节为最后一个字节
+func readAddress(b []byte) ([]byte, []byte, error) {
+	for i, v := range b {
+		if v&1 != 0 {
+			return b[:i+1], b[i+1:], nil
+		}
+	}
+	return nil, nil, errors.New("dlms hdlc: invalid address")
+}
+
+// unmarshalHDLC 解码不包括起止标志的帧
+func unmarshalHDLC(b []byte) (*hdlcFrame, error) {
+	if len(b) < 7 || b[0]&0xf0 != hdlcFormat {
+		return nil, errors.New("dlms hdlc: invalid frame format")
+	}
+	if crc16(b[:len(b)-2]) != binary.LittleEndian.Uint16(b[len(b)-2:]) {
+		return nil, errors.New("dlms hdlc: bad fcs")
+	}
+	f := &hdlcFrame{segmented: b[0]&hdlcSegment != 0}
+	var rest []byte
+	var err error
+	if f.dest, rest, err = readAddress(b[2 : len(b)-2]); err != nil {
+		return nil, err
+	}
+	if f.src, rest, err = readAddress(rest); err != nil {
+		return nil, err
+	}
+	if len(rest) < 1 {
+		return nil, errors.New("dlms hdlc: short frame")
+	}
+	f.control = rest[0]
+	if rest = rest[1:]; len(rest) > 0 {
+		header := len(b) - 2 - len(rest)
+		if len(rest) < 2 || crc16(b[:header]) != binary.LittleEndian.Uint16(rest) {
+			return nil, errors.New("dlms hdlc: bad hcs")
+		}
+		f.info = rest[2:]
+	}
+	return f, nil
+}
+
+// readFrame 读取一个帧，跳过帧之间的标志和无关的字节
+func readFrame(r *bufio.Reader) (*hdlcFrame, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if c&0xf0 != hdlcFormat {
+			continue
+		}
+		lo, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length := int(c&0x07)<<8 | int(lo)
+		if length < 7 {
+			continue
+		}
+		b := make([]byte, length)
+		b[0], b[1] = c, lo
+		if _, err = io.ReadFull(r, b[2:]); err != nil {
+			return nil, err
+		}
+		return unmarshalHDLC(b)
+	}
+}
+
+func (h *hdlc) send(f *hdlcFrame) error {
+	f.dest, f.src = h.server, h.client
+	return h.link.write(f.marshal())
+}
+
+// receive 接收发给本客户端的帧
+func (h *hdlc) receive() (*hdlcFrame, error) {
+	for {
+		f, err := readFrame(h.link.reader)
+		if err != nil {
+			return nil, err
+		}
+		if string(f.dest) == string(h.client) && string(f.src) == string(h.server) {
+			return f, nil
+		}
+	}
+}
+
+// open 发送 SNRM 建立连接，按 UA 中的参数设置信息字段长度
+func (h *hdlc) open() error {
+	h.link.begin()
+	if err := h.send(&hdlcFrame{control: hdlcSNRM}); err != nil {
+		return err
+	}
+	f, err := h.receive()
+	if err != nil {
+		return err
+	}
+	switch f.control &^ hdlcPF {
+	case hdlcUA &^ hdlcPF:
+	case hdlcDM &^ hdlcPF:
+		return errors.New("dlms hdlc: connection refused (DM), check the client and server address")
+	default:
+		return fmt.Errorf("dlms hdlc: unexpected response 0x%02x to SNRM", f.control)
+	}
+	h.ns, h.nr = 0, 0
+	h.parseParameters(f.info)
+	return nil
+}
+
+// parseParameters 解析 UA 中协商的参数
+func (h *hdlc) parseParameters(b []byte) {
+	if len(b) < 3 || b[0] != 0x81 || b[1] != 0x80 {
+		return
+	}
+	b = b[3:]
+	for len(b) >= 2 && len(b) >= 2+int(b[1]) {
+		id, value := b[0], b[2:2+int(b[1])]
+		b = b[2+int(b[1]):]
+		n := 0
+		for _, v := range value {
+			n = n<<8 | int(v)
+		}
+		switch id {
+		case 0x05:
+			// 服务端发送的最大长度
+			h.maxInfoRx = n
+		case 0x06:
+			// 服务端接收的最大长度
+			if n > 0 {
+				h.maxInfoTx = n
+			}
+		}
+	}
+}
+
+// request 按信息字段最大长度分段发送，接收所有分段后返回去掉 LLC 头的 APDU
+func (h *hdlc) request(apdu []byte) ([]byte, error) {
+	h.link.begin()
+	info := append(append([]byte(nil), llcRequest...), apdu...)
+	for len(info) > 0 {
+		n := len(info)
+		if n > h.maxInfoTx {
+			n = h.maxInfoTx
+		}
+		last := n == len(info)
+		f := &hdlcFrame{segmented: !last, control: h.nr<<5 | hdlcPF | h.ns<<1, info: info[:n]}
+		if err := h.send(f); err != nil {
+			return nil, err
+		}
+		h.ns = (h.ns + 1) & 7
+		info = info[n:]
+		if !last {
+			// 等待服务端确认后发送下一个分段
+			if _, err := h.receiveControl(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var resp []byte
+	for {
+		f, err := h.receiveControl()
+		if err != nil {
+			return nil, err
+		}
+		if f.control&1 != 0 {
+			// 忽略 RR 等监控帧
+			continue
+		}
+		h.nr = (f.control>>1 + 1) & 7
+		resp = append(resp, f.info...)
+		if !f.segmented {
+			break
+		}
+		if err = h.send(&hdlcFrame{control: h.nr<<5 | hdlcPF | hdlcRR}); err != nil {
+			return nil, err
+		}
+	}
+	if len(resp) < len(llcResponse) || string(resp[:2]) != string(llcResponse[:2]) {
+		return nil, errors.New("dlms hdlc: invalid llc header")
+	}
+	return resp[len(llcResponse):], nil
+}
+
+// receiveControl 接收帧，断开和拒绝帧返回错误
+func (h *hdlc) receiveControl() (*hdlcFrame, error) {
+	f, err := h.receive()
+	if err != nil {
+		return nil, err
+	}
+	switch f.control &^ hdlcPF {
+	case hdlcDM &^ hdlcPF:
+		return nil, errors.New("dlms hdlc: disconnected by server (DM)")
+	case hdlcFRMR &^ hdlcPF:
+		return nil, errors.New("dlms hdlc: frame rejected by server (FRMR)")
+	}
+	return f, nil
+}
+
+// close 发送 DISC 后关闭链路，不等待超时
+func (h *hdlc) close() error {
+	h.link.timeout = time.Second
+	h.link.begin()
+	if h.send(&hdlcFrame{control: hdlcDISC}) == nil {
+		_, _ = h.receive()
+	}
+	return h.link.close()
+}
+
+// wrapperVersion TCP wrapper 版本
+const wrapperVersion = 1
+
+// wrapper IEC 62056-47 TCP wrapper 传输，源和目标端口为客户端地址和服务端逻辑设备地址
+type wrapper struct {
+	link   *link
+	source uint16
+	dest   uint16
+}
+
+func (w *wrapper) open() error {
+	return nil
+}
+
+func (w *wrapper) request(apdu []byte) ([]byte, error) {
+	w.link.begin()
+	b := make([]byte, 8, 8+len(apdu))
+	binary.BigEndian.PutUint16(b[0:], wrapperVersion)
+	binary.BigEndian.PutUint16(b[2:], w.source)
+	binary.BigEndian.PutUint16(b[4:], w.dest)
+	binary.BigEndian.PutUint16(b[6:], uint16(len(apdu)))
+	if err := w.link.write(append(b, apdu...)); err != nil {
+		return nil, err
+	}
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(w.link.reader, header); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(header) != wrapperVersion {
+			return nil, errors.New("dlms wrapper: invalid version")
+		}
+		resp := make([]byte, binary.BigEndian.Uint16(header[6:]))
+		if _, err := io.ReadFull(w.link.reader, resp); err != nil {
+			return nil, err
+		}
+		// 忽略发给其他客户端的数据
+		if binary.BigEndian.Uint16(header[4:]) == w.source {
+			return resp, nil
+		}
+	}
+}
+
+func (w *wrapper) close() error {
+	return w.link.close()
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// FunctionCode 应用层功能码
+type FunctionCode uint8
+
+const (
+	FuncConfirm             FunctionCode = 0
+	FuncRead                FunctionCode = 1
+	FuncWrite               FunctionCode = 2
+	FuncSelect              FunctionCode = 3
+	FuncOperate             FunctionCode = 4
+	FuncDirectOperate       FunctionCode = 5
+	FuncDirectOperateNoAck  FunctionCode = 6
+	FuncEnableUnsolicited   FunctionCode = 20
+	FuncDisableUnsolicited  FunctionCode = 21
+	FuncResponse            FunctionCode = 129
+	FuncUnsolicitedResponse FunctionCode = 130
+)
+
+// 应用层控制字节
+const (
+	appFir = 0x80
+	appFin = 0x40
+	appCon = 0x20
+	appUns = 0x10
+	appSeq = 0x0f
+)
+
+// MaxFragmentSize 应用层报文分片的最大长度
+const MaxFragmentSize = 2048
+
+// IIN 内部指示位，高字节为 IIN1，低字节为 IIN2
+type IIN uint16
+
+const (
+	IINAllStations         IIN = 0x0100
+	IINClass1Events        IIN = 0x0200
+	IINClass2Events        IIN = 0x0400
+	IINClass3Events        IIN = 0x0800
+	IINNeedTime            IIN = 0x1000
+	IINLocalControl        IIN = 0x2000
+	IINDeviceTrouble       IIN = 0x4000
+	IINDeviceRestart       IIN = 0x8000
+	IINNoFuncCodeSupport   IIN = 0x0001
+	IINObjectUnknown       IIN = 0x0002
+	IINParameterError      IIN = 0x0004
+	IINEventBufferOverflow IIN = 0x0008
+	IINAlreadyExecuting    IIN = 0x0010
+	IINConfigCorrupt       IIN = 0x0020
+)
+
+// iinRestartIndex g80v1 中 DEVICE_RESTART 的索引
+const iinRestartIndex = 7
+
+var iinNames = []struct {
+	bit  IIN
+	name string
+}{
+	{IINAllStations, "allStations"},
+	{IINClass1Events, "class1Events"},
+	{IINClass2Events, "class2Events"},
+	{IINClass3Events, "class3Events"},
+	{IINNeedTime, "needTime"},
+	{IINLocalControl, "localControl"},
+	{IINDeviceTrouble, "deviceTrouble"},
+	{IINDeviceRestart, "deviceRestart"},
+	{IINNoFuncCodeSupport, "noFuncCodeSupport"},
+	{IINObjectUnknown, "objectUnknown"},
+	{IINParameterError, "parameterError"},
+	{IINEventBufferOverflow, "eventBufferOverflow"},
+	{IINAlreadyExecuting, "alreadyExecuting"},
+	{IINConfigCorrupt, "configCorrupt"},
+}
+
+// Names 置位的指示位名称
+func (i IIN) Names() []string {
+	names := []string{}
+	for _, n := range iinNames {
+		if i&n.bit != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+// errorBits 表示请求失败的指示位
+const errorBits = IINNoFuncCodeSupport | IINObjectUnknown | IINParameterError
+
+// IINError 从站以 IIN2 错误位拒绝请求
+type IINError struct {
+	IIN IIN
+}
+
+func (e *IINError) Error() string {
+	return fmt.Sprintf("dnp3: request rejected %v", (e.IIN & errorBits).Names())
+}
+
+// Qualifier 对象头限定词
+const (
+	QualifierStartStop8   = 0x00
+	QualifierStartStop16  = 0x01
+	QualifierAll          = 0x06
+	QualifierCount8       = 0x07
+	QualifierCount16      = 0x08
+	QualifierIndexCount8  = 0x17
+	QualifierIndexCount16 = 0x28
+)
+
+// ObjectHeader 对象头
+type ObjectHeader struct {
+	Group     uint8
+	Variation uint8
+	Qualifier uint8
+	// Start、Stop 用于起止范围限定词，Count 用于数量限定词
+	Start, Stop uint32
+	Count       uint32
+}
+
+// appendHeader 编码对象头
+func appendHeader(b []byte, h ObjectHeader) []byte {
+	b = append(b, h.Group, h.Variation, h.Qualifier)
+	switch h.Qualifier {
+	case QualifierStartStop8:
+		b = append(b, byte(h.Start), byte(h.Stop))
+	case QualifierStartStop16:
+		b = binary.LittleEndian.AppendUint16(b, uint16(h.Start))
+		b = binary.LittleEndian.AppendUint16(b, uint16(h.Stop))
+	case QualifierCount8, QualifierIndexCount8:
+		b = append(b, byte(h.Count))
+	case QualifierCount16, QualifierIndexCount16:
+		b = binary.LittleEndian.AppendUint16(b, uint16(h.Count))
+	}
+	return b
+}
+
+// indexSize 索引前缀的字节数
+func (h ObjectHeader) indexSize() int {
+	switch h.Qualifier {
+	case QualifierIndexCount8:
+		return 1
+	case QualifierIndexCount16:
+		return 2
+	}
+	return 0
+}
+
+// quantity 对象数量
+func (h ObjectHeader) quantity() int {
+	switch h.Qualifier {
+	case QualifierStartStop8, QualifierStartStop16:
+		return int(h.Stop) - int(h.Start) + 1
+	case QualifierAll:
+		return 0
+	}
+	return int(h.Count)
+}
+
+var errShortObject = errors.New("dnp3: short object data")
+
+// parseHeader 解码对象头，返回剩余数据
+func parseHeader(b []byte) (ObjectHeader, []byte, error) {
+	if len(b) < 3 {
+		return ObjectHeader{}, nil, errShortObject
+	}
+	h := ObjectHeader{Group: b[0], Variation: b[1], Qualifier: b[2]}
+	b = b[3:]
+	switch h.Qualifier {
+	case QualifierStartStop8:
+		if len(b) < 2 {
+			return h, nil, errShortObject
+		}
+		h.Start, h.Stop, b = uint32(b[0]), uint32(b[1]), b[2:]
+	case QualifierStartStop16:
+		if len(b) < 4 {
+			return h, nil, errShortObject
+		}
+		h.Start, h.Stop, b = uint32(binary.LittleEndian.Uint16(b)), uint32(binary.LittleEndian.Uint16(b[2:])), b[4:]
+	case QualifierAll:
+	case QualifierCount8, QualifierIndexCount8:
+		if len(b) < 1 {
+			return h, nil, errShortObject
+		}
+		h.Count, b = uint32(b[0]), b[1:]
+	case QualifierCount16, QualifierIndexCount16:
+		if len(b) < 2 {
+			return h, nil, errShortObject
+		}
+		h.Count, b = uint32(binary.LittleEndian.Uint16(b)), b[2:]
+	default:
+		return h, nil, fmt.Errorf("dnp3: unsupported qualifier 0x%02x", h.Qualifier)
+	}
+	if h.Stop < h.Start {
+		return h, nil, fmt.Errorf("dnp3: invalid range %d-%d", h.Start, h.Stop)
+	}
+	return h, b, nil
+}
+
+// APDU 应用层报文
+type APDU struct {
+	Control  uint8
+	Function FunctionCode
+	// IIN 只用于响应
+	IIN     IIN
+	Objects []byte
+}
+
+func (a *APDU) Seq() uint8 {
+	return a.Control & appSeq
+}
+
+// isResponse 是否为从站方向的响应
+func (a *APDU) isResponse() bool {
+	return a.Function == FuncResponse || a.Function == FuncUnsolicitedResponse
+}
+
+func (a *APDU) marshal() []byte {
+	b := make([]byte, 0, 4+len(a.Objects))
+	b = append(b, a.Control, byte(a.Function))
+	if a.isResponse() {
+		b = append(b, byte(a.IIN>>8), byte(a.IIN))
+	}
+	return append(b, a.Objects...)
+}
+
+func parseAPDU(b []byte) (*APDU, error) {
+	if len(b) < 2 {
+		return nil, errors.New("dnp3: short apdu")
+	}
+	a := &APDU{Control: b[0], Function: FunctionCode(b[1])}
+	b = b[2:]
+	if a.isResponse() {
+		if len(b) < 2 {
+			return nil, errors.New("dnp3: short response")
+		}
+		a.IIN, b = IIN(b[0])<<8|IIN(b[1]), b[2:]
+	}
+	a.Objects = b
+	return a, nil
+}
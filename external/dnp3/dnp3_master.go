@@ -0,0 +1,270 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 操作类型
+const (
+	OperationIntegrityPoll = "integrityPoll"
+	OperationEventPoll     = "eventPoll"
+	OperationReadEvents    = "readEvents"
+	OperationRead          = "read"
+	OperationOperate       = "operate"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&MasterNode{})
+}
+
+// MasterConfiguration 节点配置
+type MasterConfiguration struct {
+	// Server 从站地址，格式：host:port，端口默认 20000
+	Server string `json:"server" label:"Server" desc:"Outstation address, format: host:port, port defaults to 20000" required:"true" ref:"primary"`
+	// LocalAddr 主站链路地址
+	LocalAddr uint16 `json:"localAddr" label:"Master address" desc:"Link address of this master"`
+	// RemoteAddr 从站链路地址
+	RemoteAddr uint16 `json:"remoteAddr" label:"Outstation address" desc:"Link address of the outstation"`
+	// Timeout 连接和响应超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and response timeout in seconds"`
+	// Operation 操作：integrityPoll、eventPoll、readEvents、read、operate
+	Operation string `json:"operation" label:"Operation" desc:"Operation: integrityPoll, eventPoll, readEvents, read or operate"`
+	// Classes eventPoll 和 readEvents 读取的事件等级，默认 1、2、3
+	Classes []int `json:"classes" label:"Classes" desc:"eventPoll/readEvents: event classes to read, defaults to 1, 2 and 3"`
+	// MaxEvents readEvents 主站最多缓存的事件数量，超过时丢弃最早的事件，0 为 10000
+	MaxEvents int `json:"maxEvents" label:"Max events" desc:"readEvents: maximum number of buffered events, oldest are dropped when full, 0 means 10000"`
+	// Group、Variation read 读取的对象组和变体，变体为 0 表示从站默认变体
+	Group     uint8 `json:"group" label:"Group" desc:"read: object group, eg. 30 for analog inputs"`
+	Variation uint8 `json:"variation" label:"Variation" desc:"read: object variation, 0 means the outstation default"`
+	// Start、Stop read 读取的索引范围，start 小于 0 表示读取所有点位
+	Start int `json:"start" label:"Start" desc:"read: start index, negative reads all points"`
+	Stop  int `json:"stop" label:"Stop" desc:"read: stop index"`
+	// Mode operate 控制模式：selectBeforeOperate、directOperate、directOperateNoAck
+	Mode string `json:"mode" label:"Mode" desc:"operate: selectBeforeOperate, directOperate or directOperateNoAck"`
+	// Commands operate 的控制命令，为空则使用消息负荷 msg.Data 中的命令
+	Commands []Command `json:"commands" label:"Commands" desc:"operate: control commands, empty uses the command or command array in msg.Data"`
+	// ClearRestart 轮询发现从站重启时清除 DEVICE_RESTART 指示位
+	ClearRestart bool `json:"clearRestart" label:"Clear restart" desc:"Clear the outstation DEVICE_RESTART indication after a poll reports it"`
+}
+
+// MasterNode DNP3 主站节点，通过 TCP 对从站进行完整性轮询、事件轮询、读取对象组或者执行 CROB 和模拟量输出控制。
+// 轮询和读取的结果重新赋值到msg.Data：
+//
+//	{"iin": ["deviceRestart"], "points": [{"type": "analogInput", "index": 0, "value": 12.5, "flags": 1, "group": 30, "variation": 5}]}
+//
+// readEvents 读取事件顺序记录，从站 IIN 指示仍有事件时继续轮询，和请求期间收到的非请求响应中的事件一起按设备原始时标排序，
+// dropped 为主站事件缓存已满丢弃的事件数量，iin 包括 eventBufferOverflow 时表示从站的事件缓冲区已经溢出：
+//
+//	{"iin": [], "points": [{"type": "binaryInput", "index": 2, "value": true, "flags": 129, "timestamp": 1700000000000, "event": true, "group": 2, "variation": 2}], "dropped": 0}
+//
+// 控制命令来自配置 commands，或者消息负荷 msg.Data 中的命令或命令数组：
+//
+//	[{"type": "binaryOutput", "index": 0, "code": "latchOn"}, {"type": "analogOutput", "index": 1, "value": 50}]
+//
+// 控制结果为 {"iin": [], "statuses": ["success", "success"]}。
+// 相同 server 和链路地址的节点共享一个连接。
+// 请求成功并且所有命令的状态为 success，流转到`Success`链，否则流转到`Failure`链
+type MasterNode struct {
+	base.SharedNode[*Master]
+	//节点配置
+	Config MasterConfiguration
+}
+
+// Type 返回组件类型
+func (x *MasterNode) Type() string {
+	return "x/dnp3Master"
+}
+
+// New 默认参数
+func (x *MasterNode) New() types.Node {
+	return &MasterNode{
+		Config: MasterConfiguration{
+			Server:       "127.0.0.1:20000",
+			LocalAddr:    DefaultMasterAddr,
+			RemoteAddr:   DefaultOutstationAddr,
+			Timeout:      5,
+			Operation:    OperationIntegrityPoll,
+			Start:        -1,
+			Stop:         -1,
+			Mode:         ModeSelectBeforeOperate,
+			ClearRestart: true,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *MasterNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	switch x.Config.Operation {
+	case "":
+		x.Config.Operation = OperationIntegrityPoll
+	case OperationIntegrityPoll, OperationRead:
+	case OperationEventPoll, OperationReadEvents:
+		for _, class := range x.Config.Classes {
+			if class < 1 || class > 3 {
+				return fmt.Errorf("invalid dnp3 event class: %d", class)
+			}
+		}
+	case OperationOperate:
+		switch x.Config.Mode {
+		case "":
+			x.Config.Mode = ModeSelectBeforeOperate
+		case ModeSelectBeforeOperate, ModeDirectOperate, ModeDirectOperateNoAck:
+		default:
+			return fmt.Errorf("unsupported dnp3 control mode: %s", x.Config.Mode)
+		}
+		for _, c := range x.Config.Commands {
+			if _, err = c.encode(nil); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported dnp3 operation: %s", x.Config.Operation)
+	}
+	config := MasterConfig{
+		Server:     x.Config.Server,
+		LocalAddr:  x.Config.LocalAddr,
+		RemoteAddr: x.Config.RemoteAddr,
+		Timeout:    time.Duration(x.Config.Timeout) * time.Second,
+		MaxEvents:  x.Config.MaxEvents,
+	}
+	key := fmt.Sprintf("%s/%d/%d", config.address(), config.LocalAddr, config.RemoteAddr)
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), key, ruleConfig.NodeClientInitNow, func() (*Master, error) {
+		return NewMaster(config), nil
+	}, func(master *Master) error {
+		if master != nil {
+			return master.Close()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *MasterNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	master, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var resp *Response
+	switch x.Config.Operation {
+	case OperationEventPoll:
+		resp, err = master.EventPoll(x.Config.Classes...)
+	case OperationReadEvents:
+		var dropped int
+		if resp, dropped, err = master.ReadEvents(x.Config.Classes...); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if x.Config.ClearRestart && resp.IIN&IINDeviceRestart != 0 {
+			if err = master.ClearRestart(); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+		}
+		x.tellResult(ctx, msg, map[string]interface{}{"iin": resp.IIN.Names(), "points": resp.Points, "dropped": dropped})
+		return
+	case OperationRead:
+		resp, err = master.Read(x.Config.Group, x.Config.Variation, x.Config.Start, x.Config.Stop)
+	case OperationOperate:
+		commands := x.Config.Commands
+		if len(commands) == 0 {
+			if commands, err = parseCommandData(msg.GetData()); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+		}
+		if resp, err = master.Operate(x.Config.Mode, commands...); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		statuses := make([]string, 0, len(resp.Statuses))
+		for _, status := range resp.Statuses {
+			statuses = append(statuses, status.String())
+		}
+		x.tellResult(ctx, msg, map[string]interface{}{"iin": resp.IIN.Names(), "statuses": statuses})
+		return
+	default:
+		resp, err = master.IntegrityPoll()
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if x.Config.ClearRestart && resp.IIN&IINDeviceRestart != 0 {
+		if err = master.ClearRestart(); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	x.tellResult(ctx, msg, map[string]interface{}{"iin": resp.IIN.Names(), "points": resp.Points})
+}
+
+func (x *MasterNode) tellResult(ctx types.RuleContext, msg types.RuleMsg, result map[string]interface{}) {
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *MasterNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *MasterNode) Desc() string {
+	return "DNP3 master for integrity/event polls, time-ordered sequence-of-events reads, object reads and CROB/analog output commands. Routes to Success/Failure"
+}
+
+// parseCommandData 解析消息负荷中的命令或命令数组
+func parseCommandData(data string) ([]Command, error) {
+	data = strings.TrimSpace(data)
+	var commands []Command
+	if strings.HasPrefix(data, "[") {
+		if err := json.Unmarshal([]byte(data), &commands); err != nil {
+			return nil, fmt.Errorf("invalid dnp3 commands: %w", err)
+		}
+	} else {
+		var c Command
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			return nil, fmt.Errorf("invalid dnp3 command: %w", err)
+		}
+		commands = append(commands, c)
+	}
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("no dnp3 commands")
+	}
+	return commands, nil
+}
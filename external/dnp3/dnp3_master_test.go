@@ -0,0 +1,386 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// waitIIN 等待从站处理主站的确认，确认是异步发送的，读响应返回时从站可能还没有清除事件
+func waitIIN(t *testing.T, o *Outstation, want IIN) {
+	deadline := time.Now().Add(time.Second)
+	for o.IIN() != want && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, want, o.IIN())
+}
+
+// startOutstation 在随机端口启动从站
+func startOutstation(t *testing.T, config OutstationConfig) (*Outstation, string) {
+	o, err := NewOutstation(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = o.Serve(l)
+	}()
+	return o, l.Addr().String()
+}
+
+func testOutstationConfig() OutstationConfig {
+	return OutstationConfig{
+		LocalAddr:        DefaultOutstationAddr,
+		BinaryInputs:     4,
+		BinaryOutputs:    2,
+		Counters:         2,
+		AnalogInputs:     3,
+		AnalogOutputs:    2,
+		BinaryInputClass: 1,
+		CounterClass:     3,
+		AnalogInputClass: 2,
+		AnalogDeadband:   0.5,
+	}
+}
+
+func TestMasterOutstation(t *testing.T) {
+	o, server := startOutstation(t, testOutstationConfig())
+	defer o.Close()
+	commands := make(chan CommandEvent, 4)
+	o.OnCommand = func(event CommandEvent) {
+		commands <- event
+	}
+	master := NewMaster(MasterConfig{Server: server, LocalAddr: DefaultMasterAddr, RemoteAddr: DefaultOutstationAddr, Timeout: time.Second})
+	defer master.Close()
+
+	assert.Nil(t, o.Update(AnalogInput, 1, 12.5))
+	assert.Nil(t, o.Update(BinaryInput, 2, true))
+	assert.NotNil(t, o.Update(AnalogInput, 3, 1))
+	assert.NotNil(t, o.Update("string", 0, 1))
+	assert.NotNil(t, o.Update(Counter, 0, -1))
+
+	resp, err := master.IntegrityPoll()
+	assert.Nil(t, err)
+	assert.True(t, resp.IIN&IINDeviceRestart != 0)
+	assert.True(t, resp.IIN&IINClass1Events != 0)
+	// 2 个事件和 13 个静态值
+	assert.Equal(t, 15, len(resp.Points))
+	// 事件按照产生的顺序
+	assert.Equal(t, 12.5, resp.Points[0].Value)
+	assert.Equal(t, Point{Type: BinaryInput, Index: 2, Value: true, Flags: FlagOnline, Timestamp: resp.Points[1].Timestamp, Event: true, Group: 2, Variation: 2}, resp.Points[1])
+	assert.True(t, resp.Points[1].Timestamp > 0)
+	assert.Equal(t, Point{Type: AnalogInput, Index: 1, Value: 12.5, Flags: FlagOnline, Group: 30, Variation: 5}, resp.Points[2+4+2+2+1])
+	assert.Equal(t, uint8(40), resp.Points[14].Group)
+
+	assert.Nil(t, master.ClearRestart())
+	// 事件已经确认
+	resp, err = master.EventPoll()
+	assert.Nil(t, err)
+	assert.Equal(t, IIN(0), resp.IIN)
+	assert.Equal(t, 0, len(resp.Points))
+
+	// 死区和等级
+	assert.Nil(t, o.Update(AnalogInput, 1, 12.75))
+	assert.Nil(t, o.Update(AnalogInput, 1, 13.25))
+	assert.Nil(t, o.Update(Counter, 0, 100))
+	assert.Nil(t, o.Update(Counter, 0, 100))
+	assert.Equal(t, IINClass2Events|IINClass3Events, o.IIN())
+	resp, err = master.EventPoll(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resp.Points))
+	assert.Equal(t, uint32(100), resp.Points[0].Value)
+	assert.Equal(t, uint8(22), resp.Points[0].Group)
+	resp, err = master.Read(32, 0, -1, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resp.Points))
+	assert.Equal(t, 13.25, resp.Points[0].Value)
+	_, err = master.EventPoll(4)
+	assert.NotNil(t, err)
+
+	// 读取对象组
+	resp, err = master.Read(30, 1, 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(resp.Points))
+	assert.Equal(t, float64(13), resp.Points[0].Value)
+	assert.Equal(t, uint8(1), resp.Points[0].Variation)
+	_, err = master.Read(30, 0, 0, 9)
+	var iinErr *IINError
+	assert.True(t, errors.As(err, &iinErr))
+	assert.True(t, iinErr.IIN&IINParameterError != 0)
+	_, err = master.Read(110, 0, -1, -1)
+	assert.True(t, errors.As(err, &iinErr))
+	assert.True(t, iinErr.IIN&IINObjectUnknown != 0)
+
+	// 控制
+	resp, err = master.Operate(ModeSelectBeforeOperate,
+		Command{Type: BinaryOutput, Index: 1, Code: "latchOn"},
+		Command{Type: AnalogOutput, Index: 0, Value: 42})
+	assert.Nil(t, err)
+	assert.Equal(t, []CommandStatus{StatusSuccess, StatusSuccess}, resp.Statuses)
+	event := <-commands
+	assert.Equal(t, "operate", event.Function)
+	assert.Equal(t, uint16(DefaultMasterAddr), event.MasterAddr)
+	assert.Equal(t, 2, len(event.Commands))
+	assert.Equal(t, "latchOn", event.Commands[0].Code)
+	p, err := o.Get(BinaryOutput, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, true, p.Value)
+	p, _ = o.Get(AnalogOutput, 0)
+	assert.Equal(t, float64(42), p.Value)
+
+	_, err = master.Operate(ModeDirectOperate, Command{Type: AnalogOutput, Index: 1, Value: 2.5})
+	assert.Nil(t, err)
+	event = <-commands
+	assert.Equal(t, ModeDirectOperate, event.Function)
+	assert.Equal(t, 2.5, event.Commands[0].Value)
+
+	_, err = master.Operate(ModeDirectOperateNoAck, Command{Type: BinaryOutput, Index: 1, Code: "trip"})
+	assert.Nil(t, err)
+	event = <-commands
+	assert.Equal(t, "trip", event.Commands[0].Code)
+
+	resp, err = master.Operate(ModeDirectOperate, Command{Type: BinaryOutput, Index: 5, Code: "latchOn"})
+	var cmdErr *CommandError
+	assert.True(t, errors.As(err, &cmdErr))
+	assert.Equal(t, StatusNotSupported, cmdErr.Status)
+	_, err = master.Operate("toggle", Command{Type: BinaryOutput, Index: 1, Code: "latchOn"})
+	assert.NotNil(t, err)
+	_, err = master.Operate(ModeDirectOperate)
+	assert.NotNil(t, err)
+	select {
+	case event = <-commands:
+		t.Fatalf("unexpected command: %+v", event)
+	default:
+	}
+
+	// 没有选择直接执行
+	objects, _ := Command{Type: BinaryOutput, Index: 0, Code: "latchOn"}.encode(nil)
+	_, err = master.command(FuncOperate, objects, []Command{{Type: BinaryOutput}})
+	assert.True(t, errors.As(err, &cmdErr))
+	assert.Equal(t, StatusNoSelect, cmdErr.Status)
+
+	// 从站重启后重新连接
+	_ = o.Close()
+	o2, err := NewOutstation(testOutstationConfig())
+	assert.Nil(t, err)
+	l, err := net.Listen("tcp", server)
+	assert.Nil(t, err)
+	go func() {
+		_ = o2.Serve(l)
+	}()
+	defer o2.Close()
+	resp, err = master.IntegrityPoll()
+	assert.Nil(t, err)
+	assert.True(t, resp.IIN&IINDeviceRestart != 0)
+}
+
+func TestMultiFragment(t *testing.T) {
+	config := testOutstationConfig()
+	config.AnalogInputs = 1000
+	config.AnalogInputClass = 1
+	config.MaxEvents = 500
+	o, server := startOutstation(t, config)
+	defer o.Close()
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, o.Update(AnalogInput, i, float64(i)))
+	}
+	assert.Equal(t, IINClass1Events|IINDeviceRestart|IINEventBufferOverflow, o.IIN())
+
+	master := NewMaster(MasterConfig{Server: server, LocalAddr: DefaultMasterAddr, RemoteAddr: DefaultOutstationAddr, Timeout: time.Second})
+	defer master.Close()
+	resp, err := master.IntegrityPoll()
+	assert.Nil(t, err)
+	// 第一个值为 0，没有产生事件，缓冲区保留最后 500 个事件
+	assert.Equal(t, 500+4+2+2+1000+2, len(resp.Points))
+	assert.Equal(t, 500, resp.Points[0].Index)
+	assert.Equal(t, float64(999), resp.Points[499].Value)
+	for i := 0; i < 1000; i++ {
+		p := resp.Points[500+4+2+2+i]
+		assert.Equal(t, i, p.Index)
+		assert.Equal(t, float64(i), p.Value)
+	}
+	waitIIN(t, o, IINDeviceRestart)
+}
+
+func TestReadEvents(t *testing.T) {
+	config := testOutstationConfig()
+	config.MaxEvents = 3
+	o, server := startOutstation(t, config)
+	defer o.Close()
+	master := NewMaster(MasterConfig{Server: server, LocalAddr: DefaultMasterAddr, RemoteAddr: DefaultOutstationAddr, Timeout: time.Second, MaxEvents: 4})
+	defer master.Close()
+	_, _, err := master.ReadEvents(4)
+	assert.NotNil(t, err)
+
+	// 链路中断期间产生的事件超过从站缓冲区
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, o.Update(BinaryInput, i, true))
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.Nil(t, o.Update(AnalogInput, 0, 5))
+	// 之前收到的非请求响应中的事件，时标早于从站缓存的事件
+	early := Point{Type: BinaryInput, Index: 0, Value: true, Timestamp: Now() - 60000, Event: true, Group: 2, Variation: 2}
+	master.addEvents([]Point{{Type: AnalogInput, Index: 0, Value: 1.0, Group: 30, Variation: 5}, early})
+
+	resp, dropped, err := master.ReadEvents()
+	assert.Nil(t, err)
+	assert.True(t, resp.IIN&IINEventBufferOverflow != 0)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, 4, len(resp.Points))
+	assert.Equal(t, early, resp.Points[0])
+	assert.Equal(t, 2, resp.Points[1].Index)
+	assert.Equal(t, 3, resp.Points[2].Index)
+	assert.Equal(t, AnalogInput, resp.Points[3].Type)
+	for i := 1; i < len(resp.Points); i++ {
+		assert.True(t, resp.Points[i].Timestamp >= resp.Points[i-1].Timestamp)
+	}
+	waitIIN(t, o, IINDeviceRestart)
+
+	// 主站事件缓存已满时丢弃最早的事件
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, o.Update(BinaryInput, i, false))
+	}
+	master.addEvents([]Point{early, early})
+	resp, dropped, err = master.ReadEvents(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 4, len(resp.Points))
+	assert.Equal(t, early, resp.Points[0])
+	assert.Equal(t, 0, resp.Points[1].Index)
+
+	resp, dropped, err = master.ReadEvents()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, 0, len(resp.Points))
+}
+
+func TestMasterNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&MasterNode{})
+
+	for _, configuration := range []types.Configuration{
+		{"operation": "write"},
+		{"operation": "eventPoll", "classes": []int{0}},
+		{"operation": "readEvents", "classes": []int{4}},
+		{"operation": "operate", "mode": "toggle"},
+		{"operation": "operate", "commands": []map[string]interface{}{{"type": "binaryOutput", "code": "open"}}},
+	} {
+		_, err := test.CreateAndInitNode("x/dnp3Master", configuration, Registry)
+		assert.NotNil(t, err)
+	}
+
+	o, server := startOutstation(t, testOutstationConfig())
+	defer o.Close()
+	assert.Nil(t, o.Update(AnalogInput, 0, 21.5))
+
+	pollNode, err := test.CreateAndInitNode("x/dnp3Master", types.Configuration{"server": server}, Registry)
+	assert.Nil(t, err)
+	defer pollNode.Destroy()
+	readNode, err := test.CreateAndInitNode("x/dnp3Master", types.Configuration{
+		"server":    server,
+		"operation": "read",
+		"group":     30,
+		"start":     0,
+		"stop":      0,
+	}, Registry)
+	assert.Nil(t, err)
+	defer readNode.Destroy()
+	operateNode, err := test.CreateAndInitNode("x/dnp3Master", types.Configuration{
+		"server":    server,
+		"operation": "operate",
+		"mode":      "directOperate",
+	}, Registry)
+	assert.Nil(t, err)
+	defer operateNode.Destroy()
+
+	// send 发送消息并等待回调完成，之后的断言和请求依赖上一个请求的结果
+	send := func(node types.Node, data string, callback func(msg types.RuleMsg, relationType string, err error)) {
+		done := make(chan struct{})
+		test.NodeOnMsg(t, node, []test.Msg{{MetaData: types.NewMetadata(), DataType: types.JSON, MsgType: "TEST", Data: data}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer close(done)
+			callback(msg, relationType, err)
+		})
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for dnp3 master node")
+		}
+	}
+	var result map[string]interface{}
+	send(pollNode, "{}", func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, []interface{}{"class2Events", "deviceRestart"}, result["iin"])
+		assert.Equal(t, 14, len(result["points"].([]interface{})))
+	})
+	// 重启指示位已经清除
+	assert.Equal(t, IIN(0), o.IIN())
+
+	send(readNode, "{}", func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		points := result["points"].([]interface{})
+		assert.Equal(t, 1, len(points))
+		assert.Equal(t, 21.5, points[0].(map[string]interface{})["value"])
+	})
+
+	eventsNode, err := test.CreateAndInitNode("x/dnp3Master", types.Configuration{
+		"server":    server,
+		"operation": "readEvents",
+		"classes":   []int{1},
+	}, Registry)
+	assert.Nil(t, err)
+	defer eventsNode.Destroy()
+	assert.Nil(t, o.Update(BinaryInput, 3, true))
+	assert.Nil(t, o.Update(BinaryInput, 1, true))
+	send(eventsNode, "{}", func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		points := result["points"].([]interface{})
+		assert.Equal(t, 2, len(points))
+		assert.Equal(t, float64(3), points[0].(map[string]interface{})["index"])
+		assert.Equal(t, float64(1), points[1].(map[string]interface{})["index"])
+		assert.Equal(t, float64(0), result["dropped"])
+	})
+
+	send(operateNode, `[{"type": "binaryOutput", "index": 0, "code": "latchOn"}, {"type": "analogOutput", "index": 1, "value": 3}]`, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, []interface{}{"success", "success"}, result["statuses"])
+	})
+	p, _ := o.Get(AnalogOutput, 1)
+	assert.Equal(t, float64(3), p.Value)
+
+	send(operateNode, `{"type": "binaryOutput", "index": 9, "code": "latchOn"}`, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+	})
+	send(operateNode, `[]`, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
@@ -0,0 +1,318 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// 链路层
+const (
+	linkStart1 = 0x05
+	linkStart2 = 0x64
+	// linkHeaderSize 链路头大小，包括 CRC
+	linkHeaderSize = 10
+	// linkBlockSize 用户数据每块的大小，每块后面跟 CRC
+	linkBlockSize = 16
+	// maxLinkData 每帧最大用户数据
+	maxLinkData = 250
+	// maxTransportData 每个传输层分段最大的应用层数据
+	maxTransportData = maxLinkData - 1
+)
+
+// 链路控制字节
+const (
+	linkDir = 0x80
+	linkPrm = 0x40
+	linkFcb = 0x20
+	linkFcv = 0x10
+)
+
+// 链路功能码
+const (
+	// 主站方向
+	linkResetLinkStates     = 0x00
+	linkTestLinkStates      = 0x02
+	linkConfirmedUserData   = 0x03
+	linkUnconfirmedUserData = 0x04
+	linkRequestLinkStatus   = 0x09
+	// 从站方向
+	linkAck          = 0x00
+	linkNack         = 0x01
+	linkStatus       = 0x0b
+	linkNotSupported = 0x0f
+)
+
+// 传输层头
+const (
+	transportFin = 0x80
+	transportFir = 0x40
+	transportSeq = 0x3f
+)
+
+// ErrCRC 链路帧 CRC 校验失败
+var ErrCRC = errors.New("dnp3: crc error")
+
+// crcTable DNP3 CRC-16，多项式 0x3D65，反射
+var crcTable = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xa6bc
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// CRC 计算 DNP3 CRC
+func CRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc = crc>>8 ^ crcTable[byte(crc)^b]
+	}
+	return ^crc
+}
+
+func appendCRC(b []byte, data []byte) []byte {
+	crc := CRC(data)
+	return append(b, byte(crc), byte(crc>>8))
+}
+
+// frame 链路帧
+type frame struct {
+	control uint8
+	dest    uint16
+	src     uint16
+	data    []byte
+}
+
+func (f *frame) function() uint8 {
+	return f.control & 0x0f
+}
+
+func (f *frame) primary() bool {
+	return f.control&linkPrm != 0
+}
+
+// marshal 编码链路帧，用户数据每 16 字节一块，每块带 CRC
+func (f *frame) marshal() []byte {
+	b := make([]byte, 0, linkHeaderSize+len(f.data)+(len(f.data)+linkBlockSize-1)/linkBlockSize*2)
+	b = append(b, linkStart1, linkStart2, byte(5+len(f.data)), f.control)
+	b = binary.LittleEndian.AppendUint16(b, f.dest)
+	b = binary.LittleEndian.AppendUint16(b, f.src)
+	b = appendCRC(b, b)
+	for i := 0; i < len(f.data); i += linkBlockSize {
+		block := f.data[i:min(i+linkBlockSize, len(f.data))]
+		b = append(b, block...)
+		b = appendCRC(b, block)
+	}
+	return b
+}
+
+// readFrame 读取一个链路帧，跳过起始字节之前的数据
+func readFrame(r *bufio.Reader) (*frame, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != linkStart1 {
+			continue
+		}
+		if next, err := r.Peek(1); err != nil {
+			return nil, err
+		} else if next[0] != linkStart2 {
+			continue
+		}
+		header := make([]byte, linkHeaderSize)
+		header[0] = linkStart1
+		if _, err = io.ReadFull(r, header[1:]); err != nil {
+			return nil, err
+		}
+		if binary.LittleEndian.Uint16(header[8:]) != CRC(header[:8]) {
+			return nil, ErrCRC
+		}
+		if header[2] < 5 {
+			return nil, fmt.Errorf("dnp3: invalid link length %d", header[2])
+		}
+		f := &frame{
+			control: header[3],
+			dest:    binary.LittleEndian.Uint16(header[4:]),
+			src:     binary.LittleEndian.Uint16(header[6:]),
+		}
+		remaining := int(header[2]) - 5
+		f.data = make([]byte, 0, remaining)
+		block := make([]byte, linkBlockSize+2)
+		for remaining > 0 {
+			n := min(remaining, linkBlockSize)
+			if _, err = io.ReadFull(r, block[:n+2]); err != nil {
+				return nil, err
+			}
+			if binary.LittleEndian.Uint16(block[n:]) != CRC(block[:n]) {
+				return nil, ErrCRC
+			}
+			f.data = append(f.data, block[:n]...)
+			remaining -= n
+		}
+		return f, nil
+	}
+}
+
+// channel 一个 TCP 连接上的链路层和传输层，发送的都是不需要链路确认的用户数据
+type channel struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	// master 是否为主站，决定 DIR 位
+	master bool
+	local  uint16
+	// remote 对端地址，anyRemote 为 true 时使用最后收到的报文的源地址
+	remote    uint16
+	anyRemote bool
+	txSeq     uint8
+	// 传输层重组
+	rx       []byte
+	rxSeq    uint8
+	rxActive bool
+}
+
+func newChannel(conn net.Conn, master bool, local, remote uint16, anyRemote bool) *channel {
+	return &channel{
+		conn:      conn,
+		reader:    bufio.NewReader(conn),
+		master:    master,
+		local:     local,
+		remote:    remote,
+		anyRemote: anyRemote,
+	}
+}
+
+func (c *channel) dir() uint8 {
+	if c.master {
+		return linkDir
+	}
+	return 0
+}
+
+// send 分段发送应用层报文
+func (c *channel) send(apdu []byte, timeout time.Duration) error {
+	var b []byte
+	for i := 0; i == 0 || i < len(apdu); i += maxTransportData {
+		end := min(i+maxTransportData, len(apdu))
+		header := c.txSeq & transportSeq
+		if i == 0 {
+			header |= transportFir
+		}
+		if end == len(apdu) {
+			header |= transportFin
+		}
+		c.txSeq++
+		f := frame{
+			control: c.dir() | linkPrm | linkUnconfirmedUserData,
+			dest:    c.remote,
+			src:     c.local,
+			data:    append([]byte{header}, apdu[i:end]...),
+		}
+		b = append(b, f.marshal()...)
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(timeout))
+	_, err := c.conn.Write(b)
+	return err
+}
+
+// receive 读取一个完整的应用层报文，同时响应链路层服务请求
+// deadline 为零值表示不超时
+func (c *channel) receive(deadline time.Time) ([]byte, error) {
+	_ = c.conn.SetReadDeadline(deadline)
+	for {
+		f, err := readFrame(c.reader)
+		if err != nil {
+			return nil, err
+		}
+		if f.dest != c.local || (!c.anyRemote && f.src != c.remote) {
+			continue
+		}
+		if !f.primary() {
+			continue
+		}
+		if c.anyRemote {
+			c.remote = f.src
+		}
+		switch f.function() {
+		case linkResetLinkStates, linkTestLinkStates:
+			err = c.reply(linkAck)
+		case linkRequestLinkStatus:
+			err = c.reply(linkStatus)
+		case linkConfirmedUserData:
+			if err = c.reply(linkAck); err == nil {
+				if apdu := c.reassemble(f.data); apdu != nil {
+					return apdu, nil
+				}
+			}
+		case linkUnconfirmedUserData:
+			if apdu := c.reassemble(f.data); apdu != nil {
+				return apdu, nil
+			}
+		default:
+			err = c.reply(linkNotSupported)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reply 回复链路层从站帧
+func (c *channel) reply(function uint8) error {
+	f := frame{control: c.dir() | function, dest: c.remote, src: c.local}
+	_, err := c.conn.Write(f.marshal())
+	return err
+}
+
+// reassemble 传输层重组，报文完整时返回应用层报文
+func (c *channel) reassemble(tpdu []byte) []byte {
+	if len(tpdu) == 0 {
+		return nil
+	}
+	header, seq := tpdu[0], tpdu[0]&transportSeq
+	if header&transportFir != 0 {
+		c.rx = append(c.rx[:0], tpdu[1:]...)
+		c.rxActive = true
+	} else if c.rxActive && seq == (c.rxSeq+1)&transportSeq {
+		c.rx = append(c.rx, tpdu[1:]...)
+	} else {
+		c.rxActive = false
+		return nil
+	}
+	c.rxSeq = seq
+	if header&transportFin == 0 {
+		return nil
+	}
+	c.rxActive = false
+	return append([]byte(nil), c.rx...)
+}
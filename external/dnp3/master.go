@@ -0,0 +1,401 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+	"github.com/rulego/rulego-components-iot/pkg/soe"
+)
+
+const (
+	// DefaultPort DNP3 TCP 端口
+	DefaultPort = "20000"
+	// DefaultMasterAddr 默认主站链路地址
+	DefaultMasterAddr = 1
+	// DefaultOutstationAddr 默认从站链路地址
+	DefaultOutstationAddr = 1024
+	// DefaultTimeout 默认响应超时
+	DefaultTimeout = 5 * time.Second
+	// maxEventRounds 读取事件时最多连续轮询的次数，防止从站持续产生事件时不能返回
+	maxEventRounds = 16
+)
+
+// 控制模式
+const (
+	// ModeSelectBeforeOperate 先选择后执行
+	ModeSelectBeforeOperate = "selectBeforeOperate"
+	// ModeDirectOperate 直接执行
+	ModeDirectOperate = "directOperate"
+	// ModeDirectOperateNoAck 直接执行，从站不响应
+	ModeDirectOperateNoAck = "directOperateNoAck"
+)
+
+// ErrClosed 主站已经关闭
+var ErrClosed = errors.New("dnp3: master closed")
+
+// CommandError 控制命令被从站拒绝
+type CommandError struct {
+	Command Command
+	Status  CommandStatus
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("dnp3: %s %d command failed: %s", e.Command.Type, e.Command.Index, e.Status)
+}
+
+// MasterConfig 主站配置
+type MasterConfig struct {
+	// Server 从站地址，格式：host:port，端口默认 20000
+	Server string
+	// LocalAddr 主站链路地址
+	LocalAddr uint16
+	// RemoteAddr 从站链路地址
+	RemoteAddr uint16
+	// Timeout 连接和响应超时
+	Timeout time.Duration
+	// MaxEvents 最多缓存的事件数量，超过时丢弃最早的事件，0 使用 soe.DefaultCapacity
+	MaxEvents int
+}
+
+// address 补全端口的从站地址
+func (c MasterConfig) address() string {
+	server := strings.TrimPrefix(c.Server, "tcp://")
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, DefaultPort)
+	}
+	return server
+}
+
+// Response 从站响应
+type Response struct {
+	IIN IIN
+	// Points 读取的点位，包括静态值和事件
+	Points []Point
+	// Statuses 控制命令的状态，顺序和命令一致
+	Statuses []CommandStatus
+}
+
+// Master DNP3 TCP 主站，请求是串行的，可以并发调用
+// 连接在第一次请求时建立，通信出错时关闭，下一次请求重新连接。
+// 请求期间收到的非请求响应（unsolicited）中的事件写入事件缓存，由 ReadEvents 按时标顺序取出
+type Master struct {
+	config MasterConfig
+	mu     sync.Mutex
+	conn   net.Conn
+	ch     *channel
+	seq    uint8
+	closed bool
+	// events 事件缓存
+	events *soe.Buffer[Point]
+}
+
+// NewMaster 创建主站
+func NewMaster(config MasterConfig) *Master {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	return &Master{config: config, events: soe.NewBuffer[Point](config.MaxEvents)}
+}
+
+// Close 关闭连接
+func (m *Master) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return m.disconnect()
+}
+
+// IntegrityPoll 完整性轮询，读取所有事件和静态值
+func (m *Master) IntegrityPoll() (*Response, error) {
+	var b []byte
+	for _, v := range []uint8{2, 3, 4, 1} {
+		b = appendHeader(b, ObjectHeader{Group: 60, Variation: v, Qualifier: QualifierAll})
+	}
+	return m.read(b)
+}
+
+// EventPoll 事件轮询，读取指定等级的事件，默认读取 1、2、3 级事件
+func (m *Master) EventPoll(classes ...int) (*Response, error) {
+	if len(classes) == 0 {
+		classes = []int{1, 2, 3}
+	}
+	var b []byte
+	for _, class := range classes {
+		if class < 1 || class > 3 {
+			return nil, fmt.Errorf("dnp3: invalid event class %d", class)
+		}
+		b = appendHeader(b, ObjectHeader{Group: 60, Variation: uint8(class + 1), Qualifier: QualifierAll})
+	}
+	return m.read(b)
+}
+
+// ReadEvents 读取事件顺序记录（SOE）：轮询指定等级的事件（默认 1、2、3 级），从站 IIN 仍然指示有事件时继续轮询，
+// 从站缓存的事件（包括链路中断期间产生的）和之前收到的非请求响应中的事件一起按设备原始时标排序输出，没有时标的事件按接收时间排序。
+// 返回的 Response 中 IIN 为最后一次轮询的指示位，IINEventBufferOverflow 表示从站的事件缓冲区已经溢出；
+// dropped 为主站事件缓存已满丢弃的事件数量。轮询出错时已经收到的事件保留在缓存中，下一次读取时返回
+func (m *Master) ReadEvents(classes ...int) (resp *Response, dropped int, err error) {
+	if len(classes) == 0 {
+		classes = []int{1, 2, 3}
+	}
+	var pending IIN
+	for _, class := range classes {
+		if class < 1 || class > 3 {
+			return nil, 0, fmt.Errorf("dnp3: invalid event class %d", class)
+		}
+		pending |= IINClass1Events << (class - 1)
+	}
+	var iin IIN
+	for round := 0; round < maxEventRounds; round++ {
+		poll, err := m.EventPoll(classes...)
+		if err != nil {
+			return nil, 0, err
+		}
+		// 保留任意一次轮询的缓冲区溢出指示
+		iin = iin&IINEventBufferOverflow | poll.IIN
+		n := m.addEvents(poll.Points)
+		// 事件在主站确认后才从从站删除，响应中的 IIN 仍然包括本次读取的事件
+		if n == 0 || poll.IIN&pending == 0 {
+			break
+		}
+	}
+	points, dropped := m.events.Drain()
+	return &Response{IIN: iin, Points: points}, dropped, nil
+}
+
+// addEvents 事件写入事件缓存，返回事件数量
+func (m *Master) addEvents(points []Point) int {
+	now := time.Now()
+	n := 0
+	for _, p := range points {
+		if p.Event {
+			m.events.Add(p, p.Timestamp, now)
+			n++
+		}
+	}
+	return n
+}
+
+// Read 读取对象组，variation 为 0 表示从站默认变体，start 小于 0 表示读取所有点位
+func (m *Master) Read(group, variation uint8, start, stop int) (*Response, error) {
+	h := ObjectHeader{Group: group, Variation: variation, Qualifier: QualifierAll}
+	if start >= 0 {
+		if stop < start || stop > 0xffff {
+			return nil, fmt.Errorf("dnp3: invalid range %d-%d", start, stop)
+		}
+		h.Qualifier, h.Start, h.Stop = QualifierStartStop16, uint32(start), uint32(stop)
+		if stop <= 0xff {
+			h.Qualifier = QualifierStartStop8
+		}
+	}
+	return m.read(appendHeader(nil, h))
+}
+
+// ClearRestart 清除从站的 DEVICE_RESTART 指示位
+func (m *Master) ClearRestart() error {
+	b := appendHeader(nil, ObjectHeader{Group: 80, Variation: 1, Qualifier: QualifierStartStop8, Start: iinRestartIndex, Stop: iinRestartIndex})
+	_, err := m.request(FuncWrite, append(b, 0x00))
+	return err
+}
+
+// Operate 执行控制命令，mode 为 selectBeforeOperate、directOperate 或者 directOperateNoAck
+// 任意一个命令的状态不是 success 时返回 CommandError
+func (m *Master) Operate(mode string, commands ...Command) (*Response, error) {
+	if len(commands) == 0 {
+		return nil, errors.New("dnp3: no commands")
+	}
+	var b []byte
+	var err error
+	for _, c := range commands {
+		if b, err = c.encode(b); err != nil {
+			return nil, err
+		}
+	}
+	switch mode {
+	case ModeSelectBeforeOperate, "":
+		resp, err := m.command(FuncSelect, b, commands)
+		if err != nil {
+			return resp, err
+		}
+		return m.command(FuncOperate, b, commands)
+	case ModeDirectOperate:
+		return m.command(FuncDirectOperate, b, commands)
+	case ModeDirectOperateNoAck:
+		_, err = m.request(FuncDirectOperateNoAck, b)
+		return &Response{}, err
+	default:
+		return nil, fmt.Errorf("dnp3: unsupported control mode %s", mode)
+	}
+}
+
+// read 发送读取请求并解码点位
+func (m *Master) read(objects []byte) (*Response, error) {
+	resp, err := m.request(FuncRead, objects)
+	if err != nil {
+		return nil, err
+	}
+	points, err := ParseObjects(resp.Objects)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{IIN: resp.IIN, Points: points}, nil
+}
+
+// command 发送控制请求并检查每个命令的状态
+func (m *Master) command(function FunctionCode, objects []byte, commands []Command) (*Response, error) {
+	resp, err := m.request(function, objects)
+	if err != nil {
+		return nil, err
+	}
+	echo, err := parseCommands(resp.Objects)
+	if err != nil {
+		return nil, err
+	}
+	if len(echo) != len(commands) {
+		return nil, fmt.Errorf("dnp3: expected %d command objects in response, got %d", len(commands), len(echo))
+	}
+	result := &Response{IIN: resp.IIN}
+	for i, o := range echo {
+		result.Statuses = append(result.Statuses, o.status)
+		if o.status != StatusSuccess && err == nil {
+			err = &CommandError{Command: commands[i], Status: o.status}
+		}
+	}
+	return result, err
+}
+
+// request 发送请求并读取响应，多个分片的响应合并对象数据
+// 复用的连接出错时重新连接并重试一次
+func (m *Master) request(function FunctionCode, objects []byte) (*APDU, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	reused := m.ch != nil
+	resp, err := m.exchange(function, objects)
+	var netErr net.Error
+	if err != nil && reused && m.ch == nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		resp, err = m.exchange(function, objects)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.IIN&errorBits != 0 {
+		return resp, &IINError{IIN: resp.IIN}
+	}
+	return resp, nil
+}
+
+// exchange 一次请求响应，调用方需持有锁，通信出错时关闭连接
+func (m *Master) exchange(function FunctionCode, objects []byte) (*APDU, error) {
+	if err := m.connect(); err != nil {
+		return nil, err
+	}
+	seq := m.seq
+	m.seq = (m.seq + 1) & appSeq
+	req := APDU{Control: appFir | appFin | seq, Function: function, Objects: objects}
+	if err := m.ch.send(req.marshal(), m.config.Timeout); err != nil {
+		_ = m.disconnect()
+		return nil, err
+	}
+	if function == FuncDirectOperateNoAck {
+		return &APDU{Function: FuncResponse}, nil
+	}
+	deadline := time.Now().Add(m.config.Timeout)
+	result := &APDU{Function: FuncResponse}
+	started := false
+	for {
+		data, err := m.ch.receive(deadline)
+		if err != nil {
+			_ = m.disconnect()
+			return nil, err
+		}
+		resp, err := parseAPDU(data)
+		if err != nil {
+			continue
+		}
+		if resp.Function == FuncUnsolicitedResponse {
+			if points, err := ParseObjects(resp.Objects); err == nil {
+				m.addEvents(points)
+			}
+			if resp.Control&appCon != 0 {
+				err = m.confirm(resp.Seq(), true)
+			}
+		} else if resp.Function == FuncResponse && resp.Seq() == seq {
+			if resp.Control&appFir != 0 {
+				started = true
+				result.Objects = result.Objects[:0]
+			}
+			if !started {
+				continue
+			}
+			result.IIN = resp.IIN
+			result.Objects = append(result.Objects, resp.Objects...)
+			if resp.Control&appCon != 0 {
+				err = m.confirm(seq, false)
+			}
+			if err == nil && resp.Control&appFin != 0 {
+				return result, nil
+			}
+			// 后续分片的序号递增
+			seq = (seq + 1) & appSeq
+			deadline = time.Now().Add(m.config.Timeout)
+		}
+		if err != nil {
+			_ = m.disconnect()
+			return nil, err
+		}
+	}
+}
+
+// confirm 确认响应
+func (m *Master) confirm(seq uint8, unsolicited bool) error {
+	control := appFir | appFin | seq
+	if unsolicited {
+		control |= appUns
+	}
+	return m.ch.send((&APDU{Control: control, Function: FuncConfirm}).marshal(), m.config.Timeout)
+}
+
+func (m *Master) connect() error {
+	if m.ch != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", m.config.address(), m.config.Timeout)
+	if err != nil {
+		return err
+	}
+	m.conn = capture.WrapConn(conn, capture.Source("dnp3", m.config.address()))
+	m.ch = newChannel(m.conn, true, m.config.LocalAddr, m.config.RemoteAddr, false)
+	return nil
+}
+
+func (m *Master) disconnect() error {
+	if m.conn == nil {
+		return nil
+	}
+	err := m.conn.Close()
+	m.conn, m.ch = nil, nil
+	return err
+}
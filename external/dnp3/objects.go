@@ -0,0 +1,571 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// 点位类型
+const (
+	BinaryInput  = "binaryInput"
+	BinaryOutput = "binaryOutput"
+	Counter      = "counter"
+	AnalogInput  = "analogInput"
+	AnalogOutput = "analogOutput"
+)
+
+// 点位品质标志
+const (
+	FlagOnline       = 0x01
+	FlagRestart      = 0x02
+	FlagCommLost     = 0x04
+	FlagRemoteForced = 0x08
+	FlagLocalForced  = 0x10
+	FlagOverRange    = 0x20
+	FlagBinaryState  = 0x80
+)
+
+// Point 点位值
+type Point struct {
+	// Type 点位类型：binaryInput、binaryOutput、counter、analogInput、analogOutput
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	// Value 二进制点位为 bool，计数器为 uint32，模拟量为 float64
+	Value interface{} `json:"value"`
+	// Flags 品质标志，二进制点位的状态位（FlagBinaryState）只体现在 Value 中
+	Flags uint8 `json:"flags"`
+	// Timestamp 事件时间，Unix 毫秒，0 表示没有时间
+	Timestamp int64 `json:"timestamp,omitempty"`
+	// Event 是否为变化事件
+	Event     bool  `json:"event,omitempty"`
+	Group     uint8 `json:"group"`
+	Variation uint8 `json:"variation"`
+}
+
+// valueKind 对象中数值的编码
+type valueKind uint8
+
+const (
+	valueNone valueKind = iota
+	valueU16
+	valueU32
+	valueI16
+	valueI32
+	valueF32
+	valueF64
+)
+
+func (k valueKind) size() int {
+	switch k {
+	case valueU16, valueI16:
+		return 2
+	case valueU32, valueI32, valueF32:
+		return 4
+	case valueF64:
+		return 8
+	}
+	return 0
+}
+
+// layout 点位对象的结构：品质标志、数值、6 字节时间
+type layout struct {
+	pointType string
+	event     bool
+	flags     bool
+	value     valueKind
+	time      bool
+}
+
+func (l layout) size() int {
+	size := l.value.size()
+	if l.flags {
+		size++
+	}
+	if l.time {
+		size += 6
+	}
+	return size
+}
+
+func gv(group, variation uint8) uint16 {
+	return uint16(group)<<8 | uint16(variation)
+}
+
+// layouts 支持的点位对象
+var layouts = map[uint16]layout{
+	gv(1, 2):  {BinaryInput, false, true, valueNone, false},
+	gv(2, 1):  {BinaryInput, true, true, valueNone, false},
+	gv(2, 2):  {BinaryInput, true, true, valueNone, true},
+	gv(10, 2): {BinaryOutput, false, true, valueNone, false},
+	gv(20, 1): {Counter, false, true, valueU32, false},
+	gv(20, 2): {Counter, false, true, valueU16, false},
+	gv(20, 5): {Counter, false, false, valueU32, false},
+	gv(20, 6): {Counter, false, false, valueU16, false},
+	gv(22, 1): {Counter, true, true, valueU32, false},
+	gv(22, 2): {Counter, true, true, valueU16, false},
+	gv(22, 5): {Counter, true, true, valueU32, true},
+	gv(22, 6): {Counter, true, true, valueU16, true},
+	gv(30, 1): {AnalogInput, false, true, valueI32, false},
+	gv(30, 2): {AnalogInput, false, true, valueI16, false},
+	gv(30, 3): {AnalogInput, false, false, valueI32, false},
+	gv(30, 4): {AnalogInput, false, false, valueI16, false},
+	gv(30, 5): {AnalogInput, false, true, valueF32, false},
+	gv(30, 6): {AnalogInput, false, true, valueF64, false},
+	gv(32, 1): {AnalogInput, true, true, valueI32, false},
+	gv(32, 2): {AnalogInput, true, true, valueI16, false},
+	gv(32, 3): {AnalogInput, true, true, valueI32, true},
+	gv(32, 4): {AnalogInput, true, true, valueI16, true},
+	gv(32, 5): {AnalogInput, true, true, valueF32, false},
+	gv(32, 6): {AnalogInput, true, true, valueF64, false},
+	gv(32, 7): {AnalogInput, true, true, valueF32, true},
+	gv(32, 8): {AnalogInput, true, true, valueF64, true},
+	gv(40, 1): {AnalogOutput, false, true, valueI32, false},
+	gv(40, 2): {AnalogOutput, false, true, valueI16, false},
+	gv(40, 3): {AnalogOutput, false, true, valueF32, false},
+	gv(40, 4): {AnalogOutput, false, true, valueF64, false},
+}
+
+// packed 按位打包的对象
+var packed = map[uint16]string{
+	gv(1, 1):  BinaryInput,
+	gv(10, 1): BinaryOutput,
+	gv(80, 1): "",
+}
+
+// otherSizes 其他可以跳过的对象大小
+var otherSizes = map[uint16]int{
+	gv(12, 1): crobSize,
+	gv(41, 1): 5,
+	gv(41, 2): 3,
+	gv(41, 3): 5,
+	gv(41, 4): 9,
+	gv(50, 1): 6,
+	gv(51, 1): 6,
+	gv(51, 2): 6,
+	gv(52, 1): 2,
+	gv(52, 2): 2,
+}
+
+// appendTime 编码 48 位 Unix 毫秒时间
+func appendTime(b []byte, ms int64) []byte {
+	v := uint64(ms)
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24), byte(v>>32), byte(v>>40))
+}
+
+func decodeTime(b []byte) int64 {
+	return int64(uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 | uint64(b[4])<<32 | uint64(b[5])<<40)
+}
+
+// Now 当前 DNP3 时间，Unix 毫秒
+func Now() int64 {
+	return time.Now().UnixMilli()
+}
+
+// toFloat 点位值转换为 float64
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case bool:
+		if n {
+			return 1
+		}
+		return 0
+	case uint32:
+		return float64(n)
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
+
+// clamp 四舍五入并限制在整数范围内，超出范围时返回 false
+func clamp(v float64, lo, hi float64) (float64, bool) {
+	v = math.Round(v)
+	if math.IsNaN(v) || v < lo {
+		return lo, false
+	} else if v > hi {
+		return hi, false
+	}
+	return v, true
+}
+
+// encode 按照对象结构编码点位
+func (l layout) encode(b []byte, p Point) []byte {
+	flags := p.Flags
+	v := toFloat(p.Value)
+	if l.value == valueNone {
+		flags &^= FlagBinaryState
+		if v != 0 {
+			flags |= FlagBinaryState
+		}
+	}
+	var value []byte
+	ok := true
+	switch l.value {
+	case valueU16:
+		v, ok = clamp(v, 0, math.MaxUint16)
+		value = binary.LittleEndian.AppendUint16(nil, uint16(v))
+	case valueU32:
+		v, ok = clamp(v, 0, math.MaxUint32)
+		value = binary.LittleEndian.AppendUint32(nil, uint32(v))
+	case valueI16:
+		v, ok = clamp(v, math.MinInt16, math.MaxInt16)
+		value = binary.LittleEndian.AppendUint16(nil, uint16(int16(v)))
+	case valueI32:
+		v, ok = clamp(v, math.MinInt32, math.MaxInt32)
+		value = binary.LittleEndian.AppendUint32(nil, uint32(int32(v)))
+	case valueF32:
+		value = binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(v)))
+	case valueF64:
+		value = binary.LittleEndian.AppendUint64(nil, math.Float64bits(v))
+	}
+	if !ok && l.pointType != Counter {
+		flags |= FlagOverRange
+	}
+	if l.flags {
+		b = append(b, flags)
+	}
+	b = append(b, value...)
+	if l.time {
+		b = appendTime(b, p.Timestamp)
+	}
+	return b
+}
+
+// decode 解码点位对象，data 的长度为对象大小
+func (l layout) decode(data []byte) Point {
+	p := Point{Type: l.pointType, Event: l.event, Flags: FlagOnline}
+	if l.flags {
+		p.Flags, data = data[0], data[1:]
+	}
+	switch l.value {
+	case valueNone:
+		p.Value = p.Flags&FlagBinaryState != 0
+		p.Flags &^= FlagBinaryState
+	case valueU16:
+		p.Value = uint32(binary.LittleEndian.Uint16(data))
+	case valueU32:
+		p.Value = binary.LittleEndian.Uint32(data)
+	case valueI16:
+		p.Value = float64(int16(binary.LittleEndian.Uint16(data)))
+	case valueI32:
+		p.Value = float64(int32(binary.LittleEndian.Uint32(data)))
+	case valueF32:
+		p.Value = float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	case valueF64:
+		p.Value = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	}
+	if l.time {
+		p.Timestamp = decodeTime(data[l.value.size():])
+	}
+	return p
+}
+
+// objectIndexes 对象头中每个对象的索引，prefixed 表示索引在对象之前
+func objectIndexes(h ObjectHeader) (start uint32, count int, prefixed bool) {
+	switch h.Qualifier {
+	case QualifierStartStop8, QualifierStartStop16:
+		return h.Start, h.quantity(), false
+	case QualifierIndexCount8, QualifierIndexCount16:
+		return 0, h.quantity(), true
+	case QualifierCount8, QualifierCount16:
+		return 0, h.quantity(), false
+	}
+	return 0, 0, false
+}
+
+// readIndex 读取索引前缀
+func readIndex(b []byte, size int) (uint32, []byte, error) {
+	if len(b) < size {
+		return 0, nil, errShortObject
+	}
+	if size == 1 {
+		return uint32(b[0]), b[1:], nil
+	}
+	return uint32(binary.LittleEndian.Uint16(b)), b[2:], nil
+}
+
+// ParseObjects 解码响应中的点位对象，跳过时间等其他对象
+func ParseObjects(b []byte) ([]Point, error) {
+	points := []Point{}
+	for len(b) > 0 {
+		h, rest, err := parseHeader(b)
+		if err != nil {
+			return nil, err
+		}
+		b = rest
+		key := gv(h.Group, h.Variation)
+		start, count, prefixed := objectIndexes(h)
+		if pointType, ok := packed[key]; ok {
+			if prefixed || h.Qualifier == QualifierAll {
+				return nil, fmt.Errorf("dnp3: unsupported qualifier 0x%02x for g%dv%d", h.Qualifier, h.Group, h.Variation)
+			}
+			size := (count + 7) / 8
+			if len(b) < size {
+				return nil, errShortObject
+			}
+			for i := 0; i < count && pointType != ""; i++ {
+				points = append(points, Point{
+					Type:      pointType,
+					Index:     int(start) + i,
+					Value:     b[i/8]&(1<<(i%8)) != 0,
+					Flags:     FlagOnline,
+					Group:     h.Group,
+					Variation: h.Variation,
+				})
+			}
+			b = b[size:]
+			continue
+		}
+		l, isPoint := layouts[key]
+		size := l.size()
+		if !isPoint {
+			var ok bool
+			if size, ok = otherSizes[key]; !ok {
+				if h.Group == 60 {
+					continue
+				}
+				return nil, fmt.Errorf("dnp3: unsupported object g%dv%d", h.Group, h.Variation)
+			}
+		}
+		for i := 0; i < count; i++ {
+			index := start + uint32(i)
+			if prefixed {
+				if index, b, err = readIndex(b, h.indexSize()); err != nil {
+					return nil, err
+				}
+			}
+			if len(b) < size {
+				return nil, errShortObject
+			}
+			if isPoint {
+				p := l.decode(b[:size])
+				p.Index, p.Group, p.Variation = int(index), h.Group, h.Variation
+				points = append(points, p)
+			}
+			b = b[size:]
+		}
+	}
+	return points, nil
+}
+
+// 控制继电器输出块 (CROB) 控制码
+const (
+	ControlNul      = 0x00
+	ControlPulseOn  = 0x01
+	ControlPulseOff = 0x02
+	ControlLatchOn  = 0x03
+	ControlLatchOff = 0x04
+	ControlClose    = 0x41
+	ControlTrip     = 0x81
+)
+
+// crobSize g12v1 对象大小
+const crobSize = 11
+
+var controlCodes = map[string]uint8{
+	"nul":      ControlNul,
+	"pulseOn":  ControlPulseOn,
+	"pulseOff": ControlPulseOff,
+	"latchOn":  ControlLatchOn,
+	"latchOff": ControlLatchOff,
+	"close":    ControlClose,
+	"trip":     ControlTrip,
+}
+
+// ParseControlCode 解析控制码名称：nul、pulseOn、pulseOff、latchOn、latchOff、close、trip，不区分大小写
+func ParseControlCode(name string) (uint8, error) {
+	for k, v := range controlCodes {
+		if strings.EqualFold(k, name) {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("dnp3: unknown control code %s", name)
+}
+
+// ControlCodeName 控制码名称，忽略 queue 和 clear 位
+func ControlCodeName(code uint8) string {
+	for k, v := range controlCodes {
+		if v == code&^0x30 {
+			return k
+		}
+	}
+	return fmt.Sprintf("0x%02x", code)
+}
+
+// CommandStatus 控制命令状态
+type CommandStatus uint8
+
+const (
+	StatusSuccess       CommandStatus = 0
+	StatusTimeout       CommandStatus = 1
+	StatusNoSelect      CommandStatus = 2
+	StatusFormatError   CommandStatus = 3
+	StatusNotSupported  CommandStatus = 4
+	StatusAlreadyActive CommandStatus = 5
+	StatusHardwareError CommandStatus = 6
+	StatusLocal         CommandStatus = 7
+	StatusTooManyObjs   CommandStatus = 8
+	StatusNotAuthorized CommandStatus = 9
+)
+
+var statusNames = []string{"success", "timeout", "noSelect", "formatError", "notSupported",
+	"alreadyActive", "hardwareError", "local", "tooManyObjs", "notAuthorized"}
+
+func (s CommandStatus) String() string {
+	if int(s) < len(statusNames) {
+		return statusNames[s]
+	}
+	return fmt.Sprintf("status(%d)", uint8(s))
+}
+
+// Command 控制命令，binaryOutput 为 CROB（g12v1），analogOutput 为模拟量输出块（g41）
+type Command struct {
+	// Type 点位类型：binaryOutput、analogOutput
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	// Code CROB 控制码：pulseOn、pulseOff、latchOn、latchOff、close、trip
+	Code string `json:"code,omitempty"`
+	// Count CROB 脉冲次数，默认 1
+	Count uint8 `json:"count,omitempty"`
+	// OnTime、OffTime CROB 脉冲时间，单位毫秒
+	OnTime  uint32 `json:"onTime,omitempty"`
+	OffTime uint32 `json:"offTime,omitempty"`
+	// Value 模拟量输出值
+	Value float64 `json:"value"`
+	// Variation 模拟量输出对象变体：1 int32、2 int16、3 float32、4 float64，默认整数值使用 1，否则使用 3
+	Variation uint8 `json:"variation,omitempty"`
+}
+
+// encode 编码命令对象，带 2 字节索引前缀
+func (c Command) encode(b []byte) ([]byte, error) {
+	if c.Index < 0 || c.Index > math.MaxUint16 {
+		return nil, fmt.Errorf("dnp3: invalid index %d", c.Index)
+	}
+	switch c.Type {
+	case BinaryOutput:
+		code, err := ParseControlCode(c.Code)
+		if err != nil {
+			return nil, err
+		}
+		count := c.Count
+		if count == 0 {
+			count = 1
+		}
+		b = appendHeader(b, ObjectHeader{Group: 12, Variation: 1, Qualifier: QualifierIndexCount16, Count: 1})
+		b = binary.LittleEndian.AppendUint16(b, uint16(c.Index))
+		b = append(b, code, count)
+		b = binary.LittleEndian.AppendUint32(b, c.OnTime)
+		b = binary.LittleEndian.AppendUint32(b, c.OffTime)
+		return append(b, byte(StatusSuccess)), nil
+	case AnalogOutput:
+		variation := c.Variation
+		if variation == 0 {
+			variation = 1
+			if c.Value != math.Trunc(c.Value) {
+				variation = 3
+			}
+		}
+		b = appendHeader(b, ObjectHeader{Group: 41, Variation: variation, Qualifier: QualifierIndexCount16, Count: 1})
+		b = binary.LittleEndian.AppendUint16(b, uint16(c.Index))
+		switch variation {
+		case 1:
+			v, _ := clamp(c.Value, math.MinInt32, math.MaxInt32)
+			b = binary.LittleEndian.AppendUint32(b, uint32(int32(v)))
+		case 2:
+			v, _ := clamp(c.Value, math.MinInt16, math.MaxInt16)
+			b = binary.LittleEndian.AppendUint16(b, uint16(int16(v)))
+		case 3:
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(c.Value)))
+		case 4:
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.Value))
+		default:
+			return nil, fmt.Errorf("dnp3: unsupported analog output variation %d", variation)
+		}
+		return append(b, byte(StatusSuccess)), nil
+	default:
+		return nil, fmt.Errorf("dnp3: unsupported command type %s", c.Type)
+	}
+}
+
+// commandObject 请求中的一个命令对象
+type commandObject struct {
+	command Command
+	status  CommandStatus
+	// statusOffset 状态字节在对象数据中的偏移，用于回显
+	statusOffset int
+}
+
+// parseCommands 解码控制请求中的命令对象，不支持的对象返回错误
+func parseCommands(b []byte) ([]commandObject, error) {
+	var objects []commandObject
+	total := len(b)
+	for len(b) > 0 {
+		h, rest, err := parseHeader(b)
+		if err != nil {
+			return nil, err
+		}
+		b = rest
+		size, ok := otherSizes[gv(h.Group, h.Variation)]
+		if !ok || (h.Group != 12 && h.Group != 41) || h.indexSize() == 0 {
+			return nil, fmt.Errorf("dnp3: unsupported command object g%dv%d qualifier 0x%02x", h.Group, h.Variation, h.Qualifier)
+		}
+		for i := 0; i < h.quantity(); i++ {
+			var index uint32
+			if index, b, err = readIndex(b, h.indexSize()); err != nil {
+				return nil, err
+			}
+			if len(b) < size {
+				return nil, errShortObject
+			}
+			data := b[:size]
+			b = b[size:]
+			o := commandObject{status: CommandStatus(data[size-1]), statusOffset: total - len(b) - 1}
+			o.command.Index = int(index)
+			if h.Group == 12 {
+				o.command.Type = BinaryOutput
+				o.command.Code = ControlCodeName(data[0])
+				o.command.Count = data[1]
+				o.command.OnTime = binary.LittleEndian.Uint32(data[2:])
+				o.command.OffTime = binary.LittleEndian.Uint32(data[6:])
+			} else {
+				o.command.Type = AnalogOutput
+				o.command.Variation = h.Variation
+				switch h.Variation {
+				case 1:
+					o.command.Value = float64(int32(binary.LittleEndian.Uint32(data)))
+				case 2:
+					o.command.Value = float64(int16(binary.LittleEndian.Uint16(data)))
+				case 3:
+					o.command.Value = float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+				case 4:
+					o.command.Value = math.Float64frombits(binary.LittleEndian.Uint64(data))
+				}
+			}
+			objects = append(objects, o)
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestLink(t *testing.T) {
+	assert.Equal(t, uint16(0xea82), CRC([]byte("123456789")))
+
+	// 主站请求链路状态
+	f := frame{control: linkDir | linkPrm | linkRequestLinkStatus, dest: 1024, src: 1}
+	assert.Equal(t, "056405c900040100", hex.EncodeToString(f.marshal()[:8]))
+
+	data := make([]byte, 40)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	f = frame{control: linkDir | linkPrm | linkUnconfirmedUserData, dest: 1024, src: 1, data: data}
+	raw := f.marshal()
+	assert.Equal(t, 10+40+3*2, len(raw))
+	decoded, err := readFrame(bufio.NewReader(bytes.NewReader(append([]byte{0x00, 0x05, 0x01}, raw...))))
+	assert.Nil(t, err)
+	assert.Equal(t, f, *decoded)
+
+	raw[20] ^= 0xff
+	_, err = readFrame(bufio.NewReader(bytes.NewReader(raw)))
+	assert.Equal(t, ErrCRC, err)
+
+	// 传输层分段和重组
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	master := newChannel(a, true, 1, 1024, false)
+	outstation := newChannel(b, false, 1024, 0, true)
+	apdu := make([]byte, 600)
+	for i := range apdu {
+		apdu[i] = byte(i * 7)
+	}
+	go func() {
+		_ = master.send(apdu, time.Second)
+	}()
+	received, err := outstation.receive(time.Now().Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, apdu, received)
+	assert.Equal(t, uint16(1), outstation.remote)
+}
+
+func TestObjects(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 6e6, time.UTC).UnixMilli()
+	points := []Point{
+		{Type: BinaryInput, Value: true, Flags: FlagOnline, Group: 1, Variation: 2},
+		{Type: BinaryInput, Value: true, Flags: FlagOnline, Timestamp: now, Event: true, Group: 2, Variation: 2},
+		{Type: Counter, Value: uint32(4000000000), Flags: FlagOnline, Group: 20, Variation: 1},
+		{Type: Counter, Value: uint32(65535), Flags: FlagOnline, Group: 20, Variation: 6},
+		{Type: AnalogInput, Value: float64(-1234), Flags: FlagOnline, Group: 30, Variation: 1},
+		{Type: AnalogInput, Value: float64(12.5), Flags: FlagOnline, Group: 30, Variation: 5},
+		{Type: AnalogInput, Value: float64(12.25), Flags: FlagOnline, Timestamp: now, Event: true, Group: 32, Variation: 7},
+		{Type: AnalogOutput, Value: float64(7), Flags: FlagOnline, Group: 40, Variation: 1},
+	}
+	var b []byte
+	for i, p := range points {
+		b = appendHeader(b, ObjectHeader{Group: p.Group, Variation: p.Variation, Qualifier: QualifierIndexCount16, Count: 1})
+		b = append(b, byte(i), 0)
+		b = layouts[gv(p.Group, p.Variation)].encode(b, p)
+		points[i].Index = i
+	}
+	decoded, err := ParseObjects(b)
+	assert.Nil(t, err)
+	assert.Equal(t, points, decoded)
+
+	// 超出范围
+	b = layouts[gv(30, 2)].encode(nil, Point{Value: float64(40000), Flags: FlagOnline})
+	assert.Equal(t, []byte{FlagOnline | FlagOverRange, 0xff, 0x7f}, b)
+
+	// g1v1 打包的二进制输入，g50v1 时间被跳过
+	b = []byte{1, 1, QualifierStartStop8, 3, 12, 0x05, 0x02, 50, 1, QualifierCount8, 1, 0, 0, 0, 0, 0, 0}
+	decoded, err = ParseObjects(b)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(decoded))
+	assert.Equal(t, 3, decoded[0].Index)
+	assert.Equal(t, true, decoded[0].Value)
+	assert.Equal(t, false, decoded[1].Value)
+	assert.Equal(t, true, decoded[2].Value)
+	assert.Equal(t, true, decoded[9].Value)
+
+	_, err = ParseObjects([]byte{1, 2, QualifierStartStop8, 0, 3, 0x81})
+	assert.NotNil(t, err)
+	_, err = ParseObjects([]byte{110, 1, QualifierCount8, 1, 0})
+	assert.NotNil(t, err)
+}
+
+func TestCommands(t *testing.T) {
+	commands := []Command{
+		{Type: BinaryOutput, Index: 3, Code: "latchOn", Count: 1},
+		{Type: BinaryOutput, Index: 300, Code: "PULSEON", Count: 2, OnTime: 1000, OffTime: 500},
+		{Type: AnalogOutput, Index: 1, Value: 50, Variation: 1},
+		{Type: AnalogOutput, Index: 2, Value: 12.5, Variation: 3},
+		{Type: AnalogOutput, Index: 4, Value: -7, Variation: 2},
+	}
+	var b []byte
+	var err error
+	for _, c := range commands {
+		b, err = c.encode(b)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, "0c01280100030003010000000000000000", hex.EncodeToString(b[:17]))
+	objects, err := parseCommands(b)
+	assert.Nil(t, err)
+	assert.Equal(t, len(commands), len(objects))
+	commands[1].Code = "pulseOn"
+	for i, o := range objects {
+		assert.Equal(t, commands[i], o.command)
+		assert.Equal(t, StatusSuccess, o.status)
+		assert.Equal(t, byte(0), b[o.statusOffset])
+	}
+	assert.Equal(t, len(b)-1, objects[4].statusOffset)
+
+	// 默认变体
+	b, _ = Command{Type: AnalogOutput, Value: 1.5}.encode(nil)
+	assert.Equal(t, uint8(3), b[1])
+	b, _ = Command{Type: AnalogOutput, Value: 2}.encode(nil)
+	assert.Equal(t, uint8(1), b[1])
+
+	for _, c := range []Command{
+		{Type: BinaryOutput, Code: "open"},
+		{Type: AnalogOutput, Variation: 9},
+		{Type: Counter},
+		{Type: BinaryOutput, Index: -1, Code: "latchOn"},
+	} {
+		_, err = c.encode(nil)
+		assert.NotNil(t, err)
+	}
+	_, err = parseCommands([]byte{30, 1, QualifierIndexCount16, 1, 0, 0, 0})
+	assert.NotNil(t, err)
+
+	code, err := ParseControlCode("Trip")
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(ControlTrip), code)
+	assert.Equal(t, "close", ControlCodeName(ControlClose))
+	assert.Equal(t, "0x09", ControlCodeName(0x09))
+	assert.Equal(t, "noSelect", StatusNoSelect.String())
+	assert.Equal(t, []string{"class1Events", "deviceRestart", "objectUnknown"}, (IINClass1Events | IINDeviceRestart | IINObjectUnknown).Names())
+}
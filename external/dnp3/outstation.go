@@ -0,0 +1,822 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnp3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+const (
+	// DefaultMaxEvents 默认事件缓冲区大小
+	DefaultMaxEvents = 100
+	// DefaultSelectTimeout 默认选择后执行的超时
+	DefaultSelectTimeout = 5 * time.Second
+	// maxPoints 每种点位的最大数量
+	maxPoints = 65536
+)
+
+// pointTypes 点位类型，静态数据按照这个顺序响应
+var pointTypes = []string{BinaryInput, BinaryOutput, Counter, AnalogInput, AnalogOutput}
+
+// OutstationConfig 从站配置
+type OutstationConfig struct {
+	// LocalAddr 从站链路地址
+	LocalAddr uint16
+	// RemoteAddr 主站链路地址，0 表示接受任意主站
+	RemoteAddr uint16
+	// 各类点位的数量，索引从 0 开始
+	BinaryInputs  int
+	BinaryOutputs int
+	Counters      int
+	AnalogInputs  int
+	AnalogOutputs int
+	// AnalogVariation 模拟量输入的静态变体：1 int32、2 int16、3 int32 无标志、4 int16 无标志、5 float32、6 float64，默认 5
+	AnalogVariation uint8
+	// 各类点位变化事件的等级，0 表示不产生事件
+	BinaryInputClass uint8
+	CounterClass     uint8
+	AnalogInputClass uint8
+	// AnalogDeadband 模拟量输入变化超过死区时产生事件
+	AnalogDeadband float64
+	// MaxEvents 事件缓冲区大小，溢出时丢弃最早的事件
+	MaxEvents int
+	// SelectTimeout 选择后执行的超时
+	SelectTimeout time.Duration
+	// Timeout 发送和等待确认的超时
+	Timeout time.Duration
+}
+
+// CommandEvent 从站收到并执行的控制命令
+type CommandEvent struct {
+	// Function 功能码：operate、directOperate、directOperateNoAck
+	Function   string    `json:"function"`
+	Commands   []Command `json:"commands"`
+	MasterAddr uint16    `json:"masterAddr"`
+	ClientAddr string    `json:"clientAddr"`
+}
+
+// pointState 点位的当前值
+type pointState struct {
+	value     interface{}
+	flags     uint8
+	timestamp int64
+	// reported 最后一次产生事件时的值，模拟量死区以这个值为准
+	reported interface{}
+}
+
+// event 缓冲区中的事件
+type event struct {
+	id    uint64
+	class uint8
+	point Point
+}
+
+// Outstation DNP3 TCP 从站，维护点位数据库和事件缓冲区，响应主站的读取和控制请求
+type Outstation struct {
+	config OutstationConfig
+	// OnCommand 执行控制命令后调用，需要在 Serve 之前设置
+	OnCommand func(event CommandEvent)
+
+	mu     sync.Mutex
+	points map[string][]pointState
+	events []event
+	nextID uint64
+	iin    IIN
+
+	connLock sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewOutstation 创建从站
+func NewOutstation(config OutstationConfig) (*Outstation, error) {
+	if config.AnalogVariation == 0 {
+		config.AnalogVariation = 5
+	}
+	if _, ok := layouts[gv(30, config.AnalogVariation)]; !ok {
+		return nil, fmt.Errorf("dnp3: unsupported analog input variation %d", config.AnalogVariation)
+	}
+	for _, class := range []uint8{config.BinaryInputClass, config.CounterClass, config.AnalogInputClass} {
+		if class > 3 {
+			return nil, fmt.Errorf("dnp3: invalid event class %d", class)
+		}
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = DefaultMaxEvents
+	}
+	if config.SelectTimeout <= 0 {
+		config.SelectTimeout = DefaultSelectTimeout
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	o := &Outstation{
+		config: config,
+		points: make(map[string][]pointState),
+		iin:    IINDeviceRestart,
+		conns:  make(map[net.Conn]struct{}),
+	}
+	for i, size := range []int{config.BinaryInputs, config.BinaryOutputs, config.Counters, config.AnalogInputs, config.AnalogOutputs} {
+		if size < 0 || size > maxPoints {
+			return nil, fmt.Errorf("dnp3: invalid %s count %d", pointTypes[i], size)
+		}
+		states := make([]pointState, size)
+		for j := range states {
+			states[j] = pointState{value: zeroValue(pointTypes[i]), flags: FlagOnline, reported: zeroValue(pointTypes[i])}
+		}
+		o.points[pointTypes[i]] = states
+	}
+	return o, nil
+}
+
+// zeroValue 点位类型的初始值
+func zeroValue(pointType string) interface{} {
+	switch pointType {
+	case BinaryInput, BinaryOutput:
+		return false
+	case Counter:
+		return uint32(0)
+	}
+	return float64(0)
+}
+
+// convertValue 转换为点位类型的值
+func convertValue(pointType string, value interface{}) (interface{}, error) {
+	switch pointType {
+	case BinaryInput, BinaryOutput:
+		return cast.ToBoolE(value)
+	case Counter:
+		v, err := cast.ToFloat64E(value)
+		if err != nil {
+			return nil, err
+		}
+		if v < 0 || v > math.MaxUint32 {
+			return nil, fmt.Errorf("dnp3: counter value %v out of range", value)
+		}
+		return uint32(v), nil
+	case AnalogInput, AnalogOutput:
+		return cast.ToFloat64E(value)
+	}
+	return nil, fmt.Errorf("dnp3: unknown point type %s", pointType)
+}
+
+// staticVariation 点位类型的静态对象
+func (o *Outstation) staticVariation(pointType string) (uint8, uint8) {
+	switch pointType {
+	case BinaryInput:
+		return 1, 2
+	case BinaryOutput:
+		return 10, 2
+	case Counter:
+		return 20, 1
+	case AnalogInput:
+		return 30, o.config.AnalogVariation
+	default:
+		if o.floatAnalogs() {
+			return 40, 3
+		}
+		return 40, 1
+	}
+}
+
+// eventVariation 点位类型的事件对象，都带时间
+func (o *Outstation) eventVariation(pointType string) (uint8, uint8) {
+	switch pointType {
+	case BinaryInput:
+		return 2, 2
+	case Counter:
+		return 22, 5
+	default:
+		if o.floatAnalogs() {
+			return 32, 7
+		}
+		return 32, 3
+	}
+}
+
+func (o *Outstation) floatAnalogs() bool {
+	return o.config.AnalogVariation >= 5
+}
+
+// eventClass 点位类型的事件等级
+func (o *Outstation) eventClass(pointType string) uint8 {
+	switch pointType {
+	case BinaryInput:
+		return o.config.BinaryInputClass
+	case Counter:
+		return o.config.CounterClass
+	case AnalogInput:
+		return o.config.AnalogInputClass
+	}
+	return 0
+}
+
+// Update 更新点位值，二进制输入、计数器和模拟量输入变化时按配置的等级产生事件
+func (o *Outstation) Update(pointType string, index int, value interface{}) error {
+	v, err := convertValue(pointType, value)
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	states, ok := o.points[pointType]
+	if !ok {
+		return fmt.Errorf("dnp3: unknown point type %s", pointType)
+	}
+	if index < 0 || index >= len(states) {
+		return fmt.Errorf("dnp3: %s index %d out of range", pointType, index)
+	}
+	state := &states[index]
+	now := Now()
+	state.value, state.flags, state.timestamp = v, FlagOnline, now
+	class := o.eventClass(pointType)
+	changed := state.reported != v
+	if pointType == AnalogInput {
+		changed = math.Abs(v.(float64)-state.reported.(float64)) > o.config.AnalogDeadband
+	}
+	if class == 0 || !changed {
+		return nil
+	}
+	state.reported = v
+	group, variation := o.eventVariation(pointType)
+	o.nextID++
+	o.events = append(o.events, event{id: o.nextID, class: class, point: Point{
+		Type: pointType, Index: index, Value: v, Flags: FlagOnline, Timestamp: now,
+		Event: true, Group: group, Variation: variation,
+	}})
+	if len(o.events) > o.config.MaxEvents {
+		o.events = o.events[len(o.events)-o.config.MaxEvents:]
+		o.iin |= IINEventBufferOverflow
+	}
+	return nil
+}
+
+// Get 获取点位当前值
+func (o *Outstation) Get(pointType string, index int) (Point, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	states, ok := o.points[pointType]
+	if !ok {
+		return Point{}, fmt.Errorf("dnp3: unknown point type %s", pointType)
+	}
+	if index < 0 || index >= len(states) {
+		return Point{}, fmt.Errorf("dnp3: %s index %d out of range", pointType, index)
+	}
+	group, variation := o.staticVariation(pointType)
+	s := states[index]
+	return Point{Type: pointType, Index: index, Value: s.value, Flags: s.flags, Timestamp: s.timestamp, Group: group, Variation: variation}, nil
+}
+
+// IIN 当前的内部指示位，包括各等级是否有事件
+func (o *Outstation) IIN() IIN {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.currentIIN()
+}
+
+func (o *Outstation) currentIIN() IIN {
+	iin := o.iin
+	for _, e := range o.events {
+		iin |= IINClass1Events << (e.class - 1)
+	}
+	return iin
+}
+
+// clearEvents 主站确认后删除已发送的事件
+func (o *Outstation) clearEvents(ids map[uint64]bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	events := o.events[:0]
+	for _, e := range o.events {
+		if !ids[e.id] {
+			events = append(events, e)
+		}
+	}
+	o.events = events
+	if len(o.events) == 0 {
+		o.iin &^= IINEventBufferOverflow
+	}
+}
+
+// Serve 在 listener 上接受主站连接，直到 listener 关闭
+func (o *Outstation) Serve(l net.Listener) error {
+	o.connLock.Lock()
+	if o.closed {
+		o.connLock.Unlock()
+		return net.ErrClosed
+	}
+	o.listener = l
+	o.connLock.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go o.ServeConn(conn)
+	}
+}
+
+// ServeConn 处理一个主站连接，直到连接关闭
+func (o *Outstation) ServeConn(conn net.Conn) {
+	o.connLock.Lock()
+	if o.closed {
+		o.connLock.Unlock()
+		_ = conn.Close()
+		return
+	}
+	o.conns[conn] = struct{}{}
+	o.connLock.Unlock()
+	defer func() {
+		o.connLock.Lock()
+		delete(o.conns, conn)
+		o.connLock.Unlock()
+		_ = conn.Close()
+	}()
+	s := &session{
+		o:  o,
+		ch: newChannel(conn, false, o.config.LocalAddr, o.config.RemoteAddr, o.config.RemoteAddr == 0),
+	}
+	for {
+		data, err := s.ch.receive(time.Time{})
+		if err != nil {
+			if errors.Is(err, ErrCRC) {
+				continue
+			}
+			return
+		}
+		req, err := parseAPDU(data)
+		if err != nil || req.isResponse() {
+			continue
+		}
+		if err = s.handle(req); err != nil {
+			return
+		}
+	}
+}
+
+// Close 关闭 listener 和所有连接
+func (o *Outstation) Close() error {
+	o.connLock.Lock()
+	defer o.connLock.Unlock()
+	o.closed = true
+	var err error
+	if o.listener != nil {
+		err = o.listener.Close()
+	}
+	for conn := range o.conns {
+		_ = conn.Close()
+	}
+	return err
+}
+
+// session 一个主站连接的状态
+type session struct {
+	o  *Outstation
+	ch *channel
+	// 等待主站确认的事件
+	pending    map[uint64]bool
+	pendingSeq uint8
+	// 最后一次选择
+	selectSeq     uint8
+	selectObjects []byte
+	selectTime    time.Time
+}
+
+// handle 处理一个请求
+func (s *session) handle(req *APDU) error {
+	seq := req.Seq()
+	switch req.Function {
+	case FuncConfirm:
+		if s.pending != nil && seq == s.pendingSeq {
+			s.o.clearEvents(s.pending)
+			s.pending = nil
+		}
+		return nil
+	case FuncRead:
+		return s.read(req)
+	case FuncWrite:
+		return s.respond(seq, s.write(req.Objects), nil)
+	case FuncSelect, FuncOperate, FuncDirectOperate, FuncDirectOperateNoAck:
+		return s.control(req)
+	default:
+		return s.respond(seq, IINNoFuncCodeSupport, nil)
+	}
+}
+
+// respond 发送单个分片的响应
+func (s *session) respond(seq uint8, iin IIN, objects []byte) error {
+	s.o.mu.Lock()
+	iin |= s.o.currentIIN()
+	s.o.mu.Unlock()
+	resp := APDU{Control: appFir | appFin | seq, Function: FuncResponse, IIN: iin, Objects: objects}
+	return s.ch.send(resp.marshal(), s.o.config.Timeout)
+}
+
+// write 处理写请求，支持清除 DEVICE_RESTART 和对时
+func (s *session) write(b []byte) IIN {
+	for len(b) > 0 {
+		h, rest, err := parseHeader(b)
+		if err != nil {
+			return IINParameterError
+		}
+		b = rest
+		switch {
+		case h.Group == 80 && h.Variation == 1 && (h.Qualifier == QualifierStartStop8 || h.Qualifier == QualifierStartStop16):
+			size := (h.quantity() + 7) / 8
+			if len(b) < size {
+				return IINParameterError
+			}
+			for i := 0; i < h.quantity(); i++ {
+				if h.Start+uint32(i) == iinRestartIndex && b[i/8]&(1<<(i%8)) == 0 {
+					s.o.mu.Lock()
+					s.o.iin &^= IINDeviceRestart
+					s.o.mu.Unlock()
+				}
+			}
+			b = b[size:]
+		case h.Group == 50 && h.Variation == 1 && h.Qualifier == QualifierCount8:
+			size := h.quantity() * 6
+			if len(b) < size {
+				return IINParameterError
+			}
+			b = b[size:]
+		default:
+			return IINObjectUnknown
+		}
+	}
+	return 0
+}
+
+// readItem 读取响应中的一个对象
+type readItem struct {
+	point Point
+	// eventID 事件编号，0 表示静态值
+	eventID uint64
+}
+
+// read 处理读请求，按分片发送响应，中间分片等待主站确认
+func (s *session) read(req *APDU) error {
+	items, iin := s.collect(req.Objects)
+	fragments := s.pack(items)
+	seq := req.Seq()
+	ids := make(map[uint64]bool)
+	for _, item := range items {
+		if item.eventID != 0 {
+			ids[item.eventID] = true
+		}
+	}
+	for i, objects := range fragments {
+		last := i == len(fragments)-1
+		control := seq
+		if i == 0 {
+			control |= appFir
+		}
+		if last {
+			control |= appFin
+		}
+		if !last || len(ids) > 0 {
+			control |= appCon
+		}
+		s.o.mu.Lock()
+		resp := APDU{Control: control, Function: FuncResponse, IIN: iin | s.o.currentIIN(), Objects: objects}
+		s.o.mu.Unlock()
+		if err := s.ch.send(resp.marshal(), s.o.config.Timeout); err != nil {
+			return err
+		}
+		if last {
+			break
+		}
+		if ok, err := s.awaitConfirm(seq); err != nil || !ok {
+			return err
+		}
+		seq = (seq + 1) & appSeq
+	}
+	if len(ids) > 0 {
+		s.pending, s.pendingSeq = ids, seq
+	} else {
+		s.pending = nil
+	}
+	return nil
+}
+
+// awaitConfirm 等待中间分片的确认，超时或者收到其他请求时放弃响应
+func (s *session) awaitConfirm(seq uint8) (bool, error) {
+	data, err := s.ch.receive(time.Now().Add(s.o.config.Timeout))
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			_ = s.ch.conn.SetReadDeadline(time.Time{})
+			return false, nil
+		}
+		return false, err
+	}
+	req, err := parseAPDU(data)
+	if err != nil || req.Function != FuncConfirm || req.Seq() != seq {
+		return false, nil
+	}
+	return true, nil
+}
+
+// collect 根据读请求的对象头收集静态值和事件
+func (s *session) collect(b []byte) ([]readItem, IIN) {
+	o := s.o
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var items []readItem
+	var iin IIN
+	seen := make(map[uint64]bool)
+	addEvents := func(match func(e event) bool, variation uint8, limit int) {
+		for _, e := range o.events {
+			if limit > 0 && len(seen) >= limit {
+				break
+			}
+			if seen[e.id] || !match(e) {
+				continue
+			}
+			seen[e.id] = true
+			p := e.point
+			if variation != 0 {
+				p.Variation = variation
+			}
+			items = append(items, readItem{point: p, eventID: e.id})
+		}
+	}
+	for len(b) > 0 {
+		h, rest, err := parseHeader(b)
+		if err != nil {
+			iin |= IINParameterError
+			break
+		}
+		b = rest
+		limit := 0
+		if h.Qualifier == QualifierCount8 || h.Qualifier == QualifierCount16 {
+			limit = len(seen) + h.quantity()
+		}
+		if h.Group == 60 {
+			switch {
+			case h.Variation == 1:
+				for _, pointType := range pointTypes {
+					items = o.appendStatic(items, pointType, 0, 0, -1)
+				}
+			case h.Variation >= 2 && h.Variation <= 4:
+				class := h.Variation - 1
+				addEvents(func(e event) bool { return e.class == class }, 0, limit)
+			default:
+				iin |= IINObjectUnknown
+			}
+			continue
+		}
+		pointType, isEvent, ok := o.lookupGroup(h.Group, h.Variation)
+		if !ok {
+			iin |= IINObjectUnknown
+			continue
+		}
+		if isEvent {
+			addEvents(func(e event) bool { return e.point.Type == pointType && e.point.Group == h.Group }, h.Variation, limit)
+			continue
+		}
+		start, stop := 0, len(o.points[pointType])-1
+		switch h.Qualifier {
+		case QualifierAll:
+		case QualifierStartStop8, QualifierStartStop16:
+			start, stop = int(h.Start), int(h.Stop)
+			if stop >= len(o.points[pointType]) {
+				iin |= IINParameterError
+				continue
+			}
+		default:
+			iin |= IINParameterError
+			continue
+		}
+		items = o.appendStatic(items, pointType, h.Variation, start, stop)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		// 事件在静态值之前，按照产生的顺序
+		a, b := items[i].eventID, items[j].eventID
+		return a != 0 && (b == 0 || a < b)
+	})
+	return items, iin
+}
+
+// lookupGroup 读请求的对象组对应的点位类型，variation 为 0 表示默认变体
+func (o *Outstation) lookupGroup(group, variation uint8) (string, bool, bool) {
+	for _, pointType := range pointTypes {
+		if g, _ := o.staticVariation(pointType); g == group {
+			_, ok := layouts[gv(group, variation)]
+			return pointType, false, variation == 0 || ok
+		}
+		if pointType == BinaryOutput || pointType == AnalogOutput {
+			continue
+		}
+		if g, _ := o.eventVariation(pointType); g == group {
+			_, ok := layouts[gv(group, variation)]
+			return pointType, true, variation == 0 || ok
+		}
+	}
+	return "", false, false
+}
+
+// appendStatic 添加静态值，variation 为 0 使用默认变体，stop 小于 0 表示到最后一个点位
+func (o *Outstation) appendStatic(items []readItem, pointType string, variation uint8, start, stop int) []readItem {
+	group, defaultVariation := o.staticVariation(pointType)
+	if variation == 0 {
+		variation = defaultVariation
+	}
+	states := o.points[pointType]
+	if stop < 0 {
+		stop = len(states) - 1
+	}
+	for i := start; i <= stop; i++ {
+		s := states[i]
+		items = append(items, readItem{point: Point{
+			Type: pointType, Index: i, Value: s.value, Flags: s.flags, Group: group, Variation: variation,
+		}})
+	}
+	return items
+}
+
+// pack 把对象打包成分片，连续的静态值使用起止范围，事件使用 2 字节索引前缀
+func (s *session) pack(items []readItem) [][]byte {
+	// 分片容量，去掉应用层头和 IIN
+	const capacity = MaxFragmentSize - 4
+	// 对象头的最大长度
+	const headerSize = 7
+	var fragments [][]byte
+	var current, body []byte
+	var header ObjectHeader
+	open := false
+	closeBlock := func() {
+		if open {
+			current = append(appendHeader(current, header), body...)
+			open, body = false, nil
+		}
+	}
+	for _, item := range items {
+		p := item.point
+		l := layouts[gv(p.Group, p.Variation)]
+		event := item.eventID != 0
+		extend := open && header.Group == p.Group && header.Variation == p.Variation &&
+			(event || int(header.Stop)+1 == p.Index)
+		size := l.size()
+		if event {
+			size += 2
+		}
+		used := len(current)
+		if open {
+			used += headerSize + len(body)
+		}
+		if !extend {
+			size += headerSize
+		}
+		if used+size > capacity && len(current)+len(body) > 0 {
+			closeBlock()
+			fragments = append(fragments, current)
+			current, extend = nil, false
+		}
+		if !extend {
+			closeBlock()
+			open = true
+			if event {
+				header = ObjectHeader{Group: p.Group, Variation: p.Variation, Qualifier: QualifierIndexCount16}
+			} else {
+				header = ObjectHeader{Group: p.Group, Variation: p.Variation, Qualifier: QualifierStartStop16,
+					Start: uint32(p.Index), Stop: uint32(p.Index)}
+			}
+		} else if !event {
+			header.Stop++
+		}
+		if event {
+			header.Count++
+			body = append(body, byte(p.Index), byte(p.Index>>8))
+		}
+		body = l.encode(body, p)
+	}
+	closeBlock()
+	return append(fragments, current)
+}
+
+// control 处理选择和执行请求
+func (s *session) control(req *APDU) error {
+	seq := req.Seq()
+	objects, err := parseCommands(req.Objects)
+	if err != nil {
+		if req.Function == FuncDirectOperateNoAck {
+			return nil
+		}
+		return s.respond(seq, IINParameterError, nil)
+	}
+	statuses := make([]CommandStatus, len(objects))
+	for i, obj := range objects {
+		statuses[i] = s.o.validate(obj.command)
+	}
+	switch req.Function {
+	case FuncSelect:
+		s.selectObjects = nil
+		if allSuccess(statuses) {
+			s.selectSeq, s.selectObjects, s.selectTime = seq, append([]byte(nil), req.Objects...), time.Now()
+		}
+	case FuncOperate:
+		selected := s.selectObjects != nil && seq == (s.selectSeq+1)&appSeq &&
+			bytes.Equal(s.selectObjects, req.Objects) && time.Since(s.selectTime) <= s.o.config.SelectTimeout
+		s.selectObjects = nil
+		for i := range statuses {
+			if !selected && statuses[i] == StatusSuccess {
+				statuses[i] = StatusNoSelect
+			}
+		}
+	}
+	if req.Function != FuncSelect {
+		s.o.execute(req.Function, objects, statuses, s.ch)
+	}
+	if req.Function == FuncDirectOperateNoAck {
+		return nil
+	}
+	echo := append([]byte(nil), req.Objects...)
+	for i, obj := range objects {
+		echo[obj.statusOffset] = byte(statuses[i])
+	}
+	return s.respond(seq, 0, echo)
+}
+
+func allSuccess(statuses []CommandStatus) bool {
+	for _, status := range statuses {
+		if status != StatusSuccess {
+			return false
+		}
+	}
+	return true
+}
+
+// validate 检查命令的点位和控制码
+func (o *Outstation) validate(c Command) CommandStatus {
+	o.mu.Lock()
+	count := len(o.points[c.Type])
+	o.mu.Unlock()
+	if c.Index >= count {
+		return StatusNotSupported
+	}
+	if c.Type == BinaryOutput {
+		if _, err := ParseControlCode(c.Code); err != nil || c.Code == "nul" {
+			return StatusNotSupported
+		}
+	}
+	return StatusSuccess
+}
+
+// execute 执行状态为成功的命令，更新输出点位的状态并通知 OnCommand
+func (o *Outstation) execute(function FunctionCode, objects []commandObject, statuses []CommandStatus, ch *channel) {
+	event := CommandEvent{MasterAddr: ch.remote, ClientAddr: ch.conn.RemoteAddr().String()}
+	switch function {
+	case FuncOperate:
+		event.Function = "operate"
+	case FuncDirectOperate:
+		event.Function = ModeDirectOperate
+	default:
+		event.Function = ModeDirectOperateNoAck
+	}
+	for i, obj := range objects {
+		if statuses[i] != StatusSuccess {
+			continue
+		}
+		c := obj.command
+		switch c.Type {
+		case BinaryOutput:
+			switch c.Code {
+			case "latchOn", "close":
+				_ = o.Update(BinaryOutput, c.Index, true)
+			case "latchOff", "trip":
+				_ = o.Update(BinaryOutput, c.Index, false)
+			}
+		case AnalogOutput:
+			_ = o.Update(AnalogOutput, c.Index, c.Value)
+		}
+		event.Commands = append(event.Commands, c)
+	}
+	if len(event.Commands) > 0 && o.OnCommand != nil {
+		o.OnCommand(event)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fins
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// 存储区
+const (
+	AreaCIO = "CIO"
+	AreaW   = "W"
+	AreaH   = "H"
+	AreaD   = "D"
+)
+
+// 数据类型
+const (
+	DataTypeBool   = "BOOL"
+	DataTypeWord   = "WORD"
+	DataTypeInt    = "INT"
+	DataTypeUInt   = "UINT"
+	DataTypeDWord  = "DWORD"
+	DataTypeDInt   = "DINT"
+	DataTypeUDInt  = "UDINT"
+	DataTypeReal   = "REAL"
+	DataTypeLInt   = "LINT"
+	DataTypeULInt  = "ULINT"
+	DataTypeLReal  = "LREAL"
+	DataTypeString = "STRING"
+)
+
+// maxStringLength STRING 的最大字节数
+const maxStringLength = 1998
+
+// areaCodes CS/CJ/CP/NX 系列的存储区编码，分别为字访问和位访问
+var areaCodes = map[string][2]byte{
+	AreaCIO: {0xb0, 0x30},
+	AreaW:   {0xb1, 0x31},
+	AreaH:   {0xb2, 0x32},
+	AreaD:   {0x82, 0x02},
+}
+
+// typeWords 数据类型占用的字数
+var typeWords = map[string]int{
+	DataTypeWord:  1,
+	DataTypeInt:   1,
+	DataTypeUInt:  1,
+	DataTypeDWord: 2,
+	DataTypeDInt:  2,
+	DataTypeUDInt: 2,
+	DataTypeReal:  2,
+	DataTypeLInt:  4,
+	DataTypeULInt: 4,
+	DataTypeLReal: 4,
+}
+
+var (
+	addressPattern    = regexp.MustCompile(`^(CIO|W|H|D|DM)?(\d+)(?:\.(\d{1,2}))?$`)
+	stringTypePattern = regexp.MustCompile(`^STRING(?:\[(\d+)\])?$`)
+)
+
+// Address 解析后的 FINS 地址
+type Address struct {
+	// Area 存储区：CIO, W, H, D
+	Area string
+	// Word 字地址
+	Word uint16
+	// Bit 位地址 0-15，仅 BOOL 类型有效
+	Bit int
+	// DataType 数据类型
+	DataType string
+	// Words 占用的字数，BOOL 为 1
+	Words int
+	// Length STRING 的字节数
+	Length int
+	raw    string
+}
+
+// ParseAddress 解析 FINS 地址，不区分大小写，格式：<存储区><字地址>[.<位>][:<数据类型>]
+//   - 存储区：CIO、W、H、D（也可以写作 DM），省略存储区表示 CIO
+//   - 字访问：D100, W10:INT, H20:DINT, D200:REAL, D300:STRING[10]
+//   - 位访问：CIO0.00, W10.5, D100.15
+//
+// 未指定数据类型时字地址为 WORD，位地址为 BOOL。
+// 32/64 位数据按 PLC 的存储方式低字在前，STRING 每个字保存2个字符，高字节在前
+func ParseAddress(s string) (*Address, error) {
+	raw := strings.TrimSpace(s)
+	location, dataType, _ := strings.Cut(strings.ToUpper(raw), ":")
+	m := addressPattern.FindStringSubmatch(location)
+	if m == nil {
+		return nil, fmt.Errorf("invalid fins address: %s", raw)
+	}
+	a := &Address{raw: raw, Area: m[1]}
+	switch a.Area {
+	case "":
+		a.Area = AreaCIO
+	case "DM":
+		a.Area = AreaD
+	}
+	word, err := strconv.ParseUint(m[2], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fins address %s: %w", raw, err)
+	}
+	a.Word = uint16(word)
+	if m[3] != "" {
+		a.Bit, _ = strconv.Atoi(m[3])
+		if a.Bit > 15 {
+			return nil, fmt.Errorf("invalid fins bit address: %s", raw)
+		}
+		if dataType != "" && dataType != DataTypeBool {
+			return nil, fmt.Errorf("fins bit address %s only supports BOOL", raw)
+		}
+		a.DataType, a.Words = DataTypeBool, 1
+		return a, nil
+	}
+	if dataType == "" {
+		dataType = DataTypeWord
+	}
+	if m := stringTypePattern.FindStringSubmatch(dataType); m != nil {
+		length := 2
+		if m[1] != "" {
+			if length, err = strconv.Atoi(m[1]); err != nil || length == 0 || length > maxStringLength {
+				return nil, fmt.Errorf("invalid fins string length: %s", raw)
+			}
+		}
+		a.DataType, a.Length, a.Words = DataTypeString, length, (length+1)/2
+	} else if words, ok := typeWords[dataType]; ok {
+		a.DataType, a.Words = dataType, words
+	} else {
+		return nil, fmt.Errorf("unsupported fins data type: %s", dataType)
+	}
+	if int(a.Word)+a.Words > 0x10000 {
+		return nil, fmt.Errorf("fins address out of range: %s", raw)
+	}
+	return a, nil
+}
+
+// String 返回原始地址
+func (a *Address) String() string {
+	return a.raw
+}
+
+// areaCode FINS 协议的存储区编码
+func (a *Address) areaCode() byte {
+	codes := areaCodes[a.Area]
+	if a.DataType == DataTypeBool {
+		return codes[1]
+	}
+	return codes[0]
+}
+
+// Size 读写的字节数，BOOL 为 1，其他为字数*2
+func (a *Address) Size() int {
+	if a.DataType == DataTypeBool {
+		return 1
+	}
+	return a.Words * 2
+}
+
+// Decode 把 PLC 字节解码为值，BOOL 为1个字节，其他为大端序的字
+func (a *Address) Decode(data []byte) (interface{}, error) {
+	if len(data) < a.Size() {
+		return nil, fmt.Errorf("fins address %s: short data", a.raw)
+	}
+	switch a.DataType {
+	case DataTypeBool:
+		return data[0]&0x01 != 0, nil
+	case DataTypeWord, DataTypeUInt:
+		return binary.BigEndian.Uint16(data), nil
+	case DataTypeInt:
+		return int16(binary.BigEndian.Uint16(data)), nil
+	case DataTypeDWord, DataTypeUDInt:
+		return uint32(swapWords(data, 2)), nil
+	case DataTypeDInt:
+		return int32(swapWords(data, 2)), nil
+	case DataTypeReal:
+		return math.Float32frombits(uint32(swapWords(data, 2))), nil
+	case DataTypeLInt:
+		return int64(swapWords(data, 4)), nil
+	case DataTypeULInt:
+		return swapWords(data, 4), nil
+	case DataTypeLReal:
+		return math.Float64frombits(swapWords(data, 4)), nil
+	case DataTypeString:
+		s := data[:a.Length]
+		// 以 0 结束
+		for i, b := range s {
+			if b == 0 {
+				s = s[:i]
+				break
+			}
+		}
+		return string(s), nil
+	default:
+		return nil, fmt.Errorf("unsupported fins data type: %s", a.DataType)
+	}
+}
+
+// Encode 把值编码为 PLC 字节
+func (a *Address) Encode(value interface{}) ([]byte, error) {
+	data := make([]byte, a.Size())
+	switch a.DataType {
+	case DataTypeBool:
+		v, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, fmt.Errorf("fins address %s: %w", a.raw, err)
+		}
+		if v {
+			data[0] = 1
+		}
+		return data, nil
+	case DataTypeString:
+		s := cast.ToString(value)
+		if len(s) > a.Length {
+			s = s[:a.Length]
+		}
+		copy(data, s)
+		return data, nil
+	case DataTypeLInt, DataTypeULInt:
+		// 64位整数不经过 float64 转换，避免丢失精度
+		v, err := cast.ToInt64E(value)
+		if err != nil {
+			return nil, fmt.Errorf("fins address %s: %w", a.raw, err)
+		}
+		putSwappedWords(data, uint64(v), 4)
+		return data, nil
+	}
+	v, err := cast.ToFloat64E(value)
+	if err != nil {
+		return nil, fmt.Errorf("fins address %s: %w", a.raw, err)
+	}
+	switch a.DataType {
+	case DataTypeWord, DataTypeUInt:
+		binary.BigEndian.PutUint16(data, uint16(int64(v)))
+	case DataTypeInt:
+		binary.BigEndian.PutUint16(data, uint16(int16(v)))
+	case DataTypeDWord, DataTypeUDInt:
+		putSwappedWords(data, uint64(uint32(int64(v))), 2)
+	case DataTypeDInt:
+		putSwappedWords(data, uint64(uint32(int32(v))), 2)
+	case DataTypeReal:
+		putSwappedWords(data, uint64(math.Float32bits(float32(v))), 2)
+	case DataTypeLReal:
+		putSwappedWords(data, math.Float64bits(v), 4)
+	default:
+		return nil, fmt.Errorf("unsupported fins data type: %s", a.DataType)
+	}
+	return data, nil
+}
+
+// swapWords 把低字在前的 words 个字转换为整数
+func swapWords(data []byte, words int) uint64 {
+	var v uint64
+	for i := words - 1; i >= 0; i-- {
+		v = v<<16 | uint64(binary.BigEndian.Uint16(data[i*2:]))
+	}
+	return v
+}
+
+// putSwappedWords 把整数按低字在前写入 words 个字
+func putSwappedWords(data []byte, v uint64, words int) {
+	for i := 0; i < words; i++ {
+		binary.BigEndian.PutUint16(data[i*2:], uint16(v))
+		v >>= 16
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fins
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	a, err := ParseAddress("D100:REAL")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaD, a.Area)
+	assert.Equal(t, uint16(100), a.Word)
+	assert.Equal(t, DataTypeReal, a.DataType)
+	assert.Equal(t, 2, a.Words)
+	assert.Equal(t, byte(0x82), a.areaCode())
+
+	a, err = ParseAddress("w10.05")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaW, a.Area)
+	assert.Equal(t, 5, a.Bit)
+	assert.Equal(t, DataTypeBool, a.DataType)
+	assert.Equal(t, byte(0x31), a.areaCode())
+
+	a, err = ParseAddress("CIO0.15")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaCIO, a.Area)
+	assert.Equal(t, 15, a.Bit)
+
+	a, err = ParseAddress("100")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaCIO, a.Area)
+	assert.Equal(t, DataTypeWord, a.DataType)
+
+	a, err = ParseAddress("DM20:LREAL")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaD, a.Area)
+	assert.Equal(t, 4, a.Words)
+
+	a, err = ParseAddress("H5:STRING[5]")
+	assert.Nil(t, err)
+	assert.Equal(t, DataTypeString, a.DataType)
+	assert.Equal(t, 3, a.Words)
+
+	for _, s := range []string{"", "E0", "D", "D100.16", "D0.1:INT", "D0:FOO", "D65535:DINT", "D0:STRING[0]", "D70000"} {
+		_, err = ParseAddress(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	cases := []struct {
+		address string
+		value   interface{}
+		want    interface{}
+	}{
+		{"D0.0", true, true},
+		{"D0", 0xabcd, uint16(0xabcd)},
+		{"D0:INT", -100, int16(-100)},
+		{"D0:DINT", -70000, int32(-70000)},
+		{"D0:UDINT", 70000, uint32(70000)},
+		{"D0:REAL", 21.5, float32(21.5)},
+		{"D0:LINT", "-9007199254740993", int64(-9007199254740993)},
+		{"D0:LREAL", 1.25, 1.25},
+		{"D0:STRING[4]", "hello", "hell"},
+		{"D0:STRING[5]", "abc", "abc"},
+	}
+	for _, c := range cases {
+		a, err := ParseAddress(c.address)
+		assert.Nil(t, err)
+		data, err := a.Encode(c.value)
+		assert.Nil(t, err)
+		assert.Equal(t, a.Size(), len(data))
+		v, err := a.Decode(data)
+		assert.Nil(t, err)
+		assert.Equal(t, c.want, v, c.address)
+	}
+
+	// 低字在前
+	a, _ := ParseAddress("D0:DINT")
+	data, _ := a.Encode(0x12345678)
+	assert.Equal(t, []byte{0x56, 0x78, 0x12, 0x34}, data)
+	a, _ = ParseAddress("D0:STRING[3]")
+	data, _ = a.Encode("abc")
+	assert.Equal(t, []byte{'a', 'b', 'c', 0}, data)
+	a, _ = ParseAddress("D0:REAL")
+	_, err := a.Encode("abc")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,464 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fins
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+)
+
+const (
+	// DefaultPort FINS 端口
+	DefaultPort = "9600"
+	// DefaultServer 默认 PLC 地址
+	DefaultServer = "127.0.0.1:9600"
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 5 * time.Second
+	// NetworkUDP FINS/UDP
+	NetworkUDP = "udp"
+	// NetworkTCP FINS/TCP
+	NetworkTCP = "tcp"
+	// maxReadWords 单次读取的最大字数
+	maxReadWords = 999
+	// maxWriteWords 单次写入的最大字数
+	maxWriteWords = 996
+)
+
+// FINS 协议常量
+const (
+	headerSize       = 10
+	icfCommand       = 0x80
+	icfResponse      = 0x40
+	gatewayCount     = 0x02
+	mrcMemoryArea    = 0x01
+	srcRead          = 0x01
+	srcWrite         = 0x02
+	tcpHeaderSize    = 16
+	tcpNodeRequest   = 0
+	tcpNodeResponse  = 1
+	tcpFrame         = 2
+	maxResponseBytes = 2048
+)
+
+// ErrClosed 连接已经关闭
+var ErrClosed = errors.New("fins connection is closed")
+
+// EndCodeError PLC 返回的结束码不是正常结束
+type EndCodeError struct {
+	Address string
+	// Main 主响应码
+	Main byte
+	// Sub 子响应码
+	Sub byte
+}
+
+func (e *EndCodeError) Error() string {
+	var reason string
+	switch e.Main {
+	case 0x01:
+		reason = "local node error"
+	case 0x02:
+		reason = "destination node error"
+	case 0x03:
+		reason = "controller error"
+	case 0x04:
+		reason = "service unsupported"
+	case 0x05:
+		reason = "routing table error"
+	case 0x10:
+		reason = "command format error"
+	case 0x11:
+		reason = "parameter error"
+	case 0x20:
+		reason = "read not possible"
+	case 0x21:
+		reason = "write not possible"
+	case 0x22:
+		reason = "not executable in current mode"
+	case 0x23:
+		reason = "no such device"
+	case 0x24:
+		reason = "cannot start/stop"
+	case 0x25:
+		reason = "unit error"
+	case 0x26:
+		reason = "command error"
+	case 0x30:
+		reason = "access right error"
+	default:
+		reason = "unknown error"
+	}
+	return fmt.Sprintf("fins %s: %s (0x%02x%02x)", e.Address, reason, e.Main, e.Sub)
+}
+
+// ClientConfig 创建 FINS 客户端的配置
+type ClientConfig struct {
+	// Server PLC 地址，格式：host:port，端口默认 9600
+	Server string
+	// Network udp 或者 tcp，默认 udp
+	Network string
+	// DestNet 目标网络号，本地网络为 0
+	DestNet byte
+	// DestNode 目标节点号，0 表示：UDP 使用 PLC IP 地址的最后一个字节，TCP 使用握手时 PLC 返回的节点号
+	DestNode byte
+	// DestUnit 目标单元号，CPU 为 0
+	DestUnit byte
+	// SrcNet 源网络号
+	SrcNet byte
+	// SrcNode 源节点号，0 表示：UDP 使用本机 IP 地址的最后一个字节，TCP 由 PLC 自动分配
+	SrcNode byte
+	// Timeout 连接和请求超时
+	Timeout time.Duration
+}
+
+// Key 共享连接的 key，格式：network://server/destNet.destNode.destUnit/srcNet.srcNode
+func (c ClientConfig) Key() string {
+	return fmt.Sprintf("%s://%s/%d.%d.%d/%d.%d", c.network(), c.address(),
+		c.DestNet, c.DestNode, c.DestUnit, c.SrcNet, c.SrcNode)
+}
+
+// network 网络类型，默认 udp
+func (c ClientConfig) network() string {
+	if strings.EqualFold(c.Network, NetworkTCP) {
+		return NetworkTCP
+	}
+	return NetworkUDP
+}
+
+// address 补全端口的 PLC 地址
+func (c ClientConfig) address() string {
+	server := c.Server
+	for _, prefix := range []string{"udp://", "tcp://"} {
+		server = strings.TrimPrefix(server, prefix)
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, DefaultPort)
+	}
+	return server
+}
+
+// Client FINS/UDP 或 FINS/TCP 客户端，请求是串行的，可以并发调用
+// 通信出错时关闭连接，由调用方重新建立
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	tcp     bool
+	timeout time.Duration
+	// header 请求头中的目标和源地址：DNA, DA1, DA2, SNA, SA1, SA2
+	header [6]byte
+	sid    byte
+	closed bool
+}
+
+// Dial 连接 PLC，FINS/TCP 还会交换节点号
+func Dial(config ClientConfig) (*Client, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	conn, err := net.DialTimeout(config.network(), config.address(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn = capture.WrapConn(conn, capture.Source("fins", config.address()))
+	c := &Client{
+		conn:    conn,
+		tcp:     config.network() == NetworkTCP,
+		timeout: timeout,
+		header:  [6]byte{config.DestNet, config.DestNode, config.DestUnit, config.SrcNet, config.SrcNode, 0},
+	}
+	if c.tcp {
+		err = c.handshake()
+	} else {
+		if c.header[1] == 0 {
+			c.header[1] = lastOctet(conn.RemoteAddr())
+		}
+		if c.header[4] == 0 {
+			c.header[4] = lastOctet(conn.LocalAddr())
+		}
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// lastOctet IPv4 地址的最后一个字节
+func lastOctet(addr net.Addr) byte {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		ip = a.IP
+	case *net.TCPAddr:
+		ip = a.IP
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4[3]
+	}
+	return 0
+}
+
+// handshake FINS/TCP 交换节点号
+func (c *Client) handshake() error {
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := writeTCPFrame(c.conn, tcpNodeRequest, []byte{0, 0, 0, c.header[4]}); err != nil {
+		return err
+	}
+	command, payload, err := readTCPFrame(c.conn)
+	if err != nil {
+		return err
+	}
+	if command != tcpNodeResponse || len(payload) < 8 {
+		return fmt.Errorf("fins/tcp: unexpected node address response")
+	}
+	c.header[4] = payload[3]
+	if c.header[1] == 0 {
+		c.header[1] = payload[7]
+	}
+	return nil
+}
+
+// Closed 连接是否已经关闭
+func (c *Client) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
+
+// ReadItems 读取多个变量，返回的值与 items 一一对应
+func (c *Client) ReadItems(items []*Address) ([]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		data, err := c.read(item)
+		if err != nil {
+			return nil, err
+		}
+		if values[i], err = item.Decode(data); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// WriteItems 写入多个变量，values 与 items 一一对应
+func (c *Client) WriteItems(items []*Address, values [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	for i, item := range items {
+		if len(values[i]) != item.Size() {
+			return fmt.Errorf("fins %s: expected %d bytes, got %d", item, item.Size(), len(values[i]))
+		}
+		if err := c.write(item, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read 读取一个变量，超过单次读取字数的变量拆分为多个请求
+func (c *Client) read(item *Address) ([]byte, error) {
+	if item.DataType == DataTypeBool {
+		return c.memoryArea(item, srcRead, item.Word, 1, nil)
+	}
+	data := make([]byte, 0, item.Size())
+	for offset := 0; offset < item.Words; offset += maxReadWords {
+		count := item.Words - offset
+		if count > maxReadWords {
+			count = maxReadWords
+		}
+		resp, err := c.memoryArea(item, srcRead, item.Word+uint16(offset), count, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) != count*2 {
+			return nil, fmt.Errorf("fins read %s: unexpected data length %d", item, len(resp))
+		}
+		data = append(data, resp...)
+	}
+	return data, nil
+}
+
+// write 写入一个变量，超过单次写入字数的变量拆分为多个请求
+func (c *Client) write(item *Address, value []byte) error {
+	if item.DataType == DataTypeBool {
+		_, err := c.memoryArea(item, srcWrite, item.Word, 1, value)
+		return err
+	}
+	for offset := 0; offset < item.Words; offset += maxWriteWords {
+		count := item.Words - offset
+		if count > maxWriteWords {
+			count = maxWriteWords
+		}
+		if _, err := c.memoryArea(item, srcWrite, item.Word+uint16(offset), count, value[offset*2:(offset+count)*2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryArea 执行存储区读写命令，count 为字数或者位数
+func (c *Client) memoryArea(item *Address, src byte, word uint16, count int, data []byte) ([]byte, error) {
+	bit := byte(0)
+	if item.DataType == DataTypeBool {
+		bit = byte(item.Bit)
+	}
+	command := []byte{mrcMemoryArea, src, item.areaCode(), byte(word >> 8), byte(word), bit, byte(count >> 8), byte(count)}
+	resp, err := c.exchange(append(command, data...))
+	if err != nil {
+		var endCode *EndCodeError
+		if errors.As(err, &endCode) {
+			endCode.Address = item.String()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// exchange 发送 FINS 命令并读取响应，返回结束码之后的数据
+// 通信出错时关闭连接
+func (c *Client) exchange(command []byte) ([]byte, error) {
+	c.sid++
+	frame := make([]byte, 0, headerSize+len(command))
+	frame = append(frame, icfCommand, 0x00, gatewayCount)
+	frame = append(frame, c.header[:]...)
+	frame = append(frame, c.sid)
+	frame = append(frame, command...)
+
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	var err error
+	if c.tcp {
+		err = writeTCPFrame(c.conn, tcpFrame, frame)
+	} else {
+		_, err = c.conn.Write(frame)
+	}
+	if err != nil {
+		c.closeOnError()
+		return nil, err
+	}
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			c.closeOnError()
+			return nil, err
+		}
+		if len(resp) < headerSize+4 || resp[0]&icfResponse == 0 {
+			c.closeOnError()
+			return nil, fmt.Errorf("fins: invalid response")
+		}
+		// UDP 可能收到之前超时的请求的响应，丢弃
+		if resp[9] != c.sid {
+			if c.tcp {
+				c.closeOnError()
+				return nil, fmt.Errorf("fins: unexpected response")
+			}
+			continue
+		}
+		if resp[10] != command[0] || resp[11] != command[1] {
+			c.closeOnError()
+			return nil, fmt.Errorf("fins: unexpected response")
+		}
+		// 忽略中继错误标志和 CPU 错误标志
+		if main, sub := resp[12]&0x7f, resp[13]&0x3f; main != 0 || sub != 0 {
+			return nil, &EndCodeError{Main: main, Sub: sub}
+		}
+		return resp[headerSize+4:], nil
+	}
+}
+
+// readResponse 读取一个响应帧
+func (c *Client) readResponse() ([]byte, error) {
+	if !c.tcp {
+		buf := make([]byte, maxResponseBytes+headerSize+4)
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	command, payload, err := readTCPFrame(c.conn)
+	if err != nil {
+		return nil, err
+	}
+	if command != tcpFrame {
+		return nil, fmt.Errorf("fins/tcp: unexpected command %d", command)
+	}
+	return payload, nil
+}
+
+// closeOnError 通信出错后连接状态未知，关闭连接
+func (c *Client) closeOnError() {
+	c.closed = true
+	_ = c.conn.Close()
+}
+
+// writeTCPFrame 发送 FINS/TCP 报文，头部为 "FINS"、长度、命令和错误码
+func writeTCPFrame(w io.Writer, command uint32, payload []byte) error {
+	frame := make([]byte, tcpHeaderSize, tcpHeaderSize+len(payload))
+	copy(frame, "FINS")
+	binary.BigEndian.PutUint32(frame[4:8], uint32(8+len(payload)))
+	binary.BigEndian.PutUint32(frame[8:12], command)
+	_, err := w.Write(append(frame, payload...))
+	return err
+}
+
+// readTCPFrame 读取一个 FINS/TCP 报文，返回命令和去掉头部的内容
+func readTCPFrame(r io.Reader) (uint32, []byte, error) {
+	header := make([]byte, tcpHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[4:8])
+	if string(header[:4]) != "FINS" || length < 8 || length > maxResponseBytes+headerSize+4+8 {
+		return 0, nil, fmt.Errorf("fins/tcp: invalid header")
+	}
+	if code := binary.BigEndian.Uint32(header[12:16]); code != 0 {
+		return 0, nil, fmt.Errorf("fins/tcp: error code 0x%08x", code)
+	}
+	payload := make([]byte, length-8)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return binary.BigEndian.Uint32(header[8:12]), payload, nil
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fins
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// Server PLC 地址，格式：host:port，端口默认 9600
+	Server string `json:"server" label:"Server" desc:"PLC address, format: host:port, port defaults to 9600" required:"true" ref:"primary"`
+	// Network 传输协议：udp 或者 tcp
+	Network string `json:"network" label:"Network" desc:"Transport: udp or tcp"`
+	// DestNet 目标网络号，本地网络为 0
+	DestNet int `json:"destNet" label:"Destination network" desc:"FINS destination network address, 0 for the local network"`
+	// DestNode 目标节点号，0 表示自动：UDP 使用 PLC IP 地址的最后一个字节，TCP 使用握手时 PLC 返回的节点号
+	DestNode int `json:"destNode" label:"Destination node" desc:"FINS destination node address, 0 uses the last octet of the PLC IP (udp) or the node returned by the PLC (tcp)"`
+	// DestUnit 目标单元号，CPU 为 0
+	DestUnit int `json:"destUnit" label:"Destination unit" desc:"FINS destination unit address, 0 for the CPU unit"`
+	// SrcNode 源节点号，0 表示自动：UDP 使用本机 IP 地址的最后一个字节，TCP 由 PLC 分配
+	SrcNode int `json:"srcNode" label:"Source node" desc:"FINS source node address, 0 uses the last octet of the local IP (udp) or is assigned by the PLC (tcp)"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Items 读取的变量，为空则使用消息负荷 msg.Data 中的变量
+	Items []Item `json:"items" label:"Items" desc:"Variables to read, empty uses the variables in msg.Data"`
+}
+
+// Item 读取的变量
+type Item struct {
+	// Name 变量名称，作为输出的 key，为空使用地址
+	Name string `json:"name,omitempty"`
+	// Address 变量地址，eg. D100:REAL，见 ParseAddress
+	Address string `json:"address"`
+}
+
+// ReadNode 欧姆龙 FINS 读取节点，通过 FINS/UDP 或 FINS/TCP 读取 CJ/CP/CS/NX 系列 PLC 的 D/W/H/CIO 区变量
+// 变量来自配置 items 或者消息负荷 msg.Data，格式：
+//
+//	[
+//	  {"name": "temperature", "address": "D100:REAL"},
+//	  {"name": "running", "address": "W10.05"}
+//	]
+//
+// 也可以是地址数组：["D100:REAL", "W10.05"]。结果以变量名称为 key 重新赋值到msg.Data：
+//
+//	{"temperature": 21.5, "running": true}
+//
+// 相同 PLC 和节点地址的节点共享一个连接。所有变量读取成功，流转到`Success`链，否则流转到`Failure`链
+type ReadNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config ReadConfiguration
+	// addresses 配置的变量解析后的地址
+	addresses []*Address
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/finsRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Server:  DefaultServer,
+			Network: NetworkUDP,
+			Timeout: 5,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.addresses, err = parseItems(x.Config.Items); err != nil {
+		return err
+	}
+	config, err := newClientConfig(x.Config.Server, x.Config.Network, x.Config.DestNet, x.Config.DestNode, x.Config.DestUnit, x.Config.SrcNode, x.Config.Timeout)
+	if err != nil {
+		return err
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), config)
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items, addresses := x.Config.Items, x.addresses
+	if len(items) == 0 {
+		var err error
+		if items, err = parseReadItems(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if addresses, err = parseItems(items); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var values []interface{}
+	err = conn.Do(func(client *Client) error {
+		values, err = client.ReadItems(addresses)
+		return err
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{}, len(items))
+	for i, item := range items {
+		result[item.key()] = values[i]
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "Omron FINS UDP/TCP reader for CJ/CP/CS/NX D/W/H/CIO areas with word and bit access. Routes to Success/Failure"
+}
+
+// key 输出的 key，名称为空使用地址
+func (i Item) key() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return i.Address
+}
+
+// newClientConfig 校验节点地址并创建客户端配置
+func newClientConfig(server, network string, destNet, destNode, destUnit, srcNode int, timeout int64) (ClientConfig, error) {
+	if network != "" && !strings.EqualFold(network, NetworkUDP) && !strings.EqualFold(network, NetworkTCP) {
+		return ClientConfig{}, fmt.Errorf("unsupported fins network: %s", network)
+	}
+	for _, v := range []int{destNet, destNode, destUnit, srcNode} {
+		if v < 0 || v > 0xff {
+			return ClientConfig{}, fmt.Errorf("invalid fins node address: %d", v)
+		}
+	}
+	return ClientConfig{
+		Server:   server,
+		Network:  network,
+		DestNet:  byte(destNet),
+		DestNode: byte(destNode),
+		DestUnit: byte(destUnit),
+		SrcNode:  byte(srcNode),
+		Timeout:  time.Duration(timeout) * time.Second,
+	}, nil
+}
+
+// initSharedConn 初始化共享连接，相同 PLC 和节点地址的组件共用一个连接
+func initSharedConn(node *base.SharedNode[*SharedConn], ruleConfig types.Config, nodeType string, config ClientConfig) error {
+	return node.InitWithClose(ruleConfig, nodeType, config.Key(), ruleConfig.NodeClientInitNow, func() (*SharedConn, error) {
+		conn := AcquireConn(config)
+		if _, err := conn.Client(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
+
+// parseItems 解析变量地址
+func parseItems(items []Item) ([]*Address, error) {
+	addresses := make([]*Address, 0, len(items))
+	for _, item := range items {
+		a, err := ParseAddress(item.Address)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, nil
+}
+
+// parseReadItems 解析消息负荷中的变量，支持变量数组或者地址数组
+func parseReadItems(data string) ([]Item, error) {
+	data = strings.TrimSpace(data)
+	var items []Item
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		var list []string
+		if json.Unmarshal([]byte(data), &list) != nil {
+			return nil, err
+		}
+		items = nil
+		for _, address := range list {
+			items = append(items, Item{Address: address})
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no fins variables to read")
+	}
+	return items, nil
+}
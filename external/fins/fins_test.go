@@ -0,0 +1,354 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fins
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testPLC 测试用的 FINS PLC，同时监听 UDP 和 TCP，只实现存储区读写
+type testPLC struct {
+	udp      net.PacketConn
+	listener net.Listener
+	mu       sync.Mutex
+	// memory 存储区，key 为字访问的存储区编码，每个字2个字节
+	memory map[byte][]byte
+	// requests 收到的读写请求数量
+	requests int
+	// headers 收到的最后一个请求头
+	header []byte
+}
+
+func startTestPLC(t *testing.T) *testPLC {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	listener, err := net.Listen("tcp", udp.LocalAddr().String())
+	assert.Nil(t, err)
+	plc := &testPLC{
+		udp:      udp,
+		listener: listener,
+		memory: map[byte][]byte{
+			0xb0: make([]byte, 200),
+			0xb1: make([]byte, 200),
+			0xb2: make([]byte, 200),
+			0x82: make([]byte, 4000),
+		},
+	}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := plc.handle(buf[:n]); resp != nil {
+				_, _ = udp.WriteTo(resp, addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go plc.serveTCP(conn)
+		}
+	}()
+	return plc
+}
+
+func (p *testPLC) server() string {
+	return p.udp.LocalAddr().String()
+}
+
+func (p *testPLC) close() {
+	_ = p.udp.Close()
+	_ = p.listener.Close()
+}
+
+// area 返回存储区的副本
+func (p *testPLC) area(code byte) []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]byte(nil), p.memory[code]...)
+}
+
+// set 写入存储区，offset 为字节偏移
+func (p *testPLC) set(code byte, offset int, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	copy(p.memory[code][offset:], data)
+}
+
+func (p *testPLC) serveTCP(conn net.Conn) {
+	defer conn.Close()
+	for {
+		command, payload, err := readTCPFrame(conn)
+		if err != nil {
+			return
+		}
+		switch command {
+		case tcpNodeRequest:
+			// 客户端节点号 0 自动分配为 10，服务端节点号为 1
+			client := payload[3]
+			if client == 0 {
+				client = 10
+			}
+			err = writeTCPFrame(conn, tcpNodeResponse, []byte{0, 0, 0, client, 0, 0, 0, 1})
+		case tcpFrame:
+			err = writeTCPFrame(conn, tcpFrame, p.handle(payload))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle 处理 FINS 命令，返回响应帧
+func (p *testPLC) handle(frame []byte) []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests++
+	p.header = append([]byte(nil), frame[:headerSize]...)
+	// 交换源地址和目标地址
+	resp := []byte{0xc0, 0, gatewayCount, frame[6], frame[7], frame[8], frame[3], frame[4], frame[5], frame[9], frame[10], frame[11]}
+	code, word, bit := frame[12], int(binary.BigEndian.Uint16(frame[13:15])), int(frame[15])
+	count := int(binary.BigEndian.Uint16(frame[16:18]))
+	mem := p.memory[code|0x80]
+	if mem == nil {
+		return append(resp, 0x11, 0x01)
+	}
+	isBit := code&0x80 == 0
+	if (word+count)*2 > len(mem) {
+		return append(resp, 0x11, 0x03)
+	}
+	switch frame[11] {
+	case srcRead:
+		resp = append(resp, 0, 0)
+		if isBit {
+			return append(resp, byte(binary.BigEndian.Uint16(mem[word*2:])>>bit)&0x01)
+		}
+		return append(resp, mem[word*2:(word+count)*2]...)
+	case srcWrite:
+		data := frame[18:]
+		if isBit {
+			v := binary.BigEndian.Uint16(mem[word*2:])&^(1<<bit) | uint16(data[0]&0x01)<<bit
+			binary.BigEndian.PutUint16(mem[word*2:], v)
+		} else {
+			copy(mem[word*2:], data[:count*2])
+		}
+		return append(resp, 0, 0)
+	}
+	return append(resp, 0x04, 0x01)
+}
+
+func TestReadWriteNode(t *testing.T) {
+	plc := startTestPLC(t)
+	defer plc.close()
+
+	plc.set(0x82, 200, []byte{0x00, 0x00, 0x41, 0xac})
+	plc.set(0x82, 300, []byte("hello\x00"))
+	plc.set(0xb1, 20, []byte{0x00, 0x20})
+	plc.set(0xb2, 4, []byte{0xff, 0xfb})
+	plc.set(0x82, 2000, []byte(strings.Repeat("x", 1998)))
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	Registry.Add(&WriteNode{})
+
+	// 非法地址和节点号
+	_, err := test.CreateAndInitNode("x/finsRead", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"address": "D100.1:REAL"}},
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/finsRead", types.Configuration{
+		"server":   plc.server(),
+		"destNode": 256,
+	}, Registry)
+	assert.NotNil(t, err)
+
+	for _, network := range []string{NetworkUDP, NetworkTCP} {
+		node, err := test.CreateAndInitNode("x/finsRead", types.Configuration{
+			"server":  plc.server(),
+			"network": network,
+			"items": []map[string]interface{}{
+				{"name": "temperature", "address": "D100:REAL"},
+				{"name": "running", "address": "W10.05"},
+				{"name": "level", "address": "H2:INT"},
+				{"name": "model", "address": "D150:STRING[10]"},
+				{"name": "long", "address": "D1000:STRING[1998]"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+
+		test.NodeOnMsg(t, node, []test.Msg{{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "READ",
+			Data:       `{}`,
+			AfterSleep: time.Millisecond * 200,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			values := make(map[string]interface{})
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+			assert.Equal(t, 21.5, values["temperature"])
+			assert.Equal(t, true, values["running"])
+			assert.Equal(t, float64(-5), values["level"])
+			assert.Equal(t, "hello", values["model"])
+			assert.Equal(t, strings.Repeat("x", 1998), values["long"])
+		})
+		node.Destroy()
+	}
+	plc.mu.Lock()
+	assert.Equal(t, 10, plc.requests)
+	plc.mu.Unlock()
+
+	writer, err := test.CreateAndInitNode("x/finsWrite", types.Configuration{
+		"server":   plc.server(),
+		"network":  NetworkTCP,
+		"destNode": 5,
+	}, Registry)
+	assert.Nil(t, err)
+	defer writer.Destroy()
+	templateWriter, err := test.CreateAndInitNode("x/finsWrite", types.Configuration{
+		"server":  plc.server(),
+		"srcNode": 30,
+		"items":   []map[string]interface{}{{"address": "D100:REAL", "value": "${metadata.setpoint}"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer templateWriter.Destroy()
+
+	test.NodeOnMsg(t, writer, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "WRITE",
+			Data:       `{"W10.04": true, "H2:INT": -100, "D150:STRING[10]": "world"}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "OUT_OF_RANGE",
+			Data:       `{"CIO150:DINT": 1}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "OUT_OF_RANGE" {
+			assert.Equal(t, types.Failure, relationType)
+			var endCode *EndCodeError
+			assert.True(t, errors.As(err, &endCode))
+			assert.Equal(t, byte(0x11), endCode.Main)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+	})
+	// TCP 使用配置的目标节点号和 PLC 分配的源节点号
+	plc.mu.Lock()
+	assert.Equal(t, byte(5), plc.header[4])
+	assert.Equal(t, byte(10), plc.header[7])
+	plc.mu.Unlock()
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("setpoint", "42.5")
+	test.NodeOnMsg(t, templateWriter, []test.Msg{{
+		MetaData:   metadata,
+		DataType:   types.JSON,
+		MsgType:    "WRITE",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+	// UDP 使用 PLC IP 地址的最后一个字节作为目标节点号
+	plc.mu.Lock()
+	assert.Equal(t, byte(1), plc.header[4])
+	assert.Equal(t, byte(30), plc.header[7])
+	plc.mu.Unlock()
+	assert.Equal(t, float32(42.5), math.Float32frombits(uint32(swapWords(plc.area(0x82)[200:], 2))))
+
+	reader, err := test.CreateAndInitNode("x/finsRead", types.Configuration{
+		"server": plc.server(),
+	}, Registry)
+	assert.Nil(t, err)
+	defer reader.Destroy()
+	test.NodeOnMsg(t, reader, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `["W10.04", "W10.05", "H2:INT", "D150:STRING[10]", "D100:REAL"]`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, true, values["W10.04"])
+		assert.Equal(t, true, values["W10.05"])
+		assert.Equal(t, float64(-100), values["H2:INT"])
+		assert.Equal(t, "world", values["D150:STRING[10]"])
+		assert.Equal(t, 42.5, values["D100:REAL"])
+	})
+}
+
+func TestSharedConnReconnect(t *testing.T) {
+	plc := startTestPLC(t)
+	defer plc.close()
+
+	conn := AcquireConn(ClientConfig{Server: plc.server(), Network: NetworkTCP, Timeout: time.Second})
+	defer conn.Release()
+	client, err := conn.Client()
+	assert.Nil(t, err)
+
+	a, _ := ParseAddress("D0")
+	// 模拟连接断开，Do 重新连接后重试
+	_ = client.conn.Close()
+	err = conn.Do(func(client *Client) error {
+		_, err := client.ReadItems([]*Address{a})
+		return err
+	})
+	assert.Nil(t, err)
+	newClient, _ := conn.Client()
+	assert.True(t, newClient != client)
+
+	// 超过单次写入字数的变量拆分写入
+	a, _ = ParseAddress("D1000:STRING[1998]")
+	value, _ := a.Encode(strings.Repeat("y", 1998))
+	plc.mu.Lock()
+	requests := plc.requests
+	plc.mu.Unlock()
+	assert.Nil(t, newClient.WriteItems([]*Address{a}, [][]byte{value}))
+	plc.mu.Lock()
+	assert.Equal(t, requests+2, plc.requests)
+	plc.mu.Unlock()
+	values, err := newClient.ReadItems([]*Address{a})
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("y", 1998), values[0])
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fins
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteConfiguration 写入节点配置
+type WriteConfiguration struct {
+	// Server PLC 地址，格式：host:port，端口默认 9600
+	Server string `json:"server" label:"Server" desc:"PLC address, format: host:port, port defaults to 9600" required:"true" ref:"primary"`
+	// Network 传输协议：udp 或者 tcp
+	Network string `json:"network" label:"Network" desc:"Transport: udp or tcp"`
+	// DestNet 目标网络号，本地网络为 0
+	DestNet int `json:"destNet" label:"Destination network" desc:"FINS destination network address, 0 for the local network"`
+	// DestNode 目标节点号，0 表示自动：UDP 使用 PLC IP 地址的最后一个字节，TCP 使用握手时 PLC 返回的节点号
+	DestNode int `json:"destNode" label:"Destination node" desc:"FINS destination node address, 0 uses the last octet of the PLC IP (udp) or the node returned by the PLC (tcp)"`
+	// DestUnit 目标单元号，CPU 为 0
+	DestUnit int `json:"destUnit" label:"Destination unit" desc:"FINS destination unit address, 0 for the CPU unit"`
+	// SrcNode 源节点号，0 表示自动：UDP 使用本机 IP 地址的最后一个字节，TCP 由 PLC 分配
+	SrcNode int `json:"srcNode" label:"Source node" desc:"FINS source node address, 0 uses the last octet of the local IP (udp) or is assigned by the PLC (tcp)"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Items 写入的变量，为空则使用消息负荷 msg.Data 中的变量
+	Items []WriteItem `json:"items" label:"Items" desc:"Variables to write, empty uses the address-value object in msg.Data"`
+}
+
+// WriteItem 写入的变量
+type WriteItem struct {
+	// Address 变量地址，eg. D100:REAL，见 ParseAddress
+	Address string `json:"address"`
+	// Value 写入的值，允许使用 ${} 占位符变量
+	Value string `json:"value"`
+}
+
+// WriteNode 欧姆龙 FINS 写入节点，通过 FINS/UDP 或 FINS/TCP 写入 CJ/CP/CS/NX 系列 PLC 的 D/W/H/CIO 区变量
+// 变量来自配置 items，值允许使用 ${} 占位符变量，或者消息负荷 msg.Data 中地址->值的对象：
+//
+//	{"D100:REAL": 21.5, "W10.05": true, "D200:STRING[10]": "hello"}
+//
+// 相同 PLC 和节点地址的节点共享一个连接。所有变量写入成功，流转到`Success`链，否则流转到`Failure`链
+type WriteNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config WriteConfiguration
+	// addresses 配置的变量解析后的地址
+	addresses []*Address
+	// valueTemplates 配置的变量值模板
+	valueTemplates []str.Template
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/finsWrite"
+}
+
+// New 默认参数
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteConfiguration{
+			Server:  DefaultServer,
+			Network: NetworkUDP,
+			Timeout: 5,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.addresses = nil
+	x.valueTemplates = nil
+	for _, item := range x.Config.Items {
+		a, err := ParseAddress(item.Address)
+		if err != nil {
+			return err
+		}
+		x.addresses = append(x.addresses, a)
+		x.valueTemplates = append(x.valueTemplates, str.NewTemplate(item.Value))
+	}
+	config, err := newClientConfig(x.Config.Server, x.Config.Network, x.Config.DestNet, x.Config.DestNode, x.Config.DestUnit, x.Config.SrcNode, x.Config.Timeout)
+	if err != nil {
+		return err
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), config)
+}
+
+// OnMsg 处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	addresses, values, err := x.getValues(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	err = conn.Do(func(client *Client) error {
+		return client.WriteItems(addresses, values)
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// getValues 获取写入的变量和编码后的值
+func (x *WriteNode) getValues(ctx types.RuleContext, msg types.RuleMsg) ([]*Address, [][]byte, error) {
+	if len(x.addresses) > 0 {
+		evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		values := make([][]byte, 0, len(x.addresses))
+		for i, a := range x.addresses {
+			v, err := a.Encode(x.valueTemplates[i].Execute(evn))
+			if err != nil {
+				return nil, nil, err
+			}
+			values = append(values, v)
+		}
+		return x.addresses, values, nil
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(msg.GetData()), &data); err != nil {
+		return nil, nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("no fins variables to write")
+	}
+	addresses := make([]*Address, 0, len(data))
+	values := make([][]byte, 0, len(data))
+	for address, value := range data {
+		a, err := ParseAddress(address)
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := a.Encode(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		addresses = append(addresses, a)
+		values = append(values, v)
+	}
+	return addresses, values, nil
+}
+
+// Destroy 销毁组件
+func (x *WriteNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Omron FINS UDP/TCP writer for CJ/CP/CS/NX D/W/H/CIO areas with word and bit access. Routes to Success/Failure"
+}
@@ -0,0 +1,332 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package focas
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPort FOCAS2 以太网端口
+	DefaultPort = "8193"
+	// DefaultServer 默认 CNC 地址
+	DefaultServer = "127.0.0.1:8193"
+	// DefaultTimeout 默认连接和请求超时
+	DefaultTimeout = 5 * time.Second
+	// ModeNative 通过 cgo 调用 FOCAS 原生库（libfwlib32），需要使用 -tags focas 编译
+	ModeNative = "native"
+	// ModeGateway 通过 HTTP 网关访问，网关运行 FOCAS 原生库，见 GatewayClient
+	ModeGateway = "gateway"
+	// maxMacros 单次读取的最大宏变量数量
+	maxMacros = 1000
+)
+
+// ErrClosed 连接已经关闭
+var ErrClosed = errors.New("focas connection is closed")
+
+// ErrNativeUnsupported 没有编译 FOCAS 原生库支持
+var ErrNativeUnsupported = errors.New("focas native library is not available, build with -tags focas and cgo enabled or use gateway mode")
+
+// Error FOCAS 函数返回的错误码
+type Error struct {
+	// Func FOCAS 函数名称，eg. cnc_statinfo
+	Func string
+	// Code 返回码，见 FOCAS 文档 EW_xxx
+	Code int
+}
+
+func (e *Error) Error() string {
+	var reason string
+	switch e.Code {
+	case -17:
+		reason = "protocol error"
+	case -16:
+		reason = "socket error"
+	case -15:
+		reason = "dll not exist"
+	case -8:
+		reason = "handle number error"
+	case -7:
+		reason = "version mismatch"
+	case -6:
+		reason = "abnormal library state"
+	case -5:
+		reason = "system error"
+	case -2:
+		reason = "reset or stop request"
+	case -1:
+		reason = "busy"
+	case 1:
+		reason = "function not executed or not available"
+	case 2:
+		reason = "data block length error"
+	case 3:
+		reason = "data number error"
+	case 4:
+		reason = "data attribute error"
+	case 5:
+		reason = "data error"
+	case 6:
+		reason = "no option"
+	case 7:
+		reason = "write protection"
+	case 8:
+		reason = "memory overflow"
+	case 9:
+		reason = "cnc parameter error"
+	case 10:
+		reason = "buffer empty or full"
+	case 11:
+		reason = "path number error"
+	case 12:
+		reason = "cnc mode error"
+	case 13:
+		reason = "cnc execution rejection"
+	case 15:
+		reason = "cnc alarm"
+	case 16:
+		reason = "cnc stop or emergency"
+	case 17:
+		reason = "data protected"
+	default:
+		reason = "unknown error"
+	}
+	return fmt.Sprintf("focas %s: %s (%d)", e.Func, reason, e.Code)
+}
+
+// Broken 错误是否表示连接已经不可用，需要重新获取句柄
+func (e *Error) Broken() bool {
+	return e.Code == -16 || e.Code == -17 || e.Code == -8
+}
+
+// Status CNC 运行状态，见 cnc_statinfo
+type Status struct {
+	// Mode 操作方式：MDI、MEM、EDIT、HND、JOG、INC、REF、RMT 等
+	Mode string `json:"mode"`
+	// Run 自动运行状态：reset、stop、hold、start、mstr
+	Run string `json:"run"`
+	// Motion 轴运动状态：none、motion、dwell
+	Motion string `json:"motion"`
+	// Emergency 急停
+	Emergency bool `json:"emergency"`
+	// Alarm 有报警
+	Alarm bool `json:"alarm"`
+	// Program 当前执行的程序号
+	Program int `json:"program"`
+	// MainProgram 主程序号
+	MainProgram int `json:"mainProgram"`
+}
+
+// modes 操作方式，下标为 cnc_statinfo 的 aut
+var modes = []string{"MDI", "MEM", "****", "EDIT", "HND", "JOG", "TJOG", "THND", "INC", "REF", "RMT"}
+
+// runs 自动运行状态，下标为 cnc_statinfo 的 run
+var runs = []string{"reset", "stop", "hold", "start", "mstr"}
+
+// motions 轴运动状态，下标为 cnc_statinfo 的 motion
+var motions = []string{"none", "motion", "dwell"}
+
+// NewStatus 转换 cnc_statinfo 返回的状态码
+func NewStatus(aut, run, motion, emergency, alarm int) *Status {
+	return &Status{
+		Mode:      lookup(modes, aut),
+		Run:       lookup(runs, run),
+		Motion:    lookup(motions, motion),
+		Emergency: emergency == 1,
+		Alarm:     alarm == 1,
+	}
+}
+
+// Alarm CNC 报警，见 cnc_rdalmmsg2
+type Alarm struct {
+	// Number 报警号
+	Number int `json:"number"`
+	// Type 报警类型：SW、PW、IO、PS、OT、OH、SV、SR、MC、SP、DS、IE、BG、SN、EX、PC
+	Type string `json:"type"`
+	// Axis 报警的轴号，0 表示与轴无关
+	Axis int `json:"axis"`
+	// Message 报警信息
+	Message string `json:"message"`
+}
+
+// alarmTypes 报警类型，下标为 cnc_rdalmmsg2 的 type
+var alarmTypes = []string{"SW", "PW", "IO", "PS", "OT", "OH", "SV", "SR", "MC", "SP", "DS", "IE", "BG", "SN", "", "EX", "", "", "", "PC"}
+
+// AlarmType 报警类型名称
+func AlarmType(t int) string {
+	return lookup(alarmTypes, t)
+}
+
+// Overrides 倍率，单位：%
+type Overrides struct {
+	// Feed 进给倍率 0~254
+	Feed int `json:"feed"`
+	// Rapid 快速移动倍率 100、50、25 或者 0（F0）
+	Rapid int `json:"rapid"`
+	// Spindle 主轴倍率
+	Spindle int `json:"spindle"`
+}
+
+// NewOverrides 转换 PMC 信号中的倍率
+// g12 进给倍率信号 *FV0~*FV7（负逻辑），g14 快速移动倍率信号 ROV1/ROV2，g30 主轴倍率信号 SOV0~SOV7
+func NewOverrides(g12, g14, g30 byte) *Overrides {
+	rapid := 0
+	switch g14 & 0x03 {
+	case 0:
+		rapid = 100
+	case 1:
+		rapid = 50
+	case 2:
+		rapid = 25
+	}
+	return &Overrides{
+		Feed:    int(^g12),
+		Rapid:   rapid,
+		Spindle: int(g30),
+	}
+}
+
+// MacroValue 转换 cnc_rdmacro 返回的宏变量值：mcr_val / 10^dec_val
+// 空变量（<空>）返回 nil
+func MacroValue(mcr int32, dec int16) interface{} {
+	if mcr == 0 && dec == -1 {
+		return nil
+	}
+	return float64(mcr) / math.Pow10(int(dec))
+}
+
+// ParseMacros 解析宏变量号，逗号分隔，支持范围，eg. 100-105,500
+func ParseMacros(s string) ([]int, error) {
+	var numbers []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "#")
+		if part == "" {
+			continue
+		}
+		start, end := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			start, end = strings.TrimSpace(part[:i]), strings.TrimPrefix(strings.TrimSpace(part[i+1:]), "#")
+		}
+		from, err := strconv.Atoi(start)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("invalid macro variable %q", part)
+		}
+		to, err := strconv.Atoi(end)
+		if err != nil || to < from {
+			return nil, fmt.Errorf("invalid macro variable %q", part)
+		}
+		for n := from; n <= to; n++ {
+			if !seen[n] {
+				seen[n] = true
+				numbers = append(numbers, n)
+			}
+			if len(numbers) > maxMacros {
+				return nil, fmt.Errorf("too many macro variables, max %d", maxMacros)
+			}
+		}
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+// Client FOCAS 客户端，由原生库或者网关实现
+// 客户端不是并发安全的，由 SharedConn 保证同一时间只有一个请求
+type Client interface {
+	// Status 读取运行状态和程序号
+	Status() (*Status, error)
+	// Alarms 读取当前报警
+	Alarms() ([]Alarm, error)
+	// Overrides 读取进给、快速移动和主轴倍率
+	Overrides() (*Overrides, error)
+	// ReadMacros 读取宏变量，返回值与 numbers 一一对应，空变量为 nil
+	ReadMacros(numbers []int) ([]interface{}, error)
+	// Closed 连接是否已经断开
+	Closed() bool
+	// Close 释放句柄
+	Close() error
+}
+
+// ClientConfig 创建 FOCAS 客户端的配置
+type ClientConfig struct {
+	// Mode 访问方式：native 或者 gateway，默认 native
+	Mode string
+	// Server CNC 地址，格式：host:port，端口默认 8193
+	Server string
+	// Gateway 网关地址，gateway 方式使用，eg. http://127.0.0.1:8080
+	Gateway string
+	// Timeout 连接和请求超时
+	Timeout time.Duration
+}
+
+// Key 共享连接的 key，格式：mode://server，网关方式附加网关地址
+func (c ClientConfig) Key() string {
+	if c.mode() == ModeGateway {
+		return fmt.Sprintf("%s://%s@%s", ModeGateway, c.address(), c.Gateway)
+	}
+	return fmt.Sprintf("%s://%s", ModeNative, c.address())
+}
+
+// mode 访问方式，默认 native
+func (c ClientConfig) mode() string {
+	if strings.EqualFold(c.Mode, ModeGateway) {
+		return ModeGateway
+	}
+	return ModeNative
+}
+
+// address CNC 地址，没有端口使用默认端口
+func (c ClientConfig) address() string {
+	if _, _, err := net.SplitHostPort(c.Server); err != nil {
+		return net.JoinHostPort(c.Server, DefaultPort)
+	}
+	return c.Server
+}
+
+// timeout 超时，默认 DefaultTimeout
+func (c ClientConfig) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultTimeout
+}
+
+// Dial 按访问方式创建客户端
+func Dial(config ClientConfig) (Client, error) {
+	if config.Server == "" {
+		return nil, errors.New("focas server cannot be empty")
+	}
+	if config.mode() == ModeGateway {
+		return DialGateway(config)
+	}
+	return dialNative(config)
+}
+
+// lookup 状态码对应的名称，超出范围返回状态码
+func lookup(names []string, i int) string {
+	if i >= 0 && i < len(names) && names[i] != "" {
+		return names[i]
+	}
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package focas
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// Mode 访问方式：native 通过 cgo 调用 FOCAS 原生库，gateway 通过 HTTP 网关
+	Mode string `json:"mode" label:"Mode" desc:"Access mode: native (FOCAS library via cgo, build with -tags focas) or gateway (HTTP gateway)"`
+	// Server CNC 地址，格式：host:port，端口默认 8193
+	Server string `json:"server" label:"Server" desc:"CNC address, format: host:port, port defaults to 8193" required:"true" ref:"primary"`
+	// Gateway 网关地址，gateway 方式使用，eg. http://127.0.0.1:8080
+	Gateway string `json:"gateway" label:"Gateway" desc:"FOCAS HTTP gateway address, used by gateway mode"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Status 读取运行状态和程序号
+	Status bool `json:"status" label:"Status" desc:"Read operation mode, run state, emergency/alarm flags and program numbers"`
+	// Alarms 读取当前报警
+	Alarms bool `json:"alarms" label:"Alarms" desc:"Read active alarms"`
+	// Overrides 读取进给、快速移动和主轴倍率
+	Overrides bool `json:"overrides" label:"Overrides" desc:"Read feed, rapid and spindle overrides"`
+	// Macros 读取的宏变量号，逗号分隔，支持范围，eg. 100-105,500
+	Macros string `json:"macros" label:"Macros" desc:"Macro variables to read, comma separated with ranges, eg. 100-105,500"`
+}
+
+// ReadNode FANUC FOCAS 读取节点，读取 0i/30i/31i/32i 等 FANUC CNC 的运行状态、报警、倍率和宏变量
+// 支持两种访问方式：
+//   - native：通过 cgo 调用 FOCAS 原生库（Linux 版 libfwlib32），需要使用 -tags focas 编译
+//   - gateway：通过运行 FOCAS 原生库的 HTTP 网关访问，接口见 GatewayClient
+//
+// 结果重新赋值到msg.Data，只包含配置读取的部分：
+//
+//	{
+//	  "status": {"mode": "MEM", "run": "start", "motion": "motion", "emergency": false, "alarm": false, "program": 1234, "mainProgram": 1000},
+//	  "alarms": [{"number": 1000, "type": "PS", "axis": 0, "message": "..."}],
+//	  "overrides": {"feed": 100, "rapid": 100, "spindle": 100},
+//	  "macros": {"#100": 1.5, "#101": null}
+//	}
+//
+// 相同 CNC 的节点共享一个连接。读取成功，流转到`Success`链，否则流转到`Failure`链
+type ReadNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config ReadConfiguration
+	// macros 解析后的宏变量号
+	macros []int
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/focasRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Mode:    ModeNative,
+			Server:  DefaultServer,
+			Timeout: 5,
+			Status:  true,
+			Alarms:  true,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.macros, err = ParseMacros(x.Config.Macros); err != nil {
+		return err
+	}
+	if !x.Config.Status && !x.Config.Alarms && !x.Config.Overrides && len(x.macros) == 0 {
+		return errors.New("nothing to read, enable status, alarms, overrides or macros")
+	}
+	config := ClientConfig{
+		Mode:    x.Config.Mode,
+		Server:  x.Config.Server,
+		Gateway: x.Config.Gateway,
+		Timeout: time.Duration(x.Config.Timeout) * time.Second,
+	}
+	if config.Server == "" {
+		return errors.New("focas server cannot be empty")
+	}
+	if config.mode() == ModeGateway && config.Gateway == "" {
+		return errors.New("focas gateway cannot be empty")
+	}
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), config.Key(), ruleConfig.NodeClientInitNow, func() (*SharedConn, error) {
+		conn := AcquireConn(config)
+		if _, err := conn.Client(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{})
+	err = conn.Do(func(client Client) error {
+		return x.read(client, result)
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "FANUC CNC reader over FOCAS (native library or HTTP gateway) for status, alarms, overrides and macro variables. Routes to Success/Failure"
+}
+
+// read 读取配置的数据
+func (x *ReadNode) read(client Client, result map[string]interface{}) error {
+	if x.Config.Status {
+		status, err := client.Status()
+		if err != nil {
+			return err
+		}
+		result["status"] = status
+	}
+	if x.Config.Alarms {
+		alarms, err := client.Alarms()
+		if err != nil {
+			return err
+		}
+		result["alarms"] = alarms
+	}
+	if x.Config.Overrides {
+		overrides, err := client.Overrides()
+		if err != nil {
+			return err
+		}
+		result["overrides"] = overrides
+	}
+	if len(x.macros) > 0 {
+		values, err := client.ReadMacros(x.macros)
+		if err != nil {
+			return err
+		}
+		macros := make(map[string]interface{}, len(values))
+		for i, v := range values {
+			macros["#"+strconv.Itoa(x.macros[i])] = v
+		}
+		result["macros"] = macros
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package focas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GatewayClient 通过 HTTP 网关访问 FOCAS，网关运行在可以加载 FOCAS 原生库的主机上（如 Windows 工控机）
+// 每个请求通过查询参数 server 指定 CNC 地址，响应为 JSON：
+//
+//	GET /status?server=host:port          -> {"mode":"MEM","run":"start","motion":"motion","emergency":false,"alarm":false,"program":1234,"mainProgram":1000}
+//	GET /alarms?server=host:port          -> [{"number":1000,"type":"PS","axis":0,"message":"..."}]
+//	GET /overrides?server=host:port       -> {"feed":100,"rapid":100,"spindle":100}
+//	GET /macros?server=host:port&numbers=100,101 -> {"100":1.5,"101":null}
+//
+// 失败时返回非 2xx 状态码，响应体：{"error":"...","code":-16}，code 为 FOCAS 返回码
+type GatewayClient struct {
+	// Gateway 网关地址
+	Gateway string
+	// Server CNC 地址
+	Server string
+	// Client HTTP 客户端
+	Client *http.Client
+	// Timeout 请求超时
+	Timeout time.Duration
+}
+
+var _ Client = (*GatewayClient)(nil)
+
+// gatewayError 网关返回的错误
+type gatewayError struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
+
+// DialGateway 创建网关客户端，网关是无状态的，不会预先建立连接
+func DialGateway(config ClientConfig) (*GatewayClient, error) {
+	if config.Gateway == "" {
+		return nil, errors.New("focas gateway cannot be empty")
+	}
+	if _, err := url.Parse(config.Gateway); err != nil {
+		return nil, err
+	}
+	return &GatewayClient{
+		Gateway: strings.TrimSuffix(config.Gateway, "/"),
+		Server:  config.address(),
+		Client:  &http.Client{},
+		Timeout: config.timeout(),
+	}, nil
+}
+
+// Status 读取运行状态和程序号
+func (c *GatewayClient) Status() (*Status, error) {
+	var status Status
+	if err := c.get("status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Alarms 读取当前报警
+func (c *GatewayClient) Alarms() ([]Alarm, error) {
+	alarms := []Alarm{}
+	if err := c.get("alarms", nil, &alarms); err != nil {
+		return nil, err
+	}
+	return alarms, nil
+}
+
+// Overrides 读取进给、快速移动和主轴倍率
+func (c *GatewayClient) Overrides() (*Overrides, error) {
+	var overrides Overrides
+	if err := c.get("overrides", nil, &overrides); err != nil {
+		return nil, err
+	}
+	return &overrides, nil
+}
+
+// ReadMacros 读取宏变量
+func (c *GatewayClient) ReadMacros(numbers []int) ([]interface{}, error) {
+	list := make([]string, len(numbers))
+	for i, n := range numbers {
+		list[i] = strconv.Itoa(n)
+	}
+	var result map[string]interface{}
+	if err := c.get("macros", url.Values{"numbers": {strings.Join(list, ",")}}, &result); err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(numbers))
+	for i, n := range list {
+		v, ok := result[n]
+		if !ok {
+			return nil, fmt.Errorf("focas gateway: macro variable #%s missing", n)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Closed 网关是无状态的，总是返回 false
+func (c *GatewayClient) Closed() bool {
+	return false
+}
+
+// Close 关闭空闲连接
+func (c *GatewayClient) Close() error {
+	c.Client.CloseIdleConnections()
+	return nil
+}
+
+// get 请求网关并解析 JSON 响应
+func (c *GatewayClient) get(op string, query url.Values, v interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("server", c.Server)
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Gateway+"/"+op+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e gatewayError
+		if json.Unmarshal(body, &e) == nil && e.Code != 0 {
+			return &Error{Func: op, Code: e.Code}
+		}
+		if e.Error != "" {
+			return fmt.Errorf("focas gateway %s: %s", op, e.Error)
+		}
+		return fmt.Errorf("focas gateway %s: %s", op, resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
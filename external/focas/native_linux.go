@@ -0,0 +1,197 @@
+//go:build focas && cgo
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package focas
+
+/*
+#cgo LDFLAGS: -lfwlib32 -lm -lpthread
+#include <stdlib.h>
+#include "fwlib32.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+// maxAlarms 单次读取的最大报警数量
+const maxAlarms = 32
+
+// 原生库进程初始化，只执行一次
+var (
+	startupOnce sync.Once
+	startupErr  error
+)
+
+// nativeClient 通过 FOCAS 原生库访问 CNC
+// 编译需要 FOCAS 库的头文件和 libfwlib32.so，eg.
+//
+//	CGO_CFLAGS=-I/opt/fwlib CGO_LDFLAGS=-L/opt/fwlib go build -tags focas
+type nativeClient struct {
+	handle C.ushort
+	closed bool
+}
+
+var _ Client = (*nativeClient)(nil)
+
+func dialNative(config ClientConfig) (Client, error) {
+	startupOnce.Do(func() {
+		logFile := C.CString(os.DevNull)
+		defer C.free(unsafe.Pointer(logFile))
+		if ret := C.cnc_startupprocess(0, logFile); ret != 0 {
+			startupErr = &Error{Func: "cnc_startupprocess", Code: int(ret)}
+		}
+	})
+	if startupErr != nil {
+		return nil, startupErr
+	}
+	host, port, err := net.SplitHostPort(config.address())
+	if err != nil {
+		return nil, err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	timeout := int(config.timeout().Seconds())
+	if timeout <= 0 {
+		timeout = 1
+	}
+	ip := C.CString(host)
+	defer C.free(unsafe.Pointer(ip))
+	var handle C.ushort
+	if ret := C.cnc_allclibhndl3(ip, C.ushort(p), C.long(timeout), &handle); ret != 0 {
+		return nil, &Error{Func: "cnc_allclibhndl3", Code: int(ret)}
+	}
+	return &nativeClient{handle: handle}, nil
+}
+
+// Status 读取运行状态和程序号
+func (c *nativeClient) Status() (*Status, error) {
+	var st C.ODBST
+	if err := c.check("cnc_statinfo", C.cnc_statinfo(c.handle, &st)); err != nil {
+		return nil, err
+	}
+	status := NewStatus(int(st.aut), int(st.run), int(st.motion), int(st.emergency), int(st.alarm))
+	var pro C.ODBPRO
+	if err := c.check("cnc_rdprgnum", C.cnc_rdprgnum(c.handle, &pro)); err != nil {
+		return nil, err
+	}
+	status.Program, status.MainProgram = int(pro.data), int(pro.mdata)
+	return status, nil
+}
+
+// Alarms 读取当前报警，最多 maxAlarms 个
+func (c *nativeClient) Alarms() ([]Alarm, error) {
+	var msgs [maxAlarms]C.ODBALMMSG2
+	num := C.short(maxAlarms)
+	if err := c.check("cnc_rdalmmsg2", C.cnc_rdalmmsg2(c.handle, -1, &num, &msgs[0])); err != nil {
+		return nil, err
+	}
+	alarms := make([]Alarm, 0, int(num))
+	for i := 0; i < int(num) && i < maxAlarms; i++ {
+		m := &msgs[i]
+		length := int(m.msg_len)
+		if length < 0 {
+			length = 0
+		} else if length > len(m.alm_msg) {
+			length = len(m.alm_msg)
+		}
+		alarms = append(alarms, Alarm{
+			Number:  int(m.alm_no),
+			Type:    AlarmType(int(m._type)),
+			Axis:    int(m.axis),
+			Message: C.GoStringN(&m.alm_msg[0], C.int(length)),
+		})
+	}
+	return alarms, nil
+}
+
+// Overrides 读取 PMC G 区的倍率信号：G12 进给倍率，G14 快速移动倍率，G30 主轴倍率
+func (c *nativeClient) Overrides() (*Overrides, error) {
+	feed, err := c.readG(12, 14)
+	if err != nil {
+		return nil, err
+	}
+	spindle, err := c.readG(30, 30)
+	if err != nil {
+		return nil, err
+	}
+	return NewOverrides(feed[0], feed[2], spindle[0]), nil
+}
+
+// ReadMacros 读取宏变量
+func (c *nativeClient) ReadMacros(numbers []int) ([]interface{}, error) {
+	values := make([]interface{}, len(numbers))
+	for i, n := range numbers {
+		if n > 32767 {
+			return nil, errors.New("focas: macro variable number out of range")
+		}
+		var m C.ODBM
+		if err := c.check("cnc_rdmacro", C.cnc_rdmacro(c.handle, C.short(n), C.short(C.sizeof_ODBM), &m)); err != nil {
+			return nil, err
+		}
+		values[i] = MacroValue(int32(m.mcr_val), int16(m.dec_val))
+	}
+	return values, nil
+}
+
+// Closed 连接是否已经断开
+func (c *nativeClient) Closed() bool {
+	return c.closed
+}
+
+// Close 释放句柄
+func (c *nativeClient) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if ret := C.cnc_freelibhndl(c.handle); ret != 0 {
+		return &Error{Func: "cnc_freelibhndl", Code: int(ret)}
+	}
+	return nil
+}
+
+// readG 按字节读取 PMC G 区地址 start~end，最多 5 个字节
+func (c *nativeClient) readG(start, end int) ([]byte, error) {
+	var buf C.IODBPMC
+	n := end - start + 1
+	if err := c.check("pmc_rdpmcrng", C.pmc_rdpmcrng(c.handle, 0, 0, C.ushort(start), C.ushort(end), C.ushort(8+n), &buf)); err != nil {
+		return nil, err
+	}
+	return C.GoBytes(unsafe.Pointer(&buf.u), C.int(n)), nil
+}
+
+// check 转换返回码，连接错误时标记连接已经断开
+func (c *nativeClient) check(fn string, ret C.short) error {
+	if ret == 0 {
+		return nil
+	}
+	err := &Error{Func: fn, Code: int(ret)}
+	if err.Broken() {
+		c.closed = true
+		C.cnc_freelibhndl(c.handle)
+	}
+	return err
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package framecapture 提供运行时开启和停止原始报文抓取的节点，见 pkg/capture
+package framecapture
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 操作
+const (
+	// ActionStart 开启抓取会话
+	ActionStart = "start"
+	// ActionStop 停止抓取会话并输出缓冲区中的报文
+	ActionStop = "stop"
+	// ActionDump 输出并清空缓冲区中的报文，会话继续抓取
+	ActionDump = "dump"
+	// ActionStatus 只查询会话状态
+	ActionStatus = "status"
+)
+
+// 规则链控制抓取的消息类型
+const (
+	// MsgTypeStart 开启抓取
+	MsgTypeStart = "CAPTURE_START"
+	// MsgTypeStop 停止抓取
+	MsgTypeStop = "CAPTURE_STOP"
+)
+
+// KeyActive 执行后抓取会话是否在运行的元数据key：true、false
+const KeyActive = "captureActive"
+
+func init() {
+	_ = rulego.Registry.Register(&CaptureNode{})
+}
+
+// CaptureConfiguration 节点配置
+type CaptureConfiguration struct {
+	// Filter 报文来源的过滤条件，格式：<协议>/<地址>，以 * 结尾按前缀匹配，eg. s7/192.168.0.10:102、iec104/*，
+	// 支持 ${metadata.key} 和 ${msg.key} 占位符
+	Filter string `json:"filter" label:"Filter" desc:"Frame source filter <protocol>/<address>, a trailing * matches by prefix, eg. s7/192.168.0.10:102 or iec104/*. Supports placeholders" required:"true"`
+	// Action 操作：start、stop、dump、status，支持占位符
+	// 为空时按消息类型执行：CAPTURE_START 开启，CAPTURE_STOP 停止，其他消息类型输出缓冲区中的报文
+	Action string `json:"action" label:"Action" desc:"start, stop, dump or status. Empty uses the message type: CAPTURE_START starts, CAPTURE_STOP stops, other types dump the buffered frames"`
+	// Capacity 环形缓冲区保存的报文数量，0 为 1000
+	Capacity int `json:"capacity" label:"Capacity" desc:"Ring buffer size in frames, 0 means 1000"`
+	// File 同时写入的文件，为空只保存在内存，支持占位符
+	File string `json:"file" label:"File" desc:"Also write the frames to this file, empty keeps them in memory only. Supports placeholders"`
+	// Format 文件格式：jsonl、pcap，为空按文件扩展名
+	Format string `json:"format" label:"Format" desc:"File format: jsonl or pcap (IPv4 TCP/UDP encapsulation for Wireshark), empty uses the file extension"`
+	// Duration 抓取的持续时间，单位秒，超过后自动停止，0 表示一直抓取直到 stop
+	Duration int64 `json:"duration" label:"Duration" desc:"Stop capturing automatically after this many seconds, 0 captures until stop"`
+}
+
+// CaptureNode 原始报文抓取节点，运行时开启和停止协议客户端（IEC-104、DNP3、S7、FINS、MC、DLMS、BACnet）的原始报文抓取，
+// 现场没有 Wireshark 或者无法访问设备网络时用于诊断集成问题，不需要修改组件配置或者重新连接。
+// 开启后匹配 filter 的客户端收发的报文保存在环形缓冲区中，可以同时写入 JSON Lines 或者 pcap 文件。
+// 执行结果重新赋值到msg.Data，stop 和 dump 输出缓冲区中的报文：
+//
+//	{"active": true, "stats": {"filter": "s7/*", "started": "...", "total": 12, "buffered": 2, "dropped": 0},
+//	 "frames": [{"time": "...", "source": "s7/192.168.0.10:102", "direction": "tx", "local": "...", "remote": "192.168.0.10:102", "length": 22, "hex": "0300001611e00000..."}]}
+//
+// 元数据 captureActive 为执行后会话是否在运行。
+// 执行成功流转到`Success`链，会话不存在（stop、dump）或者操作无效流转到`Failure`链
+type CaptureNode struct {
+	//节点配置
+	Config         CaptureConfiguration
+	filterTemplate str.Template
+	actionTemplate str.Template
+	fileTemplate   str.Template
+}
+
+// Type 返回组件类型
+func (x *CaptureNode) Type() string {
+	return "x/frameCapture"
+}
+
+// New 默认参数
+func (x *CaptureNode) New() types.Node {
+	return &CaptureNode{Config: CaptureConfiguration{Filter: "*", Capacity: capture.DefaultCapacity}}
+}
+
+// Init 初始化组件
+func (x *CaptureNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Filter) == "" {
+		return errors.New("filter can not be empty")
+	}
+	if err = x.captureConfig("").Validate(); err != nil {
+		return err
+	}
+	x.filterTemplate = str.NewTemplate(x.Config.Filter)
+	x.actionTemplate = str.NewTemplate(x.Config.Action)
+	x.fileTemplate = str.NewTemplate(x.Config.File)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *CaptureNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	filter := x.filterTemplate.Execute(evn)
+	action := strings.ToLower(strings.TrimSpace(x.actionTemplate.Execute(evn)))
+	if action == "" {
+		action = actionOf(msg.Type)
+	}
+	var (
+		session *capture.Session
+		frames  []capture.Frame
+		err     error
+	)
+	switch action {
+	case ActionStart:
+		session, err = capture.Start(filter, x.captureConfig(x.fileTemplate.Execute(evn)))
+	case ActionStop:
+		if session, err = capture.Stop(filter); err == nil {
+			frames, _ = session.Drain()
+		}
+	case ActionDump:
+		var ok bool
+		if session, ok = capture.Get(filter); ok {
+			frames, _ = session.Drain()
+		} else {
+			err = fmt.Errorf("%w: %s", capture.ErrSessionNotFound, filter)
+		}
+	case ActionStatus:
+		session, _ = capture.Get(filter)
+	default:
+		err = fmt.Errorf("unsupported frame capture action: %s", action)
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	_, active := capture.Get(filter)
+	result := map[string]interface{}{"active": active}
+	if session != nil {
+		result["stats"] = session.Stats()
+	}
+	if action == ActionStop || action == ActionDump {
+		if frames == nil {
+			frames = []capture.Frame{}
+		}
+		result["frames"] = frames
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KeyActive, strconv.FormatBool(active))
+	msg.DataType = types.JSON
+	msg.SetData(str.ToString(b))
+	ctx.TellSuccess(msg)
+}
+
+// captureConfig 抓取会话配置
+func (x *CaptureNode) captureConfig(file string) capture.Config {
+	return capture.Config{
+		Capacity: x.Config.Capacity,
+		File:     file,
+		Format:   x.Config.Format,
+		Duration: time.Duration(x.Config.Duration) * time.Second,
+	}
+}
+
+// Destroy 销毁组件，已经开启的抓取会话继续运行，直到 stop 或者超过持续时间
+func (x *CaptureNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *CaptureNode) Desc() string {
+	return "Starts, stops or dumps raw request/response frame capture (hex with timestamps, ring buffer or jsonl/pcap file) of protocol clients at runtime. Routes to Success/Failure"
+}
+
+// actionOf 消息类型对应的操作
+func actionOf(msgType string) string {
+	switch msgType {
+	case MsgTypeStart:
+		return ActionStart
+	case MsgTypeStop:
+		return ActionStop
+	default:
+		return ActionDump
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package framecapture
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestCaptureNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&CaptureNode{})
+
+	_, err := test.CreateAndInitNode("x/frameCapture", types.Configuration{"filter": ""}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/frameCapture", types.Configuration{"format": "csv"}, Registry)
+	assert.NotNil(t, err)
+
+	file := filepath.Join(t.TempDir(), "s7.jsonl")
+	node, err := test.CreateAndInitNode("x/frameCapture", types.Configuration{
+		"filter":   "${metadata.filter}",
+		"capacity": 2,
+		"file":     file,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var result map[string]interface{}
+	var relation string
+	// send 发送消息并等待回调完成
+	send := func(node types.Node, msgType string) {
+		metadata := types.NewMetadata()
+		metadata.PutValue("filter", "s7/*")
+		done := make(chan struct{})
+		test.NodeOnMsg(t, node, []test.Msg{{MetaData: metadata, DataType: types.JSON, MsgType: msgType, Data: "{}"}},
+			func(msg types.RuleMsg, relationType string, err error) {
+				defer close(done)
+				relation = relationType
+				result = nil
+				if relationType == types.Success {
+					assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+					assert.Equal(t, result["active"] == true, msg.Metadata.GetValue(KeyActive) == "true")
+				}
+			})
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for frame capture node")
+		}
+	}
+
+	send(node, "POLL")
+	assert.Equal(t, types.Failure, relation)
+	send(node, MsgTypeStart)
+	assert.Equal(t, types.Success, relation)
+	assert.Equal(t, true, result["active"])
+	assert.True(t, capture.Enabled("s7/10.0.0.1:102"))
+
+	local := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 50000}
+	remote := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 102}
+	for _, data := range [][]byte{{0x03, 0x00, 0x00, 0x16}, {0x03, 0x00, 0x00, 0x1b}, {0x03, 0x00, 0x00, 0x07}} {
+		capture.Record("s7/10.0.0.1:102", capture.DirectionTx, local, remote, data)
+	}
+	capture.Record("iec104/10.0.0.1:2404", capture.DirectionTx, local, remote, []byte{0x68})
+
+	send(node, "POLL")
+	assert.Equal(t, types.Success, relation)
+	frames := result["frames"].([]interface{})
+	assert.Equal(t, 2, len(frames))
+	assert.Equal(t, "0300001b", frames[0].(map[string]interface{})["hex"])
+	stats := result["stats"].(map[string]interface{})
+	assert.Equal(t, float64(3), stats["total"])
+	assert.Equal(t, float64(0), stats["buffered"])
+
+	send(node, MsgTypeStop)
+	assert.Equal(t, types.Success, relation)
+	assert.Equal(t, false, result["active"])
+	assert.Equal(t, 0, len(result["frames"].([]interface{})))
+	assert.False(t, capture.Enabled("s7/10.0.0.1:102"))
+
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	var frame capture.Frame
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &frame))
+	assert.Equal(t, "03000016", frame.Hex)
+	assert.Equal(t, "10.0.0.1:102", frame.Remote)
+
+	statusNode, err := test.CreateAndInitNode("x/frameCapture", types.Configuration{"filter": "s7/*", "action": "status"}, Registry)
+	assert.Nil(t, err)
+	defer statusNode.Destroy()
+	send(statusNode, MsgTypeStart)
+	assert.Equal(t, types.Success, relation)
+	assert.Equal(t, map[string]interface{}{"active": false}, result)
+}
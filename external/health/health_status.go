@@ -0,0 +1,110 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package health 提供查询协议客户端连接健康状态的节点
+package health
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/rulego/rulego"
+	healthMonitor "github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// ErrNotFound 没有目标的健康状态
+var ErrNotFound = errors.New("health status not found")
+
+func init() {
+	_ = rulego.Registry.Register(&StatusNode{})
+}
+
+// StatusConfiguration 健康状态查询节点配置
+type StatusConfiguration struct {
+	// Component 组件，eg. s7、modbus、opcua，为空查询所有组件。支持 ${metadata.key} 占位符
+	Component string `json:"component" label:"Component" desc:"Component to query, eg. s7, modbus, opcua. Empty queries all components. Supports ${metadata.key}"`
+	// Target 连接目标，为空查询组件的所有目标，不为空时只输出该目标的状态对象。支持 ${metadata.key} 占位符
+	Target string `json:"target" label:"Target" desc:"Connection target to query, empty queries all targets of the component. Outputs a single status object when set. Supports ${metadata.key}"`
+}
+
+// StatusNode 查询协议客户端的连接健康状态，包括连接状态、最后成功时间、最后错误和 RTT。
+// target 为空时查询结果为状态数组，否则为单个状态对象，重新赋值到msg.Data。
+// 查询成功，流转到`Success`链；指定的目标不存在，流转到`Failure`链
+type StatusNode struct {
+	//节点配置
+	Config            StatusConfiguration
+	componentTemplate str.Template
+	targetTemplate    str.Template
+}
+
+// Type 返回组件类型
+func (x *StatusNode) Type() string {
+	return "x/healthStatus"
+}
+
+// New 默认参数
+func (x *StatusNode) New() types.Node {
+	return &StatusNode{}
+}
+
+// Init 初始化组件
+func (x *StatusNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	x.componentTemplate = str.NewTemplate(x.Config.Component)
+	x.targetTemplate = str.NewTemplate(x.Config.Target)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *StatusNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	component := x.componentTemplate.Execute(evn)
+	target := x.targetTemplate.Execute(evn)
+	var result interface{}
+	if target == "" {
+		result = healthMonitor.List(component)
+	} else {
+		status, ok := healthMonitor.Default().Get(component, target)
+		if !ok {
+			ctx.TellFailure(msg, ErrNotFound)
+			return
+		}
+		result = status
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(string(data))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *StatusNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *StatusNode) Desc() string {
+	return "Query connection health of protocol clients: state, last success time, last error and RTT. Routes to Success/Failure"
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	healthMonitor "github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestStatusNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&StatusNode{})
+
+	healthMonitor.Connected("health-node-test", "10.0.0.1:102")
+	healthMonitor.Report("health-node-test", "10.0.0.1:102", 2*time.Millisecond, nil)
+	healthMonitor.Disconnected("health-node-test", "10.0.0.2:102", errors.New("i/o timeout"))
+	defer healthMonitor.Remove("health-node-test", "10.0.0.1:102")
+	defer healthMonitor.Remove("health-node-test", "10.0.0.2:102")
+
+	listNode, err := test.CreateAndInitNode("x/healthStatus", types.Configuration{"component": "health-node-test"}, Registry)
+	assert.Nil(t, err)
+	getNode, err := test.CreateAndInitNode("x/healthStatus", types.Configuration{
+		"component": "health-node-test",
+		"target":    "${metadata.target}",
+	}, Registry)
+	assert.Nil(t, err)
+
+	test.NodeOnMsg(t, listNode, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.TEXT,
+			MsgType:    "QUERY",
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var list []healthMonitor.Status
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &list))
+		assert.Equal(t, 2, len(list))
+		assert.Equal(t, healthMonitor.StateConnected, list[0].State)
+		assert.Equal(t, 2.0, list[0].RTT)
+		assert.Equal(t, healthMonitor.StateError, list[1].State)
+		assert.Equal(t, "i/o timeout", list[1].LastError)
+	})
+
+	found := types.NewMetadata()
+	found.PutValue("target", "10.0.0.2:102")
+	missing := types.NewMetadata()
+	missing.PutValue("target", "10.0.0.3:102")
+	test.NodeOnMsg(t, getNode, []test.Msg{
+		{
+			MetaData:   found,
+			DataType:   types.TEXT,
+			MsgType:    "FOUND",
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   missing,
+			DataType:   types.TEXT,
+			MsgType:    "MISSING",
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "MISSING" {
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, ErrNotFound, err)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		var status healthMonitor.Status
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &status))
+		assert.Equal(t, "10.0.0.2:102", status.Target)
+		assert.Equal(t, healthMonitor.StateError, status.State)
+	})
+}
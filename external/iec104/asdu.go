@@ -0,0 +1,268 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iec104
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 类型标识
+const (
+	MSpNa1 uint8 = 1
+	MDpNa1 uint8 = 3
+	MStNa1 uint8 = 5
+	MMeNa1 uint8 = 9
+	MMeNb1 uint8 = 11
+	MMeNc1 uint8 = 13
+	MItNa1 uint8 = 15
+	MSpTb1 uint8 = 30
+	MDpTb1 uint8 = 31
+	MStTb1 uint8 = 32
+	MMeTd1 uint8 = 34
+	MMeTe1 uint8 = 35
+	MMeTf1 uint8 = 36
+	MItTb1 uint8 = 37
+	MEiNa1 uint8 = 70
+	CIcNa1 uint8 = 100
+	CCsNa1 uint8 = 103
+)
+
+// 传送原因
+const (
+	CausePeriodic       uint8 = 1
+	CauseSpontaneous    uint8 = 3
+	CauseInitialized    uint8 = 4
+	CauseActivation     uint8 = 6
+	CauseActivationCon  uint8 = 7
+	CauseActivationTerm uint8 = 10
+	CauseRemoteCommand  uint8 = 11
+	CauseLocalCommand   uint8 = 12
+	CauseInterrogated   uint8 = 20
+)
+
+// QOIStation 站总召唤的召唤限定词
+const QOIStation = 20
+
+// 品质描述
+const (
+	QualityOverflow    = 0x01
+	QualityBlocked     = 0x10
+	QualitySubstituted = 0x20
+	QualityNotTopical  = 0x40
+	QualityInvalid     = 0x80
+)
+
+// asduHeaderSize 类型标识、可变结构限定词、两字节传送原因和两字节公共地址
+const asduHeaderSize = 6
+
+// ioaSize 信息对象地址的字节数
+const ioaSize = 3
+
+var errShortASDU = errors.New("iec104: short asdu")
+
+// typeNames 支持的监视方向类型
+var typeNames = map[uint8]string{
+	MSpNa1: "M_SP_NA_1",
+	MDpNa1: "M_DP_NA_1",
+	MStNa1: "M_ST_NA_1",
+	MMeNa1: "M_ME_NA_1",
+	MMeNb1: "M_ME_NB_1",
+	MMeNc1: "M_ME_NC_1",
+	MItNa1: "M_IT_NA_1",
+	MSpTb1: "M_SP_TB_1",
+	MDpTb1: "M_DP_TB_1",
+	MStTb1: "M_ST_TB_1",
+	MMeTd1: "M_ME_TD_1",
+	MMeTe1: "M_ME_TE_1",
+	MMeTf1: "M_ME_TF_1",
+	MItTb1: "M_IT_TB_1",
+}
+
+// elementSize 信息元素的字节数，不包括时标
+var elementSize = map[uint8]int{
+	MSpNa1: 1, MDpNa1: 1, MStNa1: 2, MMeNa1: 3, MMeNb1: 3, MMeNc1: 5, MItNa1: 5,
+	MSpTb1: 1, MDpTb1: 1, MStTb1: 2, MMeTd1: 3, MMeTe1: 3, MMeTf1: 5, MItTb1: 5,
+}
+
+// hasTime 是否带 CP56Time2a 时标
+func hasTime(typeId uint8) bool {
+	return typeId >= MSpTb1 && typeId <= MItTb1
+}
+
+// ASDU 应用服务数据单元，传送原因两字节、公共地址两字节、信息对象地址三字节
+type ASDU struct {
+	Type uint8
+	// Sequence SQ 位，信息对象地址连续时只有第一个对象带地址
+	Sequence   bool
+	Count      int
+	Cause      uint8
+	Negative   bool
+	Test       bool
+	Originator uint8
+	CommonAddr uint16
+	// Objects 信息对象
+	Objects []byte
+}
+
+// marshal 编码 ASDU
+func (a *ASDU) marshal() []byte {
+	vsq := byte(a.Count & 0x7f)
+	if a.Sequence {
+		vsq |= 0x80
+	}
+	cause := a.Cause & 0x3f
+	if a.Negative {
+		cause |= 0x40
+	}
+	if a.Test {
+		cause |= 0x80
+	}
+	b := []byte{a.Type, vsq, cause, a.Originator}
+	b = binary.LittleEndian.AppendUint16(b, a.CommonAddr)
+	return append(b, a.Objects...)
+}
+
+// parseASDU 解码 ASDU
+func parseASDU(b []byte) (*ASDU, error) {
+	if len(b) < asduHeaderSize {
+		return nil, errShortASDU
+	}
+	return &ASDU{
+		Type:       b[0],
+		Sequence:   b[1]&0x80 != 0,
+		Count:      int(b[1] & 0x7f),
+		Cause:      b[2] & 0x3f,
+		Negative:   b[2]&0x40 != 0,
+		Test:       b[2]&0x80 != 0,
+		Originator: b[3],
+		CommonAddr: binary.LittleEndian.Uint16(b[4:]),
+		Objects:    b[asduHeaderSize:],
+	}, nil
+}
+
+// appendIOA 编码信息对象地址
+func appendIOA(b []byte, ioa uint32) []byte {
+	return append(b, byte(ioa), byte(ioa>>8), byte(ioa>>16))
+}
+
+func readIOA(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// Point 监视方向的信息对象
+type Point struct {
+	// Type 类型标识，eg. M_ME_NC_1
+	Type string `json:"type"`
+	// Address 信息对象地址
+	Address uint32 `json:"address"`
+	// Value 单点为 bool，双点为 0-3（1 分、2 合），步位置为 int，测量值为 float64，累计量为 int32
+	Value interface{} `json:"value"`
+	// Quality 品质描述，0 表示正常
+	Quality uint8 `json:"quality"`
+	// Cause 传送原因，eg. 3 突发，20 响应站召唤
+	Cause uint8 `json:"cause"`
+	// Timestamp 时标，Unix 毫秒，0 表示没有时标
+	Timestamp int64 `json:"timestamp,omitempty"`
+	// TimeInvalid 时标的 IV 位
+	TimeInvalid bool `json:"timeInvalid,omitempty"`
+	// ClockSkew 时标超前接收时间的毫秒数，只在超过允许的时钟偏差时设置
+	ClockSkew int64 `json:"clockSkew,omitempty"`
+}
+
+// ParsePoints 解码监视方向的 ASDU，不支持的类型返回错误
+func ParsePoints(a *ASDU, tc TimeConfig) ([]Point, error) {
+	name, ok := typeNames[a.Type]
+	if !ok {
+		return nil, fmt.Errorf("iec104: unsupported type %d", a.Type)
+	}
+	size := elementSize[a.Type]
+	if hasTime(a.Type) {
+		size += cp56Size
+	}
+	b := a.Objects
+	points := make([]Point, 0, a.Count)
+	var ioa uint32
+	for i := 0; i < a.Count; i++ {
+		if !a.Sequence || i == 0 {
+			if len(b) < ioaSize {
+				return nil, errShortASDU
+			}
+			ioa, b = readIOA(b), b[ioaSize:]
+		} else {
+			ioa++
+		}
+		if len(b) < size {
+			return nil, errShortASDU
+		}
+		p := Point{Type: name, Address: ioa, Cause: a.Cause}
+		decodeElement(a.Type, b, &p)
+		if hasTime(a.Type) {
+			t, invalid, err := tc.Decode(b[elementSize[a.Type]:])
+			if err != nil {
+				return nil, err
+			}
+			p.Timestamp, p.TimeInvalid = t.UnixMilli(), invalid
+		}
+		points = append(points, p)
+		b = b[size:]
+	}
+	return points, nil
+}
+
+// decodeElement 解码信息元素的值和品质
+func decodeElement(typeId uint8, b []byte, p *Point) {
+	switch typeId {
+	case MSpNa1, MSpTb1:
+		p.Value, p.Quality = b[0]&0x01 != 0, b[0]&0xf0
+	case MDpNa1, MDpTb1:
+		p.Value, p.Quality = int(b[0]&0x03), b[0]&0xf0
+	case MStNa1, MStTb1:
+		// 低 7 位为有符号的位置，最高位为瞬变状态
+		p.Value, p.Quality = int(int8(b[0]<<1)>>1), b[1]
+	case MMeNa1, MMeTd1:
+		p.Value, p.Quality = float64(int16(binary.LittleEndian.Uint16(b)))/32768, b[2]
+	case MMeNb1, MMeTe1:
+		p.Value, p.Quality = float64(int16(binary.LittleEndian.Uint16(b))), b[2]
+	case MMeNc1, MMeTf1:
+		p.Value, p.Quality = float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), b[4]
+	case MItNa1, MItTb1:
+		// 累计量的顺序号字节最高位为 IV
+		p.Value, p.Quality = int32(binary.LittleEndian.Uint32(b)), b[4]&QualityInvalid
+	}
+}
+
+// checkSkew 时标超前 now 超过 max 时设置 ClockSkew 并返回 true，过去的时标可能是缓存的事件，不认为是偏差
+func checkSkew(points []Point, now time.Time, max time.Duration) bool {
+	if max <= 0 {
+		return false
+	}
+	skewed := false
+	for i := range points {
+		p := &points[i]
+		if p.Timestamp == 0 || p.TimeInvalid {
+			continue
+		}
+		if skew := p.Timestamp - now.UnixMilli(); skew > max.Milliseconds() {
+			p.ClockSkew, skewed = skew, true
+		}
+	}
+	return skewed
+}
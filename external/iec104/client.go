@@ -0,0 +1,462 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package iec104 提供 IEC 60870-5-104 客户端（控制站），支持站总召唤、C_CS_NA_1 时钟同步和事件顺序记录（SOE）读取
+package iec104
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+	"github.com/rulego/rulego-components-iot/pkg/soe"
+)
+
+const (
+	// DefaultPort IEC 104 TCP 端口
+	DefaultPort = "2404"
+	// DefaultTimeout 默认响应超时
+	DefaultTimeout = 5 * time.Second
+	// DefaultCommonAddr 默认公共地址
+	DefaultCommonAddr = 1
+	// DefaultEventQuiet 读取事件时没有新报文的等待时间，超过时认为子站缓存的事件已经上送完
+	DefaultEventQuiet = 500 * time.Millisecond
+)
+
+// APCI
+const (
+	startByte = 0x68
+	// maxAPDULength 控制域和 ASDU 的最大长度
+	maxAPDULength = 253
+	// ackWindow 接收多少个 I 帧后发送 S 帧确认（w）
+	ackWindow = 8
+)
+
+// U 帧功能
+const (
+	uStartDtAct = 0x07
+	uStartDtCon = 0x0b
+	uStopDtAct  = 0x13
+	uStopDtCon  = 0x23
+	uTestFrAct  = 0x43
+	uTestFrCon  = 0x83
+)
+
+// ErrClosed 客户端已经关闭
+var ErrClosed = errors.New("iec104: client closed")
+
+// NegativeError 子站否定确认命令
+type NegativeError struct {
+	Type  uint8
+	Cause uint8
+}
+
+func (e *NegativeError) Error() string {
+	return fmt.Sprintf("iec104: command %d rejected, cause %d", e.Type, e.Cause)
+}
+
+// ClientConfig 客户端配置
+type ClientConfig struct {
+	// Server 子站地址，格式：host:port，端口默认 2404
+	Server string
+	// CommonAddr 公共地址（ASDU 地址）
+	CommonAddr uint16
+	// Timeout 连接和响应超时
+	Timeout time.Duration
+	// TimeSync 建立连接后发送 C_CS_NA_1 时钟同步
+	TimeSync bool
+	// Time CP56Time2a 时标的解释方式，也用于编码时钟同步命令
+	Time TimeConfig
+	// MaxClockSkew 时标超前本地时间的允许偏差，超过时标记点位的 ClockSkew，开启 TimeSync 时在下一次请求前重新同步，0 不检查
+	MaxClockSkew time.Duration
+	// MaxEvents 最多缓存的突发事件数量，超过时丢弃最早的事件，0 使用 soe.DefaultCapacity
+	MaxEvents int
+}
+
+// address 补全端口的子站地址
+func (c ClientConfig) address() string {
+	server := strings.TrimPrefix(c.Server, "tcp://")
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, DefaultPort)
+	}
+	return server
+}
+
+// Client IEC 104 客户端，请求是串行的，可以并发调用
+// 连接在第一次请求时建立并启动数据传输，通信出错时关闭，下一次请求重新连接。
+// 时钟同步和读取事件期间收到的突发（传送原因 3）以及远方、就地命令引起的返回信息（11、12）写入事件缓存，
+// 由 ReadEvents 按时标顺序取出
+type Client struct {
+	config ClientConfig
+	mu     sync.Mutex
+	conn   net.Conn
+	// sendSeq、recvSeq 发送和接收序号
+	sendSeq, recvSeq uint16
+	// unacked 未确认的接收 I 帧数量
+	unacked int
+	// resync 检测到时钟偏差，下一次请求前重新同步
+	resync bool
+	closed bool
+	// events 突发事件缓存
+	events *soe.Buffer[Point]
+}
+
+// NewClient 创建客户端
+func NewClient(config ClientConfig) *Client {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	return &Client{config: config, events: soe.NewBuffer[Point](config.MaxEvents)}
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return c.disconnect()
+}
+
+// Interrogate 站总召唤，返回响应站召唤的点位和召唤期间收到的突发点位
+func (c *Client) Interrogate() ([]Point, error) {
+	points := []Point{}
+	err := c.request(func() error {
+		objects := appendIOA(nil, 0)
+		if err := c.command(CIcNa1, append(objects, QOIStation)); err != nil {
+			return err
+		}
+		for {
+			a, err := c.receive()
+			if err != nil {
+				return err
+			}
+			if a.Type == CIcNa1 {
+				if a.Cause == CauseActivationTerm {
+					return nil
+				}
+				continue
+			}
+			p, err := c.points(a)
+			if err != nil {
+				return err
+			}
+			points = append(points, p...)
+		}
+	})
+	return points, err
+}
+
+// SyncClock 发送 C_CS_NA_1 时钟同步，返回同步的时间
+func (c *Client) SyncClock() (time.Time, error) {
+	var t time.Time
+	err := c.request(func() error {
+		var err error
+		t, err = c.syncClock()
+		return err
+	})
+	return t, err
+}
+
+// ReadEvents 读取事件顺序记录（SOE）：连接断开时重新连接并启动数据传输，子站随后上送链路中断期间缓存的事件，
+// 持续接收直到 quiet 时间内没有新的报文，返回缓存中的所有事件，按设备原始时标排序，没有时标的事件按接收时间排序。
+// dropped 为缓存已满丢弃的事件数量。读取出错时已经收到的事件保留在缓存中，下一次读取时返回
+func (c *Client) ReadEvents(quiet time.Duration) (events []Point, dropped int, err error) {
+	if quiet <= 0 {
+		quiet = DefaultEventQuiet
+	}
+	err = c.request(func() error {
+		for {
+			a, err := c.receiveWithin(quiet)
+			var idle *idleError
+			if errors.As(err, &idle) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err = c.collect(a); err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	events, dropped = c.events.Drain()
+	return events, dropped, nil
+}
+
+// collect 突发事件写入事件缓存，其他 ASDU 忽略
+func (c *Client) collect(a *ASDU) error {
+	switch a.Cause {
+	case CauseSpontaneous, CauseRemoteCommand, CauseLocalCommand:
+	default:
+		return nil
+	}
+	points, err := c.points(a)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, p := range points {
+		c.events.Add(p, p.Timestamp, now)
+	}
+	return nil
+}
+
+// request 执行请求，复用的连接出错时重新连接并重试一次
+func (c *Client) request(fn func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	reused := c.conn != nil
+	err := c.exchange(fn)
+	var netErr net.Error
+	if err != nil && reused && c.conn == nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		err = c.exchange(fn)
+	}
+	return err
+}
+
+// exchange 连接并执行请求，调用方需持有锁，通信出错时关闭连接
+func (c *Client) exchange(fn func() error) error {
+	if err := c.connect(); err != nil {
+		return err
+	}
+	if c.resync && c.config.TimeSync {
+		if _, err := c.syncClock(); err != nil {
+			return err
+		}
+	}
+	err := fn()
+	if err == nil {
+		err = c.ack()
+	}
+	var negative *NegativeError
+	if err != nil && !errors.As(err, &negative) {
+		_ = c.disconnect()
+	}
+	return err
+}
+
+// syncClock 发送时钟同步并等待确认
+func (c *Client) syncClock() (time.Time, error) {
+	now := time.Now()
+	if err := c.command(CCsNa1, c.config.Time.Encode(appendIOA(nil, 0), now)); err != nil {
+		return now, err
+	}
+	c.resync = false
+	return now, nil
+}
+
+// command 发送激活命令并等待激活确认，期间收到的突发事件写入事件缓存，其他 ASDU 忽略
+func (c *Client) command(typeId uint8, objects []byte) error {
+	req := &ASDU{Type: typeId, Count: 1, Cause: CauseActivation, CommonAddr: c.config.CommonAddr, Objects: objects}
+	if err := c.sendI(req.marshal()); err != nil {
+		return err
+	}
+	for {
+		a, err := c.receive()
+		if err != nil {
+			return err
+		}
+		if a.Type != typeId || a.Cause != CauseActivationCon {
+			if err = c.collect(a); err != nil {
+				return err
+			}
+			continue
+		}
+		if a.Negative {
+			return &NegativeError{Type: typeId, Cause: a.Cause}
+		}
+		return nil
+	}
+}
+
+// points 解码监视方向的点位并检查时钟偏差，不支持的类型忽略
+func (c *Client) points(a *ASDU) ([]Point, error) {
+	if _, ok := typeNames[a.Type]; !ok || a.CommonAddr != c.config.CommonAddr {
+		return nil, nil
+	}
+	points, err := ParsePoints(a, c.config.Time)
+	if err != nil {
+		return nil, err
+	}
+	if checkSkew(points, time.Now(), c.config.MaxClockSkew) {
+		c.resync = true
+	}
+	return points, nil
+}
+
+// receive 读取下一个 ASDU，处理 S 帧和 U 帧，按确认窗口发送 S 帧
+func (c *Client) receive() (*ASDU, error) {
+	return c.receiveWithin(c.config.Timeout)
+}
+
+// receiveWithin 读取下一个 ASDU，timeout 内没有收到报文时返回 idleError
+func (c *Client) receiveWithin(timeout time.Duration) (*ASDU, error) {
+	for {
+		frame, err := c.readFrame(timeout)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case frame[0]&0x01 == 0:
+			// I 帧
+			c.recvSeq = (binary.LittleEndian.Uint16(frame) >> 1) + 1
+			c.recvSeq &= 0x7fff
+			c.unacked++
+			if c.unacked >= ackWindow {
+				if err = c.ack(); err != nil {
+					return nil, err
+				}
+			}
+			a, err := parseASDU(frame[4:])
+			if err != nil {
+				return nil, err
+			}
+			return a, nil
+		case frame[0]&0x03 == 0x03:
+			if frame[0] == uTestFrAct {
+				if err = c.sendU(uTestFrCon); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+}
+
+// idleError 在报文开始之前读取超时，连接上没有数据
+type idleError struct {
+	error
+}
+
+func (e *idleError) Unwrap() error {
+	return e.error
+}
+
+// readFrame 读取一个 APDU，返回控制域和 ASDU，timeout 内没有收到报文时返回 idleError
+func (c *Client) readFrame(timeout time.Duration) ([]byte, error) {
+	_ = c.conn.SetReadDeadline(time.Now().Add(timeout))
+	header := make([]byte, 2)
+	if n, err := io.ReadFull(c.conn, header); err != nil {
+		var netErr net.Error
+		if n == 0 && errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, &idleError{err}
+		}
+		return nil, err
+	}
+	if header[0] != startByte || header[1] < 4 || header[1] > maxAPDULength {
+		return nil, fmt.Errorf("iec104: invalid apdu header % x", header)
+	}
+	frame := make([]byte, header[1])
+	if _, err := io.ReadFull(c.conn, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// sendI 发送 I 帧，同时确认已接收的 I 帧
+func (c *Client) sendI(asdu []byte) error {
+	if len(asdu) > maxAPDULength-4 {
+		return errors.New("iec104: asdu too long")
+	}
+	b := []byte{startByte, byte(len(asdu) + 4)}
+	b = binary.LittleEndian.AppendUint16(b, c.sendSeq<<1)
+	b = binary.LittleEndian.AppendUint16(b, c.recvSeq<<1)
+	c.sendSeq = (c.sendSeq + 1) & 0x7fff
+	c.unacked = 0
+	return c.write(append(b, asdu...))
+}
+
+// ack 有未确认的 I 帧时发送 S 帧
+func (c *Client) ack() error {
+	if c.unacked == 0 {
+		return nil
+	}
+	b := []byte{startByte, 4, 0x01, 0x00}
+	b = binary.LittleEndian.AppendUint16(b, c.recvSeq<<1)
+	c.unacked = 0
+	return c.write(b)
+}
+
+func (c *Client) sendU(function byte) error {
+	return c.write([]byte{startByte, 4, function, 0, 0, 0})
+}
+
+func (c *Client) write(b []byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.config.Timeout))
+	_, err := c.conn.Write(b)
+	return err
+}
+
+// connect 建立连接并启动数据传输，开启 TimeSync 时同步时钟
+func (c *Client) connect() error {
+	if c.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", c.config.address(), c.config.Timeout)
+	if err != nil {
+		return err
+	}
+	conn = capture.WrapConn(conn, capture.Source("iec104", c.config.address()))
+	c.conn, c.sendSeq, c.recvSeq, c.unacked = conn, 0, 0, 0
+	if err = c.startDT(); err == nil && c.config.TimeSync {
+		_, err = c.syncClock()
+	}
+	if err != nil {
+		_ = c.disconnect()
+	}
+	return err
+}
+
+// startDT 发送 STARTDT 并等待确认
+func (c *Client) startDT() error {
+	if err := c.sendU(uStartDtAct); err != nil {
+		return err
+	}
+	for {
+		frame, err := c.readFrame(c.config.Timeout)
+		if err != nil {
+			return err
+		}
+		switch frame[0] {
+		case uStartDtCon:
+			return nil
+		case uTestFrAct:
+			if err = c.sendU(uTestFrCon); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *Client) disconnect() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iec104
+
+import (
+	"errors"
+	"time"
+)
+
+// cp56Size CP56Time2a 的字节数
+const cp56Size = 7
+
+// CP56Time2a 的标志位
+const (
+	cp56Invalid    = 0x80
+	cp56SummerTime = 0x80
+)
+
+var errShortTime = errors.New("iec104: short CP56Time2a")
+
+// TimeConfig CP56Time2a 时标的解释方式
+// 时标只有墙上时间，没有时区；很多老的 RTU 使用当地时间，并用 SU 位表示夏令时
+type TimeConfig struct {
+	// Location 时标所在的时区，为空使用 UTC
+	Location *time.Location
+	// IgnoreSummerTime 忽略 SU 位，完全按照 Location 的规则解释
+	IgnoreSummerTime bool
+}
+
+func (c TimeConfig) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// Decode 解码 CP56Time2a，invalid 为 IV 位
+// 没有忽略 SU 位时，夏令时切换的重复时段按照 SU 位选择；SU 位与时区规则不一致时（eg. 固定时区的 RTU 使用夏令时）按 1 小时修正
+func (c TimeConfig) Decode(b []byte) (t time.Time, invalid bool, err error) {
+	if len(b) < cp56Size {
+		return time.Time{}, false, errShortTime
+	}
+	ms := int(b[0]) | int(b[1])<<8
+	minute, hour, day := int(b[2]&0x3f), int(b[3]&0x1f), int(b[4]&0x1f)
+	month, year := time.Month(b[5]&0x0f), 2000+int(b[6]&0x7f)
+	invalid = b[2]&cp56Invalid != 0
+	summer := b[3]&cp56SummerTime != 0
+	loc := c.location()
+	t = time.Date(year, month, day, hour, minute, 0, ms*int(time.Millisecond), loc)
+	if c.IgnoreSummerTime || t.IsDST() == summer {
+		return t, invalid, nil
+	}
+	// 重复的时段：另一个时刻的墙上时间相同并且夏令时与 SU 位一致
+	for _, d := range []time.Duration{-time.Hour, time.Hour} {
+		if alt := t.Add(d); alt.IsDST() == summer && sameWallClock(alt, t) {
+			return alt, invalid, nil
+		}
+	}
+	if summer {
+		// 墙上时间比标准时间快 1 小时
+		return t.Add(-time.Hour), invalid, nil
+	}
+	return t.Add(time.Hour), invalid, nil
+}
+
+// Encode 编码 CP56Time2a，SU 位为 Location 在该时刻是否为夏令时
+func (c TimeConfig) Encode(b []byte, t time.Time) []byte {
+	t = t.In(c.location())
+	ms := t.Second()*1000 + t.Nanosecond()/int(time.Millisecond)
+	hour := byte(t.Hour())
+	if !c.IgnoreSummerTime && t.IsDST() {
+		hour |= cp56SummerTime
+	}
+	// 星期一为 1，星期日为 7
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return append(b, byte(ms), byte(ms>>8), byte(t.Minute()), hour,
+		byte(t.Day())|byte(weekday)<<5, byte(t.Month()), byte(t.Year()%100))
+}
+
+func sameWallClock(a, b time.Time) bool {
+	return a.Hour() == b.Hour() && a.Minute() == b.Minute() && a.Day() == b.Day()
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iec104
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 操作类型
+const (
+	OperationInterrogation = "interrogation"
+	OperationClockSync     = "clockSync"
+	OperationReadEvents    = "readEvents"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ClientNode{})
+}
+
+// ClientConfiguration 节点配置
+type ClientConfiguration struct {
+	// Server 子站地址，格式：host:port，端口默认 2404
+	Server string `json:"server" label:"Server" desc:"Controlled station address, format: host:port, port defaults to 2404" required:"true" ref:"primary"`
+	// CommonAddr 公共地址（ASDU 地址）
+	CommonAddr uint16 `json:"commonAddr" label:"Common address" desc:"Common address of ASDU"`
+	// Timeout 连接和响应超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and response timeout in seconds"`
+	// Operation 操作：interrogation 站总召唤、clockSync 时钟同步、readEvents 读取事件顺序记录（SOE）
+	Operation string `json:"operation" label:"Operation" desc:"Operation: interrogation, clockSync or readEvents"`
+	// TimeSync 建立连接后发送 C_CS_NA_1 时钟同步
+	TimeSync bool `json:"timeSync" label:"Time sync" desc:"Send C_CS_NA_1 clock synchronization after each connect"`
+	// TimeZone CP56Time2a 时标的时区，UTC、Local 或者 IANA 名称，为空使用 UTC
+	TimeZone string `json:"timeZone" label:"Time zone" desc:"Time zone of CP56Time2a timestamps: UTC, Local or an IANA name, empty uses UTC"`
+	// IgnoreSummerTime 忽略时标的 SU 位，完全按照时区规则解释
+	IgnoreSummerTime bool `json:"ignoreSummerTime" label:"Ignore summer time bit" desc:"Ignore the CP56Time2a SU bit and apply the time zone rules only"`
+	// MaxClockSkew 时标超前本地时间的允许偏差，单位秒，超过时标记点位的 clockSkew，开启 timeSync 时重新同步，0 不检查
+	MaxClockSkew int64 `json:"maxClockSkew" label:"Max clock skew" desc:"Seconds a timestamp may run ahead of local time before the point is flagged with clockSkew and, with timeSync, the clock is resynchronized. 0 disables the check"`
+	// EventQuiet readEvents 没有新报文的等待时间，单位毫秒，超过时认为子站缓存的事件已经上送完，默认 500
+	EventQuiet int64 `json:"eventQuiet" label:"Event quiet time" desc:"readEvents: milliseconds without new frames after which the buffered events are considered complete, default 500"`
+	// MaxEvents 最多缓存的突发事件数量，超过时丢弃最早的事件
+	MaxEvents int `json:"maxEvents" label:"Max events" desc:"Max spontaneous events buffered between reads, the oldest are dropped when full, default 10000"`
+}
+
+// ClientNode IEC 60870-5-104 客户端节点，通过 TCP 对子站进行站总召唤或者时钟同步。
+// 站总召唤的结果重新赋值到msg.Data：
+//
+//	{"points": [{"type": "M_ME_NC_1", "address": 1001, "value": 21.5, "quality": 0, "cause": 20}]}
+//
+// 带时标的点位按配置的时区和 SU 位解释 CP56Time2a，timestamp 为 Unix 毫秒。
+// 时钟同步的结果为 {"time": 1700000000000}。
+// readEvents 读取事件顺序记录（SOE）：连接断开时重新连接，接收子站在链路中断期间缓存并在启动数据传输后上送的突发事件，
+// 直到 eventQuiet 内没有新的报文，与两次读取之间收到的突发事件一起按设备原始时标排序输出，dropped 为缓存已满丢弃的事件数量：
+//
+//	{"points": [{"type": "M_SP_TB_1", "address": 1001, "value": true, "quality": 0, "cause": 3, "timestamp": 1700000000000}], "dropped": 0}
+//
+// 相同 server 和公共地址的节点共享一个连接。
+// 请求成功流转到`Success`链，否则流转到`Failure`链
+type ClientNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config ClientConfiguration
+}
+
+// Type 返回组件类型
+func (x *ClientNode) Type() string {
+	return "x/iec104Client"
+}
+
+// New 默认参数
+func (x *ClientNode) New() types.Node {
+	return &ClientNode{
+		Config: ClientConfiguration{
+			Server:     "127.0.0.1:2404",
+			CommonAddr: DefaultCommonAddr,
+			Timeout:    5,
+			Operation:  OperationInterrogation,
+			TimeSync:   true,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	switch x.Config.Operation {
+	case "":
+		x.Config.Operation = OperationInterrogation
+	case OperationInterrogation, OperationClockSync, OperationReadEvents:
+	default:
+		return fmt.Errorf("unsupported iec104 operation: %s", x.Config.Operation)
+	}
+	config := ClientConfig{
+		Server:       x.Config.Server,
+		CommonAddr:   x.Config.CommonAddr,
+		Timeout:      time.Duration(x.Config.Timeout) * time.Second,
+		TimeSync:     x.Config.TimeSync,
+		Time:         TimeConfig{IgnoreSummerTime: x.Config.IgnoreSummerTime},
+		MaxClockSkew: time.Duration(x.Config.MaxClockSkew) * time.Second,
+		MaxEvents:    x.Config.MaxEvents,
+	}
+	if x.Config.TimeZone != "" {
+		if config.Time.Location, err = time.LoadLocation(x.Config.TimeZone); err != nil {
+			return err
+		}
+	}
+	key := fmt.Sprintf("%s/%d", config.address(), config.CommonAddr)
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), key, ruleConfig.NodeClientInitNow, func() (*Client, error) {
+		return NewClient(config), nil
+	}, func(client *Client) error {
+		if client != nil {
+			return client.Close()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *ClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var result map[string]interface{}
+	switch x.Config.Operation {
+	case OperationClockSync:
+		var t time.Time
+		if t, err = client.SyncClock(); err == nil {
+			result = map[string]interface{}{"time": t.UnixMilli()}
+		}
+	case OperationReadEvents:
+		var points []Point
+		var dropped int
+		if points, dropped, err = client.ReadEvents(time.Duration(x.Config.EventQuiet) * time.Millisecond); err == nil {
+			result = map[string]interface{}{"points": points, "dropped": dropped}
+		}
+	default:
+		var points []Point
+		if points, err = client.Interrogate(); err == nil {
+			result = map[string]interface{}{"points": points}
+		}
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ClientNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ClientNode) Desc() string {
+	return "IEC 60870-5-104 client for general interrogation, clock synchronization and sequence-of-events reads in device timestamp order with configurable CP56Time2a time zone handling. Routes to Success/Failure"
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iec104
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestClientNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ClientNode{})
+
+	for _, configuration := range []types.Configuration{
+		{"operation": "counterInterrogation"},
+		{"timeZone": "Mars/Olympus"},
+	} {
+		_, err := test.CreateAndInitNode("x/iec104Client", configuration, Registry)
+		assert.NotNil(t, err)
+	}
+
+	tc := TimeConfig{Location: time.FixedZone("CET", 3600)}
+	tm := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	station := &testStation{tc: tc, responses: []*ASDU{
+		{Type: MMeTf1, Count: 1, Cause: CauseInterrogated, CommonAddr: 1, Objects: tc.Encode(append(appendIOA(nil, 1001), 0x00, 0x00, 0xac, 0x41, 0x00), tm)},
+	}}
+	startStation(t, station)
+
+	interrogationNode, err := test.CreateAndInitNode("x/iec104Client", types.Configuration{
+		"server":   station.addr,
+		"timeZone": "Etc/GMT-1",
+	}, Registry)
+	assert.Nil(t, err)
+	defer interrogationNode.Destroy()
+	syncNode, err := test.CreateAndInitNode("x/iec104Client", types.Configuration{
+		"server":     station.addr,
+		"commonAddr": 2,
+		"operation":  OperationClockSync,
+		"timeSync":   false,
+		"timeZone":   "Etc/GMT-1",
+	}, Registry)
+	assert.Nil(t, err)
+	defer syncNode.Destroy()
+
+	type clientResult struct {
+		Points []Point `json:"points"`
+		Time   int64   `json:"time"`
+	}
+	// send 发送消息，通过通道返回回调中解析的结果
+	send := func(node types.Node) clientResult {
+		results := make(chan clientResult, 1)
+		test.NodeOnMsg(t, node, []test.Msg{{MetaData: types.NewMetadata(), DataType: types.JSON, MsgType: "TEST", Data: "{}"}}, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			var result clientResult
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+			results <- result
+		})
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for iec104 client node")
+			return clientResult{}
+		}
+	}
+	result := send(interrogationNode)
+	assert.Equal(t, 1, len(result.Points))
+	assert.Equal(t, 21.5, result.Points[0].Value)
+	assert.Equal(t, tm.UnixMilli(), result.Points[0].Timestamp)
+	<-station.syncs
+
+	result = send(syncNode)
+	assert.True(t, result.Time > 0)
+	assert.Equal(t, result.Time, (<-station.syncs).UnixMilli())
+}
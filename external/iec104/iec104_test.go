@@ -0,0 +1,283 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iec104
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testStation 测试用的子站，站总召唤返回 responses 中的 ASDU
+type testStation struct {
+	addr      string
+	tc        TimeConfig
+	responses []*ASDU
+	// syncs 收到的时钟同步
+	syncs chan time.Time
+	// reject 否定确认时钟同步
+	reject bool
+	// events 启动数据传输后上送的缓存事件
+	events []*ASDU
+}
+
+func startStation(t *testing.T, station *testStation) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	station.addr = l.Addr().String()
+	station.syncs = make(chan time.Time, 8)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go station.serve(conn)
+		}
+	}()
+}
+
+func (s *testStation) serve(conn net.Conn) {
+	defer conn.Close()
+	var sendSeq uint16
+	send := func(a *ASDU) {
+		data := a.marshal()
+		b := []byte{startByte, byte(len(data) + 4)}
+		b = binary.LittleEndian.AppendUint16(b, sendSeq<<1)
+		b = binary.LittleEndian.AppendUint16(b, 0)
+		sendSeq++
+		_, _ = conn.Write(append(b, data...))
+	}
+	for {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		frame := make([]byte, header[1])
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			return
+		}
+		if frame[0] == uStartDtAct {
+			// 确认前先发送测试帧
+			_, _ = conn.Write([]byte{startByte, 4, uTestFrAct, 0, 0, 0, startByte, 4, uStartDtCon, 0, 0, 0})
+			for _, event := range s.events {
+				send(event)
+			}
+			continue
+		}
+		if frame[0]&0x01 != 0 {
+			continue
+		}
+		a, err := parseASDU(frame[4:])
+		if err != nil {
+			return
+		}
+		switch a.Type {
+		case CCsNa1:
+			tm, _, _ := s.tc.Decode(a.Objects[ioaSize:])
+			s.syncs <- tm
+			send(&ASDU{Type: CCsNa1, Count: 1, Cause: CauseActivationCon, Negative: s.reject, CommonAddr: a.CommonAddr, Objects: a.Objects})
+		case CIcNa1:
+			send(&ASDU{Type: CIcNa1, Count: 1, Cause: CauseActivationCon, CommonAddr: a.CommonAddr, Objects: a.Objects})
+			for _, resp := range s.responses {
+				send(resp)
+			}
+			send(&ASDU{Type: CIcNa1, Count: 1, Cause: CauseActivationTerm, CommonAddr: a.CommonAddr, Objects: a.Objects})
+		}
+	}
+}
+
+func TestTimeConfig(t *testing.T) {
+	utc := TimeConfig{}
+	now := time.Date(2025, 3, 9, 14, 5, 7, 250*int(time.Millisecond), time.UTC)
+	b := utc.Encode(nil, now)
+	// 2025-03-09 是星期日
+	assert.Equal(t, []byte{0x52, 0x1c, 5, 14, 9 | 7<<5, 3, 25}, b)
+	decoded, invalid, err := utc.Decode(b)
+	assert.Nil(t, err)
+	assert.False(t, invalid)
+	assert.True(t, now.Equal(decoded))
+	b[2] |= cp56Invalid
+	_, invalid, _ = utc.Decode(b)
+	assert.True(t, invalid)
+	_, _, err = utc.Decode(b[:6])
+	assert.NotNil(t, err)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	local := TimeConfig{Location: berlin}
+	summer := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	b = local.Encode(nil, summer)
+	assert.Equal(t, byte(10|cp56SummerTime), b[3])
+	decoded, _, _ = local.Decode(b)
+	assert.True(t, summer.Equal(decoded))
+
+	// 夏令时结束时 02:30 出现两次，按 SU 位区分
+	wall := []byte{0, 0, 30, 2 | cp56SummerTime, 26, 10, 25}
+	decoded, _, _ = local.Decode(wall)
+	assert.Equal(t, time.Date(2025, 10, 26, 0, 30, 0, 0, time.UTC), decoded.UTC())
+	wall[3] = 2
+	decoded, _, _ = local.Decode(wall)
+	assert.Equal(t, time.Date(2025, 10, 26, 1, 30, 0, 0, time.UTC), decoded.UTC())
+
+	// 固定时区的 RTU 设置了 SU 位，墙上时间快 1 小时
+	fixed := TimeConfig{Location: time.FixedZone("CET", 3600)}
+	wall = []byte{0, 0, 0, 10 | cp56SummerTime, 1, 7, 25}
+	decoded, _, _ = fixed.Decode(wall)
+	assert.Equal(t, time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC), decoded.UTC())
+	fixed.IgnoreSummerTime = true
+	decoded, _, _ = fixed.Decode(wall)
+	assert.Equal(t, time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC), decoded.UTC())
+}
+
+func TestParsePoints(t *testing.T) {
+	// 连续地址的短浮点数
+	objects := appendIOA(nil, 1001)
+	for _, v := range []float32{21.5, -3} {
+		objects = binary.LittleEndian.AppendUint32(objects, math.Float32bits(v))
+		objects = append(objects, 0)
+	}
+	points, err := ParsePoints(&ASDU{Type: MMeNc1, Sequence: true, Count: 2, Cause: CauseInterrogated, Objects: objects}, TimeConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, []Point{
+		{Type: "M_ME_NC_1", Address: 1001, Value: 21.5, Cause: CauseInterrogated},
+		{Type: "M_ME_NC_1", Address: 1002, Value: float64(-3), Cause: CauseInterrogated},
+	}, points)
+
+	tm := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	objects = TimeConfig{}.Encode(append(appendIOA(nil, 7), 0x01|QualityInvalid), tm)
+	objects = TimeConfig{}.Encode(append(appendIOA(objects, 9), 0x02), tm)
+	points, err = ParsePoints(&ASDU{Type: MDpTb1, Count: 2, Cause: CauseSpontaneous, Objects: objects}, TimeConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, points[0].Value)
+	assert.Equal(t, uint8(QualityInvalid), points[0].Quality)
+	assert.Equal(t, uint32(9), points[1].Address)
+	assert.Equal(t, 2, points[1].Value)
+	assert.Equal(t, tm.UnixMilli(), points[1].Timestamp)
+
+	points, err = ParsePoints(&ASDU{Type: MMeNa1, Count: 1, Objects: append(appendIOA(nil, 1), 0x00, 0x40, 0)}, TimeConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, points[0].Value)
+	points, err = ParsePoints(&ASDU{Type: MStNa1, Count: 1, Objects: append(appendIOA(nil, 1), 0x7f, 0)}, TimeConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, -1, points[0].Value)
+
+	_, err = ParsePoints(&ASDU{Type: MMeNc1, Count: 2, Objects: objects[:8]}, TimeConfig{})
+	assert.NotNil(t, err)
+	_, err = ParsePoints(&ASDU{Type: 120, Count: 1}, TimeConfig{})
+	assert.NotNil(t, err)
+}
+
+func TestClient(t *testing.T) {
+	tc := TimeConfig{Location: time.FixedZone("CET", 3600)}
+	future := time.Now().Add(time.Hour)
+	station := &testStation{tc: tc, responses: []*ASDU{
+		{Type: MSpNa1, Count: 1, Cause: CauseInterrogated, CommonAddr: 1, Objects: append(appendIOA(nil, 1), 0x01)},
+		// 时钟超前的突发事件
+		{Type: MSpTb1, Count: 1, Cause: CauseSpontaneous, CommonAddr: 1, Objects: tc.Encode(append(appendIOA(nil, 2), 0x00), future)},
+		// 其他公共地址和不支持的类型忽略
+		{Type: MSpNa1, Count: 1, Cause: CauseInterrogated, CommonAddr: 2, Objects: append(appendIOA(nil, 1), 0x01)},
+		{Type: MEiNa1, Count: 1, Cause: CauseInitialized, CommonAddr: 1, Objects: append(appendIOA(nil, 0), 0x00)},
+	}}
+	startStation(t, station)
+
+	client := NewClient(ClientConfig{Server: station.addr, CommonAddr: 1, Timeout: time.Second, TimeSync: true, Time: tc, MaxClockSkew: time.Minute})
+	defer client.Close()
+	points, err := client.Interrogate()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(points))
+	assert.Equal(t, true, points[0].Value)
+	assert.Equal(t, int64(0), points[0].ClockSkew)
+	assert.Equal(t, future.UnixMilli(), points[1].Timestamp)
+	assert.True(t, points[1].ClockSkew > time.Minute.Milliseconds())
+
+	// 连接时同步一次，发现时钟偏差后下一次请求前再同步一次
+	synced := <-station.syncs
+	assert.True(t, time.Since(synced) < time.Second)
+	_, err = client.Interrogate()
+	assert.Nil(t, err)
+	select {
+	case <-station.syncs:
+	case <-time.After(time.Second):
+		t.Fatal("clock not resynchronized")
+	}
+
+	station.reject = true
+	_, err = client.SyncClock()
+	var negative *NegativeError
+	assert.True(t, errors.As(err, &negative))
+	assert.Nil(t, client.Close())
+	_, err = client.Interrogate()
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestReadEvents(t *testing.T) {
+	tc := TimeConfig{}
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	event := func(ioa uint32, value byte, at time.Time) *ASDU {
+		return &ASDU{Type: MSpTb1, Count: 1, Cause: CauseSpontaneous, CommonAddr: 1, Objects: tc.Encode(append(appendIOA(nil, ioa), value), at)}
+	}
+	// 链路中断期间子站缓存的事件，上送顺序和发生顺序不一致
+	station := &testStation{tc: tc, events: []*ASDU{
+		event(3, 0x01, base.Add(3*time.Second)),
+		event(1, 0x01, base.Add(time.Second)),
+		{Type: MMeNc1, Count: 1, Cause: CauseInterrogated, CommonAddr: 1, Objects: append(appendIOA(nil, 9), 0, 0, 0, 0, 0)},
+		event(2, 0x00, base.Add(2*time.Second)),
+	}}
+	startStation(t, station)
+
+	// 连接时的时钟同步期间收到的事件写入事件缓存
+	client := NewClient(ClientConfig{Server: station.addr, CommonAddr: 1, Timeout: time.Second, TimeSync: true, Time: tc, MaxEvents: 2})
+	defer client.Close()
+	_, err := client.SyncClock()
+	assert.Nil(t, err)
+	events, dropped, err := client.ReadEvents(100 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, uint32(1), events[0].Address)
+	assert.Equal(t, base.Add(time.Second).UnixMilli(), events[0].Timestamp)
+	assert.Equal(t, uint32(2), events[1].Address)
+
+	// 重新连接后补齐链路中断期间的事件
+	client = NewClient(ClientConfig{Server: station.addr, CommonAddr: 1, Timeout: time.Second, Time: tc})
+	defer client.Close()
+	events, dropped, err = client.ReadEvents(100 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, 3, len(events))
+	for i, e := range events {
+		assert.Equal(t, uint32(i+1), e.Address)
+		assert.Equal(t, CauseSpontaneous, e.Cause)
+	}
+	events, _, err = client.ReadEvents(50 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+}
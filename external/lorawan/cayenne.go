@@ -0,0 +1,32 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lorawan
+
+import (
+	"github.com/rulego/rulego-components-iot/pkg/lpp"
+)
+
+// DecodeCayenneLPP 解码 Cayenne LPP 负荷，key 为 类型名称_通道，eg. temperature_3，
+// 多分量类型的值为对象，eg. {"gps_1": {"latitude": 42.3519, "longitude": -87.9094, "altitude": 10}}。
+// 支持的数据类型见 lpp 包
+func DecodeCayenneLPP(b []byte) (map[string]interface{}, error) {
+	items, err := lpp.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return lpp.ToMap(items), nil
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lorawan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// DevEuiMetadataKey 设备 EUI
+	DevEuiMetadataKey = "devEui"
+	// DeviceNameMetadataKey 设备名称
+	DeviceNameMetadataKey = "deviceName"
+	// ApplicationIdMetadataKey 应用 Id
+	ApplicationIdMetadataKey = "applicationId"
+	// FPortMetadataKey 应用端口
+	FPortMetadataKey = "fPort"
+	// FCntMetadataKey 上行帧计数
+	FCntMetadataKey = "fCnt"
+)
+
+const (
+	// CodecObject 使用网络服务器已经解码的数据
+	CodecObject = "object"
+	// CodecNone 不解码，只输出十六进制负荷
+	CodecNone = "none"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&DecodeNode{})
+}
+
+// DecodeConfiguration 节点配置
+type DecodeConfiguration struct {
+	// Codec 负荷解码器：cayenneLpp、object（使用网络服务器解码的数据）、none 或者通过 RegisterCodec 注册的解码器
+	Codec string `json:"codec" label:"Codec" desc:"Payload codec: cayenneLpp, object (decoded by the network server), none or a codec registered by RegisterCodec"`
+	// Codecs 应用端口->负荷解码器，优先于 Codec，eg. {"2": "cayenneLpp"}
+	Codecs map[string]string `json:"codecs" label:"Codecs By FPort" desc:"Payload codec by fPort, overrides codec, eg. {\"2\": \"cayenneLpp\"}"`
+}
+
+// DecodeNode LoRaWAN 上行数据解析节点，msg.Data 为 ChirpStack v4/v3 的上行事件或者 The Things Stack v3 的上行消息，
+// 通常来自网络服务器的 MQTT 集成，eg. 订阅 application/+/device/+/event/up。
+// 解析设备信息、fPort、fCnt、信号最强网关的 RSSI/SNR，应用负荷 FRMPayload 按解码器解码到 values，eg.
+//
+//	{"devEui":"0004a30b001c0530","deviceName":"room-1","fPort":2,"fCnt":10,"rssi":-57,"snr":10,"payload":"0367011005670103","values":{"temperature_3":27.2,"temperature_5":25.9}}
+//
+// 并把 devEui、deviceName、applicationId、fPort、fCnt 写入元数据。
+// 解析成功，流转到`Success`链；不是上行事件或者解码失败，流转到`Failure`链
+type DecodeNode struct {
+	//节点配置
+	Config DecodeConfiguration
+	codecs map[int]string
+}
+
+// Type 返回组件类型
+func (x *DecodeNode) Type() string {
+	return "x/lorawanDecode"
+}
+
+// New 默认参数
+func (x *DecodeNode) New() types.Node {
+	return &DecodeNode{
+		Config: DecodeConfiguration{
+			Codec: CodecCayenneLPP,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DecodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if err := checkCodec(x.Config.Codec); err != nil {
+		return err
+	}
+	x.codecs = make(map[int]string, len(x.Config.Codecs))
+	for port, codec := range x.Config.Codecs {
+		fPort, err := strconv.Atoi(port)
+		if err != nil || fPort < 0 || fPort > 255 {
+			return fmt.Errorf("invalid fPort: %s", port)
+		}
+		if err = checkCodec(codec); err != nil {
+			return err
+		}
+		x.codecs[fPort] = codec
+	}
+	return nil
+}
+
+// checkCodec 检查解码器是否存在
+func checkCodec(name string) error {
+	if name == "" || name == CodecObject || name == CodecNone {
+		return nil
+	}
+	if _, ok := GetCodec(name); !ok {
+		return fmt.Errorf("lorawan codec not found: %s", name)
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *DecodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	uplink, err := ParseUplink([]byte(msg.GetData()))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if uplink.Values, err = x.decode(uplink); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(uplink)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(DevEuiMetadataKey, uplink.DevEui)
+	msg.Metadata.PutValue(DeviceNameMetadataKey, uplink.DeviceName)
+	msg.Metadata.PutValue(ApplicationIdMetadataKey, uplink.ApplicationId)
+	msg.Metadata.PutValue(FPortMetadataKey, strconv.Itoa(uplink.FPort))
+	msg.Metadata.PutValue(FCntMetadataKey, strconv.FormatUint(uint64(uplink.FCnt), 10))
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// decode 按端口选择解码器解码应用负荷，MAC 命令端口 0 和空负荷不解码
+func (x *DecodeNode) decode(uplink *Uplink) (interface{}, error) {
+	name, ok := x.codecs[uplink.FPort]
+	if !ok {
+		name = x.Config.Codec
+	}
+	switch {
+	case name == CodecObject:
+		return uplink.Object(), nil
+	case name == "" || name == CodecNone || uplink.FPort == 0 || len(uplink.Data()) == 0:
+		return nil, nil
+	}
+	codec, ok := GetCodec(name)
+	if !ok {
+		return nil, fmt.Errorf("lorawan codec not found: %s", name)
+	}
+	return codec.Decode(uplink.FPort, uplink.Data())
+}
+
+// Destroy 销毁组件
+func (x *DecodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *DecodeNode) Desc() string {
+	return "LoRaWAN uplink decoder for ChirpStack v4/v3 and The Things Stack events, decoding the application payload by Cayenne LPP or a registered codec. Routes to Success/Failure"
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lorawan
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDecodeNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DecodeNode{})
+	_, err := test.CreateAndInitNode("x/lorawanDecode", types.Configuration{"codec": "unknown"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/lorawanDecode", types.Configuration{"codecs": map[string]string{"x": "none"}}, Registry)
+	assert.NotNil(t, err)
+
+	RegisterCodec("test", CodecFunc(func(fPort int, payload []byte) (interface{}, error) {
+		if len(payload) != 2 {
+			return nil, errors.New("invalid payload")
+		}
+		return map[string]interface{}{"counter": int(payload[0])<<8 | int(payload[1])}, nil
+	}))
+	node, err := test.CreateAndInitNode("x/lorawanDecode", types.Configuration{
+		"codecs": map[string]string{"1": "test", "3": "object"},
+	}, Registry)
+	assert.Nil(t, err)
+
+	newMsg := func(msgType, data string) test.Msg {
+		return test.Msg{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    msgType,
+			Data:       data,
+			AfterSleep: time.Millisecond * 100,
+		}
+	}
+	test.NodeOnMsg(t, node, []test.Msg{
+		newMsg("CAYENNE", chirpStackV4Uplink),
+		newMsg("CODEC", `{"deviceInfo": {"devEui": "0004a30b001c0531"}, "fCnt": 1, "fPort": 1, "data": "AQI="}`),
+		newMsg("OBJECT", `{"deviceInfo": {"devEui": "0004a30b001c0532"}, "fPort": 3, "data": "AQI=", "object": {"on": true}}`),
+		newMsg("INVALID", `{"deviceInfo": {"devEui": "0004a30b001c0533"}, "fPort": 2, "data": "AQI="}`),
+		newMsg("JOIN", `{"deviceInfo": {"devEui": "0004a30b001c0534"}, "devAddr": "00189440"}`),
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		var uplink map[string]interface{}
+		switch msg.Type {
+		case "CAYENNE":
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "0004a30b001c0530", msg.Metadata.GetValue(DevEuiMetadataKey))
+			assert.Equal(t, "room-1", msg.Metadata.GetValue(DeviceNameMetadataKey))
+			assert.Equal(t, "2", msg.Metadata.GetValue(FPortMetadataKey))
+			assert.Equal(t, "10", msg.Metadata.GetValue(FCntMetadataKey))
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &uplink))
+			assert.Equal(t, map[string]interface{}{"temperature_3": 27.2, "temperature_5": 25.9}, uplink["values"])
+			assert.Equal(t, -57.0, uplink["rssi"])
+		case "CODEC":
+			assert.Equal(t, types.Success, relationType)
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &uplink))
+			assert.Equal(t, map[string]interface{}{"counter": 258.0}, uplink["values"])
+		case "OBJECT":
+			assert.Equal(t, types.Success, relationType)
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &uplink))
+			assert.Equal(t, map[string]interface{}{"on": true}, uplink["values"])
+		default:
+			assert.Equal(t, types.Failure, relationType)
+		}
+	})
+}
@@ -0,0 +1,351 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lorawan 提供 LoRaWAN 上行数据解析节点
+// 支持 ChirpStack v4/v3 事件和 The Things Stack v3 上行消息，负荷按注册的编解码器解码，内置 Cayenne LPP
+package lorawan
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrNotUplink 不是上行数据事件，eg. join、status 事件
+var ErrNotUplink = errors.New("lorawan: not an uplink event")
+
+// Uplink 上行数据
+type Uplink struct {
+	DevEui          string            `json:"devEui"`
+	DeviceName      string            `json:"deviceName,omitempty"`
+	ApplicationId   string            `json:"applicationId,omitempty"`
+	ApplicationName string            `json:"applicationName,omitempty"`
+	DevAddr         string            `json:"devAddr,omitempty"`
+	FCnt            uint32            `json:"fCnt"`
+	FPort           int               `json:"fPort"`
+	Confirmed       bool              `json:"confirmed"`
+	Time            string            `json:"time,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	// GatewayId、Rssi、Snr 信号最强的网关
+	GatewayId string  `json:"gatewayId,omitempty"`
+	Rssi      int     `json:"rssi"`
+	Snr       float64 `json:"snr"`
+	Frequency int64   `json:"frequency,omitempty"`
+	Dr        int     `json:"dr"`
+	// Payload 应用负荷 FRMPayload，十六进制
+	Payload string `json:"payload"`
+	// Values 解码后的传感器数据
+	Values interface{} `json:"values,omitempty"`
+	// data 应用负荷
+	data []byte
+	// object 网络服务器已经解码的数据
+	object interface{}
+}
+
+// Data 应用负荷
+func (u *Uplink) Data() []byte {
+	return u.data
+}
+
+// Object 网络服务器已经解码的数据，没有返回 nil
+func (u *Uplink) Object() interface{} {
+	return u.object
+}
+
+type rxInfo struct {
+	GatewayId string
+	Rssi      int
+	Snr       float64
+}
+
+// setPayload 解码 base64 应用负荷
+func (u *Uplink) setPayload(s string) error {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("lorawan: invalid payload: %w", err)
+	}
+	u.data, u.Payload = data, hex.EncodeToString(data)
+	return nil
+}
+
+// setRxInfo 选择信号最强的网关
+func (u *Uplink) setRxInfo(infos []rxInfo) {
+	for i, info := range infos {
+		if i == 0 || info.Rssi > u.Rssi {
+			u.GatewayId, u.Rssi, u.Snr = info.GatewayId, info.Rssi, info.Snr
+		}
+	}
+}
+
+// chirpStackV4 ChirpStack v4 上行事件
+type chirpStackV4 struct {
+	Time       string `json:"time"`
+	DeviceInfo struct {
+		ApplicationId   string            `json:"applicationId"`
+		ApplicationName string            `json:"applicationName"`
+		DeviceName      string            `json:"deviceName"`
+		DevEui          string            `json:"devEui"`
+		Tags            map[string]string `json:"tags"`
+	} `json:"deviceInfo"`
+	DevAddr   string                 `json:"devAddr"`
+	Dr        int                    `json:"dr"`
+	FCnt      uint32                 `json:"fCnt"`
+	FPort     *int                   `json:"fPort"`
+	Confirmed bool                   `json:"confirmed"`
+	Data      string                 `json:"data"`
+	Object    map[string]interface{} `json:"object"`
+	RxInfo    []struct {
+		GatewayId string  `json:"gatewayId"`
+		Rssi      int     `json:"rssi"`
+		Snr       float64 `json:"snr"`
+	} `json:"rxInfo"`
+	TxInfo struct {
+		Frequency int64 `json:"frequency"`
+	} `json:"txInfo"`
+}
+
+// chirpStackV3 ChirpStack v3 上行事件，devEUI 为十六进制或者 base64
+type chirpStackV3 struct {
+	ApplicationId   string            `json:"applicationID"`
+	ApplicationName string            `json:"applicationName"`
+	DeviceName      string            `json:"deviceName"`
+	DevEui          string            `json:"devEUI"`
+	DevAddr         string            `json:"devAddr"`
+	Dr              int               `json:"dr"`
+	FCnt            uint32            `json:"fCnt"`
+	FPort           *int              `json:"fPort"`
+	Confirmed       bool              `json:"confirmedUplink"`
+	Data            string            `json:"data"`
+	ObjectJSON      string            `json:"objectJSON"`
+	Tags            map[string]string `json:"tags"`
+	RxInfo          []struct {
+		GatewayId string  `json:"gatewayID"`
+		Time      string  `json:"time"`
+		Rssi      int     `json:"rssi"`
+		LoRaSnr   float64 `json:"loRaSNR"`
+	} `json:"rxInfo"`
+	TxInfo struct {
+		Frequency int64 `json:"frequency"`
+		Dr        int   `json:"dr"`
+	} `json:"txInfo"`
+}
+
+// thingsStack The Things Stack v3 上行消息
+type thingsStack struct {
+	EndDeviceIds struct {
+		DeviceId       string `json:"device_id"`
+		DevEui         string `json:"dev_eui"`
+		DevAddr        string `json:"dev_addr"`
+		ApplicationIds struct {
+			ApplicationId string `json:"application_id"`
+		} `json:"application_ids"`
+	} `json:"end_device_ids"`
+	ReceivedAt    string `json:"received_at"`
+	UplinkMessage *struct {
+		FPort          *int                   `json:"f_port"`
+		FCnt           uint32                 `json:"f_cnt"`
+		FrmPayload     string                 `json:"frm_payload"`
+		DecodedPayload map[string]interface{} `json:"decoded_payload"`
+		Confirmed      bool                   `json:"confirmed"`
+		RxMetadata     []struct {
+			GatewayIds struct {
+				GatewayId string `json:"gateway_id"`
+			} `json:"gateway_ids"`
+			Rssi int     `json:"rssi"`
+			Snr  float64 `json:"snr"`
+		} `json:"rx_metadata"`
+		Settings struct {
+			Frequency string `json:"frequency"`
+		} `json:"settings"`
+	} `json:"uplink_message"`
+}
+
+// ParseUplink 解析上行事件 JSON，自动识别 ChirpStack v4、ChirpStack v3 和 The Things Stack v3 格式
+func ParseUplink(b []byte) (*Uplink, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	switch {
+	case fields["deviceInfo"] != nil:
+		return parseChirpStackV4(b)
+	case fields["devEUI"] != nil:
+		return parseChirpStackV3(b)
+	case fields["end_device_ids"] != nil:
+		return parseThingsStack(b)
+	}
+	return nil, errors.New("lorawan: unknown uplink format")
+}
+
+func parseChirpStackV4(b []byte) (*Uplink, error) {
+	var event chirpStackV4
+	if err := json.Unmarshal(b, &event); err != nil {
+		return nil, err
+	}
+	if event.FPort == nil {
+		return nil, ErrNotUplink
+	}
+	u := &Uplink{
+		DevEui:          strings.ToLower(event.DeviceInfo.DevEui),
+		DeviceName:      event.DeviceInfo.DeviceName,
+		ApplicationId:   event.DeviceInfo.ApplicationId,
+		ApplicationName: event.DeviceInfo.ApplicationName,
+		DevAddr:         event.DevAddr,
+		FCnt:            event.FCnt,
+		FPort:           *event.FPort,
+		Confirmed:       event.Confirmed,
+		Time:            event.Time,
+		Tags:            event.DeviceInfo.Tags,
+		Frequency:       event.TxInfo.Frequency,
+		Dr:              event.Dr,
+	}
+	if event.Object != nil {
+		u.object = event.Object
+	}
+	infos := make([]rxInfo, len(event.RxInfo))
+	for i, info := range event.RxInfo {
+		infos[i] = rxInfo{GatewayId: info.GatewayId, Rssi: info.Rssi, Snr: info.Snr}
+	}
+	u.setRxInfo(infos)
+	return u, u.setPayload(event.Data)
+}
+
+func parseChirpStackV3(b []byte) (*Uplink, error) {
+	var event chirpStackV3
+	if err := json.Unmarshal(b, &event); err != nil {
+		return nil, err
+	}
+	if event.FPort == nil {
+		return nil, ErrNotUplink
+	}
+	u := &Uplink{
+		DevEui:          decodeEui(event.DevEui),
+		DeviceName:      event.DeviceName,
+		ApplicationId:   event.ApplicationId,
+		ApplicationName: event.ApplicationName,
+		DevAddr:         decodeEui(event.DevAddr),
+		FCnt:            event.FCnt,
+		FPort:           *event.FPort,
+		Confirmed:       event.Confirmed,
+		Tags:            event.Tags,
+		Frequency:       event.TxInfo.Frequency,
+		Dr:              event.TxInfo.Dr,
+	}
+	if event.Dr != 0 {
+		u.Dr = event.Dr
+	}
+	if event.ObjectJSON != "" {
+		var object interface{}
+		if err := json.Unmarshal([]byte(event.ObjectJSON), &object); err == nil {
+			u.object = object
+		}
+	}
+	infos := make([]rxInfo, len(event.RxInfo))
+	for i, info := range event.RxInfo {
+		infos[i] = rxInfo{GatewayId: decodeEui(info.GatewayId), Rssi: info.Rssi, Snr: info.LoRaSnr}
+		if u.Time == "" {
+			u.Time = info.Time
+		}
+	}
+	u.setRxInfo(infos)
+	return u, u.setPayload(event.Data)
+}
+
+func parseThingsStack(b []byte) (*Uplink, error) {
+	var event thingsStack
+	if err := json.Unmarshal(b, &event); err != nil {
+		return nil, err
+	}
+	message := event.UplinkMessage
+	if message == nil || message.FPort == nil {
+		return nil, ErrNotUplink
+	}
+	u := &Uplink{
+		DevEui:        strings.ToLower(event.EndDeviceIds.DevEui),
+		DeviceName:    event.EndDeviceIds.DeviceId,
+		ApplicationId: event.EndDeviceIds.ApplicationIds.ApplicationId,
+		DevAddr:       strings.ToLower(event.EndDeviceIds.DevAddr),
+		FCnt:          message.FCnt,
+		FPort:         *message.FPort,
+		Confirmed:     message.Confirmed,
+		Time:          event.ReceivedAt,
+	}
+	_, _ = fmt.Sscan(message.Settings.Frequency, &u.Frequency)
+	if message.DecodedPayload != nil {
+		u.object = message.DecodedPayload
+	}
+	infos := make([]rxInfo, len(message.RxMetadata))
+	for i, info := range message.RxMetadata {
+		infos[i] = rxInfo{GatewayId: info.GatewayIds.GatewayId, Rssi: info.Rssi, Snr: info.Snr}
+	}
+	u.setRxInfo(infos)
+	return u, u.setPayload(message.FrmPayload)
+}
+
+// decodeEui ChirpStack v3 的 JSON 编码器把 EUI 编码为 base64，统一转换为小写十六进制
+func decodeEui(s string) string {
+	if _, err := hex.DecodeString(s); err == nil {
+		return strings.ToLower(s)
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return hex.EncodeToString(b)
+	}
+	return s
+}
+
+// Codec 负荷解码器
+type Codec interface {
+	// Decode 按端口解码应用负荷
+	Decode(fPort int, payload []byte) (interface{}, error)
+}
+
+// CodecFunc 函数实现的负荷解码器
+type CodecFunc func(fPort int, payload []byte) (interface{}, error)
+
+func (f CodecFunc) Decode(fPort int, payload []byte) (interface{}, error) {
+	return f(fPort, payload)
+}
+
+// CodecCayenneLPP 内置的 Cayenne LPP 解码器名称
+const CodecCayenneLPP = "cayenneLpp"
+
+var (
+	codecsLock sync.RWMutex
+	codecs     = map[string]Codec{
+		CodecCayenneLPP: CodecFunc(func(fPort int, payload []byte) (interface{}, error) {
+			return DecodeCayenneLPP(payload)
+		}),
+	}
+)
+
+// RegisterCodec 注册负荷解码器，x/lorawanDecode 节点通过名称使用
+func RegisterCodec(name string, codec Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+	codecs[name] = codec
+}
+
+// GetCodec 获取负荷解码器
+func GetCodec(name string) (Codec, bool) {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+	codec, ok := codecs[name]
+	return codec, ok
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lorawan
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// chirpStackV4Uplink ChirpStack v4 上行事件
+const chirpStackV4Uplink = `{
+	"deduplicationId": "3ac7e3c4-4401-4b8d-9386-a5c902f9202d",
+	"time": "2024-03-15T08:30:00.123Z",
+	"deviceInfo": {
+		"tenantId": "52f14cd4-c6f1-4fbd-8f87-4025e1d49242",
+		"tenantName": "ChirpStack",
+		"applicationId": "17c82e96-be03-4f38-aef3-f83d48582d97",
+		"applicationName": "building",
+		"deviceProfileName": "cayenne",
+		"deviceName": "room-1",
+		"devEui": "0004A30B001C0530",
+		"tags": {"floor": "3"}
+	},
+	"devAddr": "00189440",
+	"adr": true,
+	"dr": 5,
+	"fCnt": 10,
+	"fPort": 2,
+	"confirmed": false,
+	"data": "A2cBEAVnAQM=",
+	"object": {"temperature": 27.2},
+	"rxInfo": [
+		{"gatewayId": "0016c001ff10a235", "rssi": -80, "snr": 7.5},
+		{"gatewayId": "0016c001ff10a236", "rssi": -57, "snr": 10}
+	],
+	"txInfo": {"frequency": 868100000, "modulation": {"lora": {"bandwidth": 125000, "spreadingFactor": 7}}}
+}`
+
+func TestCayenneLPP(t *testing.T) {
+	tests := []struct {
+		data   string
+		values map[string]interface{}
+	}{
+		{"03670110056700ff", map[string]interface{}{"temperature_3": 27.2, "temperature_5": 25.5}},
+		{"0167ffd7", map[string]interface{}{"temperature_1": -4.1}},
+		{"067104d2fb2e0000", map[string]interface{}{"accelerometer_6": map[string]interface{}{"x": 1.234, "y": -1.234, "z": 0.0}}},
+		{"018806765ff2960a0003e8", map[string]interface{}{"gps_1": map[string]interface{}{"latitude": 42.3519, "longitude": -87.9094, "altitude": 10.0}}},
+		{"010064020201f403662c0468290573275d", map[string]interface{}{
+			"digital_in_1": int64(100), "analog_in_2": 5.0, "presence_3": int64(44), "relative_humidity_4": 20.5, "barometric_pressure_5": 1007.7,
+		}},
+		{"07650190", map[string]interface{}{"luminosity_7": int64(400)}},
+		{"", map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.data)
+		values, err := DecodeCayenneLPP(data)
+		assert.Nil(t, err, tt.data)
+		assert.Equal(t, tt.values, values, tt.data)
+	}
+	for _, s := range []string{"03", "0367", "036701", "03ff0000", "018806765ff2960a00"} {
+		data, _ := hex.DecodeString(s)
+		_, err := DecodeCayenneLPP(data)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestParseUplink(t *testing.T) {
+	uplink, err := ParseUplink([]byte(chirpStackV4Uplink))
+	assert.Nil(t, err)
+	assert.Equal(t, "0004a30b001c0530", uplink.DevEui)
+	assert.Equal(t, "room-1", uplink.DeviceName)
+	assert.Equal(t, "building", uplink.ApplicationName)
+	assert.Equal(t, 2, uplink.FPort)
+	assert.Equal(t, uint32(10), uplink.FCnt)
+	assert.Equal(t, 5, uplink.Dr)
+	assert.Equal(t, "0016c001ff10a236", uplink.GatewayId)
+	assert.Equal(t, -57, uplink.Rssi)
+	assert.Equal(t, 10.0, uplink.Snr)
+	assert.Equal(t, int64(868100000), uplink.Frequency)
+	assert.Equal(t, "0367011005670103", uplink.Payload)
+	assert.Equal(t, map[string]string{"floor": "3"}, uplink.Tags)
+	assert.Equal(t, map[string]interface{}{"temperature": 27.2}, uplink.Object())
+
+	// ChirpStack v3，EUI 为 base64
+	uplink, err = ParseUplink([]byte(`{
+		"applicationID": "1", "applicationName": "building", "deviceName": "room-1", "devEUI": "AASjCwAcBTA=",
+		"rxInfo": [{"gatewayID": "ABbAAf8QojU=", "time": "2024-03-15T08:30:00Z", "rssi": -60, "loRaSNR": 9}],
+		"txInfo": {"frequency": 868300000, "dr": 3},
+		"adr": true, "fCnt": 11, "fPort": 2, "data": "AQI=", "objectJSON": "{\"on\":true}"
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "0004a30b001c0530", uplink.DevEui)
+	assert.Equal(t, "0016c001ff10a235", uplink.GatewayId)
+	assert.Equal(t, 9.0, uplink.Snr)
+	assert.Equal(t, 3, uplink.Dr)
+	assert.Equal(t, "2024-03-15T08:30:00Z", uplink.Time)
+	assert.Equal(t, "0102", uplink.Payload)
+	assert.Equal(t, map[string]interface{}{"on": true}, uplink.Object())
+
+	// The Things Stack v3
+	uplink, err = ParseUplink([]byte(`{
+		"end_device_ids": {"device_id": "room-1", "application_ids": {"application_id": "building"}, "dev_eui": "0004A30B001C0530", "dev_addr": "260B1234"},
+		"received_at": "2024-03-15T08:30:00.5Z",
+		"uplink_message": {
+			"f_port": 1, "f_cnt": 12, "frm_payload": "AQI=",
+			"rx_metadata": [{"gateway_ids": {"gateway_id": "gw-1"}, "rssi": -70, "snr": 5.25}],
+			"settings": {"frequency": "867100000"}
+		}
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "0004a30b001c0530", uplink.DevEui)
+	assert.Equal(t, "building", uplink.ApplicationId)
+	assert.Equal(t, "260b1234", uplink.DevAddr)
+	assert.Equal(t, 1, uplink.FPort)
+	assert.Equal(t, "gw-1", uplink.GatewayId)
+	assert.Equal(t, int64(867100000), uplink.Frequency)
+	assert.Nil(t, uplink.Object())
+
+	// 不是上行事件
+	_, err = ParseUplink([]byte(`{"deviceInfo": {"devEui": "0004a30b001c0530"}, "devAddr": "00189440"}`))
+	assert.Equal(t, ErrNotUplink, err)
+	_, err = ParseUplink([]byte(`{"end_device_ids": {"device_id": "room-1"}, "join_accept": {}}`))
+	assert.Equal(t, ErrNotUplink, err)
+	_, err = ParseUplink([]byte(`{"temperature": 20}`))
+	assert.NotNil(t, err)
+	_, err = ParseUplink([]byte(`{"deviceInfo": {}, "fPort": 1, "data": "%%"}`))
+	assert.NotNil(t, err)
+}
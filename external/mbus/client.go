@@ -0,0 +1,318 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mbus
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/serial"
+)
+
+const (
+	// SerialScheme 串口地址前缀，eg. serial:///dev/ttyUSB0
+	SerialScheme = "serial://"
+	// DefaultBaudRate M-Bus 默认波特率
+	DefaultBaudRate = 2400
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 3 * time.Second
+	// addressSecondary 已选择的二级地址电表的主地址
+	addressSecondary = 0xfd
+	// maxTelegrams 多电文读取的最大电文数
+	maxTelegrams = 16
+)
+
+// 帧和控制字段
+const (
+	frameAck   = 0xe5
+	frameShort = 0x10
+	frameLong  = 0x68
+	frameStop  = 0x16
+	ctrlSndNke = 0x40
+	ctrlSndUd  = 0x53
+	// ctrlReqUd2 REQ_UD2，FCB 位 0x20 交替
+	ctrlReqUd2 = 0x5b
+	ctrlFCB    = 0x20
+	// ciSelect 二级地址选择
+	ciSelect = 0x52
+)
+
+var (
+	// ErrClosed 连接已经关闭
+	ErrClosed = errors.New("mbus connection is closed")
+	// ErrNoResponse 电表没有响应
+	ErrNoResponse = errors.New("mbus: no response from meter")
+)
+
+// Address 电表地址，主地址 0~250 或者 8 位二级地址（识别号，F 为通配符）
+type Address struct {
+	Primary   int
+	Secondary string
+}
+
+// ParseAddress 解析电表地址，8 位为二级地址，否则为主地址
+func ParseAddress(s string) (Address, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 8 {
+		for _, c := range strings.ToLower(s) {
+			if (c < '0' || c > '9') && c != 'f' {
+				return Address{}, fmt.Errorf("invalid mbus secondary address: %s", s)
+			}
+		}
+		return Address{Secondary: strings.ToUpper(s)}, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 || v > 250 {
+		return Address{}, fmt.Errorf("invalid mbus address: %s", s)
+	}
+	return Address{Primary: v}, nil
+}
+
+func (a Address) String() string {
+	if a.Secondary != "" {
+		return a.Secondary
+	}
+	return strconv.Itoa(a.Primary)
+}
+
+// ClientConfig 客户端配置
+type ClientConfig struct {
+	// Server 网关地址，TCP 格式：host:port，串口格式：serial:///dev/ttyUSB0
+	Server string
+	// BaudRate 串口波特率
+	BaudRate int
+	// Timeout 连接和请求超时
+	Timeout time.Duration
+}
+
+// Key 共享连接的 key
+func (c ClientConfig) Key() string {
+	return c.Server
+}
+
+// Client 有线 M-Bus 主站，一个总线上的电表按顺序读取
+// 连接断开时关闭客户端，由调用方重新建立
+type Client struct {
+	rw       io.ReadWriteCloser
+	reader   *bufio.Reader
+	timeout  time.Duration
+	deadline time.Time
+	mu       sync.Mutex
+	closed   bool
+}
+
+// Dial 打开串口或者连接 TCP 网关
+func Dial(config ClientConfig) (*Client, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c := &Client{timeout: timeout}
+	if strings.HasPrefix(config.Server, SerialScheme) {
+		baudRate := config.BaudRate
+		if baudRate == 0 {
+			baudRate = DefaultBaudRate
+		}
+		port, err := serial.Open(&serial.Config{
+			Address:  strings.TrimPrefix(config.Server, SerialScheme),
+			BaudRate: baudRate,
+			DataBits: 8,
+			StopBits: 1,
+			Parity:   "E",
+			Timeout:  100 * time.Millisecond,
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.rw = port
+	} else {
+		conn, err := net.DialTimeout("tcp", strings.TrimPrefix(config.Server, "tcp://"), timeout)
+		if err != nil {
+			return nil, err
+		}
+		c.rw = conn
+	}
+	c.reader = bufio.NewReader(c)
+	return c, nil
+}
+
+// Read 串口读取超时时在截止时间之前重试
+func (c *Client) Read(p []byte) (int, error) {
+	for {
+		n, err := c.rw.Read(p)
+		if n > 0 || !errors.Is(err, serial.ErrTimeout) || time.Now().After(c.deadline) {
+			return n, err
+		}
+	}
+}
+
+// Closed 连接是否已经关闭
+func (c *Client) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rw.Close()
+}
+
+// ReadMeter 读取电表数据，多电文的记录合并到第一个电文
+func (c *Client) ReadMeter(address Address) (*Telegram, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	primary := byte(address.Primary)
+	if address.Secondary != "" {
+		if err := c.selectSecondary(address.Secondary); err != nil {
+			return nil, err
+		}
+		primary = addressSecondary
+	} else if err := c.exchangeAck(shortFrame(ctrlSndNke, primary)); err != nil {
+		return nil, err
+	}
+	var telegram *Telegram
+	fcb := byte(ctrlFCB)
+	for i := 0; i < maxTelegrams; i++ {
+		data, err := c.exchange(shortFrame(ctrlReqUd2|fcb, primary))
+		if err != nil {
+			return nil, err
+		}
+		t, err := ParseUserData(data)
+		if err != nil {
+			return nil, err
+		}
+		if telegram == nil {
+			telegram = t
+		} else {
+			telegram.Records = append(telegram.Records, t.Records...)
+		}
+		if !t.more {
+			break
+		}
+		fcb ^= ctrlFCB
+	}
+	telegram.ManufacturerData = ""
+	telegram.summarize()
+	return telegram, nil
+}
+
+// selectSecondary 按识别号选择电表，制造商、版本和介质为通配
+func (c *Client) selectSecondary(id string) error {
+	data := []byte{ciSelect, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}
+	for i := 0; i < 4; i++ {
+		v, _ := strconv.ParseUint(id[6-2*i:8-2*i], 16, 8)
+		data[1+i] = byte(v)
+	}
+	return c.exchangeAck(longFrame(ctrlSndUd, addressSecondary, data))
+}
+
+func shortFrame(control, address byte) []byte {
+	return []byte{frameShort, control, address, control + address, frameStop}
+}
+
+func longFrame(control, address byte, data []byte) []byte {
+	l := byte(len(data) + 2)
+	b := []byte{frameLong, l, l, frameLong, control, address}
+	b = append(b, data...)
+	return append(b, checksum(b[4:]), frameStop)
+}
+
+func checksum(b []byte) byte {
+	var sum byte
+	for _, v := range b {
+		sum += v
+	}
+	return sum
+}
+
+// exchangeAck 发送帧并等待单字符确认
+func (c *Client) exchangeAck(frame []byte) error {
+	if err := c.write(frame); err != nil {
+		return err
+	}
+	b, err := c.reader.ReadByte()
+	if err != nil {
+		return c.fail(err)
+	}
+	if b != frameAck {
+		return fmt.Errorf("mbus: unexpected response 0x%02x, expected ack", b)
+	}
+	return nil
+}
+
+// exchange 发送 REQ_UD2 并接收 RSP_UD 长帧，返回从 CI 开始的用户数据
+func (c *Client) exchange(frame []byte) ([]byte, error) {
+	if err := c.write(frame); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return nil, c.fail(err)
+	}
+	if header[0] != frameLong || header[3] != frameLong || header[1] != header[2] || header[1] < 3 {
+		return nil, fmt.Errorf("mbus: invalid long frame header % x", header)
+	}
+	body := make([]byte, int(header[1])+2)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return nil, c.fail(err)
+	}
+	if body[len(body)-1] != frameStop || checksum(body[:len(body)-2]) != body[len(body)-2] {
+		return nil, errors.New("mbus: bad checksum")
+	}
+	return body[2 : len(body)-2], nil
+}
+
+// write 发送帧，丢弃之前超时的响应
+func (c *Client) write(frame []byte) error {
+	c.reader.Reset(c)
+	c.deadline = time.Now().Add(c.timeout)
+	if conn, ok := c.rw.(net.Conn); ok {
+		_ = conn.SetDeadline(c.deadline)
+	}
+	if _, err := c.rw.Write(frame); err != nil {
+		return c.fail(err)
+	}
+	return nil
+}
+
+// fail 电表没有响应时返回 ErrNoResponse，其他读写错误关闭连接，由共享连接重新建立
+func (c *Client) fail(err error) error {
+	var ne net.Error
+	if errors.Is(err, serial.ErrTimeout) || errors.As(err, &ne) && ne.Timeout() {
+		return ErrNoResponse
+	}
+	c.closed = true
+	_ = c.rw.Close()
+	return err
+}
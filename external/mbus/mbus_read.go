@@ -0,0 +1,213 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// Server 网关地址，TCP 格式：host:port，串口格式：serial:///dev/ttyUSB0
+	Server string `json:"server" label:"Server" desc:"M-Bus gateway address, tcp: host:port, serial: serial:///dev/ttyUSB0" required:"true" ref:"primary"`
+	// BaudRate 串口波特率
+	BaudRate int `json:"baudRate" label:"Baud Rate" desc:"Serial baud rate, 8E1 framing"`
+	// Timeout 连接和每个电表的响应超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and meter response timeout in seconds"`
+	// Items 读取的电表，为空则使用消息负荷 msg.Data 中的电表
+	Items []Item `json:"items" label:"Items" desc:"Meters to read, empty uses the meters in msg.Data"`
+}
+
+// Item 读取的电表
+type Item struct {
+	// Name 名称，作为输出的 key，为空使用地址
+	Name string `json:"name,omitempty"`
+	// Address 主地址 0~250 或者 8 位二级地址
+	Address string `json:"address"`
+}
+
+// ReadNode 有线 M-Bus 读取节点，通过串口或者透明传输的 TCP 网关读取热量表、水表、燃气表等电表
+// 电表来自配置 items 或者消息负荷 msg.Data，格式：
+//
+//	[
+//	  {"name": "heat", "address": "5"},
+//	  {"name": "water", "address": "12345678"}
+//	]
+//
+// 也可以是地址数组：["5", "12345678"]。8 位地址为二级地址（识别号，F 为通配符），先选择电表后读取。
+// 多电文的记录合并，结果以名称为 key 重新赋值到msg.Data：
+//
+//	{"heat": {"id": "12345678", "manufacturer": "KAM", "medium": "heat", "values": {"energy": 12345000, "volume": 123.45}, "records": [...]}}
+//
+// 同一总线的节点共享一个连接。所有电表读取成功，流转到`Success`链，否则流转到`Failure`链
+type ReadNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config ReadConfiguration
+	// addresses 配置的电表解析后的地址
+	addresses []Address
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/mbusRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Server:   "serial:///dev/ttyUSB0",
+			BaudRate: DefaultBaudRate,
+			Timeout:  3,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.addresses, err = parseItems(x.Config.Items); err != nil {
+		return err
+	}
+	config := ClientConfig{
+		Server:   x.Config.Server,
+		BaudRate: x.Config.BaudRate,
+		Timeout:  time.Duration(x.Config.Timeout) * time.Second,
+	}
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), config.Key(), ruleConfig.NodeClientInitNow, func() (*SharedConn, error) {
+		conn := AcquireConn(config)
+		if _, err := conn.Client(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items, addresses := x.Config.Items, x.addresses
+	if len(items) == 0 {
+		var err error
+		if items, err = parseMsgItems(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if addresses, err = parseItems(items); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{}, len(items))
+	for i, address := range addresses {
+		var telegram *Telegram
+		err = conn.Do(func(client *Client) error {
+			telegram, err = client.ReadMeter(address)
+			return err
+		})
+		if err != nil {
+			ctx.TellFailure(msg, fmt.Errorf("meter %s: %w", address, err))
+			return
+		}
+		result[items[i].key()] = telegram
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "Wired M-Bus meter reader over serial or TCP gateways by primary or secondary address, returning decoded records. Routes to Success/Failure"
+}
+
+// key 输出的 key，名称为空使用地址
+func (i Item) key() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return i.Address
+}
+
+// parseItems 解析电表地址
+func parseItems(items []Item) ([]Address, error) {
+	addresses := make([]Address, 0, len(items))
+	for _, item := range items {
+		address, err := ParseAddress(item.Address)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// parseMsgItems 解析消息负荷中的电表，支持电表对象数组或者地址数组
+func parseMsgItems(data string) ([]Item, error) {
+	data = strings.TrimSpace(data)
+	var items []Item
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		var list []interface{}
+		if json.Unmarshal([]byte(data), &list) != nil {
+			return nil, err
+		}
+		items = nil
+		for _, address := range list {
+			items = append(items, Item{Address: fmt.Sprint(address)})
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no mbus meters")
+	}
+	return items, nil
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mbus
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testGateway 模拟透明传输的 M-Bus 网关，总线上有主地址 5、识别号 12345678 的热量表
+type testGateway struct {
+	listener net.Listener
+	mu       sync.Mutex
+	// requests 收到的 REQ_UD2 控制字段
+	requests []byte
+}
+
+func startTestGateway(t *testing.T) *testGateway {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	g := &testGateway{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go g.serve(conn)
+		}
+	}()
+	return g
+}
+
+func (g *testGateway) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	selected, telegram := false, 0
+	for {
+		start, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		switch start {
+		case frameShort:
+			b := make([]byte, 4)
+			if _, err = io.ReadFull(r, b); err != nil {
+				return
+			}
+			control, address := b[0], b[1]
+			if address != 5 && !(address == addressSecondary && selected) {
+				// 电表不存在，不响应
+				continue
+			}
+			if control == ctrlSndNke {
+				telegram = 0
+				_, _ = conn.Write([]byte{frameAck})
+				continue
+			}
+			g.mu.Lock()
+			g.requests = append(g.requests, control)
+			g.mu.Unlock()
+			// 第 1 个电文后面还有记录
+			data := testUserData(telegram == 0)
+			if telegram == 1 {
+				data = append([]byte{ciResponseNone}, 0x04, 0x06, 0x02, 0x00, 0x00, 0x00)
+			}
+			telegram++
+			_, _ = conn.Write(longFrame(0x08, address, data))
+		case frameLong:
+			b := make([]byte, 3)
+			if _, err = io.ReadFull(r, b); err != nil {
+				return
+			}
+			body := make([]byte, int(b[0])+2)
+			if _, err = io.ReadFull(r, body); err != nil {
+				return
+			}
+			selected = body[2] == ciSelect && hex.EncodeToString(body[3:7]) == "78563412"
+			telegram = 0
+			if selected {
+				_, _ = conn.Write([]byte{frameAck})
+			}
+		}
+	}
+}
+
+func TestReadNode(t *testing.T) {
+	gateway := startTestGateway(t)
+	defer gateway.listener.Close()
+	server := gateway.listener.Addr().String()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	_, err := test.CreateAndInitNode("x/mbusRead", types.Configuration{
+		"server": server,
+		"items":  []map[string]interface{}{{"address": "251"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/mbusRead", types.Configuration{
+		"server":  server,
+		"timeout": 1,
+		"items": []map[string]interface{}{
+			{"name": "primary", "address": "5"},
+			{"name": "secondary", "address": "12345678"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgs := []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}
+	test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var values map[string]Telegram
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		for _, name := range []string{"primary", "secondary"} {
+			assert.Equal(t, "12345678", values[name].Id)
+			assert.Equal(t, "heat", values[name].Medium)
+			// 第 2 个电文的记录合并
+			assert.Equal(t, 8, len(values[name].Records))
+			assert.Equal(t, 2000.0, values[name].Records[7].Value)
+			assert.Equal(t, 12345000.0, values[name].Values["energy"])
+		}
+	})
+	// 多电文读取时 FCB 交替
+	gateway.mu.Lock()
+	assert.Equal(t, []byte{0x7b, 0x5b, 0x7b, 0x5b}, gateway.requests)
+	gateway.mu.Unlock()
+
+	// 不存在的电表超时
+	node2, err := test.CreateAndInitNode("x/mbusRead", types.Configuration{
+		"server":  server,
+		"timeout": 1,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node2.Destroy()
+	msgs = []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `[7]`,
+		AfterSleep: time.Millisecond * 1200,
+	}, {
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `["5"]`,
+		AfterSleep: time.Millisecond * 200,
+	}}
+	var count int
+	test.NodeOnMsg(t, node2, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		count++
+		if count == 1 {
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, "meter 7: mbus: no response from meter", err.Error())
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+	})
+}
+
+func TestWirelessDecodeNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WirelessDecodeNode{})
+	_, err := test.CreateAndInitNode("x/wmbusDecode", types.Configuration{
+		"keys": map[string]interface{}{"12345678": "0102"},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/wmbusDecode", types.Configuration{
+		"keys": map[string]interface{}{"12345678": "000102030405060708090a0b0c0d0e0f"},
+	}, Registry)
+	assert.Nil(t, err)
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	telegram := testWireless(t, key)
+
+	msgs := []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.TEXT,
+		MsgType:    "TELEGRAM",
+		Data:       hex.EncodeToString(telegram),
+		AfterSleep: time.Millisecond * 100,
+	}, {
+		MetaData:   types.NewMetadata(),
+		DataType:   types.TEXT,
+		MsgType:    "TELEGRAM",
+		Data:       "zz",
+		AfterSleep: time.Millisecond * 100,
+	}}
+	var count int
+	test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		count++
+		if count == 2 {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "12345678", msg.Metadata.GetValue(MeterIdMetadataKey))
+		assert.Equal(t, "water", msg.Metadata.GetValue(MediumMetadataKey))
+		var values Telegram
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 12.345, values.Values["volume"])
+	})
+}
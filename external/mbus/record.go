@@ -0,0 +1,558 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 记录的功能
+const (
+	FunctionInstantaneous = "instantaneous"
+	FunctionMaximum       = "maximum"
+	FunctionMinimum       = "minimum"
+	FunctionError         = "error"
+)
+
+var functions = [4]string{FunctionInstantaneous, FunctionMaximum, FunctionMinimum, FunctionError}
+
+// 控制信息字段 CI
+const (
+	ciResponseLong  = 0x72
+	ciResponseNone  = 0x78
+	ciResponseShort = 0x7a
+	ciELL           = 0x8c
+)
+
+// 特殊 DIF
+const (
+	difManufacturer = 0x0f
+	difMoreRecords  = 0x1f
+	difIdle         = 0x2f
+)
+
+var errShortData = errors.New("mbus: short data")
+
+// Telegram 解码后的电表数据
+type Telegram struct {
+	// Id 电表识别号，8 位
+	Id string `json:"id"`
+	// Manufacturer 制造商代码，eg. KAM
+	Manufacturer string `json:"manufacturer"`
+	// Version 版本
+	Version int `json:"version"`
+	// Medium 介质，eg. heat、water、electricity
+	Medium string `json:"medium"`
+	// AccessNumber 访问计数
+	AccessNumber int `json:"accessNumber"`
+	// Status 状态字节
+	Status int `json:"status"`
+	// Records 数据记录
+	Records []Record `json:"records"`
+	// Values 当前值，数量->值，只包括存储号、费率和子单元为 0 的瞬时值
+	Values map[string]interface{} `json:"values"`
+	// ManufacturerData 制造商专用数据，十六进制
+	ManufacturerData string `json:"manufacturerData,omitempty"`
+	// more 后续电文还有记录
+	more bool
+}
+
+// Record 数据记录
+type Record struct {
+	// Quantity 数量，eg. energy、volume、flowTemperature
+	Quantity string `json:"quantity"`
+	// Value 换算后的值
+	Value interface{} `json:"value"`
+	// Unit 单位
+	Unit string `json:"unit,omitempty"`
+	// Function 功能：instantaneous、maximum、minimum、error
+	Function string `json:"function"`
+	// Storage 存储号，0 为当前值，其他为历史值
+	Storage int `json:"storage"`
+	// Tariff 费率
+	Tariff int `json:"tariff"`
+	// Subunit 子单元
+	Subunit int `json:"subunit"`
+}
+
+// media 介质代码
+var media = map[byte]string{
+	0x00: "other", 0x01: "oil", 0x02: "electricity", 0x03: "gas", 0x04: "heat", 0x05: "steam",
+	0x06: "warmWater", 0x07: "water", 0x08: "heatCostAllocator", 0x09: "compressedAir",
+	0x0a: "cooling", 0x0b: "cooling", 0x0c: "heat", 0x0d: "heatCooling", 0x0e: "bus",
+	0x0f: "unknown", 0x15: "hotWater", 0x16: "coldWater", 0x17: "dualWater", 0x18: "pressure",
+	0x19: "adConverter", 0x1a: "smokeDetector", 0x1b: "roomSensor", 0x1c: "gasDetector",
+	0x20: "breaker", 0x21: "valve", 0x25: "customerUnit", 0x28: "wasteWater", 0x29: "garbage",
+	0x37: "radioConverter",
+}
+
+// MediumName 介质名称
+func MediumName(medium byte) string {
+	if name, ok := media[medium]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", medium)
+}
+
+// ManufacturerCode 制造商代码，3 个字母按 5 位编码
+func ManufacturerCode(m uint16) string {
+	return string([]byte{byte(m>>10&0x1f) + 64, byte(m>>5&0x1f) + 64, byte(m&0x1f) + 64})
+}
+
+// formatId 4 字节 BCD 识别号，低字节在前
+func formatId(b []byte) string {
+	return fmt.Sprintf("%02x%02x%02x%02x", b[3], b[2], b[1], b[0])
+}
+
+// parseHeader 解析长报头：识别号、制造商、版本、介质、访问计数、状态和配置字
+func (t *Telegram) parseHeader(b []byte) {
+	t.Id = formatId(b[0:4])
+	t.Manufacturer = ManufacturerCode(binary.LittleEndian.Uint16(b[4:6]))
+	t.Version = int(b[6])
+	t.Medium = MediumName(b[7])
+	t.AccessNumber = int(b[8])
+	t.Status = int(b[9])
+}
+
+// vifInfo VIF 对应的数量、单位和十进制指数
+type vifInfo struct {
+	quantity string
+	unit     string
+	exp      int
+	// kind 值的特殊格式：date、dateTime 或者为空
+	kind string
+}
+
+// lookupVIF 主 VIF 表，见 EN 13757-3
+func lookupVIF(v byte) vifInfo {
+	n := int(v & 0x07)
+	switch {
+	case v <= 0x07:
+		return vifInfo{quantity: "energy", unit: "Wh", exp: n - 3}
+	case v <= 0x0f:
+		return vifInfo{quantity: "energy", unit: "J", exp: n}
+	case v <= 0x17:
+		return vifInfo{quantity: "volume", unit: "m³", exp: n - 6}
+	case v <= 0x1f:
+		return vifInfo{quantity: "mass", unit: "kg", exp: n - 3}
+	case v <= 0x23:
+		return vifInfo{quantity: "onTime", unit: durationUnit(v)}
+	case v <= 0x27:
+		return vifInfo{quantity: "operatingTime", unit: durationUnit(v)}
+	case v <= 0x2f:
+		return vifInfo{quantity: "power", unit: "W", exp: n - 3}
+	case v <= 0x37:
+		return vifInfo{quantity: "power", unit: "J/h", exp: n}
+	case v <= 0x3f:
+		return vifInfo{quantity: "volumeFlow", unit: "m³/h", exp: n - 6}
+	case v <= 0x47:
+		return vifInfo{quantity: "volumeFlow", unit: "m³/min", exp: n - 7}
+	case v <= 0x4f:
+		return vifInfo{quantity: "volumeFlow", unit: "m³/s", exp: n - 9}
+	case v <= 0x57:
+		return vifInfo{quantity: "massFlow", unit: "kg/h", exp: n - 3}
+	case v <= 0x5b:
+		return vifInfo{quantity: "flowTemperature", unit: "°C", exp: n&3 - 3}
+	case v <= 0x5f:
+		return vifInfo{quantity: "returnTemperature", unit: "°C", exp: n&3 - 3}
+	case v <= 0x63:
+		return vifInfo{quantity: "temperatureDifference", unit: "K", exp: n&3 - 3}
+	case v <= 0x67:
+		return vifInfo{quantity: "externalTemperature", unit: "°C", exp: n&3 - 3}
+	case v <= 0x6b:
+		return vifInfo{quantity: "pressure", unit: "bar", exp: n&3 - 3}
+	case v == 0x6c:
+		return vifInfo{quantity: "date", kind: "date"}
+	case v == 0x6d:
+		return vifInfo{quantity: "dateTime", kind: "dateTime"}
+	case v == 0x6e:
+		return vifInfo{quantity: "hcaUnits"}
+	case v <= 0x73:
+		return vifInfo{quantity: "averagingDuration", unit: durationUnit(v)}
+	case v <= 0x77:
+		return vifInfo{quantity: "actualityDuration", unit: durationUnit(v)}
+	case v == 0x78:
+		return vifInfo{quantity: "fabricationNumber"}
+	case v == 0x79:
+		return vifInfo{quantity: "enhancedIdentification"}
+	case v == 0x7a:
+		return vifInfo{quantity: "busAddress"}
+	default:
+		return vifInfo{quantity: fmt.Sprintf("vif_%02x", v)}
+	}
+}
+
+// lookupVIFFD 扩展 VIF 表 0xFD
+func lookupVIFFD(v byte) vifInfo {
+	n := int(v & 0x0f)
+	switch {
+	case v == 0x08:
+		return vifInfo{quantity: "accessNumber"}
+	case v == 0x09:
+		return vifInfo{quantity: "medium"}
+	case v == 0x0a:
+		return vifInfo{quantity: "manufacturer"}
+	case v == 0x0b:
+		return vifInfo{quantity: "parameterSet"}
+	case v == 0x0c:
+		return vifInfo{quantity: "modelVersion"}
+	case v == 0x0d:
+		return vifInfo{quantity: "hardwareVersion"}
+	case v == 0x0e:
+		return vifInfo{quantity: "firmwareVersion"}
+	case v == 0x0f:
+		return vifInfo{quantity: "softwareVersion"}
+	case v == 0x10:
+		return vifInfo{quantity: "customerLocation"}
+	case v == 0x11:
+		return vifInfo{quantity: "customer"}
+	case v == 0x17:
+		return vifInfo{quantity: "errorFlags"}
+	case v == 0x1a:
+		return vifInfo{quantity: "digitalOutput"}
+	case v == 0x1b:
+		return vifInfo{quantity: "digitalInput"}
+	case v == 0x3a:
+		return vifInfo{quantity: "dimensionless"}
+	case v >= 0x40 && v <= 0x4f:
+		return vifInfo{quantity: "voltage", unit: "V", exp: n - 9}
+	case v >= 0x50 && v <= 0x5f:
+		return vifInfo{quantity: "current", unit: "A", exp: n - 12}
+	case v == 0x60:
+		return vifInfo{quantity: "resetCounter"}
+	case v == 0x61:
+		return vifInfo{quantity: "cumulationCounter"}
+	case v == 0x74:
+		return vifInfo{quantity: "remainingBatteryLife", unit: "d"}
+	default:
+		return vifInfo{quantity: fmt.Sprintf("vif_fd%02x", v)}
+	}
+}
+
+// lookupVIFFB 扩展 VIF 表 0xFB，大单位换算成主表的单位
+func lookupVIFFB(v byte) vifInfo {
+	n := int(v & 0x01)
+	switch {
+	case v <= 0x01:
+		return vifInfo{quantity: "energy", unit: "Wh", exp: n + 5}
+	case v >= 0x08 && v <= 0x09:
+		return vifInfo{quantity: "energy", unit: "J", exp: n + 8}
+	case v >= 0x10 && v <= 0x11:
+		return vifInfo{quantity: "volume", unit: "m³", exp: n + 2}
+	case v >= 0x18 && v <= 0x19:
+		return vifInfo{quantity: "mass", unit: "kg", exp: n + 5}
+	case v >= 0x28 && v <= 0x29:
+		return vifInfo{quantity: "power", unit: "W", exp: n + 5}
+	case v >= 0x30 && v <= 0x31:
+		return vifInfo{quantity: "power", unit: "J/h", exp: n + 8}
+	default:
+		return vifInfo{quantity: fmt.Sprintf("vif_fb%02x", v)}
+	}
+}
+
+func durationUnit(v byte) string {
+	return [4]string{"s", "min", "h", "d"}[v&0x03]
+}
+
+// parseRecords 解析可变数据结构的数据记录
+func (t *Telegram) parseRecords(b []byte) error {
+	for len(b) > 0 {
+		dif := b[0]
+		b = b[1:]
+		switch dif {
+		case difIdle:
+			continue
+		case difManufacturer, difMoreRecords:
+			t.more = dif == difMoreRecords
+			if len(b) > 0 {
+				t.ManufacturerData = fmt.Sprintf("%x", b)
+			}
+			return nil
+		}
+		r := Record{Function: functions[dif>>4&0x03], Storage: int(dif >> 6 & 0x01)}
+		// DIFE：存储号、费率和子单元
+		for i, ext := 0, dif&0x80 != 0; ext; i++ {
+			if len(b) == 0 {
+				return errShortData
+			}
+			dife := b[0]
+			b = b[1:]
+			r.Storage |= int(dife&0x0f) << (1 + 4*i)
+			r.Tariff |= int(dife>>4&0x03) << (2 * i)
+			r.Subunit |= int(dife>>6&0x01) << i
+			ext = dife&0x80 != 0
+		}
+		if len(b) == 0 {
+			return errShortData
+		}
+		vif := b[0]
+		b = b[1:]
+		var info vifInfo
+		switch vif {
+		case 0xfd, 0xfb:
+			if len(b) == 0 {
+				return errShortData
+			}
+			if vif == 0xfd {
+				info = lookupVIFFD(b[0] & 0x7f)
+			} else {
+				info = lookupVIFFB(b[0] & 0x7f)
+			}
+			vif = b[0]
+			b = b[1:]
+		case 0x7c, 0xfc:
+			// 文本 VIF，单位为倒序的 ASCII 字符
+			if len(b) == 0 || len(b) < 1+int(b[0]) {
+				return errShortData
+			}
+			info = vifInfo{quantity: "custom", unit: reverseString(b[1 : 1+int(b[0])])}
+			b = b[1+int(b[0]):]
+		case 0x7f, 0xff:
+			info = vifInfo{quantity: "manufacturerSpecific"}
+		default:
+			info = lookupVIF(vif & 0x7f)
+		}
+		// VIFE：倍数修正，其他扩展忽略
+		for ext := vif&0x80 != 0; ext; {
+			if len(b) == 0 {
+				return errShortData
+			}
+			vife := b[0]
+			b = b[1:]
+			switch v := vife & 0x7f; {
+			case v >= 0x70 && v <= 0x77:
+				info.exp += int(v&0x07) - 6
+			case v == 0x7d:
+				info.exp += 3
+			}
+			ext = vife&0x80 != 0
+		}
+		value, rest, err := decodeValue(dif&0x0f, b, info)
+		if err != nil {
+			return err
+		}
+		b = rest
+		r.Quantity, r.Unit, r.Value = info.quantity, info.unit, value
+		t.Records = append(t.Records, r)
+	}
+	return nil
+}
+
+// decodeValue 按数据域编码解码值
+func decodeValue(field byte, b []byte, info vifInfo) (interface{}, []byte, error) {
+	var size int
+	switch field {
+	case 0x00, 0x08:
+		return nil, b, nil
+	case 0x01, 0x02, 0x03, 0x04, 0x06:
+		size = int(field)
+	case 0x05:
+		size = 4
+	case 0x07:
+		size = 8
+	case 0x09, 0x0a, 0x0b, 0x0c:
+		size = int(field - 0x08)
+	case 0x0e:
+		size = 6
+	case 0x0d:
+		return decodeVariable(b, info)
+	default:
+		return nil, nil, fmt.Errorf("mbus: unsupported data field 0x%x", field)
+	}
+	if len(b) < size {
+		return nil, nil, errShortData
+	}
+	data, rest := b[:size], b[size:]
+	switch {
+	case info.kind == "date" && size == 2:
+		return formatDate(data), rest, nil
+	case info.kind == "dateTime" && size == 4:
+		return formatDateTime(data), rest, nil
+	case info.kind == "dateTime" && size == 6:
+		return formatDateTime(data), rest, nil
+	case field == 0x05:
+		return scaleFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(data))), info.exp), rest, nil
+	case field >= 0x09:
+		v, err := decodeBCD(data)
+		if err != nil {
+			// 非法 BCD，返回十六进制
+			return fmt.Sprintf("%x", data), rest, nil
+		}
+		if info.quantity == "fabricationNumber" && v >= 0 {
+			return strconv.FormatInt(v, 10), rest, nil
+		}
+		return scale(v, info.exp), rest, nil
+	default:
+		return scale(decodeInt(data), info.exp), rest, nil
+	}
+}
+
+// decodeVariable 可变长度数据：字符串、BCD 或者二进制数
+func decodeVariable(b []byte, info vifInfo) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errShortData
+	}
+	lvar := int(b[0])
+	b = b[1:]
+	size := lvar
+	switch {
+	case lvar < 0xc0:
+	case lvar < 0xe0:
+		size = (lvar & 0x0f)
+	case lvar < 0xf0:
+		size = lvar - 0xe0
+	default:
+		return nil, nil, fmt.Errorf("mbus: unsupported variable length 0x%x", lvar)
+	}
+	if len(b) < size {
+		return nil, nil, errShortData
+	}
+	data, rest := b[:size], b[size:]
+	switch {
+	case lvar < 0xc0:
+		return reverseString(data), rest, nil
+	case lvar < 0xe0:
+		v, err := decodeBCD(data)
+		if err != nil {
+			return fmt.Sprintf("%x", data), rest, nil
+		}
+		if lvar >= 0xd0 {
+			v = -v
+		}
+		return scale(v, info.exp), rest, nil
+	default:
+		if size > 8 {
+			return fmt.Sprintf("%x", data), rest, nil
+		}
+		return scale(decodeInt(data), info.exp), rest, nil
+	}
+}
+
+// decodeInt 小端有符号整数
+func decodeInt(b []byte) int64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	shift := 64 - 8*len(b)
+	return int64(v<<shift) >> shift
+}
+
+// decodeBCD 小端 BCD，最高半字节为 F 表示负数
+func decodeBCD(b []byte) (int64, error) {
+	var v int64
+	negative := false
+	for i := len(b) - 1; i >= 0; i-- {
+		hi, lo := b[i]>>4, b[i]&0x0f
+		if i == len(b)-1 && hi == 0x0f {
+			negative, hi = true, 0
+		}
+		if hi > 9 || lo > 9 {
+			return 0, errors.New("mbus: invalid bcd")
+		}
+		v = v*100 + int64(hi)*10 + int64(lo)
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// scale 按十进制指数换算，指数为 0 时返回整数
+func scale(v int64, exp int) interface{} {
+	if exp == 0 {
+		return v
+	}
+	return scaleFloat(float64(v), exp)
+}
+
+func scaleFloat(f float64, exp int) float64 {
+	if exp < 0 {
+		return f / math.Pow10(-exp)
+	}
+	return f * math.Pow10(exp)
+}
+
+func reverseString(b []byte) string {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return strings.TrimSpace(string(r))
+}
+
+// formatDate 日期，G 类型
+func formatDate(b []byte) string {
+	day, month := b[0]&0x1f, b[1]&0x0f
+	year := int(b[0]&0xe0)>>5 | int(b[1]&0xf0)>>1
+	return fmt.Sprintf("%04d-%02d-%02d", 2000+year, month, day)
+}
+
+// formatDateTime 日期时间，F 类型（4 字节）或者 I 类型（6 字节，包括秒）
+func formatDateTime(b []byte) string {
+	second := 0
+	if len(b) == 6 {
+		second = int(b[0] & 0x3f)
+		b = b[1:]
+	}
+	minute, hour, day, month := b[0]&0x3f, b[1]&0x1f, b[2]&0x1f, b[3]&0x0f
+	year := int(b[2]&0xe0)>>5 | int(b[3]&0xf0)>>1
+	return fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02d", 2000+year, month, day, hour, minute, second)
+}
+
+// summarize 汇总当前值
+func (t *Telegram) summarize() {
+	t.Values = map[string]interface{}{}
+	for _, r := range t.Records {
+		if r.Storage != 0 || r.Tariff != 0 || r.Subunit != 0 || r.Function != FunctionInstantaneous {
+			continue
+		}
+		if _, ok := t.Values[r.Quantity]; !ok {
+			t.Values[r.Quantity] = r.Value
+		}
+	}
+}
+
+// ParseUserData 解析有线 M-Bus RSP_UD 的应用层数据（从 CI 开始）
+func ParseUserData(b []byte) (*Telegram, error) {
+	if len(b) == 0 {
+		return nil, errShortData
+	}
+	t := &Telegram{}
+	switch b[0] {
+	case ciResponseLong:
+		if len(b) < 13 {
+			return nil, errShortData
+		}
+		t.parseHeader(b[1:13])
+		b = b[13:]
+	case ciResponseNone:
+		b = b[1:]
+	default:
+		return nil, fmt.Errorf("mbus: unsupported ci field 0x%02x", b[0])
+	}
+	if err := t.parseRecords(b); err != nil {
+		return nil, err
+	}
+	t.summarize()
+	return t, nil
+}
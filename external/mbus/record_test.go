@@ -0,0 +1,146 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mbus
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testUserData 热量表的 RSP_UD 用户数据
+func testUserData(more bool) []byte {
+	b := []byte{
+		ciResponseLong, 0x78, 0x56, 0x34, 0x12, 0x2d, 0x2c, 0x01, 0x04, 0x05, 0x00, 0x00, 0x00,
+		// 能量 12345 kWh
+		0x04, 0x06, 0x39, 0x30, 0x00, 0x00,
+		// 体积 123.45 m³，BCD
+		0x0c, 0x14, 0x45, 0x23, 0x01, 0x00,
+		// 供水温度 100.0 °C
+		0x02, 0x5a, 0xe8, 0x03,
+		// 存储号 1 的日期 2024-03-10
+		0x42, 0x6c, 0x0a, 0x33,
+		// 费率 1 的能量
+		0x84, 0x10, 0x06, 0x01, 0x00, 0x00, 0x00,
+		// 制造编号
+		0x0c, 0x78, 0x21, 0x43, 0x65, 0x87,
+		// 错误标志
+		0x01, 0xfd, 0x17, 0x00,
+	}
+	if more {
+		return append(b, difMoreRecords)
+	}
+	return append(b, difManufacturer, 0x01, 0x02)
+}
+
+func TestParseUserData(t *testing.T) {
+	telegram, err := ParseUserData(testUserData(false))
+	assert.Nil(t, err)
+	assert.Equal(t, "12345678", telegram.Id)
+	assert.Equal(t, "KAM", telegram.Manufacturer)
+	assert.Equal(t, "heat", telegram.Medium)
+	assert.Equal(t, 5, telegram.AccessNumber)
+	assert.Equal(t, "0102", telegram.ManufacturerData)
+	assert.Equal(t, 7, len(telegram.Records))
+	assert.Equal(t, Record{Quantity: "energy", Value: 12345000.0, Unit: "Wh", Function: FunctionInstantaneous}, telegram.Records[0])
+	assert.Equal(t, 123.45, telegram.Records[1].Value)
+	assert.Equal(t, "m³", telegram.Records[1].Unit)
+	assert.Equal(t, 100.0, telegram.Records[2].Value)
+	assert.Equal(t, Record{Quantity: "date", Value: "2024-03-10", Function: FunctionInstantaneous, Storage: 1}, telegram.Records[3])
+	assert.Equal(t, 1, telegram.Records[4].Tariff)
+	assert.Equal(t, "87654321", telegram.Records[5].Value)
+	assert.Equal(t, "errorFlags", telegram.Records[6].Quantity)
+	assert.Equal(t, int64(0), telegram.Records[6].Value)
+	assert.Equal(t, 12345000.0, telegram.Values["energy"])
+	assert.Equal(t, 123.45, telegram.Values["volume"])
+	_, ok := telegram.Values["date"]
+	assert.False(t, ok)
+
+	_, err = ParseUserData(testUserData(false)[:20])
+	assert.NotNil(t, err)
+	_, err = ParseUserData([]byte{0x73})
+	assert.NotNil(t, err)
+
+	v, err := decodeBCD([]byte{0x45, 0xf1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-145), v)
+	assert.Equal(t, int64(-2), decodeInt([]byte{0xfe, 0xff, 0xff}))
+	assert.Equal(t, "2024-03-10T08:15:30", formatDateTime([]byte{30, 15, 8, 0x0a, 0x33, 0}))
+}
+
+// testWireless 加密的水表电文，加上帧格式 A 的 CRC
+func testWireless(t *testing.T, key []byte) []byte {
+	plain := []byte{0x2f, 0x2f, 0x04, 0x13, 0x39, 0x30, 0x00, 0x00, 0x2f, 0x2f, 0x2f, 0x2f, 0x2f, 0x2f, 0x2f, 0x2f}
+	header := []byte{0x00, 0x44, 0x2d, 0x2c, 0x78, 0x56, 0x34, 0x12, 0x01, 0x07, ciResponseShort, 0x2a, 0x00, 0x10, 0x05}
+	iv := append(append([]byte(nil), header[2:10]...), 0x2a, 0x2a, 0x2a, 0x2a, 0x2a, 0x2a, 0x2a, 0x2a)
+	block, err := aes.NewCipher(key)
+	assert.Nil(t, err)
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+	b := append(header, encrypted...)
+	b[0] = byte(len(b) - 1)
+	// 第 1 块 10 字节，后续每块 16 字节
+	out := binary.BigEndian.AppendUint16(append([]byte(nil), b[:10]...), crcEN13757(b[:10]))
+	for rest := b[10:]; len(rest) > 0; {
+		n := 16
+		if len(rest) < n {
+			n = len(rest)
+		}
+		out = append(out, rest[:n]...)
+		out = binary.BigEndian.AppendUint16(out, crcEN13757(rest[:n]))
+		rest = rest[n:]
+	}
+	return out
+}
+
+func TestDecodeWireless(t *testing.T) {
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	keys := func(id string) []byte {
+		if id == "12345678" {
+			return key
+		}
+		return nil
+	}
+	b := testWireless(t, key)
+	telegram, err := DecodeWireless(b, keys)
+	assert.Nil(t, err)
+	assert.Equal(t, "12345678", telegram.Id)
+	assert.Equal(t, "KAM", telegram.Manufacturer)
+	assert.Equal(t, "water", telegram.Medium)
+	assert.Equal(t, 0x2a, telegram.AccessNumber)
+	assert.Equal(t, 12.345, telegram.Values["volume"])
+
+	// 接收器已经去掉 CRC
+	stripped, err := stripCRC(b)
+	assert.Nil(t, err)
+	telegram, err = DecodeWireless(stripped, keys)
+	assert.Nil(t, err)
+	assert.Equal(t, 12.345, telegram.Values["volume"])
+
+	_, err = DecodeWireless(b, nil)
+	assert.True(t, errors.Is(err, ErrNoKey))
+	_, err = DecodeWireless(b, func(string) []byte { return make([]byte, 16) })
+	assert.Equal(t, "wmbus: decryption failed, check the key", err.Error())
+	b[12] ^= 0xff
+	_, err = DecodeWireless(b, keys)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mbus
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// 安全模式，见 EN 13757-7
+const (
+	securityNone = 0
+	// securityAESCBC AES-128-CBC，IV 为制造商、地址和 8 个访问计数
+	securityAESCBC = 5
+)
+
+// ErrNoKey 加密的电文没有配置密钥
+var ErrNoKey = errors.New("wmbus: no key for encrypted telegram")
+
+// KeyFunc 按电表识别号获取 AES-128 密钥，没有密钥返回 nil
+type KeyFunc func(id string) []byte
+
+// crcEN13757 EN 13757 CRC-16，多项式 0x3D65
+func crcEN13757(b []byte) uint16 {
+	crc := uint16(0)
+	for _, v := range b {
+		crc ^= uint16(v) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x3d65
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return ^crc
+}
+
+// stripCRC 去掉帧格式 A 或者帧格式 B 的 CRC，接收器已经去掉 CRC 的电文原样返回
+// 帧格式 A（模式 T、C）：第 1 块 10 字节，后续每块 16 字节，每块后面 2 字节 CRC，L 不包括 CRC
+// 帧格式 B（模式 C）：L 包括 CRC，前 128 字节一个 CRC，其余一个 CRC
+func stripCRC(b []byte) ([]byte, error) {
+	if len(b) < 11 {
+		return nil, errShortData
+	}
+	l := int(b[0])
+	blocks := 1
+	if l > 9 {
+		blocks += (l - 9 + 15) / 16
+	}
+	if len(b) == l+1+2*blocks && checkBlock(b[:12]) {
+		out := make([]byte, 0, l+1)
+		out = append(out, b[:10]...)
+		for rest := b[12:]; len(rest) > 2; {
+			n := 16
+			if len(rest)-2 < n {
+				n = len(rest) - 2
+			}
+			if !checkBlock(rest[:n+2]) {
+				return nil, errors.New("wmbus: bad crc")
+			}
+			out = append(out, rest[:n]...)
+			rest = rest[n+2:]
+		}
+		return out, nil
+	}
+	if len(b) != l+1 {
+		return nil, fmt.Errorf("wmbus: length field %d does not match telegram length %d", l, len(b))
+	}
+	if l <= 127 && checkBlock(b) {
+		// 帧格式 B，L 减去 CRC 的长度
+		out := append([]byte(nil), b[:len(b)-2]...)
+		out[0] -= 2
+		return out, nil
+	}
+	if l > 127 && checkBlock(b[:128]) && checkBlock(b[128:]) {
+		out := append(append([]byte(nil), b[:126]...), b[128:len(b)-2]...)
+		out[0] -= 4
+		return out, nil
+	}
+	return b, nil
+}
+
+// checkBlock 校验块最后 2 字节的 CRC，高字节在前
+func checkBlock(b []byte) bool {
+	return len(b) > 2 && crcEN13757(b[:len(b)-2]) == binary.BigEndian.Uint16(b[len(b)-2:])
+}
+
+// DecodeWireless 解码无线 M-Bus 电文，包括链路层 L、C、M、A 字段，CRC 可选
+// 支持短报头、长报头和无报头的应用层，以及扩展链路层 ELL I，加密模式 5 使用 keys 获取的密钥解密
+func DecodeWireless(b []byte, keys KeyFunc) (*Telegram, error) {
+	b, err := stripCRC(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 11 {
+		return nil, errShortData
+	}
+	t := &Telegram{
+		Id:           formatId(b[4:8]),
+		Manufacturer: ManufacturerCode(binary.LittleEndian.Uint16(b[2:4])),
+		Version:      int(b[8]),
+		Medium:       MediumName(b[9]),
+	}
+	// iv 前 8 字节：制造商和地址
+	iv := make([]byte, 16)
+	copy(iv, b[2:10])
+	ci, data := b[10], b[11:]
+	if ci == ciELL {
+		// ELL I：通信控制和访问计数
+		if len(data) < 3 {
+			return nil, errShortData
+		}
+		ci, data = data[2], data[3:]
+	}
+	var config uint16
+	switch ci {
+	case ciResponseShort:
+		if len(data) < 4 {
+			return nil, errShortData
+		}
+		t.AccessNumber, t.Status = int(data[0]), int(data[1])
+		config = binary.LittleEndian.Uint16(data[2:4])
+		data = data[4:]
+	case ciResponseLong:
+		if len(data) < 12 {
+			return nil, errShortData
+		}
+		t.parseHeader(data[0:10])
+		config = binary.LittleEndian.Uint16(data[10:12])
+		// 长报头中的识别号和制造商为电表的地址
+		copy(iv[0:2], data[4:6])
+		copy(iv[2:6], data[0:4])
+		iv[6], iv[7] = data[6], data[7]
+		data = data[12:]
+	case ciResponseNone:
+	default:
+		return nil, fmt.Errorf("wmbus: unsupported ci field 0x%02x", ci)
+	}
+	switch mode := config >> 8 & 0x1f; mode {
+	case securityNone:
+	case securityAESCBC:
+		var key []byte
+		if keys != nil {
+			key = keys(t.Id)
+		}
+		if key == nil {
+			return nil, fmt.Errorf("%w %s", ErrNoKey, t.Id)
+		}
+		for i := 8; i < 16; i++ {
+			iv[i] = byte(t.AccessNumber)
+		}
+		blocks := int(config >> 4 & 0x0f)
+		if data, err = decryptCBC(key, iv, data, blocks); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("wmbus: unsupported security mode %d", mode)
+	}
+	if err = t.parseRecords(data); err != nil {
+		return nil, err
+	}
+	t.summarize()
+	return t, nil
+}
+
+// decryptCBC 解密前 blocks 个块，blocks 为 0 时解密所有完整的块，解密后的数据以 2F 2F 开始
+func decryptCBC(key, iv, data []byte, blocks int) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	n := blocks * aes.BlockSize
+	if blocks == 0 {
+		n = len(data) / aes.BlockSize * aes.BlockSize
+	}
+	if n == 0 || n > len(data) {
+		return nil, errors.New("wmbus: invalid encrypted length")
+	}
+	out := append([]byte(nil), data...)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out[:n], out[:n])
+	if out[0] != difIdle || out[1] != difIdle {
+		return nil, errors.New("wmbus: decryption failed, check the key")
+	}
+	return out, nil
+}
@@ -0,0 +1,279 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mc
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// 数据类型
+const (
+	DataTypeBool   = "BOOL"
+	DataTypeWord   = "WORD"
+	DataTypeInt    = "INT"
+	DataTypeUInt   = "UINT"
+	DataTypeDWord  = "DWORD"
+	DataTypeDInt   = "DINT"
+	DataTypeUDInt  = "UDINT"
+	DataTypeReal   = "REAL"
+	DataTypeLReal  = "LREAL"
+	DataTypeString = "STRING"
+)
+
+// maxStringLength STRING 的最大字节数
+const maxStringLength = 1920
+
+// device 软元件
+type device struct {
+	// code 二进制格式的软元件代码
+	code byte
+	// ascii ASCII 格式的软元件代码
+	ascii string
+	// bit 是否位软元件
+	bit bool
+	// hex 软元件编号是否十六进制
+	hex bool
+}
+
+// devices 支持的软元件，Q/L 系列兼容格式，iQ-R 系列同样支持
+var devices = map[string]device{
+	"D": {code: 0xa8, ascii: "D*"},
+	"W": {code: 0xb4, ascii: "W*", hex: true},
+	"R": {code: 0xaf, ascii: "R*"},
+	"M": {code: 0x90, ascii: "M*", bit: true},
+	"L": {code: 0x92, ascii: "L*", bit: true},
+	"B": {code: 0xa0, ascii: "B*", bit: true, hex: true},
+	"X": {code: 0x9c, ascii: "X*", bit: true, hex: true},
+	"Y": {code: 0x9d, ascii: "Y*", bit: true, hex: true},
+}
+
+// typeWords 数据类型占用的字数
+var typeWords = map[string]int{
+	DataTypeWord:  1,
+	DataTypeInt:   1,
+	DataTypeUInt:  1,
+	DataTypeDWord: 2,
+	DataTypeDInt:  2,
+	DataTypeUDInt: 2,
+	DataTypeReal:  2,
+	DataTypeLReal: 4,
+}
+
+var (
+	addressPattern    = regexp.MustCompile(`^([DWRMLBXY])([0-9A-F]+)(?:\.([0-9A-F]))?$`)
+	stringTypePattern = regexp.MustCompile(`^STRING(?:\[(\d+)\])?$`)
+)
+
+// Address 解析后的 MC 协议地址
+type Address struct {
+	// Device 软元件：D, W, R, M, L, B, X, Y
+	Device string
+	// Number 软元件编号
+	Number int
+	// Bit 字软元件的位 0-15，仅 BOOL 类型有效
+	Bit int
+	// DataType 数据类型
+	DataType string
+	// Words 占用的字数，BOOL 为 1
+	Words int
+	// Length STRING 的字节数
+	Length int
+	raw    string
+}
+
+// ParseAddress 解析 MC 协议地址，不区分大小写，格式：<软元件><编号>[.<位>][:<数据类型>]
+//   - 字软元件 D、W、R：D100, D200:REAL, W1A:DINT, D300:STRING[10]，字软元件的位：D100.F
+//   - 位软元件 M、L、B、X、Y：M10, X1F, Y20，指定数据类型时按字读写从该编号开始的16个位，eg. M0:WORD
+//
+// X、Y、B、W 的编号为十六进制，其他为十进制。未指定数据类型时字软元件为 WORD，位软元件为 BOOL。
+// 32/64 位数据按 PLC 的存储方式低字在前，STRING 每个字保存2个字符，低字节在前
+func ParseAddress(s string) (*Address, error) {
+	raw := strings.TrimSpace(s)
+	location, dataType, _ := strings.Cut(strings.ToUpper(raw), ":")
+	m := addressPattern.FindStringSubmatch(location)
+	if m == nil {
+		return nil, fmt.Errorf("invalid mc address: %s", raw)
+	}
+	a := &Address{raw: raw, Device: m[1]}
+	dev := devices[a.Device]
+	base := 10
+	if dev.hex {
+		base = 16
+	}
+	number, err := strconv.ParseUint(m[2], base, 24)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mc address %s: %w", raw, err)
+	}
+	a.Number = int(number)
+	if m[3] != "" || (dev.bit && (dataType == "" || dataType == DataTypeBool)) {
+		if m[3] != "" {
+			if dev.bit {
+				return nil, fmt.Errorf("invalid mc address: %s", raw)
+			}
+			bit, _ := strconv.ParseUint(m[3], 16, 8)
+			a.Bit = int(bit)
+		}
+		if dataType != "" && dataType != DataTypeBool {
+			return nil, fmt.Errorf("mc bit address %s only supports BOOL", raw)
+		}
+		a.DataType, a.Words = DataTypeBool, 1
+		return a, nil
+	}
+	if dataType == "" {
+		dataType = DataTypeWord
+	}
+	if m := stringTypePattern.FindStringSubmatch(dataType); m != nil {
+		length := 2
+		if m[1] != "" {
+			if length, err = strconv.Atoi(m[1]); err != nil || length == 0 || length > maxStringLength {
+				return nil, fmt.Errorf("invalid mc string length: %s", raw)
+			}
+		}
+		a.DataType, a.Length, a.Words = DataTypeString, length, (length+1)/2
+	} else if words, ok := typeWords[dataType]; ok {
+		a.DataType, a.Words = dataType, words
+	} else {
+		return nil, fmt.Errorf("unsupported mc data type: %s", dataType)
+	}
+	return a, nil
+}
+
+// String 返回原始地址
+func (a *Address) String() string {
+	return a.raw
+}
+
+// device 软元件
+func (a *Address) device() device {
+	return devices[a.Device]
+}
+
+// span 读取时占用的区间 [start, end)，字软元件单位为字，位软元件单位为位
+func (a *Address) span() (int, int) {
+	if !a.device().bit {
+		return a.Number, a.Number + a.Words
+	}
+	if a.DataType == DataTypeBool {
+		return a.Number, a.Number + 1
+	}
+	return a.Number, a.Number + a.Words*16
+}
+
+// Decode 把 PLC 的字解码为值，位软元件的 BOOL 为第1个字的最低位
+func (a *Address) Decode(words []uint16) (interface{}, error) {
+	if len(words) < a.Words {
+		return nil, fmt.Errorf("mc address %s: short data", a.raw)
+	}
+	switch a.DataType {
+	case DataTypeBool:
+		return words[0]>>a.Bit&0x01 != 0, nil
+	case DataTypeWord, DataTypeUInt:
+		return words[0], nil
+	case DataTypeInt:
+		return int16(words[0]), nil
+	case DataTypeDWord, DataTypeUDInt:
+		return uint32(joinWords(words, 2)), nil
+	case DataTypeDInt:
+		return int32(joinWords(words, 2)), nil
+	case DataTypeReal:
+		return math.Float32frombits(uint32(joinWords(words, 2))), nil
+	case DataTypeLReal:
+		return math.Float64frombits(joinWords(words, 4)), nil
+	case DataTypeString:
+		s := make([]byte, 0, a.Length)
+		for i := 0; i < a.Length; i++ {
+			b := byte(words[i/2] >> (8 * (i % 2)))
+			// 以 0 结束
+			if b == 0 {
+				break
+			}
+			s = append(s, b)
+		}
+		return string(s), nil
+	default:
+		return nil, fmt.Errorf("unsupported mc data type: %s", a.DataType)
+	}
+}
+
+// Encode 把值编码为 PLC 的字，BOOL 为 0 或者 1
+func (a *Address) Encode(value interface{}) ([]uint16, error) {
+	words := make([]uint16, a.Words)
+	switch a.DataType {
+	case DataTypeBool:
+		v, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, fmt.Errorf("mc address %s: %w", a.raw, err)
+		}
+		if v {
+			words[0] = 1
+		}
+		return words, nil
+	case DataTypeString:
+		s := cast.ToString(value)
+		if len(s) > a.Length {
+			s = s[:a.Length]
+		}
+		for i := 0; i < len(s); i++ {
+			words[i/2] |= uint16(s[i]) << (8 * (i % 2))
+		}
+		return words, nil
+	}
+	v, err := cast.ToFloat64E(value)
+	if err != nil {
+		return nil, fmt.Errorf("mc address %s: %w", a.raw, err)
+	}
+	switch a.DataType {
+	case DataTypeWord, DataTypeUInt:
+		words[0] = uint16(int64(v))
+	case DataTypeInt:
+		words[0] = uint16(int16(v))
+	case DataTypeDWord, DataTypeUDInt:
+		splitWords(words, uint64(uint32(int64(v))), 2)
+	case DataTypeDInt:
+		splitWords(words, uint64(uint32(int32(v))), 2)
+	case DataTypeReal:
+		splitWords(words, uint64(math.Float32bits(float32(v))), 2)
+	case DataTypeLReal:
+		splitWords(words, math.Float64bits(v), 4)
+	default:
+		return nil, fmt.Errorf("unsupported mc data type: %s", a.DataType)
+	}
+	return words, nil
+}
+
+// joinWords 把低字在前的 n 个字转换为整数
+func joinWords(words []uint16, n int) uint64 {
+	var v uint64
+	for i := n - 1; i >= 0; i-- {
+		v = v<<16 | uint64(words[i])
+	}
+	return v
+}
+
+// splitWords 把整数按低字在前写入 n 个字
+func splitWords(words []uint16, v uint64, n int) {
+	for i := 0; i < n; i++ {
+		words[i] = uint16(v)
+		v >>= 16
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mc
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	a, err := ParseAddress("D100:REAL")
+	assert.Nil(t, err)
+	assert.Equal(t, "D", a.Device)
+	assert.Equal(t, 100, a.Number)
+	assert.Equal(t, DataTypeReal, a.DataType)
+	assert.Equal(t, 2, a.Words)
+
+	a, err = ParseAddress("x1f")
+	assert.Nil(t, err)
+	assert.Equal(t, "X", a.Device)
+	assert.Equal(t, 0x1f, a.Number)
+	assert.Equal(t, DataTypeBool, a.DataType)
+
+	a, err = ParseAddress("D100.A")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, a.Bit)
+	assert.Equal(t, DataTypeBool, a.DataType)
+
+	a, err = ParseAddress("M16:WORD")
+	assert.Nil(t, err)
+	assert.Equal(t, DataTypeWord, a.DataType)
+	start, end := a.span()
+	assert.Equal(t, 16, start)
+	assert.Equal(t, 32, end)
+
+	a, err = ParseAddress("W1A")
+	assert.Nil(t, err)
+	assert.Equal(t, 0x1a, a.Number)
+	assert.Equal(t, DataTypeWord, a.DataType)
+
+	a, err = ParseAddress("D0:STRING[5]")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, a.Words)
+
+	for _, s := range []string{"", "Z0", "D1A", "M1.1", "D0.1:INT", "D0:FOO", "M0:BOOL:X", "D0:STRING[0]", "D0:STRING[2000]"} {
+		_, err = ParseAddress(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	cases := []struct {
+		address string
+		value   interface{}
+		want    interface{}
+	}{
+		{"M0", true, true},
+		{"D0", 0xabcd, uint16(0xabcd)},
+		{"D0:INT", -100, int16(-100)},
+		{"D0:DINT", -70000, int32(-70000)},
+		{"D0:UDINT", 70000, uint32(70000)},
+		{"D0:REAL", 21.5, float32(21.5)},
+		{"D0:LREAL", 1.25, 1.25},
+		{"D0:STRING[4]", "hello", "hell"},
+		{"D0:STRING[5]", "abc", "abc"},
+	}
+	for _, c := range cases {
+		a, err := ParseAddress(c.address)
+		assert.Nil(t, err)
+		words, err := a.Encode(c.value)
+		assert.Nil(t, err)
+		assert.Equal(t, a.Words, len(words))
+		v, err := a.Decode(words)
+		assert.Nil(t, err)
+		assert.Equal(t, c.want, v, c.address)
+	}
+
+	// 低字在前，字符串低字节在前
+	a, _ := ParseAddress("D0:DINT")
+	words, _ := a.Encode(0x12345678)
+	assert.Equal(t, []uint16{0x5678, 0x1234}, words)
+	a, _ = ParseAddress("D0:STRING[3]")
+	words, _ = a.Encode("abc")
+	assert.Equal(t, []uint16{0x6261, 0x0063}, words)
+	a, _ = ParseAddress("D0:REAL")
+	_, err := a.Encode("abc")
+	assert.NotNil(t, err)
+}
+
+func TestMergeItems(t *testing.T) {
+	var items []*Address
+	for _, s := range []string{"D100", "M3", "D0:DINT", "D10", "M20:WORD", "D2060", "D1100:STRING[1900]", "X0", "D150:STRING[10]", "D170.A"} {
+		a, err := ParseAddress(s)
+		assert.Nil(t, err)
+		items = append(items, a)
+	}
+	blocks := mergeItems(items)
+	assert.Equal(t, 6, len(blocks))
+	// D0-D10 合并，D100 间隔超过 readGap
+	assert.Equal(t, "D", blocks[0].device)
+	assert.Equal(t, []int{2, 3}, blocks[0].items)
+	assert.Equal(t, 11, blocks[0].words())
+	// D100、D150 的字符串和 D170 的位合并为 D100-D170
+	assert.Equal(t, []int{0, 8, 9}, blocks[1].items)
+	assert.Equal(t, 71, blocks[1].words())
+	// D1100 的字符串和 D2060 合并后超过 maxPoints
+	assert.Equal(t, []int{6}, blocks[2].items)
+	assert.Equal(t, []int{5}, blocks[3].items)
+	// M3 和 M20:WORD 合并为 M3-M35
+	assert.Equal(t, "M", blocks[4].device)
+	assert.Equal(t, []int{1, 4}, blocks[4].items)
+	assert.Equal(t, 3, blocks[4].words())
+	assert.Equal(t, "X", blocks[5].device)
+
+	words := []uint16{0xfff8, 0x0007}
+	assert.Equal(t, []uint16{0x001f}, extractBits(words, 3, 5))
+	assert.Equal(t, []uint16{0xffff, 0x0000}, extractBits(words, 3, 17))
+}
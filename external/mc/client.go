@@ -0,0 +1,513 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+)
+
+const (
+	// DefaultServer 默认 PLC 地址，端口为 PLC 以太网设置中打开的 MC 协议端口
+	DefaultServer = "127.0.0.1:5007"
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 5 * time.Second
+	// Frame3E 3E 帧
+	Frame3E = "3E"
+	// Frame4E 4E 帧，带序列号
+	Frame4E = "4E"
+	// FormatBinary 二进制格式
+	FormatBinary = "binary"
+	// FormatASCII ASCII 格式
+	FormatASCII = "ascii"
+	// DefaultPCNo 默认 PC 号，直连的 PLC 为 0xFF
+	DefaultPCNo = 0xff
+	// maxPoints 单次批量读写的最大字数
+	maxPoints = 960
+	// readGap 合并批量读取时允许跳过的最大字数，多读 64 个字（128 字节）的耗时远小于一次额外的请求往返
+	readGap = 64
+)
+
+// MC 协议常量
+const (
+	cmdBatchRead   = 0x0401
+	cmdBatchWrite  = 0x1401
+	subWordUnits   = 0x0000
+	subBitUnits    = 0x0001
+	moduleIO       = 0x03ff
+	timerUnit      = 250 * time.Millisecond
+	maxResponseLen = 4096
+)
+
+// ErrClosed 连接已经关闭
+var ErrClosed = errors.New("mc connection is closed")
+
+// EndCodeError PLC 返回的结束码不是正常结束
+type EndCodeError struct {
+	Address string
+	Code    uint16
+}
+
+func (e *EndCodeError) Error() string {
+	var reason string
+	switch e.Code {
+	case 0xc050:
+		reason = "ascii data cannot be converted to binary"
+	case 0xc051, 0xc052, 0xc053, 0xc054:
+		reason = "number of points out of range"
+	case 0xc056:
+		reason = "device out of range"
+	case 0xc058:
+		reason = "request data length mismatch"
+	case 0xc059:
+		reason = "command or subcommand not supported"
+	case 0xc05b:
+		reason = "device cannot be accessed"
+	case 0xc05c:
+		reason = "invalid request"
+	case 0xc061:
+		reason = "request data length mismatch"
+	default:
+		reason = "error"
+	}
+	return fmt.Sprintf("mc %s: %s (0x%04x)", e.Address, reason, e.Code)
+}
+
+// ClientConfig 创建 MC 协议客户端的配置
+type ClientConfig struct {
+	// Server PLC 地址，格式：host:port
+	Server string
+	// Frame 帧类型：3E 或者 4E，默认 3E
+	Frame string
+	// Format 数据格式：binary 或者 ascii，需要与 PLC 的通信数据代码设置一致，默认 binary
+	Format string
+	// NetworkNo 网络号，直连为 0
+	NetworkNo byte
+	// PCNo PC 号，直连为 0xFF
+	PCNo byte
+	// Timeout 连接和请求超时，同时作为 PLC 的监视定时器
+	Timeout time.Duration
+}
+
+// Key 共享连接的 key，格式：server/frame/format/networkNo.pcNo
+func (c ClientConfig) Key() string {
+	return fmt.Sprintf("%s/%s/%s/%d.%d", strings.TrimPrefix(c.Server, "tcp://"), c.frame(), c.format(), c.NetworkNo, c.PCNo)
+}
+
+// frame 帧类型，默认 3E
+func (c ClientConfig) frame() string {
+	if strings.EqualFold(c.Frame, Frame4E) {
+		return Frame4E
+	}
+	return Frame3E
+}
+
+// format 数据格式，默认 binary
+func (c ClientConfig) format() string {
+	if strings.EqualFold(c.Format, FormatASCII) {
+		return FormatASCII
+	}
+	return FormatBinary
+}
+
+// Client MC 协议 TCP 客户端，支持 3E/4E 帧和二进制/ASCII 格式，请求是串行的，可以并发调用
+// 通信出错时关闭连接，由调用方重新建立
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	frame4E bool
+	ascii   bool
+	network byte
+	pc      byte
+	timeout time.Duration
+	// serial 4E 帧的序列号
+	serial uint16
+	closed bool
+}
+
+// Dial 连接 PLC
+func Dial(config ClientConfig) (*Client, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	conn, err := net.DialTimeout("tcp", strings.TrimPrefix(config.Server, "tcp://"), timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn = capture.WrapConn(conn, capture.Source("mc", strings.TrimPrefix(config.Server, "tcp://")))
+	return &Client{
+		conn:    conn,
+		frame4E: config.frame() == Frame4E,
+		ascii:   config.format() == FormatASCII,
+		network: config.NetworkNo,
+		pc:      config.PCNo,
+		timeout: timeout,
+	}, nil
+}
+
+// Closed 连接是否已经关闭
+func (c *Client) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
+
+// block 一次批量读取的区间，单位同 Address.span
+type block struct {
+	device     string
+	start, end int
+	items      []int
+}
+
+// words 区间的字数
+func (b *block) words() int {
+	if devices[b.device].bit {
+		return (b.end - b.start + 15) / 16
+	}
+	return b.end - b.start
+}
+
+// ReadItems 读取多个变量，相同软元件上相邻的变量合并为一次批量读取，返回的值与 items 一一对应
+func (c *Client) ReadItems(items []*Address) ([]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	values := make([]interface{}, len(items))
+	for _, b := range mergeItems(items) {
+		words, err := c.readWords(b.device, b.start, b.words())
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range b.items {
+			item := items[i]
+			start, end := item.span()
+			var data []uint16
+			if devices[b.device].bit {
+				data = extractBits(words, start-b.start, end-start)
+			} else {
+				data = words[start-b.start : end-b.start]
+			}
+			if values[i], err = item.Decode(data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+// mergeItems 按软元件合并变量，间隔不超过 readGap 并且总字数不超过 maxPoints 的变量合并到一个区间
+func mergeItems(items []*Address) []*block {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := items[order[i]], items[order[j]]
+		if a.Device != b.Device {
+			return a.Device < b.Device
+		}
+		return a.Number < b.Number
+	})
+	var blocks []*block
+	var current *block
+	for _, i := range order {
+		item := items[i]
+		start, end := item.span()
+		gap := readGap
+		if item.device().bit {
+			gap *= 16
+		}
+		if current != nil && current.device == item.Device && start <= current.end+gap {
+			merged := &block{device: current.device, start: current.start, end: current.end}
+			if end > merged.end {
+				merged.end = end
+			}
+			if merged.words() <= maxPoints {
+				current.end = merged.end
+				current.items = append(current.items, i)
+				continue
+			}
+		}
+		current = &block{device: item.Device, start: start, end: end, items: []int{i}}
+		blocks = append(blocks, current)
+	}
+	return blocks
+}
+
+// extractBits 从 words 的第 offset 位开始取 n 个位，按低位在前放到新的字中
+func extractBits(words []uint16, offset, n int) []uint16 {
+	result := make([]uint16, (n+15)/16)
+	for i := 0; i < n; i++ {
+		pos := offset + i
+		if words[pos/16]>>(pos%16)&0x01 != 0 {
+			result[i/16] |= 1 << (i % 16)
+		}
+	}
+	return result
+}
+
+// WriteItems 写入多个变量，values 与 items 一一对应
+// 位软元件的 BOOL 按位写入，其他按字写入；字软元件的位不支持写入
+func (c *Client) WriteItems(items []*Address, values [][]uint16) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	for i, item := range items {
+		if len(values[i]) != item.Words {
+			return fmt.Errorf("mc %s: expected %d words, got %d", item, item.Words, len(values[i]))
+		}
+		var err error
+		if item.DataType == DataTypeBool {
+			if !item.device().bit {
+				return fmt.Errorf("mc %s: writing a bit of a word device is not supported", item)
+			}
+			err = c.writeBit(item.Device, item.Number, values[i][0] != 0)
+		} else {
+			err = c.writeWords(item.Device, item.Number, values[i])
+		}
+		if err != nil {
+			var endCode *EndCodeError
+			if errors.As(err, &endCode) {
+				endCode.Address = item.String()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// readWords 按字批量读取
+func (c *Client) readWords(dev string, number, count int) ([]uint16, error) {
+	resp, err := c.exchange(cmdBatchRead, subWordUnits, c.deviceSpec(dev, number, count))
+	if err != nil {
+		var endCode *EndCodeError
+		if errors.As(err, &endCode) {
+			endCode.Address = dev + c.formatNumber(dev, number)
+		}
+		return nil, err
+	}
+	words := make([]uint16, count)
+	if c.ascii {
+		if len(resp) != count*4 {
+			return nil, fmt.Errorf("mc: unexpected data length %d", len(resp))
+		}
+		for i := range words {
+			v, err := strconv.ParseUint(string(resp[i*4:i*4+4]), 16, 16)
+			if err != nil {
+				return nil, fmt.Errorf("mc: invalid ascii data")
+			}
+			words[i] = uint16(v)
+		}
+		return words, nil
+	}
+	if len(resp) != count*2 {
+		return nil, fmt.Errorf("mc: unexpected data length %d", len(resp))
+	}
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint16(resp[i*2:])
+	}
+	return words, nil
+}
+
+// writeWords 按字批量写入
+func (c *Client) writeWords(dev string, number int, words []uint16) error {
+	data := c.deviceSpec(dev, number, len(words))
+	for _, w := range words {
+		if c.ascii {
+			data = append(data, fmt.Sprintf("%04X", w)...)
+		} else {
+			data = binary.LittleEndian.AppendUint16(data, w)
+		}
+	}
+	_, err := c.exchange(cmdBatchWrite, subWordUnits, data)
+	return err
+}
+
+// writeBit 按位写入一个位软元件
+func (c *Client) writeBit(dev string, number int, on bool) error {
+	data := c.deviceSpec(dev, number, 1)
+	switch {
+	case c.ascii && on:
+		data = append(data, '1')
+	case c.ascii:
+		data = append(data, '0')
+	case on:
+		// 二进制格式每个字节保存2个点，高4位在前
+		data = append(data, 0x10)
+	default:
+		data = append(data, 0x00)
+	}
+	_, err := c.exchange(cmdBatchWrite, subBitUnits, data)
+	return err
+}
+
+// formatNumber 软元件编号的文本，X、Y、B、W 为十六进制
+func (c *Client) formatNumber(dev string, number int) string {
+	if devices[dev].hex {
+		return strings.ToUpper(strconv.FormatInt(int64(number), 16))
+	}
+	return strconv.Itoa(number)
+}
+
+// deviceSpec 软元件、起始编号和点数
+func (c *Client) deviceSpec(dev string, number, points int) []byte {
+	d := devices[dev]
+	if c.ascii {
+		n := fmt.Sprintf("%06d", number)
+		if d.hex {
+			n = fmt.Sprintf("%06X", number)
+		}
+		return []byte(fmt.Sprintf("%s%s%04X", d.ascii, n, points))
+	}
+	return []byte{byte(number), byte(number >> 8), byte(number >> 16), d.code, byte(points), byte(points >> 8)}
+}
+
+// exchange 发送请求并读取响应，返回结束码之后的数据
+// 通信出错时关闭连接
+func (c *Client) exchange(command, subcommand uint16, data []byte) ([]byte, error) {
+	timer := uint16(c.timeout / timerUnit)
+	if timer == 0 {
+		timer = 1
+	}
+	c.serial++
+	var req []byte
+	if c.ascii {
+		var sb strings.Builder
+		if c.frame4E {
+			fmt.Fprintf(&sb, "5400%04X0000", c.serial)
+		} else {
+			sb.WriteString("5000")
+		}
+		fmt.Fprintf(&sb, "%02X%02X%04X00%04X%04X%04X%04X", c.network, c.pc, moduleIO, 12+len(data), timer, command, subcommand)
+		req = append([]byte(sb.String()), data...)
+	} else {
+		if c.frame4E {
+			req = []byte{0x54, 0x00, byte(c.serial), byte(c.serial >> 8), 0x00, 0x00}
+		} else {
+			req = []byte{0x50, 0x00}
+		}
+		req = append(req, c.network, c.pc, moduleIO&0xff, moduleIO>>8, 0x00)
+		req = binary.LittleEndian.AppendUint16(req, uint16(6+len(data)))
+		req = binary.LittleEndian.AppendUint16(req, timer)
+		req = binary.LittleEndian.AppendUint16(req, command)
+		req = binary.LittleEndian.AppendUint16(req, subcommand)
+		req = append(req, data...)
+	}
+
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := c.conn.Write(req); err != nil {
+		c.closeOnError()
+		return nil, err
+	}
+	code, resp, err := c.readResponse()
+	if err != nil {
+		c.closeOnError()
+		return nil, err
+	}
+	if code != 0 {
+		return nil, &EndCodeError{Code: code}
+	}
+	return resp, nil
+}
+
+// readResponse 读取响应，返回结束码和数据
+func (c *Client) readResponse() (uint16, []byte, error) {
+	// 副头部（4E 帧包含序列号）、网络号、PC 号、模块 I/O 号、模块站号、响应数据长度
+	size := 9
+	if c.frame4E {
+		size += 4
+	}
+	if c.ascii {
+		size *= 2
+	}
+	header := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return 0, nil, err
+	}
+	if c.ascii {
+		decoded := make([]byte, size/2)
+		if _, err := hex.Decode(decoded, header); err != nil {
+			return 0, nil, fmt.Errorf("mc: invalid response header")
+		}
+		header = decoded
+		// ASCII 格式的数值为大端序，转换为与二进制格式相同的小端序
+		if c.frame4E {
+			header[2], header[3] = header[3], header[2]
+		}
+		header[len(header)-2], header[len(header)-1] = header[len(header)-1], header[len(header)-2]
+	}
+	subheader := []byte{0xd0, 0x00}
+	if c.frame4E {
+		subheader = []byte{0xd4, 0x00, byte(c.serial), byte(c.serial >> 8)}
+	}
+	if !bytes.HasPrefix(header, subheader) {
+		return 0, nil, fmt.Errorf("mc: unexpected response")
+	}
+	length := int(binary.LittleEndian.Uint16(header[len(header)-2:]))
+	if length < 2 || length > maxResponseLen {
+		return 0, nil, fmt.Errorf("mc: invalid response length %d", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, body); err != nil {
+		return 0, nil, err
+	}
+	if c.ascii {
+		if length < 4 {
+			return 0, nil, fmt.Errorf("mc: invalid response length %d", length)
+		}
+		code, err := strconv.ParseUint(string(body[:4]), 16, 16)
+		if err != nil {
+			return 0, nil, fmt.Errorf("mc: invalid end code")
+		}
+		return uint16(code), body[4:], nil
+	}
+	return binary.LittleEndian.Uint16(body), body[2:], nil
+}
+
+// closeOnError 通信出错后连接状态未知，关闭连接
+func (c *Client) closeOnError() {
+	c.closed = true
+	_ = c.conn.Close()
+}
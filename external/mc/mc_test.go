@@ -0,0 +1,437 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testPLC 测试用的 MC 协议 PLC，根据请求的副头部识别 3E/4E 帧和二进制/ASCII 格式，只实现批量读写
+type testPLC struct {
+	listener net.Listener
+	mu       sync.Mutex
+	// memory 软元件，位软元件每个字保存16个位
+	memory map[byte][]uint16
+	// requests 收到的批量读写请求数量
+	requests int
+}
+
+func startTestPLC(t *testing.T) *testPLC {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	plc := &testPLC{listener: listener, memory: map[byte][]uint16{}}
+	for _, d := range devices {
+		plc.memory[d.code] = make([]uint16, 2048)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go plc.serve(conn)
+		}
+	}()
+	return plc
+}
+
+func (p *testPLC) server() string {
+	return p.listener.Addr().String()
+}
+
+// device 返回软元件内存的副本
+func (p *testPLC) device(name string) []uint16 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]uint16(nil), p.memory[devices[name].code]...)
+}
+
+// set 从第 n 个字开始设置软元件内存
+func (p *testPLC) set(name string, n int, words ...uint16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	copy(p.memory[devices[name].code][n:], words)
+}
+
+func (p *testPLC) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		first := make([]byte, 1)
+		if _, err := io.ReadFull(conn, first); err != nil {
+			return
+		}
+		ascii := first[0] == '5'
+		var resp []byte
+		var err error
+		if ascii {
+			resp, err = p.handleASCII(conn)
+		} else {
+			resp, err = p.handleBinary(conn, first[0])
+		}
+		if err != nil {
+			return
+		}
+		if _, err = conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (p *testPLC) handleBinary(conn net.Conn, first byte) ([]byte, error) {
+	size := 8
+	if first == 0x54 {
+		size += 4
+	}
+	header := make([]byte, size)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	body := make([]byte, binary.LittleEndian.Uint16(header[size-2:]))
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, err
+	}
+	command, sub := binary.LittleEndian.Uint16(body[2:]), binary.LittleEndian.Uint16(body[4:])
+	data := body[6:]
+	number := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+	points := int(binary.LittleEndian.Uint16(data[4:]))
+	var values []uint16
+	if command == cmdBatchWrite {
+		if sub == subBitUnits {
+			values = []uint16{uint16(data[6] >> 4)}
+		} else {
+			for i := 0; i < points; i++ {
+				values = append(values, binary.LittleEndian.Uint16(data[6+i*2:]))
+			}
+		}
+	}
+	code, result := p.execute(command, sub, data[3], number, points, values)
+	resp := []byte{0xd0, 0x00}
+	if first == 0x54 {
+		resp = []byte{0xd4, 0x00, header[1], header[2], 0x00, 0x00}
+	}
+	resp = append(resp, header[size-7:size-2]...)
+	resp = binary.LittleEndian.AppendUint16(resp, uint16(2+len(result)*2))
+	resp = binary.LittleEndian.AppendUint16(resp, code)
+	for _, w := range result {
+		resp = binary.LittleEndian.AppendUint16(resp, w)
+	}
+	return resp, nil
+}
+
+func (p *testPLC) handleASCII(conn net.Conn) ([]byte, error) {
+	subheader := make([]byte, 3)
+	if _, err := io.ReadFull(conn, subheader); err != nil {
+		return nil, err
+	}
+	frame4E := subheader[0] == '4'
+	size := 14
+	if frame4E {
+		size += 8
+	}
+	header := make([]byte, size)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	length, _ := strconv.ParseUint(string(header[size-4:]), 16, 16)
+	body := make([]byte, length)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, err
+	}
+	command, _ := strconv.ParseUint(string(body[4:8]), 16, 16)
+	sub, _ := strconv.ParseUint(string(body[8:12]), 16, 16)
+	data := body[12:]
+	var dev device
+	for _, d := range devices {
+		if d.ascii == string(data[:2]) {
+			dev = d
+		}
+	}
+	base := 10
+	if dev.hex {
+		base = 16
+	}
+	number, _ := strconv.ParseUint(string(data[2:8]), base, 32)
+	points, _ := strconv.ParseUint(string(data[8:12]), 16, 16)
+	var values []uint16
+	if command == cmdBatchWrite {
+		if sub == subBitUnits {
+			values = []uint16{uint16(data[12] - '0')}
+		} else {
+			for i := 0; i < int(points); i++ {
+				v, _ := strconv.ParseUint(string(data[12+i*4:16+i*4]), 16, 16)
+				values = append(values, uint16(v))
+			}
+		}
+	}
+	code, result := p.execute(uint16(command), uint16(sub), dev.code, int(number), int(points), values)
+	var sb strings.Builder
+	if frame4E {
+		fmt.Fprintf(&sb, "D400%s0000", header[:4])
+	} else {
+		sb.WriteString("D000")
+	}
+	sb.Write(header[size-14 : size-4])
+	fmt.Fprintf(&sb, "%04X%04X", 4+len(result)*4, code)
+	for _, w := range result {
+		fmt.Fprintf(&sb, "%04X", w)
+	}
+	return []byte(sb.String()), nil
+}
+
+// execute 执行批量读写，返回结束码和读取的字
+func (p *testPLC) execute(command, sub uint16, code byte, number, points int, values []uint16) (uint16, []uint16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests++
+	mem := p.memory[code]
+	bitDevice := false
+	for _, d := range devices {
+		if d.code == code {
+			bitDevice = d.bit
+		}
+	}
+	getBit := func(n int) uint16 { return mem[n/16] >> (n % 16) & 0x01 }
+	setBit := func(n int, v uint16) { mem[n/16] = mem[n/16]&^(1<<(n%16)) | (v&0x01)<<(n%16) }
+	end := number + points
+	if bitDevice {
+		end = (number + points*16 + 15) / 16
+	}
+	if mem == nil || end > len(mem) {
+		return 0xc056, nil
+	}
+	switch {
+	case command == cmdBatchRead:
+		result := make([]uint16, points)
+		for i := range result {
+			if bitDevice {
+				for b := 0; b < 16; b++ {
+					result[i] |= getBit(number+i*16+b) << b
+				}
+			} else {
+				result[i] = mem[number+i]
+			}
+		}
+		return 0, result
+	case command == cmdBatchWrite && sub == subBitUnits:
+		setBit(number, values[0])
+	case command == cmdBatchWrite && bitDevice:
+		for i, w := range values {
+			for b := 0; b < 16; b++ {
+				setBit(number+i*16+b, w>>b)
+			}
+		}
+	case command == cmdBatchWrite:
+		copy(mem[number:], values)
+	default:
+		return 0xc059, nil
+	}
+	return 0, nil
+}
+
+func TestReadWriteNode(t *testing.T) {
+	plc := startTestPLC(t)
+	defer plc.listener.Close()
+
+	plc.set("D", 100, 0x0000, 0x41ac)
+	plc.set("D", 150, 0x6568, 0x6c6c, 0x006f)
+	plc.set("D", 160, 0xfffb)
+	plc.set("D", 170, 0x0400)
+	plc.set("M", 0, 0x0400)
+	plc.set("X", 1, 0x8000)
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	Registry.Add(&WriteNode{})
+
+	// 非法地址和帧格式
+	_, err := test.CreateAndInitNode("x/mcRead", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"address": "M1.1"}},
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/mcRead", types.Configuration{
+		"server": plc.server(),
+		"frame":  "1E",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	for _, frame := range []string{Frame3E, Frame4E} {
+		for _, format := range []string{FormatBinary, FormatASCII} {
+			node, err := test.CreateAndInitNode("x/mcRead", types.Configuration{
+				"server": plc.server(),
+				"frame":  frame,
+				"format": format,
+				"items": []map[string]interface{}{
+					{"name": "temperature", "address": "D100:REAL"},
+					{"name": "running", "address": "M10"},
+					{"name": "level", "address": "D160:INT"},
+					{"name": "model", "address": "D150:STRING[10]"},
+					{"name": "alarm", "address": "D170.A"},
+					{"name": "input", "address": "X1F"},
+				},
+			}, Registry)
+			assert.Nil(t, err)
+
+			test.NodeOnMsg(t, node, []test.Msg{{
+				MetaData:   types.NewMetadata(),
+				DataType:   types.JSON,
+				MsgType:    "READ",
+				Data:       `{}`,
+				AfterSleep: time.Millisecond * 200,
+			}}, func(msg types.RuleMsg, relationType string, err error) {
+				assert.Equal(t, types.Success, relationType, frame+format)
+				values := make(map[string]interface{})
+				assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+				assert.Equal(t, 21.5, values["temperature"])
+				assert.Equal(t, true, values["running"])
+				assert.Equal(t, float64(-5), values["level"])
+				assert.Equal(t, "hello", values["model"])
+				assert.Equal(t, true, values["alarm"])
+				assert.Equal(t, true, values["input"])
+			})
+			node.Destroy()
+		}
+	}
+	// 每次读取合并为 D、M、X 3个批量读取
+	plc.mu.Lock()
+	assert.Equal(t, 12, plc.requests)
+	plc.mu.Unlock()
+
+	writer, err := test.CreateAndInitNode("x/mcWrite", types.Configuration{
+		"server": plc.server(),
+		"format": FormatASCII,
+	}, Registry)
+	assert.Nil(t, err)
+	defer writer.Destroy()
+	templateWriter, err := test.CreateAndInitNode("x/mcWrite", types.Configuration{
+		"server": plc.server(),
+		"frame":  Frame4E,
+		"items":  []map[string]interface{}{{"address": "D100:REAL", "value": "${metadata.setpoint}"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer templateWriter.Destroy()
+
+	test.NodeOnMsg(t, writer, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "WRITE",
+			Data:       `{"Y10": true, "M10": false, "D160:INT": -100, "D150:STRING[10]": "world", "M32:WORD": 5}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "OUT_OF_RANGE",
+			Data:       `{"D5000": 1}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "WORD_BIT",
+			Data:       `{"D170.1": true}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		switch msg.Type {
+		case "OUT_OF_RANGE":
+			assert.Equal(t, types.Failure, relationType)
+			var endCode *EndCodeError
+			assert.True(t, errors.As(err, &endCode))
+			assert.Equal(t, uint16(0xc056), endCode.Code)
+		case "WORD_BIT":
+			assert.Equal(t, types.Failure, relationType)
+		default:
+			assert.Equal(t, types.Success, relationType)
+		}
+	})
+	assert.Equal(t, uint16(0x0001), plc.device("Y")[1])
+	assert.Equal(t, uint16(0x0005), plc.device("M")[2])
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("setpoint", "42.5")
+	test.NodeOnMsg(t, templateWriter, []test.Msg{{
+		MetaData:   metadata,
+		DataType:   types.JSON,
+		MsgType:    "WRITE",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+	d := plc.device("D")
+	assert.Equal(t, float32(42.5), math.Float32frombits(uint32(d[101])<<16|uint32(d[100])))
+
+	reader, err := test.CreateAndInitNode("x/mcRead", types.Configuration{
+		"server": plc.server(),
+	}, Registry)
+	assert.Nil(t, err)
+	defer reader.Destroy()
+	test.NodeOnMsg(t, reader, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `["Y10", "M10", "D160:INT", "D150:STRING[10]", "M32:WORD"]`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, true, values["Y10"])
+		assert.Equal(t, false, values["M10"])
+		assert.Equal(t, float64(-100), values["D160:INT"])
+		assert.Equal(t, "world", values["D150:STRING[10]"])
+		assert.Equal(t, float64(5), values["M32:WORD"])
+	})
+}
+
+func TestSharedConnReconnect(t *testing.T) {
+	plc := startTestPLC(t)
+	defer plc.listener.Close()
+
+	conn := AcquireConn(ClientConfig{Server: plc.server(), PCNo: DefaultPCNo, Timeout: time.Second})
+	defer conn.Release()
+	client, err := conn.Client()
+	assert.Nil(t, err)
+
+	a, _ := ParseAddress("D0")
+	// 模拟连接断开，Do 重新连接后重试
+	_ = client.conn.Close()
+	err = conn.Do(func(client *Client) error {
+		_, err := client.ReadItems([]*Address{a})
+		return err
+	})
+	assert.Nil(t, err)
+	newClient, _ := conn.Client()
+	assert.True(t, newClient != client)
+}
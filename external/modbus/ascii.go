@@ -0,0 +1,285 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/goburrow/serial"
+	"github.com/simonvetter/modbus"
+)
+
+// 帧格式
+const (
+	// FramingTCP 按 server 协议的默认帧格式：tcp:// 为 MBAP，rtu:// 为 RTU
+	FramingTCP = "tcp"
+	// FramingRTUOverTCP 通过 TCP/UDP 透传 RTU 帧，常见于串口服务器和网关
+	FramingRTUOverTCP = "rtu-over-tcp"
+	// FramingASCII Modbus ASCII 帧，支持串口和 TCP 透传
+	FramingASCII = "ascii"
+)
+
+// asciiMaxFrameLength ASCII 帧最大长度：起始符 + 2*(从机编号 + PDU + LRC) + CRLF
+const asciiMaxFrameLength = 1 + 2*(1+253+1) + 2
+
+// ErrBadLRC ASCII 帧 LRC 校验失败
+var ErrBadLRC = errors.New("modbus ascii: bad lrc")
+
+// CheckFraming 校验 server 是否支持该帧格式
+func CheckFraming(server, framing string) error {
+	switch strings.ToLower(framing) {
+	case "", FramingTCP:
+		return nil
+	case FramingRTUOverTCP:
+		if strings.HasPrefix(server, "tcp://") || strings.HasPrefix(server, "udp://") {
+			return nil
+		}
+	case FramingASCII:
+		if strings.HasPrefix(server, "tcp://") || IsSerial(server) {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported modbus framing: %s", framing)
+	}
+	return fmt.Errorf("modbus framing %s is not supported by server: %s", framing, server)
+}
+
+// framingURL 按帧格式转换底层库的连接地址
+// ASCII 帧底层库不支持，启动本地桥接把 RTU 帧转换为 ASCII 帧，底层库通过 rtuovertcp 连接桥接
+func framingURL(c ClientConfig) (string, error) {
+	if err := CheckFraming(c.Server, c.Framing); err != nil {
+		return "", err
+	}
+	switch strings.ToLower(c.Framing) {
+	case FramingRTUOverTCP:
+		if strings.HasPrefix(c.Server, "udp://") {
+			return "rtuoverudp://" + strings.TrimPrefix(c.Server, "udp://"), nil
+		}
+		return "rtuovertcp://" + strings.TrimPrefix(c.Server, "tcp://"), nil
+	case FramingASCII:
+		return startASCIIBridge(c)
+	default:
+		return c.Server, nil
+	}
+}
+
+// startASCIIBridge 打开设备连接并监听本地随机端口，返回底层库的连接地址
+// 桥接只接受一个连接，该连接关闭时同时关闭设备连接，重连时会创建新的桥接
+func startASCIIBridge(c ClientConfig) (string, error) {
+	timeout := time.Duration(c.TcpConfig.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	var link io.ReadWriteCloser
+	var err error
+	if IsSerial(c.Server) {
+		link, err = serial.Open(serialConfig(c.Server, c.RtuConfig))
+	} else {
+		link, err = net.DialTimeout("tcp", strings.TrimPrefix(c.Server, "tcp://"), timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = link.Close()
+		return "", err
+	}
+	go func() {
+		defer listener.Close()
+		_ = listener.(*net.TCPListener).SetDeadline(time.Now().Add(timeout))
+		conn, err := listener.Accept()
+		if err != nil {
+			_ = link.Close()
+			return
+		}
+		_ = listener.Close()
+		b := &asciiBridge{conn: conn, link: link, timeout: timeout}
+		b.serve()
+	}()
+	return "rtuovertcp://" + listener.Addr().String(), nil
+}
+
+// asciiBridge 在底层库的 RTU 帧和设备的 ASCII 帧之间转换
+type asciiBridge struct {
+	conn    net.Conn
+	link    io.ReadWriteCloser
+	timeout time.Duration
+	// pending 设备连接已读取但未处理的数据
+	pending []byte
+}
+
+func (b *asciiBridge) serve() {
+	defer b.link.Close()
+	defer b.conn.Close()
+	for {
+		frame, err := readRTURequest(b.conn)
+		if err != nil {
+			return
+		}
+		b.pending = b.pending[:0]
+		if _, err = b.link.Write(rtuToASCII(frame)); err != nil {
+			return
+		}
+		line, err := b.readLine()
+		if err != nil {
+			var netErr net.Error
+			if (errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, serial.ErrTimeout) {
+				// 设备无响应，由底层库按超时处理
+				continue
+			}
+			return
+		}
+		response, err := asciiToRTU(line)
+		if err != nil {
+			// 丢弃错误的响应帧，由底层库按超时处理
+			continue
+		}
+		if _, err = b.conn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+// readLine 读取一个以 LF 结尾的 ASCII 帧，超时返回错误
+func (b *asciiBridge) readLine() ([]byte, error) {
+	deadline := time.Now().Add(b.timeout)
+	if conn, ok := b.link.(net.Conn); ok {
+		_ = conn.SetReadDeadline(deadline)
+	}
+	buf := make([]byte, 256)
+	for {
+		if i := bytes.IndexByte(b.pending, '\n'); i >= 0 {
+			line := b.pending[:i+1]
+			b.pending = b.pending[i+1:]
+			return line, nil
+		}
+		if len(b.pending) > asciiMaxFrameLength {
+			b.pending = b.pending[:0]
+			return nil, modbus.ErrProtocolError
+		}
+		n, err := b.link.Read(buf)
+		b.pending = append(b.pending, buf[:n]...)
+		if err != nil && !errors.Is(err, serial.ErrTimeout) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, serial.ErrTimeout
+		}
+	}
+}
+
+// readRTURequest 读取底层库发送的一个 RTU 请求帧，长度由功能码决定
+func readRTURequest(r io.Reader) ([]byte, error) {
+	frame := make([]byte, 2, 256)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, err
+	}
+	var header int
+	switch frame[1] {
+	case 0x01, 0x02, 0x03, 0x04, 0x05, 0x06:
+		header = 6
+	case 0x16:
+		header = 8
+	case 0x0f, 0x10:
+		header = 7
+	case 0x17:
+		header = 11
+	default:
+		return nil, fmt.Errorf("unsupported function code: 0x%02x", frame[1])
+	}
+	frame = frame[:header]
+	if _, err := io.ReadFull(r, frame[2:]); err != nil {
+		return nil, err
+	}
+	if frame[1] == 0x0f || frame[1] == 0x10 || frame[1] == 0x17 {
+		// 字节数之后为写入的数据
+		frame = frame[:header+int(frame[header-1])]
+		if _, err := io.ReadFull(r, frame[header:]); err != nil {
+			return nil, err
+		}
+	}
+	// CRC 由底层库生成，转换为 ASCII 帧时不需要
+	if _, err := io.ReadFull(r, make([]byte, 2)); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// rtuToASCII 把不含 CRC 的 RTU 帧转换为 ASCII 帧：':' + HEX(从机编号 + PDU + LRC) + CRLF
+func rtuToASCII(frame []byte) []byte {
+	data := append(append([]byte(nil), frame...), lrc(frame))
+	out := make([]byte, 0, 1+2*len(data)+2)
+	out = append(out, ':')
+	out = append(out, strings.ToUpper(hex.EncodeToString(data))...)
+	return append(out, '\r', '\n')
+}
+
+// asciiToRTU 校验 ASCII 帧并转换为带 CRC 的 RTU 帧
+func asciiToRTU(line []byte) ([]byte, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		line = line[i+1:]
+	} else {
+		return nil, modbus.ErrProtocolError
+	}
+	data := make([]byte, hex.DecodedLen(len(line)))
+	if _, err := hex.Decode(data, line); err != nil {
+		return nil, err
+	}
+	if len(data) < 3 {
+		return nil, modbus.ErrShortFrame
+	}
+	frame := data[:len(data)-1]
+	if lrc(frame) != data[len(data)-1] {
+		return nil, ErrBadLRC
+	}
+	sum := crc16(frame)
+	return append(frame, byte(sum), byte(sum>>8)), nil
+}
+
+// lrc 纵向冗余校验：所有字节和的补码
+func lrc(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return -sum
+}
+
+// crc16 Modbus RTU CRC，低字节在前发送
+func crc16(data []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xa001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
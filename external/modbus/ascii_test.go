@@ -0,0 +1,89 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+	"github.com/simonvetter/modbus"
+)
+
+func TestASCIIFrame(t *testing.T) {
+	request := []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0a}
+	assert.Equal(t, ":01030000000AF2\r\n", string(rtuToASCII(request)))
+
+	frame, err := asciiToRTU([]byte(":01030000000AF2\r\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0a, 0xc5, 0xcd}, frame)
+
+	_, err = asciiToRTU([]byte(":01030000000AF3\r\n"))
+	assert.Equal(t, ErrBadLRC, err)
+
+	assert.Nil(t, CheckFraming("tcp://127.0.0.1:502", ""))
+	assert.Nil(t, CheckFraming("udp://127.0.0.1:502", FramingRTUOverTCP))
+	assert.Nil(t, CheckFraming("rtu:///dev/ttyUSB0", FramingASCII))
+	assert.NotNil(t, CheckFraming("rtu:///dev/ttyUSB0", FramingRTUOverTCP))
+	assert.NotNil(t, CheckFraming("tcp://127.0.0.1:502", "mbap"))
+}
+
+// TestASCIIClient 通过 TCP 连接模拟 ASCII 设备
+func TestASCIIClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			request, err := asciiToRTU(line)
+			if err != nil {
+				return
+			}
+			// 读保持寄存器，返回地址对应的值
+			quantity := int(request[5])
+			response := []byte{request[0], request[1], byte(quantity * 2)}
+			for i := 0; i < quantity; i++ {
+				response = append(response, 0, request[3]+byte(i))
+			}
+			_, _ = conn.Write(rtuToASCII(response))
+		}
+	}()
+
+	client, err := NewClient(ClientConfig{
+		Server:    "tcp://" + listener.Addr().String(),
+		UnitId:    1,
+		Framing:   FramingASCII,
+		TcpConfig: TcpConfig{Timeout: 1},
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+
+	values, err := client.ReadRegisters(10, 3, modbus.HOLDING_REGISTER)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint16{10, 11, 12}, values)
+}
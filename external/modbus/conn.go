@@ -0,0 +1,267 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/simonvetter/modbus"
+)
+
+// ErrConnClosed 共享连接已经释放
+var ErrConnClosed = errors.New("modbus connection is closed")
+
+// healthComponent 上报健康状态使用的组件名称
+const healthComponent = "modbus"
+
+// conns 全局共享连接，key 见 ClientConfig.Key
+var (
+	connsLock sync.Mutex
+	conns     = map[string]*SharedConn{}
+)
+
+// ClientConfig 创建 Modbus 客户端的配置
+type ClientConfig struct {
+	Server string
+	UnitId uint8
+	// Framing 帧格式，见 FramingTCP、FramingRTUOverTCP、FramingASCII
+	Framing   string
+	TcpConfig TcpConfig
+	RtuConfig RtuConfig
+	// CircuitBreaker 熔断配置，共享连接的组件共享熔断器，以第一个启用熔断的配置为准
+	CircuitBreaker breaker.Config
+}
+
+// Key 共享连接的key，TCP 格式：server/unitId/framing，tcp+tls 追加证书路径
+// 串口同一时刻只能由一个连接收发，同一总线上的所有从机共享一个连接，key 为 server/framing
+// 帧格式或者 TLS 配置不同的组件不共享连接，避免使用其他组件的帧格式或者证书通信
+func (c ClientConfig) Key() string {
+	framing := strings.ToLower(c.Framing)
+	if framing == "" {
+		framing = FramingTCP
+	}
+	if IsSerial(c.Server) {
+		return fmt.Sprintf("%s/%s", c.Server, framing)
+	}
+	key := fmt.Sprintf("%s/%d/%s", c.Server, c.UnitId, framing)
+	if strings.HasPrefix(c.Server, "tcp+tls://") {
+		key += "/" + strings.Join([]string{c.TcpConfig.CertPath, c.TcpConfig.KeyPath, c.TcpConfig.CaPath}, "|")
+	}
+	return key
+}
+
+// NewClient 创建并打开 Modbus 客户端
+func NewClient(c ClientConfig) (*modbus.ModbusClient, error) {
+	if err := configureRS485(c.Server, c.RtuConfig); err != nil {
+		return nil, err
+	}
+	url, err := framingURL(c)
+	if err != nil {
+		return nil, err
+	}
+	config := &modbus.ClientConfiguration{
+		URL:      url,
+		Speed:    c.RtuConfig.Speed,
+		DataBits: c.RtuConfig.DataBits,
+		StopBits: c.RtuConfig.StopBits,
+		Timeout:  time.Duration(c.TcpConfig.Timeout) * time.Second,
+		Parity:   c.RtuConfig.Parity,
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if strings.HasPrefix(c.Server, "tcp+tls://") {
+		// Modbus/TCP Security 要求双向认证，底层客户端固定使用 TLS 1.2 及以上版本
+		tlsConf := tlsconfig.Config{CAFile: c.TcpConfig.CaPath, CertFile: c.TcpConfig.CertPath, KeyFile: c.TcpConfig.KeyPath}
+		config.TLSClientCert, err = tlsConf.LoadCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client tls key pair: %w", err)
+		}
+		config.TLSRootCAs, err = tlsConf.LoadCAPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls CA/server certificate: %w", err)
+		}
+	}
+	client, err := modbus.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	_ = client.SetUnitId(c.UnitId)
+	if err = client.Open(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// SharedConn 按 server/unitId 或者串口共享的 Modbus 连接
+// 相同设备的多个节点共用一个连接，连接断开时由任意一个节点重建，其他节点自动使用新连接
+// 底层客户端的请求是串行的，可以并发调用。串口连接的配置以第一个获取连接的组件为准
+type SharedConn struct {
+	config ClientConfig
+	mu     sync.Mutex
+	// breaker 熔断器，设备不可用时快速失败
+	breaker *breaker.Breaker
+	// bus 保证一批请求独占连接，串口总线上切换从机编号后再发送请求
+	bus sync.Mutex
+	// client 当前连接，nil 表示尚未打开或者已经关闭
+	client *modbus.ModbusClient
+	// last 上一次请求结束的时间，由 bus 保护
+	last time.Time
+	// refs 引用计数，为 0 时关闭连接
+	refs   int
+	closed bool
+}
+
+// initSharedConn 初始化共享连接，相同设备的组件共用一个连接
+func initSharedConn(node *base.SharedNode[*SharedConn], ruleConfig types.Config, nodeType string, config ClientConfig) error {
+	return node.InitWithClose(ruleConfig, nodeType, config.Key(), ruleConfig.NodeClientInitNow, func() (*SharedConn, error) {
+		conn := AcquireConn(config)
+		if _, err := conn.Open(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
+
+// AcquireConn 获取设备对应的共享连接，不存在则创建，引用计数加1
+// 连接在第一次使用时打开，不再使用时需要调用 Release
+func AcquireConn(config ClientConfig) *SharedConn {
+	key := config.Key()
+	connsLock.Lock()
+	defer connsLock.Unlock()
+	// 熔断器只在创建时设置，Do/Open 不加锁读取；连接存在期间熔断器的引用计数大于 0，
+	// 再次获取返回同一个熔断器，只增加引用计数并应用后续节点启用的熔断配置
+	b := breaker.Acquire(healthComponent, key, config.CircuitBreaker)
+	c, ok := conns[key]
+	if !ok {
+		c = &SharedConn{config: config, breaker: b}
+		conns[key] = c
+	}
+	c.mu.Lock()
+	c.refs++
+	c.mu.Unlock()
+	return c
+}
+
+// Client 获取连接，未打开时打开
+func (c *SharedConn) Client() (*modbus.ModbusClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrConnClosed
+	}
+	if c.client != nil {
+		return c.client, nil
+	}
+	client, err := NewClient(c.config)
+	if err != nil {
+		health.Disconnected(healthComponent, c.config.Key(), err)
+		return nil, err
+	}
+	c.client = client
+	health.Connected(healthComponent, c.config.Key())
+	return client, nil
+}
+
+// Open 熔断器允许时获取连接，连接结果上报到熔断器，熔断器断开时返回 breaker.OpenError
+func (c *SharedConn) Open() (*modbus.ModbusClient, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	client, err := c.Client()
+	c.breaker.Done(err)
+	return client, err
+}
+
+// Reconnect 重建连接，old 为出错的连接
+// 如果连接已经被其他节点重建，直接返回新连接
+func (c *SharedConn) Reconnect(old *modbus.ModbusClient) (*modbus.ModbusClient, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrConnClosed
+	}
+	if c.client != nil && c.client != old {
+		client := c.client
+		c.mu.Unlock()
+		return client, nil
+	}
+	if c.client != nil {
+		_ = c.client.Close()
+		c.client = nil
+	}
+	c.mu.Unlock()
+	health.Disconnected(healthComponent, c.config.Key(), nil)
+	// 等待设备或者网关释放旧连接
+	time.Sleep(200 * time.Millisecond)
+	return c.Client()
+}
+
+// pace 距离上一次请求结束满 interval 后执行请求，调用方需要持有 bus
+func (c *SharedConn) pace(interval time.Duration, fn func() error) error {
+	if interval > 0 && !c.last.IsZero() {
+		if d := interval - time.Since(c.last); d > 0 {
+			time.Sleep(d)
+		}
+	}
+	err := fn()
+	c.last = time.Now()
+	return err
+}
+
+// Release 引用计数减1，为 0 时关闭连接并从全局移除
+func (c *SharedConn) Release() error {
+	key := c.config.Key()
+	connsLock.Lock()
+	defer connsLock.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.breaker.Release()
+	c.refs--
+	if c.refs > 0 {
+		return nil
+	}
+	c.closed = true
+	if conns[key] == c {
+		delete(conns, key)
+	}
+	health.Remove(healthComponent, key)
+	if c.client != nil {
+		client := c.client
+		c.client = nil
+		return client.Close()
+	}
+	return nil
+}
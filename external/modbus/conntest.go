@@ -0,0 +1,140 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/rulego/rulego-components-iot/pkg/conntest"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册连接测试，连接配置相同的组件共用
+func init() {
+	for _, componentType := range []string{
+		"x/modbus",
+		"x/modbusRead",
+		types.EndpointTypePrefix + "modbus",
+	} {
+		_ = conntest.Register(componentType, TestConnection)
+	}
+}
+
+// TestConnection 测试 Modbus 连接配置，依次执行：
+//   - config：校验帧格式和点位映射
+//   - connect：打开 TCP 连接或者串口
+//   - read：读取样本数据块，确认从机响应。样本为配置的 testSample（格式同 reads 的数据块），
+//     为空使用 reads 的第一个数据块或者 tags 的第一个点位，都为空时跳过
+//
+// 测试使用共享连接，串口被其他组件打开时复用该连接，读取不重试，失败时不重建连接
+func TestConnection(ctx context.Context, config map[string]interface{}) *conntest.Result {
+	c := (&ReadNode{}).New().(*ReadNode).Config
+	if err := maps.Map2Struct(config, &c); err != nil {
+		return conntest.NewResult("", "").Fail(err)
+	}
+	result := conntest.NewResult("", c.Server)
+	var sample *ReadRequest
+	result.Run(conntest.StepConfig, func() (string, error) {
+		if err := CheckFraming(c.Server, c.Framing); err != nil {
+			return "", err
+		}
+		var err error
+		if c.Tags, err = ApplyProfile(c.Profile, c.Tags); err != nil {
+			return "", err
+		}
+		if c.Tags, err = ResolveTags(c.Tags); err != nil {
+			return "", err
+		}
+		if err = ValidateTags(c.Tags); err != nil {
+			return "", err
+		}
+		sample, err = sampleRequest(config, c.Reads)
+		return "", err
+	})
+	if !result.Success {
+		return result
+	}
+	// 连接超时不超过测试的剩余时间
+	timeout := conntest.Timeout(ctx, 0)
+	if timeout > 0 && (c.TcpConfig.Timeout <= 0 || float64(c.TcpConfig.Timeout) > timeout.Seconds()) {
+		c.TcpConfig.Timeout = int64(math.Max(1, math.Floor(timeout.Seconds())))
+	}
+
+	conn := AcquireConn((&ReadNode{Config: c}).clientConfig())
+	defer func() {
+		_ = conn.Release()
+	}()
+	result.Run(conntest.StepConnect, func() (string, error) {
+		if _, err := conn.Client(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("connected, unit id: %d", c.UnitId), nil
+	})
+
+	retry := RetryConfig{MaxRetries: -1}
+	switch {
+	case sample != nil:
+		result.Run(conntest.StepRead, func() (string, error) {
+			results, err := ReadBlocks(conn, nil, c.UnitId, c.EncodingConfig, retry, []ReadRequest{*sample})
+			if err != nil {
+				return "", err
+			}
+			result.Sample = results[0]
+			return fmt.Sprintf("%s %d", sample.Area, sample.Address), nil
+		})
+	case len(c.Tags) > 0:
+		result.Run(conntest.StepRead, func() (string, error) {
+			values, err := ReadTags(conn, nil, c.UnitId, c.EncodingConfig, retry, NewTagPlan(c.Tags[:1], 0))
+			if err != nil {
+				return "", err
+			}
+			result.Sample = values
+			return c.Tags[0].Name, nil
+		})
+	default:
+		result.Skip(conntest.StepRead, "no sample block configured")
+	}
+	return result
+}
+
+// sampleRequest 样本数据块，testSample 为空时使用 reads 的第一个数据块，都为空返回 nil
+func sampleRequest(config map[string]interface{}, reads []ReadRequest) (*ReadRequest, error) {
+	if v := conntest.Sample(config); v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		requests, err := parseReadRequests(string(b))
+		if err != nil {
+			return nil, err
+		}
+		if len(requests) == 0 {
+			return nil, errors.New("testSample is empty")
+		}
+		reads = requests
+	}
+	if len(reads) == 0 {
+		return nil, nil
+	}
+	sample := reads[0]
+	return &sample, sample.Validate()
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/simonvetter/modbus"
+)
+
+// 寄存器数据类型
+const (
+	DataTypeUint16  = "uint16"
+	DataTypeInt16   = "int16"
+	DataTypeUint32  = "uint32"
+	DataTypeInt32   = "int32"
+	DataTypeFloat32 = "float32"
+	DataTypeUint64  = "uint64"
+	DataTypeInt64   = "int64"
+	DataTypeFloat64 = "float64"
+)
+
+// registerWidth 每种数据类型占用的寄存器数量
+var registerWidth = map[string]uint16{
+	DataTypeUint16:  1,
+	DataTypeInt16:   1,
+	DataTypeUint32:  2,
+	DataTypeInt32:   2,
+	DataTypeFloat32: 2,
+	DataTypeUint64:  4,
+	DataTypeInt64:   4,
+	DataTypeFloat64: 4,
+}
+
+// RegisterWidth 返回数据类型占用的寄存器数量，dataType 不区分大小写，为空表示 uint16
+func RegisterWidth(dataType string) (uint16, error) {
+	if dataType == "" {
+		return 1, nil
+	}
+	if w, ok := registerWidth[strings.ToLower(dataType)]; ok {
+		return w, nil
+	}
+	return 0, fmt.Errorf("unsupported modbus data type: %s", dataType)
+}
+
+// DecodeRegisters 按数据类型解码寄存器原始字节（线上字节顺序）
+// endianness 和 wordOrder 的含义与 simonvetter/modbus 客户端相同
+func DecodeRegisters(raw []byte, dataType string, endianness modbus.Endianness, wordOrder modbus.WordOrder) ([]interface{}, error) {
+	width, err := RegisterWidth(dataType)
+	if err != nil {
+		return nil, err
+	}
+	if dataType == "" {
+		dataType = DataTypeUint16
+	}
+	dataType = strings.ToLower(dataType)
+	size := int(width) * 2
+	if len(raw)%size != 0 {
+		return nil, fmt.Errorf("invalid register data length %d for %s", len(raw), dataType)
+	}
+	values := make([]interface{}, 0, len(raw)/size)
+	for i := 0; i < len(raw); i += size {
+		u := decodeWords(raw[i:i+size], endianness, wordOrder)
+		switch dataType {
+		case DataTypeUint16:
+			values = append(values, uint16(u))
+		case DataTypeInt16:
+			values = append(values, int16(uint16(u)))
+		case DataTypeUint32:
+			values = append(values, uint32(u))
+		case DataTypeInt32:
+			values = append(values, int32(uint32(u)))
+		case DataTypeFloat32:
+			values = append(values, math.Float32frombits(uint32(u)))
+		case DataTypeUint64:
+			values = append(values, u)
+		case DataTypeInt64:
+			values = append(values, int64(u))
+		case DataTypeFloat64:
+			values = append(values, math.Float64frombits(u))
+		}
+	}
+	return values, nil
+}
+
+// decodeWords 把多个寄存器组合为一个无符号整数
+// 大端序高字在前、小端序低字在前时按原顺序组合，否则先交换字的顺序
+func decodeWords(b []byte, endianness modbus.Endianness, wordOrder modbus.WordOrder) uint64 {
+	words := len(b) / 2
+	buf := make([]byte, len(b))
+	natural := (endianness == modbus.LITTLE_ENDIAN && wordOrder == modbus.LOW_WORD_FIRST) ||
+		(endianness != modbus.LITTLE_ENDIAN && wordOrder != modbus.LOW_WORD_FIRST)
+	if natural || words == 1 {
+		copy(buf, b)
+	} else {
+		for i := 0; i < words; i++ {
+			j := words - 1 - i
+			buf[2*i], buf[2*i+1] = b[2*j], b[2*j+1]
+		}
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if endianness == modbus.LITTLE_ENDIAN {
+		order = binary.LittleEndian
+	}
+	switch words {
+	case 1:
+		return uint64(order.Uint16(buf))
+	case 2:
+		return uint64(order.Uint32(buf))
+	default:
+		return order.Uint64(buf)
+	}
+}
+
+// encodeWords 把无符号整数拆分为 words 个寄存器，是 decodeWords 的逆过程
+func encodeWords(u uint64, words int, endianness modbus.Endianness, wordOrder modbus.WordOrder) []byte {
+	var order binary.ByteOrder = binary.BigEndian
+	if endianness == modbus.LITTLE_ENDIAN {
+		order = binary.LittleEndian
+	}
+	buf := make([]byte, words*2)
+	switch words {
+	case 1:
+		order.PutUint16(buf, uint16(u))
+	case 2:
+		order.PutUint32(buf, uint32(u))
+	default:
+		order.PutUint64(buf, u)
+	}
+	natural := (endianness == modbus.LITTLE_ENDIAN && wordOrder == modbus.LOW_WORD_FIRST) ||
+		(endianness != modbus.LITTLE_ENDIAN && wordOrder != modbus.LOW_WORD_FIRST)
+	if natural || words == 1 {
+		return buf
+	}
+	b := make([]byte, len(buf))
+	for i := 0; i < words; i++ {
+		j := words - 1 - i
+		b[2*i], b[2*i+1] = buf[2*j], buf[2*j+1]
+	}
+	return b
+}
@@ -17,7 +17,6 @@
 package modbus
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,6 +27,7 @@ import (
 	"time"
 
 	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/components/base"
 	"github.com/rulego/rulego/utils/maps"
@@ -77,21 +77,27 @@ func init() {
 type ModbusConfiguration struct {
 	// 服务器地址
 	Server string `json:"server" label:"Server" desc:"Modbus server address, format: tcp://host:port or rtu:///dev/ttyUSB0" required:"true" ref:"primary"`
-	// Modbus 方法名称
-	Cmd string `json:"cmd" label:"Command" desc:"Modbus command: ReadCoils, ReadRegisters, WriteCoil, WriteRegister, etc."`
+	// Modbus 方法名称 允许使用 ${} 占位符变量，eg. 使用 x/tagWrite 输出的 ${metadata.cmd}
+	Cmd string `json:"cmd" label:"Command" desc:"Modbus command: ReadCoils, ReadRegisters, WriteCoil, WriteRegister, etc. Supports ${} variables"`
 	// UnitId 从机编号
 	UnitId uint8 `json:"unitId" label:"Unit ID" desc:"Modbus slave unit ID"`
+	// Framing 帧格式：tcp(默认，按 server 协议), rtu-over-tcp(TCP/UDP 透传 RTU 帧), ascii(Modbus ASCII，支持串口和 TCP)
+	Framing string `json:"framing" label:"Framing" desc:"Frame format: tcp (default, by server scheme), rtu-over-tcp (RTU frames over TCP/UDP), ascii (Modbus ASCII over serial or TCP)"`
 	// address 寄存器地址 允许使用 ${} 占位符变量，示例：50或者0x32
-	Address string `json:"address" label:"Address" desc:"Register address, supports \${} variables, e.g. 50 or 0x32"`
+	Address string `json:"address" label:"Address" desc:"Register address, supports ${} variables, e.g. 50 or 0x32"`
 	// quantity 寄存器数量 允许使用 ${} 占位符变量
-	Quantity string `json:"quantity" label:"Quantity" desc:"Number of registers, supports \${} variables"`
+	Quantity string `json:"quantity" label:"Quantity" desc:"Number of registers, supports ${} variables"`
 	// value 寄存器值 允许使用 ${} 占位符变量。。读则不需要提供，如果写入多个与逗号隔开，例如：0x1,0x1 true 51,52
-	Value string `json:"value" label:"Value" desc:"Register value for write, supports \${} variables, comma-separated for multiple"`
+	Value string `json:"value" label:"Value" desc:"Register value for write, supports ${} variables, comma-separated for multiple"`
 	// RegType 寄存器类型：  允许使用 ${} 占位符变量，0:保持寄存器(功能码0x3)，1:输入寄存器(功能码:0x4)
 	RegType        string         `json:"regType" label:"Register Type" desc:"Register type: 0=Holding, 1=Input"`
 	TcpConfig      TcpConfig      `json:"tcpConfig" label:"TCP Config" desc:"TCP connection configuration"`
 	RtuConfig      RtuConfig      `json:"rtuConfig" label:"RTU Config" desc:"RTU serial configuration"`
 	EncodingConfig EncodingConfig `json:"encodingConfig" label:"Encoding Config" desc:"Data encoding configuration"`
+	// Retry 重试策略和请求间隔
+	Retry RetryConfig `json:"retry" label:"Retry" desc:"Retry count, backoff, retryable exception codes and min interval between requests"`
+	// CircuitBreaker 熔断配置，连续通信失败达到阈值后快速失败，不再等待连接超时
+	CircuitBreaker breaker.Config `json:"circuitBreaker" label:"Circuit Breaker" desc:"Fail fast with CIRCUIT_OPEN after consecutive communication failures instead of waiting for the connect timeout"`
 }
 
 type EncodingConfig struct {
@@ -118,13 +124,15 @@ type RtuConfig struct {
 	// DataBits sets the number of bits per serial character (rtu only)
 	DataBits uint `json:"dataBits" label:"Data Bits" desc:"Bits per serial character: 5, 6, 7, 8"`
 	// Parity sets the serial link parity mode (rtu only)
-	Parity uint `json:"parity" label:"Parity" desc:"Parity mode: 0=None, 1=Odd, 2=Even"`
+	Parity uint `json:"parity" label:"Parity" desc:"Parity mode: 0=None, 1=Even, 2=Odd"`
 	// StopBits sets the number of serial stop bits (rtu only)
 	StopBits uint `json:"stopBits" label:"Stop Bits" desc:"Stop bits: 1, 2"`
+	// RS485 RS-485 收发切换配置 (rtu only)
+	RS485 RS485Config `json:"rs485" label:"RS-485" desc:"RS-485 direction control configuration, Linux only"`
 }
 
 // reconnectFunc 重新获取连接的回调函数
-// 由 ModbusNode 提供，通过 SharedNode.Close() + GetSafely() 实现安全的连接重建
+// 由 SharedConn.Reconnect 提供，多个节点共享的连接只重建一次
 type reconnectFunc func(oldClient *modbus.ModbusClient) (*modbus.ModbusClient, error)
 
 // RetryableModbusClient 带重试逻辑的Modbus客户端
@@ -133,6 +141,10 @@ type RetryableModbusClient struct {
 	maxRetries  int
 	logger      types.Logger
 	reconnectFn reconnectFunc
+	// retry 重试等待时间和可以重试的异常码，见 RetryConfig
+	retry RetryConfig
+	// pace 执行一次请求，保证与上一次请求的间隔，nil 表示直接执行
+	pace func(fn func() error) error
 	// 保存运行时配置（底层库不支持getter，重连后需手动恢复）
 	mu            sync.RWMutex
 	currentUnitId uint8
@@ -141,7 +153,7 @@ type RetryableModbusClient struct {
 }
 
 // NewRetryableModbusClient 创建一个新的带重试逻辑的Modbus客户端
-// reconnectFn: 连接失败时用于重建连接的回调，由调用方通过 SharedNode 机制提供
+// reconnectFn: 连接失败时用于重建连接的回调，由调用方通过 SharedConn 提供
 func NewRetryableModbusClient(client *modbus.ModbusClient, maxRetries int, logger types.Logger, reconnectFn reconnectFunc, unitId uint8, endianness modbus.Endianness, wordOrder modbus.WordOrder) *RetryableModbusClient {
 	return &RetryableModbusClient{
 		client:        client,
@@ -154,28 +166,42 @@ func NewRetryableModbusClient(client *modbus.ModbusClient, maxRetries int, logge
 	}
 }
 
-// executeWithRetry 执行操作并在连接错误时重试
+// executeWithRetry 执行操作，通信错误时重建连接后重试，可以重试的异常响应等待后直接重试
 func (r *RetryableModbusClient) executeWithRetry(operation string, fn func() error) error {
 	var err error
 	for retry := 0; retry <= r.maxRetries; retry++ {
-		err = fn()
+		if r.pace != nil {
+			err = r.pace(fn)
+		} else {
+			err = fn()
+		}
 		if err == nil {
 			return nil
 		}
+		// 配置错误重试无效
+		if err == modbus.ErrConfigurationError {
+			return err
+		}
+		code, isException := exceptionCode(err)
+		// 非法功能码、地址等异常响应重试无效
+		if isException && !r.retry.retryable(code) {
+			return err
+		}
 
-		// 判断是否为连接错误，并且重试次数未达上限
+		// 重试次数未达上限
 		if retry < r.maxRetries {
-			// 跳过明确的非网络/重试无效的协议错误
-			if err == modbus.ErrIllegalFunction ||
-				err == modbus.ErrIllegalDataAddress ||
-				err == modbus.ErrIllegalDataValue ||
-				err == modbus.ErrConfigurationError {
-				return err
+			if d := r.retry.backoff(retry); d > 0 {
+				time.Sleep(d)
+			}
+			if isException {
+				// 从机已经响应，连接正常，不需要重建连接
+				r.warnf("Modbus %s exception: %s, retry count: %d", operation, err, retry)
+				continue
 			}
 
 			r.warnf("Modbus %s error: %s, retry count: %d, trying to reconnect...", operation, err, retry)
 
-			// 通过 SharedNode 机制重建连接，避免直接操作共享连接
+			// 通过 SharedConn 重建连接，避免直接操作共享连接
 			if r.reconnectFn != nil {
 				newClient, reconnectErr := r.reconnectFn(r.client)
 				if reconnectErr != nil {
@@ -541,20 +567,18 @@ func (r *RetryableModbusClient) applyRuntimeConfig() {
 }
 
 // ModbusNode 客户端节点，
+// 与 x/modbusRead 使用同一套共享连接，相同 server 和 unitId 的节点共享一个连接，同一串口上的所有从机共享一个连接
 // 成功：转向Success链，发送消息执行结果存放在msg.Data
 // 失败：转向Failure链
 type ModbusNode struct {
-	base.SharedNode[*modbus.ModbusClient]
+	base.SharedNode[*SharedConn]
 	//节点配置
 	Config           ModbusConfiguration
+	cmdTemplate      str.Template
 	addressTemplate  str.Template
 	quantityTemplate str.Template
 	valueTemplate    str.Template
 	regTypeTemplate  str.Template
-	reconnectLocker  sync.Mutex
-	// 记录当前 UnitId
-	currentUnitId   uint8
-	currentUnitIdMu sync.RWMutex
 }
 
 type Params struct {
@@ -573,21 +597,6 @@ type ModbusValue struct {
 }
 
 // Type 返回组件类型
-
-func (x *ModbusNode) getCurrentUnitId() uint8 {
-	x.currentUnitIdMu.RLock()
-	defer x.currentUnitIdMu.RUnlock()
-	return x.currentUnitId
-}
-
-func (x *ModbusNode) setUnitId(client *modbus.ModbusClient, unitId uint8) {
-	x.currentUnitIdMu.Lock()
-	defer x.currentUnitIdMu.Unlock()
-	x.currentUnitId = unitId
-	if client != nil {
-		client.SetUnitId(unitId)
-	}
-}
 func (x *ModbusNode) Type() string {
 	return "x/modbus"
 }
@@ -624,20 +633,20 @@ func (x *ModbusNode) New() types.Node {
 func (x *ModbusNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
 	if err == nil {
-		// 初始化当前 UnitId
-		x.setUnitId(nil, x.Config.UnitId)
-
-		//初始化客户端
-		err = x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*modbus.ModbusClient, error) {
-			return x.initClient()
-		}, func(client *modbus.ModbusClient) error {
-			if client != nil {
-				return client.Close()
-			}
-			return nil
-		})
+		err = CheckFraming(x.Config.Server, x.Config.Framing)
+	}
+	if err == nil {
+		err = x.Config.Retry.Validate()
+	}
+	if err == nil {
+		err = x.Config.CircuitBreaker.Validate()
+	}
+	if err == nil {
+		//初始化客户端，与 x/modbusRead 共享相同设备的连接
+		err = initSharedConn(&x.SharedNode, ruleConfig, x.Type(), x.clientConfig())
 	}
 	//初始化模板
+	x.cmdTemplate = str.NewTemplate(x.Config.Cmd)
 	x.addressTemplate = str.NewTemplate(x.Config.Address)
 	x.quantityTemplate = str.NewTemplate(x.Config.Quantity)
 	x.valueTemplate = str.NewTemplate(x.Config.Value)
@@ -679,46 +688,6 @@ func readModbusValues[T bool | uint16 | uint32 | uint64 | float32 | float64 | by
 	return addVals
 }
 
-// reconnect 通过 SharedNode 机制安全地重建连接
-// 使用互斥锁避免并发重连导致惊群效应：多个请求同时失败时，只有一个执行 Close+GetSafely，
-// 其余请求等待后直接通过 GetSafely 获取已重建的连接
-func (x *ModbusNode) reconnect(oldClient *modbus.ModbusClient) (*modbus.ModbusClient, error) {
-	// 如果是共享节点池模式，则需要委托给实际拥有连接的源节点
-	if x.SharedNode.IsFromPool() && x.RuleConfig.NodePool != nil {
-		if nodeCtx, ok := x.RuleConfig.NodePool.Get(x.SharedNode.InstanceId); ok {
-			if sourceNode, ok := nodeCtx.GetNode().(*ModbusNode); ok {
-				return sourceNode.reconnect(oldClient)
-			}
-		}
-		return nil, fmt.Errorf("failed to get source modbus node from pool for instance %s", x.SharedNode.InstanceId)
-	}
-
-	x.reconnectLocker.Lock()
-	defer x.reconnectLocker.Unlock()
-
-	// 检查连接是否已经被其他协程重建
-	currentClient, err := x.SharedNode.GetSafely()
-	if err != nil {
-		// 获取或初始化失败，直接返回错误，避免无意义的双重重试
-		return nil, err
-	}
-	if currentClient != oldClient {
-		// 已经被其他协程重建，直接返回新连接
-		return currentClient, nil
-	}
-
-	// 主动关闭旧连接并等待网关释放资源
-	if oldClient != nil {
-		_ = oldClient.Close()
-		time.Sleep(200 * time.Millisecond)
-	}
-
-	// Close 会清理 localClient 并重置 clientInitialized=false
-	_ = x.SharedNode.Close()
-	// GetSafely 检测到 clientInitialized=false 后会调用 InitInstanceFunc 创建新客户端
-	return x.SharedNode.GetSafely()
-}
-
 // OnMsg 处理消息
 func (x *ModbusNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	var (
@@ -733,22 +702,18 @@ func (x *ModbusNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 		return
 	}
 
-	// 为此次请求创建临时的retryableClient，传入 reconnect 回调和运行时配置
-	retryableClient := NewRetryableModbusClient(
-		conn, 3, x.RuleConfig.Logger, x.reconnect,
-		x.getCurrentUnitId(),
-		modbus.Endianness(x.Config.EncodingConfig.Endianness),
-		modbus.WordOrder(x.Config.EncodingConfig.WordOrder),
-	)
-
 	params, err = x.getParams(ctx, msg)
 	if err != nil {
 		ctx.TellFailure(msg, err)
 		return
 	}
 
-	// 使用带重试功能的客户端执行操作
-	err, data = x.executeModbusCommand(params, retryableClient)
+	// 独占共享连接，使用带重试功能的客户端执行操作
+	err = withClient(conn, x.RuleConfig.Logger, x.Config.UnitId, x.Config.EncodingConfig, x.Config.Retry, func(client *RetryableModbusClient) error {
+		var cmdErr error
+		cmdErr, data = x.executeModbusCommand(params, client)
+		return cmdErr
+	})
 
 	if err != nil {
 		ctx.TellFailure(msg, err)
@@ -1007,7 +972,7 @@ func (x *ModbusNode) getParams(ctx types.RuleContext, msg types.RuleMsg) (*Param
 	}
 	val = x.valueTemplate.Execute(evn)
 	// 更新参数
-	params.Cmd = x.Config.Cmd
+	params.Cmd = strings.TrimSpace(x.cmdTemplate.Execute(evn))
 	params.Address = address
 	params.Quantity = quanitity
 	params.Value = val
@@ -1065,49 +1030,15 @@ func (x *ModbusNode) errorf(format string, v ...interface{}) {
 	}
 }
 
-// 初始化连接
-func (x *ModbusNode) initClient() (*modbus.ModbusClient, error) {
-	config := &modbus.ClientConfiguration{
-		URL:      x.Config.Server,
-		Speed:    x.Config.RtuConfig.Speed,
-		DataBits: x.Config.RtuConfig.DataBits,
-		StopBits: x.Config.RtuConfig.StopBits,
-		Timeout:  time.Duration(x.Config.TcpConfig.Timeout) * time.Second,
-		Parity:   x.Config.RtuConfig.Parity,
-	}
-	x.debugf("Initializing Modbus connection to %s with timeout=%ds, unitId=%d",
-		x.Config.Server, x.Config.TcpConfig.Timeout, x.Config.UnitId)
-	// handle TLS options
-	if strings.HasPrefix(x.Config.Server, "tcp+tls://") {
-		clientKeyPair, err := tls.LoadX509KeyPair(x.Config.TcpConfig.CertPath, x.Config.TcpConfig.KeyPath)
-		if err != nil {
-			x.errorf("failed to load client tls key pair: %v", err)
-			return nil, err
-		}
-		config.TLSClientCert = &clientKeyPair
-
-		config.TLSRootCAs, err = modbus.LoadCertPool(x.Config.TcpConfig.CaPath)
-		if err != nil {
-			x.errorf("failed to load tls CA/server certificate: %v", err)
-			return nil, err
-		}
-	}
-
-	conn, err := modbus.NewClient(config)
-	if err != nil {
-		x.errorf("Failed to create Modbus client: %v", err)
-		return nil, err
-	}
-	conn.SetEncoding(modbus.Endianness(x.Config.EncodingConfig.Endianness), modbus.WordOrder(x.Config.EncodingConfig.WordOrder))
-	conn.SetUnitId(x.Config.UnitId)
-
-	err = conn.Open()
-	if err != nil {
-		x.errorf("Failed to open Modbus connection: %v", err)
-		return nil, err
+func (x *ModbusNode) clientConfig() ClientConfig {
+	return ClientConfig{
+		Server:         x.Config.Server,
+		UnitId:         x.Config.UnitId,
+		Framing:        x.Config.Framing,
+		TcpConfig:      x.Config.TcpConfig,
+		RtuConfig:      x.Config.RtuConfig,
+		CircuitBreaker: x.Config.CircuitBreaker,
 	}
-	x.debugf("Modbus connection established successfully to %s", x.Config.Server)
-	return conn, err
 }
 
 // byteToBool 将string转换为bool，支持,01,true,false
@@ -0,0 +1,464 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+	"github.com/simonvetter/modbus"
+)
+
+// 数据区
+const (
+	AreaCoil            = "coil"
+	AreaDiscreteInput   = "discreteInput"
+	AreaHoldingRegister = "holdingRegister"
+	AreaInputRegister   = "inputRegister"
+)
+
+// 单次请求允许读取的最大数量，Modbus 协议限制
+const (
+	maxReadBits      = 2000
+	maxReadRegisters = 125
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// 服务器地址
+	Server string `json:"server" label:"Server" desc:"Modbus server address, format: tcp://host:port or rtu:///dev/ttyUSB0" required:"true" ref:"primary"`
+	// UnitId 从机编号
+	UnitId uint8 `json:"unitId" label:"Unit ID" desc:"Modbus slave unit ID"`
+	// Framing 帧格式：tcp(默认，按 server 协议), rtu-over-tcp(TCP/UDP 透传 RTU 帧), ascii(Modbus ASCII，支持串口和 TCP)
+	Framing string `json:"framing" label:"Framing" desc:"Frame format: tcp (default, by server scheme), rtu-over-tcp (RTU frames over TCP/UDP), ascii (Modbus ASCII over serial or TCP)"`
+	// Reads 读取的数据块，为空则使用消息负荷 msg.Data 中的数据块
+	Reads []ReadRequest `json:"reads" label:"Reads" desc:"Blocks to read, empty uses the blocks in msg.Data"`
+	// Tags 寄存器点位映射，配置后按点位读取并输出以点位名称为 key 的工程值，忽略 reads
+	Tags []Tag `json:"tags" label:"Tags" desc:"Register mappings decoded to engineering values keyed by tag name, overrides reads"`
+	// Profile 设备配置文件名称或者文件路径，配置文件的点位与 tags 合并，同名点位以 tags 为准，见 Profile
+	Profile string `json:"profile" label:"Profile" desc:"Device profile name (e.g. schneider-pm5xxx) or .json/.yaml file path, its tags are merged with tags"`
+	// MaxGap 合并点位读取时允许的最大地址间隔，单位寄存器，0 表示只合并连续的点位
+	MaxGap         uint16         `json:"maxGap" label:"Max Gap" desc:"Max address gap in registers when coalescing tag reads, 0 only merges contiguous tags"`
+	TcpConfig      TcpConfig      `json:"tcpConfig" label:"TCP Config" desc:"TCP connection configuration"`
+	RtuConfig      RtuConfig      `json:"rtuConfig" label:"RTU Config" desc:"RTU serial configuration"`
+	EncodingConfig EncodingConfig `json:"encodingConfig" label:"Encoding Config" desc:"Data encoding configuration"`
+	// Retry 重试策略和请求间隔
+	Retry RetryConfig `json:"retry" label:"Retry" desc:"Retry count, backoff, retryable exception codes and min interval between requests"`
+	// CircuitBreaker 熔断配置，连续通信失败达到阈值后快速失败，不再等待连接超时
+	CircuitBreaker breaker.Config `json:"circuitBreaker" label:"Circuit Breaker" desc:"Fail fast with CIRCUIT_OPEN after consecutive communication failures instead of waiting for the connect timeout"`
+}
+
+// ReadRequest 读取的数据块
+type ReadRequest struct {
+	// Name 数据块名称，原样返回
+	Name string `json:"name,omitempty"`
+	// Area 数据区：coil, discreteInput, holdingRegister, inputRegister
+	Area string `json:"area"`
+	// Address 起始地址
+	Address uint16 `json:"address"`
+	// Quantity 读取值的数量，寄存器数量为 quantity * 数据类型占用的寄存器数
+	Quantity uint16 `json:"quantity"`
+	// DataType 寄存器数据类型：uint16, int16, uint32, int32, float32, uint64, int64, float64，默认 uint16
+	// 线圈和离散输入忽略该字段
+	DataType string `json:"dataType,omitempty"`
+}
+
+// ReadResult 数据块读取结果
+type ReadResult struct {
+	Name     string        `json:"name,omitempty"`
+	UnitId   uint8         `json:"unitId"`
+	Area     string        `json:"area"`
+	Address  uint16        `json:"address"`
+	DataType string        `json:"dataType"`
+	Values   []interface{} `json:"values"`
+	// Quality 读取成功为 GOOD，读取失败（包括异常响应）不输出结果，异常码放在失败消息的元数据
+	quality.Quality
+}
+
+// ReadNode Modbus 读取节点
+// 读取线圈、离散输入、保持寄存器和输入寄存器，数据块来自配置 reads 或者消息负荷 msg.Data，格式：
+//
+//	[
+//	  {"name": "status", "area": "coil", "address": 0, "quantity": 8},
+//	  {"name": "temperature", "area": "holdingRegister", "address": 100, "quantity": 2, "dataType": "float32"}
+//	]
+//
+// 也可以是单个数据块对象。寄存器按 encodingConfig 的字节序和字序解码，结果会重新赋值到msg.Data：
+//
+//	[
+//	  {"name": "status", "unitId": 1, "area": "coil", "address": 0, "dataType": "bool", "values": [true, false, ...]},
+//	  {"name": "temperature", "unitId": 1, "area": "holdingRegister", "address": 100, "dataType": "float32", "values": [21.5, 22]}
+//	]
+//
+// 配置 tags 时按点位映射读取，支持字节序 ABCD/DCBA/BADC/CDAB 和线性缩放，结果以点位名称为 key：
+//
+//	{"temperature": 21.5, "pressure": 1.013, "model": "PLC-200"}
+//
+// 常见设备的点位可以通过 profile 引用设备配置文件，eg. schneider-pm5xxx、eastron-sdm630，见 Profile
+//
+// server 支持 tcp://host:port 和 RTU 串口 rtu:///dev/ttyUSB0，相同 server 和 unitId 的节点共享一个连接，
+// 同一串口上的所有从机共享一个连接。所有数据块读取成功，流转到`Success`链，否则流转到`Failure`链
+type ReadNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config ReadConfiguration
+	// tagPlan 点位读取计划
+	tagPlan *TagPlan
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/modbusRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Server: DefaultServer,
+			UnitId: DefaultUnitId,
+			TcpConfig: TcpConfig{
+				Timeout: 5,
+			},
+			RtuConfig: RtuConfig{
+				Speed:    DefaultSpeed,
+				DataBits: DefaultDataBits,
+				Parity:   DefaultParity,
+				StopBits: DefaultStopBits,
+			},
+			EncodingConfig: EncodingConfig{
+				Endianness: uint(DefaultEndianness),
+				WordOrder:  uint(DefaultWordOrder),
+			},
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	for _, r := range x.Config.Reads {
+		if err = r.Validate(); err != nil {
+			return err
+		}
+	}
+	if x.Config.Tags, err = ApplyProfile(x.Config.Profile, x.Config.Tags); err != nil {
+		return err
+	}
+	if x.Config.Tags, err = ResolveTags(x.Config.Tags); err != nil {
+		return err
+	}
+	if err = ValidateTags(x.Config.Tags); err != nil {
+		return err
+	}
+	if err = CheckFraming(x.Config.Server, x.Config.Framing); err != nil {
+		return err
+	}
+	if err = x.Config.Retry.Validate(); err != nil {
+		return err
+	}
+	if err = x.Config.CircuitBreaker.Validate(); err != nil {
+		return err
+	}
+	x.tagPlan = NewTagPlan(x.Config.Tags, x.Config.MaxGap)
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), x.clientConfig())
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	if len(x.Config.Tags) > 0 {
+		x.readTags(ctx, msg)
+		return
+	}
+	reads := x.Config.Reads
+	if len(reads) == 0 {
+		var err error
+		if reads, err = parseReadRequests(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	results, err := ReadBlocks(conn, x.RuleConfig.Logger, x.Config.UnitId, x.Config.EncodingConfig, x.Config.Retry, reads)
+	if err != nil {
+		tellFailure(ctx, msg, err)
+		return
+	}
+	bytes, err := json.Marshal(results)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// readTags 按点位映射读取，msg.Data 替换为以点位名称为 key 的工程值
+func (x *ReadNode) readTags(ctx types.RuleContext, msg types.RuleMsg) {
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	values, err := ReadTags(conn, x.RuleConfig.Logger, x.Config.UnitId, x.Config.EncodingConfig, x.Config.Retry, x.tagPlan)
+	if err != nil {
+		tellFailure(ctx, msg, err)
+		return
+	}
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "Modbus TCP/RTU master for reading coils, discrete inputs, holding and input registers as decoded JSON values. Routes to Success/Failure"
+}
+
+func (x *ReadNode) clientConfig() ClientConfig {
+	return ClientConfig{
+		Server:         x.Config.Server,
+		UnitId:         x.Config.UnitId,
+		Framing:        x.Config.Framing,
+		TcpConfig:      x.Config.TcpConfig,
+		RtuConfig:      x.Config.RtuConfig,
+		CircuitBreaker: x.Config.CircuitBreaker,
+	}
+}
+
+// tellFailure 从机返回异常响应时，元数据 qualityLevel 为 BAD，qualityCode 为异常码
+func tellFailure(ctx types.RuleContext, msg types.RuleMsg, err error) {
+	if q, ok := ExceptionQuality(err); ok {
+		msg.Metadata.PutValue(quality.KeyLevel, string(q.Level))
+		msg.Metadata.PutValue(quality.KeyCode, strconv.Itoa(int(q.Code)))
+	}
+	ctx.TellFailure(msg, err)
+}
+
+// exceptions 异常响应错误对应的异常码
+var exceptions = []struct {
+	err  error
+	code uint8
+}{
+	{modbus.ErrIllegalFunction, quality.ModbusIllegalFunction},
+	{modbus.ErrIllegalDataAddress, quality.ModbusIllegalDataAddress},
+	{modbus.ErrIllegalDataValue, quality.ModbusIllegalDataValue},
+	{modbus.ErrServerDeviceFailure, quality.ModbusServerDeviceFailure},
+	{modbus.ErrAcknowledge, quality.ModbusAcknowledge},
+	{modbus.ErrServerDeviceBusy, quality.ModbusServerDeviceBusy},
+	{modbus.ErrMemoryParityError, quality.ModbusMemoryParityError},
+	{modbus.ErrGWPathUnavailable, quality.ModbusGatewayPathUnavailable},
+	{modbus.ErrGWTargetFailedToRespond, quality.ModbusGatewayTargetNoResponse},
+}
+
+// ExceptionQuality 把从机的异常响应转换为归一化的质量，不是异常响应（eg. 超时、连接断开）返回 false
+func ExceptionQuality(err error) (quality.Quality, bool) {
+	if code, ok := exceptionCode(err); ok {
+		return quality.FromModbusException(code), true
+	}
+	return quality.Quality{}, false
+}
+
+// ReadBlocks 使用共享连接读取多个数据块，任意数据块读取失败返回错误
+func ReadBlocks(conn *SharedConn, logger types.Logger, unitId uint8, encoding EncodingConfig, retry RetryConfig, reads []ReadRequest) ([]ReadResult, error) {
+	endianness := modbus.Endianness(encoding.Endianness)
+	wordOrder := modbus.WordOrder(encoding.WordOrder)
+	results := make([]ReadResult, 0, len(reads))
+	err := withClient(conn, logger, unitId, encoding, retry, func(client *RetryableModbusClient) error {
+		for _, r := range reads {
+			result, err := readBlock(client, unitId, r, endianness, wordOrder)
+			if err != nil {
+				return fmt.Errorf("read %s %d: %w", r.Area, r.Address, err)
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// withClient 独占连接并切换从机编号后执行 fn，串口总线上的其他从机等待本批请求完成
+// 执行结果和耗时上报到健康状态监控。熔断器断开时不等待总线，直接返回 breaker.OpenError，
+// 连接失败和重试后仍然失败的通信错误计为熔断器的失败，从机的异常响应说明设备可用，不计为失败
+func withClient(conn *SharedConn, logger types.Logger, unitId uint8, encoding EncodingConfig, retry RetryConfig, fn func(client *RetryableModbusClient) error) error {
+	if err := conn.breaker.Allow(); err != nil {
+		return err
+	}
+	conn.bus.Lock()
+	defer conn.bus.Unlock()
+	client, err := conn.Client()
+	if err != nil {
+		conn.breaker.Done(err)
+		return err
+	}
+	if err = client.SetUnitId(unitId); err != nil {
+		conn.breaker.Done(nil)
+		return err
+	}
+	// 共享连接的其他节点可能使用不同的编码
+	client.SetEncoding(modbus.Endianness(encoding.Endianness), modbus.WordOrder(encoding.WordOrder))
+	start := time.Now()
+	retryableClient := NewRetryableModbusClient(client, retry.retries(), logger, conn.Reconnect, unitId,
+		modbus.Endianness(encoding.Endianness), modbus.WordOrder(encoding.WordOrder))
+	retryableClient.retry = retry
+	retryableClient.pace = func(fn func() error) error {
+		return conn.pace(retry.interval(), fn)
+	}
+	err = fn(retryableClient)
+	health.Report(healthComponent, conn.config.Key(), time.Since(start), err)
+	var connErr *ModbusConnErr
+	if errors.As(err, &connErr) {
+		conn.breaker.Done(err)
+	} else {
+		conn.breaker.Done(nil)
+	}
+	return err
+}
+
+// readBlock 读取一个数据块
+func readBlock(client *RetryableModbusClient, unitId uint8, r ReadRequest, endianness modbus.Endianness, wordOrder modbus.WordOrder) (ReadResult, error) {
+	result := ReadResult{
+		Name:     r.Name,
+		UnitId:   unitId,
+		Area:     r.Area,
+		Address:  r.Address,
+		DataType: "bool",
+		Quality:  quality.GoodQuality,
+	}
+	var (
+		bools []bool
+		raw   []byte
+		err   error
+	)
+	switch r.Area {
+	case AreaCoil:
+		bools, err = client.ReadCoils(r.Address, r.Quantity)
+	case AreaDiscreteInput:
+		bools, err = client.ReadDiscreteInputs(r.Address, r.Quantity)
+	case AreaHoldingRegister, AreaInputRegister:
+		regType := modbus.HOLDING_REGISTER
+		if r.Area == AreaInputRegister {
+			regType = modbus.INPUT_REGISTER
+		}
+		width, _ := RegisterWidth(r.DataType)
+		raw, err = client.ReadRawBytes(r.Address, r.Quantity*width*2, regType)
+		if err != nil {
+			return result, err
+		}
+		result.DataType = strings.ToLower(r.DataType)
+		if result.DataType == "" {
+			result.DataType = DataTypeUint16
+		}
+		result.Values, err = DecodeRegisters(raw, r.DataType, endianness, wordOrder)
+		return result, err
+	default:
+		return result, fmt.Errorf("unsupported modbus area: %s", r.Area)
+	}
+	if err != nil {
+		return result, err
+	}
+	result.Values = make([]interface{}, 0, len(bools))
+	for _, b := range bools {
+		result.Values = append(result.Values, b)
+	}
+	return result, nil
+}
+
+// Validate 校验数据块
+func (r ReadRequest) Validate() error {
+	switch r.Area {
+	case AreaCoil, AreaDiscreteInput, AreaHoldingRegister, AreaInputRegister:
+	default:
+		return fmt.Errorf("unsupported modbus area: %s", r.Area)
+	}
+	if r.Quantity == 0 {
+		return fmt.Errorf("modbus quantity cannot be 0, area: %s, address: %d", r.Area, r.Address)
+	}
+	if r.Area == AreaHoldingRegister || r.Area == AreaInputRegister {
+		width, err := RegisterWidth(r.DataType)
+		if err != nil {
+			return err
+		}
+		if uint32(r.Quantity)*uint32(width) > maxReadRegisters {
+			return fmt.Errorf("modbus quantity %d of %s exceeds %d registers", r.Quantity, r.DataType, maxReadRegisters)
+		}
+	} else if r.Quantity > maxReadBits {
+		return fmt.Errorf("modbus quantity %d exceeds %d bits", r.Quantity, maxReadBits)
+	}
+	return nil
+}
+
+// parseReadRequests 解析消息负荷中的数据块，支持单个对象或者数组
+func parseReadRequests(data string) ([]ReadRequest, error) {
+	data = strings.TrimSpace(data)
+	var reads []ReadRequest
+	if strings.HasPrefix(data, "[") {
+		if err := json.Unmarshal([]byte(data), &reads); err != nil {
+			return nil, err
+		}
+	} else {
+		var r ReadRequest
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return nil, err
+		}
+		reads = append(reads, r)
+	}
+	if len(reads) == 0 {
+		return nil, fmt.Errorf("no modbus blocks to read")
+	}
+	for _, r := range reads {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return reads, nil
+}
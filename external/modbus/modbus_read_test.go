@@ -0,0 +1,251 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/simonvetter/modbus"
+)
+
+// testHandler 测试用的 Modbus 从站
+type testHandler struct {
+	coils     []bool
+	inputs    []bool
+	holding   []uint16
+	inputRegs []uint16
+}
+
+func (h *testHandler) HandleCoils(req *modbus.CoilsRequest) ([]bool, error) {
+	if int(req.Addr)+int(req.Quantity) > len(h.coils) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	if req.IsWrite {
+		copy(h.coils[req.Addr:], req.Args)
+		return nil, nil
+	}
+	return h.coils[req.Addr : req.Addr+req.Quantity], nil
+}
+
+func (h *testHandler) HandleDiscreteInputs(req *modbus.DiscreteInputsRequest) ([]bool, error) {
+	if int(req.Addr)+int(req.Quantity) > len(h.inputs) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	return h.inputs[req.Addr : req.Addr+req.Quantity], nil
+}
+
+func (h *testHandler) HandleHoldingRegisters(req *modbus.HoldingRegistersRequest) ([]uint16, error) {
+	if int(req.Addr)+int(req.Quantity) > len(h.holding) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	if req.IsWrite {
+		copy(h.holding[req.Addr:], req.Args)
+		return nil, nil
+	}
+	return h.holding[req.Addr : req.Addr+req.Quantity], nil
+}
+
+func (h *testHandler) HandleInputRegisters(req *modbus.InputRegistersRequest) ([]uint16, error) {
+	if int(req.Addr)+int(req.Quantity) > len(h.inputRegs) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	return h.inputRegs[req.Addr : req.Addr+req.Quantity], nil
+}
+
+// startTestServer 启动测试用的 Modbus TCP 从站
+func startTestServer(t *testing.T, url string, handler *testHandler) *modbus.ModbusServer {
+	server, err := modbus.NewServer(&modbus.ServerConfiguration{
+		URL:        url,
+		Timeout:    10 * time.Second,
+		MaxClients: 5,
+	}, handler)
+	assert.Nil(t, err)
+	assert.Nil(t, server.Start())
+	return server
+}
+
+func TestReadNode(t *testing.T) {
+	f32 := math.Float32bits(21.5)
+	handler := &testHandler{
+		coils:     []bool{true, false, true, true},
+		inputs:    []bool{false, true},
+		holding:   []uint16{1, 0xFFFF, uint16(f32 >> 16), uint16(f32)},
+		inputRegs: []uint16{0x0001, 0x0002},
+	}
+	server := startTestServer(t, "tcp://localhost:15021", handler)
+	defer server.Stop()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+
+	// 非法数据区
+	_, err := test.CreateAndInitNode("x/modbusRead", types.Configuration{
+		"server": "tcp://localhost:15021",
+		"reads":  []map[string]interface{}{{"area": "unknown", "address": 0, "quantity": 1}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/modbusRead", types.Configuration{
+		"server": "tcp://localhost:15021",
+		"unitId": 1,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	// 相同设备的节点共享连接
+	node2, err := test.CreateAndInitNode("x/modbusRead", types.Configuration{
+		"server": "tcp://localhost:15021",
+		"unitId": 1,
+		"reads":  []map[string]interface{}{{"area": "inputRegister", "address": 0, "quantity": 1, "dataType": "uint32"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node2.Destroy()
+	conn1, _ := node.(*ReadNode).SharedNode.GetSafely()
+	conn2, _ := node2.(*ReadNode).SharedNode.GetSafely()
+	assert.True(t, conn1 == conn2)
+
+	msgList := []test.Msg{
+		{
+			MetaData: types.NewMetadata(),
+			DataType: types.JSON,
+			MsgType:  "READ",
+			Data: `[{"name":"status","area":"coil","address":0,"quantity":4},
+{"area":"discreteInput","address":0,"quantity":2},
+{"area":"holdingRegister","address":0,"quantity":2,"dataType":"int16"},
+{"name":"temperature","area":"holdingRegister","address":2,"quantity":1,"dataType":"float32"}]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "OUT_OF_RANGE",
+			Data:       `{"area":"holdingRegister","address":10,"quantity":1}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "OUT_OF_RANGE" {
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, "BAD", msg.Metadata.GetValue(quality.KeyLevel))
+			assert.Equal(t, "2", msg.Metadata.GetValue(quality.KeyCode))
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		var results []ReadResult
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &results))
+		assert.Equal(t, 4, len(results))
+		assert.Equal(t, quality.Good, results[0].Level)
+		assert.Equal(t, "status", results[0].Name)
+		assert.Equal(t, "bool", results[0].DataType)
+		assert.Equal(t, 4, len(results[0].Values))
+		assert.Equal(t, true, results[0].Values[0])
+		assert.Equal(t, false, results[0].Values[1])
+		assert.Equal(t, true, results[1].Values[1])
+		assert.Equal(t, "int16", results[2].DataType)
+		assert.Equal(t, float64(1), results[2].Values[0])
+		assert.Equal(t, float64(-1), results[2].Values[1])
+		assert.Equal(t, "temperature", results[3].Name)
+		assert.Equal(t, 21.5, results[3].Values[0])
+	})
+
+	test.NodeOnMsg(t, node2, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var results []ReadResult
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &results))
+		assert.Equal(t, float64(0x00010002), results[0].Values[0])
+	})
+}
+
+func TestDecodeRegisters(t *testing.T) {
+	// 0x12345678 线上字节顺序
+	raw := []byte{0x12, 0x34, 0x56, 0x78}
+	values, err := DecodeRegisters(raw, "uint32", modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0x12345678), values[0])
+	values, err = DecodeRegisters(raw, "uint32", modbus.BIG_ENDIAN, modbus.LOW_WORD_FIRST)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0x56781234), values[0])
+	values, err = DecodeRegisters(raw, "uint32", modbus.LITTLE_ENDIAN, modbus.LOW_WORD_FIRST)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0x78563412), values[0])
+	values, err = DecodeRegisters(raw, "UINT16", modbus.LITTLE_ENDIAN, modbus.LOW_WORD_FIRST)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(values))
+	assert.Equal(t, uint16(0x3412), values[0])
+	values, err = DecodeRegisters(raw, "", modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(0x1234), values[0])
+
+	raw = make([]byte, 8)
+	bits := math.Float64bits(-3.25)
+	for i := 0; i < 8; i++ {
+		raw[i] = byte(bits >> (56 - 8*i))
+	}
+	values, err = DecodeRegisters(raw, "float64", modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST)
+	assert.Nil(t, err)
+	assert.Equal(t, -3.25, values[0])
+	values, err = DecodeRegisters(raw, "int64", modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(bits), values[0])
+
+	_, err = DecodeRegisters([]byte{1, 2}, "uint32", modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST)
+	assert.NotNil(t, err)
+	_, err = DecodeRegisters(raw, "string", modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST)
+	assert.NotNil(t, err)
+}
+
+func TestClientConfigKey(t *testing.T) {
+	// TCP 按 server/unitId 共享连接
+	tcp1 := ClientConfig{Server: "tcp://localhost:15021", UnitId: 1}
+	tcp2 := ClientConfig{Server: "tcp://localhost:15021", UnitId: 2}
+	assert.True(t, tcp1.Key() != tcp2.Key())
+
+	// 同一串口上的从机共享连接
+	rtu1 := ClientConfig{Server: "rtu:///dev/ttyUSB0", UnitId: 1}
+	rtu2 := ClientConfig{Server: "rtu:///dev/ttyUSB0", UnitId: 2}
+	assert.Equal(t, rtu1.Key(), rtu2.Key())
+	assert.True(t, IsSerial(rtu1.Server))
+
+	// 帧格式不同不共享连接，默认帧格式为 tcp
+	assert.True(t, tcp1.Key() != ClientConfig{Server: tcp1.Server, UnitId: 1, Framing: FramingRTUOverTCP}.Key())
+	assert.Equal(t, tcp1.Key(), ClientConfig{Server: tcp1.Server, UnitId: 1, Framing: FramingTCP}.Key())
+	assert.True(t, rtu1.Key() != ClientConfig{Server: rtu1.Server, UnitId: 1, Framing: FramingASCII}.Key())
+
+	// TLS 证书不同不共享连接
+	tls1 := ClientConfig{Server: "tcp+tls://localhost:15021", UnitId: 1, TcpConfig: TcpConfig{CertPath: "a.crt", KeyPath: "a.key", CaPath: "ca.crt"}}
+	tls2 := ClientConfig{Server: "tcp+tls://localhost:15021", UnitId: 1, TcpConfig: TcpConfig{CertPath: "b.crt", KeyPath: "b.key", CaPath: "ca.crt"}}
+	assert.True(t, tls1.Key() != tls2.Key())
+	assert.False(t, IsSerial(tcp1.Server))
+
+	// 未启用 RS-485 或者非串口地址时不打开串口
+	assert.Nil(t, configureRS485("rtu:///dev/not-exist", RtuConfig{}))
+	assert.Nil(t, configureRS485("tcp://localhost:15021", RtuConfig{RS485: RS485Config{Enabled: true}}))
+	assert.NotNil(t, configureRS485("rtu:///dev/not-exist", RtuConfig{RS485: RS485Config{Enabled: true}}))
+}
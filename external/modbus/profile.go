@@ -0,0 +1,267 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtinProfiles 内置的设备配置文件
+//
+//go:embed profiles/*.json
+var builtinProfiles embed.FS
+
+// Profile 设备配置文件，打包常见设备（电能表、变频器、电力分析仪等）的寄存器点位映射，
+// 读取节点和端点通过 profile 按名称引用，不需要为每台设备重复配置点位
+type Profile struct {
+	// Name 配置文件名称，全局唯一，eg. schneider-pm5xxx
+	Name string `json:"name"`
+	// Manufacturer 厂商
+	Manufacturer string `json:"manufacturer,omitempty"`
+	// Model 型号
+	Model string `json:"model,omitempty"`
+	// Description 描述
+	Description string `json:"description,omitempty"`
+	// ByteOrder 点位默认的字节序：ABCD, DCBA, BADC, CDAB，点位配置的字节序优先
+	ByteOrder string `json:"byteOrder,omitempty"`
+	// Tags 寄存器点位映射，地址为从 0 开始的协议地址
+	Tags []Tag `json:"tags"`
+}
+
+// Validate 校验配置文件
+func (p Profile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("modbus profile name cannot be empty")
+	}
+	if len(p.Tags) == 0 {
+		return fmt.Errorf("modbus profile %s: tags cannot be empty", p.Name)
+	}
+	if _, _, err := ParseByteOrder(p.ByteOrder); err != nil {
+		return fmt.Errorf("modbus profile %s: %w", p.Name, err)
+	}
+	if err := ValidateTags(p.tags()); err != nil {
+		return fmt.Errorf("modbus profile %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// tags 点位列表，没有配置字节序的点位使用配置文件的字节序
+func (p Profile) tags() []Tag {
+	list := make([]Tag, len(p.Tags))
+	for i, t := range p.Tags {
+		if t.ByteOrder == "" {
+			t.ByteOrder = p.ByteOrder
+		}
+		list[i] = t
+	}
+	return list
+}
+
+var (
+	profilesLock sync.RWMutex
+	profiles     = map[string]Profile{}
+)
+
+func init() {
+	entries, _ := builtinProfiles.ReadDir("profiles")
+	for _, entry := range entries {
+		data, err := builtinProfiles.ReadFile(path.Join("profiles", entry.Name()))
+		if err == nil {
+			err = loadProfiles(data, false)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("builtin modbus profile %s: %v", entry.Name(), err))
+		}
+	}
+}
+
+// RegisterProfile 注册配置文件，已经存在的同名配置文件被替换，任意配置文件校验失败则不注册
+func RegisterProfile(list ...Profile) error {
+	for _, p := range list {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	profilesLock.Lock()
+	defer profilesLock.Unlock()
+	for _, p := range list {
+		profiles[strings.ToLower(p.Name)] = p
+	}
+	return nil
+}
+
+// GetProfile 按名称获取配置文件，名称不区分大小写
+func GetProfile(name string) (Profile, bool) {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+	p, ok := profiles[strings.ToLower(strings.TrimSpace(name))]
+	return p, ok
+}
+
+// Profiles 所有配置文件名称，按名称排序
+func Profiles() []string {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadProfiles 加载并注册配置文件，支持 JSON 和 YAML：单个配置文件、配置文件数组或者 {"profiles": [...]}
+func LoadProfiles(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return loadProfiles(data, true)
+}
+
+// LoadProfileFile 从文件加载配置文件，见 LoadProfiles
+func LoadProfileFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = LoadProfiles(f); err != nil {
+		return fmt.Errorf("load modbus profile %s: %w", file, err)
+	}
+	return nil
+}
+
+// LoadProfileDir 加载目录下所有 .json、.yaml 和 .yml 配置文件
+func LoadProfileDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !isProfileFile(entry.Name()) {
+			continue
+		}
+		if err = LoadProfileFile(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyProfile 把配置文件的点位和配置的点位合并，配置的同名点位覆盖配置文件中的点位，其余点位追加在后面
+// profile 为配置文件名称，或者 .json、.yaml、.yml 文件路径（加载后按文件中的第一个配置文件使用），为空返回配置的点位
+func ApplyProfile(profile string, tags []Tag) ([]Tag, error) {
+	profile = strings.TrimSpace(profile)
+	if profile == "" {
+		return tags, nil
+	}
+	var p Profile
+	if isProfileFile(profile) {
+		data, err := os.ReadFile(profile)
+		if err != nil {
+			return nil, err
+		}
+		list, err := parseProfiles(data)
+		if err != nil {
+			return nil, fmt.Errorf("load modbus profile %s: %w", profile, err)
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("modbus profile %s is empty", profile)
+		}
+		if err = list[0].Validate(); err != nil {
+			return nil, err
+		}
+		p = list[0]
+	} else {
+		var ok bool
+		if p, ok = GetProfile(profile); !ok {
+			return nil, fmt.Errorf("modbus profile not found: %s", profile)
+		}
+	}
+	merged := p.tags()
+	index := make(map[string]int, len(merged))
+	for i, t := range merged {
+		index[t.Name] = i
+	}
+	for _, t := range tags {
+		if i, ok := index[t.Name]; ok && t.Name != "" {
+			merged[i] = t
+		} else {
+			merged = append(merged, t)
+		}
+	}
+	return merged, nil
+}
+
+// isProfileFile 是否是配置文件路径
+func isProfileFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// loadProfiles 解析并注册配置文件
+func loadProfiles(data []byte, allowEmpty bool) error {
+	list, err := parseProfiles(data)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 && !allowEmpty {
+		return fmt.Errorf("no modbus profiles")
+	}
+	return RegisterProfile(list...)
+}
+
+// parseProfiles 解析 JSON 或者 YAML 格式的配置文件，YAML 先转换为 JSON，字段名称与 JSON 一致
+func parseProfiles(data []byte) ([]Profile, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if m, ok := doc.(map[string]interface{}); ok {
+		if list, ok := m["profiles"]; ok {
+			doc = list
+		}
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var list []Profile
+	if _, ok := doc.([]interface{}); ok {
+		err = json.Unmarshal(b, &list)
+	} else {
+		var p Profile
+		err = json.Unmarshal(b, &p)
+		list = []Profile{p}
+	}
+	return list, err
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestProfile(t *testing.T) {
+	// 内置配置文件
+	for _, name := range []string{"schneider-pm5xxx", "eastron-sdm630", "abb-drives-profile"} {
+		p, ok := GetProfile(name)
+		assert.True(t, ok, name)
+		assert.Nil(t, p.Validate())
+	}
+	p, _ := GetProfile("Schneider-PM5xxx")
+	assert.Equal(t, uint16(2999), p.Tags[0].Address)
+
+	assert.NotNil(t, RegisterProfile(Profile{Name: "empty"}))
+	assert.NotNil(t, RegisterProfile(Profile{Name: "order", ByteOrder: "ACBD", Tags: []Tag{{Name: "a"}}}))
+
+	dir := t.TempDir()
+	yamlProfile := `profiles:
+  - name: test-meter
+    byteOrder: CDAB
+    tags:
+      - name: power
+        address: 10
+        dataType: float32
+        scale: 0.1
+      - {name: energy, address: 12, dataType: uint32, byteOrder: ABCD}
+`
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "meter.yaml"), []byte(yamlProfile), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("ignored"), 0600))
+	assert.Nil(t, LoadProfileDir(dir))
+	_, ok := GetProfile("test-meter")
+	assert.True(t, ok)
+
+	// 配置的同名点位覆盖配置文件中的点位，没有配置字节序的点位使用配置文件的字节序
+	list, err := ApplyProfile("test-meter", []Tag{{Name: "energy", Address: 14, DataType: "uint16"}, {Name: "status", Address: 1}})
+	assert.Nil(t, err)
+	assert.Equal(t, []Tag{
+		{Name: "power", Address: 10, DataType: "float32", ByteOrder: ByteOrderCDAB, Scale: 0.1},
+		{Name: "energy", Address: 14, DataType: "uint16"},
+		{Name: "status", Address: 1},
+	}, list)
+
+	// 文件路径
+	list, err = ApplyProfile(filepath.Join(dir, "meter.yaml"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, ByteOrderABCD, list[1].ByteOrder)
+
+	_, err = ApplyProfile("unknown", nil)
+	assert.NotNil(t, err)
+	list, err = ApplyProfile("", []Tag{{Name: "a"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	node, err := test.CreateAndInitNode("x/modbusRead", types.Configuration{
+		"server":  "tcp://127.0.0.1:502",
+		"profile": "eastron-sdm630",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+	tags := node.(*ReadNode).Config.Tags
+	assert.Equal(t, "voltageL1", tags[0].Name)
+	assert.Equal(t, AreaInputRegister, tags[0].Area)
+}
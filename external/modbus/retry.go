@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+)
+
+// DefaultMaxRetries 默认的最大重试次数
+const DefaultMaxRetries = 3
+
+// DefaultRetryableExceptions 默认可以重试的异常码：确认、从机忙、网关路径不可用、网关目标设备无响应
+var DefaultRetryableExceptions = []uint8{
+	quality.ModbusAcknowledge,
+	quality.ModbusServerDeviceBusy,
+	quality.ModbusGatewayPathUnavailable,
+	quality.ModbusGatewayTargetNoResponse,
+}
+
+// RetryConfig 请求失败的重试策略和请求间隔
+// 超时、连接断开等通信错误重建连接后重试；从机返回的异常响应只有 retryableExceptions 中的异常码重试，并且不重建连接，其他异常码直接返回错误
+type RetryConfig struct {
+	// MaxRetries 最大重试次数，0 使用默认值 3，负数表示不重试
+	MaxRetries int `json:"maxRetries" label:"Max Retries" desc:"Max retries of a failed request, 0 uses the default 3, negative disables retries"`
+	// Backoff 第一次重试前的等待时间，单位毫秒，之后每次重试翻倍，0 表示立即重试
+	Backoff int `json:"backoff" label:"Backoff" desc:"Wait before the first retry in milliseconds, doubled on each retry, 0 retries immediately"`
+	// MaxBackoff 重试等待时间上限，单位毫秒，0 表示不限制
+	MaxBackoff int `json:"maxBackoff" label:"Max Backoff" desc:"Upper limit of the retry wait in milliseconds, 0 means no limit"`
+	// RetryableExceptions 可以重试的异常码，为空使用默认值 [5, 6, 10, 11]
+	RetryableExceptions []uint8 `json:"retryableExceptions" label:"Retryable Exceptions" desc:"Exception codes that are retried, other exceptions fail at once, empty uses 5, 6, 10, 11"`
+	// RequestInterval 距离同一连接上一次请求结束的最小间隔，单位毫秒，用于响应慢的串口设备，0 表示不限制
+	RequestInterval int `json:"requestInterval" label:"Request Interval" desc:"Min delay in milliseconds after the previous request on the same connection, for slow serial devices, 0 means no delay"`
+}
+
+// Validate 校验重试策略
+func (c RetryConfig) Validate() error {
+	if c.Backoff < 0 || c.MaxBackoff < 0 || c.RequestInterval < 0 {
+		return errors.New("modbus retry backoff, maxBackoff and requestInterval cannot be negative")
+	}
+	for _, code := range c.RetryableExceptions {
+		if !isExceptionCode(code) {
+			return fmt.Errorf("unknown modbus exception code: %d", code)
+		}
+	}
+	return nil
+}
+
+// retries 最大重试次数
+func (c RetryConfig) retries() int {
+	if c.MaxRetries == 0 {
+		return DefaultMaxRetries
+	}
+	if c.MaxRetries < 0 {
+		return 0
+	}
+	return c.MaxRetries
+}
+
+// backoff 第 retry 次（从0开始）重试前的等待时间
+func (c RetryConfig) backoff(retry int) time.Duration {
+	if c.Backoff <= 0 {
+		return 0
+	}
+	d := time.Duration(c.Backoff) * time.Millisecond
+	limit := time.Duration(c.MaxBackoff) * time.Millisecond
+	for i := 0; i < retry; i++ {
+		d *= 2
+		if limit > 0 && d >= limit {
+			break
+		}
+	}
+	if limit > 0 && d > limit {
+		d = limit
+	}
+	return d
+}
+
+// retryable 异常码是否可以重试
+func (c RetryConfig) retryable(code uint8) bool {
+	codes := c.RetryableExceptions
+	if len(codes) == 0 {
+		codes = DefaultRetryableExceptions
+	}
+	for _, item := range codes {
+		if item == code {
+			return true
+		}
+	}
+	return false
+}
+
+// interval 请求间隔
+func (c RetryConfig) interval() time.Duration {
+	return time.Duration(c.RequestInterval) * time.Millisecond
+}
+
+// exceptionCode 从机异常响应的异常码，不是异常响应（eg. 超时、连接断开）返回 false
+func exceptionCode(err error) (uint8, bool) {
+	for _, e := range exceptions {
+		if errors.Is(err, e.err) {
+			return e.code, true
+		}
+	}
+	return 0, false
+}
+
+// isExceptionCode 是否支持的异常码
+func isExceptionCode(code uint8) bool {
+	for _, e := range exceptions {
+		if e.code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+	"github.com/simonvetter/modbus"
+)
+
+func TestRetryConfig(t *testing.T) {
+	assert.Nil(t, RetryConfig{}.Validate())
+	assert.Nil(t, RetryConfig{RetryableExceptions: []uint8{4, 6}}.Validate())
+	assert.True(t, RetryConfig{RetryableExceptions: []uint8{7}}.Validate() != nil)
+	assert.True(t, RetryConfig{Backoff: -1}.Validate() != nil)
+
+	assert.Equal(t, DefaultMaxRetries, RetryConfig{}.retries())
+	assert.Equal(t, 0, RetryConfig{MaxRetries: -1}.retries())
+	assert.Equal(t, 5, RetryConfig{MaxRetries: 5}.retries())
+
+	assert.Equal(t, time.Duration(0), RetryConfig{}.backoff(2))
+	c := RetryConfig{Backoff: 100, MaxBackoff: 300}
+	assert.Equal(t, 100*time.Millisecond, c.backoff(0))
+	assert.Equal(t, 200*time.Millisecond, c.backoff(1))
+	assert.Equal(t, 300*time.Millisecond, c.backoff(2))
+	assert.Equal(t, 300*time.Millisecond, c.backoff(30))
+
+	assert.True(t, RetryConfig{}.retryable(6))
+	assert.False(t, RetryConfig{}.retryable(2))
+	assert.True(t, RetryConfig{RetryableExceptions: []uint8{2}}.retryable(2))
+	assert.False(t, RetryConfig{RetryableExceptions: []uint8{2}}.retryable(6))
+}
+
+func TestExecuteWithRetry(t *testing.T) {
+	newClient := func(retry RetryConfig) *RetryableModbusClient {
+		client := NewRetryableModbusClient(nil, retry.retries(), nil, nil, 1, DefaultEndianness, DefaultWordOrder)
+		client.retry = retry
+		return client
+	}
+	failing := func(calls *int, errs ...error) func() error {
+		return func() error {
+			*calls++
+			if *calls <= len(errs) {
+				return errs[*calls-1]
+			}
+			return nil
+		}
+	}
+
+	// 从机忙重试后成功，不需要重建连接
+	calls := 0
+	err := newClient(RetryConfig{}).executeWithRetry("ReadRegisters", failing(&calls, modbus.ErrServerDeviceBusy, modbus.ErrServerDeviceBusy))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+
+	// 非法地址直接返回
+	calls = 0
+	err = newClient(RetryConfig{}).executeWithRetry("ReadRegisters", failing(&calls, modbus.ErrIllegalDataAddress))
+	assert.Equal(t, modbus.ErrIllegalDataAddress, err)
+	assert.Equal(t, 1, calls)
+
+	// 配置为不可重试的异常码直接返回
+	calls = 0
+	err = newClient(RetryConfig{RetryableExceptions: []uint8{5}}).executeWithRetry("ReadRegisters", failing(&calls, modbus.ErrServerDeviceBusy))
+	assert.Equal(t, modbus.ErrServerDeviceBusy, err)
+	assert.Equal(t, 1, calls)
+
+	// 超过重试次数
+	calls = 0
+	err = newClient(RetryConfig{MaxRetries: 1}).executeWithRetry("ReadRegisters", failing(&calls, modbus.ErrServerDeviceBusy, modbus.ErrServerDeviceBusy))
+	assert.True(t, errors.Is(err, modbus.ErrServerDeviceBusy))
+	assert.Equal(t, 2, calls)
+
+	// 不重试
+	calls = 0
+	err = newClient(RetryConfig{MaxRetries: -1}).executeWithRetry("ReadRegisters", failing(&calls, modbus.ErrServerDeviceBusy))
+	assert.True(t, errors.Is(err, modbus.ErrServerDeviceBusy))
+	assert.Equal(t, 1, calls)
+
+	// 重试前等待
+	calls = 0
+	start := time.Now()
+	err = newClient(RetryConfig{Backoff: 20}).executeWithRetry("ReadRegisters", failing(&calls, modbus.ErrAcknowledge, modbus.ErrAcknowledge))
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 60*time.Millisecond)
+}
+
+func TestSharedConnPace(t *testing.T) {
+	conn := &SharedConn{}
+	var times []time.Time
+	record := func() error {
+		times = append(times, time.Now())
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, conn.pace(30*time.Millisecond, record))
+	}
+	assert.Equal(t, 3, len(times))
+	assert.True(t, times[1].Sub(times[0]) >= 30*time.Millisecond)
+	assert.True(t, times[2].Sub(times[1]) >= 30*time.Millisecond)
+
+	// 间隔为0时不等待
+	start := time.Now()
+	assert.Nil(t, conn.pace(0, record))
+	assert.True(t, time.Since(start) < 30*time.Millisecond)
+}
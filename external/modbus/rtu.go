@@ -0,0 +1,104 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goburrow/serial"
+	"github.com/simonvetter/modbus"
+)
+
+// SerialScheme RTU 串口地址前缀，eg. rtu:///dev/ttyUSB0
+const SerialScheme = "rtu://"
+
+// RS485Config RS-485 收发切换配置，仅 Linux 支持
+type RS485Config struct {
+	// Enabled 是否启用串口驱动的 RS-485 模式，由驱动通过 RTS 控制收发方向
+	Enabled bool `json:"enabled" label:"Enabled" desc:"Enable RS-485 mode of the serial driver, RTS controls the transmit direction"`
+	// DelayRtsBeforeSend 发送前 RTS 延迟，单位毫秒
+	DelayRtsBeforeSend int `json:"delayRtsBeforeSend" label:"Delay RTS Before Send" desc:"Delay RTS before send in milliseconds"`
+	// DelayRtsAfterSend 发送后 RTS 延迟，单位毫秒
+	DelayRtsAfterSend int `json:"delayRtsAfterSend" label:"Delay RTS After Send" desc:"Delay RTS after send in milliseconds"`
+	// RtsHighDuringSend 发送时 RTS 为高电平
+	RtsHighDuringSend bool `json:"rtsHighDuringSend" label:"RTS High During Send" desc:"Set RTS high during send"`
+	// RtsHighAfterSend 发送后 RTS 为高电平
+	RtsHighAfterSend bool `json:"rtsHighAfterSend" label:"RTS High After Send" desc:"Set RTS high after send"`
+	// RxDuringTx 发送时允许接收
+	RxDuringTx bool `json:"rxDuringTx" label:"Rx During Tx" desc:"Receive while transmitting"`
+}
+
+// IsSerial 是否为 RTU 串口地址
+func IsSerial(server string) bool {
+	return strings.HasPrefix(server, SerialScheme)
+}
+
+// configureRS485 设置串口的 RS-485 模式
+// 底层库打开串口时不支持 RS-485 配置，这里先按相同参数打开一次串口设置驱动的 RS-485 模式，
+// 驱动会保留该模式直到下一次修改
+func configureRS485(server string, c RtuConfig) error {
+	if !IsSerial(server) || !c.RS485.Enabled {
+		return nil
+	}
+	config := serialConfig(server, c)
+	config.RS485 = serial.RS485Config{
+		Enabled:            true,
+		DelayRtsBeforeSend: time.Duration(c.RS485.DelayRtsBeforeSend) * time.Millisecond,
+		DelayRtsAfterSend:  time.Duration(c.RS485.DelayRtsAfterSend) * time.Millisecond,
+		RtsHighDuringSend:  c.RS485.RtsHighDuringSend,
+		RtsHighAfterSend:   c.RS485.RtsHighAfterSend,
+		RxDuringTx:         c.RS485.RxDuringTx,
+	}
+	port, err := serial.Open(config)
+	if err != nil {
+		return fmt.Errorf("failed to enable rs485 mode: %w", err)
+	}
+	return port.Close()
+}
+
+// serialConfig 转换为串口配置，未配置的参数使用与底层库相同的默认值
+func serialConfig(server string, c RtuConfig) *serial.Config {
+	if c.Speed == 0 {
+		c.Speed = DefaultSpeed
+	}
+	if c.DataBits == 0 {
+		c.DataBits = DefaultDataBits
+	}
+	if c.StopBits == 0 {
+		c.StopBits = 1
+		if c.Parity == modbus.PARITY_NONE {
+			c.StopBits = 2
+		}
+	}
+	parity := "N"
+	switch c.Parity {
+	case modbus.PARITY_EVEN:
+		parity = "E"
+	case modbus.PARITY_ODD:
+		parity = "O"
+	}
+	return &serial.Config{
+		Address:  strings.TrimPrefix(server, SerialScheme),
+		BaudRate: int(c.Speed),
+		DataBits: int(c.DataBits),
+		StopBits: int(c.StopBits),
+		Parity:   parity,
+		Timeout:  10 * time.Millisecond,
+	}
+}
@@ -0,0 +1,394 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/cast"
+	"github.com/simonvetter/modbus"
+)
+
+// DataTypeString 字符串，每个寄存器2个字符
+const DataTypeString = "string"
+
+// 字节序，A 为数值的最高字节
+const (
+	ByteOrderABCD = "ABCD"
+	ByteOrderDCBA = "DCBA"
+	ByteOrderBADC = "BADC"
+	ByteOrderCDAB = "CDAB"
+)
+
+// Tag 寄存器点位映射，把一个或者多个寄存器解码为工程值
+type Tag struct {
+	// Name 点位名称，作为输出的 key，引用注册表点位时为空使用注册表中的点位名称
+	Name string `json:"name"`
+	// Ref 引用全局点位注册表中的点位，格式：tag:<名称>，配置后地址、数据类型、缩放和偏移量从注册表读取
+	// 注册表中的地址格式：[area:]address，例如 inputRegister:30、100，字符串类型为 string[寄存器数量]
+	Ref string `json:"ref,omitempty"`
+	// Area 数据区：holdingRegister, inputRegister，默认 holdingRegister
+	Area string `json:"area,omitempty"`
+	// Address 起始地址
+	Address uint16 `json:"address"`
+	// DataType 数据类型：int16, uint16, int32, uint32, float32, int64, uint64, float64, string，默认 uint16
+	DataType string `json:"dataType,omitempty"`
+	// Length 字符串占用的寄存器数量，仅 string 类型有效
+	Length uint16 `json:"length,omitempty"`
+	// ByteOrder 字节序：ABCD, DCBA, BADC, CDAB，为空使用 encodingConfig
+	ByteOrder string `json:"byteOrder,omitempty"`
+	// Scale 缩放系数，工程值 = 原始值 * scale + offset，scale 和 offset 都为 0 时输出原始值
+	Scale float64 `json:"scale,omitempty"`
+	// Offset 偏移量
+	Offset float64 `json:"offset,omitempty"`
+}
+
+// Validate 校验点位
+func (t Tag) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("modbus tag name cannot be empty, address: %d", t.Address)
+	}
+	switch t.area() {
+	case AreaHoldingRegister, AreaInputRegister:
+	default:
+		return fmt.Errorf("tag %s: unsupported modbus area: %s", t.Name, t.Area)
+	}
+	if _, _, err := ParseByteOrder(t.ByteOrder); err != nil {
+		return fmt.Errorf("tag %s: %w", t.Name, err)
+	}
+	width, err := t.Width()
+	if err != nil {
+		return fmt.Errorf("tag %s: %w", t.Name, err)
+	}
+	if width > maxReadRegisters {
+		return fmt.Errorf("tag %s: %d registers exceeds %d", t.Name, width, maxReadRegisters)
+	}
+	return nil
+}
+
+// ValidateTags 校验点位列表，点位名称不能重复
+func ValidateTags(tags []Tag) error {
+	names := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[t.Name]; ok {
+			return fmt.Errorf("duplicate modbus tag name: %s", t.Name)
+		}
+		names[t.Name] = struct{}{}
+	}
+	return nil
+}
+
+// ResolveTags 解析引用全局点位注册表的点位，返回新的点位列表，点位中配置的缩放和偏移量优先
+func ResolveTags(list []Tag) ([]Tag, error) {
+	resolved := make([]Tag, len(list))
+	for i, t := range list {
+		if t.Ref != "" {
+			address, ref, err := tags.Resolve(tags.ProtocolModbus, t.Ref)
+			if err != nil {
+				return nil, err
+			}
+			if ref == nil {
+				return nil, fmt.Errorf("invalid modbus tag reference: %s", t.Ref)
+			}
+			if err = t.applyRef(address, ref); err != nil {
+				return nil, fmt.Errorf("tag %s: %w", ref.Name, err)
+			}
+		}
+		resolved[i] = t
+	}
+	return resolved, nil
+}
+
+// applyRef 使用注册表中的点位填充地址、数据类型、缩放和偏移量
+func (t *Tag) applyRef(address string, ref *tags.Tag) error {
+	if t.Name == "" {
+		t.Name = ref.Name
+	}
+	if idx := strings.LastIndex(address, ":"); idx >= 0 {
+		t.Area, address = address[:idx], address[idx+1:]
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(address), 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid modbus address: %s", address)
+	}
+	t.Address = uint16(v)
+	if dataType := strings.ToLower(ref.DataType); strings.HasPrefix(dataType, DataTypeString+"[") && strings.HasSuffix(dataType, "]") {
+		length, err := strconv.ParseUint(dataType[len(DataTypeString)+1:len(dataType)-1], 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid string length: %s", ref.DataType)
+		}
+		t.DataType, t.Length = DataTypeString, uint16(length)
+	} else if ref.DataType != "" {
+		t.DataType = ref.DataType
+	}
+	if t.Scale == 0 && t.Offset == 0 {
+		t.Scale, t.Offset = ref.Scale, ref.Offset
+	}
+	return nil
+}
+
+// area 数据区，默认保持寄存器
+func (t Tag) area() string {
+	if t.Area == "" {
+		return AreaHoldingRegister
+	}
+	return t.Area
+}
+
+// Width 占用的寄存器数量
+func (t Tag) Width() (uint16, error) {
+	if strings.ToLower(t.DataType) == DataTypeString {
+		if t.Length == 0 {
+			return 0, fmt.Errorf("string length cannot be 0")
+		}
+		return t.Length, nil
+	}
+	return RegisterWidth(t.DataType)
+}
+
+// Decode 从寄存器原始字节（线上字节顺序）解码工程值
+func (t Tag) Decode(raw []byte, encoding EncodingConfig) (interface{}, error) {
+	endianness, wordOrder, _ := ParseByteOrder(t.ByteOrder)
+	if t.ByteOrder == "" {
+		endianness, wordOrder = modbus.Endianness(encoding.Endianness), modbus.WordOrder(encoding.WordOrder)
+	}
+	if strings.ToLower(t.DataType) == DataTypeString {
+		return decodeString(raw, endianness), nil
+	}
+	values, err := DecodeRegisters(raw, t.DataType, endianness, wordOrder)
+	if err != nil {
+		return nil, err
+	}
+	if t.Scale == 0 && t.Offset == 0 {
+		return values[0], nil
+	}
+	scale := t.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return toFloat64(values[0])*scale + t.Offset, nil
+}
+
+// Encode 把工程值编码为寄存器原始字节（线上字节顺序），是 Decode 的逆过程
+// 配置了 scale 或 offset 时先换算为原始值，整数类型四舍五入
+func (t Tag) Encode(value interface{}, encoding EncodingConfig) ([]byte, error) {
+	endianness, wordOrder, _ := ParseByteOrder(t.ByteOrder)
+	if t.ByteOrder == "" {
+		endianness, wordOrder = modbus.Endianness(encoding.Endianness), modbus.WordOrder(encoding.WordOrder)
+	}
+	width, err := t.Width()
+	if err != nil {
+		return nil, err
+	}
+	dataType := strings.ToLower(t.DataType)
+	if dataType == DataTypeString {
+		return encodeString(cast.ToString(value), int(width)*2, endianness), nil
+	}
+	v, err := cast.ToFloat64E(value)
+	if err != nil {
+		return nil, fmt.Errorf("tag %s: %w", t.Name, err)
+	}
+	if t.Scale != 0 || t.Offset != 0 {
+		scale := t.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		v = (v - t.Offset) / scale
+	}
+	var u uint64
+	switch dataType {
+	case "", DataTypeUint16:
+		u = uint64(uint16(int64(math.Round(v))))
+	case DataTypeInt16:
+		u = uint64(uint16(int16(math.Round(v))))
+	case DataTypeUint32:
+		u = uint64(uint32(int64(math.Round(v))))
+	case DataTypeInt32:
+		u = uint64(uint32(int32(math.Round(v))))
+	case DataTypeFloat32:
+		u = uint64(math.Float32bits(float32(v)))
+	case DataTypeUint64:
+		u = uint64(math.Round(v))
+	case DataTypeInt64:
+		u = uint64(int64(math.Round(v)))
+	case DataTypeFloat64:
+		u = math.Float64bits(v)
+	}
+	return encodeWords(u, int(width), endianness, wordOrder), nil
+}
+
+// ParseByteOrder 把字节序转换为底层库的 endianness 和 wordOrder，不区分大小写
+//   - ABCD: 大端序，高字在前
+//   - DCBA: 小端序，低字在前
+//   - BADC: 字内字节交换，高字在前
+//   - CDAB: 字交换，低字在前
+func ParseByteOrder(s string) (modbus.Endianness, modbus.WordOrder, error) {
+	switch strings.ToUpper(s) {
+	case "", ByteOrderABCD:
+		return modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST, nil
+	case ByteOrderDCBA:
+		return modbus.LITTLE_ENDIAN, modbus.LOW_WORD_FIRST, nil
+	case ByteOrderBADC:
+		return modbus.LITTLE_ENDIAN, modbus.HIGH_WORD_FIRST, nil
+	case ByteOrderCDAB:
+		return modbus.BIG_ENDIAN, modbus.LOW_WORD_FIRST, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported byte order: %s", s)
+	}
+}
+
+// TagPlan 点位读取计划，把点位合并为尽可能少的读取请求
+type TagPlan struct {
+	blocks []*tagBlock
+	size   int
+}
+
+// NewTagPlan 创建点位读取计划
+// 同一数据区中地址间隔不超过 maxGap 个寄存器的点位合并为一次读取，每次读取不超过协议限制的 125 个寄存器
+// 间隔中的寄存器也会被读取，设备不允许读取未定义地址时 maxGap 应该为 0
+func NewTagPlan(tags []Tag, maxGap uint16) *TagPlan {
+	return &TagPlan{blocks: groupTags(tags, maxGap), size: len(tags)}
+}
+
+// Requests 读取请求数量
+func (p *TagPlan) Requests() int {
+	return len(p.blocks)
+}
+
+// ReadTags 使用共享连接按读取计划读取点位，返回以点位名称为 key 的工程值
+func ReadTags(conn *SharedConn, logger types.Logger, unitId uint8, encoding EncodingConfig, retry RetryConfig, plan *TagPlan) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, plan.size)
+	err := withClient(conn, logger, unitId, encoding, retry, func(client *RetryableModbusClient) error {
+		for _, block := range plan.blocks {
+			regType := modbus.HOLDING_REGISTER
+			if block.area == AreaInputRegister {
+				regType = modbus.INPUT_REGISTER
+			}
+			raw, err := client.ReadRawBytes(block.address, block.quantity*2, regType)
+			if err != nil {
+				return fmt.Errorf("read %s %d: %w", block.area, block.address, err)
+			}
+			for _, t := range block.tags {
+				width, _ := t.Width()
+				start := int(t.Address-block.address) * 2
+				v, err := t.Decode(raw[start:start+int(width)*2], encoding)
+				if err != nil {
+					return fmt.Errorf("tag %s: %w", t.Name, err)
+				}
+				values[t.Name] = v
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// tagBlock 合并后的一次读取
+type tagBlock struct {
+	area     string
+	address  uint16
+	quantity uint16
+	tags     []Tag
+}
+
+// groupTags 按数据区和地址合并点位，每次读取不超过协议限制
+// 点位按地址排序后贪心合并，得到的读取次数最少
+func groupTags(tags []Tag, maxGap uint16) []*tagBlock {
+	sorted := append([]Tag(nil), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].area() != sorted[j].area() {
+			return sorted[i].area() < sorted[j].area()
+		}
+		return sorted[i].Address < sorted[j].Address
+	})
+	var blocks []*tagBlock
+	var current *tagBlock
+	for _, t := range sorted {
+		width, _ := t.Width()
+		end := uint32(t.Address) + uint32(width)
+		if current != nil && current.area == t.area() && uint32(t.Address) <= uint32(current.address)+uint32(current.quantity)+uint32(maxGap) &&
+			end-uint32(current.address) <= maxReadRegisters {
+			if end > uint32(current.address)+uint32(current.quantity) {
+				current.quantity = uint16(end - uint32(current.address))
+			}
+			current.tags = append(current.tags, t)
+			continue
+		}
+		current = &tagBlock{area: t.area(), address: t.Address, quantity: width, tags: []Tag{t}}
+		blocks = append(blocks, current)
+	}
+	return blocks
+}
+
+// decodeString 解码字符串，小端序时交换每个寄存器的两个字节，去掉末尾的空字符和空格
+func decodeString(raw []byte, endianness modbus.Endianness) string {
+	b := make([]byte, len(raw))
+	copy(b, raw)
+	if endianness == modbus.LITTLE_ENDIAN {
+		for i := 0; i+1 < len(b); i += 2 {
+			b[i], b[i+1] = b[i+1], b[i]
+		}
+	}
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+// encodeString 编码字符串，不足 size 字节补空字符，超出截断
+func encodeString(s string, size int, endianness modbus.Endianness) []byte {
+	b := make([]byte, size)
+	copy(b, s)
+	if endianness == modbus.LITTLE_ENDIAN {
+		for i := 0; i+1 < len(b); i += 2 {
+			b[i], b[i+1] = b[i+1], b[i]
+		}
+	}
+	return b
+}
+
+func toFloat64(v interface{}) float64 {
+	switch n := v.(type) {
+	case uint16:
+		return float64(n)
+	case int16:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
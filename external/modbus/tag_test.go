@@ -0,0 +1,164 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestTagDecode(t *testing.T) {
+	encoding := EncodingConfig{Endianness: uint(DefaultEndianness), WordOrder: uint(DefaultWordOrder)}
+	// 0x12345678 在不同字节序下的线上字节
+	cases := map[string][]byte{
+		ByteOrderABCD: {0x12, 0x34, 0x56, 0x78},
+		ByteOrderDCBA: {0x78, 0x56, 0x34, 0x12},
+		ByteOrderBADC: {0x34, 0x12, 0x78, 0x56},
+		ByteOrderCDAB: {0x56, 0x78, 0x12, 0x34},
+	}
+	for order, raw := range cases {
+		v, err := Tag{Name: "v", DataType: "uint32", ByteOrder: order}.Decode(raw, encoding)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(0x12345678), v, order)
+	}
+
+	// 缩放和偏移
+	v, err := Tag{Name: "v", DataType: "int16", Scale: 0.1, Offset: -40}.Decode([]byte{0x01, 0xF4}, encoding)
+	assert.Nil(t, err)
+	assert.True(t, math.Abs(v.(float64)-10) < 1e-9)
+
+	// 字符串
+	v, err = Tag{Name: "v", DataType: "string", Length: 3}.Decode([]byte("PLC1\x00\x00"), encoding)
+	assert.Nil(t, err)
+	assert.Equal(t, "PLC1", v)
+	v, err = Tag{Name: "v", DataType: "string", Length: 2, ByteOrder: ByteOrderBADC}.Decode([]byte("LP1C"), encoding)
+	assert.Nil(t, err)
+	assert.Equal(t, "PLC1", v)
+
+	assert.NotNil(t, ValidateTags([]Tag{{Name: "a", ByteOrder: "ACBD"}}))
+	assert.NotNil(t, ValidateTags([]Tag{{Name: "a", DataType: "string"}}))
+	assert.NotNil(t, ValidateTags([]Tag{{Name: "a"}, {Name: "a", Address: 1}}))
+	assert.NotNil(t, ValidateTags([]Tag{{Name: "a", Area: AreaCoil}}))
+}
+
+func TestResolveTags(t *testing.T) {
+	registry := tags.NewRegistry()
+	assert.Nil(t, registry.Register(
+		tags.Tag{Name: "boiler.temp", Protocol: tags.ProtocolModbus, Address: "inputRegister:30", DataType: "int16", Scale: 0.1},
+		tags.Tag{Name: "boiler.model", Protocol: tags.ProtocolModbus, Address: "40", DataType: "string[4]"},
+		tags.Tag{Name: "boiler.bad", Protocol: tags.ProtocolModbus, Address: "holdingRegister:x"},
+		tags.Tag{Name: "boiler.node", Protocol: tags.ProtocolOPCUA, Address: "ns=2;s=Temp"},
+	))
+	tags.SetDefault(registry)
+	defer tags.SetDefault(tags.NewRegistry())
+
+	resolved, err := ResolveTags([]Tag{
+		{Ref: "tag:boiler.temp"},
+		{Name: "model", Ref: "tag:boiler.model"},
+		{Name: "raw", Address: 1},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ValidateTags(resolved))
+	assert.Equal(t, Tag{Name: "boiler.temp", Ref: "tag:boiler.temp", Area: AreaInputRegister, Address: 30, DataType: "int16", Scale: 0.1}, resolved[0])
+	assert.Equal(t, Tag{Name: "model", Ref: "tag:boiler.model", Address: 40, DataType: DataTypeString, Length: 4}, resolved[1])
+	assert.Equal(t, Tag{Name: "raw", Address: 1}, resolved[2])
+
+	_, err = ResolveTags([]Tag{{Ref: "tag:unknown"}})
+	assert.NotNil(t, err)
+	_, err = ResolveTags([]Tag{{Ref: "tag:boiler.bad"}})
+	assert.NotNil(t, err)
+	// 协议不匹配
+	_, err = ResolveTags([]Tag{{Ref: "tag:boiler.node"}})
+	assert.NotNil(t, err)
+}
+
+func TestGroupTags(t *testing.T) {
+	blocks := groupTags([]Tag{
+		{Name: "c", Address: 2, DataType: "float32"},
+		{Name: "a", Address: 0},
+		{Name: "b", Address: 1},
+		{Name: "d", Address: 10},
+		{Name: "e", Area: AreaInputRegister, Address: 0},
+	}, 0)
+	assert.Equal(t, 3, len(blocks))
+	assert.Equal(t, uint16(0), blocks[0].address)
+	assert.Equal(t, uint16(4), blocks[0].quantity)
+	assert.Equal(t, 3, len(blocks[0].tags))
+	assert.Equal(t, uint16(10), blocks[1].address)
+	assert.Equal(t, AreaInputRegister, blocks[2].area)
+
+	// 间隔不超过 maxGap 的点位合并
+	tags := []Tag{{Name: "a", Address: 0}, {Name: "b", Address: 10}, {Name: "c", Address: 30}}
+	assert.Equal(t, 3, NewTagPlan(tags, 0).Requests())
+	assert.Equal(t, 2, NewTagPlan(tags, 9).Requests())
+	assert.Equal(t, 1, NewTagPlan(tags, 19).Requests())
+
+	// 不超过 125 个寄存器
+	tags = []Tag{{Name: "a", Address: 0}, {Name: "b", Address: 123, DataType: "float32"}, {Name: "c", Address: 124, DataType: "float32"}}
+	blocks = groupTags(tags, 200)
+	assert.Equal(t, 2, len(blocks))
+	assert.Equal(t, uint16(125), blocks[0].quantity)
+	assert.Equal(t, uint16(124), blocks[1].address)
+}
+
+func TestReadNodeTags(t *testing.T) {
+	f32 := math.Float32bits(21.5)
+	handler := &testHandler{
+		holding:   []uint16{uint16(f32), uint16(f32 >> 16), 5, 0x504C, 0x4331},
+		inputRegs: []uint16{0xFFFF},
+	}
+	server := startTestServer(t, "tcp://localhost:15023", handler)
+	defer server.Stop()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+
+	node, err := test.CreateAndInitNode("x/modbusRead", types.Configuration{
+		"server": "tcp://localhost:15023",
+		"tags": []map[string]interface{}{
+			{"name": "temperature", "address": 0, "dataType": "float32", "byteOrder": "CDAB"},
+			{"name": "pressure", "address": 2, "dataType": "uint16", "scale": 0.5},
+			{"name": "model", "address": 3, "dataType": "string", "length": 2},
+			{"name": "offset", "area": "inputRegister", "address": 0, "dataType": "int16"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var values map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 21.5, values["temperature"])
+		assert.Equal(t, 2.5, values["pressure"])
+		assert.Equal(t, "PLC1", values["model"])
+		assert.Equal(t, float64(-1), values["offset"])
+	})
+}
@@ -0,0 +1,390 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package nmea 提供 GPS/GNSS NMEA 0183 语句解码节点
+// 解析串口或者 TCP 端点收到的 RMC、GGA、VTG 语句，合并为包含位置、速度、航向和定位质量的 JSON，
+// 可以按定位状态、定位质量、卫星数和 HDOP 过滤无效的定位，适用于车载网关和移动资产定位
+package nmea
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持的语句类型
+const (
+	TypeRMC = "RMC"
+	TypeGGA = "GGA"
+	TypeVTG = "VTG"
+)
+
+// knotToKmh 1 节等于 1.852 公里每小时
+const knotToKmh = 1.852
+
+var (
+	// ErrChecksum 校验错误
+	ErrChecksum = errors.New("nmea checksum mismatch")
+	// ErrNoSentence 没有支持的语句
+	ErrNoSentence = errors.New("no supported nmea sentence")
+)
+
+// Sentence NMEA 语句
+type Sentence struct {
+	// Talker 发送者，eg. GP、GN、GL、BD
+	Talker string
+	// Type 语句类型，eg. RMC、GGA
+	Type string
+	// Fields 语句类型之后的字段
+	Fields []string
+}
+
+// field 获取字段，不存在返回空字符串
+func (s Sentence) field(i int) string {
+	if i < len(s.Fields) {
+		return strings.TrimSpace(s.Fields[i])
+	}
+	return ""
+}
+
+// ParseSentence 解析一条语句，eg. $GPRMC,...*hh。有校验时检查校验，requireChecksum 为 true 时没有校验返回错误
+func ParseSentence(line string, requireChecksum bool) (Sentence, error) {
+	line = strings.TrimSpace(line)
+	if len(line) < 7 || line[0] != '$' {
+		return Sentence{}, fmt.Errorf("invalid nmea sentence: %q", line)
+	}
+	body := line[1:]
+	if i := strings.LastIndexByte(body, '*'); i >= 0 {
+		expected, err := strconv.ParseUint(body[i+1:], 16, 8)
+		if err != nil || len(body)-i-1 != 2 {
+			return Sentence{}, fmt.Errorf("invalid nmea checksum: %q", line)
+		}
+		body = body[:i]
+		var sum byte
+		for j := 0; j < len(body); j++ {
+			sum ^= body[j]
+		}
+		if sum != byte(expected) {
+			return Sentence{}, ErrChecksum
+		}
+	} else if requireChecksum {
+		return Sentence{}, fmt.Errorf("nmea checksum missing: %q", line)
+	}
+	fields := strings.Split(body, ",")
+	address := fields[0]
+	if len(address) < 5 {
+		return Sentence{}, fmt.Errorf("invalid nmea address: %q", address)
+	}
+	// 私有语句 $P 开头，没有 talker
+	if address[0] == 'P' {
+		return Sentence{Talker: "P", Type: address[1:], Fields: fields[1:]}, nil
+	}
+	return Sentence{Talker: address[:2], Type: address[2:], Fields: fields[1:]}, nil
+}
+
+// Fix 合并 RMC、GGA、VTG 得到的定位结果，语句中没有的字段为空
+type Fix struct {
+	// Talker 发送者，eg. GP、GN
+	Talker string `json:"talker"`
+	// Sentences 解析的语句类型
+	Sentences []string `json:"sentences"`
+	// Time UTC 时间，RMC 带日期时为 RFC3339，否则为 hh:mm:ss.sss
+	Time string `json:"time,omitempty"`
+	// Valid RMC 定位状态 A 为有效
+	Valid *bool `json:"valid,omitempty"`
+	// Latitude、Longitude 十进制度，南纬和西经为负
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	// Altitude 海拔，单位米
+	Altitude *float64 `json:"altitude,omitempty"`
+	// GeoidSeparation 大地水准面差距，单位米
+	GeoidSeparation *float64 `json:"geoidSeparation,omitempty"`
+	// SpeedKnots、SpeedKmh 对地速度
+	SpeedKnots *float64 `json:"speedKnots,omitempty"`
+	SpeedKmh   *float64 `json:"speedKmh,omitempty"`
+	// Course 真北航向，单位度
+	Course *float64 `json:"course,omitempty"`
+	// MagneticVariation 磁偏角，西偏为负
+	MagneticVariation *float64 `json:"magneticVariation,omitempty"`
+	// FixQuality GGA 定位质量：0 无效，1 GPS，2 DGPS，4 RTK 固定解，5 RTK 浮点解，6 推算
+	FixQuality *int `json:"fixQuality,omitempty"`
+	// Satellites 使用的卫星数
+	Satellites *int `json:"satellites,omitempty"`
+	// Hdop 水平精度因子
+	Hdop *float64 `json:"hdop,omitempty"`
+	// Mode NMEA 2.3 的模式：A 自主，D 差分，E 推算，N 无效
+	Mode string `json:"mode,omitempty"`
+}
+
+// Decode 解析多行语句并合并为定位结果，不支持的语句忽略，没有 RMC、GGA、VTG 语句返回 ErrNoSentence
+func Decode(text string, requireChecksum bool) (*Fix, error) {
+	fix := &Fix{}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		s, err := ParseSentence(line, requireChecksum)
+		if err != nil {
+			return nil, err
+		}
+		if err = fix.apply(s); err != nil {
+			return nil, fmt.Errorf("%s%s: %w", s.Talker, s.Type, err)
+		}
+	}
+	if len(fix.Sentences) == 0 {
+		return nil, ErrNoSentence
+	}
+	return fix, nil
+}
+
+// apply 合并一条语句，不支持的语句忽略
+func (f *Fix) apply(s Sentence) error {
+	var err error
+	switch s.Type {
+	case TypeRMC:
+		err = f.applyRMC(s)
+	case TypeGGA:
+		err = f.applyGGA(s)
+	case TypeVTG:
+		err = f.applyVTG(s)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f.Talker = s.Talker
+	f.Sentences = append(f.Sentences, s.Type)
+	return nil
+}
+
+func (f *Fix) applyRMC(s Sentence) error {
+	valid := s.field(1) == "A"
+	f.Valid = &valid
+	if err := f.setPosition(s.field(2), s.field(3), s.field(4), s.field(5)); err != nil {
+		return err
+	}
+	if t, err := timeOf(s.field(0), s.field(8)); err != nil {
+		return err
+	} else if t != "" {
+		f.Time = t
+	}
+	if err := f.setSpeed(s.field(6), ""); err != nil {
+		return err
+	}
+	course, err := floatOf(s.field(7))
+	if err != nil {
+		return err
+	}
+	if course != nil {
+		f.Course = course
+	}
+	variation, err := floatOf(s.field(9))
+	if err != nil {
+		return err
+	}
+	if variation != nil {
+		if s.field(10) == "W" {
+			*variation = -*variation
+		}
+		f.MagneticVariation = variation
+	}
+	if mode := s.field(11); mode != "" {
+		f.Mode = mode[:1]
+	}
+	return nil
+}
+
+func (f *Fix) applyGGA(s Sentence) error {
+	if err := f.setPosition(s.field(1), s.field(2), s.field(3), s.field(4)); err != nil {
+		return err
+	}
+	// RMC 的时间带日期，优先使用
+	if f.Time == "" || !strings.Contains(f.Time, "T") {
+		if t, err := timeOf(s.field(0), ""); err != nil {
+			return err
+		} else if t != "" {
+			f.Time = t
+		}
+	}
+	var err error
+	if f.FixQuality, err = intOf(s.field(5)); err != nil {
+		return err
+	}
+	if f.Satellites, err = intOf(s.field(6)); err != nil {
+		return err
+	}
+	if f.Hdop, err = floatOf(s.field(7)); err != nil {
+		return err
+	}
+	if f.Altitude, err = floatOf(s.field(8)); err != nil {
+		return err
+	}
+	f.GeoidSeparation, err = floatOf(s.field(10))
+	return err
+}
+
+func (f *Fix) applyVTG(s Sentence) error {
+	// NMEA 2.0 之前的格式没有 T、M、N、K 单位字段
+	track, knots, kmh, mode := s.field(0), s.field(4), s.field(6), s.field(8)
+	if s.field(1) != "T" {
+		track, knots, kmh, mode = s.field(0), s.field(2), s.field(3), ""
+	}
+	course, err := floatOf(track)
+	if err != nil {
+		return err
+	}
+	if course != nil {
+		f.Course = course
+	}
+	if err = f.setSpeed(knots, kmh); err != nil {
+		return err
+	}
+	if mode != "" {
+		f.Mode = mode[:1]
+	}
+	return nil
+}
+
+// setPosition 解析 ddmm.mmmm 格式的纬度和 dddmm.mmmm 格式的经度，为空时不修改
+func (f *Fix) setPosition(lat, ns, lon, ew string) error {
+	if lat == "" || lon == "" {
+		return nil
+	}
+	latitude, err := degreesOf(lat, 2)
+	if err != nil || latitude > 90 {
+		return fmt.Errorf("invalid latitude: %s", lat)
+	}
+	longitude, err := degreesOf(lon, 3)
+	if err != nil || longitude > 180 {
+		return fmt.Errorf("invalid longitude: %s", lon)
+	}
+	if ns == "S" {
+		latitude = -latitude
+	}
+	if ew == "W" {
+		longitude = -longitude
+	}
+	f.Latitude, f.Longitude = &latitude, &longitude
+	return nil
+}
+
+// setSpeed 设置速度，只有一个单位时换算另一个
+func (f *Fix) setSpeed(knots, kmh string) error {
+	k, err := floatOf(knots)
+	if err != nil {
+		return err
+	}
+	h, err := floatOf(kmh)
+	if err != nil {
+		return err
+	}
+	switch {
+	case k != nil && h == nil:
+		v := round(*k*knotToKmh, 3)
+		h = &v
+	case k == nil && h != nil:
+		v := round(*h/knotToKmh, 3)
+		k = &v
+	case k == nil:
+		return nil
+	}
+	f.SpeedKnots, f.SpeedKmh = k, h
+	return nil
+}
+
+// degreesOf 度分格式转换为十进制度，digits 为度的位数
+func degreesOf(s string, digits int) (float64, error) {
+	if len(s) < digits+2 {
+		return 0, fmt.Errorf("invalid coordinate: %s", s)
+	}
+	deg, err := strconv.Atoi(s[:digits])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.ParseFloat(s[digits:], 64)
+	if err != nil || minutes >= 60 {
+		return 0, fmt.Errorf("invalid coordinate: %s", s)
+	}
+	return round(float64(deg)+minutes/60, 7), nil
+}
+
+// timeOf 解析 hhmmss.ss 和 ddmmyy，有日期时返回 RFC3339，否则返回 hh:mm:ss.sss，时间为空返回空字符串
+func timeOf(hms, dmy string) (string, error) {
+	if hms == "" {
+		return "", nil
+	}
+	if len(hms) < 6 {
+		return "", fmt.Errorf("invalid time: %s", hms)
+	}
+	h, err1 := strconv.Atoi(hms[0:2])
+	m, err2 := strconv.Atoi(hms[2:4])
+	sec, err3 := strconv.ParseFloat(hms[4:], 64)
+	if err1 != nil || err2 != nil || err3 != nil || h > 23 || m > 59 || sec >= 61 {
+		return "", fmt.Errorf("invalid time: %s", hms)
+	}
+	ns := int(math.Round((sec-math.Floor(sec))*1e3)) * int(time.Millisecond)
+	if dmy == "" {
+		return fmt.Sprintf("%02d:%02d:%06.3f", h, m, sec), nil
+	}
+	if len(dmy) != 6 {
+		return "", fmt.Errorf("invalid date: %s", dmy)
+	}
+	day, err1 := strconv.Atoi(dmy[0:2])
+	month, err2 := strconv.Atoi(dmy[2:4])
+	year, err3 := strconv.Atoi(dmy[4:6])
+	if err1 != nil || err2 != nil || err3 != nil || day < 1 || day > 31 || month < 1 || month > 12 {
+		return "", fmt.Errorf("invalid date: %s", dmy)
+	}
+	// 两位年份 80 之前为 20xx
+	if year < 80 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	t := time.Date(year, time.Month(month), day, h, m, int(sec), ns, time.UTC)
+	return t.Format("2006-01-02T15:04:05.000Z07:00"), nil
+}
+
+func floatOf(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %s", s)
+	}
+	return &v, nil
+}
+
+func intOf(s string) (*int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer: %s", s)
+	}
+	return &v, nil
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nmea
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// ErrNoFix 定位无效或者定位质量没有达到要求
+var ErrNoFix = errors.New("no valid gps fix")
+
+func init() {
+	_ = rulego.Registry.Register(&DecodeNode{})
+}
+
+// DecodeConfiguration NMEA 解码节点配置
+type DecodeConfiguration struct {
+	// RequireChecksum 要求语句带 *hh 校验
+	RequireChecksum bool `json:"requireChecksum" label:"Require Checksum" desc:"Reject sentences without the *hh checksum"`
+	// RequireValid 丢弃 RMC 状态为 V 或者模式为 N 的定位
+	RequireValid bool `json:"requireValid" label:"Require Valid" desc:"Reject fixes whose RMC status is V or mode is N"`
+	// MinFixQuality 最低的 GGA 定位质量，eg. 1 GPS、2 DGPS、4 RTK 固定解，0 不检查
+	MinFixQuality int `json:"minFixQuality" label:"Min Fix Quality" desc:"Minimum GGA fix quality, eg. 1 GPS, 2 DGPS, 4 RTK fixed. 0 disables"`
+	// MinSatellites 最少的卫星数，0 不检查
+	MinSatellites int `json:"minSatellites" label:"Min Satellites" desc:"Minimum satellites in use from GGA, 0 disables"`
+	// MaxHdop 最大的水平精度因子，0 不检查
+	MaxHdop float64 `json:"maxHdop" label:"Max HDOP" desc:"Maximum horizontal dilution of precision from GGA, 0 disables"`
+}
+
+// DecodeNode 解析 msg.Data 中的 NMEA 0183 语句，支持 RMC、GGA、VTG，多行语句合并为一个定位结果，其他语句忽略。
+// 解码结果重新赋值到msg.Data，例如：
+//
+//	{"talker": "GN", "sentences": ["RMC", "GGA"], "time": "2025-10-16T08:00:03.000Z", "valid": true,
+//	 "latitude": 31.2304, "longitude": 121.4737, "altitude": 12.5, "speedKnots": 10.2, "speedKmh": 18.89,
+//	 "course": 84.4, "fixQuality": 1, "satellites": 9, "hdop": 0.9}
+//
+// 解码成功且定位满足质量要求，流转到`Success`链；解析失败或者定位被过滤，流转到`Failure`链
+type DecodeNode struct {
+	//节点配置
+	Config DecodeConfiguration
+}
+
+// Type 返回组件类型
+func (x *DecodeNode) Type() string {
+	return "x/nmeaDecode"
+}
+
+// New 默认参数
+func (x *DecodeNode) New() types.Node {
+	return &DecodeNode{
+		Config: DecodeConfiguration{
+			RequireValid:  true,
+			MinFixQuality: 1,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DecodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if x.Config.MinFixQuality < 0 || x.Config.MinSatellites < 0 || x.Config.MaxHdop < 0 {
+		return errors.New("nmea fix filter must not be negative")
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *DecodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	fix, err := Decode(msg.GetData(), x.Config.RequireChecksum)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if err = x.check(fix); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(fix)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// check 按配置过滤定位，只检查语句中有的字段
+func (x *DecodeNode) check(fix *Fix) error {
+	if x.Config.RequireValid {
+		if fix.Valid != nil && !*fix.Valid {
+			return fmt.Errorf("%w: status void", ErrNoFix)
+		}
+		if fix.Mode == "N" {
+			return fmt.Errorf("%w: mode not valid", ErrNoFix)
+		}
+	}
+	if fix.FixQuality != nil && *fix.FixQuality < x.Config.MinFixQuality {
+		return fmt.Errorf("%w: fix quality %d", ErrNoFix, *fix.FixQuality)
+	}
+	if fix.Satellites != nil && *fix.Satellites < x.Config.MinSatellites {
+		return fmt.Errorf("%w: %d satellites", ErrNoFix, *fix.Satellites)
+	}
+	if x.Config.MaxHdop > 0 && fix.Hdop != nil && *fix.Hdop > x.Config.MaxHdop {
+		return fmt.Errorf("%w: hdop %v", ErrNoFix, *fix.Hdop)
+	}
+	return nil
+}
+
+// Destroy 销毁组件
+func (x *DecodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *DecodeNode) Desc() string {
+	return "Decode NMEA 0183 RMC, GGA and VTG sentences into position, speed and fix quality JSON, filtering invalid or poor fixes. Routes to Success/Failure"
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nmea
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDecodeNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DecodeNode{})
+
+	_, err := test.CreateAndInitNode("x/nmeaDecode", types.Configuration{"maxHdop": -1}, Registry)
+	assert.NotNil(t, err)
+	node, err := test.CreateAndInitNode("x/nmeaDecode", types.Configuration{}, Registry)
+	assert.Nil(t, err)
+	strictNode, err := test.CreateAndInitNode("x/nmeaDecode", types.Configuration{
+		"requireChecksum": true,
+		"minFixQuality":   2,
+		"minSatellites":   6,
+		"maxHdop":         2,
+	}, Registry)
+	assert.Nil(t, err)
+
+	msg := func(data string) []test.Msg {
+		return []test.Msg{{MetaData: types.NewMetadata(), DataType: types.TEXT, MsgType: "SERIAL", Data: data, AfterSleep: 10 * time.Millisecond}}
+	}
+	gga := "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47"
+	test.NodeOnMsg(t, node, msg(gga), func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.JSON, msg.DataType)
+		var fix map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &fix))
+		assert.Equal(t, 48.1173, fix["latitude"])
+		assert.Equal(t, 545.4, fix["altitude"])
+		assert.Equal(t, float64(8), fix["satellites"])
+		_, ok := fix["speedKnots"]
+		assert.False(t, ok)
+	})
+	// GPS 定位质量低于 DGPS
+	test.NodeOnMsg(t, strictNode, msg(gga), func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+		assert.True(t, errors.Is(err, ErrNoFix))
+	})
+	test.NodeOnMsg(t, node, msg("$GPRMC,235947,V,,,,,,,161025,,,N*5C"), func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+		assert.True(t, errors.Is(err, ErrNoFix))
+	})
+	test.NodeOnMsg(t, strictNode, msg("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K,A"), func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+	})
+	test.NodeOnMsg(t, node, msg("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K,A"), func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nmea
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseSentence(t *testing.T) {
+	s, err := ParseSentence("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A\r\n", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "GP", s.Talker)
+	assert.Equal(t, TypeRMC, s.Type)
+	assert.Equal(t, 11, len(s.Fields))
+
+	_, err = ParseSentence("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6B", false)
+	assert.True(t, errors.Is(err, ErrChecksum))
+	_, err = ParseSentence("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K", true)
+	assert.NotNil(t, err)
+	s, err = ParseSentence("$PGRME,15.0,M,45.0,M,25.0,M", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "GRME", s.Type)
+	for _, line := range []string{"GPRMC,1", "$GP", "$GPRMC*ZZ", "$GPRMC,1*6"} {
+		_, err = ParseSentence(line, false)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	fix, err := Decode("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A\r\n"+
+		"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74\r\n"+
+		"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47\r\n", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "GP", fix.Talker)
+	assert.Equal(t, []string{TypeRMC, TypeGGA}, fix.Sentences)
+	assert.Equal(t, "1994-03-23T12:35:19.000Z", fix.Time)
+	assert.True(t, *fix.Valid)
+	assert.Equal(t, 48.1173, *fix.Latitude)
+	assert.Equal(t, 11.5166667, *fix.Longitude)
+	assert.Equal(t, 22.4, *fix.SpeedKnots)
+	assert.Equal(t, 41.485, *fix.SpeedKmh)
+	assert.Equal(t, 84.4, *fix.Course)
+	assert.Equal(t, -3.1, *fix.MagneticVariation)
+	assert.Equal(t, 1, *fix.FixQuality)
+	assert.Equal(t, 8, *fix.Satellites)
+	assert.Equal(t, 0.9, *fix.Hdop)
+	assert.Equal(t, 545.4, *fix.Altitude)
+	assert.Equal(t, 46.9, *fix.GeoidSeparation)
+
+	fix, err = Decode("$GNRMC,080003.50,A,3113.8240,N,12128.4220,E,10.2,84.4,161025,,,D*43", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "2025-10-16T08:00:03.500Z", fix.Time)
+	assert.Equal(t, 31.2304, *fix.Latitude)
+	assert.Equal(t, 121.4737, *fix.Longitude)
+	assert.Equal(t, "D", fix.Mode)
+	assert.Nil(t, fix.MagneticVariation)
+
+	fix, err = Decode("$GPGGA,080004.00,,,,,0,00,99.99,,,,,,*6A", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "08:00:04.000", fix.Time)
+	assert.Nil(t, fix.Latitude)
+	assert.Equal(t, 0, *fix.FixQuality)
+
+	fix, err = Decode("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K,A*25", true)
+	assert.Nil(t, err)
+	assert.Equal(t, 54.7, *fix.Course)
+	assert.Equal(t, 5.5, *fix.SpeedKnots)
+	assert.Equal(t, 10.2, *fix.SpeedKmh)
+	assert.Equal(t, "A", fix.Mode)
+	fix, err = Decode("$GPVTG,054.7,034.4,005.5,010.2*54", true)
+	assert.Nil(t, err)
+	assert.Equal(t, 10.2, *fix.SpeedKmh)
+	assert.Equal(t, "", fix.Mode)
+
+	fix, err = Decode("$GPRMC,235947,V,,,,,,,161025,,,N*5C", true)
+	assert.Nil(t, err)
+	assert.False(t, *fix.Valid)
+	assert.Equal(t, "N", fix.Mode)
+
+	_, err = Decode("$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74", true)
+	assert.True(t, errors.Is(err, ErrNoSentence))
+	_, err = Decode("$GPGGA,123519,9907.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,", false)
+	assert.NotNil(t, err)
+	_, err = Decode("$GPRMC,126519,A,4807.038,N,01131.000,E,022.4,084.4,230394,,", false)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,332 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package obd 提供通过 ELM327 兼容适配器读取车辆 OBD-II 数据的节点
+// 适配器通过串口（蓝牙、USB）或者 TCP（WiFi 适配器）连接，节点按定时触发查询模式 01 的标准 PID，
+// 例如转速、车速、冷却液温度，以及模式 03 的故障码，解码后输出 JSON，适用于车队远程信息处理
+package obd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// readTick 单次读取的超时，用于检查总超时
+const readTick = 100 * time.Millisecond
+
+// prompt ELM327 命令结束后输出的提示符
+const prompt = '>'
+
+var (
+	// ErrNoData 车辆没有响应请求，通常是 PID 不支持
+	ErrNoData = errors.New("obd no data")
+	// ErrTimeout 等待适配器提示符超时
+	ErrTimeout = errors.New("elm327 response timeout")
+)
+
+// AdapterError 适配器返回的错误，eg. UNABLE TO CONNECT、CAN ERROR、BUS INIT: ...ERROR
+type AdapterError struct {
+	Command  string
+	Response string
+}
+
+func (e *AdapterError) Error() string {
+	return fmt.Sprintf("elm327 %s: %s", e.Command, e.Response)
+}
+
+// adapterErrors 表示命令失败的响应
+var adapterErrors = []string{"?", "UNABLE TO CONNECT", "CAN ERROR", "BUS ERROR", "BUS BUSY", "FB ERROR", "DATA ERROR", "STOPPED", "ERROR", "BUFFER FULL", "LV RESET", "ACT ALERT"}
+
+// Port 适配器连接
+type Port interface {
+	io.ReadWriteCloser
+	// SetReadTimeout 设置单次读取的超时，超时返回 0 字节
+	SetReadTimeout(t time.Duration) error
+}
+
+// tcpPort WiFi 适配器的 TCP 连接
+type tcpPort struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (p *tcpPort) SetReadTimeout(t time.Duration) error {
+	p.timeout = t
+	return nil
+}
+
+// Read 读取超时返回 0 字节
+func (p *tcpPort) Read(b []byte) (int, error) {
+	if p.timeout > 0 {
+		_ = p.SetReadDeadline(time.Now().Add(p.timeout))
+	}
+	n, err := p.Conn.Read(b)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return n, nil
+	}
+	return n, err
+}
+
+// DialTCP 返回连接 WiFi 适配器的函数，eg. 192.168.0.10:35000
+func DialTCP(server string, timeout time.Duration) func() (Port, error) {
+	return func() (Port, error) {
+		conn, err := net.DialTimeout("tcp", server, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return &tcpPort{Conn: conn}, nil
+	}
+}
+
+// ClientConfig 客户端配置
+type ClientConfig struct {
+	// Protocol ATSP 的 OBD 协议编号，0 自动识别，6 为 ISO 15765-4 CAN 11/500
+	Protocol string
+	// Timeout 单条命令的超时，包括适配器搜索协议的时间
+	Timeout time.Duration
+}
+
+// Client ELM327 客户端，连接断开后下一次请求重新连接并初始化适配器
+type Client struct {
+	config ClientConfig
+	dial   func() (Port, error)
+	// mu 保护 port，适配器一次只能执行一条命令
+	mu   sync.Mutex
+	port Port
+}
+
+// NewClient 创建客户端，第一次请求时连接
+func NewClient(dial func() (Port, error), config ClientConfig) *Client {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Protocol == "" {
+		config.Protocol = "0"
+	}
+	return &Client{config: config, dial: dial}
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closePort()
+}
+
+func (c *Client) closePort() error {
+	if c.port == nil {
+		return nil
+	}
+	err := c.port.Close()
+	c.port = nil
+	return err
+}
+
+// connect 连接并初始化：复位、关闭回显、换行、空格和报文头，设置协议
+func (c *Client) connect() error {
+	if c.port != nil {
+		return nil
+	}
+	port, err := c.dial()
+	if err != nil {
+		return err
+	}
+	if err = port.SetReadTimeout(readTick); err != nil {
+		_ = port.Close()
+		return err
+	}
+	c.port = port
+	for _, cmd := range []string{"ATZ", "ATE0", "ATL0", "ATS0", "ATH0", "ATSP" + c.config.Protocol} {
+		if _, err = c.command(cmd); err != nil {
+			_ = c.closePort()
+			return err
+		}
+	}
+	return nil
+}
+
+// Command 执行命令，返回去掉回显和状态信息的响应行
+func (c *Client) Command(cmd string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	lines, err := c.command(cmd)
+	var adapterErr *AdapterError
+	if err != nil && !errors.Is(err, ErrNoData) && !errors.As(err, &adapterErr) {
+		// 读写失败或者超时时断开，下一次请求重新连接
+		_ = c.closePort()
+	}
+	return lines, err
+}
+
+// command 发送命令并读取到提示符
+func (c *Client) command(cmd string) ([]string, error) {
+	if _, err := c.port.Write([]byte(cmd + "\r")); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(c.config.Timeout)
+	var resp []byte
+	buf := make([]byte, 256)
+	for bytes.IndexByte(resp, prompt) < 0 {
+		if time.Now().After(deadline) {
+			return nil, ErrTimeout
+		}
+		n, err := c.port.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, buf[:n]...)
+	}
+	return parseLines(cmd, string(resp[:bytes.IndexByte(resp, prompt)]))
+}
+
+// parseLines 拆分响应行，去掉回显、SEARCHING... 和 BUS INIT 等状态信息，识别错误
+func parseLines(cmd, resp string) ([]string, error) {
+	var lines []string
+	for _, line := range strings.FieldsFunc(resp, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "\x00", ""))
+		switch {
+		case line == "", line == cmd, strings.HasPrefix(line, "SEARCHING"):
+			continue
+		case strings.HasPrefix(line, "BUS INIT"):
+			if strings.HasSuffix(line, "ERROR") {
+				return nil, &AdapterError{Command: cmd, Response: line}
+			}
+			continue
+		case line == "NO DATA":
+			return nil, ErrNoData
+		}
+		for _, e := range adapterErrors {
+			if line == e || strings.HasPrefix(line, e+" ") {
+				return nil, &AdapterError{Command: cmd, Response: line}
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// Request 发送 OBD 请求，返回每个 ECU 的响应报文（十六进制解码后），CAN 多帧响应合并为一个报文
+func (c *Client) Request(mode byte, pid ...byte) ([][]byte, error) {
+	lines, err := c.Command(strings.ToUpper(hex.EncodeToString(append([]byte{mode}, pid...))))
+	if err != nil {
+		return nil, err
+	}
+	return parseFrames(lines)
+}
+
+// parseFrames 解析响应行。ISO-TP 多帧响应先输出 3 位十六进制的长度，再输出 0:、1: 开头的分段
+func parseFrames(lines []string) ([][]byte, error) {
+	var frames [][]byte
+	var multi []byte
+	length := -1
+	for _, line := range lines {
+		line = strings.ReplaceAll(line, " ", "")
+		if len(line) == 3 {
+			if n, err := hexUint(line); err == nil {
+				length, multi = n, multi[:0]
+				continue
+			}
+		}
+		if i := strings.IndexByte(line, ':'); i > 0 && length >= 0 {
+			b, err := hex.DecodeString(line[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid elm327 response: %s", line)
+			}
+			multi = append(multi, b...)
+			if len(multi) >= length {
+				frames = append(frames, append([]byte(nil), multi[:length]...))
+				length = -1
+			}
+			continue
+		}
+		b, err := hex.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid elm327 response: %s", line)
+		}
+		frames = append(frames, b)
+	}
+	if length >= 0 && len(multi) > 0 {
+		// 分段不完整时使用已经收到的数据
+		frames = append(frames, multi)
+	}
+	return frames, nil
+}
+
+func hexUint(s string) (int, error) {
+	var n int
+	_, err := fmt.Sscanf(s, "%x", &n)
+	return n, err
+}
+
+// ReadPID 读取模式 01 的 PID，返回第一个 ECU 的数据字节
+func (c *Client) ReadPID(pid byte) ([]byte, error) {
+	frames, err := c.Request(0x01, pid)
+	if err != nil {
+		return nil, err
+	}
+	for _, frame := range frames {
+		if len(frame) >= 2 && frame[0] == 0x41 && frame[1] == pid {
+			return frame[2:], nil
+		}
+	}
+	return nil, ErrNoData
+}
+
+// ReadDTCs 读取模式 03 的已确认故障码，合并所有 ECU 的故障码
+func (c *Client) ReadDTCs() ([]string, error) {
+	frames, err := c.Request(0x03)
+	if errors.Is(err, ErrNoData) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dtcs := []string{}
+	for _, frame := range frames {
+		if len(frame) == 0 || frame[0] != 0x43 {
+			continue
+		}
+		dtcs = append(dtcs, DecodeDTCs(frame[1:])...)
+	}
+	return dtcs, nil
+}
+
+// DecodeDTCs 解码 43 之后的故障码字节。CAN 协议第一个字节为故障码数量，长度为奇数，
+// 其他协议固定 3 个故障码，不足时补 0
+func DecodeDTCs(data []byte) []string {
+	if len(data)%2 == 1 {
+		data = data[1:]
+	}
+	dtcs := []string{}
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 && data[i+1] == 0 {
+			continue
+		}
+		dtcs = append(dtcs, fmt.Sprintf("%c%d%X%02X", "PCBU"[data[i]>>6], data[i]>>4&0x03, data[i]&0x0f, data[i+1]))
+	}
+	return dtcs
+}
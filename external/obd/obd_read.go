@@ -0,0 +1,215 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package obd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	serialNode "github.com/rulego/rulego-components-iot/external/serial"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 适配器的连接方式
+const (
+	TransportTCP    = "tcp"
+	TransportSerial = "serial"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 节点配置
+type ReadConfiguration struct {
+	// Transport 连接方式：tcp（WiFi 适配器）、serial（USB、蓝牙串口适配器）
+	Transport string `json:"transport" label:"Transport" desc:"Adapter connection: tcp (WiFi adapters) or serial (USB and Bluetooth serial adapters)"`
+	// Server tcp 连接的适配器地址，格式：host:port
+	Server string `json:"server" label:"Server" desc:"tcp: adapter address, format: host:port" ref:"primary"`
+	// Port serial 连接的串口，eg. /dev/rfcomm0、COM3
+	Port string `json:"port" label:"Port" desc:"serial: serial port, eg. /dev/rfcomm0 or COM3"`
+	// BaudRate serial 连接的波特率
+	BaudRate int `json:"baudRate" label:"Baud Rate" desc:"serial: baud rate, ELM327 adapters default to 38400"`
+	// Protocol ATSP 的 OBD 协议编号，0 自动识别
+	Protocol string `json:"protocol" label:"Protocol" desc:"OBD protocol number for ATSP, 0 detects automatically, eg. 6 for ISO 15765-4 CAN 11 bit 500 kbaud"`
+	// Timeout 单条命令的超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Timeout of each adapter command in seconds"`
+	// Pids 读取的 PID 名称，eg. rpm、speed、coolantTemp
+	Pids []string `json:"pids" label:"PIDs" desc:"Mode 01 PIDs to read, eg. rpm, speed, coolantTemp, engineLoad, fuelLevel, controlModuleVoltage"`
+	// ReadDtc 读取故障指示灯状态和已确认的故障码
+	ReadDtc bool `json:"readDtc" label:"Read DTCs" desc:"Read the MIL status and stored diagnostic trouble codes"`
+}
+
+// ReadNode 通过 ELM327 兼容适配器读取车辆的 OBD-II 数据，由定时任务触发。结果重新赋值到msg.Data：
+//
+//	{"rpm": 1726.5, "speed": 45, "coolantTemp": 88, "mil": true, "dtcCount": 1, "dtcs": ["P0301"], "unsupported": ["oilTemp"]}
+//
+// 车辆不支持的 PID 放在 unsupported，不影响其他 PID。相同适配器的节点共享一个连接。
+// 读取成功流转到`Success`链；适配器连接失败或者车辆没有响应（eg. 点火关闭），流转到`Failure`链
+type ReadNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config ReadConfiguration
+	pids   []PID
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/obdRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Transport: TransportTCP,
+			Server:    "192.168.0.10:35000",
+			BaudRate:  38400,
+			Protocol:  "0",
+			Timeout:   5,
+			Pids:      []string{"rpm", "speed", "coolantTemp", "engineLoad", "fuelLevel"},
+			ReadDtc:   true,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if len(x.Config.Pids) == 0 && !x.Config.ReadDtc {
+		return errors.New("no obd pids to read")
+	}
+	x.pids = x.pids[:0]
+	for _, name := range x.Config.Pids {
+		p, ok := PIDs[name]
+		if !ok {
+			return fmt.Errorf("unsupported obd pid: %s, supported: %s", name, strings.Join(PIDNames(), ", "))
+		}
+		x.pids = append(x.pids, p)
+	}
+	timeout := time.Duration(x.Config.Timeout) * time.Second
+	var key string
+	var dial func() (Port, error)
+	switch x.Config.Transport {
+	case "", TransportTCP:
+		if x.Config.Server == "" {
+			return errors.New("obd adapter server is empty")
+		}
+		key, dial = x.Config.Server, DialTCP(x.Config.Server, timeout)
+	case TransportSerial:
+		if x.Config.Port == "" {
+			return errors.New("obd adapter serial port is empty")
+		}
+		config := serialNode.SharedSerialConfig{
+			Port:     x.Config.Port,
+			BaudRate: x.Config.BaudRate,
+			DataBits: 8,
+			StopBits: serialNode.StopBits1,
+			Parity:   serialNode.ParityNone,
+			DTR:      true,
+		}
+		key = x.Config.Port
+		dial = func() (Port, error) {
+			return &serialNode.SafeSerialPort{Config: config}, nil
+		}
+	default:
+		return fmt.Errorf("unsupported obd transport: %s", x.Config.Transport)
+	}
+	config := ClientConfig{Protocol: x.Config.Protocol, Timeout: timeout}
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), key, ruleConfig.NodeClientInitNow, func() (*Client, error) {
+		return NewClient(dial, config), nil
+	}, func(client *Client) error {
+		if client != nil {
+			return client.Close()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result, err := x.read(client)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// read 读取配置的 PID 和故障码，车辆不支持的 PID 记录在 unsupported
+func (x *ReadNode) read(client *Client) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	unsupported := []string{}
+	for _, p := range x.pids {
+		b, err := client.ReadPID(p.Code)
+		if errors.Is(err, ErrNoData) || (err == nil && len(b) < p.Size) {
+			unsupported = append(unsupported, p.Name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[p.Name] = p.Decode(b)
+	}
+	if x.Config.ReadDtc {
+		b, err := client.ReadPID(0x01)
+		if err != nil && !errors.Is(err, ErrNoData) {
+			return nil, err
+		}
+		status := DecodeMonitorStatus(b)
+		result["mil"], result["dtcCount"] = status.Mil, status.DtcCount
+		dtcs, err := client.ReadDTCs()
+		if err != nil {
+			return nil, err
+		}
+		result["dtcs"] = dtcs
+	}
+	result["unsupported"] = unsupported
+	return result, nil
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "Read OBD-II PIDs such as RPM, speed and coolant temperature plus MIL status and DTCs through an ELM327 adapter over TCP or serial. Routes to Success/Failure"
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package obd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestReadNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+
+	for _, configuration := range []types.Configuration{
+		{"pids": []string{"boost"}},
+		{"pids": []string{}, "readDtc": false},
+		{"transport": "can"},
+		{"transport": TransportSerial, "port": ""},
+	} {
+		_, err := test.CreateAndInitNode("x/obdRead", configuration, Registry)
+		assert.NotNil(t, err)
+	}
+
+	adapter := newTestAdapter(t, map[string]string{
+		"010C": "410C1AF8",
+		"010D": "410D2D",
+		"0105": "410580",
+		"0101": "41018107E500",
+		"03":   "43010301",
+	})
+	node, err := test.CreateAndInitNode("x/obdRead", types.Configuration{
+		"server": adapter.addr,
+		"pids":   []string{"rpm", "speed", "coolantTemp", "oilTemp"},
+	}, Registry)
+	assert.Nil(t, err)
+	offNode, err := test.CreateAndInitNode("x/obdRead", types.Configuration{
+		"server": "127.0.0.1:1",
+		"pids":   []string{"rpm"},
+	}, Registry)
+	assert.Nil(t, err)
+
+	msgs := []test.Msg{{MetaData: types.NewMetadata(), DataType: types.TEXT, MsgType: "TICK", AfterSleep: 200 * time.Millisecond}}
+	test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, 1726.0, result["rpm"])
+		assert.Equal(t, 45.0, result["speed"])
+		assert.Equal(t, 88.0, result["coolantTemp"])
+		assert.Equal(t, true, result["mil"])
+		assert.Equal(t, 1.0, result["dtcCount"])
+		assert.Equal(t, []interface{}{"P0301"}, result["dtcs"])
+		assert.Equal(t, []interface{}{"oilTemp"}, result["unsupported"])
+	})
+	test.NodeOnMsg(t, offNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
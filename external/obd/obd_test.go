@@ -0,0 +1,178 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package obd
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testAdapter 模拟 ELM327 WiFi 适配器
+type testAdapter struct {
+	addr      string
+	mu        sync.Mutex
+	responses map[string]string
+	commands  []string
+	conns     int
+}
+
+func newTestAdapter(t *testing.T, responses map[string]string) *testAdapter {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	a := &testAdapter{addr: listener.Addr().String(), responses: responses}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			a.mu.Lock()
+			a.conns++
+			a.mu.Unlock()
+			go a.serve(conn)
+		}
+	}()
+	return a
+}
+
+func (a *testAdapter) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	echo := true
+	for {
+		cmd, err := reader.ReadString('\r')
+		if err != nil {
+			return
+		}
+		cmd = strings.TrimSpace(cmd)
+		a.mu.Lock()
+		a.commands = append(a.commands, cmd)
+		resp, ok := a.responses[cmd]
+		a.mu.Unlock()
+		switch {
+		case cmd == "ATZ":
+			echo, resp = true, "\r\rELM327 v1.5"
+		case cmd == "ATE0":
+			echo, resp = false, "OK"
+		case strings.HasPrefix(cmd, "AT"):
+			resp = "OK"
+		case resp == "close":
+			return
+		case !ok:
+			resp = "NO DATA"
+		}
+		if echo {
+			resp = cmd + "\r" + resp
+		}
+		_, _ = conn.Write([]byte(resp + "\r\r>"))
+	}
+}
+
+func (a *testAdapter) sent() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.commands...)
+}
+
+func TestParseFrames(t *testing.T) {
+	frames, err := parseFrames([]string{"410C1AF8", "410C1B00"})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{{0x41, 0x0c, 0x1a, 0xf8}, {0x41, 0x0c, 0x1b, 0x00}}, frames)
+
+	// ISO-TP 多帧响应
+	frames, err = parseFrames([]string{"00A", "0:43040101020203", "1:0304000000"})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{{0x43, 0x04, 0x01, 0x01, 0x02, 0x02, 0x03, 0x03, 0x04, 0x00}}, frames)
+
+	_, err = parseFrames([]string{"41 0C ZZ"})
+	assert.NotNil(t, err)
+
+	_, err = parseLines("0100", "SEARCHING...\rUNABLE TO CONNECT\r")
+	var adapterErr *AdapterError
+	assert.True(t, errors.As(err, &adapterErr))
+	_, err = parseLines("0100", "BUS INIT: ...ERROR\r")
+	assert.True(t, errors.As(err, &adapterErr))
+	lines, err := parseLines("0100", "BUS INIT: ...OK\r41 00 BE 3E B8 11\r")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"41 00 BE 3E B8 11"}, lines)
+}
+
+func TestDecodeDTCs(t *testing.T) {
+	// 非 CAN 协议固定 3 个故障码
+	assert.Equal(t, []string{"P0133", "C0401", "B2345"}, DecodeDTCs([]byte{0x01, 0x33, 0x44, 0x01, 0xa3, 0x45}))
+	assert.Equal(t, []string{"U0100"}, DecodeDTCs([]byte{0xc1, 0x00, 0x00, 0x00, 0x00, 0x00}))
+	// CAN 协议第一个字节为数量
+	assert.Equal(t, []string{"P0301", "P0420"}, DecodeDTCs([]byte{0x02, 0x03, 0x01, 0x04, 0x20}))
+	assert.Equal(t, []string{}, DecodeDTCs([]byte{0x00}))
+}
+
+func TestPIDs(t *testing.T) {
+	assert.Equal(t, 1726.0, PIDs["rpm"].Decode([]byte{0x1a, 0xf8}))
+	assert.Equal(t, 88.0, PIDs["coolantTemp"].Decode([]byte{0x80}))
+	assert.Equal(t, 50.2, PIDs["fuelLevel"].Decode([]byte{0x80}))
+	assert.Equal(t, 14.2, PIDs["controlModuleVoltage"].Decode([]byte{0x37, 0x78}))
+	assert.Equal(t, 123456.7, PIDs["odometer"].Decode([]byte{0x00, 0x12, 0xd6, 0x87}))
+	assert.Equal(t, MonitorStatus{Mil: true, DtcCount: 2}, DecodeMonitorStatus([]byte{0x82, 0x07, 0xe5, 0x00}))
+	assert.Equal(t, "ambientTemp", PIDNames()[0])
+}
+
+func TestClient(t *testing.T) {
+	adapter := newTestAdapter(t, map[string]string{
+		"010C": "SEARCHING...\r410C1AF8\r410C1AF8",
+		"010D": "410D2D",
+		"0105": "CAN ERROR",
+		"03":   "00A\r0:43040101020203\r1:0304040000",
+		"010F": "close",
+	})
+	client := NewClient(DialTCP(adapter.addr, time.Second), ClientConfig{Protocol: "6", Timeout: time.Second})
+	defer client.Close()
+
+	b, err := client.ReadPID(0x0c)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x1a, 0xf8}, b)
+	assert.Equal(t, []string{"ATZ", "ATE0", "ATL0", "ATS0", "ATH0", "ATSP6", "010C"}, adapter.sent())
+	b, err = client.ReadPID(0x0d)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x2d}, b)
+	_, err = client.ReadPID(0x5c)
+	assert.True(t, errors.Is(err, ErrNoData))
+	var adapterErr *AdapterError
+	_, err = client.ReadPID(0x05)
+	assert.True(t, errors.As(err, &adapterErr))
+
+	dtcs, err := client.ReadDTCs()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"P0101", "P0202", "P0303", "P0404"}, dtcs)
+
+	// 适配器断开后重新连接并初始化
+	_, err = client.ReadPID(0x0f)
+	assert.NotNil(t, err)
+	b, err = client.ReadPID(0x0d)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x2d}, b)
+	adapter.mu.Lock()
+	assert.Equal(t, 2, adapter.conns)
+	adapter.mu.Unlock()
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package obd
+
+import (
+	"math"
+	"sort"
+)
+
+// PID 模式 01 的标准 PID
+type PID struct {
+	// Name 输出字段名
+	Name string
+	// Code PID 编号
+	Code byte
+	// Size 数据字节数
+	Size int
+	// Unit 单位
+	Unit string
+	// Decode 把数据字节转换为工程值
+	Decode func(b []byte) float64
+}
+
+// 常用的解码公式，A、B 为第一和第二个数据字节
+var (
+	percent     = func(b []byte) float64 { return round(float64(b[0]) * 100 / 255) }
+	temperature = func(b []byte) float64 { return float64(b[0]) - 40 }
+	byteValue   = func(b []byte) float64 { return float64(b[0]) }
+	wordValue   = func(b []byte) float64 { return float64(word(b)) }
+)
+
+// PIDs 支持的 PID，key 为输出字段名
+var PIDs = map[string]PID{}
+
+func init() {
+	for _, p := range []PID{
+		{Name: "engineLoad", Code: 0x04, Size: 1, Unit: "%", Decode: percent},
+		{Name: "coolantTemp", Code: 0x05, Size: 1, Unit: "°C", Decode: temperature},
+		{Name: "fuelPressure", Code: 0x0A, Size: 1, Unit: "kPa", Decode: func(b []byte) float64 { return float64(b[0]) * 3 }},
+		{Name: "intakePressure", Code: 0x0B, Size: 1, Unit: "kPa", Decode: byteValue},
+		{Name: "rpm", Code: 0x0C, Size: 2, Unit: "rpm", Decode: func(b []byte) float64 { return float64(word(b)) / 4 }},
+		{Name: "speed", Code: 0x0D, Size: 1, Unit: "km/h", Decode: byteValue},
+		{Name: "timingAdvance", Code: 0x0E, Size: 1, Unit: "°", Decode: func(b []byte) float64 { return float64(b[0])/2 - 64 }},
+		{Name: "intakeTemp", Code: 0x0F, Size: 1, Unit: "°C", Decode: temperature},
+		{Name: "mafRate", Code: 0x10, Size: 2, Unit: "g/s", Decode: func(b []byte) float64 { return float64(word(b)) / 100 }},
+		{Name: "throttlePosition", Code: 0x11, Size: 1, Unit: "%", Decode: percent},
+		{Name: "runTime", Code: 0x1F, Size: 2, Unit: "s", Decode: wordValue},
+		{Name: "distanceWithMil", Code: 0x21, Size: 2, Unit: "km", Decode: wordValue},
+		{Name: "fuelLevel", Code: 0x2F, Size: 1, Unit: "%", Decode: percent},
+		{Name: "distanceSinceClear", Code: 0x31, Size: 2, Unit: "km", Decode: wordValue},
+		{Name: "barometricPressure", Code: 0x33, Size: 1, Unit: "kPa", Decode: byteValue},
+		{Name: "controlModuleVoltage", Code: 0x42, Size: 2, Unit: "V", Decode: func(b []byte) float64 { return float64(word(b)) / 1000 }},
+		{Name: "ambientTemp", Code: 0x46, Size: 1, Unit: "°C", Decode: temperature},
+		{Name: "oilTemp", Code: 0x5C, Size: 1, Unit: "°C", Decode: temperature},
+		{Name: "fuelRate", Code: 0x5E, Size: 2, Unit: "L/h", Decode: func(b []byte) float64 { return float64(word(b)) / 20 }},
+		{Name: "odometer", Code: 0xA6, Size: 4, Unit: "km", Decode: func(b []byte) float64 {
+			return float64(uint32(b[0])<<24|uint32(b[1])<<16|uint32(b[2])<<8|uint32(b[3])) / 10
+		}},
+	} {
+		PIDs[p.Name] = p
+	}
+}
+
+// PIDNames 支持的 PID 名称，按字母排序
+func PIDNames() []string {
+	names := make([]string, 0, len(PIDs))
+	for name := range PIDs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MonitorStatus PID 01 的故障指示灯状态和故障码数量
+type MonitorStatus struct {
+	// Mil 故障指示灯是否点亮
+	Mil bool `json:"mil"`
+	// DtcCount 已确认的故障码数量
+	DtcCount int `json:"dtcCount"`
+}
+
+// DecodeMonitorStatus 解码 PID 01 的第一个字节
+func DecodeMonitorStatus(b []byte) MonitorStatus {
+	if len(b) == 0 {
+		return MonitorStatus{}
+	}
+	return MonitorStatus{Mil: b[0]&0x80 != 0, DtcCount: int(b[0] & 0x7f)}
+}
+
+func word(b []byte) uint16 {
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
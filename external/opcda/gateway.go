@@ -0,0 +1,207 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 5 * time.Second
+	// SourceCache 从 OPC DA 服务器缓存读取，OPC_DS_CACHE
+	SourceCache = "cache"
+	// SourceDevice 从设备读取，OPC_DS_DEVICE
+	SourceDevice = "device"
+)
+
+// Error 网关或者 OPC DA 服务器返回的错误
+type Error struct {
+	// ItemId 出错的点位，为空表示整个请求失败
+	ItemId string `json:"itemId,omitempty"`
+	// Message 错误信息
+	Message string `json:"error"`
+	// Code OPC DA 返回的 HRESULT，eg. -1073479673（OPC_E_UNKNOWNITEMID）
+	Code int32 `json:"code,omitempty"`
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("opcda")
+	if e.ItemId != "" {
+		b.WriteString(" ")
+		b.WriteString(e.ItemId)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Message)
+	if e.Code != 0 {
+		fmt.Fprintf(&b, " (0x%08x)", uint32(e.Code))
+	}
+	return b.String()
+}
+
+// ReadRequest 读取请求
+type ReadRequest struct {
+	// Server OPC DA 服务器的 ProgID，eg. Matrikon.OPC.Simulation.1
+	Server string `json:"server"`
+	// Host OPC DA 服务器所在主机，通过 DCOM 访问，为空表示网关所在主机
+	Host string `json:"host,omitempty"`
+	// Items 读取的点位 ItemID
+	Items []string `json:"items"`
+	// Source 数据源：cache 或者 device
+	Source string `json:"source,omitempty"`
+}
+
+// ItemValue 点位的读取结果
+type ItemValue struct {
+	// ItemId 点位 ItemID
+	ItemId string `json:"itemId"`
+	// Value 点位的值，VARIANT 转换为 JSON，数组为 JSON 数组
+	Value interface{} `json:"value"`
+	// Quality OPC DA 质量字，eg. 192（GOOD）
+	Quality uint16 `json:"quality"`
+	// Timestamp 时间戳，RFC 3339 格式
+	Timestamp string `json:"timestamp,omitempty"`
+	// Error 点位读取失败的原因，为空表示成功
+	Error string `json:"error,omitempty"`
+	// Code 点位读取失败的 HRESULT
+	Code int32 `json:"code,omitempty"`
+}
+
+// Err 点位读取失败时返回 Error，否则返回 nil
+func (v ItemValue) Err() error {
+	if v.Error == "" && v.Code == 0 {
+		return nil
+	}
+	message := v.Error
+	if message == "" {
+		message = "read failed"
+	}
+	return &Error{ItemId: v.ItemId, Message: message, Code: v.Code}
+}
+
+// readResponse 读取响应
+type readResponse struct {
+	Items []ItemValue `json:"items"`
+}
+
+// GatewayClient OPC DA 网关客户端
+//
+// OPC DA 基于 COM/DCOM，只能在 Windows 上访问，网关运行在可以访问 OPC DA 服务器的 Windows 主机上，
+// 把 OPC DA 的同步读取转换为 HTTP + JSON 接口：
+//
+//	POST /read
+//	{"server":"Matrikon.OPC.Simulation.1","host":"","items":["Random.Int4","Random.Real8"],"source":"cache"}
+//	->
+//	{"items":[
+//	  {"itemId":"Random.Int4","value":123,"quality":192,"timestamp":"2025-06-01T08:00:00.123Z"},
+//	  {"itemId":"Random.Real8","value":null,"quality":0,"error":"unknown item id","code":-1073479673}
+//	]}
+//
+// 响应的 items 和请求的顺序一致，点位读取失败时 error 和 code 不为空，其他点位正常返回。
+// 请求失败（eg. 无法连接 OPC DA 服务器）时返回非 2xx 状态码，响应体：{"error":"...","code":-2147023174}，code 为 HRESULT
+//
+// 网关是无状态的，不会预先建立连接，OPC DA 的连接和组由网关管理
+type GatewayClient struct {
+	// Gateway 网关地址，eg. http://192.168.1.10:8080
+	Gateway string
+	// Client HTTP 客户端
+	Client *http.Client
+	// Timeout 请求超时
+	Timeout time.Duration
+}
+
+// NewGatewayClient 创建网关客户端
+func NewGatewayClient(gateway string, timeout time.Duration) (*GatewayClient, error) {
+	if gateway == "" {
+		return nil, errors.New("opcda gateway cannot be empty")
+	}
+	u, err := url.Parse(gateway)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("opcda gateway must be an http or https url: %s", gateway)
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &GatewayClient{
+		Gateway: strings.TrimSuffix(gateway, "/"),
+		Client:  &http.Client{},
+		Timeout: timeout,
+	}, nil
+}
+
+// Read 同步读取点位，返回的结果和 items 的顺序一致，点位的错误见 ItemValue.Err
+func (c *GatewayClient) Read(request ReadRequest) ([]ItemValue, error) {
+	var resp readResponse
+	if err := c.post("read", request, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Items) != len(request.Items) {
+		return nil, fmt.Errorf("opcda gateway returned %d items, want %d", len(resp.Items), len(request.Items))
+	}
+	return resp.Items, nil
+}
+
+// Close 关闭空闲连接
+func (c *GatewayClient) Close() error {
+	c.Client.CloseIdleConnections()
+	return nil
+}
+
+// post 请求网关并解析 JSON 响应
+func (c *GatewayClient) post(op string, request, v interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Gateway+"/"+op, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e Error
+		if json.Unmarshal(body, &e) == nil && e.Message != "" {
+			return &e
+		}
+		return fmt.Errorf("opcda gateway %s: %s", op, resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
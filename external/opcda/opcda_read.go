@@ -0,0 +1,228 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// Gateway 网关地址，网关接口见 GatewayClient
+	Gateway string `json:"gateway" label:"Gateway" desc:"OPC DA HTTP gateway running on a Windows host with access to the OPC DA server, eg. http://192.168.1.10:8080" required:"true"`
+	// Server OPC DA 服务器的 ProgID
+	Server string `json:"server" label:"Server" desc:"OPC DA server ProgID, eg. Matrikon.OPC.Simulation.1" required:"true" ref:"primary"`
+	// Host OPC DA 服务器所在主机，网关通过 DCOM 访问，为空表示网关所在主机
+	Host string `json:"host" label:"Host" desc:"Host of the OPC DA server reached by the gateway over DCOM, empty uses the gateway host"`
+	// Source 数据源：cache 从服务器缓存读取，device 从设备读取
+	Source string `json:"source" label:"Source" desc:"Data source: cache (server cache) or device (read through to the device)"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Gateway request timeout in seconds"`
+	// Items 读取的点位，为空则使用消息负荷 msg.Data 中的点位
+	Items []Item `json:"items" label:"Items" desc:"Items to read, empty uses the items in msg.Data"`
+	// Quality 值输出为 {"value": 21.5, "qualityLevel": "GOOD", "qualityCode": 192, "timestamp": "..."}
+	Quality bool `json:"quality" label:"Quality" desc:"Output values as {value, qualityLevel, qualityCode, timestamp}"`
+}
+
+// Item 读取的点位
+type Item struct {
+	// Name 名称，作为输出的 key，为空使用 ItemID
+	Name string `json:"name,omitempty"`
+	// ItemId 点位 ItemID，eg. Channel1.Device1.Tag1
+	ItemId string `json:"itemId"`
+}
+
+// key 输出的 key
+func (i Item) key() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return i.ItemId
+}
+
+// Value 开启 quality 时输出的值，qualityCode 为 OPC DA 质量字
+type Value struct {
+	Value interface{} `json:"value"`
+	quality.Quality
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// ReadNode OPC DA（OPC Classic）读取节点，通过 HTTP 网关读取旧系统中 OPC DA 服务器的点位，不需要在 Windows 上编写脚本
+// 网关运行在可以访问 OPC DA 服务器的 Windows 主机上，接口见 GatewayClient。
+// 如果使用 OPC DA 到 OPC UA 的转换器（wrapper），可以直接使用 x/opcuaRead 读取转换后的节点
+//
+// 点位为空时使用消息负荷 msg.Data 中的点位，格式：["Random.Int4"] 或者 [{"name":"temperature","itemId":"Random.Real8"}]
+// 结果重新赋值到msg.Data，key 为点位名称，为空使用 ItemID：
+//
+//	{"temperature": 21.5, "Random.Int4": 123}
+//
+// 开启 quality 时按 OPC DA 质量字归一化为 GOOD/UNCERTAIN/BAD，同时输出时间戳：
+//
+//	{"temperature": {"value": 21.5, "qualityLevel": "GOOD", "qualityCode": 192, "timestamp": "2025-06-01T08:00:00.123Z"}}
+//
+// 所有点位读取成功，流转到`Success`链，否则流转到`Failure`链
+type ReadNode struct {
+	//节点配置
+	Config ReadConfiguration
+	client *GatewayClient
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/opcdaRead"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Gateway: "http://127.0.0.1:8080",
+			Server:  "Matrikon.OPC.Simulation.1",
+			Source:  SourceCache,
+			Timeout: 5,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Server == "" {
+		return errors.New("opcda server cannot be empty")
+	}
+	switch x.Config.Source {
+	case "":
+		x.Config.Source = SourceCache
+	case SourceCache, SourceDevice:
+	default:
+		return fmt.Errorf("opcda source must be cache or device, got %s", x.Config.Source)
+	}
+	if err = checkItems(x.Config.Items); err != nil {
+		return err
+	}
+	x.client, err = NewGatewayClient(x.Config.Gateway, time.Duration(x.Config.Timeout)*time.Second)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items := x.Config.Items
+	if len(items) == 0 {
+		var err error
+		if items, err = parseItems(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	request := ReadRequest{
+		Server: x.Config.Server,
+		Host:   x.Config.Host,
+		Items:  make([]string, len(items)),
+		Source: x.Config.Source,
+	}
+	for i, item := range items {
+		request.Items[i] = item.ItemId
+	}
+	values, err := x.client.Read(request)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{}, len(items))
+	for i, item := range items {
+		v := values[i]
+		if err := v.Err(); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if x.Config.Quality {
+			result[item.key()] = Value{Value: v.Value, Quality: quality.FromOPCDA(v.Quality), Timestamp: v.Timestamp}
+		} else {
+			result[item.key()] = v.Value
+		}
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	if x.client != nil {
+		_ = x.client.Close()
+	}
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "OPC DA (OPC Classic) item reader through an HTTP gateway on the Windows host, with optional quality and timestamps. Routes to Success/Failure"
+}
+
+// checkItems 检查点位的 ItemID 不能为空
+func checkItems(items []Item) error {
+	for _, item := range items {
+		if item.ItemId == "" {
+			return errors.New("opcda itemId cannot be empty")
+		}
+	}
+	return nil
+}
+
+// parseItems 解析消息负荷中的点位，元素为 ItemID 或者 {"name":"...","itemId":"..."}
+func parseItems(data string) ([]Item, error) {
+	var list []interface{}
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(list))
+	for _, v := range list {
+		if itemId, ok := v.(string); ok {
+			items = append(items, Item{ItemId: itemId})
+			continue
+		}
+		var item Item
+		if err := maps.Map2Struct(v, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("no opcda items to read")
+	}
+	return items, checkItems(items)
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcda
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testGateway 测试用的 OPC DA 网关
+type testGateway struct {
+	*httptest.Server
+	mu sync.Mutex
+	// requests 收到的读取请求
+	requests []ReadRequest
+	// down 为 true 时返回无法连接 OPC DA 服务器
+	down bool
+}
+
+func newTestGateway() *testGateway {
+	g := &testGateway{}
+	g.Server = httptest.NewServer(http.HandlerFunc(g.serve))
+	return g
+}
+
+func (g *testGateway) serve(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if r.Method != http.MethodPost || r.URL.Path != "/read" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var request ReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	g.requests = append(g.requests, request)
+	if g.down {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`{"error":"server unavailable","code":-2147023174}`))
+		return
+	}
+	var resp readResponse
+	for _, itemId := range request.Items {
+		v := ItemValue{ItemId: itemId, Timestamp: "2025-06-01T08:00:00.123Z"}
+		switch itemId {
+		case "Random.Int4":
+			v.Value, v.Quality = 123, 0xc0
+		case "Random.Real8":
+			// 最后可用值
+			v.Value, v.Quality = 21.5, 0x44
+		default:
+			v.Error, v.Code = "unknown item id", -1073479673
+		}
+		resp.Items = append(resp.Items, v)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (g *testGateway) setDown(down bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.down = down
+}
+
+func TestGatewayClient(t *testing.T) {
+	gateway := newTestGateway()
+	defer gateway.Close()
+
+	_, err := NewGatewayClient("", 0)
+	assert.NotNil(t, err)
+	_, err = NewGatewayClient("tcp://127.0.0.1:8080", 0)
+	assert.NotNil(t, err)
+	client, err := NewGatewayClient(gateway.URL+"/", time.Second)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	values, err := client.Read(ReadRequest{Server: "Matrikon.OPC.Simulation.1", Host: "scada01", Items: []string{"Random.Int4", "Unknown"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(values))
+	assert.Nil(t, values[0].Err())
+	assert.Equal(t, float64(123), values[0].Value)
+	assert.Equal(t, "opcda Unknown: unknown item id (0xc0040007)", values[1].Err().Error())
+	assert.Equal(t, ReadRequest{Server: "Matrikon.OPC.Simulation.1", Host: "scada01", Items: []string{"Random.Int4", "Unknown"}}, gateway.requests[0])
+
+	gateway.setDown(true)
+	_, err = client.Read(ReadRequest{Server: "Matrikon.OPC.Simulation.1", Items: []string{"Random.Int4"}})
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, int32(-2147023174), e.Code)
+}
+
+func TestReadNode(t *testing.T) {
+	gateway := newTestGateway()
+	defer gateway.Close()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+
+	// 非法数据源
+	_, err := test.CreateAndInitNode("x/opcdaRead", types.Configuration{
+		"gateway": gateway.URL,
+		"source":  "memory",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/opcdaRead", types.Configuration{
+		"gateway": gateway.URL,
+		"source":  SourceDevice,
+		"quality": true,
+		"items": []map[string]interface{}{
+			{"name": "counter", "itemId": "Random.Int4"},
+			{"itemId": "Random.Real8"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgNode, err := test.CreateAndInitNode("x/opcdaRead", types.Configuration{
+		"gateway": gateway.URL,
+	}, Registry)
+	assert.Nil(t, err)
+	defer msgNode.Destroy()
+
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, map[string]interface{}{"value": float64(123), "qualityLevel": "GOOD", "qualityCode": float64(192),
+			"timestamp": "2025-06-01T08:00:00.123Z"}, values["counter"])
+		assert.Equal(t, "UNCERTAIN", values["Random.Real8"]["qualityLevel"])
+	})
+
+	test.NodeOnMsg(t, msgNode, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "ITEMS",
+			Data:       `["Random.Int4", {"name": "temperature", "itemId": "Random.Real8"}]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "UNKNOWN",
+			Data:       `["Random.Int4", "Unknown"]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "EMPTY",
+			Data:       `[]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		switch msg.Type {
+		case "ITEMS":
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `{"Random.Int4":123,"temperature":21.5}`, msg.GetData())
+		case "UNKNOWN":
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, "opcda Unknown: unknown item id (0xc0040007)", err.Error())
+		default:
+			assert.Equal(t, types.Failure, relationType)
+		}
+	})
+
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+	assert.Equal(t, SourceDevice, gateway.requests[0].Source)
+	assert.Equal(t, "Matrikon.OPC.Simulation.1", gateway.requests[0].Server)
+	assert.Equal(t, SourceCache, gateway.requests[1].Source)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego-components-iot/pkg/conntest"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册连接测试，连接配置相同的组件共用
+func init() {
+	for _, componentType := range []string{
+		"x/opcuaRead",
+		"x/opcuaWrite",
+		"x/opcuaReadAttributes",
+		"x/opcuaDiagnostics",
+		"x/opcuaHistoryWrite",
+		"x/opcuaSubscribe",
+		types.EndpointTypePrefix + "opcua",
+	} {
+		_ = conntest.Register(componentType, TestConnection)
+	}
+}
+
+// TestConnection 测试 OPC UA 连接配置，依次执行：
+//   - connect：获取服务器的端点列表，诊断信息为服务器支持的安全策略和模式
+//   - auth：按安全策略、模式和认证方式建立会话
+//   - read：读取样本点位，样本点位为配置的 testSample，为空使用 nodeIds 的第一个点位，都为空时跳过
+//
+// 测试使用独立的会话，结束后关闭，不影响组件的共享会话
+func TestConnection(ctx context.Context, config map[string]interface{}) *conntest.Result {
+	c := Configuration{Policy: "None", Mode: "none", Auth: "anonymous"}
+	if err := maps.Map2Struct(config, &c); err != nil {
+		return conntest.NewResult("", "").Fail(err)
+	}
+	result := conntest.NewResult("", c.Server)
+	if c.Server == "" {
+		return result.Fail(fmt.Errorf("server is required"))
+	}
+	if err := opcuaClient.ValidateSecurity(c.Policy, c.Mode); err != nil {
+		return result.Fail(err)
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	result.Run(conntest.StepConnect, func() (string, error) {
+		endpoints, err := opcua.GetEndpoints(ctx, c.Server)
+		if err != nil {
+			return "", err
+		}
+		return describeEndpoints(endpoints), nil
+	})
+	var client *opcua.Client
+	result.Run(conntest.StepAuth, func() (string, error) {
+		holder := opcuaClient.DefaultHolder(c)
+		holder.Ctx = ctx
+		var err error
+		if client, err = holder.NewOpcUaClient(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("session created, auth: %s", c.Auth), nil
+	})
+	if client != nil {
+		defer func() {
+			_ = opcuaClient.CloseClient(client)
+		}()
+	}
+
+	nodeId := sampleNodeId(config)
+	if nodeId == "" {
+		result.Skip(conntest.StepRead, "no sample node configured")
+		return result
+	}
+	result.Run(conntest.StepRead, func() (string, error) {
+		data, resp, err := opcuaClient.ReadWithOptionsContext(ctx, client, []string{nodeId}, opcuaClient.ReadOptions{
+			BatchSize:          1,
+			TimestampsToReturn: opcuaClient.DefaultTimestampsToReturn,
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Results) == 0 || resp.Results[0] == nil {
+			return "", fmt.Errorf("no result for %s", nodeId)
+		}
+		r := resp.Results[0]
+		if quality.FromOPCUA(uint32(r.Status)).IsBad() {
+			return "", fmt.Errorf("%s: %w", nodeId, r.Status)
+		}
+		d := opcuaClient.Data{
+			DisplayName: data[0].DisplayName,
+			NodeId:      nodeId,
+			RecordTime:  r.ServerTimestamp,
+			SourceTime:  r.SourceTimestamp,
+			Value:       r.Value.Value(),
+			Timestamp:   time.Now(),
+		}
+		d.SetStatus(r.Status)
+		_, _ = d.ParseValueFor(client)
+		result.Sample = d
+		return nodeId, nil
+	})
+	return result
+}
+
+// describeEndpoints 服务器支持的安全策略和模式，eg. None/None, Basic256Sha256/SignAndEncrypt
+func describeEndpoints(endpoints []*ua.EndpointDescription) string {
+	list := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		list = append(list, strings.TrimPrefix(e.SecurityPolicyURI, ua.SecurityPolicyURIPrefix)+"/"+
+			strings.TrimPrefix(e.SecurityMode.String(), "MessageSecurityMode"))
+	}
+	return fmt.Sprintf("%d endpoints: %s", len(endpoints), strings.Join(list, ", "))
+}
+
+// sampleNodeId 样本点位，testSample 为空时使用 nodeIds 的第一个点位
+func sampleNodeId(config map[string]interface{}) string {
+	if nodeId, ok := conntest.Sample(config).(string); ok && nodeId != "" {
+		return nodeId
+	}
+	switch nodeIds := config["nodeIds"].(type) {
+	case []string:
+		if len(nodeIds) > 0 {
+			return nodeIds[0]
+		}
+	case []interface{}:
+		if len(nodeIds) > 0 {
+			return fmt.Sprint(nodeIds[0])
+		}
+	}
+	return ""
+}
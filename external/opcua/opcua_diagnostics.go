@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/rulego/rulego"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&DiagnosticsNode{})
+}
+
+// DiagnosticsNode opcua服务器诊断节点
+// 读取服务器标准状态和诊断节点（ServerStatus、ServerDiagnosticsSummary、SubscriptionDiagnosticsArray），
+// 结果会重新赋值到msg.Data，通过`Success`链传给下一个节点，可用于服务器过载、会话泄漏告警
+// 结果格式：
+//
+//	{
+//	  "serverStatus": {"state": "Running", "startTime": "...", "currentTime": "...", "secondsTillShutdown": 0, "productName": "..."},
+//	  "diagnosticsEnabled": true,
+//	  "summary": {"currentSessionCount": 3, "cumulatedSessionCount": 10, "currentSubscriptionCount": 2, "rejectedRequestsCount": 0, ...},
+//	  "subscriptions": [{"sessionId": "ns=1;i=100", "subscriptionId": 1, "monitoredItemCount": 20, "discardedMessageCount": 0, ...}],
+//	  "errors": {"SubscriptionDiagnosticsArray": "..."}
+//	}
+//
+// 服务器未提供的诊断节点记录在 errors 中，读取请求失败则流转到`Failure`链
+type DiagnosticsNode struct {
+	base.SharedNode[*opcua.Client]
+	//节点配置
+	Config Configuration
+}
+
+func (x *DiagnosticsNode) New() types.Node {
+	return &DiagnosticsNode{
+		Config: Configuration{
+			Server:             "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+			Policy:             "None",
+			Mode:               "none",
+			Auth:               "anonymous",
+			MaxAge:             opcuaClient.DefaultMaxAge,
+			TimestampsToReturn: opcuaClient.DefaultTimestampsToReturn,
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *DiagnosticsNode) Type() string {
+	return "x/opcuaDiagnostics"
+}
+
+func (x *DiagnosticsNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	x.RuleConfig = ruleConfig
+	_ = x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*opcua.Client, error) {
+		return x.initClient()
+	}, func(client *opcua.Client) error {
+		return opcuaClient.CloseClient(client)
+	})
+	return err
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *DiagnosticsNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	c, cancel := opcuaClient.WithTimeout(ctx.GetContext(), time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+	result, err := opcuaClient.ReadServerDiagnostics(c, client)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if dbyte, err := json.Marshal(result); err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		msg.SetData(string(dbyte))
+		ctx.TellSuccess(msg)
+	}
+}
+
+// Destroy 清理资源
+func (x *DiagnosticsNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *DiagnosticsNode) Desc() string {
+	return "OPC-UA client for reading server status and diagnostics such as session and subscription counts. Routes to Success/Failure"
+}
+
+func (x *DiagnosticsNode) initClient() (*opcua.Client, error) {
+	holder := opcuaClient.DefaultHolder(x.Config)
+	holder.Component = x.Type()
+	return holder.NewOpcUaClient()
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/endpoint/opcuaserver"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDiagnosticsNode(t *testing.T) {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48408,
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DiagnosticsNode{})
+	node, err := test.CreateAndInitNode("x/opcuaDiagnostics", types.Configuration{
+		"server":  "opc.tcp://localhost:48408",
+		"timeout": 5,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       "{}",
+			AfterSleep: time.Millisecond * 500,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var result opcuaClient.ServerDiagnostics
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.NotNil(t, result.ServerStatus)
+		assert.Equal(t, "Running", result.ServerStatus.State)
+		assert.False(t, result.ServerStatus.CurrentTime.IsZero())
+	})
+}
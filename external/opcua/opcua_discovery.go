@@ -0,0 +1,146 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// KeyEndpointUrl 选中端点地址的元数据key
+	KeyEndpointUrl = "endpointUrl"
+	// KeySecurityPolicy 选中端点安全策略的元数据key
+	KeySecurityPolicy = "securityPolicy"
+	// KeySecurityMode 选中端点安全模式的元数据key
+	KeySecurityMode = "securityMode"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&DiscoveryNode{})
+}
+
+// DiscoveryConfiguration 节点配置
+type DiscoveryConfiguration struct {
+	//Discovery URL, eg. opc.tcp://localhost:4840, supports ${metadata.xx} variables
+	Server string `json:"server" label:"Discovery URL" desc:"OPC UA discovery url, format: opc.tcp://host:port, supports ${metadata.xx}" required:"true"`
+	//Preferred Security Policy, one of None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss, deprecated Basic128Rsa15, Basic256, empty means any
+	Policy string `json:"policy" label:"Security Policy" desc:"Preferred security policy, empty selects the highest security level"`
+	//Preferred Security Mode, one of None, Sign, SignAndEncrypt, empty means any
+	Mode string `json:"mode" label:"Security Mode" desc:"Preferred security mode, empty selects the highest security level"`
+	//FindServers whether to call FindServers on the discovery url
+	FindServers bool `json:"findServers" label:"Find Servers" desc:"Whether to call FindServers on the discovery url"`
+	//Timeout discovery timeout in seconds
+	Timeout int `json:"timeout" label:"Timeout" desc:"Discovery timeout in seconds"`
+}
+
+// DiscoveryNode opcua发现节点
+// 通过 GetEndpoints/FindServers 查询发现地址，结果会重新赋值到msg.Data，通过`Success`链传给下一个节点
+// 与期望安全策略、安全模式最匹配的端点会写入元数据：endpointUrl、securityPolicy、securityMode
+// 结果格式：
+//
+//	{
+//	  "servers": [{"applicationUri": "urn:server", "applicationName": "Server", "applicationType": "Server", "discoveryUrls": ["opc.tcp://localhost:4840"]}],
+//	  "endpoints": [{"endpointUrl": "opc.tcp://localhost:4840", "securityPolicy": "Basic256Sha256", "securityMode": "SignAndEncrypt", "securityLevel": 3, "userTokenTypes": ["Anonymous", "UserName"]}],
+//	  "selected": {"endpointUrl": "opc.tcp://localhost:4840", "securityPolicy": "Basic256Sha256", "securityMode": "SignAndEncrypt"}
+//	}
+//
+// 查询失败或者没有匹配的端点，流程转到`Failure`链
+type DiscoveryNode struct {
+	//节点配置
+	Config         DiscoveryConfiguration
+	serverTemplate str.Template
+}
+
+func (x *DiscoveryNode) New() types.Node {
+	return &DiscoveryNode{
+		Config: DiscoveryConfiguration{
+			Server:  "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+			Timeout: 10,
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *DiscoveryNode) Type() string {
+	return "x/opcuaDiscovery"
+}
+
+func (x *DiscoveryNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Server) == "" {
+		return errors.New("server can not be empty")
+	}
+	if _, err = opcuaClient.ParseSecurityMode(x.Config.Mode); err != nil {
+		return err
+	}
+	x.serverTemplate = str.NewTemplate(x.Config.Server)
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *DiscoveryNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	server := x.serverTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	timeout := time.Duration(x.Config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	c, cancel := opcuaClient.WithTimeout(ctx.GetContext(), timeout)
+	defer cancel()
+
+	result, err := opcuaClient.Discover(c, server, x.Config.FindServers, x.Config.Policy, x.Config.Mode)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	dbyte, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(string(dbyte))
+	if result.Selected == nil {
+		ctx.TellFailure(msg, errors.New("no matching endpoint found for policy "+x.Config.Policy+" and mode "+x.Config.Mode))
+		return
+	}
+	msg.Metadata.PutValue(KeyEndpointUrl, result.Selected.EndpointURL)
+	msg.Metadata.PutValue(KeySecurityPolicy, result.Selected.SecurityPolicy)
+	msg.Metadata.PutValue(KeySecurityMode, result.Selected.SecurityMode)
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+func (x *DiscoveryNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *DiscoveryNode) Desc() string {
+	return "OPC-UA discovery, query endpoints and servers of a discovery url and select the best matching endpoint. Routes to Success/Failure"
+}
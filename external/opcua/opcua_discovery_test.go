@@ -0,0 +1,86 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/endpoint/opcuaserver"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDiscoveryNode(t *testing.T) {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48403,
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DiscoveryNode{})
+	var nodeType = "x/opcuaDiscovery"
+
+	t.Run("InitError", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(nodeType, types.Configuration{
+			"server": "opc.tcp://localhost:48403",
+			"mode":   "encrypt",
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Discover", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(nodeType, types.Configuration{
+			"server":      "${metadata.server}",
+			"policy":      "None",
+			"mode":        "None",
+			"findServers": true,
+			"timeout":     5,
+		}, Registry)
+		assert.Nil(t, err)
+
+		metaData := types.NewMetadata()
+		metaData.PutValue("server", "opc.tcp://localhost:48403")
+		msgList := []test.Msg{
+			{
+				MetaData:   metaData,
+				DataType:   types.JSON,
+				MsgType:    "TEST",
+				Data:       "{}",
+				AfterSleep: time.Millisecond * 500,
+			},
+		}
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			var result opcuaClient.DiscoveryResult
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+			assert.True(t, len(result.Endpoints) > 0)
+			assert.True(t, len(result.Servers) > 0)
+			assert.Equal(t, "None", msg.Metadata.GetValue(KeySecurityPolicy))
+			assert.Equal(t, "None", msg.Metadata.GetValue(KeySecurityMode))
+		})
+	})
+}
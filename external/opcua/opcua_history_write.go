@@ -0,0 +1,232 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// KeyHistoryUpdateResults 每个点位历史数据写入结果的元数据key
+const KeyHistoryUpdateResults = "historyUpdateResults"
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&HistoryWriteNode{})
+}
+
+// HistoryWriteConfiguration 节点配置
+type HistoryWriteConfiguration struct {
+	Configuration `json:",squash"`
+	// UpdateMode 默认历史数据更新方式：Insert, Replace, Update，点位可以通过 mode 字段覆盖
+	UpdateMode string `json:"updateMode" label:"Update Mode" desc:"Default history update mode: Insert, Replace, Update, can be overridden by the mode field of each node"`
+}
+
+// HistoryUpdateResult 单个点位历史数据写入结果
+type HistoryUpdateResult struct {
+	WriteResult
+	// OperationResults 每条历史数据的写入结果，与 values 一一对应
+	OperationResults []uint32 `json:"operationResults,omitempty"`
+}
+
+// HistoryWriteNode opcua历史数据写入节点
+// 通过 HistoryUpdate 服务把消息负荷 msg.Data 中的历史数据写入到服务器历史库，用于断网后的数据补录，格式为：
+//
+//	{
+//	  "nodeId": "ns=3;i=1009",
+//	  "mode": "Insert",
+//	  "values": [
+//	    {"value": 12.5, "sourceTime": "2025-01-01T00:00:00Z"},
+//	    {"value": 12.6, "dataType": "Double", "sourceTime": "2025-01-01T00:00:01Z", "quality": 0}
+//	  ]
+//	}
+//
+// 也可以是多个点位组成的数组。mode 可选，支持：Insert, Replace, Update，不指定时使用配置 updateMode
+// 每个点位的写入结果以 JSON 数组保存在元数据 historyUpdateResults 中：
+// [{"nodeId":"ns=3;i=1009","status":"OK","statusCode":0,"operationResults":[0,0]}]
+// 所有点位和每条历史数据都没有返回 Bad 状态，流转到`Success`链
+// 否则流程转到`Failure`链，可以配合重试或者缓存节点实现断点续传
+type HistoryWriteNode struct {
+	base.SharedNode[*opcua.Client]
+	//节点配置
+	Config HistoryWriteConfiguration
+}
+
+func (x *HistoryWriteNode) New() types.Node {
+	return &HistoryWriteNode{
+		Config: HistoryWriteConfiguration{
+			Configuration: Configuration{
+				Server: "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+				Policy: "None",
+				Mode:   "none",
+				Auth:   "anonymous",
+			},
+			UpdateMode: opcuaClient.DefaultHistoryUpdateMode,
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *HistoryWriteNode) Type() string {
+	return "x/opcuaHistoryWrite"
+}
+
+func (x *HistoryWriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if _, err = opcuaClient.ParsePerformUpdateType(x.Config.UpdateMode); err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	_ = x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*opcua.Client, error) {
+		return x.initClient()
+	}, func(client *opcua.Client) error {
+		return opcuaClient.CloseClient(client)
+	})
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *HistoryWriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items, err := parseHistoryUpdateItems(msg.GetData())
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+
+	results := make([]HistoryUpdateResult, len(items))
+	details := make([]*ua.UpdateDataDetails, 0, len(items))
+	// index 记录 details 中每一项对应的 items 下标
+	index := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i] = HistoryUpdateResult{WriteResult: WriteResult{NodeId: item.NodeId}}
+		d, err := opcuaClient.NewUpdateDataDetails(item, x.Config.UpdateMode)
+		if err != nil {
+			results[i].setError(ua.StatusBadInvalidArgument, err)
+			continue
+		}
+		details = append(details, d)
+		index = append(index, i)
+	}
+
+	if len(details) > 0 {
+		c, cancel := opcuaClient.WithTimeout(ctx.GetContext(), time.Duration(x.Config.Timeout)*time.Second)
+		defer cancel()
+		resp, err := opcuaClient.HistoryUpdate(c, client, details)
+		if err != nil {
+			opcuaClient.RecordWriteErrors(x.Type(), x.Config.Server, len(items))
+			ctx.TellFailure(msg, err)
+			return
+		}
+		for j, r := range resp {
+			if r == nil {
+				continue
+			}
+			result := &results[index[j]]
+			result.setStatus(r.StatusCode)
+			for _, status := range r.OperationResults {
+				result.OperationResults = append(result.OperationResults, uint32(status))
+			}
+		}
+	}
+
+	var errs []string
+	for _, r := range results {
+		if failed := historyUpdateFailed(r); failed != "" {
+			errs = append(errs, failed)
+		}
+	}
+	if b, err := json.Marshal(results); err == nil {
+		msg.Metadata.PutValue(KeyHistoryUpdateResults, string(b))
+	}
+	if len(errs) > 0 {
+		opcuaClient.RecordWriteErrors(x.Type(), x.Config.Server, len(errs))
+		ctx.TellFailure(msg, fmt.Errorf("history update failed: %s", strings.Join(errs, "; ")))
+	} else {
+		ctx.TellSuccess(msg)
+	}
+}
+
+// Destroy 清理资源
+func (x *HistoryWriteNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *HistoryWriteNode) Desc() string {
+	return "OPC-UA client for inserting or replacing historical values via HistoryUpdate. Routes to Success/Failure"
+}
+
+func (x *HistoryWriteNode) initClient() (*opcua.Client, error) {
+	holder := opcuaClient.DefaultHolder(x.Config)
+	holder.Component = x.Type()
+	return holder.NewOpcUaClient()
+}
+
+// parseHistoryUpdateItems 解析消息负荷，支持单个点位对象或者点位数组
+func parseHistoryUpdateItems(data string) ([]opcuaClient.HistoryUpdateItem, error) {
+	data = strings.TrimSpace(data)
+	var items []opcuaClient.HistoryUpdateItem
+	if strings.HasPrefix(data, "[") {
+		if err := json.Unmarshal([]byte(data), &items); err != nil {
+			return nil, err
+		}
+	} else {
+		var item opcuaClient.HistoryUpdateItem
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no history values to update")
+	}
+	return items, nil
+}
+
+// historyUpdateFailed 返回点位写入失败的原因，成功返回空字符串
+func historyUpdateFailed(r HistoryUpdateResult) string {
+	if r.Status == "" {
+		return fmt.Sprintf("%s: no result", r.NodeId)
+	}
+	if opcuaClient.IsBadStatus(ua.StatusCode(r.StatusCode)) {
+		return fmt.Sprintf("%s: %s", r.NodeId, r.Error)
+	}
+	for i, status := range r.OperationResults {
+		if opcuaClient.IsBadStatus(ua.StatusCode(status)) {
+			return fmt.Sprintf("%s: value %d %s", r.NodeId, i, ua.StatusCode(status).Error())
+		}
+	}
+	return ""
+}
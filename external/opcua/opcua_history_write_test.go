@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/endpoint/opcuaserver"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestHistoryWriteNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&HistoryWriteNode{})
+
+	// 非法的更新方式
+	_, err := test.CreateAndInitNode("x/opcuaHistoryWrite", types.Configuration{
+		"server":     "opc.tcp://localhost:48412",
+		"updateMode": "Remove",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err = ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48412,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Double", "value": 20.5},
+		},
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	node, err := test.CreateAndInitNode("x/opcuaHistoryWrite", types.Configuration{
+		"server":     "opc.tcp://localhost:48412",
+		"updateMode": "Replace",
+		"timeout":    5,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "INVALID",
+			Data:       `[{"nodeId":"ns=1;s=Temperature","values":[{"value":12.5}]}]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "BACKFILL",
+			Data:       `{"nodeId":"ns=1;s=Temperature","values":[{"value":12.5,"sourceTime":"2025-01-01T00:00:00Z"}]}`,
+			AfterSleep: time.Millisecond * 500,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		// 内置服务器不支持历史数据，两条消息都流转到 Failure 链
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+		if msg.Type == "INVALID" {
+			// 缺少时间戳，不发送请求，记录点位错误
+			var results []HistoryUpdateResult
+			assert.Nil(t, json.Unmarshal([]byte(msg.Metadata.GetValue(KeyHistoryUpdateResults)), &results))
+			assert.Equal(t, 1, len(results))
+			assert.Equal(t, "ns=1;s=Temperature", results[0].NodeId)
+			assert.Equal(t, "StatusBadInvalidArgument", results[0].Status)
+			assert.True(t, strings.Contains(results[0].Error, "sourceTime"))
+		} else {
+			assert.True(t, strings.Contains(err.Error(), "ServiceUnsupported"))
+		}
+	})
+}
+
+func TestParseHistoryUpdateItems(t *testing.T) {
+	items, err := parseHistoryUpdateItems(`{"nodeId":"ns=1;s=A","values":[{"value":1,"sourceTime":"2025-01-01T00:00:00Z"}]}`)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "ns=1;s=A", items[0].NodeId)
+	assert.Equal(t, 1, len(items[0].Values))
+
+	items, err = parseHistoryUpdateItems(` [{"nodeId":"ns=1;s=A","mode":"Insert"},{"nodeId":"ns=1;s=B"}]`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, "Insert", items[0].Mode)
+
+	_, err = parseHistoryUpdateItems(`[]`)
+	assert.NotNil(t, err)
+	_, err = parseHistoryUpdateItems(`not json`)
+	assert.NotNil(t, err)
+}
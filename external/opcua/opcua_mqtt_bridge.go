@@ -0,0 +1,431 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/external/sparkplug"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 桥接的负荷格式
+const (
+	// BridgeFormatJSON JSON 格式
+	BridgeFormatJSON = "json"
+	// BridgeFormatSparkplug Sparkplug B 格式，分组作为边缘节点下的设备
+	BridgeFormatSparkplug = "sparkplug"
+)
+
+// KeyPublished 桥接节点输出消息中发布的 MQTT 消息数量的元数据key
+const KeyPublished = "published"
+
+// bridgeTimeout 连接和发布的超时时间
+const bridgeTimeout = 5 * time.Second
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&MqttBridgeNode{})
+}
+
+// BridgeGroup 点位分组到 MQTT 主题的映射
+type BridgeGroup struct {
+	// Name 分组名称，sparkplug 格式作为设备标识
+	Name string `json:"name"`
+	// NodeIds 分组的点位，支持 * 结尾的前缀匹配，eg. ns=2;s=Line1.*，也可以使用 tag:<名称> 读取时的点位名称
+	NodeIds []string `json:"nodeIds"`
+	// Topic json 格式的 MQTT 主题模板，可以使用 ${group}，perTag 时还可以使用 ${name}、${nodeId}、${displayName}，以及 ${metadata.key}
+	Topic string `json:"topic"`
+	// Format 负荷格式：json（默认）、sparkplug
+	Format string `json:"format"`
+	// PerTag json 格式每个点位发布一条消息，负荷为点位数据；否则分组发布一条消息，负荷为 {"group":"", "timestamp":"", "values":{"名称":值}}
+	PerTag bool `json:"perTag"`
+	// Qos json 格式的 QoS
+	Qos byte `json:"qos"`
+	// Retain json 格式是否保留消息
+	Retain bool `json:"retain"`
+	// Metrics sparkplug 格式分组的指标定义，指标名称为点位名称，出生证明中发布，未定义的点位不发布
+	Metrics []sparkplug.MetricConfig `json:"metrics"`
+}
+
+// BridgeSparkplugConfig sparkplug 格式的边缘节点配置
+type BridgeSparkplugConfig struct {
+	// GroupId 组标识
+	GroupId string `json:"groupId"`
+	// EdgeNodeId 边缘节点标识
+	EdgeNodeId string `json:"edgeNodeId"`
+	// UseAliases 是否为指标分配别名
+	UseAliases bool `json:"useAliases"`
+}
+
+// MqttBridgeConfiguration OPC UA 到 MQTT 桥接节点配置
+type MqttBridgeConfiguration struct {
+	// Server MQTT 服务器地址，格式：host:port
+	Server string `json:"server" label:"Server" desc:"MQTT broker address, format: host:port" required:"true" ref:"primary"`
+	// Username 用户名
+	Username string `json:"username" label:"Username" desc:"MQTT authentication username" ref:"shared"`
+	// Password 密码
+	Password string `json:"password" label:"Password" desc:"MQTT authentication password" ref:"shared"`
+	// ClientID 客户端ID，为空随机生成，sparkplug 边缘节点使用 ClientID 加 -sparkplug 后缀
+	ClientID string `json:"clientId" label:"Client ID" desc:"MQTT client unique identifier, default is random. The Sparkplug edge node appends -sparkplug"`
+	// CAFile CA 证书文件
+	CAFile string `json:"caFile" label:"CA File" desc:"CA certificate file path for TLS" ref:"shared"`
+	// CertFile 客户端证书文件
+	CertFile string `json:"certFile" label:"Cert File" desc:"TLS client certificate file path" ref:"shared"`
+	// CertKeyFile 客户端私钥文件
+	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"TLS client private key file path" ref:"shared"`
+	// TLS TLS 配置，证书文件为空时使用 caFile、certFile、certKeyFile
+	TLS tlsconfig.Config `json:"tls" label:"TLS" desc:"TLS settings, certificate files fall back to caFile, certFile and certKeyFile"`
+	// Groups 点位分组和 MQTT 主题的映射
+	Groups []BridgeGroup `json:"groups" label:"Groups" desc:"OPC UA node groups mapped to MQTT topics with json or sparkplug payloads" required:"true"`
+	// Sparkplug sparkplug 格式的边缘节点
+	Sparkplug BridgeSparkplugConfig `json:"sparkplug" label:"Sparkplug" desc:"Sparkplug edge node used by groups with the sparkplug format"`
+}
+
+// MqttBridgeNode OPC UA 到 MQTT 桥接节点，按声明的分组把 OPC UA 点位数据发布到 MQTT，不需要为每个点位单独连线。
+// 消息负荷 msg.Data 为 OPC UA 读取或者订阅的数据数组，eg. endpoint/opcua、x/opcuaRead、x/opcuaSubscribe 的输出。
+// 点位按 nodeIds 匹配到分组，一个点位可以属于多个分组，不属于任何分组的点位忽略：
+//   - json 格式：按主题模板发布，perTag 时每个点位一条消息，否则每个分组一条消息
+//   - sparkplug 格式：分组作为设备发布 DBIRTH 和 DDATA，指标需要在分组中定义
+//
+// 全部发布成功，流转到`Success`链，元数据 published 为发布的消息数量，否则流转到`Failure`链
+type MqttBridgeNode struct {
+	base.SharedNode[*mqttBridge]
+	//节点配置
+	Config MqttBridgeConfiguration
+	// topicTemplates 每个分组的主题模板
+	topicTemplates []str.Template
+	// metrics sparkplug 格式分组定义的指标名称
+	metrics []map[string]bool
+}
+
+// Type 返回组件类型
+func (x *MqttBridgeNode) Type() string {
+	return "x/opcuaMqttBridge"
+}
+
+// New 默认参数
+func (x *MqttBridgeNode) New() types.Node {
+	return &MqttBridgeNode{
+		Config: MqttBridgeConfiguration{
+			Server: "127.0.0.1:1883",
+			Sparkplug: BridgeSparkplugConfig{
+				GroupId:    "rulego",
+				EdgeNodeId: "opcua",
+				UseAliases: true,
+			},
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *MqttBridgeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if len(x.Config.Groups) == 0 {
+		return errors.New("groups can not be empty")
+	}
+	if err = x.Config.TLS.WithFiles(x.Config.CAFile, x.Config.CertFile, x.Config.CertKeyFile).Validate(); err != nil {
+		return err
+	}
+	x.topicTemplates = make([]str.Template, len(x.Config.Groups))
+	x.metrics = make([]map[string]bool, len(x.Config.Groups))
+	edgeConfig := sparkplug.PublishConfiguration{
+		Server:      x.Config.Server,
+		Username:    x.Config.Username,
+		Password:    x.Config.Password,
+		CAFile:      x.Config.CAFile,
+		CertFile:    x.Config.CertFile,
+		CertKeyFile: x.Config.CertKeyFile,
+		TLS:         x.Config.TLS,
+		GroupId:     x.Config.Sparkplug.GroupId,
+		EdgeNodeId:  x.Config.Sparkplug.EdgeNodeId,
+		UseAliases:  x.Config.Sparkplug.UseAliases,
+	}
+	if x.Config.ClientID != "" {
+		edgeConfig.ClientID = x.Config.ClientID + "-sparkplug"
+	}
+	useJSON := false
+	for i := range x.Config.Groups {
+		g := &x.Config.Groups[i]
+		if g.Name == "" || len(g.NodeIds) == 0 {
+			return fmt.Errorf("group %d: name and nodeIds can not be empty", i)
+		}
+		if g.Format == "" {
+			g.Format = BridgeFormatJSON
+		}
+		switch g.Format {
+		case BridgeFormatJSON:
+			if g.Topic == "" {
+				return fmt.Errorf("group %s: topic can not be empty", g.Name)
+			}
+			if g.Qos > 2 {
+				return fmt.Errorf("group %s: invalid qos %d", g.Name, g.Qos)
+			}
+			x.topicTemplates[i] = str.NewTemplate(g.Topic)
+			useJSON = true
+		case BridgeFormatSparkplug:
+			if len(g.Metrics) == 0 {
+				return fmt.Errorf("group %s: sparkplug metrics can not be empty", g.Name)
+			}
+			x.metrics[i] = make(map[string]bool, len(g.Metrics))
+			for _, m := range g.Metrics {
+				x.metrics[i][m.Name] = true
+			}
+			edgeConfig.Devices = append(edgeConfig.Devices, sparkplug.DeviceConfig{DeviceId: g.Name, Metrics: g.Metrics})
+		default:
+			return fmt.Errorf("group %s: unsupported format %s", g.Name, g.Format)
+		}
+	}
+	var edge *sparkplug.EdgeConfig
+	if len(edgeConfig.Devices) > 0 {
+		conf, err := edgeConfig.EdgeConfig()
+		if err != nil {
+			return err
+		}
+		// 先检查指标定义，避免连接时才发现配置错误
+		if err = conf.Validate(); err != nil {
+			return err
+		}
+		edge = &conf
+	}
+	resourcePath := x.Config.Server + "/" + x.Config.ClientID + "/" + x.Config.Sparkplug.GroupId + "/" + x.Config.Sparkplug.EdgeNodeId
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), resourcePath, ruleConfig.NodeClientInitNow, func() (*mqttBridge, error) {
+		return newMqttBridge(x.Config, useJSON, edge)
+	}, func(bridge *mqttBridge) error {
+		if bridge != nil {
+			return bridge.Close()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *MqttBridgeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var list []opcuaClient.Data
+	if err := json.Unmarshal([]byte(msg.GetData()), &list); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bridge, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	published := 0
+	for i, g := range x.Config.Groups {
+		members := make([]opcuaClient.Data, 0, len(list))
+		for _, d := range list {
+			if g.Match(d) {
+				members = append(members, d)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		var n int
+		if g.Format == BridgeFormatSparkplug {
+			n, err = x.publishSparkplug(bridge, i, members)
+		} else {
+			n, err = x.publishJSON(bridge, i, evn, members)
+		}
+		published += n
+		if err != nil {
+			ctx.TellFailure(msg, fmt.Errorf("group %s: %w", g.Name, err))
+			return
+		}
+	}
+	msg.Metadata.PutValue(KeyPublished, fmt.Sprint(published))
+	ctx.TellSuccess(msg)
+}
+
+// publishJSON 按主题模板发布 json 格式的消息，返回发布的消息数量
+func (x *MqttBridgeNode) publishJSON(bridge *mqttBridge, i int, evn map[string]interface{}, members []opcuaClient.Data) (int, error) {
+	g := x.Config.Groups[i]
+	vars := make(map[string]interface{}, len(evn)+4)
+	for k, v := range evn {
+		vars[k] = v
+	}
+	vars["group"] = g.Name
+	if !g.PerTag {
+		values := make(map[string]interface{}, len(members))
+		timestamp := time.Time{}
+		for _, d := range members {
+			values[BridgeTagName(d)] = d.Value
+			if d.SourceTime.After(timestamp) {
+				timestamp = d.SourceTime
+			}
+		}
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		payload, err := json.Marshal(map[string]interface{}{"group": g.Name, "timestamp": timestamp, "values": values})
+		if err != nil {
+			return 0, err
+		}
+		return 1, bridge.publish(x.topicTemplates[i].Execute(vars), g.Qos, g.Retain, payload)
+	}
+	for n, d := range members {
+		vars["name"] = BridgeTagName(d)
+		vars["nodeId"] = d.NodeId
+		vars["displayName"] = d.DisplayName
+		payload, err := json.Marshal(d)
+		if err != nil {
+			return n, err
+		}
+		if err = bridge.publish(x.topicTemplates[i].Execute(vars), g.Qos, g.Retain, payload); err != nil {
+			return n, err
+		}
+	}
+	return len(members), nil
+}
+
+// publishSparkplug 发布分组设备的 DDATA，只发布分组定义的指标，返回发布的消息数量
+func (x *MqttBridgeNode) publishSparkplug(bridge *mqttBridge, i int, members []opcuaClient.Data) (int, error) {
+	values := make(map[string]interface{}, len(members))
+	for _, d := range members {
+		if name := BridgeTagName(d); x.metrics[i][name] {
+			values[name] = d.Value
+		}
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+	if err := bridge.edge.Publish(x.Config.Groups[i].Name, values); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// Destroy 销毁组件
+func (x *MqttBridgeNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *MqttBridgeNode) Desc() string {
+	return "OPC UA to MQTT bridge mapping node groups to templated MQTT topics with JSON or Sparkplug B payloads. Routes to Success/Failure"
+}
+
+// Match 点位是否属于分组，按 nodeId 或者点位名称匹配，* 结尾表示前缀匹配
+func (g BridgeGroup) Match(d opcuaClient.Data) bool {
+	for _, pattern := range g.NodeIds {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(d.NodeId, prefix) || d.Tag != "" && strings.HasPrefix(d.Tag, prefix) {
+				return true
+			}
+		} else if d.NodeId == pattern || d.Tag != "" && d.Tag == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// BridgeTagName 点位在 MQTT 负荷中的名称，依次使用点位名称、显示名称和 nodeId
+func BridgeTagName(d opcuaClient.Data) string {
+	if d.Tag != "" {
+		return d.Tag
+	}
+	if d.DisplayName != "" {
+		return d.DisplayName
+	}
+	return d.NodeId
+}
+
+// mqttBridge json 格式的 MQTT 客户端和 sparkplug 边缘节点
+type mqttBridge struct {
+	// client json 格式的客户端，没有 json 格式的分组时为 nil
+	client paho.Client
+	// edge sparkplug 边缘节点，没有 sparkplug 格式的分组时为 nil
+	edge *sparkplug.EdgeNode
+}
+
+// newMqttBridge 连接 MQTT 服务器，断开后自动重连
+func newMqttBridge(conf MqttBridgeConfiguration, useJSON bool, edge *sparkplug.EdgeConfig) (*mqttBridge, error) {
+	b := &mqttBridge{}
+	if useJSON {
+		opts := paho.NewClientOptions()
+		opts.AddBroker(conf.Server)
+		opts.SetUsername(conf.Username)
+		opts.SetPassword(conf.Password)
+		if conf.ClientID == "" {
+			opts.SetClientID("rulego/" + str.RandomStr(8))
+		} else {
+			opts.SetClientID(conf.ClientID)
+		}
+		opts.SetAutoReconnect(true)
+		if tlsConf := conf.TLS.WithFiles(conf.CAFile, conf.CertFile, conf.CertKeyFile); tlsConf.Enabled() {
+			tlsConfig, err := tlsConf.ClientConfig()
+			if err != nil {
+				return nil, err
+			}
+			opts.SetTLSConfig(tlsConfig)
+		}
+		client := paho.NewClient(opts)
+		token := client.Connect()
+		if !token.WaitTimeout(bridgeTimeout) {
+			client.Disconnect(0)
+			return nil, fmt.Errorf("mqtt connect %s timeout", conf.Server)
+		}
+		if token.Error() != nil {
+			return nil, token.Error()
+		}
+		b.client = client
+	}
+	if edge != nil {
+		node, err := sparkplug.NewEdgeNode(*edge)
+		if err != nil {
+			_ = b.Close()
+			return nil, err
+		}
+		b.edge = node
+	}
+	return b, nil
+}
+
+func (b *mqttBridge) publish(topic string, qos byte, retain bool, payload []byte) error {
+	token := b.client.Publish(topic, qos, retain, payload)
+	if !token.WaitTimeout(bridgeTimeout) {
+		return fmt.Errorf("mqtt publish %s timeout", topic)
+	}
+	return token.Error()
+}
+
+// Close 断开连接，sparkplug 边缘节点先发布死亡证明
+func (b *mqttBridge) Close() error {
+	if b.client != nil {
+		b.client.Disconnect(250)
+	}
+	if b.edge != nil {
+		return b.edge.Close()
+	}
+	return nil
+}
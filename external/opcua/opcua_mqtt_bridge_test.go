@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/external/sparkplug"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/simulator"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestBridgeGroupMatch(t *testing.T) {
+	g := BridgeGroup{NodeIds: []string{"ns=2;s=Line1.*", "ns=2;i=1001", "tank.*"}}
+	assert.True(t, g.Match(opcuaClient.Data{NodeId: "ns=2;s=Line1.Speed"}))
+	assert.True(t, g.Match(opcuaClient.Data{NodeId: "ns=2;i=1001"}))
+	assert.True(t, g.Match(opcuaClient.Data{NodeId: "ns=3;s=L", Tag: "tank.level"}))
+	assert.False(t, g.Match(opcuaClient.Data{NodeId: "ns=2;i=10011"}))
+	assert.False(t, g.Match(opcuaClient.Data{NodeId: "ns=2;s=Line2.Speed"}))
+
+	assert.Equal(t, "tank.level", BridgeTagName(opcuaClient.Data{NodeId: "ns=3;s=L", DisplayName: "L", Tag: "tank.level"}))
+	assert.Equal(t, "L", BridgeTagName(opcuaClient.Data{NodeId: "ns=3;s=L", DisplayName: "L"}))
+	assert.Equal(t, "ns=3;s=L", BridgeTagName(opcuaClient.Data{NodeId: "ns=3;s=L"}))
+}
+
+func TestMqttBridgeNode(t *testing.T) {
+	broker := simulator.StartBroker(t)
+	var lock sync.Mutex
+	received := make(map[string][]byte)
+	broker.Subscribe("#", func(msg simulator.Message) {
+		lock.Lock()
+		defer lock.Unlock()
+		received[msg.Topic] = msg.Payload
+	})
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&MqttBridgeNode{})
+	_, err := test.CreateAndInitNode("x/opcuaMqttBridge", types.Configuration{"server": broker.Addr()}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/opcuaMqttBridge", types.Configuration{
+		"server": broker.Addr(),
+		"groups": []map[string]interface{}{{"name": "line1", "nodeIds": []string{"ns=2;s=Line1.*"}, "format": "xml", "topic": "a"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/opcuaMqttBridge", types.Configuration{
+		"server": broker.Addr(),
+		"groups": []map[string]interface{}{
+			{"name": "line1", "nodeIds": []string{"ns=2;s=Line1.*"}, "topic": "plant/${metadata.site}/${group}"},
+			{"name": "line1", "nodeIds": []string{"ns=2;s=Line1.*"}, "topic": "tags/${group}/${name}", "perTag": true, "qos": 1},
+			{"name": "line2", "nodeIds": []string{"ns=2;s=Line2.*"}, "format": "sparkplug", "metrics": []map[string]interface{}{
+				{"name": "Speed", "dataType": "Double"},
+			}},
+		},
+		"sparkplug": map[string]interface{}{"groupId": "plant", "edgeNodeId": "gw1"},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	data, _ := json.Marshal([]opcuaClient.Data{
+		{NodeId: "ns=2;s=Line1.Speed", DisplayName: "Speed", Value: 12.5, SourceTime: time.Now()},
+		{NodeId: "ns=2;s=Line1.Running", DisplayName: "Running", Value: true},
+		{NodeId: "ns=2;s=Line2.Speed", DisplayName: "Speed", Value: 8.0},
+		{NodeId: "ns=2;s=Line2.Mode", DisplayName: "Mode", Value: "auto"},
+		{NodeId: "ns=2;s=Other", DisplayName: "Other", Value: 1},
+	})
+	metadata := types.NewMetadata()
+	metadata.PutValue("site", "s1")
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   metadata,
+		DataType:   types.JSON,
+		MsgType:    opcuaClient.OPC_UA_DATA_MSG_TYPE,
+		Data:       string(data),
+		AfterSleep: time.Millisecond * 500,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		// 分组消息 1 条 + 点位消息 2 条 + DDATA 1 条
+		assert.Equal(t, "4", msg.Metadata.GetValue(KeyPublished))
+	})
+
+	lock.Lock()
+	defer lock.Unlock()
+	var group struct {
+		Group  string                 `json:"group"`
+		Values map[string]interface{} `json:"values"`
+	}
+	assert.Nil(t, json.Unmarshal(received["plant/s1/line1"], &group))
+	assert.Equal(t, "line1", group.Group)
+	assert.Equal(t, map[string]interface{}{"Speed": 12.5, "Running": true}, group.Values)
+
+	var tag opcuaClient.Data
+	assert.Nil(t, json.Unmarshal(received["tags/line1/Speed"], &tag))
+	assert.Equal(t, "ns=2;s=Line1.Speed", tag.NodeId)
+	assert.NotNil(t, received["tags/line1/Running"])
+
+	assert.NotNil(t, received["spBv1.0/plant/DBIRTH/gw1/line2"])
+	p, err := sparkplug.DecodePayload(received["spBv1.0/plant/DDATA/gw1/line2"])
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(p.Metrics))
+	assert.Equal(t, 8.0, p.Metrics[0].Value)
+}
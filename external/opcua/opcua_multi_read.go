@@ -0,0 +1,400 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&MultiReadNode{})
+}
+
+// 合并结果的输出格式
+const (
+	// MultiReadOutputList 所有服务器的结果合并为一个数组，每条数据带 server 字段
+	MultiReadOutputList = "list"
+	// MultiReadOutputByServer 按服务器名称分组：{"<server>":[...]}
+	MultiReadOutputByServer = "byServer"
+	// MultiReadOutputByNode 按节点分组：{"<nodeId>":{"<server>":<value>}}
+	MultiReadOutputByNode = "byNode"
+)
+
+// 元数据 key
+const (
+	// MetadataFailedServers 读取失败的服务器名称，逗号分隔
+	MetadataFailedServers = "failedServers"
+	// MetadataMismatches 各服务器读取值不一致的节点，逗号分隔
+	MetadataMismatches = "mismatches"
+)
+
+// MultiReadServer 服务器配置，安全和认证配置为空时使用节点的配置
+type MultiReadServer struct {
+	// Name 服务器名称，结果中使用该名称标识服务器，为空时使用服务器地址
+	Name string `json:"name" label:"Name" desc:"Server name used to tag the results, defaults to the endpoint"`
+	// Server OPC UA 服务器地址
+	Server      string `json:"server" label:"Server" desc:"OPC UA server endpoint, format: opc.tcp://host:port" required:"true"`
+	Policy      string `json:"policy" label:"Security Policy" desc:"Security policy, empty uses the node setting"`
+	Mode        string `json:"mode" label:"Security Mode" desc:"Security mode, empty uses the node setting"`
+	Auth        string `json:"auth" label:"Auth Mode" desc:"Authentication mode, empty uses the node setting"`
+	Username    string `json:"username" label:"Username" desc:"Authentication username, empty uses the node setting"`
+	Password    string `json:"password" label:"Password" desc:"Authentication password, empty uses the node setting"`
+	CertFile    string `json:"certFile" label:"Cert File" desc:"Client certificate file path, empty uses the node setting"`
+	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"Client private key file path, empty uses the node setting"`
+	// NodeIds 节点映射：消息中的节点 -> 该服务器上的节点，没有映射的节点按原样读取
+	NodeIds map[string]string `json:"nodeIds" label:"Node IDs" desc:"Mapping from the node ID in the message to the node ID on this server, unmapped node IDs are read as is"`
+}
+
+// MultiReadConfiguration 节点配置
+type MultiReadConfiguration struct {
+	// Servers 服务器列表
+	Servers []MultiReadServer `json:"servers" label:"Servers" desc:"OPC UA servers to read concurrently" required:"true"`
+	//Security Policy URL or one of auto, None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss
+	Policy string `json:"policy" label:"Security Policy" desc:"Default security policy: auto, None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss"`
+	//Security Mode: one of auto, None, Sign, SignAndEncrypt
+	Mode string `json:"mode" label:"Security Mode" desc:"Default security mode: auto, None, Sign, SignAndEncrypt"`
+	//Authentication Mode: one of Anonymous, UserName, Certificate
+	Auth        string `json:"auth" label:"Auth Mode" desc:"Default authentication mode: Anonymous, UserName, Certificate"`
+	Username    string `json:"username" label:"Username" desc:"Default authentication username"`
+	Password    string `json:"password" label:"Password" desc:"Default authentication password"`
+	CertFile    string `json:"certFile" label:"Cert File" desc:"Default client certificate file path"`
+	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"Default client private key file path"`
+	//PoolSize number of sessions per server
+	PoolSize int `json:"poolSize" label:"Pool Size" desc:"Number of sessions per server, shared with other nodes using the same server"`
+	//BatchSize max node ids per read request, 0 means use server MaxNodesPerRead limit
+	BatchSize int `json:"batchSize" label:"Batch Size" desc:"Max node IDs per read request, 0 uses server MaxNodesPerRead limit"`
+	//Timeout read request timeout in seconds, 0 means no timeout
+	Timeout int `json:"timeout" label:"Timeout" desc:"Read request timeout in seconds for each server, 0 means no timeout"`
+	//MaxAge max age of cached value in milliseconds, 0 means read the latest value from device
+	MaxAge float64 `json:"maxAge" label:"Max Age" desc:"Max age of cached value in milliseconds, 0 reads the latest value from device"`
+	//TimestampsToReturn one of Source, Server, Both, Neither
+	TimestampsToReturn string `json:"timestampsToReturn" label:"Timestamps To Return" desc:"Timestamps to return: Source, Server, Both, Neither"`
+	//Output one of list, byServer, byNode
+	Output string `json:"output" label:"Output" desc:"Merged output: list (one array tagged by server), byServer (grouped by server name), byNode (values of each node grouped by server)"`
+	//RequireAll route to failure if any server fails, otherwise only when all servers fail
+	RequireAll bool `json:"requireAll" label:"Require All" desc:"Route to Failure if any server fails, otherwise only when all servers fail"`
+}
+
+// serverConfig 合并节点默认配置后的服务器连接配置
+func (c MultiReadConfiguration) serverConfig(s MultiReadServer) Configuration {
+	config := Configuration{
+		Server:      s.Server,
+		Policy:      s.Policy,
+		Mode:        s.Mode,
+		Auth:        s.Auth,
+		Username:    s.Username,
+		Password:    s.Password,
+		CertFile:    s.CertFile,
+		CertKeyFile: s.CertKeyFile,
+	}
+	if config.Policy == "" {
+		config.Policy = c.Policy
+	}
+	if config.Mode == "" {
+		config.Mode = c.Mode
+	}
+	if config.Auth == "" {
+		config.Auth = c.Auth
+	}
+	if config.Username == "" {
+		config.Username, config.Password = c.Username, c.Password
+	}
+	if config.CertFile == "" {
+		config.CertFile, config.CertKeyFile = c.CertFile, c.CertKeyFile
+	}
+	return config
+}
+
+// MultiReadData 带服务器名称的读取结果
+type MultiReadData struct {
+	Server string `json:"server"`
+	opcuaClient.Data
+}
+
+// multiReadTarget 服务器连接
+type multiReadTarget struct {
+	name    string
+	server  MultiReadServer
+	address string
+	pool    *opcuaClient.SessionPool
+}
+
+// multiReadResult 一个服务器的读取结果
+type multiReadResult struct {
+	data []MultiReadData
+	err  error
+}
+
+// MultiReadNode 从多个 OPC UA 服务器并发读取相同的节点，合并结果并标记所属服务器，
+// 用于比较冗余 PLC 的数据或者汇总多条相同产线的数据
+// 消息负荷 msg.Data 为节点列表，格式：["ns=3;i=1003","ns=3;i=1005"]，
+// 不同服务器上的节点地址不同时，通过 servers[].nodeIds 把消息中的节点映射为该服务器上的节点，结果中的 nodeId 仍为消息中的节点
+//
+// 输出格式（output）：
+//   - list：[{"server":"plcA","nodeId":"ns=3;i=1003","value":1,...},{"server":"plcB",...}]
+//   - byServer：{"plcA":[...],"plcB":[...]}
+//   - byNode：{"ns=3;i=1003":{"plcA":1,"plcB":1}}
+//
+// 质量为 BAD 的节点同样输出，value 为 null，可以通过 qualityLevel 判断
+// 部分服务器读取失败时仍然通过 Success 链输出其他服务器的结果，失败的服务器名称写入元数据 failedServers，
+// 所有服务器都失败或者 requireAll 为 true 时任一服务器失败，通过 Failure 链输出
+// 各服务器读取到的值不一致的节点写入元数据 mismatches
+//
+// 每个服务器使用独立的会话池，与连接配置相同的其他节点共享
+type MultiReadNode struct {
+	//节点配置
+	Config MultiReadConfiguration
+	// targets 服务器连接，与 Config.Servers 一一对应
+	targets []*multiReadTarget
+}
+
+func (x *MultiReadNode) New() types.Node {
+	return &MultiReadNode{
+		Config: MultiReadConfiguration{
+			Policy:             "None",
+			Mode:               "none",
+			Auth:               "anonymous",
+			MaxAge:             opcuaClient.DefaultMaxAge,
+			TimestampsToReturn: opcuaClient.DefaultTimestampsToReturn,
+			Output:             MultiReadOutputList,
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *MultiReadNode) Type() string {
+	return "x/opcuaMultiRead"
+}
+
+func (x *MultiReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if len(x.Config.Servers) == 0 {
+		return errors.New("servers is required")
+	}
+	switch x.Config.Output {
+	case "":
+		x.Config.Output = MultiReadOutputList
+	case MultiReadOutputList, MultiReadOutputByServer, MultiReadOutputByNode:
+	default:
+		return fmt.Errorf("unsupported output: %s", x.Config.Output)
+	}
+	if _, err = opcuaClient.ParseTimestampsToReturn(x.Config.TimestampsToReturn); err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(x.Config.Servers))
+	targets := make([]*multiReadTarget, 0, len(x.Config.Servers))
+	for i, s := range x.Config.Servers {
+		if s.Server == "" {
+			return fmt.Errorf("servers[%d].server is required", i)
+		}
+		name := s.Name
+		if name == "" {
+			name = s.Server
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate server name: %s", name)
+		}
+		names[name] = true
+		config := x.Config.serverConfig(s)
+		if err = opcuaClient.ValidateSecurity(config.Policy, config.Mode); err != nil {
+			return fmt.Errorf("server %s: %w", name, err)
+		}
+		targets = append(targets, &multiReadTarget{name: name, server: s, address: s.Server})
+	}
+	// 校验通过后再获取会话池，会话在第一次读取时创建
+	for _, target := range targets {
+		holder := opcuaClient.DefaultHolder(x.Config.serverConfig(target.server))
+		holder.Component = x.Type()
+		target.pool = opcuaClient.AcquireSessionPool(holder, x.Config.PoolSize)
+	}
+	x.targets = targets
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *MultiReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	nodeIds := make([]string, 0)
+	if err := json.Unmarshal([]byte(msg.GetData()), &nodeIds); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	results := make([]multiReadResult, len(x.targets))
+	var wg sync.WaitGroup
+	for i, target := range x.targets {
+		wg.Add(1)
+		go func(i int, target *multiReadTarget) {
+			defer wg.Done()
+			data, err := x.read(ctx, target, nodeIds)
+			results[i] = multiReadResult{data: data, err: err}
+		}(i, target)
+	}
+	wg.Wait()
+
+	var failed, errs []string
+	for i, result := range results {
+		if result.err != nil {
+			failed = append(failed, x.targets[i].name)
+			errs = append(errs, fmt.Sprintf("%s: %v", x.targets[i].name, result.err))
+		}
+	}
+	if len(failed) > 0 {
+		msg.Metadata.PutValue(MetadataFailedServers, strings.Join(failed, ","))
+	}
+	if len(failed) == len(x.targets) || (x.Config.RequireAll && len(failed) > 0) {
+		ctx.TellFailure(msg, fmt.Errorf("read failed: %s", strings.Join(errs, "; ")))
+		return
+	}
+	if mismatches := x.mismatches(nodeIds, results); len(mismatches) > 0 {
+		msg.Metadata.PutValue(MetadataMismatches, strings.Join(mismatches, ","))
+	}
+	if dbyte, err := json.Marshal(x.merge(nodeIds, results)); err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		msg.SetDataType(types.JSON)
+		msg.SetData(string(dbyte))
+		ctx.TellSuccess(msg)
+	}
+}
+
+// Destroy 清理资源
+func (x *MultiReadNode) Destroy() {
+	for _, target := range x.targets {
+		_ = target.pool.Release()
+	}
+	x.targets = nil
+}
+
+// Desc returns the component description
+func (x *MultiReadNode) Desc() string {
+	return "OPC-UA client reading the same node values from multiple servers concurrently and merging the results tagged by server. Routes to Success/Failure"
+}
+
+// read 从一个服务器读取节点，nodeId 映射为该服务器上的节点，结果中的 nodeId 恢复为消息中的节点
+func (x *MultiReadNode) read(ctx types.RuleContext, target *multiReadTarget, nodeIds []string) ([]MultiReadData, error) {
+	client, err := target.pool.Get()
+	if err != nil {
+		return nil, err
+	}
+	mapped := make([]string, len(nodeIds))
+	for i, nodeId := range nodeIds {
+		mapped[i] = nodeId
+		if m, ok := target.server.NodeIds[nodeId]; ok && m != "" {
+			mapped[i] = m
+		}
+	}
+	start := time.Now()
+	data, resp, err := opcuaClient.ReadWithOptionsContext(ctx.GetContext(), client, mapped, opcuaClient.ReadOptions{
+		BatchSize:          x.Config.BatchSize,
+		Timeout:            time.Duration(x.Config.Timeout) * time.Second,
+		MaxAge:             x.Config.MaxAge,
+		TimestampsToReturn: x.Config.TimestampsToReturn,
+	})
+	opcuaClient.RecordRead(x.Type(), target.address, start, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]MultiReadData, len(resp.Results))
+	for i, result := range resp.Results {
+		d := opcuaClient.Data{
+			DisplayName: data[i].DisplayName,
+			NodeId:      nodeIds[i],
+			Timestamp:   time.Now(),
+		}
+		if result != nil {
+			d.RecordTime = result.ServerTimestamp
+			d.SourceTime = result.SourceTimestamp
+			d.SetStatus(result.Status)
+			if !quality.FromOPCUA(uint32(result.Status)).IsBad() {
+				d.Value = opcuaClient.VariantValue(result.Value)
+				_, _ = d.ParseValueFor(client)
+			}
+		}
+		items[i] = MultiReadData{Server: target.name, Data: d}
+	}
+	return items, nil
+}
+
+// merge 按输出格式合并读取结果，失败的服务器不输出
+func (x *MultiReadNode) merge(nodeIds []string, results []multiReadResult) interface{} {
+	switch x.Config.Output {
+	case MultiReadOutputByServer:
+		merged := make(map[string][]MultiReadData, len(results))
+		for i, result := range results {
+			if result.err == nil {
+				merged[x.targets[i].name] = result.data
+			}
+		}
+		return merged
+	case MultiReadOutputByNode:
+		merged := make(map[string]map[string]interface{}, len(nodeIds))
+		for _, nodeId := range nodeIds {
+			merged[nodeId] = map[string]interface{}{}
+		}
+		for i, result := range results {
+			for _, d := range result.data {
+				merged[d.NodeId][x.targets[i].name] = d.Value
+			}
+		}
+		return merged
+	default:
+		merged := make([]MultiReadData, 0, len(nodeIds)*len(results))
+		for _, result := range results {
+			merged = append(merged, result.data...)
+		}
+		return merged
+	}
+}
+
+// mismatches 返回各服务器读取值不一致的节点，质量为 BAD 的值不参与比较
+func (x *MultiReadNode) mismatches(nodeIds []string, results []multiReadResult) []string {
+	values := make(map[string][]interface{}, len(nodeIds))
+	for _, result := range results {
+		for _, d := range result.data {
+			if d.QualityLevel != quality.Bad {
+				values[d.NodeId] = append(values[d.NodeId], d.Value)
+			}
+		}
+	}
+	var mismatches []string
+	for nodeId, list := range values {
+		for _, v := range list[1:] {
+			if !reflect.DeepEqual(list[0], v) {
+				mismatches = append(mismatches, nodeId)
+				break
+			}
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/endpoint/opcuaserver"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// startMultiReadServer 启动测试服务器
+func startMultiReadServer(t *testing.T, port int, name string, value float64) *opcuaserver.OpcUaServer {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": port,
+		"variables": []map[string]interface{}{
+			{"name": name, "dataType": "Double", "value": value},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	return ep
+}
+
+func TestMultiReadNodeConfig(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&MultiReadNode{})
+	_, err := test.CreateAndInitNode("x/opcuaMultiRead", types.Configuration{}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/opcuaMultiRead", types.Configuration{
+		"servers": []map[string]interface{}{{"name": "a"}},
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/opcuaMultiRead", types.Configuration{
+		"servers": []map[string]interface{}{
+			{"name": "a", "server": "opc.tcp://localhost:4840"},
+			{"name": "a", "server": "opc.tcp://localhost:4841"},
+		},
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/opcuaMultiRead", types.Configuration{
+		"servers": []map[string]interface{}{{"server": "opc.tcp://localhost:4840"}},
+		"output":  "table",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	// 服务器未配置安全和认证时使用节点的配置
+	c := MultiReadConfiguration{Auth: "username", Username: "admin", Password: "secret", Policy: "None"}
+	config := c.serverConfig(MultiReadServer{Server: "opc.tcp://localhost:4840", Auth: "anonymous"})
+	assert.Equal(t, "anonymous", config.Auth)
+	assert.Equal(t, "admin", config.Username)
+	assert.Equal(t, "None", config.Policy)
+}
+
+func TestMultiReadNode(t *testing.T) {
+	a := startMultiReadServer(t, 48415, "Temperature", 20.5)
+	defer a.Destroy()
+	b := startMultiReadServer(t, 48416, "Temp", 21.5)
+	defer b.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&MultiReadNode{})
+	servers := []map[string]interface{}{
+		{"name": "plcA", "server": "opc.tcp://localhost:48415"},
+		{"name": "plcB", "server": "opc.tcp://localhost:48416", "nodeIds": map[string]string{"ns=1;s=Temperature": "ns=1;s=Temp"}},
+	}
+	listNode, err := test.CreateAndInitNode("x/opcuaMultiRead", types.Configuration{
+		"servers": servers,
+		"timeout": 5,
+	}, Registry)
+	assert.Nil(t, err)
+	defer listNode.Destroy()
+	byNode, err := test.CreateAndInitNode("x/opcuaMultiRead", types.Configuration{
+		"servers": append(servers, map[string]interface{}{"name": "offline", "server": "opc.tcp://localhost:48417"}),
+		"timeout": 5,
+		"output":  MultiReadOutputByNode,
+	}, Registry)
+	assert.Nil(t, err)
+	defer byNode.Destroy()
+
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `["ns=1;s=Temperature"]`,
+			AfterSleep: time.Millisecond * 500,
+		},
+	}
+	test.NodeOnMsg(t, listNode, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var data []map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &data))
+		assert.Equal(t, 2, len(data))
+		assert.Equal(t, "plcA", data[0]["server"])
+		assert.Equal(t, 20.5, data[0]["value"])
+		assert.Equal(t, "plcB", data[1]["server"])
+		assert.Equal(t, "ns=1;s=Temperature", data[1]["nodeId"])
+		assert.Equal(t, 21.5, data[1]["value"])
+		assert.Equal(t, "ns=1;s=Temperature", msg.Metadata.GetValue(MetadataMismatches))
+	})
+
+	// 部分服务器读取失败时输出其他服务器的结果
+	test.NodeOnMsg(t, byNode, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var data map[string]map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &data))
+		assert.Equal(t, 20.5, data["ns=1;s=Temperature"]["plcA"])
+		assert.Equal(t, 21.5, data["ns=1;s=Temperature"]["plcB"])
+		_, ok := data["ns=1;s=Temperature"]["offline"]
+		assert.False(t, ok)
+		assert.Equal(t, "offline", msg.Metadata.GetValue(MetadataFailedServers))
+	})
+}
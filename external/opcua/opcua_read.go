@@ -17,15 +17,16 @@
 package opcua
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/gopcua/opcua"
-	"github.com/gopcua/opcua/ua"
 	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
 	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego-components-iot/pkg/tags"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/components/base"
 	"github.com/rulego/rulego/utils/maps"
@@ -40,10 +41,10 @@ func init() {
 type Configuration struct {
 	//OPC UA Server Endpoint, eg. opc.tcp://localhost:4840
 	Server string `json:"server" label:"Server" desc:"OPC UA server endpoint, format: opc.tcp://host:port" required:"true" ref:"primary"`
-	//Security Policy URL or one of None, Basic128Rsa15, Basic256, Basic256Sha256
-	Policy string `json:"policy" label:"Security Policy" desc:"Security policy: None, Basic128Rsa15, Basic256, Basic256Sha256"`
-	//Security Mode: one of None, Sign, SignAndEncrypt
-	Mode string `json:"mode" label:"Security Mode" desc:"Security mode: None, Sign, SignAndEncrypt"`
+	//Security Policy URL or one of auto, None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss, deprecated Basic128Rsa15, Basic256
+	Policy string `json:"policy" label:"Security Policy" desc:"Security policy: auto (strongest supported by client and server), None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss. Basic128Rsa15 and Basic256 are deprecated"`
+	//Security Mode: one of auto, None, Sign, SignAndEncrypt
+	Mode string `json:"mode" label:"Security Mode" desc:"Security mode: auto, None, Sign, SignAndEncrypt"`
 	//Authentication Mode: one of Anonymous, UserName, Certificate
 	Auth     string `json:"auth" label:"Auth Mode" desc:"Authentication mode: Anonymous, UserName, Certificate"`
 	Username string `json:"username" label:"Username" desc:"Authentication username" ref:"shared"`
@@ -52,6 +53,22 @@ type Configuration struct {
 	CertFile string `json:"certFile" label:"Cert File" desc:"Client certificate file path" ref:"shared"`
 	//OPC UA Server CertKeyFile Path
 	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"Client private key file path" ref:"shared"`
+	//PoolSize number of sessions shared by nodes with the same server, greater than 1 enables round-robin session pool
+	PoolSize int `json:"poolSize" label:"Pool Size" desc:"Number of sessions shared by nodes with the same server, greater than 1 enables round-robin session pool"`
+	//BatchSize max node ids per read request, 0 means use server MaxNodesPerRead limit
+	BatchSize int `json:"batchSize" label:"Batch Size" desc:"Max node IDs per read request, 0 uses server MaxNodesPerRead limit"`
+	//Timeout read request timeout in seconds, 0 means no timeout
+	Timeout int `json:"timeout" label:"Timeout" desc:"Read request timeout in seconds, 0 means no timeout"`
+	//MaxAge max age of cached value in milliseconds, 0 means read the latest value from device
+	MaxAge float64 `json:"maxAge" label:"Max Age" desc:"Max age of cached value in milliseconds, 0 reads the latest value from device"`
+	//TimestampsToReturn one of Source, Server, Both, Neither
+	TimestampsToReturn string `json:"timestampsToReturn" label:"Timestamps To Return" desc:"Timestamps to return: Source, Server, Both, Neither"`
+	//Enrich whether to add unit, min, max and description of each node to the read results, read once and cached
+	Enrich bool `json:"enrich" label:"Enrich" desc:"Add unit, min, max and description of each node to the read results, read once and cached"`
+	//Array output of array and matrix values: JSON array, one value per index or an index range
+	Array opcuaClient.ArrayOptions `json:"array" label:"Array" desc:"Output of array and matrix values: JSON array (default), explode to one value per index, and an optional index range"`
+	//CircuitBreaker fail fast after consecutive communication failures, shared by nodes with the same server
+	CircuitBreaker breaker.Config `json:"circuitBreaker" label:"Circuit Breaker" desc:"Fail fast with CIRCUIT_OPEN after consecutive communication failures instead of waiting for the connect timeout"`
 }
 
 func (c Configuration) GetServer() string {
@@ -82,6 +99,7 @@ func (c Configuration) GetCertKeyFile() string {
 // ReadNode opcua读取节点
 // 查询消息负荷 msg.Data 中节点列表点位数据
 // 节点列表格式：["ns=3;i=1003","ns=3;i=1005"]
+// 也可以使用 tag:<名称> 引用全局点位注册表（pkg/tags）中的 OPC UA 点位，结果的 value 按点位缩放换算，tag 为点位名称
 // 查询结果会重新赋值到msg.Data，通过`Success`链传给下一个节点
 // 结果格式：
 // [
@@ -91,25 +109,40 @@ func (c Configuration) GetCertKeyFile() string {
 //	   "floatValue": 0,
 //	   "nodeId": "ns=3;i=1003",
 //	   "quality": 0,
+//	   "qualityLevel": "GOOD",
+//	   "qualityCode": 0,
 //	   "recordTime": "0001-01-01T00:00:00Z",
 //	   "sourceTime": "0001-01-01T00:00:00Z",
 //	   "timestamp": "0001-01-01T00:00:00Z",
 //	}
 //
 // ]
+//
+// 数组和矩阵类型点位默认输出 JSON 数组，array.mode 为 explode 时展开为每个下标一条数据（eg. Tag[0]、Tag[1]），
+// array.indexRange 只保留指定下标范围（eg. 2:5）
+//
+// poolSize 大于1时，相同服务器地址的节点共享会话池，每次读取按轮询方式分配会话
+//
+// circuitBreaker.failureThreshold 大于0时启用熔断，连续通信失败达到阈值后不再连接服务器，直接以 CIRCUIT_OPEN 错误流转到`Failure`链
 type ReadNode struct {
 	base.SharedNode[*opcua.Client]
 	//节点配置
 	Config Configuration
+	// pool 会话池，poolSize 大于1时启用
+	pool *opcuaClient.SessionPool
+	// breaker 熔断器，相同服务器地址的节点共享
+	breaker *breaker.Breaker
 }
 
 func (x *ReadNode) New() types.Node {
 	return &ReadNode{
 		Config: Configuration{
-			Server: "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
-			Policy: "None",
-			Mode:   "none",
-			Auth:   "anonymous",
+			Server:             "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+			Policy:             "None",
+			Mode:               "none",
+			Auth:               "anonymous",
+			MaxAge:             opcuaClient.DefaultMaxAge,
+			TimestampsToReturn: opcuaClient.DefaultTimestampsToReturn,
 		},
 	}
 }
@@ -121,31 +154,75 @@ func (x *ReadNode) Type() string {
 
 func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if _, err = opcuaClient.ParseTimestampsToReturn(x.Config.TimestampsToReturn); err != nil {
+		return err
+	}
+	if err = x.Config.Array.Validate(); err != nil {
+		return err
+	}
+	if err = opcuaClient.ValidateSecurity(x.Config.Policy, x.Config.Mode); err != nil {
+		return err
+	}
+	if err = x.Config.CircuitBreaker.Validate(); err != nil {
+		return err
+	}
 	x.RuleConfig = ruleConfig
+	x.breaker = opcuaClient.AcquireBreaker(x.Config.Server, x.Config.CircuitBreaker)
+	if x.Config.PoolSize > 1 {
+		// 启用会话池时由会话池管理会话，不再创建共享客户端
+		x.pool = opcuaClient.AcquireSessionPool(x.newHolder(), x.Config.PoolSize)
+		return err
+	}
 	_ = x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*opcua.Client, error) {
 		return x.initClient()
 	}, func(client *opcua.Client) error {
-		return client.Close(context.Background())
+		return opcuaClient.CloseClient(client)
 	})
 	return err
 }
 
 // OnMsg 实现 Node 接口，处理消息
 func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	client, err := x.SharedNode.GetSafely()
+	nodeIds := make([]string, 0)
+	err := json.Unmarshal([]byte(msg.GetData()), &nodeIds)
 	if err != nil {
 		ctx.TellFailure(msg, err)
 		return
 	}
-
-	nodeIds := make([]string, 0)
-	err = json.Unmarshal([]byte(msg.GetData()), &nodeIds)
+	// tag:<名称> 引用全局点位注册表中的点位
+	refs := make([]*tags.Tag, len(nodeIds))
+	for i, nodeId := range nodeIds {
+		if nodeIds[i], refs[i], err = tags.Resolve(tags.ProtocolOPCUA, nodeId); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	// 熔断器断开时不连接服务器，直接失败
+	if err = x.breaker.Allow(); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	client, err := x.getClient()
 	if err != nil {
+		x.breaker.Done(err)
 		ctx.TellFailure(msg, err)
 		return
 	}
 
-	data, resp, err := opcuaClient.Read(client, nodeIds)
+	start := time.Now()
+	// 规则链超时或者取消时中止读取
+	data, resp, err := opcuaClient.ReadWithOptionsContext(ctx.GetContext(), client, nodeIds, opcuaClient.ReadOptions{
+		BatchSize:          x.Config.BatchSize,
+		Timeout:            time.Duration(x.Config.Timeout) * time.Second,
+		MaxAge:             x.Config.MaxAge,
+		TimestampsToReturn: x.Config.TimestampsToReturn,
+		Enrich:             x.Config.Enrich,
+	})
+	opcuaClient.RecordRead(x.Type(), x.Config.Server, start, resp, err)
+	x.breaker.Done(opcuaClient.CommError(err))
 	if err != nil {
 		ctx.TellFailure(msg, err)
 		return
@@ -153,7 +230,10 @@ func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	succ := false
 	errs := make([]string, 10)
 	for i, result := range resp.Results {
-		if result != nil && result.Status != ua.StatusOK {
+		if result == nil {
+			continue
+		}
+		if quality.FromOPCUA(uint32(result.Status)).IsBad() {
 			if len(errs) < 10 {
 				//防止查询结果过多
 				errs = append(errs, result.Status.Error())
@@ -164,17 +244,25 @@ func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 				NodeId:      data[i].NodeId,
 				RecordTime:  result.ServerTimestamp,
 				SourceTime:  result.SourceTimestamp,
-				Value:       result.Value.Value(),
-				Quality:     uint32(result.Status),
+				Value:       opcuaClient.VariantValue(result.Value),
 				Timestamp:   time.Now(),
+				// 保留 Enrich 补充的元数据
+				NodeMetadata: data[i].NodeMetadata,
 			}
-			_, _ = d.ParseValue()
+			d.SetStatus(result.Status)
+			if refs[i] != nil {
+				d.Tag = refs[i].Name
+				d.Value = refs[i].Scaled(d.Value)
+			}
+			_, _ = d.ParseValueFor(client)
 			data[i] = d
 			succ = true
 		}
 	}
 	if succ {
-		if dbyte, err := json.Marshal(data); err != nil {
+		if data, err = x.Config.Array.Apply(data); err != nil {
+			ctx.TellFailure(msg, err)
+		} else if dbyte, err := json.Marshal(data); err != nil {
 			ctx.TellFailure(msg, err)
 		} else {
 			msg.SetData(string(dbyte))
@@ -187,6 +275,13 @@ func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 
 // Destroy 清理资源
 func (x *ReadNode) Destroy() {
+	x.breaker.Release()
+	x.breaker = nil
+	if x.pool != nil {
+		_ = x.pool.Release()
+		x.pool = nil
+		return
+	}
 	_ = x.SharedNode.Close()
 }
 
@@ -195,7 +290,20 @@ func (x *ReadNode) Desc() string {
 	return "OPC-UA client for reading node values. Routes to Success/Failure"
 }
 
+// getClient 获取客户端，启用会话池时按轮询方式分配会话
+func (x *ReadNode) getClient() (*opcua.Client, error) {
+	if x.pool != nil {
+		return x.pool.Get()
+	}
+	return x.SharedNode.GetSafely()
+}
+
 func (x *ReadNode) initClient() (*opcua.Client, error) {
-	client, err := opcuaClient.DefaultHolder(x.Config).NewOpcUaClient()
-	return client, err
+	return x.newHolder().NewOpcUaClient()
+}
+
+func (x *ReadNode) newHolder() *opcuaClient.OpcUaClientHolder {
+	holder := opcuaClient.DefaultHolder(x.Config)
+	holder.Component = x.Type()
+	return holder
 }
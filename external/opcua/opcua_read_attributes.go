@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/rulego/rulego"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&ReadAttributesNode{})
+}
+
+// ReadAttributesConfiguration 节点配置
+type ReadAttributesConfiguration struct {
+	Configuration `json:",squash"`
+	// Attributes 需要读取的属性，例如：DisplayName, DataType, Description, AccessLevel, EngineeringUnits, EURange
+	Attributes []string `json:"attributes" label:"Attributes" desc:"Attributes to read, e.g. DisplayName, DataType, Description, AccessLevel, EngineeringUnits, EURange"`
+}
+
+// ReadAttributesNode opcua属性读取节点
+// 读取消息负荷 msg.Data 中节点列表的属性，节点列表格式：["ns=3;i=1003","ns=3;i=1005"]
+// 结果会重新赋值到msg.Data，通过`Success`链传给下一个节点
+// 结果格式：
+//
+//	[
+//	  {
+//	    "nodeId": "ns=3;i=1003",
+//	    "attributes": {
+//	      "DataType": "i=11",
+//	      "Description": "temperature",
+//	      "EngineeringUnits": {"displayName": "°C", "unitId": 4408652, "namespaceUri": "http://www.opcfoundation.org/UA/units/un/cefact"}
+//	    },
+//	    "errors": {"EURange": "..."}
+//	  }
+//	]
+type ReadAttributesNode struct {
+	base.SharedNode[*opcua.Client]
+	//节点配置
+	Config ReadAttributesConfiguration
+}
+
+func (x *ReadAttributesNode) New() types.Node {
+	return &ReadAttributesNode{
+		Config: ReadAttributesConfiguration{
+			Configuration: Configuration{
+				Server:             "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+				Policy:             "None",
+				Mode:               "none",
+				Auth:               "anonymous",
+				MaxAge:             opcuaClient.DefaultMaxAge,
+				TimestampsToReturn: opcuaClient.DefaultTimestampsToReturn,
+			},
+			Attributes: []string{"DisplayName", "DataType", "Description", "AccessLevel"},
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *ReadAttributesNode) Type() string {
+	return "x/opcuaReadAttributes"
+}
+
+func (x *ReadAttributesNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	x.RuleConfig = ruleConfig
+	_ = x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*opcua.Client, error) {
+		return x.initClient()
+	}, func(client *opcua.Client) error {
+		return opcuaClient.CloseClient(client)
+	})
+	return err
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *ReadAttributesNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+
+	nodeIds := make([]string, 0)
+	err = json.Unmarshal([]byte(msg.GetData()), &nodeIds)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+
+	c, cancel := opcuaClient.WithTimeout(ctx.GetContext(), time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+	result, err := opcuaClient.ReadAttributesContext(c, client, nodeIds, x.Config.Attributes)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if dbyte, err := json.Marshal(result); err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		msg.SetData(string(dbyte))
+		ctx.TellSuccess(msg)
+	}
+}
+
+// Destroy 清理资源
+func (x *ReadAttributesNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadAttributesNode) Desc() string {
+	return "OPC-UA client for reading node attributes such as DataType, Description and EngineeringUnits. Routes to Success/Failure"
+}
+
+func (x *ReadAttributesNode) initClient() (*opcua.Client, error) {
+	holder := opcuaClient.DefaultHolder(x.Config)
+	holder.Component = x.Type()
+	return holder.NewOpcUaClient()
+}
@@ -0,0 +1,32 @@
+/*
+ * Copyright 2024 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestReadAttributesNode(t *testing.T) {
+	node := &ReadAttributesNode{}
+	assert.Equal(t, "x/opcuaReadAttributes", node.Type())
+
+	newNode := node.New().(*ReadAttributesNode)
+	assert.Equal(t, "None", newNode.Config.Policy)
+	assert.Equal(t, 4, len(newNode.Config.Attributes))
+}
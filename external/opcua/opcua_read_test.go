@@ -22,9 +22,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rulego/rulego-components-iot/endpoint/opcuaserver"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
 	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
 	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
 	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
 )
 
 func TestReadNode(t *testing.T) {
@@ -166,3 +170,203 @@ func TestReadNode(t *testing.T) {
 	})
 
 }
+
+func TestReadNodeReadOptions(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+
+	node, err := test.CreateAndInitNode("x/opcuaRead", types.Configuration{
+		"server": "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+	}, Registry)
+	assert.Nil(t, err)
+	readNode := node.(*ReadNode)
+	assert.Equal(t, float64(opcuaClient.DefaultMaxAge), readNode.Config.MaxAge)
+	assert.Equal(t, opcuaClient.DefaultTimestampsToReturn, readNode.Config.TimestampsToReturn)
+
+	node, err = test.CreateAndInitNode("x/opcuaRead", types.Configuration{
+		"server":             "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+		"timeout":            3,
+		"maxAge":             0,
+		"timestampsToReturn": "source",
+	}, Registry)
+	assert.Nil(t, err)
+	readNode = node.(*ReadNode)
+	assert.Equal(t, 3, readNode.Config.Timeout)
+	assert.Equal(t, float64(0), readNode.Config.MaxAge)
+
+	_, err = test.CreateAndInitNode("x/opcuaRead", types.Configuration{
+		"server":             "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+		"timestampsToReturn": "all",
+	}, Registry)
+	assert.NotNil(t, err)
+}
+
+func TestReadNodeEnrich(t *testing.T) {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48409,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Double", "value": 20.5, "description": "room temperature"},
+		},
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	node, err := test.CreateAndInitNode("x/opcuaRead", types.Configuration{
+		"server": "opc.tcp://localhost:48409",
+		"enrich": true,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `["ns=1;s=Temperature"]`,
+			AfterSleep: time.Millisecond * 500,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var data []map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &data))
+		assert.Equal(t, 1, len(data))
+		assert.Equal(t, 20.5, data[0]["value"])
+		assert.Equal(t, "room temperature", data[0]["description"])
+		// 服务器未提供 EngineeringUnits 和 EURange
+		_, ok := data[0]["unit"]
+		assert.False(t, ok)
+	})
+}
+
+func TestReadNodeSessionPool(t *testing.T) {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48411,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Double", "value": 20.5},
+		},
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	Registry.Add(&WriteNode{})
+	config := types.Configuration{
+		"server":   "opc.tcp://localhost:48411",
+		"poolSize": 2,
+	}
+	readNode, err := test.CreateAndInitNode("x/opcuaRead", config, Registry)
+	assert.Nil(t, err)
+	writeNode, err := test.CreateAndInitNode("x/opcuaWrite", config, Registry)
+	assert.Nil(t, err)
+	defer writeNode.Destroy()
+
+	// 相同服务器地址的节点共享会话池
+	pool := readNode.(*ReadNode).pool
+	assert.NotNil(t, pool)
+	assert.True(t, pool == writeNode.(*WriteNode).pool)
+	assert.Equal(t, 2, pool.Size())
+	// 启用会话池时不创建共享客户端
+	assert.False(t, readNode.(*ReadNode).SharedNode.IsInit())
+
+	// 认证配置不同的节点不共享会话池
+	userNode, err := test.CreateAndInitNode("x/opcuaRead", types.Configuration{
+		"server":   "opc.tcp://localhost:48411",
+		"poolSize": 2,
+		"auth":     "username",
+		"username": "admin",
+		"password": "admin",
+	}, Registry)
+	assert.Nil(t, err)
+	assert.True(t, pool != userNode.(*ReadNode).pool)
+	userNode.Destroy()
+
+	// 轮询分配会话
+	c1, err := pool.Get()
+	assert.Nil(t, err)
+	c2, err := pool.Get()
+	assert.Nil(t, err)
+	c3, err := pool.Get()
+	assert.Nil(t, err)
+	assert.True(t, c1 != c2)
+	assert.True(t, c1 == c3)
+
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `["ns=1;s=Temperature"]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `["ns=1;s=Temperature"]`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}
+	test.NodeOnMsg(t, readNode, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var data []opcuaClient.Data
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &data))
+		assert.Equal(t, 20.5, data[0].FloatValue)
+	})
+
+	// 仍有节点引用时不关闭会话池
+	readNode.Destroy()
+	_, err = pool.Get()
+	assert.Nil(t, err)
+
+	writeNode.Destroy()
+	_, err = pool.Get()
+	assert.Equal(t, opcuaClient.ErrPoolClosed, err)
+}
+
+func TestReadNodeCircuitBreaker(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	_, err := test.CreateAndInitNode("x/opcuaRead", types.Configuration{
+		"server":         "opc.tcp://localhost:48418",
+		"circuitBreaker": map[string]interface{}{"failureThreshold": -1},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	// 服务器不可用，第一次连接失败后熔断器断开
+	node, err := test.CreateAndInitNode("x/opcuaRead", types.Configuration{
+		"server":         "opc.tcp://localhost:48418",
+		"circuitBreaker": map[string]interface{}{"failureThreshold": 1, "openTimeout": 60000},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	msg := test.Msg{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "TEST",
+		Data:       `["ns=1;s=Temperature"]`,
+		AfterSleep: time.Millisecond * 200,
+	}
+	var errs []error
+	test.NodeOnMsg(t, node, []test.Msg{msg, msg}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+		errs = append(errs, err)
+	})
+	assert.Equal(t, 2, len(errs))
+	assert.False(t, breaker.IsOpen(errs[0]))
+	assert.True(t, breaker.IsOpen(errs[1]))
+	assert.Equal(t, breaker.StateOpen, node.(*ReadNode).breaker.State())
+}
@@ -0,0 +1,390 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const (
+	// KeyNodeId 数据变化消息中点位的元数据key
+	KeyNodeId = "nodeId"
+	// RelationSubscribed 订阅创建成功后触发消息流转的关系
+	RelationSubscribed = "Subscribed"
+)
+
+// resubscribeInterval 检查共享客户端是否重连的间隔
+var resubscribeInterval = 5 * time.Second
+
+// 注册节点
+func init() {
+	_ = rulego.Registry.Register(&SubscribeNode{})
+}
+
+// SubscribeConfiguration 节点配置
+type SubscribeConfiguration struct {
+	Configuration `json:",squash"`
+	// NodeIds 订阅的点位，为空则使用消息负荷 msg.Data 中的点位列表
+	NodeIds []string `json:"nodeIds" label:"Node IDs" desc:"OPC UA node IDs to subscribe, empty uses the node id list in msg.Data"`
+	// Interval 发布间隔（毫秒）
+	Interval int `json:"interval" label:"Publishing Interval" desc:"Subscription publishing interval in milliseconds"`
+	// Sampling 所有点位默认的采样间隔、队列大小、丢弃策略和触发条件
+	Sampling opcuaClient.MonitoringOptions `json:"sampling" label:"Sampling" desc:"Default sampling interval, queue size, discard policy and data change trigger of all nodes"`
+	// NodeOptions 按点位覆盖的采样参数，未设置的字段使用 sampling，eg. 快速计数器和慢速温度共用一个订阅
+	NodeOptions []opcuaClient.MonitoredItem `json:"nodeOptions" label:"Node Options" desc:"Per node id overrides of the sampling options, unset fields use sampling"`
+	// ShutdownTimeout 取消订阅时等待正在处理的数据变化回调完成的最大时间（秒）
+	ShutdownTimeout int `json:"shutdownTimeout" label:"Shutdown Timeout" desc:"Max seconds to wait for in-flight data change callbacks before closing the subscription, default 10"`
+}
+
+// SubscribeNode opcua订阅节点
+// 收到消息后创建订阅，点位来自配置 nodeIds 或者消息负荷 msg.Data，格式：["ns=3;i=1003","ns=3;i=1005"]
+// 每个点位的数据变化作为新消息通过`Success`链发送到当前规则链，元数据 nodeId 为变化的点位，消息负荷格式：
+//
+//	{
+//	  "displayName": "",
+//	  "nodeId": "ns=3;i=1003",
+//	  "value": 12.5,
+//	  "floatValue": 12.5,
+//	  "quality": 0,
+//	  "qualityLevel": "GOOD",
+//	  "qualityCode": 0,
+//	  "recordTime": "2025-01-01T00:00:00Z",
+//	  "sourceTime": "2025-01-01T00:00:00Z",
+//	  "timestamp": "2025-01-01T00:00:00Z"
+//	}
+//
+// sampling 设置所有点位的采样间隔、队列大小、丢弃策略和触发条件，nodeOptions 按点位覆盖，快速变化和慢速变化的点位可以共用一个订阅
+// 再次收到消息会取消原有订阅并按新的点位重新订阅，节点销毁时取消订阅
+// 取消订阅时先停止接收新的数据变化，等待正在处理的回调完成（最多 shutdownTimeout 秒）后再关闭订阅和会话
+// 共享客户端重连后使用新的客户端重新创建订阅
+// 订阅创建成功，触发消息流转到`Subscribed`链，失败流转到`Failure`链，订阅过程中的错误作为新消息流转到`Failure`链
+type SubscribeNode struct {
+	base.SharedNode[*opcua.Client]
+	// GracefulShutdown 停机时等待正在处理的数据变化回调
+	base.GracefulShutdown
+	//节点配置
+	Config SubscribeConfiguration
+	// mu 保护当前订阅
+	mu sync.Mutex
+	// cancel 停止当前订阅的通知处理协程，订阅由通知处理协程持有，退出时取消
+	cancel context.CancelFunc
+	// done 当前订阅的通知处理协程退出后关闭
+	done chan struct{}
+}
+
+func (x *SubscribeNode) New() types.Node {
+	return &SubscribeNode{
+		Config: SubscribeConfiguration{
+			Configuration: Configuration{
+				Server:             "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+				Policy:             "None",
+				Mode:               "none",
+				Auth:               "anonymous",
+				MaxAge:             opcuaClient.DefaultMaxAge,
+				TimestampsToReturn: opcuaClient.DefaultTimestampsToReturn,
+			},
+			Interval:        1000,
+			ShutdownTimeout: 10,
+		},
+	}
+}
+
+// Type 返回组件类型
+func (x *SubscribeNode) Type() string {
+	return "x/opcuaSubscribe"
+}
+
+func (x *SubscribeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if err = opcuaClient.ValidateMonitoredItems(x.Config.Sampling, x.Config.NodeOptions); err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	x.GracefulShutdown.InitGracefulShutdown(ruleConfig.Logger, x.shutdownTimeout())
+	_ = x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*opcua.Client, error) {
+		return x.initClient()
+	}, func(client *opcua.Client) error {
+		return opcuaClient.CloseClient(client)
+	})
+	return nil
+}
+
+// OnMsg 实现 Node 接口，处理消息
+func (x *SubscribeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	nodeIds := x.Config.NodeIds
+	if len(nodeIds) == 0 {
+		if err = json.Unmarshal([]byte(msg.GetData()), &nodeIds); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	if len(nodeIds) == 0 {
+		ctx.TellFailure(msg, errors.New("nodeIds can not be empty"))
+		return
+	}
+	if err = x.subscribe(ctx, client, nodeIds); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellNext(msg, RelationSubscribed)
+}
+
+// subscribe 取消原有订阅，创建新的订阅
+func (x *SubscribeNode) subscribe(ctx types.RuleContext, client *opcua.Client, nodeIds []string) error {
+	items, err := opcuaClient.NewMonitoredItems(nodeIds, x.Config.Sampling, x.Config.NodeOptions)
+	if err != nil {
+		return err
+	}
+	timestampsToReturn, err := opcuaClient.ParseTimestampsToReturn(x.Config.TimestampsToReturn)
+	if err != nil {
+		return err
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.unsubscribeLocked()
+
+	notifyCh := make(chan *opcua.PublishNotificationData, 100)
+	sub, err := x.createSubscription(client, timestampsToReturn, items, nodeIds, notifyCh)
+	if err != nil {
+		return err
+	}
+
+	c, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	x.cancel = cancel
+	x.done = done
+	// 数据变化消息使用订阅自己的 context，不受触发消息结束或超时的影响
+	notifyCtx := ctx.SetContext(c)
+	go func() {
+		defer close(done)
+		x.run(c, notifyCtx, &subscription{
+			client:             client,
+			sub:                sub,
+			notifyCh:           notifyCh,
+			items:              items,
+			nodeIds:            nodeIds,
+			timestampsToReturn: timestampsToReturn,
+		})
+	}()
+	return nil
+}
+
+// subscription 通知处理协程持有的订阅
+type subscription struct {
+	client             *opcua.Client
+	sub                *opcua.Subscription
+	notifyCh           chan *opcua.PublishNotificationData
+	items              []*ua.MonitoredItemCreateRequest
+	nodeIds            []string
+	timestampsToReturn ua.TimestampsToReturn
+}
+
+// cancel 取消订阅
+func (s *subscription) cancel() {
+	if s.sub == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_ = s.sub.Cancel(ctx)
+	cancel()
+	s.sub = nil
+}
+
+// createSubscription 创建订阅并添加监控点位，全部点位监控失败返回错误
+func (x *SubscribeNode) createSubscription(client *opcua.Client, timestampsToReturn ua.TimestampsToReturn, items []*ua.MonitoredItemCreateRequest,
+	nodeIds []string, notifyCh chan *opcua.PublishNotificationData) (*opcua.Subscription, error) {
+	sub, err := client.Subscribe(context.Background(), &opcua.SubscriptionParameters{
+		Interval: time.Duration(x.Config.Interval) * time.Millisecond,
+	}, notifyCh)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sub.Monitor(context.Background(), timestampsToReturn, items...)
+	if err != nil {
+		_ = sub.Cancel(context.Background())
+		return nil, err
+	}
+	var errs []string
+	for i, result := range resp.Results {
+		if result.StatusCode != ua.StatusOK && i < len(nodeIds) {
+			errs = append(errs, nodeIds[i]+": "+result.StatusCode.Error())
+		}
+	}
+	if len(errs) == len(nodeIds) {
+		_ = sub.Cancel(context.Background())
+		return nil, fmt.Errorf("monitor failed: %v", errs)
+	} else if len(errs) > 0 {
+		x.Printf("monitor nodes error %v", strings.Join(errs, ","))
+	}
+	return sub, nil
+}
+
+// run 处理订阅通知，把数据变化发送到规则链，退出时取消订阅
+func (x *SubscribeNode) run(c context.Context, ctx types.RuleContext, s *subscription) {
+	defer s.cancel()
+	ticker := time.NewTicker(resubscribeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case res := <-s.notifyCh:
+			// 停机过程中不再处理新的数据变化
+			if x.GracefulShutdown.IsShuttingDown() {
+				return
+			}
+			x.handleNotification(ctx, s.client, res, s.nodeIds)
+		case <-ticker.C:
+			x.resubscribe(ctx, s)
+		}
+	}
+}
+
+// resubscribe 共享客户端重连后，原订阅随旧会话失效，使用新的客户端重新创建订阅
+// 创建失败时保留旧的客户端，下次检查时重试
+func (x *SubscribeNode) resubscribe(ctx types.RuleContext, s *subscription) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil || client == s.client {
+		return
+	}
+	s.cancel()
+	sub, err := x.createSubscription(client, s.timestampsToReturn, s.items, s.nodeIds, s.notifyCh)
+	if err != nil {
+		ctx.TellFailure(ctx.NewMsg(opcuaClient.OPC_UA_DATA_MSG_TYPE, types.NewMetadata(), ""), err)
+		return
+	}
+	s.client = client
+	s.sub = sub
+}
+
+// handleNotification 处理一次订阅通知
+func (x *SubscribeNode) handleNotification(ctx types.RuleContext, client *opcua.Client, res *opcua.PublishNotificationData, nodeIds []string) {
+	x.GracefulShutdown.IncrementActiveOperations()
+	defer x.GracefulShutdown.DecrementActiveOperations()
+	if res == nil {
+		return
+	}
+	if res.Error != nil {
+		ctx.TellFailure(ctx.NewMsg(opcuaClient.OPC_UA_DATA_MSG_TYPE, types.NewMetadata(), ""), res.Error)
+		return
+	}
+	notification, ok := res.Value.(*ua.DataChangeNotification)
+	if !ok {
+		return
+	}
+	for _, item := range notification.MonitoredItems {
+		if item == nil || item.Value == nil || int(item.ClientHandle) >= len(nodeIds) {
+			continue
+		}
+		opcuaClient.RecordValues(x.Type(), x.Config.Server, item.Value.Status)
+		d := opcuaClient.Data{
+			NodeId:     nodeIds[item.ClientHandle],
+			RecordTime: item.Value.ServerTimestamp,
+			SourceTime: item.Value.SourceTimestamp,
+			Timestamp:  time.Now(),
+		}
+		d.SetStatus(item.Value.Status)
+		if item.Value.Value != nil {
+			d.Value = item.Value.Value.Value()
+		}
+		_, _ = d.ParseValueFor(client)
+		b, err := json.Marshal(d)
+		if err != nil {
+			continue
+		}
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyNodeId, d.NodeId)
+		ctx.TellNext(ctx.NewMsg(opcuaClient.OPC_UA_DATA_MSG_TYPE, metadata, string(b)), types.Success)
+	}
+}
+
+// unsubscribeLocked 取消当前订阅，调用前需要持有 mu
+// 先停止接收新的数据变化，等待正在处理的回调完成，通知处理协程退出时取消订阅
+func (x *SubscribeNode) unsubscribeLocked() {
+	if x.cancel != nil {
+		x.cancel()
+		x.cancel = nil
+	}
+	if x.done != nil {
+		timeout := x.shutdownTimeout()
+		select {
+		case <-x.done:
+		case <-time.After(timeout):
+			x.Printf("wait for subscription callbacks timeout after %v", timeout)
+		}
+		x.done = nil
+	}
+}
+
+// Printf 日志输出
+func (x *SubscribeNode) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// Destroy 取消订阅并清理资源
+func (x *SubscribeNode) Destroy() {
+	x.GracefulShutdown.GracefulStop(func() {
+		x.mu.Lock()
+		x.unsubscribeLocked()
+		x.mu.Unlock()
+		_ = x.SharedNode.Close()
+	})
+}
+
+// shutdownTimeout 等待回调完成的超时时间
+func (x *SubscribeNode) shutdownTimeout() time.Duration {
+	if x.Config.ShutdownTimeout > 0 {
+		return time.Duration(x.Config.ShutdownTimeout) * time.Second
+	}
+	return base.DefaultShutdownTimeout
+}
+
+// Desc returns the component description
+func (x *SubscribeNode) Desc() string {
+	return "OPC-UA client for subscribing node data changes, each change is sent as a new message. Routes to Subscribed/Success/Failure"
+}
+
+func (x *SubscribeNode) initClient() (*opcua.Client, error) {
+	holder := opcuaClient.DefaultHolder(x.Config)
+	holder.Component = x.Type()
+	return holder.NewOpcUaClient()
+}
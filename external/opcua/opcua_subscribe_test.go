@@ -0,0 +1,260 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcua
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego-components-iot/endpoint/opcuaserver"
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestSubscribeNode(t *testing.T) {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48407,
+		"variables": []map[string]interface{}{
+			{"name": "Temperature", "dataType": "Double", "value": 20.5},
+		},
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&SubscribeNode{})
+	node, err := test.CreateAndInitNode("x/opcuaSubscribe", types.Configuration{
+		"server":   "opc.tcp://localhost:48407",
+		"interval": 100,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var lock sync.Mutex
+	var values []float64
+	var subscribed int32
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `["ns=1;s=Temperature"]`,
+			AfterSleep: time.Millisecond * 500,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		if relationType == RelationSubscribed {
+			// 触发消息在订阅创建后结束
+			assert.Equal(t, "TEST", msg.Type)
+			atomic.AddInt32(&subscribed, 1)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, opcuaClient.OPC_UA_DATA_MSG_TYPE, msg.Type)
+		assert.Equal(t, "ns=1;s=Temperature", msg.Metadata.GetValue(KeyNodeId))
+		var d opcuaClient.Data
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &d))
+		lock.Lock()
+		values = append(values, d.FloatValue)
+		lock.Unlock()
+	})
+
+	assert.Nil(t, ep.SetValue("Temperature", 36.5))
+	time.Sleep(time.Millisecond * 500)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&subscribed))
+	lock.Lock()
+	defer lock.Unlock()
+	assert.True(t, len(values) >= 2)
+	assert.Equal(t, 20.5, values[0])
+	assert.Equal(t, 36.5, values[len(values)-1])
+}
+
+func TestSubscribeNodeDestroy(t *testing.T) {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48410,
+		"variables": []map[string]interface{}{
+			{"name": "Pressure", "dataType": "Double", "value": 1.0},
+		},
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&SubscribeNode{})
+	node, err := test.CreateAndInitNode("x/opcuaSubscribe", types.Configuration{
+		"server":          "opc.tcp://localhost:48410",
+		"interval":        100,
+		"shutdownTimeout": 2,
+	}, Registry)
+	assert.Nil(t, err)
+
+	var count int32
+	msgList := []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "TEST",
+			Data:       `["ns=1;s=Pressure"]`,
+			AfterSleep: time.Millisecond * 500,
+		},
+	}
+	test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+		atomic.AddInt32(&count, 1)
+	})
+	assert.True(t, atomic.LoadInt32(&count) >= 1)
+
+	node.Destroy()
+	subNode := node.(*SubscribeNode)
+	assert.True(t, subNode.IsShuttingDown())
+	assert.Equal(t, int64(0), subNode.GetActiveOperations())
+
+	// 销毁后不再发送数据变化
+	received := atomic.LoadInt32(&count)
+	assert.Nil(t, ep.SetValue("Pressure", 2.0))
+	time.Sleep(time.Millisecond * 500)
+	assert.Equal(t, received, atomic.LoadInt32(&count))
+}
+
+func TestSubscribeNodeResubscribe(t *testing.T) {
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err := ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48413,
+		"variables": []map[string]interface{}{
+			{"name": "Level", "dataType": "Double", "value": 3.5},
+		},
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&SubscribeNode{})
+	node, err := test.CreateAndInitNode("x/opcuaSubscribe", types.Configuration{
+		"server":   "opc.tcp://localhost:48413",
+		"interval": 100,
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+	subNode := node.(*SubscribeNode)
+	client, err := subNode.SharedNode.GetSafely()
+	assert.Nil(t, err)
+
+	id, err := ua.ParseNodeID("ns=1;s=Level")
+	assert.Nil(t, err)
+	// 模拟重连前的旧客户端
+	s := &subscription{
+		notifyCh:           make(chan *opcua.PublishNotificationData, 10),
+		items:              []*ua.MonitoredItemCreateRequest{opcua.NewMonitoredItemCreateRequestWithDefaults(id, ua.AttributeIDValue, 0)},
+		nodeIds:            []string{"ns=1;s=Level"},
+		timestampsToReturn: ua.TimestampsToReturnBoth,
+	}
+	defer s.cancel()
+	subNode.resubscribe(nil, s)
+	assert.True(t, s.client == client)
+	assert.NotNil(t, s.sub)
+
+	select {
+	case res := <-s.notifyCh:
+		assert.Nil(t, res.Error)
+	case <-time.After(2 * time.Second):
+		t.Error("重新订阅后应该收到数据变化")
+	}
+}
+
+func TestSubscribeNodeSampling(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&SubscribeNode{})
+	_, err := test.CreateAndInitNode("x/opcuaSubscribe", types.Configuration{
+		"sampling": map[string]interface{}{"trigger": "Value"},
+	}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/opcuaSubscribe", types.Configuration{
+		"nodeOptions": []map[string]interface{}{{"nodeId": "ns=1;s=Counter", "discardPolicy": "random"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	ep := (&opcuaserver.OpcUaServer{}).New().(*opcuaserver.OpcUaServer)
+	err = ep.Init(engine.NewConfig(), types.Configuration{
+		"host": "localhost",
+		"port": 48414,
+		"variables": []map[string]interface{}{
+			{"name": "Counter", "dataType": "Int32", "value": 1},
+			{"name": "Temperature", "dataType": "Double", "value": 20.5},
+		},
+	})
+	assert.Nil(t, err)
+	err = ep.Start()
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	// 快速计数器和慢速温度共用一个订阅
+	node, err := test.CreateAndInitNode("x/opcuaSubscribe", types.Configuration{
+		"server":   "opc.tcp://localhost:48414",
+		"interval": 100,
+		"nodeIds":  []string{"ns=1;s=Counter", "ns=1;s=Temperature"},
+		"sampling": map[string]interface{}{"samplingInterval": 1000, "trigger": "StatusValue"},
+		"nodeOptions": []map[string]interface{}{
+			{"nodeId": "ns=1;s=Counter", "samplingInterval": 50, "queueSize": 100, "discardPolicy": "newest", "trigger": "StatusValueTimestamp"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var lock sync.Mutex
+	nodes := map[string]int{}
+	var subscribed int32
+	test.NodeOnMsg(t, node, []test.Msg{
+		{MetaData: types.NewMetadata(), DataType: types.JSON, MsgType: "TEST", Data: "{}", AfterSleep: time.Millisecond * 500},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if relationType == RelationSubscribed {
+			atomic.AddInt32(&subscribed, 1)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		lock.Lock()
+		nodes[msg.Metadata.GetValue(KeyNodeId)]++
+		lock.Unlock()
+	})
+
+	assert.Nil(t, ep.SetValue("Counter", int32(2)))
+	time.Sleep(time.Millisecond * 500)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&subscribed))
+	lock.Lock()
+	defer lock.Unlock()
+	assert.True(t, nodes["ns=1;s=Counter"] >= 2)
+	assert.True(t, nodes["ns=1;s=Temperature"] >= 1)
+}
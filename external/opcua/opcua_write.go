@@ -19,6 +19,7 @@ package opcua
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -26,10 +27,20 @@ import (
 	"github.com/gopcua/opcua"
 	"github.com/gopcua/opcua/ua"
 	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
 	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/tags"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/components/base"
 	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// KeyWriteResults 每个点位写入结果的元数据key
+	KeyWriteResults = "writeResults"
+	// KeyWriteSummary 写入成功和失败点位汇总的元数据key
+	KeyWriteSummary = "writeSummary"
 )
 
 // 注册节点
@@ -37,14 +48,55 @@ func init() {
 	_ = rulego.Registry.Register(&WriteNode{})
 }
 
+// WriteResult 单个点位写入结果
+type WriteResult struct {
+	NodeId     string `json:"nodeId"`
+	Status     string `json:"status"`
+	StatusCode uint32 `json:"statusCode"`
+	Error      string `json:"error,omitempty"`
+	// Verified 回读校验结果，未开启校验时为空
+	Verified *bool `json:"verified,omitempty"`
+	// ReadBack 回读的值
+	ReadBack interface{} `json:"readBack,omitempty"`
+}
+
+// WriteSummary 批量写入的汇总，succeeded 为写入成功（开启校验时校验通过）的点位，failed 为失败点位的状态码和错误
+type WriteSummary struct {
+	Total     int           `json:"total"`
+	Succeeded []string      `json:"succeeded"`
+	Failed    []WriteResult `json:"failed"`
+}
+
+// ok 写入成功并且校验通过
+func (r *WriteResult) ok() bool {
+	return r.StatusCode == uint32(ua.StatusOK) && r.Status != "" && (r.Verified == nil || *r.Verified)
+}
+
+func (r *WriteResult) setStatus(status ua.StatusCode) {
+	r.StatusCode = uint32(status)
+	if desc, ok := ua.StatusCodes[status]; ok {
+		r.Status = desc.Name
+	} else {
+		r.Status = fmt.Sprintf("0x%X", uint32(status))
+	}
+	if status != ua.StatusOK {
+		r.Error = status.Error()
+	}
+}
+
+func (r *WriteResult) setError(status ua.StatusCode, err error) {
+	r.setStatus(status)
+	r.Error = err.Error()
+}
+
 // WriteNodeConfiguration  节点配置
 type WriteNodeConfiguration struct {
 	//OPC UA Server Endpoint, eg. opc.tcp://localhost:4840
 	Server string `json:"server" label:"Server" desc:"OPC UA server endpoint, format: opc.tcp://host:port" required:"true" ref:"primary"`
-	//Security Policy URL or one of None, Basic128Rsa15, Basic256, Basic256Sha256
-	Policy string `json:"policy" label:"Security Policy" desc:"Security policy: None, Basic128Rsa15, Basic256, Basic256Sha256"`
-	//Security Mode: one of None, Sign, SignAndEncrypt
-	Mode string `json:"mode" label:"Security Mode" desc:"Security mode: None, Sign, SignAndEncrypt"`
+	//Security Policy URL or one of auto, None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss, deprecated Basic128Rsa15, Basic256
+	Policy string `json:"policy" label:"Security Policy" desc:"Security policy: auto (strongest supported by client and server), None, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss. Basic128Rsa15 and Basic256 are deprecated"`
+	//Security Mode: one of auto, None, Sign, SignAndEncrypt
+	Mode string `json:"mode" label:"Security Mode" desc:"Security mode: auto, None, Sign, SignAndEncrypt"`
 	//Authentication Mode: one of Anonymous, UserName, Certificate
 	Auth     string `json:"auth" label:"Auth Mode" desc:"Authentication mode: Anonymous, UserName, Certificate"`
 	Username string `json:"username" label:"Username" desc:"Authentication username" ref:"shared"`
@@ -53,6 +105,44 @@ type WriteNodeConfiguration struct {
 	CertFile string `json:"certFile" label:"Cert File" desc:"Client certificate file path" ref:"shared"`
 	//OPC UA Server CertKeyFile Path
 	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"Client private key file path" ref:"shared"`
+	//PoolSize number of sessions shared by nodes with the same server, greater than 1 enables round-robin session pool
+	PoolSize int `json:"poolSize" label:"Pool Size" desc:"Number of sessions shared by nodes with the same server, greater than 1 enables round-robin session pool"`
+	//Timeout write request timeout in seconds, also used for the verify read, 0 means no timeout
+	Timeout int `json:"timeout" label:"Timeout" desc:"Write request timeout in seconds, also used for the verify read. 0 means no timeout"`
+	//Verify whether to read back the written nodes and compare the values
+	Verify bool `json:"verify" label:"Verify" desc:"Read back the written nodes and compare the values, routes to Failure if verification fails"`
+	//VerifyTolerance max allowed difference between written and read back numeric values
+	VerifyTolerance float64 `json:"verifyTolerance" label:"Verify Tolerance" desc:"Max allowed difference between written and read back numeric values"`
+	//VerifyDelay delay in milliseconds before reading back
+	VerifyDelay int `json:"verifyDelay" label:"Verify Delay" desc:"Delay in milliseconds before reading back"`
+	//SuccessThreshold min ratio of succeeded writes to route to Success, 0 routes to Success if any write succeeded and no verification failed
+	SuccessThreshold float64 `json:"successThreshold" label:"Success Threshold" desc:"Min ratio (0-1] of succeeded writes to route to Success. 0 routes to Success if any write succeeded and no verification failed"`
+	//ResultsToData replace msg.Data with the write summary
+	ResultsToData bool `json:"resultsToData" label:"Results To Data" desc:"Replace msg.Data with the write summary {total, succeeded, failed}"`
+	//Items points to write, nodeId and value allow ${} placeholders, empty uses the point array in msg.Data
+	Items []WriteItem `json:"items" label:"Items" desc:"Points to write, nodeId and value allow ${metadata.xx} and ${msg.xx} placeholders. Empty uses the point array in msg.Data"`
+	//CircuitBreaker fail fast after consecutive communication failures, shared by nodes with the same server
+	CircuitBreaker breaker.Config `json:"circuitBreaker" label:"Circuit Breaker" desc:"Fail fast with CIRCUIT_OPEN after consecutive communication failures instead of waiting for the connect timeout"`
+}
+
+// WriteItem 写入的点位
+type WriteItem struct {
+	// NodeId 点位ID，允许使用 ${} 占位符变量，eg. ${metadata.deviceNodeId}、${msg.device.nodeId}
+	NodeId string `json:"nodeId"`
+	// DataType 数据类型，为空时根据值推断，允许使用 ${} 占位符变量
+	DataType string `json:"dataType"`
+	// Value 写入的值，允许使用 ${} 占位符变量，替换后为字符串，按 dataType 转换
+	Value string `json:"value"`
+	// ValuePath 写入值在 msg.Data 中的 JSON 路径，eg. command.setpoint，保留 JSON 值的类型（包括数组），优先于 value
+	ValuePath string `json:"valuePath"`
+}
+
+// writeItemTemplate 写入点位的模板
+type writeItemTemplate struct {
+	nodeId    str.Template
+	dataType  str.Template
+	value     str.Template
+	valuePath string
 }
 
 func (c WriteNodeConfiguration) GetServer() string {
@@ -90,16 +180,44 @@ func (c WriteNodeConfiguration) GetCertKeyFile() string {
 //	  },
 //	  {
 //	    "nodeId": "ns=3;i=1010",
+//	    "dataType": "Int16",
 //	    "value": 2
+//	  },
+//	  {
+//	    "nodeId": "ns=3;i=1011",
+//	    "dataType": "Float[]",
+//	    "value": [1.5, 2.5]
 //	  }
 //	]
 //
-// 写入成功，流转到`Success`链
+// dataType 可选，支持：Boolean, SByte, Byte, Int16, UInt16, Int32, UInt32, Int64, UInt64, Float, Double, String, DateTime, Guid, ByteString，
+// 不指定时根据 JSON 值推断类型
+// 配置 items 时从配置获取写入的点位，nodeId、dataType 和 value 允许使用 ${metadata.xx}、${msg.xx} 占位符变量，
+// valuePath 从 msg.Data 的 JSON 路径取值并保留原始类型，一个写入节点即可服务多个设备的指令，例如：
+//
+//	[{"nodeId": "${metadata.deviceNodeId}", "dataType": "Double", "valuePath": "command.setpoint"}]
+//
+// 所有点位通过一个 WriteRequest 写入，每个点位的写入结果以 JSON 数组保存在元数据 writeResults 中：[{"nodeId":"ns=3;i=1009","status":"OK","statusCode":0}]
+// 成功和失败点位的汇总保存在元数据 writeSummary 中，开启 resultsToData 时替换 msg.Data：
+//
+//	{"total": 3, "succeeded": ["ns=3;i=1009"], "failed": [{"nodeId":"ns=3;i=1010","status":"BadTypeMismatch","statusCode":2155085824,"error":"..."}]}
+//
+// 开启 verify 后，写入成功的点位会重新读取并与写入值比较（数值允许 verifyTolerance 误差），结果记录在 verified、readBack 字段
+// successThreshold 为 0 时，至少一个点位写入成功并且没有校验失败，流转到`Success`链；
+// 大于 0 时，写入成功并且校验通过的点位比例不小于 successThreshold，流转到`Success`链
 // 否则流程转到`Failure`链
+// poolSize 大于1时，相同服务器地址的节点共享会话池，每次写入按轮询方式分配会话
+// circuitBreaker.failureThreshold 大于0时启用熔断，连续通信失败达到阈值后不再连接服务器，直接以 CIRCUIT_OPEN 错误流转到`Failure`链
 type WriteNode struct {
 	base.SharedNode[*opcua.Client]
 	//节点配置
 	Config WriteNodeConfiguration
+	// pool 会话池，poolSize 大于1时启用
+	pool *opcuaClient.SessionPool
+	// breaker 熔断器，相同服务器地址的节点共享
+	breaker *breaker.Breaker
+	// items 配置的写入点位模板
+	items []writeItemTemplate
 }
 
 func (x *WriteNode) New() types.Node {
@@ -120,43 +238,94 @@ func (x *WriteNode) Type() string {
 
 func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.SuccessThreshold < 0 || x.Config.SuccessThreshold > 1 {
+		return errors.New("successThreshold must be between 0 and 1")
+	}
+	if err = opcuaClient.ValidateSecurity(x.Config.Policy, x.Config.Mode); err != nil {
+		return err
+	}
+	if err = x.Config.CircuitBreaker.Validate(); err != nil {
+		return err
+	}
+	x.items = nil
+	for i, item := range x.Config.Items {
+		if strings.TrimSpace(item.NodeId) == "" {
+			return fmt.Errorf("items[%d]: nodeId is required", i)
+		}
+		x.items = append(x.items, writeItemTemplate{
+			nodeId:    str.NewTemplate(item.NodeId),
+			dataType:  str.NewTemplate(item.DataType),
+			value:     str.NewTemplate(item.Value),
+			valuePath: strings.TrimSpace(item.ValuePath),
+		})
+	}
 	x.RuleConfig = ruleConfig
+	x.breaker = opcuaClient.AcquireBreaker(x.Config.Server, x.Config.CircuitBreaker)
+	if x.Config.PoolSize > 1 {
+		// 启用会话池时由会话池管理会话，不再创建共享客户端
+		x.pool = opcuaClient.AcquireSessionPool(x.newHolder(), x.Config.PoolSize)
+		return err
+	}
 	_ = x.SharedNode.InitWithClose(x.RuleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*opcua.Client, error) {
 		return x.initClient()
 	}, func(client *opcua.Client) error {
-		return client.Close(context.Background())
+		return opcuaClient.CloseClient(client)
 	})
 	return err
 }
 
 // OnMsg 实现 Node 接口，处理消息
 func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	client, err := x.SharedNode.GetSafely()
+	data, err := x.getData(ctx, msg)
 	if err != nil {
 		ctx.TellFailure(msg, err)
 		return
 	}
-
-	data := make([]opcuaClient.Data, 0)
-	err = json.Unmarshal([]byte(msg.GetData()), &data)
+	// 熔断器断开时不连接服务器，直接失败
+	if err = x.breaker.Allow(); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	client, err := x.getClient()
 	if err != nil {
+		x.breaker.Done(err)
 		ctx.TellFailure(msg, err)
 		return
 	}
 
-	nodesToWrite := make([]*ua.WriteValue, 0)
-
-	for _, d := range data {
+	results := make([]WriteResult, len(data))
+	nodesToWrite := make([]*ua.WriteValue, 0, len(data))
+	// index 记录 nodesToWrite 中每一项对应的 data 下标
+	index := make([]int, 0, len(data))
+	// written 记录每一项写入的值
+	written := make([]*ua.Variant, len(data))
+	for i, d := range data {
+		// tag:<名称> 引用全局点位注册表中的点位，写入值按点位的缩放和偏移量换算为原始值
+		nodeId, ref, err := tags.Resolve(tags.ProtocolOPCUA, d.NodeId)
+		if err != nil {
+			results[i] = WriteResult{NodeId: d.NodeId}
+			results[i].setError(ua.StatusBadNodeIDUnknown, err)
+			continue
+		}
+		if ref != nil {
+			d.NodeId, d.Value = nodeId, ref.Unscaled(d.Value)
+			if d.DataType == "" {
+				d.DataType = ref.DataType
+			}
+		}
+		results[i] = WriteResult{NodeId: d.NodeId}
 		id, err := ua.ParseNodeID(d.NodeId)
 		if err != nil {
-			ctx.TellFailure(msg, err)
-			return
+			results[i].setError(ua.StatusBadNodeIDInvalid, err)
+			continue
 		}
-
-		v, err := ua.NewVariant(castValue(d.Value, d.DataType))
+		v, err := opcuaClient.NewVariant(d.Value, d.DataType)
 		if err != nil {
-			ctx.TellFailure(msg, err)
-			return
+			results[i].setError(ua.StatusBadTypeMismatch, err)
+			continue
 		}
 		nodesToWrite = append(nodesToWrite, &ua.WriteValue{
 			NodeID:      id,
@@ -166,26 +335,65 @@ func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 				Value:        v,
 			},
 		})
+		index = append(index, i)
+		written[i] = v
+	}
+
+	if len(nodesToWrite) > 0 {
+		req := &ua.WriteRequest{
+			NodesToWrite: nodesToWrite,
+		}
+		c, cancel := opcuaClient.WithTimeout(ctx.GetContext(), time.Duration(x.Config.Timeout)*time.Second)
+		resp, err := client.Write(c, req)
+		cancel()
+		x.breaker.Done(opcuaClient.CommError(err))
+		if err != nil {
+			opcuaClient.RecordWriteErrors(x.Type(), x.Config.Server, len(data))
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if resp != nil {
+			for j, status := range resp.Results {
+				if j < len(index) {
+					results[index[j]].setStatus(status)
+				}
+			}
+		}
 	}
 
-	req := &ua.WriteRequest{
-		NodesToWrite: nodesToWrite,
+	if len(nodesToWrite) == 0 {
+		// 没有需要写入的点位，获取到连接说明服务器可用
+		x.breaker.Done(nil)
 	}
 
-	resp, err := client.Write(context.Background(), req)
-	if err != nil {
-		ctx.TellFailure(msg, err)
-		return
+	verifyFailed := false
+	if x.Config.Verify {
+		verifyFailed = !x.verify(ctx.GetContext(), client, results, written)
 	}
-	succ := false
-	var errs []string // 移除预分配的大小，避免空字符串
-	if resp != nil {
-		for _, result := range resp.Results {
-			if result == ua.StatusOK {
-				succ = true
-			} else {
-				errs = append(errs, result.Error())
-			}
+
+	summary := WriteSummary{Total: len(results), Succeeded: []string{}, Failed: []WriteResult{}}
+	var errs []string
+	for _, r := range results {
+		if r.ok() {
+			summary.Succeeded = append(summary.Succeeded, r.NodeId)
+		} else {
+			summary.Failed = append(summary.Failed, r)
+			errs = append(errs, r.NodeId+": "+r.Error)
+		}
+	}
+	succ := len(summary.Succeeded) > 0 && !verifyFailed
+	if x.Config.SuccessThreshold > 0 {
+		succ = len(results) > 0 && float64(len(summary.Succeeded)) >= x.Config.SuccessThreshold*float64(len(results))
+	}
+	opcuaClient.RecordWriteErrors(x.Type(), x.Config.Server, len(errs))
+	if b, err := json.Marshal(results); err == nil {
+		msg.Metadata.PutValue(KeyWriteResults, string(b))
+	}
+	if b, err := json.Marshal(summary); err == nil {
+		msg.Metadata.PutValue(KeyWriteSummary, string(b))
+		if x.Config.ResultsToData {
+			msg.SetDataType(types.JSON)
+			msg.SetData(string(b))
 		}
 	}
 	if succ {
@@ -199,251 +407,128 @@ func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	}
 }
 
-// Destroy 清理资源
-func (x *WriteNode) Destroy() {
-	_ = x.SharedNode.Close()
-}
-
-// Desc returns the component description
-func (x *WriteNode) Desc() string {
-	return "OPC-UA client for writing node values. Routes to Success/Failure"
-}
-
-func (x *WriteNode) initClient() (*opcua.Client, error) {
-	client, err := opcuaClient.DefaultHolder(x.Config).NewOpcUaClient()
-	return client, err
-}
-
-// castValue 尝试将 []interface{} 转换为特定类型的切片，以便 ua.NewVariant 可以正确处理
-// castValue attempts to convert []interface{} to a slice of a specific type so that ua.NewVariant can handle it correctly
-func castValue(val interface{}, dataType string) interface{} {
-	if dataType != "" {
-		return castValueByType(val, dataType)
+// getData 获取写入的点位，配置了 items 时按模板生成，否则解析 msg.Data 中的点位数组
+func (x *WriteNode) getData(ctx types.RuleContext, msg types.RuleMsg) ([]opcuaClient.Data, error) {
+	data := make([]opcuaClient.Data, 0, len(x.items))
+	if len(x.items) == 0 {
+		err := json.Unmarshal([]byte(msg.GetData()), &data)
+		return data, err
 	}
-	switch v := val.(type) {
-	case []interface{}:
-		if len(v) == 0 {
-			return v
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	var payload interface{}
+	for _, item := range x.items {
+		d := opcuaClient.Data{
+			NodeId:   strings.TrimSpace(item.nodeId.Execute(evn)),
+			DataType: strings.TrimSpace(item.dataType.Execute(evn)),
 		}
-		// 根据第一个元素的类型进行转换
-		// Convert based on the type of the first element
-		switch v[0].(type) {
-		case float64:
-			arr := make([]float64, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = f
-				} else {
-					return val // 如果类型不一致，返回原始值 | If types are inconsistent, return the original value
-				}
-			}
-			return arr
-		case string:
-			arr := make([]string, len(v))
-			for i, e := range v {
-				if s, ok := e.(string); ok {
-					arr[i] = s
-				} else {
-					return val
+		if item.valuePath != "" {
+			if payload == nil {
+				if err := json.Unmarshal([]byte(msg.GetData()), &payload); err != nil {
+					return nil, fmt.Errorf("parse msg data for value path %s: %w", item.valuePath, err)
 				}
 			}
-			return arr
-		case bool:
-			arr := make([]bool, len(v))
-			for i, e := range v {
-				if b, ok := e.(bool); ok {
-					arr[i] = b
-				} else {
-					return val
-				}
+			v := maps.Get(payload, item.valuePath)
+			if v == nil {
+				return nil, fmt.Errorf("value path %s not found in msg data", item.valuePath)
 			}
-			return arr
+			d.Value = v
+		} else {
+			d.Value = item.value.Execute(evn)
 		}
+		data = append(data, d)
 	}
-	return val
+	return data, nil
 }
 
-func castValueByType(val interface{}, dataType string) interface{} {
-	dataType = strings.ToLower(dataType)
-	// 检查是否为数组类型
-	// Check if it is an array type
-	if v, ok := val.([]interface{}); ok {
-		switch dataType {
-		case "boolean":
-			arr := make([]bool, len(v))
-			for i, e := range v {
-				if b, ok := e.(bool); ok {
-					arr[i] = b
-				}
-			}
-			return arr
-		case "sbyte":
-			arr := make([]int8, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = int8(f)
-				}
-			}
-			return arr
-		case "byte":
-			arr := make([]byte, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = byte(f)
-				}
-			}
-			return arr
-		case "int16":
-			arr := make([]int16, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = int16(f)
-				}
-			}
-			return arr
-		case "uint16":
-			arr := make([]uint16, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = uint16(f)
-				}
-			}
-			return arr
-		case "int32":
-			arr := make([]int32, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = int32(f)
-				}
-			}
-			return arr
-		case "uint32":
-			arr := make([]uint32, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = uint32(f)
-				}
-			}
-			return arr
-		case "int64":
-			arr := make([]int64, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = int64(f)
-				}
-			}
-			return arr
-		case "uint64":
-			arr := make([]uint64, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = uint64(f)
-				}
-			}
-			return arr
-		case "float":
-			arr := make([]float32, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = float32(f)
-				}
-			}
-			return arr
-		case "double":
-			arr := make([]float64, len(v))
-			for i, e := range v {
-				if f, ok := e.(float64); ok {
-					arr[i] = f
-				}
-			}
-			return arr
-		case "string":
-			arr := make([]string, len(v))
-			for i, e := range v {
-				if s, ok := e.(string); ok {
-					arr[i] = s
-				}
-			}
-			return arr
-		case "datetime":
-			arr := make([]time.Time, len(v))
-			for i, e := range v {
-				if s, ok := e.(string); ok {
-					if t, err := time.Parse(time.RFC3339, s); err == nil {
-						arr[i] = t
-					}
-				}
-			}
-			return arr
+// verify 回读写入成功的点位并与写入值比较，全部一致返回 true
+func (x *WriteNode) verify(ctx context.Context, client *opcua.Client, results []WriteResult, written []*ua.Variant) bool {
+	nodesToRead := make([]*ua.ReadValueID, 0, len(results))
+	index := make([]int, 0, len(results))
+	for i, r := range results {
+		if r.StatusCode != uint32(ua.StatusOK) || r.Status == "" || written[i] == nil {
+			continue
 		}
+		id, _ := ua.ParseNodeID(r.NodeId)
+		nodesToRead = append(nodesToRead, &ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDValue})
+		index = append(index, i)
 	}
-
-	// 标量类型处理
-	// Scalar type handling
-	switch dataType {
-	case "boolean":
-		if v, ok := val.(bool); ok {
-			return v
-		}
-	case "sbyte":
-		if v, ok := val.(float64); ok {
-			return int8(v)
-		}
-	case "byte":
-		if v, ok := val.(float64); ok {
-			return byte(v)
-		}
-	case "int16":
-		if v, ok := val.(float64); ok {
-			return int16(v)
-		}
-	case "uint16":
-		if v, ok := val.(float64); ok {
-			return uint16(v)
-		}
-	case "int32":
-		if v, ok := val.(float64); ok {
-			return int32(v)
-		}
-	case "uint32":
-		if v, ok := val.(float64); ok {
-			return uint32(v)
-		}
-	case "int64":
-		if v, ok := val.(float64); ok {
-			return int64(v)
-		}
-	case "uint64":
-		if v, ok := val.(float64); ok {
-			return uint64(v)
-		}
-	case "float":
-		if v, ok := val.(float64); ok {
-			return float32(v)
-		}
-	case "double":
-		if v, ok := val.(float64); ok {
-			return v
-		}
-	case "string":
-		if v, ok := val.(string); ok {
-			return v
+	if len(nodesToRead) == 0 {
+		return true
+	}
+	if x.Config.VerifyDelay > 0 {
+		select {
+		case <-time.After(time.Duration(x.Config.VerifyDelay) * time.Millisecond):
+		case <-ctx.Done():
 		}
-	case "datetime":
-		if v, ok := val.(string); ok {
-			if t, err := time.Parse(time.RFC3339, v); err == nil {
-				return t
+	}
+	ok := true
+	c, cancel := opcuaClient.WithTimeout(ctx, time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+	// MaxAge 为 0 要求服务器从设备读取最新值
+	resp, err := client.Read(c, &ua.ReadRequest{
+		MaxAge:             0,
+		NodesToRead:        nodesToRead,
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	})
+	for j, i := range index {
+		verified := false
+		switch {
+		case err != nil:
+			results[i].Error = "verify failed: " + err.Error()
+		case resp == nil || j >= len(resp.Results) || resp.Results[j] == nil:
+			results[i].Error = "verify failed: no read back result"
+		case resp.Results[j].Status != ua.StatusOK:
+			results[i].Error = "verify failed: " + resp.Results[j].Status.Error()
+		default:
+			var readBack interface{}
+			if resp.Results[j].Value != nil {
+				readBack = resp.Results[j].Value.Value()
+			}
+			results[i].ReadBack = readBack
+			verified = opcuaClient.ValueEqual(written[i].Value(), readBack, x.Config.VerifyTolerance)
+			if !verified {
+				results[i].Error = fmt.Sprintf("verify failed: wrote %v, read back %v", written[i].Value(), readBack)
 			}
 		}
-	case "guid":
-		if v, ok := val.(string); ok {
-			// 如果需要支持 GUID，需要实现 ParseGUID 或者使用第三方库
-			// 暂时移除 ParseGUID 调用，避免编译错误
-			// if id, err := ua.ParseGUID(v); err == nil {
-			// 	return *id
-			// }
-			return v
+		results[i].Verified = &verified
+		if !verified {
+			ok = false
 		}
 	}
-	return val
+	return ok
+}
+
+// Destroy 清理资源
+func (x *WriteNode) Destroy() {
+	x.breaker.Release()
+	x.breaker = nil
+	if x.pool != nil {
+		_ = x.pool.Release()
+		x.pool = nil
+		return
+	}
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "OPC-UA client for writing node values in a single batch request with per-node results. Routes to Success/Failure"
+}
+
+// getClient 获取客户端，启用会话池时按轮询方式分配会话
+func (x *WriteNode) getClient() (*opcua.Client, error) {
+	if x.pool != nil {
+		return x.pool.Get()
+	}
+	return x.SharedNode.GetSafely()
+}
+
+func (x *WriteNode) initClient() (*opcua.Client, error) {
+	return x.newHolder().NewOpcUaClient()
+}
+
+func (x *WriteNode) newHolder() *opcuaClient.OpcUaClientHolder {
+	holder := opcuaClient.DefaultHolder(x.Config)
+	holder.Component = x.Type()
+	return holder
 }
@@ -17,13 +17,17 @@
 package opcua
 
 import (
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/gopcua/opcua/ua"
 	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+	"github.com/rulego/rulego-components-iot/pkg/simulator"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
 )
 
 func TestWriteNode(t *testing.T) {
@@ -162,3 +166,144 @@ func TestWriteNode(t *testing.T) {
 	})
 
 }
+
+func TestWriteNodeVerifyConfig(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	node, err := test.CreateAndInitNode("x/opcuaWrite", types.Configuration{
+		"server":          "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+		"verify":          true,
+		"verifyTolerance": 0.01,
+		"verifyDelay":     100,
+	}, Registry)
+	assert.Nil(t, err)
+	writeNode := node.(*WriteNode)
+	assert.True(t, writeNode.Config.Verify)
+	assert.Equal(t, 0.01, writeNode.Config.VerifyTolerance)
+	assert.Equal(t, 100, writeNode.Config.VerifyDelay)
+}
+
+func TestWriteNodeBatch(t *testing.T) {
+	srv := simulator.StartOPCUA(t,
+		simulator.OPCUAVariable{Name: "SP1", DataType: "Double", Value: 0.0, Writable: true},
+		simulator.OPCUAVariable{Name: "SP2", DataType: "Int32", Value: int32(0), Writable: true},
+	)
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	_, err := test.CreateAndInitNode("x/opcuaWrite", types.Configuration{"server": srv.Endpoint(), "successThreshold": 1.5}, Registry)
+	assert.NotNil(t, err)
+
+	data, _ := json.Marshal([]map[string]interface{}{
+		{"nodeId": srv.NodeId("SP1"), "value": 21.5, "dataType": "Double"},
+		{"nodeId": srv.NodeId("SP2"), "value": 7, "dataType": "Int32"},
+		{"nodeId": srv.NodeId("Missing"), "value": 1, "dataType": "Int32"},
+		{"nodeId": "ns=x;i=1", "value": 1},
+	})
+	for _, c := range []struct {
+		threshold float64
+		relation  string
+	}{
+		{0.5, types.Success},
+		{0.75, types.Failure},
+	} {
+		node, err := test.CreateAndInitNode("x/opcuaWrite", types.Configuration{
+			"server":           srv.Endpoint(),
+			"policy":           "None",
+			"mode":             "None",
+			"auth":             "Anonymous",
+			"successThreshold": c.threshold,
+			"resultsToData":    true,
+		}, Registry)
+		assert.Nil(t, err)
+		var summary WriteSummary
+		relation := ""
+		done := make(chan struct{})
+		test.NodeOnMsg(t, node, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.JSON,
+			MsgType:  opcuaClient.OPC_UA_DATA_MSG_TYPE,
+			Data:     string(data),
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer close(done)
+			relation = relationType
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &summary))
+			assert.Equal(t, msg.GetData(), msg.Metadata.GetValue(KeyWriteSummary))
+		})
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for write summary")
+		}
+		node.Destroy()
+		assert.Equal(t, c.relation, relation)
+		assert.Equal(t, 4, summary.Total)
+		assert.Equal(t, []string{srv.NodeId("SP1"), srv.NodeId("SP2")}, summary.Succeeded)
+		assert.Equal(t, 2, len(summary.Failed))
+		assert.Equal(t, uint32(ua.StatusBadNodeIDUnknown), summary.Failed[0].StatusCode)
+		assert.Equal(t, uint32(ua.StatusBadNodeIDInvalid), summary.Failed[1].StatusCode)
+	}
+	value, err := srv.Value("SP1")
+	assert.Nil(t, err)
+	assert.Equal(t, 21.5, value)
+}
+
+func TestWriteNodeItems(t *testing.T) {
+	srv := simulator.StartOPCUA(t,
+		simulator.OPCUAVariable{Name: "Dev1.SP", DataType: "Double", Value: 0.0, Writable: true},
+		simulator.OPCUAVariable{Name: "Dev2.SP", DataType: "Double", Value: 0.0, Writable: true},
+		simulator.OPCUAVariable{Name: "Dev2.Mode", DataType: "Int32", Value: int32(0), Writable: true},
+	)
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+	_, err := test.CreateAndInitNode("x/opcuaWrite", types.Configuration{
+		"server": srv.Endpoint(),
+		"items":  []map[string]interface{}{{"value": "1"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	// 一个写入节点按元数据中的设备点位写入不同设备
+	node, err := test.CreateAndInitNode("x/opcuaWrite", types.Configuration{
+		"server": srv.Endpoint(),
+		"policy": "None",
+		"mode":   "None",
+		"auth":   "Anonymous",
+		"items": []map[string]interface{}{
+			{"nodeId": "${metadata.deviceNodeId}", "dataType": "Double", "valuePath": "command.setpoint"},
+			{"nodeId": "${msg.command.modeNodeId}", "dataType": "Int32", "value": "${metadata.mode}"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var relations []string
+	newMsg := func(device string, setpoint interface{}) test.Msg {
+		data, _ := json.Marshal(map[string]interface{}{
+			"command": map[string]interface{}{"setpoint": setpoint, "modeNodeId": srv.NodeId("Dev2.Mode")},
+		})
+		return test.Msg{
+			MetaData:   types.BuildMetadata(map[string]string{"deviceNodeId": srv.NodeId(device), "mode": "3"}),
+			DataType:   types.JSON,
+			MsgType:    "COMMAND",
+			Data:       string(data),
+			AfterSleep: time.Millisecond * 300,
+		}
+	}
+	test.NodeOnMsg(t, node, []test.Msg{
+		newMsg("Dev1.SP", 21.5),
+		newMsg("Dev2.SP", 18),
+		{MetaData: types.NewMetadata(), DataType: types.JSON, MsgType: "COMMAND", Data: `{"command":{}}`, AfterSleep: time.Millisecond * 300},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		relations = append(relations, relationType)
+	})
+	assert.Equal(t, []string{types.Success, types.Success, types.Failure}, relations)
+
+	value, err := srv.Value("Dev1.SP")
+	assert.Nil(t, err)
+	assert.Equal(t, 21.5, value)
+	value, err = srv.Value("Dev2.SP")
+	assert.Nil(t, err)
+	assert.Equal(t, 18.0, value)
+	value, err = srv.Value("Dev2.Mode")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), value)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package payloadcodec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/codec"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 文本负荷的编码
+const (
+	EncodingBinary = "binary"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&DecodeNode{})
+}
+
+// Configuration 编解码节点配置
+type Configuration struct {
+	// Codec 编解码器：cbor、msgpack、protobuf、json 或者通过 codec.Register 注册的自定义编解码器
+	Codec string `json:"codec" label:"Codec" desc:"Codec: cbor, msgpack, protobuf, json or a custom codec registered with codec.Register" required:"true"`
+	// Encoding 文本负荷的编码
+	Encoding string `json:"encoding" label:"Encoding" desc:"Encoding of text payloads: hex, base64. Binary messages are decoded as is; the encoder also supports binary (default)"`
+	// Descriptor protobuf 描述符集合文件的路径，由 protoc --include_imports --descriptor_set_out 生成
+	Descriptor string `json:"descriptor" label:"Descriptor set" desc:"Path of the protobuf descriptor set generated by protoc --include_imports --descriptor_set_out"`
+	// Message protobuf 消息的完整名称，eg. sensor.v1.Reading
+	Message string `json:"message" label:"Message" desc:"Fully qualified protobuf message name, eg. sensor.v1.Reading"`
+	// Params 自定义编解码器的参数
+	Params map[string]interface{} `json:"params" label:"Params" desc:"Parameters of custom codecs"`
+}
+
+// newCodec 按配置创建编解码器
+func (c Configuration) newCodec(allowBinary bool) (codec.Codec, error) {
+	if c.Codec == "" {
+		return nil, fmt.Errorf("codec is required")
+	}
+	if err := checkEncoding(c.Encoding, allowBinary); err != nil {
+		return nil, err
+	}
+	return codec.New(strings.ToLower(c.Codec), codec.Options{
+		Descriptor: c.Descriptor,
+		Message:    c.Message,
+		Params:     c.Params,
+	})
+}
+
+// DecodeNode 负荷解码节点，使用 CBOR、MessagePack、Protobuf 等编解码器把设备或者云端的二进制负荷解码为 JSON。
+// msg.Data 为二进制数据或者十六进制、base64 字符串；Protobuf 需要指定描述符集合和消息名称，字段按 JSON 名称输出，枚举输出为名称，bytes 输出为 base64。
+// 解码结果重新赋值到msg.Data。解码成功，流转到`Success`链，否则流转到`Failure`链
+type DecodeNode struct {
+	//节点配置
+	Config Configuration
+	codec  codec.Codec
+}
+
+// Type 返回组件类型
+func (x *DecodeNode) Type() string {
+	return "x/payloadDecode"
+}
+
+// New 默认参数
+func (x *DecodeNode) New() types.Node {
+	return &DecodeNode{
+		Config: Configuration{
+			Codec:    codec.CBOR,
+			Encoding: EncodingHex,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DecodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	var err error
+	x.codec, err = x.Config.newCodec(false)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *DecodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data []byte
+	if msg.DataType == types.BINARY {
+		data = msg.GetBytes()
+	} else {
+		var err error
+		if data, err = decodeText(msg.GetData(), x.Config.Encoding); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	value, err := x.codec.Decode(data)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *DecodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *DecodeNode) Desc() string {
+	return "Payload decoder converting CBOR, MessagePack or Protobuf (with a user-provided descriptor set) to JSON through a pluggable codec registry. Routes to Success/Failure"
+}
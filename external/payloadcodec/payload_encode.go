@@ -0,0 +1,128 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package payloadcodec
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/codec"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&EncodeNode{})
+}
+
+// EncodeNode 负荷编码节点，使用 CBOR、MessagePack、Protobuf 等编解码器把 msg.Data 中的 JSON 编码为二进制负荷，用于向设备或者云端发送。
+// 整数按整数编码；Protobuf 字段可以使用原始名称或者 JSON 名称，枚举可以使用名称或者数值，未知的字段返回错误。
+// 编码结果重新赋值到msg.Data，encoding 为 binary 时消息数据类型为 BINARY。
+// 编码成功，流转到`Success`链，否则流转到`Failure`链
+type EncodeNode struct {
+	//节点配置
+	Config Configuration
+	codec  codec.Codec
+}
+
+// Type 返回组件类型
+func (x *EncodeNode) Type() string {
+	return "x/payloadEncode"
+}
+
+// New 默认参数
+func (x *EncodeNode) New() types.Node {
+	return &EncodeNode{
+		Config: Configuration{
+			Codec:    codec.CBOR,
+			Encoding: EncodingBinary,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *EncodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	var err error
+	x.codec, err = x.Config.newCodec(true)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *EncodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(msg.GetData()))
+	// 保留整数的精度
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	data, err := x.codec.Encode(value)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	switch strings.ToLower(x.Config.Encoding) {
+	case EncodingHex:
+		msg.SetDataType(types.TEXT)
+		msg.SetData(hex.EncodeToString(data))
+	case EncodingBase64:
+		msg.SetDataType(types.TEXT)
+		msg.SetData(base64.StdEncoding.EncodeToString(data))
+	default:
+		msg.SetDataType(types.BINARY)
+		msg.SetBytes(data)
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *EncodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *EncodeNode) Desc() string {
+	return "Payload encoder converting JSON to CBOR, MessagePack or Protobuf (with a user-provided descriptor set) through a pluggable codec registry. Routes to Success/Failure"
+}
+
+// checkEncoding 校验编码，allowBinary 表示是否支持 binary
+func checkEncoding(encoding string, allowBinary bool) error {
+	switch strings.ToLower(encoding) {
+	case "", EncodingHex, EncodingBase64:
+		return nil
+	case EncodingBinary:
+		if allowBinary {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported encoding: %s", encoding)
+}
+
+// decodeText 解码十六进制或者 base64 字符串，十六进制忽略空白字符
+func decodeText(s string, encoding string) ([]byte, error) {
+	if strings.ToLower(encoding) == EncodingBase64 {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	}
+	return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package payloadcodec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestPayloadNodes(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DecodeNode{})
+	Registry.Add(&EncodeNode{})
+
+	_, err := test.CreateAndInitNode("x/payloadDecode", types.Configuration{"codec": "avro"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/payloadDecode", types.Configuration{"encoding": "binary"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/payloadEncode", types.Configuration{"codec": "protobuf"}, Registry)
+	assert.NotNil(t, err)
+
+	decoder, err := test.CreateAndInitNode("x/payloadDecode", types.Configuration{"codec": "cbor"}, Registry)
+	assert.Nil(t, err)
+	encoder, err := test.CreateAndInitNode("x/payloadEncode", types.Configuration{"codec": "msgpack"}, Registry)
+	assert.Nil(t, err)
+	hexEncoder, err := test.CreateAndInitNode("x/payloadEncode", types.Configuration{"codec": "cbor", "encoding": "hex"}, Registry)
+	assert.Nil(t, err)
+
+	test.NodeOnMsg(t, decoder, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.TEXT,
+			MsgType:    "UPLINK",
+			Data:       "a2 61 61 01 61 62 82 f5 f6",
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.TEXT,
+			MsgType:    "SHORT",
+			Data:       "a2 61",
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "SHORT" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.JSON, msg.DataType)
+		assert.Equal(t, `{"a":1,"b":[true,null]}`, msg.GetData())
+	})
+
+	test.NodeOnMsg(t, encoder, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "COMMAND",
+		Data:       `{"a":1}`,
+		AfterSleep: time.Millisecond * 100,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.BINARY, msg.DataType)
+		assert.Equal(t, []byte{0x81, 0xa1, 'a', 0x01}, msg.GetBytes())
+	})
+
+	test.NodeOnMsg(t, hexEncoder, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "COMMAND",
+			Data:       `{"seq":18446744073709551615}`,
+			AfterSleep: time.Millisecond * 100,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "INVALID",
+			Data:       `{"seq":`,
+			AfterSleep: time.Millisecond * 100,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "INVALID" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "a1637365711bffffffffffffffff", msg.GetData())
+	})
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pollcontrol 提供暂停和恢复轮询端点的节点
+// 维护窗口期间由规则链暂停 OPC UA、Modbus、S7、MTConnect 等轮询端点，停止访问设备，不需要停止端点或者修改配置
+package pollcontrol
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/poll"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 操作
+const (
+	// ActionPause 暂停轮询
+	ActionPause = "pause"
+	// ActionResume 恢复轮询
+	ActionResume = "resume"
+	// ActionStatus 只查询是否已经暂停
+	ActionStatus = "status"
+)
+
+// KeyPaused 端点是否已经暂停的元数据key：true、false
+const KeyPaused = "paused"
+
+func init() {
+	_ = rulego.Registry.Register(&PollControlNode{})
+}
+
+// PollControlConfiguration 节点配置
+type PollControlConfiguration struct {
+	// RouterId 轮询端点的路由 Id，支持 ${metadata.key} 和 ${msg.key} 占位符
+	RouterId string `json:"routerId" label:"Router ID" desc:"Router id of the polling endpoint. Supports ${metadata.key} and ${msg.key} placeholders" required:"true"`
+	// Action 操作：pause、resume、status，支持 ${metadata.key} 和 ${msg.key} 占位符
+	// 为空时按消息类型执行：POLL_PAUSE 暂停，POLL_RESUME 恢复，其他消息类型只查询状态
+	Action string `json:"action" label:"Action" desc:"pause, resume or status. Empty uses the message type: POLL_PAUSE pauses, POLL_RESUME resumes, other types only query the status"`
+}
+
+// PollControlNode 暂停和恢复轮询端点，端点通过路由 Id 查找
+// 暂停期间端点的定时任务照常触发但不读取设备，连接和配置保持不变，恢复后下一次触发时开始读取
+// 元数据 paused 为执行后端点是否已经暂停，消息负荷不变。
+// 执行成功流转到`Success`链，端点不存在或者操作无效流转到`Failure`链
+type PollControlNode struct {
+	//节点配置
+	Config           PollControlConfiguration
+	routerIdTemplate str.Template
+	actionTemplate   str.Template
+}
+
+// Type 返回组件类型
+func (x *PollControlNode) Type() string {
+	return "x/pollControl"
+}
+
+// New 默认参数
+func (x *PollControlNode) New() types.Node {
+	return &PollControlNode{}
+}
+
+// Init 初始化组件
+func (x *PollControlNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.RouterId) == "" {
+		return errors.New("routerId can not be empty")
+	}
+	x.routerIdTemplate = str.NewTemplate(x.Config.RouterId)
+	x.actionTemplate = str.NewTemplate(x.Config.Action)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *PollControlNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	routerId := x.routerIdTemplate.Execute(evn)
+	ep, ok := poll.Lookup(routerId)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("polling endpoint not found: %s", routerId))
+		return
+	}
+	action := strings.ToLower(strings.TrimSpace(x.actionTemplate.Execute(evn)))
+	if action == "" {
+		action = actionOf(msg.Type)
+	}
+	switch action {
+	case ActionPause:
+		ep.Pause()
+	case ActionResume:
+		ep.Resume()
+	case ActionStatus:
+	default:
+		ctx.TellFailure(msg, fmt.Errorf("unsupported poll control action: %s", action))
+		return
+	}
+	msg.Metadata.PutValue(KeyPaused, strconv.FormatBool(ep.Paused()))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *PollControlNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *PollControlNode) Desc() string {
+	return "Pauses or resumes a polling endpoint by router id without stopping it, for maintenance windows. Routes to Success/Failure"
+}
+
+// actionOf 消息类型对应的操作
+func actionOf(msgType string) string {
+	switch msgType {
+	case poll.MsgTypePause:
+		return ActionPause
+	case poll.MsgTypeResume:
+		return ActionResume
+	default:
+		return ActionStatus
+	}
+}
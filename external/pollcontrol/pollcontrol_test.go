@@ -0,0 +1,82 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pollcontrol
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego-components-iot/pkg/poll"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestPollControlNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&PollControlNode{})
+
+	_, err := test.CreateAndInitNode("x/pollControl", types.Configuration{}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/pollControl", types.Configuration{
+		"routerId": "${metadata.routerId}",
+		"action":   "${metadata.action}",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	ep := &poll.Pauser{}
+	poll.Register("plc1", ep)
+	defer poll.Unregister("plc1", ep)
+
+	meta := func(routerId, action string) *types.Metadata {
+		metadata := types.NewMetadata()
+		metadata.PutValue("routerId", routerId)
+		metadata.PutValue("action", action)
+		return metadata
+	}
+
+	var relations, paused []string
+	// 节点在 OnMsg 返回前完成回调，同步处理保证消息的顺序
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		relations = append(relations, relationType)
+		paused = append(paused, msg.Metadata.GetValue(KeyPaused))
+	})
+	for _, m := range []struct {
+		routerId, action, msgType string
+	}{
+		{"plc1", ActionPause, "MAINTENANCE"},
+		{"plc1", ActionStatus, "MAINTENANCE"},
+		{"plc1", "Resume", "MAINTENANCE"},
+		// 操作为空时按消息类型执行
+		{"plc1", "", poll.MsgTypePause},
+		{"plc1", "restart", "MAINTENANCE"},
+		{"plc2", ActionPause, "MAINTENANCE"},
+	} {
+		node.OnMsg(ctx, types.NewMsg(0, m.msgType, types.JSON, meta(m.routerId, m.action), "{}"))
+	}
+
+	assert.Equal(t, []string{types.Success, types.Success, types.Success, types.Success, types.Failure, types.Failure}, relations)
+	assert.Equal(t, []string{"true", "true", "false", "true", "", ""}, paused)
+	assert.True(t, ep.Paused())
+}
+
+func TestActionOf(t *testing.T) {
+	assert.Equal(t, ActionPause, actionOf(poll.MsgTypePause))
+	assert.Equal(t, ActionResume, actionOf(poll.MsgTypeResume))
+	assert.Equal(t, ActionStatus, actionOf("TELEMETRY"))
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// 存储区
+const (
+	AreaDB = "DB"
+	AreaM  = "M"
+	AreaI  = "I"
+	AreaQ  = "Q"
+)
+
+// 数据类型
+const (
+	DataTypeBool   = "BOOL"
+	DataTypeByte   = "BYTE"
+	DataTypeSInt   = "SINT"
+	DataTypeChar   = "CHAR"
+	DataTypeWord   = "WORD"
+	DataTypeInt    = "INT"
+	DataTypeUInt   = "UINT"
+	DataTypeDWord  = "DWORD"
+	DataTypeDInt   = "DINT"
+	DataTypeUDInt  = "UDINT"
+	DataTypeReal   = "REAL"
+	DataTypeLInt   = "LINT"
+	DataTypeULInt  = "ULINT"
+	DataTypeLReal  = "LREAL"
+	DataTypeString = "STRING"
+)
+
+// defaultStringLength STRING 未指定长度时的最大字符数
+const defaultStringLength = 254
+
+// areaCodes S7 协议的存储区编码
+var areaCodes = map[string]byte{
+	AreaI:  0x81,
+	AreaQ:  0x82,
+	AreaM:  0x83,
+	AreaDB: 0x84,
+}
+
+// typeSizes 数据类型占用的字节数
+var typeSizes = map[string]int{
+	DataTypeByte:  1,
+	DataTypeSInt:  1,
+	DataTypeChar:  1,
+	DataTypeWord:  2,
+	DataTypeInt:   2,
+	DataTypeUInt:  2,
+	DataTypeDWord: 4,
+	DataTypeDInt:  4,
+	DataTypeUDInt: 4,
+	DataTypeReal:  4,
+	DataTypeLInt:  8,
+	DataTypeULInt: 8,
+	DataTypeLReal: 8,
+}
+
+var (
+	dbAddressPattern   = regexp.MustCompile(`^DB(\d+)\.DB([XBWD])(\d+)(?:\.(\d))?$`)
+	areaAddressPattern = regexp.MustCompile(`^([MIEQA])([BWD]?)(\d+)(?:\.(\d))?$`)
+	stringTypePattern  = regexp.MustCompile(`^STRING(?:\[(\d+)\])?$`)
+)
+
+// Address 解析后的 S7 地址
+type Address struct {
+	// Area 存储区：DB, M, I, Q
+	Area string
+	// DBNumber 数据块编号，仅 DB 区有效
+	DBNumber uint16
+	// Start 起始字节
+	Start int
+	// Bit 位地址，仅 BOOL 类型有效
+	Bit int
+	// DataType 数据类型
+	DataType string
+	// Size 占用的字节数，BOOL 为 1
+	Size int
+	raw  string
+}
+
+// ParseAddress 解析 S7 地址，不区分大小写，格式：<地址>[:<数据类型>]
+//   - DB 区：DB1.DBX0.1, DB1.DBB2, DB1.DBW4, DB1.DBD20:REAL, DB1.DBB30:STRING[20]
+//   - M/I/Q 区：M0.0, MB1, MW2, MD4:DINT, I0.1, IW64, Q0.0, QD8:REAL，也可以使用德语助记符 E/A 表示 I/Q
+//
+// 未指定数据类型时按地址宽度：X 为 BOOL，B 为 BYTE，W 为 WORD，D 为 DWORD。
+// 指定的数据类型需要与 W/D 地址宽度一致，B 地址可以指定任意非 BOOL 类型，按类型宽度读取
+func ParseAddress(s string) (*Address, error) {
+	raw := strings.TrimSpace(s)
+	location, dataType, _ := strings.Cut(strings.ToUpper(raw), ":")
+	a := &Address{raw: raw}
+	var width, start, bit string
+	if m := dbAddressPattern.FindStringSubmatch(location); m != nil {
+		db, err := strconv.ParseUint(m[1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s7 address %s: %w", raw, err)
+		}
+		a.Area, a.DBNumber, width, start, bit = AreaDB, uint16(db), m[2], m[3], m[4]
+	} else if m = areaAddressPattern.FindStringSubmatch(location); m != nil {
+		switch m[1] {
+		case "E":
+			a.Area = AreaI
+		case "A":
+			a.Area = AreaQ
+		default:
+			a.Area = m[1]
+		}
+		width, start, bit = m[2], m[3], m[4]
+		if width == "" {
+			width = "X"
+		}
+	} else {
+		return nil, fmt.Errorf("invalid s7 address: %s", raw)
+	}
+	var err error
+	if a.Start, err = strconv.Atoi(start); err != nil || a.Start > 0xffff {
+		return nil, fmt.Errorf("invalid s7 address start: %s", raw)
+	}
+	if width == "X" {
+		if bit == "" || bit > "7" {
+			return nil, fmt.Errorf("invalid s7 bit address: %s", raw)
+		}
+		if dataType != "" && dataType != DataTypeBool {
+			return nil, fmt.Errorf("s7 bit address %s only supports BOOL", raw)
+		}
+		a.Bit, _ = strconv.Atoi(bit)
+		a.DataType, a.Size = DataTypeBool, 1
+		return a, nil
+	}
+	if bit != "" {
+		return nil, fmt.Errorf("invalid s7 address: %s", raw)
+	}
+	widths := map[string]int{"B": 1, "W": 2, "D": 4}
+	defaults := map[string]string{"B": DataTypeByte, "W": DataTypeWord, "D": DataTypeDWord}
+	if dataType == "" {
+		dataType = defaults[width]
+	}
+	if m := stringTypePattern.FindStringSubmatch(dataType); m != nil {
+		length := defaultStringLength
+		if m[1] != "" {
+			if length, err = strconv.Atoi(m[1]); err != nil || length == 0 || length > defaultStringLength {
+				return nil, fmt.Errorf("invalid s7 string length: %s", raw)
+			}
+		}
+		a.DataType, a.Size = DataTypeString, length+2
+	} else if size, ok := typeSizes[dataType]; ok {
+		a.DataType, a.Size = dataType, size
+	} else {
+		return nil, fmt.Errorf("unsupported s7 data type: %s", dataType)
+	}
+	if width != "B" && a.Size != widths[width] {
+		return nil, fmt.Errorf("s7 data type %s does not match address width: %s", a.DataType, raw)
+	}
+	return a, nil
+}
+
+// String 返回原始地址
+func (a *Address) String() string {
+	return a.raw
+}
+
+// areaCode S7 协议的存储区编码
+func (a *Address) areaCode() byte {
+	return areaCodes[a.Area]
+}
+
+// Decode 把 PLC 字节（大端序）解码为值
+func (a *Address) Decode(data []byte) (interface{}, error) {
+	if len(data) < a.Size {
+		return nil, fmt.Errorf("s7 address %s: short data", a.raw)
+	}
+	switch a.DataType {
+	case DataTypeBool:
+		return data[0]&0x01 != 0, nil
+	case DataTypeByte:
+		return data[0], nil
+	case DataTypeSInt:
+		return int8(data[0]), nil
+	case DataTypeChar:
+		return string(data[:1]), nil
+	case DataTypeWord, DataTypeUInt:
+		return binary.BigEndian.Uint16(data), nil
+	case DataTypeInt:
+		return int16(binary.BigEndian.Uint16(data)), nil
+	case DataTypeDWord, DataTypeUDInt:
+		return binary.BigEndian.Uint32(data), nil
+	case DataTypeDInt:
+		return int32(binary.BigEndian.Uint32(data)), nil
+	case DataTypeReal:
+		return math.Float32frombits(binary.BigEndian.Uint32(data)), nil
+	case DataTypeLInt:
+		return int64(binary.BigEndian.Uint64(data)), nil
+	case DataTypeULInt:
+		return binary.BigEndian.Uint64(data), nil
+	case DataTypeLReal:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	case DataTypeString:
+		// 第1个字节为最大长度，第2个字节为实际长度
+		length := int(data[1])
+		if length > a.Size-2 {
+			length = a.Size - 2
+		}
+		return string(data[2 : 2+length]), nil
+	default:
+		return nil, fmt.Errorf("unsupported s7 data type: %s", a.DataType)
+	}
+}
+
+// Encode 把值编码为 PLC 字节（大端序）
+func (a *Address) Encode(value interface{}) ([]byte, error) {
+	data := make([]byte, a.Size)
+	switch a.DataType {
+	case DataTypeBool:
+		v, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, fmt.Errorf("s7 address %s: %w", a.raw, err)
+		}
+		if v {
+			data[0] = 1
+		}
+		return data, nil
+	case DataTypeChar:
+		s := cast.ToString(value)
+		if len(s) > 0 {
+			data[0] = s[0]
+		}
+		return data, nil
+	case DataTypeString:
+		s := cast.ToString(value)
+		if len(s) > a.Size-2 {
+			s = s[:a.Size-2]
+		}
+		data[0], data[1] = byte(a.Size-2), byte(len(s))
+		copy(data[2:], s)
+		return data, nil
+	case DataTypeLInt, DataTypeULInt:
+		// 64位整数不经过 float64 转换，避免丢失精度
+		v, err := cast.ToInt64E(value)
+		if err != nil {
+			return nil, fmt.Errorf("s7 address %s: %w", a.raw, err)
+		}
+		binary.BigEndian.PutUint64(data, uint64(v))
+		return data, nil
+	}
+	v, err := cast.ToFloat64E(value)
+	if err != nil {
+		return nil, fmt.Errorf("s7 address %s: %w", a.raw, err)
+	}
+	switch a.DataType {
+	case DataTypeByte:
+		data[0] = byte(int64(v))
+	case DataTypeSInt:
+		data[0] = byte(int8(v))
+	case DataTypeWord, DataTypeUInt:
+		binary.BigEndian.PutUint16(data, uint16(int64(v)))
+	case DataTypeInt:
+		binary.BigEndian.PutUint16(data, uint16(int16(v)))
+	case DataTypeDWord, DataTypeUDInt:
+		binary.BigEndian.PutUint32(data, uint32(int64(v)))
+	case DataTypeDInt:
+		binary.BigEndian.PutUint32(data, uint32(int32(v)))
+	case DataTypeReal:
+		binary.BigEndian.PutUint32(data, math.Float32bits(float32(v)))
+	case DataTypeLReal:
+		binary.BigEndian.PutUint64(data, math.Float64bits(v))
+	default:
+		return nil, fmt.Errorf("unsupported s7 data type: %s", a.DataType)
+	}
+	return data, nil
+}
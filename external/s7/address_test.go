@@ -0,0 +1,104 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	a, err := ParseAddress("DB1.DBD20:REAL")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaDB, a.Area)
+	assert.Equal(t, uint16(1), a.DBNumber)
+	assert.Equal(t, 20, a.Start)
+	assert.Equal(t, DataTypeReal, a.DataType)
+	assert.Equal(t, 4, a.Size)
+
+	a, err = ParseAddress("db10.dbx3.7")
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(10), a.DBNumber)
+	assert.Equal(t, 3, a.Start)
+	assert.Equal(t, 7, a.Bit)
+	assert.Equal(t, DataTypeBool, a.DataType)
+
+	a, err = ParseAddress("MW2")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaM, a.Area)
+	assert.Equal(t, DataTypeWord, a.DataType)
+
+	a, err = ParseAddress("E0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaI, a.Area)
+	assert.Equal(t, 1, a.Bit)
+
+	a, err = ParseAddress("QD8:DINT")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaQ, a.Area)
+	assert.Equal(t, DataTypeDInt, a.DataType)
+
+	a, err = ParseAddress("DB1.DBB0:LREAL")
+	assert.Nil(t, err)
+	assert.Equal(t, 8, a.Size)
+
+	a, err = ParseAddress("DB1.DBB30:STRING[20]")
+	assert.Nil(t, err)
+	assert.Equal(t, DataTypeString, a.DataType)
+	assert.Equal(t, 22, a.Size)
+
+	for _, s := range []string{"", "DB1", "DB1.DBX0", "DB1.DBX0.8", "MB0.1", "M0", "DB1.DBW0:REAL", "DB1.DBX0.0:INT", "MW0:FOO", "DB1.DBB0:STRING[300]"} {
+		_, err = ParseAddress(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	cases := []struct {
+		address string
+		value   interface{}
+		want    interface{}
+	}{
+		{"M0.0", true, true},
+		{"MB0", 200, byte(200)},
+		{"MB0:SINT", -3, int8(-3)},
+		{"MW0:INT", -100, int16(-100)},
+		{"MW0", 0xabcd, uint16(0xabcd)},
+		{"MD0:DINT", -70000, int32(-70000)},
+		{"MD0:REAL", 21.5, float32(21.5)},
+		{"MB0:LINT", "-9007199254740993", int64(-9007199254740993)},
+		{"MB0:LREAL", 1.25, 1.25},
+		{"MB0:STRING[4]", "hello", "hell"},
+	}
+	for _, c := range cases {
+		a, err := ParseAddress(c.address)
+		assert.Nil(t, err)
+		data, err := a.Encode(c.value)
+		assert.Nil(t, err)
+		assert.Equal(t, a.Size, len(data))
+		v, err := a.Decode(data)
+		assert.Nil(t, err)
+		assert.Equal(t, c.want, v, c.address)
+	}
+
+	a, _ := ParseAddress("MD0:REAL")
+	data, _ := a.Encode(21.5)
+	assert.Equal(t, []byte{0x41, 0xac, 0x00, 0x00}, data)
+	_, err := a.Encode("abc")
+	assert.NotNil(t, err)
+}
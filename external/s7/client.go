@@ -0,0 +1,467 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/capture"
+)
+
+const (
+	// DefaultPort ISO-on-TCP 端口
+	DefaultPort = "102"
+	// DefaultServer 默认 PLC 地址
+	DefaultServer = "127.0.0.1:102"
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 5 * time.Second
+	// defaultPDUSize 建立连接时请求的 PDU 大小，实际大小由 PLC 协商
+	defaultPDUSize = 960
+	// maxItems 单次请求的最大变量数量
+	maxItems = 20
+)
+
+// S7 协议常量
+const (
+	tpktHeaderSize = 4
+	cotpDataSize   = 3
+	requestHeader  = 10
+	responseHeader = 12
+	itemSpecSize   = 12
+	itemHeaderSize = 4
+	rosctrJob      = 0x01
+	rosctrAckData  = 0x03
+	funcReadVar    = 0x04
+	funcWriteVar   = 0x05
+	funcSetupComm  = 0xf0
+	transportBit   = 0x01
+	transportByte  = 0x02
+	dataBit        = 0x03
+	dataByte       = 0x04
+	dataInteger    = 0x05
+	returnCodeOK   = 0xff
+)
+
+// ErrClosed 连接已经关闭
+var ErrClosed = errors.New("s7 connection is closed")
+
+// ItemError 变量读写失败，PLC 返回的错误码
+type ItemError struct {
+	Address string
+	Code    byte
+}
+
+func (e *ItemError) Error() string {
+	var reason string
+	switch e.Code {
+	case 0x01:
+		reason = "hardware fault"
+	case 0x03:
+		reason = "access denied"
+	case 0x05:
+		reason = "address out of range"
+	case 0x06:
+		reason = "data type not supported"
+	case 0x07:
+		reason = "data type inconsistent"
+	case 0x0a:
+		reason = "object does not exist"
+	default:
+		reason = "unknown error"
+	}
+	return fmt.Sprintf("s7 %s: %s (0x%02x)", e.Address, reason, e.Code)
+}
+
+// ClientConfig 创建 S7 客户端的配置
+type ClientConfig struct {
+	// Server PLC 地址，格式：host:port，端口默认 102
+	Server string
+	// Rack 机架号
+	Rack int
+	// Slot 槽号，S7-300/400 一般为 2，S7-1200/1500 一般为 1
+	Slot int
+	// Timeout 连接和请求超时
+	Timeout time.Duration
+	// CircuitBreaker 熔断配置，相同 PLC 的连接共享熔断器，以第一个启用熔断的配置为准
+	CircuitBreaker breaker.Config
+}
+
+// Key 共享连接的 key，格式：server/rack/slot
+func (c ClientConfig) Key() string {
+	return fmt.Sprintf("%s/%d/%d", c.address(), c.Rack, c.Slot)
+}
+
+// address 补全端口的 PLC 地址
+func (c ClientConfig) address() string {
+	server := strings.TrimPrefix(c.Server, "tcp://")
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, DefaultPort)
+	}
+	return server
+}
+
+// Client S7 ISO-on-TCP 客户端，请求是串行的，可以并发调用
+// 通信出错时关闭连接，由调用方重新建立
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	timeout time.Duration
+	pduSize int
+	pduRef  uint16
+	closed  bool
+}
+
+// Dial 连接 PLC 并协商 PDU 大小
+func Dial(config ClientConfig) (*Client, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	conn, err := net.DialTimeout("tcp", config.address(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn = capture.WrapConn(conn, capture.Source("s7", config.address()))
+	c := &Client{conn: conn, timeout: timeout}
+	if err = c.connect(config.Rack, config.Slot); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// PDUSize 协商后的 PDU 大小
+func (c *Client) PDUSize() int {
+	return c.pduSize
+}
+
+// Closed 连接是否已经关闭
+func (c *Client) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
+
+// connect 建立 COTP 连接并协商 PDU 大小
+func (c *Client) connect(rack, slot int) error {
+	// COTP 连接请求，本地 TSAP 0x0100，远端 TSAP 为 PG 连接 + 机架槽号
+	cr := []byte{
+		0x11, 0xe0, 0x00, 0x00, 0x00, 0x01, 0x00,
+		0xc0, 0x01, 0x0a,
+		0xc1, 0x02, 0x01, 0x00,
+		0xc2, 0x02, 0x01, byte(rack*0x20 + slot),
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := writeTPKT(c.conn, cr); err != nil {
+		return err
+	}
+	cc, err := readTPKT(c.conn)
+	if err != nil {
+		return err
+	}
+	if len(cc) < 2 || cc[1] != 0xd0 {
+		return fmt.Errorf("s7 connection refused, check rack and slot")
+	}
+	param, _, err := c.exchange([]byte{funcSetupComm, 0x00, 0x00, 0x01, 0x00, 0x01,
+		byte(defaultPDUSize >> 8), byte(defaultPDUSize & 0xff)}, nil)
+	if err != nil {
+		return err
+	}
+	if len(param) < 8 {
+		return fmt.Errorf("s7 setup communication: short response")
+	}
+	c.pduSize = int(binary.BigEndian.Uint16(param[6:8]))
+	if c.pduSize < requestHeader+2+itemSpecSize+itemHeaderSize+2 {
+		return fmt.Errorf("s7 setup communication: invalid pdu size %d", c.pduSize)
+	}
+	return nil
+}
+
+// chunk 一次请求中的一个变量，大于 PDU 的变量拆分为多个 chunk
+type chunk struct {
+	addr  *Address
+	start int
+	data  []byte
+}
+
+// ReadItems 读取多个变量，按 PDU 大小合并为尽可能少的请求，返回的值与 items 一一对应
+func (c *Client) ReadItems(items []*Address) ([]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	buffers := make([][]byte, len(items))
+	var chunks []chunk
+	// 奇数长度需要填充1个字节，按偶数拆分
+	maxData := (c.pduSize - responseHeader - 2 - itemHeaderSize) &^ 1
+	for i, item := range items {
+		buffers[i] = make([]byte, item.Size)
+		chunks = append(chunks, split(item, buffers[i], maxData)...)
+	}
+	for len(chunks) > 0 {
+		n, reqSize, respSize := 0, requestHeader+2, responseHeader+2
+		for n < len(chunks) && n < maxItems {
+			size := itemHeaderSize + len(chunks[n].data) + len(chunks[n].data)%2
+			if reqSize+itemSpecSize > c.pduSize || respSize+size > c.pduSize {
+				break
+			}
+			reqSize += itemSpecSize
+			respSize += size
+			n++
+		}
+		if err := c.readChunks(chunks[:n]); err != nil {
+			return nil, err
+		}
+		chunks = chunks[n:]
+	}
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		v, err := item.Decode(buffers[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// WriteItems 写入多个变量，values 与 items 一一对应
+func (c *Client) WriteItems(items []*Address, values [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	var chunks []chunk
+	maxData := (c.pduSize - requestHeader - 2 - itemSpecSize - itemHeaderSize) &^ 1
+	for i, item := range items {
+		if len(values[i]) != item.Size {
+			return fmt.Errorf("s7 %s: expected %d bytes, got %d", item, item.Size, len(values[i]))
+		}
+		chunks = append(chunks, split(item, values[i], maxData)...)
+	}
+	for len(chunks) > 0 {
+		n, reqSize := 0, requestHeader+2
+		for n < len(chunks) && n < maxItems {
+			size := itemSpecSize + itemHeaderSize + len(chunks[n].data) + len(chunks[n].data)%2
+			if reqSize+size > c.pduSize {
+				break
+			}
+			reqSize += size
+			n++
+		}
+		if err := c.writeChunks(chunks[:n]); err != nil {
+			return err
+		}
+		chunks = chunks[n:]
+	}
+	return nil
+}
+
+// split 按 maxData 拆分变量，chunk 的 data 指向 buf 中对应的区间
+func split(item *Address, buf []byte, maxData int) []chunk {
+	var chunks []chunk
+	for offset := 0; offset < len(buf); offset += maxData {
+		end := offset + maxData
+		if end > len(buf) {
+			end = len(buf)
+		}
+		chunks = append(chunks, chunk{addr: item, start: item.Start + offset, data: buf[offset:end]})
+	}
+	return chunks
+}
+
+// itemSpec 变量描述
+func itemSpec(ck chunk) []byte {
+	spec := []byte{0x12, 0x0a, 0x10, transportByte, 0, 0, 0, 0, ck.addr.areaCode(), 0, 0, 0}
+	bitAddress := ck.start * 8
+	length := len(ck.data)
+	if ck.addr.DataType == DataTypeBool {
+		spec[3] = transportBit
+		bitAddress += ck.addr.Bit
+	}
+	binary.BigEndian.PutUint16(spec[4:6], uint16(length))
+	if ck.addr.Area == AreaDB {
+		binary.BigEndian.PutUint16(spec[6:8], ck.addr.DBNumber)
+	}
+	spec[9], spec[10], spec[11] = byte(bitAddress>>16), byte(bitAddress>>8), byte(bitAddress)
+	return spec
+}
+
+// readChunks 一次请求读取多个 chunk
+func (c *Client) readChunks(chunks []chunk) error {
+	param := []byte{funcReadVar, byte(len(chunks))}
+	for _, ck := range chunks {
+		param = append(param, itemSpec(ck)...)
+	}
+	_, data, err := c.exchange(param, nil)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	for i, ck := range chunks {
+		if offset+itemHeaderSize > len(data) {
+			return fmt.Errorf("s7 read %s: short response", ck.addr)
+		}
+		code, transport := data[offset], data[offset+1]
+		if code != returnCodeOK {
+			return &ItemError{Address: ck.addr.String(), Code: code}
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if transport == dataByte || transport == dataInteger {
+			// 长度单位为位
+			length /= 8
+		}
+		offset += itemHeaderSize
+		if offset+length > len(data) || length != len(ck.data) {
+			return fmt.Errorf("s7 read %s: unexpected data length %d", ck.addr, length)
+		}
+		copy(ck.data, data[offset:offset+length])
+		offset += length
+		if length%2 == 1 && i < len(chunks)-1 {
+			offset++
+		}
+	}
+	return nil
+}
+
+// writeChunks 一次请求写入多个 chunk
+func (c *Client) writeChunks(chunks []chunk) error {
+	param := []byte{funcWriteVar, byte(len(chunks))}
+	var data []byte
+	for i, ck := range chunks {
+		param = append(param, itemSpec(ck)...)
+		header := []byte{0x00, dataByte, 0, 0}
+		bits := len(ck.data) * 8
+		if ck.addr.DataType == DataTypeBool {
+			header[1], bits = dataBit, 1
+		}
+		binary.BigEndian.PutUint16(header[2:4], uint16(bits))
+		data = append(data, header...)
+		data = append(data, ck.data...)
+		if len(ck.data)%2 == 1 && i < len(chunks)-1 {
+			data = append(data, 0)
+		}
+	}
+	_, resp, err := c.exchange(param, data)
+	if err != nil {
+		return err
+	}
+	if len(resp) < len(chunks) {
+		return fmt.Errorf("s7 write: short response")
+	}
+	for i, ck := range chunks {
+		if resp[i] != returnCodeOK {
+			return &ItemError{Address: ck.addr.String(), Code: resp[i]}
+		}
+	}
+	return nil
+}
+
+// exchange 发送一个 S7 作业请求并读取响应，返回响应的参数和数据
+// 通信出错时关闭连接
+func (c *Client) exchange(param, data []byte) ([]byte, []byte, error) {
+	c.pduRef++
+	payload := make([]byte, 0, cotpDataSize+requestHeader+len(param)+len(data))
+	payload = append(payload, 0x02, 0xf0, 0x80)
+	payload = append(payload, 0x32, rosctrJob, 0x00, 0x00, byte(c.pduRef>>8), byte(c.pduRef),
+		byte(len(param)>>8), byte(len(param)), byte(len(data)>>8), byte(len(data)))
+	payload = append(payload, param...)
+	payload = append(payload, data...)
+
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := writeTPKT(c.conn, payload); err != nil {
+		c.closeOnError()
+		return nil, nil, err
+	}
+	resp, err := readTPKT(c.conn)
+	if err != nil {
+		c.closeOnError()
+		return nil, nil, err
+	}
+	if len(resp) < cotpDataSize+responseHeader || resp[cotpDataSize] != 0x32 {
+		c.closeOnError()
+		return nil, nil, fmt.Errorf("s7: invalid response")
+	}
+	s7 := resp[cotpDataSize:]
+	if s7[1] != rosctrAckData || binary.BigEndian.Uint16(s7[4:6]) != c.pduRef {
+		c.closeOnError()
+		return nil, nil, fmt.Errorf("s7: unexpected response")
+	}
+	if s7[10] != 0 || s7[11] != 0 {
+		return nil, nil, fmt.Errorf("s7: error class 0x%02x, code 0x%02x", s7[10], s7[11])
+	}
+	paramLen := int(binary.BigEndian.Uint16(s7[6:8]))
+	dataLen := int(binary.BigEndian.Uint16(s7[8:10]))
+	if responseHeader+paramLen+dataLen > len(s7) {
+		c.closeOnError()
+		return nil, nil, fmt.Errorf("s7: short response")
+	}
+	return s7[responseHeader : responseHeader+paramLen], s7[responseHeader+paramLen : responseHeader+paramLen+dataLen], nil
+}
+
+// closeOnError 通信出错后连接状态未知，关闭连接
+func (c *Client) closeOnError() {
+	c.closed = true
+	_ = c.conn.Close()
+}
+
+// writeTPKT 发送 TPKT 报文
+func writeTPKT(w io.Writer, payload []byte) error {
+	packet := make([]byte, tpktHeaderSize, tpktHeaderSize+len(payload))
+	packet[0] = 0x03
+	binary.BigEndian.PutUint16(packet[2:4], uint16(tpktHeaderSize+len(payload)))
+	_, err := w.Write(append(packet, payload...))
+	return err
+}
+
+// readTPKT 读取一个 TPKT 报文，返回去掉 TPKT 头的内容
+func readTPKT(r io.Reader) ([]byte, error) {
+	header := make([]byte, tpktHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(header[2:4]))
+	if header[0] != 0x03 || length < tpktHeaderSize {
+		return nil, fmt.Errorf("s7: invalid tpkt header")
+	}
+	payload := make([]byte, length-tpktHeaderSize)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
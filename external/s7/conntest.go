@@ -0,0 +1,132 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rulego/rulego-components-iot/pkg/conntest"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册连接测试，连接配置相同的组件共用
+func init() {
+	for _, componentType := range []string{
+		"x/s7Read",
+		"x/s7Write",
+		types.EndpointTypePrefix + "s7",
+	} {
+		_ = conntest.Register(componentType, TestConnection)
+	}
+}
+
+// TestConnection 测试 S7 连接配置，依次执行：
+//   - config：加载符号表，解析变量地址
+//   - connect：建立 ISO-on-TCP 连接并协商 PDU 大小，诊断信息为协商后的 PDU 大小
+//   - read：读取样本变量，样本变量为配置的 testSample（地址或者 {"name","address"} 对象），
+//     为空使用 items 的第一个变量，都为空时跳过
+//
+// 测试使用共享连接，PLC 已经被其他组件连接时复用该连接
+func TestConnection(ctx context.Context, config map[string]interface{}) *conntest.Result {
+	c := (&ReadNode{}).New().(*ReadNode).Config
+	if err := maps.Map2Struct(config, &c); err != nil {
+		return conntest.NewResult("", "").Fail(err)
+	}
+	result := conntest.NewResult("", c.Server)
+	var sample *Item
+	var address *Address
+	result.Run(conntest.StepConfig, func() (string, error) {
+		var err error
+		if sample, err = sampleItem(config, c.Items); err != nil || sample == nil {
+			return "", err
+		}
+		symbols, err := LoadSymbols(c.Symbols)
+		if err != nil {
+			return "", err
+		}
+		items, _, err := resolveItems([]Item{*sample})
+		if err != nil {
+			return "", err
+		}
+		if address, err = symbols.ParseAddress(items[0].Address); err != nil {
+			return "", err
+		}
+		sample = &items[0]
+		return "", nil
+	})
+	if !result.Success {
+		return result
+	}
+	clientConfig := (&ReadNode{Config: c}).clientConfig()
+	clientConfig.Timeout = conntest.Timeout(ctx, clientConfig.Timeout)
+
+	conn := AcquireConn(clientConfig)
+	defer func() {
+		_ = conn.Release()
+	}()
+	result.Run(conntest.StepConnect, func() (string, error) {
+		client, err := conn.Client()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("rack: %d, slot: %d, pdu size: %d", c.Rack, c.Slot, client.PDUSize()), nil
+	})
+	if sample == nil {
+		result.Skip(conntest.StepRead, "no sample variable configured")
+		return result
+	}
+	result.Run(conntest.StepRead, func() (string, error) {
+		var values []interface{}
+		err := conn.Do(func(client *Client) error {
+			var err error
+			values, err = client.ReadItems([]*Address{address})
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		result.Sample = map[string]interface{}{sample.key(): values[0]}
+		return sample.Address, nil
+	})
+	return result
+}
+
+// sampleItem 样本变量，testSample 为空时使用 items 的第一个变量，都为空返回 nil
+func sampleItem(config map[string]interface{}, items []Item) (*Item, error) {
+	switch v := conntest.Sample(config).(type) {
+	case nil:
+	case string:
+		return &Item{Address: v}, nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var item Item
+		if err = json.Unmarshal(b, &item); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", conntest.KeySample, err)
+		}
+		return &item, nil
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
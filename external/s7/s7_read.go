@@ -0,0 +1,276 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&ReadNode{})
+}
+
+// ReadConfiguration 读取节点配置
+type ReadConfiguration struct {
+	// Server PLC 地址，格式：host:port，端口默认 102
+	Server string `json:"server" label:"Server" desc:"PLC address, format: host:port, port defaults to 102" required:"true" ref:"primary"`
+	// Rack 机架号
+	Rack int `json:"rack" label:"Rack" desc:"PLC rack number"`
+	// Slot 槽号，S7-300/400 一般为 2，S7-1200/1500 一般为 1
+	Slot int `json:"slot" label:"Slot" desc:"PLC slot number, usually 2 for S7-300/400 and 1 for S7-1200/1500"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Items 读取的变量，为空则使用消息负荷 msg.Data 中的变量
+	Items []Item `json:"items" label:"Items" desc:"Variables to read, empty uses the variables in msg.Data"`
+	// Symbols 符号表文件或者目录，地址可以使用符号名称，见 SymbolTable
+	Symbols []string `json:"symbols" label:"Symbol Tables" desc:"TIA Portal exports to resolve symbolic addresses such as Motor1.Speed: PLC tag tables (.xlsx, .csv, .sdf) or data block sources (.db, .scl, .awl), files or directories"`
+	// CircuitBreaker 熔断配置，连续通信失败达到阈值后快速失败，不再等待连接超时
+	CircuitBreaker breaker.Config `json:"circuitBreaker" label:"Circuit Breaker" desc:"Fail fast with CIRCUIT_OPEN after consecutive communication failures instead of waiting for the connect timeout"`
+}
+
+// Item 读取的变量
+type Item struct {
+	// Name 变量名称，作为输出的 key，为空使用地址
+	Name string `json:"name,omitempty"`
+	// Address 变量地址，eg. DB1.DBD20:REAL，见 ParseAddress，也可以是符号表中的符号 Motor1.Speed 或者点位注册表的引用 tag:<名称>
+	Address string `json:"address"`
+}
+
+// ReadNode S7 读取节点，通过 ISO-on-TCP 读取 S7-300/400/1200/1500 PLC 的 DB/M/I/Q 区变量
+// 变量来自配置 items 或者消息负荷 msg.Data，格式：
+//
+//	[
+//	  {"name": "temperature", "address": "DB1.DBD20:REAL"},
+//	  {"name": "running", "address": "M0.1"}
+//	]
+//
+// 也可以是地址数组：["DB1.DBD20:REAL", "M0.1"]。地址为 tag:<名称> 时从点位注册表（pkg/tags）解析，
+// 名称为空使用点位名称，值按点位的缩放系数和偏移量换算。配置 symbols 后地址可以使用 TIA Portal 导出的符号名称，
+// 例如 Motor1.Speed，按符号的数据类型解码，见 SymbolTable。
+// 所有变量按 PDU 大小合并为尽可能少的请求，结果以变量名称为 key 重新赋值到msg.Data：
+//
+//	{"temperature": 21.5, "running": true}
+//
+// 相同 server、rack 和 slot 的节点共享一个连接。所有变量读取成功，流转到`Success`链，否则流转到`Failure`链
+// 1200/1500 PLC 需要在 TIA Portal 中允许 PUT/GET 通信，并且 DB 关闭优化的块访问
+type ReadNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config ReadConfiguration
+	// items 配置的变量，点位引用已经解析为地址
+	items []Item
+	// addresses 配置的变量解析后的地址
+	addresses []*Address
+	// refs 配置的变量引用的点位，不是点位引用的为 nil
+	refs []*tags.Tag
+	// symbols 符号表，没有配置为 nil
+	symbols *SymbolTable
+}
+
+// Type 返回组件类型
+func (x *ReadNode) Type() string {
+	return "x/s7Read"
+}
+
+// New 默认参数
+func (x *ReadNode) New() types.Node {
+	return &ReadNode{
+		Config: ReadConfiguration{
+			Server:  DefaultServer,
+			Rack:    0,
+			Slot:    1,
+			Timeout: 5,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *ReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if err = x.Config.CircuitBreaker.Validate(); err != nil {
+		return err
+	}
+	if x.symbols, err = LoadSymbols(x.Config.Symbols); err != nil {
+		return err
+	}
+	if x.items, x.refs, err = resolveItems(x.Config.Items); err != nil {
+		return err
+	}
+	if x.addresses, err = parseItems(x.symbols, x.items); err != nil {
+		return err
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), x.clientConfig())
+}
+
+// OnMsg 处理消息
+func (x *ReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	items, addresses, refs := x.items, x.addresses, x.refs
+	if len(items) == 0 {
+		var err error
+		if items, err = parseReadItems(msg.GetData()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if items, refs, err = resolveItems(items); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if addresses, err = parseItems(x.symbols, items); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var values []interface{}
+	err = conn.Do(func(client *Client) error {
+		values, err = client.ReadItems(addresses)
+		return err
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{}, len(items))
+	for i, item := range items {
+		if refs[i] != nil {
+			result[item.key()] = refs[i].Scaled(values[i])
+		} else {
+			result[item.key()] = values[i]
+		}
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *ReadNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *ReadNode) Desc() string {
+	return "Siemens S7 ISO-on-TCP reader for DB/M/I/Q variables with typed addresses, batched into as few requests as possible. Routes to Success/Failure"
+}
+
+func (x *ReadNode) clientConfig() ClientConfig {
+	return ClientConfig{
+		Server:         x.Config.Server,
+		Rack:           x.Config.Rack,
+		Slot:           x.Config.Slot,
+		Timeout:        time.Duration(x.Config.Timeout) * time.Second,
+		CircuitBreaker: x.Config.CircuitBreaker,
+	}
+}
+
+// key 输出的 key，名称为空使用地址
+func (i Item) key() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return i.Address
+}
+
+// initSharedConn 初始化共享连接，相同 PLC 的组件共用一个连接
+func initSharedConn(node *base.SharedNode[*SharedConn], ruleConfig types.Config, nodeType string, config ClientConfig) error {
+	return node.InitWithClose(ruleConfig, nodeType, config.Key(), ruleConfig.NodeClientInitNow, func() (*SharedConn, error) {
+		conn := AcquireConn(config)
+		if _, err := conn.Open(); err != nil {
+			_ = conn.Release()
+			return nil, err
+		}
+		return conn, nil
+	}, func(conn *SharedConn) error {
+		if conn != nil {
+			return conn.Release()
+		}
+		return nil
+	})
+}
+
+// parseItems 解析变量地址，地址可以是符号表中的符号
+func parseItems(symbols *SymbolTable, items []Item) ([]*Address, error) {
+	addresses := make([]*Address, 0, len(items))
+	for _, item := range items {
+		a, err := symbols.ParseAddress(item.Address)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, nil
+}
+
+// resolveItems 把 tag:<名称> 形式的点位引用解析为地址，名称为空使用点位名称，返回对应的点位
+func resolveItems(items []Item) ([]Item, []*tags.Tag, error) {
+	resolved := make([]Item, len(items))
+	refs := make([]*tags.Tag, len(items))
+	for i, item := range items {
+		address, tag, err := tags.Resolve(tags.ProtocolS7, item.Address)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tag != nil && item.Name == "" {
+			item.Name = tag.Name
+		}
+		item.Address = address
+		resolved[i], refs[i] = item, tag
+	}
+	return resolved, refs, nil
+}
+
+// parseReadItems 解析消息负荷中的变量，支持变量数组或者地址数组
+func parseReadItems(data string) ([]Item, error) {
+	data = strings.TrimSpace(data)
+	var items []Item
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		var list []string
+		if json.Unmarshal([]byte(data), &list) != nil {
+			return nil, err
+		}
+		items = nil
+		for _, address := range list {
+			items = append(items, Item{Address: address})
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no s7 variables to read")
+	}
+	return items, nil
+}
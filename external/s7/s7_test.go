@@ -0,0 +1,461 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/conntest"
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testPLC 测试用的 S7 PLC，只实现建立连接和读写变量
+type testPLC struct {
+	listener net.Listener
+	pduSize  int
+	mu       sync.Mutex
+	// memory 存储区，key 为存储区编码<<16 | DB 编号
+	memory map[uint32][]byte
+	// requests 收到的读写请求数量
+	requests int
+}
+
+func startTestPLC(t *testing.T, pduSize int) *testPLC {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	plc := &testPLC{
+		listener: listener,
+		pduSize:  pduSize,
+		memory: map[uint32][]byte{
+			0x84<<16 | 1: make([]byte, 64),
+			0x84<<16 | 2: make([]byte, 512),
+			0x83 << 16:   make([]byte, 16),
+		},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go plc.serve(conn)
+		}
+	}()
+	return plc
+}
+
+func (p *testPLC) server() string {
+	return p.listener.Addr().String()
+}
+
+func (p *testPLC) area(area byte, db uint16) []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.memory[uint32(area)<<16|uint32(db)]
+}
+
+func (p *testPLC) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		payload, err := readTPKT(conn)
+		if err != nil {
+			return
+		}
+		if payload[1] == 0xe0 {
+			cc := append([]byte(nil), payload...)
+			cc[1] = 0xd0
+			_ = writeTPKT(conn, cc)
+			continue
+		}
+		s7 := payload[cotpDataSize:]
+		paramLen := int(binary.BigEndian.Uint16(s7[6:8]))
+		dataLen := int(binary.BigEndian.Uint16(s7[8:10]))
+		param := s7[requestHeader : requestHeader+paramLen]
+		data := s7[requestHeader+paramLen : requestHeader+paramLen+dataLen]
+		var respParam, respData []byte
+		switch param[0] {
+		case funcSetupComm:
+			respParam = []byte{funcSetupComm, 0, 0, 1, 0, 1, byte(p.pduSize >> 8), byte(p.pduSize)}
+		case funcReadVar:
+			p.mu.Lock()
+			p.requests++
+			p.mu.Unlock()
+			respParam = []byte{funcReadVar, param[1]}
+			for i := 0; i < int(param[1]); i++ {
+				if i > 0 && len(respData)%2 == 1 {
+					respData = append(respData, 0)
+				}
+				mem, bit, start, length := p.locate(param[2+i*itemSpecSize:])
+				if mem == nil || start+length > len(mem) {
+					respData = append(respData, 0x05, 0, 0, 0)
+					continue
+				}
+				if bit >= 0 {
+					respData = append(respData, returnCodeOK, dataBit, 0, 1, (mem[start]>>bit)&0x01)
+					continue
+				}
+				respData = append(respData, returnCodeOK, dataByte, byte(length*8>>8), byte(length*8))
+				respData = append(respData, mem[start:start+length]...)
+			}
+		case funcWriteVar:
+			p.mu.Lock()
+			p.requests++
+			p.mu.Unlock()
+			respParam = []byte{funcWriteVar, param[1]}
+			offset := 0
+			for i := 0; i < int(param[1]); i++ {
+				if i > 0 && offset%2 == 1 {
+					offset++
+				}
+				mem, bit, start, length := p.locate(param[2+i*itemSpecSize:])
+				value := data[offset+itemHeaderSize : offset+itemHeaderSize+length]
+				offset += itemHeaderSize + length
+				if mem == nil || start+length > len(mem) {
+					respData = append(respData, 0x05)
+					continue
+				}
+				p.mu.Lock()
+				if bit >= 0 {
+					mem[start] = mem[start]&^(1<<bit) | (value[0]&0x01)<<bit
+				} else {
+					copy(mem[start:], value)
+				}
+				p.mu.Unlock()
+				respData = append(respData, returnCodeOK)
+			}
+		}
+		resp := []byte{0x02, 0xf0, 0x80, 0x32, rosctrAckData, 0, 0, s7[4], s7[5],
+			byte(len(respParam) >> 8), byte(len(respParam)), byte(len(respData) >> 8), byte(len(respData)), 0, 0}
+		resp = append(resp, respParam...)
+		resp = append(resp, respData...)
+		if err = writeTPKT(conn, resp); err != nil {
+			return
+		}
+	}
+}
+
+// locate 解析变量描述，bit 为 -1 表示按字节读写
+func (p *testPLC) locate(spec []byte) (mem []byte, bit int, start int, length int) {
+	length = int(binary.BigEndian.Uint16(spec[4:6]))
+	db := binary.BigEndian.Uint16(spec[6:8])
+	address := int(spec[9])<<16 | int(spec[10])<<8 | int(spec[11])
+	mem = p.area(spec[8], db)
+	if spec[3] == transportBit {
+		return mem, address % 8, address / 8, 1
+	}
+	return mem, -1, address / 8, length
+}
+
+func TestReadWriteNode(t *testing.T) {
+	plc := startTestPLC(t, 240)
+	defer plc.listener.Close()
+
+	db1 := plc.area(0x84, 1)
+	binary.BigEndian.PutUint32(db1[20:], math.Float32bits(21.5))
+	db1[0] = 0x02
+	copy(db1[30:], []byte{10, 5, 'h', 'e', 'l', 'l', 'o'})
+	m := plc.area(0x83, 0)
+	binary.BigEndian.PutUint16(m[2:], uint16(0xfffb))
+	db2 := plc.area(0x84, 2)
+	db2[0], db2[1] = 254, 254
+	copy(db2[2:], strings.Repeat("x", 254))
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	Registry.Add(&WriteNode{})
+
+	// 非法地址
+	_, err := test.CreateAndInitNode("x/s7Read", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"address": "DB1.DBW0:REAL"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/s7Read", types.Configuration{
+		"server": plc.server(),
+		"items": []map[string]interface{}{
+			{"name": "temperature", "address": "DB1.DBD20:REAL"},
+			{"name": "running", "address": "DB1.DBX0.1"},
+			{"name": "level", "address": "MW2:INT"},
+			{"name": "model", "address": "DB1.DBB30:STRING[10]"},
+			{"name": "long", "address": "DB2.DBB0:STRING"},
+		},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	// 相同 PLC 的节点共享连接
+	node2, err := test.CreateAndInitNode("x/s7Read", types.Configuration{
+		"server": plc.server(),
+	}, Registry)
+	assert.Nil(t, err)
+	defer node2.Destroy()
+	conn1, _ := node.(*ReadNode).SharedNode.GetSafely()
+	conn2, _ := node2.(*ReadNode).SharedNode.GetSafely()
+	assert.True(t, conn1 == conn2)
+
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 21.5, values["temperature"])
+		assert.Equal(t, true, values["running"])
+		assert.Equal(t, float64(-5), values["level"])
+		assert.Equal(t, "hello", values["model"])
+		assert.Equal(t, strings.Repeat("x", 254), values["long"])
+	})
+	// 大于 PDU 的变量拆分读取
+	plc.mu.Lock()
+	assert.True(t, plc.requests >= 2)
+	plc.mu.Unlock()
+
+	writer, err := test.CreateAndInitNode("x/s7Write", types.Configuration{
+		"server": plc.server(),
+	}, Registry)
+	assert.Nil(t, err)
+	defer writer.Destroy()
+	templateWriter, err := test.CreateAndInitNode("x/s7Write", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"address": "DB1.DBD20:REAL", "value": "${metadata.setpoint}"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer templateWriter.Destroy()
+
+	test.NodeOnMsg(t, writer, []test.Msg{
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "WRITE",
+			Data:       `{"DB1.DBX0.0": true, "MW2:INT": -100, "DB1.DBB30:STRING[10]": "world"}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+		{
+			MetaData:   types.NewMetadata(),
+			DataType:   types.JSON,
+			MsgType:    "OUT_OF_RANGE",
+			Data:       `{"DB1.DBW100": 1}`,
+			AfterSleep: time.Millisecond * 200,
+		},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "OUT_OF_RANGE" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+	})
+	metadata := types.NewMetadata()
+	metadata.PutValue("setpoint", "42.5")
+	test.NodeOnMsg(t, templateWriter, []test.Msg{{
+		MetaData:   metadata,
+		DataType:   types.JSON,
+		MsgType:    "WRITE",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+
+	test.NodeOnMsg(t, node2, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `["DB1.DBX0.0", "DB1.DBX0.1", "MW2:INT", "DB1.DBB30:STRING[10]", "DB1.DBD20:REAL"]`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, true, values["DB1.DBX0.0"])
+		assert.Equal(t, true, values["DB1.DBX0.1"])
+		assert.Equal(t, float64(-100), values["MW2:INT"])
+		assert.Equal(t, "world", values["DB1.DBB30:STRING[10]"])
+		assert.Equal(t, 42.5, values["DB1.DBD20:REAL"])
+	})
+}
+
+func TestSharedConnReconnect(t *testing.T) {
+	plc := startTestPLC(t, 480)
+	defer plc.listener.Close()
+
+	conn := AcquireConn(ClientConfig{Server: plc.server(), Slot: 1, Timeout: time.Second})
+	defer conn.Release()
+	client, err := conn.Client()
+	assert.Nil(t, err)
+	assert.Equal(t, 480, client.PDUSize())
+
+	a, _ := ParseAddress("DB1.DBW0")
+	// 模拟连接断开，Do 重新连接后重试
+	_ = client.conn.Close()
+	err = conn.Do(func(client *Client) error {
+		_, err := client.ReadItems([]*Address{a})
+		return err
+	})
+	assert.Nil(t, err)
+	newClient, _ := conn.Client()
+	assert.True(t, newClient != client)
+}
+
+func TestSharedConnCircuitBreaker(t *testing.T) {
+	plc := startTestPLC(t, 480)
+	server := plc.server()
+	// PLC 不可用
+	_ = plc.listener.Close()
+
+	conn := AcquireConn(ClientConfig{Server: server, Slot: 1, Timeout: time.Second,
+		CircuitBreaker: breaker.Config{FailureThreshold: 2, OpenTimeout: 100}})
+	defer conn.Release()
+	calls := 0
+	fn := func(client *Client) error {
+		calls++
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		err := conn.Do(fn)
+		assert.NotNil(t, err)
+		assert.False(t, breaker.IsOpen(err))
+	}
+	// 连续失败达到阈值后快速失败
+	err := conn.Do(fn)
+	assert.True(t, breaker.IsOpen(err))
+	_, err = conn.Open()
+	assert.True(t, breaker.IsOpen(err))
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, breaker.StateOpen, breaker.Get(healthComponent, conn.Key()).State())
+
+	// 断开时间结束后试探失败，重新断开
+	time.Sleep(150 * time.Millisecond)
+	err = conn.Do(fn)
+	assert.False(t, breaker.IsOpen(err))
+	assert.True(t, breaker.IsOpen(conn.Do(fn)))
+}
+
+func TestTagReference(t *testing.T) {
+	plc := startTestPLC(t, 240)
+	defer plc.listener.Close()
+	db1 := plc.area(0x84, 1)
+	binary.BigEndian.PutUint16(db1[40:], 215)
+
+	registry := tags.NewRegistry()
+	assert.Nil(t, registry.Register(tags.Tag{Name: "tank.level", Protocol: tags.ProtocolS7, Address: "DB1.DBW40:INT", Unit: "%", Scale: 0.1}))
+	tags.SetDefault(registry)
+	defer tags.SetDefault(tags.NewRegistry())
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	Registry.Add(&WriteNode{})
+
+	// 未注册的点位
+	_, err := test.CreateAndInitNode("x/s7Read", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"address": "tag:unknown"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	reader, err := test.CreateAndInitNode("x/s7Read", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"address": "tag:tank.level"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer reader.Destroy()
+	writer, err := test.CreateAndInitNode("x/s7Write", types.Configuration{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"address": "tag:tank.level", "value": "${metadata.level}"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer writer.Destroy()
+
+	test.NodeOnMsg(t, reader, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.True(t, math.Abs(values["tank.level"].(float64)-21.5) < 1e-9)
+	})
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("level", "60.2")
+	test.NodeOnMsg(t, writer, []test.Msg{{
+		MetaData:   metadata,
+		DataType:   types.JSON,
+		MsgType:    "WRITE",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+	plc.mu.Lock()
+	assert.Equal(t, uint16(602), binary.BigEndian.Uint16(db1[40:]))
+	plc.mu.Unlock()
+}
+
+func TestConnectionTest(t *testing.T) {
+	plc := startTestPLC(t, 240)
+	defer plc.listener.Close()
+	db1 := plc.area(0x84, 1)
+	binary.BigEndian.PutUint32(db1[20:], math.Float32bits(21.5))
+
+	assert.True(t, conntest.Supported("x/s7Write"))
+	result, err := conntest.TestConnection(context.Background(), "x/s7Read", map[string]interface{}{
+		"server": plc.server(),
+		"items":  []map[string]interface{}{{"name": "temperature", "address": "DB1.DBD20:REAL"}},
+	})
+	assert.Nil(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 3, len(result.Steps))
+	assert.Equal(t, "rack: 0, slot: 1, pdu size: 240", result.Steps[1].Message)
+	assert.Equal(t, map[string]interface{}{"temperature": float32(21.5)}, result.Sample)
+
+	// 样本变量优先，没有变量时跳过读取
+	result, _ = conntest.TestConnection(context.Background(), "x/s7Read", map[string]interface{}{
+		"server":           plc.server(),
+		conntest.KeySample: "DB1.DBW0:REAL",
+	})
+	assert.False(t, result.Success)
+	assert.Equal(t, conntest.StepConfig, result.Steps[0].Name)
+	result, _ = conntest.TestConnection(context.Background(), "x/s7Read", map[string]interface{}{"server": plc.server()})
+	assert.True(t, result.Success)
+	assert.True(t, result.Steps[2].Skipped)
+
+	// PLC 不可用
+	_ = plc.listener.Close()
+	result, _ = conntest.TestConnection(context.Background(), "x/s7Read", map[string]interface{}{"server": plc.server(), "timeout": 1})
+	assert.False(t, result.Success)
+	assert.Equal(t, conntest.StepConnect, result.Steps[len(result.Steps)-1].Name)
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteConfiguration 写入节点配置
+type WriteConfiguration struct {
+	// Server PLC 地址，格式：host:port，端口默认 102
+	Server string `json:"server" label:"Server" desc:"PLC address, format: host:port, port defaults to 102" required:"true" ref:"primary"`
+	// Rack 机架号
+	Rack int `json:"rack" label:"Rack" desc:"PLC rack number"`
+	// Slot 槽号，S7-300/400 一般为 2，S7-1200/1500 一般为 1
+	Slot int `json:"slot" label:"Slot" desc:"PLC slot number, usually 2 for S7-300/400 and 1 for S7-1200/1500"`
+	// Timeout 连接和请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Connect and request timeout in seconds"`
+	// Items 写入的变量，为空则使用消息负荷 msg.Data 中的变量
+	Items []WriteItem `json:"items" label:"Items" desc:"Variables to write, empty uses the address-value object in msg.Data"`
+	// Symbols 符号表文件或者目录，地址可以使用符号名称，见 SymbolTable
+	Symbols []string `json:"symbols" label:"Symbol Tables" desc:"TIA Portal exports to resolve symbolic addresses such as Motor1.Speed: PLC tag tables (.xlsx, .csv, .sdf) or data block sources (.db, .scl, .awl), files or directories"`
+	// CircuitBreaker 熔断配置，连续通信失败达到阈值后快速失败，不再等待连接超时
+	CircuitBreaker breaker.Config `json:"circuitBreaker" label:"Circuit Breaker" desc:"Fail fast with CIRCUIT_OPEN after consecutive communication failures instead of waiting for the connect timeout"`
+}
+
+// WriteItem 写入的变量
+type WriteItem struct {
+	// Address 变量地址，eg. DB1.DBD20:REAL，见 ParseAddress，也可以是符号表中的符号 Motor1.Speed 或者点位注册表的引用 tag:<名称>
+	Address string `json:"address"`
+	// Value 写入的值，允许使用 ${} 占位符变量
+	Value string `json:"value"`
+}
+
+// WriteNode S7 写入节点，通过 ISO-on-TCP 写入 S7-300/400/1200/1500 PLC 的 DB/M/I/Q 区变量
+// 变量来自配置 items，值允许使用 ${} 占位符变量，或者消息负荷 msg.Data 中地址->值的对象：
+//
+//	{"DB1.DBD20:REAL": 21.5, "M0.1": true, "DB1.DBB30:STRING[20]": "hello"}
+//
+// 地址为 tag:<名称> 时从点位注册表（pkg/tags）解析，写入的工程值按点位的缩放系数和偏移量换算为原始值。
+// 配置 symbols 后地址可以使用 TIA Portal 导出的符号名称，例如 Motor1.Speed，按符号的数据类型编码，见 SymbolTable。
+// 所有变量按 PDU 大小合并为尽可能少的请求，相同 server、rack 和 slot 的节点共享一个连接。
+// 所有变量写入成功，流转到`Success`链，否则流转到`Failure`链
+type WriteNode struct {
+	base.SharedNode[*SharedConn]
+	//节点配置
+	Config WriteConfiguration
+	// addresses 配置的变量解析后的地址
+	addresses []*Address
+	// refs 配置的变量引用的点位，不是点位引用的为 nil
+	refs []*tags.Tag
+	// valueTemplates 配置的变量值模板
+	valueTemplates []str.Template
+	// symbols 符号表，没有配置为 nil
+	symbols *SymbolTable
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/s7Write"
+}
+
+// New 默认参数
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{
+		Config: WriteConfiguration{
+			Server:  DefaultServer,
+			Rack:    0,
+			Slot:    1,
+			Timeout: 5,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if err = x.Config.CircuitBreaker.Validate(); err != nil {
+		return err
+	}
+	if x.symbols, err = LoadSymbols(x.Config.Symbols); err != nil {
+		return err
+	}
+	x.addresses = nil
+	x.refs = nil
+	x.valueTemplates = nil
+	for _, item := range x.Config.Items {
+		a, tag, err := resolveAddress(x.symbols, item.Address)
+		if err != nil {
+			return err
+		}
+		x.addresses = append(x.addresses, a)
+		x.refs = append(x.refs, tag)
+		x.valueTemplates = append(x.valueTemplates, str.NewTemplate(item.Value))
+	}
+	return initSharedConn(&x.SharedNode, ruleConfig, x.Type(), ClientConfig{
+		Server:         x.Config.Server,
+		Rack:           x.Config.Rack,
+		Slot:           x.Config.Slot,
+		Timeout:        time.Duration(x.Config.Timeout) * time.Second,
+		CircuitBreaker: x.Config.CircuitBreaker,
+	})
+}
+
+// OnMsg 处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	addresses, values, err := x.getValues(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	err = conn.Do(func(client *Client) error {
+		return client.WriteItems(addresses, values)
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// getValues 获取写入的变量和编码后的值
+func (x *WriteNode) getValues(ctx types.RuleContext, msg types.RuleMsg) ([]*Address, [][]byte, error) {
+	if len(x.addresses) > 0 {
+		evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		values := make([][]byte, 0, len(x.addresses))
+		for i, a := range x.addresses {
+			v, err := a.Encode(unscale(x.refs[i], a, x.valueTemplates[i].Execute(evn)))
+			if err != nil {
+				return nil, nil, err
+			}
+			values = append(values, v)
+		}
+		return x.addresses, values, nil
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(msg.GetData()), &data); err != nil {
+		return nil, nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("no s7 variables to write")
+	}
+	addresses := make([]*Address, 0, len(data))
+	values := make([][]byte, 0, len(data))
+	for address, value := range data {
+		a, tag, err := resolveAddress(x.symbols, address)
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := a.Encode(unscale(tag, a, value))
+		if err != nil {
+			return nil, nil, err
+		}
+		addresses = append(addresses, a)
+		values = append(values, v)
+	}
+	return addresses, values, nil
+}
+
+// Destroy 销毁组件
+func (x *WriteNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Siemens S7 ISO-on-TCP writer for DB/M/I/Q variables with typed addresses. Routes to Success/Failure"
+}
+
+// resolveAddress 解析地址，地址可以是符号表中的符号或者点位注册表的引用 tag:<名称>
+func resolveAddress(symbols *SymbolTable, address string) (*Address, *tags.Tag, error) {
+	address, tag, err := tags.Resolve(tags.ProtocolS7, address)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := symbols.ParseAddress(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, tag, nil
+}
+
+// unscale 把点位的工程值换算为原始值，整数类型四舍五入
+func unscale(tag *tags.Tag, a *Address, value interface{}) interface{} {
+	if tag == nil || tag.Scale == 0 && tag.Offset == 0 {
+		return value
+	}
+	if s, ok := value.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return value
+		}
+		value = f
+	}
+	v := tag.Unscaled(value)
+	if f, ok := v.(float64); ok && a.DataType != DataTypeReal && a.DataType != DataTypeLReal {
+		return math.Round(f)
+	}
+	return v
+}
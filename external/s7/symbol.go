@@ -0,0 +1,460 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	blockAddressPattern = regexp.MustCompile(`^DB(\d+)$`)
+	fileDBNumberPattern = regexp.MustCompile(`(?i)(?:^|[^A-Z])DB_?(\d+)(?:[^0-9]|$)`)
+)
+
+// tiaDataTypes TIA Portal/STEP 7 数据类型对应的 S7 数据类型，时间类型按存储格式读取原始值
+var tiaDataTypes = map[string]string{
+	"BOOL":        DataTypeBool,
+	"BYTE":        DataTypeByte,
+	"USINT":       DataTypeByte,
+	"SINT":        DataTypeSInt,
+	"CHAR":        DataTypeChar,
+	"WORD":        DataTypeWord,
+	"INT":         DataTypeInt,
+	"UINT":        DataTypeUInt,
+	"S5TIME":      DataTypeWord,
+	"DATE":        DataTypeUInt,
+	"DWORD":       DataTypeDWord,
+	"DINT":        DataTypeDInt,
+	"UDINT":       DataTypeUDInt,
+	"REAL":        DataTypeReal,
+	"TIME":        DataTypeDInt,
+	"TIME_OF_DAY": DataTypeUDInt,
+	"TOD":         DataTypeUDInt,
+	"LWORD":       DataTypeULInt,
+	"LINT":        DataTypeLInt,
+	"ULINT":       DataTypeULInt,
+	"LREAL":       DataTypeLReal,
+	"LTIME":       DataTypeLInt,
+}
+
+// Symbol 符号表中的变量
+type Symbol struct {
+	// Name 符号名称，eg. Motor1.Speed
+	Name string `json:"name"`
+	// Address 绝对地址，包含数据类型，eg. DB1.DBD20:REAL，见 ParseAddress
+	Address string `json:"address"`
+	// DataType TIA Portal 中的数据类型，eg. Real
+	DataType string `json:"dataType,omitempty"`
+	// Comment 注释
+	Comment string `json:"comment,omitempty"`
+}
+
+// SymbolTable 符号表，把 TIA Portal/STEP 7 导出的符号名称解析为绝对地址，名称不区分大小写，
+// 可以带 TIA Portal 的引号："Motor1".Speed 和 Motor1.Speed 等价。
+// 支持的导出文件：
+//   - PLC 变量表：TIA Portal 导出的 .xlsx（PLC Tags 工作表），或者另存为 .csv，
+//     需要 Name、Data Type、Logical Address 列；没有表头时按 STEP 7 符号表 .sdf 的列顺序：名称、地址、数据类型、注释
+//   - 数据块源文件：TIA Portal “从块生成源”导出的 .db/.scl/.awl（包含依赖的 UDT），按非优化块访问的规则计算偏移量，
+//     符号名称为 <数据块>.<成员>，结构体成员用 . 分隔，数组元素为 <名称>[下标]
+//
+// 数据块源文件不包含数据块编号时，依次从已经加载的符号表中 DB 块的符号（eg. STEP 7 符号表的 "Motor1","DB 1"）、
+// 文件名中的 DB<编号>（只包含一个数据块时，eg. Motor1_DB1.db）获取
+type SymbolTable struct {
+	symbols map[string]Symbol
+	// blocks 数据块符号对应的编号
+	blocks map[string]int
+}
+
+// NewSymbolTable 创建空的符号表
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{symbols: make(map[string]Symbol), blocks: make(map[string]int)}
+}
+
+// LoadSymbols 按顺序加载符号表文件，目录加载其中所有支持的文件，PLC 变量表先于数据块源文件加载。
+// 没有文件返回 nil，nil 符号表只解析绝对地址
+func LoadSymbols(files []string) (*SymbolTable, error) {
+	var tables, sources []string
+	for _, file := range files {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		names := []string{file}
+		if info.IsDir() {
+			entries, err := os.ReadDir(file)
+			if err != nil {
+				return nil, err
+			}
+			names = names[:0]
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					names = append(names, filepath.Join(file, entry.Name()))
+				}
+			}
+		}
+		for _, name := range names {
+			switch strings.ToLower(filepath.Ext(name)) {
+			case ".xlsx", ".csv", ".sdf", ".txt":
+				tables = append(tables, name)
+			case ".db", ".scl", ".awl", ".udt":
+				sources = append(sources, name)
+			default:
+				if !info.IsDir() {
+					return nil, fmt.Errorf("unsupported s7 symbol file: %s", name)
+				}
+			}
+		}
+	}
+	if len(tables) == 0 && len(sources) == 0 {
+		return nil, nil
+	}
+	t := NewSymbolTable()
+	for _, file := range append(tables, sources...) {
+		if err := t.LoadFile(file); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// LoadFile 按扩展名加载符号表文件
+func (t *SymbolTable) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".xlsx":
+		err = t.LoadTagTableXLSX(bytes.NewReader(data), int64(len(data)))
+	case ".csv", ".sdf", ".txt":
+		err = t.LoadTagTable(bytes.NewReader(data))
+	case ".db", ".scl", ".awl", ".udt":
+		number := 0
+		if m := fileDBNumberPattern.FindStringSubmatch(filepath.Base(file)); m != nil {
+			number, _ = strconv.Atoi(m[1])
+		}
+		err = t.LoadDBSource(bytes.NewReader(data), number)
+	default:
+		return fmt.Errorf("unsupported s7 symbol file: %s", file)
+	}
+	if err != nil {
+		return fmt.Errorf("load s7 symbols %s: %w", file, err)
+	}
+	return nil
+}
+
+// Len 符号数量
+func (t *SymbolTable) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.symbols)
+}
+
+// Symbols 按名称排序的所有符号
+func (t *SymbolTable) Symbols() []Symbol {
+	if t == nil {
+		return nil
+	}
+	list := make([]Symbol, 0, len(t.symbols))
+	for _, s := range t.symbols {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Add 添加符号，地址需要是有效的绝对地址，相同名称的符号被覆盖
+func (t *SymbolTable) Add(s Symbol) error {
+	if s.Name == "" {
+		return errors.New("s7 symbol name cannot be empty")
+	}
+	if _, err := ParseAddress(s.Address); err != nil {
+		return fmt.Errorf("s7 symbol %s: %w", s.Name, err)
+	}
+	t.symbols[symbolKey(s.Name)] = s
+	return nil
+}
+
+// Lookup 查找符号
+func (t *SymbolTable) Lookup(name string) (Symbol, bool) {
+	if t == nil {
+		return Symbol{}, false
+	}
+	s, ok := t.symbols[symbolKey(name)]
+	return s, ok
+}
+
+// ParseAddress 解析地址，地址是符号表中的符号时使用符号的绝对地址和数据类型，否则见 ParseAddress
+func (t *SymbolTable) ParseAddress(s string) (*Address, error) {
+	if symbol, ok := t.Lookup(s); ok {
+		return ParseAddress(symbol.Address)
+	}
+	a, err := ParseAddress(s)
+	if err != nil && t != nil {
+		return nil, fmt.Errorf("unknown s7 symbol or invalid address: %s", strings.TrimSpace(s))
+	}
+	return a, err
+}
+
+// symbolKey 符号的 key：去掉引号和首尾空白，不区分大小写
+func symbolKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(name, `"`, "")))
+}
+
+// tiaDataType TIA Portal 数据类型对应的 S7 数据类型，支持 String[n]
+func tiaDataType(dataType string) (string, bool) {
+	dataType = strings.ToUpper(strings.ReplaceAll(dataType, " ", ""))
+	if m := stringTypePattern.FindStringSubmatch(dataType); m != nil {
+		if m[1] == "" {
+			return DataTypeString, true
+		}
+		return dataType, true
+	}
+	v, ok := tiaDataTypes[dataType]
+	return v, ok
+}
+
+// LoadTagTable 加载 CSV 格式的 PLC 变量表，自动识别逗号、分号和制表符分隔
+func (t *SymbolTable) LoadTagTable(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = ','
+	for _, sep := range []rune{';', '\t'} {
+		if bytes.Count(firstLine, []byte(string(sep))) > bytes.Count(firstLine, []byte(string(reader.Comma))) {
+			reader.Comma = sep
+		}
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	return t.addTagRows(rows)
+}
+
+// addTagRows 添加 PLC 变量表的行，第一行包含 Name 和 Address 列时作为表头，
+// 不是 I/Q/M/DB 地址（eg. 定时器、计数器、程序块）和不支持的数据类型的行被忽略
+func (t *SymbolTable) addTagRows(rows [][]string) error {
+	name, address, dataType, comment := 0, 1, 2, 3
+	if len(rows) > 0 {
+		header := map[string]int{}
+		for i, col := range rows[0] {
+			header[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(col), " ", ""))] = i
+		}
+		if i, ok := header["name"]; ok {
+			address = -1
+			for _, col := range []string{"logicaladdress", "address"} {
+				if j, ok := header[col]; ok {
+					address = j
+					break
+				}
+			}
+			if address < 0 {
+				return errors.New("s7 tag table has no address column")
+			}
+			name, dataType, comment = i, -1, -1
+			if j, ok := header["datatype"]; ok {
+				dataType = j
+			}
+			if j, ok := header["comment"]; ok {
+				comment = j
+			}
+			rows = rows[1:]
+		}
+	}
+	cell := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	for _, row := range rows {
+		symbol := Symbol{
+			Name:     strings.Trim(cell(row, name), `"`),
+			DataType: cell(row, dataType),
+			Comment:  cell(row, comment),
+		}
+		location := strings.ToUpper(strings.ReplaceAll(strings.TrimPrefix(cell(row, address), "%"), " ", ""))
+		if symbol.Name == "" || location == "" {
+			continue
+		}
+		if m := blockAddressPattern.FindStringSubmatch(location); m != nil {
+			t.blocks[symbolKey(symbol.Name)], _ = strconv.Atoi(m[1])
+			continue
+		}
+		if !dbAddressPattern.MatchString(location) && !areaAddressPattern.MatchString(location) {
+			continue
+		}
+		symbol.Address = location
+		if symbol.DataType != "" {
+			s7Type, ok := tiaDataType(symbol.DataType)
+			if !ok {
+				continue
+			}
+			if s7Type != DataTypeBool {
+				symbol.Address += ":" + s7Type
+			}
+		}
+		if err := t.Add(symbol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadTagTableXLSX 加载 TIA Portal 导出的 .xlsx PLC 变量表，使用 PLC Tags 工作表，不存在时使用第一个工作表
+func (t *SymbolTable) LoadTagTableXLSX(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	decode := func(name string, v interface{}) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("xlsx: %s not found", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return xml.NewDecoder(rc).Decode(v)
+	}
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err = decode("xl/workbook.xml", &workbook); err != nil {
+		return err
+	}
+	if err = decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return err
+	}
+	if len(workbook.Sheets) == 0 {
+		return errors.New("xlsx: no worksheet")
+	}
+	sheet := workbook.Sheets[0]
+	for _, s := range workbook.Sheets {
+		if strings.EqualFold(s.Name, "PLC Tags") {
+			sheet = s
+			break
+		}
+	}
+	sheetFile := ""
+	for _, rel := range rels.Items {
+		if rel.ID == sheet.ID {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetFile = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetFile = path.Join("xl", rel.Target)
+			}
+		}
+	}
+	var sharedStrings struct {
+		Items []struct {
+			Text string   `xml:"t"`
+			Runs []string `xml:"r>t"`
+		} `xml:"si"`
+	}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err = decode("xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return err
+		}
+	}
+	var worksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err = decode(sheetFile, &worksheet); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(worksheet.Rows))
+	for _, r := range worksheet.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := i
+			if letters := strings.TrimRightFunc(c.Ref, unicode.IsDigit); letters != "" {
+				col = 0
+				for _, ch := range strings.ToUpper(letters) {
+					col = col*26 + int(ch-'A'+1)
+				}
+				col--
+			}
+			value := c.Value
+			switch c.Type {
+			case "s":
+				if i, err := strconv.Atoi(c.Value); err == nil && i < len(sharedStrings.Items) {
+					item := sharedStrings.Items[i]
+					value = item.Text + strings.Join(item.Runs, "")
+				}
+			case "inlineStr":
+				value = c.Inline
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			row[col] = value
+		}
+		rows = append(rows, row)
+	}
+	return t.addTagRows(rows)
+}
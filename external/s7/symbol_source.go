@@ -0,0 +1,536 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxTypeDepth 结构体和 UDT 嵌套的最大深度
+const maxTypeDepth = 32
+
+// sourceToken 源文件的词法单元，quoted 为双引号括起的名称
+type sourceToken struct {
+	text   string
+	quoted bool
+}
+
+// sourceTypeKind 数据类型的种类
+type sourceTypeKind int
+
+const (
+	kindElementary sourceTypeKind = iota
+	kindUDT
+	kindStruct
+	kindArray
+)
+
+// sourceType 源文件中的数据类型
+type sourceType struct {
+	kind sourceTypeKind
+	// name 基本类型或者 UDT 名称
+	name   string
+	fields []sourceField
+	// lo, hi 数组下标范围
+	lo, hi int
+	elem   *sourceType
+}
+
+// sourceField 结构体成员
+type sourceField struct {
+	name string
+	typ  sourceType
+}
+
+// sourceBlock 数据块
+type sourceBlock struct {
+	name   string
+	number int
+	// ref 基于 UDT 或者 FB 的数据块引用的类型名称
+	ref string
+	typ *sourceType
+}
+
+// LoadDBSource 加载 TIA Portal/STEP 7 导出的数据块源文件（SCL 或 STL 格式），按非优化块访问的规则计算成员的偏移量：
+// BOOL 按位连续存储，其他类型字节对齐，2个字节及以上的类型、STRING、结构体和数组从偶数字节开始，结构体和数组的长度补齐为偶数。
+// 源文件中的 TYPE 定义的 UDT 可以被数据块引用，FB 的背景数据块被忽略。
+// number 为源文件和已加载的符号表都没有给出数据块编号时使用的编号，只在源文件包含一个数据块时有效，0 表示没有
+func (t *SymbolTable) LoadDBSource(r io.Reader, number int) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	p := &sourceParser{tokens: tokenizeSource(string(data))}
+	udts, blocks, err := p.parse()
+	if err != nil {
+		return err
+	}
+	// FB 的背景数据块被忽略
+	var dataBlocks []sourceBlock
+	for _, b := range blocks {
+		if b.typ == nil {
+			udt, ok := udts[symbolKey(b.ref)]
+			if !ok {
+				continue
+			}
+			b.typ = &udt
+		}
+		dataBlocks = append(dataBlocks, b)
+	}
+	if len(dataBlocks) != 1 {
+		number = 0
+	}
+	for _, b := range dataBlocks {
+		if b.number == 0 {
+			b.number = t.blocks[symbolKey(b.name)]
+		}
+		if b.number == 0 {
+			b.number = number
+		}
+		if b.number == 0 {
+			return fmt.Errorf("number of data block %s is unknown, add its DB symbol to a tag table or name the file <name>_DB<number>", b.name)
+		}
+		l := &sourceLayout{table: t, udts: udts, db: b.number}
+		if b.typ.kind == kindStruct {
+			for _, f := range b.typ.fields {
+				if err = l.place(b.name+"."+f.name, f.typ, 0); err != nil {
+					return err
+				}
+			}
+		} else if err = l.place(b.name, *b.typ, 0); err != nil {
+			return err
+		}
+		t.blocks[symbolKey(b.name)] = b.number
+	}
+	return nil
+}
+
+// tokenizeSource 源文件分词，忽略注释、{} 属性和单引号字符串
+func tokenizeSource(src string) []sourceToken {
+	var tokens []sourceToken
+	runes := []rune(src)
+	n := len(runes)
+	// index 从 i 开始查找 end 的位置，没有找到返回 n
+	index := func(i int, end string) int {
+		e := []rune(end)
+		for ; i+len(e) <= n; i++ {
+			if string(runes[i:i+len(e)]) == end {
+				return i
+			}
+		}
+		return n
+	}
+	// skipTo 跳过到 end 之后
+	skipTo := func(i int, end string) int {
+		if i = index(i, end); i < n {
+			i += len([]rune(end))
+		}
+		return i
+	}
+	for i := 0; i < n; {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' && i+1 < n && runes[i+1] == '/':
+			i = skipTo(i, "\n")
+		case c == '(' && i+1 < n && runes[i+1] == '*':
+			i = skipTo(i+2, "*)")
+		case c == '{':
+			i = skipTo(i, "}")
+		case c == '\'':
+			i = skipTo(i+1, "'")
+			tokens = append(tokens, sourceToken{text: "''"})
+		case c == '"':
+			j := index(i+1, `"`)
+			tokens = append(tokens, sourceToken{text: string(runes[i+1 : j]), quoted: true})
+			i = j + 1
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < n && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, sourceToken{text: string(runes[i:j])})
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < n && (unicode.IsDigit(runes[j]) || (runes[j] == '.' && j+1 < n && unicode.IsDigit(runes[j+1]))) {
+				j++
+			}
+			tokens = append(tokens, sourceToken{text: string(runes[i:j])})
+			i = j
+		case i+1 < n && (string(runes[i:i+2]) == ":=" || string(runes[i:i+2]) == ".."):
+			tokens = append(tokens, sourceToken{text: string(runes[i : i+2])})
+			i += 2
+		default:
+			tokens = append(tokens, sourceToken{text: string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+// sourceParser 数据块源文件解析器
+type sourceParser struct {
+	tokens []sourceToken
+	pos    int
+}
+
+var errUnexpectedEnd = errors.New("unexpected end of s7 source")
+
+func (p *sourceParser) next() (sourceToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return sourceToken{}, false
+	}
+	p.pos++
+	return p.tokens[p.pos-1], true
+}
+
+// is 当前词法单元是否为指定的关键字或者符号
+func (p *sourceParser) is(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+// accept 当前词法单元为指定的关键字或者符号时跳过
+func (p *sourceParser) accept(keyword string) bool {
+	if p.is(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sourceParser) expect(keyword string) error {
+	if p.accept(keyword) {
+		return nil
+	}
+	if p.pos >= len(p.tokens) {
+		return errUnexpectedEnd
+	}
+	return fmt.Errorf("s7 source: expected %s but got %s", keyword, p.tokens[p.pos].text)
+}
+
+// skipTo 跳过到指定的关键字之后
+func (p *sourceParser) skipTo(keyword string) error {
+	for !p.accept(keyword) {
+		if _, ok := p.next(); !ok {
+			return errUnexpectedEnd
+		}
+	}
+	return nil
+}
+
+// number 整数，允许负号
+func (p *sourceParser) number() (int, error) {
+	sign := 1
+	if p.accept("-") {
+		sign = -1
+	}
+	tok, ok := p.next()
+	if !ok {
+		return 0, errUnexpectedEnd
+	}
+	v, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return 0, fmt.Errorf("s7 source: invalid number %s", tok.text)
+	}
+	return sign * v, nil
+}
+
+// name 块或者类型名称，支持 "名称"、DB 1、DB1、UDT 1 形式
+func (p *sourceParser) name() (string, int, error) {
+	tok, ok := p.next()
+	if !ok {
+		return "", 0, errUnexpectedEnd
+	}
+	if tok.quoted {
+		return tok.text, 0, nil
+	}
+	prefix := strings.ToUpper(tok.text)
+	if (prefix == "DB" || prefix == "UDT") && p.pos < len(p.tokens) {
+		if v, err := strconv.Atoi(p.tokens[p.pos].text); err == nil {
+			p.pos++
+			return prefix + strconv.Itoa(v), v, nil
+		}
+	}
+	if m := blockAddressPattern.FindStringSubmatch(prefix); m != nil {
+		v, _ := strconv.Atoi(m[1])
+		return tok.text, v, nil
+	}
+	return tok.text, 0, nil
+}
+
+// parse 解析源文件中的 UDT 和数据块，其他块被忽略
+func (p *sourceParser) parse() (map[string]sourceType, []sourceBlock, error) {
+	udts := make(map[string]sourceType)
+	var blocks []sourceBlock
+	for {
+		tok, ok := p.next()
+		if !ok {
+			return udts, blocks, nil
+		}
+		if tok.quoted {
+			continue
+		}
+		switch keyword := strings.ToUpper(tok.text); keyword {
+		case "TYPE":
+			name, _, err := p.name()
+			if err != nil {
+				return nil, nil, err
+			}
+			if err = p.skipTo("STRUCT"); err != nil {
+				return nil, nil, err
+			}
+			fields, err := p.fields("END_STRUCT")
+			if err != nil {
+				return nil, nil, err
+			}
+			udts[symbolKey(name)] = sourceType{kind: kindStruct, fields: fields}
+			if err = p.skipTo("END_TYPE"); err != nil {
+				return nil, nil, err
+			}
+		case "DATA_BLOCK":
+			b, err := p.block()
+			if err != nil {
+				return nil, nil, err
+			}
+			blocks = append(blocks, b)
+		case "FUNCTION_BLOCK", "FUNCTION", "ORGANIZATION_BLOCK":
+			if err := p.skipTo("END_" + keyword); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+}
+
+// block 解析 DATA_BLOCK 之后的数据块定义
+func (p *sourceParser) block() (sourceBlock, error) {
+	var b sourceBlock
+	var err error
+	if b.name, b.number, err = p.name(); err != nil {
+		return b, err
+	}
+	for {
+		tok, ok := p.next()
+		if !ok {
+			return b, errUnexpectedEnd
+		}
+		switch {
+		case tok.quoted:
+			if b.ref == "" && b.typ == nil {
+				b.ref = tok.text
+			}
+		case strings.EqualFold(tok.text, "STRUCT"), strings.EqualFold(tok.text, "VAR"):
+			end := "END_STRUCT"
+			if strings.EqualFold(tok.text, "VAR") {
+				end = "END_VAR"
+			}
+			fields, err := p.fields(end)
+			if err != nil {
+				return b, err
+			}
+			b.typ = &sourceType{kind: kindStruct, fields: fields}
+		case strings.EqualFold(tok.text, "FB"), strings.EqualFold(tok.text, "SFB"), strings.EqualFold(tok.text, "UDT"):
+			// 背景数据块或者基于 UDT 的数据块：FB 1、UDT 1
+			if v, err := p.number(); err == nil && b.ref == "" && b.typ == nil {
+				b.ref = strings.ToUpper(tok.text) + strconv.Itoa(v)
+			}
+		case strings.EqualFold(tok.text, "BEGIN"):
+			return b, p.skipTo("END_DATA_BLOCK")
+		case strings.EqualFold(tok.text, "END_DATA_BLOCK"):
+			return b, nil
+		}
+	}
+}
+
+// fields 解析结构体成员直到 end 关键字
+func (p *sourceParser) fields(end string) ([]sourceField, error) {
+	var fields []sourceField
+	for {
+		if p.accept(end) {
+			return fields, nil
+		}
+		tok, ok := p.next()
+		if !ok {
+			return nil, errUnexpectedEnd
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, fmt.Errorf("member %s: %w", tok.text, err)
+		}
+		typ, err := p.typ()
+		if err != nil {
+			return nil, fmt.Errorf("member %s: %w", tok.text, err)
+		}
+		fields = append(fields, sourceField{name: tok.text, typ: typ})
+		// 跳过初始值
+		if err = p.skipTo(";"); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// typ 解析数据类型
+func (p *sourceParser) typ() (sourceType, error) {
+	tok, ok := p.next()
+	if !ok {
+		return sourceType{}, errUnexpectedEnd
+	}
+	if tok.quoted {
+		return sourceType{kind: kindUDT, name: tok.text}, nil
+	}
+	switch strings.ToUpper(tok.text) {
+	case "STRUCT":
+		fields, err := p.fields("END_STRUCT")
+		return sourceType{kind: kindStruct, fields: fields}, err
+	case "ARRAY":
+		typ := sourceType{kind: kindArray}
+		var err error
+		if err = p.expect("["); err != nil {
+			return typ, err
+		}
+		if typ.lo, err = p.number(); err != nil {
+			return typ, err
+		}
+		if err = p.expect(".."); err != nil {
+			return typ, err
+		}
+		if typ.hi, err = p.number(); err != nil {
+			return typ, err
+		}
+		if p.is(",") {
+			return typ, errors.New("multi-dimensional arrays are not supported")
+		}
+		if typ.hi < typ.lo {
+			return typ, fmt.Errorf("invalid array bounds [%d..%d]", typ.lo, typ.hi)
+		}
+		if err = p.expect("]"); err != nil {
+			return typ, err
+		}
+		if err = p.expect("OF"); err != nil {
+			return typ, err
+		}
+		elem, err := p.typ()
+		typ.elem = &elem
+		return typ, err
+	case "STRING":
+		if p.accept("[") {
+			length, err := p.number()
+			if err != nil {
+				return sourceType{}, err
+			}
+			if err = p.expect("]"); err != nil {
+				return sourceType{}, err
+			}
+			return sourceType{name: fmt.Sprintf("%s[%d]", tok.text, length)}, nil
+		}
+		return sourceType{name: tok.text}, nil
+	case "UDT":
+		v, err := p.number()
+		return sourceType{kind: kindUDT, name: "UDT" + strconv.Itoa(v)}, err
+	default:
+		return sourceType{name: tok.text}, nil
+	}
+}
+
+// sourceLayout 按非优化块访问的规则计算成员的偏移量并添加符号
+type sourceLayout struct {
+	table  *SymbolTable
+	udts   map[string]sourceType
+	db     int
+	offset int
+	bit    int
+}
+
+// align 结束未满的位字节，even 为 true 时对齐到偶数字节
+func (l *sourceLayout) align(even bool) {
+	if l.bit > 0 {
+		l.offset++
+		l.bit = 0
+	}
+	if even && l.offset%2 == 1 {
+		l.offset++
+	}
+}
+
+// place 为成员分配地址，结构体和数组展开为各个基本类型的成员
+func (l *sourceLayout) place(name string, typ sourceType, depth int) error {
+	if depth > maxTypeDepth {
+		return fmt.Errorf("s7 source: %s nested too deep", name)
+	}
+	switch typ.kind {
+	case kindUDT:
+		udt, ok := l.udts[symbolKey(typ.name)]
+		if !ok {
+			return fmt.Errorf("s7 source: unknown data type %q of %s", typ.name, name)
+		}
+		return l.place(name, udt, depth+1)
+	case kindStruct:
+		l.align(true)
+		for _, f := range typ.fields {
+			if err := l.place(name+"."+f.name, f.typ, depth+1); err != nil {
+				return err
+			}
+		}
+		l.align(true)
+		return nil
+	case kindArray:
+		l.align(true)
+		for i := typ.lo; i <= typ.hi; i++ {
+			if l.offset > 0xffff {
+				return fmt.Errorf("s7 source: %s exceeds the data block size", name)
+			}
+			if err := l.place(fmt.Sprintf("%s[%d]", name, i), *typ.elem, depth+1); err != nil {
+				return err
+			}
+		}
+		l.align(true)
+		return nil
+	}
+	dataType, ok := tiaDataType(typ.name)
+	if !ok {
+		return fmt.Errorf("s7 source: unsupported data type %s of %s", typ.name, name)
+	}
+	symbol := Symbol{Name: name, DataType: typ.name}
+	if dataType == DataTypeBool {
+		symbol.Address = fmt.Sprintf("DB%d.DBX%d.%d", l.db, l.offset, l.bit)
+		if l.bit++; l.bit == 8 {
+			l.offset, l.bit = l.offset+1, 0
+		}
+		return l.table.Add(symbol)
+	}
+	size := typeSizes[dataType]
+	if m := stringTypePattern.FindStringSubmatch(dataType); m != nil {
+		size = defaultStringLength + 2
+		if m[1] != "" {
+			size, _ = strconv.Atoi(m[1])
+			size += 2
+		}
+	}
+	l.align(size > 1)
+	width := map[int]string{2: "W", 4: "D"}[size]
+	if width == "" {
+		width = "B"
+	}
+	symbol.Address = fmt.Sprintf("DB%d.DB%s%d:%s", l.db, width, l.offset, dataType)
+	l.offset += size
+	return l.table.Add(symbol)
+}
@@ -0,0 +1,283 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s7
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+const testDBSource = `TYPE "UDT_Drive"
+VERSION : 0.1
+   STRUCT
+      Current : Real;
+      Fault : Bool;
+   END_STRUCT;
+
+END_TYPE
+
+DATA_BLOCK "Motor1"
+{ S7_Optimized_Access := 'FALSE' }
+VERSION : 0.1
+NON_RETAIN
+   STRUCT 
+      Running : Bool;   // 运行
+      Ready : Bool;
+      Speed : Real := 0.0;
+      Mode : Byte;
+      Name : String[10];
+      Drive : "UDT_Drive";
+      Values : Array[0..2] of Int;
+      Flags : Array[1..3] of Bool;
+      Total : LReal;
+   END_STRUCT;
+
+BEGIN
+   Speed := 1500.0;
+END_DATA_BLOCK
+
+DATA_BLOCK "Motor1_Instance" "FB_Motor"
+BEGIN
+END_DATA_BLOCK
+`
+
+// testXLSX 生成 TIA Portal 导出格式的 PLC 变量表
+func testXLSX(t *testing.T, rows [][]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	write := func(name, content string) {
+		f, err := w.Create(name)
+		assert.Nil(t, err)
+		_, err = f.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	write("xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="User Constants" sheetId="1" r:id="rId1"/><sheet name="PLC Tags" sheetId="2" r:id="rId2"/></sheets></workbook>`)
+	write("xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="worksheets/sheet2.xml"/></Relationships>`)
+	write("xl/worksheets/sheet1.xml", `<worksheet><sheetData/></worksheet>`)
+	var shared, sheet strings.Builder
+	index := 0
+	for i, row := range rows {
+		sheet.WriteString(`<row>`)
+		for j, v := range row {
+			if v == "" {
+				continue
+			}
+			sheet.WriteString(`<c r="` + string(rune('A'+j)) + string(rune('1'+i)) + `" t="s"><v>` + string(rune('0'+index/10)) + string(rune('0'+index%10)) + `</v></c>`)
+			shared.WriteString(`<si><t>` + v + `</t></si>`)
+			index++
+		}
+		sheet.WriteString(`</row>`)
+	}
+	write("xl/sharedStrings.xml", `<sst>`+shared.String()+`</sst>`)
+	write("xl/worksheets/sheet2.xml", `<worksheet><sheetData>`+sheet.String()+`</sheetData></worksheet>`)
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestSymbolTable(t *testing.T) {
+	table := NewSymbolTable()
+	// TIA Portal 变量表另存为 CSV
+	assert.Nil(t, table.LoadTagTable(strings.NewReader("\xef\xbb\xbfName;Path;Data Type;Logical Address;Comment\n"+
+		"Start;Default tag table;Bool;%I0.0;start button\n"+
+		"Level;Default tag table;Int;%MW10;\n"+
+		"Flow;Default tag table;Real;%MD20;\n"+
+		"Timer1;Default tag table;Timer;%T1;\n")))
+	// STEP 7 符号表
+	assert.Nil(t, table.LoadTagTable(strings.NewReader(`"Pump","Q   4.1","BOOL","pump"`+"\n"+`"Recipe","DB   2","DB   2",""`+"\n")))
+
+	s, ok := table.Lookup(`"Start"`)
+	assert.True(t, ok)
+	assert.Equal(t, "I0.0", s.Address)
+	assert.Equal(t, "start button", s.Comment)
+	a, err := table.ParseAddress("level")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaM, a.Area)
+	assert.Equal(t, DataTypeInt, a.DataType)
+	a, err = table.ParseAddress("Flow")
+	assert.Nil(t, err)
+	assert.Equal(t, DataTypeReal, a.DataType)
+	a, err = table.ParseAddress("Pump")
+	assert.Nil(t, err)
+	assert.Equal(t, AreaQ, a.Area)
+	assert.Equal(t, 1, a.Bit)
+	_, ok = table.Lookup("Timer1")
+	assert.False(t, ok)
+
+	// 绝对地址不经过符号表
+	a, err = table.ParseAddress("DB1.DBW0:INT")
+	assert.Nil(t, err)
+	assert.Equal(t, DataTypeInt, a.DataType)
+	_, err = table.ParseAddress("Unknown.Symbol")
+	assert.NotNil(t, err)
+	var none *SymbolTable
+	_, err = none.ParseAddress("MW2")
+	assert.Nil(t, err)
+
+	// 数据块编号来自符号表
+	assert.Nil(t, table.LoadDBSource(strings.NewReader(`DATA_BLOCK "Recipe"
+  STRUCT
+   Count : INT ;
+   Enabled : ARRAY [1 .. 2] OF BOOL ;
+   Weight : REAL ;
+  END_STRUCT ;
+BEGIN
+END_DATA_BLOCK`), 0))
+	for name, address := range map[string]string{
+		"Recipe.Count":      "DB2.DBW0:INT",
+		"Recipe.Enabled[2]": "DB2.DBX2.1",
+		"Recipe.Weight":     "DB2.DBD4:REAL",
+	} {
+		s, ok = table.Lookup(name)
+		assert.True(t, ok)
+		assert.Equal(t, address, s.Address)
+	}
+
+	// 按非优化块访问计算偏移量
+	assert.NotNil(t, NewSymbolTable().LoadDBSource(strings.NewReader(testDBSource), 0))
+	assert.Nil(t, table.LoadDBSource(strings.NewReader(testDBSource), 5))
+	for name, address := range map[string]string{
+		`"Motor1".Running`:     "DB5.DBX0.0",
+		"Motor1.Ready":         "DB5.DBX0.1",
+		"Motor1.Speed":         "DB5.DBD2:REAL",
+		"Motor1.Mode":          "DB5.DBB6:BYTE",
+		"Motor1.Name":          "DB5.DBB8:STRING[10]",
+		"Motor1.Drive.Current": "DB5.DBD20:REAL",
+		"Motor1.Drive.Fault":   "DB5.DBX24.0",
+		"Motor1.Values[0]":     "DB5.DBW26:INT",
+		"Motor1.Values[2]":     "DB5.DBW30:INT",
+		"Motor1.Flags[3]":      "DB5.DBX32.2",
+		"Motor1.Total":         "DB5.DBB34:LREAL",
+	} {
+		s, ok = table.Lookup(name)
+		assert.True(t, ok)
+		assert.Equal(t, address, s.Address)
+	}
+	s, _ = table.Lookup("motor1.speed")
+	assert.Equal(t, "Real", s.DataType)
+	_, ok = table.Lookup("Motor1_Instance.Running")
+	assert.False(t, ok)
+
+	assert.NotNil(t, NewSymbolTable().LoadDBSource(strings.NewReader(`DATA_BLOCK DB 3 STRUCT a : "Missing"; END_STRUCT; BEGIN END_DATA_BLOCK`), 0))
+	assert.NotNil(t, NewSymbolTable().LoadDBSource(strings.NewReader(`DATA_BLOCK DB 3 STRUCT a : DTL; END_STRUCT; BEGIN END_DATA_BLOCK`), 0))
+
+	// xlsx 变量表和目录加载
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "PLCTags.xlsx"), testXLSX(t, [][]string{
+		{"Name", "Path", "Data Type", "Logical Address", "Comment"},
+		{"Alarm", "Default tag table", "Bool", "%M1.3", "alarm"},
+		{"Counter", "Default tag table", "DInt", "%MD4"},
+	}), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Motor1_DB7.db"), []byte(testDBSource), 0600))
+	symbols, err := LoadSymbols([]string{dir})
+	assert.Nil(t, err)
+	s, ok = symbols.Lookup("Alarm")
+	assert.True(t, ok)
+	assert.Equal(t, "M1.3", s.Address)
+	s, _ = symbols.Lookup("Counter")
+	assert.Equal(t, "MD4:DINT", s.Address)
+	s, _ = symbols.Lookup("Motor1.Speed")
+	assert.Equal(t, "DB7.DBD2:REAL", s.Address)
+	assert.Equal(t, 2+14, symbols.Len())
+
+	symbols, err = LoadSymbols(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, symbols)
+	_, err = LoadSymbols([]string{filepath.Join(dir, "missing.csv")})
+	assert.NotNil(t, err)
+}
+
+func TestSymbolicAddress(t *testing.T) {
+	plc := startTestPLC(t, 240)
+	defer plc.listener.Close()
+	db2 := plc.area(0x84, 2)
+	binary.BigEndian.PutUint32(db2[2:], math.Float32bits(1480.5))
+	db2[0] = 0x01
+
+	file := filepath.Join(t.TempDir(), "Motor1.db")
+	assert.Nil(t, os.WriteFile(file, []byte(testDBSource), 0600))
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&ReadNode{})
+	Registry.Add(&WriteNode{})
+
+	// 数据块编号未知
+	_, err := test.CreateAndInitNode("x/s7Read", types.Configuration{
+		"server":  plc.server(),
+		"symbols": []string{file},
+		"items":   []map[string]interface{}{{"address": "Motor1.Speed"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	file = filepath.Join(filepath.Dir(file), "Motor1_DB2.db")
+	assert.Nil(t, os.WriteFile(file, []byte(testDBSource), 0600))
+	reader, err := test.CreateAndInitNode("x/s7Read", types.Configuration{
+		"server":  plc.server(),
+		"symbols": []string{file},
+		"items":   []map[string]interface{}{{"address": `"Motor1".Speed`}, {"name": "running", "address": "Motor1.Running"}},
+	}, Registry)
+	assert.Nil(t, err)
+	defer reader.Destroy()
+	writer, err := test.CreateAndInitNode("x/s7Write", types.Configuration{
+		"server":  plc.server(),
+		"symbols": []string{file},
+	}, Registry)
+	assert.Nil(t, err)
+	defer writer.Destroy()
+
+	test.NodeOnMsg(t, reader, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "READ",
+		Data:       `{}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		values := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &values))
+		assert.Equal(t, 1480.5, values[`"Motor1".Speed`])
+		assert.Equal(t, true, values["running"])
+	})
+
+	test.NodeOnMsg(t, writer, []test.Msg{{
+		MetaData:   types.NewMetadata(),
+		DataType:   types.JSON,
+		MsgType:    "WRITE",
+		Data:       `{"Motor1.Values[1]": -12, "Motor1.Name": "pump"}`,
+		AfterSleep: time.Millisecond * 200,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+	})
+	plc.mu.Lock()
+	assert.Equal(t, int16(-12), int16(binary.BigEndian.Uint16(db2[28:])))
+	assert.Equal(t, "pump", string(db2[10:14]))
+	plc.mu.Unlock()
+}
@@ -0,0 +1,333 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shadow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 操作
+const (
+	// OpReport 合并设备上报的状态
+	OpReport = "report"
+	// OpDesire 合并期望的状态
+	OpDesire = "desire"
+	// OpGet 获取影子
+	OpGet = "get"
+	// OpDelete 删除影子
+	OpDelete = "delete"
+)
+
+// 额外触发的关系
+const (
+	// RelationDelta delta 发生变化且不为空，需要向设备下发期望状态
+	RelationDelta = "Delta"
+	// RelationAcknowledged 设备上报的状态达到了期望值
+	RelationAcknowledged = "Acknowledged"
+)
+
+// KeyVersion 元数据中影子版本号的 key
+const KeyVersion = "shadowVersion"
+
+// DefaultNamespace 默认命名空间
+const DefaultNamespace = "default"
+
+// ErrNotFound 设备影子不存在
+var ErrNotFound = errors.New("shadow not found")
+
+// namespaces 命名空间->共享的设备影子集合
+var (
+	namespacesLock sync.Mutex
+	namespaces     = map[string]*sharedShadows{}
+)
+
+// sharedShadows 按命名空间共享的设备影子集合
+type sharedShadows struct {
+	shadows *Shadows
+	refs    int
+}
+
+// Namespace 获取命名空间的设备影子集合，供其它组件读取
+func Namespace(name string) (*Shadows, bool) {
+	namespacesLock.Lock()
+	defer namespacesLock.Unlock()
+	s, ok := namespaces[name]
+	if !ok {
+		return nil, false
+	}
+	return s.shadows, true
+}
+
+// acquire 获取命名空间的设备影子集合，不存在则按存储配置创建，引用计数加1
+func acquire(namespace string, config StoreConfig) (*Shadows, error) {
+	namespacesLock.Lock()
+	defer namespacesLock.Unlock()
+	if s, ok := namespaces[namespace]; ok {
+		s.refs++
+		return s.shadows, nil
+	}
+	store, err := NewStore(config)
+	if err != nil {
+		return nil, err
+	}
+	shadows, err := New(store)
+	if err != nil {
+		if store != nil {
+			_ = store.Close()
+		}
+		return nil, err
+	}
+	namespaces[namespace] = &sharedShadows{shadows: shadows, refs: 1}
+	return shadows, nil
+}
+
+// release 引用计数减1，为 0 时关闭存储并移除
+func release(namespace string) error {
+	namespacesLock.Lock()
+	defer namespacesLock.Unlock()
+	s, ok := namespaces[namespace]
+	if !ok {
+		return nil
+	}
+	s.refs--
+	if s.refs > 0 {
+		return nil
+	}
+	delete(namespaces, namespace)
+	return s.shadows.Close()
+}
+
+func init() {
+	_ = rulego.Registry.Register(&DeviceShadowNode{})
+}
+
+// DeviceShadowConfiguration 设备影子节点配置
+type DeviceShadowConfiguration struct {
+	// Device 设备名称，支持 ${metadata.key} 和 ${msg.key} 占位符
+	Device string `json:"device" label:"Device" desc:"Device name of the shadow, supports ${metadata.key} and ${msg.key} placeholders" required:"true"`
+	// Operation 操作：report、desire、get、delete，支持 ${metadata.key} 占位符
+	Operation string `json:"operation" label:"Operation" desc:"report merges reported telemetry, desire merges desired state, get and delete read or remove the shadow. Supports ${metadata.key} placeholders"`
+	// StateField msg.Data 中状态的字段，为空使用整个 msg.Data
+	StateField string `json:"stateField" label:"State Field" desc:"Field of msg.Data holding the state, empty uses the whole msg.Data"`
+	// ClearAcknowledged 设备上报的值与期望值一致后，从期望状态中移除该字段
+	ClearAcknowledged bool `json:"clearAcknowledged" label:"Clear Acknowledged" desc:"Remove desired fields once the device reports the desired value"`
+	// Namespace 命名空间，相同命名空间的节点共享设备影子，存储配置以第一个节点为准
+	Namespace string `json:"namespace" label:"Namespace" desc:"Nodes in the same namespace share shadows, the store of the first node is used"`
+	// Store 存储类型：memory、file 或者通过 RegisterStore 注册的类型
+	Store string `json:"store" label:"Store" desc:"memory, file or a store registered by RegisterStore"`
+	// Dir file 存储的目录
+	Dir string `json:"dir" label:"Dir" desc:"Directory of the file store"`
+	// StoreOptions 自定义存储的参数
+	StoreOptions map[string]interface{} `json:"storeOptions" label:"Store Options" desc:"Options of a registered store"`
+}
+
+// Output 节点输出
+type Output struct {
+	*Shadow
+	// Delta 期望状态中与上报状态不一致的部分
+	Delta map[string]interface{} `json:"delta"`
+	// Acknowledged 本次上报达到期望值的字段
+	Acknowledged []string `json:"acknowledged,omitempty"`
+}
+
+// DeviceShadowNode 设备影子节点，按设备记录上报状态（reported）和期望状态（desired），结果重新赋值到msg.Data：
+//
+//	{
+//	  "device": "boiler01",
+//	  "reported": {"temperature": 21.5, "setpoint": 20},
+//	  "desired": {"setpoint": 22},
+//	  "delta": {"setpoint": 22},
+//	  "version": 5,
+//	  "reportedAt": 1700000000000,
+//	  "desiredAt": 1700000000000
+//	}
+//
+// report 把 msg.Data 中的遥测合并到上报状态，desire 合并到期望状态，值为 null 的字段删除，嵌套对象按字段合并；
+// get 输出当前影子，delete 删除影子并输出删除前的影子。版本号放在元数据 shadowVersion。
+// 相同命名空间的节点共享设备影子，可以在不同规则链中分别处理上报和期望状态，修改后通过存储持久化。
+// 成功流转到`Success`链；delta 变化且不为空时同时流转到`Delta`链，用于向设备下发命令；
+// 上报达到期望值时同时流转到`Acknowledged`链。影子不存在或者失败，流转到`Failure`链
+type DeviceShadowNode struct {
+	//节点配置
+	Config            DeviceShadowConfiguration
+	deviceTemplate    str.Template
+	operationTemplate str.Template
+	shadows           *Shadows
+}
+
+// Type 返回组件类型
+func (x *DeviceShadowNode) Type() string {
+	return "x/deviceShadow"
+}
+
+// New 默认参数
+func (x *DeviceShadowNode) New() types.Node {
+	return &DeviceShadowNode{
+		Config: DeviceShadowConfiguration{
+			Device:            "${metadata.deviceName}",
+			Operation:         OpReport,
+			ClearAcknowledged: true,
+			Namespace:         DefaultNamespace,
+			Store:             StoreMemory,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DeviceShadowNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Device) == "" {
+		return ErrDeviceEmpty
+	}
+	if x.Config.Operation == "" {
+		x.Config.Operation = OpReport
+	}
+	if !strings.Contains(x.Config.Operation, "${") {
+		if err = checkOperation(x.Config.Operation); err != nil {
+			return err
+		}
+	}
+	if x.Config.Namespace == "" {
+		x.Config.Namespace = DefaultNamespace
+	}
+	x.deviceTemplate = str.NewTemplate(x.Config.Device)
+	x.operationTemplate = str.NewTemplate(x.Config.Operation)
+	x.shadows, err = acquire(x.Config.Namespace, StoreConfig{Type: x.Config.Store, Dir: x.Config.Dir, Options: x.Config.StoreOptions})
+	return err
+}
+
+// OnMsg 处理消息
+func (x *DeviceShadowNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	env := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	device := x.deviceTemplate.Execute(env)
+	operation := x.operationTemplate.Execute(env)
+	if err := checkOperation(operation); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var output Output
+	var relations []string
+	switch operation {
+	case OpReport, OpDesire:
+		state, err := x.parseState(msg.GetData())
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		var update *Update
+		if operation == OpReport {
+			update, err = x.shadows.Report(device, state, x.Config.ClearAcknowledged, time.Now())
+		} else {
+			update, err = x.shadows.Desire(device, state, time.Now())
+		}
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		output = Output{Shadow: update.Shadow, Delta: update.Delta, Acknowledged: update.Acknowledged}
+		relations = append(relations, types.Success)
+		if update.DeltaChanged && len(update.Delta) > 0 {
+			relations = append(relations, RelationDelta)
+		}
+		if len(update.Acknowledged) > 0 {
+			relations = append(relations, RelationAcknowledged)
+		}
+	case OpGet:
+		shadow, ok := x.shadows.Get(device)
+		if !ok {
+			ctx.TellFailure(msg, fmt.Errorf("%w: %s", ErrNotFound, device))
+			return
+		}
+		output = Output{Shadow: shadow, Delta: shadow.Delta()}
+		relations = append(relations, types.Success)
+	case OpDelete:
+		shadow, ok, err := x.shadows.Delete(device)
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if !ok {
+			ctx.TellFailure(msg, fmt.Errorf("%w: %s", ErrNotFound, device))
+			return
+		}
+		output = Output{Shadow: shadow, Delta: shadow.Delta()}
+		relations = append(relations, types.Success)
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KeyVersion, strconv.FormatInt(output.Version, 10))
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(data))
+	ctx.TellNext(msg, relations...)
+}
+
+// Destroy 销毁组件
+func (x *DeviceShadowNode) Destroy() {
+	if x.shadows != nil {
+		_ = release(x.Config.Namespace)
+		x.shadows = nil
+	}
+}
+
+// Desc returns the component description
+func (x *DeviceShadowNode) Desc() string {
+	return "Device shadow keeping reported and desired state per device, merging telemetry and computing deltas, in memory or persisted to a pluggable store. Routes to Success/Delta/Acknowledged/Failure"
+}
+
+// parseState 解析 msg.Data 中的状态
+func (x *DeviceShadowNode) parseState(data string) (map[string]interface{}, error) {
+	var state map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("shadow state must be a json object: %w", err)
+	}
+	if x.Config.StateField == "" {
+		return state, nil
+	}
+	sub, ok := maps.Get(state, x.Config.StateField).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("shadow state field %s is not an object", x.Config.StateField)
+	}
+	return sub, nil
+}
+
+// checkOperation 校验操作
+func checkOperation(operation string) error {
+	switch operation {
+	case OpReport, OpDesire, OpGet, OpDelete:
+		return nil
+	default:
+		return fmt.Errorf("invalid shadow operation: %s", operation)
+	}
+}
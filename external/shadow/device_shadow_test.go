@@ -0,0 +1,110 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shadow
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDeviceShadowNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DeviceShadowNode{})
+
+	_, err := test.CreateAndInitNode("x/deviceShadow", types.Configuration{"operation": "patch"}, Registry)
+	assert.NotNil(t, err)
+	_, err = test.CreateAndInitNode("x/deviceShadow", types.Configuration{"store": "redis", "namespace": "invalid"}, Registry)
+	assert.NotNil(t, err)
+
+	// 上报和期望状态在不同的节点中处理，通过命名空间共享影子
+	reporter, err := test.CreateAndInitNode("x/deviceShadow", types.Configuration{
+		"namespace":  "test",
+		"stateField": "values",
+	}, Registry)
+	assert.Nil(t, err)
+	defer reporter.Destroy()
+	controller, err := test.CreateAndInitNode("x/deviceShadow", types.Configuration{
+		"namespace": "test",
+		"operation": "${metadata.op}",
+	}, Registry)
+	assert.Nil(t, err)
+	defer controller.Destroy()
+	_, ok := Namespace("test")
+	assert.True(t, ok)
+
+	meta := func(op string) *types.Metadata {
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceName", "boiler01")
+		metadata.PutValue("op", op)
+		return metadata
+	}
+	// run 同步处理消息，返回触发的关系和最后一个输出，节点在 OnMsg 返回前完成所有回调
+	run := func(node types.Node, msgs ...test.Msg) ([]string, Output, types.RuleMsg) {
+		var relations []string
+		var output Output
+		var out types.RuleMsg
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			relations = append(relations, relationType)
+			out = msg
+			output = Output{}
+			_ = json.Unmarshal([]byte(msg.GetData()), &output)
+		})
+		for _, m := range msgs {
+			node.OnMsg(ctx, types.NewMsg(0, m.MsgType, m.DataType, m.MetaData, m.Data))
+		}
+		return relations, output, out
+	}
+
+	relations, output, msg := run(reporter, test.Msg{MetaData: meta(""), DataType: types.JSON, MsgType: "TELEMETRY", Data: `{"ts":1,"values":{"temperature":21.5,"setpoint":20}}`})
+	assert.Equal(t, []string{types.Success}, relations)
+	assert.Equal(t, "boiler01", output.Device)
+	assert.Equal(t, 21.5, output.Reported["temperature"])
+	assert.Equal(t, "1", msg.Metadata.GetValue(KeyVersion))
+
+	// 修改期望状态，delta 变化时流转到 Delta 链
+	relations, output, _ = run(controller, test.Msg{MetaData: meta(OpDesire), DataType: types.JSON, MsgType: "COMMAND", Data: `{"setpoint":22}`})
+	assert.Equal(t, []string{types.Success, RelationDelta}, relations)
+	assert.Equal(t, map[string]interface{}{"setpoint": 22.0}, output.Delta)
+	relations, _, _ = run(controller, test.Msg{MetaData: meta(OpDesire), DataType: types.JSON, MsgType: "COMMAND", Data: `{"setpoint":22}`})
+	assert.Equal(t, []string{types.Success}, relations)
+
+	// 设备上报达到期望值
+	relations, output, _ = run(reporter, test.Msg{MetaData: meta(""), DataType: types.JSON, MsgType: "TELEMETRY", Data: `{"values":{"setpoint":22}}`})
+	assert.Equal(t, []string{types.Success, RelationAcknowledged}, relations)
+	assert.Equal(t, []string{"setpoint"}, output.Acknowledged)
+	assert.Equal(t, 0, len(output.Delta))
+	assert.Equal(t, 0, len(output.Desired))
+
+	relations, output, _ = run(controller, test.Msg{MetaData: meta(OpGet), DataType: types.JSON, MsgType: "GET", Data: `{}`})
+	assert.Equal(t, []string{types.Success}, relations)
+	assert.Equal(t, int64(3), output.Version)
+
+	// 非法的状态和操作
+	relations, _, _ = run(reporter, test.Msg{MetaData: meta(""), DataType: types.JSON, MsgType: "TELEMETRY", Data: `{"values":1}`})
+	assert.Equal(t, []string{types.Failure}, relations)
+	relations, _, _ = run(controller, test.Msg{MetaData: meta("patch"), DataType: types.JSON, MsgType: "COMMAND", Data: `{}`})
+	assert.Equal(t, []string{types.Failure}, relations)
+
+	relations, _, _ = run(controller, test.Msg{MetaData: meta(OpDelete), DataType: types.JSON, MsgType: "DELETE", Data: `{}`})
+	assert.Equal(t, []string{types.Success}, relations)
+	relations, _, _ = run(controller, test.Msg{MetaData: meta(OpGet), DataType: types.JSON, MsgType: "GET", Data: `{}`})
+	assert.Equal(t, []string{types.Failure}, relations)
+}
@@ -0,0 +1,308 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package shadow 提供设备影子节点
+// 按设备记录最后一次上报的状态（reported）和期望的状态（desired），计算两者的差异（delta），
+// 让跨协议的命令/确认模式可以通过声明期望状态实现。影子保存在内存中，可以通过 Store 持久化
+package shadow
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrDeviceEmpty 设备名称为空
+var ErrDeviceEmpty = errors.New("shadow device is empty")
+
+// Shadow 设备影子
+type Shadow struct {
+	// Device 设备名称
+	Device string `json:"device"`
+	// Reported 设备最后一次上报的状态
+	Reported map[string]interface{} `json:"reported"`
+	// Desired 期望的状态，设备上报的值与期望值一致后从期望状态中移除
+	Desired map[string]interface{} `json:"desired"`
+	// Version 版本号，每次状态变化加1
+	Version int64 `json:"version"`
+	// ReportedAt 最后一次上报的时间，Unix 毫秒
+	ReportedAt int64 `json:"reportedAt,omitempty"`
+	// DesiredAt 最后一次修改期望状态的时间，Unix 毫秒
+	DesiredAt int64 `json:"desiredAt,omitempty"`
+}
+
+// Delta 期望状态中与上报状态不一致的部分
+func (s *Shadow) Delta() map[string]interface{} {
+	return diff(s.Desired, s.Reported)
+}
+
+// clone 深拷贝
+func (s *Shadow) clone() *Shadow {
+	c := *s
+	c.Reported = cloneMap(s.Reported)
+	c.Desired = cloneMap(s.Desired)
+	return &c
+}
+
+// Update 状态修改的结果
+type Update struct {
+	// Shadow 修改后的影子
+	Shadow *Shadow
+	// Delta 修改后期望状态中与上报状态不一致的部分
+	Delta map[string]interface{}
+	// DeltaChanged delta 是否与修改前不同
+	DeltaChanged bool
+	// Changed 上报或者期望状态是否发生变化
+	Changed bool
+	// Acknowledged 设备上报后已经达到、从期望状态中移除的字段
+	Acknowledged []string
+}
+
+// Shadows 设备影子集合，可以并发调用，修改后通过 Store 持久化
+type Shadows struct {
+	mu      sync.Mutex
+	store   Store
+	shadows map[string]*Shadow
+}
+
+// New 创建设备影子集合，从 store 加载已经保存的影子，store 为 nil 只保存在内存中
+func New(store Store) (*Shadows, error) {
+	s := &Shadows{store: store, shadows: map[string]*Shadow{}}
+	if store != nil {
+		shadows, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		for _, shadow := range shadows {
+			s.shadows[shadow.Device] = shadow
+		}
+	}
+	return s, nil
+}
+
+// Get 获取设备影子的拷贝
+func (s *Shadows) Get(device string) (*Shadow, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shadow, ok := s.shadows[device]
+	if !ok {
+		return nil, false
+	}
+	return shadow.clone(), true
+}
+
+// Devices 返回所有设备名称
+func (s *Shadows) Devices() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	devices := make([]string, 0, len(s.shadows))
+	for device := range s.shadows {
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+// Report 合并设备上报的状态，值为 null 的字段从上报状态中删除，嵌套对象按字段合并。
+// clearAcknowledged 为 true 时，上报后与期望值一致的字段从期望状态中移除
+func (s *Shadows) Report(device string, state map[string]interface{}, clearAcknowledged bool, now time.Time) (*Update, error) {
+	return s.update(device, func(shadow *Shadow) (bool, []string) {
+		changed := merge(shadow.Reported, state)
+		shadow.ReportedAt = now.UnixMilli()
+		if !clearAcknowledged {
+			return changed, nil
+		}
+		desired := cloneMap(shadow.Desired)
+		acknowledged := acknowledge(shadow.Desired, shadow.Reported)
+		return changed || !reflect.DeepEqual(desired, shadow.Desired), acknowledged
+	})
+}
+
+// Desire 合并期望的状态，值为 null 的字段从期望状态中删除
+func (s *Shadows) Desire(device string, state map[string]interface{}, now time.Time) (*Update, error) {
+	return s.update(device, func(shadow *Shadow) (bool, []string) {
+		changed := merge(shadow.Desired, state)
+		shadow.DesiredAt = now.UnixMilli()
+		return changed, nil
+	})
+}
+
+// Delete 删除设备影子，返回删除前的影子
+func (s *Shadows) Delete(device string) (*Shadow, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shadow, ok := s.shadows[device]
+	if !ok {
+		return nil, false, nil
+	}
+	if s.store != nil {
+		if err := s.store.Delete(device); err != nil {
+			return nil, false, err
+		}
+	}
+	delete(s.shadows, device)
+	return shadow, true, nil
+}
+
+// Close 关闭持久化存储
+func (s *Shadows) Close() error {
+	if s.store != nil {
+		return s.store.Close()
+	}
+	return nil
+}
+
+// update 修改设备影子，状态变化时版本号加1并持久化，持久化失败时不修改
+func (s *Shadows) update(device string, fn func(shadow *Shadow) (bool, []string)) (*Update, error) {
+	if device == "" {
+		return nil, ErrDeviceEmpty
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.shadows[device]
+	if !ok {
+		current = &Shadow{Device: device, Reported: map[string]interface{}{}, Desired: map[string]interface{}{}}
+	}
+	before := current.Delta()
+	shadow := current.clone()
+	changed, acknowledged := fn(shadow)
+	if changed {
+		shadow.Version++
+	}
+	if s.store != nil && (changed || !ok) {
+		if err := s.store.Save(shadow); err != nil {
+			return nil, err
+		}
+	}
+	s.shadows[device] = shadow
+	delta := shadow.Delta()
+	return &Update{
+		Shadow:       shadow.clone(),
+		Delta:        delta,
+		DeltaChanged: !reflect.DeepEqual(before, delta),
+		Changed:      changed,
+		Acknowledged: acknowledged,
+	}, nil
+}
+
+// merge 把 patch 合并到 state，值为 null 的字段删除，嵌套对象按字段合并，返回 state 是否变化
+func merge(state, patch map[string]interface{}) bool {
+	var changed bool
+	for k, v := range patch {
+		old, ok := state[k]
+		if v == nil {
+			if ok {
+				delete(state, k)
+				changed = true
+			}
+			continue
+		}
+		if sub, isMap := v.(map[string]interface{}); isMap {
+			if oldSub, oldIsMap := old.(map[string]interface{}); oldIsMap {
+				if merge(oldSub, sub) {
+					changed = true
+				}
+				if len(oldSub) == 0 {
+					delete(state, k)
+				}
+				continue
+			}
+			oldSub := map[string]interface{}{}
+			merge(oldSub, sub)
+			if len(oldSub) > 0 || ok {
+				state[k] = oldSub
+				changed = true
+			}
+			continue
+		}
+		if !ok || !reflect.DeepEqual(old, v) {
+			state[k] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// diff 返回 desired 中与 reported 不一致的部分，嵌套对象按字段比较
+func diff(desired, reported map[string]interface{}) map[string]interface{} {
+	delta := map[string]interface{}{}
+	for k, v := range desired {
+		r, ok := reported[k]
+		if sub, isMap := v.(map[string]interface{}); isMap {
+			if rSub, rIsMap := r.(map[string]interface{}); rIsMap {
+				if d := diff(sub, rSub); len(d) > 0 {
+					delta[k] = d
+				}
+				continue
+			}
+		}
+		if !ok || !reflect.DeepEqual(r, v) {
+			delta[k] = cloneValue(v)
+		}
+	}
+	return delta
+}
+
+// acknowledge 从 desired 中移除与 reported 一致的字段，返回移除的顶层字段
+func acknowledge(desired, reported map[string]interface{}) []string {
+	var acknowledged []string
+	for k, v := range desired {
+		r, ok := reported[k]
+		if !ok {
+			continue
+		}
+		if sub, isMap := v.(map[string]interface{}); isMap {
+			if rSub, rIsMap := r.(map[string]interface{}); rIsMap {
+				acknowledge(sub, rSub)
+				if len(sub) == 0 {
+					delete(desired, k)
+					acknowledged = append(acknowledged, k)
+				}
+				continue
+			}
+		}
+		if reflect.DeepEqual(r, v) {
+			delete(desired, k)
+			acknowledged = append(acknowledged, k)
+		}
+	}
+	return acknowledged
+}
+
+// cloneMap 深拷贝 JSON 对象
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = cloneValue(v)
+	}
+	return c
+}
+
+// cloneValue 深拷贝 JSON 值
+func cloneValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return cloneMap(value)
+	case []interface{}:
+		c := make([]interface{}, len(value))
+		for i, item := range value {
+			c[i] = cloneValue(item)
+		}
+		return c
+	default:
+		return v
+	}
+}
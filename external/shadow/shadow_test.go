@@ -0,0 +1,176 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shadow
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// state 解析 JSON 对象
+func state(t *testing.T, s string) map[string]interface{} {
+	var m map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(s), &m))
+	return m
+}
+
+func TestShadows(t *testing.T) {
+	shadows, err := New(nil)
+	assert.Nil(t, err)
+	now := time.UnixMilli(1700000000000)
+
+	_, err = shadows.Report("", state(t, `{"a":1}`), true, now)
+	assert.Equal(t, ErrDeviceEmpty, err)
+
+	update, err := shadows.Report("boiler01", state(t, `{"temperature":21.5,"setpoint":20,"mode":{"fan":"auto","heat":true}}`), true, now)
+	assert.Nil(t, err)
+	assert.True(t, update.Changed)
+	assert.False(t, update.DeltaChanged)
+	assert.Equal(t, int64(1), update.Shadow.Version)
+	assert.Equal(t, int64(1700000000000), update.Shadow.ReportedAt)
+
+	// 相同的值不修改版本号
+	update, err = shadows.Report("boiler01", state(t, `{"temperature":21.5}`), true, now)
+	assert.Nil(t, err)
+	assert.False(t, update.Changed)
+	assert.Equal(t, int64(1), update.Shadow.Version)
+
+	// 期望状态产生 delta，嵌套对象按字段比较
+	update, err = shadows.Desire("boiler01", state(t, `{"setpoint":22,"mode":{"fan":"auto","heat":false}}`), now)
+	assert.Nil(t, err)
+	assert.True(t, update.DeltaChanged)
+	assert.Equal(t, state(t, `{"setpoint":22,"mode":{"heat":false}}`), update.Delta)
+
+	// 上报部分达到期望值，达到的字段从期望状态移除，嵌套对象中已经一致的字段也移除
+	update, err = shadows.Report("boiler01", state(t, `{"setpoint":22}`), true, now)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"setpoint"}, update.Acknowledged)
+	assert.Equal(t, state(t, `{"mode":{"heat":false}}`), update.Delta)
+	assert.Equal(t, state(t, `{"mode":{"heat":false}}`), update.Shadow.Desired)
+
+	// null 删除字段
+	update, err = shadows.Desire("boiler01", state(t, `{"mode":null}`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(update.Delta))
+	assert.True(t, update.DeltaChanged)
+	update, err = shadows.Report("boiler01", state(t, `{"mode":{"heat":null}}`), true, now)
+	assert.Nil(t, err)
+	assert.Equal(t, state(t, `{"temperature":21.5,"setpoint":22,"mode":{"fan":"auto"}}`), update.Shadow.Reported)
+
+	// 不清除达到的期望值
+	_, err = shadows.Desire("pump01", state(t, `{"running":true}`), now)
+	assert.Nil(t, err)
+	update, err = shadows.Report("pump01", state(t, `{"running":true}`), false, now)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(update.Acknowledged))
+	assert.Equal(t, 0, len(update.Delta))
+	assert.Equal(t, state(t, `{"running":true}`), update.Shadow.Desired)
+
+	// 返回的是拷贝
+	shadow, ok := shadows.Get("boiler01")
+	assert.True(t, ok)
+	shadow.Reported["temperature"] = 0
+	shadow, _ = shadows.Get("boiler01")
+	assert.Equal(t, 21.5, shadow.Reported["temperature"])
+	assert.Equal(t, 2, len(shadows.Devices()))
+
+	_, ok, err = shadows.Delete("boiler01")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	_, ok = shadows.Get("boiler01")
+	assert.False(t, ok)
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(StoreConfig{Type: StoreFile, Dir: dir})
+	assert.Nil(t, err)
+	shadows, err := New(store)
+	assert.Nil(t, err)
+	_, err = shadows.Report("site/boiler01", state(t, `{"temperature":21.5}`), true, time.Now())
+	assert.Nil(t, err)
+	_, err = shadows.Desire("site/boiler01", state(t, `{"setpoint":22}`), time.Now())
+	assert.Nil(t, err)
+	_, err = shadows.Report("pump01", state(t, `{"running":true}`), true, time.Now())
+	assert.Nil(t, err)
+	_, _, err = shadows.Delete("pump01")
+	assert.Nil(t, err)
+	assert.Nil(t, shadows.Close())
+
+	// 重新打开后恢复
+	store, err = NewStore(StoreConfig{Type: StoreFile, Dir: dir})
+	assert.Nil(t, err)
+	shadows, err = New(store)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"site/boiler01"}, shadows.Devices())
+	shadow, ok := shadows.Get("site/boiler01")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), shadow.Version)
+	assert.Equal(t, state(t, `{"setpoint":22}`), shadow.Delta())
+
+	_, err = NewStore(StoreConfig{Type: StoreFile})
+	assert.NotNil(t, err)
+	_, err = NewStore(StoreConfig{Type: "redis"})
+	assert.NotNil(t, err)
+}
+
+// testStore 记录保存次数的自定义存储
+type testStore struct {
+	saved int
+}
+
+func (s *testStore) Load() ([]*Shadow, error) {
+	return []*Shadow{{Device: "d1", Reported: map[string]interface{}{"a": 1.0}, Desired: map[string]interface{}{}, Version: 3}}, nil
+}
+
+func (s *testStore) Save(shadow *Shadow) error {
+	s.saved++
+	return nil
+}
+
+func (s *testStore) Delete(device string) error {
+	return nil
+}
+
+func (s *testStore) Close() error {
+	return nil
+}
+
+func TestRegisterStore(t *testing.T) {
+	assert.NotNil(t, RegisterStore(StoreFile, func(config StoreConfig) (Store, error) { return nil, nil }))
+	assert.NotNil(t, RegisterStore("test", nil))
+
+	store := &testStore{}
+	assert.Nil(t, RegisterStore("test", func(config StoreConfig) (Store, error) {
+		assert.Equal(t, "value", config.Options["key"])
+		return store, nil
+	}))
+	s, err := NewStore(StoreConfig{Type: "test", Options: map[string]interface{}{"key": "value"}})
+	assert.Nil(t, err)
+	shadows, err := New(s)
+	assert.Nil(t, err)
+	update, err := shadows.Report("d1", state(t, `{"a":1}`), true, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), update.Shadow.Version)
+	assert.Equal(t, 0, store.saved)
+	_, err = shadows.Report("d1", state(t, `{"a":2}`), true, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.saved)
+}
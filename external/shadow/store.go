@@ -0,0 +1,178 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shadow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 内置的存储类型
+const (
+	// StoreMemory 只保存在内存中，重启后丢失
+	StoreMemory = "memory"
+	// StoreFile 每个设备一个 JSON 文件
+	StoreFile = "file"
+)
+
+// fileExt 影子文件的扩展名
+const fileExt = ".json"
+
+// Store 设备影子的持久化存储，Shadows 在锁内调用，不需要并发安全
+type Store interface {
+	// Load 加载所有保存的影子
+	Load() ([]*Shadow, error)
+	// Save 保存影子
+	Save(shadow *Shadow) error
+	// Delete 删除影子
+	Delete(device string) error
+	// Close 关闭存储
+	Close() error
+}
+
+// StoreConfig 存储配置
+type StoreConfig struct {
+	// Type 存储类型：memory、file 或者通过 RegisterStore 注册的类型
+	Type string `json:"type"`
+	// Dir file 存储的目录
+	Dir string `json:"dir"`
+	// Options 自定义存储的参数
+	Options map[string]interface{} `json:"options"`
+}
+
+// StoreFactory 创建存储
+type StoreFactory func(config StoreConfig) (Store, error)
+
+var (
+	storesLock sync.RWMutex
+	stores     = map[string]StoreFactory{}
+)
+
+// RegisterStore 注册自定义存储，eg. Redis、数据库，不能覆盖内置类型
+func RegisterStore(name string, factory StoreFactory) error {
+	if name == "" || factory == nil {
+		return errors.New("store name and factory are required")
+	}
+	if name == StoreMemory || name == StoreFile {
+		return fmt.Errorf("shadow store %s is built in", name)
+	}
+	storesLock.Lock()
+	defer storesLock.Unlock()
+	stores[name] = factory
+	return nil
+}
+
+// NewStore 按配置创建存储，memory 返回 nil
+func NewStore(config StoreConfig) (Store, error) {
+	switch config.Type {
+	case "", StoreMemory:
+		return nil, nil
+	case StoreFile:
+		return NewFileStore(config.Dir)
+	}
+	storesLock.RLock()
+	factory, ok := stores[config.Type]
+	storesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown shadow store: %s", config.Type)
+	}
+	return factory(config)
+}
+
+// FileStore 每个设备一个 JSON 文件，文件名为转义后的设备名称，先写临时文件再重命名
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore 创建文件存储，目录不存在时创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("shadow store dir is empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Load 加载目录下的所有影子，跳过无法解析的文件
+func (s *FileStore) Load() ([]*Shadow, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var shadows []*Shadow
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var shadow Shadow
+		if json.Unmarshal(data, &shadow) != nil || shadow.Device == "" {
+			continue
+		}
+		if shadow.Reported == nil {
+			shadow.Reported = map[string]interface{}{}
+		}
+		if shadow.Desired == nil {
+			shadow.Desired = map[string]interface{}{}
+		}
+		shadows = append(shadows, &shadow)
+	}
+	return shadows, nil
+}
+
+// Save 保存影子
+func (s *FileStore) Save(shadow *Shadow) error {
+	data, err := json.Marshal(shadow)
+	if err != nil {
+		return err
+	}
+	path := s.path(shadow.Device)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Delete 删除影子
+func (s *FileStore) Delete(device string) error {
+	if err := os.Remove(s.path(device)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Close 关闭存储
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// path 设备影子文件的路径
+func (s *FileStore) path(device string) string {
+	return filepath.Join(s.dir, url.PathEscape(device)+fileExt)
+}
@@ -0,0 +1,481 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// errMalformed 报文格式错误
+var errMalformed = errors.New("snmp: malformed packet")
+
+// Asn1BER 变量的 BER 类型
+type Asn1BER byte
+
+const (
+	Integer          Asn1BER = 0x02
+	OctetString      Asn1BER = 0x04
+	Null             Asn1BER = 0x05
+	ObjectIdentifier Asn1BER = 0x06
+	Sequence         Asn1BER = 0x30
+	IPAddress        Asn1BER = 0x40
+	Counter32        Asn1BER = 0x41
+	Gauge32          Asn1BER = 0x42
+	TimeTicks        Asn1BER = 0x43
+	Opaque           Asn1BER = 0x44
+	Counter64        Asn1BER = 0x46
+	NoSuchObject     Asn1BER = 0x80
+	NoSuchInstance   Asn1BER = 0x81
+	EndOfMibView     Asn1BER = 0x82
+)
+
+var berNames = map[Asn1BER]string{
+	Integer: "Integer", OctetString: "OctetString", Null: "Null", ObjectIdentifier: "ObjectIdentifier",
+	IPAddress: "IPAddress", Counter32: "Counter32", Gauge32: "Gauge32", TimeTicks: "TimeTicks",
+	Opaque: "Opaque", Counter64: "Counter64", NoSuchObject: "NoSuchObject",
+	NoSuchInstance: "NoSuchInstance", EndOfMibView: "EndOfMibView",
+}
+
+func (t Asn1BER) String() string {
+	if name, ok := berNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", byte(t))
+}
+
+// ParseAsn1BER 按名称解析变量类型，不区分大小写
+func ParseAsn1BER(name string) (Asn1BER, error) {
+	for t, n := range berNames {
+		if strings.EqualFold(n, name) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("snmp: unknown type %s", name)
+}
+
+// Variable 变量绑定
+// Value 的类型：Integer 为 int64，OctetString 和 Opaque 为 []byte，ObjectIdentifier 和 IPAddress 为 string，
+// Counter32、Gauge32 和 TimeTicks 为 uint32，Counter64 为 uint64，Null 和异常值为 nil
+type Variable struct {
+	// OID 点分格式，没有前导点，eg. 1.3.6.1.2.1.1.1.0
+	OID   string
+	Type  Asn1BER
+	Value interface{}
+}
+
+// IsException 是否 noSuchObject、noSuchInstance 或者 endOfMibView
+func (v Variable) IsException() bool {
+	return v.Type == NoSuchObject || v.Type == NoSuchInstance || v.Type == EndOfMibView
+}
+
+// JSONValue 转换成适合 JSON 输出的值，可打印的 OctetString 输出为字符串，否则输出为 xx:xx 形式的十六进制
+func (v Variable) JSONValue() interface{} {
+	switch value := v.Value.(type) {
+	case []byte:
+		if v.Type == OctetString && printable(value) {
+			return string(value)
+		}
+		hex := make([]string, len(value))
+		for i, b := range value {
+			hex[i] = fmt.Sprintf("%02x", b)
+		}
+		return strings.Join(hex, ":")
+	default:
+		return value
+	}
+}
+
+// printable 是否可打印的 UTF-8 字符串，末尾的 \0 忽略
+func printable(b []byte) bool {
+	s := strings.TrimRight(string(b), "\x00")
+	for _, r := range s {
+		if r == 0xfffd || (r < 0x20 && r != '\r' && r != '\n' && r != '\t') || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// tlv 编码 TLV
+func tlv(tag byte, contents ...[]byte) []byte {
+	n := 0
+	for _, c := range contents {
+		n += len(c)
+	}
+	b := make([]byte, 0, n+6)
+	b = append(b, tag)
+	b = appendLength(b, n)
+	for _, c := range contents {
+		b = append(b, c...)
+	}
+	return b
+}
+
+func appendLength(b []byte, n int) []byte {
+	switch {
+	case n < 0x80:
+		return append(b, byte(n))
+	case n <= 0xff:
+		return append(b, 0x81, byte(n))
+	case n <= 0xffff:
+		return append(b, 0x82, byte(n>>8), byte(n))
+	default:
+		return append(b, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func encodeInteger(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	i := 0
+	for i < 7 && ((b[i] == 0 && b[i+1]&0x80 == 0) || (b[i] == 0xff && b[i+1]&0x80 != 0)) {
+		i++
+	}
+	return b[i:]
+}
+
+func encodeUnsigned(v uint64) []byte {
+	b := make([]byte, 9)
+	binary.BigEndian.PutUint64(b[1:], v)
+	i := 0
+	for i < 8 && b[i] == 0 && b[i+1]&0x80 == 0 {
+		i++
+	}
+	return b[i:]
+}
+
+func decodeInteger(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return 0, errMalformed
+	}
+	v := int64(int8(b[0]))
+	for _, c := range b[1:] {
+		v = v<<8 | int64(c)
+	}
+	return v, nil
+}
+
+func decodeUnsigned(b []byte) (uint64, error) {
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	if len(b) == 0 || len(b) > 8 {
+		return 0, errMalformed
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// ParseOID 解析点分格式的 OID，允许前导点
+func ParseOID(oid string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(oid), "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("snmp: invalid oid %q", oid)
+	}
+	arcs := make([]uint32, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("snmp: invalid oid %q", oid)
+		}
+		arcs[i] = uint32(v)
+	}
+	if arcs[0] > 2 || (arcs[0] < 2 && arcs[1] >= 40) {
+		return nil, fmt.Errorf("snmp: invalid oid %q", oid)
+	}
+	return arcs, nil
+}
+
+// NormalizeOID 去掉前导点和空白，并校验格式
+func NormalizeOID(oid string) (string, error) {
+	if _, err := ParseOID(oid); err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(oid), "."), nil
+}
+
+func encodeOID(oid string) ([]byte, error) {
+	arcs, err := ParseOID(oid)
+	if err != nil {
+		return nil, err
+	}
+	b := appendBase128(nil, uint64(arcs[0])*40+uint64(arcs[1]))
+	for _, arc := range arcs[2:] {
+		b = appendBase128(b, uint64(arc))
+	}
+	return b, nil
+}
+
+func appendBase128(b []byte, v uint64) []byte {
+	n := 1
+	for t := v >> 7; t > 0; t >>= 7 {
+		n++
+	}
+	for i := n - 1; i >= 0; i-- {
+		c := byte(v>>(7*uint(i))) & 0x7f
+		if i > 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func decodeOID(b []byte) (string, error) {
+	var arcs []string
+	var v uint64
+	for i, c := range b {
+		if v > math.MaxUint32>>7 {
+			return "", errMalformed
+		}
+		v = v<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(b)-1 {
+				return "", errMalformed
+			}
+			continue
+		}
+		if arcs == nil {
+			if v < 80 {
+				arcs = append(arcs, strconv.FormatUint(v/40, 10), strconv.FormatUint(v%40, 10))
+			} else {
+				arcs = append(arcs, "2", strconv.FormatUint(v-80, 10))
+			}
+		} else {
+			arcs = append(arcs, strconv.FormatUint(v, 10))
+		}
+		v = 0
+	}
+	if arcs == nil {
+		return "", errMalformed
+	}
+	return strings.Join(arcs, "."), nil
+}
+
+// CompareOID 比较两个 OID 的字典序
+func CompareOID(a, b string) int {
+	x, _ := ParseOID(a)
+	y, _ := ParseOID(b)
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(x) - len(y)
+}
+
+// HasPrefixOID oid 是否在 root 子树下
+func HasPrefixOID(oid, root string) bool {
+	return oid == root || strings.HasPrefix(oid, root+".")
+}
+
+// decoder 顺序读取 TLV
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) empty() bool {
+	return len(d.data) == 0
+}
+
+// next 读取下一个 TLV，返回的内容是原始数据的子切片，容量延伸到原始数据末尾
+func (d *decoder) next() (byte, []byte, error) {
+	if len(d.data) < 2 {
+		return 0, nil, errMalformed
+	}
+	tag := d.data[0]
+	n := int(d.data[1])
+	offset := 2
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 3 || len(d.data) < 2+size {
+			return 0, nil, errMalformed
+		}
+		n = 0
+		for _, c := range d.data[2 : 2+size] {
+			n = n<<8 | int(c)
+		}
+		offset += size
+	}
+	if len(d.data)-offset < n {
+		return 0, nil, errMalformed
+	}
+	content := d.data[offset : offset+n]
+	d.data = d.data[offset+n:]
+	return tag, content, nil
+}
+
+func (d *decoder) expect(tag Asn1BER) ([]byte, error) {
+	t, content, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	if t != byte(tag) {
+		return nil, fmt.Errorf("snmp: expected %s, got 0x%02x", tag, t)
+	}
+	return content, nil
+}
+
+func (d *decoder) integer() (int64, error) {
+	content, err := d.expect(Integer)
+	if err != nil {
+		return 0, err
+	}
+	return decodeInteger(content)
+}
+
+// encodeValue 编码变量值，值允许使用 JSON 解码后的 float64 和字符串
+func encodeValue(t Asn1BER, value interface{}) ([]byte, error) {
+	switch t {
+	case Integer:
+		v, err := toInt64(value)
+		if err != nil {
+			return nil, err
+		}
+		return tlv(byte(t), encodeInteger(v)), nil
+	case OctetString, Opaque:
+		switch v := value.(type) {
+		case []byte:
+			return tlv(byte(t), v), nil
+		case string:
+			return tlv(byte(t), []byte(v)), nil
+		case nil:
+			return tlv(byte(t)), nil
+		default:
+			return tlv(byte(t), []byte(fmt.Sprint(v))), nil
+		}
+	case Null, NoSuchObject, NoSuchInstance, EndOfMibView:
+		return tlv(byte(t)), nil
+	case ObjectIdentifier:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("snmp: invalid oid value %v", value)
+		}
+		oid, err := encodeOID(v)
+		if err != nil {
+			return nil, err
+		}
+		return tlv(byte(t), oid), nil
+	case IPAddress:
+		v, _ := value.(string)
+		ip := net.ParseIP(v).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("snmp: invalid ip address %v", value)
+		}
+		return tlv(byte(t), ip), nil
+	case Counter32, Gauge32, TimeTicks, Counter64:
+		v, err := toUint64(value)
+		if err != nil {
+			return nil, err
+		}
+		if t != Counter64 && v > math.MaxUint32 {
+			return nil, fmt.Errorf("snmp: %s value %d out of range", t, v)
+		}
+		return tlv(byte(t), encodeUnsigned(v)), nil
+	default:
+		return nil, fmt.Errorf("snmp: unsupported type %s", t)
+	}
+}
+
+func decodeValue(tag byte, content []byte) (interface{}, error) {
+	switch Asn1BER(tag) {
+	case Integer:
+		return decodeInteger(content)
+	case OctetString, Opaque:
+		return append([]byte{}, content...), nil
+	case Null, NoSuchObject, NoSuchInstance, EndOfMibView:
+		return nil, nil
+	case ObjectIdentifier:
+		return decodeOID(content)
+	case IPAddress:
+		if len(content) != 4 && len(content) != 16 {
+			return nil, errMalformed
+		}
+		return net.IP(content).String(), nil
+	case Counter32, Gauge32, TimeTicks:
+		v, err := decodeUnsigned(content)
+		if err != nil || v > math.MaxUint32 {
+			return nil, errMalformed
+		}
+		return uint32(v), nil
+	case Counter64:
+		return decodeUnsigned(content)
+	default:
+		return append([]byte{}, content...), nil
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("snmp: invalid integer %v", v)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("snmp: invalid integer %v", value)
+	}
+}
+
+func toUint64(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case uint:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	default:
+		n, err := toInt64(value)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("snmp: invalid unsigned integer %v", value)
+		}
+		return uint64(n), nil
+	}
+}
@@ -0,0 +1,449 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPort 默认的代理端口
+	DefaultPort = 161
+	// DefaultTrapPort 默认的 trap 端口
+	DefaultTrapPort = 162
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 5 * time.Second
+	// DefaultMaxRepetitions GetBulk 默认的最大获取次数
+	DefaultMaxRepetitions = 10
+	// maxPacketSize 接收缓冲区大小
+	maxPacketSize = 65535
+)
+
+// 常用 OID
+const (
+	SysUpTimeOID  = "1.3.6.1.2.1.1.3.0"
+	SnmpTrapOID   = "1.3.6.1.6.3.1.1.4.1.0"
+	SnmpTrapsOID  = "1.3.6.1.6.3.1.1.5"
+	EnterpriseOID = "1.3.6.1.6.3.1.1.4.3.0"
+)
+
+// ErrTimeout 请求超时
+var ErrTimeout = errors.New("snmp: request timeout")
+
+// StatusError 响应的错误状态
+type StatusError struct {
+	Status int
+	// Index 出错的变量序号，从 1 开始
+	Index int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("snmp: %s at index %d", ErrorStatusName(e.Status), e.Index)
+}
+
+// ClientConfig 客户端配置
+type ClientConfig struct {
+	// Server 代理地址，格式：host:port，端口默认 161
+	Server    string
+	Version   Version
+	Community string
+	Timeout   time.Duration
+	// Retries 超时重试次数
+	Retries int
+	// User v3 用户
+	User        User
+	ContextName string
+	// EngineID 本地引擎ID，发送 v3 trap 时作为权威引擎，为空随机生成
+	EngineID []byte
+}
+
+// Client SNMP 客户端，一个客户端对应一个代理或者 trap 接收方，请求串行执行
+type Client struct {
+	conn        *net.UDPConn
+	config      ClientConfig
+	credentials *Credentials
+	start       time.Time
+	mu          sync.Mutex
+	requestID   int32
+	buf         []byte
+	// 以下为引擎发现得到的权威引擎
+	engineID   []byte
+	boots      int32
+	engineTime int32
+	syncedAt   time.Time
+	keys       *Keys
+	// localKeys 本地引擎的密钥，发送 v3 trap 使用
+	localKeys *Keys
+}
+
+// NewClient 创建客户端
+func NewClient(config ClientConfig) (*Client, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	c := &Client{config: config, start: time.Now(), requestID: mrand.Int31(), buf: make([]byte, maxPacketSize)}
+	if config.Version == Version3 {
+		credentials, err := NewCredentials(config.User)
+		if err != nil {
+			return nil, err
+		}
+		c.credentials = credentials
+		if c.config.EngineID == nil {
+			c.config.EngineID = NewEngineID()
+		}
+	}
+	raddr, err := net.ResolveUDPAddr("udp", WithPort(config.Server, DefaultPort))
+	if err != nil {
+		return nil, err
+	}
+	if c.conn, err = net.DialUDP("udp", nil, raddr); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WithPort 地址没有端口时使用默认端口
+func WithPort(address string, port int) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(address, strconv.Itoa(port))
+}
+
+// Close 关闭客户端
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Get 获取变量
+func (c *Client) Get(oids ...string) ([]Variable, error) {
+	return c.request(PDU{Type: GetRequest}, oids)
+}
+
+// GetNext 获取字典序的下一个变量
+func (c *Client) GetNext(oids ...string) ([]Variable, error) {
+	return c.request(PDU{Type: GetNextRequest}, oids)
+}
+
+// GetBulk 批量获取，v1 不支持
+func (c *Client) GetBulk(nonRepeaters, maxRepetitions int, oids ...string) ([]Variable, error) {
+	if c.config.Version == Version1 {
+		return nil, errors.New("snmp: getBulk is not supported by v1")
+	}
+	return c.request(PDU{Type: GetBulkRequest, NonRepeaters: nonRepeaters, MaxRepetitions: maxRepetitions}, oids)
+}
+
+// Walk 获取子树下的所有变量，v1 使用 GetNext，其他使用 GetBulk。root 本身是实例时返回该变量
+func (c *Client) Walk(root string, maxRepetitions int) ([]Variable, error) {
+	root, err := NormalizeOID(root)
+	if err != nil {
+		return nil, err
+	}
+	if maxRepetitions <= 0 {
+		maxRepetitions = DefaultMaxRepetitions
+	}
+	var result []Variable
+	oid := root
+walk:
+	for {
+		var variables []Variable
+		if c.config.Version == Version1 {
+			variables, err = c.GetNext(oid)
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) && statusErr.Status == 2 {
+				// v1 到达 MIB 末尾返回 noSuchName
+				break
+			}
+		} else {
+			variables, err = c.GetBulk(0, maxRepetitions, oid)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(variables) == 0 {
+			break
+		}
+		for _, v := range variables {
+			if v.Type == EndOfMibView || !HasPrefixOID(v.OID, root) {
+				break walk
+			}
+			if CompareOID(v.OID, oid) <= 0 {
+				return nil, fmt.Errorf("snmp: oid %s is not increasing", v.OID)
+			}
+			result = append(result, v)
+			oid = v.OID
+		}
+	}
+	if len(result) == 0 {
+		variables, err := c.Get(root)
+		if err != nil {
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				return result, nil
+			}
+			return nil, err
+		}
+		if len(variables) == 1 && !variables[0].IsException() {
+			result = variables
+		}
+	}
+	return result, nil
+}
+
+// Trap 发送 SNMPv2-Trap，自动添加 sysUpTime.0 和 snmpTrapOID.0，v1 不支持
+func (c *Client) Trap(trapOID string, variables []Variable) error {
+	if c.config.Version == Version1 {
+		return errors.New("snmp: v1 traps are not supported")
+	}
+	pdu, err := c.notification(SNMPv2Trap, trapOID, variables)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestID++
+	pdu.RequestID = c.requestID
+	p := &Packet{Version: c.config.Version, Community: c.config.Community, PDU: pdu}
+	var keys *Keys
+	if c.config.Version == Version3 {
+		// trap 的权威引擎是发送方
+		if c.localKeys == nil {
+			c.localKeys = c.credentials.Localize(c.config.EngineID)
+		}
+		keys = c.localKeys
+		p.MsgID = pdu.RequestID
+		p.Flags = c.credentials.User.Flags()
+		p.Security = SecurityParameters{
+			AuthoritativeEngineID:    c.config.EngineID,
+			AuthoritativeEngineBoots: 1,
+			AuthoritativeEngineTime:  int32(time.Since(c.start) / time.Second),
+			UserName:                 c.credentials.User.UserName,
+		}
+		p.ContextEngineID = c.config.EngineID
+		p.ContextName = c.config.ContextName
+	}
+	data, err := p.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(data)
+	return err
+}
+
+// Inform 发送 InformRequest 并等待确认，自动添加 sysUpTime.0 和 snmpTrapOID.0，v1 不支持
+func (c *Client) Inform(trapOID string, variables []Variable) error {
+	if c.config.Version == Version1 {
+		return errors.New("snmp: v1 informs are not supported")
+	}
+	pdu, err := c.notification(InformRequest, trapOID, variables)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(pdu)
+	return err
+}
+
+func (c *Client) notification(t PDUType, trapOID string, variables []Variable) (PDU, error) {
+	trapOID, err := NormalizeOID(trapOID)
+	if err != nil {
+		return PDU{}, err
+	}
+	uptime := uint32(time.Since(c.start) / (10 * time.Millisecond))
+	return PDU{Type: t, Variables: append([]Variable{
+		{OID: SysUpTimeOID, Type: TimeTicks, Value: uptime},
+		{OID: SnmpTrapOID, Type: ObjectIdentifier, Value: trapOID},
+	}, variables...)}, nil
+}
+
+func (c *Client) request(pdu PDU, oids []string) ([]Variable, error) {
+	if len(oids) == 0 {
+		return nil, errors.New("snmp: no oids")
+	}
+	for _, oid := range oids {
+		oid, err := NormalizeOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		pdu.Variables = append(pdu.Variables, Variable{OID: oid, Type: Null})
+	}
+	resp, err := c.do(pdu)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Variables, nil
+}
+
+// do 发送请求并返回响应，v3 先进行引擎发现，权威引擎重启或者时间不同步时重新同步后重试一次
+func (c *Client) do(pdu PDU) (*PDU, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config.Version == Version3 && c.engineID == nil {
+		if err := c.discover(); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := c.exchange(pdu)
+	var report *ReportError
+	if errors.As(err, &report) && (report.OID == NotInTimeWindowsOID || report.OID == UnknownEngineIDsOID) {
+		resp, err = c.exchange(pdu)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorStatus != 0 {
+		return nil, &StatusError{Status: resp.ErrorStatus, Index: resp.ErrorIndex}
+	}
+	return resp, nil
+}
+
+// discover 引擎发现，RFC 3414 4
+func (c *Client) discover() error {
+	c.requestID++
+	p := &Packet{
+		Version: Version3,
+		MsgID:   c.requestID,
+		Flags:   ReportableFlag,
+		PDU:     PDU{Type: GetRequest, RequestID: c.requestID},
+	}
+	resp, err := c.roundTrip(p, nil)
+	if err != nil {
+		return err
+	}
+	if resp.PDU.Type != Report || len(resp.Security.AuthoritativeEngineID) == 0 {
+		return errors.New("snmp: engine discovery failed")
+	}
+	c.sync(resp.Security)
+	return nil
+}
+
+// sync 同步权威引擎，引擎ID变化时重新本地化密钥
+func (c *Client) sync(security SecurityParameters) {
+	if !bytes.Equal(c.engineID, security.AuthoritativeEngineID) {
+		c.engineID = security.AuthoritativeEngineID
+		c.keys = c.credentials.Localize(c.engineID)
+	}
+	c.boots = security.AuthoritativeEngineBoots
+	c.engineTime = security.AuthoritativeEngineTime
+	c.syncedAt = time.Now()
+}
+
+func (c *Client) exchange(pdu PDU) (*PDU, error) {
+	c.requestID++
+	pdu.RequestID = c.requestID
+	p := &Packet{Version: c.config.Version, Community: c.config.Community, PDU: pdu}
+	if c.config.Version == Version3 {
+		p.MsgID = pdu.RequestID
+		p.Flags = c.credentials.User.Flags() | ReportableFlag
+		p.Security = SecurityParameters{
+			AuthoritativeEngineID:    c.engineID,
+			AuthoritativeEngineBoots: c.boots,
+			AuthoritativeEngineTime:  c.engineTime + int32(time.Since(c.syncedAt)/time.Second),
+			UserName:                 c.credentials.User.UserName,
+		}
+		p.ContextEngineID = c.engineID
+		p.ContextName = c.config.ContextName
+	}
+	resp, err := c.roundTrip(p, c.keys)
+	if err != nil {
+		return nil, err
+	}
+	if resp.PDU.Type == Report {
+		report := &ReportError{}
+		if len(resp.PDU.Variables) > 0 {
+			report.OID = resp.PDU.Variables[0].OID
+		}
+		// 未认证的 Report 只用于引擎ID变化，时间窗口错误的 Report 是认证的
+		if resp.Flags&AuthFlag != 0 || report.OID == UnknownEngineIDsOID {
+			c.sync(resp.Security)
+		}
+		return nil, report
+	}
+	if resp.PDU.Type != GetResponse {
+		return nil, fmt.Errorf("snmp: unexpected %s", resp.PDU.Type)
+	}
+	return &resp.PDU, nil
+}
+
+// roundTrip 发送报文，等待 requestID（v1/v2c）或者 msgID（v3）匹配的回复，超时重发
+func (c *Client) roundTrip(p *Packet, keys *Keys) (*Packet, error) {
+	data, err := p.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt <= c.config.Retries; attempt++ {
+		if _, err = c.conn.Write(data); err != nil {
+			return nil, err
+		}
+		if err = c.conn.SetReadDeadline(time.Now().Add(c.config.Timeout)); err != nil {
+			return nil, err
+		}
+		for {
+			n, err := c.conn.Read(c.buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			if resp := c.match(p, c.buf[:n], keys); resp != nil {
+				return resp, nil
+			}
+		}
+	}
+	return nil, ErrTimeout
+}
+
+// match 解码并验证回复，不匹配或者验证失败返回 nil
+func (c *Client) match(req *Packet, data []byte, keys *Keys) *Packet {
+	resp, err := Unmarshal(data)
+	if err != nil || resp.Version != req.Version {
+		return nil
+	}
+	if resp.Version != Version3 {
+		if resp.PDU.RequestID != req.PDU.RequestID {
+			return nil
+		}
+		return resp
+	}
+	if resp.MsgID != req.MsgID {
+		return nil
+	}
+	level := resp.Flags & (AuthFlag | PrivFlag)
+	if level&AuthFlag != 0 {
+		if keys == nil || resp.Authenticate(data, keys) != nil || resp.Decrypt(keys) != nil {
+			return nil
+		}
+	} else if resp.Encrypted() {
+		return nil
+	}
+	// 除 Report 外，回复的安全级别必须和请求一致
+	if resp.PDU.Type != Report && level != req.Flags&(AuthFlag|PrivFlag) {
+		return nil
+	}
+	return resp
+}
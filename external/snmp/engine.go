@@ -0,0 +1,263 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// timeWindow 时间窗口，单位秒，RFC 3414 3.2.7
+const timeWindow = 150
+
+// maxBoots snmpEngineBoots 的最大值，达到后报文都在时间窗口外，RFC 3414 2.2.2
+const maxBoots = 2147483647
+
+// maxCachedKeys 本地化密钥缓存的最大数量，超过后清空重新缓存
+const maxCachedKeys = 1024
+
+// maxRemoteEngines 记录的远端权威引擎的最大数量，超过后清空重新记录
+const maxRemoteEngines = 1024
+
+// NewEngineID 生成随机的引擎ID，RFC 3411 格式 5（管理员指定的字节）
+func NewEngineID() []byte {
+	id := []byte{0x80, 0x00, 0x00, 0x00, 0x05, 0, 0, 0, 0, 0, 0, 0, 0}
+	_, _ = rand.Read(id[5:])
+	return id
+}
+
+// ParseEngineID 解析十六进制的引擎ID，允许 0x 前缀和冒号分隔，为空生成随机的引擎ID
+func ParseEngineID(s string) ([]byte, error) {
+	if s == "" {
+		return NewEngineID(), nil
+	}
+	s = trimHex(s)
+	id, err := hex.DecodeString(s)
+	if err != nil || len(id) < 5 || len(id) > 32 {
+		return nil, fmt.Errorf("snmp: invalid engine id %s", s)
+	}
+	return id, nil
+}
+
+func trimHex(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		if r != ':' && r != ' ' {
+			b.WriteRune(r)
+		}
+	}
+	s = b.String()
+	if len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X") {
+		s = s[2:]
+	}
+	return s
+}
+
+// Engine SNMPv3 引擎，按用户名验证和解密收到的报文，并作为权威引擎回复请求和 inform
+type Engine struct {
+	ID    []byte
+	Boots int32
+	start time.Time
+	users map[string]*Credentials
+	mu    sync.Mutex
+	// keys 用户名和权威引擎ID->本地化密钥，只缓存认证通过的报文使用的密钥
+	keys map[string]*Keys
+	// remotes 权威引擎ID->发送方（trap）的 boots 和时间，用于检查非权威报文的时间窗口
+	remotes map[string]*remoteEngine
+	// reports Report OID->计数
+	reports map[string]uint32
+}
+
+// remoteEngine 远端权威引擎的时间记录，RFC 3414 3.2.7 b
+type remoteEngine struct {
+	boots int32
+	// latestTime 收到的最新的引擎时间
+	latestTime int32
+	// received 收到 latestTime 时的本地时间
+	received time.Time
+}
+
+// NewEngine 创建引擎，用户的主密钥在创建时生成
+func NewEngine(id []byte, boots int32, users []User) (*Engine, error) {
+	e := &Engine{
+		ID:      id,
+		Boots:   boots,
+		start:   time.Now(),
+		users:   make(map[string]*Credentials, len(users)),
+		keys:    map[string]*Keys{},
+		remotes: map[string]*remoteEngine{},
+		reports: map[string]uint32{},
+	}
+	for _, user := range users {
+		if _, ok := e.users[user.UserName]; ok {
+			return nil, fmt.Errorf("snmp: duplicate user %s", user.UserName)
+		}
+		credentials, err := NewCredentials(user)
+		if err != nil {
+			return nil, err
+		}
+		e.users[user.UserName] = credentials
+	}
+	return e, nil
+}
+
+// Time 引擎启动后的秒数
+func (e *Engine) Time() int32 {
+	return int32(time.Since(e.start) / time.Second)
+}
+
+// localize 获取本地化密钥，返回的 cache 在报文认证通过后调用，避免伪造的引擎ID占满缓存
+func (e *Engine) localize(credentials *Credentials, engineID []byte) (*Keys, func()) {
+	key := credentials.User.UserName + "/" + hex.EncodeToString(engineID)
+	e.mu.Lock()
+	keys, ok := e.keys[key]
+	e.mu.Unlock()
+	if ok {
+		return keys, func() {}
+	}
+	keys = credentials.Localize(engineID)
+	return keys, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if len(e.keys) >= maxCachedKeys {
+			e.keys = map[string]*Keys{}
+		}
+		e.keys[key] = keys
+	}
+}
+
+// checkRemoteTime 检查非权威报文（trap）的时间窗口并更新发送方的时间记录，RFC 3414 3.2.7 b
+// 第一次收到的报文建立记录；boots 变小或者引擎时间比记录的估计值早超过时间窗口的报文视为重放
+func (e *Engine) checkRemoteTime(security SecurityParameters) error {
+	if security.AuthoritativeEngineBoots >= maxBoots {
+		return &ReportError{OID: NotInTimeWindowsOID}
+	}
+	key := hex.EncodeToString(security.AuthoritativeEngineID)
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	remote, ok := e.remotes[key]
+	if !ok {
+		if len(e.remotes) >= maxRemoteEngines {
+			e.remotes = map[string]*remoteEngine{}
+		}
+		e.remotes[key] = &remoteEngine{boots: security.AuthoritativeEngineBoots, latestTime: security.AuthoritativeEngineTime, received: now}
+		return nil
+	}
+	estimated := remote.latestTime + int32(now.Sub(remote.received)/time.Second)
+	if security.AuthoritativeEngineBoots < remote.boots ||
+		(security.AuthoritativeEngineBoots == remote.boots && security.AuthoritativeEngineTime < estimated-timeWindow) {
+		return &ReportError{OID: NotInTimeWindowsOID}
+	}
+	if security.AuthoritativeEngineBoots > remote.boots || security.AuthoritativeEngineTime > remote.latestTime {
+		remote.boots = security.AuthoritativeEngineBoots
+		remote.latestTime = security.AuthoritativeEngineTime
+		remote.received = now
+	}
+	return nil
+}
+
+// Open 验证和解密收到的 v3 报文，data 为原始报文。
+// authoritative 为 true 时本引擎是权威引擎（请求和 inform），还会检查引擎ID和时间窗口；
+// 否则权威引擎是发送方（trap），按记录的发送方 boots 和时间检查时间窗口，拒绝重放的报文。
+// 只接受和用户安全级别一致的报文。验证失败返回 *ReportError，时间窗口错误时同时返回密钥用于认证 Report
+func (e *Engine) Open(p *Packet, data []byte, authoritative bool) (*Keys, error) {
+	security := p.Security
+	if authoritative && !bytes.Equal(security.AuthoritativeEngineID, e.ID) {
+		return nil, &ReportError{OID: UnknownEngineIDsOID}
+	}
+	credentials, ok := e.users[security.UserName]
+	if !ok {
+		return nil, &ReportError{OID: UnknownUserNamesOID}
+	}
+	if p.Flags&(AuthFlag|PrivFlag) != credentials.User.Flags() {
+		return nil, &ReportError{OID: UnsupportedSecLevelsOID}
+	}
+	if p.Flags&AuthFlag == 0 {
+		return nil, nil
+	}
+	keys, cache := e.localize(credentials, security.AuthoritativeEngineID)
+	if err := p.Authenticate(data, keys); err != nil {
+		return nil, &ReportError{OID: WrongDigestsOID}
+	}
+	cache()
+	if authoritative {
+		diff := security.AuthoritativeEngineTime - e.Time()
+		if security.AuthoritativeEngineBoots != e.Boots || diff > timeWindow || diff < -timeWindow {
+			return keys, &ReportError{OID: NotInTimeWindowsOID}
+		}
+	} else if err := e.checkRemoteTime(security); err != nil {
+		return nil, err
+	}
+	if err := p.Decrypt(keys); err != nil {
+		return nil, &ReportError{OID: DecryptionErrorsOID}
+	}
+	return keys, nil
+}
+
+// Report 编码回复的 Report，时间窗口错误使用 keys 认证，其他不认证
+func (e *Engine) Report(p *Packet, report *ReportError, keys *Keys) ([]byte, error) {
+	e.mu.Lock()
+	e.reports[report.OID]++
+	count := e.reports[report.OID]
+	e.mu.Unlock()
+	reply := &Packet{
+		Version:         Version3,
+		MsgID:           p.MsgID,
+		Security:        e.security(p.Security.UserName),
+		ContextEngineID: e.ID,
+		ContextName:     p.ContextName,
+		PDU: PDU{
+			Type:      Report,
+			RequestID: p.PDU.RequestID,
+			Variables: []Variable{{OID: report.OID, Type: Counter32, Value: count}},
+		},
+	}
+	if report.OID == NotInTimeWindowsOID && keys != nil {
+		reply.Flags = AuthFlag
+	} else {
+		keys = nil
+	}
+	return reply.Marshal(keys)
+}
+
+// Response 编码对请求或者 inform 的回复，使用请求的用户和安全级别
+func (e *Engine) Response(p *Packet, pdu PDU, keys *Keys) ([]byte, error) {
+	reply := &Packet{
+		Version:         Version3,
+		MsgID:           p.MsgID,
+		Flags:           p.Flags &^ ReportableFlag,
+		Security:        e.security(p.Security.UserName),
+		ContextEngineID: p.ContextEngineID,
+		ContextName:     p.ContextName,
+		PDU:             pdu,
+	}
+	return reply.Marshal(keys)
+}
+
+func (e *Engine) security(userName string) SecurityParameters {
+	return SecurityParameters{
+		AuthoritativeEngineID:    e.ID,
+		AuthoritativeEngineBoots: e.Boots,
+		AuthoritativeEngineTime:  e.Time(),
+		UserName:                 userName,
+	}
+}
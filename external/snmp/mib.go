@@ -0,0 +1,426 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// builtinNames 内置的 OID 名称：SNMPv2-SMI 的根节点和 SNMPv2-MIB、IF-MIB 的常用对象
+var builtinNames = map[string]string{
+	"0":                       "ccitt",
+	"0.0":                     "zeroDotZero",
+	"1":                       "iso",
+	"2":                       "joint-iso-ccitt",
+	"1.3":                     "org",
+	"1.3.6":                   "dod",
+	"1.3.6.1":                 "internet",
+	"1.3.6.1.1":               "directory",
+	"1.3.6.1.2":               "mgmt",
+	"1.3.6.1.2.1":             "mib-2",
+	"1.3.6.1.2.1.1":           "system",
+	"1.3.6.1.2.1.1.1":         "sysDescr",
+	"1.3.6.1.2.1.1.2":         "sysObjectID",
+	"1.3.6.1.2.1.1.3":         "sysUpTime",
+	"1.3.6.1.2.1.1.4":         "sysContact",
+	"1.3.6.1.2.1.1.5":         "sysName",
+	"1.3.6.1.2.1.1.6":         "sysLocation",
+	"1.3.6.1.2.1.1.7":         "sysServices",
+	"1.3.6.1.2.1.2":           "interfaces",
+	"1.3.6.1.2.1.2.1":         "ifNumber",
+	"1.3.6.1.2.1.2.2":         "ifTable",
+	"1.3.6.1.2.1.2.2.1":       "ifEntry",
+	"1.3.6.1.2.1.2.2.1.1":     "ifIndex",
+	"1.3.6.1.2.1.2.2.1.2":     "ifDescr",
+	"1.3.6.1.2.1.2.2.1.3":     "ifType",
+	"1.3.6.1.2.1.2.2.1.4":     "ifMtu",
+	"1.3.6.1.2.1.2.2.1.5":     "ifSpeed",
+	"1.3.6.1.2.1.2.2.1.6":     "ifPhysAddress",
+	"1.3.6.1.2.1.2.2.1.7":     "ifAdminStatus",
+	"1.3.6.1.2.1.2.2.1.8":     "ifOperStatus",
+	"1.3.6.1.2.1.2.2.1.9":     "ifLastChange",
+	"1.3.6.1.2.1.2.2.1.10":    "ifInOctets",
+	"1.3.6.1.2.1.2.2.1.11":    "ifInUcastPkts",
+	"1.3.6.1.2.1.2.2.1.13":    "ifInDiscards",
+	"1.3.6.1.2.1.2.2.1.14":    "ifInErrors",
+	"1.3.6.1.2.1.2.2.1.16":    "ifOutOctets",
+	"1.3.6.1.2.1.2.2.1.17":    "ifOutUcastPkts",
+	"1.3.6.1.2.1.2.2.1.19":    "ifOutDiscards",
+	"1.3.6.1.2.1.2.2.1.20":    "ifOutErrors",
+	"1.3.6.1.2.1.10":          "transmission",
+	"1.3.6.1.2.1.31":          "ifMIB",
+	"1.3.6.1.2.1.31.1.1":      "ifXTable",
+	"1.3.6.1.2.1.31.1.1.1":    "ifXEntry",
+	"1.3.6.1.2.1.31.1.1.1.1":  "ifName",
+	"1.3.6.1.2.1.31.1.1.1.6":  "ifHCInOctets",
+	"1.3.6.1.2.1.31.1.1.1.10": "ifHCOutOctets",
+	"1.3.6.1.2.1.31.1.1.1.15": "ifHighSpeed",
+	"1.3.6.1.2.1.31.1.1.1.18": "ifAlias",
+	"1.3.6.1.3":               "experimental",
+	"1.3.6.1.4":               "private",
+	"1.3.6.1.4.1":             "enterprises",
+	"1.3.6.1.5":               "security",
+	"1.3.6.1.6":               "snmpV2",
+	"1.3.6.1.6.1":             "snmpDomains",
+	"1.3.6.1.6.2":             "snmpProxys",
+	"1.3.6.1.6.3":             "snmpModules",
+	"1.3.6.1.6.3.1":           "snmpMIB",
+	"1.3.6.1.6.3.1.1":         "snmpMIBObjects",
+	"1.3.6.1.6.3.1.1.4":       "snmpTrap",
+	"1.3.6.1.6.3.1.1.4.1":     "snmpTrapOID",
+	"1.3.6.1.6.3.1.1.4.3":     "snmpTrapEnterprise",
+	"1.3.6.1.6.3.1.1.5":       "snmpTraps",
+	"1.3.6.1.6.3.1.1.5.1":     "coldStart",
+	"1.3.6.1.6.3.1.1.5.2":     "warmStart",
+	"1.3.6.1.6.3.1.1.5.3":     "linkDown",
+	"1.3.6.1.6.3.1.1.5.4":     "linkUp",
+	"1.3.6.1.6.3.1.1.5.5":     "authenticationFailure",
+	"1.3.6.1.6.3.15.1.1":      "usmStats",
+	"1.3.6.1.6.3.15.1.1.2":    "usmStatsNotInTimeWindows",
+	"1.3.6.1.6.3.15.1.1.4":    "usmStatsUnknownEngineIDs",
+	"1.3.6.1.6.3.10.2.1.1":    "snmpEngineID",
+	"1.3.6.1.6.3.10.2.1.2":    "snmpEngineBoots",
+	"1.3.6.1.6.3.10.2.1.3":    "snmpEngineTime",
+}
+
+// oidMacros 定义 OID 的 SMI 宏，宏前面的标识符为对象名称
+var oidMacros = map[string]bool{
+	"OBJECT-TYPE":        true,
+	"MODULE-IDENTITY":    true,
+	"OBJECT-IDENTITY":    true,
+	"NOTIFICATION-TYPE":  true,
+	"OBJECT-GROUP":       true,
+	"NOTIFICATION-GROUP": true,
+	"MODULE-COMPLIANCE":  true,
+	"AGENT-CAPABILITIES": true,
+}
+
+// MIB OID 与符号名称的对照表，内置根节点和常用对象，可以加载 SMIv1/SMIv2 MIB 文件补充。
+// 只解析 OID 赋值（OBJECT IDENTIFIER、OBJECT-TYPE、NOTIFICATION-TYPE 等），不解析类型和约束
+type MIB struct {
+	mu sync.RWMutex
+	// names OID -> 名称
+	names map[string]string
+	// oids 名称 -> OID
+	oids map[string]string
+	// pending 父节点尚未定义的对象，加载其他 MIB 文件后再解析
+	pending []mibObject
+}
+
+// mibObject MIB 文件中的 OID 赋值：name ::= { parent 1 2 }
+type mibObject struct {
+	name   string
+	parent string
+	arcs   []string
+	// names 中间节点的名称，eg. { iso org(3) dod(6) }，与 arcs 一一对应，可以为空
+	names []string
+}
+
+// NewMIB 创建只包含内置名称的 MIB
+func NewMIB() *MIB {
+	m := &MIB{names: make(map[string]string), oids: make(map[string]string)}
+	for oid, name := range builtinNames {
+		m.add(name, oid)
+	}
+	return m
+}
+
+// LoadMIB 创建 MIB 并加载 MIB 文件或者目录下的所有文件
+func LoadMIB(paths ...string) (*MIB, error) {
+	m := NewMIB()
+	if err := m.LoadFiles(paths...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadFiles 加载 MIB 文件，目录加载其中的所有文件（不递归）
+func (m *MIB) LoadFiles(paths ...string) error {
+	for _, path := range paths {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		files := []string{path}
+		if info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			files = files[:0]
+			for _, entry := range entries {
+				if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+		for _, file := range files {
+			if err := m.loadFile(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MIB) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := m.Load(f); err != nil {
+		return fmt.Errorf("snmp: load mib %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load 加载 MIB 模块，父节点在其他模块中定义的对象等加载对应模块后再解析
+func (m *MIB) Load(r io.Reader) error {
+	tokens, err := tokenizeMIB(r)
+	if err != nil {
+		return err
+	}
+	objects, err := parseMIB(tokens)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = append(m.pending, objects...)
+	m.resolve()
+	return nil
+}
+
+// Unresolved 父节点尚未定义的对象名称
+func (m *MIB) Unresolved() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.pending))
+	for _, o := range m.pending {
+		names = append(names, o.name)
+	}
+	return names
+}
+
+// Name OID 的符号名称，按最长前缀匹配，剩余部分作为实例后缀，eg. 1.3.6.1.2.1.2.2.1.2.3 -> ifDescr.3
+// 没有匹配的名称返回原 OID
+func (m *MIB) Name(oid string) string {
+	oid = strings.TrimPrefix(strings.TrimSpace(oid), ".")
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for prefix := oid; prefix != ""; {
+		if name, ok := m.names[prefix]; ok {
+			return name + oid[len(prefix):]
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return oid
+}
+
+// OID 符号名称对应的数字 OID，支持实例后缀和模块前缀，eg. IF-MIB::ifDescr.3 -> 1.3.6.1.2.1.2.2.1.2.3
+// 数字 OID 校验后原样返回
+func (m *MIB) OID(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if i := strings.Index(name, "::"); i >= 0 {
+		name = name[i+2:]
+	}
+	if oid, err := NormalizeOID(name); err == nil {
+		return oid, nil
+	}
+	base, suffix := name, ""
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		base, suffix = name[:i], name[i:]
+	}
+	m.mu.RLock()
+	oid, ok := m.oids[base]
+	m.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("snmp: unknown mib object %s", base)
+	}
+	return NormalizeOID(oid + suffix)
+}
+
+// add 添加名称，同一个 OID 保留先定义的名称
+func (m *MIB) add(name, oid string) {
+	if _, ok := m.names[oid]; !ok {
+		m.names[oid] = name
+	}
+	m.oids[name] = oid
+}
+
+// resolve 解析父节点已经定义的对象，直到没有可以解析的对象
+func (m *MIB) resolve() {
+	for {
+		progress := false
+		pending := m.pending[:0]
+		for _, o := range m.pending {
+			root, ok := m.oids[o.parent]
+			if !ok {
+				if _, err := strconv.ParseUint(o.parent, 10, 32); err != nil {
+					pending = append(pending, o)
+					continue
+				}
+				root = o.parent
+			}
+			oid := root
+			for i, arc := range o.arcs {
+				oid += "." + arc
+				if o.names[i] != "" {
+					m.add(o.names[i], oid)
+				}
+			}
+			m.add(o.name, oid)
+			progress = true
+		}
+		m.pending = pending
+		if !progress {
+			return
+		}
+	}
+}
+
+// tokenizeMIB 把 MIB 文件拆分成标识符、数字和符号，去掉注释和字符串
+func tokenizeMIB(r io.Reader) ([]string, error) {
+	var tokens []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	inString := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		for i := 0; i < len(line); {
+			c := line[i]
+			switch {
+			case inString:
+				if c == '"' {
+					inString = false
+				}
+				i++
+			case c == '"':
+				inString = true
+				i++
+			case c == '-' && i+1 < len(line) && line[i+1] == '-':
+				// 注释到行尾或者下一个 --
+				if end := strings.Index(line[i+2:], "--"); end >= 0 {
+					i += end + 4
+				} else {
+					i = len(line)
+				}
+			case c == ':' && strings.HasPrefix(line[i:], "::="):
+				tokens = append(tokens, "::=")
+				i += 3
+			case isMIBIdent(rune(c)):
+				j := i
+				for j < len(line) && (isMIBIdent(rune(line[j])) || line[j] == '-' && !strings.HasPrefix(line[j:], "--")) {
+					j++
+				}
+				tokens = append(tokens, line[i:j])
+				i = j
+			case unicode.IsSpace(rune(c)):
+				i++
+			default:
+				tokens = append(tokens, string(c))
+				i++
+			}
+		}
+	}
+	return tokens, scanner.Err()
+}
+
+func isMIBIdent(c rune) bool {
+	return c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_')
+}
+
+// isValueName 是否是值引用（小写字母开头的标识符）
+func isValueName(token string) bool {
+	return token != "" && token[0] >= 'a' && token[0] <= 'z'
+}
+
+// parseMIB 提取 OID 赋值
+func parseMIB(tokens []string) ([]mibObject, error) {
+	var objects []mibObject
+	name := ""
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case oidMacros[token] && i > 0 && isValueName(tokens[i-1]):
+			name = tokens[i-1]
+		case token == "IDENTIFIER" && i > 1 && tokens[i-1] == "OBJECT" && isValueName(tokens[i-2]):
+			name = tokens[i-2]
+		case token == "::=":
+			if name == "" || i+1 >= len(tokens) || tokens[i+1] != "{" {
+				name = ""
+				continue
+			}
+			end := i + 2
+			for end < len(tokens) && tokens[end] != "}" {
+				end++
+			}
+			if end >= len(tokens) {
+				return nil, fmt.Errorf("snmp: unterminated oid value of %s", name)
+			}
+			o, err := parseOIDValue(name, tokens[i+2:end])
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, o)
+			name = ""
+			i = end
+		}
+	}
+	return objects, nil
+}
+
+// parseOIDValue 解析 { parent 1 name(2) } 形式的 OID 值，父节点可以是名称或者数字
+func parseOIDValue(name string, tokens []string) (mibObject, error) {
+	o := mibObject{name: name}
+	for i := 0; i < len(tokens); i++ {
+		token, label := tokens[i], ""
+		if i+3 < len(tokens) && tokens[i+1] == "(" && tokens[i+3] == ")" {
+			label, token = token, tokens[i+2]
+			i += 3
+		}
+		if o.parent == "" {
+			o.parent = token
+			continue
+		}
+		if _, err := strconv.ParseUint(token, 10, 32); err != nil {
+			return o, fmt.Errorf("snmp: invalid oid value of %s", name)
+		}
+		o.arcs = append(o.arcs, token)
+		o.names = append(o.names, label)
+	}
+	if o.parent == "" {
+		return o, fmt.Errorf("snmp: empty oid value of %s", name)
+	}
+	return o, nil
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testUpsMIB UPS-MIB 片段
+const testUpsMIB = `
+UPS-MIB DEFINITIONS ::= BEGIN
+
+IMPORTS
+    MODULE-IDENTITY, OBJECT-TYPE, NOTIFICATION-TYPE,
+    OBJECT-IDENTITY, Counter32, Gauge32, Integer32, mib-2
+        FROM SNMPv2-SMI
+    DisplayString, TimeStamp, TimeInterval, TestAndIncr,
+      AutonomousType, TEXTUAL-CONVENTION
+        FROM SNMPv2-TC;
+
+upsMIB MODULE-IDENTITY
+    LAST-UPDATED "9402230000Z"
+    ORGANIZATION "IETF UPS MIB Working Group"
+    CONTACT-INFO
+            "        Jeffrey D. Case
+                     -- not a comment ::= { fake 1 }
+            "
+    DESCRIPTION
+            "The MIB module to describe Uninterruptible Power
+            Supplies."
+    ::= { mib-2 33 }
+
+PositiveInteger ::= TEXTUAL-CONVENTION
+    DISPLAY-HINT "d"
+    STATUS       current
+    DESCRIPTION  "positive integer"
+    SYNTAX       INTEGER (1..2147483647)
+
+upsObjects          OBJECT IDENTIFIER ::= { upsMIB 1 }
+
+-- upsBattery ::= { upsObjects 99 }
+upsBattery          OBJECT IDENTIFIER ::= { upsObjects 2 } -- battery group
+
+upsBatteryStatus OBJECT-TYPE
+    SYNTAX     INTEGER {
+                   unknown(1),
+                   batteryNormal(2),
+                   batteryLow(3),
+                   batteryDepleted(4)
+               }
+    MAX-ACCESS read-only
+    STATUS     current
+    DESCRIPTION
+            "The indication of the capacity remaining in the UPS
+            system's batteries."
+    ::= { upsBattery 1 }
+
+upsEstimatedChargeRemaining OBJECT-TYPE
+    SYNTAX     INTEGER (0..100)
+    UNITS      "percent"
+    MAX-ACCESS read-only
+    STATUS     current
+    DESCRIPTION
+            "An estimate of the battery charge remaining."
+    ::= { upsBattery 4 }
+
+upsTraps OBJECT IDENTIFIER ::= { upsMIB 2 }
+
+upsTrapOnBattery NOTIFICATION-TYPE
+    OBJECTS { upsEstimatedMinutesRemaining, upsSecondsOnBattery }
+    STATUS  current
+    DESCRIPTION
+            "The UPS is operating on battery power."
+    ::= { upsTraps 1 }
+
+upsWellKnownTests   OBJECT IDENTIFIER ::= { upsTest 7 }
+upsTest             OBJECT IDENTIFIER ::= { upsObjects 7 }
+upsTestNoTestsInitiated OBJECT-IDENTITY
+    STATUS  current
+    DESCRIPTION "No tests have been initiated."
+    ::= { upsWellKnownTests 1 }
+
+END
+`
+
+// testVendorMIB 父节点在其他 MIB 中定义
+const testVendorMIB = `
+ACME-MIB DEFINITIONS ::= BEGIN
+IMPORTS enterprises FROM SNMPv2-SMI upsObjects FROM UPS-MIB;
+acme OBJECT IDENTIFIER ::= { iso org(3) dod(6) internet(1) private(4) enterprises(1) 99999 }
+acmeUps OBJECT IDENTIFIER ::= { upsObjects 100 }
+END
+`
+
+func TestMIB(t *testing.T) {
+	mib := NewMIB()
+	assert.Equal(t, "sysUpTime.0", mib.Name("1.3.6.1.2.1.1.3.0"))
+	assert.Equal(t, "ifDescr.3", mib.Name(".1.3.6.1.2.1.2.2.1.2.3"))
+	assert.Equal(t, "enterprises.9.1.1", mib.Name("1.3.6.1.4.1.9.1.1"))
+	assert.Equal(t, "linkDown", mib.Name("1.3.6.1.6.3.1.1.5.3"))
+	assert.Equal(t, "mib-2.33.1.2.4.0", mib.Name("1.3.6.1.2.1.33.1.2.4.0"))
+	assert.Equal(t, "5.1", mib.Name("5.1"))
+	oid, err := mib.OID("SNMPv2-MIB::sysName.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.6.1.2.1.1.5.0", oid)
+	oid, err = mib.OID(".1.3.6.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.6.1", oid)
+	_, err = mib.OID("upsBatteryStatus.0")
+	assert.NotNil(t, err)
+	_, err = mib.OID("sysName.x")
+	assert.NotNil(t, err)
+
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "ACME-MIB.txt"), []byte(testVendorMIB), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "UPS-MIB"), []byte(testUpsMIB), 0644))
+	mib, err = LoadMIB(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "upsEstimatedChargeRemaining.0", mib.Name("1.3.6.1.2.1.33.1.2.4.0"))
+	assert.Equal(t, "upsBatteryStatus.0", mib.Name("1.3.6.1.2.1.33.1.2.1.0"))
+	assert.Equal(t, "upsBattery.99", mib.Name("1.3.6.1.2.1.33.1.2.99"))
+	assert.Equal(t, "upsTrapOnBattery", mib.Name("1.3.6.1.2.1.33.2.1"))
+	assert.Equal(t, "upsTestNoTestsInitiated", mib.Name("1.3.6.1.2.1.33.1.7.7.1"))
+	assert.Equal(t, "acme.1", mib.Name("1.3.6.1.4.1.99999.1"))
+	assert.Equal(t, "acmeUps", mib.Name("1.3.6.1.2.1.33.1.100"))
+	oid, err = mib.OID("upsEstimatedChargeRemaining.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.6.1.2.1.33.1.2.4.0", oid)
+	_, err = mib.OID("fake")
+	assert.NotNil(t, err)
+	_, err = mib.OID("PositiveInteger")
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(mib.Unresolved()))
+
+	mib = NewMIB()
+	assert.Nil(t, mib.Load(strings.NewReader(testVendorMIB)))
+	assert.Equal(t, []string{"acmeUps"}, mib.Unresolved())
+	assert.Nil(t, mib.Load(strings.NewReader(testUpsMIB)))
+	assert.Equal(t, 0, len(mib.Unresolved()))
+	assert.Equal(t, "acmeUps.1", mib.Name("1.3.6.1.2.1.33.1.100.1"))
+
+	assert.NotNil(t, mib.Load(strings.NewReader("bad OBJECT IDENTIFIER ::= { mib-2 ")))
+	assert.NotNil(t, mib.Load(strings.NewReader("bad OBJECT IDENTIFIER ::= { mib-2 x }")))
+	_, err = LoadMIB(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,522 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Version SNMP 版本
+type Version int
+
+const (
+	Version1  Version = 0
+	Version2c Version = 1
+	Version3  Version = 3
+)
+
+func (v Version) String() string {
+	switch v {
+	case Version1:
+		return "1"
+	case Version2c:
+		return "2c"
+	case Version3:
+		return "3"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(v))
+	}
+}
+
+// ParseVersion 解析版本：1、2c、3，允许 v 前缀
+func ParseVersion(version string) (Version, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v") {
+	case "1":
+		return Version1, nil
+	case "2c", "2", "":
+		return Version2c, nil
+	case "3":
+		return Version3, nil
+	default:
+		return 0, fmt.Errorf("snmp: unsupported version %s", version)
+	}
+}
+
+// PDUType PDU 类型
+type PDUType byte
+
+const (
+	GetRequest     PDUType = 0xa0
+	GetNextRequest PDUType = 0xa1
+	GetResponse    PDUType = 0xa2
+	SetRequest     PDUType = 0xa3
+	// Trap SNMPv1 Trap
+	Trap           PDUType = 0xa4
+	GetBulkRequest PDUType = 0xa5
+	InformRequest  PDUType = 0xa6
+	SNMPv2Trap     PDUType = 0xa7
+	Report         PDUType = 0xa8
+)
+
+var pduNames = map[PDUType]string{
+	GetRequest: "GetRequest", GetNextRequest: "GetNextRequest", GetResponse: "GetResponse",
+	SetRequest: "SetRequest", Trap: "Trap", GetBulkRequest: "GetBulkRequest",
+	InformRequest: "InformRequest", SNMPv2Trap: "SNMPv2Trap", Report: "Report",
+}
+
+func (t PDUType) String() string {
+	if name, ok := pduNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", byte(t))
+}
+
+// 错误状态
+var errorStatusNames = []string{
+	"noError", "tooBig", "noSuchName", "badValue", "readOnly", "genErr", "noAccess", "wrongType",
+	"wrongLength", "wrongEncoding", "wrongValue", "noCreation", "inconsistentValue",
+	"resourceUnavailable", "commitFailed", "undoFailed", "authorizationError", "notWritable", "inconsistentName",
+}
+
+// ErrorStatusName 错误状态名称
+func ErrorStatusName(status int) string {
+	if status >= 0 && status < len(errorStatusNames) {
+		return errorStatusNames[status]
+	}
+	return fmt.Sprintf("error(%d)", status)
+}
+
+// MsgFlags SNMPv3 报文标志
+type MsgFlags byte
+
+const (
+	AuthFlag       MsgFlags = 0x01
+	PrivFlag       MsgFlags = 0x02
+	ReportableFlag MsgFlags = 0x04
+)
+
+// securityModelUSM 基于用户的安全模型
+const securityModelUSM = 3
+
+// DefaultMaxSize 默认的最大报文长度
+const DefaultMaxSize = 65507
+
+// PDU 协议数据单元
+type PDU struct {
+	Type      PDUType
+	RequestID int32
+	// ErrorStatus 错误状态，0 表示成功
+	ErrorStatus int
+	// ErrorIndex 出错的变量序号，从 1 开始
+	ErrorIndex int
+	// NonRepeaters GetBulk 只获取一次的变量数量
+	NonRepeaters int
+	// MaxRepetitions GetBulk 其余变量的最大获取次数
+	MaxRepetitions int
+	Variables      []Variable
+	// 以下字段只用于 SNMPv1 Trap
+	Enterprise   string
+	AgentAddress string
+	GenericTrap  int
+	SpecificTrap int
+	Timestamp    uint32
+}
+
+// SecurityParameters USM 安全参数
+type SecurityParameters struct {
+	AuthoritativeEngineID    []byte
+	AuthoritativeEngineBoots int32
+	AuthoritativeEngineTime  int32
+	UserName                 string
+	AuthenticationParameters []byte
+	PrivacyParameters        []byte
+}
+
+// Packet SNMP 报文
+type Packet struct {
+	Version Version
+	// Community v1/v2c 团体名
+	Community string
+	// 以下字段只用于 v3
+	MsgID           int32
+	MaxSize         int32
+	Flags           MsgFlags
+	Security        SecurityParameters
+	ContextEngineID []byte
+	ContextName     string
+	PDU             PDU
+	// encrypted 解码时加密的 scopedPDU，Decrypt 后清空
+	encrypted []byte
+	// authOffset 解码时认证参数在原始报文中的偏移
+	authOffset int
+}
+
+// Encrypted 是否还有未解密的 scopedPDU
+func (p *Packet) Encrypted() bool {
+	return p.encrypted != nil
+}
+
+// Marshal 编码报文，v3 报文按 Flags 使用 keys 加密和认证
+func (p *Packet) Marshal(keys *Keys) ([]byte, error) {
+	pdu, err := p.PDU.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if p.Version != Version3 {
+		return tlv(byte(Sequence), encodeIntegerTLV(int64(p.Version)), tlv(byte(OctetString), []byte(p.Community)), pdu), nil
+	}
+	msgData := tlv(byte(Sequence), tlv(byte(OctetString), p.ContextEngineID), tlv(byte(OctetString), []byte(p.ContextName)), pdu)
+	security := p.Security
+	if p.Flags&PrivFlag != 0 {
+		if p.Flags&AuthFlag == 0 {
+			return nil, errors.New("snmp: privacy requires authentication")
+		}
+		if keys == nil || keys.privKey == nil {
+			return nil, errors.New("snmp: no privacy key")
+		}
+		encrypted, salt, err := keys.encrypt(msgData, security.AuthoritativeEngineBoots, security.AuthoritativeEngineTime)
+		if err != nil {
+			return nil, err
+		}
+		security.PrivacyParameters = salt
+		msgData = tlv(byte(OctetString), encrypted)
+	}
+	if p.Flags&AuthFlag == 0 {
+		return p.marshalV3(security, msgData), nil
+	}
+	if keys == nil || keys.authKey == nil {
+		return nil, errors.New("snmp: no authentication key")
+	}
+	// 先用全 0 的认证参数计算摘要，长度不变所以再次编码的结构相同
+	security.AuthenticationParameters = make([]byte, keys.Auth.macLen())
+	security.AuthenticationParameters = keys.mac(p.marshalV3(security, msgData))
+	return p.marshalV3(security, msgData), nil
+}
+
+func (p *Packet) marshalV3(security SecurityParameters, msgData []byte) []byte {
+	maxSize := p.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+	header := tlv(byte(Sequence), encodeIntegerTLV(int64(p.MsgID)), encodeIntegerTLV(int64(maxSize)),
+		tlv(byte(OctetString), []byte{byte(p.Flags)}), encodeIntegerTLV(securityModelUSM))
+	usm := tlv(byte(Sequence),
+		tlv(byte(OctetString), security.AuthoritativeEngineID),
+		encodeIntegerTLV(int64(security.AuthoritativeEngineBoots)),
+		encodeIntegerTLV(int64(security.AuthoritativeEngineTime)),
+		tlv(byte(OctetString), []byte(security.UserName)),
+		tlv(byte(OctetString), security.AuthenticationParameters),
+		tlv(byte(OctetString), security.PrivacyParameters))
+	return tlv(byte(Sequence), encodeIntegerTLV(int64(Version3)), header, tlv(byte(OctetString), usm), msgData)
+}
+
+func encodeIntegerTLV(v int64) []byte {
+	return tlv(byte(Integer), encodeInteger(v))
+}
+
+func (p *PDU) marshal() ([]byte, error) {
+	var bindings []byte
+	for _, v := range p.Variables {
+		oid, err := encodeOID(v.OID)
+		if err != nil {
+			return nil, err
+		}
+		value, err := encodeValue(v.Type, v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.OID, err)
+		}
+		bindings = append(bindings, tlv(byte(Sequence), tlv(byte(ObjectIdentifier), oid), value)...)
+	}
+	list := tlv(byte(Sequence), bindings)
+	switch p.Type {
+	case Trap:
+		enterprise, err := encodeOID(p.Enterprise)
+		if err != nil {
+			return nil, err
+		}
+		agent := net.ParseIP(p.AgentAddress).To4()
+		if agent == nil {
+			agent = net.IPv4zero.To4()
+		}
+		return tlv(byte(p.Type), tlv(byte(ObjectIdentifier), enterprise), tlv(byte(IPAddress), agent),
+			encodeIntegerTLV(int64(p.GenericTrap)), encodeIntegerTLV(int64(p.SpecificTrap)),
+			tlv(byte(TimeTicks), encodeUnsigned(uint64(p.Timestamp))), list), nil
+	case GetBulkRequest:
+		return tlv(byte(p.Type), encodeIntegerTLV(int64(p.RequestID)), encodeIntegerTLV(int64(p.NonRepeaters)),
+			encodeIntegerTLV(int64(p.MaxRepetitions)), list), nil
+	default:
+		return tlv(byte(p.Type), encodeIntegerTLV(int64(p.RequestID)), encodeIntegerTLV(int64(p.ErrorStatus)),
+			encodeIntegerTLV(int64(p.ErrorIndex)), list), nil
+	}
+}
+
+// Unmarshal 解码报文，v3 加密的 scopedPDU 需要调用 Decrypt 解密
+func Unmarshal(data []byte) (*Packet, error) {
+	d := decoder{data: data}
+	body, err := d.expect(Sequence)
+	if err != nil {
+		return nil, err
+	}
+	d = decoder{data: body}
+	version, err := d.integer()
+	if err != nil {
+		return nil, err
+	}
+	p := &Packet{Version: Version(version)}
+	switch p.Version {
+	case Version1, Version2c:
+		community, err := d.expect(OctetString)
+		if err != nil {
+			return nil, err
+		}
+		p.Community = string(community)
+		if err = p.PDU.unmarshal(&d); err != nil {
+			return nil, err
+		}
+	case Version3:
+		if err = p.unmarshalV3(data, &d); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("snmp: unsupported version %d", version)
+	}
+	return p, nil
+}
+
+func (p *Packet) unmarshalV3(data []byte, d *decoder) error {
+	header, err := d.expect(Sequence)
+	if err != nil {
+		return err
+	}
+	h := decoder{data: header}
+	msgID, err := h.integer()
+	if err != nil {
+		return err
+	}
+	maxSize, err := h.integer()
+	if err != nil {
+		return err
+	}
+	flags, err := h.expect(OctetString)
+	if err != nil || len(flags) != 1 {
+		return errMalformed
+	}
+	model, err := h.integer()
+	if err != nil {
+		return err
+	}
+	if model != securityModelUSM {
+		return fmt.Errorf("snmp: unsupported security model %d", model)
+	}
+	p.MsgID, p.MaxSize, p.Flags = int32(msgID), int32(maxSize), MsgFlags(flags[0])
+
+	usm, err := d.expect(OctetString)
+	if err != nil {
+		return err
+	}
+	u := decoder{data: usm}
+	if usm, err = u.expect(Sequence); err != nil {
+		return err
+	}
+	u = decoder{data: usm}
+	s := &p.Security
+	if s.AuthoritativeEngineID, err = u.expect(OctetString); err != nil {
+		return err
+	}
+	boots, err := u.integer()
+	if err != nil {
+		return err
+	}
+	engineTime, err := u.integer()
+	if err != nil {
+		return err
+	}
+	s.AuthoritativeEngineBoots, s.AuthoritativeEngineTime = int32(boots), int32(engineTime)
+	userName, err := u.expect(OctetString)
+	if err != nil {
+		return err
+	}
+	s.UserName = string(userName)
+	if s.AuthenticationParameters, err = u.expect(OctetString); err != nil {
+		return err
+	}
+	// 子切片和原始报文共享底层数组，按容量差计算偏移，用于验证摘要
+	p.authOffset = cap(data) - cap(s.AuthenticationParameters)
+	if s.PrivacyParameters, err = u.expect(OctetString); err != nil {
+		return err
+	}
+	s.AuthoritativeEngineID = append([]byte{}, s.AuthoritativeEngineID...)
+	s.AuthenticationParameters = append([]byte{}, s.AuthenticationParameters...)
+	s.PrivacyParameters = append([]byte{}, s.PrivacyParameters...)
+
+	if p.Flags&PrivFlag != 0 {
+		encrypted, err := d.expect(OctetString)
+		if err != nil {
+			return err
+		}
+		p.encrypted = append([]byte{}, encrypted...)
+		return nil
+	}
+	return p.unmarshalScoped(d)
+}
+
+func (p *Packet) unmarshalScoped(d *decoder) error {
+	scoped, err := d.expect(Sequence)
+	if err != nil {
+		return err
+	}
+	s := decoder{data: scoped}
+	contextEngineID, err := s.expect(OctetString)
+	if err != nil {
+		return err
+	}
+	contextName, err := s.expect(OctetString)
+	if err != nil {
+		return err
+	}
+	p.ContextEngineID, p.ContextName = append([]byte{}, contextEngineID...), string(contextName)
+	return p.PDU.unmarshal(&s)
+}
+
+// Authenticate 验证 v3 报文的摘要，data 为解码前的原始报文
+func (p *Packet) Authenticate(data []byte, keys *Keys) error {
+	if keys == nil || keys.authKey == nil {
+		return errors.New("snmp: no authentication key")
+	}
+	mac := p.Security.AuthenticationParameters
+	if len(mac) != keys.Auth.macLen() || p.authOffset <= 0 || p.authOffset+len(mac) > len(data) {
+		return ErrWrongDigest
+	}
+	raw := append([]byte{}, data...)
+	for i := range mac {
+		raw[p.authOffset+i] = 0
+	}
+	if !hmac.Equal(keys.mac(raw), mac) {
+		return ErrWrongDigest
+	}
+	return nil
+}
+
+// Decrypt 解密 v3 报文的 scopedPDU
+func (p *Packet) Decrypt(keys *Keys) error {
+	if p.encrypted == nil {
+		return nil
+	}
+	if keys == nil || keys.privKey == nil {
+		return errors.New("snmp: no privacy key")
+	}
+	plaintext, err := keys.decrypt(p.encrypted, p.Security)
+	if err != nil {
+		return err
+	}
+	// DES 解密后有填充，只解析第一个 TLV
+	if err = p.unmarshalScoped(&decoder{data: plaintext}); err != nil {
+		return ErrDecryption
+	}
+	p.encrypted = nil
+	return nil
+}
+
+func (p *PDU) unmarshal(d *decoder) error {
+	tag, content, err := d.next()
+	if err != nil {
+		return err
+	}
+	p.Type = PDUType(tag)
+	b := decoder{data: content}
+	if p.Type == Trap {
+		enterprise, err := b.expect(ObjectIdentifier)
+		if err != nil {
+			return err
+		}
+		if p.Enterprise, err = decodeOID(enterprise); err != nil {
+			return err
+		}
+		agent, err := b.expect(IPAddress)
+		if err != nil || len(agent) != 4 {
+			return errMalformed
+		}
+		p.AgentAddress = net.IP(agent).String()
+		generic, err := b.integer()
+		if err != nil {
+			return err
+		}
+		specific, err := b.integer()
+		if err != nil {
+			return err
+		}
+		timestamp, err := b.expect(TimeTicks)
+		if err != nil {
+			return err
+		}
+		ticks, err := decodeUnsigned(timestamp)
+		if err != nil {
+			return err
+		}
+		p.GenericTrap, p.SpecificTrap, p.Timestamp = int(generic), int(specific), uint32(ticks)
+	} else {
+		if tag < byte(GetRequest) || tag > byte(Report) {
+			return fmt.Errorf("snmp: unknown pdu type 0x%02x", tag)
+		}
+		var values [3]int64
+		for i := range values {
+			if values[i], err = b.integer(); err != nil {
+				return err
+			}
+		}
+		p.RequestID = int32(values[0])
+		if p.Type == GetBulkRequest {
+			p.NonRepeaters, p.MaxRepetitions = int(values[1]), int(values[2])
+		} else {
+			p.ErrorStatus, p.ErrorIndex = int(values[1]), int(values[2])
+		}
+	}
+	list, err := b.expect(Sequence)
+	if err != nil {
+		return err
+	}
+	l := decoder{data: list}
+	for !l.empty() {
+		binding, err := l.expect(Sequence)
+		if err != nil {
+			return err
+		}
+		v := decoder{data: binding}
+		oid, err := v.expect(ObjectIdentifier)
+		if err != nil {
+			return err
+		}
+		name, err := decodeOID(oid)
+		if err != nil {
+			return err
+		}
+		tag, content, err := v.next()
+		if err != nil {
+			return err
+		}
+		value, err := decodeValue(tag, content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		p.Variables = append(p.Variables, Variable{OID: name, Type: Asn1BER(tag), Value: value})
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestBER(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 255, 256, -1, -128, -129, 1 << 40, -1 << 63} {
+		decoded, err := decodeInteger(encodeInteger(v))
+		assert.Nil(t, err)
+		assert.Equal(t, v, decoded)
+	}
+	assert.Equal(t, []byte{0x00, 0x80}, encodeInteger(128))
+	assert.Equal(t, []byte{0xff, 0x7f}, encodeInteger(-129))
+	for _, v := range []uint64{0, 127, 128, 0xffffffff, 0xffffffffffffffff} {
+		decoded, err := decodeUnsigned(encodeUnsigned(v))
+		assert.Nil(t, err)
+		assert.Equal(t, v, decoded)
+	}
+	assert.Equal(t, []byte{0x00, 0xff, 0xff, 0xff, 0xff}, encodeUnsigned(0xffffffff))
+
+	b, err := encodeOID(".1.3.6.1.4.1.2680.1.2.7.3.2.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "2b060104019478010207030200", hex.EncodeToString(b))
+	oid, err := decodeOID(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.6.1.4.1.2680.1.2.7.3.2.0", oid)
+	b, _ = encodeOID("2.999.3")
+	oid, _ = decodeOID(b)
+	assert.Equal(t, "2.999.3", oid)
+	for _, invalid := range []string{"", "1", "3.1", "1.40", "1.3.x", "1.3.4294967296"} {
+		_, err = ParseOID(invalid)
+		assert.NotNil(t, err)
+	}
+	_, err = decodeOID([]byte{0x2b, 0x86})
+	assert.NotNil(t, err)
+
+	assert.True(t, CompareOID("1.3.6.1.2", "1.3.6.1.10") < 0)
+	assert.True(t, CompareOID("1.3.6.1", "1.3.6.1.0") < 0)
+	assert.Equal(t, 0, CompareOID("1.3.6", "1.3.6"))
+	assert.True(t, HasPrefixOID("1.3.6.1.2.1", "1.3.6.1"))
+	assert.False(t, HasPrefixOID("1.3.6.10", "1.3.6.1"))
+
+	assert.Equal(t, "ups1", Variable{Type: OctetString, Value: []byte("ups1")}.JSONValue())
+	assert.Equal(t, "00:1a:2b", Variable{Type: OctetString, Value: []byte{0, 0x1a, 0x2b}}.JSONValue())
+	assert.Equal(t, uint32(7), Variable{Type: Gauge32, Value: uint32(7)}.JSONValue())
+}
+
+func TestPacket(t *testing.T) {
+	p := &Packet{
+		Version:   Version2c,
+		Community: "public",
+		PDU: PDU{
+			Type:      SNMPv2Trap,
+			RequestID: -5,
+			Variables: []Variable{
+				{OID: "1.3.6.1.2.1.1.3.0", Type: TimeTicks, Value: uint32(12345)},
+				{OID: "1.3.6.1.6.3.1.1.4.1.0", Type: ObjectIdentifier, Value: "1.3.6.1.6.3.1.1.5.3"},
+				{OID: "1.3.6.1.2.1.2.2.1.1.1", Type: Integer, Value: float64(-7)},
+				{OID: "1.3.6.1.2.1.2.2.1.2.1", Type: OctetString, Value: "eth0"},
+				{OID: "1.3.6.1.2.1.4.20.1.1.1", Type: IPAddress, Value: "10.0.0.1"},
+				{OID: "1.3.6.1.2.1.2.2.1.10.1", Type: Counter32, Value: 4000000000},
+				{OID: "1.3.6.1.2.1.2.2.1.5.1", Type: Gauge32, Value: "100"},
+				{OID: "1.3.6.1.2.1.31.1.1.1.6.1", Type: Counter64, Value: uint64(1) << 63},
+				{OID: "1.3.6.1.2.1.1.9.0", Type: NoSuchObject},
+				{OID: "1.3.6.1.2.1.1.10.0", Type: Null},
+			},
+		},
+	}
+	data, err := p.Marshal(nil)
+	assert.Nil(t, err)
+	decoded, err := Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, Version2c, decoded.Version)
+	assert.Equal(t, "public", decoded.Community)
+	assert.Equal(t, SNMPv2Trap, decoded.PDU.Type)
+	assert.Equal(t, int32(-5), decoded.PDU.RequestID)
+	values := []interface{}{uint32(12345), "1.3.6.1.6.3.1.1.5.3", int64(-7), []byte("eth0"), "10.0.0.1",
+		uint32(4000000000), uint32(100), uint64(1) << 63, nil, nil}
+	for i, v := range decoded.PDU.Variables {
+		assert.Equal(t, p.PDU.Variables[i].OID, v.OID)
+		assert.Equal(t, p.PDU.Variables[i].Type, v.Type)
+		assert.Equal(t, values[i], v.Value)
+	}
+	assert.True(t, decoded.PDU.Variables[8].IsException())
+
+	_, err = (&Packet{PDU: PDU{Variables: []Variable{{OID: "1.3.6", Type: Integer, Value: "x"}}}}).Marshal(nil)
+	assert.NotNil(t, err)
+	_, err = (&Packet{PDU: PDU{Variables: []Variable{{OID: "1.3.6", Type: Counter32, Value: -1}}}}).Marshal(nil)
+	assert.NotNil(t, err)
+
+	// SNMPv1 Trap
+	p = &Packet{
+		Version:   Version1,
+		Community: "public",
+		PDU: PDU{
+			Type:         Trap,
+			Enterprise:   "1.3.6.1.4.1.318",
+			AgentAddress: "192.168.1.10",
+			GenericTrap:  6,
+			SpecificTrap: 5,
+			Timestamp:    100,
+			Variables:    []Variable{{OID: "1.3.6.1.4.1.318.2.3.3.0", Type: OctetString, Value: "on battery"}},
+		},
+	}
+	data, err = p.Marshal(nil)
+	assert.Nil(t, err)
+	decoded, err = Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, p.PDU.Enterprise, decoded.PDU.Enterprise)
+	assert.Equal(t, "192.168.1.10", decoded.PDU.AgentAddress)
+	assert.Equal(t, 6, decoded.PDU.GenericTrap)
+	assert.Equal(t, 5, decoded.PDU.SpecificTrap)
+	assert.Equal(t, uint32(100), decoded.PDU.Timestamp)
+	assert.Equal(t, 1, len(decoded.PDU.Variables))
+
+	// GetBulk
+	data, _ = (&Packet{Version: Version2c, PDU: PDU{Type: GetBulkRequest, NonRepeaters: 1, MaxRepetitions: 20,
+		Variables: []Variable{{OID: "1.3.6.1.2.1.1", Type: Null}}}}).Marshal(nil)
+	decoded, err = Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, decoded.PDU.NonRepeaters)
+	assert.Equal(t, 20, decoded.PDU.MaxRepetitions)
+
+	for i := 1; i < len(data); i++ {
+		_, err = Unmarshal(data[:i])
+		assert.NotNil(t, err)
+	}
+}
+
+func TestLocalizeKey(t *testing.T) {
+	// RFC 3414 A.3
+	engineID, _ := hex.DecodeString("000000000000000000000002")
+	for protocol, expected := range map[AuthProtocol]string{
+		MD5:    "526f5eed9fcce26f8964c2930787d82b",
+		SHA:    "6695febc9288e36282235fc7151f128497b38f3f",
+		SHA256: "8982e0e549e866db361a6b625d84cccc11162d453ee8ce3a6445c2d6776f0f8b",
+	} {
+		credentials, err := NewCredentials(User{UserName: "user", AuthProtocol: protocol, AuthPassword: "maplesyrup"})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(credentials.Localize(engineID).authKey))
+	}
+
+	for _, user := range []User{
+		{},
+		{UserName: "u", AuthProtocol: MD5, AuthPassword: "short"},
+		{UserName: "u", PrivProtocol: AES, PrivPassword: "password"},
+		{UserName: "u", AuthProtocol: "md4", AuthPassword: "password"},
+		{UserName: "u", AuthProtocol: SHA, AuthPassword: "password", PrivProtocol: AES, PrivPassword: "short"},
+	} {
+		_, err := NewCredentials(user)
+		assert.NotNil(t, err)
+	}
+	auth, err := ParseAuthProtocol("sha-256")
+	assert.Nil(t, err)
+	assert.Equal(t, SHA256, auth)
+	priv, err := ParsePrivProtocol("aes128")
+	assert.Nil(t, err)
+	assert.Equal(t, AES, priv)
+	priv, err = ParsePrivProtocol("aes-256")
+	assert.Nil(t, err)
+	assert.Equal(t, AES256, priv)
+	priv, err = ParsePrivProtocol("AES192C")
+	assert.Nil(t, err)
+	assert.Equal(t, AES192C, priv)
+	_, err = ParsePrivProtocol("3des")
+	assert.NotNil(t, err)
+
+	// Blumenthal：Kul || H(Kul)
+	credentials, _ := NewCredentials(User{UserName: "u", AuthProtocol: MD5, AuthPassword: "maplesyrup", PrivProtocol: AES256, PrivPassword: "maplesyrup"})
+	keys := credentials.Localize(engineID)
+	assert.Equal(t, 32, len(keys.privKey))
+	assert.Equal(t, "526f5eed9fcce26f8964c2930787d82b", hex.EncodeToString(keys.privKey[:16]))
+	sum := md5.Sum(keys.privKey[:16])
+	assert.Equal(t, sum[:], keys.privKey[16:])
+	// Reeder：Kul || localize(passwordToKey(Kul))
+	credentials, _ = NewCredentials(User{UserName: "u", AuthProtocol: SHA, AuthPassword: "maplesyrup", PrivProtocol: AES256C, PrivPassword: "maplesyrup"})
+	keys = credentials.Localize(engineID)
+	assert.Equal(t, 40, len(keys.privKey))
+	assert.Equal(t, "6695febc9288e36282235fc7151f128497b38f3f", hex.EncodeToString(keys.privKey[:20]))
+	assert.Equal(t, localizeKey(SHA, passwordToKey(SHA, string(keys.privKey[:20])), engineID), keys.privKey[20:])
+	// SHA256 的本地化密钥已经足够长，不需要扩展
+	credentials, _ = NewCredentials(User{UserName: "u", AuthProtocol: SHA256, AuthPassword: "maplesyrup", PrivProtocol: AES256, PrivPassword: "maplesyrup"})
+	assert.Equal(t, 32, len(credentials.Localize(engineID).privKey))
+}
+
+func TestSecurePacket(t *testing.T) {
+	engineID := NewEngineID()
+	for _, user := range []User{
+		{UserName: "md5des", AuthProtocol: MD5, AuthPassword: "authpass", PrivProtocol: DES, PrivPassword: "privpass"},
+		{UserName: "shaaes", AuthProtocol: SHA, AuthPassword: "authpass", PrivProtocol: AES, PrivPassword: "privpass"},
+		{UserName: "sha512", AuthProtocol: SHA512, AuthPassword: "authpass"},
+		{UserName: "sha256aes256", AuthProtocol: SHA256, AuthPassword: "authpass", PrivProtocol: AES256, PrivPassword: "privpass"},
+		{UserName: "md5aes192", AuthProtocol: MD5, AuthPassword: "authpass", PrivProtocol: AES192, PrivPassword: "privpass"},
+		{UserName: "shaaes256c", AuthProtocol: SHA, AuthPassword: "authpass", PrivProtocol: AES256C, PrivPassword: "privpass"},
+	} {
+		credentials, err := NewCredentials(user)
+		assert.Nil(t, err)
+		keys := credentials.Localize(engineID)
+		p := &Packet{
+			Version: Version3,
+			MsgID:   42,
+			Flags:   user.Flags() | ReportableFlag,
+			Security: SecurityParameters{
+				AuthoritativeEngineID:    engineID,
+				AuthoritativeEngineBoots: 3,
+				AuthoritativeEngineTime:  1000,
+				UserName:                 user.UserName,
+			},
+			ContextEngineID: engineID,
+			ContextName:     "ctx",
+			PDU:             PDU{Type: GetRequest, RequestID: 7, Variables: []Variable{{OID: "1.3.6.1.2.1.1.1.0", Type: Null}}},
+		}
+		data, err := p.Marshal(keys)
+		assert.Nil(t, err)
+		decoded, err := Unmarshal(data)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(42), decoded.MsgID)
+		assert.Equal(t, user.UserName, decoded.Security.UserName)
+		assert.Equal(t, user.PrivProtocol != NoPriv, decoded.Encrypted())
+		assert.Nil(t, decoded.Authenticate(data, keys))
+		assert.Nil(t, decoded.Decrypt(keys))
+		assert.Equal(t, "ctx", decoded.ContextName)
+		assert.Equal(t, int32(7), decoded.PDU.RequestID)
+		assert.Equal(t, "1.3.6.1.2.1.1.1.0", decoded.PDU.Variables[0].OID)
+
+		// 篡改报文
+		data[len(data)-1] ^= 1
+		decoded, err = Unmarshal(data)
+		if err == nil {
+			assert.True(t, errors.Is(decoded.Authenticate(data, keys), ErrWrongDigest))
+		}
+		// 其他引擎的密钥
+		decoded, _ = Unmarshal(data)
+		if decoded != nil {
+			assert.NotNil(t, decoded.Authenticate(data, credentials.Localize(NewEngineID())))
+		}
+	}
+
+	_, err := (&Packet{Version: Version3, Flags: AuthFlag}).Marshal(nil)
+	assert.NotNil(t, err)
+	_, err = (&Packet{Version: Version3, Flags: PrivFlag}).Marshal(nil)
+	assert.NotNil(t, err)
+}
+
+func TestEngine(t *testing.T) {
+	user := User{UserName: "admin", AuthProtocol: SHA, AuthPassword: "authpass", PrivProtocol: AES, PrivPassword: "privpass"}
+	engine, err := NewEngine(NewEngineID(), 1, []User{user, {UserName: "guest"}})
+	assert.Nil(t, err)
+	_, err = NewEngine(NewEngineID(), 1, []User{{UserName: "guest"}, {UserName: "guest"}})
+	assert.NotNil(t, err)
+
+	// 引擎发现
+	discovery := &Packet{Version: Version3, MsgID: 1, Flags: ReportableFlag, PDU: PDU{Type: GetRequest, RequestID: 1}}
+	data, _ := discovery.Marshal(nil)
+	p, _ := Unmarshal(data)
+	_, err = engine.Open(p, data, true)
+	var report *ReportError
+	assert.True(t, errors.As(err, &report))
+	assert.Equal(t, UnknownEngineIDsOID, report.OID)
+	data, err = engine.Report(p, report, nil)
+	assert.Nil(t, err)
+	reply, err := Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, Report, reply.PDU.Type)
+	assert.Equal(t, engine.ID, reply.Security.AuthoritativeEngineID)
+	assert.Equal(t, UnknownEngineIDsOID, reply.PDU.Variables[0].OID)
+	assert.Equal(t, uint32(1), reply.PDU.Variables[0].Value)
+
+	credentials, _ := NewCredentials(user)
+	keys := credentials.Localize(engine.ID)
+	open := func(name string, flags MsgFlags, engineTime int32, keys *Keys) error {
+		p := &Packet{
+			Version: Version3,
+			MsgID:   2,
+			Flags:   flags | ReportableFlag,
+			Security: SecurityParameters{
+				AuthoritativeEngineID:    engine.ID,
+				AuthoritativeEngineBoots: 1,
+				AuthoritativeEngineTime:  engineTime,
+				UserName:                 name,
+			},
+			PDU: PDU{Type: InformRequest, RequestID: 2},
+		}
+		data, err := p.Marshal(keys)
+		assert.Nil(t, err)
+		p, _ = Unmarshal(data)
+		_, err = engine.Open(p, data, true)
+		return err
+	}
+	assert.Nil(t, open("admin", AuthFlag|PrivFlag, 0, keys))
+	assert.Nil(t, open("guest", 0, 0, nil))
+	assert.Equal(t, &ReportError{OID: UnknownUserNamesOID}, open("nobody", 0, 0, nil))
+	assert.Equal(t, &ReportError{OID: UnsupportedSecLevelsOID}, open("admin", AuthFlag, 0, keys))
+	assert.Equal(t, &ReportError{OID: NotInTimeWindowsOID}, open("admin", AuthFlag|PrivFlag, 1000, keys))
+	wrong, _ := NewCredentials(User{UserName: "admin", AuthProtocol: SHA, AuthPassword: "wrongpass", PrivProtocol: AES, PrivPassword: "privpass"})
+	assert.Equal(t, &ReportError{OID: WrongDigestsOID}, open("admin", AuthFlag|PrivFlag, 0, wrong.Localize(engine.ID)))
+
+	// trap：权威引擎是发送方，认证失败的报文不缓存密钥，旧的 boots 和时间视为重放
+	sender := NewEngineID()
+	senderKeys := credentials.Localize(sender)
+	trap := func(boots, engineTime int32, keys *Keys) error {
+		p := &Packet{
+			Version: Version3,
+			MsgID:   3,
+			Flags:   AuthFlag | PrivFlag,
+			Security: SecurityParameters{
+				AuthoritativeEngineID:    sender,
+				AuthoritativeEngineBoots: boots,
+				AuthoritativeEngineTime:  engineTime,
+				UserName:                 "admin",
+			},
+			PDU: PDU{Type: SNMPv2Trap, RequestID: 3},
+		}
+		data, err := p.Marshal(keys)
+		assert.Nil(t, err)
+		p, _ = Unmarshal(data)
+		_, err = engine.Open(p, data, false)
+		return err
+	}
+	cached := len(engine.keys)
+	assert.Equal(t, &ReportError{OID: WrongDigestsOID}, trap(5, 1000, wrong.Localize(sender)))
+	assert.Equal(t, cached, len(engine.keys))
+	assert.Nil(t, trap(5, 1000, senderKeys))
+	assert.Equal(t, cached+1, len(engine.keys))
+	assert.Nil(t, trap(5, 900, senderKeys))
+	assert.Equal(t, &ReportError{OID: NotInTimeWindowsOID}, trap(5, 800, senderKeys))
+	assert.Equal(t, &ReportError{OID: NotInTimeWindowsOID}, trap(4, 2000, senderKeys))
+	assert.Nil(t, trap(6, 10, senderKeys))
+	assert.Equal(t, &ReportError{OID: NotInTimeWindowsOID}, trap(5, 1000, senderKeys))
+	assert.Equal(t, &ReportError{OID: NotInTimeWindowsOID}, trap(maxBoots, 0, senderKeys))
+}
@@ -0,0 +1,290 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 操作类型
+const (
+	OperationGet     = "get"
+	OperationGetNext = "getNext"
+	OperationGetBulk = "getBulk"
+	OperationWalk    = "walk"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&GetNode{})
+}
+
+// SecurityConfig SNMPv3 USM 安全配置，认证协议为空表示 noAuthNoPriv，加密协议为空表示 authNoPriv
+type SecurityConfig struct {
+	// UserName 用户名
+	UserName string `json:"userName" label:"User name" desc:"SNMPv3 USM user name" ref:"shared"`
+	// AuthProtocol 认证协议：MD5、SHA、SHA224、SHA256、SHA384、SHA512
+	AuthProtocol string `json:"authProtocol" label:"Auth protocol" desc:"SNMPv3 auth protocol: MD5, SHA, SHA224, SHA256, SHA384 or SHA512. Empty means noAuthNoPriv" ref:"shared"`
+	// AuthPassword 认证密码，至少 8 个字符
+	AuthPassword string `json:"authPassword" label:"Auth password" desc:"SNMPv3 auth password, at least 8 characters" ref:"shared"`
+	// PrivProtocol 加密协议：DES、AES、AES192、AES256、AES192C、AES256C
+	PrivProtocol string `json:"privProtocol" label:"Priv protocol" desc:"SNMPv3 privacy protocol: DES, AES, AES192, AES256 (Blumenthal key extension) or AES192C, AES256C (Reeder/Cisco key extension). Empty means authNoPriv" ref:"shared"`
+	// PrivPassword 加密密码，至少 8 个字符
+	PrivPassword string `json:"privPassword" label:"Priv password" desc:"SNMPv3 privacy password, at least 8 characters" ref:"shared"`
+}
+
+// User 转换成 USM 用户
+func (c SecurityConfig) User() (User, error) {
+	auth, err := ParseAuthProtocol(c.AuthProtocol)
+	if err != nil {
+		return User{}, err
+	}
+	priv, err := ParsePrivProtocol(c.PrivProtocol)
+	if err != nil {
+		return User{}, err
+	}
+	user := User{
+		UserName:     c.UserName,
+		AuthProtocol: auth,
+		AuthPassword: c.AuthPassword,
+		PrivProtocol: priv,
+		PrivPassword: c.PrivPassword,
+	}
+	return user, user.Validate()
+}
+
+// GetConfiguration 节点配置
+type GetConfiguration struct {
+	// Server 代理地址，格式：host:port，端口默认 161
+	Server string `json:"server" label:"Server" desc:"SNMP agent address, format: host:port, port defaults to 161" required:"true" ref:"primary"`
+	// Version 版本：1、2c、3
+	Version string `json:"version" label:"Version" desc:"SNMP version: 1, 2c or 3"`
+	// Community 团体名，v1/v2c 使用
+	Community string `json:"community" label:"Community" desc:"Community string for v1/v2c" ref:"shared"`
+	// Operation 操作：get、getNext、getBulk、walk
+	Operation string `json:"operation" label:"Operation" desc:"Operation: get, getNext, getBulk or walk (getBulk/walk subtree per OID)"`
+	// OIDs 查询的 OID，可以使用 MIB 中的名称，eg. sysUpTime.0，为空则使用消息负荷 msg.Data 中的 OID 数组
+	OIDs []string `json:"oids" label:"OIDs" desc:"OIDs or MIB names (eg. sysUpTime.0) to query, empty uses the JSON array of OIDs in msg.Data"`
+	// NonRepeaters getBulk 只获取一次的 OID 数量
+	NonRepeaters int `json:"nonRepeaters" label:"Non repeaters" desc:"getBulk: number of leading OIDs fetched once"`
+	// MaxRepetitions getBulk 和 walk 每次请求的最大获取次数
+	MaxRepetitions int `json:"maxRepetitions" label:"Max repetitions" desc:"getBulk/walk: max repetitions per request"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Request timeout in seconds"`
+	// Retries 超时重试次数
+	Retries int `json:"retries" label:"Retries" desc:"Retries after a request timeout"`
+	// ContextName v3 上下文名称
+	ContextName string `json:"contextName" label:"Context name" desc:"SNMPv3 context name"`
+	// Mibs 加载的 MIB 文件或者目录，补充内置的 OID 名称
+	Mibs []string `json:"mibs" label:"MIB files" desc:"MIB files or directories to load, extending the built-in OID names"`
+	// ResolveNames 结果以 OID 的符号名称为 key，eg. ifDescr.3，没有名称的保留数字 OID
+	ResolveNames   bool `json:"resolveNames" label:"Resolve names" desc:"Key results by symbolic OID names, eg. ifDescr.3, falling back to numeric OIDs"`
+	SecurityConfig `json:",squash"`
+}
+
+// GetNode SNMP 查询节点，通过 GET、GETNEXT、GETBULK 或者 WALK 查询网络设备、UPS 等的代理，支持 v1、v2c 和 v3（USM 认证和加密）。
+// OID 来自配置 oids 或者消息负荷 msg.Data，格式：
+//
+//	["1.3.6.1.2.1.1.3.0", "1.3.6.1.2.1.33.1.2.4.0"]
+//
+// 结果以 OID 为 key 重新赋值到msg.Data，walk 返回各个 OID 子树下的所有变量，不存在的变量值为 null：
+//
+//	{"1.3.6.1.2.1.1.3.0": 123456, "1.3.6.1.2.1.33.1.2.4.0": 95}
+//
+// 开启 resolveNames 时以内置名称和加载的 MIB 中的符号名称为 key：
+//
+//	{"sysUpTime.0": 123456, "upsEstimatedChargeRemaining.0": 95}
+//
+// 可打印的 OctetString 输出为字符串，否则输出为 xx:xx 形式的十六进制。
+// 查询成功，流转到`Success`链，否则流转到`Failure`链
+type GetNode struct {
+	base.SharedNode[*Client]
+	//节点配置
+	Config GetConfiguration
+	// oids 校验后的 OID
+	oids []string
+	// mib OID 名称
+	mib *MIB
+}
+
+// Type 返回组件类型
+func (x *GetNode) Type() string {
+	return "x/snmpGet"
+}
+
+// New 默认参数
+func (x *GetNode) New() types.Node {
+	return &GetNode{
+		Config: GetConfiguration{
+			Server:         "127.0.0.1:161",
+			Version:        "2c",
+			Community:      "public",
+			Operation:      OperationGet,
+			MaxRepetitions: DefaultMaxRepetitions,
+			Timeout:        5,
+			Retries:        1,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *GetNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	version, err := ParseVersion(x.Config.Version)
+	if err != nil {
+		return err
+	}
+	switch x.Config.Operation {
+	case "":
+		x.Config.Operation = OperationGet
+	case OperationGet, OperationGetNext, OperationGetBulk, OperationWalk:
+	default:
+		return fmt.Errorf("unsupported snmp operation: %s", x.Config.Operation)
+	}
+	if version == Version1 && x.Config.Operation == OperationGetBulk {
+		return fmt.Errorf("snmp v1 does not support getBulk")
+	}
+	if x.mib, err = LoadMIB(x.Config.Mibs...); err != nil {
+		return err
+	}
+	if x.oids, err = normalizeOIDs(x.mib, x.Config.OIDs); err != nil {
+		return err
+	}
+	config := ClientConfig{
+		Server:      x.Config.Server,
+		Version:     version,
+		Community:   x.Config.Community,
+		Timeout:     time.Duration(x.Config.Timeout) * time.Second,
+		Retries:     x.Config.Retries,
+		ContextName: x.Config.ContextName,
+	}
+	if version == Version3 {
+		if config.User, err = x.Config.SecurityConfig.User(); err != nil {
+			return err
+		}
+	}
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*Client, error) {
+		return NewClient(config)
+	}, func(client *Client) error {
+		if client != nil {
+			return client.Close()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *GetNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	oids := x.oids
+	if len(oids) == 0 {
+		var list []string
+		if err := json.Unmarshal([]byte(msg.GetData()), &list); err != nil {
+			ctx.TellFailure(msg, fmt.Errorf("invalid snmp oids: %w", err))
+			return
+		}
+		var err error
+		if oids, err = normalizeOIDs(x.mib, list); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if len(oids) == 0 {
+			ctx.TellFailure(msg, fmt.Errorf("no snmp oids to query"))
+			return
+		}
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var variables []Variable
+	switch x.Config.Operation {
+	case OperationGetNext:
+		variables, err = client.GetNext(oids...)
+	case OperationGetBulk:
+		variables, err = client.GetBulk(x.Config.NonRepeaters, x.Config.MaxRepetitions, oids...)
+	case OperationWalk:
+		for _, oid := range oids {
+			var subtree []Variable
+			if subtree, err = client.Walk(oid, x.Config.MaxRepetitions); err != nil {
+				break
+			}
+			variables = append(variables, subtree...)
+		}
+	default:
+		variables, err = client.Get(oids...)
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := make(map[string]interface{}, len(variables))
+	for _, v := range variables {
+		if v.Type == EndOfMibView {
+			continue
+		}
+		key := v.OID
+		if x.Config.ResolveNames {
+			key = x.mib.Name(v.OID)
+		}
+		result[key] = v.JSONValue()
+	}
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *GetNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *GetNode) Desc() string {
+	return "SNMP v1/v2c/v3 query with get, getNext, getBulk or walk of OIDs. Routes to Success/Failure"
+}
+
+// normalizeOIDs 把名称转换为数字 OID，校验并去掉前导点
+func normalizeOIDs(mib *MIB, oids []string) ([]string, error) {
+	result := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		if strings.TrimSpace(oid) == "" {
+			continue
+		}
+		normalized, err := mib.OID(oid)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, normalized)
+	}
+	return result, nil
+}
@@ -0,0 +1,337 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+var testUser = User{UserName: "admin", AuthProtocol: SHA256, AuthPassword: "authpass", PrivProtocol: AES, PrivPassword: "privpass"}
+
+var testMIB = []Variable{
+	{OID: "1.3.6.1.2.1.1.1.0", Type: OctetString, Value: []byte("UPS 3000")},
+	{OID: "1.3.6.1.2.1.1.2.0", Type: ObjectIdentifier, Value: "1.3.6.1.4.1.318"},
+	{OID: "1.3.6.1.2.1.1.3.0", Type: TimeTicks, Value: uint32(123456)},
+	{OID: "1.3.6.1.2.1.1.5.0", Type: OctetString, Value: []byte("ups1")},
+	{OID: "1.3.6.1.2.1.2.2.1.2.1", Type: OctetString, Value: []byte("eth0")},
+	{OID: "1.3.6.1.2.1.2.2.1.2.2", Type: OctetString, Value: []byte("eth1")},
+	{OID: "1.3.6.1.2.1.2.2.1.6.1", Type: OctetString, Value: []byte{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e}},
+	{OID: "1.3.6.1.2.1.2.2.1.10.1", Type: Counter32, Value: uint32(4000000000)},
+	{OID: "1.3.6.1.2.1.33.1.2.4.0", Type: Integer, Value: int64(95)},
+}
+
+// testAgent 测试用的 SNMP 代理
+type testAgent struct {
+	conn      *net.UDPConn
+	engine    *Engine
+	community string
+}
+
+func newTestAgent(t *testing.T) *testAgent {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewEngine(NewEngineID(), 2, []User{testUser,
+		{UserName: "md5des", AuthProtocol: MD5, AuthPassword: "authpass", PrivProtocol: DES, PrivPassword: "privpass"},
+		{UserName: "aes256c", AuthProtocol: SHA, AuthPassword: "authpass", PrivProtocol: AES256C, PrivPassword: "privpass"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &testAgent{conn: conn, engine: engine, community: "public"}
+	go a.serve()
+	return a
+}
+
+func (a *testAgent) addr() string {
+	return a.conn.LocalAddr().String()
+}
+
+func (a *testAgent) Close() {
+	_ = a.conn.Close()
+}
+
+func (a *testAgent) serve() {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		data := append([]byte{}, buf[:n]...)
+		p, err := Unmarshal(data)
+		if err != nil {
+			continue
+		}
+		var reply []byte
+		if p.Version == Version3 {
+			keys, err := a.engine.Open(p, data, true)
+			var report *ReportError
+			if errors.As(err, &report) {
+				reply, _ = a.engine.Report(p, report, keys)
+			} else {
+				reply, _ = a.engine.Response(p, a.respond(p.PDU, p.Version), keys)
+			}
+		} else if p.Community == a.community {
+			reply, _ = (&Packet{Version: p.Version, Community: p.Community, PDU: a.respond(p.PDU, p.Version)}).Marshal(nil)
+		}
+		if reply != nil {
+			_, _ = a.conn.WriteToUDP(reply, addr)
+		}
+	}
+}
+
+// next 字典序的下一个变量
+func (a *testAgent) next(oid string) (Variable, bool) {
+	i := sort.Search(len(testMIB), func(i int) bool { return CompareOID(testMIB[i].OID, oid) > 0 })
+	if i == len(testMIB) {
+		return Variable{OID: oid, Type: EndOfMibView}, false
+	}
+	return testMIB[i], true
+}
+
+func (a *testAgent) respond(req PDU, version Version) PDU {
+	resp := PDU{Type: GetResponse, RequestID: req.RequestID}
+	fail := func(i int) PDU {
+		resp.ErrorStatus, resp.ErrorIndex, resp.Variables = 2, i+1, req.Variables
+		return resp
+	}
+	switch req.Type {
+	case GetRequest:
+		for i, v := range req.Variables {
+			found := Variable{OID: v.OID, Type: NoSuchObject}
+			for _, m := range testMIB {
+				if m.OID == v.OID {
+					found = m
+				}
+			}
+			if found.Type == NoSuchObject && version == Version1 {
+				return fail(i)
+			}
+			resp.Variables = append(resp.Variables, found)
+		}
+	case GetNextRequest:
+		for i, v := range req.Variables {
+			next, ok := a.next(v.OID)
+			if !ok && version == Version1 {
+				return fail(i)
+			}
+			resp.Variables = append(resp.Variables, next)
+		}
+	case GetBulkRequest:
+		for _, v := range req.Variables[:req.NonRepeaters] {
+			next, _ := a.next(v.OID)
+			resp.Variables = append(resp.Variables, next)
+		}
+		repeaters := append([]Variable{}, req.Variables[req.NonRepeaters:]...)
+		for r := 0; r < req.MaxRepetitions; r++ {
+			for i, v := range repeaters {
+				next, _ := a.next(v.OID)
+				resp.Variables = append(resp.Variables, next)
+				repeaters[i] = next
+			}
+		}
+	case InformRequest:
+		resp.Variables = req.Variables
+	}
+	return resp
+}
+
+func TestClient(t *testing.T) {
+	agent := newTestAgent(t)
+	defer agent.Close()
+
+	for _, config := range []ClientConfig{
+		{Version: Version1, Community: "public"},
+		{Version: Version2c, Community: "public"},
+		{Version: Version3, User: testUser, ContextName: "ups"},
+		{Version: Version3, User: User{UserName: "md5des", AuthProtocol: MD5, AuthPassword: "authpass", PrivProtocol: DES, PrivPassword: "privpass"}},
+	} {
+		config.Server = agent.addr()
+		config.Timeout = time.Second
+		client, err := NewClient(config)
+		assert.Nil(t, err)
+
+		variables, err := client.Get("1.3.6.1.2.1.1.5.0", ".1.3.6.1.2.1.33.1.2.4.0")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("ups1"), variables[0].Value)
+		assert.Equal(t, int64(95), variables[1].Value)
+
+		variables, err = client.GetNext("1.3.6.1.2.1.1.3.0")
+		assert.Nil(t, err)
+		assert.Equal(t, "1.3.6.1.2.1.1.5.0", variables[0].OID)
+
+		variables, err = client.Walk("1.3.6.1.2.1.2.2.1", 2)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(variables))
+		assert.Equal(t, "1.3.6.1.2.1.2.2.1.10.1", variables[3].OID)
+
+		// 根节点是实例
+		variables, err = client.Walk("1.3.6.1.2.1.1.1.0", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(variables))
+		variables, err = client.Walk("1.3.6.1.9", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(variables))
+
+		if config.Version == Version1 {
+			_, err = client.Get("1.3.6.1.2.1.1.9.0")
+			var statusErr *StatusError
+			assert.True(t, errors.As(err, &statusErr))
+			assert.Equal(t, 1, statusErr.Index)
+			_, err = client.GetBulk(0, 1, "1.3.6.1")
+			assert.NotNil(t, err)
+			assert.NotNil(t, client.Trap("1.3.6.1.6.3.1.1.5.1", nil))
+		} else {
+			variables, err = client.Get("1.3.6.1.2.1.1.9.0")
+			assert.Nil(t, err)
+			assert.Equal(t, NoSuchObject, variables[0].Type)
+			variables, err = client.GetBulk(1, 3, "1.3.6.1.2.1.1.1.0", "1.3.6.1.2.1.2.2.1.2")
+			assert.Nil(t, err)
+			assert.Equal(t, 4, len(variables))
+			assert.Equal(t, "1.3.6.1.2.1.1.2.0", variables[0].OID)
+			assert.Equal(t, "1.3.6.1.2.1.2.2.1.6.1", variables[3].OID)
+			assert.Nil(t, client.Inform("1.3.6.1.6.3.1.1.5.1", nil))
+		}
+		_ = client.Close()
+	}
+
+	// 错误的团体名和密码
+	client, _ := NewClient(ClientConfig{Server: agent.addr(), Version: Version2c, Community: "private", Timeout: 100 * time.Millisecond})
+	_, err := client.Get("1.3.6.1.2.1.1.5.0")
+	assert.Equal(t, ErrTimeout, err)
+	_ = client.Close()
+	client, _ = NewClient(ClientConfig{Server: agent.addr(), Version: Version3, User: User{UserName: "admin", AuthProtocol: SHA256, AuthPassword: "wrongpass", PrivProtocol: AES, PrivPassword: "privpass"}})
+	_, err = client.Get("1.3.6.1.2.1.1.5.0")
+	assert.Equal(t, &ReportError{OID: WrongDigestsOID}, err)
+	_ = client.Close()
+	_, err = NewClient(ClientConfig{Server: agent.addr(), Version: Version3, User: User{UserName: "admin", AuthProtocol: MD5, AuthPassword: "short"}})
+	assert.NotNil(t, err)
+}
+
+func TestGetNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&GetNode{})
+
+	for _, configuration := range []types.Configuration{
+		{"version": "4"},
+		{"operation": "set"},
+		{"version": "1", "operation": "getBulk"},
+		{"oids": []string{"1.3.x"}},
+		{"version": "3", "userName": "admin", "authProtocol": "SHA", "authPassword": "short"},
+		{"version": "3", "userName": "admin", "privProtocol": "AES", "privPassword": "privpass"},
+		{"oids": []string{"unknownObject.0"}},
+		{"mibs": []string{"/nonexistent/mibs"}},
+	} {
+		_, err := test.CreateAndInitNode("x/snmpGet", configuration, Registry)
+		assert.NotNil(t, err)
+	}
+
+	agent := newTestAgent(t)
+	defer agent.Close()
+
+	node, err := test.CreateAndInitNode("x/snmpGet", types.Configuration{
+		"server": agent.addr(),
+		"oids":   []string{"1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.33.1.2.4.0", "1.3.6.1.2.1.1.9.0"},
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData: types.NewMetadata(),
+		DataType: types.JSON,
+		MsgType:  "TEST",
+		Data:     "{}",
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, "ups1", result["1.3.6.1.2.1.1.5.0"])
+		assert.Equal(t, float64(95), result["1.3.6.1.2.1.33.1.2.4.0"])
+		assert.Nil(t, result["1.3.6.1.2.1.1.9.0"])
+	})
+
+	nameNode, err := test.CreateAndInitNode("x/snmpGet", types.Configuration{
+		"server":       agent.addr(),
+		"version":      "3",
+		"operation":    "walk",
+		"oids":         []string{"SNMPv2-MIB::sysUpTime", "ifDescr"},
+		"resolveNames": true,
+		"userName":     "aes256c",
+		"authProtocol": "SHA",
+		"authPassword": "authpass",
+		"privProtocol": "AES256C",
+		"privPassword": "privpass",
+	}, Registry)
+	assert.Nil(t, err)
+	defer nameNode.Destroy()
+	test.NodeOnMsg(t, nameNode, []test.Msg{{
+		MetaData: types.NewMetadata(),
+		DataType: types.JSON,
+		MsgType:  "TEST",
+		Data:     "{}",
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, map[string]interface{}{"sysUpTime.0": float64(123456), "ifDescr.1": "eth0", "ifDescr.2": "eth1"}, result)
+	})
+
+	walkNode, err := test.CreateAndInitNode("x/snmpGet", types.Configuration{
+		"server":       agent.addr(),
+		"version":      "3",
+		"operation":    "walk",
+		"userName":     "admin",
+		"authProtocol": "sha256",
+		"authPassword": "authpass",
+		"privProtocol": "aes",
+		"privPassword": "privpass",
+	}, Registry)
+	assert.Nil(t, err)
+	defer walkNode.Destroy()
+	test.NodeOnMsg(t, walkNode, []test.Msg{{
+		MetaData: types.NewMetadata(),
+		DataType: types.JSON,
+		MsgType:  "TEST",
+		Data:     `["1.3.6.1.2.1.2.2.1"]`,
+	}, {
+		MetaData: types.NewMetadata(),
+		DataType: types.JSON,
+		MsgType:  "INVALID",
+		Data:     `{"oid": "1.3.6.1"}`,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "INVALID" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, 4, len(result))
+		assert.Equal(t, "00:1a:2b:3c:4d:5e", result["1.3.6.1.2.1.2.2.1.6.1"])
+		assert.Equal(t, float64(4000000000), result["1.3.6.1.2.1.2.2.1.10.1"])
+	})
+}
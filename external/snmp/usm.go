@@ -0,0 +1,402 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snmp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	mrand "math/rand"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// ErrWrongDigest 摘要验证失败
+	ErrWrongDigest = errors.New("snmp: wrong digest")
+	// ErrDecryption 解密失败
+	ErrDecryption = errors.New("snmp: decryption error")
+)
+
+// USM 统计 OID，用于 Report
+const (
+	UnsupportedSecLevelsOID = "1.3.6.1.6.3.15.1.1.1.0"
+	NotInTimeWindowsOID     = "1.3.6.1.6.3.15.1.1.2.0"
+	UnknownUserNamesOID     = "1.3.6.1.6.3.15.1.1.3.0"
+	UnknownEngineIDsOID     = "1.3.6.1.6.3.15.1.1.4.0"
+	WrongDigestsOID         = "1.3.6.1.6.3.15.1.1.5.0"
+	DecryptionErrorsOID     = "1.3.6.1.6.3.15.1.1.6.0"
+)
+
+var reportNames = map[string]string{
+	UnsupportedSecLevelsOID: "unsupported security level",
+	NotInTimeWindowsOID:     "not in time window",
+	UnknownUserNamesOID:     "unknown user name",
+	UnknownEngineIDsOID:     "unknown engine id",
+	WrongDigestsOID:         "wrong digest",
+	DecryptionErrorsOID:     "decryption error",
+}
+
+// AuthProtocol 认证协议
+type AuthProtocol string
+
+const (
+	NoAuth AuthProtocol = ""
+	MD5    AuthProtocol = "MD5"
+	SHA    AuthProtocol = "SHA"
+	SHA224 AuthProtocol = "SHA224"
+	SHA256 AuthProtocol = "SHA256"
+	SHA384 AuthProtocol = "SHA384"
+	SHA512 AuthProtocol = "SHA512"
+)
+
+// ParseAuthProtocol 解析认证协议，不区分大小写，为空表示不认证
+func ParseAuthProtocol(protocol string) (AuthProtocol, error) {
+	p := AuthProtocol(strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(protocol)), "-", ""))
+	if p == "SHA1" {
+		p = SHA
+	}
+	switch p {
+	case NoAuth, MD5, SHA, SHA224, SHA256, SHA384, SHA512:
+		return p, nil
+	default:
+		return "", fmt.Errorf("snmp: unsupported auth protocol %s", protocol)
+	}
+}
+
+func (a AuthProtocol) hash() func() hash.Hash {
+	switch a {
+	case MD5:
+		return md5.New
+	case SHA:
+		return sha1.New
+	case SHA224:
+		return sha256.New224
+	case SHA256:
+		return sha256.New
+	case SHA384:
+		return sha512.New384
+	case SHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// macLen 截断后的摘要长度，RFC 3414 和 RFC 7860
+func (a AuthProtocol) macLen() int {
+	switch a {
+	case MD5, SHA:
+		return 12
+	case SHA224:
+		return 16
+	case SHA256:
+		return 24
+	case SHA384:
+		return 32
+	case SHA512:
+		return 48
+	default:
+		return 0
+	}
+}
+
+// PrivProtocol 加密协议
+type PrivProtocol string
+
+// AES192、AES256 按 draft-blumenthal-aes-usm 扩展密钥（net-snmp 等），
+// AES192C、AES256C 按 draft-reeder-snmpv3-usm-3desede 扩展密钥（Cisco 等）
+const (
+	NoPriv  PrivProtocol = ""
+	DES     PrivProtocol = "DES"
+	AES     PrivProtocol = "AES"
+	AES192  PrivProtocol = "AES192"
+	AES256  PrivProtocol = "AES256"
+	AES192C PrivProtocol = "AES192C"
+	AES256C PrivProtocol = "AES256C"
+)
+
+// ParsePrivProtocol 解析加密协议，不区分大小写，为空表示不加密
+func ParsePrivProtocol(protocol string) (PrivProtocol, error) {
+	p := PrivProtocol(strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(protocol)), "-", ""))
+	if p == "AES128" {
+		p = AES
+	}
+	if p.keyLen() == 0 && p != NoPriv {
+		return "", fmt.Errorf("snmp: unsupported priv protocol %s", protocol)
+	}
+	return p, nil
+}
+
+// keyLen 加密密钥长度，DES 包含 8 字节的 pre-IV
+func (p PrivProtocol) keyLen() int {
+	switch p {
+	case DES, AES:
+		return 16
+	case AES192, AES192C:
+		return 24
+	case AES256, AES256C:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// reeder 是否按 Reeder 草案扩展密钥
+func (p PrivProtocol) reeder() bool {
+	return p == AES192C || p == AES256C
+}
+
+// User USM 用户
+type User struct {
+	UserName     string
+	AuthProtocol AuthProtocol
+	AuthPassword string
+	PrivProtocol PrivProtocol
+	PrivPassword string
+}
+
+// Flags 用户安全级别对应的报文标志
+func (u User) Flags() MsgFlags {
+	var flags MsgFlags
+	if u.AuthProtocol != NoAuth {
+		flags |= AuthFlag
+		if u.PrivProtocol != NoPriv {
+			flags |= PrivFlag
+		}
+	}
+	return flags
+}
+
+// Validate 校验用户配置
+func (u User) Validate() error {
+	if u.UserName == "" {
+		return errors.New("snmp: user name is required")
+	}
+	if u.AuthProtocol != NoAuth && u.AuthProtocol.hash() == nil {
+		return fmt.Errorf("snmp: unsupported auth protocol %s", u.AuthProtocol)
+	}
+	if u.PrivProtocol != NoPriv && u.PrivProtocol.keyLen() == 0 {
+		return fmt.Errorf("snmp: unsupported priv protocol %s", u.PrivProtocol)
+	}
+	if u.AuthProtocol != NoAuth && len(u.AuthPassword) < 8 {
+		return errors.New("snmp: auth password must be at least 8 characters")
+	}
+	if u.PrivProtocol != NoPriv {
+		if u.AuthProtocol == NoAuth {
+			return errors.New("snmp: privacy requires an auth protocol")
+		}
+		if len(u.PrivPassword) < 8 {
+			return errors.New("snmp: priv password must be at least 8 characters")
+		}
+	}
+	return nil
+}
+
+// Credentials 用户的主密钥，由密码生成，计算量较大，需要复用
+type Credentials struct {
+	User    User
+	authKey []byte
+	privKey []byte
+}
+
+// NewCredentials 校验用户并由密码生成主密钥
+func NewCredentials(user User) (*Credentials, error) {
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+	c := &Credentials{User: user}
+	if user.AuthProtocol != NoAuth {
+		c.authKey = passwordToKey(user.AuthProtocol, user.AuthPassword)
+		if user.PrivProtocol != NoPriv {
+			c.privKey = passwordToKey(user.AuthProtocol, user.PrivPassword)
+		}
+	}
+	return c, nil
+}
+
+// Localize 生成指定权威引擎的本地化密钥
+func (c *Credentials) Localize(engineID []byte) *Keys {
+	keys := &Keys{Auth: c.User.AuthProtocol, Priv: c.User.PrivProtocol}
+	if c.authKey != nil {
+		keys.authKey = localizeKey(c.User.AuthProtocol, c.authKey, engineID)
+	}
+	if c.privKey != nil {
+		keys.privKey = extendKey(c.User.AuthProtocol, c.User.PrivProtocol, localizeKey(c.User.AuthProtocol, c.privKey, engineID), engineID)
+	}
+	return keys
+}
+
+// extendKey 本地化密钥比加密密钥短时扩展密钥，eg. SHA 认证使用 AES256 加密
+func extendKey(auth AuthProtocol, priv PrivProtocol, key, engineID []byte) []byte {
+	n := priv.keyLen()
+	for len(key) < n {
+		if priv.reeder() {
+			// Reeder：以本地化密钥作为密码再次生成并本地化密钥，追加到末尾
+			key = append(key, localizeKey(auth, passwordToKey(auth, string(key)), engineID)...)
+		} else {
+			// Blumenthal：Kul' = Kul || H(Kul)
+			h := auth.hash()()
+			h.Write(key)
+			key = h.Sum(key)
+		}
+	}
+	return key
+}
+
+// passwordToKey RFC 3414 A.2，密码重复填充到 1MB 计算摘要
+func passwordToKey(protocol AuthProtocol, password string) []byte {
+	h := protocol.hash()()
+	buf := make([]byte, 64)
+	pos := 0
+	for count := 0; count < 1048576; count += 64 {
+		for i := range buf {
+			buf[i] = password[pos%len(password)]
+			pos++
+		}
+		h.Write(buf)
+	}
+	return h.Sum(nil)
+}
+
+func localizeKey(protocol AuthProtocol, key, engineID []byte) []byte {
+	h := protocol.hash()()
+	h.Write(key)
+	h.Write(engineID)
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// Keys 用户在某个权威引擎下的本地化密钥
+type Keys struct {
+	Auth    AuthProtocol
+	Priv    PrivProtocol
+	authKey []byte
+	privKey []byte
+}
+
+func (k *Keys) mac(data []byte) []byte {
+	h := hmac.New(k.Auth.hash(), k.authKey)
+	h.Write(data)
+	return h.Sum(nil)[:k.Auth.macLen()]
+}
+
+// salt 加密盐值计数器，随机初始值
+var salt atomic.Uint64
+
+func init() {
+	salt.Store(mrand.Uint64())
+}
+
+// encrypt 加密 scopedPDU，返回密文和作为 privacyParameters 的盐值
+func (k *Keys) encrypt(plaintext []byte, boots, engineTime int32) ([]byte, []byte, error) {
+	switch k.Priv {
+	case DES:
+		// RFC 3414 8.1.1.1，盐值为 boots 和计数器
+		block, err := des.NewCipher(k.privKey[:8])
+		if err != nil {
+			return nil, nil, err
+		}
+		params := make([]byte, 8)
+		binary.BigEndian.PutUint32(params, uint32(boots))
+		binary.BigEndian.PutUint32(params[4:], uint32(salt.Add(1)))
+		iv := make([]byte, 8)
+		for i := range iv {
+			iv[i] = k.privKey[8+i] ^ params[i]
+		}
+		padded := make([]byte, (len(plaintext)+7)/8*8)
+		copy(padded, plaintext)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+		return padded, params, nil
+	case AES, AES192, AES256, AES192C, AES256C:
+		// RFC 3826，IV 为 boots、time 和 64 位盐值，192/256 位密钥使用相同的 IV
+		block, err := aes.NewCipher(k.privKey[:k.Priv.keyLen()])
+		if err != nil {
+			return nil, nil, err
+		}
+		params := make([]byte, 8)
+		binary.BigEndian.PutUint64(params, salt.Add(1))
+		out := make([]byte, len(plaintext))
+		cipher.NewCFBEncrypter(block, aesIV(boots, engineTime, params)).XORKeyStream(out, plaintext)
+		return out, params, nil
+	default:
+		return nil, nil, fmt.Errorf("snmp: unsupported priv protocol %s", k.Priv)
+	}
+}
+
+func (k *Keys) decrypt(ciphertext []byte, security SecurityParameters) ([]byte, error) {
+	params := security.PrivacyParameters
+	if len(params) != 8 {
+		return nil, ErrDecryption
+	}
+	switch k.Priv {
+	case DES:
+		if len(ciphertext)%8 != 0 {
+			return nil, ErrDecryption
+		}
+		block, err := des.NewCipher(k.privKey[:8])
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, 8)
+		for i := range iv {
+			iv[i] = k.privKey[8+i] ^ params[i]
+		}
+		out := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, ciphertext)
+		return out, nil
+	case AES, AES192, AES256, AES192C, AES256C:
+		block, err := aes.NewCipher(k.privKey[:k.Priv.keyLen()])
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, len(ciphertext))
+		cipher.NewCFBDecrypter(block, aesIV(security.AuthoritativeEngineBoots, security.AuthoritativeEngineTime, params)).XORKeyStream(out, ciphertext)
+		return out, nil
+	default:
+		return nil, fmt.Errorf("snmp: unsupported priv protocol %s", k.Priv)
+	}
+}
+
+func aesIV(boots, engineTime int32, params []byte) []byte {
+	iv := make([]byte, 16)
+	binary.BigEndian.PutUint32(iv, uint32(boots))
+	binary.BigEndian.PutUint32(iv[4:], uint32(engineTime))
+	copy(iv[8:], params)
+	return iv
+}
+
+// ReportError 对端返回的 Report
+type ReportError struct {
+	OID string
+}
+
+func (e *ReportError) Error() string {
+	if name, ok := reportNames[e.OID]; ok {
+		return "snmp: " + name
+	}
+	return "snmp: report " + e.OID
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"sync"
+)
+
+// metricDef 出生证明中定义的指标
+type metricDef struct {
+	name     string
+	dataType DataType
+}
+
+// AliasTable 按边缘节点维护出生证明中的别名->指标名称和数据类型，可以并发调用
+// 别名在边缘节点内唯一，节点和设备的出生证明共用一个表
+type AliasTable struct {
+	mu    sync.Mutex
+	nodes map[string]map[uint64]metricDef
+}
+
+// NewAliasTable 创建别名表
+func NewAliasTable() *AliasTable {
+	return &AliasTable{nodes: map[string]map[uint64]metricDef{}}
+}
+
+// Apply 根据消息类型更新别名表，或者补全只有别名的指标名称和数据类型
+// 返回是否存在无法解析的别名，这时通常需要请求边缘节点重新发送出生证明
+func (a *AliasTable) Apply(topic Topic, p *Payload) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := topic.NodeKey()
+	switch topic.MessageType {
+	case NBIRTH:
+		// 节点重新上线，之前的别名全部失效
+		aliases := map[uint64]metricDef{}
+		a.nodes[key] = aliases
+		record(aliases, p)
+		return false
+	case DBIRTH:
+		aliases, ok := a.nodes[key]
+		if !ok {
+			aliases = map[uint64]metricDef{}
+			a.nodes[key] = aliases
+		}
+		record(aliases, p)
+		return false
+	case NDEATH:
+		delete(a.nodes, key)
+		return false
+	case STATE:
+		return false
+	}
+	aliases := a.nodes[key]
+	unknown := false
+	for i := range p.Metrics {
+		m := &p.Metrics[i]
+		if m.Alias == nil {
+			continue
+		}
+		def, ok := aliases[*m.Alias]
+		if !ok {
+			if m.Name == "" {
+				unknown = true
+			}
+			continue
+		}
+		if m.Name == "" {
+			m.Name = def.name
+		}
+		if m.DataType == Unknown {
+			m.DataType = def.dataType
+			m.Value = ConvertValue(def.dataType, m.Value)
+		}
+	}
+	return unknown
+}
+
+// Lookup 查找别名对应的指标名称
+func (a *AliasTable) Lookup(nodeKey string, alias uint64) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	def, ok := a.nodes[nodeKey][alias]
+	return def.name, ok
+}
+
+// record 记录出生证明中定义的别名
+func record(aliases map[uint64]metricDef, p *Payload) {
+	for _, m := range p.Metrics {
+		if m.Alias != nil && m.Name != "" {
+			aliases[*m.Alias] = metricDef{name: m.Name, dataType: m.DataType}
+		}
+	}
+}
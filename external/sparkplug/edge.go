@@ -0,0 +1,381 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// BdSeqMetric 出生和死亡证明中的会话序号指标，用于关联 NBIRTH 和遗嘱 NDEATH
+const BdSeqMetric = "bdSeq"
+
+// edgeTimeout 连接和发布的超时时间
+const edgeTimeout = 5 * time.Second
+
+// ErrUnknownMetric 指标没有在出生证明中定义
+var ErrUnknownMetric = errors.New("sparkplug: unknown metric")
+
+// EdgeMetric 出生证明中定义的指标
+type EdgeMetric struct {
+	Name     string
+	DataType DataType
+	// Value 出生证明中的初始值，nil 表示空值
+	Value interface{}
+}
+
+// EdgeDevice 边缘节点下的设备
+type EdgeDevice struct {
+	DeviceId string
+	Metrics  []EdgeMetric
+}
+
+// EdgeConfig 边缘节点配置
+type EdgeConfig struct {
+	// Server MQTT 服务器地址，格式：host:port
+	Server      string
+	Username    string
+	Password    string
+	ClientID    string
+	CAFile      string
+	CertFile    string
+	CertKeyFile string
+	// TLS TLS 配置，证书文件为空时使用 CAFile、CertFile、CertKeyFile
+	TLS        tlsconfig.Config
+	GroupId    string
+	EdgeNodeId string
+	// UseAliases 是否为指标分配别名，数据消息只发送别名
+	UseAliases bool
+	// Metrics 节点的指标
+	Metrics []EdgeMetric
+	// Devices 设备和设备的指标
+	Devices []EdgeDevice
+}
+
+// message 待发布的消息
+type message struct {
+	topic   string
+	payload []byte
+}
+
+// edgeState 边缘节点的序号和指标状态，不是并发安全的
+type edgeState struct {
+	groupId    string
+	edgeNodeId string
+	useAliases bool
+	// seq 下一条消息的序号 0-255
+	seq uint64
+	// bdSeq 会话序号
+	bdSeq uint64
+	// deviceIds 设备顺序，"" 表示节点
+	deviceIds []string
+	metrics   map[string][]Metric
+	index     map[string]map[string]int
+}
+
+// Validate 检查组标识、节点标识和指标定义
+func (c EdgeConfig) Validate() error {
+	if err := c.TLS.WithFiles(c.CAFile, c.CertFile, c.CertKeyFile).Validate(); err != nil {
+		return err
+	}
+	_, err := newEdgeState(c)
+	return err
+}
+
+// newEdgeState 检查指标定义并分配别名，别名在边缘节点内唯一，从 1 开始
+func newEdgeState(conf EdgeConfig) (*edgeState, error) {
+	if conf.GroupId == "" || conf.EdgeNodeId == "" {
+		return nil, errors.New("sparkplug: groupId and edgeNodeId can not be empty")
+	}
+	s := &edgeState{
+		groupId:    conf.GroupId,
+		edgeNodeId: conf.EdgeNodeId,
+		useAliases: conf.UseAliases,
+		metrics:    map[string][]Metric{},
+		index:      map[string]map[string]int{},
+	}
+	var alias uint64
+	add := func(deviceId string, metrics []EdgeMetric) error {
+		if _, ok := s.metrics[deviceId]; ok {
+			return fmt.Errorf("sparkplug: duplicate device %s", deviceId)
+		}
+		s.deviceIds = append(s.deviceIds, deviceId)
+		index := map[string]int{}
+		list := make([]Metric, 0, len(metrics))
+		for _, m := range metrics {
+			if m.Name == "" {
+				return errors.New("sparkplug: metric name can not be empty")
+			}
+			if _, ok := index[m.Name]; ok {
+				return fmt.Errorf("sparkplug: duplicate metric %s", m.Name)
+			}
+			switch m.DataType {
+			case Unknown, DataSet, Template, PropertySet, PropertySetList:
+				return fmt.Errorf("sparkplug: metric %s: unsupported data type %s", m.Name, m.DataType)
+			}
+			// 先编码一次，检查初始值
+			if _, err := encodeMetric(Metric{Name: m.Name, DataType: m.DataType, Value: m.Value}); err != nil {
+				return err
+			}
+			metric := Metric{Name: m.Name, DataType: m.DataType, Value: m.Value}
+			if s.useAliases {
+				alias++
+				a := alias
+				metric.Alias = &a
+			}
+			index[m.Name] = len(list)
+			list = append(list, metric)
+		}
+		s.metrics[deviceId] = list
+		s.index[deviceId] = index
+		return nil
+	}
+	if err := add("", conf.Metrics); err != nil {
+		return nil, err
+	}
+	for _, d := range conf.Devices {
+		if d.DeviceId == "" {
+			return nil, errors.New("sparkplug: deviceId can not be empty")
+		}
+		if err := add(d.DeviceId, d.Metrics); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// topic 节点或者设备的主题
+func (s *edgeState) topic(messageType, deviceId string) string {
+	if deviceId != "" {
+		messageType = "D" + messageType[1:]
+	}
+	return Topic{GroupId: s.groupId, MessageType: messageType, EdgeNodeId: s.edgeNodeId, DeviceId: deviceId}.String()
+}
+
+// nextSeq 返回下一条消息的序号
+func (s *edgeState) nextSeq() *uint64 {
+	seq := s.seq
+	s.seq = (s.seq + 1) % 256
+	return &seq
+}
+
+// death 节点的死亡证明，作为 MQTT 遗嘱
+func (s *edgeState) death() (message, error) {
+	payload, err := EncodePayload(&Payload{
+		Timestamp: uint64(time.Now().UnixMilli()),
+		Metrics:   []Metric{{Name: BdSeqMetric, DataType: UInt64, Value: s.bdSeq}},
+	})
+	return message{topic: s.topic(NDEATH, ""), payload: payload}, err
+}
+
+// births 节点和设备的出生证明，包含全部指标的名称、别名、数据类型和当前值，序号从 0 开始
+func (s *edgeState) births() ([]message, error) {
+	s.seq = 0
+	now := uint64(time.Now().UnixMilli())
+	messages := make([]message, 0, len(s.deviceIds))
+	for _, deviceId := range s.deviceIds {
+		p := &Payload{Timestamp: now, Seq: s.nextSeq()}
+		if deviceId == "" {
+			p.Metrics = append(p.Metrics,
+				Metric{Name: BdSeqMetric, Timestamp: now, DataType: UInt64, Value: s.bdSeq},
+				Metric{Name: RebirthMetric, Timestamp: now, DataType: Boolean, Value: false},
+			)
+		}
+		for _, m := range s.metrics[deviceId] {
+			m.Timestamp = now
+			p.Metrics = append(p.Metrics, m)
+		}
+		payload, err := EncodePayload(p)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message{topic: s.topic(NBIRTH, deviceId), payload: payload})
+	}
+	return messages, nil
+}
+
+// data 节点或者设备的数据消息，使用别名时只发送别名
+func (s *edgeState) data(deviceId string, values map[string]interface{}) (message, error) {
+	index, ok := s.index[deviceId]
+	if !ok {
+		return message{}, fmt.Errorf("sparkplug: unknown device %s", deviceId)
+	}
+	if len(values) == 0 {
+		return message{}, errors.New("sparkplug: no metrics to publish")
+	}
+	for name := range values {
+		if _, ok := index[name]; !ok {
+			return message{}, fmt.Errorf("%w: %s", ErrUnknownMetric, name)
+		}
+	}
+	now := uint64(time.Now().UnixMilli())
+	seq := s.seq
+	metrics := s.metrics[deviceId]
+	p := &Payload{Timestamp: now, Seq: &seq}
+	// 按出生证明中的顺序输出
+	for i := range metrics {
+		v, ok := values[metrics[i].Name]
+		if !ok {
+			continue
+		}
+		m := Metric{Name: metrics[i].Name, Alias: metrics[i].Alias, Timestamp: now, DataType: metrics[i].DataType, Value: v}
+		if m.Alias != nil {
+			m.Name = ""
+		}
+		p.Metrics = append(p.Metrics, m)
+	}
+	payload, err := EncodePayload(p)
+	if err != nil {
+		return message{}, err
+	}
+	// 编码成功才更新当前值和序号
+	for i := range metrics {
+		if v, ok := values[metrics[i].Name]; ok {
+			metrics[i].Value = v
+		}
+	}
+	s.nextSeq()
+	return message{topic: s.topic(NDATA, deviceId), payload: payload}, nil
+}
+
+// EdgeNode Sparkplug B 边缘节点，连接时以 NDEATH 作为遗嘱，连接成功后发布 NBIRTH 和 DBIRTH，
+// 订阅 NCMD，收到 Node Control/Rebirth 命令时重新发布出生证明
+type EdgeNode struct {
+	client paho.Client
+	// mu 保护 state，保证序号顺序和发布顺序一致
+	mu    sync.Mutex
+	state *edgeState
+}
+
+// NewEdgeNode 创建边缘节点并连接 MQTT 服务器，断开后自动重连并重新发布出生证明
+func NewEdgeNode(conf EdgeConfig) (*EdgeNode, error) {
+	state, err := newEdgeState(conf)
+	if err != nil {
+		return nil, err
+	}
+	will, err := state.death()
+	if err != nil {
+		return nil, err
+	}
+	e := &EdgeNode{state: state}
+	opts := paho.NewClientOptions()
+	opts.AddBroker(conf.Server)
+	opts.SetUsername(conf.Username)
+	opts.SetPassword(conf.Password)
+	if conf.ClientID == "" {
+		opts.SetClientID("rulego/" + str.RandomStr(8))
+	} else {
+		opts.SetClientID(conf.ClientID)
+	}
+	// Sparkplug 要求边缘节点使用 clean session
+	opts.SetCleanSession(true)
+	opts.SetAutoReconnect(true)
+	opts.SetOrderMatters(false)
+	opts.SetBinaryWill(will.topic, will.payload, 1, false)
+	opts.SetOnConnectHandler(e.onConnected)
+	if tlsConf := conf.TLS.WithFiles(conf.CAFile, conf.CertFile, conf.CertKeyFile); tlsConf.Enabled() {
+		tlsConfig, err := tlsConf.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+	e.client = paho.NewClient(opts)
+	token := e.client.Connect()
+	if !token.WaitTimeout(edgeTimeout) {
+		e.client.Disconnect(0)
+		return nil, fmt.Errorf("sparkplug: connect %s timeout", conf.Server)
+	}
+	if token.Error() != nil {
+		return nil, token.Error()
+	}
+	return e, nil
+}
+
+// Publish 发布节点（deviceId 为空）或者设备的数据，指标必须在出生证明中定义
+func (e *EdgeNode) Publish(deviceId string, values map[string]interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	msg, err := e.state.data(deviceId, values)
+	if err != nil {
+		return err
+	}
+	return e.publish(msg)
+}
+
+// Rebirth 重新发布节点和设备的出生证明
+func (e *EdgeNode) Rebirth() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	messages, err := e.state.births()
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if err = e.publish(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 发布死亡证明并断开连接
+func (e *EdgeNode) Close() error {
+	e.mu.Lock()
+	msg, err := e.state.death()
+	if err == nil && e.client.IsConnectionOpen() {
+		_ = e.publish(msg)
+	}
+	e.mu.Unlock()
+	e.client.Disconnect(250)
+	return nil
+}
+
+func (e *EdgeNode) publish(msg message) error {
+	token := e.client.Publish(msg.topic, 0, false, msg.payload)
+	if !token.WaitTimeout(edgeTimeout) {
+		return fmt.Errorf("sparkplug: publish %s timeout", msg.topic)
+	}
+	return token.Error()
+}
+
+// onConnected 订阅节点命令并发布出生证明
+func (e *EdgeNode) onConnected(c paho.Client) {
+	topic := e.state.topic(NCMD, "")
+	c.Subscribe(topic, 1, e.onCommand).WaitTimeout(edgeTimeout)
+	_ = e.Rebirth()
+}
+
+// onCommand 处理节点命令，只支持 Node Control/Rebirth
+func (e *EdgeNode) onCommand(c paho.Client, m paho.Message) {
+	p, err := DecodePayload(m.Payload())
+	if err != nil {
+		return
+	}
+	for _, metric := range p.Metrics {
+		if metric.Name == RebirthMetric && metric.Value == true {
+			_ = e.Rebirth()
+			return
+		}
+	}
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func testEdgeConfig(useAliases bool) EdgeConfig {
+	return EdgeConfig{
+		GroupId:    "plant",
+		EdgeNodeId: "edge1",
+		UseAliases: useAliases,
+		Metrics: []EdgeMetric{
+			{Name: "temperature", DataType: Float, Value: 20},
+		},
+		Devices: []EdgeDevice{
+			{DeviceId: "pump", Metrics: []EdgeMetric{
+				{Name: "speed", DataType: Int32},
+				{Name: "running", DataType: Boolean, Value: false},
+			}},
+		},
+	}
+}
+
+func TestEdgeState(t *testing.T) {
+	state, err := newEdgeState(testEdgeConfig(true))
+	assert.Nil(t, err)
+
+	messages, err := state.births()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(messages))
+	assert.Equal(t, "spBv1.0/plant/NBIRTH/edge1", messages[0].topic)
+	assert.Equal(t, "spBv1.0/plant/DBIRTH/edge1/pump", messages[1].topic)
+
+	nbirth, err := DecodePayload(messages[0].payload)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), *nbirth.Seq)
+	assert.Equal(t, 3, len(nbirth.Metrics))
+	assert.Equal(t, BdSeqMetric, nbirth.Metrics[0].Name)
+	assert.Equal(t, RebirthMetric, nbirth.Metrics[1].Name)
+	assert.Equal(t, "temperature", nbirth.Metrics[2].Name)
+	assert.Equal(t, uint64(1), *nbirth.Metrics[2].Alias)
+	assert.Equal(t, float32(20), nbirth.Metrics[2].Value)
+
+	dbirth, err := DecodePayload(messages[1].payload)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), *dbirth.Seq)
+	assert.Equal(t, uint64(2), *dbirth.Metrics[0].Alias)
+	assert.True(t, dbirth.Metrics[0].IsNull)
+	assert.Equal(t, uint64(3), *dbirth.Metrics[1].Alias)
+
+	// 数据消息只发送别名，接收方按出生证明补全名称
+	msg, err := state.data("pump", map[string]interface{}{"speed": -10, "running": true})
+	assert.Nil(t, err)
+	assert.Equal(t, "spBv1.0/plant/DDATA/edge1/pump", msg.topic)
+	ddata, err := DecodePayload(msg.payload)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), *ddata.Seq)
+	assert.Equal(t, 2, len(ddata.Metrics))
+	assert.Equal(t, "", ddata.Metrics[0].Name)
+	assert.Equal(t, uint64(2), *ddata.Metrics[0].Alias)
+	assert.Equal(t, int32(-10), ddata.Metrics[0].Value)
+
+	aliases := NewAliasTable()
+	aliases.Apply(Topic{GroupId: "plant", MessageType: NBIRTH, EdgeNodeId: "edge1"}, nbirth)
+	aliases.Apply(Topic{GroupId: "plant", MessageType: DBIRTH, EdgeNodeId: "edge1", DeviceId: "pump"}, dbirth)
+	unknown := aliases.Apply(Topic{GroupId: "plant", MessageType: DDATA, EdgeNodeId: "edge1", DeviceId: "pump"}, ddata)
+	assert.False(t, unknown)
+	assert.Equal(t, "speed", ddata.Metrics[0].Name)
+	assert.Equal(t, "running", ddata.Metrics[1].Name)
+
+	// 未定义的指标和无法编码的值不发布，也不占用序号
+	_, err = state.data("", map[string]interface{}{"humidity": 1})
+	assert.True(t, errors.Is(err, ErrUnknownMetric))
+	_, err = state.data("", map[string]interface{}{"temperature": "hot"})
+	assert.NotNil(t, err)
+	_, err = state.data("fan", map[string]interface{}{"speed": 1})
+	assert.NotNil(t, err)
+
+	msg, err = state.data("", map[string]interface{}{"temperature": 21.5})
+	assert.Nil(t, err)
+	assert.Equal(t, "spBv1.0/plant/NDATA/edge1", msg.topic)
+	ndata, err := DecodePayload(msg.payload)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), *ndata.Seq)
+	assert.Equal(t, float32(21.5), ndata.Metrics[0].Value)
+
+	// 重新发布出生证明时使用最新的值，序号从 0 开始
+	messages, err = state.births()
+	assert.Nil(t, err)
+	nbirth, err = DecodePayload(messages[0].payload)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), *nbirth.Seq)
+	assert.Equal(t, float32(21.5), nbirth.Metrics[2].Value)
+
+	// 序号 255 之后回到 0
+	state.seq = 255
+	msg, err = state.data("", map[string]interface{}{"temperature": 1})
+	assert.Nil(t, err)
+	ndata, _ = DecodePayload(msg.payload)
+	assert.Equal(t, uint64(255), *ndata.Seq)
+	assert.Equal(t, uint64(0), state.seq)
+
+	death, err := state.death()
+	assert.Nil(t, err)
+	assert.Equal(t, "spBv1.0/plant/NDEATH/edge1", death.topic)
+	ndeath, _ := DecodePayload(death.payload)
+	assert.Nil(t, ndeath.Seq)
+	assert.Equal(t, BdSeqMetric, ndeath.Metrics[0].Name)
+}
+
+func TestEdgeStateWithoutAliases(t *testing.T) {
+	state, err := newEdgeState(testEdgeConfig(false))
+	assert.Nil(t, err)
+	msg, err := state.data("pump", map[string]interface{}{"speed": 5})
+	assert.Nil(t, err)
+	ddata, _ := DecodePayload(msg.payload)
+	assert.Equal(t, "speed", ddata.Metrics[0].Name)
+	assert.Nil(t, ddata.Metrics[0].Alias)
+}
+
+func TestEdgeStateInvalid(t *testing.T) {
+	for _, conf := range []EdgeConfig{
+		{GroupId: "plant"},
+		{GroupId: "plant", EdgeNodeId: "edge1", Metrics: []EdgeMetric{{Name: "a", DataType: Int8}, {Name: "a", DataType: Int8}}},
+		{GroupId: "plant", EdgeNodeId: "edge1", Metrics: []EdgeMetric{{Name: "a", DataType: DataSet}}},
+		{GroupId: "plant", EdgeNodeId: "edge1", Metrics: []EdgeMetric{{Name: "a", DataType: Int8, Value: "x"}}},
+		{GroupId: "plant", EdgeNodeId: "edge1", Devices: []EdgeDevice{{DeviceId: "d"}, {DeviceId: "d"}}},
+		{GroupId: "plant", EdgeNodeId: "edge1", Devices: []EdgeDevice{{}}},
+	} {
+		_, err := newEdgeState(conf)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestPublishNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&PublishNode{})
+
+	// 不支持的数据类型
+	_, err := test.CreateAndInitNode("x/sparkplugPublish", types.Configuration{
+		"server":  "127.0.0.1:1883",
+		"metrics": []map[string]interface{}{{"name": "a", "dataType": "Decimal"}},
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/sparkplugPublish", types.Configuration{
+		"server":     "127.0.0.1:1883",
+		"groupId":    "plant",
+		"edgeNodeId": "edge1",
+		"metrics":    []map[string]interface{}{{"name": "temperature", "dataType": "Float", "value": 20}},
+		"devices": []map[string]interface{}{
+			{"deviceId": "pump", "metrics": []map[string]interface{}{{"name": "speed", "dataType": "Int32"}}},
+		},
+		"deviceId": "${metadata.deviceId}",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	edgeConfig, err := node.(*PublishNode).Config.EdgeConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, Float, edgeConfig.Metrics[0].DataType)
+	assert.Equal(t, Int32, edgeConfig.Devices[0].Metrics[0].DataType)
+	assert.True(t, edgeConfig.UseAliases)
+
+	test.NodeOnMsg(t, node, []test.Msg{{
+		MetaData: types.NewMetadata(),
+		DataType: types.JSON,
+		MsgType:  "TEST",
+		Data:     `[1,2]`,
+	}}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// HostState 主机应用的 STATE 消息负荷
+type HostState struct {
+	Online bool `json:"online"`
+	// Timestamp 毫秒时间戳，出生和死亡证明使用相同的时间戳
+	Timestamp int64 `json:"timestamp"`
+}
+
+// HostConfig 主机应用配置
+type HostConfig struct {
+	// Server MQTT 服务器地址，格式：host:port
+	Server      string
+	Username    string
+	Password    string
+	ClientID    string
+	CAFile      string
+	CertFile    string
+	CertKeyFile string
+	// TLS TLS 配置，证书文件为空时使用 CAFile、CertFile、CertKeyFile
+	TLS tlsconfig.Config
+	// HostId 主机应用标识，STATE 主题为 spBv1.0/STATE/<host_id>
+	HostId string
+}
+
+// HostApplication Sparkplug B 主机应用，连接时以 {"online": false} 的 STATE 作为保留遗嘱，
+// 连接成功后发布保留的 {"online": true} STATE，边缘节点据此判断主要主机应用是否在线
+type HostApplication struct {
+	client paho.Client
+	topic  string
+	// timestamp 出生和死亡证明的时间戳，重连时保持不变，和遗嘱一致
+	timestamp int64
+}
+
+// Validate 检查主机应用标识
+func (c HostConfig) Validate() error {
+	if c.HostId == "" {
+		return errors.New("sparkplug: hostId can not be empty")
+	}
+	return c.TLS.WithFiles(c.CAFile, c.CertFile, c.CertKeyFile).Validate()
+}
+
+// NewHostApplication 创建主机应用并连接 MQTT 服务器，断开后自动重连并重新发布 STATE 出生证明
+func NewHostApplication(conf HostConfig) (*HostApplication, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	h := &HostApplication{
+		topic:     Topic{MessageType: STATE, HostId: conf.HostId}.String(),
+		timestamp: time.Now().UnixMilli(),
+	}
+	will, _ := h.state(false)
+	opts := paho.NewClientOptions()
+	opts.AddBroker(conf.Server)
+	opts.SetUsername(conf.Username)
+	opts.SetPassword(conf.Password)
+	if conf.ClientID == "" {
+		opts.SetClientID("rulego/" + str.RandomStr(8))
+	} else {
+		opts.SetClientID(conf.ClientID)
+	}
+	opts.SetCleanSession(true)
+	opts.SetAutoReconnect(true)
+	opts.SetBinaryWill(h.topic, will, 1, true)
+	opts.SetOnConnectHandler(func(c paho.Client) {
+		_ = h.publish(true)
+	})
+	if tlsConf := conf.TLS.WithFiles(conf.CAFile, conf.CertFile, conf.CertKeyFile); tlsConf.Enabled() {
+		tlsConfig, err := tlsConf.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+	h.client = paho.NewClient(opts)
+	token := h.client.Connect()
+	if !token.WaitTimeout(edgeTimeout) {
+		h.client.Disconnect(0)
+		return nil, fmt.Errorf("sparkplug: connect %s timeout", conf.Server)
+	}
+	if token.Error() != nil {
+		return nil, token.Error()
+	}
+	return h, nil
+}
+
+// Topic STATE 主题
+func (h *HostApplication) Topic() string {
+	return h.topic
+}
+
+// Close 发布 STATE 死亡证明并断开连接
+func (h *HostApplication) Close() error {
+	if h.client.IsConnectionOpen() {
+		_ = h.publish(false)
+	}
+	h.client.Disconnect(250)
+	return nil
+}
+
+// state 编码 STATE 负荷
+func (h *HostApplication) state(online bool) ([]byte, error) {
+	return json.Marshal(HostState{Online: online, Timestamp: h.timestamp})
+}
+
+// publish 发布保留的 STATE 消息，QoS 为 1
+func (h *HostApplication) publish(online bool) error {
+	payload, err := h.state(online)
+	if err != nil {
+		return err
+	}
+	token := h.client.Publish(h.topic, 1, true, payload)
+	if !token.WaitTimeout(edgeTimeout) {
+		return fmt.Errorf("sparkplug: publish %s timeout", h.topic)
+	}
+	return token.Error()
+}
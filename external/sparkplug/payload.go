@@ -0,0 +1,458 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// DataType Sparkplug B 指标数据类型
+type DataType uint32
+
+// 指标数据类型
+const (
+	Unknown         DataType = 0
+	Int8            DataType = 1
+	Int16           DataType = 2
+	Int32           DataType = 3
+	Int64           DataType = 4
+	UInt8           DataType = 5
+	UInt16          DataType = 6
+	UInt32          DataType = 7
+	UInt64          DataType = 8
+	Float           DataType = 9
+	Double          DataType = 10
+	Boolean         DataType = 11
+	String          DataType = 12
+	DateTime        DataType = 13
+	Text            DataType = 14
+	UUID            DataType = 15
+	DataSet         DataType = 16
+	Bytes           DataType = 17
+	File            DataType = 18
+	Template        DataType = 19
+	PropertySet     DataType = 20
+	PropertySetList DataType = 21
+)
+
+var dataTypeNames = map[DataType]string{
+	Unknown: "Unknown", Int8: "Int8", Int16: "Int16", Int32: "Int32", Int64: "Int64",
+	UInt8: "UInt8", UInt16: "UInt16", UInt32: "UInt32", UInt64: "UInt64",
+	Float: "Float", Double: "Double", Boolean: "Boolean", String: "String", DateTime: "DateTime",
+	Text: "Text", UUID: "UUID", DataSet: "DataSet", Bytes: "Bytes", File: "File",
+	Template: "Template", PropertySet: "PropertySet", PropertySetList: "PropertySetList",
+}
+
+// String 数据类型名称
+func (t DataType) String() string {
+	if name, ok := dataTypeNames[t]; ok {
+		return name
+	}
+	return strconv.Itoa(int(t))
+}
+
+// MarshalJSON 输出数据类型名称
+func (t DataType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON 支持数据类型名称或者编号
+func (t *DataType) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		var v uint32
+		if err = json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*t = DataType(v)
+		return nil
+	}
+	v, err := ParseDataType(name)
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// ParseDataType 解析数据类型名称，不区分大小写，也支持编号
+func ParseDataType(name string) (DataType, error) {
+	if v, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return DataType(v), nil
+	}
+	for t, n := range dataTypeNames {
+		if strings.EqualFold(n, name) {
+			return t, nil
+		}
+	}
+	return Unknown, fmt.Errorf("unsupported sparkplug data type: %s", name)
+}
+
+// Payload Sparkplug B 负荷
+type Payload struct {
+	// Timestamp 毫秒时间戳
+	Timestamp uint64 `json:"timestamp"`
+	// Metrics 指标
+	Metrics []Metric `json:"metrics"`
+	// Seq 序号 0-255，NDEATH 等没有序号的消息为 nil
+	Seq *uint64 `json:"seq,omitempty"`
+	// UUID 负荷格式标识
+	UUID string `json:"uuid,omitempty"`
+	// Body 自定义数据
+	Body []byte `json:"body,omitempty"`
+}
+
+// Metric Sparkplug B 指标
+type Metric struct {
+	// Name 指标名称，使用别名的数据消息中可以为空
+	Name string `json:"name,omitempty"`
+	// Alias 指标别名，在出生证明中定义
+	Alias *uint64 `json:"alias,omitempty"`
+	// Timestamp 毫秒时间戳
+	Timestamp uint64 `json:"timestamp,omitempty"`
+	// DataType 数据类型，数据消息中可以省略，使用出生证明中的类型
+	DataType DataType `json:"dataType"`
+	// IsHistorical 是否为历史数据
+	IsHistorical bool `json:"isHistorical,omitempty"`
+	// IsTransient 是否为瞬态数据，不需要存储
+	IsTransient bool `json:"isTransient,omitempty"`
+	// IsNull 值是否为空
+	IsNull bool `json:"isNull,omitempty"`
+	// Value 指标值，DataSet、Template 等复杂类型保留原始的 protobuf 字节
+	Value interface{} `json:"value"`
+}
+
+// protobuf 字段类型
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errTruncated 数据不完整
+var errTruncated = errors.New("sparkplug: truncated payload")
+
+// DecodePayload 解码 protobuf 编码的 Sparkplug B 负荷
+func DecodePayload(data []byte) (*Payload, error) {
+	p := &Payload{}
+	err := readFields(data, func(field int, wire int, u uint64, b []byte) error {
+		switch field {
+		case 1:
+			p.Timestamp = u
+		case 2:
+			m, err := decodeMetric(b)
+			if err != nil {
+				return err
+			}
+			p.Metrics = append(p.Metrics, m)
+		case 3:
+			seq := u
+			p.Seq = &seq
+		case 4:
+			p.UUID = string(b)
+		case 5:
+			p.Body = append([]byte(nil), b...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// decodeMetric 解码指标，值按数据类型转换，数据类型未知时保留 protobuf 字段的类型
+func decodeMetric(data []byte) (Metric, error) {
+	var m Metric
+	err := readFields(data, func(field int, wire int, u uint64, b []byte) error {
+		switch field {
+		case 1:
+			m.Name = string(b)
+		case 2:
+			alias := u
+			m.Alias = &alias
+		case 3:
+			m.Timestamp = u
+		case 4:
+			m.DataType = DataType(u)
+		case 5:
+			m.IsHistorical = u != 0
+		case 6:
+			m.IsTransient = u != 0
+		case 7:
+			m.IsNull = u != 0
+		case 10:
+			m.Value = uint32(u)
+		case 11:
+			m.Value = u
+		case 12:
+			m.Value = math.Float32frombits(uint32(u))
+		case 13:
+			m.Value = math.Float64frombits(u)
+		case 14:
+			m.Value = u != 0
+		case 15:
+			m.Value = string(b)
+		case 16, 17, 18, 19:
+			m.Value = append([]byte(nil), b...)
+		}
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+	if m.IsNull {
+		m.Value = nil
+	} else {
+		m.Value = ConvertValue(m.DataType, m.Value)
+	}
+	return m, nil
+}
+
+// ConvertValue 把 protobuf 字段的值转换为数据类型对应的值，有符号整数按补码转换
+func ConvertValue(t DataType, v interface{}) interface{} {
+	var u uint64
+	switch n := v.(type) {
+	case uint32:
+		u = uint64(n)
+	case uint64:
+		u = n
+	default:
+		return v
+	}
+	switch t {
+	case Int8:
+		return int8(u)
+	case Int16:
+		return int16(u)
+	case Int32:
+		return int32(u)
+	case Int64:
+		return int64(u)
+	case UInt8:
+		return uint8(u)
+	case UInt16:
+		return uint16(u)
+	case UInt32:
+		return uint32(u)
+	default:
+		return v
+	}
+}
+
+// readFields 遍历 protobuf 字段，varint、fixed32 和 fixed64 字段的值在 u 中，length-delimited 字段的值在 b 中
+func readFields(data []byte, fn func(field int, wire int, u uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		field, wire := int(key>>3), int(key&0x07)
+		var u uint64
+		var b []byte
+		switch wire {
+		case wireVarint:
+			if u, n = binary.Uvarint(data); n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			u = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			u = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errTruncated
+			}
+			b = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			return fmt.Errorf("sparkplug: unsupported wire type %d", wire)
+		}
+		if err := fn(field, wire, u, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebirthMetric 请求边缘节点重新发送出生证明的 NCMD 指标
+const RebirthMetric = "Node Control/Rebirth"
+
+// RebirthPayload 请求边缘节点重新发送出生证明的 NCMD 负荷
+func RebirthPayload() *Payload {
+	now := uint64(time.Now().UnixMilli())
+	return &Payload{
+		Timestamp: now,
+		Metrics:   []Metric{{Name: RebirthMetric, Timestamp: now, DataType: Boolean, Value: true}},
+	}
+}
+
+// EncodePayload 按 protobuf 编码 Sparkplug B 负荷，指标值按数据类型转换
+func EncodePayload(p *Payload) ([]byte, error) {
+	var buf []byte
+	buf = appendVarintField(buf, 1, p.Timestamp)
+	for _, m := range p.Metrics {
+		b, err := encodeMetric(m)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendBytesField(buf, 2, b)
+	}
+	if p.Seq != nil {
+		buf = appendVarintField(buf, 3, *p.Seq)
+	}
+	if p.UUID != "" {
+		buf = appendBytesField(buf, 4, []byte(p.UUID))
+	}
+	if len(p.Body) > 0 {
+		buf = appendBytesField(buf, 5, p.Body)
+	}
+	return buf, nil
+}
+
+// encodeMetric 编码指标
+func encodeMetric(m Metric) ([]byte, error) {
+	var buf []byte
+	if m.Name != "" {
+		buf = appendBytesField(buf, 1, []byte(m.Name))
+	}
+	if m.Alias != nil {
+		buf = appendVarintField(buf, 2, *m.Alias)
+	}
+	if m.Timestamp > 0 {
+		buf = appendVarintField(buf, 3, m.Timestamp)
+	}
+	buf = appendVarintField(buf, 4, uint64(m.DataType))
+	if m.IsHistorical {
+		buf = appendVarintField(buf, 5, 1)
+	}
+	if m.IsTransient {
+		buf = appendVarintField(buf, 6, 1)
+	}
+	if m.IsNull || m.Value == nil {
+		return appendVarintField(buf, 7, 1), nil
+	}
+	v := m.Value
+	switch m.DataType {
+	case Int8, Int16, Int32:
+		n, err := cast.ToInt64E(v)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		buf = appendVarintField(buf, 10, uint64(uint32(int32(n))))
+	case UInt8, UInt16, UInt32:
+		n, err := cast.ToInt64E(v)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		buf = appendVarintField(buf, 10, uint64(uint32(n)))
+	case Int64, UInt64, DateTime:
+		n, err := toUint64(v)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		buf = appendVarintField(buf, 11, n)
+	case Float:
+		f, err := cast.ToFloat64E(v)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		buf = appendKey(buf, 12, wireFixed32)
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(f)))
+	case Double:
+		f, err := cast.ToFloat64E(v)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		buf = appendKey(buf, 13, wireFixed64)
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(f))
+	case Boolean:
+		b, err := cast.ToBoolE(v)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		if b {
+			buf = appendVarintField(buf, 14, 1)
+		} else {
+			buf = appendVarintField(buf, 14, 0)
+		}
+	case String, Text, UUID:
+		buf = appendBytesField(buf, 15, []byte(cast.ToString(v)))
+	case Bytes, File:
+		b, ok := v.([]byte)
+		if !ok {
+			b = []byte(cast.ToString(v))
+		}
+		buf = appendBytesField(buf, 16, b)
+	default:
+		return nil, fmt.Errorf("metric %s: unsupported sparkplug data type %s", m.Name, m.DataType)
+	}
+	return buf, nil
+}
+
+// toUint64 转换为64位整数，负数按补码
+func toUint64(v interface{}) (uint64, error) {
+	switch n := v.(type) {
+	case uint64:
+		return n, nil
+	case string:
+		if u, err := strconv.ParseUint(n, 10, 64); err == nil {
+			return u, nil
+		}
+	}
+	n, err := cast.ToInt64E(v)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+func appendKey(buf []byte, field int, wire int) []byte {
+	return binary.AppendUvarint(buf, uint64(field)<<3|uint64(wire))
+}
+
+func appendVarintField(buf []byte, field int, v uint64) []byte {
+	buf = appendKey(buf, field, wireVarint)
+	return binary.AppendUvarint(buf, v)
+}
+
+func appendBytesField(buf []byte, field int, b []byte) []byte {
+	buf = appendKey(buf, field, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
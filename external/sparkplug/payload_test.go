@@ -0,0 +1,154 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestPayloadRoundTrip(t *testing.T) {
+	p := &Payload{
+		Timestamp: 1700000000000,
+		Seq:       uint64Ptr(3),
+		Metrics: []Metric{
+			{Name: "int8", Alias: uint64Ptr(1), DataType: Int8, Value: -5},
+			{Name: "int32", DataType: Int32, Value: -100000},
+			{Name: "int64", DataType: Int64, Value: int64(-1)},
+			{Name: "uint16", DataType: UInt16, Value: 65535},
+			{Name: "uint64", DataType: UInt64, Value: uint64(1 << 63)},
+			{Name: "float", DataType: Float, Value: 21.5},
+			{Name: "double", DataType: Double, Value: "3.25"},
+			{Name: "bool", DataType: Boolean, Value: true},
+			{Name: "string", DataType: String, Value: "hello"},
+			{Name: "bytes", DataType: Bytes, Value: []byte{1, 2}},
+			{Name: "null", DataType: Double, Value: nil},
+			{Name: "history", DataType: Int16, Value: 7, IsHistorical: true, Timestamp: 1699999999000},
+		},
+	}
+	data, err := EncodePayload(p)
+	assert.Nil(t, err)
+	decoded, err := DecodePayload(data)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1700000000000), decoded.Timestamp)
+	assert.Equal(t, uint64(3), *decoded.Seq)
+	assert.Equal(t, 12, len(decoded.Metrics))
+
+	m := decoded.Metrics
+	assert.Equal(t, uint64(1), *m[0].Alias)
+	assert.Equal(t, int8(-5), m[0].Value)
+	assert.Equal(t, int32(-100000), m[1].Value)
+	assert.Equal(t, int64(-1), m[2].Value)
+	assert.Equal(t, uint16(65535), m[3].Value)
+	assert.Equal(t, uint64(1<<63), m[4].Value)
+	assert.Equal(t, float32(21.5), m[5].Value)
+	assert.Equal(t, 3.25, m[6].Value)
+	assert.Equal(t, true, m[7].Value)
+	assert.Equal(t, "hello", m[8].Value)
+	assert.Equal(t, []byte{1, 2}, m[9].Value)
+	assert.True(t, m[10].IsNull)
+	assert.Nil(t, m[10].Value)
+	assert.True(t, m[11].IsHistorical)
+	assert.Equal(t, uint64(1699999999000), m[11].Timestamp)
+
+	_, err = EncodePayload(&Payload{Metrics: []Metric{{Name: "bad", DataType: Int32, Value: "abc"}}})
+	assert.NotNil(t, err)
+	_, err = DecodePayload([]byte{0x12, 0x10, 0x01})
+	assert.NotNil(t, err)
+}
+
+func TestDataTypeJSON(t *testing.T) {
+	b, err := json.Marshal(Metric{Name: "a", DataType: Float, Value: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"a","dataType":"Float","value":1}`, string(b))
+
+	var m Metric
+	assert.Nil(t, json.Unmarshal([]byte(`{"dataType":"boolean"}`), &m))
+	assert.Equal(t, Boolean, m.DataType)
+	assert.Nil(t, json.Unmarshal([]byte(`{"dataType":10}`), &m))
+	assert.Equal(t, Double, m.DataType)
+	assert.NotNil(t, json.Unmarshal([]byte(`{"dataType":"decimal"}`), &m))
+}
+
+func TestParseTopic(t *testing.T) {
+	topic, err := ParseTopic("spBv1.0/plant/DDATA/edge1/pump")
+	assert.Nil(t, err)
+	assert.Equal(t, Topic{GroupId: "plant", MessageType: DDATA, EdgeNodeId: "edge1", DeviceId: "pump"}, topic)
+	assert.Equal(t, "spBv1.0/plant/DDATA/edge1/pump", topic.String())
+	assert.Equal(t, "plant/edge1", topic.NodeKey())
+
+	topic, err = ParseTopic("spBv1.0/STATE/scada")
+	assert.Nil(t, err)
+	assert.Equal(t, Topic{MessageType: STATE, HostId: "scada"}, topic)
+	assert.Equal(t, "spBv1.0/STATE/scada", topic.String())
+
+	for _, s := range []string{
+		"spBv1.0/plant/NDATA/edge1/pump",
+		"spBv1.0/plant/DDATA/edge1",
+		"spBv1.0/plant/XDATA/edge1",
+		"spAv1.0/plant/NDATA/edge1",
+		"spBv1.0/plant",
+	} {
+		_, err = ParseTopic(s)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestAliasTable(t *testing.T) {
+	table := NewAliasTable()
+	node := Topic{GroupId: "plant", MessageType: NBIRTH, EdgeNodeId: "edge1"}
+	table.Apply(node, &Payload{Metrics: []Metric{
+		{Name: "temperature", Alias: uint64Ptr(1), DataType: Int16},
+	}})
+	device := Topic{GroupId: "plant", MessageType: DBIRTH, EdgeNodeId: "edge1", DeviceId: "pump"}
+	table.Apply(device, &Payload{Metrics: []Metric{
+		{Name: "pump/speed", Alias: uint64Ptr(2), DataType: Float},
+	}})
+	name, ok := table.Lookup("plant/edge1", 2)
+	assert.True(t, ok)
+	assert.Equal(t, "pump/speed", name)
+
+	// 只有别名的数据消息按出生证明补全名称，并且按数据类型转换值
+	data := &Payload{Metrics: []Metric{
+		{Alias: uint64Ptr(1), Value: uint32(0xffff)},
+		{Alias: uint64Ptr(2), Value: float32(1.5)},
+	}}
+	unknown := table.Apply(Topic{GroupId: "plant", MessageType: NDATA, EdgeNodeId: "edge1"}, data)
+	assert.False(t, unknown)
+	assert.Equal(t, "temperature", data.Metrics[0].Name)
+	assert.Equal(t, Int16, data.Metrics[0].DataType)
+	assert.Equal(t, int16(-1), data.Metrics[0].Value)
+	assert.Equal(t, "pump/speed", data.Metrics[1].Name)
+
+	unknown = table.Apply(Topic{GroupId: "plant", MessageType: NDATA, EdgeNodeId: "edge1"},
+		&Payload{Metrics: []Metric{{Alias: uint64Ptr(9), Value: uint32(1)}}})
+	assert.True(t, unknown)
+
+	// 节点死亡后别名失效
+	table.Apply(Topic{GroupId: "plant", MessageType: NDEATH, EdgeNodeId: "edge1"}, &Payload{})
+	_, ok = table.Lookup("plant/edge1", 1)
+	assert.False(t, ok)
+	unknown = table.Apply(Topic{GroupId: "plant", MessageType: DDATA, EdgeNodeId: "edge1", DeviceId: "pump"},
+		&Payload{Metrics: []Metric{{Alias: uint64Ptr(2), Value: float32(1)}}})
+	assert.True(t, unknown)
+}
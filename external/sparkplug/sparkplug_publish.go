@@ -0,0 +1,212 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"encoding/json"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/tlsconfig"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&PublishNode{})
+}
+
+// PublishConfiguration 发布节点配置
+type PublishConfiguration struct {
+	// Server MQTT 服务器地址，格式：host:port
+	Server string `json:"server" label:"Server" desc:"MQTT broker address, format: host:port" required:"true" ref:"primary"`
+	// Username 用户名
+	Username string `json:"username" label:"Username" desc:"MQTT authentication username" ref:"shared"`
+	// Password 密码
+	Password string `json:"password" label:"Password" desc:"MQTT authentication password" ref:"shared"`
+	// ClientID 客户端ID，为空随机生成
+	ClientID string `json:"clientId" label:"Client ID" desc:"MQTT client unique identifier, default is random"`
+	// CAFile CA 证书文件
+	CAFile string `json:"caFile" label:"CA File" desc:"CA certificate file path for TLS" ref:"shared"`
+	// CertFile 客户端证书文件
+	CertFile string `json:"certFile" label:"Cert File" desc:"TLS client certificate file path" ref:"shared"`
+	// CertKeyFile 客户端私钥文件
+	CertKeyFile string `json:"certKeyFile" label:"Cert Key File" desc:"TLS client private key file path" ref:"shared"`
+	// TLS TLS 配置，证书文件为空时使用 caFile、certFile、certKeyFile
+	TLS tlsconfig.Config `json:"tls" label:"TLS" desc:"TLS settings, certificate files fall back to caFile, certFile and certKeyFile"`
+	// GroupId 组标识
+	GroupId string `json:"groupId" label:"Group ID" desc:"Sparkplug group id" required:"true"`
+	// EdgeNodeId 边缘节点标识
+	EdgeNodeId string `json:"edgeNodeId" label:"Edge node ID" desc:"Sparkplug edge node id" required:"true"`
+	// UseAliases 是否为指标分配别名，数据消息只发送别名
+	UseAliases bool `json:"useAliases" label:"Use aliases" desc:"Assign aliases to metrics in the birth certificates and send only aliases in data messages"`
+	// Metrics 节点的指标
+	Metrics []MetricConfig `json:"metrics" label:"Metrics" desc:"Edge node metrics published in NBIRTH"`
+	// Devices 设备和设备的指标
+	Devices []DeviceConfig `json:"devices" label:"Devices" desc:"Devices and their metrics published in DBIRTH"`
+	// DeviceId 数据所属的设备，为空表示节点，允许使用 ${} 占位符变量
+	DeviceId string `json:"deviceId" label:"Device ID" desc:"Device the msg data belongs to, empty publishes NDATA for the edge node. Supports ${} placeholders"`
+}
+
+// MetricConfig 指标定义
+type MetricConfig struct {
+	// Name 指标名称
+	Name string `json:"name"`
+	// DataType 数据类型，eg. Int32、Float、Double、Boolean、String
+	DataType string `json:"dataType"`
+	// Value 出生证明中的初始值，为空表示空值
+	Value interface{} `json:"value,omitempty"`
+}
+
+// DeviceConfig 设备定义
+type DeviceConfig struct {
+	// DeviceId 设备标识
+	DeviceId string `json:"deviceId"`
+	// Metrics 设备的指标
+	Metrics []MetricConfig `json:"metrics"`
+}
+
+// PublishNode Sparkplug B 发布节点，让规则链作为一个边缘节点接入 Sparkplug 主机应用。
+// 连接时以 NDEATH 作为遗嘱，连接成功后按配置发布 NBIRTH 和 DBIRTH，收到 NCMD Node Control/Rebirth 命令时重新发布出生证明。
+// 消息负荷 msg.Data 为指标名称->值的对象，按 deviceId 发布 NDATA 或者 DDATA，带有序号，开启别名时只发送别名：
+//
+//	{"temperature": 21.5, "running": true}
+//
+// 指标必须在配置中定义。发布成功，流转到`Success`链，否则流转到`Failure`链
+type PublishNode struct {
+	base.SharedNode[*EdgeNode]
+	//节点配置
+	Config PublishConfiguration
+	// deviceIdTemplate 设备标识模板
+	deviceIdTemplate str.Template
+}
+
+// Type 返回组件类型
+func (x *PublishNode) Type() string {
+	return "x/sparkplugPublish"
+}
+
+// New 默认参数
+func (x *PublishNode) New() types.Node {
+	return &PublishNode{
+		Config: PublishConfiguration{
+			Server:     "127.0.0.1:1883",
+			GroupId:    "rulego",
+			EdgeNodeId: "edge1",
+			UseAliases: true,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *PublishNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	edgeConfig, err := x.Config.EdgeConfig()
+	if err != nil {
+		return err
+	}
+	// 先检查指标定义，避免连接时才发现配置错误
+	if _, err = newEdgeState(edgeConfig); err != nil {
+		return err
+	}
+	x.deviceIdTemplate = str.NewTemplate(x.Config.DeviceId)
+	resourcePath := x.Config.Server + "/" + x.Config.GroupId + "/" + x.Config.EdgeNodeId
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), resourcePath, ruleConfig.NodeClientInitNow, func() (*EdgeNode, error) {
+		return NewEdgeNode(edgeConfig)
+	}, func(edge *EdgeNode) error {
+		if edge != nil {
+			return edge.Close()
+		}
+		return nil
+	})
+}
+
+// OnMsg 处理消息
+func (x *PublishNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &values); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	deviceId := x.deviceIdTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	edge, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if err = edge.Publish(deviceId, values); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *PublishNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// Desc returns the component description
+func (x *PublishNode) Desc() string {
+	return "Sparkplug B edge node publishing NBIRTH/DBIRTH from configured metrics and NDATA/DDATA with sequence numbers and aliases, answering NCMD rebirth requests. Routes to Success/Failure"
+}
+
+// EdgeConfig 转换为边缘节点配置，解析数据类型
+func (c PublishConfiguration) EdgeConfig() (EdgeConfig, error) {
+	metrics, err := edgeMetrics(c.Metrics)
+	if err != nil {
+		return EdgeConfig{}, err
+	}
+	conf := EdgeConfig{
+		Server:      c.Server,
+		Username:    c.Username,
+		Password:    c.Password,
+		ClientID:    c.ClientID,
+		CAFile:      c.CAFile,
+		CertFile:    c.CertFile,
+		CertKeyFile: c.CertKeyFile,
+		TLS:         c.TLS,
+		GroupId:     c.GroupId,
+		EdgeNodeId:  c.EdgeNodeId,
+		UseAliases:  c.UseAliases,
+		Metrics:     metrics,
+	}
+	for _, d := range c.Devices {
+		metrics, err = edgeMetrics(d.Metrics)
+		if err != nil {
+			return EdgeConfig{}, err
+		}
+		conf.Devices = append(conf.Devices, EdgeDevice{DeviceId: d.DeviceId, Metrics: metrics})
+	}
+	return conf, nil
+}
+
+func edgeMetrics(list []MetricConfig) ([]EdgeMetric, error) {
+	metrics := make([]EdgeMetric, 0, len(list))
+	for _, m := range list {
+		dataType, err := ParseDataType(m.DataType)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, EdgeMetric{Name: m.Name, DataType: dataType, Value: m.Value})
+	}
+	return metrics, nil
+}
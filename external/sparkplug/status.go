@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"sort"
+	"sync"
+)
+
+// Availability 边缘节点或者设备的在线状态变化
+type Availability struct {
+	GroupId    string `json:"groupId"`
+	EdgeNodeId string `json:"edgeNodeId"`
+	// DeviceId 设备标识，节点的状态变化为空
+	DeviceId string `json:"deviceId,omitempty"`
+	Online   bool   `json:"online"`
+	// Reason 引起状态变化的消息类型：NBIRTH、NDEATH、DBIRTH、DDEATH
+	Reason string `json:"reason"`
+	// BdSeq 节点出生和死亡证明中的会话序号
+	BdSeq *uint64 `json:"bdSeq,omitempty"`
+	// Timestamp 引起状态变化的消息的毫秒时间戳
+	Timestamp uint64 `json:"timestamp"`
+}
+
+// nodeStatus 边缘节点的在线状态
+type nodeStatus struct {
+	online bool
+	// bdSeq 当前 NBIRTH 中的会话序号
+	bdSeq *uint64
+	// devices 设备的在线状态
+	devices map[string]bool
+}
+
+// StatusTracker 根据出生和死亡证明跟踪边缘节点和设备的在线状态，可以并发调用
+// NDEATH 的 bdSeq 与当前 NBIRTH 不一致时为上一个会话的遗嘱，不改变状态
+type StatusTracker struct {
+	mu    sync.Mutex
+	nodes map[string]*nodeStatus
+}
+
+// NewStatusTracker 创建状态跟踪器
+func NewStatusTracker() *StatusTracker {
+	return &StatusTracker{nodes: map[string]*nodeStatus{}}
+}
+
+// Apply 根据消息类型更新在线状态，返回状态变化
+// stale 表示过期的 NDEATH，这时不应该清除节点的别名等会话状态
+func (s *StatusTracker) Apply(topic Topic, p *Payload) (changes []Availability, stale bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := topic.NodeKey()
+	node := s.nodes[key]
+	change := func(deviceId string, online bool) Availability {
+		a := Availability{GroupId: topic.GroupId, EdgeNodeId: topic.EdgeNodeId, DeviceId: deviceId,
+			Online: online, Reason: topic.MessageType}
+		if p != nil {
+			a.Timestamp = p.Timestamp
+		}
+		if deviceId == "" && node != nil {
+			a.BdSeq = node.bdSeq
+		}
+		return a
+	}
+	switch topic.MessageType {
+	case NBIRTH:
+		online := node != nil && node.online
+		if !online {
+			node = &nodeStatus{devices: map[string]bool{}}
+			s.nodes[key] = node
+		}
+		// 在线时的 NBIRTH 为重新发送的出生证明，只更新会话序号
+		node.online = true
+		node.bdSeq = BdSeq(p)
+		if !online {
+			changes = append(changes, change("", true))
+		}
+	case NDEATH:
+		if node == nil || !node.online {
+			return nil, false
+		}
+		if bdSeq := BdSeq(p); bdSeq != nil && node.bdSeq != nil && *bdSeq != *node.bdSeq {
+			return nil, true
+		}
+		// 节点离线时下面的设备全部离线
+		devices := make([]string, 0, len(node.devices))
+		for deviceId, online := range node.devices {
+			if online {
+				devices = append(devices, deviceId)
+			}
+		}
+		sort.Strings(devices)
+		for _, deviceId := range devices {
+			changes = append(changes, change(deviceId, false))
+		}
+		changes = append(changes, change("", false))
+		node.online = false
+		node.devices = map[string]bool{}
+	case DBIRTH:
+		if node == nil {
+			// 端点启动时节点已经在线，没有收到 NBIRTH
+			node = &nodeStatus{online: true, devices: map[string]bool{}}
+			s.nodes[key] = node
+		}
+		if !node.devices[topic.DeviceId] {
+			node.devices[topic.DeviceId] = true
+			changes = append(changes, change(topic.DeviceId, true))
+		}
+	case DDEATH:
+		if node != nil && node.devices[topic.DeviceId] {
+			node.devices[topic.DeviceId] = false
+			changes = append(changes, change(topic.DeviceId, false))
+		}
+	}
+	return changes, false
+}
+
+// Online 查询节点（deviceId 为空）或者设备是否在线，nodeKey 格式：<group_id>/<edge_node_id>
+func (s *StatusTracker) Online(nodeKey, deviceId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node, ok := s.nodes[nodeKey]
+	if !ok || !node.online {
+		return false
+	}
+	if deviceId == "" {
+		return true
+	}
+	return node.devices[deviceId]
+}
+
+// BdSeq 出生或者死亡证明中的会话序号，没有返回 nil
+func BdSeq(p *Payload) *uint64 {
+	if p == nil {
+		return nil
+	}
+	for _, m := range p.Metrics {
+		if m.Name == BdSeqMetric && !m.IsNull {
+			if v, err := toUint64(m.Value); err == nil {
+				return &v
+			}
+		}
+	}
+	return nil
+}
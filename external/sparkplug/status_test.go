@@ -0,0 +1,90 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestStatusTracker(t *testing.T) {
+	tracker := NewStatusTracker()
+	topic := func(s string) Topic {
+		tp, err := ParseTopic(s)
+		assert.Nil(t, err)
+		return tp
+	}
+	birth := func(bdSeq uint64) *Payload {
+		return &Payload{Timestamp: 1000 + bdSeq, Metrics: []Metric{{Name: BdSeqMetric, DataType: UInt64, Value: bdSeq}}}
+	}
+
+	changes, stale := tracker.Apply(topic("spBv1.0/plant/NBIRTH/edge1"), birth(1))
+	assert.False(t, stale)
+	assert.Equal(t, 1, len(changes))
+	assert.True(t, changes[0].Online)
+	assert.Equal(t, uint64(1), *changes[0].BdSeq)
+	assert.Equal(t, uint64(1001), changes[0].Timestamp)
+	assert.True(t, tracker.Online("plant/edge1", ""))
+
+	// 重新发送的出生证明不产生状态变化
+	changes, _ = tracker.Apply(topic("spBv1.0/plant/NBIRTH/edge1"), birth(1))
+	assert.Equal(t, 0, len(changes))
+
+	for _, device := range []string{"pump", "fan"} {
+		changes, _ = tracker.Apply(topic("spBv1.0/plant/DBIRTH/edge1/"+device), &Payload{})
+		assert.Equal(t, 1, len(changes))
+		assert.Equal(t, device, changes[0].DeviceId)
+	}
+	changes, _ = tracker.Apply(topic("spBv1.0/plant/DDEATH/edge1/fan"), &Payload{})
+	assert.Equal(t, []Availability{{GroupId: "plant", EdgeNodeId: "edge1", DeviceId: "fan", Reason: DDEATH}}, changes)
+	assert.False(t, tracker.Online("plant/edge1", "fan"))
+	assert.True(t, tracker.Online("plant/edge1", "pump"))
+
+	// bdSeq 不一致的 NDEATH 为上一个会话的遗嘱
+	changes, stale = tracker.Apply(topic("spBv1.0/plant/NDEATH/edge1"), birth(0))
+	assert.True(t, stale)
+	assert.Equal(t, 0, len(changes))
+	assert.True(t, tracker.Online("plant/edge1", ""))
+
+	// 节点离线时在线的设备也离线
+	changes, stale = tracker.Apply(topic("spBv1.0/plant/NDEATH/edge1"), birth(1))
+	assert.False(t, stale)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, "pump", changes[0].DeviceId)
+	assert.False(t, changes[0].Online)
+	assert.Equal(t, "", changes[1].DeviceId)
+	assert.Equal(t, NDEATH, changes[1].Reason)
+	assert.False(t, tracker.Online("plant/edge1", ""))
+	assert.False(t, tracker.Online("plant/edge1", "pump"))
+
+	// 离线后重复的 NDEATH 不产生状态变化
+	changes, stale = tracker.Apply(topic("spBv1.0/plant/NDEATH/edge1"), birth(1))
+	assert.False(t, stale)
+	assert.Equal(t, 0, len(changes))
+
+	// 没有收到 NBIRTH 的节点，DBIRTH 时认为节点在线
+	changes, _ = tracker.Apply(topic("spBv1.0/plant/DBIRTH/edge2/meter"), &Payload{})
+	assert.Equal(t, 1, len(changes))
+	assert.True(t, tracker.Online("plant/edge2", "meter"))
+
+	assert.Nil(t, BdSeq(&Payload{}))
+	assert.Nil(t, BdSeq(nil))
+
+	_, err := NewHostApplication(HostConfig{Server: "127.0.0.1:1883"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Namespace Sparkplug B 主题命名空间
+const Namespace = "spBv1.0"
+
+// 消息类型
+const (
+	NBIRTH = "NBIRTH"
+	NDEATH = "NDEATH"
+	DBIRTH = "DBIRTH"
+	DDEATH = "DDEATH"
+	NDATA  = "NDATA"
+	DDATA  = "DDATA"
+	NCMD   = "NCMD"
+	DCMD   = "DCMD"
+	STATE  = "STATE"
+)
+
+// Topic Sparkplug B 主题，格式：spBv1.0/<group_id>/<message_type>/<edge_node_id>[/<device_id>]
+// 主机应用的状态主题格式：spBv1.0/STATE/<host_id>
+type Topic struct {
+	GroupId     string `json:"groupId,omitempty"`
+	MessageType string `json:"messageType"`
+	EdgeNodeId  string `json:"edgeNodeId,omitempty"`
+	DeviceId    string `json:"deviceId,omitempty"`
+	// HostId 主机应用标识，只有 STATE 消息有效
+	HostId string `json:"hostId,omitempty"`
+}
+
+// ParseTopic 解析 Sparkplug B 主题
+func ParseTopic(topic string) (Topic, error) {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 3 || parts[0] != Namespace {
+		return Topic{}, fmt.Errorf("invalid sparkplug topic: %s", topic)
+	}
+	if parts[1] == STATE {
+		return Topic{MessageType: STATE, HostId: strings.Join(parts[2:], "/")}, nil
+	}
+	if len(parts) < 4 || len(parts) > 5 {
+		return Topic{}, fmt.Errorf("invalid sparkplug topic: %s", topic)
+	}
+	t := Topic{GroupId: parts[1], MessageType: parts[2], EdgeNodeId: parts[3]}
+	if len(parts) == 5 {
+		t.DeviceId = parts[4]
+	}
+	switch t.MessageType {
+	case NBIRTH, NDEATH, NDATA, NCMD:
+		if t.DeviceId != "" {
+			return Topic{}, fmt.Errorf("invalid sparkplug topic: %s", topic)
+		}
+	case DBIRTH, DDEATH, DDATA, DCMD:
+		if t.DeviceId == "" {
+			return Topic{}, fmt.Errorf("invalid sparkplug topic: %s", topic)
+		}
+	default:
+		return Topic{}, fmt.Errorf("invalid sparkplug message type: %s", t.MessageType)
+	}
+	return t, nil
+}
+
+// String 主题字符串
+func (t Topic) String() string {
+	if t.MessageType == STATE {
+		return Namespace + "/" + STATE + "/" + t.HostId
+	}
+	topic := Namespace + "/" + t.GroupId + "/" + t.MessageType + "/" + t.EdgeNodeId
+	if t.DeviceId != "" {
+		topic += "/" + t.DeviceId
+	}
+	return topic
+}
+
+// NodeKey 边缘节点的标识，格式：<group_id>/<edge_node_id>
+func (t Topic) NodeKey() string {
+	return t.GroupId + "/" + t.EdgeNodeId
+}
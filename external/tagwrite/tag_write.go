@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tagwrite
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 输出消息的元数据key
+const (
+	// KeyProtocol 点位的协议
+	KeyProtocol = "protocol"
+	// KeyTags 写入的点位名称，多个用逗号分隔
+	KeyTags = "tags"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&WriteNode{})
+}
+
+// WriteConfiguration 节点配置
+type WriteConfiguration struct {
+	// Tag 写入的点位名称，允许使用 ${} 占位符变量，为空则使用消息负荷 msg.Data 中的写入命令
+	Tag string `json:"tag" label:"Tag" desc:"Tag to write, supports ${metadata.key} and ${msg.key} placeholders. Empty uses the write commands in msg.Data"`
+	// Value 写入的值，允许使用 ${} 占位符变量，结果为 JSON 时按 JSON 值写入，否则按字符串写入；为空写入整个消息负荷
+	Value string `json:"value" label:"Value" desc:"Value to write when tag is set, supports placeholders. JSON results keep their type. Empty writes the whole msg.Data"`
+}
+
+// WriteNode 点位写入节点，把 "写入点位 X = 值" 的命令路由到点位所属协议的写入节点。
+// 点位在全局点位注册表（pkg/tags）中声明，writable 为 true 才允许写入，写入命令来自配置 tag、value，或者消息负荷 msg.Data：
+//
+//	{"tag": "boiler.setpoint", "value": 21.5}
+//	[{"tag": "boiler.setpoint", "value": 21.5}, {"tag": "pump.run", "value": true}]
+//	{"boiler.setpoint": 21.5, "pump.run": true}
+//
+// 写入命令转换为对应写入节点的消息负荷（见 Plan），以点位的 route（为空使用协议名称 opcua、modbus、s7、bacnet）作为关系类型流转，
+// 规则链通过关系类型连接 x/opcuaWrite、x/modbus、x/s7Write、x/bacnetWrite 等写入节点。
+// 相同关系类型的点位合并为一条消息，Modbus 每个点位一条消息，命令保存在元数据 cmd、address、value 中，
+// x/modbus 节点的 cmd、address、value 配置为 ${metadata.cmd}、${metadata.address}、${metadata.value}。
+// 输出消息的元数据 protocol 为点位的协议，tags 为写入的点位名称。
+// 任意一个点位不存在、不可写或者值无法转换，不输出写入消息，流转到`Failure`链
+type WriteNode struct {
+	//节点配置
+	Config        WriteConfiguration
+	tagTemplate   str.Template
+	valueTemplate str.Template
+}
+
+// Type 返回组件类型
+func (x *WriteNode) Type() string {
+	return "x/tagWrite"
+}
+
+// New 默认参数
+func (x *WriteNode) New() types.Node {
+	return &WriteNode{}
+}
+
+// Init 初始化组件
+func (x *WriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.tagTemplate, x.valueTemplate = nil, nil
+	if strings.TrimSpace(x.Config.Tag) != "" {
+		x.tagTemplate = str.NewTemplate(x.Config.Tag)
+		if x.Config.Value != "" {
+			x.valueTemplate = str.NewTemplate(x.Config.Value)
+		}
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *WriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	commands, err := x.commands(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	writes, err := Plan(tags.Default(), commands)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	outputs := make([]types.RuleMsg, 0, len(writes))
+	for i, w := range writes {
+		b, err := json.Marshal(w.Data)
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		out := msg
+		if i > 0 {
+			out = msg.Copy()
+		}
+		out.Metadata.PutValue(KeyProtocol, w.Protocol)
+		out.Metadata.PutValue(KeyTags, strings.Join(w.Tags, ","))
+		for k, v := range w.Metadata {
+			out.Metadata.PutValue(k, v)
+		}
+		out.DataType = types.JSON
+		out.SetData(str.ToString(b))
+		outputs = append(outputs, out)
+	}
+	for i, out := range outputs {
+		ctx.TellNext(out, writes[i].Route)
+	}
+}
+
+// commands 获取写入命令
+func (x *WriteNode) commands(ctx types.RuleContext, msg types.RuleMsg) ([]Command, error) {
+	if x.tagTemplate == nil {
+		return ParseCommands([]byte(msg.GetData()))
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	raw := msg.GetData()
+	if x.valueTemplate != nil {
+		raw = x.valueTemplate.Execute(evn)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+	return []Command{{Tag: x.tagTemplate.Execute(evn), Value: value}}, nil
+}
+
+// Destroy 销毁组件
+func (x *WriteNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *WriteNode) Desc() string {
+	return "Routes \"write tag = value\" commands to the OPC UA, Modbus, S7 or BACnet write path of each writable tag in the tag registry. Routes to the tag route (protocol by default)/Failure"
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tagwrite
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestWriteNode(t *testing.T) {
+	old := tags.Default()
+	tags.SetDefault(testRegistry(t))
+	defer tags.SetDefault(old)
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&WriteNode{})
+
+	node, err := test.CreateAndInitNode("x/tagWrite", types.Configuration{}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+	configured, err := test.CreateAndInitNode("x/tagWrite", types.Configuration{
+		"tag":   "${metadata.tag}",
+		"value": "${metadata.value}",
+	}, Registry)
+	assert.Nil(t, err)
+	defer configured.Destroy()
+
+	outputs := make(map[string]types.RuleMsg)
+	var failures int
+	// 节点在 OnMsg 返回前完成回调，同步处理后再断言
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		if relationType == types.Failure {
+			failures++
+			return
+		}
+		outputs[relationType] = msg
+	})
+	for _, data := range []string{
+		`{"boiler.setpoint": 21.5, "pump.freq": 50, "ahu.damper": 0.25}`,
+		`{"tag": "boiler.temp", "value": 30}`,
+		`"write"`,
+	} {
+		node.OnMsg(ctx, types.NewMsg(0, "COMMAND", types.JSON, types.NewMetadata(), data))
+	}
+	assert.Equal(t, 2, failures)
+	assert.Equal(t, 3, len(outputs))
+
+	msg := outputs["opcua"]
+	assert.Equal(t, "opcua", msg.Metadata.GetValue(KeyProtocol))
+	assert.Equal(t, "boiler.setpoint", msg.Metadata.GetValue(KeyTags))
+	var items []map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &items))
+	assert.Equal(t, "tag:boiler.setpoint", items[0]["nodeId"])
+
+	msg = outputs["pump"]
+	assert.Equal(t, "modbus", msg.Metadata.GetValue(KeyProtocol))
+	assert.Equal(t, "WriteRegister", msg.Metadata.GetValue(KeyCmd))
+	assert.Equal(t, "100", msg.Metadata.GetValue(KeyAddress))
+	assert.Equal(t, "500", msg.Metadata.GetValue(KeyValue))
+
+	msg = outputs["bacnet"]
+	assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &items))
+	assert.Equal(t, 25.0, items[0]["value"])
+	assert.Equal(t, 8.0, items[0]["priority"])
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("tag", "tag:pump.run")
+	metadata.PutValue("value", "true")
+	outputs = make(map[string]types.RuleMsg)
+	configured.OnMsg(ctx, types.NewMsg(0, "COMMAND", types.JSON, metadata, `{}`))
+	assert.Equal(t, "WriteCoil", outputs["modbus"].Metadata.GetValue(KeyCmd))
+	assert.Equal(t, "true", outputs["modbus"].Metadata.GetValue(KeyValue))
+	assert.Equal(t, "pump.run", outputs["modbus"].Metadata.GetValue(KeyTags))
+}
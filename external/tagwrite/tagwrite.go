@@ -0,0 +1,333 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tagwrite 提供可写虚拟点位的命令路由：点位在全局点位注册表（pkg/tags）中声明一次，
+// "写入点位 X = 值" 的命令按点位的协议转换为 OPC UA、Modbus、S7、BACnet 写入节点的消息格式，
+// 由规则链按关系类型路由到对应的写入节点，云端到现场的指令只需要一种格式
+package tagwrite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+)
+
+// Modbus 写入消息的元数据key，x/modbus 节点配置 cmd、address、value 为 ${metadata.cmd}、${metadata.address}、${metadata.value}
+const (
+	KeyCmd     = "cmd"
+	KeyAddress = "address"
+	KeyValue   = "value"
+)
+
+// Modbus 数据区
+const (
+	areaCoil            = "coil"
+	areaHoldingRegister = "holdingRegister"
+)
+
+// ErrNotWritable 点位不允许写入
+var ErrNotWritable = errors.New("tag is not writable")
+
+// Command 点位写入命令
+type Command struct {
+	// Tag 点位名称，允许带 tag: 前缀
+	Tag string `json:"tag"`
+	// Value 写入的工程值，null 表示释放 BACnet 命令优先级
+	Value interface{} `json:"value"`
+}
+
+// Write 路由到一个写入节点的消息
+type Write struct {
+	// Route 关系类型，点位的 route，为空使用协议名称
+	Route string
+	// Protocol 协议
+	Protocol string
+	// Tags 写入的点位名称
+	Tags []string
+	// Data 写入节点的消息负荷
+	Data interface{}
+	// Metadata 写入节点使用的元数据，只用于 Modbus
+	Metadata map[string]string
+}
+
+// ParseCommands 解析消息负荷中的写入命令，支持以下格式：
+//
+//	{"tag": "boiler.setpoint", "value": 21.5}
+//	[{"tag": "boiler.setpoint", "value": 21.5}, {"tag": "pump.run", "value": true}]
+//	{"boiler.setpoint": 21.5, "pump.run": true}
+//
+// 点位名称->值的对象按名称排序
+func ParseCommands(data []byte) ([]Command, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var commands []Command
+	switch value := v.(type) {
+	case []interface{}:
+		for _, item := range value {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid tag write command: %v", item)
+			}
+			c, err := parseCommand(m)
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, c)
+		}
+	case map[string]interface{}:
+		if _, ok := value["tag"]; ok {
+			c, err := parseCommand(value)
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, c)
+			break
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			commands = append(commands, Command{Tag: name, Value: value[name]})
+		}
+	default:
+		return nil, errors.New("tag write commands must be an object or an array")
+	}
+	if len(commands) == 0 {
+		return nil, errors.New("no tag write commands")
+	}
+	return commands, nil
+}
+
+func parseCommand(m map[string]interface{}) (Command, error) {
+	name, ok := m["tag"].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return Command{}, errors.New("tag write command requires a tag name")
+	}
+	return Command{Tag: name, Value: m["value"]}, nil
+}
+
+// Plan 在注册表中查找点位，把写入命令转换为各写入节点的消息。
+// 相同关系类型的 OPC UA、S7、BACnet 点位合并为一条消息，Modbus 每个点位一条消息；
+// 任意一个点位不存在、不可写或者值无法转换时返回错误，不输出任何消息
+//   - opcua：x/opcuaWrite 的点位数组 [{"nodeId": "tag:<名称>", "value": 21.5}]，由写入节点按点位解析地址、数据类型和缩放
+//   - s7：x/s7Write 的地址->值对象 {"tag:<名称>": 21.5}，由写入节点按点位解析地址和缩放
+//   - bacnet：x/bacnetWrite 的对象属性数组 [{"objectType": "analog-output", "instance": 1, "value": 21.5, "priority": 8}]，
+//     点位地址格式为 objectType:instance[:property]，dataType 作为 valueType，值按缩放换算为原始值
+//   - modbus：x/modbus 的命令，保存在元数据 cmd、address、value 中，点位地址格式为 [coil:|holdingRegister:]address，
+//     线圈使用 WriteCoil，寄存器按数据类型使用 WriteRegister、WriteUint32、WriteFloat32、WriteUint64、WriteFloat64、WriteBytes，
+//     值按缩放换算为原始值，整数四舍五入
+func Plan(registry *tags.Registry, commands []Command) ([]Write, error) {
+	var writes []*Write
+	routes := make(map[string]*Write)
+	for _, c := range commands {
+		name := c.Tag
+		if ref, ok := tags.ParseRef(name); ok {
+			name = ref
+		}
+		name = strings.TrimSpace(name)
+		tag, ok := registry.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", tags.ErrTagNotFound, name)
+		}
+		if !tag.Writable {
+			return nil, fmt.Errorf("%w: %s", ErrNotWritable, name)
+		}
+		protocol := strings.ToLower(tag.Protocol)
+		route := tag.WriteRoute()
+		if protocol == tags.ProtocolModbus {
+			metadata, err := modbusCommand(tag, c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: %w", name, err)
+			}
+			data := map[string]interface{}{"tag": name}
+			for k, v := range metadata {
+				data[k] = v
+			}
+			writes = append(writes, &Write{Route: route, Protocol: protocol, Tags: []string{name}, Data: data, Metadata: metadata})
+			continue
+		}
+		w, ok := routes[route]
+		if !ok {
+			w = &Write{Route: route, Protocol: protocol}
+			routes[route] = w
+			writes = append(writes, w)
+		} else if w.Protocol != protocol {
+			return nil, fmt.Errorf("route %s is used by both %s and %s tags", route, w.Protocol, protocol)
+		}
+		switch protocol {
+		case tags.ProtocolOPCUA:
+			items, _ := w.Data.([]map[string]interface{})
+			w.Data = append(items, map[string]interface{}{"nodeId": tags.RefPrefix + name, "value": c.Value})
+		case tags.ProtocolS7:
+			values, _ := w.Data.(map[string]interface{})
+			if values == nil {
+				values = make(map[string]interface{})
+				w.Data = values
+			}
+			values[tags.RefPrefix+name] = c.Value
+		case tags.ProtocolBACnet:
+			item, err := bacnetItem(tag, c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: %w", name, err)
+			}
+			items, _ := w.Data.([]map[string]interface{})
+			w.Data = append(items, item)
+		default:
+			return nil, fmt.Errorf("tag %s: unsupported write protocol: %s", name, tag.Protocol)
+		}
+		w.Tags = append(w.Tags, name)
+	}
+	result := make([]Write, 0, len(writes))
+	for _, w := range writes {
+		result = append(result, *w)
+	}
+	return result, nil
+}
+
+// bacnetItem 转换为 x/bacnetWrite 的对象属性，地址格式：objectType:instance[:property]
+func bacnetItem(tag tags.Tag, value interface{}) (map[string]interface{}, error) {
+	parts := strings.Split(tag.Address, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid bacnet address: %s", tag.Address)
+	}
+	instance, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bacnet instance: %s", tag.Address)
+	}
+	item := map[string]interface{}{
+		"objectType": strings.TrimSpace(parts[0]),
+		"instance":   instance,
+		"value":      tag.Unscaled(value),
+	}
+	if len(parts) == 3 {
+		item["property"] = strings.TrimSpace(parts[2])
+	}
+	if tag.DataType != "" {
+		item["valueType"] = tag.DataType
+	}
+	if tag.Priority != 0 {
+		item["priority"] = tag.Priority
+	}
+	return item, nil
+}
+
+// modbusCommand 转换为 x/modbus 的命令、地址和值，地址格式：[coil:|holdingRegister:]address
+func modbusCommand(tag tags.Tag, value interface{}) (map[string]string, error) {
+	area, address := areaHoldingRegister, tag.Address
+	if idx := strings.LastIndex(address, ":"); idx >= 0 {
+		area, address = address[:idx], address[idx+1:]
+	}
+	addr, err := strconv.ParseUint(strings.TrimSpace(address), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modbus address: %s", tag.Address)
+	}
+	if value == nil {
+		return nil, errors.New("modbus value cannot be null")
+	}
+	var cmd, v string
+	switch area {
+	case areaCoil:
+		b, err := toBool(value)
+		if err != nil {
+			return nil, err
+		}
+		cmd, v = "WriteCoil", strconv.FormatBool(b)
+	case areaHoldingRegister:
+		dataType := strings.ToLower(tag.DataType)
+		if strings.HasPrefix(dataType, "string") {
+			cmd, v = "WriteBytes", fmt.Sprint(value)
+			break
+		}
+		f, err := toFloat(tag.Unscaled(value))
+		if err != nil {
+			return nil, err
+		}
+		switch dataType {
+		case "", "uint16":
+			cmd, v = "WriteRegister", strconv.FormatUint(uint64(uint16(int64(math.Round(f)))), 10)
+		case "int16":
+			cmd, v = "WriteRegister", strconv.FormatUint(uint64(uint16(int16(math.Round(f)))), 10)
+		case "uint32":
+			cmd, v = "WriteUint32", strconv.FormatUint(uint64(uint32(int64(math.Round(f)))), 10)
+		case "int32":
+			cmd, v = "WriteUint32", strconv.FormatUint(uint64(uint32(int32(math.Round(f)))), 10)
+		case "float32":
+			cmd, v = "WriteFloat32", strconv.FormatFloat(f, 'g', -1, 32)
+		case "uint64":
+			cmd, v = "WriteUint64", strconv.FormatUint(uint64(math.Round(f)), 10)
+		case "int64":
+			cmd, v = "WriteUint64", strconv.FormatUint(uint64(int64(math.Round(f))), 10)
+		case "float64":
+			cmd, v = "WriteFloat64", strconv.FormatFloat(f, 'g', -1, 64)
+		default:
+			return nil, fmt.Errorf("unsupported modbus data type: %s", tag.DataType)
+		}
+	default:
+		return nil, fmt.Errorf("modbus area %s is not writable", area)
+	}
+	return map[string]string{KeyCmd: cmd, KeyAddress: strconv.FormatUint(addr, 10), KeyValue: v}, nil
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	case json.Number:
+		return n.Float64()
+	}
+	// 整数类型
+	if f, err := strconv.ParseFloat(fmt.Sprint(v), 64); err == nil {
+		return f, nil
+	}
+	return 0, fmt.Errorf("invalid numeric value: %v", v)
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(b)) {
+		case "1", "true", "on":
+			return true, nil
+		case "0", "false", "off":
+			return false, nil
+		}
+		return false, fmt.Errorf("invalid boolean value: %s", b)
+	}
+	f, err := toFloat(v)
+	if err != nil {
+		return false, err
+	}
+	return f != 0, nil
+}
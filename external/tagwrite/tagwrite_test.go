@@ -0,0 +1,116 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tagwrite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rulego/rulego-components-iot/pkg/tags"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func testRegistry(t *testing.T) *tags.Registry {
+	r := tags.NewRegistry()
+	assert.Nil(t, r.Register(
+		tags.Tag{Name: "boiler.setpoint", Protocol: tags.ProtocolOPCUA, Address: "ns=2;s=Boiler.Setpoint", Writable: true},
+		tags.Tag{Name: "boiler.temp", Protocol: tags.ProtocolOPCUA, Address: "ns=2;s=Boiler.Temp"},
+		tags.Tag{Name: "line.speed", Protocol: tags.ProtocolS7, Address: "DB1.DBD20:REAL", Writable: true},
+		tags.Tag{Name: "pump.run", Protocol: tags.ProtocolModbus, Address: "coil:5", Writable: true},
+		tags.Tag{Name: "pump.freq", Protocol: "Modbus", Address: "holdingRegister:100", DataType: "int16", Scale: 0.1, Writable: true, Route: "pump"},
+		tags.Tag{Name: "pump.limit", Protocol: tags.ProtocolModbus, Address: "200", DataType: "float32", Writable: true},
+		tags.Tag{Name: "pump.level", Protocol: tags.ProtocolModbus, Address: "inputRegister:1", Writable: true},
+		tags.Tag{Name: "ahu.damper", Protocol: tags.ProtocolBACnet, Address: "analog-output:1", Scale: 0.01, Priority: 8, Writable: true},
+		tags.Tag{Name: "ahu.mode", Protocol: tags.ProtocolBACnet, Address: "multi-state-value:2:present-value", DataType: "unsigned", Writable: true},
+		tags.Tag{Name: "ahu.fan", Protocol: tags.ProtocolBACnet, Address: "binary-output", Writable: true},
+		tags.Tag{Name: "plc.status", Protocol: tags.ProtocolS7, Address: "M0.0", Writable: true, Route: "bacnet"},
+	))
+	return r
+}
+
+func TestParseCommands(t *testing.T) {
+	commands, err := ParseCommands([]byte(`{"tag": "boiler.setpoint", "value": 21.5}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Command{{Tag: "boiler.setpoint", Value: 21.5}}, commands)
+	commands, err = ParseCommands([]byte(`[{"tag": "tag:a", "value": true}, {"tag": "b", "value": null}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Command{{Tag: "tag:a", Value: true}, {Tag: "b"}}, commands)
+	commands, err = ParseCommands([]byte(`{"b": "on", "a": 1}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Command{{Tag: "a", Value: 1.0}, {Tag: "b", Value: "on"}}, commands)
+
+	for _, invalid := range []string{`1`, `[]`, `{}`, `[1]`, `[{"value": 1}]`, `{"tag": 1}`, `{`} {
+		_, err = ParseCommands([]byte(invalid))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	r := testRegistry(t)
+	writes, err := Plan(r, []Command{
+		{Tag: "boiler.setpoint", Value: 21.5},
+		{Tag: "tag:line.speed", Value: 3.5},
+		{Tag: "pump.run", Value: "on"},
+		{Tag: "pump.freq", Value: -12.34},
+		{Tag: "ahu.damper", Value: 0.5},
+		{Tag: "ahu.mode", Value: 3.0},
+		{Tag: "pump.limit", Value: 1.5},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(writes))
+
+	assert.Equal(t, Write{Route: "opcua", Protocol: "opcua", Tags: []string{"boiler.setpoint"},
+		Data: []map[string]interface{}{{"nodeId": "tag:boiler.setpoint", "value": 21.5}}}, writes[0])
+	assert.Equal(t, Write{Route: "s7", Protocol: "s7", Tags: []string{"line.speed"},
+		Data: map[string]interface{}{"tag:line.speed": 3.5}}, writes[1])
+
+	assert.Equal(t, "modbus", writes[2].Route)
+	assert.Equal(t, map[string]string{"cmd": "WriteCoil", "address": "5", "value": "true"}, writes[2].Metadata)
+	assert.Equal(t, map[string]interface{}{"tag": "pump.run", "cmd": "WriteCoil", "address": "5", "value": "true"}, writes[2].Data)
+	// 工程值按缩放换算为原始值，负数按补码写入
+	assert.Equal(t, "pump", writes[3].Route)
+	assert.Equal(t, map[string]string{"cmd": "WriteRegister", "address": "100", "value": "65413"}, writes[3].Metadata)
+
+	assert.Equal(t, Write{Route: "bacnet", Protocol: "bacnet", Tags: []string{"ahu.damper", "ahu.mode"}, Data: []map[string]interface{}{
+		{"objectType": "analog-output", "instance": uint64(1), "value": 50.0, "priority": 8},
+		{"objectType": "multi-state-value", "instance": uint64(2), "property": "present-value", "valueType": "unsigned", "value": 3.0},
+	}}, writes[4])
+	assert.Equal(t, map[string]string{"cmd": "WriteFloat32", "address": "200", "value": "1.5"}, writes[5].Metadata)
+
+	// BACnet null 释放命令优先级
+	writes, err = Plan(r, []Command{{Tag: "ahu.damper"}})
+	assert.Nil(t, err)
+	assert.Nil(t, writes[0].Data.([]map[string]interface{})[0]["value"])
+
+	_, err = Plan(r, []Command{{Tag: "boiler.setpoint", Value: 1.0}, {Tag: "boiler.temp", Value: 1.0}})
+	assert.True(t, errors.Is(err, ErrNotWritable))
+	_, err = Plan(r, []Command{{Tag: "missing", Value: 1.0}})
+	assert.True(t, errors.Is(err, tags.ErrTagNotFound))
+	for _, invalid := range []Command{
+		{Tag: "pump.run", Value: "maybe"},
+		{Tag: "pump.run"},
+		{Tag: "pump.freq", Value: "fast"},
+		{Tag: "pump.level", Value: 1.0},
+		{Tag: "ahu.fan", Value: 1.0},
+	} {
+		_, err = Plan(r, []Command{invalid})
+		assert.NotNil(t, err)
+	}
+	// 同一关系类型不能用于不同的协议
+	_, err = Plan(r, []Command{{Tag: "ahu.damper", Value: 1.0}, {Tag: "plc.status", Value: true}})
+	assert.NotNil(t, err)
+}
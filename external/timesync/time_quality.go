@@ -0,0 +1,167 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package timesync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// RelationAlert 时钟未同步或者偏差超过阈值时额外触发的关系
+const RelationAlert = "Alert"
+
+// 质量码
+const (
+	// CodeOffsetExceeded 已同步但偏差超过阈值
+	CodeOffsetExceeded uint32 = 1
+	// CodeUnsynchronized 时钟未同步
+	CodeUnsynchronized uint32 = 2
+)
+
+func init() {
+	_ = rulego.Registry.Register(&QualityNode{})
+}
+
+// QualityConfiguration 节点配置
+type QualityConfiguration struct {
+	// Source 状态来源：chrony、ntpd、ptp、ntp
+	Source string `json:"source" label:"Source" desc:"Clock sync status source: chrony (chronyc tracking), ntpd (ntpq rv), ptp (linuxptp pmc) or ntp (SNTP query to server)"`
+	// Command chronyc、ntpq、pmc 的路径，为空从 PATH 查找
+	Command string `json:"command" label:"Command" desc:"Path of chronyc, ntpq or pmc, empty looks it up in PATH"`
+	// Server ntp 来源查询的服务器，格式：host[:port]
+	Server string `json:"server" label:"Server" desc:"ntp: NTP server to query, format: host[:port]"`
+	// Timeout 查询超时，单位秒
+	Timeout int `json:"timeout" label:"Timeout" desc:"Query timeout in seconds"`
+	// MaxOffset 允许的最大时钟偏差，单位毫秒，超过时质量为 UNCERTAIN 并触发 Alert
+	MaxOffset float64 `json:"maxOffset" label:"Max Offset" desc:"Maximum clock offset in ms, a larger offset is UNCERTAIN and triggers Alert"`
+}
+
+// Result 时钟同步状态和归一化的质量
+type Result struct {
+	Status
+	quality.Quality
+}
+
+// QualityNode 查询本机时钟同步状态，输出同步状态、偏差、层级和参考时钟，重新赋值到msg.Data，质量等级放在元数据 qualityLevel。
+// 时钟已同步且偏差不超过阈值为 GOOD，偏差超过阈值为 UNCERTAIN，未同步为 BAD。
+// 查询成功，流转到`Success`链，质量不是 GOOD 时同时流转到`Alert`链；查询失败，流转到`Failure`链
+type QualityNode struct {
+	//节点配置
+	Config QualityConfiguration
+}
+
+// Type 返回组件类型
+func (x *QualityNode) Type() string {
+	return "x/timeQuality"
+}
+
+// New 默认参数
+func (x *QualityNode) New() types.Node {
+	return &QualityNode{
+		Config: QualityConfiguration{
+			Source:    SourceChrony,
+			Timeout:   3,
+			MaxOffset: 100,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *QualityNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	switch x.Config.Source {
+	case SourceChrony, SourceNtpd, SourcePtp:
+	case SourceNtp:
+		if x.Config.Server == "" {
+			return errors.New("ntp server is empty")
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownSource, x.Config.Source)
+	}
+	if x.Config.Timeout <= 0 {
+		x.Config.Timeout = 3
+	}
+	if x.Config.MaxOffset <= 0 {
+		return fmt.Errorf("invalid max offset: %v", x.Config.MaxOffset)
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *QualityNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	parent := ctx.GetContext()
+	if parent == nil {
+		parent = context.Background()
+	}
+	queryCtx, cancel := context.WithTimeout(parent, time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+	target := x.Config.Command
+	if x.Config.Source == SourceNtp {
+		target = x.Config.Server
+	}
+	status, err := Query(queryCtx, x.Config.Source, target)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result := Result{Status: status, Quality: x.qualityOf(status)}
+	data, err := json.Marshal(result)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(quality.KeyLevel, string(result.Level))
+	msg.SetDataType(types.JSON)
+	msg.SetData(string(data))
+	if result.IsGood() {
+		ctx.TellSuccess(msg)
+	} else {
+		ctx.TellNext(msg, types.Success, RelationAlert)
+	}
+}
+
+// qualityOf 根据同步状态和偏差阈值归一化质量
+func (x *QualityNode) qualityOf(status Status) quality.Quality {
+	switch {
+	case !status.Synchronized:
+		return quality.Quality{Level: quality.Bad, Code: CodeUnsynchronized}
+	case math.Abs(status.Offset) > x.Config.MaxOffset:
+		return quality.Quality{Level: quality.Uncertain, Code: CodeOffsetExceeded}
+	default:
+		return quality.GoodQuality
+	}
+}
+
+// Destroy 销毁组件
+func (x *QualityNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *QualityNode) Desc() string {
+	return "Query local clock synchronization (chrony, ntpd, PTP or an NTP server) and rate timestamp quality by sync state and offset. Routes to Success/Alert/Failure"
+}
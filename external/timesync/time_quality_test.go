@@ -0,0 +1,98 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package timesync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/quality"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestQualityNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&QualityNode{})
+
+	outputs := map[string]string{
+		"chronyc":                 "A9FEA97B,169.254.169.123,4,1760601600.1,0.000012345,0,0,0,0,0,0.0004,0.0007,64.4,Normal",
+		"/opt/chrony/bin/chronyc": "A9FEA97B,169.254.169.123,4,1760601600.1,-0.250000000,0,0,0,0,0,0.0004,0.0007,64.4,Normal",
+		"pmc":                     "master_offset 0\ngmPresent false\nportState LISTENING\n",
+	}
+	defer func(run func(ctx context.Context, name string, args ...string) ([]byte, error)) {
+		runCommand = run
+	}(runCommand)
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if out, ok := outputs[name]; ok {
+			return []byte(out), nil
+		}
+		return nil, errors.New("exec: " + name + ": executable file not found in $PATH")
+	}
+
+	for _, configuration := range []types.Configuration{
+		{"source": "gps"},
+		{"source": SourceNtp},
+		{"maxOffset": -1},
+	} {
+		_, err := test.CreateAndInitNode("x/timeQuality", configuration, Registry)
+		assert.NotNil(t, err)
+	}
+
+	msgs := []test.Msg{{MetaData: types.NewMetadata(), DataType: types.TEXT, MsgType: "TICK", AfterSleep: 50 * time.Millisecond}}
+	run := func(configuration types.Configuration) (map[string]int, Result, types.RuleMsg) {
+		node, err := test.CreateAndInitNode("x/timeQuality", configuration, Registry)
+		assert.Nil(t, err)
+		relations := make(map[string]int)
+		var result Result
+		var out types.RuleMsg
+		test.NodeOnMsg(t, node, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			relations[relationType]++
+			out = msg
+			if relationType != types.Failure {
+				assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+			}
+		})
+		return relations, result, out
+	}
+
+	relations, result, msg := run(types.Configuration{})
+	assert.Equal(t, map[string]int{types.Success: 1}, relations)
+	assert.Equal(t, quality.Good, result.Level)
+	assert.Equal(t, "169.254.169.123", result.Reference)
+	assert.Equal(t, string(quality.Good), msg.Metadata.GetValue(quality.KeyLevel))
+
+	// 偏差 250ms 超过阈值
+	relations, result, _ = run(types.Configuration{"command": "/opt/chrony/bin/chronyc", "maxOffset": 100})
+	assert.Equal(t, map[string]int{types.Success: 1, RelationAlert: 1}, relations)
+	assert.Equal(t, quality.Uncertain, result.Level)
+	assert.Equal(t, CodeOffsetExceeded, result.Code)
+	assert.Equal(t, -250.0, result.Offset)
+
+	relations, result, msg = run(types.Configuration{"source": SourcePtp})
+	assert.Equal(t, map[string]int{types.Success: 1, RelationAlert: 1}, relations)
+	assert.Equal(t, quality.Bad, result.Level)
+	assert.Equal(t, CodeUnsynchronized, result.Code)
+	assert.Equal(t, string(quality.Bad), msg.Metadata.GetValue(quality.KeyLevel))
+
+	relations, _, _ = run(types.Configuration{"source": SourceNtpd})
+	assert.Equal(t, map[string]int{types.Failure: 1}, relations)
+}
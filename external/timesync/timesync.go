@@ -0,0 +1,371 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package timesync 提供查询本机时钟同步状态的节点
+// 支持 chrony（chronyc tracking）、ntpd（ntpq rv）、linuxptp（pmc TIME_STATUS_NP）和直接向 NTP 服务器发送 SNTP 请求，
+// 把同步状态、时钟偏差和层级归一化为同一个状态对象，历史库依赖的时间戳不可信时规则链可以及时告警
+package timesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 时钟同步状态的来源
+const (
+	// SourceChrony chronyc -c tracking
+	SourceChrony = "chrony"
+	// SourceNtpd ntpq -c rv
+	SourceNtpd = "ntpd"
+	// SourcePtp linuxptp pmc TIME_STATUS_NP 和 PORT_DATA_SET
+	SourcePtp = "ptp"
+	// SourceNtp 直接向 NTP 服务器发送 SNTP 请求
+	SourceNtp = "ntp"
+)
+
+// 闰秒状态
+const (
+	LeapNormal         = "normal"
+	LeapInsert         = "insert"
+	LeapDelete         = "delete"
+	LeapUnsynchronized = "unsynchronized"
+)
+
+// ErrUnknownSource 不支持的状态来源
+var ErrUnknownSource = errors.New("unknown time sync source")
+
+// ntpEpoch NTP 时间戳的起点 1900-01-01 到 Unix 起点的秒数
+const ntpEpoch = 2208988800
+
+// Status 时钟同步状态
+type Status struct {
+	// Source 状态来源：chrony、ntpd、ptp、ntp
+	Source string `json:"source"`
+	// Synchronized 本机时钟是否已同步到参考时钟
+	Synchronized bool `json:"synchronized"`
+	// Offset 时钟偏差，单位毫秒，参考时间减本机时间，正值表示本机时钟偏慢
+	Offset float64 `json:"offset"`
+	// Stratum NTP 层级，PTP 为 0
+	Stratum int `json:"stratum,omitempty"`
+	// Reference 参考时钟：NTP 服务器地址或者 refid，PTP 为主时钟 identity
+	Reference string `json:"reference,omitempty"`
+	// RootDelay 到主参考时钟的往返延迟，单位毫秒
+	RootDelay float64 `json:"rootDelay,omitempty"`
+	// RootDispersion 到主参考时钟的离散度，单位毫秒
+	RootDispersion float64 `json:"rootDispersion,omitempty"`
+	// Leap 闰秒状态：normal、insert、delete、unsynchronized
+	Leap string `json:"leap,omitempty"`
+	// PortState PTP 端口状态，eg. SLAVE、MASTER、LISTENING
+	PortState string `json:"portState,omitempty"`
+}
+
+// runCommand 执行命令并返回标准输出，测试时替换
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return nil, fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
+
+// QueryChrony 执行 chronyc -c tracking 查询 chrony 的同步状态，command 为空使用 chronyc
+func QueryChrony(ctx context.Context, command string) (Status, error) {
+	if command == "" {
+		command = "chronyc"
+	}
+	out, err := runCommand(ctx, command, "-c", "tracking")
+	if err != nil {
+		return Status{}, err
+	}
+	return ParseChronyTracking(string(out))
+}
+
+// ParseChronyTracking 解析 chronyc -c tracking 的 CSV 输出：
+// refid,名称,层级,参考时间,系统时间偏差,上次偏差,RMS 偏差,频率,剩余频率,漂移,根延迟,根离散度,更新间隔,闰秒状态。
+// 系统时间偏差为正表示本机时钟偏慢
+func ParseChronyTracking(out string) (Status, error) {
+	fields := strings.Split(strings.TrimSpace(out), ",")
+	if len(fields) < 14 {
+		return Status{}, fmt.Errorf("unexpected chronyc tracking output: %q", strings.TrimSpace(out))
+	}
+	status := Status{Source: SourceChrony, Reference: fields[1]}
+	var err error
+	if status.Stratum, err = strconv.Atoi(fields[2]); err != nil {
+		return Status{}, fmt.Errorf("invalid chrony stratum: %s", fields[2])
+	}
+	values := make([]float64, 3)
+	for i, index := range []int{4, 10, 11} {
+		if values[i], err = strconv.ParseFloat(fields[index], 64); err != nil {
+			return Status{}, fmt.Errorf("invalid chrony tracking value: %s", fields[index])
+		}
+	}
+	status.Offset, status.RootDelay, status.RootDispersion = values[0]*1000, values[1]*1000, values[2]*1000
+	switch strings.ToLower(strings.TrimSpace(fields[13])) {
+	case "normal":
+		status.Leap = LeapNormal
+	case "insert second":
+		status.Leap = LeapInsert
+	case "delete second":
+		status.Leap = LeapDelete
+	default:
+		status.Leap = LeapUnsynchronized
+	}
+	// 没有选中参考源时 refid 为 0
+	status.Synchronized = status.Leap != LeapUnsynchronized && strings.Trim(fields[0], "0") != ""
+	return status, nil
+}
+
+// QueryNtpd 执行 ntpq -c rv 查询 ntpd 的系统变量，command 为空使用 ntpq
+func QueryNtpd(ctx context.Context, command string) (Status, error) {
+	if command == "" {
+		command = "ntpq"
+	}
+	out, err := runCommand(ctx, command, "-c", "rv")
+	if err != nil {
+		return Status{}, err
+	}
+	return ParseNtpqVariables(string(out))
+}
+
+// ParseNtpqVariables 解析 ntpq -c rv 输出的 key=value 列表，offset、rootdelay、rootdisp 单位为毫秒，
+// offset 为参考时间减本机时间
+func ParseNtpqVariables(out string) (Status, error) {
+	vars := parseVariables(out)
+	stratum, err := strconv.Atoi(vars["stratum"])
+	if err != nil {
+		return Status{}, fmt.Errorf("unexpected ntpq output: %q", strings.TrimSpace(out))
+	}
+	status := Status{Source: SourceNtpd, Stratum: stratum, Reference: vars["refid"]}
+	for key, v := range map[string]*float64{"offset": &status.Offset, "rootdelay": &status.RootDelay, "rootdisp": &status.RootDispersion} {
+		if s, ok := vars[key]; ok {
+			if *v, err = strconv.ParseFloat(s, 64); err != nil {
+				return Status{}, fmt.Errorf("invalid ntpq %s: %s", key, s)
+			}
+		}
+	}
+	status.Leap = leapOf(vars["leap"])
+	status.Synchronized = status.Leap != LeapUnsynchronized && stratum > 0 && stratum < 16
+	return status, nil
+}
+
+// parseVariables 解析逗号分隔的 key=value，值可以带引号，输出可能折行
+func parseVariables(out string) map[string]string {
+	vars := make(map[string]string)
+	var field strings.Builder
+	quoted := false
+	flush := func() {
+		if k, v, ok := strings.Cut(strings.TrimSpace(field.String()), "="); ok {
+			vars[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+		field.Reset()
+	}
+	for _, r := range out {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			field.WriteRune(r)
+		case r == ',' && !quoted:
+			flush()
+		case r == '\n' || r == '\r':
+			field.WriteRune(' ')
+		default:
+			field.WriteRune(r)
+		}
+	}
+	flush()
+	return vars
+}
+
+// leapOf 解析 ntpq 的 leap 变量：00、01、10、11 或者 leap_none 等名称
+func leapOf(s string) string {
+	switch s {
+	case "00", "0", "leap_none":
+		return LeapNormal
+	case "01", "1", "leap_add_sec":
+		return LeapInsert
+	case "10", "2", "leap_del_sec":
+		return LeapDelete
+	default:
+		return LeapUnsynchronized
+	}
+}
+
+// QueryPtp 执行 pmc 查询 ptp4l 的 TIME_STATUS_NP 和 PORT_DATA_SET，command 为空使用 pmc
+func QueryPtp(ctx context.Context, command string) (Status, error) {
+	if command == "" {
+		command = "pmc"
+	}
+	out, err := runCommand(ctx, command, "-u", "-b", "0", "GET TIME_STATUS_NP", "GET PORT_DATA_SET")
+	if err != nil {
+		return Status{}, err
+	}
+	return ParsePmc(string(out))
+}
+
+// ParsePmc 解析 pmc 的管理消息响应。master_offset 单位纳秒，为本机时间减主时钟时间。
+// 端口为 SLAVE 且有主时钟时已同步，端口为 MASTER 时本机就是主时钟
+func ParsePmc(out string) (Status, error) {
+	status := Status{Source: SourcePtp}
+	var gmPresent, found bool
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "master_offset":
+			ns, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return Status{}, fmt.Errorf("invalid ptp master_offset: %s", fields[1])
+			}
+			status.Offset, found = -float64(ns)/1e6, true
+		case "gmPresent":
+			gmPresent = fields[1] == "true"
+		case "gmIdentity":
+			status.Reference = fields[1]
+		case "portState":
+			status.PortState = fields[1]
+		}
+	}
+	if !found {
+		return Status{}, fmt.Errorf("unexpected pmc output: %q", strings.TrimSpace(out))
+	}
+	switch status.PortState {
+	case "MASTER", "GRAND_MASTER":
+		status.Synchronized = true
+	case "", "SLAVE":
+		status.Synchronized = gmPresent
+	}
+	return status, nil
+}
+
+// QueryNtp 向 NTP 服务器发送 SNTP 请求，计算本机时钟偏差，server 格式为 host[:port]，端口默认 123
+func QueryNtp(ctx context.Context, server string) (Status, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return Status{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	req := make([]byte, 48)
+	// LI=0 VN=4 Mode=3（客户端）
+	req[0] = 0x23
+	t1 := time.Now()
+	putTimestamp(req[40:], t1)
+	if _, err = conn.Write(req); err != nil {
+		return Status{}, err
+	}
+	resp := make([]byte, 128)
+	for {
+		n, err := conn.Read(resp)
+		if err != nil {
+			return Status{}, err
+		}
+		t4 := time.Now()
+		// 丢弃不是这个请求的响应
+		if n < 48 || resp[0]&0x07 != 4 || !bytes.Equal(resp[24:32], req[40:48]) {
+			continue
+		}
+		return ntpStatus(server, resp[:48], t1, t4)
+	}
+}
+
+// ntpStatus 根据 SNTP 响应计算偏差：((T2-T1)+(T3-T4))/2
+func ntpStatus(server string, resp []byte, t1, t4 time.Time) (Status, error) {
+	stratum := int(resp[1])
+	if stratum == 0 {
+		// kiss-of-death，refid 为原因，eg. RATE、DENY
+		return Status{}, fmt.Errorf("ntp server %s refused: %s", server, strings.TrimRight(string(resp[12:16]), "\x00"))
+	}
+	t2, t3 := timestampOf(resp[32:40]), timestampOf(resp[40:48])
+	offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+	status := Status{
+		Source:         SourceNtp,
+		Stratum:        stratum,
+		Reference:      server,
+		Offset:         float64(offset) / float64(time.Millisecond),
+		RootDelay:      shortOf(resp[4:8]),
+		RootDispersion: shortOf(resp[8:12]),
+	}
+	switch resp[0] >> 6 {
+	case 0:
+		status.Leap = LeapNormal
+	case 1:
+		status.Leap = LeapInsert
+	case 2:
+		status.Leap = LeapDelete
+	default:
+		status.Leap = LeapUnsynchronized
+	}
+	status.Synchronized = status.Leap != LeapUnsynchronized && stratum < 16
+	return status, nil
+}
+
+// putTimestamp 写入 64 位 NTP 时间戳
+func putTimestamp(b []byte, t time.Time) {
+	sec := uint64(t.Unix() + ntpEpoch)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	binary.BigEndian.PutUint64(b, sec<<32|frac)
+}
+
+// timestampOf 解析 64 位 NTP 时间戳
+func timestampOf(b []byte) time.Time {
+	v := binary.BigEndian.Uint64(b)
+	sec, frac := int64(v>>32)-ntpEpoch, v&0xffffffff
+	return time.Unix(sec, int64(frac*1e9>>32))
+}
+
+// shortOf 解析 16.16 定点数的秒，返回毫秒
+func shortOf(b []byte) float64 {
+	return math.Round(float64(binary.BigEndian.Uint32(b))/65536*1e6) / 1e3
+}
+
+// Query 按来源查询时钟同步状态，ntp 来源的 target 为服务器地址，其他来源为命令路径
+func Query(ctx context.Context, source, target string) (Status, error) {
+	switch source {
+	case SourceChrony:
+		return QueryChrony(ctx, target)
+	case SourceNtpd:
+		return QueryNtpd(ctx, target)
+	case SourcePtp:
+		return QueryPtp(ctx, target)
+	case SourceNtp:
+		if target == "" {
+			return Status{}, errors.New("ntp server is empty")
+		}
+		return QueryNtp(ctx, target)
+	default:
+		return Status{}, fmt.Errorf("%w: %s", ErrUnknownSource, source)
+	}
+}
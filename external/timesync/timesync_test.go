@@ -0,0 +1,159 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package timesync
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseChronyTracking(t *testing.T) {
+	status, err := ParseChronyTracking("A9FEA97B,169.254.169.123,4,1760601600.123456789,0.000012345,-0.000002000,0.000010000,-3.210,0.001,0.020,0.000456000,0.000789000,64.4,Normal\n")
+	assert.Nil(t, err)
+	assert.Equal(t, SourceChrony, status.Source)
+	assert.True(t, status.Synchronized)
+	assert.Equal(t, "169.254.169.123", status.Reference)
+	assert.Equal(t, 4, status.Stratum)
+	assert.True(t, status.Offset > 0.0123 && status.Offset < 0.0124)
+	assert.True(t, status.RootDelay > 0.455 && status.RootDelay < 0.457)
+	assert.Equal(t, LeapNormal, status.Leap)
+
+	status, err = ParseChronyTracking("00000000,,0,0.000000000,0.000000000,0.000000000,0.000000000,0.000,0.000,0.000,1.000000000,1.000000000,0.0,Not synchronised")
+	assert.Nil(t, err)
+	assert.False(t, status.Synchronized)
+	assert.Equal(t, LeapUnsynchronized, status.Leap)
+
+	_, err = ParseChronyTracking("506 Cannot talk to daemon")
+	assert.NotNil(t, err)
+}
+
+func TestParseNtpqVariables(t *testing.T) {
+	status, err := ParseNtpqVariables(`associd=0 status=0615 leap_none, sync_ntp, 1 event, clock_sync,
+version="ntpd 4.2.8p15@1.3728-o Wed Sep 23 11:46:38 UTC 2020 (1)",
+processor="x86_64", system="Linux/5.15.0", leap=00, stratum=3,
+precision=-24, rootdelay=12.345, rootdisp=30.125, refid=10.0.0.1,
+reftime=eaa1b2c3.d4e5f607  Thu, Oct 16 2025  8:00:03.831, tc=10,
+mintc=3, offset=-1.250, frequency=-12.345, sys_jitter=0.321,
+clk_jitter=0.123, clk_wander=0.004`)
+	assert.Nil(t, err)
+	assert.Equal(t, SourceNtpd, status.Source)
+	assert.True(t, status.Synchronized)
+	assert.Equal(t, 3, status.Stratum)
+	assert.Equal(t, "10.0.0.1", status.Reference)
+	assert.Equal(t, -1.25, status.Offset)
+	assert.Equal(t, 12.345, status.RootDelay)
+	assert.Equal(t, 30.125, status.RootDispersion)
+
+	status, err = ParseNtpqVariables("associd=0 status=c618 leap_alarm, sync_ntp, leap=11, stratum=16, offset=0.000, refid=INIT")
+	assert.Nil(t, err)
+	assert.False(t, status.Synchronized)
+
+	_, err = ParseNtpqVariables("ntpq: read: Connection refused")
+	assert.NotNil(t, err)
+}
+
+func TestParsePmc(t *testing.T) {
+	status, err := ParsePmc(`sending: GET TIME_STATUS_NP
+	90e2ba.fffe.123456-0 seq 0 RESPONSE MANAGEMENT TIME_STATUS_NP
+		master_offset              -1500
+		ingress_time               1760601600123456789
+		cumulativeScaledRateOffset +0.000000000
+		scaledLastGmPhaseChange    0
+		gmTimeBaseIndicator        0
+		lastGmPhaseChange          0x0000'0000000000000000.0000
+		gmPresent                  true
+		gmIdentity                 001122.fffe.334455
+sending: GET PORT_DATA_SET
+	90e2ba.fffe.123456-1 seq 1 RESPONSE MANAGEMENT PORT_DATA_SET
+		portIdentity            90e2ba.fffe.123456-1
+		portState               SLAVE
+		logMinDelayReqInterval  0
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, SourcePtp, status.Source)
+	assert.True(t, status.Synchronized)
+	assert.Equal(t, 0.0015, status.Offset)
+	assert.Equal(t, "001122.fffe.334455", status.Reference)
+	assert.Equal(t, "SLAVE", status.PortState)
+
+	status, err = ParsePmc("master_offset 0\ngmPresent false\nportState LISTENING\n")
+	assert.Nil(t, err)
+	assert.False(t, status.Synchronized)
+	status, err = ParsePmc("master_offset 0\ngmPresent false\nportState MASTER\n")
+	assert.Nil(t, err)
+	assert.True(t, status.Synchronized)
+
+	_, err = ParsePmc("sending: GET TIME_STATUS_NP\n")
+	assert.NotNil(t, err)
+}
+
+// ntpServer 测试用的 NTP 服务器，时间比本机快 skew
+func ntpServer(t *testing.T, skew time.Duration, stratum byte) string {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() {
+		buf := make([]byte, 128)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if n < 48 {
+				continue
+			}
+			resp := make([]byte, 48)
+			resp[0] = 0x24
+			resp[1] = stratum
+			copy(resp[4:8], []byte{0x00, 0x00, 0x80, 0x00})
+			copy(resp[12:16], "GPS\x00")
+			copy(resp[24:32], buf[40:48])
+			now := time.Now().Add(skew)
+			putTimestamp(resp[32:], now)
+			putTimestamp(resp[40:], now)
+			_, _ = conn.WriteToUDP(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQueryNtp(t *testing.T) {
+	now := time.Unix(1760601600, 123456789)
+	b := make([]byte, 8)
+	putTimestamp(b, now)
+	assert.True(t, timestampOf(b).Sub(now).Abs() < time.Microsecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	status, err := Query(ctx, SourceNtp, ntpServer(t, 250*time.Millisecond, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, SourceNtp, status.Source)
+	assert.True(t, status.Synchronized)
+	assert.Equal(t, 1, status.Stratum)
+	assert.Equal(t, 500.0, status.RootDelay)
+	assert.True(t, status.Offset > 240 && status.Offset < 260)
+
+	_, err = Query(ctx, SourceNtp, ntpServer(t, 0, 0))
+	assert.NotNil(t, err)
+	_, err = Query(ctx, "gps", "")
+	assert.True(t, errors.Is(err, ErrUnknownSource))
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wits 提供钻井数据的 WITS0 记录解码和 WITSML 1.4 查询节点，适用于油气钻井现场的边缘网关
+package wits
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WITS0 帧标记
+const (
+	FrameStart = "&&"
+	FrameEnd   = "!!"
+)
+
+// Heartbeat 心跳帧，部分 WITS0 发送端需要接收方定时发送，否则停止发送数据
+const Heartbeat = "&&\r\n0111-9999\r\n!!\r\n"
+
+// maxItems 一帧最多的数据项，防止没有结束标记时无限增长
+const maxItems = 1000
+
+// Item WITS0 数据项
+type Item struct {
+	// Record 记录号，eg. 1 为通用时间记录
+	Record int
+	// Item 数据项号
+	Item int
+	// Value 值，数字为 float64，其他为字符串
+	Value interface{}
+}
+
+// Code 4 位数据项编码，eg. 0108
+func (i Item) Code() string {
+	return fmt.Sprintf("%02d%02d", i.Record, i.Item)
+}
+
+// ParseLine 解析一行数据：2 位记录号、2 位数据项号和值，eg. 01081520.30
+func ParseLine(line string) (Item, error) {
+	line = strings.TrimSpace(line)
+	if len(line) < 5 {
+		return Item{}, fmt.Errorf("invalid wits0 line: %q", line)
+	}
+	record, err1 := strconv.Atoi(line[:2])
+	item, err2 := strconv.Atoi(line[2:4])
+	if err1 != nil || err2 != nil {
+		return Item{}, fmt.Errorf("invalid wits0 item code: %q", line)
+	}
+	v := strings.TrimSpace(line[4:])
+	var value interface{} = v
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		value = f
+	}
+	return Item{Record: record, Item: item, Value: value}, nil
+}
+
+// Decoder 把 && 和 !! 之间的数据行组合为一帧，不是并发安全的
+// 发送端省略 && 时第一行数据开始新的一帧
+type Decoder struct {
+	items []Item
+}
+
+// Feed 输入一行，收到 !! 时返回一帧的数据项和 true，数据行解析失败返回错误并忽略该行
+func (d *Decoder) Feed(line string) ([]Item, bool, error) {
+	line = strings.TrimSpace(line)
+	switch line {
+	case "":
+		return nil, false, nil
+	case FrameStart:
+		d.items = d.items[:0]
+		return nil, false, nil
+	case FrameEnd:
+		if len(d.items) == 0 {
+			return nil, false, nil
+		}
+		items := append([]Item(nil), d.items...)
+		d.items = d.items[:0]
+		return items, true, nil
+	}
+	item, err := ParseLine(line)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(d.items) >= maxItems {
+		d.items = d.items[:0]
+		return nil, false, fmt.Errorf("wits0 frame exceeds %d items without %s", maxItems, FrameEnd)
+	}
+	d.items = append(d.items, item)
+	return nil, false, nil
+}
+
+// Reset 丢弃未结束的帧，eg. 重新连接之后
+func (d *Decoder) Reset() {
+	d.items = d.items[:0]
+}
+
+// Record1 记录 01（通用时间记录）数据项的名称
+var Record1 = map[int]string{
+	1:  "wellId",
+	2:  "sidetrackNumber",
+	3:  "recordId",
+	4:  "sequenceId",
+	5:  "date",
+	6:  "time",
+	7:  "activityCode",
+	8:  "bitDepth",
+	9:  "bitDepthVertical",
+	10: "holeDepth",
+	11: "holeDepthVertical",
+	12: "blockPosition",
+	13: "ropAvg",
+	14: "hookloadAvg",
+	15: "hookloadMax",
+	16: "wobAvg",
+	17: "wobMax",
+	18: "torqueAvg",
+	19: "torqueMax",
+	20: "rpmAvg",
+	21: "standpipePressureAvg",
+	22: "casingPressure",
+	23: "pumpStrokeRate1",
+	24: "pumpStrokeRate2",
+	25: "pumpStrokeRate3",
+	26: "tankVolumeActive",
+	27: "tankVolumeChangeActive",
+	28: "mudFlowOutPercent",
+	29: "mudFlowOutAvg",
+	30: "mudFlowInAvg",
+	31: "mudDensityOutAvg",
+	32: "mudDensityInAvg",
+	33: "mudTempOutAvg",
+	34: "mudTempInAvg",
+	35: "mudConductivityOutAvg",
+	36: "mudConductivityInAvg",
+	37: "pumpStrokeCount",
+	38: "lagStrokes",
+	39: "returnsDepth",
+	40: "gasAvg",
+}
+
+// Channels 数据项编码到名称的映射，key 为 4 位编码，eg. {"0108": "bitDepth"}
+type Channels map[string]string
+
+// Name 数据项的名称：先查找配置的映射，再查找记录 01 的标准名称，都没有使用 4 位编码
+func (c Channels) Name(item Item) string {
+	code := item.Code()
+	if name, ok := c[code]; ok && name != "" {
+		return name
+	}
+	if item.Record == 1 {
+		if name, ok := Record1[item.Item]; ok {
+			return name
+		}
+	}
+	return code
+}
+
+// Tag 把一帧的数据项转换为名称->值，nulls 中的值（eg. -8888、-9999）转换为 null
+func (c Channels) Tag(items []Item, nulls []float64) map[string]interface{} {
+	values := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		value := item.Value
+		if f, ok := value.(float64); ok {
+			for _, null := range nulls {
+				if f == null {
+					value = nil
+					break
+				}
+			}
+		}
+		values[c.Name(item)] = value
+	}
+	return values
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wits
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	item, err := ParseLine("01081520.30")
+	assert.Nil(t, err)
+	assert.Equal(t, Item{Record: 1, Item: 8, Value: 1520.3}, item)
+	assert.Equal(t, "0108", item.Code())
+
+	item, err = ParseLine("0101 WELL-7 ")
+	assert.Nil(t, err)
+	assert.Equal(t, "WELL-7", item.Value)
+
+	for _, line := range []string{"01", "ab08123", "0108"} {
+		_, err = ParseLine(line)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	var d Decoder
+	var frames [][]Item
+	var errs int
+	for _, line := range []string{
+		// 没有 && 的不完整帧被丢弃
+		"0110100",
+		"&&", "01081520.30", "0110 1522.5", "x", "0120-8888", "!!",
+		"!!",
+		// 省略 &&
+		"0812 3.5", "!!",
+	} {
+		items, ok, err := d.Feed(line + "\r\n")
+		if err != nil {
+			errs++
+		}
+		if ok {
+			frames = append(frames, items)
+		}
+	}
+	assert.Equal(t, 1, errs)
+	assert.Equal(t, 2, len(frames))
+	assert.Equal(t, []Item{{1, 8, 1520.3}, {1, 10, 1522.5}, {1, 20, -8888.0}}, frames[0])
+	assert.Equal(t, []Item{{8, 12, 3.5}}, frames[1])
+
+	channels := Channels{"0812": "mwdInclination", "0110": ""}
+	assert.Equal(t, map[string]interface{}{"bitDepth": 1520.3, "holeDepth": 1522.5, "rpmAvg": nil}, channels.Tag(frames[0], []float64{-8888, -9999}))
+	assert.Equal(t, map[string]interface{}{"mwdInclination": 3.5}, channels.Tag(frames[1], nil))
+	assert.Equal(t, "0899", channels.Name(Item{Record: 8, Item: 99}))
+}
@@ -0,0 +1,262 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wits
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultObjectType 默认查询的对象类型
+	DefaultObjectType = "log"
+	// DefaultOptionsIn 默认查询选项，返回所有元素
+	DefaultOptionsIn = "returnElements=all"
+	// storeNamespace WITSML 1.4 store 接口的命名空间
+	storeNamespace = "http://www.witsml.org/wsdl/120"
+	// getFromStoreAction WMLS_GetFromStore 的 SOAPAction
+	getFromStoreAction = "http://www.witsml.org/action/120/Store.WMLS_GetFromStore"
+)
+
+// StoreError store 返回的错误，Result 为负数的返回码，或者 SOAP Fault
+type StoreError struct {
+	// Result WMLS 返回码，eg. -401，SOAP Fault 为 0
+	Result int
+	// Message SuppMsgOut 或者 faultstring
+	Message string
+}
+
+func (e *StoreError) Error() string {
+	if e.Result == 0 {
+		return "witsml fault: " + e.Message
+	}
+	return fmt.Sprintf("witsml error %d: %s", e.Result, e.Message)
+}
+
+// StoreClient WITSML 1.4.1 store 客户端，通过 SOAP 调用 WMLS_GetFromStore，使用 HTTP Basic 认证
+type StoreClient struct {
+	// Server store 地址，eg. https://witsml.example.com/store/witsml
+	Server   string
+	Username string
+	Password string
+	// Client HTTP 客户端
+	Client *http.Client
+}
+
+// getFromStoreEnvelope WMLS_GetFromStore 的响应
+type getFromStoreEnvelope struct {
+	Body struct {
+		Response *struct {
+			Result     int    `xml:"Result"`
+			XMLout     string `xml:"XMLout"`
+			SuppMsgOut string `xml:"SuppMsgOut"`
+		} `xml:"WMLS_GetFromStoreResponse"`
+		Fault *struct {
+			Code   string `xml:"faultcode"`
+			String string `xml:"faultstring"`
+		} `xml:"Fault"`
+	} `xml:"Body"`
+}
+
+// GetFromStore 查询 store，query 为 WITSML 查询模板（eg. <logs ...><log uid="..."/></logs>），
+// 返回查询结果 XMLout、返回码和 SuppMsgOut，返回码为负数时返回 StoreError
+func (c *StoreClient) GetFromStore(ctx context.Context, objectType, query, options string) (string, int, string, error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`)
+	body.WriteString(`<ns:WMLS_GetFromStore xmlns:ns="` + storeNamespace + `">`)
+	writeElement(&body, "WMLtypeIn", objectType)
+	writeElement(&body, "QueryIn", query)
+	writeElement(&body, "OptionsIn", options)
+	writeElement(&body, "CapabilitiesIn", "")
+	body.WriteString(`</ns:WMLS_GetFromStore></soap:Body></soap:Envelope>`)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Server, &body)
+	if err != nil {
+		return "", 0, "", err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", `"`+getFromStoreAction+`"`)
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", 0, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, "", err
+	}
+	var envelope getFromStoreEnvelope
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return "", 0, "", fmt.Errorf("witsml store: %s", resp.Status)
+		}
+		return "", 0, "", err
+	}
+	if f := envelope.Body.Fault; f != nil {
+		return "", 0, "", &StoreError{Message: strings.TrimSpace(f.String)}
+	}
+	r := envelope.Body.Response
+	if r == nil {
+		return "", 0, "", errors.New("witsml store: missing WMLS_GetFromStoreResponse")
+	}
+	if r.Result < 0 {
+		return "", r.Result, r.SuppMsgOut, &StoreError{Result: r.Result, Message: r.SuppMsgOut}
+	}
+	return r.XMLout, r.Result, r.SuppMsgOut, nil
+}
+
+// writeElement 写入转义后的文本元素
+func writeElement(b *bytes.Buffer, name, text string) {
+	b.WriteString("<" + name + ">")
+	_ = xml.EscapeText(b, []byte(text))
+	b.WriteString("</" + name + ">")
+}
+
+// Log 解码后的 log 对象，每行数据为曲线助记符->值
+type Log struct {
+	UidWell      string `json:"uidWell,omitempty"`
+	UidWellbore  string `json:"uidWellbore,omitempty"`
+	Uid          string `json:"uid,omitempty"`
+	NameWell     string `json:"nameWell,omitempty"`
+	NameWellbore string `json:"nameWellbore,omitempty"`
+	Name         string `json:"name,omitempty"`
+	// IndexCurve 索引曲线的助记符，eg. DEPTH、TIME
+	IndexCurve string `json:"indexCurve,omitempty"`
+	// Units 曲线助记符->单位
+	Units map[string]string `json:"units"`
+	// Rows 数据行，数字为 float64，空值和 nullValue 为 null，其他（eg. 时间索引）为字符串
+	Rows []map[string]interface{} `json:"rows"`
+	// LastIndex 最后一行索引曲线的原始值，用于下一次查询的 startIndex
+	LastIndex string `json:"lastIndex,omitempty"`
+}
+
+// xmlLogs WITSML 1.4.1 logs 文档
+type xmlLogs struct {
+	Logs []struct {
+		UidWell      string `xml:"uidWell,attr"`
+		UidWellbore  string `xml:"uidWellbore,attr"`
+		Uid          string `xml:"uid,attr"`
+		NameWell     string `xml:"nameWell"`
+		NameWellbore string `xml:"nameWellbore"`
+		Name         string `xml:"name"`
+		IndexCurve   string `xml:"indexCurve"`
+		NullValue    string `xml:"nullValue"`
+		Curves       []struct {
+			Mnemonic  string `xml:"mnemonic"`
+			Unit      string `xml:"unit"`
+			NullValue string `xml:"nullValue"`
+		} `xml:"logCurveInfo"`
+		Data []struct {
+			MnemonicList string   `xml:"mnemonicList"`
+			UnitList     string   `xml:"unitList"`
+			Data         []string `xml:"data"`
+		} `xml:"logData"`
+	} `xml:"log"`
+}
+
+// DecodeLogs 解码 logs 查询结果，logData 的每一行按 mnemonicList 转换为助记符->值
+func DecodeLogs(xmlOut string) ([]Log, error) {
+	var doc xmlLogs
+	if err := xml.Unmarshal([]byte(xmlOut), &doc); err != nil {
+		return nil, err
+	}
+	logs := make([]Log, 0, len(doc.Logs))
+	for _, l := range doc.Logs {
+		log := Log{
+			UidWell:      l.UidWell,
+			UidWellbore:  l.UidWellbore,
+			Uid:          l.Uid,
+			NameWell:     l.NameWell,
+			NameWellbore: l.NameWellbore,
+			Name:         l.Name,
+			IndexCurve:   l.IndexCurve,
+			Units:        map[string]string{},
+			Rows:         []map[string]interface{}{},
+		}
+		nulls := map[string]string{}
+		for _, c := range l.Curves {
+			if c.Unit != "" {
+				log.Units[c.Mnemonic] = c.Unit
+			}
+			nulls[c.Mnemonic] = l.NullValue
+			if c.NullValue != "" {
+				nulls[c.Mnemonic] = c.NullValue
+			}
+		}
+		for _, d := range l.Data {
+			mnemonics := splitList(d.MnemonicList)
+			for i, unit := range splitList(d.UnitList) {
+				if i < len(mnemonics) && unit != "" {
+					log.Units[mnemonics[i]] = unit
+				}
+			}
+			for _, line := range d.Data {
+				fields := splitList(line)
+				if len(fields) != len(mnemonics) {
+					return nil, fmt.Errorf("witsml log %s: data has %d values, mnemonicList has %d", l.Uid, len(fields), len(mnemonics))
+				}
+				row := make(map[string]interface{}, len(fields))
+				for i, field := range fields {
+					null, ok := nulls[mnemonics[i]]
+					if !ok {
+						null = l.NullValue
+					}
+					row[mnemonics[i]] = parseValue(field, null)
+					if mnemonics[i] == l.IndexCurve {
+						log.LastIndex = field
+					}
+				}
+				log.Rows = append(log.Rows, row)
+			}
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+// splitList 按逗号拆分并去掉空白
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	fields := strings.Split(s, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// parseValue 空值和 nullValue 为 nil，数字为 float64，其他为字符串
+func parseValue(field, null string) interface{} {
+	if field == "" || field == null {
+		return nil
+	}
+	if f, err := strconv.ParseFloat(field, 64); err == nil {
+		return f
+	}
+	return field
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wits
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&QueryNode{})
+}
+
+// 元数据key
+const (
+	// KeyResult WMLS_GetFromStore 的返回码
+	KeyResult = "witsmlResult"
+	// KeyMessage WMLS_GetFromStore 的 SuppMsgOut
+	KeyMessage = "witsmlMessage"
+	// KeyLastIndex 最后一个 log 最后一行的索引值
+	KeyLastIndex = "lastIndex"
+)
+
+// DefaultLogQuery 默认的 log 查询，按元数据中的 uidWell、uidWellbore 和 uidLog 查询
+const DefaultLogQuery = `<logs xmlns="http://www.witsml.org/schemas/1series" version="1.4.1.1">` +
+	`<log uidWell="${metadata.uidWell}" uidWellbore="${metadata.uidWellbore}" uid="${metadata.uidLog}"/></logs>`
+
+// QueryConfiguration 查询节点配置
+type QueryConfiguration struct {
+	// Server store 地址
+	Server string `json:"server" label:"Server" desc:"WITSML 1.4.1 store SOAP endpoint, eg. https://witsml.example.com/store/witsml" required:"true" ref:"primary"`
+	// Username Basic 认证用户名
+	Username string `json:"username" label:"Username" desc:"Basic authentication username" ref:"shared"`
+	// Password Basic 认证密码
+	Password string `json:"password" label:"Password" desc:"Basic authentication password" ref:"shared"`
+	// ObjectType 查询的对象类型，eg. log、well、wellbore、trajectory
+	ObjectType string `json:"objectType" label:"Object Type" desc:"WITSML object type (WMLtypeIn), eg. log, well, wellbore, trajectory"`
+	// Query 查询模板，支持 ${metadata.xxx} 和 ${msg.xxx} 变量
+	Query string `json:"query" label:"Query" desc:"WITSML query template (QueryIn), supports ${metadata.xxx} and ${msg.xxx} variables, eg. a startIndex from the lastIndex of the previous query" component:"{\"type\":\"codeEditor\",\"language\":\"xml\"}"`
+	// OptionsIn 查询选项，eg. returnElements=all;maxReturnNodes=1000
+	OptionsIn string `json:"optionsIn" label:"Options" desc:"Query options (OptionsIn), eg. returnElements=all;maxReturnNodes=1000"`
+	// Timeout 请求超时，单位秒
+	Timeout int64 `json:"timeout" label:"Timeout" desc:"Request timeout in seconds"`
+}
+
+// QueryNode WITSML 1.4 查询节点，通过 SOAP 调用 store 的 WMLS_GetFromStore
+//
+// log 对象的查询结果解码为 JSON，每行数据为曲线助记符->值，重新赋值到msg.Data：
+//
+//	{"logs": [{"uidWell": "W-1", "uidWellbore": "B-1", "uid": "L-1", "name": "Depth Log", "indexCurve": "DEPTH",
+//	  "units": {"DEPTH": "m", "ROP": "m/h"}, "rows": [{"DEPTH": 1500.1, "ROP": 22.5}], "lastIndex": "1500.1"}]}
+//
+// 其他对象类型的查询结果 XMLout 原样输出。元数据包含 witsmlResult、witsmlMessage，log 对象有数据时包含 lastIndex，
+// 可以作为下一次查询的 startIndex 实现增量读取。
+// 查询成功，流转到`Success`链，否则流转到`Failure`链
+type QueryNode struct {
+	//节点配置
+	Config        QueryConfiguration
+	client        *StoreClient
+	queryTemplate str.Template
+	timeout       time.Duration
+}
+
+// Type 返回组件类型
+func (x *QueryNode) Type() string {
+	return "x/witsmlQuery"
+}
+
+// New 默认参数
+func (x *QueryNode) New() types.Node {
+	return &QueryNode{
+		Config: QueryConfiguration{
+			ObjectType: DefaultObjectType,
+			Query:      DefaultLogQuery,
+			OptionsIn:  DefaultOptionsIn,
+			Timeout:    30,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *QueryNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Server == "" {
+		return errors.New("witsml server cannot be empty")
+	}
+	if strings.TrimSpace(x.Config.Query) == "" {
+		return errors.New("witsml query cannot be empty")
+	}
+	if x.Config.ObjectType == "" {
+		x.Config.ObjectType = DefaultObjectType
+	}
+	x.timeout = time.Duration(x.Config.Timeout) * time.Second
+	if x.timeout <= 0 {
+		x.timeout = 30 * time.Second
+	}
+	x.queryTemplate = str.NewTemplate(x.Config.Query)
+	x.client = &StoreClient{
+		Server:   x.Config.Server,
+		Username: x.Config.Username,
+		Password: x.Config.Password,
+		Client:   &http.Client{},
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *QueryNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	query := x.queryTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	reqCtx, cancel := context.WithTimeout(ctx.GetContext(), x.timeout)
+	defer cancel()
+	xmlOut, result, suppMsg, err := x.client.GetFromStore(reqCtx, x.Config.ObjectType, query, x.Config.OptionsIn)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KeyResult, strconv.Itoa(result))
+	msg.Metadata.PutValue(KeyMessage, suppMsg)
+	if x.Config.ObjectType != DefaultObjectType {
+		msg.SetDataType(types.TEXT)
+		msg.SetData(xmlOut)
+		ctx.TellSuccess(msg)
+		return
+	}
+	logs, err := DecodeLogs(xmlOut)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if len(logs) > 0 && logs[len(logs)-1].LastIndex != "" {
+		msg.Metadata.PutValue(KeyLastIndex, logs[len(logs)-1].LastIndex)
+	}
+	bytes, err := json.Marshal(map[string]interface{}{"logs": logs})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *QueryNode) Destroy() {
+	if x.client != nil {
+		x.client.Client.CloseIdleConnections()
+	}
+}
+
+// Desc returns the component description
+func (x *QueryNode) Desc() string {
+	return "WITSML 1.4.1 store query (WMLS_GetFromStore) decoding log curves into tagged JSON rows with units and last index. Routes to Success/Failure"
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wits
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+const testLogs = `<logs xmlns="http://www.witsml.org/schemas/1series" version="1.4.1.1">
+<log uidWell="W-1" uidWellbore="B-1" uid="L-1">
+  <nameWell>Well 1</nameWell><nameWellbore>Main</nameWellbore><name>Depth Log</name>
+  <indexCurve>DEPTH</indexCurve>
+  <nullValue>-999.25</nullValue>
+  <logCurveInfo uid="DEPTH"><mnemonic>DEPTH</mnemonic><unit>m</unit></logCurveInfo>
+  <logCurveInfo uid="ROP"><mnemonic>ROP</mnemonic><unit>m/h</unit></logCurveInfo>
+  <logCurveInfo uid="WOB"><mnemonic>WOB</mnemonic><unit>kkgf</unit><nullValue>-1</nullValue></logCurveInfo>
+  <logData>
+    <mnemonicList>DEPTH,ROP,WOB</mnemonicList>
+    <unitList>m,m/h,kkgf</unitList>
+    <data>1500.0,22.5,10.1</data>
+    <data>1500.1,-999.25,-1</data>
+    <data>1500.2,,12</data>
+  </logData>
+</log>
+</logs>`
+
+// testStore 测试用的 WITSML store
+type testStore struct {
+	*httptest.Server
+	mu sync.Mutex
+	// queries 收到的查询
+	queries []string
+}
+
+func newTestStore() *testStore {
+	s := &testStore{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *testStore) serve(w http.ResponseWriter, r *http.Request) {
+	var envelope struct {
+		Body struct {
+			Request struct {
+				Type  string `xml:"WMLtypeIn"`
+				Query string `xml:"QueryIn"`
+			} `xml:"WMLS_GetFromStore"`
+		} `xml:"Body"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&envelope); err != nil || r.Header.Get("SOAPAction") != `"`+getFromStoreAction+`"` {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.queries = append(s.queries, envelope.Body.Request.Query)
+	s.mu.Unlock()
+	if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+			`<faultcode>soap:Client</faultcode><faultstring>unauthorized</faultstring></soap:Fault></soap:Body></soap:Envelope>`))
+		return
+	}
+	result, out, supp := 1, testLogs, ""
+	switch {
+	case envelope.Body.Request.Type == "well":
+		out = `<wells xmlns="http://www.witsml.org/schemas/1series" version="1.4.1.1"><well uid="W-1"><name>Well 1</name></well></wells>`
+	case strings.Contains(envelope.Body.Request.Query, `uid="missing"`):
+		result, out, supp = -433, "", "object does not exist"
+	}
+	var body strings.Builder
+	_ = xml.EscapeText(&body, []byte(out))
+	_, _ = fmt.Fprintf(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+		`<ns:WMLS_GetFromStoreResponse xmlns:ns="http://www.witsml.org/wsdl/120"><Result>%d</Result><XMLout>%s</XMLout><SuppMsgOut>%s</SuppMsgOut>`+
+		`</ns:WMLS_GetFromStoreResponse></soap:Body></soap:Envelope>`, result, body.String(), supp)
+}
+
+func TestDecodeLogs(t *testing.T) {
+	logs, err := DecodeLogs(testLogs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, "Depth Log", logs[0].Name)
+	assert.Equal(t, map[string]string{"DEPTH": "m", "ROP": "m/h", "WOB": "kkgf"}, logs[0].Units)
+	assert.Equal(t, []map[string]interface{}{
+		{"DEPTH": 1500.0, "ROP": 22.5, "WOB": 10.1},
+		{"DEPTH": 1500.1, "ROP": nil, "WOB": nil},
+		{"DEPTH": 1500.2, "ROP": nil, "WOB": 12.0},
+	}, logs[0].Rows)
+	assert.Equal(t, "1500.2", logs[0].LastIndex)
+
+	_, err = DecodeLogs(strings.Replace(testLogs, "<data>1500.2,,12</data>", "<data>1500.2,12</data>", 1))
+	assert.NotNil(t, err)
+}
+
+func TestQueryNode(t *testing.T) {
+	store := newTestStore()
+	defer store.Close()
+
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&QueryNode{})
+
+	_, err := test.CreateAndInitNode("x/witsmlQuery", types.Configuration{}, Registry)
+	assert.NotNil(t, err)
+
+	node, err := test.CreateAndInitNode("x/witsmlQuery", types.Configuration{
+		"server":   store.URL,
+		"username": "user",
+		"password": "secret",
+	}, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	wellNode, err := test.CreateAndInitNode("x/witsmlQuery", types.Configuration{
+		"server":     store.URL,
+		"username":   "user",
+		"password":   "secret",
+		"objectType": "well",
+		"query":      `<wells xmlns="http://www.witsml.org/schemas/1series" version="1.4.1.1"><well uid="${metadata.uidWell}"/></wells>`,
+	}, Registry)
+	assert.Nil(t, err)
+	defer wellNode.Destroy()
+
+	unauthorized, err := test.CreateAndInitNode("x/witsmlQuery", types.Configuration{
+		"server": store.URL,
+	}, Registry)
+	assert.Nil(t, err)
+	defer unauthorized.Destroy()
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("uidWell", "W-1")
+	metadata.PutValue("uidWellbore", "B-1")
+	metadata.PutValue("uidLog", "L-1")
+	missing := types.NewMetadata()
+	missing.PutValue("uidLog", "missing")
+	test.NodeOnMsg(t, node, []test.Msg{
+		{MetaData: metadata, DataType: types.JSON, MsgType: "LOG", Data: `{}`, AfterSleep: time.Millisecond * 200},
+		{MetaData: missing, DataType: types.JSON, MsgType: "MISSING", Data: `{}`, AfterSleep: time.Millisecond * 200},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type == "MISSING" {
+			assert.Equal(t, types.Failure, relationType)
+			var storeErr *StoreError
+			assert.True(t, errors.As(err, &storeErr))
+			assert.Equal(t, -433, storeErr.Result)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "1", msg.Metadata.GetValue(KeyResult))
+		assert.Equal(t, "1500.2", msg.Metadata.GetValue(KeyLastIndex))
+		var result struct {
+			Logs []Log `json:"logs"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, 1, len(result.Logs))
+		assert.Equal(t, "L-1", result.Logs[0].Uid)
+		assert.Equal(t, 22.5, result.Logs[0].Rows[0]["ROP"])
+	})
+
+	test.NodeOnMsg(t, wellNode, []test.Msg{
+		{MetaData: metadata, DataType: types.JSON, MsgType: "WELL", Data: `{}`, AfterSleep: time.Millisecond * 200},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.True(t, strings.HasPrefix(msg.GetData(), "<wells"))
+	})
+
+	test.NodeOnMsg(t, unauthorized, []test.Msg{
+		{MetaData: metadata, DataType: types.JSON, MsgType: "LOG", Data: `{}`, AfterSleep: time.Millisecond * 200},
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "witsml fault: unauthorized", err.Error())
+	})
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, `<logs xmlns="http://www.witsml.org/schemas/1series" version="1.4.1.1"><log uidWell="W-1" uidWellbore="B-1" uid="L-1"/></logs>`, store.queries[0])
+	assert.Equal(t, `<wells xmlns="http://www.witsml.org/schemas/1series" version="1.4.1.1"><well uid="W-1"/></wells>`, store.queries[2])
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package zigbee2mqtt 提供 Zigbee2MQTT 设备集成节点
+// 按 Zigbee2MQTT 的主题约定解析设备状态、可用性和网关消息，并且把规则链的输出构造为 <base>/<friendly_name>/set 命令，
+// MQTT 的订阅和发布使用 RuleGo 的 MQTT 端点和 mqttClient 节点
+package zigbee2mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultBaseTopic Zigbee2MQTT 默认的基础主题
+const DefaultBaseTopic = "zigbee2mqtt"
+
+// 消息类型
+const (
+	// TypeState 设备状态，主题：<base>/<friendly_name>
+	TypeState = "state"
+	// TypeAvailability 设备可用性，主题：<base>/<friendly_name>/availability
+	TypeAvailability = "availability"
+	// TypeBridgeState 网关状态，主题：<base>/bridge/state
+	TypeBridgeState = "bridgeState"
+	// TypeDevices 网关的设备列表，主题：<base>/bridge/devices
+	TypeDevices = "devices"
+	// TypeBridge 其他网关消息，主题：<base>/bridge/...
+	TypeBridge = "bridge"
+	// TypeCommand 设备命令，主题：<base>/<friendly_name>/set 或者 get
+	TypeCommand = "command"
+)
+
+// 可用性
+const (
+	Online  = "online"
+	Offline = "offline"
+)
+
+// Topic 解析后的主题
+type Topic struct {
+	// Type 消息类型
+	Type string
+	// Device 设备友好名称，可以包含 /
+	Device string
+	// Rest 网关主题 bridge/ 之后的部分，或者命令主题 set/get 之后的属性
+	Rest string
+}
+
+// commandSuffixes 命令主题的后缀
+var commandSuffixes = []string{"/set", "/get"}
+
+// ParseTopic 按基础主题解析 Zigbee2MQTT 主题
+func ParseTopic(baseTopic, topic string) (Topic, error) {
+	rest, ok := strings.CutPrefix(topic, strings.TrimSuffix(baseTopic, "/")+"/")
+	if !ok || rest == "" {
+		return Topic{}, fmt.Errorf("not a zigbee2mqtt topic: %s", topic)
+	}
+	if bridge, ok := strings.CutPrefix(rest, "bridge/"); ok {
+		switch bridge {
+		case "state":
+			return Topic{Type: TypeBridgeState}, nil
+		case "devices":
+			return Topic{Type: TypeDevices}, nil
+		}
+		return Topic{Type: TypeBridge, Rest: bridge}, nil
+	}
+	if device, ok := strings.CutSuffix(rest, "/availability"); ok {
+		return Topic{Type: TypeAvailability, Device: device}, nil
+	}
+	for _, suffix := range commandSuffixes {
+		if i := strings.LastIndex(rest, suffix); i > 0 && (len(rest) == i+len(suffix) || rest[i+len(suffix)] == '/') {
+			return Topic{Type: TypeCommand, Device: rest[:i], Rest: strings.TrimPrefix(rest[i:], "/")}, nil
+		}
+	}
+	return Topic{Type: TypeState, Device: rest}, nil
+}
+
+// ParseAvailability 解析可用性负荷，支持 {"state":"online"} 和旧版本的 online/offline 字符串
+func ParseAvailability(payload []byte) (string, error) {
+	s := strings.TrimSpace(string(payload))
+	var v struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(s), &v); err == nil && v.State != "" {
+		s = v.State
+	}
+	switch s = strings.ToLower(s); s {
+	case Online, Offline:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid zigbee2mqtt availability: %s", payload)
+}
+
+// switchValues 开关类属性开和关的值，eg. state、state_l1 为 ON/OFF，child_lock 为 LOCK/UNLOCK
+func switchValues(key string) (on, off string, ok bool) {
+	switch {
+	case key == "state" || strings.HasPrefix(key, "state_"):
+		return "ON", "OFF", true
+	case key == "child_lock":
+		return "LOCK", "UNLOCK", true
+	}
+	return "", "", false
+}
+
+// NormalizeState 规范化设备状态：开关类属性的 ON/OFF 转换为布尔值，嵌套对象展开为 key.sub，eg.
+//
+//	{"state":"ON","brightness":254,"color":{"x":0.3,"y":0.3}} -> {"state":true,"brightness":254,"color.x":0.3,"color.y":0.3}
+func NormalizeState(state map[string]interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(state))
+	flatten(values, "", state)
+	return values
+}
+
+func flatten(values map[string]interface{}, prefix string, state map[string]interface{}) {
+	for k, v := range state {
+		key := prefix + k
+		switch value := v.(type) {
+		case map[string]interface{}:
+			flatten(values, key+".", value)
+		case string:
+			on, off, ok := switchValues(key)
+			switch {
+			case ok && strings.EqualFold(value, on):
+				values[key] = true
+			case ok && strings.EqualFold(value, off):
+				values[key] = false
+			default:
+				values[key] = value
+			}
+		default:
+			values[key] = value
+		}
+	}
+}
+
+// Device 网关设备列表中的设备
+type Device struct {
+	FriendlyName string `json:"friendlyName"`
+	IeeeAddress  string `json:"ieeeAddress"`
+	Type         string `json:"type"`
+	Vendor       string `json:"vendor,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Supported    bool   `json:"supported"`
+	Disabled     bool   `json:"disabled"`
+}
+
+// ParseDevices 解析 bridge/devices 的设备列表
+func ParseDevices(payload []byte) ([]Device, error) {
+	var list []struct {
+		FriendlyName string `json:"friendly_name"`
+		IeeeAddress  string `json:"ieee_address"`
+		Type         string `json:"type"`
+		Supported    bool   `json:"supported"`
+		Disabled     bool   `json:"disabled"`
+		Definition   *struct {
+			Vendor string `json:"vendor"`
+			Model  string `json:"model"`
+		} `json:"definition"`
+	}
+	if err := json.Unmarshal(payload, &list); err != nil {
+		return nil, err
+	}
+	devices := make([]Device, len(list))
+	for i, d := range list {
+		devices[i] = Device{FriendlyName: d.FriendlyName, IeeeAddress: d.IeeeAddress, Type: d.Type, Supported: d.Supported, Disabled: d.Disabled}
+		if d.Definition != nil {
+			devices[i].Vendor, devices[i].Model = d.Definition.Vendor, d.Definition.Model
+		}
+	}
+	return devices, nil
+}
+
+// CommandPayload 构造 set 命令的负荷，NormalizeState 的逆过程：开关类属性的布尔值转换为 ON/OFF 或者 LOCK/UNLOCK，key.sub 还原为嵌套对象
+func CommandPayload(values map[string]interface{}) map[string]interface{} {
+	payload := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if b, ok := v.(bool); ok {
+			if on, off, ok := switchValues(k); ok && b {
+				v = on
+			} else if ok {
+				v = off
+			}
+		}
+		parts := strings.Split(k, ".")
+		m := payload
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := m[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				m[part] = child
+			}
+			m = child
+		}
+		m[parts[len(parts)-1]] = v
+	}
+	return payload
+}
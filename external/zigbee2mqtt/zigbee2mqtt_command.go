@@ -0,0 +1,145 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zigbee2mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 命令
+const (
+	CommandSet = "set"
+	CommandGet = "get"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&CommandNode{})
+}
+
+// CommandConfiguration 节点配置
+type CommandConfiguration struct {
+	// BaseTopic Zigbee2MQTT 基础主题，默认 zigbee2mqtt
+	BaseTopic string `json:"baseTopic" label:"Base Topic" desc:"Zigbee2MQTT base topic, default zigbee2mqtt"`
+	// Device 设备友好名称，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	Device string `json:"device" label:"Device" desc:"Device friendly name, supports ${metadata.key} and ${msg.key} variables" required:"true"`
+	// Command 命令：set 或者 get，默认 set
+	Command string `json:"command" label:"Command" desc:"Command: set or get, default set" component:"{\"type\":\"select\",\"options\":[{\"label\":\"set\",\"value\":\"set\"},{\"label\":\"get\",\"value\":\"get\"}]}"`
+}
+
+// CommandNode 把规则链的输出构造为 Zigbee2MQTT 命令，msg.Data 为属性->值的对象，eg.
+//
+//	{"state": true, "brightness": 128, "color.x": 0.3}
+//
+// set 命令的负荷按 CommandPayload 转换，eg. {"state":"ON","brightness":128,"color":{"x":0.3}}，
+// get 命令的负荷为属性->空字符串，eg. {"state":""}。
+// 命令主题 <base>/<friendly_name>/set 写入元数据 topic，负荷重新赋值到 msg.Data，
+// 后续使用 mqttClient 节点发布到主题 ${metadata.topic}。
+// 构造成功，流转到`Success`链，否则流转到`Failure`链
+type CommandNode struct {
+	//节点配置
+	Config         CommandConfiguration
+	deviceTemplate str.Template
+}
+
+// Type 返回组件类型
+func (x *CommandNode) Type() string {
+	return "x/zigbee2mqttCommand"
+}
+
+// New 默认参数
+func (x *CommandNode) New() types.Node {
+	return &CommandNode{
+		Config: CommandConfiguration{
+			BaseTopic: DefaultBaseTopic,
+			Device:    "${metadata.device}",
+			Command:   CommandSet,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *CommandNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if x.Config.BaseTopic == "" {
+		x.Config.BaseTopic = DefaultBaseTopic
+	}
+	if x.Config.Command == "" {
+		x.Config.Command = CommandSet
+	}
+	if x.Config.Command != CommandSet && x.Config.Command != CommandGet {
+		return fmt.Errorf("unsupported zigbee2mqtt command: %s", x.Config.Command)
+	}
+	if strings.TrimSpace(x.Config.Device) == "" {
+		return errors.New("zigbee2mqtt device is empty")
+	}
+	x.deviceTemplate = str.NewTemplate(x.Config.Device)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *CommandNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	device := x.deviceTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	if device == "" || device == "bridge" {
+		ctx.TellFailure(msg, fmt.Errorf("invalid zigbee2mqtt device: %s", device))
+		return
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &values); err != nil {
+		ctx.TellFailure(msg, fmt.Errorf("zigbee2mqtt command payload must be a json object: %w", err))
+		return
+	}
+	if len(values) == 0 {
+		ctx.TellFailure(msg, errors.New("zigbee2mqtt command payload is empty"))
+		return
+	}
+	payload := CommandPayload(values)
+	if x.Config.Command == CommandGet {
+		for k := range payload {
+			payload[k] = ""
+		}
+	}
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(TopicMetadataKey, strings.TrimSuffix(x.Config.BaseTopic, "/")+"/"+device+"/"+x.Config.Command)
+	msg.Metadata.PutValue(DeviceMetadataKey, device)
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *CommandNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *CommandNode) Desc() string {
+	return "Build a Zigbee2MQTT <base>/<friendly_name>/set or get command from the message, to be published by an MQTT client node. Routes to Success/Failure"
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zigbee2mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/rulego/rulego"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// DeviceMetadataKey 设备友好名称
+	DeviceMetadataKey = "device"
+	// MessageTypeMetadataKey 消息类型
+	MessageTypeMetadataKey = "messageType"
+	// AvailabilityMetadataKey 设备或者网关的可用性：online、offline
+	AvailabilityMetadataKey = "availability"
+	// IeeeAddressMetadataKey 设备 IEEE 地址
+	IeeeAddressMetadataKey = "ieeeAddress"
+	// TopicMetadataKey MQTT 主题
+	TopicMetadataKey = "topic"
+)
+
+func init() {
+	_ = rulego.Registry.Register(&DecodeNode{})
+}
+
+// Message 规范化后的消息
+type Message struct {
+	Type         string                 `json:"type"`
+	Device       string                 `json:"device,omitempty"`
+	IeeeAddress  string                 `json:"ieeeAddress,omitempty"`
+	Availability string                 `json:"availability,omitempty"`
+	Values       map[string]interface{} `json:"values,omitempty"`
+	Devices      []Device               `json:"devices,omitempty"`
+}
+
+// DecodeConfiguration 节点配置
+type DecodeConfiguration struct {
+	// BaseTopic Zigbee2MQTT 基础主题，默认 zigbee2mqtt
+	BaseTopic string `json:"baseTopic" label:"Base Topic" desc:"Zigbee2MQTT base topic, default zigbee2mqtt"`
+	// Topic 消息的 MQTT 主题，可以使用 ${metadata.key} 变量，默认使用 MQTT 端点写入的 ${metadata.topic}
+	Topic string `json:"topic" label:"Topic" desc:"MQTT topic of the message, supports ${metadata.key} variables, default ${metadata.topic} set by the MQTT endpoint"`
+	// Normalize 是否规范化设备状态：ON/OFF 转换为布尔值，嵌套对象展开
+	Normalize bool `json:"normalize" label:"Normalize" desc:"Normalize device state: ON/OFF to boolean and flatten nested objects"`
+}
+
+// DecodeNode Zigbee2MQTT 消息解析节点，msg.Data 为订阅 <base>/# 收到的负荷，按主题解析为：
+//
+//	{"type":"state","device":"living_room_lamp","ieeeAddress":"0x00158d0001a2b3c4","availability":"online","values":{"state":true,"brightness":254}}
+//
+// 消息类型 type 为 state、availability、bridgeState、devices、bridge、command，
+// 节点记录设备的可用性和 bridge/devices 中的 IEEE 地址，附加到设备状态消息，
+// 并把 device、messageType、availability、ieeeAddress 写入元数据。
+// 解析成功，流转到`Success`链，否则流转到`Failure`链
+type DecodeNode struct {
+	//节点配置
+	Config        DecodeConfiguration
+	topicTemplate str.Template
+	mu            sync.RWMutex
+	// availability 设备友好名称->可用性
+	availability map[string]string
+	// ieeeAddresses 设备友好名称->IEEE 地址
+	ieeeAddresses map[string]string
+}
+
+// Type 返回组件类型
+func (x *DecodeNode) Type() string {
+	return "x/zigbee2mqttDecode"
+}
+
+// New 默认参数
+func (x *DecodeNode) New() types.Node {
+	return &DecodeNode{
+		Config: DecodeConfiguration{
+			BaseTopic: DefaultBaseTopic,
+			Topic:     "${metadata.topic}",
+			Normalize: true,
+		},
+	}
+}
+
+// Init 初始化组件
+func (x *DecodeNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if x.Config.BaseTopic == "" {
+		x.Config.BaseTopic = DefaultBaseTopic
+	}
+	x.topicTemplate = str.NewTemplate(x.Config.Topic)
+	x.availability = make(map[string]string)
+	x.ieeeAddresses = make(map[string]string)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *DecodeNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	topic, err := ParseTopic(x.Config.BaseTopic, x.topicTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg)))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(MessageTypeMetadataKey, topic.Type)
+	if topic.Device != "" {
+		msg.Metadata.PutValue(DeviceMetadataKey, topic.Device)
+	}
+	// 其他网关消息和命令不解析
+	if topic.Type == TypeBridge || topic.Type == TypeCommand {
+		ctx.TellSuccess(msg)
+		return
+	}
+	m, err := x.decode(topic, []byte(msg.GetData()))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if m.Availability != "" {
+		msg.Metadata.PutValue(AvailabilityMetadataKey, m.Availability)
+	}
+	if m.IeeeAddress != "" {
+		msg.Metadata.PutValue(IeeeAddressMetadataKey, m.IeeeAddress)
+	}
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetDataType(types.JSON)
+	msg.SetData(str.ToString(bytes))
+	ctx.TellSuccess(msg)
+}
+
+// decode 按消息类型解析负荷，并记录设备的可用性和 IEEE 地址
+func (x *DecodeNode) decode(topic Topic, payload []byte) (*Message, error) {
+	m := &Message{Type: topic.Type, Device: topic.Device}
+	switch topic.Type {
+	case TypeAvailability, TypeBridgeState:
+		availability, err := ParseAvailability(payload)
+		if err != nil {
+			return nil, err
+		}
+		m.Availability = availability
+		if topic.Type == TypeAvailability {
+			x.mu.Lock()
+			x.availability[topic.Device] = availability
+			x.mu.Unlock()
+		}
+	case TypeDevices:
+		devices, err := ParseDevices(payload)
+		if err != nil {
+			return nil, err
+		}
+		m.Devices = devices
+		x.mu.Lock()
+		for _, d := range devices {
+			x.ieeeAddresses[d.FriendlyName] = d.IeeeAddress
+		}
+		x.mu.Unlock()
+		return m, nil
+	case TypeState:
+		var state map[string]interface{}
+		if err := json.Unmarshal(payload, &state); err != nil {
+			return nil, fmt.Errorf("invalid zigbee2mqtt state of %s: %w", topic.Device, err)
+		}
+		if x.Config.Normalize {
+			state = NormalizeState(state)
+		}
+		m.Values = state
+		x.mu.RLock()
+		m.Availability = x.availability[topic.Device]
+		x.mu.RUnlock()
+	}
+	x.mu.RLock()
+	m.IeeeAddress = x.ieeeAddresses[topic.Device]
+	x.mu.RUnlock()
+	return m, nil
+}
+
+// Destroy 销毁组件
+func (x *DecodeNode) Destroy() {
+}
+
+// Desc returns the component description
+func (x *DecodeNode) Desc() string {
+	return "Zigbee2MQTT message decoder normalizing device state, availability and bridge device lists by topic. Routes to Success/Failure"
+}
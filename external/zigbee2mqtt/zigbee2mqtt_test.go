@@ -0,0 +1,183 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zigbee2mqtt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		want  Topic
+	}{
+		{"zigbee2mqtt/living_room_lamp", Topic{Type: TypeState, Device: "living_room_lamp"}},
+		{"zigbee2mqtt/floor1/lamp", Topic{Type: TypeState, Device: "floor1/lamp"}},
+		{"zigbee2mqtt/floor1/lamp/availability", Topic{Type: TypeAvailability, Device: "floor1/lamp"}},
+		{"zigbee2mqtt/lamp/set", Topic{Type: TypeCommand, Device: "lamp", Rest: "set"}},
+		{"zigbee2mqtt/lamp/set/brightness", Topic{Type: TypeCommand, Device: "lamp", Rest: "set/brightness"}},
+		{"zigbee2mqtt/lamp/get", Topic{Type: TypeCommand, Device: "lamp", Rest: "get"}},
+		{"zigbee2mqtt/settings_panel", Topic{Type: TypeState, Device: "settings_panel"}},
+		{"zigbee2mqtt/bridge/state", Topic{Type: TypeBridgeState}},
+		{"zigbee2mqtt/bridge/devices", Topic{Type: TypeDevices}},
+		{"zigbee2mqtt/bridge/event", Topic{Type: TypeBridge, Rest: "event"}},
+	}
+	for _, tt := range tests {
+		topic, err := ParseTopic("zigbee2mqtt", tt.topic)
+		assert.Nil(t, err, tt.topic)
+		assert.Equal(t, tt.want, topic, tt.topic)
+	}
+	topic, err := ParseTopic("home/z2m/", "home/z2m/lamp")
+	assert.Nil(t, err)
+	assert.Equal(t, "lamp", topic.Device)
+	for _, s := range []string{"zigbee2mqtt", "zigbee2mqtt/", "other/lamp", ""} {
+		_, err = ParseTopic("zigbee2mqtt", s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestNormalizeState(t *testing.T) {
+	var state map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"state": "ON", "state_l2": "off", "child_lock": "LOCK", "brightness": 254, "linkquality": 120,
+		"color": {"x": 0.3, "y": 0.4}, "update": {"state": "idle"}, "action": "single"
+	}`), &state))
+	values := NormalizeState(state)
+	assert.Equal(t, map[string]interface{}{
+		"state": true, "state_l2": false, "child_lock": true, "brightness": 254.0, "linkquality": 120.0,
+		"color.x": 0.3, "color.y": 0.4, "update.state": "idle", "action": "single",
+	}, values)
+
+	payload := CommandPayload(map[string]interface{}{"state": false, "child_lock": false, "brightness": 128, "color.x": 0.3, "color.y": 0.4, "on": true})
+	assert.Equal(t, map[string]interface{}{
+		"state": "OFF", "child_lock": "UNLOCK", "brightness": 128, "color": map[string]interface{}{"x": 0.3, "y": 0.4}, "on": true,
+	}, payload)
+
+	for payload, want := range map[string]string{`online`: Online, `{"state":"offline"}`: Offline, ` ONLINE `: Online} {
+		availability, err := ParseAvailability([]byte(payload))
+		assert.Nil(t, err, payload)
+		assert.Equal(t, want, availability, payload)
+	}
+	_, err := ParseAvailability([]byte(`{"state":"unknown"}`))
+	assert.NotNil(t, err)
+
+	devices, err := ParseDevices([]byte(`[
+		{"friendly_name": "Coordinator", "ieee_address": "0x00124b0018e2a1b2", "type": "Coordinator", "supported": false, "definition": null},
+		{"friendly_name": "living_room_lamp", "ieee_address": "0x00158d0001a2b3c4", "type": "Router", "supported": true,
+		 "definition": {"vendor": "IKEA", "model": "LED1545G12", "exposes": []}}
+	]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Device{
+		{FriendlyName: "Coordinator", IeeeAddress: "0x00124b0018e2a1b2", Type: "Coordinator"},
+		{FriendlyName: "living_room_lamp", IeeeAddress: "0x00158d0001a2b3c4", Type: "Router", Vendor: "IKEA", Model: "LED1545G12", Supported: true},
+	}, devices)
+}
+
+func TestDecodeNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&DecodeNode{})
+	node, err := test.CreateAndInitNode("x/zigbee2mqttDecode", types.Configuration{}, Registry)
+	assert.Nil(t, err)
+
+	newMsg := func(topic, data string) test.Msg {
+		metadata := types.NewMetadata()
+		metadata.PutValue(TopicMetadataKey, topic)
+		return test.Msg{
+			MetaData:   metadata,
+			DataType:   types.JSON,
+			MsgType:    "MQTT",
+			Data:       data,
+			AfterSleep: time.Millisecond * 100,
+		}
+	}
+	var results []types.RuleMsg
+	var relations []string
+	test.NodeOnMsg(t, node, []test.Msg{
+		newMsg("zigbee2mqtt/bridge/devices", `[{"friendly_name": "lamp", "ieee_address": "0x00158d0001a2b3c4", "type": "Router", "supported": true}]`),
+		newMsg("zigbee2mqtt/lamp/availability", `{"state": "online"}`),
+		newMsg("zigbee2mqtt/lamp", `{"state": "ON", "brightness": 254}`),
+		newMsg("zigbee2mqtt/bridge/state", `offline`),
+		newMsg("zigbee2mqtt/lamp/set", `{"state": "OFF"}`),
+		newMsg("zigbee2mqtt/lamp", `not json`),
+		newMsg("other/lamp", `{}`),
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		results = append(results, msg)
+		relations = append(relations, relationType)
+	})
+	assert.Equal(t, []string{types.Success, types.Success, types.Success, types.Success, types.Success, types.Failure, types.Failure}, relations)
+
+	assert.Equal(t, TypeDevices, results[0].Metadata.GetValue(MessageTypeMetadataKey))
+	assert.Equal(t, Online, results[1].Metadata.GetValue(AvailabilityMetadataKey))
+	state := results[2]
+	assert.Equal(t, "lamp", state.Metadata.GetValue(DeviceMetadataKey))
+	assert.Equal(t, Online, state.Metadata.GetValue(AvailabilityMetadataKey))
+	assert.Equal(t, "0x00158d0001a2b3c4", state.Metadata.GetValue(IeeeAddressMetadataKey))
+	var m Message
+	assert.Nil(t, json.Unmarshal([]byte(state.GetData()), &m))
+	assert.Equal(t, map[string]interface{}{"state": true, "brightness": 254.0}, m.Values)
+	assert.Equal(t, TypeState, m.Type)
+	assert.Equal(t, `{"type":"bridgeState","availability":"offline"}`, results[3].GetData())
+	assert.Equal(t, `{"state": "OFF"}`, results[4].GetData())
+}
+
+func TestCommandNode(t *testing.T) {
+	Registry := &types.SafeComponentSlice{}
+	Registry.Add(&CommandNode{})
+	_, err := test.CreateAndInitNode("x/zigbee2mqttCommand", types.Configuration{"command": "toggle"}, Registry)
+	assert.NotNil(t, err)
+	node, err := test.CreateAndInitNode("x/zigbee2mqttCommand", types.Configuration{}, Registry)
+	assert.Nil(t, err)
+	getNode, err := test.CreateAndInitNode("x/zigbee2mqttCommand", types.Configuration{"baseTopic": "home/z2m", "command": "get", "device": "lamp"}, Registry)
+	assert.Nil(t, err)
+
+	newMsg := func(msgType, device, data string) test.Msg {
+		metadata := types.NewMetadata()
+		metadata.PutValue(DeviceMetadataKey, device)
+		return test.Msg{
+			MetaData:   metadata,
+			DataType:   types.JSON,
+			MsgType:    msgType,
+			Data:       data,
+			AfterSleep: time.Millisecond * 100,
+		}
+	}
+	test.NodeOnMsg(t, node, []test.Msg{
+		newMsg("ON", "lamp", `{"state": true, "brightness": 128}`),
+		newMsg("NO_DEVICE", "", `{"state": true}`),
+		newMsg("INVALID", "lamp", `[1]`),
+		newMsg("EMPTY", "lamp", `{}`),
+	}, func(msg types.RuleMsg, relationType string, err error) {
+		if msg.Type != "ON" {
+			assert.Equal(t, types.Failure, relationType)
+			return
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "zigbee2mqtt/lamp/set", msg.Metadata.GetValue(TopicMetadataKey))
+		assert.Equal(t, `{"brightness":128,"state":"ON"}`, msg.GetData())
+	})
+	test.NodeOnMsg(t, getNode, []test.Msg{newMsg("GET", "", `{"state": true, "color.x": 0}`)}, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "home/z2m/lamp/get", msg.Metadata.GetValue(TopicMetadataKey))
+		assert.Equal(t, `{"color":"","state":""}`, msg.GetData())
+	})
+}
@@ -5,11 +5,18 @@ go 1.23
 toolchain go1.24.3
 
 require (
+	github.com/eclipse/paho.mqtt.golang v1.4.3
+	github.com/goburrow/serial v0.1.0
 	github.com/gopcua/opcua v0.8.0
+	github.com/gorilla/websocket v1.5.0
+	github.com/pion/dtls/v3 v3.0.6
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/rulego/rulego v0.35.3-0.20260527090622-d8b29d722bac
 	github.com/simonvetter/modbus v1.6.4
 	go.bug.st/serial v1.6.4
+	golang.org/x/crypto v0.32.0
+	golang.org/x/sys v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,21 +24,19 @@ require (
 	github.com/creack/goselect v0.1.2 // indirect
 	github.com/dlclark/regexp2 v1.7.0 // indirect
 	github.com/dop251/goja v0.0.0-20231024180952-594410467bc6 // indirect
-	github.com/eclipse/paho.mqtt.golang v1.4.3 // indirect
 	github.com/expr-lang/expr v1.17.7 // indirect
 	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/go-sql-driver/mysql v1.8.1 // indirect
-	github.com/goburrow/serial v0.1.0 // indirect
 	github.com/gofrs/uuid/v5 v5.0.0 // indirect
 	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
-	github.com/gorilla/websocket v1.5.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/julienschmidt/httprouter v1.3.0 // indirect
 	github.com/lib/pq v1.10.9 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
-	golang.org/x/crypto v0.31.0 // indirect
-	golang.org/x/net v0.33.0 // indirect
+	github.com/pion/logging v0.2.3 // indirect
+	github.com/pion/transport/v3 v3.0.7 // indirect
+	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
 
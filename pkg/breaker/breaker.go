@@ -0,0 +1,313 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 熔断器状态
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// DefaultOpenTimeout 默认的断开时间
+const DefaultOpenTimeout = 30 * time.Second
+
+// ErrCircuitOpen 熔断器断开，设备不可用，请求没有发送到设备
+var ErrCircuitOpen = errors.New("CIRCUIT_OPEN")
+
+// OpenError 熔断器断开时返回的错误，errors.Is(err, ErrCircuitOpen) 为 true
+type OpenError struct {
+	Component string
+	Target    string
+	// RetryAfter 允许下一次试探请求的时间
+	RetryAfter time.Time
+	// LastError 断开前最后一次失败的错误
+	LastError string
+}
+
+func (e *OpenError) Error() string {
+	msg := fmt.Sprintf("%s: %s %s is unavailable, retry after %s", ErrCircuitOpen, e.Component, e.Target, e.RetryAfter.Format(time.RFC3339))
+	if e.LastError != "" {
+		msg += ", last error: " + e.LastError
+	}
+	return msg
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// IsOpen 是否熔断器断开的错误
+func IsOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// Config 熔断器配置
+type Config struct {
+	// FailureThreshold 连续通信失败次数达到阈值后断开，0 表示不启用熔断
+	FailureThreshold int `json:"failureThreshold" label:"Failure Threshold" desc:"Consecutive communication failures that open the circuit, requests then fail at once with CIRCUIT_OPEN instead of waiting for the timeout. 0 disables the circuit breaker"`
+	// OpenTimeout 断开后经过多久允许一次试探请求，单位毫秒，0 使用默认值 30000
+	OpenTimeout int `json:"openTimeout" label:"Open Timeout" desc:"Time in milliseconds the circuit stays open before one trial request is allowed, 0 uses the default 30000"`
+}
+
+// Enabled 是否启用熔断
+func (c Config) Enabled() bool {
+	return c.FailureThreshold > 0
+}
+
+// Validate 校验配置
+func (c Config) Validate() error {
+	if c.FailureThreshold < 0 || c.OpenTimeout < 0 {
+		return errors.New("circuit breaker failureThreshold and openTimeout cannot be negative")
+	}
+	return nil
+}
+
+// openTimeout 断开时间
+func (c Config) openTimeout() time.Duration {
+	if c.OpenTimeout <= 0 {
+		return DefaultOpenTimeout
+	}
+	return time.Duration(c.OpenTimeout) * time.Millisecond
+}
+
+// Status 熔断器状态
+type Status struct {
+	Component string `json:"component"`
+	Target    string `json:"target"`
+	// State 状态：closed, open, half-open
+	State string `json:"state"`
+	// Since 进入当前状态的时间
+	Since time.Time `json:"since"`
+	// Failures 连续失败次数
+	Failures int `json:"failures"`
+	// Opens 断开次数
+	Opens uint64 `json:"opens"`
+	// Rejected 断开期间拒绝的请求次数
+	Rejected uint64 `json:"rejected"`
+	// LastError 最后一次失败的错误
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Breaker 按客户端 key（组件和连接目标）共享的熔断器，并发安全
+//
+// 关闭（closed）状态正常执行请求，连续通信失败达到 FailureThreshold 次后断开（open）；
+// 断开期间 Allow 直接返回 OpenError，规则链快速失败，不再等待连接超时；
+// 断开 OpenTimeout 后进入半开（half-open）状态，只允许一个试探请求，成功后关闭，失败后重新断开。
+// 试探请求超过 OpenTimeout 没有上报结果时允许下一个试探请求
+//
+// 调用方在请求前调用 Allow，请求后调用 Done 上报结果，只有通信错误（超时、连接失败、连接断开）需要作为失败上报，
+// 设备返回的异常响应说明设备可用，应作为成功上报
+type Breaker struct {
+	mu     sync.Mutex
+	config Config
+	status Status
+	// deadline 断开状态为允许试探的时间，半开状态为试探请求的超时时间
+	deadline time.Time
+	// refs 引用计数，为 0 时从全局移除
+	refs int
+}
+
+// New 创建熔断器
+func New(component, target string, config Config) *Breaker {
+	return &Breaker{
+		config: config,
+		status: Status{Component: component, Target: target, State: StateClosed, Since: time.Now()},
+	}
+}
+
+// Allow 是否允许执行请求，熔断器断开时返回 OpenError。nil 熔断器总是允许
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.config.Enabled() || b.status.State == StateClosed {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(b.deadline) {
+		b.status.Rejected++
+		return &OpenError{
+			Component:  b.status.Component,
+			Target:     b.status.Target,
+			RetryAfter: b.deadline,
+			LastError:  b.status.LastError,
+		}
+	}
+	// 允许一个试探请求
+	if b.status.State != StateHalfOpen {
+		b.status.State, b.status.Since = StateHalfOpen, now
+	}
+	b.deadline = now.Add(b.config.openTimeout())
+	return nil
+}
+
+// Done 上报请求结果，err 为 nil 表示成功，熔断器断开的错误不计入
+func (b *Breaker) Done(err error) {
+	if b == nil || IsOpen(err) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if err == nil {
+		b.status.Failures = 0
+		if b.status.State != StateClosed {
+			b.status.State, b.status.Since = StateClosed, now
+		}
+		return
+	}
+	b.status.Failures++
+	b.status.LastError = err.Error()
+	if !b.config.Enabled() {
+		return
+	}
+	if b.status.State == StateHalfOpen || (b.status.State == StateClosed && b.status.Failures >= b.config.FailureThreshold) {
+		b.status.State, b.status.Since = StateOpen, now
+		b.status.Opens++
+		b.deadline = now.Add(b.config.openTimeout())
+	}
+}
+
+// Do 熔断器允许时执行 fn 并上报结果，isFailure 判断错误是否计为失败，为 nil 时所有错误都计为失败
+func (b *Breaker) Do(fn func() error, isFailure func(err error) bool) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	if err != nil && isFailure != nil && !isFailure(err) {
+		b.Done(nil)
+	} else {
+		b.Done(err)
+	}
+	return err
+}
+
+// State 当前状态
+func (b *Breaker) State() string {
+	return b.Status().State
+}
+
+// Status 当前状态
+func (b *Breaker) Status() Status {
+	if b == nil {
+		return Status{State: StateClosed}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.status
+}
+
+// Reset 恢复为关闭状态
+func (b *Breaker) Reset() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status.Failures = 0
+	b.status.State, b.status.Since = StateClosed, time.Now()
+	b.deadline = time.Time{}
+}
+
+// breakers 全局熔断器，key 为组件和连接目标
+var (
+	breakersLock sync.Mutex
+	breakers     = map[string]*Breaker{}
+)
+
+func key(component, target string) string {
+	return component + "\x00" + target
+}
+
+// Acquire 获取组件和连接目标对应的熔断器，不存在则创建，引用计数加1，不再使用时需要调用 Release
+// 连接相同目标的节点共享熔断器，配置以第一个启用熔断的节点为准
+func Acquire(component, target string, config Config) *Breaker {
+	k := key(component, target)
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+	b, ok := breakers[k]
+	if !ok {
+		b = New(component, target, config)
+		breakers[k] = b
+	}
+	b.mu.Lock()
+	if !b.config.Enabled() && config.Enabled() {
+		b.config = config
+	}
+	b.refs++
+	b.mu.Unlock()
+	return b
+}
+
+// Release 引用计数减1，为 0 时从全局移除
+func (b *Breaker) Release() {
+	if b == nil {
+		return
+	}
+	k := key(b.status.Component, b.status.Target)
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.refs > 0 {
+		b.refs--
+	}
+	if b.refs == 0 && breakers[k] == b {
+		delete(breakers, k)
+	}
+}
+
+// Get 获取全局熔断器，不存在返回 nil
+func Get(component, target string) *Breaker {
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+	return breakers[key(component, target)]
+}
+
+// List 获取组件的所有熔断器状态，component 为空返回全部，按组件和目标排序
+func List(component string) []Status {
+	breakersLock.Lock()
+	list := make([]*Breaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersLock.Unlock()
+	result := make([]Status, 0, len(list))
+	for _, b := range list {
+		if s := b.Status(); component == "" || s.Component == component {
+			result = append(result, s)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Component != result[j].Component {
+			return result[i].Component < result[j].Component
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result
+}
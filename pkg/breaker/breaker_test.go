@@ -0,0 +1,136 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	b := New("s7", "127.0.0.1:102/0/1", Config{FailureThreshold: 2, OpenTimeout: 50})
+	timeout := errors.New("i/o timeout")
+
+	assert.Nil(t, b.Allow())
+	b.Done(timeout)
+	assert.Equal(t, StateClosed, b.State())
+	// 成功后重新计数
+	b.Done(nil)
+	b.Done(timeout)
+	assert.Equal(t, StateClosed, b.State())
+	b.Done(timeout)
+	assert.Equal(t, StateOpen, b.State())
+
+	// 断开期间快速失败
+	err := b.Allow()
+	assert.True(t, IsOpen(err))
+	var openErr *OpenError
+	assert.True(t, errors.As(fmt.Errorf("read: %w", err), &openErr))
+	assert.Equal(t, "s7", openErr.Component)
+	assert.Equal(t, "i/o timeout", openErr.LastError)
+	// 熔断错误不计入失败
+	b.Done(err)
+	assert.Equal(t, 2, b.Status().Failures)
+	assert.Equal(t, uint64(1), b.Status().Rejected)
+
+	// 半开状态只允许一个试探请求，失败后重新断开
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.True(t, IsOpen(b.Allow()))
+	b.Done(timeout)
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, uint64(2), b.Status().Opens)
+
+	// 试探成功后关闭
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+	b.Done(nil)
+	assert.Equal(t, StateClosed, b.State())
+	assert.Nil(t, b.Allow())
+
+	// 试探请求没有上报结果时超时后允许下一个试探请求
+	b.Done(timeout)
+	b.Done(timeout)
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+	assert.True(t, IsOpen(b.Allow()))
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+
+	b.Reset()
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestDisabled(t *testing.T) {
+	b := New("modbus", "127.0.0.1:502/1/tcp", Config{})
+	for i := 0; i < 10; i++ {
+		b.Done(errors.New("timeout"))
+	}
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, StateClosed, b.State())
+
+	var nilBreaker *Breaker
+	assert.Nil(t, nilBreaker.Allow())
+	nilBreaker.Done(errors.New("timeout"))
+	nilBreaker.Release()
+
+	assert.NotNil(t, Config{FailureThreshold: -1}.Validate())
+	assert.Equal(t, DefaultOpenTimeout, Config{}.openTimeout())
+}
+
+func TestDo(t *testing.T) {
+	b := New("opcua", "opc.tcp://localhost:4840", Config{FailureThreshold: 1, OpenTimeout: 1000})
+	invalid := errors.New("invalid node id")
+	isFailure := func(err error) bool { return err != invalid }
+
+	// 非通信错误不计为失败
+	assert.Equal(t, invalid, b.Do(func() error { return invalid }, isFailure))
+	assert.Equal(t, StateClosed, b.State())
+
+	calls := 0
+	fn := func() error {
+		calls++
+		return errors.New("connection refused")
+	}
+	assert.NotNil(t, b.Do(fn, isFailure))
+	assert.True(t, IsOpen(b.Do(fn, isFailure)))
+	assert.Equal(t, 1, calls)
+}
+
+func TestAcquire(t *testing.T) {
+	b1 := Acquire("modbus", "127.0.0.1:502/1/tcp", Config{})
+	b2 := Acquire("modbus", "127.0.0.1:502/1/tcp", Config{FailureThreshold: 3})
+	assert.True(t, b1 == b2)
+	// 以第一个启用熔断的配置为准
+	assert.Equal(t, 3, b1.config.FailureThreshold)
+	b3 := Acquire("modbus", "127.0.0.1:502/1/tcp", Config{FailureThreshold: 5})
+	assert.Equal(t, 3, b3.config.FailureThreshold)
+	assert.True(t, Get("modbus", "127.0.0.1:502/1/tcp") == b1)
+	assert.Equal(t, 1, len(List("modbus")))
+	assert.Equal(t, 0, len(List("s7")))
+
+	b1.Release()
+	b2.Release()
+	assert.NotNil(t, Get("modbus", "127.0.0.1:502/1/tcp"))
+	b3.Release()
+	assert.Nil(t, Get("modbus", "127.0.0.1:502/1/tcp"))
+}
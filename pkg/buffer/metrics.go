@@ -0,0 +1,87 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buffer
+
+import "sync"
+
+// 指标名称，标签为 dir（缓存目录）
+const (
+	// MetricWrites 写入缓存的记录数
+	MetricWrites = "buffer_writes_total"
+	// MetricReplayed 重放成功的记录数
+	MetricReplayed = "buffer_replayed_total"
+	// MetricDropped 超过最大缓存字节数丢弃的记录数
+	MetricDropped = "buffer_dropped_total"
+	// MetricExpired 过期丢弃的记录数
+	MetricExpired = "buffer_expired_total"
+	// MetricPendingRecords 待重放的记录数
+	MetricPendingRecords = "buffer_pending_records"
+	// MetricPendingBytes 待重放的字节数
+	MetricPendingBytes = "buffer_pending_bytes"
+)
+
+// LabelDir 缓存目录标签
+const LabelDir = "dir"
+
+// MetricHelps 指标说明，供 MetricsHook 实现注册指标时使用
+var MetricHelps = map[string]string{
+	MetricWrites:         "Number of records written to the store-and-forward buffer.",
+	MetricReplayed:       "Number of buffered records replayed successfully.",
+	MetricDropped:        "Number of buffered records dropped because the buffer exceeded its max size.",
+	MetricExpired:        "Number of buffered records dropped because they exceeded the max age.",
+	MetricPendingRecords: "Number of records waiting to be replayed.",
+	MetricPendingBytes:   "Number of bytes waiting to be replayed.",
+}
+
+// MetricsHook 指标钩子，由调用方实现并对接 Prometheus 等监控系统
+type MetricsHook interface {
+	// IncCounter 计数器增加 delta
+	IncCounter(name string, labels map[string]string, delta float64)
+	// SetGauge 设置仪表盘的值
+	SetGauge(name string, labels map[string]string, value float64)
+}
+
+var (
+	metricsHook     MetricsHook
+	metricsHookLock sync.RWMutex
+)
+
+// SetMetricsHook 设置全局指标钩子，nil 表示不采集指标
+func SetMetricsHook(h MetricsHook) {
+	metricsHookLock.Lock()
+	defer metricsHookLock.Unlock()
+	metricsHook = h
+}
+
+// GetMetricsHook 获取全局指标钩子
+func GetMetricsHook() MetricsHook {
+	metricsHookLock.RLock()
+	defer metricsHookLock.RUnlock()
+	return metricsHook
+}
+
+func incCounter(name, dir string, delta float64) {
+	if h := GetMetricsHook(); h != nil && delta > 0 {
+		h.IncCounter(name, map[string]string{LabelDir: dir}, delta)
+	}
+}
+
+func setGauge(name, dir string, value float64) {
+	if h := GetMetricsHook(); h != nil {
+		h.SetGauge(name, map[string]string{LabelDir: dir}, value)
+	}
+}
@@ -0,0 +1,569 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buffer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSize 默认最大缓存字节数
+	DefaultMaxSize = 64 << 20
+	// DefaultSegmentSize 默认分段文件大小
+	DefaultSegmentSize = 4 << 20
+	// KeyBufferedAt 重放的消息元数据中记录缓存时间（Unix 毫秒）的key
+	KeyBufferedAt = "bufferedAt"
+)
+
+const (
+	segmentExt = ".seg"
+	cursorFile = "cursor"
+	// headerSize 记录头：数据长度(4) + CRC32(4) + 缓存时间 Unix 纳秒(8)
+	headerSize = 16
+	// maxRecordSize 单条记录最大字节数
+	maxRecordSize = 64 << 20
+)
+
+// ErrClosed 缓存已关闭
+var ErrClosed = errors.New("buffer closed")
+
+// Config 磁盘缓存配置
+type Config struct {
+	// Dir 缓存目录，为空表示不启用。每个端点使用独立的目录
+	Dir string `json:"dir" label:"Dir" desc:"Directory of the disk buffer, empty disables store-and-forward. Must be unique per endpoint"`
+	// MaxSize 最大缓存字节数，超过后丢弃最早的数据，默认 64MB
+	MaxSize int64 `json:"maxSize" label:"Max Size" desc:"Max buffered bytes, the oldest data is dropped when exceeded, default 64MB"`
+	// MaxAge 数据最长保留秒数，过期的数据不再重放，0 表示不限制
+	MaxAge int `json:"maxAge" label:"Max Age" desc:"Max seconds to keep buffered data, expired data is dropped without replay, 0 means no limit"`
+	// SegmentSize 分段文件大小，按分段删除已重放和超限的数据，默认 4MB
+	SegmentSize int64 `json:"segmentSize" label:"Segment Size" desc:"Segment file size in bytes, default 4MB"`
+	// ReplayBatch 每次恢复后最多重放的条数，0 表示全部重放
+	ReplayBatch int `json:"replayBatch" label:"Replay Batch" desc:"Max records replayed after each successful delivery, 0 replays all"`
+	// Sync 每次写入后同步到磁盘，断电不丢数据但降低写入性能
+	Sync bool `json:"sync" label:"Sync" desc:"Fsync after each write"`
+}
+
+// Enabled 是否启用缓存
+func (c Config) Enabled() bool {
+	return c.Dir != ""
+}
+
+// Record 缓存的记录
+type Record struct {
+	// Time 写入缓存的时间
+	Time time.Time
+	Data []byte
+}
+
+// Entry 端点缓存的消息
+type Entry struct {
+	Type     string            `json:"type,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Data     string            `json:"data"`
+}
+
+// Stats 缓存统计
+type Stats struct {
+	// Pending 待重放的记录数
+	Pending int `json:"pending"`
+	// Bytes 待重放的字节数
+	Bytes int64 `json:"bytes"`
+	// Written 写入的记录数
+	Written uint64 `json:"written"`
+	// Replayed 重放成功的记录数
+	Replayed uint64 `json:"replayed"`
+	// Dropped 超过最大缓存字节数丢弃的记录数
+	Dropped uint64 `json:"dropped"`
+	// Expired 过期丢弃的记录数
+	Expired uint64 `json:"expired"`
+}
+
+// segment 分段文件，文件名为递增的序号
+type segment struct {
+	seq uint64
+	// size 有效数据的字节数
+	size int64
+	// records 未重放的记录数
+	records int
+	// newest 最新记录的缓存时间
+	newest time.Time
+}
+
+// Queue 磁盘缓存队列，端点处理或者发布数据失败时写入，恢复后按写入顺序重放。
+// 数据追加写入分段文件，读取位置保存在 cursor 文件中，重启后继续重放未完成的数据。并发安全
+type Queue struct {
+	config Config
+	mu     sync.Mutex
+	// replayLock 同一时间只有一个重放
+	replayLock sync.Mutex
+	segments   []*segment
+	// offset 第一个分段的读取位置
+	offset int64
+	writer *os.File
+	stats  Stats
+	closed bool
+}
+
+// Open 打开缓存目录，恢复未重放的数据，截断末尾不完整的记录
+func Open(config Config) (*Queue, error) {
+	if config.Dir == "" {
+		return nil, errors.New("buffer dir cannot be empty")
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultMaxSize
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = DefaultSegmentSize
+	}
+	if config.SegmentSize > config.MaxSize/2 {
+		config.SegmentSize = config.MaxSize / 2
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	q := &Queue{config: config}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expire(time.Now())
+	q.updateGauges()
+	return q, nil
+}
+
+// load 加载分段文件和读取位置
+func (q *Queue) load() error {
+	entries, err := os.ReadDir(q.config.Dir)
+	if err != nil {
+		return err
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	cursorSeq, cursorOffset := q.readCursor()
+	for _, seq := range seqs {
+		if seq < cursorSeq {
+			// 已经重放完成但是没有删除的分段
+			_ = os.Remove(q.segmentPath(seq))
+			continue
+		}
+		from := int64(0)
+		if seq == cursorSeq {
+			from = cursorOffset
+		}
+		s, err := q.scan(seq, from)
+		if err != nil {
+			return err
+		}
+		if len(q.segments) == 0 {
+			q.offset = from
+			if q.offset > s.size {
+				q.offset = s.size
+			}
+		}
+		q.segments = append(q.segments, s)
+		q.stats.Pending += s.records
+	}
+	q.stats.Bytes = q.pendingBytes()
+	return nil
+}
+
+// scan 扫描分段文件，统计 from 之后的记录数，截断末尾损坏或者不完整的记录
+func (q *Queue) scan(seq uint64, from int64) (*segment, error) {
+	path := q.segmentPath(seq)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := &segment{seq: seq}
+	r := bufio.NewReader(f)
+	for {
+		record, n, err := readRecord(r)
+		if err != nil {
+			break
+		}
+		if s.size >= from {
+			s.records++
+		}
+		s.size += n
+		s.newest = record.Time
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > s.size {
+		if err := os.Truncate(path, s.size); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Push 写入一条记录，超过最大缓存字节数时丢弃最早的分段
+func (q *Queue) Push(data []byte) error {
+	if len(data) > maxRecordSize {
+		return fmt.Errorf("buffer record too large: %d bytes", len(data))
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	now := time.Now()
+	if err := q.ensureWriter(); err != nil {
+		return err
+	}
+	buf := make([]byte, headerSize+len(data))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(data)))
+	binary.BigEndian.PutUint64(buf[8:], uint64(now.UnixNano()))
+	copy(buf[headerSize:], data)
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(buf[8:]))
+	if _, err := q.writer.Write(buf); err != nil {
+		return err
+	}
+	if q.config.Sync {
+		if err := q.writer.Sync(); err != nil {
+			return err
+		}
+	}
+	last := q.segments[len(q.segments)-1]
+	last.size += int64(len(buf))
+	last.records++
+	last.newest = now
+	q.stats.Pending++
+	q.stats.Bytes += int64(len(buf))
+	q.stats.Written++
+	incCounter(MetricWrites, q.config.Dir, 1)
+	q.expire(now)
+	q.trim()
+	q.updateGauges()
+	return nil
+}
+
+// PushEntry 写入一条端点消息
+func (q *Queue) PushEntry(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return q.Push(data)
+}
+
+// Replay 按写入顺序重放记录，fn 返回错误时停止并保留该记录，下次继续重放。
+// 过期的记录直接丢弃，返回重放成功的记录数
+func (q *Queue) Replay(fn func(record Record) error) (int, error) {
+	q.replayLock.Lock()
+	defer q.replayLock.Unlock()
+	n := 0
+	for q.config.ReplayBatch <= 0 || n < q.config.ReplayBatch {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return n, ErrClosed
+		}
+		now := time.Now()
+		q.expire(now)
+		record, size, err := q.next()
+		seq, from := uint64(0), q.offset
+		if len(q.segments) > 0 {
+			seq = q.segments[0].seq
+		}
+		q.mu.Unlock()
+		if err != nil {
+			return n, err
+		}
+		if record == nil {
+			return n, nil
+		}
+		if q.expired(record.Time, now) {
+			q.mu.Lock()
+			if q.commit(seq, from, size) {
+				q.stats.Expired++
+				incCounter(MetricExpired, q.config.Dir, 1)
+				q.updateGauges()
+			}
+			q.mu.Unlock()
+			continue
+		}
+		if err := fn(*record); err != nil {
+			return n, err
+		}
+		q.mu.Lock()
+		if q.commit(seq, from, size) {
+			q.stats.Replayed++
+			incCounter(MetricReplayed, q.config.Dir, 1)
+			q.updateGauges()
+		}
+		q.mu.Unlock()
+		n++
+	}
+	return n, nil
+}
+
+// ReplayEntries 按写入顺序重放端点消息，无法解析的记录直接丢弃，见 Replay
+func (q *Queue) ReplayEntries(fn func(record Record, entry Entry) error) (int, error) {
+	return q.Replay(func(record Record) error {
+		var entry Entry
+		if err := json.Unmarshal(record.Data, &entry); err != nil {
+			return nil
+		}
+		return fn(record, entry)
+	})
+}
+
+// Len 待重放的记录数
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats.Pending
+}
+
+// Stats 缓存统计
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
+
+// Dir 缓存目录
+func (q *Queue) Dir() string {
+	return q.config.Dir
+}
+
+// Close 关闭缓存，保存读取位置
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	var err error
+	if q.writer != nil {
+		err = q.writer.Close()
+		q.writer = nil
+	}
+	if len(q.segments) > 0 {
+		if e := q.saveCursor(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// next 读取第一个分段读取位置的记录，删除已经读取完的分段，没有记录返回 nil
+func (q *Queue) next() (*Record, int64, error) {
+	for len(q.segments) > 0 {
+		head := q.segments[0]
+		if q.offset < head.size {
+			f, err := os.Open(q.segmentPath(head.seq))
+			if err != nil {
+				return nil, 0, err
+			}
+			defer f.Close()
+			if _, err := f.Seek(q.offset, io.SeekStart); err != nil {
+				return nil, 0, err
+			}
+			record, size, err := readRecord(bufio.NewReader(f))
+			if err != nil {
+				return nil, 0, fmt.Errorf("read buffer segment %d: %w", head.seq, err)
+			}
+			return record, size, nil
+		}
+		q.removeHead()
+	}
+	return nil, 0, nil
+}
+
+// commit 重放或者丢弃第一个分段读取位置的记录后前移读取位置，分段已经被丢弃时返回 false
+func (q *Queue) commit(seq uint64, from, size int64) bool {
+	if len(q.segments) == 0 || q.segments[0].seq != seq || q.offset != from {
+		return false
+	}
+	q.offset += size
+	q.segments[0].records--
+	q.stats.Pending--
+	q.stats.Bytes = q.pendingBytes()
+	if q.offset >= q.segments[0].size {
+		q.removeHead()
+	} else {
+		_ = q.saveCursor()
+	}
+	return true
+}
+
+// expire 丢弃所有记录都已过期的分段
+func (q *Queue) expire(now time.Time) {
+	for len(q.segments) > 0 && q.segments[0].records > 0 && q.expired(q.segments[0].newest, now) {
+		q.stats.Expired += uint64(q.segments[0].records)
+		incCounter(MetricExpired, q.config.Dir, float64(q.segments[0].records))
+		q.removeHead()
+	}
+}
+
+func (q *Queue) expired(t, now time.Time) bool {
+	return q.config.MaxAge > 0 && now.Sub(t) > time.Duration(q.config.MaxAge)*time.Second
+}
+
+// trim 超过最大缓存字节数时丢弃最早的分段
+func (q *Queue) trim() {
+	for len(q.segments) > 0 && q.pendingBytes() > q.config.MaxSize {
+		q.stats.Dropped += uint64(q.segments[0].records)
+		incCounter(MetricDropped, q.config.Dir, float64(q.segments[0].records))
+		q.removeHead()
+	}
+}
+
+// removeHead 删除第一个分段，最后一个分段同时关闭写入文件
+func (q *Queue) removeHead() {
+	head := q.segments[0]
+	if len(q.segments) == 1 && q.writer != nil {
+		_ = q.writer.Close()
+		q.writer = nil
+	}
+	q.stats.Pending -= head.records
+	q.segments = q.segments[1:]
+	q.offset = 0
+	q.stats.Bytes = q.pendingBytes()
+	if len(q.segments) > 0 {
+		_ = q.saveCursor()
+	} else {
+		// 下一个分段从 head.seq+1 开始，保证游标不会指向旧的序号
+		_ = q.writeCursor(head.seq+1, 0)
+	}
+	_ = os.Remove(q.segmentPath(head.seq))
+}
+
+// ensureWriter 打开最后一个分段用于追加写入，分段已满时创建新的分段
+func (q *Queue) ensureWriter() error {
+	if n := len(q.segments); n > 0 && q.segments[n-1].size < q.config.SegmentSize {
+		if q.writer != nil {
+			return nil
+		}
+		f, err := os.OpenFile(q.segmentPath(q.segments[n-1].seq), os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		q.writer = f
+		return nil
+	}
+	seq, _ := q.readCursor()
+	if n := len(q.segments); n > 0 {
+		seq = q.segments[n-1].seq + 1
+	}
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if q.writer != nil {
+		_ = q.writer.Close()
+	}
+	q.writer = f
+	q.segments = append(q.segments, &segment{seq: seq})
+	return nil
+}
+
+func (q *Queue) pendingBytes() int64 {
+	var n int64
+	for _, s := range q.segments {
+		n += s.size
+	}
+	return n - q.offset
+}
+
+func (q *Queue) segmentPath(seq uint64) string {
+	return filepath.Join(q.config.Dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// readCursor 读取保存的读取位置
+func (q *Queue) readCursor() (uint64, int64) {
+	data, err := os.ReadFile(filepath.Join(q.config.Dir, cursorFile))
+	if err != nil {
+		return 0, 0
+	}
+	var seq uint64
+	var offset int64
+	if _, err := fmt.Sscanf(string(data), "%d %d", &seq, &offset); err != nil {
+		return 0, 0
+	}
+	return seq, offset
+}
+
+func (q *Queue) saveCursor() error {
+	return q.writeCursor(q.segments[0].seq, q.offset)
+}
+
+// writeCursor 先写临时文件再重命名，保证读取位置不会损坏
+func (q *Queue) writeCursor(seq uint64, offset int64) error {
+	path := filepath.Join(q.config.Dir, cursorFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d\n", seq, offset)), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (q *Queue) updateGauges() {
+	setGauge(MetricPendingRecords, q.config.Dir, float64(q.stats.Pending))
+	setGauge(MetricPendingBytes, q.config.Dir, float64(q.stats.Bytes))
+}
+
+// readRecord 读取一条记录，返回记录和占用的字节数
+func readRecord(r io.Reader) (*Record, int64, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[0:])
+	if length > maxRecordSize {
+		return nil, 0, fmt.Errorf("invalid buffer record length: %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, 0, err
+	}
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write(header[8:])
+	_, _ = crc.Write(data)
+	if crc.Sum32() != binary.BigEndian.Uint32(header[4:]) {
+		return nil, 0, errors.New("buffer record checksum mismatch")
+	}
+	ts := int64(binary.BigEndian.Uint64(header[8:]))
+	return &Record{Time: time.Unix(0, ts), Data: data}, int64(headerSize) + int64(length), nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+type testHook struct {
+	mu       sync.Mutex
+	counters map[string]float64
+	gauges   map[string]float64
+}
+
+func (h *testHook) IncCounter(name string, labels map[string]string, delta float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counters[name] += delta
+}
+
+func (h *testHook) SetGauge(name string, labels map[string]string, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.gauges[name] = value
+}
+
+func collect(t *testing.T, q *Queue) []string {
+	var values []string
+	_, err := q.Replay(func(record Record) error {
+		values = append(values, string(record.Data))
+		return nil
+	})
+	assert.Nil(t, err)
+	return values
+}
+
+func TestQueue(t *testing.T) {
+	hook := &testHook{counters: map[string]float64{}, gauges: map[string]float64{}}
+	SetMetricsHook(hook)
+	defer SetMetricsHook(nil)
+
+	dir := t.TempDir()
+	_, err := Open(Config{})
+	assert.NotNil(t, err)
+	q, err := Open(Config{Dir: dir, SegmentSize: 64})
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, q.Push([]byte(fmt.Sprintf("record-%d", i))))
+	}
+	assert.Equal(t, 10, q.Len())
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	assert.True(t, len(segments) > 1)
+
+	// 重放失败停止，保留失败的记录
+	n, err := q.Replay(func(record Record) error {
+		if string(record.Data) == "record-3" {
+			return errors.New("uplink down")
+		}
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 7, q.Len())
+	assert.Nil(t, q.Close())
+	assert.Equal(t, ErrClosed, q.Push([]byte("closed")))
+
+	// 重启后从上次的位置继续重放
+	q, err = Open(Config{Dir: dir, SegmentSize: 64})
+	assert.Nil(t, err)
+	assert.Equal(t, 7, q.Len())
+	assert.Nil(t, q.Push([]byte("record-10")))
+	values := collect(t, q)
+	assert.Equal(t, 8, len(values))
+	assert.Equal(t, "record-3", values[0])
+	assert.Equal(t, "record-10", values[7])
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, int64(0), q.Stats().Bytes)
+	segments, _ = filepath.Glob(filepath.Join(dir, "*.seg"))
+	assert.Equal(t, 0, len(segments))
+
+	// 全部重放后继续写入
+	assert.Nil(t, q.Push([]byte("record-11")))
+	assert.Equal(t, []string{"record-11"}, collect(t, q))
+	assert.Nil(t, q.Close())
+
+	hook.mu.Lock()
+	assert.Equal(t, float64(12), hook.counters[MetricWrites])
+	assert.Equal(t, float64(12), hook.counters[MetricReplayed])
+	assert.Equal(t, float64(0), hook.gauges[MetricPendingRecords])
+	hook.mu.Unlock()
+}
+
+func TestQueueRetention(t *testing.T) {
+	// 超过最大字节数丢弃最早的分段
+	q, err := Open(Config{Dir: t.TempDir(), MaxSize: 256, SegmentSize: 64})
+	assert.Nil(t, err)
+	defer q.Close()
+	for i := 0; i < 50; i++ {
+		assert.Nil(t, q.Push([]byte(fmt.Sprintf("record-%02d", i))))
+	}
+	stats := q.Stats()
+	assert.True(t, stats.Dropped > 0)
+	assert.True(t, stats.Bytes <= 256)
+	assert.Equal(t, 50, stats.Pending+int(stats.Dropped))
+	values := collect(t, q)
+	assert.Equal(t, "record-49", values[len(values)-1])
+	assert.Equal(t, fmt.Sprintf("record-%02d", stats.Dropped), values[0])
+
+	// 过期的记录不重放
+	q2, err := Open(Config{Dir: t.TempDir(), MaxAge: 1})
+	assert.Nil(t, err)
+	defer q2.Close()
+	assert.Nil(t, q2.Push([]byte("old")))
+	time.Sleep(time.Millisecond * 1100)
+	assert.Nil(t, q2.Push([]byte("new")))
+	assert.Equal(t, []string{"new"}, collect(t, q2))
+	assert.Equal(t, uint64(1), q2.Stats().Expired)
+}
+
+func TestQueueTruncate(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(Config{Dir: dir})
+	assert.Nil(t, err)
+	assert.Nil(t, q.PushEntry(Entry{Type: "MODBUS_DATA", Metadata: map[string]string{"server": "tcp://127.0.0.1:502"}, Data: `{"temperature":21.5}`}))
+	assert.Nil(t, q.Push([]byte("second")))
+	assert.Nil(t, q.Close())
+
+	// 模拟写入时断电，末尾的记录不完整
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	assert.Equal(t, 1, len(segments))
+	info, err := os.Stat(segments[0])
+	assert.Nil(t, err)
+	assert.Nil(t, os.Truncate(segments[0], info.Size()-3))
+
+	q, err = Open(Config{Dir: dir})
+	assert.Nil(t, err)
+	defer q.Close()
+	assert.Equal(t, 1, q.Len())
+	var entries []Entry
+	n, err := q.ReplayEntries(func(record Record, entry Entry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "MODBUS_DATA", entries[0].Type)
+	assert.Equal(t, "tcp://127.0.0.1:502", entries[0].Metadata["server"])
+	assert.Equal(t, `{"temperature":21.5}`, entries[0].Data)
+}
@@ -0,0 +1,419 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package capture 提供协议客户端的原始报文抓取（调试模式）：运行时按报文来源开启抓取会话，
+// 客户端收发的原始报文连同时间戳、方向和地址保存到环形缓冲区，可以同时写入 JSON Lines 或者 pcap 文件，
+// 现场没有 Wireshark 或者无法访问设备网络时用于诊断集成问题。
+//
+// 报文来源的格式为 <协议>/<地址>，eg. s7/192.168.0.10:102、iec104/10.0.0.5:2404、bacnet/0.0.0.0:47808。
+// 没有开启抓取会话时 Record 只有一次原子读操作，不影响客户端性能
+package capture
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCapacity 环形缓冲区默认保存的报文数量
+const DefaultCapacity = 1000
+
+// 报文方向
+const (
+	// DirectionTx 客户端发送
+	DirectionTx = "tx"
+	// DirectionRx 客户端接收
+	DirectionRx = "rx"
+)
+
+// 文件格式
+const (
+	// FormatJSON 每行一个 JSON 格式的报文
+	FormatJSON = "jsonl"
+	// FormatPcap pcap 格式，报文封装在合成的 IPv4 TCP/UDP 头中，Wireshark 可以按端口解析协议
+	FormatPcap = "pcap"
+)
+
+// ErrSessionNotFound 抓取会话不存在
+var ErrSessionNotFound = errors.New("capture session not found")
+
+// Frame 抓取的原始报文
+type Frame struct {
+	// Time 收发时间
+	Time time.Time `json:"time"`
+	// Source 报文来源：<协议>/<地址>
+	Source string `json:"source"`
+	// Direction 方向：tx、rx。接收方向按客户端的每次读取记录，一个报文可能分为多个记录
+	Direction string `json:"direction"`
+	// Local 本地地址
+	Local string `json:"local,omitempty"`
+	// Remote 对端地址
+	Remote string `json:"remote,omitempty"`
+	// Length 报文长度
+	Length int `json:"length"`
+	// Hex 十六进制的报文内容
+	Hex string `json:"hex"`
+
+	data          []byte
+	local, remote net.Addr
+}
+
+// Data 报文内容
+func (f Frame) Data() []byte {
+	return f.data
+}
+
+// Config 抓取会话配置
+type Config struct {
+	// Capacity 环形缓冲区保存的报文数量，超过时丢弃最早的报文，0 使用 DefaultCapacity
+	Capacity int `json:"capacity"`
+	// File 同时写入的文件，为空只保存在内存
+	File string `json:"file"`
+	// Format 文件格式：jsonl、pcap，为空按文件扩展名，.pcap 为 pcap，其他为 jsonl
+	Format string `json:"format"`
+	// Duration 会话持续时间，超过后自动停止，0 表示一直抓取直到 Stop
+	Duration time.Duration `json:"duration"`
+}
+
+// Validate 校验配置
+func (c Config) Validate() error {
+	if c.Capacity < 0 {
+		return errors.New("capture capacity cannot be negative")
+	}
+	if c.Duration < 0 {
+		return errors.New("capture duration cannot be negative")
+	}
+	switch c.format() {
+	case FormatJSON, FormatPcap:
+	default:
+		return fmt.Errorf("unsupported capture format: %s", c.Format)
+	}
+	return nil
+}
+
+func (c Config) format() string {
+	if c.Format != "" {
+		return strings.ToLower(c.Format)
+	}
+	if strings.HasSuffix(strings.ToLower(c.File), ".pcap") {
+		return FormatPcap
+	}
+	return FormatJSON
+}
+
+// frameWriter 文件输出
+type frameWriter interface {
+	write(f Frame) error
+	close() error
+}
+
+// Session 抓取会话，按过滤条件记录匹配的报文，可以并发调用
+type Session struct {
+	filter  string
+	config  Config
+	started time.Time
+	mu      sync.Mutex
+	frames  []Frame
+	// next 环形缓冲区下一个写入位置
+	next    int
+	total   int
+	dropped int
+	writer  frameWriter
+	err     error
+	timer   *time.Timer
+}
+
+// Stats 会话状态
+type Stats struct {
+	// Filter 过滤条件
+	Filter string `json:"filter"`
+	// Started 开始时间
+	Started time.Time `json:"started"`
+	// Total 记录的报文总数
+	Total int `json:"total"`
+	// Buffered 缓冲区中的报文数量
+	Buffered int `json:"buffered"`
+	// Dropped 缓冲区已满丢弃的报文数量
+	Dropped int `json:"dropped"`
+	// File 输出文件
+	File string `json:"file,omitempty"`
+	// Error 写入文件的错误，出错后不再写入文件
+	Error string `json:"error,omitempty"`
+}
+
+// Matches 报文来源是否匹配过滤条件：空或者 * 匹配所有来源，以 * 结尾按前缀匹配，其他完全匹配，不区分大小写
+func (s *Session) Matches(source string) bool {
+	return matches(s.filter, source)
+}
+
+func matches(filter, source string) bool {
+	if filter == "" || filter == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(filter, "*"); ok {
+		return len(source) >= len(prefix) && strings.EqualFold(source[:len(prefix)], prefix)
+	}
+	return strings.EqualFold(filter, source)
+}
+
+func (s *Session) add(f Frame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total++
+	if len(s.frames) < s.config.Capacity {
+		s.frames = append(s.frames, f)
+	} else {
+		s.frames[s.next] = f
+		s.next = (s.next + 1) % s.config.Capacity
+		s.dropped++
+	}
+	if s.writer != nil && s.err == nil {
+		s.err = s.writer.write(f)
+	}
+}
+
+// Frames 缓冲区中的报文，按时间顺序
+func (s *Session) Frames() []Frame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ordered()
+}
+
+// Drain 取出缓冲区中的报文并清空缓冲区，dropped 为上一次取出以来丢弃的报文数量
+func (s *Session) Drain() (frames []Frame, dropped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frames, dropped = s.ordered(), s.dropped
+	s.frames, s.next, s.dropped = nil, 0, 0
+	return frames, dropped
+}
+
+func (s *Session) ordered() []Frame {
+	frames := make([]Frame, 0, len(s.frames))
+	frames = append(frames, s.frames[s.next:]...)
+	return append(frames, s.frames[:s.next]...)
+}
+
+// Stats 会话状态
+func (s *Session) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := Stats{Filter: s.filter, Started: s.started, Total: s.total, Buffered: len(s.frames), Dropped: s.dropped, File: s.config.File}
+	if s.err != nil {
+		stats.Error = s.err.Error()
+	}
+	return stats
+}
+
+// close 关闭输出文件
+func (s *Session) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if s.writer == nil {
+		return nil
+	}
+	err := s.writer.close()
+	s.writer = nil
+	return err
+}
+
+var (
+	sessionsLock sync.RWMutex
+	// sessions 过滤条件 -> 抓取会话
+	sessions = make(map[string]*Session)
+	// active 会话数量，为 0 时 Record 直接返回
+	active atomic.Int32
+)
+
+// Start 开启抓取会话，filter 为报文来源的过滤条件，见 Session.Matches。
+// 相同过滤条件的会话已经存在时先停止原来的会话
+func Start(filter string, config Config) (*Session, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Capacity == 0 {
+		config.Capacity = DefaultCapacity
+	}
+	filter = strings.TrimSpace(filter)
+	s := &Session{filter: filter, config: config, started: time.Now()}
+	if config.File != "" {
+		f, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if config.format() == FormatPcap {
+			s.writer, err = newPcapWriter(f)
+		} else {
+			s.writer = newJSONWriter(f)
+		}
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	sessionsLock.Lock()
+	old := sessions[filter]
+	sessions[filter] = s
+	active.Store(int32(len(sessions)))
+	sessionsLock.Unlock()
+	if old != nil {
+		_ = old.close()
+	}
+	if config.Duration > 0 {
+		s.mu.Lock()
+		s.timer = time.AfterFunc(config.Duration, func() {
+			stop(filter, s)
+		})
+		s.mu.Unlock()
+	}
+	return s, nil
+}
+
+// Stop 停止抓取会话并关闭输出文件，返回会话，缓冲区中的报文仍然可以读取
+func Stop(filter string) (*Session, error) {
+	filter = strings.TrimSpace(filter)
+	sessionsLock.RLock()
+	s, ok := sessions[filter]
+	sessionsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, filter)
+	}
+	return s, stop(filter, s)
+}
+
+// stop 停止会话，会话已经被替换时不处理
+func stop(filter string, s *Session) error {
+	sessionsLock.Lock()
+	if sessions[filter] == s {
+		delete(sessions, filter)
+		active.Store(int32(len(sessions)))
+	}
+	sessionsLock.Unlock()
+	return s.close()
+}
+
+// Get 获取抓取会话
+func Get(filter string) (*Session, bool) {
+	sessionsLock.RLock()
+	defer sessionsLock.RUnlock()
+	s, ok := sessions[strings.TrimSpace(filter)]
+	return s, ok
+}
+
+// Sessions 所有抓取会话，按过滤条件排序
+func Sessions() []*Session {
+	sessionsLock.RLock()
+	defer sessionsLock.RUnlock()
+	list := make([]*Session, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].filter < list[j].filter
+	})
+	return list
+}
+
+// Enabled 是否有抓取会话匹配报文来源
+func Enabled(source string) bool {
+	if active.Load() == 0 {
+		return false
+	}
+	sessionsLock.RLock()
+	defer sessionsLock.RUnlock()
+	for _, s := range sessions {
+		if s.Matches(source) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record 记录一个报文，写入所有匹配报文来源的抓取会话，data 被复制
+func Record(source, direction string, local, remote net.Addr, data []byte) {
+	if active.Load() == 0 || len(data) == 0 {
+		return
+	}
+	sessionsLock.RLock()
+	defer sessionsLock.RUnlock()
+	var f *Frame
+	for _, s := range sessions {
+		if !s.Matches(source) {
+			continue
+		}
+		if f == nil {
+			f = &Frame{
+				Time:      time.Now(),
+				Source:    source,
+				Direction: direction,
+				Length:    len(data),
+				Hex:       hex.EncodeToString(data),
+				data:      append([]byte(nil), data...),
+				local:     local,
+				remote:    remote,
+			}
+			if local != nil {
+				f.Local = local.String()
+			}
+			if remote != nil {
+				f.Remote = remote.String()
+			}
+		}
+		s.add(*f)
+	}
+}
+
+// Source 报文来源：<协议>/<地址>
+func Source(protocol, address string) string {
+	return protocol + "/" + address
+}
+
+// Conn 记录收发报文的连接
+type Conn struct {
+	net.Conn
+	source string
+}
+
+// WrapConn 包装连接，每次写入记录为一个发送报文，每次读取记录为一个接收报文
+func WrapConn(conn net.Conn, source string) net.Conn {
+	return &Conn{Conn: conn, source: source}
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		Record(c.source, DirectionRx, c.LocalAddr(), c.RemoteAddr(), b[:n])
+	}
+	return n, err
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		Record(c.source, DirectionTx, c.LocalAddr(), c.RemoteAddr(), b[:n])
+	}
+	return n, err
+}
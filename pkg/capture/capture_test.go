@@ -0,0 +1,187 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMatches(t *testing.T) {
+	assert.True(t, matches("", "s7/10.0.0.1:102"))
+	assert.True(t, matches("*", "s7/10.0.0.1:102"))
+	assert.True(t, matches("S7/*", "s7/10.0.0.1:102"))
+	assert.False(t, matches("s7/*", "dnp3/10.0.0.1:20000"))
+	assert.True(t, matches("s7/10.0.0.1:102", "s7/10.0.0.1:102"))
+	assert.False(t, matches("s7/10.0.0.1", "s7/10.0.0.1:102"))
+
+	assert.NotNil(t, Config{Format: "csv"}.Validate())
+	assert.NotNil(t, Config{Capacity: -1}.Validate())
+	assert.Equal(t, FormatPcap, Config{File: "a.PCAP"}.format())
+	assert.Equal(t, FormatJSON, Config{File: "a.log"}.format())
+}
+
+func TestCapture(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(conn, conn)
+	}()
+	raw, err := net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	source := Source("test", l.Addr().String())
+	conn := WrapConn(raw, source)
+	defer conn.Close()
+	exchange := func(data string) {
+		_, err := conn.Write([]byte(data))
+		assert.Nil(t, err)
+		buf := make([]byte, len(data))
+		_, err = io.ReadFull(conn, buf)
+		assert.Nil(t, err)
+	}
+
+	// 没有抓取会话
+	exchange("a")
+	assert.False(t, Enabled(source))
+	_, err = Stop("test/*")
+	assert.True(t, errors.Is(err, ErrSessionNotFound))
+
+	dir := t.TempDir()
+	all, err := Start("test/*", Config{Capacity: 3, File: filepath.Join(dir, "capture.pcap")})
+	assert.Nil(t, err)
+	one, err := Start(source, Config{File: filepath.Join(dir, "capture.jsonl")})
+	assert.Nil(t, err)
+	assert.True(t, Enabled(source))
+	assert.False(t, Enabled("other/1"))
+	assert.Equal(t, 2, len(Sessions()))
+
+	exchange("\x03\x00\x00\x07")
+	exchange("hello")
+	frames := one.Frames()
+	assert.Equal(t, 4, len(frames))
+	assert.Equal(t, DirectionTx, frames[0].Direction)
+	assert.Equal(t, "03000007", frames[0].Hex)
+	assert.Equal(t, 4, frames[0].Length)
+	assert.Equal(t, raw.LocalAddr().String(), frames[0].Local)
+	assert.Equal(t, l.Addr().String(), frames[0].Remote)
+	assert.Equal(t, DirectionRx, frames[1].Direction)
+	assert.Equal(t, []byte("hello"), frames[3].Data())
+
+	// 环形缓冲区保留最后 3 个报文
+	frames, dropped := all.Drain()
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 3, len(frames))
+	assert.Equal(t, "hello", string(frames[1].Data()))
+	assert.Equal(t, 0, len(all.Frames()))
+	assert.Equal(t, 4, all.Stats().Total)
+
+	s, err := Stop("test/*")
+	assert.Nil(t, err)
+	assert.True(t, s == all)
+	_, err = Stop(source)
+	assert.Nil(t, err)
+	exchange("b")
+	assert.Equal(t, 4, len(one.Frames()))
+
+	// JSON Lines 文件
+	f, err := os.Open(filepath.Join(dir, "capture.jsonl"))
+	assert.Nil(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	var lines []Frame
+	for scanner.Scan() {
+		var frame Frame
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &frame))
+		lines = append(lines, frame)
+	}
+	assert.Equal(t, 4, len(lines))
+	assert.Equal(t, "68656c6c6f", lines[3].Hex)
+	assert.Equal(t, source, lines[3].Source)
+
+	// pcap 文件，报文封装在 IPv4 和 TCP 头中，序号按方向递增
+	data, err := os.ReadFile(filepath.Join(dir, "capture.pcap"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0xa1b2c3d4), binary.LittleEndian.Uint32(data))
+	assert.Equal(t, uint32(pcapLinkTypeRaw), binary.LittleEndian.Uint32(data[20:]))
+	port := uint16(raw.LocalAddr().(*net.TCPAddr).Port)
+	var seqs []uint32
+	pos := 24
+	for i := 0; i < 4; i++ {
+		size := int(binary.LittleEndian.Uint32(data[pos+8:]))
+		packet := data[pos+16 : pos+16+size]
+		assert.Equal(t, byte(0x45), packet[0])
+		assert.Equal(t, byte(ipProtoTCP), packet[9])
+		assert.Equal(t, uint16(0), checksum(packet[:20]))
+		assert.Equal(t, []byte{127, 0, 0, 1}, []byte(packet[12:16]))
+		if i%2 == 0 {
+			assert.Equal(t, port, binary.BigEndian.Uint16(packet[20:]))
+			seqs = append(seqs, binary.BigEndian.Uint32(packet[24:]))
+		} else {
+			assert.Equal(t, port, binary.BigEndian.Uint16(packet[22:]))
+		}
+		pos += 16 + size
+	}
+	assert.Equal(t, len(data), pos)
+	assert.Equal(t, []uint32{1, 5}, seqs)
+
+	// 超过持续时间自动停止
+	_, err = Start(source, Config{Duration: 20 * time.Millisecond})
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, ok := Get(source)
+	assert.False(t, ok)
+	assert.False(t, Enabled(source))
+}
+
+func TestPcapUDP(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Start("bacnet/*", Config{File: filepath.Join(dir, "bacnet.pcap")})
+	assert.Nil(t, err)
+	local := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 47809}
+	remote := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 20), Port: 47808}
+	Record("bacnet/0.0.0.0:47809", DirectionRx, local, remote, []byte{0x81, 0x0a, 0x00, 0x04})
+	_, err = Stop("bacnet/*")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(s.Frames()))
+
+	data, err := os.ReadFile(filepath.Join(dir, "bacnet.pcap"))
+	assert.Nil(t, err)
+	packet := data[24+16:]
+	assert.Equal(t, 20+8+4, len(packet))
+	assert.Equal(t, byte(ipProtoUDP), packet[9])
+	// 接收方向源地址为对端
+	assert.Equal(t, []byte{192, 168, 1, 20}, []byte(packet[12:16]))
+	assert.Equal(t, uint16(47808), binary.BigEndian.Uint16(packet[20:]))
+	assert.Equal(t, uint16(47809), binary.BigEndian.Uint16(packet[22:]))
+	assert.Equal(t, uint16(12), binary.BigEndian.Uint16(packet[24:]))
+}
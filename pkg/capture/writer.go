@@ -0,0 +1,197 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+)
+
+// jsonWriter 每行一个 JSON 格式的报文
+type jsonWriter struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+func newJSONWriter(f *os.File) *jsonWriter {
+	return &jsonWriter{f: f, enc: json.NewEncoder(f)}
+}
+
+func (w *jsonWriter) write(f Frame) error {
+	return w.enc.Encode(f)
+}
+
+func (w *jsonWriter) close() error {
+	return w.f.Close()
+}
+
+const (
+	// pcapLinkTypeRaw 原始 IP 报文
+	pcapLinkTypeRaw = 101
+	// pcapMaxPayload IPv4 报文的最大负载
+	pcapMaxPayload = 0xffff - 40
+	ipProtoTCP     = 6
+	ipProtoUDP     = 17
+)
+
+// flow TCP 单向数据流
+type flow struct {
+	src, dst string
+}
+
+// pcapWriter pcap 格式输出，每个报文封装在合成的 IPv4 和 TCP/UDP 头中，TCP 按方向维护序号使 Wireshark 可以重组数据流。
+// 非 IP 地址（eg. 串口）使用 0.0.0.0:0，IPv6 地址不能表示，同样使用 0.0.0.0
+type pcapWriter struct {
+	f   *os.File
+	w   *bufio.Writer
+	seq map[flow]uint32
+	id  uint16
+}
+
+func newPcapWriter(f *os.File) (*pcapWriter, error) {
+	w := &pcapWriter{f: f, w: bufio.NewWriter(f), seq: make(map[flow]uint32)}
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 0xffff)
+	binary.LittleEndian.PutUint32(header[20:], pcapLinkTypeRaw)
+	if _, err := w.w.Write(header); err != nil {
+		return nil, err
+	}
+	return w, w.w.Flush()
+}
+
+func (w *pcapWriter) write(f Frame) error {
+	src, dst := f.local, f.remote
+	if f.Direction == DirectionRx {
+		src, dst = dst, src
+	}
+	srcIP, srcPort, udp := endpoint(src)
+	dstIP, dstPort, _ := endpoint(dst)
+	payload := f.data
+	if len(payload) > pcapMaxPayload {
+		payload = payload[:pcapMaxPayload]
+	}
+	var transport []byte
+	proto := byte(ipProtoTCP)
+	if udp {
+		proto = ipProtoUDP
+		transport = make([]byte, 8)
+		binary.BigEndian.PutUint16(transport[0:], srcPort)
+		binary.BigEndian.PutUint16(transport[2:], dstPort)
+		binary.BigEndian.PutUint16(transport[4:], uint16(8+len(payload)))
+	} else {
+		forward, reverse := flow{src: addrString(src), dst: addrString(dst)}, flow{src: addrString(dst), dst: addrString(src)}
+		seq, ok := w.seq[forward]
+		if !ok {
+			seq = 1
+		}
+		ack, ok := w.seq[reverse]
+		if !ok {
+			ack = 1
+		}
+		w.seq[forward] = seq + uint32(len(payload))
+		transport = make([]byte, 20)
+		binary.BigEndian.PutUint16(transport[0:], srcPort)
+		binary.BigEndian.PutUint16(transport[2:], dstPort)
+		binary.BigEndian.PutUint32(transport[4:], seq)
+		binary.BigEndian.PutUint32(transport[8:], ack)
+		transport[12] = 5 << 4
+		// PSH、ACK
+		transport[13] = 0x18
+		binary.BigEndian.PutUint16(transport[14:], 0xffff)
+	}
+	w.id++
+	ip := make([]byte, 20)
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(20+len(transport)+len(payload)))
+	binary.BigEndian.PutUint16(ip[4:], w.id)
+	// Don't Fragment
+	ip[6] = 0x40
+	ip[8] = 64
+	ip[9] = proto
+	copy(ip[12:16], srcIP)
+	copy(ip[16:20], dstIP)
+	binary.BigEndian.PutUint16(ip[10:], checksum(ip))
+
+	size := len(ip) + len(transport) + len(payload)
+	record := make([]byte, 16)
+	binary.LittleEndian.PutUint32(record[0:], uint32(f.Time.Unix()))
+	binary.LittleEndian.PutUint32(record[4:], uint32(f.Time.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(record[8:], uint32(size))
+	binary.LittleEndian.PutUint32(record[12:], uint32(size))
+	for _, b := range [][]byte{record, ip, transport, payload} {
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
+
+func (w *pcapWriter) close() error {
+	err := w.w.Flush()
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// endpoint IPv4 地址和端口，udp 表示 UDP 地址
+func endpoint(addr net.Addr) (ip net.IP, port uint16, udp bool) {
+	ip = net.IPv4zero.To4()
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		if a != nil {
+			if v4 := a.IP.To4(); v4 != nil {
+				ip = v4
+			}
+			port = uint16(a.Port)
+		}
+	case *net.UDPAddr:
+		udp = true
+		if a != nil {
+			if v4 := a.IP.To4(); v4 != nil {
+				ip = v4
+			}
+			port = uint16(a.Port)
+		}
+	}
+	return ip, port, udp
+}
+
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+// checksum IPv4 头校验和
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certmgr
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/acme"
+)
+
+// ACMEConfig ACME（RFC 8555）续期参数，使用 HTTP-01 验证域名
+type ACMEConfig struct {
+	// DirectoryURL ACME 目录地址，默认 Let's Encrypt
+	DirectoryURL string `json:"directoryUrl"`
+	// Email 账号联系邮箱
+	Email string `json:"email"`
+	// AccountKeyFile 账号私钥文件，不存在时生成新的私钥并保存
+	AccountKeyFile string `json:"accountKeyFile"`
+	// HTTPAddress 响应 HTTP-01 验证的监听地址，默认 :80
+	HTTPAddress string `json:"httpAddress"`
+	// Domains 申请证书的域名，为空使用当前证书的 DNS 名称
+	Domains []string `json:"domains"`
+	// Rekey 生成新的私钥，默认继续使用当前私钥
+	Rekey bool `json:"rekey"`
+}
+
+// acmeRenewer 通过 ACME 续期
+type acmeRenewer struct {
+	config ACMEConfig
+	mu     sync.Mutex
+	client *acme.Client
+}
+
+func newACME(options map[string]interface{}) (Renewer, error) {
+	r := &acmeRenewer{config: ACMEConfig{DirectoryURL: acme.LetsEncryptURL, HTTPAddress: ":80"}}
+	if err := decodeOptions(options, &r.config); err != nil {
+		return nil, err
+	}
+	if r.config.AccountKeyFile == "" {
+		return nil, errors.New("acme accountKeyFile is required")
+	}
+	return r, nil
+}
+
+// account 加载或者生成账号私钥并注册账号，账号已存在时直接使用
+func (r *acmeRenewer) account(ctx context.Context) (*acme.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		return r.client, nil
+	}
+	key, err := loadAccountKey(r.config.AccountKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	client := &acme.Client{Key: key, DirectoryURL: r.config.DirectoryURL}
+	account := &acme.Account{}
+	if r.config.Email != "" {
+		account.Contact = []string{"mailto:" + r.config.Email}
+	}
+	if _, err = client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("acme: register account: %w", err)
+	}
+	r.client = client
+	return client, nil
+}
+
+func (r *acmeRenewer) Renew(ctx context.Context, request RenewRequest) ([]byte, []byte, error) {
+	domains := r.config.Domains
+	if len(domains) == 0 {
+		domains = request.Certificate.Leaf.DNSNames
+	}
+	if len(domains) == 0 {
+		return nil, nil, errors.New("acme: no domains to renew")
+	}
+	client, err := r.account(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(domains...))
+	if err != nil {
+		return nil, nil, fmt.Errorf("acme: authorize order: %w", err)
+	}
+	if err = r.authorize(ctx, client, order.AuthzURLs); err != nil {
+		return nil, nil, err
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, nil, fmt.Errorf("acme: wait order: %w", err)
+	}
+	key, keyPEM, err := renewKey(request.Certificate, r.config.Rekey)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := createCSR(request.Certificate.Leaf, key, domains)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("acme: create certificate: %w", err)
+	}
+	return encodeChain(der), keyPEM, nil
+}
+
+// authorize 在 HTTPAddress 上响应 HTTP-01 验证，完成所有域名的授权
+func (r *acmeRenewer) authorize(ctx context.Context, client *acme.Client, urls []string) error {
+	var mu sync.Mutex
+	tokens := make(map[string]string)
+	l, err := net.Listen("tcp", r.config.HTTPAddress)
+	if err != nil {
+		return fmt.Errorf("acme: http-01 listener: %w", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		response, ok := tokens[req.URL.Path]
+		mu.Unlock()
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	})}
+	go func() { _ = server.Serve(l) }()
+	defer server.Close()
+
+	for _, url := range urls {
+		authz, err := client.GetAuthorization(ctx, url)
+		if err != nil {
+			return fmt.Errorf("acme: get authorization: %w", err)
+		}
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+		var challenge *acme.Challenge
+		for _, c := range authz.Challenges {
+			if c.Type == "http-01" {
+				challenge = c
+				break
+			}
+		}
+		if challenge == nil {
+			return fmt.Errorf("acme: no http-01 challenge for %s", authz.Identifier.Value)
+		}
+		response, err := client.HTTP01ChallengeResponse(challenge.Token)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		tokens[client.HTTP01ChallengePath(challenge.Token)] = response
+		mu.Unlock()
+		if _, err = client.Accept(ctx, challenge); err != nil {
+			return fmt.Errorf("acme: accept challenge: %w", err)
+		}
+		if _, err = client.WaitAuthorization(ctx, authz.URI); err != nil {
+			return fmt.Errorf("acme: authorize %s: %w", authz.Identifier.Value, err)
+		}
+	}
+	return nil
+}
+
+// loadAccountKey 加载账号私钥，文件不存在时生成 ECDSA P-256 私钥并保存
+func loadAccountKey(file string) (crypto.Signer, error) {
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err = writeFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("acme: no private key found in %s", file)
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("acme: unsupported account key in %s", file)
+	}
+	return signer, nil
+}
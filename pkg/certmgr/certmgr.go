@@ -0,0 +1,480 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package certmgr 提供设备侧 TLS 证书的生命周期管理
+// 监视 OPC UA、MQTT、OCPP、CoAP-DTLS 等组件使用的证书和私钥文件，文件修改后重新加载，不需要重启端点；
+// 证书过期前 N 天产生告警事件，可选通过 ACME 或者 EST 自动续期，其它续期方式可以通过 RegisterRenewer 注册
+package certmgr
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 证书事件类型，同时作为 RuleMsg 的消息类型
+const (
+	EventReloaded     = "CERT_RELOADED"
+	EventReloadFailed = "CERT_RELOAD_FAILED"
+	EventExpiring     = "CERT_EXPIRING"
+	EventExpired      = "CERT_EXPIRED"
+	EventRenewed      = "CERT_RENEWED"
+	EventRenewFailed  = "CERT_RENEW_FAILED"
+)
+
+// DefaultWarnDays 默认在证书过期前 30 天告警
+const DefaultWarnDays = 30
+
+// DefaultCheckInterval 默认检查间隔
+const DefaultCheckInterval = time.Minute
+
+var (
+	// warnInterval 重复告警的间隔
+	warnInterval = 24 * time.Hour
+	// renewRetryInterval 续期失败后重试的间隔
+	renewRetryInterval = time.Hour
+	// renewTimeout 续期的超时时间
+	renewTimeout = 5 * time.Minute
+)
+
+// ErrNotFound 证书没有注册
+var ErrNotFound = errors.New("certificate not found")
+
+// Config 管理的证书
+type Config struct {
+	// Name 证书名称，组件通过 tls.certName 引用
+	Name string `json:"name"`
+	// CertFile 证书文件，PEM 格式，可以包含中间证书
+	CertFile string `json:"certFile"`
+	// KeyFile 私钥文件，PEM 格式
+	KeyFile string `json:"keyFile"`
+	// WarnDays 过期前多少天开始告警，默认 30
+	WarnDays int `json:"warnDays"`
+	// Renew 自动续期配置，为空不续期
+	Renew RenewConfig `json:"renew"`
+}
+
+// RenewConfig 自动续期配置
+type RenewConfig struct {
+	// Type 续期方式：acme、est 或者通过 RegisterRenewer 注册的续期方式，为空不续期
+	Type string `json:"type"`
+	// Days 过期前多少天开始续期，默认 30
+	Days int `json:"days"`
+	// Options 续期方式的参数，见 ACMEConfig、ESTConfig
+	Options map[string]interface{} `json:"options"`
+}
+
+// Status 证书状态
+type Status struct {
+	Name         string    `json:"name"`
+	CertFile     string    `json:"certFile"`
+	KeyFile      string    `json:"keyFile"`
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	DNSNames     []string  `json:"dnsNames,omitempty"`
+	URIs         []string  `json:"uris,omitempty"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	// DaysLeft 剩余天数，已过期为负数
+	DaysLeft int `json:"daysLeft"`
+	// LoadedAt 最后一次加载成功的时间
+	LoadedAt time.Time `json:"loadedAt"`
+	// RenewedAt 最后一次续期成功的时间
+	RenewedAt time.Time `json:"renewedAt,omitempty"`
+	// LastError 最后一次加载或者续期的错误
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Event 证书事件
+type Event struct {
+	// Type 事件类型
+	Type   string `json:"type"`
+	Status Status `json:"status"`
+	// Error 加载或者续期失败的原因
+	Error string `json:"error,omitempty"`
+}
+
+// entry 管理的证书
+type entry struct {
+	config   Config
+	renewer  Renewer
+	cert     *tls.Certificate
+	status   Status
+	modTimes [2]time.Time
+	// warnedAt 最后一次告警的时间
+	warnedAt time.Time
+	// renewAfter 续期失败后下次续期的时间
+	renewAfter time.Time
+	renewing   bool
+}
+
+// Manager 证书管理器，并发安全
+type Manager struct {
+	mu        sync.RWMutex
+	entries   map[string]*entry
+	listeners map[int]func(Event)
+	nextId    int
+	// loopMu 保护后台检查协程
+	loopMu sync.Mutex
+	refs   int
+	stop   chan struct{}
+}
+
+// NewManager 创建证书管理器
+func NewManager() *Manager {
+	return &Manager{entries: make(map[string]*entry), listeners: make(map[int]func(Event))}
+}
+
+// Add 添加证书，立即加载，加载失败返回错误。同名的证书被替换
+func (m *Manager) Add(config Config) error {
+	if config.Name == "" {
+		return errors.New("certmgr: name is required")
+	}
+	if config.CertFile == "" || config.KeyFile == "" {
+		return fmt.Errorf("certmgr: %s requires certFile and keyFile", config.Name)
+	}
+	if config.WarnDays <= 0 {
+		config.WarnDays = DefaultWarnDays
+	}
+	if config.Renew.Days <= 0 {
+		config.Renew.Days = DefaultWarnDays
+	}
+	e := &entry{config: config}
+	if config.Renew.Type != "" {
+		var err error
+		if e.renewer, err = NewRenewer(config.Renew.Type, config.Renew.Options); err != nil {
+			return fmt.Errorf("certmgr: %s: %w", config.Name, err)
+		}
+	}
+	e.modTimes = modTimes(config)
+	if err := e.load(time.Now()); err != nil {
+		return fmt.Errorf("certmgr: %s: %w", config.Name, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[config.Name] = e
+	return nil
+}
+
+// Remove 移除证书
+func (m *Manager) Remove(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, name)
+}
+
+// Get 获取证书状态
+func (m *Manager) Get(name string) (Status, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[name]
+	if !ok {
+		return Status{}, false
+	}
+	return e.status, true
+}
+
+// List 获取所有证书的状态，按名称排序
+func (m *Manager) List() []Status {
+	m.mu.RLock()
+	result := make([]Status, 0, len(m.entries))
+	for _, e := range m.entries {
+		result = append(result, e.status)
+	}
+	m.mu.RUnlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Certificate 获取当前的证书，用于 tls.Config 的 GetCertificate、GetClientCertificate，文件更新或者续期后返回新证书
+func (m *Manager) Certificate(name string) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("certmgr: %w: %s", ErrNotFound, name)
+	}
+	return e.cert, nil
+}
+
+// Subscribe 订阅证书事件，fn 在检查协程中同步调用，不能阻塞。返回取消订阅的函数
+func (m *Manager) Subscribe(fn func(event Event)) (cancel func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextId
+	m.nextId++
+	m.listeners[id] = fn
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.listeners, id)
+	}
+}
+
+// Start 启动后台检查协程，按 interval 检查所有证书，多次调用只启动一个协程，需要调用相同次数的 Stop
+func (m *Manager) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	m.loopMu.Lock()
+	defer m.loopMu.Unlock()
+	m.refs++
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	go m.run(interval, m.stop)
+}
+
+// Stop 停止后台检查协程
+func (m *Manager) Stop() {
+	m.loopMu.Lock()
+	defer m.loopMu.Unlock()
+	if m.refs == 0 {
+		return
+	}
+	m.refs--
+	if m.refs == 0 && m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+func (m *Manager) run(interval time.Duration, stop <-chan struct{}) {
+	m.Check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Check()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Check 检查所有证书：文件修改后重新加载，即将过期或者已过期时告警，到达续期时间时在后台续期
+func (m *Manager) Check() {
+	now := time.Now()
+	var events []Event
+	m.mu.Lock()
+	for _, e := range m.entries {
+		events = append(events, m.check(e, now)...)
+	}
+	m.mu.Unlock()
+	m.notify(events)
+}
+
+// check 检查一个证书，调用者持有锁
+func (m *Manager) check(e *entry, now time.Time) []Event {
+	var events []Event
+	// 续期时由续期协程更新修改时间和证书
+	if times := modTimes(e.config); times != e.modTimes && !e.renewing {
+		// 记录修改时间，证书和私钥没有同时更新完成时，下一次修改后重试
+		e.modTimes = times
+		if err := e.load(now); err != nil {
+			e.status.LastError = err.Error()
+			events = append(events, Event{Type: EventReloadFailed, Status: e.status, Error: err.Error()})
+		} else {
+			events = append(events, Event{Type: EventReloaded, Status: e.status})
+		}
+	}
+	left := e.status.NotAfter.Sub(now)
+	if left <= time.Duration(e.config.WarnDays)*24*time.Hour && now.Sub(e.warnedAt) >= warnInterval {
+		e.warnedAt = now
+		eventType := EventExpiring
+		if left <= 0 {
+			eventType = EventExpired
+		}
+		events = append(events, Event{Type: eventType, Status: e.status})
+	}
+	if e.renewer != nil && !e.renewing && !now.Before(e.renewAfter) && left <= time.Duration(e.config.Renew.Days)*24*time.Hour {
+		e.renewing = true
+		go m.renew(e.config.Name, e)
+	}
+	return events
+}
+
+// renew 续期，写入新的证书和私钥文件后重新加载
+func (m *Manager) renew(name string, e *entry) {
+	m.mu.RLock()
+	request := RenewRequest{Name: name, Certificate: e.cert}
+	renewer := e.renewer
+	m.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), renewTimeout)
+	defer cancel()
+	certPEM, keyPEM, err := renewer.Renew(ctx, request)
+	if err == nil {
+		err = writeKeyPair(e.config, certPEM, keyPEM)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	e.renewing = false
+	var event Event
+	if err == nil {
+		e.modTimes = modTimes(e.config)
+		err = e.load(now)
+	}
+	if err != nil {
+		e.renewAfter = now.Add(renewRetryInterval)
+		e.status.LastError = err.Error()
+		event = Event{Type: EventRenewFailed, Status: e.status, Error: err.Error()}
+	} else {
+		e.status.RenewedAt = now
+		event = Event{Type: EventRenewed, Status: e.status}
+	}
+	m.mu.Unlock()
+	m.notify([]Event{event})
+}
+
+func (m *Manager) notify(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	m.mu.RLock()
+	listeners := make([]func(Event), 0, len(m.listeners))
+	for _, l := range m.listeners {
+		listeners = append(listeners, l)
+	}
+	m.mu.RUnlock()
+	for _, event := range events {
+		for _, l := range listeners {
+			l(event)
+		}
+	}
+}
+
+// load 加载证书和私钥，成功后更新状态，新证书重新开始告警
+func (e *entry) load(now time.Time) error {
+	cert, err := tls.LoadX509KeyPair(e.config.CertFile, e.config.KeyFile)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+	leaf := cert.Leaf
+	renewedAt := e.status.RenewedAt
+	e.cert = &cert
+	e.status = Status{
+		Name:         e.config.Name,
+		CertFile:     e.config.CertFile,
+		KeyFile:      e.config.KeyFile,
+		Subject:      leaf.Subject.String(),
+		Issuer:       leaf.Issuer.String(),
+		SerialNumber: leaf.SerialNumber.Text(16),
+		DNSNames:     leaf.DNSNames,
+		NotBefore:    leaf.NotBefore,
+		NotAfter:     leaf.NotAfter,
+		DaysLeft:     daysLeft(leaf.NotAfter, now),
+		LoadedAt:     now,
+		RenewedAt:    renewedAt,
+	}
+	for _, u := range leaf.URIs {
+		e.status.URIs = append(e.status.URIs, u.String())
+	}
+	e.warnedAt = time.Time{}
+	e.renewAfter = time.Time{}
+	return nil
+}
+
+// daysLeft 剩余天数，向下取整
+func daysLeft(notAfter, now time.Time) int {
+	left := notAfter.Sub(now)
+	days := int(left / (24 * time.Hour))
+	if left < 0 && left%(24*time.Hour) != 0 {
+		days--
+	}
+	return days
+}
+
+func modTimes(c Config) [2]time.Time {
+	return [2]time.Time{modTime(c.CertFile), modTime(c.KeyFile)}
+}
+
+func modTime(file string) time.Time {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// writeKeyPair 先写入临时文件再重命名，避免组件读取到不完整的文件。私钥为空时只更新证书
+func writeKeyPair(c Config, certPEM, keyPEM []byte) error {
+	if len(certPEM) == 0 {
+		return errors.New("certmgr: renewer returned an empty certificate")
+	}
+	if len(keyPEM) > 0 {
+		if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			return fmt.Errorf("certmgr: renewed key pair: %w", err)
+		}
+		if err := writeFile(c.KeyFile, keyPEM, 0600); err != nil {
+			return err
+		}
+	}
+	return writeFile(c.CertFile, certPEM, 0644)
+}
+
+func writeFile(file string, data []byte, perm os.FileMode) error {
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+var defaultManager = NewManager()
+
+// Default 全局证书管理器，tlsconfig 的 certName 引用全局证书管理器中的证书
+func Default() *Manager {
+	return defaultManager
+}
+
+// Certificate 从全局证书管理器获取证书，见 Manager.Certificate
+func Certificate(name string) (*tls.Certificate, error) {
+	return defaultManager.Certificate(name)
+}
+
+// Subscribe 订阅全局证书管理器的事件，见 Manager.Subscribe
+func Subscribe(fn func(event Event)) (cancel func()) {
+	return defaultManager.Subscribe(fn)
+}
+
+// IsEvent 是否为证书事件类型
+func IsEvent(eventType string) bool {
+	switch eventType {
+	case EventReloaded, EventReloadFailed, EventExpiring, EventExpired, EventRenewed, EventRenewFailed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,297 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certmgr
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// issue 签发证书，parent 为空时自签名
+func issue(t *testing.T, serial int64, notAfter time.Time, public crypto.PublicKey, parent *x509.Certificate, signer crypto.Signer) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "plc-1"},
+		DNSNames:     []string{"plc-1.local"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent = template
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, public, signer)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return cert
+}
+
+// writeCert 生成自签名证书并写入文件
+func writeCert(t *testing.T, config Config, serial int64, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	cert := issue(t, serial, notAfter, key.Public(), nil, key)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(config.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(config.CertFile, encodeChain([][]byte{cert.Raw}), 0644))
+	// 保证修改时间变化
+	future := time.Now().Add(time.Duration(serial) * time.Second)
+	_ = os.Chtimes(config.KeyFile, future, future)
+	_ = os.Chtimes(config.CertFile, future, future)
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) add(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []string
+	for _, e := range r.events {
+		result = append(result, e.Type)
+	}
+	return result
+}
+
+func (r *recorder) wait(t *testing.T, n int) []string {
+	for i := 0; i < 200; i++ {
+		if len(r.types()) >= n {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return r.types()
+}
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{Name: "opcua", CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem")}
+	m := NewManager()
+	assert.NotNil(t, m.Add(config))
+	assert.NotNil(t, m.Add(Config{Name: "x"}))
+	assert.NotNil(t, m.Add(Config{Name: "x", CertFile: "a", KeyFile: "b", Renew: RenewConfig{Type: "unknown"}}))
+
+	writeCert(t, config, 1, time.Now().Add(365*24*time.Hour))
+	assert.Nil(t, m.Add(config))
+	status, ok := m.Get("opcua")
+	assert.True(t, ok)
+	assert.Equal(t, "CN=plc-1", status.Subject)
+	assert.Equal(t, "1", status.SerialNumber)
+	assert.Equal(t, 364, status.DaysLeft)
+	cert, err := m.Certificate("opcua")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cert.Leaf.SerialNumber.Int64())
+	_, err = m.Certificate("mqtt")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	r := &recorder{}
+	cancel := m.Subscribe(r.add)
+	m.Check()
+	assert.Equal(t, 0, len(r.types()))
+
+	// 文件更新后重新加载，新证书即将过期
+	writeCert(t, config, 2, time.Now().Add(10*24*time.Hour))
+	m.Check()
+	m.Check()
+	assert.Equal(t, []string{EventReloaded, EventExpiring}, r.types())
+	cert, _ = m.Certificate("opcua")
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+
+	// 加载失败继续使用原有的证书
+	assert.Nil(t, os.WriteFile(config.CertFile, []byte("invalid"), 0644))
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(config.CertFile, future, future)
+	m.Check()
+	assert.Equal(t, EventReloadFailed, r.types()[2])
+	cert, _ = m.Certificate("opcua")
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+
+	writeCert(t, config, 3, time.Now().Add(-time.Hour))
+	m.Check()
+	assert.Equal(t, []string{EventReloaded, EventExpired}, r.types()[3:])
+	assert.Equal(t, -1, m.List()[0].DaysLeft)
+
+	cancel()
+	m.Remove("opcua")
+	assert.Equal(t, 0, len(m.List()))
+}
+
+// testRenewer 签发新的自签名证书
+type testRenewer struct {
+	err error
+}
+
+func (r *testRenewer) Renew(ctx context.Context, request RenewRequest) ([]byte, []byte, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	key := request.Certificate.PrivateKey.(crypto.Signer)
+	cert := &x509.Certificate{SerialNumber: big.NewInt(100), Subject: request.Certificate.Leaf.Subject,
+		NotBefore: time.Now(), NotAfter: time.Now().Add(90 * 24 * time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, cert, cert, key.Public(), key)
+	return encodeChain([][]byte{der}), nil, err
+}
+
+func TestRenew(t *testing.T) {
+	assert.NotNil(t, RegisterRenewer(RenewEST, nil))
+	assert.NotNil(t, RegisterRenewer("nil", nil))
+	renewer := &testRenewer{err: errors.New("ca unavailable")}
+	assert.Nil(t, RegisterRenewer("test", func(map[string]interface{}) (Renewer, error) { return renewer, nil }))
+
+	dir := t.TempDir()
+	config := Config{Name: "mqtt", CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem"),
+		WarnDays: 5, Renew: RenewConfig{Type: "test", Days: 20}}
+	writeCert(t, config, 1, time.Now().Add(10*24*time.Hour))
+	m := NewManager()
+	assert.Nil(t, m.Add(config))
+	r := &recorder{}
+	m.Subscribe(r.add)
+
+	m.Check()
+	assert.Equal(t, []string{EventRenewFailed}, r.wait(t, 1))
+	assert.Equal(t, "ca unavailable", r.events[0].Error)
+	// 失败后等待重试间隔
+	renewer.err = nil
+	m.Check()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, len(r.types()))
+
+	m.mu.Lock()
+	m.entries["mqtt"].renewAfter = time.Time{}
+	m.mu.Unlock()
+	m.Check()
+	assert.Equal(t, []string{EventRenewFailed, EventRenewed}, r.wait(t, 2))
+	status, _ := m.Get("mqtt")
+	assert.Equal(t, "64", status.SerialNumber)
+	assert.False(t, status.RenewedAt.IsZero())
+	// 新证书已写入文件，不会重复加载
+	m.Check()
+	assert.Equal(t, 2, len(r.types()))
+	pair, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	assert.Nil(t, err)
+	leaf, _ := x509.ParseCertificate(pair.Certificate[0])
+	assert.Equal(t, int64(100), leaf.SerialNumber.Int64())
+}
+
+func TestEST(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca := issue(t, 1, time.Now().Add(24*time.Hour), caKey.Public(), nil, caKey)
+
+	var authenticated bool
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/est/simplereenroll" || r.Header.Get("Content-Type") != "application/pkcs10" {
+			http.NotFound(w, r)
+			return
+		}
+		authenticated = len(r.TLS.PeerCertificates) > 0
+		b, _ := io.ReadAll(r.Body)
+		der, err := base64.StdEncoding.DecodeString(string(b))
+		assert.Nil(t, err)
+		csr, err := x509.ParseCertificateRequest(der)
+		assert.Nil(t, err)
+		assert.Equal(t, "plc-1", csr.Subject.CommonName)
+		cert := issue(t, 7, time.Now().Add(90*24*time.Hour), csr.PublicKey, ca, caKey)
+
+		certs := append(append([]byte{}, ca.Raw...), cert.Raw...)
+		empty := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+		content, _ := asn1.Marshal(struct{ Type asn1.ObjectIdentifier }{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}})
+		signed, err := asn1.Marshal(pkcs7SignedData{
+			Version:          1,
+			DigestAlgorithms: empty,
+			ContentInfo:      asn1.RawValue{FullBytes: content},
+			Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+			SignerInfos:      empty,
+		})
+		assert.Nil(t, err)
+		info, err := asn1.Marshal(struct {
+			Type    asn1.ObjectIdentifier
+			Content asn1.RawValue
+		}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signed}})
+		assert.Nil(t, err)
+		w.Header().Set("Content-Type", "application/pkcs7-mime; smime-type=certs-only")
+		_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(info)))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	_, err := NewRenewer(RenewEST, nil)
+	assert.NotNil(t, err)
+	renewer, err := NewRenewer(RenewEST, map[string]interface{}{"server": server.URL + "/.well-known/est/", "insecureSkipVerify": true})
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	config := Config{Name: "est", CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem")}
+	writeCert(t, config, 1, time.Now().Add(24*time.Hour))
+	current, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	assert.Nil(t, err)
+	current.Leaf, _ = x509.ParseCertificate(current.Certificate[0])
+
+	certPEM, keyPEM, err := renewer.Renew(context.Background(), RenewRequest{Name: "est", Certificate: &current})
+	assert.Nil(t, err)
+	assert.Nil(t, keyPEM)
+	assert.True(t, authenticated)
+	block, rest := pem.Decode(certPEM)
+	leaf, _ := x509.ParseCertificate(block.Bytes)
+	assert.Equal(t, int64(7), leaf.SerialNumber.Int64())
+	block, _ = pem.Decode(rest)
+	assert.Equal(t, ca.Raw, block.Bytes)
+
+	// 生成新的私钥
+	renewer, _ = NewRenewer(RenewEST, map[string]interface{}{"server": server.URL + "/.well-known/est", "insecureSkipVerify": true, "rekey": true})
+	certPEM, keyPEM, err = renewer.Renew(context.Background(), RenewRequest{Name: "est", Certificate: &current})
+	assert.Nil(t, err)
+	_, err = tls.X509KeyPair(certPEM, keyPEM)
+	assert.Nil(t, err)
+
+	_, err = parseCertsOnly([]byte{0x30, 0x00})
+	assert.NotNil(t, err)
+}
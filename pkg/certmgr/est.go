@@ -0,0 +1,181 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certmgr
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ESTConfig EST（RFC 7030）续期参数
+type ESTConfig struct {
+	// Server EST 服务地址，eg. https://est.example.com/.well-known/est 或者带 CA 标签的 https://est.example.com/.well-known/est/plc
+	Server string `json:"server"`
+	// CAFile 校验 EST 服务证书的 CA 证书文件，为空使用系统 CA
+	CAFile string `json:"caFile"`
+	// Username、Password HTTP 基本认证，为空只使用当前证书认证
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Rekey 生成新的私钥，默认继续使用当前私钥
+	Rekey bool `json:"rekey"`
+	// InsecureSkipVerify 不校验 EST 服务证书，仅用于测试
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+}
+
+// estRenewer 通过 EST simplereenroll 续期，TLS 握手使用当前证书认证
+type estRenewer struct {
+	config ESTConfig
+	roots  *x509.CertPool
+}
+
+func newEST(options map[string]interface{}) (Renewer, error) {
+	r := &estRenewer{}
+	if err := decodeOptions(options, &r.config); err != nil {
+		return nil, err
+	}
+	if r.config.Server == "" {
+		return nil, errors.New("est server is required")
+	}
+	r.config.Server = strings.TrimSuffix(r.config.Server, "/")
+	if r.config.CAFile != "" {
+		b, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		r.roots = x509.NewCertPool()
+		if !r.roots.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", r.config.CAFile)
+		}
+	}
+	return r, nil
+}
+
+func (r *estRenewer) Renew(ctx context.Context, request RenewRequest) ([]byte, []byte, error) {
+	key, keyPEM, err := renewKey(request.Certificate, r.config.Rekey)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := createCSR(request.Certificate.Leaf, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := base64.StdEncoding.EncodeToString(csr)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.Server+"/simplereenroll", strings.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/pkcs10")
+	req.Header.Set("Content-Transfer-Encoding", "base64")
+	if r.config.Username != "" {
+		req.SetBasicAuth(r.config.Username, r.config.Password)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            r.roots,
+		InsecureSkipVerify: r.config.InsecureSkipVerify,
+		Certificates:       []tls.Certificate{*request.Certificate},
+	}}}
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusAccepted:
+		return nil, nil, fmt.Errorf("est: enrollment pending, retry after %s", resp.Header.Get("Retry-After"))
+	default:
+		return nil, nil, fmt.Errorf("est: %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(b)), ""))
+	if err != nil {
+		return nil, nil, fmt.Errorf("est: invalid response: %w", err)
+	}
+	certs, err := parseCertsOnly(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeChain(orderChain(certs, key.Public())), keyPEM, nil
+}
+
+// oidSignedData PKCS#7 signedData
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+// parseCertsOnly 解析 EST 返回的 PKCS#7 certs-only 消息中的证书
+func parseCertsOnly(der []byte) ([]*x509.Certificate, error) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("est: invalid pkcs7: %w", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("est: unexpected pkcs7 content type %s", info.ContentType)
+	}
+	var signed pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signed); err != nil {
+		return nil, fmt.Errorf("est: invalid pkcs7 signed data: %w", err)
+	}
+	certs, err := x509.ParseCertificates(signed.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("est: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("est: no certificate in response")
+	}
+	return certs, nil
+}
+
+// orderChain 公钥和私钥匹配的证书放在最前面
+func orderChain(certs []*x509.Certificate, public crypto.PublicKey) [][]byte {
+	der := make([][]byte, 0, len(certs))
+	for _, c := range certs {
+		if k, ok := c.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); ok && k.Equal(public) {
+			der = append([][]byte{c.Raw}, der...)
+		} else {
+			der = append(der, c.Raw)
+		}
+	}
+	return der
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certmgr
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 内置续期方式
+const (
+	RenewACME = "acme"
+	RenewEST  = "est"
+)
+
+// RenewRequest 续期请求
+type RenewRequest struct {
+	// Name 证书名称
+	Name string
+	// Certificate 当前的证书和私钥，Leaf 已解析
+	Certificate *tls.Certificate
+}
+
+// Renewer 续期方式，返回 PEM 格式的新证书（可以包含中间证书）和私钥，私钥为空表示继续使用当前的私钥
+type Renewer interface {
+	Renew(ctx context.Context, request RenewRequest) (certPEM, keyPEM []byte, err error)
+}
+
+// RenewerFactory 按参数创建续期方式
+type RenewerFactory func(options map[string]interface{}) (Renewer, error)
+
+var (
+	renewersMu sync.RWMutex
+	renewers   = map[string]RenewerFactory{}
+	builtins   = map[string]RenewerFactory{
+		RenewACME: newACME,
+		RenewEST:  newEST,
+	}
+)
+
+// RegisterRenewer 注册自定义续期方式，不能覆盖内置续期方式
+func RegisterRenewer(name string, factory RenewerFactory) error {
+	if _, ok := builtins[name]; ok || name == "" {
+		return fmt.Errorf("certmgr: renewer %q is reserved", name)
+	}
+	if factory == nil {
+		return errors.New("certmgr: renewer factory is nil")
+	}
+	renewersMu.Lock()
+	defer renewersMu.Unlock()
+	renewers[name] = factory
+	return nil
+}
+
+// NewRenewer 按名称创建续期方式
+func NewRenewer(name string, options map[string]interface{}) (Renewer, error) {
+	factory, ok := builtins[name]
+	if !ok {
+		renewersMu.RLock()
+		factory, ok = renewers[name]
+		renewersMu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported renewer: %s", name)
+	}
+	return factory(options)
+}
+
+// decodeOptions 把参数转换为配置结构体
+func decodeOptions(options map[string]interface{}, v interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// renewKey 续期使用的私钥，rekey 为 true 时生成新的 ECDSA P-256 私钥，返回私钥和新私钥的 PEM，继续使用当前私钥时 PEM 为空
+func renewKey(current *tls.Certificate, rekey bool) (crypto.Signer, []byte, error) {
+	if !rekey {
+		key, ok := current.PrivateKey.(crypto.Signer)
+		if !ok {
+			return nil, nil, errors.New("unsupported private key")
+		}
+		return key, nil, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// createCSR 按当前证书的主题和备用名称创建证书请求，dnsNames 不为空时替换 DNS 名称。
+// OPC UA 应用实例证书的 ApplicationURI 在 URIs 中，续期后保持不变
+func createCSR(leaf *x509.Certificate, key crypto.Signer, dnsNames []string) ([]byte, error) {
+	template := &x509.CertificateRequest{
+		Subject:        leaf.Subject,
+		DNSNames:       leaf.DNSNames,
+		IPAddresses:    leaf.IPAddresses,
+		URIs:           leaf.URIs,
+		EmailAddresses: leaf.EmailAddresses,
+	}
+	if len(dnsNames) > 0 {
+		template.DNSNames = dnsNames
+	}
+	template.Subject.ExtraNames = nil
+	return x509.CreateCertificateRequest(rand.Reader, template, key)
+}
+
+// encodeChain DER 证书链转换为 PEM
+func encodeChain(der [][]byte) []byte {
+	var b []byte
+	for _, c := range der {
+		b = append(b, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c})...)
+	}
+	return b
+}
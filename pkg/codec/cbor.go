@@ -0,0 +1,443 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// maxDepth 嵌套的最大深度
+const maxDepth = 64
+
+// ErrTruncated 数据不完整
+var ErrTruncated = errors.New("codec: truncated data")
+
+// errDepth 嵌套过深
+var errDepth = errors.New("codec: nesting too deep")
+
+// jsonCodec JSON 编解码器
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Decode(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// toInt 整数或者没有小数部分的浮点数转换为 int64/uint64，ok 为 false 表示不是整数
+func toInt(v interface{}) (i int64, u uint64, unsigned, ok bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), 0, false, true
+	case int8:
+		return int64(n), 0, false, true
+	case int16:
+		return int64(n), 0, false, true
+	case int32:
+		return int64(n), 0, false, true
+	case int64:
+		return n, 0, false, true
+	case uint:
+		return 0, uint64(n), true, true
+	case uint8:
+		return 0, uint64(n), true, true
+	case uint16:
+		return 0, uint64(n), true, true
+	case uint32:
+		return 0, uint64(n), true, true
+	case uint64:
+		return 0, n, true, true
+	case float64:
+		if n == math.Trunc(n) && n >= -(1<<63) && n < 1<<63 {
+			return int64(n), 0, false, true
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, 0, false, true
+		}
+		if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+			return 0, u, true, true
+		}
+	}
+	return 0, 0, false, false
+}
+
+// toFloat 浮点数
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// sortedKeys 排序后的 key，保证编码结果稳定
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mapKey 非字符串的 key 转换为字符串
+func mapKey(k interface{}) string {
+	switch key := k.(type) {
+	case string:
+		return key
+	case []byte:
+		return string(key)
+	default:
+		return fmt.Sprint(key)
+	}
+}
+
+// CBOR 主类型
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// cborCodec CBOR（RFC 8949）编解码器。整数和没有小数部分的数值编码为整数，map 的 key 按字节序排序；
+// 解码时字节串为 []byte（JSON 序列化为 base64），非字符串的 key 转换为字符串，标签忽略只保留内容，undefined 解码为 nil
+type cborCodec struct{}
+
+func (cborCodec) Encode(v interface{}) ([]byte, error) {
+	return cborAppend(nil, v, 0)
+}
+
+func (cborCodec) Decode(data []byte) (interface{}, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(data) {
+		return nil, fmt.Errorf("cbor: %d trailing bytes", len(data)-d.pos)
+	}
+	return v, nil
+}
+
+// cborHead 编码主类型和参数
+func cborHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+func cborAppend(b []byte, v interface{}, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	if i, u, unsigned, ok := toInt(v); ok {
+		if unsigned {
+			return cborHead(b, cborUint, u), nil
+		}
+		if i < 0 {
+			return cborHead(b, cborNegInt, uint64(-(i + 1))), nil
+		}
+		return cborHead(b, cborUint, uint64(i)), nil
+	}
+	if f, ok := toFloat(v); ok {
+		return binary.BigEndian.AppendUint64(append(b, cborSimple<<5|27), math.Float64bits(f)), nil
+	}
+	var err error
+	switch value := v.(type) {
+	case nil:
+		return append(b, cborSimple<<5|22), nil
+	case bool:
+		if value {
+			return append(b, cborSimple<<5|21), nil
+		}
+		return append(b, cborSimple<<5|20), nil
+	case string:
+		return append(cborHead(b, cborText, uint64(len(value))), value...), nil
+	case []byte:
+		return append(cborHead(b, cborBytes, uint64(len(value))), value...), nil
+	case []interface{}:
+		b = cborHead(b, cborArray, uint64(len(value)))
+		for _, item := range value {
+			if b, err = cborAppend(b, item, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = cborHead(b, cborMap, uint64(len(value)))
+		for _, k := range sortedKeys(value) {
+			b = append(cborHead(b, cborText, uint64(len(k))), k...)
+			if b, err = cborAppend(b, value[k], depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("cbor: unsupported type %T", v)
+	}
+}
+
+// cborDecoder CBOR 解码器
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// next 读取 n 个字节
+func (d *cborDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, ErrTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// head 读取主类型和参数，indefinite 表示不定长
+func (d *cborDecoder) head() (major byte, info byte, n uint64, indefinite bool, err error) {
+	b, err := d.next(1)
+	if err != nil {
+		return
+	}
+	major, info = b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		var arg []byte
+		if arg, err = d.next(1 << (info - 24)); err != nil {
+			return
+		}
+		for _, c := range arg {
+			n = n<<8 | uint64(c)
+		}
+	case info == 31 && major >= cborBytes && major != cborTag:
+		indefinite = true
+	default:
+		err = fmt.Errorf("cbor: invalid additional info %d", info)
+	}
+	return
+}
+
+// length 参数作为长度，不能超过剩余的字节数
+func (d *cborDecoder) length(n uint64) (int, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, ErrTruncated
+	}
+	return int(n), nil
+}
+
+// isBreak 不定长数据是否结束
+func (d *cborDecoder) isBreak() (bool, error) {
+	if d.pos >= len(d.data) {
+		return false, ErrTruncated
+	}
+	if d.data[d.pos] == 0xff {
+		d.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+// chunks 读取字节串或者文本串，支持不定长
+func (d *cborDecoder) chunks(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		length, err := d.length(n)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(length)
+		return append([]byte{}, b...), err
+	}
+	result := []byte{}
+	for {
+		end, err := d.isBreak()
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return result, nil
+		}
+		m, _, n, indef, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || indef {
+			return nil, errors.New("cbor: invalid indefinite string chunk")
+		}
+		length, err := d.length(n)
+		if err != nil {
+			return nil, err
+		}
+		b, _ := d.next(length)
+		result = append(result, b...)
+	}
+}
+
+func (d *cborDecoder) value(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	major, info, n, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+		return n, nil
+	case cborNegInt:
+		if n <= math.MaxInt64 {
+			return -1 - int64(n), nil
+		}
+		return -1 - float64(n), nil
+	case cborBytes:
+		return d.chunks(major, n, indefinite)
+	case cborText:
+		b, err := d.chunks(major, n, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, errors.New("cbor: invalid utf-8 text")
+		}
+		return string(b), nil
+	case cborArray:
+		var items []interface{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite {
+				if end, err := d.isBreak(); err != nil || end {
+					return items, err
+				}
+			} else if i == 0 {
+				if _, err = d.length(n); err != nil {
+					return nil, err
+				}
+				items = make([]interface{}, 0, n)
+			}
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if items == nil {
+			items = []interface{}{}
+		}
+		return items, nil
+	case cborMap:
+		m := map[string]interface{}{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite {
+				if end, err := d.isBreak(); err != nil || end {
+					return m, err
+				}
+			}
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[mapKey(k)] = v
+		}
+		return m, nil
+	case cborTag:
+		return d.value(depth + 1)
+	default:
+		if indefinite {
+			return nil, errors.New("cbor: unexpected break")
+		}
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 25:
+			return halfToFloat(uint16(n)), nil
+		case 26:
+			return float64(math.Float32frombits(uint32(n))), nil
+		case 27:
+			return math.Float64frombits(n), nil
+		default:
+			return n, nil
+		}
+	}
+}
+
+// halfToFloat 半精度浮点数
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
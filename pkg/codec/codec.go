@@ -0,0 +1,115 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package codec 提供负荷编解码器注册表
+// 内置 JSON、CBOR、MessagePack 和 Protobuf（基于用户提供的描述符集合）编解码器，在 JSON 值和设备、云端的二进制负荷之间转换，
+// 其它格式可以通过 Register 注册
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 内置编解码器
+const (
+	JSON     = "json"
+	CBOR     = "cbor"
+	MsgPack  = "msgpack"
+	Protobuf = "protobuf"
+)
+
+// ErrUnknownCodec 编解码器不存在
+var ErrUnknownCodec = errors.New("unknown codec")
+
+// Codec 编解码器，在 JSON 值（nil、bool、float64、string、[]interface{}、map[string]interface{}）和字节之间转换，需要并发安全
+type Codec interface {
+	// Encode 把 JSON 值编码为字节
+	Encode(v interface{}) ([]byte, error)
+	// Decode 把字节解码为可以序列化为 JSON 的值
+	Decode(data []byte) (interface{}, error)
+}
+
+// Options 创建编解码器的参数
+type Options struct {
+	// Descriptor protobuf 描述符集合文件的路径，由 protoc --include_imports --descriptor_set_out 生成
+	Descriptor string `json:"descriptor"`
+	// DescriptorSet protobuf 描述符集合的内容，优先于 Descriptor
+	DescriptorSet []byte `json:"-"`
+	// Message protobuf 消息的完整名称，eg. sensor.v1.Reading
+	Message string `json:"message"`
+	// Params 自定义编解码器的参数
+	Params map[string]interface{} `json:"params"`
+}
+
+// Factory 创建编解码器
+type Factory func(options Options) (Codec, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+	builtins  = map[string]Factory{
+		JSON:     func(Options) (Codec, error) { return jsonCodec{}, nil },
+		CBOR:     func(Options) (Codec, error) { return cborCodec{}, nil },
+		MsgPack:  func(Options) (Codec, error) { return msgpackCodec{}, nil },
+		Protobuf: newProtobuf,
+	}
+)
+
+// Register 注册自定义编解码器，不能覆盖内置编解码器
+func Register(name string, factory Factory) error {
+	if _, ok := builtins[name]; ok || name == "" {
+		return fmt.Errorf("codec %q is reserved", name)
+	}
+	if factory == nil {
+		return errors.New("codec factory is nil")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+	return nil
+}
+
+// New 按名称创建编解码器
+func New(name string, options Options) (Codec, error) {
+	factory, ok := builtins[name]
+	if !ok {
+		mu.RLock()
+		factory, ok = factories[name]
+		mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, name)
+	}
+	return factory(options)
+}
+
+// Names 返回所有编解码器的名称
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(builtins)+len(factories))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
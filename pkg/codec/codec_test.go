@@ -0,0 +1,306 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// roundTrip 编码后再解码，比较 JSON 序列化结果
+func roundTrip(t *testing.T, c Codec, input string) []byte {
+	var v interface{}
+	assert.Nil(t, json.Unmarshal([]byte(input), &v))
+	data, err := c.Encode(v)
+	assert.Nil(t, err)
+	decoded, err := c.Decode(data)
+	assert.Nil(t, err)
+	output, err := json.Marshal(decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, input, string(output))
+	return data
+}
+
+func TestRegistry(t *testing.T) {
+	assert.NotNil(t, Register(CBOR, func(Options) (Codec, error) { return cborCodec{}, nil }))
+	assert.NotNil(t, Register("hex", nil))
+	assert.Nil(t, Register("test", func(Options) (Codec, error) { return jsonCodec{}, nil }))
+	assert.Equal(t, []string{"cbor", "json", "msgpack", "protobuf", "test"}, Names())
+	c, err := New("test", Options{})
+	assert.Nil(t, err)
+	roundTrip(t, c, `{"a":1}`)
+	_, err = New("notFound", Options{})
+	assert.True(t, errors.Is(err, ErrUnknownCodec))
+	_, err = New(Protobuf, Options{})
+	assert.NotNil(t, err)
+}
+
+func TestCBOR(t *testing.T) {
+	c, _ := New(CBOR, Options{})
+	data := roundTrip(t, c, `{"a":1,"b":[true,null]}`)
+	assert.Equal(t, "a2616101616282f5f6", hex.EncodeToString(data))
+	roundTrip(t, c, `{"big":4294967296,"f":1.5,"n":-500,"o":{"x":[]},"s":"温度"}`)
+
+	for in, out := range map[string]string{
+		"f93c00":             `1`,
+		"fb3ff199999999999a": `1.1`,
+		"9f0102ff":           `[1,2]`,
+		"bf6161f4ff":         `{"a":false}`,
+		"7f61616162ff":       `"ab"`,
+		"c11a514b67b0":       `1363896240`,
+		"3903e7":             `-1000`,
+		"a10102":             `{"1":2}`,
+	} {
+		b, _ := hex.DecodeString(in)
+		v, err := c.Decode(b)
+		assert.Nil(t, err)
+		output, _ := json.Marshal(v)
+		assert.Equal(t, out, string(output))
+	}
+	for _, in := range []string{"", "ff", "82", "1a0000", "9f01", "0102"} {
+		b, _ := hex.DecodeString(in)
+		_, err := c.Decode(b)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestMsgPack(t *testing.T) {
+	c, _ := New(MsgPack, Options{})
+	data := roundTrip(t, c, `{"a":1}`)
+	assert.Equal(t, "81a16101", hex.EncodeToString(data))
+	roundTrip(t, c, `{"big":4294967296,"f":1.5,"list":[1,"x",null,false],"n":-500,"o":{},"s":"温度"}`)
+
+	for in, out := range map[string]string{
+		"d6ff00000000":       `"1970-01-01T00:00:00Z"`,
+		"cb3ff8000000000000": `1.5`,
+		"cd0100":             `256`,
+		"d0ff":               `-1`,
+		"c40201ff":           `"Af8="`,
+		"92c3c2":             `[true,false]`,
+	} {
+		b, _ := hex.DecodeString(in)
+		v, err := c.Decode(b)
+		assert.Nil(t, err)
+		output, _ := json.Marshal(v)
+		assert.Equal(t, out, string(output))
+	}
+	for _, in := range []string{"", "c1", "92c3", "cd01", "a3616263c0"} {
+		b, _ := hex.DecodeString(in)
+		_, err := c.Decode(b)
+		assert.NotNil(t, err)
+	}
+}
+
+// 构造描述符集合，等价于：
+//
+//	syntax = "proto3";
+//	package sensor.v1;
+//	enum Status { UNKNOWN = 0; OK = 1; FAULT = 2; }
+//	message Location { double lat = 1; double lon = 2; }
+//	message Reading {
+//	  string device_id = 1; double temperature = 2; int32 rssi = 3; repeated float samples = 4;
+//	  Status status = 5; Location location = 6; map<string, int64> counters = 7; bytes raw = 8;
+//	  sint64 delta = 9; bool ok = 10; uint64 seq = 11; repeated string tags = 12;
+//	}
+func testDescriptorSet() []byte {
+	str := func(b []byte, number int32, s string) []byte { return appendLengthDelimited(b, number, []byte(s)) }
+	num := func(b []byte, number int32, v uint64) []byte {
+		return appendVarint(appendTag(b, number, wireVarint), v)
+	}
+	field := func(name string, number, label, typ int32, typeName string) []byte {
+		b := num(num(num(str(nil, 1, name), 3, uint64(number)), 4, uint64(label)), 5, uint64(typ))
+		if typeName != "" {
+			b = str(b, 6, typeName)
+		}
+		return b
+	}
+	value := func(name string, number uint64) []byte { return num(str(nil, 1, name), 2, number) }
+
+	status := str(nil, 1, "Status")
+	for i, name := range []string{"UNKNOWN", "OK", "FAULT"} {
+		status = appendLengthDelimited(status, 2, value(name, uint64(i)))
+	}
+	location := str(nil, 1, "Location")
+	location = appendLengthDelimited(location, 2, field("lat", 1, 1, pbDouble, ""))
+	location = appendLengthDelimited(location, 2, field("lon", 2, 1, pbDouble, ""))
+
+	entry := str(nil, 1, "CountersEntry")
+	entry = appendLengthDelimited(entry, 2, field("key", 1, 1, pbString, ""))
+	entry = appendLengthDelimited(entry, 2, field("value", 2, 1, pbInt64, ""))
+	entry = appendLengthDelimited(entry, 7, num(nil, 7, 1))
+
+	reading := str(nil, 1, "Reading")
+	for _, f := range [][]byte{
+		field("device_id", 1, 1, pbString, ""),
+		field("temperature", 2, 1, pbDouble, ""),
+		field("rssi", 3, 1, pbInt32, ""),
+		field("samples", 4, pbLabelRepeated, pbFloat, ""),
+		field("status", 5, 1, pbEnum, ".sensor.v1.Status"),
+		field("location", 6, 1, pbMessage, ".sensor.v1.Location"),
+		field("counters", 7, pbLabelRepeated, pbMessage, ".sensor.v1.Reading.CountersEntry"),
+		field("raw", 8, 1, pbBytes, ""),
+		field("delta", 9, 1, pbSint64, ""),
+		field("ok", 10, 1, pbBool, ""),
+		field("seq", 11, 1, pbUint64, ""),
+		field("tags", 12, pbLabelRepeated, pbString, ""),
+	} {
+		reading = appendLengthDelimited(reading, 2, f)
+	}
+	reading = appendLengthDelimited(reading, 3, entry)
+
+	file := str(str(nil, 1, "sensor.proto"), 2, "sensor.v1")
+	file = appendLengthDelimited(file, 4, location)
+	file = appendLengthDelimited(file, 4, reading)
+	file = appendLengthDelimited(file, 5, status)
+	file = str(file, 12, "proto3")
+	return appendLengthDelimited(nil, 1, file)
+}
+
+func TestProtobuf(t *testing.T) {
+	_, err := New(Protobuf, Options{DescriptorSet: testDescriptorSet(), Message: "sensor.v1.Missing"})
+	assert.NotNil(t, err)
+	c, err := New(Protobuf, Options{DescriptorSet: testDescriptorSet(), Message: ".sensor.v1.Reading"})
+	assert.Nil(t, err)
+
+	roundTrip(t, c, `{"counters":{"a":1,"b":-2},"delta":-3,"deviceId":"dev1","location":{"lat":31.2,"lon":121.5},"ok":true,"raw":"AQID","rssi":-70,"samples":[1.5,2.25],"seq":4294967296000,"status":"FAULT","tags":["x","y"],"temperature":21.5}`)
+
+	// 已知的编码结果，字段按编号排序，int32 负数按 10 个字节编码
+	data, err := c.Encode(map[string]interface{}{"rssi": float64(-1), "device_id": "a", "samples": []interface{}{float64(1)}})
+	assert.Nil(t, err)
+	assert.Equal(t, "0a016118ffffffffffffffffff01220400"+"00803f", hex.EncodeToString(data))
+
+	// 非 packed 编码、枚举数值、未知字段都可以解码
+	b, _ := hex.DecodeString("250000803f250000004028019a0500")
+	v, err := c.Decode(b)
+	assert.Nil(t, err)
+	output, _ := json.Marshal(v)
+	assert.Equal(t, `{"samples":[1,2],"status":"OK"}`, string(output))
+
+	_, err = c.Encode(map[string]interface{}{"unknown": 1})
+	assert.NotNil(t, err)
+	_, err = c.Encode(map[string]interface{}{"status": "BROKEN"})
+	assert.NotNil(t, err)
+	_, err = c.Encode(map[string]interface{}{"rssi": float64(1 << 40)})
+	assert.NotNil(t, err)
+	_, err = c.Decode([]byte{0x0a, 0x05, 0x61})
+	assert.NotNil(t, err)
+	_, err = c.Decode([]byte{0x11, 0x01})
+	assert.NotNil(t, err)
+}
+
+// 长度、整数溢出等异常的输入返回错误，不会 panic 或者按声明的长度分配内存
+func TestDecodeMalformed(t *testing.T) {
+	pb, _ := New(Protobuf, Options{DescriptorSet: testDescriptorSet(), Message: "sensor.v1.Reading"})
+	for name, cases := range map[string][]string{
+		CBOR: {
+			"9bffffffffffffffff",     // 数组长度超过剩余字节
+			"bbffffffffffffffff01",   // map 长度超过剩余字节
+			"5bffffffffffffffff",     // 字节串长度溢出
+			"7f7bffffffffffffffffff", // 不定长文本的分段长度溢出
+			"1c",                     // 保留的附加信息
+			"62c328",                 // 非法的 UTF-8
+		},
+		MsgPack: {
+			"ddffffffff",           // 数组长度超过剩余字节
+			"dfffffffff0101",       // map 长度超过剩余字节
+			"c6ffffffff",           // bin 长度超过剩余字节
+			"c9ffffffffff",         // 扩展长度超过剩余字节
+			"d7ffffffffff00000000", // 纳秒超过范围的时间戳
+			"a2c328",               // 非法的 UTF-8
+		},
+		Protobuf: {
+			"0affffffffffffffffff01", // 长度超过剩余字节
+			"18ffffffffffffffffff7f", // varint 超过 64 位
+			"f8ffffffff7f00",         // 字段号超过最大值
+			"0b",                     // 不支持的线路类型
+			"3204ffffffff",           // 嵌套消息截断
+		},
+	} {
+		c := pb
+		if name != Protobuf {
+			c, _ = New(name, Options{})
+		}
+		for _, in := range cases {
+			b, _ := hex.DecodeString(in)
+			_, err := c.Decode(b)
+			assert.NotNil(t, err, name+" "+in)
+		}
+	}
+
+	// 没有 key 的 map 项使用默认值
+	v, err := pb.Decode([]byte{0x3a, 0x02, 0x10, 0x05})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"": int64(5)}, v.(map[string]interface{})["counters"])
+}
+
+// fuzzDecode 解码任意输入不能 panic，解码成功的值可以序列化为 JSON，再次编码和解码的结果不变
+func fuzzDecode(f *testing.F, c Codec, seeds ...string) {
+	for _, seed := range seeds {
+		b, _ := hex.DecodeString(seed)
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v, err := c.Decode(data)
+		if err != nil {
+			return
+		}
+		first, err := json.Marshal(v)
+		if err != nil {
+			// NaN 和 Inf 不能序列化为 JSON
+			return
+		}
+		var value interface{}
+		if err = json.Unmarshal(first, &value); err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := c.Encode(value)
+		if err != nil {
+			return
+		}
+		decoded, err := c.Decode(encoded)
+		if err != nil {
+			t.Fatalf("decode %x: %v", encoded, err)
+		}
+		// 比较 JSON 值，浮点数的格式和对象 key 的顺序可能不同
+		second, _ := json.Marshal(decoded)
+		var again interface{}
+		_ = json.Unmarshal(second, &again)
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("round trip %s != %s", first, second)
+		}
+	})
+}
+
+func FuzzCBOR(f *testing.F) {
+	c, _ := New(CBOR, Options{})
+	fuzzDecode(f, c, "a2616101616282f5f6", "9f0102ff", "bf6161f4ff", "7f61616162ff", "c11a514b67b0", "f93c00", "9bffffffffffffffff")
+}
+
+func FuzzMsgPack(f *testing.F) {
+	c, _ := New(MsgPack, Options{})
+	fuzzDecode(f, c, "81a16101", "d6ff00000000", "c40201ff", "92c3c2", "cd0100", "ddffffffff")
+}
+
+func FuzzProtobuf(f *testing.F) {
+	c, _ := New(Protobuf, Options{DescriptorSet: testDescriptorSet(), Message: "sensor.v1.Reading"})
+	fuzzDecode(f, c, "0a016118ffffffffffffffffff01220400"+"00803f", "250000803f250000004028019a0500", "3a050a016110", "08ffffffffffffffffff01")
+}
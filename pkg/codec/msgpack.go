@@ -0,0 +1,356 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+// msgpackTimestamp MessagePack 时间戳扩展类型
+const msgpackTimestamp = -1
+
+// Ext MessagePack 扩展类型的值，时间戳之外的扩展类型解码为 {"type": 1, "data": "base64"}
+type Ext struct {
+	Type int8   `json:"type"`
+	Data []byte `json:"data"`
+}
+
+// msgpackCodec MessagePack 编解码器。整数和没有小数部分的数值编码为最短的整数格式，map 的 key 按字节序排序；
+// 解码时 bin 为 []byte（JSON 序列化为 base64），时间戳扩展解码为 RFC3339 字符串，其它扩展解码为 Ext
+type msgpackCodec struct{}
+
+func (msgpackCodec) Encode(v interface{}) ([]byte, error) {
+	return msgpackAppend(nil, v, 0)
+}
+
+func (msgpackCodec) Decode(data []byte) (interface{}, error) {
+	d := &msgpackDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(data) {
+		return nil, fmt.Errorf("msgpack: %d trailing bytes", len(data)-d.pos)
+	}
+	return v, nil
+}
+
+// msgpackLength 按长度选择 fix/8/16/32 格式，fix 为 0 表示没有 fix 格式，b8 为 0 表示没有 8 位格式
+func msgpackLength(b []byte, n int, fix, fixMax, b8, b16 byte) []byte {
+	switch {
+	case fix != 0 && n <= int(fixMax):
+		return append(b, fix|byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		return append(b, b8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, b16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, b16+1), uint32(n))
+	}
+}
+
+func msgpackAppend(b []byte, v interface{}, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	if i, u, unsigned, ok := toInt(v); ok {
+		if !unsigned && i >= 0 {
+			u, unsigned = uint64(i), true
+		}
+		if unsigned {
+			switch {
+			case u <= 0x7f:
+				return append(b, byte(u)), nil
+			case u <= math.MaxUint8:
+				return append(b, 0xcc, byte(u)), nil
+			case u <= math.MaxUint16:
+				return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(u)), nil
+			case u <= math.MaxUint32:
+				return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(u)), nil
+			default:
+				return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+			}
+		}
+		switch {
+		case i >= -32:
+			return append(b, byte(int8(i))), nil
+		case i >= math.MinInt8:
+			return append(b, 0xd0, byte(int8(i))), nil
+		case i >= math.MinInt16:
+			return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(int16(i))), nil
+		case i >= math.MinInt32:
+			return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(int32(i))), nil
+		default:
+			return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i)), nil
+		}
+	}
+	if f, ok := toFloat(v); ok {
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+	}
+	var err error
+	switch value := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if value {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case string:
+		return append(msgpackLength(b, len(value), 0xa0, 31, 0xd9, 0xda), value...), nil
+	case []byte:
+		return append(msgpackLength(b, len(value), 0, 0, 0xc4, 0xc5), value...), nil
+	case []interface{}:
+		b = msgpackLength(b, len(value), 0x90, 15, 0, 0xdc)
+		for _, item := range value {
+			if b, err = msgpackAppend(b, item, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = msgpackLength(b, len(value), 0x80, 15, 0, 0xde)
+		for _, k := range sortedKeys(value) {
+			b = append(msgpackLength(b, len(k), 0xa0, 31, 0xd9, 0xda), k...)
+			if b, err = msgpackAppend(b, value[k], depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+}
+
+// msgpackDecoder MessagePack 解码器
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+// next 读取 n 个字节
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, ErrTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint 读取 n 字节的大端无符号整数
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// bytes 读取 n 字节长度的字节串
+func (d *msgpackDecoder) bytes(n int) ([]byte, error) {
+	length, err := d.uint(n)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(d.data)-d.pos) {
+		return nil, ErrTruncated
+	}
+	b, _ := d.next(int(length))
+	return append([]byte{}, b...), nil
+}
+
+// msgpackString 字符串需要是合法的 UTF-8，否则序列化为 JSON 时会被替换
+func msgpackString(b []byte) (interface{}, error) {
+	if !utf8.Valid(b) {
+		return nil, errors.New("msgpack: invalid utf-8 string")
+	}
+	return string(b), nil
+}
+
+// array 读取 n 个元素
+func (d *msgpackDecoder) array(n uint64, depth int) (interface{}, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, ErrTruncated
+	}
+	items := make([]interface{}, 0, n)
+	for i := uint64(0); i < n; i++ {
+		item, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// object 读取 n 个键值对
+func (d *msgpackDecoder) object(n uint64, depth int) (interface{}, error) {
+	// 每个键值对至少 2 个字节
+	if n > uint64(len(d.data)-d.pos)/2 {
+		return nil, ErrTruncated
+	}
+	m := make(map[string]interface{}, n)
+	for i := uint64(0); i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[mapKey(k)] = v
+	}
+	return m, nil
+}
+
+// ext 读取扩展类型，size 为数据长度
+func (d *msgpackDecoder) ext(size int) (interface{}, error) {
+	t, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.next(size)
+	if err != nil {
+		return nil, err
+	}
+	if int8(t[0]) != msgpackTimestamp {
+		return Ext{Type: int8(t[0]), Data: append([]byte(nil), data...)}, nil
+	}
+	var sec int64
+	var nsec uint32
+	switch size {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		nsec, sec = uint32(v>>34), int64(v&(1<<34-1))
+	case 12:
+		nsec, sec = binary.BigEndian.Uint32(data), int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		return nil, errors.New("msgpack: invalid timestamp")
+	}
+	if nsec > 999999999 {
+		return nil, errors.New("msgpack: invalid timestamp nanoseconds")
+	}
+	return time.Unix(sec, int64(nsec)).UTC().Format(time.RFC3339Nano), nil
+}
+
+func (d *msgpackDecoder) value(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.object(uint64(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.array(uint64(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		s, err := d.next(int(c & 0x1f))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackString(s)
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		return d.bytes(1 << (c - 0xc4))
+	case 0xc7, 0xc8, 0xc9:
+		size, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(len(d.data)) {
+			return nil, ErrTruncated
+		}
+		return d.ext(int(size))
+	case 0xca:
+		v, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+		return v, nil
+	case 0xd0:
+		v, err := d.uint(1)
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := d.uint(2)
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := d.uint(4)
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := d.uint(8)
+		return int64(v), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		s, err := d.bytes(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackString(s)
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.object(n, depth)
+	default:
+		return nil, fmt.Errorf("msgpack: invalid format 0x%02x", c)
+	}
+}
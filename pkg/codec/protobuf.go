@@ -0,0 +1,867 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// protobuf 线路类型
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protobuf 字段类型，见 google/protobuf/descriptor.proto
+const (
+	pbDouble   = 1
+	pbFloat    = 2
+	pbInt64    = 3
+	pbUint64   = 4
+	pbInt32    = 5
+	pbFixed64  = 6
+	pbFixed32  = 7
+	pbBool     = 8
+	pbString   = 9
+	pbGroup    = 10
+	pbMessage  = 11
+	pbBytes    = 12
+	pbUint32   = 13
+	pbEnum     = 14
+	pbSfixed32 = 15
+	pbSfixed64 = 16
+	pbSint32   = 17
+	pbSint64   = 18
+)
+
+// pbLabelRepeated 重复字段
+const pbLabelRepeated = 3
+
+// pbMaxFieldNumber 字段号的最大值
+const pbMaxFieldNumber = 1<<29 - 1
+
+// pbField 消息的字段
+type pbField struct {
+	name     string
+	jsonName string
+	number   int32
+	label    int32
+	typ      int32
+	typeName string
+	// packed 重复的数值字段是否按 packed 编码
+	packed  bool
+	message *pbMessageType
+	enum    *pbEnumType
+}
+
+// repeated 是否为重复字段
+func (f *pbField) repeated() bool {
+	return f.label == pbLabelRepeated
+}
+
+// isMap 是否为 map 字段
+func (f *pbField) isMap() bool {
+	return f.repeated() && f.message != nil && f.message.mapEntry
+}
+
+// pbMessageType 消息类型
+type pbMessageType struct {
+	fullName string
+	fields   []*pbField
+	byNumber map[int32]*pbField
+	byName   map[string]*pbField
+	mapEntry bool
+}
+
+// pbEnumType 枚举类型
+type pbEnumType struct {
+	names  map[int32]string
+	values map[string]int32
+}
+
+// pbCodec 基于描述符集合的 protobuf 编解码器。字段按 JSON 名称（json_name，默认为小驼峰）输出，编码时也接受原始字段名；
+// 64位整数输出为数值，枚举输出为名称，bytes 为 base64，map 字段为对象，未设置的字段不输出。编码时未知的字段返回错误
+type pbCodec struct {
+	message *pbMessageType
+}
+
+// newProtobuf 加载描述符集合并查找消息类型
+func newProtobuf(options Options) (Codec, error) {
+	data := options.DescriptorSet
+	if len(data) == 0 {
+		if options.Descriptor == "" {
+			return nil, errors.New("protobuf descriptor set is required")
+		}
+		var err error
+		if data, err = os.ReadFile(options.Descriptor); err != nil {
+			return nil, err
+		}
+	}
+	if options.Message == "" {
+		return nil, errors.New("protobuf message is required")
+	}
+	types, err := parseDescriptorSet(data)
+	if err != nil {
+		return nil, err
+	}
+	message, ok := types.messages[strings.TrimPrefix(options.Message, ".")]
+	if !ok {
+		return nil, fmt.Errorf("protobuf message %s not found in descriptor set", options.Message)
+	}
+	return &pbCodec{message: message}, nil
+}
+
+func (c *pbCodec) Encode(v interface{}) ([]byte, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %s must be a json object", c.message.fullName)
+	}
+	return pbEncodeMessage(nil, c.message, m, 0)
+}
+
+func (c *pbCodec) Decode(data []byte) (interface{}, error) {
+	return pbDecodeMessage(c.message, data, 0)
+}
+
+// appendVarint 编码 varint
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// appendTag 编码字段号和线路类型
+func appendTag(b []byte, number int32, wireType int) []byte {
+	return appendVarint(b, uint64(number)<<3|uint64(wireType))
+}
+
+// appendLengthDelimited 编码长度前缀的字段
+func appendLengthDelimited(b []byte, number int32, data []byte) []byte {
+	b = appendVarint(appendTag(b, number, wireBytes), uint64(len(data)))
+	return append(b, data...)
+}
+
+// readVarint 解码 varint，返回值和读取的字节数，第10个字节只能有最低位，超过 64 位返回错误
+func readVarint(data []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		if i == 9 && data[i] > 1 {
+			break
+		}
+		v |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i] < 0x80 {
+			return v, i + 1, nil
+		}
+	}
+	if len(data) < 10 {
+		return 0, 0, ErrTruncated
+	}
+	return 0, 0, errors.New("protobuf: varint overflow")
+}
+
+// walkFields 遍历消息的字段，varint 和定长字段的值在 v 中，长度前缀字段的内容在 b 中
+func walkFields(data []byte, fn func(number int32, wireType int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		tag, n, err := readVarint(data)
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+		if tag>>3 == 0 || tag>>3 > pbMaxFieldNumber {
+			return errors.New("protobuf: invalid field number")
+		}
+		number, wireType := int32(tag>>3), int(tag&7)
+		var v uint64
+		var b []byte
+		switch wireType {
+		case wireVarint:
+			if v, n, err = readVarint(data); err != nil {
+				return err
+			}
+		case wireFixed64:
+			if len(data) < 8 {
+				return ErrTruncated
+			}
+			v, n = binary.LittleEndian.Uint64(data), 8
+		case wireFixed32:
+			if len(data) < 4 {
+				return ErrTruncated
+			}
+			v, n = uint64(binary.LittleEndian.Uint32(data)), 4
+		case wireBytes:
+			var length uint64
+			if length, n, err = readVarint(data); err != nil {
+				return err
+			}
+			if length > uint64(len(data)-n) {
+				return ErrTruncated
+			}
+			b = data[n : n+int(length)]
+			n += int(length)
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d", wireType)
+		}
+		data = data[n:]
+		if err = fn(number, wireType, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pbTypes 描述符集合中的类型，key 为不带前导点的完整名称
+type pbTypes struct {
+	messages map[string]*pbMessageType
+	enums    map[string]*pbEnumType
+}
+
+// parseDescriptorSet 解析 FileDescriptorSet 并解析字段引用的类型
+func parseDescriptorSet(data []byte) (*pbTypes, error) {
+	types := &pbTypes{messages: map[string]*pbMessageType{}, enums: map[string]*pbEnumType{}}
+	err := walkFields(data, func(number int32, wireType int, v uint64, b []byte) error {
+		if number == 1 && wireType == wireBytes {
+			return types.parseFile(b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf descriptor set: %w", err)
+	}
+	for _, m := range types.messages {
+		for _, f := range m.fields {
+			name := strings.TrimPrefix(f.typeName, ".")
+			switch f.typ {
+			case pbMessage, pbGroup:
+				if f.message = types.messages[name]; f.message == nil {
+					return nil, fmt.Errorf("protobuf type %s of %s.%s not found", f.typeName, m.fullName, f.name)
+				}
+			case pbEnum:
+				if f.enum = types.enums[name]; f.enum == nil {
+					return nil, fmt.Errorf("protobuf type %s of %s.%s not found", f.typeName, m.fullName, f.name)
+				}
+			}
+		}
+	}
+	return types, nil
+}
+
+// parseFile 解析 FileDescriptorProto
+func (t *pbTypes) parseFile(data []byte) error {
+	var pkg, syntax string
+	var messages, enums [][]byte
+	err := walkFields(data, func(number int32, wireType int, v uint64, b []byte) error {
+		switch number {
+		case 2:
+			pkg = string(b)
+		case 4:
+			messages = append(messages, b)
+		case 5:
+			enums = append(enums, b)
+		case 12:
+			syntax = string(b)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, b := range enums {
+		if err = t.parseEnum(pkg, b); err != nil {
+			return err
+		}
+	}
+	for _, b := range messages {
+		if err = t.parseMessage(pkg, b, syntax == "proto3"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// qualify 拼接完整名称
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// parseEnum 解析 EnumDescriptorProto
+func (t *pbTypes) parseEnum(scope string, data []byte) error {
+	var name string
+	enum := &pbEnumType{names: map[int32]string{}, values: map[string]int32{}}
+	err := walkFields(data, func(number int32, wireType int, v uint64, b []byte) error {
+		switch number {
+		case 1:
+			name = string(b)
+		case 2:
+			var valueName string
+			var value int32
+			err := walkFields(b, func(number int32, wireType int, v uint64, b []byte) error {
+				switch number {
+				case 1:
+					valueName = string(b)
+				case 2:
+					value = int32(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if _, ok := enum.names[value]; !ok {
+				enum.names[value] = valueName
+			}
+			enum.values[valueName] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	t.enums[qualify(scope, name)] = enum
+	return nil
+}
+
+// parseMessage 解析 DescriptorProto，包括嵌套的消息和枚举
+func (t *pbTypes) parseMessage(scope string, data []byte, proto3 bool) error {
+	m := &pbMessageType{byNumber: map[int32]*pbField{}, byName: map[string]*pbField{}}
+	var nested, enums [][]byte
+	err := walkFields(data, func(number int32, wireType int, v uint64, b []byte) error {
+		switch number {
+		case 1:
+			m.fullName = qualify(scope, string(b))
+		case 2:
+			f, err := parseField(b, proto3)
+			if err != nil {
+				return err
+			}
+			m.fields = append(m.fields, f)
+		case 3:
+			nested = append(nested, b)
+		case 4:
+			enums = append(enums, b)
+		case 7:
+			// MessageOptions.map_entry
+			return walkFields(b, func(number int32, wireType int, v uint64, b []byte) error {
+				if number == 7 {
+					m.mapEntry = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(m.fields, func(i, j int) bool { return m.fields[i].number < m.fields[j].number })
+	for _, f := range m.fields {
+		m.byNumber[f.number] = f
+		m.byName[f.name] = f
+		m.byName[f.jsonName] = f
+	}
+	t.messages[m.fullName] = m
+	for _, b := range enums {
+		if err = t.parseEnum(m.fullName, b); err != nil {
+			return err
+		}
+	}
+	for _, b := range nested {
+		if err = t.parseMessage(m.fullName, b, proto3); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseField 解析 FieldDescriptorProto，proto3 的重复数值字段默认按 packed 编码
+func parseField(data []byte, proto3 bool) (*pbField, error) {
+	f := &pbField{}
+	var packed *bool
+	err := walkFields(data, func(number int32, wireType int, v uint64, b []byte) error {
+		switch number {
+		case 1:
+			f.name = string(b)
+		case 3:
+			f.number = int32(v)
+		case 4:
+			f.label = int32(v)
+		case 5:
+			f.typ = int32(v)
+		case 6:
+			f.typeName = string(b)
+		case 8:
+			// FieldOptions.packed
+			return walkFields(b, func(number int32, wireType int, v uint64, b []byte) error {
+				if number == 2 {
+					p := v != 0
+					packed = &p
+				}
+				return nil
+			})
+		case 10:
+			f.jsonName = string(b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.jsonName == "" {
+		f.jsonName = lowerCamel(f.name)
+	}
+	if f.repeated() && packable(f.typ) {
+		f.packed = proto3
+		if packed != nil {
+			f.packed = *packed
+		}
+	}
+	return f, nil
+}
+
+// lowerCamel 下划线命名转换为小驼峰，与 protoc 生成 json_name 的规则一致
+func lowerCamel(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, c := range name {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// packable 是否为可以 packed 编码的数值类型
+func packable(typ int32) bool {
+	switch typ {
+	case pbString, pbBytes, pbMessage, pbGroup:
+		return false
+	}
+	return true
+}
+
+// wireTypeOf 数值类型的线路类型
+func wireTypeOf(typ int32) int {
+	switch typ {
+	case pbDouble, pbFixed64, pbSfixed64:
+		return wireFixed64
+	case pbFloat, pbFixed32, pbSfixed32:
+		return wireFixed32
+	case pbString, pbBytes, pbMessage:
+		return wireBytes
+	}
+	return wireVarint
+}
+
+func pbEncodeMessage(b []byte, m *pbMessageType, value map[string]interface{}, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	values := make(map[int32]interface{}, len(value))
+	for k, v := range value {
+		f, ok := m.byName[k]
+		if !ok {
+			return nil, fmt.Errorf("protobuf: unknown field %s in %s", k, m.fullName)
+		}
+		if v != nil {
+			values[f.number] = v
+		}
+	}
+	var err error
+	for _, f := range m.fields {
+		v, ok := values[f.number]
+		if !ok {
+			continue
+		}
+		if b, err = pbEncodeField(b, f, v, depth); err != nil {
+			return nil, fmt.Errorf("protobuf %s.%s: %w", m.fullName, f.name, err)
+		}
+	}
+	return b, nil
+}
+
+// pbEncodeField 编码一个字段，重复字段的值为数组，map 字段的值为对象
+func pbEncodeField(b []byte, f *pbField, v interface{}, depth int) ([]byte, error) {
+	var err error
+	if f.isMap() {
+		entries, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("map value must be a json object")
+		}
+		keys := sortedKeys(entries)
+		keyField, valueField := f.message.byNumber[1], f.message.byNumber[2]
+		if keyField == nil || valueField == nil {
+			return nil, errors.New("invalid map entry")
+		}
+		for _, k := range keys {
+			var entry []byte
+			if entry, err = pbEncodeField(nil, keyField, k, depth+1); err != nil {
+				return nil, err
+			}
+			if entries[k] != nil {
+				if entry, err = pbEncodeField(entry, valueField, entries[k], depth+1); err != nil {
+					return nil, err
+				}
+			}
+			b = appendLengthDelimited(b, f.number, entry)
+		}
+		return b, nil
+	}
+	if !f.repeated() {
+		return pbEncodeValue(b, f, v, true, depth)
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("repeated value must be a json array")
+	}
+	if f.packed {
+		var packed []byte
+		for _, item := range items {
+			if packed, err = pbEncodeValue(packed, f, item, false, depth); err != nil {
+				return nil, err
+			}
+		}
+		return appendLengthDelimited(b, f.number, packed), nil
+	}
+	for _, item := range items {
+		if b, err = pbEncodeValue(b, f, item, true, depth); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// pbEncodeValue 编码一个值，tagged 为 false 时不编码字段号，用于 packed 编码
+func pbEncodeValue(b []byte, f *pbField, v interface{}, tagged bool, depth int) ([]byte, error) {
+	if tagged && f.typ != pbString && f.typ != pbBytes && f.typ != pbMessage {
+		b = appendTag(b, f.number, wireTypeOf(f.typ))
+	}
+	switch f.typ {
+	case pbDouble, pbFloat:
+		x, err := pbFloatOf(v)
+		if err != nil {
+			return nil, err
+		}
+		if f.typ == pbFloat {
+			return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(x))), nil
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(x)), nil
+	case pbInt32, pbInt64, pbSint32, pbSint64, pbSfixed32, pbSfixed64:
+		x, err := pbIntOf(v)
+		if err != nil {
+			return nil, err
+		}
+		if (f.typ == pbInt32 || f.typ == pbSint32 || f.typ == pbSfixed32) && (x < math.MinInt32 || x > math.MaxInt32) {
+			return nil, fmt.Errorf("value %d out of int32 range", x)
+		}
+		switch f.typ {
+		case pbSint32, pbSint64:
+			return appendVarint(b, uint64(x<<1)^uint64(x>>63)), nil
+		case pbSfixed32:
+			return binary.LittleEndian.AppendUint32(b, uint32(x)), nil
+		case pbSfixed64:
+			return binary.LittleEndian.AppendUint64(b, uint64(x)), nil
+		default:
+			return appendVarint(b, uint64(x)), nil
+		}
+	case pbUint32, pbUint64, pbFixed32, pbFixed64:
+		x, err := pbUintOf(v)
+		if err != nil {
+			return nil, err
+		}
+		if (f.typ == pbUint32 || f.typ == pbFixed32) && x > math.MaxUint32 {
+			return nil, fmt.Errorf("value %d out of uint32 range", x)
+		}
+		switch f.typ {
+		case pbFixed32:
+			return binary.LittleEndian.AppendUint32(b, uint32(x)), nil
+		case pbFixed64:
+			return binary.LittleEndian.AppendUint64(b, x), nil
+		default:
+			return appendVarint(b, x), nil
+		}
+	case pbBool:
+		x, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool %v", v)
+		}
+		if x {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case pbEnum:
+		if name, ok := v.(string); ok {
+			x, ok := f.enum.values[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown enum value %s", name)
+			}
+			return appendVarint(b, uint64(int64(x))), nil
+		}
+		x, err := pbIntOf(v)
+		if err != nil {
+			return nil, err
+		}
+		return appendVarint(b, uint64(x)), nil
+	case pbString:
+		x, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string %v", v)
+		}
+		return appendLengthDelimited(b, f.number, []byte(x)), nil
+	case pbBytes:
+		var x []byte
+		switch value := v.(type) {
+		case []byte:
+			x = value
+		case string:
+			var err error
+			if x, err = base64.StdEncoding.DecodeString(value); err != nil {
+				if x, err = base64.URLEncoding.DecodeString(value); err != nil {
+					return nil, fmt.Errorf("bytes must be base64: %w", err)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid bytes %v", v)
+		}
+		return appendLengthDelimited(b, f.number, x), nil
+	case pbMessage:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be a json object", f.message.fullName)
+		}
+		data, err := pbEncodeMessage(nil, f.message, m, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return appendLengthDelimited(b, f.number, data), nil
+	default:
+		return nil, fmt.Errorf("unsupported field type %d", f.typ)
+	}
+}
+
+// pbIntOf 转换为有符号整数，支持数值和字符串
+func pbIntOf(v interface{}) (int64, error) {
+	if s, ok := v.(string); ok {
+		return strconv.ParseInt(s, 10, 64)
+	}
+	if i, u, unsigned, ok := toInt(v); ok {
+		if unsigned {
+			if u > math.MaxInt64 {
+				return 0, fmt.Errorf("value %d out of int64 range", u)
+			}
+			return int64(u), nil
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("invalid integer %v", v)
+}
+
+// pbUintOf 转换为无符号整数，支持数值和字符串
+func pbUintOf(v interface{}) (uint64, error) {
+	if s, ok := v.(string); ok {
+		return strconv.ParseUint(s, 10, 64)
+	}
+	if f, ok := v.(float64); ok && f >= 1<<63 && f < 1<<64 && f == math.Trunc(f) {
+		return uint64(f), nil
+	}
+	if i, u, unsigned, ok := toInt(v); ok {
+		if unsigned {
+			return u, nil
+		}
+		if i >= 0 {
+			return uint64(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid unsigned integer %v", v)
+}
+
+// pbFloatOf 转换为浮点数，支持数值和字符串
+func pbFloatOf(v interface{}) (float64, error) {
+	if s, ok := v.(string); ok {
+		return strconv.ParseFloat(s, 64)
+	}
+	if f, ok := toFloat(v); ok {
+		return f, nil
+	}
+	if i, u, unsigned, ok := toInt(v); ok {
+		if unsigned {
+			return float64(u), nil
+		}
+		return float64(i), nil
+	}
+	return 0, fmt.Errorf("invalid number %v", v)
+}
+
+func pbDecodeMessage(m *pbMessageType, data []byte, depth int) (map[string]interface{}, error) {
+	if depth > maxDepth {
+		return nil, errDepth
+	}
+	result := map[string]interface{}{}
+	err := walkFields(data, func(number int32, wireType int, v uint64, b []byte) error {
+		f, ok := m.byNumber[number]
+		if !ok {
+			return nil
+		}
+		if f.isMap() {
+			entry, err := pbDecodeMessage(f.message, b, depth+1)
+			if err != nil {
+				return err
+			}
+			entries, _ := result[f.jsonName].(map[string]interface{})
+			if entries == nil {
+				entries = map[string]interface{}{}
+				result[f.jsonName] = entries
+			}
+			keyField, valueField := f.message.byNumber[1], f.message.byNumber[2]
+			if keyField == nil || valueField == nil {
+				return errors.New("protobuf: invalid map entry")
+			}
+			// 没有设置的 key 为默认值
+			key, ok := entry[keyField.jsonName]
+			if !ok {
+				key = pbZero(keyField)
+			}
+			entries[mapKey(key)] = entry[valueField.jsonName]
+			return nil
+		}
+		// packed 编码的重复数值字段，解码时同时支持 packed 和非 packed
+		if f.repeated() && packable(f.typ) && wireType == wireBytes {
+			items, _ := result[f.jsonName].([]interface{})
+			for len(b) > 0 {
+				var x uint64
+				var n int
+				switch wireTypeOf(f.typ) {
+				case wireFixed64:
+					if len(b) < 8 {
+						return ErrTruncated
+					}
+					x, n = binary.LittleEndian.Uint64(b), 8
+				case wireFixed32:
+					if len(b) < 4 {
+						return ErrTruncated
+					}
+					x, n = uint64(binary.LittleEndian.Uint32(b)), 4
+				default:
+					var err error
+					if x, n, err = readVarint(b); err != nil {
+						return err
+					}
+				}
+				b = b[n:]
+				item, err := pbDecodeValue(f, wireTypeOf(f.typ), x, nil, depth)
+				if err != nil {
+					return err
+				}
+				items = append(items, item)
+			}
+			// 空的 packed 字段等价于没有设置
+			if items != nil {
+				result[f.jsonName] = items
+			}
+			return nil
+		}
+		if wireType != wireTypeOf(f.typ) {
+			return fmt.Errorf("protobuf %s.%s: unexpected wire type %d", m.fullName, f.name, wireType)
+		}
+		value, err := pbDecodeValue(f, wireType, v, b, depth)
+		if err != nil {
+			return err
+		}
+		if f.repeated() {
+			items, _ := result[f.jsonName].([]interface{})
+			result[f.jsonName] = append(items, value)
+		} else {
+			result[f.jsonName] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pbZero map key 字段的默认值
+func pbZero(f *pbField) interface{} {
+	switch f.typ {
+	case pbString:
+		return ""
+	case pbBool:
+		return false
+	}
+	return int64(0)
+}
+
+// pbDecodeValue 解码一个值
+func pbDecodeValue(f *pbField, wireType int, v uint64, b []byte, depth int) (interface{}, error) {
+	switch f.typ {
+	case pbDouble:
+		return math.Float64frombits(v), nil
+	case pbFloat:
+		return float64(math.Float32frombits(uint32(v))), nil
+	case pbInt32:
+		return int64(int32(v)), nil
+	case pbInt64, pbSfixed64:
+		return int64(v), nil
+	case pbSfixed32:
+		return int64(int32(uint32(v))), nil
+	case pbSint32, pbSint64:
+		return int64(v>>1) ^ -int64(v&1), nil
+	case pbUint32, pbFixed32:
+		return uint64(uint32(v)), nil
+	case pbUint64, pbFixed64:
+		return v, nil
+	case pbBool:
+		return v != 0, nil
+	case pbEnum:
+		if name, ok := f.enum.names[int32(v)]; ok {
+			return name, nil
+		}
+		return int64(int32(v)), nil
+	case pbString:
+		if !utf8.Valid(b) {
+			return nil, fmt.Errorf("protobuf %s: invalid utf-8 string", f.name)
+		}
+		return string(b), nil
+	case pbBytes:
+		return append([]byte{}, b...), nil
+	case pbMessage:
+		return pbDecodeMessage(f.message, b, depth+1)
+	default:
+		return nil, fmt.Errorf("protobuf %s: unsupported field type %d", f.name, f.typ)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package conntest 提供组件连接测试注册表
+// 协议组件按组件类型注册 Tester，规则链编辑器保存配置前调用 TestConnection 校验连通性、认证，
+// 并可读取一个样本点位，按步骤返回结构化的诊断结果
+package conntest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 测试步骤
+const (
+	// StepConfig 校验配置
+	StepConfig = "config"
+	// StepConnect 建立连接
+	StepConnect = "connect"
+	// StepAuth 认证或者建立会话
+	StepAuth = "auth"
+	// StepRead 读取样本点位
+	StepRead = "read"
+)
+
+// KeySample 配置中样本点位的 key，格式由组件决定，为空时使用组件配置的第一个点位，没有配置点位时跳过读取
+const KeySample = "testSample"
+
+// DefaultTimeout 调用方没有设置超时时的默认超时
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnsupported 组件类型不支持连接测试
+var ErrUnsupported = errors.New("connection test is not supported")
+
+// Step 测试步骤的结果
+type Step struct {
+	// Name 步骤名称：config、connect、auth、read
+	Name string `json:"name"`
+	// Success 是否成功，跳过的步骤为 true
+	Success bool `json:"success"`
+	// Skipped 是否跳过
+	Skipped bool `json:"skipped,omitempty"`
+	// Duration 耗时，单位毫秒
+	Duration float64 `json:"duration"`
+	// Message 步骤的诊断信息，eg. 服务器支持的安全策略
+	Message string `json:"message,omitempty"`
+	// Error 失败原因
+	Error string `json:"error,omitempty"`
+}
+
+// Result 连接测试结果
+type Result struct {
+	// Component 组件类型
+	Component string `json:"component"`
+	// Target 连接目标
+	Target string `json:"target"`
+	// Success 所有步骤是否成功
+	Success bool `json:"success"`
+	// Steps 按执行顺序的步骤，第一个失败的步骤之后的步骤不执行
+	Steps []Step `json:"steps"`
+	// Sample 样本点位的读取结果
+	Sample interface{} `json:"sample,omitempty"`
+	// Duration 总耗时，单位毫秒
+	Duration float64 `json:"duration"`
+	// Error 第一个失败步骤的错误
+	Error string `json:"error,omitempty"`
+	start time.Time
+}
+
+// NewResult 创建测试结果
+func NewResult(component, target string) *Result {
+	return &Result{Component: component, Target: target, Success: true, Steps: []Step{}, start: time.Now()}
+}
+
+// Run 执行一个步骤，fn 返回诊断信息和错误。之前的步骤失败时不执行，返回 false
+func (r *Result) Run(name string, fn func() (string, error)) bool {
+	if !r.Success {
+		return false
+	}
+	start := time.Now()
+	message, err := fn()
+	step := Step{Name: name, Success: err == nil, Duration: ms(time.Since(start)), Message: message}
+	if err != nil {
+		step.Error = err.Error()
+		r.Success = false
+		r.Error = fmt.Sprintf("%s: %s", name, err)
+	}
+	r.Steps = append(r.Steps, step)
+	return err == nil
+}
+
+// Skip 跳过一个步骤
+func (r *Result) Skip(name, message string) {
+	if r.Success {
+		r.Steps = append(r.Steps, Step{Name: name, Success: true, Skipped: true, Message: message})
+	}
+}
+
+// Fail 配置错误等不属于任何步骤的失败，记录为 config 步骤
+func (r *Result) Fail(err error) *Result {
+	r.Run(StepConfig, func() (string, error) {
+		return "", err
+	})
+	return r.Finish()
+}
+
+// Finish 计算总耗时
+func (r *Result) Finish() *Result {
+	r.Duration = ms(time.Since(r.start))
+	return r
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Tester 测试组件配置的连接，ctx 结束时中止测试。config 为组件的配置，可以包含 KeySample
+type Tester func(ctx context.Context, config map[string]interface{}) *Result
+
+var (
+	mu      sync.RWMutex
+	testers = map[string]Tester{}
+)
+
+// Register 注册组件类型的连接测试，相同类型重复注册时覆盖
+func Register(componentType string, tester Tester) error {
+	if componentType == "" {
+		return errors.New("component type is empty")
+	}
+	if tester == nil {
+		return errors.New("connection tester is nil")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	testers[componentType] = tester
+	return nil
+}
+
+// Types 返回支持连接测试的组件类型
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(testers))
+	for name := range testers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Supported 组件类型是否支持连接测试
+func Supported(componentType string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := testers[componentType]
+	return ok
+}
+
+// TestConnection 测试组件配置的连接，组件类型不支持时返回 ErrUnsupported
+// ctx 没有设置截止时间时使用 DefaultTimeout
+func TestConnection(ctx context.Context, componentType string, config map[string]interface{}) (*Result, error) {
+	mu.RLock()
+	tester, ok := testers[componentType]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, componentType)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	result := tester(ctx, config)
+	if result == nil {
+		return nil, fmt.Errorf("connection test of %s returned no result", componentType)
+	}
+	result.Component = componentType
+	return result.Finish(), nil
+}
+
+// Timeout 请求使用的超时，configured 小于等于0或者超过 ctx 剩余时间时使用 ctx 剩余时间
+func Timeout(ctx context.Context, configured time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return configured
+	}
+	if remaining := time.Until(deadline); configured <= 0 || configured > remaining {
+		return remaining
+	}
+	return configured
+}
+
+// Sample 配置中的样本点位，不存在返回 nil
+func Sample(config map[string]interface{}) interface{} {
+	return config[KeySample]
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conntest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	_, err := TestConnection(context.Background(), "x/unknown", nil)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+	assert.NotNil(t, Register("", func(ctx context.Context, config map[string]interface{}) *Result { return nil }))
+	assert.NotNil(t, Register("x/test", nil))
+
+	assert.Nil(t, Register("x/test", func(ctx context.Context, config map[string]interface{}) *Result {
+		_, ok := ctx.Deadline()
+		result := NewResult("", "127.0.0.1")
+		result.Run(StepConnect, func() (string, error) {
+			if !ok {
+				return "", errors.New("no deadline")
+			}
+			return "connected", nil
+		})
+		result.Run(StepAuth, func() (string, error) {
+			if config["password"] != "secret" {
+				return "", errors.New("bad password")
+			}
+			return "", nil
+		})
+		result.Run(StepRead, func() (string, error) {
+			result.Sample = Sample(config)
+			return "", nil
+		})
+		return result
+	}))
+	assert.True(t, Supported("x/test"))
+	assert.Equal(t, []string{"x/test"}, Types())
+
+	result, err := TestConnection(context.Background(), "x/test", map[string]interface{}{"password": "secret", KeySample: "ns=2;i=1"})
+	assert.Nil(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "x/test", result.Component)
+	assert.Equal(t, 3, len(result.Steps))
+	assert.Equal(t, "connected", result.Steps[0].Message)
+	assert.Equal(t, "ns=2;i=1", result.Sample)
+
+	// 失败的步骤之后不再执行
+	result, err = TestConnection(context.Background(), "x/test", map[string]interface{}{"password": "wrong"})
+	assert.Nil(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, 2, len(result.Steps))
+	assert.Equal(t, "bad password", result.Steps[1].Error)
+	assert.Equal(t, "auth: bad password", result.Error)
+	result.Skip(StepRead, "skipped")
+	assert.Equal(t, 2, len(result.Steps))
+
+	result = NewResult("x/test", "").Fail(errors.New("server is required"))
+	assert.Equal(t, StepConfig, result.Steps[0].Name)
+	assert.Equal(t, "config: server is required", result.Error)
+}
+
+func TestTimeout(t *testing.T) {
+	assert.Equal(t, time.Second, Timeout(context.Background(), time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, 100*time.Millisecond, Timeout(ctx, 100*time.Millisecond))
+	assert.True(t, Timeout(ctx, time.Minute) <= 500*time.Millisecond)
+	assert.True(t, Timeout(ctx, 0) > 0)
+}
@@ -0,0 +1,420 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package framing 提供字节流分帧器
+// 串口、TCP 和 UDP 端点共用，按分隔符、固定长度、静默超时、STX/ETX（可带校验）或者长度字段把字节流拆分为帧，
+// 其他私有协议可以通过 Register 注册自定义的分帧方式
+package framing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// 分帧方式
+const (
+	// Delimiter 按分隔符分帧
+	Delimiter = "delimiter"
+	// Fixed 按固定长度分帧
+	Fixed = "fixed"
+	// Timeout 数据静默超过 timeout 时结束一帧
+	Timeout = "timeout"
+	// StxEtx STX 开始、ETX 结束，ETX 后可以带校验
+	StxEtx = "stxEtx"
+	// LengthField 帧头中的长度字段给出帧长度
+	LengthField = "lengthField"
+)
+
+// 校验方式，校验范围为 STX 之后到 ETX（包括 ETX）的字节
+const (
+	ChecksumNone = "none"
+	// ChecksumXor 异或校验（BCC），1 字节
+	ChecksumXor = "xor"
+	// ChecksumSum 累加和，1 字节
+	ChecksumSum = "sum"
+	// ChecksumLrc 累加和的补码，1 字节
+	ChecksumLrc = "lrc"
+	// ChecksumCrc16 Modbus CRC16，2 字节，低字节在前
+	ChecksumCrc16 = "crc16"
+)
+
+// DefaultMaxLength 默认的最大帧长度
+const DefaultMaxLength = 4096
+
+// ErrChecksum 校验错误
+var ErrChecksum = errors.New("frame checksum mismatch")
+
+// ErrFrameTooLong 超过最大帧长度
+var ErrFrameTooLong = errors.New("frame too long")
+
+// Config 分帧配置
+type Config struct {
+	// Framing 分帧方式：delimiter、fixed、timeout、stxEtx、lengthField 或者注册的分帧方式
+	Framing string `json:"framing" label:"Framing" desc:"Framing: delimiter, fixed, timeout, stxEtx, lengthField or a registered framing"`
+	// Delimiter 分隔符，支持转义字符，eg. \r\n、\x03
+	Delimiter string `json:"delimiter" label:"Delimiter" desc:"delimiter: frame delimiter, supports escapes such as \\r\\n or \\x03"`
+	// KeepDelimiter 帧保留分隔符
+	KeepDelimiter bool `json:"keepDelimiter" label:"Keep Delimiter" desc:"delimiter: keep the delimiter at the end of the frame"`
+	// Length 固定帧长度
+	Length int `json:"length" label:"Length" desc:"fixed: frame length in bytes"`
+	// Timeout 帧间静默时间，单位毫秒。timeout 分帧时结束一帧，其他分帧方式丢弃未完成的帧，0 不检查
+	Timeout int `json:"timeout" label:"Timeout" desc:"Silence in ms that ends a frame in timeout framing, other framings drop an incomplete frame after it. 0 disables"`
+	// Stx、Etx 开始和结束字节
+	Stx byte `json:"stx" label:"STX" desc:"stxEtx: start byte, defaults to 0x02"`
+	Etx byte `json:"etx" label:"ETX" desc:"stxEtx: end byte, defaults to 0x03"`
+	// Checksum ETX 后的校验：none、xor、sum、lrc、crc16
+	Checksum string `json:"checksum" label:"Checksum" desc:"stxEtx: checksum after ETX over the bytes after STX up to ETX: none, xor, sum, lrc or crc16"`
+	// LengthOffset 长度字段在帧中的偏移
+	LengthOffset int `json:"lengthOffset" label:"Length Offset" desc:"lengthField: offset of the length field in the frame"`
+	// LengthSize 长度字段的字节数：1、2、4
+	LengthSize int `json:"lengthSize" label:"Length Size" desc:"lengthField: size of the length field in bytes: 1, 2 or 4"`
+	// LittleEndian 长度字段低字节在前，默认高字节在前
+	LittleEndian bool `json:"littleEndian" label:"Little Endian" desc:"lengthField: the length field is little endian, defaults to big endian"`
+	// LengthAdjust 帧长度 = 偏移 + 长度字段字节数 + 长度字段的值 + 调整值，长度字段包含帧头或者不包含校验时使用
+	LengthAdjust int `json:"lengthAdjust" label:"Length Adjust" desc:"lengthField: frame length = offset + size + field value + adjust, eg. -4 when the field counts a 4 byte header"`
+	// MaxLength 最大帧长度，超过时丢弃
+	MaxLength int `json:"maxLength" label:"Max Length" desc:"Maximum frame length, longer frames are dropped"`
+}
+
+// Framer 把读取的字节流拆分为帧，每个连接使用自己的分帧器，除 Encode 外的方法不需要是并发安全的
+type Framer interface {
+	// Feed 追加读取的数据，每个完整的帧调用一次 emit；校验错误和超长的帧以错误回调
+	Feed(data []byte, emit func(frame []byte, err error))
+	// Idle 数据静默超过 timeout，返回可以结束的帧，没有返回 nil，同时丢弃未完成的帧
+	Idle() []byte
+	// Pending 是否有未完成的帧
+	Pending() bool
+	// Reset 丢弃未完成的帧
+	Reset()
+	// Encode 按分帧方式封装写入的数据，可能被多个协程同时调用
+	Encode(payload []byte) []byte
+}
+
+// Factory 根据配置创建分帧器，配置错误返回 error
+type Factory func(config Config) (Framer, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+)
+
+// Register 注册自定义的分帧方式，不能覆盖内置的分帧方式
+func Register(name string, factory Factory) error {
+	switch name {
+	case "", Delimiter, Fixed, Timeout, StxEtx, LengthField:
+		return fmt.Errorf("framing %q is reserved", name)
+	}
+	if factory == nil {
+		return errors.New("framing factory is nil")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+	return nil
+}
+
+// New 检查配置并创建分帧器
+func New(config Config) (Framer, error) {
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultMaxLength
+	}
+	mu.RLock()
+	factory, ok := factories[config.Framing]
+	mu.RUnlock()
+	if ok {
+		return factory(config)
+	}
+	f := &framer{config: config}
+	switch config.Framing {
+	case Delimiter:
+		if config.Delimiter == "" {
+			return nil, errors.New("frame delimiter is empty")
+		}
+		f.delimiter = []byte(unescape(config.Delimiter))
+	case Fixed:
+		if config.Length <= 0 || config.Length > config.MaxLength {
+			return nil, fmt.Errorf("invalid frame length: %d", config.Length)
+		}
+	case Timeout:
+		if config.Timeout <= 0 {
+			return nil, errors.New("timeout framing requires a timeout")
+		}
+	case StxEtx:
+		if checksumSize(config.Checksum) < 0 {
+			return nil, fmt.Errorf("unsupported frame checksum: %s", config.Checksum)
+		}
+	case LengthField:
+		switch config.LengthSize {
+		case 1, 2, 4:
+		default:
+			return nil, fmt.Errorf("invalid length field size: %d", config.LengthSize)
+		}
+		if config.LengthOffset < 0 {
+			return nil, fmt.Errorf("invalid length field offset: %d", config.LengthOffset)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported framing: %s", config.Framing)
+	}
+	return f, nil
+}
+
+// framer 内置的分帧方式
+type framer struct {
+	config    Config
+	delimiter []byte
+	buf       []byte
+	// started stxEtx 已经收到 STX
+	started bool
+	// dropping 超长的帧丢弃到下一个帧边界
+	dropping bool
+}
+
+func (f *framer) Pending() bool {
+	return len(f.buf) > 0 || f.started || f.dropping
+}
+
+func (f *framer) Reset() {
+	f.buf, f.started, f.dropping = f.buf[:0], false, false
+}
+
+func (f *framer) Feed(data []byte, emit func(frame []byte, err error)) {
+	for _, b := range data {
+		f.feed(b, emit)
+	}
+}
+
+func (f *framer) feed(b byte, emit func(frame []byte, err error)) {
+	switch f.config.Framing {
+	case Delimiter:
+		f.buf = append(f.buf, b)
+		if !bytes.HasSuffix(f.buf, f.delimiter) {
+			f.checkLength(emit)
+			return
+		}
+		frame := f.buf
+		if !f.config.KeepDelimiter {
+			frame = frame[:len(frame)-len(f.delimiter)]
+		}
+		if f.dropping {
+			f.Reset()
+			return
+		}
+		f.emit(frame, emit)
+	case Fixed:
+		f.buf = append(f.buf, b)
+		if len(f.buf) == f.config.Length {
+			f.emit(f.buf, emit)
+		}
+	case Timeout:
+		f.buf = append(f.buf, b)
+		f.checkLength(emit)
+	case StxEtx:
+		if !f.started {
+			// STX 之前的字节丢弃
+			if b == f.stx() {
+				f.started, f.dropping = true, false
+			}
+			return
+		}
+		f.buf = append(f.buf, b)
+		body := bytes.IndexByte(f.buf, f.etx())
+		if body < 0 {
+			f.checkLength(emit)
+			return
+		}
+		size := checksumSize(f.config.Checksum)
+		if len(f.buf) < body+1+size {
+			return
+		}
+		if f.dropping {
+			f.Reset()
+			return
+		}
+		payload := f.buf[:body]
+		if size > 0 && !bytes.Equal(checksum(f.config.Checksum, f.buf[:body+1]), f.buf[body+1:]) {
+			f.Reset()
+			emit(nil, ErrChecksum)
+			return
+		}
+		f.emit(payload, emit)
+	case LengthField:
+		f.buf = append(f.buf, b)
+		header := f.config.LengthOffset + f.config.LengthSize
+		if len(f.buf) < header {
+			return
+		}
+		size := header + f.lengthOf(f.buf[f.config.LengthOffset:header]) + f.config.LengthAdjust
+		if size < header || size > f.config.MaxLength {
+			// 长度字段错误时无法找到下一个帧边界，丢弃已经读取的数据重新开始
+			f.Reset()
+			emit(nil, ErrFrameTooLong)
+			return
+		}
+		if len(f.buf) == size {
+			f.emit(f.buf, emit)
+		}
+	}
+}
+
+// checkLength 超过最大长度时丢弃已经读取的数据，直到下一个帧边界
+func (f *framer) checkLength(emit func(frame []byte, err error)) {
+	if len(f.buf) <= f.config.MaxLength {
+		return
+	}
+	if !f.dropping {
+		emit(nil, ErrFrameTooLong)
+	}
+	f.dropping = true
+	if f.config.Framing == Delimiter {
+		// 保留可能是分隔符开头的字节
+		f.buf = append(f.buf[:0], f.buf[len(f.buf)-len(f.delimiter)+1:]...)
+	} else {
+		f.buf = f.buf[:0]
+	}
+}
+
+// Idle timeout 分帧时返回缓存的帧，其他分帧方式丢弃未完成的帧
+func (f *framer) Idle() []byte {
+	var frame []byte
+	if f.config.Framing == Timeout && len(f.buf) > 0 && !f.dropping {
+		frame = append([]byte(nil), f.buf...)
+	}
+	f.Reset()
+	return frame
+}
+
+// Encode 分隔符分帧时追加分隔符，STX/ETX 分帧时加上 STX、ETX 和校验，长度字段偏移为 0 时加上长度字段
+func (f *framer) Encode(payload []byte) []byte {
+	switch f.config.Framing {
+	case Delimiter:
+		return append(append([]byte(nil), payload...), f.delimiter...)
+	case StxEtx:
+		frame := append([]byte{f.stx()}, payload...)
+		frame = append(frame, f.etx())
+		if checksumSize(f.config.Checksum) > 0 {
+			frame = append(frame, checksum(f.config.Checksum, frame[1:])...)
+		}
+		return frame
+	case LengthField:
+		if f.config.LengthOffset != 0 {
+			return payload
+		}
+		field := make([]byte, 4)
+		n := uint32(len(payload) - f.config.LengthAdjust)
+		if f.config.LittleEndian {
+			binary.LittleEndian.PutUint32(field, n)
+			field = field[:f.config.LengthSize]
+		} else {
+			binary.BigEndian.PutUint32(field, n)
+			field = field[4-f.config.LengthSize:]
+		}
+		return append(field, payload...)
+	default:
+		return payload
+	}
+}
+
+// emit 复制帧并重置缓存
+func (f *framer) emit(frame []byte, emit func(frame []byte, err error)) {
+	frame = append([]byte(nil), frame...)
+	f.Reset()
+	emit(frame, nil)
+}
+
+func (f *framer) stx() byte {
+	if f.config.Stx == 0 {
+		return 0x02
+	}
+	return f.config.Stx
+}
+
+func (f *framer) etx() byte {
+	if f.config.Etx == 0 {
+		return 0x03
+	}
+	return f.config.Etx
+}
+
+// lengthOf 解析长度字段
+func (f *framer) lengthOf(field []byte) int {
+	var n uint32
+	for i := range field {
+		b := field[i]
+		if f.config.LittleEndian {
+			b = field[len(field)-1-i]
+		}
+		n = n<<8 | uint32(b)
+	}
+	return int(n)
+}
+
+// checksumSize 校验的字节数，不支持的校验返回 -1
+func checksumSize(name string) int {
+	switch name {
+	case "", ChecksumNone:
+		return 0
+	case ChecksumXor, ChecksumSum, ChecksumLrc:
+		return 1
+	case ChecksumCrc16:
+		return 2
+	default:
+		return -1
+	}
+}
+
+// checksum 计算校验
+func checksum(name string, data []byte) []byte {
+	var v byte
+	switch name {
+	case ChecksumXor:
+		for _, b := range data {
+			v ^= b
+		}
+	case ChecksumSum, ChecksumLrc:
+		for _, b := range data {
+			v += b
+		}
+		if name == ChecksumLrc {
+			v = -v
+		}
+	case ChecksumCrc16:
+		crc := uint16(0xffff)
+		for _, b := range data {
+			crc ^= uint16(b)
+			for i := 0; i < 8; i++ {
+				if crc&1 != 0 {
+					crc = crc>>1 ^ 0xa001
+				} else {
+					crc >>= 1
+				}
+			}
+		}
+		return []byte{byte(crc), byte(crc >> 8)}
+	}
+	return []byte{v}
+}
+
+// unescape 解析分隔符中的转义字符，无法解析时按原样使用
+func unescape(s string) string {
+	if v, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return v
+	}
+	return s
+}
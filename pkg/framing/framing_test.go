@@ -0,0 +1,147 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package framing
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// feed 分帧并收集帧和错误
+func feed(f Framer, data string) ([]string, []error) {
+	var frames []string
+	var errs []error
+	f.Feed([]byte(data), func(frame []byte, err error) {
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		frames = append(frames, string(frame))
+	})
+	return frames, errs
+}
+
+func TestFramer(t *testing.T) {
+	for _, config := range []Config{
+		{Framing: "line"},
+		{Framing: Delimiter},
+		{Framing: Fixed},
+		{Framing: Timeout},
+		{Framing: StxEtx, Checksum: "md5"},
+		{Framing: LengthField, LengthSize: 3},
+	} {
+		_, err := New(config)
+		assert.NotNil(t, err)
+	}
+
+	f, err := New(Config{Framing: Delimiter, Delimiter: "\\r\\n"})
+	assert.Nil(t, err)
+	frames, _ := feed(f, "ST,GS,+0012.5kg\r\nST,GS,")
+	assert.Equal(t, []string{"ST,GS,+0012.5kg"}, frames)
+	assert.True(t, f.Pending())
+	frames, _ = feed(f, "+0013.0kg\r")
+	assert.Equal(t, 0, len(frames))
+	frames, _ = feed(f, "\n\r\n")
+	assert.Equal(t, []string{"ST,GS,+0013.0kg", ""}, frames)
+	assert.Equal(t, "OK\r\n", string(f.Encode([]byte("OK"))))
+
+	// 超长的帧丢弃到下一个分隔符
+	f, _ = New(Config{Framing: Delimiter, Delimiter: "\n", KeepDelimiter: true, MaxLength: 4})
+	frames, errs := feed(f, "123456789\nab\n")
+	assert.Equal(t, []string{"ab\n"}, frames)
+	assert.Equal(t, []error{ErrFrameTooLong}, errs)
+
+	f, _ = New(Config{Framing: Fixed, Length: 3})
+	frames, _ = feed(f, "abcdefg")
+	assert.Equal(t, []string{"abc", "def"}, frames)
+	assert.Nil(t, f.Idle())
+	assert.False(t, f.Pending())
+
+	f, _ = New(Config{Framing: Timeout, Timeout: 50})
+	frames, _ = feed(f, "0123")
+	assert.Equal(t, 0, len(frames))
+	assert.Equal(t, "0123", string(f.Idle()))
+	assert.Nil(t, f.Idle())
+
+	f, _ = New(Config{Framing: StxEtx, Checksum: ChecksumXor})
+	frame := f.Encode([]byte("A1"))
+	// 0x41 ^ 0x31 ^ 0x03
+	assert.Equal(t, []byte{0x02, 'A', '1', 0x03, 0x73}, frame)
+	frames, errs = feed(f, "noise"+string(frame)+"\x02A1\x03\x00"+string(frame))
+	assert.Equal(t, []string{"A1", "A1"}, frames)
+	assert.Equal(t, []error{ErrChecksum}, errs)
+
+	f, _ = New(Config{Framing: StxEtx, Stx: '[', Etx: ']', Checksum: ChecksumCrc16})
+	frame = f.Encode([]byte{0x01, 0x03})
+	assert.Equal(t, 6, len(frame))
+	frames, errs = feed(f, string(frame[:4]))
+	assert.Equal(t, 0, len(frames)+len(errs))
+	frames, _ = feed(f, string(frame[4:]))
+	assert.Equal(t, []string{"\x01\x03"}, frames)
+
+	assert.Equal(t, []byte{0x37}, checksum(ChecksumSum, []byte{0x10, 0x27}))
+	assert.Equal(t, []byte{0xc9}, checksum(ChecksumLrc, []byte{0x10, 0x27}))
+	// Modbus 读保持寄存器请求 01 03 00 00 00 01 的 CRC 为 84 0A
+	assert.Equal(t, []byte{0x84, 0x0a}, checksum(ChecksumCrc16, []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01}))
+
+	// 2 字节的设备地址后是 2 字节大端长度字段，长度包含 2 字节的 CRC
+	f, err = New(Config{Framing: LengthField, LengthOffset: 2, LengthSize: 2, MaxLength: 16})
+	assert.Nil(t, err)
+	frames, errs = feed(f, "\x00\x01\x00\x03AB")
+	assert.Equal(t, 0, len(frames)+len(errs))
+	frames, _ = feed(f, "C\x00\x02\x00\x00")
+	assert.Equal(t, []string{"\x00\x01\x00\x03ABC", "\x00\x02\x00\x00"}, frames)
+	frames, errs = feed(f, "\x00\x01\x01\x00")
+	assert.Equal(t, 0, len(frames))
+	assert.Equal(t, []error{ErrFrameTooLong}, errs)
+	assert.False(t, f.Pending())
+	assert.Equal(t, "AB", string(f.Encode([]byte("AB"))))
+
+	// 长度字段包含 1 字节的长度本身
+	f, _ = New(Config{Framing: LengthField, LengthSize: 1, LengthAdjust: -1})
+	assert.Equal(t, "\x03AB", string(f.Encode([]byte("AB"))))
+	frames, _ = feed(f, "\x03AB\x01")
+	assert.Equal(t, []string{"\x03AB", "\x01"}, frames)
+	f, _ = New(Config{Framing: LengthField, LengthSize: 2, LittleEndian: true})
+	assert.Equal(t, "\x02\x00AB", string(f.Encode([]byte("AB"))))
+	frames, _ = feed(f, "\x02\x00AB")
+	assert.Equal(t, []string{"\x02\x00AB"}, frames)
+}
+
+// byteFramer 测试注册的分帧方式，每个字节一帧
+type byteFramer struct{}
+
+func (byteFramer) Feed(data []byte, emit func(frame []byte, err error)) {
+	for _, b := range data {
+		emit([]byte{b}, nil)
+	}
+}
+func (byteFramer) Idle() []byte                 { return nil }
+func (byteFramer) Pending() bool                { return false }
+func (byteFramer) Reset()                       {}
+func (byteFramer) Encode(payload []byte) []byte { return payload }
+
+func TestRegister(t *testing.T) {
+	assert.NotNil(t, Register(Delimiter, func(config Config) (Framer, error) { return byteFramer{}, nil }))
+	assert.NotNil(t, Register("byte", nil))
+	assert.Nil(t, Register("byte", func(config Config) (Framer, error) { return byteFramer{}, nil }))
+	f, err := New(Config{Framing: "byte"})
+	assert.Nil(t, err)
+	frames, _ := feed(f, "ab")
+	assert.Equal(t, []string{"a", "b"}, frames)
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 连接状态
+const (
+	StateConnected    = "connected"
+	StateDisconnected = "disconnected"
+	StateError        = "error"
+)
+
+// 生命周期事件类型，同时作为 RuleMsg 的消息类型
+const (
+	EventConnect    = "CONNECT"
+	EventDisconnect = "DISCONNECT"
+	EventReconnect  = "RECONNECT"
+)
+
+// Status 协议客户端的健康状态
+type Status struct {
+	// Component 组件，例如：s7、modbus、opcua
+	Component string `json:"component"`
+	// Target 连接目标，一般为服务器地址
+	Target string `json:"target"`
+	// State 连接状态：connected, disconnected, error
+	State string `json:"state"`
+	// Since 进入当前状态的时间
+	Since time.Time `json:"since"`
+	// LastSuccess 最后一次请求成功的时间
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+	// LastError 最后一次错误
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorTime 最后一次错误的时间
+	LastErrorTime time.Time `json:"lastErrorTime,omitempty"`
+	// RTT 最后一次成功请求的耗时，单位毫秒
+	RTT float64 `json:"rtt"`
+	// Requests 请求次数
+	Requests uint64 `json:"requests"`
+	// Errors 失败的请求次数
+	Errors uint64 `json:"errors"`
+	// Reconnects 重连次数
+	Reconnects uint64 `json:"reconnects"`
+	// connected 是否曾经连接成功，用于区分 CONNECT 和 RECONNECT
+	connected bool
+}
+
+// Event 连接生命周期事件
+type Event struct {
+	// Type 事件类型：CONNECT, DISCONNECT, RECONNECT
+	Type   string `json:"type"`
+	Status Status `json:"status"`
+}
+
+// Monitor 健康状态监控，协议客户端上报连接状态和请求结果，
+// 状态变化时通知订阅者：第一次连接成功为 CONNECT，连接断开为 DISCONNECT，断开后重新连接成功为 RECONNECT。并发安全
+type Monitor struct {
+	mu        sync.RWMutex
+	statuses  map[string]*Status
+	listeners map[int]func(Event)
+	nextId    int
+}
+
+// NewMonitor 创建监控
+func NewMonitor() *Monitor {
+	return &Monitor{statuses: make(map[string]*Status), listeners: make(map[int]func(Event))}
+}
+
+// Connected 上报连接成功
+func (m *Monitor) Connected(component, target string) {
+	m.update(component, target, func(s *Status, now time.Time) string {
+		return s.connect(now)
+	})
+}
+
+// Disconnected 上报连接断开，err 不为 nil 时状态为 error
+func (m *Monitor) Disconnected(component, target string, err error) {
+	m.update(component, target, func(s *Status, now time.Time) string {
+		event := ""
+		if s.State == StateConnected {
+			event = EventDisconnect
+		}
+		state := StateDisconnected
+		if err != nil {
+			state = StateError
+			s.LastError, s.LastErrorTime = err.Error(), now
+		}
+		if s.State != state {
+			s.State, s.Since = state, now
+		}
+		return event
+	})
+}
+
+// Report 上报一次请求的结果，err 为 nil 表示成功，成功的请求同时表示连接正常
+func (m *Monitor) Report(component, target string, rtt time.Duration, err error) {
+	m.update(component, target, func(s *Status, now time.Time) string {
+		s.Requests++
+		if err != nil {
+			s.Errors++
+			s.LastError, s.LastErrorTime = err.Error(), now
+			return ""
+		}
+		s.LastSuccess = now
+		s.RTT = float64(rtt) / float64(time.Millisecond)
+		return s.connect(now)
+	})
+}
+
+// Remove 组件关闭连接后移除状态，不产生事件
+func (m *Monitor) Remove(component, target string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.statuses, key(component, target))
+}
+
+// Get 获取状态
+func (m *Monitor) Get(component, target string) (Status, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.statuses[key(component, target)]
+	if !ok {
+		return Status{}, false
+	}
+	return *s, true
+}
+
+// List 获取组件的所有状态，component 为空返回全部，按组件和目标排序
+func (m *Monitor) List(component string) []Status {
+	m.mu.RLock()
+	result := make([]Status, 0, len(m.statuses))
+	for _, s := range m.statuses {
+		if component == "" || s.Component == component {
+			result = append(result, *s)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Component != result[j].Component {
+			return result[i].Component < result[j].Component
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result
+}
+
+// Subscribe 订阅生命周期事件，fn 在上报的协程中同步调用，不能阻塞。返回取消订阅的函数
+func (m *Monitor) Subscribe(fn func(event Event)) (cancel func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextId
+	m.nextId++
+	m.listeners[id] = fn
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.listeners, id)
+	}
+}
+
+// update 修改状态，fn 返回需要通知的事件类型，为空不通知
+func (m *Monitor) update(component, target string, fn func(s *Status, now time.Time) string) {
+	now := time.Now()
+	m.mu.Lock()
+	k := key(component, target)
+	s, ok := m.statuses[k]
+	if !ok {
+		s = &Status{Component: component, Target: target, State: StateDisconnected, Since: now}
+		m.statuses[k] = s
+	}
+	event := fn(s, now)
+	var listeners []func(Event)
+	if event != "" {
+		listeners = make([]func(Event), 0, len(m.listeners))
+		for _, l := range m.listeners {
+			listeners = append(listeners, l)
+		}
+	}
+	status := *s
+	m.mu.Unlock()
+	for _, l := range listeners {
+		l(Event{Type: event, Status: status})
+	}
+}
+
+// connect 切换到连接状态，返回事件类型
+func (s *Status) connect(now time.Time) string {
+	if s.State == StateConnected {
+		return ""
+	}
+	s.State, s.Since = StateConnected, now
+	if s.connected {
+		s.Reconnects++
+		return EventReconnect
+	}
+	s.connected = true
+	return EventConnect
+}
+
+func key(component, target string) string {
+	return component + "\x00" + target
+}
+
+var defaultMonitor = NewMonitor()
+
+// Default 全局监控，组件上报到全局监控
+func Default() *Monitor {
+	return defaultMonitor
+}
+
+// Connected 向全局监控上报连接成功，见 Monitor.Connected
+func Connected(component, target string) {
+	defaultMonitor.Connected(component, target)
+}
+
+// Disconnected 向全局监控上报连接断开，见 Monitor.Disconnected
+func Disconnected(component, target string, err error) {
+	defaultMonitor.Disconnected(component, target, err)
+}
+
+// Report 向全局监控上报请求结果，见 Monitor.Report
+func Report(component, target string, rtt time.Duration, err error) {
+	defaultMonitor.Report(component, target, rtt, err)
+}
+
+// Remove 从全局监控移除状态，见 Monitor.Remove
+func Remove(component, target string) {
+	defaultMonitor.Remove(component, target)
+}
+
+// List 获取全局监控的状态，见 Monitor.List
+func List(component string) []Status {
+	return defaultMonitor.List(component)
+}
+
+// Subscribe 订阅全局监控的生命周期事件，见 Monitor.Subscribe
+func Subscribe(fn func(event Event)) (cancel func()) {
+	return defaultMonitor.Subscribe(fn)
+}
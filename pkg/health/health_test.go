@@ -0,0 +1,72 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMonitor(t *testing.T) {
+	m := NewMonitor()
+	var events []Event
+	cancel := m.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+
+	m.Disconnected("s7", "127.0.0.1:102", errors.New("dial timeout"))
+	s, ok := m.Get("s7", "127.0.0.1:102")
+	assert.True(t, ok)
+	assert.Equal(t, StateError, s.State)
+	assert.Equal(t, "dial timeout", s.LastError)
+	assert.Equal(t, 0, len(events))
+
+	m.Connected("s7", "127.0.0.1:102")
+	m.Report("s7", "127.0.0.1:102", 5*time.Millisecond, nil)
+	m.Report("s7", "127.0.0.1:102", time.Millisecond, errors.New("bad address"))
+	s, _ = m.Get("s7", "127.0.0.1:102")
+	assert.Equal(t, StateConnected, s.State)
+	assert.Equal(t, 5.0, s.RTT)
+	assert.Equal(t, uint64(2), s.Requests)
+	assert.Equal(t, uint64(1), s.Errors)
+	assert.False(t, s.LastSuccess.IsZero())
+
+	m.Disconnected("s7", "127.0.0.1:102", nil)
+	m.Report("s7", "127.0.0.1:102", time.Millisecond, nil)
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, EventConnect, events[0].Type)
+	assert.Equal(t, EventDisconnect, events[1].Type)
+	assert.Equal(t, StateDisconnected, events[1].Status.State)
+	assert.Equal(t, EventReconnect, events[2].Type)
+	assert.Equal(t, uint64(1), events[2].Status.Reconnects)
+
+	m.Connected("modbus", "tcp://127.0.0.1:502")
+	list := m.List("")
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, "modbus", list[0].Component)
+	assert.Equal(t, 1, len(m.List("s7")))
+
+	cancel()
+	m.Remove("s7", "127.0.0.1:102")
+	_, ok = m.Get("s7", "127.0.0.1:102")
+	assert.False(t, ok)
+	m.Disconnected("modbus", "tcp://127.0.0.1:502", nil)
+	assert.Equal(t, 4, len(events))
+}
@@ -0,0 +1,323 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lpp 提供 Cayenne LPP（Low Power Payload）编解码，支持标准数据类型和 ElectronicCats 扩展类型
+package lpp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type 数据类型
+type Type struct {
+	// Code 类型编号
+	Code byte
+	// Name 名称，eg. temperature
+	Name string
+	// Size 每个分量的字节数
+	Size int
+	// Fields 多分量类型的分量名称，单分量为空
+	Fields []string
+	// Scales 各分量分辨率的倒数，值=原始值/scale，单分量类型只有一个
+	Scales []float64
+	// Signed 是否有符号
+	Signed bool
+}
+
+// Len 数据的字节数
+func (t Type) Len() int {
+	if len(t.Fields) == 0 {
+		return t.Size
+	}
+	return t.Size * len(t.Fields)
+}
+
+// scale 第 i 个分量分辨率的倒数
+func (t Type) scale(i int) float64 {
+	if i < len(t.Scales) {
+		return t.Scales[i]
+	}
+	return t.Scales[0]
+}
+
+var xyz = []string{"x", "y", "z"}
+
+// types 标准数据类型（IPSO 编号-3200）和扩展类型
+var types = []Type{
+	{Code: 0, Name: "digital_in", Size: 1, Scales: []float64{1}},
+	{Code: 1, Name: "digital_out", Size: 1, Scales: []float64{1}},
+	{Code: 2, Name: "analog_in", Size: 2, Scales: []float64{100}, Signed: true},
+	{Code: 3, Name: "analog_out", Size: 2, Scales: []float64{100}, Signed: true},
+	{Code: 100, Name: "generic_sensor", Size: 4, Scales: []float64{1}},
+	{Code: 101, Name: "luminosity", Size: 2, Scales: []float64{1}},
+	{Code: 102, Name: "presence", Size: 1, Scales: []float64{1}},
+	{Code: 103, Name: "temperature", Size: 2, Scales: []float64{10}, Signed: true},
+	{Code: 104, Name: "relative_humidity", Size: 1, Scales: []float64{2}},
+	{Code: 113, Name: "accelerometer", Size: 2, Fields: xyz, Scales: []float64{1000}, Signed: true},
+	{Code: 115, Name: "barometric_pressure", Size: 2, Scales: []float64{10}},
+	{Code: 116, Name: "voltage", Size: 2, Scales: []float64{100}},
+	{Code: 117, Name: "current", Size: 2, Scales: []float64{1000}},
+	{Code: 118, Name: "frequency", Size: 4, Scales: []float64{1}},
+	{Code: 120, Name: "percentage", Size: 1, Scales: []float64{1}},
+	{Code: 121, Name: "altitude", Size: 2, Scales: []float64{1}, Signed: true},
+	{Code: 125, Name: "concentration", Size: 2, Scales: []float64{1}},
+	{Code: 128, Name: "power", Size: 2, Scales: []float64{1}},
+	{Code: 130, Name: "distance", Size: 4, Scales: []float64{1000}},
+	{Code: 131, Name: "energy", Size: 4, Scales: []float64{1000}},
+	{Code: 132, Name: "direction", Size: 2, Scales: []float64{1}},
+	{Code: 133, Name: "unixtime", Size: 4, Scales: []float64{1}},
+	{Code: 134, Name: "gyrometer", Size: 2, Fields: xyz, Scales: []float64{100}, Signed: true},
+	{Code: 135, Name: "colour", Size: 1, Fields: []string{"r", "g", "b"}, Scales: []float64{1}},
+	// 纬度、经度 0.0001°，海拔 0.01m
+	{Code: 136, Name: "gps", Size: 3, Fields: []string{"latitude", "longitude", "altitude"}, Scales: []float64{10000, 10000, 100}, Signed: true},
+	{Code: 142, Name: "switch", Size: 1, Scales: []float64{1}},
+}
+
+var (
+	byCode = map[byte]Type{}
+	byName = map[string]Type{}
+)
+
+func init() {
+	for _, t := range types {
+		byCode[t.Code] = t
+		byName[t.Name] = t
+	}
+}
+
+// TypeOf 按编号查找数据类型
+func TypeOf(code byte) (Type, bool) {
+	t, ok := byCode[code]
+	return t, ok
+}
+
+// TypeByName 按名称查找数据类型
+func TypeByName(name string) (Type, bool) {
+	t, ok := byName[name]
+	return t, ok
+}
+
+// Item 一个数据通道
+type Item struct {
+	// Channel 通道
+	Channel uint8 `json:"channel"`
+	// Type 类型名称，编码时也可以是类型编号
+	Type string `json:"type"`
+	// Value 值，分辨率为 1 时为整数，多分量类型为对象，eg. {"x": 0.1, "y": 0, "z": 1}
+	Value interface{} `json:"value"`
+}
+
+// Key 通道的 key：类型名称_通道，eg. temperature_3
+func (i Item) Key() string {
+	return i.Type + "_" + strconv.Itoa(int(i.Channel))
+}
+
+// UnmarshalJSON 类型可以是名称或者编号，eg. "temperature" 或者 103
+func (i *Item) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Channel uint8           `json:"channel"`
+		Type    json.RawMessage `json:"type"`
+		Value   interface{}     `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	i.Channel, i.Value, i.Type = raw.Channel, raw.Value, ""
+	if len(raw.Type) > 0 && raw.Type[0] == '"' {
+		return json.Unmarshal(raw.Type, &i.Type)
+	}
+	var code uint8
+	if err := json.Unmarshal(raw.Type, &code); err != nil {
+		return fmt.Errorf("cayenne lpp: invalid type %s", raw.Type)
+	}
+	i.Type = strconv.Itoa(int(code))
+	return nil
+}
+
+// Decode 解码 Cayenne LPP 负荷
+func Decode(b []byte) ([]Item, error) {
+	var items []Item
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errors.New("cayenne lpp: truncated data channel")
+		}
+		channel, code := b[0], b[1]
+		t, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("cayenne lpp: unsupported data type %d on channel %d", code, channel)
+		}
+		if len(b) < 2+t.Len() {
+			return nil, fmt.Errorf("cayenne lpp: truncated %s on channel %d", t.Name, channel)
+		}
+		data := b[2 : 2+t.Len()]
+		item := Item{Channel: channel, Type: t.Name}
+		if len(t.Fields) == 0 {
+			item.Value = value(data, t.Signed, t.scale(0))
+		} else {
+			object := make(map[string]interface{}, len(t.Fields))
+			for i, field := range t.Fields {
+				object[field] = value(data[i*t.Size:(i+1)*t.Size], t.Signed, t.scale(i))
+			}
+			item.Value = object
+		}
+		items = append(items, item)
+		b = b[2+t.Len():]
+	}
+	return items, nil
+}
+
+// ToMap 转换为 类型名称_通道->值，eg. {"temperature_3": 27.2, "gps_1": {"latitude": 42.3519, "longitude": -87.9094, "altitude": 10}}
+func ToMap(items []Item) map[string]interface{} {
+	values := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		values[item.Key()] = item.Value
+	}
+	return values
+}
+
+// FromMap 把 类型名称_通道->值 转换为数据通道，按通道和类型排序
+func FromMap(values map[string]interface{}) ([]Item, error) {
+	items := make([]Item, 0, len(values))
+	for key, v := range values {
+		i := strings.LastIndexByte(key, '_')
+		if i <= 0 {
+			return nil, fmt.Errorf("cayenne lpp: invalid key %s, expected type_channel", key)
+		}
+		channel, err := strconv.ParseUint(key[i+1:], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("cayenne lpp: invalid channel in key %s", key)
+		}
+		items = append(items, Item{Channel: uint8(channel), Type: key[:i], Value: v})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Channel != items[j].Channel {
+			return items[i].Channel < items[j].Channel
+		}
+		return items[i].Type < items[j].Type
+	})
+	return items, nil
+}
+
+// Encode 编码为 Cayenne LPP 负荷，值按分辨率四舍五入并检查范围
+func Encode(items []Item) ([]byte, error) {
+	var b []byte
+	for _, item := range items {
+		t, ok := byName[item.Type]
+		if !ok {
+			code, err := strconv.ParseUint(item.Type, 10, 8)
+			if t, ok = byCode[byte(code)]; err != nil || !ok {
+				return nil, fmt.Errorf("cayenne lpp: unsupported data type %s on channel %d", item.Type, item.Channel)
+			}
+		}
+		b = append(b, item.Channel, t.Code)
+		if len(t.Fields) == 0 {
+			raw, err := rawValue(t, 0, item.Value)
+			if err != nil {
+				return nil, fmt.Errorf("cayenne lpp: %s on channel %d: %w", t.Name, item.Channel, err)
+			}
+			b = appendRaw(b, raw, t.Size)
+			continue
+		}
+		object, ok := item.Value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cayenne lpp: %s on channel %d must be an object with %s", t.Name, item.Channel, strings.Join(t.Fields, ", "))
+		}
+		for i, field := range t.Fields {
+			v, ok := object[field]
+			if !ok {
+				return nil, fmt.Errorf("cayenne lpp: %s on channel %d: missing %s", t.Name, item.Channel, field)
+			}
+			raw, err := rawValue(t, i, v)
+			if err != nil {
+				return nil, fmt.Errorf("cayenne lpp: %s.%s on channel %d: %w", t.Name, field, item.Channel, err)
+			}
+			b = appendRaw(b, raw, t.Size)
+		}
+	}
+	return b, nil
+}
+
+// value 大端整数按分辨率换算，分辨率为 1 时返回整数
+func value(b []byte, signed bool, scale float64) interface{} {
+	var v int64
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	if bits := uint(len(b) * 8); signed && v&(1<<(bits-1)) != 0 {
+		v -= 1 << bits
+	}
+	if scale == 1 {
+		return v
+	}
+	return float64(v) / scale
+}
+
+// rawValue 第 i 个分量的值换算为原始整数并检查范围
+func rawValue(t Type, i int, v interface{}) (int64, error) {
+	var f float64
+	switch n := v.(type) {
+	case bool:
+		if n {
+			f = 1
+		}
+	case float64:
+		f = n
+	case float32:
+		f = float64(n)
+	case int:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	case uint8:
+		f = float64(n)
+	case json.Number:
+		var err error
+		if f, err = n.Float64(); err != nil {
+			return 0, err
+		}
+	case string:
+		var err error
+		if f, err = strconv.ParseFloat(n, 64); err != nil {
+			return 0, fmt.Errorf("invalid number %s", n)
+		}
+	default:
+		return 0, fmt.Errorf("invalid value %v", v)
+	}
+	raw := math.Round(f * t.scale(i))
+	bits := uint(t.Size * 8)
+	min, max := 0.0, float64(uint64(1)<<bits-1)
+	if t.Signed {
+		min, max = -float64(int64(1)<<(bits-1)), float64(int64(1)<<(bits-1)-1)
+	}
+	if math.IsNaN(raw) || raw < min || raw > max {
+		return 0, fmt.Errorf("value %v out of range", v)
+	}
+	return int64(raw), nil
+}
+
+// appendRaw 按大端追加 size 个字节
+func appendRaw(b []byte, raw int64, size int) []byte {
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(raw>>(8*uint(i))))
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lpp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		data   string
+		values map[string]interface{}
+	}{
+		{"03670110056700ff", map[string]interface{}{"temperature_3": 27.2, "temperature_5": 25.5}},
+		{"018806765ff2960a0003e8", map[string]interface{}{"gps_1": map[string]interface{}{"latitude": 42.3519, "longitude": -87.9094, "altitude": 10.0}}},
+		{"0174018b0275000a037600000d05", map[string]interface{}{"voltage_1": 3.95, "current_2": 0.01, "frequency_3": int64(3333)}},
+		{"0478050579fba0067d01f4078500102030", map[string]interface{}{
+			"percentage_4": int64(5), "altitude_5": int64(-1120), "concentration_6": int64(500), "unixtime_7": int64(1056816),
+		}},
+		{"088700ff00", map[string]interface{}{"colour_8": map[string]interface{}{"r": int64(0), "g": int64(255), "b": int64(0)}}},
+		{"098e01", map[string]interface{}{"switch_9": int64(1)}},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.data)
+		items, err := Decode(data)
+		assert.Nil(t, err, tt.data)
+		assert.Equal(t, tt.values, ToMap(items), tt.data)
+	}
+	for _, s := range []string{"03", "03ff0000", "0182000000", "098e"} {
+		data, _ := hex.DecodeString(s)
+		_, err := Decode(data)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	items, err := FromMap(map[string]interface{}{
+		"temperature_5": 25.5,
+		"temperature_3": 27.2,
+		"gps_1":         map[string]interface{}{"latitude": 42.3519, "longitude": -87.9094, "altitude": 10},
+		"digital_out_2": true,
+	})
+	assert.Nil(t, err)
+	data, err := Encode(items)
+	assert.Nil(t, err)
+	assert.Equal(t, "018806765ff2960a0003e802010103670110056700ff", hex.EncodeToString(data))
+	decoded, err := Decode(data)
+	assert.Nil(t, err)
+	assert.Equal(t, items[0].Value.(map[string]interface{})["latitude"], decoded[0].Value.(map[string]interface{})["latitude"])
+
+	// 类型编号
+	data, err = Encode([]Item{{Channel: 1, Type: "116", Value: "3.3"}, {Channel: 2, Type: "distance", Value: 1.5}})
+	assert.Nil(t, err)
+	assert.Equal(t, "0174014a0282000005dc", hex.EncodeToString(data))
+
+	var list []Item
+	assert.Nil(t, json.Unmarshal([]byte(`[{"channel":1,"type":116,"value":3.3},{"channel":2,"type":"distance","value":1.5}]`), &list))
+	data, err = Encode(list)
+	assert.Nil(t, err)
+	assert.Equal(t, "0174014a0282000005dc", hex.EncodeToString(data))
+	assert.NotNil(t, json.Unmarshal([]byte(`[{"channel":1,"type":true}]`), &list))
+
+	for _, item := range []Item{
+		{Channel: 1, Type: "unknown", Value: 1},
+		{Channel: 1, Type: "temperature", Value: 4000},
+		{Channel: 1, Type: "luminosity", Value: -1},
+		{Channel: 1, Type: "gps", Value: map[string]interface{}{"latitude": 1}},
+		{Channel: 1, Type: "accelerometer", Value: 1},
+		{Channel: 1, Type: "presence", Value: []int{1}},
+	} {
+		_, err = Encode([]Item{item})
+		assert.NotNil(t, err, item.Type)
+	}
+	_, err = FromMap(map[string]interface{}{"temperature": 1})
+	assert.NotNil(t, err)
+	_, err = FromMap(map[string]interface{}{"temperature_300": 1})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ArrayModeArray 数组和矩阵按 JSON 数组（矩阵为嵌套数组）输出，默认方式
+	ArrayModeArray = "array"
+	// ArrayModeExplode 数组和矩阵展开为每个下标一条数据，名称追加下标，eg. Tag[0]、Tag[1]、Matrix[1][2]
+	ArrayModeExplode = "explode"
+)
+
+// ArrayOptions 数组和矩阵类型点位的输出选项，标量点位不受影响
+type ArrayOptions struct {
+	// Mode 输出方式：array（默认）、explode
+	Mode string `json:"mode" label:"Array Mode" desc:"How to output array and matrix values: array (JSON array, default), explode (one value per index, e.g. Tag[0], Tag[1])"`
+	// IndexRange 只保留指定的下标范围，OPC UA NumericRange 格式，eg. 2:5、3，矩阵每一维使用逗号分隔，eg. 0:1,2:3
+	IndexRange string `json:"indexRange" label:"Index Range" desc:"Keep only the given indexes, OPC UA NumericRange format, e.g. 2:5, 3, or 0:1,2:3 for matrices"`
+}
+
+// Validate 校验输出方式和下标范围
+func (o ArrayOptions) Validate() error {
+	switch strings.ToLower(o.Mode) {
+	case "", ArrayModeArray, ArrayModeExplode:
+	default:
+		return fmt.Errorf("unsupported array mode: %s", o.Mode)
+	}
+	_, err := ParseIndexRange(o.IndexRange)
+	return err
+}
+
+// IndexRange 数组一维的下标范围，包含 Low 和 High
+type IndexRange struct {
+	Low  int
+	High int
+}
+
+// ParseIndexRange 解析 OPC UA NumericRange，每一维格式为 <下标> 或者 <起始>:<结束>，维之间使用逗号分隔，空字符串表示不截取
+func ParseIndexRange(s string) ([]IndexRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	var ranges []IndexRange
+	for _, dim := range strings.Split(s, ",") {
+		var r IndexRange
+		var err error
+		low, high, found := strings.Cut(strings.TrimSpace(dim), ":")
+		if r.Low, err = strconv.Atoi(low); err != nil || r.Low < 0 {
+			return nil, fmt.Errorf("invalid index range: %s", s)
+		}
+		r.High = r.Low
+		if found {
+			// NumericRange 要求结束下标大于起始下标
+			if r.High, err = strconv.Atoi(high); err != nil || r.High <= r.Low {
+				return nil, fmt.Errorf("invalid index range: %s", s)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// Apply 按选项处理读取结果中的数组和矩阵值，返回处理后的结果
+// 下标范围超出数组长度时截取到数组末尾，展开后的每条数据 NodeId 不变，DisplayName 和 Tag 追加下标，ArrayIndex 为原数组中的下标
+func (o ArrayOptions) Apply(data []Data) ([]Data, error) {
+	ranges, err := ParseIndexRange(o.IndexRange)
+	if err != nil {
+		return nil, err
+	}
+	explode := strings.EqualFold(o.Mode, ArrayModeExplode)
+	if len(ranges) == 0 && !explode {
+		return data, nil
+	}
+	result := make([]Data, 0, len(data))
+	for _, d := range data {
+		v := reflect.ValueOf(d.Value)
+		if !isArray(v) {
+			result = append(result, d)
+			continue
+		}
+		if !explode {
+			d.Value = sliceRange(d.Value, ranges)
+			result = append(result, d)
+			continue
+		}
+		result = explodeArray(result, d, v, ranges, nil)
+	}
+	return result, nil
+}
+
+// isArray 是否数组值，ByteString（[]byte）作为标量处理
+func isArray(v reflect.Value) bool {
+	if !v.IsValid() || v.Kind() != reflect.Slice {
+		return false
+	}
+	return v.Type().Elem().Kind() != reflect.Uint8
+}
+
+// clip 返回第一维范围截取到数组长度后的起止下标，end 不包含
+func clip(v reflect.Value, ranges []IndexRange) (int, int) {
+	if len(ranges) == 0 {
+		return 0, v.Len()
+	}
+	start, end := ranges[0].Low, ranges[0].High+1
+	if start > v.Len() {
+		start = v.Len()
+	}
+	if end > v.Len() {
+		end = v.Len()
+	}
+	return start, end
+}
+
+// sliceRange 按下标范围逐维截取数组，维数多于数组维数的范围忽略
+func sliceRange(value interface{}, ranges []IndexRange) interface{} {
+	v := reflect.ValueOf(value)
+	if len(ranges) == 0 || !isArray(v) {
+		return value
+	}
+	start, end := clip(v, ranges)
+	if len(ranges) == 1 {
+		return v.Slice(start, end).Interface()
+	}
+	out := make([]interface{}, 0, end-start)
+	for i := start; i < end; i++ {
+		out = append(out, sliceRange(v.Index(i).Interface(), ranges[1:]))
+	}
+	return out
+}
+
+// explodeArray 把数组按下标逐个展开为数据，矩阵递归展开每一维
+func explodeArray(result []Data, d Data, v reflect.Value, ranges []IndexRange, index []int) []Data {
+	start, end := clip(v, ranges)
+	var rest []IndexRange
+	if len(ranges) > 1 {
+		rest = ranges[1:]
+	}
+	for i := start; i < end; i++ {
+		idx := append(append([]int{}, index...), i)
+		value := v.Index(i).Interface()
+		if elem := reflect.ValueOf(value); isArray(elem) {
+			result = explodeArray(result, d, elem, rest, idx)
+			continue
+		}
+		item := d
+		item.Value = value
+		item.FloatValue = 0
+		item.ArrayIndex = idx
+		suffix := indexSuffix(idx)
+		item.DisplayName = d.DisplayName + suffix
+		if d.Tag != "" {
+			item.Tag = d.Tag + suffix
+		}
+		_, _ = item.ParseValue()
+		result = append(result, item)
+	}
+	return result
+}
+
+// indexSuffix 下标后缀，eg. [1][2]
+func indexSuffix(index []int) string {
+	var b strings.Builder
+	for _, i := range index {
+		b.WriteString("[")
+		b.WriteString(strconv.Itoa(i))
+		b.WriteString("]")
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseIndexRange(t *testing.T) {
+	ranges, err := ParseIndexRange("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(ranges))
+
+	ranges, err = ParseIndexRange("3")
+	assert.Nil(t, err)
+	assert.Equal(t, []IndexRange{{Low: 3, High: 3}}, ranges)
+
+	ranges, err = ParseIndexRange("0:1, 2:3")
+	assert.Nil(t, err)
+	assert.Equal(t, []IndexRange{{Low: 0, High: 1}, {Low: 2, High: 3}}, ranges)
+
+	for _, s := range []string{"a", "-1", "3:3", "5:2", "1:", "1,,2"} {
+		_, err = ParseIndexRange(s)
+		assert.True(t, err != nil)
+	}
+	assert.Nil(t, ArrayOptions{Mode: "Explode", IndexRange: "1:2"}.Validate())
+	assert.True(t, ArrayOptions{Mode: "flat"}.Validate() != nil)
+}
+
+func TestArrayOptionsApply(t *testing.T) {
+	data := []Data{
+		{DisplayName: "Scalar", NodeId: "ns=2;s=Scalar", Value: int32(5), FloatValue: 5},
+		{DisplayName: "Array", NodeId: "ns=2;s=Array", Tag: "Line1", Value: []float64{1.5, 2.5, 3.5, 4.5}},
+		{DisplayName: "Bytes", NodeId: "ns=2;s=Bytes", Value: []byte{1, 2, 3}},
+		{DisplayName: "Matrix", NodeId: "ns=2;s=Matrix", Value: [][]int32{{1, 2, 3}, {4, 5, 6}}},
+	}
+
+	// 默认不做处理
+	result, err := ArrayOptions{}.Apply(data)
+	assert.Nil(t, err)
+	assert.Equal(t, data, result)
+
+	// 截取下标范围，超出长度时截取到末尾
+	result, err = ArrayOptions{IndexRange: "2:9"}.Apply(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(result))
+	assert.Equal(t, int32(5), result[0].Value)
+	assert.Equal(t, []float64{3.5, 4.5}, result[1].Value)
+	assert.Equal(t, []byte{1, 2, 3}, result[2].Value)
+	assert.Equal(t, 0, len(result[3].Value.([][]int32)))
+
+	result, err = ArrayOptions{IndexRange: "1,0:1"}.Apply(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{[]int32{4, 5}}, result[3].Value)
+
+	// 展开为每个下标一条数据
+	result, err = ArrayOptions{Mode: ArrayModeExplode, IndexRange: "1:2"}.Apply(data[:2])
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result))
+	assert.Equal(t, "Array[1]", result[1].DisplayName)
+	assert.Equal(t, "Line1[1]", result[1].Tag)
+	assert.Equal(t, "ns=2;s=Array", result[1].NodeId)
+	assert.Equal(t, []int{1}, result[1].ArrayIndex)
+	assert.Equal(t, 2.5, result[1].Value)
+	assert.Equal(t, 2.5, result[1].FloatValue)
+	assert.Equal(t, "Array[2]", result[2].DisplayName)
+	assert.Equal(t, 3.5, result[2].FloatValue)
+	// 原数据不被修改
+	assert.Equal(t, "Line1", data[1].Tag)
+
+	result, err = ArrayOptions{Mode: ArrayModeExplode}.Apply(data[3:])
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(result))
+	assert.Equal(t, "Matrix[1][2]", result[5].DisplayName)
+	assert.Equal(t, []int{1, 2}, result[5].ArrayIndex)
+	assert.Equal(t, float64(6), result[5].FloatValue)
+
+	_, err = ArrayOptions{IndexRange: "x"}.Apply(data)
+	assert.True(t, err != nil)
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+)
+
+// 通过 HasProperty 引用挂在变量节点下的常用属性
+const (
+	PropertyEngineeringUnits = "EngineeringUnits"
+	PropertyEURange          = "EURange"
+	PropertyInstrumentRange  = "InstrumentRange"
+)
+
+// NodeAttributes 节点属性读取结果
+type NodeAttributes struct {
+	NodeId     string                 `json:"nodeId"`
+	Attributes map[string]interface{} `json:"attributes"`
+	// Errors 读取失败的属性及原因
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ParseAttributeID 解析属性名称，例如 DataType、Description、AccessLevel，不区分大小写
+func ParseAttributeID(name string) (ua.AttributeID, bool) {
+	name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "attributeid")
+	for i := ua.AttributeIDNodeID; i <= ua.AttributeIDAccessLevelEx; i++ {
+		if strings.ToLower(strings.TrimPrefix(i.String(), "AttributeID")) == name {
+			return i, true
+		}
+	}
+	return ua.AttributeIDInvalid, false
+}
+
+// isProperty 是否是通过 HasProperty 引用读取的属性
+func isProperty(name string) bool {
+	switch name {
+	case PropertyEngineeringUnits, PropertyEURange, PropertyInstrumentRange:
+		return true
+	}
+	return false
+}
+
+// ReadAttributes 读取节点的任意属性
+// attributes 支持 OPC UA 标准属性名称（DataType、Description、AccessLevel 等）
+// 以及 EngineeringUnits、EURange、InstrumentRange 属性节点
+func ReadAttributes(client *opcua.Client, nodeIds []string, attributes []string) ([]NodeAttributes, error) {
+	return ReadAttributesContext(context.Background(), client, nodeIds, attributes)
+}
+
+// ReadAttributesContext 同 ReadAttributes，ctx 取消时中止未完成的请求
+func ReadAttributesContext(ctx context.Context, client *opcua.Client, nodeIds []string, attributes []string) ([]NodeAttributes, error) {
+	ids := make([]*ua.NodeID, 0, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		id, err := ua.ParseNodeID(nodeId)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	attrIds := make([]ua.AttributeID, 0, len(attributes))
+	attrNames := make([]string, 0, len(attributes))
+	properties := make([]string, 0)
+	for _, name := range attributes {
+		if isProperty(name) {
+			properties = append(properties, name)
+			continue
+		}
+		attrId, ok := ParseAttributeID(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute: %s", name)
+		}
+		attrIds = append(attrIds, attrId)
+		attrNames = append(attrNames, strings.TrimPrefix(attrId.String(), "AttributeID"))
+	}
+
+	result := make([]NodeAttributes, len(ids))
+	nodesToRead := make([]*ua.ReadValueID, 0, len(ids)*len(attrIds))
+	for i, id := range ids {
+		result[i] = NodeAttributes{NodeId: id.String(), Attributes: make(map[string]interface{})}
+		for _, attrId := range attrIds {
+			nodesToRead = append(nodesToRead, &ua.ReadValueID{NodeID: id, AttributeID: attrId})
+		}
+	}
+
+	if len(nodesToRead) > 0 {
+		resp, err := client.Read(ctx, &ua.ReadRequest{
+			NodesToRead:        nodesToRead,
+			TimestampsToReturn: ua.TimestampsToReturnNeither,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for k, dv := range resp.Results {
+			i, j := k/len(attrIds), k%len(attrIds)
+			if dv == nil {
+				continue
+			}
+			if dv.Status != ua.StatusOK {
+				result[i].setError(attrNames[j], dv.Status)
+				continue
+			}
+			if dv.Value != nil {
+				result[i].Attributes[attrNames[j]] = AttributeValue(dv.Value.Value())
+			}
+		}
+	}
+
+	for i, id := range ids {
+		n := client.Node(id)
+		for _, name := range properties {
+			propId, err := n.TranslateBrowsePathInNamespaceToNodeID(ctx, 0, name)
+			if err != nil {
+				result[i].setError(name, err)
+				continue
+			}
+			v, err := client.Node(propId).Value(ctx)
+			if err != nil {
+				result[i].setError(name, err)
+				continue
+			}
+			if v != nil {
+				result[i].Attributes[name] = AttributeValue(v.Value())
+			}
+		}
+	}
+	return result, nil
+}
+
+func (a *NodeAttributes) setError(name string, err error) {
+	if a.Errors == nil {
+		a.Errors = make(map[string]string)
+	}
+	a.Errors[name] = err.Error()
+}
+
+// AttributeValue 将属性值转换为便于 JSON 序列化的值
+func AttributeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case *ua.NodeID:
+		if val == nil {
+			return nil
+		}
+		return val.String()
+	case *ua.ExpandedNodeID:
+		if val == nil {
+			return nil
+		}
+		return val.String()
+	case *ua.LocalizedText:
+		if val == nil {
+			return nil
+		}
+		return val.Text
+	case *ua.QualifiedName:
+		if val == nil {
+			return nil
+		}
+		return val.Name
+	case *ua.ExtensionObject:
+		if val == nil {
+			return nil
+		}
+		return AttributeValue(val.Value)
+	case *ua.EUInformation:
+		if val == nil {
+			return nil
+		}
+		m := map[string]interface{}{
+			"namespaceUri": val.NamespaceURI,
+			"unitId":       val.UnitID,
+		}
+		if val.DisplayName != nil {
+			m["displayName"] = val.DisplayName.Text
+		}
+		if val.Description != nil {
+			m["description"] = val.Description.Text
+		}
+		return m
+	case *ua.Range:
+		if val == nil {
+			return nil
+		}
+		return map[string]interface{}{
+			"low":  val.Low,
+			"high": val.High,
+		}
+	}
+	return v
+}
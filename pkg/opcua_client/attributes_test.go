@@ -0,0 +1,58 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseAttributeID(t *testing.T) {
+	id, ok := ParseAttributeID("DataType")
+	assert.True(t, ok)
+	assert.Equal(t, ua.AttributeIDDataType, id)
+
+	id, ok = ParseAttributeID("accesslevel")
+	assert.True(t, ok)
+	assert.Equal(t, ua.AttributeIDAccessLevel, id)
+
+	_, ok = ParseAttributeID("NotExist")
+	assert.False(t, ok)
+}
+
+func TestAttributeValue(t *testing.T) {
+	assert.Equal(t, "i=11", AttributeValue(ua.NewNumericNodeID(0, 11)))
+	assert.Equal(t, "temperature", AttributeValue(&ua.LocalizedText{Text: "temperature"}))
+
+	eu := AttributeValue(&ua.ExtensionObject{Value: &ua.EUInformation{
+		UnitID:      4408652,
+		DisplayName: &ua.LocalizedText{Text: "°C"},
+	}}).(map[string]interface{})
+	assert.Equal(t, "°C", eu["displayName"])
+	assert.Equal(t, int32(4408652), eu["unitId"])
+
+	r := AttributeValue(&ua.Range{Low: 0, High: 100}).(map[string]interface{})
+	assert.Equal(t, float64(100), r["high"])
+
+	// 空值不触发 panic
+	assert.Nil(t, AttributeValue((*ua.EUInformation)(nil)))
+	assert.Nil(t, AttributeValue((*ua.Range)(nil)))
+	assert.Nil(t, AttributeValue(&ua.ExtensionObject{Value: (*ua.EUInformation)(nil)}))
+	assert.Nil(t, AttributeValue((*ua.NodeID)(nil)))
+}
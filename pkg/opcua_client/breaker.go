@@ -0,0 +1,76 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+)
+
+// commStatusCodes 表示连接、会话或者通信失败的状态码
+var commStatusCodes = map[ua.StatusCode]bool{
+	ua.StatusBadCommunicationError:  true,
+	ua.StatusBadTimeout:             true,
+	ua.StatusBadRequestTimeout:      true,
+	ua.StatusBadShutdown:            true,
+	ua.StatusBadServerNotConnected:  true,
+	ua.StatusBadServerHalted:        true,
+	ua.StatusBadNoCommunication:     true,
+	ua.StatusBadNotConnected:        true,
+	ua.StatusBadSessionClosed:       true,
+	ua.StatusBadSessionIDInvalid:    true,
+	ua.StatusBadSecureChannelClosed: true,
+	ua.StatusBadTCPInternalError:    true,
+	ua.StatusBadConnectionRejected:  true,
+	ua.StatusBadDisconnect:          true,
+	ua.StatusBadConnectionClosed:    true,
+}
+
+// AcquireBreaker 获取服务器对应的熔断器，连接相同服务器的组件共享，不再使用时需要调用 Release
+func AcquireBreaker(server string, config breaker.Config) *breaker.Breaker {
+	return breaker.Acquire(healthComponent, server, config)
+}
+
+// IsCommError 是否通信错误：连接失败、超时、连接或者会话断开。
+// 点位不存在、类型不匹配等服务器返回的错误说明服务器可用，不是通信错误
+func IsCommError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var status ua.StatusCode
+	return errors.As(err, &status) && commStatusCodes[status]
+}
+
+// CommError 通信错误原样返回，其他错误返回 nil，用于向熔断器上报请求结果
+func CommError(err error) error {
+	if IsCommError(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+const (
+	// DefaultBrowseRoot 默认的浏览起点：Objects 文件夹
+	DefaultBrowseRoot = "i=85"
+	// DefaultBrowseMaxDepth 默认的最大浏览深度
+	DefaultBrowseMaxDepth = 10
+	// DefaultBrowseMaxNodes 默认最多返回的变量数量
+	DefaultBrowseMaxNodes = 1000
+	// browseReadBatch 读取变量数据类型时每个请求的节点数
+	browseReadBatch = 500
+)
+
+// BrowseOptions 浏览变量的选项
+type BrowseOptions struct {
+	// MaxDepth 最大浏览深度，根节点的子节点深度为 1，小于等于0使用 DefaultBrowseMaxDepth
+	MaxDepth int
+	// MaxNodes 最多返回的变量数量，达到后停止浏览，小于等于0使用 DefaultBrowseMaxNodes
+	MaxNodes int
+	// Match 按浏览路径过滤变量，为 nil 不过滤，不匹配的变量仍然浏览其子节点
+	Match func(path string) bool
+}
+
+// BrowsedVariable 浏览到的变量节点
+type BrowsedVariable struct {
+	// NodeId 节点 Id
+	NodeId string `json:"nodeId"`
+	// BrowseName 浏览名称，不包含命名空间
+	BrowseName string `json:"browseName"`
+	// DisplayName 显示名称
+	DisplayName string `json:"displayName"`
+	// Path 从根节点开始的浏览路径，eg. Objects/Boiler1/Temperature
+	Path string `json:"path"`
+	// DataType 数据类型，标准类型为名称（eg. Double），其他类型为节点 Id
+	DataType string `json:"dataType"`
+}
+
+// BrowseVariables 从 root 开始沿层级引用（不包括 HasProperty）广度优先浏览，返回变量节点及其数据类型
+// 每个节点只浏览一次，truncated 表示变量数量达到 MaxNodes 后停止了浏览
+func BrowseVariables(ctx context.Context, client *opcua.Client, root string, opts BrowseOptions) (variables []BrowsedVariable, truncated bool, err error) {
+	if root == "" {
+		root = DefaultBrowseRoot
+	}
+	rootId, err := ua.ParseNodeID(root)
+	if err != nil {
+		return nil, false, err
+	}
+	maxDepth, maxNodes := opts.MaxDepth, opts.MaxNodes
+	if maxDepth <= 0 {
+		maxDepth = DefaultBrowseMaxDepth
+	}
+	if maxNodes <= 0 {
+		maxNodes = DefaultBrowseMaxNodes
+	}
+	rootName := root
+	if name, err := client.Node(rootId).BrowseName(ctx); err == nil && name != nil {
+		rootName = name.Name
+	}
+
+	type item struct {
+		id   *ua.NodeID
+		path string
+	}
+	visited := map[string]bool{rootId.String(): true}
+	level := []item{{id: rootId, path: rootName}}
+	var ids []*ua.NodeID
+	for depth := 1; depth <= maxDepth && len(level) > 0 && !truncated; depth++ {
+		var next []item
+		for _, parent := range level {
+			refs, err := client.Node(parent.id).References(ctx, id.HierarchicalReferences, ua.BrowseDirectionForward,
+				ua.NodeClassObject|ua.NodeClassVariable, true)
+			if err != nil {
+				return nil, false, err
+			}
+			for _, ref := range refs {
+				if ref.NodeID == nil || ref.NodeID.NodeID == nil || ref.ReferenceTypeID.IntID() == id.HasProperty {
+					continue
+				}
+				nodeId := ref.NodeID.NodeID
+				if visited[nodeId.String()] {
+					continue
+				}
+				visited[nodeId.String()] = true
+				var browseName, displayName string
+				if ref.BrowseName != nil {
+					browseName = ref.BrowseName.Name
+				}
+				if ref.DisplayName != nil {
+					displayName = ref.DisplayName.Text
+				}
+				path := parent.path + "/" + browseName
+				next = append(next, item{id: nodeId, path: path})
+				if ref.NodeClass != ua.NodeClassVariable || (opts.Match != nil && !opts.Match(path)) {
+					continue
+				}
+				if len(variables) >= maxNodes {
+					truncated = true
+					break
+				}
+				variables = append(variables, BrowsedVariable{
+					NodeId:      nodeId.String(),
+					BrowseName:  browseName,
+					DisplayName: displayName,
+					Path:        path,
+				})
+				ids = append(ids, nodeId)
+			}
+			if truncated {
+				break
+			}
+		}
+		level = next
+	}
+
+	for start := 0; start < len(ids); start += browseReadBatch {
+		end := min(start+browseReadBatch, len(ids))
+		nodesToRead := make([]*ua.ReadValueID, 0, end-start)
+		for _, nodeId := range ids[start:end] {
+			nodesToRead = append(nodesToRead, &ua.ReadValueID{NodeID: nodeId, AttributeID: ua.AttributeIDDataType})
+		}
+		resp, err := client.Read(ctx, &ua.ReadRequest{NodesToRead: nodesToRead, TimestampsToReturn: ua.TimestampsToReturnNeither})
+		if err != nil {
+			return nil, false, err
+		}
+		for i, dv := range resp.Results {
+			if dv == nil || dv.Status != ua.StatusOK || dv.Value == nil {
+				continue
+			}
+			switch dataType := dv.Value.Value().(type) {
+			case *ua.NodeID:
+				if dataType != nil {
+					variables[start+i].DataType = DataTypeName(dataType)
+				}
+			case *ua.ExpandedNodeID:
+				// 部分服务器返回 ExpandedNodeId
+				if dataType != nil && dataType.NodeID != nil {
+					variables[start+i].DataType = DataTypeName(dataType.NodeID)
+				}
+			}
+		}
+	}
+	return variables, truncated, nil
+}
+
+// DataTypeName 数据类型名称，命名空间 0 的标准类型返回名称（eg. Double），其他类型返回节点 Id
+func DataTypeName(dataType *ua.NodeID) string {
+	if dataType.Namespace() == 0 && dataType.IntID() != 0 {
+		if name := id.Name(dataType.IntID()); name != "" {
+			return name
+		}
+	}
+	return dataType.String()
+}
+
+// MatchDataType 数据类型是否在列表中，名称不区分大小写，列表为空时都匹配
+func MatchDataType(dataType string, dataTypes []string) bool {
+	if len(dataTypes) == 0 {
+		return true
+	}
+	for _, t := range dataTypes {
+		if strings.EqualFold(strings.TrimSpace(t), dataType) {
+			return true
+		}
+	}
+	return false
+}
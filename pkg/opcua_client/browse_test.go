@@ -0,0 +1,36 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDataTypeName(t *testing.T) {
+	assert.Equal(t, "Double", DataTypeName(ua.NewNumericNodeID(0, 11)))
+	assert.Equal(t, "Boolean", DataTypeName(ua.NewTwoByteNodeID(1)))
+	assert.Equal(t, "ns=2;i=3002", DataTypeName(ua.NewNumericNodeID(2, 3002)))
+	assert.Equal(t, "s=Custom", DataTypeName(ua.NewStringNodeID(0, "Custom")))
+
+	assert.True(t, MatchDataType("Double", nil))
+	assert.True(t, MatchDataType("Double", []string{"int32", " double"}))
+	assert.False(t, MatchDataType("Float", []string{"Double"}))
+	assert.False(t, MatchDataType("", []string{"Double"}))
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// ServerStatus 服务器状态，对应 Server/ServerStatus 节点
+type ServerStatus struct {
+	// State 运行状态，例如：Running、Failed、Suspended、Shutdown
+	State               string    `json:"state"`
+	StartTime           time.Time `json:"startTime"`
+	CurrentTime         time.Time `json:"currentTime"`
+	SecondsTillShutdown uint32    `json:"secondsTillShutdown"`
+	ShutdownReason      string    `json:"shutdownReason,omitempty"`
+	ProductName         string    `json:"productName,omitempty"`
+	ProductUri          string    `json:"productUri,omitempty"`
+	ManufacturerName    string    `json:"manufacturerName,omitempty"`
+	SoftwareVersion     string    `json:"softwareVersion,omitempty"`
+	BuildNumber         string    `json:"buildNumber,omitempty"`
+}
+
+// DiagnosticsSummary 服务器诊断汇总，对应 Server/ServerDiagnostics/ServerDiagnosticsSummary 节点
+type DiagnosticsSummary struct {
+	ServerViewCount               uint32 `json:"serverViewCount"`
+	CurrentSessionCount           uint32 `json:"currentSessionCount"`
+	CumulatedSessionCount         uint32 `json:"cumulatedSessionCount"`
+	SecurityRejectedSessionCount  uint32 `json:"securityRejectedSessionCount"`
+	RejectedSessionCount          uint32 `json:"rejectedSessionCount"`
+	SessionTimeoutCount           uint32 `json:"sessionTimeoutCount"`
+	SessionAbortCount             uint32 `json:"sessionAbortCount"`
+	PublishingIntervalCount       uint32 `json:"publishingIntervalCount"`
+	CurrentSubscriptionCount      uint32 `json:"currentSubscriptionCount"`
+	CumulatedSubscriptionCount    uint32 `json:"cumulatedSubscriptionCount"`
+	SecurityRejectedRequestsCount uint32 `json:"securityRejectedRequestsCount"`
+	RejectedRequestsCount         uint32 `json:"rejectedRequestsCount"`
+}
+
+// SubscriptionDiagnostics 订阅诊断信息，对应 SubscriptionDiagnosticsArray 中的元素
+type SubscriptionDiagnostics struct {
+	SessionId                    string  `json:"sessionId"`
+	SubscriptionId               uint32  `json:"subscriptionId"`
+	Priority                     uint8   `json:"priority"`
+	PublishingInterval           float64 `json:"publishingInterval"`
+	PublishingEnabled            bool    `json:"publishingEnabled"`
+	MonitoredItemCount           uint32  `json:"monitoredItemCount"`
+	DisabledMonitoredItemCount   uint32  `json:"disabledMonitoredItemCount"`
+	MonitoringQueueOverflowCount uint32  `json:"monitoringQueueOverflowCount"`
+	EventQueueOverflowCount      uint32  `json:"eventQueueOverflowCount"`
+	PublishRequestCount          uint32  `json:"publishRequestCount"`
+	DataChangeNotificationsCount uint32  `json:"dataChangeNotificationsCount"`
+	EventNotificationsCount      uint32  `json:"eventNotificationsCount"`
+	NotificationsCount           uint32  `json:"notificationsCount"`
+	LatePublishRequestCount      uint32  `json:"latePublishRequestCount"`
+	UnacknowledgedMessageCount   uint32  `json:"unacknowledgedMessageCount"`
+	DiscardedMessageCount        uint32  `json:"discardedMessageCount"`
+	CurrentKeepAliveCount        uint32  `json:"currentKeepAliveCount"`
+	CurrentLifetimeCount         uint32  `json:"currentLifetimeCount"`
+	RepublishRequestCount        uint32  `json:"republishRequestCount"`
+}
+
+// ServerDiagnostics 服务器诊断读取结果
+type ServerDiagnostics struct {
+	ServerStatus *ServerStatus `json:"serverStatus,omitempty"`
+	// DiagnosticsEnabled 服务器是否启用了诊断，未启用时诊断计数可能全部为 0
+	DiagnosticsEnabled bool                      `json:"diagnosticsEnabled"`
+	Summary            *DiagnosticsSummary       `json:"summary,omitempty"`
+	Subscriptions      []SubscriptionDiagnostics `json:"subscriptions,omitempty"`
+	// Errors 读取失败的项及原因
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+func (d *ServerDiagnostics) setError(name string, err error) {
+	if d.Errors == nil {
+		d.Errors = make(map[string]string)
+	}
+	d.Errors[name] = err.Error()
+}
+
+// diagnosticsItem 需要读取的诊断节点
+type diagnosticsItem struct {
+	name string
+	id   uint32
+	// set 保存读取到的值
+	set func(d *ServerDiagnostics, v interface{}) error
+}
+
+// summaryItem 诊断汇总计数节点
+func summaryItem(name string, nodeId uint32, field func(s *DiagnosticsSummary) *uint32) diagnosticsItem {
+	return diagnosticsItem{name: name, id: nodeId, set: func(d *ServerDiagnostics, v interface{}) error {
+		n, err := toUint(v, math.MaxUint32)
+		if err != nil {
+			return err
+		}
+		if d.Summary == nil {
+			d.Summary = &DiagnosticsSummary{}
+		}
+		*field(d.Summary) = uint32(n)
+		return nil
+	}}
+}
+
+var diagnosticsItems = []diagnosticsItem{
+	{name: "ServerStatus", id: id.Server_ServerStatus, set: setServerStatus},
+	{name: "EnabledFlag", id: id.Server_ServerDiagnostics_EnabledFlag, set: func(d *ServerDiagnostics, v interface{}) error {
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected value type %T", v)
+		}
+		d.DiagnosticsEnabled = b
+		return nil
+	}},
+	summaryItem("ServerViewCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_ServerViewCount, func(s *DiagnosticsSummary) *uint32 { return &s.ServerViewCount }),
+	summaryItem("CurrentSessionCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_CurrentSessionCount, func(s *DiagnosticsSummary) *uint32 { return &s.CurrentSessionCount }),
+	summaryItem("CumulatedSessionCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_CumulatedSessionCount, func(s *DiagnosticsSummary) *uint32 { return &s.CumulatedSessionCount }),
+	summaryItem("SecurityRejectedSessionCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_SecurityRejectedSessionCount, func(s *DiagnosticsSummary) *uint32 { return &s.SecurityRejectedSessionCount }),
+	summaryItem("RejectedSessionCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_RejectedSessionCount, func(s *DiagnosticsSummary) *uint32 { return &s.RejectedSessionCount }),
+	summaryItem("SessionTimeoutCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_SessionTimeoutCount, func(s *DiagnosticsSummary) *uint32 { return &s.SessionTimeoutCount }),
+	summaryItem("SessionAbortCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_SessionAbortCount, func(s *DiagnosticsSummary) *uint32 { return &s.SessionAbortCount }),
+	summaryItem("PublishingIntervalCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_PublishingIntervalCount, func(s *DiagnosticsSummary) *uint32 { return &s.PublishingIntervalCount }),
+	summaryItem("CurrentSubscriptionCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_CurrentSubscriptionCount, func(s *DiagnosticsSummary) *uint32 { return &s.CurrentSubscriptionCount }),
+	summaryItem("CumulatedSubscriptionCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_CumulatedSubscriptionCount, func(s *DiagnosticsSummary) *uint32 { return &s.CumulatedSubscriptionCount }),
+	summaryItem("SecurityRejectedRequestsCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_SecurityRejectedRequestsCount, func(s *DiagnosticsSummary) *uint32 { return &s.SecurityRejectedRequestsCount }),
+	summaryItem("RejectedRequestsCount", id.Server_ServerDiagnostics_ServerDiagnosticsSummary_RejectedRequestsCount, func(s *DiagnosticsSummary) *uint32 { return &s.RejectedRequestsCount }),
+	{name: "SubscriptionDiagnosticsArray", id: id.Server_ServerDiagnostics_SubscriptionDiagnosticsArray, set: setSubscriptionDiagnostics},
+}
+
+// ReadServerDiagnostics 读取服务器标准状态和诊断节点
+// 单个节点读取失败记录在 Errors 中，不影响其他节点
+func ReadServerDiagnostics(ctx context.Context, client *opcua.Client) (*ServerDiagnostics, error) {
+	nodesToRead := make([]*ua.ReadValueID, 0, len(diagnosticsItems))
+	for _, item := range diagnosticsItems {
+		nodesToRead = append(nodesToRead, &ua.ReadValueID{
+			NodeID:      ua.NewNumericNodeID(0, item.id),
+			AttributeID: ua.AttributeIDValue,
+		})
+	}
+	resp, err := client.Read(ctx, &ua.ReadRequest{
+		NodesToRead:        nodesToRead,
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	})
+	if err != nil {
+		return nil, err
+	}
+	d := &ServerDiagnostics{}
+	for i, dv := range resp.Results {
+		if i >= len(diagnosticsItems) || dv == nil {
+			continue
+		}
+		item := diagnosticsItems[i]
+		if dv.Status != ua.StatusOK {
+			d.setError(item.name, dv.Status)
+			continue
+		}
+		if dv.Value == nil {
+			continue
+		}
+		if err := item.set(d, dv.Value.Value()); err != nil {
+			d.setError(item.name, err)
+		}
+	}
+	return d, nil
+}
+
+func setServerStatus(d *ServerDiagnostics, v interface{}) error {
+	eo, ok := v.(*ua.ExtensionObject)
+	if !ok || eo == nil {
+		return fmt.Errorf("unexpected value type %T", v)
+	}
+	status, ok := eo.Value.(*ua.ServerStatusDataType)
+	if !ok || status == nil {
+		return fmt.Errorf("unexpected value type %T", eo.Value)
+	}
+	s := &ServerStatus{
+		State:               strings.TrimPrefix(status.State.String(), "ServerState"),
+		StartTime:           status.StartTime,
+		CurrentTime:         status.CurrentTime,
+		SecondsTillShutdown: status.SecondsTillShutdown,
+	}
+	if status.ShutdownReason != nil {
+		s.ShutdownReason = status.ShutdownReason.Text
+	}
+	if info := status.BuildInfo; info != nil {
+		s.ProductName = info.ProductName
+		s.ProductUri = info.ProductURI
+		s.ManufacturerName = info.ManufacturerName
+		s.SoftwareVersion = info.SoftwareVersion
+		s.BuildNumber = info.BuildNumber
+	}
+	d.ServerStatus = s
+	return nil
+}
+
+func setSubscriptionDiagnostics(d *ServerDiagnostics, v interface{}) error {
+	list, ok := v.([]*ua.ExtensionObject)
+	if !ok {
+		return fmt.Errorf("unexpected value type %T", v)
+	}
+	d.Subscriptions = make([]SubscriptionDiagnostics, 0, len(list))
+	for _, eo := range list {
+		if eo == nil {
+			continue
+		}
+		s, ok := eo.Value.(*ua.SubscriptionDiagnosticsDataType)
+		if !ok || s == nil {
+			continue
+		}
+		item := SubscriptionDiagnostics{
+			SubscriptionId:               s.SubscriptionID,
+			Priority:                     s.Priority,
+			PublishingInterval:           s.PublishingInterval,
+			PublishingEnabled:            s.PublishingEnabled,
+			MonitoredItemCount:           s.MonitoredItemCount,
+			DisabledMonitoredItemCount:   s.DisabledMonitoredItemCount,
+			MonitoringQueueOverflowCount: s.MonitoringQueueOverflowCount,
+			EventQueueOverflowCount:      s.EventQueueOverFlowCount,
+			PublishRequestCount:          s.PublishRequestCount,
+			DataChangeNotificationsCount: s.DataChangeNotificationsCount,
+			EventNotificationsCount:      s.EventNotificationsCount,
+			NotificationsCount:           s.NotificationsCount,
+			LatePublishRequestCount:      s.LatePublishRequestCount,
+			UnacknowledgedMessageCount:   s.UnacknowledgedMessageCount,
+			DiscardedMessageCount:        s.DiscardedMessageCount,
+			CurrentKeepAliveCount:        s.CurrentKeepAliveCount,
+			CurrentLifetimeCount:         s.CurrentLifetimeCount,
+			RepublishRequestCount:        s.RepublishRequestCount,
+		}
+		if s.SessionID != nil {
+			item.SessionId = s.SessionID.String()
+		}
+		d.Subscriptions = append(d.Subscriptions, item)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+)
+
+// ServerInfo 服务器应用描述
+type ServerInfo struct {
+	ApplicationURI  string   `json:"applicationUri"`
+	ProductURI      string   `json:"productUri"`
+	ApplicationName string   `json:"applicationName"`
+	ApplicationType string   `json:"applicationType"`
+	DiscoveryURLs   []string `json:"discoveryUrls"`
+}
+
+// EndpointInfo 服务器端点描述
+type EndpointInfo struct {
+	EndpointURL         string      `json:"endpointUrl"`
+	SecurityPolicy      string      `json:"securityPolicy"`
+	SecurityPolicyURI   string      `json:"securityPolicyUri"`
+	SecurityMode        string      `json:"securityMode"`
+	SecurityLevel       uint8       `json:"securityLevel"`
+	UserTokenTypes      []string    `json:"userTokenTypes"`
+	TransportProfileURI string      `json:"transportProfileUri"`
+	Server              *ServerInfo `json:"server,omitempty"`
+}
+
+// DiscoveryResult 发现结果
+type DiscoveryResult struct {
+	// Servers FindServers 返回的服务器列表
+	Servers []ServerInfo `json:"servers"`
+	// Endpoints GetEndpoints 返回的端点列表，按安全级别从高到低排序
+	Endpoints []EndpointInfo `json:"endpoints"`
+	// Selected 与期望安全策略、安全模式最匹配的端点，没有匹配时为空
+	Selected *EndpointInfo `json:"selected,omitempty"`
+}
+
+// ParseSecurityMode 解析安全模式，不区分大小写，为空或 auto 返回 MessageSecurityModeInvalid（不限制）
+func ParseSecurityMode(mode string) (ua.MessageSecurityMode, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", SecurityAuto:
+		return ua.MessageSecurityModeInvalid, nil
+	case "none":
+		return ua.MessageSecurityModeNone, nil
+	case "sign":
+		return ua.MessageSecurityModeSign, nil
+	case "signandencrypt":
+		return ua.MessageSecurityModeSignAndEncrypt, nil
+	default:
+		return ua.MessageSecurityModeInvalid, fmt.Errorf("invalid security mode: %s, must be one of None, Sign, SignAndEncrypt", mode)
+	}
+}
+
+// Discover 查询发现地址，返回服务器列表、端点列表以及与 policy、mode 最匹配的端点
+// policy、mode 为空或 auto 时不限制，选择安全级别最高的端点
+func Discover(ctx context.Context, discoveryURL string, findServers bool, policy, mode string) (*DiscoveryResult, error) {
+	secMode, err := ParseSecurityMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	if policy, err = ParseSecurityPolicy(policy); err != nil {
+		return nil, err
+	}
+	result := &DiscoveryResult{
+		Servers:   make([]ServerInfo, 0),
+		Endpoints: make([]EndpointInfo, 0),
+	}
+	if findServers {
+		servers, err := opcua.FindServers(ctx, discoveryURL)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range servers {
+			result.Servers = append(result.Servers, *NewServerInfo(s))
+		}
+	}
+	endpoints, err := opcua.GetEndpoints(ctx, discoveryURL)
+	if err != nil {
+		return nil, err
+	}
+	// SelectEndpoint 会按安全级别从高到低排序
+	selected, _ := opcua.SelectEndpoint(endpoints, policy, secMode)
+	for _, e := range endpoints {
+		info := NewEndpointInfo(e)
+		result.Endpoints = append(result.Endpoints, info)
+		if e == selected {
+			result.Selected = &info
+		}
+	}
+	return result, nil
+}
+
+// NewServerInfo 转换服务器应用描述
+func NewServerInfo(s *ua.ApplicationDescription) *ServerInfo {
+	if s == nil {
+		return nil
+	}
+	info := &ServerInfo{
+		ApplicationURI:  s.ApplicationURI,
+		ProductURI:      s.ProductURI,
+		ApplicationType: strings.TrimPrefix(s.ApplicationType.String(), "ApplicationType"),
+		DiscoveryURLs:   s.DiscoveryURLs,
+	}
+	if s.ApplicationName != nil {
+		info.ApplicationName = s.ApplicationName.Text
+	}
+	return info
+}
+
+// NewEndpointInfo 转换服务器端点描述
+func NewEndpointInfo(e *ua.EndpointDescription) EndpointInfo {
+	info := EndpointInfo{
+		EndpointURL:         e.EndpointURL,
+		SecurityPolicy:      strings.TrimPrefix(e.SecurityPolicyURI, ua.SecurityPolicyURIPrefix),
+		SecurityPolicyURI:   e.SecurityPolicyURI,
+		SecurityMode:        strings.TrimPrefix(e.SecurityMode.String(), "MessageSecurityMode"),
+		SecurityLevel:       e.SecurityLevel,
+		UserTokenTypes:      make([]string, 0),
+		TransportProfileURI: e.TransportProfileURI,
+		Server:              NewServerInfo(e.Server),
+	}
+	for _, t := range e.UserIdentityTokens {
+		tok := strings.TrimPrefix(t.TokenType.String(), "UserTokenType")
+		dup := false
+		for _, existing := range info.UserTokenTypes {
+			if existing == tok {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			info.UserTokenTypes = append(info.UserTokenTypes, tok)
+		}
+	}
+	return info
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gopcua/opcua"
+)
+
+// enrichAttributes 丰富读取结果需要读取的属性
+var enrichAttributes = []string{"Description", PropertyEngineeringUnits, PropertyEURange}
+
+// NodeMetadata 点位的工程单位、量程和描述
+type NodeMetadata struct {
+	// Unit 工程单位，来自 EngineeringUnits 属性的 DisplayName
+	Unit string `json:"unit,omitempty"`
+	// Min 量程下限，来自 EURange 属性
+	Min *float64 `json:"min,omitempty"`
+	// Max 量程上限，来自 EURange 属性
+	Max *float64 `json:"max,omitempty"`
+	// Description 点位描述
+	Description string `json:"description,omitempty"`
+}
+
+// metadataCache 点位元数据缓存，key 为客户端，value 为 nodeId -> *NodeMetadata
+var metadataCache sync.Map
+
+// ForgetMetadata 清除客户端的点位元数据缓存，客户端关闭时调用
+func ForgetMetadata(client *opcua.Client) {
+	metadataCache.Delete(client)
+}
+
+// enrich 为读取结果补充工程单位、量程和描述，每个点位只读取一次并缓存
+// 读取失败时不补充，下次读取时重试
+func enrich(ctx context.Context, client *opcua.Client, data []Data) {
+	v, _ := metadataCache.LoadOrStore(client, &sync.Map{})
+	cache := v.(*sync.Map)
+
+	missing := make([]string, 0)
+	for _, d := range data {
+		if d.NodeId == "" {
+			continue
+		}
+		if _, ok := cache.Load(d.NodeId); !ok {
+			missing = append(missing, d.NodeId)
+		}
+	}
+	if len(missing) > 0 {
+		attrs, err := ReadAttributesContext(ctx, client, missing, enrichAttributes)
+		if err != nil {
+			logger.Printf("read node metadata error: %v", err)
+		} else {
+			for _, a := range attrs {
+				cache.Store(a.NodeId, newNodeMetadata(a))
+			}
+		}
+	}
+	for i := range data {
+		if m, ok := cache.Load(data[i].NodeId); ok && m != nil {
+			data[i].NodeMetadata = m.(*NodeMetadata)
+		}
+	}
+}
+
+// newNodeMetadata 从属性读取结果构建点位元数据，没有任何元数据返回 nil
+func newNodeMetadata(a NodeAttributes) *NodeMetadata {
+	m := &NodeMetadata{}
+	if s, ok := a.Attributes["Description"].(string); ok {
+		m.Description = s
+	}
+	if eu, ok := a.Attributes[PropertyEngineeringUnits].(map[string]interface{}); ok {
+		if s, ok := eu["displayName"].(string); ok {
+			m.Unit = s
+		}
+	}
+	if r, ok := a.Attributes[PropertyEURange].(map[string]interface{}); ok {
+		if low, ok := r["low"].(float64); ok {
+			m.Min = &low
+		}
+		if high, ok := r["high"].(float64); ok {
+			m.Max = &high
+		}
+	}
+	if m.Unit == "" && m.Min == nil && m.Max == nil && m.Description == "" {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestNewNodeMetadata(t *testing.T) {
+	m := newNodeMetadata(NodeAttributes{
+		NodeId: "ns=1;s=Temperature",
+		Attributes: map[string]interface{}{
+			"Description": "room temperature",
+			PropertyEngineeringUnits: AttributeValue(&ua.EUInformation{
+				DisplayName: &ua.LocalizedText{Text: "°C"},
+			}),
+			PropertyEURange: AttributeValue(&ua.Range{Low: -20, High: 80}),
+		},
+	})
+	assert.NotNil(t, m)
+	assert.Equal(t, "°C", m.Unit)
+	assert.Equal(t, -20.0, *m.Min)
+	assert.Equal(t, 80.0, *m.Max)
+	assert.Equal(t, "room temperature", m.Description)
+
+	b, err := json.Marshal(Data{NodeId: "ns=1;s=Temperature", NodeMetadata: m})
+	assert.Nil(t, err)
+	var v map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b, &v))
+	assert.Equal(t, "°C", v["unit"])
+	assert.Equal(t, -20.0, v["min"])
+	assert.Equal(t, 80.0, v["max"])
+
+	// 没有元数据
+	assert.Nil(t, newNodeMetadata(NodeAttributes{
+		NodeId:     "ns=1;s=Count",
+		Attributes: map[string]interface{}{"Description": ""},
+		Errors:     map[string]string{PropertyEURange: "not found"},
+	}))
+	b, err = json.Marshal(Data{NodeId: "ns=1;s=Count"})
+	assert.Nil(t, err)
+	v = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(b, &v))
+	_, ok := v["unit"]
+	assert.False(t, ok)
+}
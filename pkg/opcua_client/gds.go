@@ -0,0 +1,500 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// GdsNamespace GDS 信息模型命名空间
+const GdsNamespace = "http://opcfoundation.org/UA/GDS/"
+
+// 证书续期默认参数
+const (
+	DefaultRenewBefore   = 7 * 24 * time.Hour
+	DefaultCheckInterval = time.Hour
+	DefaultPollInterval  = 5 * time.Second
+	DefaultGdsTimeout    = 5 * time.Minute
+)
+
+// trustListReadSize 每次读取信任列表文件的字节数
+const trustListReadSize = 64 * 1024
+
+// GdsOptions GDS 证书拉取配置
+type GdsOptions struct {
+	// ApplicationId 应用在 GDS 中注册的 NodeId，例如：ns=2;g=...
+	ApplicationId string
+	// ApplicationUri 应用 URI，写入证书签名请求的 SubjectAltName
+	ApplicationUri string
+	// CommonName 证书主题 CN，为空使用 ApplicationUri
+	CommonName string
+	// CertificateGroupId 证书组 NodeId，为空使用 GDS 默认证书组
+	CertificateGroupId string
+	// CertificateTypeId 证书类型 NodeId，为空使用 GDS 默认证书类型
+	CertificateTypeId string
+	// KeySize 私钥长度，默认 2048
+	KeySize int
+	// CertFile 证书保存路径（PEM）
+	CertFile string
+	// KeyFile 私钥保存路径（PEM）
+	KeyFile string
+	// TrustListDir 信任列表保存目录，为空不拉取信任列表
+	// 目录结构：trusted/certs、trusted/crl、issuers/certs、issuers/crl
+	TrustListDir string
+	// RenewBefore 证书过期前多久续期，默认 7 天
+	RenewBefore time.Duration
+	// PollInterval 等待 GDS 签发证书的轮询间隔，默认 5 秒
+	PollInterval time.Duration
+	// Timeout 单次拉取超时时间，默认 5 分钟
+	Timeout time.Duration
+}
+
+// GdsClient GDS 证书拉取客户端，支持从 GDS 申请签名证书、拉取信任列表以及在证书过期前自动续期
+//
+//	gds := opcuaClient.NewGdsClient(opcuaClient.DefaultHolder(config), opcuaClient.GdsOptions{
+//		ApplicationId:  "ns=2;g=...",
+//		ApplicationUri: "urn:rulego:gateway",
+//		CertFile:       "/etc/rulego/pki/cert.pem",
+//		KeyFile:        "/etc/rulego/pki/key.pem",
+//		TrustListDir:   "/etc/rulego/pki",
+//	})
+//	gds.StartAutoRenew(opcuaClient.DefaultCheckInterval)
+//	defer gds.Stop()
+type GdsClient struct {
+	// Holder 连接 GDS 的客户端配置
+	Holder  *OpcUaClientHolder
+	Options GdsOptions
+	// OnRenew 证书更新后回调，可用于重建使用该证书的客户端
+	OnRenew func(cert *x509.Certificate)
+
+	lock   sync.Mutex
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewGdsClient 创建 GDS 证书拉取客户端
+func NewGdsClient(holder *OpcUaClientHolder, opts GdsOptions) *GdsClient {
+	if opts.KeySize <= 0 {
+		opts.KeySize = 2048
+	}
+	if opts.RenewBefore <= 0 {
+		opts.RenewBefore = DefaultRenewBefore
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultGdsTimeout
+	}
+	return &GdsClient{Holder: holder, Options: opts}
+}
+
+// Pull 连接 GDS 申请新的签名证书，保存证书、私钥和信任列表
+func (g *GdsClient) Pull(ctx context.Context) (*x509.Certificate, error) {
+	if g.Holder == nil {
+		return nil, errors.New("gds client holder is nil")
+	}
+	if g.Options.CertFile == "" || g.Options.KeyFile == "" {
+		return nil, errors.New("certFile and keyFile can not be empty")
+	}
+	applicationId, err := ua.ParseNodeID(g.Options.ApplicationId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid applicationId: %w", err)
+	}
+	groupId, err := parseOptionalNodeID(g.Options.CertificateGroupId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificateGroupId: %w", err)
+	}
+	typeId, err := parseOptionalNodeID(g.Options.CertificateTypeId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificateTypeId: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.Options.Timeout)
+	defer cancel()
+
+	key, csr, err := NewCertificateRequest(g.Options.ApplicationUri, g.Options.CommonName, g.Options.KeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := g.Holder.NewOpcUaClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close(context.Background())
+
+	directory, err := findDirectory(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	out, err := callMethod(ctx, client, directory, "StartSigningRequest",
+		applicationId, groupId, typeId, csr)
+	if err != nil {
+		return nil, err
+	}
+	requestId, ok := outputArg(out, 0).(*ua.NodeID)
+	if !ok {
+		return nil, errors.New("StartSigningRequest returned invalid requestId")
+	}
+
+	out, err = g.finishRequest(ctx, client, directory, applicationId, requestId)
+	if err != nil {
+		return nil, err
+	}
+	der, _ := outputArg(out, 0).([]byte)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse signed certificate: %w", err)
+	}
+	if !publicKeyEqual(cert, &key.PublicKey) {
+		return nil, errors.New("signed certificate does not match the private key")
+	}
+	issuers, _ := outputArg(out, 2).([][]byte)
+
+	if g.Options.TrustListDir != "" {
+		trustList, err := readTrustList(ctx, client, directory, applicationId, groupId)
+		if err != nil {
+			return nil, err
+		}
+		if err := WriteTrustList(g.Options.TrustListDir, trustList); err != nil {
+			return nil, err
+		}
+	} else if len(issuers) > 0 {
+		g.Holder.Printf("gds issuer certificates ignored, trustListDir is empty")
+	}
+
+	if err := writePem(g.Options.KeyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), 0600); err != nil {
+		return nil, err
+	}
+	if err := writePem(g.Options.CertFile, "CERTIFICATE", cert.Raw, 0644); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// finishRequest 轮询 FinishRequest，直到 GDS 签发证书
+func (g *GdsClient) finishRequest(ctx context.Context, client *opcua.Client, directory *ua.NodeID, applicationId, requestId *ua.NodeID) ([]*ua.Variant, error) {
+	ticker := time.NewTicker(g.Options.PollInterval)
+	defer ticker.Stop()
+	for {
+		out, err := callMethod(ctx, client, directory, "FinishRequest", applicationId, requestId)
+		if err == nil {
+			return out, nil
+		}
+		// 证书尚未签发
+		if !errors.Is(err, ua.StatusBadNothingToDo) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for signed certificate: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedsRenewal 证书不存在、无法解析或者即将过期时返回 true
+func (g *GdsClient) NeedsRenewal() bool {
+	cert, err := LoadCertificate(g.Options.CertFile)
+	if err != nil {
+		return true
+	}
+	return time.Now().Add(g.Options.RenewBefore).After(cert.NotAfter)
+}
+
+// Renew 证书需要续期时重新拉取
+func (g *GdsClient) Renew(ctx context.Context) error {
+	if !g.NeedsRenewal() {
+		return nil
+	}
+	cert, err := g.Pull(ctx)
+	if err != nil {
+		return err
+	}
+	g.Holder.Printf("gds certificate renewed, expires at %s", cert.NotAfter.Format(time.RFC3339))
+	if g.OnRenew != nil {
+		g.OnRenew(cert)
+	}
+	return nil
+}
+
+// StartAutoRenew 启动后台协程，每隔 checkInterval 检查证书并在过期前续期
+func (g *GdsClient) StartAutoRenew(checkInterval time.Duration) {
+	if checkInterval <= 0 {
+		checkInterval = DefaultCheckInterval
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.stopCh != nil {
+		return
+	}
+	stopCh := make(chan struct{})
+	g.stopCh = stopCh
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			if err := g.Renew(context.Background()); err != nil {
+				g.Holder.Printf("gds certificate renew error %v", err)
+			}
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止自动续期
+func (g *GdsClient) Stop() {
+	g.lock.Lock()
+	stopCh := g.stopCh
+	g.stopCh = nil
+	g.lock.Unlock()
+	if stopCh != nil {
+		close(stopCh)
+		g.wg.Wait()
+	}
+}
+
+// NewCertificateRequest 生成私钥和证书签名请求（DER），applicationUri 写入 SubjectAltName
+func NewCertificateRequest(applicationUri, commonName string, keySize int) (*rsa.PrivateKey, []byte, error) {
+	if applicationUri == "" {
+		return nil, nil, errors.New("applicationUri can not be empty")
+	}
+	uri, err := url.Parse(applicationUri)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid applicationUri: %w", err)
+	}
+	if commonName == "" {
+		commonName = applicationUri
+	}
+	if keySize <= 0 {
+		keySize = 2048
+	}
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+		URIs:    []*url.URL{uri},
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, csr, nil
+}
+
+// LoadCertificate 加载 PEM 或 DER 格式的证书
+func LoadCertificate(file string) (*x509.Certificate, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(b); block != nil {
+		b = block.Bytes
+	}
+	return x509.ParseCertificate(b)
+}
+
+// WriteTrustList 保存信任列表，证书以 DER 格式保存为 <sha1>.der，CRL 保存为 <sha1>.crl
+// 每个目录会先清空旧的文件，保证与 GDS 一致
+func WriteTrustList(dir string, trustList *ua.TrustListDataType) error {
+	if trustList == nil {
+		return errors.New("trust list is nil")
+	}
+	lists := []struct {
+		mask  uint32
+		path  string
+		ext   string
+		items [][]byte
+	}{
+		{uint32(ua.TrustListMasksTrustedCertificates), filepath.Join("trusted", "certs"), ".der", trustList.TrustedCertificates},
+		{uint32(ua.TrustListMasksTrustedCrls), filepath.Join("trusted", "crl"), ".crl", trustList.TrustedCrls},
+		{uint32(ua.TrustListMasksIssuerCertificates), filepath.Join("issuers", "certs"), ".der", trustList.IssuerCertificates},
+		{uint32(ua.TrustListMasksIssuerCrls), filepath.Join("issuers", "crl"), ".crl", trustList.IssuerCrls},
+	}
+	for _, l := range lists {
+		if trustList.SpecifiedLists&l.mask == 0 {
+			continue
+		}
+		p := filepath.Join(dir, l.path)
+		if err := os.MkdirAll(p, 0755); err != nil {
+			return err
+		}
+		old, err := filepath.Glob(filepath.Join(p, "*"+l.ext))
+		if err != nil {
+			return err
+		}
+		for _, f := range old {
+			if err := os.Remove(f); err != nil {
+				return err
+			}
+		}
+		for _, item := range l.items {
+			sum := sha1.Sum(item)
+			if err := os.WriteFile(filepath.Join(p, hex.EncodeToString(sum[:])+l.ext), item, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findDirectory 查找 GDS Directory 对象
+func findDirectory(ctx context.Context, client *opcua.Client) (*ua.NodeID, error) {
+	ns, err := client.FindNamespace(ctx, GdsNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("gds namespace not found: %w", err)
+	}
+	return client.Node(ua.NewNumericNodeID(0, id.ObjectsFolder)).TranslateBrowsePathInNamespaceToNodeID(ctx, ns, "Directory")
+}
+
+// readTrustList 通过 GetTrustList 获取信任列表文件，并使用 FileType 的 Open/Read/Close 方法读取
+func readTrustList(ctx context.Context, client *opcua.Client, directory, applicationId, groupId *ua.NodeID) (*ua.TrustListDataType, error) {
+	out, err := callMethod(ctx, client, directory, "GetTrustList", applicationId, groupId)
+	if err != nil {
+		return nil, err
+	}
+	trustListId, ok := outputArg(out, 0).(*ua.NodeID)
+	if !ok {
+		return nil, errors.New("GetTrustList returned invalid trustListId")
+	}
+	// 打开模式：Read
+	out, err = callMethodInNamespace(ctx, client, trustListId, 0, "Open", uint8(1))
+	if err != nil {
+		return nil, err
+	}
+	handle, ok := outputArg(out, 0).(uint32)
+	if !ok {
+		return nil, errors.New("open trust list returned invalid file handle")
+	}
+	defer func() {
+		_, _ = callMethodInNamespace(context.Background(), client, trustListId, 0, "Close", handle)
+	}()
+
+	var buf bytes.Buffer
+	for {
+		out, err = callMethodInNamespace(ctx, client, trustListId, 0, "Read", handle, int32(trustListReadSize))
+		if err != nil {
+			return nil, err
+		}
+		data, _ := outputArg(out, 0).([]byte)
+		buf.Write(data)
+		if len(data) < trustListReadSize {
+			break
+		}
+	}
+	trustList := new(ua.TrustListDataType)
+	if _, err := ua.Decode(buf.Bytes(), trustList); err != nil {
+		return nil, fmt.Errorf("decode trust list: %w", err)
+	}
+	return trustList, nil
+}
+
+// callMethod 调用 GDS 命名空间中对象的方法
+func callMethod(ctx context.Context, client *opcua.Client, object *ua.NodeID, method string, args ...interface{}) ([]*ua.Variant, error) {
+	ns, err := client.FindNamespace(ctx, GdsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return callMethodInNamespace(ctx, client, object, ns, method, args...)
+}
+
+// callMethodInNamespace 按浏览名称查找对象的方法并调用
+func callMethodInNamespace(ctx context.Context, client *opcua.Client, object *ua.NodeID, ns uint16, method string, args ...interface{}) ([]*ua.Variant, error) {
+	methodId, err := client.Node(object).TranslateBrowsePathInNamespaceToNodeID(ctx, ns, method)
+	if err != nil {
+		return nil, fmt.Errorf("method %s not found: %w", method, err)
+	}
+	inputs := make([]*ua.Variant, 0, len(args))
+	for _, arg := range args {
+		v, err := ua.NewVariant(arg)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, v)
+	}
+	res, err := client.Call(ctx, &ua.CallMethodRequest{
+		ObjectID:       object,
+		MethodID:       methodId,
+		InputArguments: inputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != ua.StatusOK {
+		return nil, fmt.Errorf("call %s: %w", method, res.StatusCode)
+	}
+	return res.OutputArguments, nil
+}
+
+func outputArg(out []*ua.Variant, i int) interface{} {
+	if i >= len(out) || out[i] == nil {
+		return nil
+	}
+	return out[i].Value()
+}
+
+// parseOptionalNodeID 解析可选的 NodeId，为空返回空 NodeId
+func parseOptionalNodeID(s string) (*ua.NodeID, error) {
+	if s == "" {
+		return ua.NewTwoByteNodeID(0), nil
+	}
+	return ua.ParseNodeID(s)
+}
+
+func publicKeyEqual(cert *x509.Certificate, key *rsa.PublicKey) bool {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	return ok && pub.Equal(key)
+}
+
+// writePem 先写入临时文件再重命名，避免读取到不完整的文件
+func writePem(file, blockType string, der []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestNewCertificateRequest(t *testing.T) {
+	key, der, err := NewCertificateRequest("urn:rulego:gateway", "", 1024)
+	assert.Nil(t, err)
+	csr, err := x509.ParseCertificateRequest(der)
+	assert.Nil(t, err)
+	assert.Nil(t, csr.CheckSignature())
+	assert.Equal(t, "urn:rulego:gateway", csr.Subject.CommonName)
+	assert.Equal(t, 1, len(csr.URIs))
+	assert.Equal(t, "urn:rulego:gateway", csr.URIs[0].String())
+	assert.True(t, key.PublicKey.Equal(csr.PublicKey))
+
+	_, _, err = NewCertificateRequest("", "", 1024)
+	assert.NotNil(t, err)
+}
+
+func TestGdsNeedsRenewal(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	g := NewGdsClient(DefaultHolder(nil), GdsOptions{CertFile: certFile, RenewBefore: 24 * time.Hour})
+
+	// 证书不存在
+	assert.True(t, g.NeedsRenewal())
+
+	key, _, err := NewCertificateRequest("urn:rulego:gateway", "", 1024)
+	assert.Nil(t, err)
+	writeCert := func(notAfter time.Time) {
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "gateway"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     notAfter,
+		}, &x509.Certificate{Subject: pkix.Name{CommonName: "gateway"}}, &key.PublicKey, key)
+		assert.Nil(t, err)
+		assert.Nil(t, writePem(certFile, "CERTIFICATE", der, 0644))
+	}
+
+	writeCert(time.Now().Add(30 * 24 * time.Hour))
+	assert.False(t, g.NeedsRenewal())
+	cert, err := LoadCertificate(certFile)
+	assert.Nil(t, err)
+	assert.True(t, publicKeyEqual(cert, &key.PublicKey))
+
+	writeCert(time.Now().Add(time.Hour))
+	assert.True(t, g.NeedsRenewal())
+}
+
+func TestWriteTrustList(t *testing.T) {
+	dir := t.TempDir()
+	err := WriteTrustList(dir, &ua.TrustListDataType{
+		SpecifiedLists:      uint32(ua.TrustListMasksTrustedCertificates | ua.TrustListMasksIssuerCertificates),
+		TrustedCertificates: [][]byte{{1, 2, 3}, {4, 5, 6}},
+		IssuerCertificates:  [][]byte{{7, 8, 9}},
+	})
+	assert.Nil(t, err)
+	trusted, _ := filepath.Glob(filepath.Join(dir, "trusted", "certs", "*.der"))
+	assert.Equal(t, 2, len(trusted))
+	issuers, _ := filepath.Glob(filepath.Join(dir, "issuers", "certs", "*.der"))
+	assert.Equal(t, 1, len(issuers))
+	_, err = os.Stat(filepath.Join(dir, "trusted", "crl"))
+	assert.True(t, os.IsNotExist(err))
+
+	// 重新写入时清除旧的证书
+	err = WriteTrustList(dir, &ua.TrustListDataType{
+		SpecifiedLists:      uint32(ua.TrustListMasksTrustedCertificates),
+		TrustedCertificates: [][]byte{{1, 2, 3}},
+	})
+	assert.Nil(t, err)
+	trusted, _ = filepath.Glob(filepath.Join(dir, "trusted", "certs", "*.der"))
+	assert.Equal(t, 1, len(trusted))
+	// 未指定的列表保持不变
+	issuers, _ = filepath.Glob(filepath.Join(dir, "issuers", "certs", "*.der"))
+	assert.Equal(t, 1, len(issuers))
+}
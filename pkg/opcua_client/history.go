@@ -0,0 +1,156 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// DefaultHistoryUpdateMode 默认历史数据更新方式
+const DefaultHistoryUpdateMode = "Insert"
+
+// HistoryValue 一条历史数据
+type HistoryValue struct {
+	// Value 值
+	Value interface{} `json:"value"`
+	// DataType 数据类型，为空时根据 JSON 值推断，取值同 NewVariant
+	DataType string `json:"dataType,omitempty"`
+	// SourceTime 数据产生时间，历史数据的时间戳
+	SourceTime time.Time `json:"sourceTime"`
+	// ServerTime 服务器时间，为空时不设置
+	ServerTime time.Time `json:"serverTime"`
+	// Quality 质量码，0 表示 Good
+	Quality uint32 `json:"quality"`
+}
+
+// HistoryUpdateItem 一个点位的历史数据
+type HistoryUpdateItem struct {
+	// NodeId 点位
+	NodeId string `json:"nodeId"`
+	// Mode 更新方式：Insert, Replace, Update，为空时使用默认方式
+	Mode string `json:"mode,omitempty"`
+	// Values 历史数据
+	Values []HistoryValue `json:"values"`
+}
+
+// ParsePerformUpdateType 解析历史数据更新方式，不区分大小写，空字符串返回 Insert
+// 只支持写入类的 Insert、Replace、Update，不支持 Remove
+func ParsePerformUpdateType(s string) (ua.PerformUpdateType, error) {
+	switch strings.ToLower(s) {
+	case "", "insert":
+		return ua.PerformUpdateTypeInsert, nil
+	case "replace":
+		return ua.PerformUpdateTypeReplace, nil
+	case "update":
+		return ua.PerformUpdateTypeUpdate, nil
+	default:
+		return 0, fmt.Errorf("invalid history update mode: %s", s)
+	}
+}
+
+// NewUpdateDataDetails 把点位历史数据转换为 UpdateDataDetails
+// mode 为点位没有指定更新方式时使用的默认方式
+func NewUpdateDataDetails(item HistoryUpdateItem, mode string) (*ua.UpdateDataDetails, error) {
+	nodeId, err := ua.ParseNodeID(item.NodeId)
+	if err != nil {
+		return nil, err
+	}
+	if item.Mode != "" {
+		mode = item.Mode
+	}
+	performType, err := ParsePerformUpdateType(mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(item.Values) == 0 {
+		return nil, fmt.Errorf("node %s has no history values", item.NodeId)
+	}
+	values := make([]*ua.DataValue, 0, len(item.Values))
+	for i, v := range item.Values {
+		if v.SourceTime.IsZero() {
+			return nil, fmt.Errorf("node %s value %d: sourceTime is required", item.NodeId, i)
+		}
+		variant, err := NewVariant(v.Value, v.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("node %s value %d: %w", item.NodeId, i, err)
+		}
+		dv := &ua.DataValue{
+			EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+			Value:           variant,
+			SourceTimestamp: v.SourceTime,
+		}
+		if v.Quality != 0 {
+			dv.EncodingMask |= ua.DataValueStatusCode
+			dv.Status = ua.StatusCode(v.Quality)
+		}
+		if !v.ServerTime.IsZero() {
+			dv.EncodingMask |= ua.DataValueServerTimestamp
+			dv.ServerTimestamp = v.ServerTime
+		}
+		values = append(values, dv)
+	}
+	return &ua.UpdateDataDetails{
+		NodeID:               nodeId,
+		PerformInsertReplace: performType,
+		UpdateValues:         values,
+	}, nil
+}
+
+// HistoryUpdate 写入历史数据，每个 details 对应返回一个结果
+func HistoryUpdate(ctx context.Context, client *opcua.Client, details []*ua.UpdateDataDetails) ([]*ua.HistoryUpdateResult, error) {
+	req := &ua.HistoryUpdateRequest{
+		HistoryUpdateDetails: make([]*ua.ExtensionObject, 0, len(details)),
+	}
+	for _, d := range details {
+		req.HistoryUpdateDetails = append(req.HistoryUpdateDetails, &ua.ExtensionObject{
+			TypeID:       ua.NewFourByteExpandedNodeID(0, id.UpdateDataDetails_Encoding_DefaultBinary),
+			EncodingMask: ua.ExtensionObjectBinary,
+			Value:        d,
+		})
+	}
+	var res *ua.HistoryUpdateResponse
+	err := client.Send(ctx, req, func(v ua.Response) error {
+		r, ok := v.(*ua.HistoryUpdateResponse)
+		if !ok {
+			return fmt.Errorf("invalid response: %T", v)
+		}
+		res = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.ResponseHeader != nil && res.ResponseHeader.ServiceResult != ua.StatusOK {
+		return nil, res.ResponseHeader.ServiceResult
+	}
+	if len(res.Results) != len(details) {
+		return nil, fmt.Errorf("history update returned %d results, expected %d", len(res.Results), len(details))
+	}
+	return res.Results, nil
+}
+
+// IsBadStatus 状态码是否为 Bad
+func IsBadStatus(status ua.StatusCode) bool {
+	return uint32(status)&0xC0000000 == 0x80000000
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParsePerformUpdateType(t *testing.T) {
+	mode, err := ParsePerformUpdateType("")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.PerformUpdateTypeInsert, mode)
+	mode, err = ParsePerformUpdateType("replace")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.PerformUpdateTypeReplace, mode)
+	mode, err = ParsePerformUpdateType("Update")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.PerformUpdateTypeUpdate, mode)
+	_, err = ParsePerformUpdateType("Remove")
+	assert.NotNil(t, err)
+}
+
+func TestNewUpdateDataDetails(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, err := NewUpdateDataDetails(HistoryUpdateItem{
+		NodeId: "ns=1;s=Temperature",
+		Values: []HistoryValue{
+			{Value: 12.5, SourceTime: ts},
+			{Value: 13, DataType: "Int16", SourceTime: ts.Add(time.Second), Quality: uint32(ua.StatusUncertain)},
+		},
+	}, "Replace")
+	assert.Nil(t, err)
+	assert.Equal(t, "ns=1;s=Temperature", d.NodeID.String())
+	assert.Equal(t, ua.PerformUpdateTypeReplace, d.PerformInsertReplace)
+	assert.Equal(t, 2, len(d.UpdateValues))
+	assert.Equal(t, 12.5, d.UpdateValues[0].Value.Value())
+	assert.Equal(t, ts, d.UpdateValues[0].SourceTimestamp)
+	assert.False(t, d.UpdateValues[0].Has(ua.DataValueStatusCode))
+	assert.Equal(t, int16(13), d.UpdateValues[1].Value.Value())
+	assert.Equal(t, ua.StatusUncertain, d.UpdateValues[1].Status)
+
+	// 点位指定的更新方式优先
+	d, err = NewUpdateDataDetails(HistoryUpdateItem{
+		NodeId: "ns=1;s=Temperature",
+		Mode:   "Insert",
+		Values: []HistoryValue{{Value: 1.0, SourceTime: ts}},
+	}, "Replace")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.PerformUpdateTypeInsert, d.PerformInsertReplace)
+
+	// 缺少时间戳
+	_, err = NewUpdateDataDetails(HistoryUpdateItem{
+		NodeId: "ns=1;s=Temperature",
+		Values: []HistoryValue{{Value: 1.0}},
+	}, "")
+	assert.NotNil(t, err)
+	// 没有历史数据
+	_, err = NewUpdateDataDetails(HistoryUpdateItem{NodeId: "ns=1;s=Temperature"}, "")
+	assert.NotNil(t, err)
+	// 非法点位
+	_, err = NewUpdateDataDetails(HistoryUpdateItem{
+		NodeId: "ns=abc;i=1",
+		Values: []HistoryValue{{Value: 1.0, SourceTime: ts}},
+	}, "")
+	assert.NotNil(t, err)
+}
+
+func TestIsBadStatus(t *testing.T) {
+	assert.False(t, IsBadStatus(ua.StatusOK))
+	assert.False(t, IsBadStatus(ua.StatusUncertain))
+	assert.True(t, IsBadStatus(ua.StatusBadServiceUnsupported))
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego-components-iot/pkg/health"
+)
+
+// 指标名称，标签均为 component（组件类型）和 server（OPC UA 服务器地址）
+const (
+	// MetricReads 读取请求次数
+	MetricReads = "opcua_reads_total"
+	// MetricReadErrors 读取请求失败次数
+	MetricReadErrors = "opcua_read_errors_total"
+	// MetricReadDuration 读取请求耗时（秒）
+	MetricReadDuration = "opcua_read_duration_seconds"
+	// MetricValuesReceived 收到的点位值数量，包括读取和订阅
+	MetricValuesReceived = "opcua_values_received_total"
+	// MetricBadQuality 质量码不为 Good 的点位值数量
+	MetricBadQuality = "opcua_bad_quality_total"
+	// MetricReconnects 客户端重连次数
+	MetricReconnects = "opcua_reconnects_total"
+	// MetricWriteErrors 写入失败的点位数量
+	MetricWriteErrors = "opcua_write_errors_total"
+	// MetricPollsDropped 背压策略跳过或者丢弃的定时读取次数
+	MetricPollsDropped = "opcua_polls_dropped_total"
+)
+
+// LabelComponent 组件类型标签，例如：endpoint/opcua、x/opcuaRead
+const LabelComponent = "component"
+
+// LabelServer OPC UA 服务器地址标签
+const LabelServer = "server"
+
+// DefaultBuckets 耗时直方图建议分桶（秒），供 MetricsHook 实现创建直方图时使用
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MetricHelps 指标说明，供 MetricsHook 实现注册指标时使用
+var MetricHelps = map[string]string{
+	MetricReads:          "Number of OPC UA read requests.",
+	MetricReadErrors:     "Number of failed OPC UA read requests.",
+	MetricReadDuration:   "OPC UA read request latency in seconds.",
+	MetricValuesReceived: "Number of OPC UA values received by reads and subscriptions.",
+	MetricBadQuality:     "Number of OPC UA values received with a non-good status code.",
+	MetricReconnects:     "Number of OPC UA client reconnects.",
+	MetricWriteErrors:    "Number of OPC UA nodes that failed to be written.",
+	MetricPollsDropped:   "Number of OPC UA polls skipped or dropped by the overlap policy.",
+}
+
+// MetricsHook 指标钩子，由调用方实现并对接 Prometheus 等监控系统，例如使用 prometheus/client_golang：
+//
+//	type promHook struct {
+//		counters   map[string]*prometheus.CounterVec
+//		histograms map[string]*prometheus.HistogramVec
+//	}
+//
+//	func (h *promHook) IncCounter(name string, labels map[string]string, delta float64) {
+//		h.counters[name].With(labels).Add(delta)
+//	}
+//
+//	func (h *promHook) ObserveHistogram(name string, labels map[string]string, value float64) {
+//		h.histograms[name].With(labels).Observe(value)
+//	}
+//
+//	opcuaClient.SetMetricsHook(&promHook{...})
+type MetricsHook interface {
+	// IncCounter 计数器增加 delta
+	IncCounter(name string, labels map[string]string, delta float64)
+	// ObserveHistogram 直方图记录一个观测值
+	ObserveHistogram(name string, labels map[string]string, value float64)
+}
+
+var (
+	metricsHook     MetricsHook
+	metricsHookLock sync.RWMutex
+)
+
+// SetMetricsHook 设置全局指标钩子，nil 表示不采集指标
+func SetMetricsHook(h MetricsHook) {
+	metricsHookLock.Lock()
+	defer metricsHookLock.Unlock()
+	metricsHook = h
+}
+
+// GetMetricsHook 获取全局指标钩子
+func GetMetricsHook() MetricsHook {
+	metricsHookLock.RLock()
+	defer metricsHookLock.RUnlock()
+	return metricsHook
+}
+
+func metricLabels(component, server string) map[string]string {
+	return map[string]string{LabelComponent: component, LabelServer: server}
+}
+
+// RecordRead 记录一次读取请求的次数、耗时、收到的点位值和质量码，同时上报健康状态
+func RecordRead(component, server string, start time.Time, resp *ua.ReadResponse, err error) {
+	health.Report(healthComponent, server, time.Since(start), err)
+	h := GetMetricsHook()
+	if h == nil {
+		return
+	}
+	labels := metricLabels(component, server)
+	h.IncCounter(MetricReads, labels, 1)
+	h.ObserveHistogram(MetricReadDuration, labels, time.Since(start).Seconds())
+	if err != nil {
+		h.IncCounter(MetricReadErrors, labels, 1)
+		return
+	}
+	if resp == nil {
+		return
+	}
+	statuses := make([]ua.StatusCode, 0, len(resp.Results))
+	for _, result := range resp.Results {
+		if result != nil {
+			statuses = append(statuses, result.Status)
+		}
+	}
+	RecordValues(component, server, statuses...)
+}
+
+// RecordValues 记录收到的点位值数量和质量码
+func RecordValues(component, server string, statuses ...ua.StatusCode) {
+	h := GetMetricsHook()
+	if h == nil || len(statuses) == 0 {
+		return
+	}
+	labels := metricLabels(component, server)
+	h.IncCounter(MetricValuesReceived, labels, float64(len(statuses)))
+	bad := 0
+	for _, status := range statuses {
+		if status != ua.StatusOK {
+			bad++
+		}
+	}
+	if bad > 0 {
+		h.IncCounter(MetricBadQuality, labels, float64(bad))
+	}
+}
+
+// RecordWriteErrors 记录写入失败的点位数量
+func RecordWriteErrors(component, server string, n int) {
+	h := GetMetricsHook()
+	if h == nil || n <= 0 {
+		return
+	}
+	h.IncCounter(MetricWriteErrors, metricLabels(component, server), float64(n))
+}
+
+// RecordPollsDropped 记录背压策略跳过或者丢弃的定时读取次数
+func RecordPollsDropped(component, server string, n int) {
+	h := GetMetricsHook()
+	if h == nil || n <= 0 {
+		return
+	}
+	h.IncCounter(MetricPollsDropped, metricLabels(component, server), float64(n))
+}
+
+// recordReconnect 记录客户端重连
+func recordReconnect(component, server string) {
+	if h := GetMetricsHook(); h != nil {
+		h.IncCounter(MetricReconnects, metricLabels(component, server), 1)
+	}
+}
+
+// healthComponent 上报健康状态使用的组件名称
+const healthComponent = "opcua"
+
+// watchState 监听客户端连接状态，统计重连次数并上报健康状态，客户端关闭后退出
+func watchState(component, server string, stateCh <-chan opcua.ConnState) {
+	for s := range stateCh {
+		switch s {
+		case opcua.Connected:
+			health.Connected(healthComponent, server)
+		case opcua.Disconnected:
+			health.Disconnected(healthComponent, server, nil)
+		case opcua.Reconnecting:
+			recordReconnect(component, server)
+			health.Disconnected(healthComponent, server, nil)
+		case opcua.Closed:
+			health.Remove(healthComponent, server)
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testMetricsHook 记录指标的测试钩子
+type testMetricsHook struct {
+	lock       sync.Mutex
+	counters   map[string]float64
+	histograms map[string]int
+}
+
+func newTestMetricsHook() *testMetricsHook {
+	return &testMetricsHook{counters: make(map[string]float64), histograms: make(map[string]int)}
+}
+
+func (h *testMetricsHook) IncCounter(name string, labels map[string]string, delta float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.counters[name+labels[LabelComponent]+labels[LabelServer]] += delta
+}
+
+func (h *testMetricsHook) ObserveHistogram(name string, labels map[string]string, value float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.histograms[name+labels[LabelComponent]+labels[LabelServer]]++
+}
+
+func (h *testMetricsHook) counter(name, component, server string) float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.counters[name+component+server]
+}
+
+func (h *testMetricsHook) histogramCount(name, component, server string) int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.histograms[name+component+server]
+}
+
+func TestMetricsHook(t *testing.T) {
+	hook := newTestMetricsHook()
+	SetMetricsHook(hook)
+	defer SetMetricsHook(nil)
+
+	server := "opc.tcp://localhost:4840"
+	RecordRead("x/opcuaRead", server, time.Now(), &ua.ReadResponse{
+		Results: []*ua.DataValue{
+			{Status: ua.StatusOK},
+			{Status: ua.StatusBadNodeIDUnknown},
+			{Status: ua.StatusOK},
+		},
+	}, nil)
+	RecordRead("x/opcuaRead", server, time.Now(), nil, errors.New("timeout"))
+	RecordValues("x/opcuaRead", server, ua.StatusOK)
+	RecordWriteErrors("x/opcuaRead", server, 2)
+	RecordWriteErrors("x/opcuaRead", server, 0)
+
+	assert.Equal(t, float64(2), hook.counter(MetricReads, "x/opcuaRead", server))
+	assert.Equal(t, float64(1), hook.counter(MetricReadErrors, "x/opcuaRead", server))
+	assert.Equal(t, float64(4), hook.counter(MetricValuesReceived, "x/opcuaRead", server))
+	assert.Equal(t, float64(1), hook.counter(MetricBadQuality, "x/opcuaRead", server))
+	assert.Equal(t, float64(2), hook.counter(MetricWriteErrors, "x/opcuaRead", server))
+	assert.Equal(t, 2, hook.histogramCount(MetricReadDuration, "x/opcuaRead", server))
+}
+
+func TestMetricsWatchState(t *testing.T) {
+	hook := newTestMetricsHook()
+	SetMetricsHook(hook)
+	defer SetMetricsHook(nil)
+
+	server := "opc.tcp://localhost:4840"
+	stateCh := make(chan opcua.ConnState, 8)
+	done := make(chan struct{})
+	go func() {
+		watchState("endpoint/opcua", server, stateCh)
+		close(done)
+	}()
+	stateCh <- opcua.Connected
+	stateCh <- opcua.Reconnecting
+	stateCh <- opcua.Connected
+	stateCh <- opcua.Reconnecting
+	stateCh <- opcua.Closed
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchState not exit after closed")
+	}
+	assert.Equal(t, float64(2), hook.counter(MetricReconnects, "endpoint/opcua", server))
+
+	// 没有设置钩子时不采集
+	SetMetricsHook(nil)
+	RecordWriteErrors("x/opcuaWrite", server, 1)
+	assert.Equal(t, float64(0), hook.counter(MetricWriteErrors, "x/opcuaWrite", server))
+}
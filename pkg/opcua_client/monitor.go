@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// 数据变化的触发条件
+const (
+	// TriggerStatus 只有状态变化时通知
+	TriggerStatus = "Status"
+	// TriggerStatusValue 状态或者值变化时通知，服务器默认
+	TriggerStatusValue = "StatusValue"
+	// TriggerStatusValueTimestamp 状态、值或者源时间戳变化时通知
+	TriggerStatusValueTimestamp = "StatusValueTimestamp"
+)
+
+// 队列满时的丢弃策略
+const (
+	// DiscardOldest 丢弃最早的值，默认
+	DiscardOldest = "oldest"
+	// DiscardNewest 丢弃最新的值
+	DiscardNewest = "newest"
+)
+
+// DefaultQueueSize 默认的监控队列大小
+const DefaultQueueSize = 10
+
+// MonitoringOptions 订阅中监控点位的采样参数
+// 作为订阅的默认参数时，未设置的字段使用 OPC UA 默认值；作为点位覆盖参数时，未设置的字段使用订阅的默认参数
+type MonitoringOptions struct {
+	// SamplingInterval 采样间隔（毫秒），0 表示未设置（默认由服务器按最快速度采样），-1 表示与发布间隔相同
+	SamplingInterval float64 `json:"samplingInterval" label:"Sampling Interval" desc:"Sampling interval in milliseconds, -1 uses the publishing interval, 0 means not set (the server samples as fast as possible)"`
+	// QueueSize 监控队列大小，0 表示未设置（默认 10）
+	QueueSize uint32 `json:"queueSize" label:"Queue Size" desc:"Monitored item queue size, 0 means not set (default 10)"`
+	// DiscardPolicy 队列满时的丢弃策略：oldest（默认）、newest
+	DiscardPolicy string `json:"discardPolicy" label:"Discard Policy" desc:"Value discarded when the queue is full: oldest (default), newest"`
+	// Trigger 数据变化的触发条件：Status、StatusValue（服务器默认）、StatusValueTimestamp
+	Trigger string `json:"trigger" label:"Trigger" desc:"Data change trigger: Status, StatusValue (server default), StatusValueTimestamp"`
+}
+
+// MonitoredItem 按点位覆盖的采样参数
+type MonitoredItem struct {
+	// NodeId 点位，eg. ns=2;s=Counter
+	NodeId            string `json:"nodeId" label:"Node ID" desc:"OPC UA node ID the options apply to"`
+	MonitoringOptions `json:",squash"`
+}
+
+// Validate 校验采样参数
+func (o MonitoringOptions) Validate() error {
+	if o.SamplingInterval < 0 && o.SamplingInterval != -1 {
+		return fmt.Errorf("invalid sampling interval: %v, must be -1 or not negative", o.SamplingInterval)
+	}
+	if _, err := parseDiscardOldest(o.DiscardPolicy); err != nil {
+		return err
+	}
+	_, err := parseTrigger(o.Trigger)
+	return err
+}
+
+// Merge 使用 override 中已设置的字段覆盖当前参数
+func (o MonitoringOptions) Merge(override MonitoringOptions) MonitoringOptions {
+	if override.SamplingInterval != 0 {
+		o.SamplingInterval = override.SamplingInterval
+	}
+	if override.QueueSize != 0 {
+		o.QueueSize = override.QueueSize
+	}
+	if override.DiscardPolicy != "" {
+		o.DiscardPolicy = override.DiscardPolicy
+	}
+	if override.Trigger != "" {
+		o.Trigger = override.Trigger
+	}
+	return o
+}
+
+// parameters 转换为监控参数
+func (o MonitoringOptions) parameters(clientHandle uint32) (*ua.MonitoringParameters, error) {
+	discardOldest, err := parseDiscardOldest(o.DiscardPolicy)
+	if err != nil {
+		return nil, err
+	}
+	trigger, err := parseTrigger(o.Trigger)
+	if err != nil {
+		return nil, err
+	}
+	params := &ua.MonitoringParameters{
+		ClientHandle:     clientHandle,
+		SamplingInterval: o.SamplingInterval,
+		QueueSize:        o.QueueSize,
+		DiscardOldest:    discardOldest,
+	}
+	if params.QueueSize == 0 {
+		params.QueueSize = DefaultQueueSize
+	}
+	if trigger != nil {
+		params.Filter = ua.NewExtensionObject(&ua.DataChangeFilter{
+			Trigger:      *trigger,
+			DeadbandType: uint32(ua.DeadbandTypeNone),
+		})
+	}
+	return params, nil
+}
+
+// NewMonitoredItems 创建点位的监控请求，clientHandle 为点位在 nodeIds 中的下标
+// 点位使用 defaults 参数，items 中的点位使用覆盖后的参数，点位按解析后的 NodeId 匹配
+func NewMonitoredItems(nodeIds []string, defaults MonitoringOptions, items []MonitoredItem) ([]*ua.MonitoredItemCreateRequest, error) {
+	overrides := make(map[string]MonitoringOptions, len(items))
+	for _, item := range items {
+		id, err := ua.ParseNodeID(item.NodeId)
+		if err != nil {
+			return nil, err
+		}
+		overrides[id.String()] = item.MonitoringOptions
+	}
+	requests := make([]*ua.MonitoredItemCreateRequest, 0, len(nodeIds))
+	for i, nodeId := range nodeIds {
+		id, err := ua.ParseNodeID(nodeId)
+		if err != nil {
+			return nil, err
+		}
+		options := defaults
+		if override, ok := overrides[id.String()]; ok {
+			options = defaults.Merge(override)
+		}
+		params, err := options.parameters(uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", nodeId, err)
+		}
+		requests = append(requests, &ua.MonitoredItemCreateRequest{
+			ItemToMonitor: &ua.ReadValueID{
+				NodeID:       id,
+				AttributeID:  ua.AttributeIDValue,
+				DataEncoding: &ua.QualifiedName{},
+			},
+			MonitoringMode:      ua.MonitoringModeReporting,
+			RequestedParameters: params,
+		})
+	}
+	return requests, nil
+}
+
+// ValidateMonitoredItems 校验订阅的默认参数和按点位覆盖的参数
+func ValidateMonitoredItems(defaults MonitoringOptions, items []MonitoredItem) error {
+	if err := defaults.Validate(); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := ua.ParseNodeID(item.NodeId); err != nil {
+			return err
+		}
+		if err := item.MonitoringOptions.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", item.NodeId, err)
+		}
+	}
+	return nil
+}
+
+// parseDiscardOldest 解析丢弃策略，为空丢弃最早的值
+func parseDiscardOldest(policy string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "", DiscardOldest:
+		return true, nil
+	case DiscardNewest:
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid discard policy: %s, must be one of oldest, newest", policy)
+	}
+}
+
+// parseTrigger 解析触发条件，为空返回 nil，不设置过滤器
+func parseTrigger(trigger string) (*ua.DataChangeTrigger, error) {
+	var t ua.DataChangeTrigger
+	switch strings.ToLower(strings.TrimSpace(trigger)) {
+	case "":
+		return nil, nil
+	case strings.ToLower(TriggerStatus):
+		t = ua.DataChangeTriggerStatus
+	case strings.ToLower(TriggerStatusValue):
+		t = ua.DataChangeTriggerStatusValue
+	case strings.ToLower(TriggerStatusValueTimestamp):
+		t = ua.DataChangeTriggerStatusValueTimestamp
+	default:
+		return nil, fmt.Errorf("invalid data change trigger: %s, must be one of Status, StatusValue, StatusValueTimestamp", trigger)
+	}
+	return &t, nil
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMonitoringOptions(t *testing.T) {
+	assert.Nil(t, MonitoringOptions{}.Validate())
+	assert.Nil(t, MonitoringOptions{SamplingInterval: -1, DiscardPolicy: "Newest", Trigger: "statusvaluetimestamp"}.Validate())
+	assert.True(t, MonitoringOptions{SamplingInterval: -2}.Validate() != nil)
+	assert.True(t, MonitoringOptions{DiscardPolicy: "random"}.Validate() != nil)
+	assert.True(t, MonitoringOptions{Trigger: "Value"}.Validate() != nil)
+
+	defaults := MonitoringOptions{SamplingInterval: 5000, QueueSize: 1, Trigger: TriggerStatusValue}
+	merged := defaults.Merge(MonitoringOptions{SamplingInterval: 100, DiscardPolicy: DiscardNewest})
+	assert.Equal(t, MonitoringOptions{SamplingInterval: 100, QueueSize: 1, DiscardPolicy: DiscardNewest, Trigger: TriggerStatusValue}, merged)
+	assert.Equal(t, defaults, defaults.Merge(MonitoringOptions{}))
+
+	assert.Nil(t, ValidateMonitoredItems(defaults, []MonitoredItem{{NodeId: "ns=2;s=Counter", MonitoringOptions: MonitoringOptions{QueueSize: 100}}}))
+	assert.True(t, ValidateMonitoredItems(defaults, []MonitoredItem{{NodeId: "ns=2;i=abc"}}) != nil)
+	assert.True(t, ValidateMonitoredItems(defaults, []MonitoredItem{{NodeId: "ns=2;s=Counter", MonitoringOptions: MonitoringOptions{Trigger: "x"}}}) != nil)
+}
+
+func TestNewMonitoredItems(t *testing.T) {
+	// 没有设置参数时与 gopcua 默认值相同
+	items, err := NewMonitoredItems([]string{"ns=2;s=Temperature"}, MonitoringOptions{}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	params := items[0].RequestedParameters
+	assert.Equal(t, uint32(0), params.ClientHandle)
+	assert.Equal(t, float64(0), params.SamplingInterval)
+	assert.Equal(t, uint32(DefaultQueueSize), params.QueueSize)
+	assert.True(t, params.DiscardOldest)
+	assert.True(t, params.Filter == nil)
+	assert.Equal(t, ua.AttributeIDValue, items[0].ItemToMonitor.AttributeID)
+	assert.Equal(t, ua.MonitoringModeReporting, items[0].MonitoringMode)
+
+	// 快速计数器和慢速温度共用一个订阅，点位按解析后的 NodeId 匹配
+	items, err = NewMonitoredItems([]string{"ns=2;s=Temperature", "ns=2;i=1001"},
+		MonitoringOptions{SamplingInterval: 5000},
+		[]MonitoredItem{{NodeId: "ns=2;i=1001", MonitoringOptions: MonitoringOptions{SamplingInterval: 50, QueueSize: 100, DiscardPolicy: DiscardNewest, Trigger: TriggerStatusValueTimestamp}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, float64(5000), items[0].RequestedParameters.SamplingInterval)
+	assert.Equal(t, uint32(DefaultQueueSize), items[0].RequestedParameters.QueueSize)
+	counter := items[1].RequestedParameters
+	assert.Equal(t, uint32(1), counter.ClientHandle)
+	assert.Equal(t, float64(50), counter.SamplingInterval)
+	assert.Equal(t, uint32(100), counter.QueueSize)
+	assert.False(t, counter.DiscardOldest)
+	filter, ok := counter.Filter.Value.(*ua.DataChangeFilter)
+	assert.True(t, ok)
+	assert.Equal(t, ua.DataChangeTriggerStatusValueTimestamp, filter.Trigger)
+	assert.Equal(t, uint32(ua.DeadbandTypeNone), filter.DeadbandType)
+
+	_, err = NewMonitoredItems([]string{"ns=2;i=abc"}, MonitoringOptions{}, nil)
+	assert.True(t, err != nil)
+	_, err = NewMonitoredItems([]string{"ns=2;s=Temperature"}, MonitoringOptions{Trigger: "x"}, nil)
+	assert.True(t, err != nil)
+}
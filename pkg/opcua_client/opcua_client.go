@@ -24,10 +24,14 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
 	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego-components-iot/pkg/quality"
 	"github.com/rulego/rulego/api/types"
 )
 
@@ -42,14 +46,35 @@ type Data struct {
 	RecordTime  time.Time   `json:"recordTime"`
 	SourceTime  time.Time   `json:"sourceTime"`
 	Value       interface{} `json:"value"`
-	Quality     uint32      `json:"quality"`
-	FloatValue  float64     `json:"floatValue"`
-	Timestamp   time.Time   `json:"timestamp"`
-	DataType    string      `json:"dataType"`
+	// Quality OPC UA StatusCode
+	Quality uint32 `json:"quality"`
+	// QualityLevel 归一化的质量：GOOD、UNCERTAIN、BAD，QualityCode 与 Quality 相同，和其他协议组件的输出保持一致
+	QualityLevel quality.Level `json:"qualityLevel"`
+	QualityCode  uint32        `json:"qualityCode"`
+	FloatValue   float64       `json:"floatValue"`
+	Timestamp    time.Time     `json:"timestamp"`
+	DataType     string        `json:"dataType"`
+	// Tag 使用 tag:<名称> 读取时对应的点位名称
+	Tag string `json:"tag,omitempty"`
+	// ArrayIndex 数组展开（ArrayOptions.Mode 为 explode）时在原数组中的下标，矩阵为每一维的下标
+	ArrayIndex []int `json:"arrayIndex,omitempty"`
+	// NodeMetadata 工程单位、量程和描述，开启 ReadOptions.Enrich 时填充
+	*NodeMetadata
+}
+
+// SetStatus 设置 StatusCode 和归一化的质量
+func (d *Data) SetStatus(status ua.StatusCode) {
+	q := quality.FromOPCUA(uint32(status))
+	d.Quality, d.QualityLevel, d.QualityCode = q.Code, q.Level, q.Code
 }
 
 // ParseValue 解析数据FloatValue
 func (d *Data) ParseValue() (*Data, error) {
+	return d.ParseValueFor(nil)
+}
+
+// ParseValueFor 解析数据FloatValue，自定义结构体使用 client 加载的结构体定义解码
+func (d *Data) ParseValueFor(client *opcua.Client) (*Data, error) {
 	var err error
 	if d != nil && d.Value != nil {
 		switch d.Value.(type) {
@@ -85,6 +110,19 @@ func (d *Data) ParseValue() (*Data, error) {
 			} else {
 				d.FloatValue = 0
 			}
+		case *ua.ExtensionObject:
+			// 自定义结构体解码为嵌套 JSON
+			d.Value, err = DecodeExtensionObject(client, d.Value.(*ua.ExtensionObject))
+		case []*ua.ExtensionObject:
+			arr := make([]interface{}, 0, len(d.Value.([]*ua.ExtensionObject)))
+			for _, eo := range d.Value.([]*ua.ExtensionObject) {
+				v, decodeErr := DecodeExtensionObject(client, eo)
+				if decodeErr != nil {
+					err = decodeErr
+				}
+				arr = append(arr, v)
+			}
+			d.Value = arr
 		default:
 			return nil, errors.New(fmt.Sprintf("Type conversion is not supported : %v", d))
 		}
@@ -125,6 +163,8 @@ type OpcUaClientHolder struct {
 	Ctx context.Context
 	// Logger 日志
 	Logger types.Logger
+	// Component 组件类型，作为指标的 component 标签
+	Component string
 	// endpointOptionsPrinted 跟踪是否已经打印过端点选项（避免重复打印）
 	endpointOptionsPrinted bool
 }
@@ -150,27 +190,41 @@ func (x *OpcUaClientHolder) NewOpcUaClient() (*opcua.Client, error) {
 	if x.Config == nil {
 		return nil, errors.New("config is nil")
 	}
+	// 解析 ${secret:key} 凭据占位符，解析后的配置只在创建客户端时使用，不保存到 holder
+	config, err := ResolveConfig(x.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err = ValidateSecurity(config.GetPolicy(), config.GetMode()); err != nil {
+		return nil, err
+	}
 	// Get a list of the endpoints for our target server
-	endpoints, err := opcua.GetEndpoints(x.Ctx, x.Config.GetServer())
+	endpoints, err := opcua.GetEndpoints(x.Ctx, config.GetServer())
 	if err != nil {
 		return nil, err
 	}
 	// Get the options to pass into the client based on the flags passed into the executable
-	opts := x.createOptions(endpoints)
+	opts := x.createOptions(config, endpoints)
+	// 监听连接状态，统计重连次数并上报健康状态
+	stateCh := make(chan opcua.ConnState, 8)
+	opts = append(opts, opcua.StateChangedCh(stateCh))
 	// Create a Client with the selected options
-	c, err := opcua.NewClient(x.Config.GetServer(), opts...)
+	c, err := opcua.NewClient(config.GetServer(), opts...)
 	if err != nil {
 		return nil, err
 	}
+	go watchState(x.Component, config.GetServer(), stateCh)
 	if err := c.Connect(x.Ctx); err != nil {
+		close(stateCh)
+		health.Disconnected(healthComponent, config.GetServer(), err)
 		return nil, err
 	}
 	return c, nil
 }
 
-// createOptions 构建Options
-func (x *OpcUaClientHolder) createOptions(endpoints []*ua.EndpointDescription) []opcua.Option {
-	if x.Config == nil {
+// createOptions 构建Options，config 为解析凭据后的配置
+func (x *OpcUaClientHolder) createOptions(config ConfigProp, endpoints []*ua.EndpointDescription) []opcua.Option {
+	if config == nil {
 		return []opcua.Option{}
 	}
 
@@ -181,8 +235,8 @@ func (x *OpcUaClientHolder) createOptions(endpoints []*ua.EndpointDescription) [
 	opts := []opcua.Option{}
 	var cert []byte
 	var privateKey *rsa.PrivateKey
-	if x.Config.GetCertFile() != "" && x.Config.GetCertKeyFile() != "" {
-		c, err := tls.LoadX509KeyPair(x.Config.GetCertFile(), x.Config.GetCertKeyFile())
+	if config.GetCertFile() != "" && config.GetCertKeyFile() != "" {
+		c, err := tls.LoadX509KeyPair(config.GetCertFile(), config.GetCertKeyFile())
 		if err == nil {
 			if pk, ok := c.PrivateKey.(*rsa.PrivateKey); ok {
 				cert = c.Certificate[0]
@@ -192,85 +246,25 @@ func (x *OpcUaClientHolder) createOptions(endpoints []*ua.EndpointDescription) [
 		}
 	}
 
-	var secPolicy string
-	policyLower := strings.ToLower(x.Config.GetPolicy())
-	switch {
-	case policyLower == "auto":
-		// set it later
-	case strings.HasPrefix(x.Config.GetPolicy(), ua.SecurityPolicyURIPrefix):
-		secPolicy = x.Config.GetPolicy()
-	case policyLower == "none":
-		secPolicy = ua.SecurityPolicyURIPrefix + "None"
-	case policyLower == "basic128rsa15":
-		secPolicy = ua.SecurityPolicyURIPrefix + "Basic128Rsa15"
-	case policyLower == "basic256":
-		secPolicy = ua.SecurityPolicyURIPrefix + "Basic256"
-	case policyLower == "basic256sha256":
-		secPolicy = ua.SecurityPolicyURIPrefix + "Basic256Sha256"
-	case policyLower == "aes128_sha256_rsaoaep":
-		secPolicy = ua.SecurityPolicyURIPrefix + "Aes128_Sha256_RsaOaep"
-	case policyLower == "aes256_sha256_rsapss":
-		secPolicy = ua.SecurityPolicyURIPrefix + "Aes256_Sha256_RsaPss"
-	default:
-		// Invalid security policy, secPolicy remains empty
+	// 配置已经在 NewOpcUaClient 中校验，无效的策略和模式按自动选择处理
+	secPolicy, _ := ParseSecurityPolicy(config.GetPolicy())
+	secMode, _ := ParseSecurityMode(config.GetMode())
+	if IsDeprecatedPolicy(secPolicy) {
+		x.Printf("opcua security policy %s is deprecated, use Basic256Sha256, Aes128_Sha256_RsaOaep or Aes256_Sha256_RsaPss instead", config.GetPolicy())
 	}
 
 	// Select the most appropriate authentication mode from server capabilities and user input
-	authMode, authOptions := x.authOption(cert, privateKey)
+	authMode, authOptions := x.authOption(config, cert, privateKey)
 	opts = append(opts, authOptions...)
 
-	var secMode ua.MessageSecurityMode
-	switch strings.ToLower(x.Config.GetMode()) {
-	case "auto":
-	case "none":
-		secMode = ua.MessageSecurityModeNone
-	case "sign":
-		secMode = ua.MessageSecurityModeSign
-	case "signandencrypt":
-		secMode = ua.MessageSecurityModeSignAndEncrypt
-	default:
-		// Invalid security mode, will use default
-	}
-
 	// Allow input of only one of sec-mode,sec-policy when choosing 'None'
 	if secMode == ua.MessageSecurityModeNone || secPolicy == ua.SecurityPolicyURINone {
 		secMode = ua.MessageSecurityModeNone
 		secPolicy = ua.SecurityPolicyURINone
 	}
 
-	// Find the best endpoint based on our input and server recommendation (highest SecurityMode+SecurityLevel)
-	var serverEndpoint *ua.EndpointDescription
-	switch {
-	case x.Config.GetMode() == "auto" && x.Config.GetPolicy() == "auto": // No user selection, choose best
-		for _, e := range endpoints {
-			if serverEndpoint == nil || (e.SecurityMode >= serverEndpoint.SecurityMode && e.SecurityLevel >= serverEndpoint.SecurityLevel) {
-				serverEndpoint = e
-			}
-		}
-
-	case x.Config.GetMode() != "auto" && x.Config.GetPolicy() == "auto": // User only cares about mode, select highest securitylevel with that mode
-		for _, e := range endpoints {
-			if e.SecurityMode == secMode && (serverEndpoint == nil || e.SecurityLevel >= serverEndpoint.SecurityLevel) {
-				serverEndpoint = e
-			}
-		}
-
-	case x.Config.GetMode() == "auto" && x.Config.GetPolicy() != "auto": // User only cares about policy, select highest securitylevel with that policy
-		for _, e := range endpoints {
-			if e.SecurityPolicyURI == secPolicy && (serverEndpoint == nil || e.SecurityLevel >= serverEndpoint.SecurityLevel) {
-				serverEndpoint = e
-			}
-		}
-
-	default: // User cares about both
-		fmt.Println("secMode: ", secMode, "secPolicy:", secPolicy)
-		for _, e := range endpoints {
-			if e.SecurityPolicyURI == secPolicy && e.SecurityMode == secMode && (serverEndpoint == nil || e.SecurityLevel >= serverEndpoint.SecurityLevel) {
-				serverEndpoint = e
-			}
-		}
-	}
-
+	// 选择双方都支持的最强端点，auto 时按策略强度、安全模式和服务器安全等级选择
+	serverEndpoint := selectEndpoint(endpoints, secPolicy, secMode, authMode, privateKey != nil)
 	if serverEndpoint == nil { // Didn't find an endpoint with matching policy and mode.
 		// 只在首次失败时打印端点选项，帮助用户了解正确配置
 		if !x.endpointOptionsPrinted && x.Logger != nil {
@@ -294,21 +288,21 @@ func (x *OpcUaClientHolder) createOptions(endpoints []*ua.EndpointDescription) [
 	return opts
 }
 
-func (x *OpcUaClientHolder) authOption(cert []byte, pk *rsa.PrivateKey) (ua.UserTokenType, []opcua.Option) {
-	if x.Config == nil {
+func (x *OpcUaClientHolder) authOption(config ConfigProp, cert []byte, pk *rsa.PrivateKey) (ua.UserTokenType, []opcua.Option) {
+	if config == nil {
 		return ua.UserTokenTypeAnonymous, []opcua.Option{opcua.AuthAnonymous()}
 	}
 
 	var authMode ua.UserTokenType
 	var authOptions []opcua.Option
-	switch strings.ToLower(x.Config.GetAuth()) {
+	switch strings.ToLower(config.GetAuth()) {
 	case "anonymous":
 		authMode = ua.UserTokenTypeAnonymous
 		authOptions = append(authOptions, opcua.AuthAnonymous())
 
 	case "username":
 		authMode = ua.UserTokenTypeUserName
-		authOptions = append(authOptions, opcua.AuthUsername(x.Config.GetUsername(), x.Config.GetPassword()))
+		authOptions = append(authOptions, opcua.AuthUsername(config.GetUsername(), config.GetPassword()))
 
 	case "certificate":
 		authMode = ua.UserTokenTypeCertificate
@@ -382,54 +376,373 @@ func (x *OpcUaClientHolder) printEndpointOptions(endpoints []*ua.EndpointDescrip
 	}
 }
 
+// DefaultReadConcurrency 分批读取默认并发数
+const DefaultReadConcurrency = 4
+
+// DefaultMaxAge 读取请求默认 MaxAge（毫秒）
+const DefaultMaxAge = 1000
+
+// DefaultTimestampsToReturn 读取请求默认返回的时间戳类型
+const DefaultTimestampsToReturn = "Both"
+
+// ReadOptions 读取选项
+type ReadOptions struct {
+	// BatchSize 每个读取请求最多包含的点位数量
+	// <=0 表示使用服务器 OperationLimits.MaxNodesPerRead，服务器未限制则不分批
+	BatchSize int
+	// Concurrency 分批读取时的最大并发请求数，<=0 使用 DefaultReadConcurrency
+	Concurrency int
+	// Timeout 单个读取请求超时时间，<=0 表示不设置超时
+	Timeout time.Duration
+	// MaxAge 允许服务器返回的缓存值最大时长（毫秒），0 表示要求服务器读取最新值
+	MaxAge float64
+	// TimestampsToReturn 返回的时间戳类型：Source、Server、Both、Neither，为空使用 DefaultTimestampsToReturn
+	TimestampsToReturn string
+	// Enrich 是否为读取结果补充工程单位（unit）、量程（min、max）和描述（description），点位元数据只读取一次并缓存
+	Enrich bool
+}
+
+// DefaultReadOptions 返回默认读取选项
+func DefaultReadOptions() ReadOptions {
+	return ReadOptions{
+		MaxAge:             DefaultMaxAge,
+		TimestampsToReturn: DefaultTimestampsToReturn,
+	}
+}
+
+// ParseTimestampsToReturn 解析时间戳类型，不区分大小写，为空返回 TimestampsToReturnBoth
+func ParseTimestampsToReturn(s string) (ua.TimestampsToReturn, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "both":
+		return ua.TimestampsToReturnBoth, nil
+	case "source":
+		return ua.TimestampsToReturnSource, nil
+	case "server":
+		return ua.TimestampsToReturnServer, nil
+	case "neither":
+		return ua.TimestampsToReturnNeither, nil
+	default:
+		return ua.TimestampsToReturnInvalid, fmt.Errorf("invalid timestampsToReturn: %s, must be one of Source, Server, Both, Neither", s)
+	}
+}
+
+// maxNodesPerRead 缓存服务器 MaxNodesPerRead 限制，key 为 *opcua.Client
+var maxNodesPerRead sync.Map
+
 // Read 读取点位数据
 func Read(client *opcua.Client, nodeIds []string) ([]Data, *ua.ReadResponse, error) {
-	ctx := context.Background()
-	allIds := make([]*ua.ReadValueID, 0)
-	data := make([]Data, 0)
+	return ReadContext(context.Background(), client, nodeIds)
+}
+
+// ReadContext 读取点位数据，ctx 取消时中止未完成的请求
+func ReadContext(ctx context.Context, client *opcua.Client, nodeIds []string) ([]Data, *ua.ReadResponse, error) {
+	return ReadWithOptionsContext(ctx, client, nodeIds, DefaultReadOptions())
+}
+
+// ReadWithOptions 读取点位数据，点位数量超过批次大小时自动拆分成多个请求并发读取，并按原顺序合并结果
+func ReadWithOptions(client *opcua.Client, nodeIds []string, opts ReadOptions) ([]Data, *ua.ReadResponse, error) {
+	return ReadWithOptionsContext(context.Background(), client, nodeIds, opts)
+}
+
+// ReadWithOptionsContext 同 ReadWithOptions，ctx 取消时（eg. 规则链超时、端点停机）中止未完成的请求，
+// opts.Timeout 为每个请求在 ctx 基础上的超时
+func ReadWithOptionsContext(ctx context.Context, client *opcua.Client, nodeIds []string, opts ReadOptions) ([]Data, *ua.ReadResponse, error) {
+	if _, err := ParseTimestampsToReturn(opts.TimestampsToReturn); err != nil {
+		return nil, nil, err
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		c, cancel := WithTimeout(ctx, opts.Timeout)
+		batchSize = serverMaxNodesPerRead(c, client)
+		cancel()
+	}
+	if batchSize <= 0 || len(nodeIds) <= batchSize {
+		return readBatch(ctx, client, nodeIds, opts)
+	}
+	return readBatches(nodeIds, batchSize, opts.Concurrency, func(ids []string) ([]Data, *ua.ReadResponse, error) {
+		return readBatch(ctx, client, ids, opts)
+	})
+}
+
+// WithTimeout 创建请求使用的 context，timeout<=0 不设置超时，parent 为 nil 使用 context.Background()
+func WithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// readBatches 按批次大小拆分点位，最多 concurrency 个批次并发读取，按原顺序合并结果，任意批次失败返回错误
+func readBatches(nodeIds []string, batchSize, concurrency int, read func(ids []string) ([]Data, *ua.ReadResponse, error)) ([]Data, *ua.ReadResponse, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultReadConcurrency
+	}
+	batches := (len(nodeIds) + batchSize - 1) / batchSize
+	batchData := make([][]Data, batches)
+	batchResp := make([]*ua.ReadResponse, batches)
+	batchErr := make([]error, batches)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < batches; i++ {
+		end := (i + 1) * batchSize
+		if end > len(nodeIds) {
+			end = len(nodeIds)
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, ids []string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			batchData[i], batchResp[i], batchErr[i] = read(ids)
+		}(i, nodeIds[i*batchSize:end])
+	}
+	wg.Wait()
+
+	data := make([]Data, 0, len(nodeIds))
+	resp := &ua.ReadResponse{Results: make([]*ua.DataValue, 0, len(nodeIds))}
+	for i := 0; i < batches; i++ {
+		if batchErr[i] != nil {
+			return nil, nil, batchErr[i]
+		}
+		if resp.ResponseHeader == nil {
+			resp.ResponseHeader = batchResp[i].ResponseHeader
+		}
+		data = append(data, batchData[i]...)
+		resp.Results = append(resp.Results, batchResp[i].Results...)
+		resp.DiagnosticInfos = append(resp.DiagnosticInfos, batchResp[i].DiagnosticInfos...)
+	}
+	return data, resp, nil
+}
+
+// ServerMaxNodesPerRead 获取服务器单次读取最大点位数量限制，0 表示未限制或无法获取
+// 读取请求失败时不缓存，下次调用时重试
+func ServerMaxNodesPerRead(client *opcua.Client) int {
+	return serverMaxNodesPerRead(context.Background(), client)
+}
+
+func serverMaxNodesPerRead(ctx context.Context, client *opcua.Client) int {
+	if client == nil {
+		return 0
+	}
+	if v, ok := maxNodesPerRead.Load(client); ok {
+		return v.(int)
+	}
+	limit := 0
+	req := &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{
+			{NodeID: ua.NewNumericNodeID(0, id.Server_ServerCapabilities_OperationLimits_MaxNodesPerRead), AttributeID: ua.AttributeIDValue},
+		},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	}
+	resp, err := client.Read(ctx, req)
+	if err != nil {
+		return 0
+	}
+	if len(resp.Results) > 0 && resp.Results[0].Status == ua.StatusOK && resp.Results[0].Value != nil {
+		if v, ok := resp.Results[0].Value.Value().(uint32); ok {
+			limit = int(v)
+		}
+	}
+	maxNodesPerRead.Store(client, limit)
+	return limit
+}
 
+// CloseClient 清除客户端的缓存后关闭客户端
+func CloseClient(client *opcua.Client) error {
+	maxNodesPerRead.Delete(client)
+	displayNames.Delete(client)
+	structureScopes.Delete(client)
+	ForgetMetadata(client)
+	return client.Close(context.Background())
+}
+
+// RegisterNodes 向服务器注册点位，返回注册后的点位列表，顺序与 nodeIds 一致
+// 周期读取大量点位时，使用注册后的点位可以减少服务器查找开销
+func RegisterNodes(ctx context.Context, client *opcua.Client, nodeIds []string) ([]string, error) {
+	ids := make([]*ua.NodeID, 0, len(nodeIds))
 	for _, nodeId := range nodeIds {
 		id, err := ua.ParseNodeID(nodeId)
 		if err != nil {
-			logger.Printf("parse node id error %v ", err)
-			return nil, nil, err
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	resp, err := client.RegisterNodes(ctx, &ua.RegisterNodesRequest{NodesToRegister: ids})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.RegisteredNodeIDs) != len(nodeIds) {
+		return nil, fmt.Errorf("register nodes: expected %d node ids, got %d", len(nodeIds), len(resp.RegisteredNodeIDs))
+	}
+	registered := make([]string, 0, len(resp.RegisteredNodeIDs))
+	for _, id := range resp.RegisteredNodeIDs {
+		registered = append(registered, id.String())
+	}
+	// 注册时缓存显示名称，周期读取不再逐个查询
+	names, err := readDisplayNames(ctx, client, ids)
+	if err != nil {
+		logger.Printf("read display names error: %v", err)
+	} else {
+		cache := displayNameCache(client)
+		for i, id := range registered {
+			if names[i] != "" {
+				cache.Store(id, names[i])
+			}
+		}
+	}
+	return registered, nil
+}
+
+// UnregisterNodes 注销通过 RegisterNodes 注册的点位
+func UnregisterNodes(ctx context.Context, client *opcua.Client, nodeIds []string) error {
+	ids := make([]*ua.NodeID, 0, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		id, err := ua.ParseNodeID(nodeId)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	_, err := client.UnregisterNodes(ctx, &ua.UnregisterNodesRequest{NodesToUnregister: ids})
+	cache := displayNameCache(client)
+	for _, id := range ids {
+		cache.Delete(id.String())
+	}
+	return err
+}
+
+// displayNames 点位显示名称缓存，key 为客户端，value 为 nodeId -> 显示名称
+var displayNames sync.Map
+
+// displayNameCache 获取客户端的显示名称缓存
+func displayNameCache(client *opcua.Client) *sync.Map {
+	v, _ := displayNames.LoadOrStore(client, &sync.Map{})
+	return v.(*sync.Map)
+}
+
+// cachedDisplayNames 获取点位显示名称，缓存中没有的点位合并为一个请求读取，读取成功后缓存
+func cachedDisplayNames(ctx context.Context, client *opcua.Client, ids []*ua.NodeID) ([]string, error) {
+	cache := displayNameCache(client)
+	names := make([]string, len(ids))
+	missing := make([]*ua.NodeID, 0)
+	missingIndex := make([]int, 0)
+	for i, id := range ids {
+		if v, ok := cache.Load(id.String()); ok {
+			names[i] = v.(string)
 		} else {
-			allIds = append(allIds, &ua.ReadValueID{NodeID: id})
+			missing = append(missing, id)
+			missingIndex = append(missingIndex, i)
+		}
+	}
+	if len(missing) == 0 {
+		return names, nil
+	}
+	read, err := readDisplayNames(ctx, client, missing)
+	if err != nil {
+		return nil, err
+	}
+	for k, i := range missingIndex {
+		names[i] = read[k]
+		if read[k] != "" {
+			cache.Store(ids[i].String(), read[k])
+		}
+	}
+	return names, nil
+}
+
+// readDisplayNames 一个请求读取多个点位的显示名称，读取失败的点位返回空字符串
+func readDisplayNames(ctx context.Context, client *opcua.Client, ids []*ua.NodeID) ([]string, error) {
+	nodesToRead := make([]*ua.ReadValueID, 0, len(ids))
+	for _, id := range ids {
+		nodesToRead = append(nodesToRead, &ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDDisplayName})
+	}
+	resp, err := client.Read(ctx, &ua.ReadRequest{
+		NodesToRead:        nodesToRead,
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ids))
+	for i, result := range resp.Results {
+		if i >= len(names) || result == nil || result.Status != ua.StatusOK || result.Value == nil {
+			continue
 		}
-		n := client.Node(id)
-		lt, err := n.DisplayName(ctx)
+		if lt, ok := result.Value.Value().(*ua.LocalizedText); ok && lt != nil {
+			names[i] = lt.Text
+		}
+	}
+	return names, nil
+}
+
+// readBatch 单个请求读取点位数据
+func readBatch(ctx context.Context, client *opcua.Client, nodeIds []string, opts ReadOptions) ([]Data, *ua.ReadResponse, error) {
+	ctx, cancel := WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	timestampsToReturn, err := ParseTimestampsToReturn(opts.TimestampsToReturn)
+	if err != nil {
+		return nil, nil, err
+	}
+	allIds := make([]*ua.ReadValueID, 0, len(nodeIds))
+	ids := make([]*ua.NodeID, 0, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		id, err := ua.ParseNodeID(nodeId)
 		if err != nil {
+			logger.Printf("parse node id error %v ", err)
 			return nil, nil, err
 		}
-		data = append(data, Data{NodeId: id.String(), DisplayName: lt.Text})
+		allIds = append(allIds, &ua.ReadValueID{NodeID: id})
+		ids = append(ids, id)
+	}
+	names, err := cachedDisplayNames(ctx, client, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := make([]Data, 0, len(ids))
+	for i, id := range ids {
+		data = append(data, Data{NodeId: id.String(), DisplayName: names[i]})
 	}
 
 	req := &ua.ReadRequest{
-		MaxAge:             1000,
+		MaxAge:             opts.MaxAge,
 		NodesToRead:        allIds,
-		TimestampsToReturn: ua.TimestampsToReturnBoth,
+		TimestampsToReturn: timestampsToReturn,
 	}
 	var resp *ua.ReadResponse
-	resp, err := client.Read(ctx, req)
+	resp, err = client.Read(ctx, req)
+	if err == nil && loadUnknownStructures(ctx, client, resp.Results) {
+		// 加载自定义结构体定义后重新读取，获取结构体原始内容
+		resp, err = client.Read(ctx, req)
+	}
 	if err != nil {
 		logger.Printf("point read error: %v", err)
 		return nil, nil, err
 	} else {
 		for i, result := range resp.Results {
-			if result != nil && result.Status == ua.StatusOK {
+			// UNCERTAIN 的值也输出，由 qualityLevel 区分
+			if result != nil && !quality.FromOPCUA(uint32(result.Status)).IsBad() {
 				d := Data{
 					DisplayName: data[i].DisplayName,
 					NodeId:      data[i].NodeId,
 					RecordTime:  result.ServerTimestamp,
 					SourceTime:  result.SourceTimestamp,
-					Value:       result.Value.Value(),
-					Quality:     uint32(result.Status),
+					Value:       VariantValue(result.Value),
 					Timestamp:   time.Now(),
 				}
-				_, _ = d.ParseValue()
+				d.SetStatus(result.Status)
+				_, _ = d.ParseValueFor(client)
 				data[i] = d
 			}
 		}
 	}
+	if opts.Enrich {
+		enrich(ctx, client, data)
+	}
 	return data, resp, nil
 }
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestReadBatches(t *testing.T) {
+	nodeIds := make([]string, 0, 10)
+	for i := 0; i < 10; i++ {
+		nodeIds = append(nodeIds, "ns=1;i="+string(rune('0'+i)))
+	}
+	var running, maxRunning, calls int32
+	read := func(ids []string) ([]Data, *ua.ReadResponse, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&calls, 1)
+		data := make([]Data, 0, len(ids))
+		resp := &ua.ReadResponse{ResponseHeader: &ua.ResponseHeader{}}
+		for _, id := range ids {
+			data = append(data, Data{NodeId: id})
+			resp.Results = append(resp.Results, &ua.DataValue{Status: ua.StatusOK})
+		}
+		return data, resp, nil
+	}
+
+	// 按批次拆分并保持原顺序
+	data, resp, err := readBatches(nodeIds, 3, 2, read)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), calls)
+	assert.True(t, maxRunning <= 2)
+	assert.Equal(t, 10, len(data))
+	assert.Equal(t, 10, len(resp.Results))
+	assert.NotNil(t, resp.ResponseHeader)
+	for i, d := range data {
+		assert.Equal(t, nodeIds[i], d.NodeId)
+	}
+
+	// 任意批次失败返回错误
+	_, _, err = readBatches(nodeIds, 4, 0, func(ids []string) ([]Data, *ua.ReadResponse, error) {
+		if strings.HasSuffix(ids[0], "4") {
+			return nil, nil, errors.New("batch failed")
+		}
+		return read(ids)
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "batch failed", err.Error())
+}
+
+func TestParseTimestampsToReturn(t *testing.T) {
+	v, err := ParseTimestampsToReturn("")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.TimestampsToReturnBoth, v)
+	v, err = ParseTimestampsToReturn(" Source ")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.TimestampsToReturnSource, v)
+	v, err = ParseTimestampsToReturn("server")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.TimestampsToReturnServer, v)
+	v, err = ParseTimestampsToReturn("NEITHER")
+	assert.Nil(t, err)
+	assert.Equal(t, ua.TimestampsToReturnNeither, v)
+	_, err = ParseTimestampsToReturn("all")
+	assert.NotNil(t, err)
+
+	opts := DefaultReadOptions()
+	assert.Equal(t, float64(DefaultMaxAge), opts.MaxAge)
+	assert.Equal(t, DefaultTimestampsToReturn, opts.TimestampsToReturn)
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), 0)
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	ctx, cancel = WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.True(t, ok)
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+
+	// 父 context 取消时（eg. 端点停机）请求也被取消
+	parent, stop := context.WithCancel(context.Background())
+	ctx, cancel = WithTimeout(parent, time.Minute)
+	defer cancel()
+	stop()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
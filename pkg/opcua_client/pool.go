@@ -0,0 +1,179 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/gopcua/opcua"
+)
+
+// ErrPoolClosed 会话池已经关闭
+var ErrPoolClosed = errors.New("opcua session pool is closed")
+
+// pools 全局会话池，key 为 poolKey
+var (
+	poolsLock sync.Mutex
+	pools     = map[string]*SessionPool{}
+)
+
+// SessionPool OPC UA 会话池，服务器地址和连接安全配置都相同的多个节点共享
+// 每个槽位持有一个独立会话，Get 按轮询方式分配，用于提高服务器允许多会话时的并发读写吞吐量
+// 会话在第一次分配时创建，断开后在下次分配时重新创建
+type SessionPool struct {
+	key    string
+	server string
+	holder *OpcUaClientHolder
+	mu     sync.Mutex
+	// slots 会话槽位
+	slots []*poolSlot
+	// next 下一次分配的槽位
+	next int
+	// refs 引用计数，为 0 时关闭所有会话
+	refs   int
+	closed bool
+}
+
+// poolSlot 会话槽位，创建会话时只锁定当前槽位，不影响其他槽位分配
+type poolSlot struct {
+	mu sync.Mutex
+	// client 会话，nil 表示尚未创建
+	client *opcua.Client
+}
+
+// poolKey 会话池 key，由服务器地址、认证方式、凭证摘要、安全策略、安全模式和证书路径组成
+// 连接同一个服务器但认证或安全配置不同的节点使用不同的会话池，避免使用其他节点的身份访问服务器
+func poolKey(config ConfigProp) string {
+	credentials := sha256.Sum256([]byte(config.GetUsername() + "\x00" + config.GetPassword()))
+	return strings.Join([]string{
+		config.GetServer(),
+		config.GetAuth(),
+		hex.EncodeToString(credentials[:]),
+		config.GetPolicy(),
+		config.GetMode(),
+		config.GetCertFile(),
+		config.GetCertKeyFile(),
+	}, "|")
+}
+
+// AcquireSessionPool 获取连接配置对应的会话池，不存在则创建，引用计数加1
+// 多个节点请求的大小不同时取最大值，不再使用时需要调用 Release
+func AcquireSessionPool(holder *OpcUaClientHolder, size int) *SessionPool {
+	if size < 1 {
+		size = 1
+	}
+	key := poolKey(holder.Config)
+	poolsLock.Lock()
+	defer poolsLock.Unlock()
+	p, ok := pools[key]
+	if !ok {
+		p = &SessionPool{key: key, server: holder.Config.GetServer(), holder: holder}
+		pools[key] = p
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.slots) < size {
+		p.slots = append(p.slots, &poolSlot{})
+	}
+	p.refs++
+	return p
+}
+
+// Size 会话池大小
+func (p *SessionPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.slots)
+}
+
+// Get 按轮询方式分配一个会话，会话未创建或者已关闭时重新创建
+func (p *SessionPool) Get() (*opcua.Client, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	slot := p.slots[p.next]
+	p.next = (p.next + 1) % len(p.slots)
+	p.mu.Unlock()
+
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if slot.client != nil && slot.client.State() != opcua.Closed {
+		return slot.client, nil
+	}
+	if slot.client != nil {
+		_ = CloseClient(slot.client)
+		slot.client = nil
+	}
+	client, err := p.holder.NewOpcUaClient()
+	if err != nil {
+		p.holder.Printf("opcua session pool %s connect error: %v", p.server, err)
+		return nil, err
+	}
+	// 创建期间会话池被关闭
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		_ = CloseClient(client)
+		return nil, ErrPoolClosed
+	}
+	slot.client = client
+	return client, nil
+}
+
+// Release 引用计数减1，为 0 时关闭所有会话并从全局移除
+func (p *SessionPool) Release() error {
+	poolsLock.Lock()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		poolsLock.Unlock()
+		return nil
+	}
+	p.refs--
+	if p.refs > 0 {
+		p.mu.Unlock()
+		poolsLock.Unlock()
+		return nil
+	}
+	p.closed = true
+	if pools[p.key] == p {
+		delete(pools, p.key)
+	}
+	slots := p.slots
+	p.mu.Unlock()
+	poolsLock.Unlock()
+
+	var errs []error
+	for _, slot := range slots {
+		slot.mu.Lock()
+		if slot.client != nil {
+			if err := CloseClient(slot.client); err != nil {
+				errs = append(errs, err)
+			}
+			slot.client = nil
+		}
+		slot.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrSecretNotFound 凭据不存在
+var ErrSecretNotFound = errors.New("secret not found")
+
+// secretPattern 凭据占位符，例如：${secret:opcua/password}
+var secretPattern = regexp.MustCompile(`\$\{secret:([^}]+)\}`)
+
+// envKeyPattern 环境变量名称中需要替换为下划线的字符
+var envKeyPattern = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// SecretProvider 凭据提供者，根据 key 获取用户名、密码、证书路径等敏感配置
+// key 不存在时返回 ErrSecretNotFound
+type SecretProvider interface {
+	GetSecret(key string) (string, error)
+}
+
+// SecretProviderFunc 使用函数实现 SecretProvider
+type SecretProviderFunc func(key string) (string, error)
+
+func (f SecretProviderFunc) GetSecret(key string) (string, error) {
+	return f(key)
+}
+
+// EnvSecretProvider 从环境变量获取凭据
+// key 转换为大写，非字母数字字符替换为下划线，例如：opcua/password -> OPCUA_PASSWORD
+type EnvSecretProvider struct {
+	// Prefix 环境变量前缀，例如：RULEGO_
+	Prefix string
+}
+
+func (p EnvSecretProvider) GetSecret(key string) (string, error) {
+	name := p.Prefix + strings.ToUpper(envKeyPattern.ReplaceAllString(key, "_"))
+	if v, ok := os.LookupEnv(name); ok {
+		return v, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+// FileSecretProvider 从文件获取凭据，文件路径为 Dir/key，内容去除末尾换行符
+// 适用于 docker/kubernetes secrets 挂载目录，例如：/run/secrets
+type FileSecretProvider struct {
+	Dir string
+}
+
+func (p FileSecretProvider) GetSecret(key string) (string, error) {
+	dir := filepath.Clean(p.Dir)
+	path := filepath.Join(dir, filepath.FromSlash(key))
+	// 不允许访问 Dir 以外的文件，Join 会清理 a/../../x 这类路径，再以清理后的相对路径判断
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid secret key: %s", key)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// ChainSecretProvider 依次从多个提供者获取凭据，返回第一个找到的值
+type ChainSecretProvider []SecretProvider
+
+func (c ChainSecretProvider) GetSecret(key string) (string, error) {
+	for _, p := range c {
+		v, err := p.GetSecret(key)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrSecretNotFound) {
+			return "", err
+		}
+	}
+	return "", ErrSecretNotFound
+}
+
+var (
+	secretProvider     SecretProvider = EnvSecretProvider{}
+	secretProviderLock sync.RWMutex
+)
+
+// SetSecretProvider 设置全局凭据提供者，默认使用 EnvSecretProvider
+func SetSecretProvider(p SecretProvider) {
+	secretProviderLock.Lock()
+	defer secretProviderLock.Unlock()
+	secretProvider = p
+}
+
+// GetSecretProvider 获取全局凭据提供者
+func GetSecretProvider() SecretProvider {
+	secretProviderLock.RLock()
+	defer secretProviderLock.RUnlock()
+	return secretProvider
+}
+
+// ResolveSecrets 替换字符串中的 ${secret:key} 占位符，没有占位符则原样返回
+func ResolveSecrets(s string) (string, error) {
+	if !strings.Contains(s, "${secret:") {
+		return s, nil
+	}
+	p := GetSecretProvider()
+	if p == nil {
+		return "", errors.New("secret provider is nil")
+	}
+	var resolveErr error
+	result := secretPattern.ReplaceAllStringFunc(s, func(m string) string {
+		key := strings.TrimSpace(secretPattern.FindStringSubmatch(m)[1])
+		v, err := p.GetSecret(key)
+		if err != nil && resolveErr == nil {
+			resolveErr = fmt.Errorf("resolve secret %s: %w", key, err)
+		}
+		return v
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return result, nil
+}
+
+// secretConfig 凭据解析后的客户端配置
+type secretConfig struct {
+	ConfigProp
+	username    string
+	password    string
+	certFile    string
+	certKeyFile string
+}
+
+func (c secretConfig) GetUsername() string {
+	return c.username
+}
+func (c secretConfig) GetPassword() string {
+	return c.password
+}
+func (c secretConfig) GetCertFile() string {
+	return c.certFile
+}
+func (c secretConfig) GetCertKeyFile() string {
+	return c.certKeyFile
+}
+
+// ResolveConfig 解析配置中 Username、Password、CertFile、CertKeyFile 的凭据占位符
+func ResolveConfig(c ConfigProp) (ConfigProp, error) {
+	if c == nil {
+		return nil, errors.New("config is nil")
+	}
+	var err error
+	r := secretConfig{ConfigProp: c}
+	if r.username, err = ResolveSecrets(c.GetUsername()); err != nil {
+		return nil, err
+	}
+	if r.password, err = ResolveSecrets(c.GetPassword()); err != nil {
+		return nil, err
+	}
+	if r.certFile, err = ResolveSecrets(c.GetCertFile()); err != nil {
+		return nil, err
+	}
+	if r.certKeyFile, err = ResolveSecrets(c.GetCertKeyFile()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testConfig 测试用客户端配置
+type testConfig struct {
+	server, username, password, certFile, certKeyFile string
+}
+
+func (c testConfig) GetServer() string      { return c.server }
+func (c testConfig) GetPolicy() string      { return "None" }
+func (c testConfig) GetMode() string        { return "None" }
+func (c testConfig) GetAuth() string        { return "UserName" }
+func (c testConfig) GetUsername() string    { return c.username }
+func (c testConfig) GetPassword() string    { return c.password }
+func (c testConfig) GetCertFile() string    { return c.certFile }
+func (c testConfig) GetCertKeyFile() string { return c.certKeyFile }
+
+func TestResolveConfig(t *testing.T) {
+	t.Setenv("OPCUA_USERNAME", "admin")
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(dir+"/opcua", 0755))
+	assert.Nil(t, os.WriteFile(dir+"/opcua/password", []byte("123456\n"), 0600))
+	SetSecretProvider(ChainSecretProvider{
+		EnvSecretProvider{},
+		FileSecretProvider{Dir: dir},
+		SecretProviderFunc(func(key string) (string, error) {
+			if key == "opcua/cert" {
+				return "/etc/opcua/cert.pem", nil
+			}
+			return "", ErrSecretNotFound
+		}),
+	})
+	defer SetSecretProvider(EnvSecretProvider{})
+
+	config, err := ResolveConfig(testConfig{
+		server:   "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer",
+		username: "${secret:opcua/username}",
+		password: "${secret:opcua/password}",
+		certFile: "${secret:opcua/cert}",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "opc.tcp://127.0.0.1:53530/OPCUA/SimulationServer", config.GetServer())
+	assert.Equal(t, "admin", config.GetUsername())
+	assert.Equal(t, "123456", config.GetPassword())
+	assert.Equal(t, "/etc/opcua/cert.pem", config.GetCertFile())
+	assert.Equal(t, "", config.GetCertKeyFile())
+
+	_, err = ResolveConfig(testConfig{password: "${secret:opcua/not_exist}"})
+	assert.NotNil(t, err)
+
+	// 解析后的凭据不保存到 holder
+	holder := DefaultHolder(testConfig{server: "opc.tcp://127.0.0.1:1", password: "${secret:opcua/password}"})
+	_, err = holder.NewOpcUaClient()
+	assert.NotNil(t, err)
+	assert.Equal(t, "${secret:opcua/password}", holder.Config.GetPassword())
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "secrets")
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "a"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "a", "password"), []byte("123456\r\n"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "outside"), []byte("leak"), 0600))
+	p := FileSecretProvider{Dir: dir}
+
+	v, err := p.GetSecret("a/password")
+	assert.Nil(t, err)
+	assert.Equal(t, "123456", v)
+	v, err = p.GetSecret("a/../a/password")
+	assert.Nil(t, err)
+	assert.Equal(t, "123456", v)
+
+	_, err = p.GetSecret("a/not_exist")
+	assert.Equal(t, ErrSecretNotFound, err)
+
+	// 不允许访问 Dir 以外的文件
+	for _, key := range []string{"../outside", "a/../../outside", "a/../..", ".", ""} {
+		_, err = p.GetSecret(key)
+		assert.NotNil(t, err)
+		assert.NotEqual(t, ErrSecretNotFound, err)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// SecurityAuto 安全策略或者安全模式为 auto 时，从服务器端点中选择双方都支持的最强组合
+const SecurityAuto = "auto"
+
+// policyStrength 安全策略强度，值越大越强，auto 时优先选择强度高的策略
+// Basic128Rsa15 和 Basic256 已被 OPC UA 规范废弃，强度低于其他加密策略
+var policyStrength = map[string]int{
+	ua.SecurityPolicyURINone:                0,
+	ua.SecurityPolicyURIBasic128Rsa15:       1,
+	ua.SecurityPolicyURIBasic256:            2,
+	ua.SecurityPolicyURIAes128Sha256RsaOaep: 3,
+	ua.SecurityPolicyURIBasic256Sha256:      4,
+	ua.SecurityPolicyURIAes256Sha256RsaPss:  5,
+}
+
+// ParseSecurityPolicy 解析安全策略，支持策略名称（不区分大小写，下划线可省略，eg. Aes128_Sha256_RsaOaep、aes128sha256rsaoaep）和完整的策略 URI
+// 空字符串和 auto 返回空字符串，表示自动选择
+func ParseSecurityPolicy(policy string) (string, error) {
+	policy = strings.TrimSpace(policy)
+	if policy == "" || strings.EqualFold(policy, SecurityAuto) {
+		return "", nil
+	}
+	if _, ok := policyStrength[policy]; ok {
+		return policy, nil
+	}
+	name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(policy, ua.SecurityPolicyURIPrefix), "_", ""))
+	for uri := range policyStrength {
+		if strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(uri, ua.SecurityPolicyURIPrefix), "_", "")) == name {
+			return uri, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported opcua security policy: %s", policy)
+}
+
+// IsDeprecatedPolicy 是否已被 OPC UA 规范废弃的安全策略：Basic128Rsa15、Basic256
+func IsDeprecatedPolicy(uri string) bool {
+	return uri == ua.SecurityPolicyURIBasic128Rsa15 || uri == ua.SecurityPolicyURIBasic256
+}
+
+// ValidateSecurity 校验安全策略和安全模式，None 策略只能与 None 模式组合，加密策略不能使用 None 模式
+func ValidateSecurity(policy, mode string) error {
+	uri, err := ParseSecurityPolicy(policy)
+	if err != nil {
+		return err
+	}
+	secMode, err := ParseSecurityMode(mode)
+	if err != nil {
+		return err
+	}
+	if uri == "" || secMode == ua.MessageSecurityModeInvalid {
+		return nil
+	}
+	if (uri == ua.SecurityPolicyURINone) != (secMode == ua.MessageSecurityModeNone) {
+		return fmt.Errorf("opcua security policy %s can not be used with security mode %s", policy, mode)
+	}
+	return nil
+}
+
+// selectEndpoint 按安全策略和安全模式选择服务器端点，policy 为空或者 mode 为 Invalid 表示自动选择
+// 自动选择时只考虑客户端支持的策略，没有客户端证书时只能使用 None 策略。
+// 候选端点按以下顺序比较：支持认证方式、策略强度（自动选择策略时）、安全模式、服务器给出的安全等级
+func selectEndpoint(endpoints []*ua.EndpointDescription, policy string, mode ua.MessageSecurityMode, authMode ua.UserTokenType, hasCert bool) *ua.EndpointDescription {
+	var best *ua.EndpointDescription
+	for _, e := range endpoints {
+		if e == nil {
+			continue
+		}
+		if policy != "" && e.SecurityPolicyURI != policy {
+			continue
+		}
+		if mode != ua.MessageSecurityModeInvalid && e.SecurityMode != mode {
+			continue
+		}
+		if policy == "" {
+			if _, ok := policyStrength[e.SecurityPolicyURI]; !ok {
+				continue
+			}
+			if !hasCert && e.SecurityPolicyURI != ua.SecurityPolicyURINone {
+				continue
+			}
+		}
+		if best == nil || strongerEndpoint(e, best, policy == "", authMode) {
+			best = e
+		}
+	}
+	return best
+}
+
+// strongerEndpoint a 是否优于 b
+func strongerEndpoint(a, b *ua.EndpointDescription, comparePolicy bool, authMode ua.UserTokenType) bool {
+	if sa, sb := supportsToken(a, authMode), supportsToken(b, authMode); sa != sb {
+		return sa
+	}
+	if comparePolicy {
+		if pa, pb := policyStrength[a.SecurityPolicyURI], policyStrength[b.SecurityPolicyURI]; pa != pb {
+			return pa > pb
+		}
+	}
+	if a.SecurityMode != b.SecurityMode {
+		return a.SecurityMode > b.SecurityMode
+	}
+	return a.SecurityLevel > b.SecurityLevel
+}
+
+// supportsToken 端点是否支持认证方式
+func supportsToken(e *ua.EndpointDescription, authMode ua.UserTokenType) bool {
+	for _, t := range e.UserIdentityTokens {
+		if t != nil && t.TokenType == authMode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseSecurityPolicy(t *testing.T) {
+	for policy, expected := range map[string]string{
+		"":                           "",
+		"Auto":                       "",
+		"None":                       ua.SecurityPolicyURINone,
+		"basic256sha256":             ua.SecurityPolicyURIBasic256Sha256,
+		"Aes128_Sha256_RsaOaep":      ua.SecurityPolicyURIAes128Sha256RsaOaep,
+		"aes256sha256rsapss":         ua.SecurityPolicyURIAes256Sha256RsaPss,
+		ua.SecurityPolicyURIBasic256: ua.SecurityPolicyURIBasic256,
+	} {
+		uri, err := ParseSecurityPolicy(policy)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, uri)
+	}
+	_, err := ParseSecurityPolicy("Basic512")
+	assert.True(t, err != nil)
+	_, err = ParseSecurityPolicy(ua.SecurityPolicyURIPrefix + "Unknown")
+	assert.True(t, err != nil)
+
+	assert.True(t, IsDeprecatedPolicy(ua.SecurityPolicyURIBasic128Rsa15))
+	assert.True(t, IsDeprecatedPolicy(ua.SecurityPolicyURIBasic256))
+	assert.False(t, IsDeprecatedPolicy(ua.SecurityPolicyURIAes128Sha256RsaOaep))
+}
+
+func TestValidateSecurity(t *testing.T) {
+	assert.Nil(t, ValidateSecurity("auto", "auto"))
+	assert.Nil(t, ValidateSecurity("None", "none"))
+	assert.Nil(t, ValidateSecurity("Aes256_Sha256_RsaPss", "SignAndEncrypt"))
+	assert.Nil(t, ValidateSecurity("auto", "Sign"))
+	assert.True(t, ValidateSecurity("Aes128_Sha256_RsaOaep", "None") != nil)
+	assert.True(t, ValidateSecurity("None", "Sign") != nil)
+	assert.True(t, ValidateSecurity("Basic512", "Sign") != nil)
+	assert.True(t, ValidateSecurity("None", "encrypt") != nil)
+}
+
+func TestSelectEndpoint(t *testing.T) {
+	anonymous := []*ua.UserTokenPolicy{{TokenType: ua.UserTokenTypeAnonymous}}
+	endpoint := func(policy string, mode ua.MessageSecurityMode, level uint8, tokens []*ua.UserTokenPolicy) *ua.EndpointDescription {
+		return &ua.EndpointDescription{SecurityPolicyURI: policy, SecurityMode: mode, SecurityLevel: level, UserIdentityTokens: tokens}
+	}
+	none := endpoint(ua.SecurityPolicyURINone, ua.MessageSecurityModeNone, 0, anonymous)
+	// 服务器给废弃策略更高的安全等级
+	basic128 := endpoint(ua.SecurityPolicyURIBasic128Rsa15, ua.MessageSecurityModeSignAndEncrypt, 200, anonymous)
+	sha256Sign := endpoint(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSign, 10, anonymous)
+	sha256Encrypt := endpoint(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSignAndEncrypt, 20, anonymous)
+	pss := endpoint(ua.SecurityPolicyURIAes256Sha256RsaPss, ua.MessageSecurityModeSign, 5, anonymous)
+	pssUserName := endpoint(ua.SecurityPolicyURIAes256Sha256RsaPss, ua.MessageSecurityModeSignAndEncrypt, 50, []*ua.UserTokenPolicy{{TokenType: ua.UserTokenTypeUserName}})
+	unknown := endpoint(ua.SecurityPolicyURIPrefix+"Future", ua.MessageSecurityModeSignAndEncrypt, 255, anonymous)
+	endpoints := []*ua.EndpointDescription{none, basic128, sha256Sign, sha256Encrypt, pss, pssUserName, unknown}
+
+	// 自动选择最强的策略，优先支持认证方式的端点
+	assert.True(t, selectEndpoint(endpoints, "", ua.MessageSecurityModeInvalid, ua.UserTokenTypeAnonymous, true) == pss)
+	assert.True(t, selectEndpoint(endpoints, "", ua.MessageSecurityModeInvalid, ua.UserTokenTypeUserName, true) == pssUserName)
+	// 只限制安全模式
+	assert.True(t, selectEndpoint(endpoints, "", ua.MessageSecurityModeSignAndEncrypt, ua.UserTokenTypeAnonymous, true) == sha256Encrypt)
+	// 只限制安全策略
+	assert.True(t, selectEndpoint(endpoints, ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeInvalid, ua.UserTokenTypeAnonymous, true) == sha256Encrypt)
+	assert.True(t, selectEndpoint(endpoints, ua.SecurityPolicyURIBasic128Rsa15, ua.MessageSecurityModeSignAndEncrypt, ua.UserTokenTypeAnonymous, true) == basic128)
+	// 没有客户端证书时只能使用 None
+	assert.True(t, selectEndpoint(endpoints, "", ua.MessageSecurityModeInvalid, ua.UserTokenTypeAnonymous, false) == none)
+	assert.True(t, selectEndpoint(endpoints, ua.SecurityPolicyURIAes128Sha256RsaOaep, ua.MessageSecurityModeInvalid, ua.UserTokenTypeAnonymous, true) == nil)
+	assert.True(t, selectEndpoint(nil, "", ua.MessageSecurityModeInvalid, ua.UserTokenTypeAnonymous, true) == nil)
+}
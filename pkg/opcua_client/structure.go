@@ -0,0 +1,423 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// maxStructureDepth 结构体嵌套最大深度，防止循环引用
+const maxStructureDepth = 16
+
+// RawStructure 自定义结构体的原始二进制内容
+// 通过 ua.RegisterExtensionObject 注册到自定义结构体的编码 NodeId，避免 gopcua 丢弃未知结构体内容
+type RawStructure struct {
+	Body []byte
+}
+
+func (s *RawStructure) Decode(b []byte) (int, error) {
+	s.Body = append([]byte(nil), b...)
+	return len(b), nil
+}
+
+func (s *RawStructure) Encode() ([]byte, error) {
+	return s.Body, nil
+}
+
+// structureField 结构体字段定义
+type structureField struct {
+	Name      string
+	ValueRank int32
+	Optional  bool
+	// Builtin 内置类型，Struct 不为空时忽略
+	Builtin ua.TypeID
+	// Struct 嵌套结构体定义
+	Struct *structureDef
+}
+
+// structureDef 结构体定义
+type structureDef struct {
+	Type   ua.StructureType
+	Fields []structureField
+}
+
+// structureScope 一个客户端连接的服务器上加载的结构体定义
+// NodeId 依赖服务器的命名空间索引，不同服务器相同的 ns=2;i=… 可能是不同的类型，所以定义按客户端隔离
+type structureScope struct {
+	// structures 编码 NodeId -> 结构体定义
+	structures sync.Map
+	// dataTypes 数据类型 NodeId -> 结构体定义
+	dataTypes sync.Map
+}
+
+var (
+	// structureScopes 客户端 -> *structureScope，客户端关闭时通过 CloseClient 删除
+	structureScopes sync.Map
+	// rawRegistered 已注册 RawStructure 的编码 NodeId
+	rawRegistered sync.Map
+)
+
+// structuresOf 获取客户端的结构体定义，client 为 nil 时返回 nil
+func structuresOf(client *opcua.Client) *structureScope {
+	if client == nil {
+		return nil
+	}
+	v, _ := structureScopes.LoadOrStore(client, &structureScope{})
+	return v.(*structureScope)
+}
+
+// lookup 根据编码 NodeId 查找结构体定义
+func (s *structureScope) lookup(encodingId *ua.NodeID) (*structureDef, bool) {
+	if s == nil || encodingId == nil {
+		return nil, false
+	}
+	v, ok := s.structures.Load(encodingId.String())
+	if !ok {
+		return nil, false
+	}
+	return v.(*structureDef), true
+}
+
+// registerRawStructure 把编码 NodeId 注册为 RawStructure
+// RawStructure 只保存原始字节，与具体服务器无关，解码时再使用客户端自己的结构体定义
+func registerRawStructure(encodingId *ua.NodeID) {
+	if _, loaded := rawRegistered.LoadOrStore(encodingId.String(), true); loaded {
+		return
+	}
+	defer func() {
+		// 已注册为其他类型时忽略
+		_ = recover()
+	}()
+	ua.RegisterExtensionObject(encodingId, new(RawStructure))
+}
+
+// LoadStructure 根据编码 NodeId 从服务器读取数据类型的 DataTypeDefinition，用于解码自定义结构体
+// 定义保存在客户端自己的作用域中，仅支持 OPC UA 1.04 及以上版本服务器提供的 DataTypeDefinition 属性
+func LoadStructure(ctx context.Context, client *opcua.Client, encodingId *ua.NodeID) error {
+	scope := structuresOf(client)
+	if _, ok := scope.lookup(encodingId); ok {
+		return nil
+	}
+	nodes, err := client.Node(encodingId).ReferencedNodes(ctx, id.HasEncoding, ua.BrowseDirectionInverse, ua.NodeClassAll, false)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("data type of encoding %s not found", encodingId)
+	}
+	def, err := loadStructureDef(ctx, client, scope, nodes[0].ID, 0)
+	if err != nil {
+		return err
+	}
+	scope.structures.Store(encodingId.String(), def)
+	registerRawStructure(encodingId)
+	return nil
+}
+
+// loadStructureDef 读取数据类型的结构体定义
+func loadStructureDef(ctx context.Context, client *opcua.Client, scope *structureScope, dataTypeId *ua.NodeID, depth int) (*structureDef, error) {
+	if v, ok := scope.dataTypes.Load(dataTypeId.String()); ok {
+		return v.(*structureDef), nil
+	}
+	if depth > maxStructureDepth {
+		return nil, fmt.Errorf("structure %s nested too deep", dataTypeId)
+	}
+	definition, err := dataTypeDefinition(ctx, client, dataTypeId)
+	if err != nil {
+		return nil, err
+	}
+	sd, ok := definition.(*ua.StructureDefinition)
+	if !ok {
+		return nil, fmt.Errorf("data type %s is not a structure", dataTypeId)
+	}
+	def := &structureDef{Type: sd.StructureType}
+	for _, f := range sd.Fields {
+		field := structureField{
+			Name:      f.Name,
+			ValueRank: f.ValueRank,
+			Optional:  f.IsOptional,
+		}
+		field.Builtin, field.Struct, err = resolveFieldType(ctx, client, scope, f.DataType, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		def.Fields = append(def.Fields, field)
+	}
+	scope.dataTypes.Store(dataTypeId.String(), def)
+	return def, nil
+}
+
+// dataTypeDefinition 读取 DataTypeDefinition 属性，返回 *ua.StructureDefinition 或 *ua.EnumDefinition
+func dataTypeDefinition(ctx context.Context, client *opcua.Client, dataTypeId *ua.NodeID) (interface{}, error) {
+	v, err := client.Node(dataTypeId).Attribute(ctx, ua.AttributeIDDataTypeDefinition)
+	if err != nil {
+		return nil, err
+	}
+	if eo, ok := v.Value().(*ua.ExtensionObject); ok && eo != nil && eo.Value != nil {
+		return eo.Value, nil
+	}
+	return nil, fmt.Errorf("data type %s has no definition", dataTypeId)
+}
+
+// resolveFieldType 解析字段数据类型，返回内置类型或者嵌套结构体定义
+func resolveFieldType(ctx context.Context, client *opcua.Client, scope *structureScope, dataTypeId *ua.NodeID, depth int) (ua.TypeID, *structureDef, error) {
+	for i := 0; i <= maxStructureDepth; i++ {
+		if dataTypeId.Namespace() == 0 {
+			switch n := dataTypeId.IntID(); {
+			case n >= uint32(ua.TypeIDBoolean) && n <= uint32(ua.TypeIDDiagnosticInfo):
+				return ua.TypeID(n), nil, nil
+			case n == id.Number || n == id.Integer || n == id.UInteger:
+				return ua.TypeIDVariant, nil, nil
+			case n == id.Enumeration:
+				return ua.TypeIDInt32, nil, nil
+			}
+		}
+		if definition, err := dataTypeDefinition(ctx, client, dataTypeId); err == nil {
+			switch definition.(type) {
+			case *ua.StructureDefinition:
+				def, err := loadStructureDef(ctx, client, scope, dataTypeId, depth)
+				return 0, def, err
+			case *ua.EnumDefinition:
+				return ua.TypeIDInt32, nil, nil
+			}
+		}
+		// 没有定义的数据类型使用父类型，例如 Duration -> Double
+		parents, err := client.Node(dataTypeId).ReferencedNodes(ctx, id.HasSubtype, ua.BrowseDirectionInverse, ua.NodeClassAll, false)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(parents) == 0 {
+			return 0, nil, fmt.Errorf("can not resolve data type %s", dataTypeId)
+		}
+		dataTypeId = parents[0].ID
+	}
+	return 0, nil, fmt.Errorf("can not resolve data type %s", dataTypeId)
+}
+
+// loadUnknownStructures 加载结果中当前客户端未知结构体的定义，返回是否需要重新读取
+// 未注册的编码 gopcua 不保留原始内容，需要注册 RawStructure 后重新读取
+func loadUnknownStructures(ctx context.Context, client *opcua.Client, results []*ua.DataValue) bool {
+	scope := structuresOf(client)
+	reread := false
+	load := func(eo *ua.ExtensionObject) {
+		if eo == nil || eo.EncodingMask != ua.ExtensionObjectBinary || eo.TypeID == nil || eo.TypeID.NodeID == nil {
+			return
+		}
+		if _, isRaw := eo.Value.(*RawStructure); eo.Value != nil && !isRaw {
+			return
+		}
+		if _, ok := scope.lookup(eo.TypeID.NodeID); ok {
+			return
+		}
+		if err := LoadStructure(ctx, client, eo.TypeID.NodeID); err != nil {
+			logger.Printf("load structure %s error: %v", eo.TypeID.NodeID, err)
+			return
+		}
+		if eo.Value == nil {
+			reread = true
+		}
+	}
+	for _, result := range results {
+		if result == nil || result.Value == nil {
+			continue
+		}
+		switch v := result.Value.Value().(type) {
+		case *ua.ExtensionObject:
+			load(v)
+		case []*ua.ExtensionObject:
+			for _, eo := range v {
+				load(eo)
+			}
+		}
+	}
+	return reread
+}
+
+// DecodeExtensionObject 使用客户端加载的结构体定义把 ExtensionObject 转换为 JSON 友好的值
+// 客户端作用域中有定义的结构体解码为 map，即使编码 NodeId 在全局注册为其他类型也以客户端的定义为准
+// 没有定义时，gopcua 内置结构体返回结构体本身，未知结构体返回原始内容和错误
+func DecodeExtensionObject(client *opcua.Client, eo *ua.ExtensionObject) (interface{}, error) {
+	return structuresOf(client).decode(eo)
+}
+
+func (s *structureScope) decode(eo *ua.ExtensionObject) (interface{}, error) {
+	if eo == nil || eo.Value == nil {
+		return nil, nil
+	}
+	raw, isRaw := eo.Value.(*RawStructure)
+	var encodingId *ua.NodeID
+	if eo.TypeID != nil {
+		encodingId = eo.TypeID.NodeID
+	}
+	def, ok := s.lookup(encodingId)
+	if !ok {
+		if isRaw {
+			return raw.Body, fmt.Errorf("structure %s not loaded", encodingId)
+		}
+		return eo.Value, nil
+	}
+	var body []byte
+	if isRaw {
+		body = raw.Body
+	} else {
+		// 全局注册的类型不一定是这个服务器的类型，重新编码后按客户端的定义解码
+		var err error
+		if body, err = ua.Encode(eo.Value); err != nil {
+			return eo.Value, err
+		}
+	}
+	buf := ua.NewBuffer(body)
+	m := s.decodeStructure(buf, def)
+	return m, buf.Error()
+}
+
+// decodeStructure 按结构体定义解码
+func (s *structureScope) decodeStructure(buf *ua.Buffer, def *structureDef) map[string]interface{} {
+	m := make(map[string]interface{}, len(def.Fields))
+	switch def.Type {
+	case ua.StructureTypeUnion:
+		// 联合体：UInt32 选择字段序号（从 1 开始），0 表示空
+		sw := buf.ReadUint32()
+		if sw > 0 && int(sw) <= len(def.Fields) {
+			f := def.Fields[sw-1]
+			m[f.Name] = s.decodeField(buf, f)
+		}
+	case ua.StructureTypeStructureWithOptionalFields:
+		// 可选字段：UInt32 掩码，按可选字段顺序占用位
+		mask := buf.ReadUint32()
+		bit := 0
+		for _, f := range def.Fields {
+			if f.Optional {
+				present := mask&(1<<uint(bit)) != 0
+				bit++
+				if !present {
+					continue
+				}
+			}
+			m[f.Name] = s.decodeField(buf, f)
+		}
+	default:
+		for _, f := range def.Fields {
+			m[f.Name] = s.decodeField(buf, f)
+		}
+	}
+	return m
+}
+
+// decodeField 解码字段，ValueRank >= 0 时按数组解码
+func (s *structureScope) decodeField(buf *ua.Buffer, f structureField) interface{} {
+	if f.ValueRank < 0 {
+		return s.decodeValue(buf, f)
+	}
+	n := buf.ReadInt32()
+	if n < 0 || buf.Error() != nil {
+		return nil
+	}
+	arr := make([]interface{}, 0, n)
+	for i := int32(0); i < n && buf.Error() == nil; i++ {
+		arr = append(arr, s.decodeValue(buf, f))
+	}
+	return arr
+}
+
+// decodeValue 解码单个值
+func (s *structureScope) decodeValue(buf *ua.Buffer, f structureField) interface{} {
+	if f.Struct != nil {
+		return s.decodeStructure(buf, f.Struct)
+	}
+	switch f.Builtin {
+	case ua.TypeIDBoolean:
+		return buf.ReadBool()
+	case ua.TypeIDSByte:
+		return buf.ReadInt8()
+	case ua.TypeIDByte:
+		return buf.ReadByte()
+	case ua.TypeIDInt16:
+		return buf.ReadInt16()
+	case ua.TypeIDUint16:
+		return buf.ReadUint16()
+	case ua.TypeIDInt32:
+		return buf.ReadInt32()
+	case ua.TypeIDUint32:
+		return buf.ReadUint32()
+	case ua.TypeIDInt64:
+		return buf.ReadInt64()
+	case ua.TypeIDUint64:
+		return buf.ReadUint64()
+	case ua.TypeIDFloat:
+		return buf.ReadFloat32()
+	case ua.TypeIDDouble:
+		return buf.ReadFloat64()
+	case ua.TypeIDString:
+		return buf.ReadString()
+	case ua.TypeIDDateTime:
+		return buf.ReadTime()
+	case ua.TypeIDByteString:
+		return buf.ReadBytes()
+	case ua.TypeIDXMLElement:
+		return buf.ReadString()
+	case ua.TypeIDStatusCode:
+		return buf.ReadUint32()
+	case ua.TypeIDGUID:
+		v := new(ua.GUID)
+		buf.ReadStruct(v)
+		return v.String()
+	case ua.TypeIDNodeID:
+		v := new(ua.NodeID)
+		buf.ReadStruct(v)
+		return v.String()
+	case ua.TypeIDExpandedNodeID:
+		v := new(ua.ExpandedNodeID)
+		buf.ReadStruct(v)
+		return v.String()
+	case ua.TypeIDQualifiedName:
+		v := new(ua.QualifiedName)
+		buf.ReadStruct(v)
+		return v.Name
+	case ua.TypeIDLocalizedText:
+		v := new(ua.LocalizedText)
+		buf.ReadStruct(v)
+		return v.Text
+	case ua.TypeIDExtensionObject:
+		v := new(ua.ExtensionObject)
+		buf.ReadStruct(v)
+		d, _ := s.decode(v)
+		return d
+	case ua.TypeIDDataValue:
+		v := new(ua.DataValue)
+		buf.ReadStruct(v)
+		if v.Value != nil {
+			return v.Value.Value()
+		}
+		return nil
+	case ua.TypeIDVariant:
+		v := new(ua.Variant)
+		buf.ReadStruct(v)
+		return v.Value()
+	case ua.TypeIDDiagnosticInfo:
+		v := new(ua.DiagnosticInfo)
+		buf.ReadStruct(v)
+		return v
+	}
+	return nil
+}
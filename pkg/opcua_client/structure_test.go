@@ -0,0 +1,100 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDecodeExtensionObject(t *testing.T) {
+	client, err := opcua.NewClient("opc.tcp://localhost:4840")
+	assert.Nil(t, err)
+	defer structureScopes.Delete(client)
+	encodingId := ua.NewStringNodeID(2, "TestStructure.Encoding")
+	// { Name: String, Speed: Double, Position: { X: Int32, Y: Int32 }, Codes: UInt16[], Note?: String }
+	structuresOf(client).structures.Store(encodingId.String(), &structureDef{
+		Type: ua.StructureTypeStructureWithOptionalFields,
+		Fields: []structureField{
+			{Name: "Name", ValueRank: -1, Builtin: ua.TypeIDString},
+			{Name: "Speed", ValueRank: -1, Builtin: ua.TypeIDDouble},
+			{Name: "Position", ValueRank: -1, Struct: &structureDef{
+				Fields: []structureField{
+					{Name: "X", ValueRank: -1, Builtin: ua.TypeIDInt32},
+					{Name: "Y", ValueRank: -1, Builtin: ua.TypeIDInt32},
+				},
+			}},
+			{Name: "Codes", ValueRank: 1, Builtin: ua.TypeIDUint16},
+			{Name: "Note", ValueRank: -1, Optional: true, Builtin: ua.TypeIDString},
+		},
+	})
+
+	buf := ua.NewBuffer(nil)
+	buf.WriteUint32(0) // 可选字段掩码：Note 不存在
+	buf.WriteString("axis1")
+	buf.WriteFloat64(12.5)
+	buf.WriteInt32(3)
+	buf.WriteInt32(-4)
+	buf.WriteInt32(2)
+	buf.WriteUint16(7)
+	buf.WriteUint16(8)
+
+	eo := &ua.ExtensionObject{
+		EncodingMask: ua.ExtensionObjectBinary,
+		TypeID:       &ua.ExpandedNodeID{NodeID: encodingId},
+		Value:        &RawStructure{Body: buf.Bytes()},
+	}
+	v, err := DecodeExtensionObject(client, eo)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name":     "axis1",
+		"Speed":    12.5,
+		"Position": map[string]interface{}{"X": int32(3), "Y": int32(-4)},
+		"Codes":    []interface{}{uint16(7), uint16(8)},
+	}, v)
+
+	d := &Data{Value: eo}
+	_, err = d.ParseValueFor(client)
+	assert.Nil(t, err)
+	assert.Equal(t, v, d.Value)
+
+	// 其他客户端的定义互不影响
+	other, err := opcua.NewClient("opc.tcp://localhost:4841")
+	assert.Nil(t, err)
+	defer structureScopes.Delete(other)
+	_, err = DecodeExtensionObject(other, eo)
+	assert.NotNil(t, err)
+	structuresOf(other).structures.Store(encodingId.String(), &structureDef{
+		Fields: []structureField{
+			{Name: "Length", ValueRank: -1, Builtin: ua.TypeIDUint32},
+		},
+	})
+	v, err = DecodeExtensionObject(other, eo)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"Length": uint32(0)}, v)
+
+	// 未加载定义返回原始内容
+	_, err = DecodeExtensionObject(client, &ua.ExtensionObject{
+		EncodingMask: ua.ExtensionObjectBinary,
+		TypeID:       &ua.ExpandedNodeID{NodeID: ua.NewStringNodeID(2, "Unknown.Encoding")},
+		Value:        &RawStructure{Body: []byte{1}},
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,329 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// dataTypes 支持的数据类型及其对应的 Go 类型，key 为小写类型名称
+var dataTypes = map[string]reflect.Type{
+	"boolean":    reflect.TypeOf(false),
+	"sbyte":      reflect.TypeOf(int8(0)),
+	"byte":       reflect.TypeOf(byte(0)),
+	"int16":      reflect.TypeOf(int16(0)),
+	"uint16":     reflect.TypeOf(uint16(0)),
+	"int32":      reflect.TypeOf(int32(0)),
+	"uint32":     reflect.TypeOf(uint32(0)),
+	"int64":      reflect.TypeOf(int64(0)),
+	"uint64":     reflect.TypeOf(uint64(0)),
+	"float":      reflect.TypeOf(float32(0)),
+	"double":     reflect.TypeOf(float64(0)),
+	"string":     reflect.TypeOf(""),
+	"datetime":   reflect.TypeOf(time.Time{}),
+	"guid":       reflect.TypeOf(&ua.GUID{}),
+	"bytestring": reflect.TypeOf([]byte{}),
+}
+
+// NewVariant 根据数据类型构造 ua.Variant，写入节点、历史数据和内嵌服务器变量都使用这个转换
+// dataType 为空时根据 JSON 值推断类型，否则严格按照 dataType 转换，无法转换时返回错误
+// dataType 支持：Boolean, SByte, Byte, Int16, UInt16, Int32, UInt32, Int64, UInt64, Float, Double, String, DateTime, Guid, ByteString
+// 数组值使用 JSON 数组表示，dataType 可以带 [] 后缀，例如：Int16[]
+func NewVariant(val interface{}, dataType string) (*ua.Variant, error) {
+	if strings.TrimSpace(dataType) == "" {
+		return ua.NewVariant(inferValue(val))
+	}
+	v, err := ConvertValue(val, dataType)
+	if err != nil {
+		return nil, err
+	}
+	return ua.NewVariant(v)
+}
+
+// VariantValue 返回 Variant 的值，服务器可能对 UNCERTAIN 等非 Good 的结果不返回值，此时为 nil
+func VariantValue(v *ua.Variant) interface{} {
+	if v == nil {
+		return nil
+	}
+	return v.Value()
+}
+
+// ConvertValue 严格按照数据类型转换值，支持标量和数组，值为 nil 时返回类型的零值
+func ConvertValue(val interface{}, dataType string) (interface{}, error) {
+	dt := strings.ToLower(strings.TrimSpace(dataType))
+	isArray := strings.HasSuffix(dt, "[]")
+	dt = strings.TrimSuffix(dt, "[]")
+	t, ok := dataTypes[dt]
+	if !ok {
+		return nil, fmt.Errorf("unsupported data type: %s", dataType)
+	}
+	if val == nil {
+		if isArray {
+			return reflect.MakeSlice(reflect.SliceOf(t), 0, 0).Interface(), nil
+		}
+		if t.Kind() == reflect.Ptr {
+			return reflect.New(t.Elem()).Interface(), nil
+		}
+		return reflect.Zero(t).Interface(), nil
+	}
+	arr, ok := val.([]interface{})
+	if !ok {
+		if isArray {
+			return nil, fmt.Errorf("data type %s requires an array value", dataType)
+		}
+		return convertScalar(val, dt)
+	}
+	slice := reflect.MakeSlice(reflect.SliceOf(t), len(arr), len(arr))
+	for i, e := range arr {
+		v, err := convertScalar(e, dt)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		slice.Index(i).Set(reflect.ValueOf(v))
+	}
+	return slice.Interface(), nil
+}
+
+// inferValue 根据 JSON 数组第一个元素的类型把 []interface{} 转换为对应类型的切片，以便 ua.NewVariant 可以正确处理
+// 元素类型不一致时返回原始值
+func inferValue(val interface{}) interface{} {
+	arr, ok := val.([]interface{})
+	if !ok || len(arr) == 0 {
+		return val
+	}
+	var t reflect.Type
+	switch arr[0].(type) {
+	case float64:
+		t = dataTypes["double"]
+	case string:
+		t = dataTypes["string"]
+	case bool:
+		t = dataTypes["boolean"]
+	default:
+		return val
+	}
+	slice := reflect.MakeSlice(reflect.SliceOf(t), len(arr), len(arr))
+	for i, e := range arr {
+		ev := reflect.ValueOf(e)
+		if !ev.IsValid() || ev.Type() != t {
+			return val
+		}
+		slice.Index(i).Set(ev)
+	}
+	return slice.Interface()
+}
+
+// convertScalar 转换单个值，dt 为小写类型名称
+func convertScalar(val interface{}, dt string) (interface{}, error) {
+	switch dt {
+	case "boolean":
+		switch v := val.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+		if f, ok := toFloat64(val); ok {
+			return f != 0, nil
+		}
+	case "sbyte":
+		i, err := toInt(val, math.MinInt8, math.MaxInt8)
+		return int8(i), err
+	case "byte":
+		i, err := toUint(val, math.MaxUint8)
+		return byte(i), err
+	case "int16":
+		i, err := toInt(val, math.MinInt16, math.MaxInt16)
+		return int16(i), err
+	case "uint16":
+		i, err := toUint(val, math.MaxUint16)
+		return uint16(i), err
+	case "int32":
+		i, err := toInt(val, math.MinInt32, math.MaxInt32)
+		return int32(i), err
+	case "uint32":
+		i, err := toUint(val, math.MaxUint32)
+		return uint32(i), err
+	case "int64":
+		return toInt(val, math.MinInt64, math.MaxInt64)
+	case "uint64":
+		return toUint(val, math.MaxUint64)
+	case "float":
+		f, err := toFloat(val)
+		return float32(f), err
+	case "double":
+		return toFloat(val)
+	case "string":
+		if v, ok := val.(string); ok {
+			return v, nil
+		}
+		return fmt.Sprint(val), nil
+	case "datetime":
+		switch v := val.(type) {
+		case string:
+			return time.Parse(time.RFC3339, v)
+		case time.Time:
+			return v, nil
+		}
+		// 数值按毫秒时间戳处理
+		if f, ok := toFloat64(val); ok {
+			return time.UnixMilli(int64(f)), nil
+		}
+	case "guid":
+		if v, ok := val.(string); ok {
+			if g := ua.NewGUID(v); g != nil {
+				return g, nil
+			}
+		}
+	case "bytestring":
+		if v, ok := val.(string); ok {
+			return base64.StdEncoding.DecodeString(v)
+		}
+	}
+	return nil, fmt.Errorf("can not convert %v (%T) to %s", val, val, dt)
+}
+
+// toFloat 转换为 float64，支持数值字符串
+func toFloat(val interface{}) (float64, error) {
+	if f, ok := toFloat64(val); ok {
+		return f, nil
+	}
+	if s, ok := val.(string); ok {
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	}
+	return 0, fmt.Errorf("can not convert %v (%T) to number", val, val)
+}
+
+// toInt 转换为 int64 并检查取值范围
+func toInt(val interface{}, lo, hi int64) (int64, error) {
+	var i int64
+	switch v := val.(type) {
+	case int64:
+		i = v
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 0, 64)
+		if err != nil {
+			return 0, err
+		}
+		i = n
+	default:
+		f, err := toFloat(val)
+		if err != nil {
+			return 0, err
+		}
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer", val)
+		}
+		i = int64(f)
+	}
+	if i < lo || i > hi {
+		return 0, fmt.Errorf("value %v out of range [%d, %d]", val, lo, hi)
+	}
+	return i, nil
+}
+
+// toUint 转换为 uint64 并检查取值范围
+func toUint(val interface{}, hi uint64) (uint64, error) {
+	var u uint64
+	switch v := val.(type) {
+	case uint64:
+		u = v
+	case string:
+		n, err := strconv.ParseUint(strings.TrimSpace(v), 0, 64)
+		if err != nil {
+			return 0, err
+		}
+		u = n
+	default:
+		f, err := toFloat(val)
+		if err != nil {
+			return 0, err
+		}
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+			return 0, fmt.Errorf("value %v is not an unsigned integer", val)
+		}
+		u = uint64(f)
+	}
+	if u > hi {
+		return 0, fmt.Errorf("value %v out of range [0, %d]", val, hi)
+	}
+	return u, nil
+}
+
+// toFloat64 将数值类型统一转换为 float64
+func toFloat64(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// ValueEqual 比较两个值是否相等，数值类型允许 tolerance 误差，数组逐个元素比较
+func ValueEqual(a, b interface{}, tolerance float64) bool {
+	if fa, ok := toFloat64(a); ok {
+		fb, ok := toFloat64(b)
+		return ok && math.Abs(fa-fb) <= tolerance
+	}
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Slice && vb.Kind() == reflect.Slice && va.Type().Elem().Kind() != reflect.Uint8 {
+		if va.Len() != vb.Len() {
+			return false
+		}
+		for i := 0; i < va.Len(); i++ {
+			if !ValueEqual(va.Index(i).Interface(), vb.Index(i).Interface(), tolerance) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
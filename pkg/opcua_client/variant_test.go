@@ -0,0 +1,90 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opcuaClient
+
+import (
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestNewVariant(t *testing.T) {
+	v, err := NewVariant(float64(12), "Int16")
+	assert.Nil(t, err)
+	assert.Equal(t, int16(12), v.Value())
+
+	v, err = NewVariant("42", "UInt32")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(42), v.Value())
+
+	v, err = NewVariant(float64(1.5), "Float")
+	assert.Nil(t, err)
+	assert.Equal(t, float32(1.5), v.Value())
+
+	v, err = NewVariant([]interface{}{float64(1), float64(2)}, "Int16[]")
+	assert.Nil(t, err)
+	assert.Equal(t, []int16{1, 2}, v.Value())
+
+	// 未指定类型时根据 JSON 值推断
+	v, err = NewVariant([]interface{}{true, false}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, false}, v.Value())
+
+	v, err = NewVariant([]interface{}{float64(1), float64(2.5)}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{1, 2.5}, v.Value())
+
+	v, err = NewVariant(float64(20.5), "")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(20.5), v.Value())
+
+	// 没有初始值时使用类型的零值
+	v, err = NewVariant(nil, "Double")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(0), v.Value())
+	assert.Equal(t, ua.TypeIDDouble, v.Type())
+
+	_, err = NewVariant(float64(70000), "Int16")
+	assert.NotNil(t, err)
+
+	_, err = NewVariant(float64(1.5), "Int32")
+	assert.NotNil(t, err)
+
+	_, err = NewVariant(float64(1), "Int16[]")
+	assert.NotNil(t, err)
+
+	_, err = NewVariant(float64(1), "Decimal")
+	assert.NotNil(t, err)
+}
+
+func TestValueEqual(t *testing.T) {
+	assert.True(t, ValueEqual(float32(1.5), float32(1.5), 0))
+	assert.True(t, ValueEqual(int16(10), int32(10), 0))
+	assert.True(t, ValueEqual(float64(10), float64(10.05), 0.1))
+	assert.False(t, ValueEqual(float64(10), float64(10.5), 0.1))
+	assert.True(t, ValueEqual([]int16{1, 2}, []int16{1, 2}, 0))
+	assert.False(t, ValueEqual([]int16{1, 2}, []int16{1, 3}, 0))
+	assert.True(t, ValueEqual("on", "on", 0))
+	assert.False(t, ValueEqual(true, false, 0))
+	assert.False(t, ValueEqual(float64(1), "1", 0))
+}
+
+func TestVariantValue(t *testing.T) {
+	assert.Nil(t, VariantValue(nil))
+	assert.Equal(t, int32(5), VariantValue(ua.MustVariant(int32(5))))
+}
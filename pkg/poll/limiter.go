@@ -0,0 +1,218 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package poll 提供轮询端点的背压控制、定时读取的对齐、抖动以及暂停和恢复
+// 规则链处理较慢时，定时任务在上一次 DoProcess 完成之前再次触发，读取结果会不断堆积。
+// Limiter 限制同时处理的数量，超过时按重叠策略跳过、排队或者丢弃最早的结果
+package poll
+
+import (
+	"errors"
+	"sync"
+)
+
+// 重叠策略
+const (
+	// OverlapAllow 不限制，每次触发都立即处理，默认策略
+	OverlapAllow = "allow"
+	// OverlapSkip 处理数量达到上限时跳过本次触发
+	OverlapSkip = "skip"
+	// OverlapQueue 处理数量达到上限时排队，队列满后丢弃本次触发
+	OverlapQueue = "queue"
+	// OverlapDropOldest 处理数量达到上限时排队，队列满后丢弃最早排队的结果
+	OverlapDropOldest = "dropOldest"
+)
+
+const (
+	// DefaultMaxInFlight 默认最大同时处理数量
+	DefaultMaxInFlight = 1
+	// DefaultQueueSize 默认排队数量
+	DefaultQueueSize = 10
+)
+
+// Config 背压配置
+type Config struct {
+	// Overlap 上一次处理未完成时再次触发的策略：allow（默认）、skip、queue、dropOldest
+	Overlap string `json:"overlap" label:"Overlap Policy" desc:"Policy when a poll fires while previous ones are still processing: allow (default), skip, queue, dropOldest"`
+	// MaxInFlight 最大同时处理数量，默认 1，allow 策略不限制
+	MaxInFlight int `json:"maxInFlight" label:"Max In-Flight" desc:"Max polls processed concurrently, default 1. Not limited by the allow policy"`
+	// QueueSize queue 和 dropOldest 策略的最大排队数量，默认 10
+	QueueSize int `json:"queueSize" label:"Queue Size" desc:"Max pending polls of the queue and dropOldest policies, default 10"`
+}
+
+// Validate 检查重叠策略
+func (c Config) Validate() error {
+	switch c.Overlap {
+	case "", OverlapAllow, OverlapSkip, OverlapQueue, OverlapDropOldest:
+		return nil
+	default:
+		return errors.New("unsupported overlap policy: " + c.Overlap)
+	}
+}
+
+// Stats 统计
+type Stats struct {
+	// InFlight 正在处理的数量
+	InFlight int `json:"inFlight"`
+	// Pending 排队的数量
+	Pending int `json:"pending"`
+	// Dropped 跳过或者丢弃的数量
+	Dropped uint64 `json:"dropped"`
+}
+
+// Limiter 按重叠策略限制同时处理的数量，并发安全
+// 任务在调用 Do 的协程中执行，完成后在同一协程中继续执行排队的任务，不额外创建协程，
+// 因此定时任务停止时等待正在执行的任务即可等待排队的任务完成
+type Limiter struct {
+	config Config
+	// onDrop 任务被跳过或者丢弃时调用，n 为丢弃的数量
+	onDrop   func(n int)
+	mu       sync.Mutex
+	inFlight int
+	queue    []func()
+	dropped  uint64
+	closed   bool
+}
+
+// NewLimiter 创建限制器，config 需要先通过 Validate 检查
+// onDrop 在任务被跳过或者丢弃时调用，用于记录指标，可以为 nil
+func NewLimiter(config Config, onDrop func(n int)) *Limiter {
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = DefaultMaxInFlight
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	return &Limiter{config: config, onDrop: onDrop}
+}
+
+// Admit 是否可以开始一次新的轮询，skip 策略下处理数量已达上限时返回 false 并计入丢弃，
+// 调用方可以据此跳过本次读取，避免读取后再丢弃
+func (l *Limiter) Admit() bool {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return false
+	}
+	if l.config.Overlap == OverlapSkip && l.inFlight >= l.config.MaxInFlight {
+		l.drop(1)
+		return false
+	}
+	l.mu.Unlock()
+	return true
+}
+
+// Do 按重叠策略执行 job，返回 job 是否被执行或者排队，false 表示被丢弃
+// dropOldest 策略丢弃的是最早排队的任务，本次 job 总是排队
+func (l *Limiter) Do(job func()) bool {
+	l.mu.Lock()
+	if l.closed {
+		l.drop(1)
+		return false
+	}
+	if l.config.Overlap == "" || l.config.Overlap == OverlapAllow {
+		l.mu.Unlock()
+		job()
+		return true
+	}
+	if l.inFlight >= l.config.MaxInFlight {
+		switch l.config.Overlap {
+		case OverlapQueue:
+			if len(l.queue) >= l.config.QueueSize {
+				l.drop(1)
+				return false
+			}
+			l.queue = append(l.queue, job)
+		case OverlapDropOldest:
+			if len(l.queue) < l.config.QueueSize {
+				l.queue = append(l.queue, job)
+				break
+			}
+			l.queue[0] = nil
+			l.queue = append(l.queue[1:], job)
+			l.drop(1)
+			return true
+		default:
+			l.drop(1)
+			return false
+		}
+		l.mu.Unlock()
+		return true
+	}
+	l.inFlight++
+	l.mu.Unlock()
+	for job != nil {
+		l.run(job)
+		job = l.next()
+	}
+	return true
+}
+
+// run 执行任务，任务 panic 时也要释放处理数量
+func (l *Limiter) run(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.release()
+			panic(r)
+		}
+	}()
+	job()
+}
+
+// next 取出下一个排队的任务，没有则释放处理数量
+func (l *Limiter) next() func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.queue) > 0 && !l.closed {
+		job := l.queue[0]
+		l.queue[0] = nil
+		l.queue = l.queue[1:]
+		return job
+	}
+	l.inFlight--
+	return nil
+}
+
+// drop 记录丢弃的数量并释放锁，在锁外调用 onDrop
+func (l *Limiter) drop(n int) {
+	l.dropped += uint64(n)
+	l.mu.Unlock()
+	if l.onDrop != nil && n > 0 {
+		l.onDrop(n)
+	}
+}
+
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+}
+
+// Stats 获取统计
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Stats{InFlight: l.inFlight, Pending: len(l.queue), Dropped: l.dropped}
+}
+
+// Close 丢弃排队的任务，之后的任务都被丢弃，正在执行的任务不受影响
+func (l *Limiter) Close() {
+	l.mu.Lock()
+	l.closed = true
+	n := len(l.queue)
+	l.queue = nil
+	l.drop(n)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package poll
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// runBlocked 执行第一个任务并阻塞，在任务执行期间调用 fn，返回执行过的任务编号
+func runBlocked(l *Limiter, fn func(submit func(i int) bool)) []int {
+	var (
+		mu   sync.Mutex
+		ran  []int
+		wg   sync.WaitGroup
+		gate = make(chan struct{})
+		busy = make(chan struct{})
+	)
+	record := func(i int) {
+		mu.Lock()
+		ran = append(ran, i)
+		mu.Unlock()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.Do(func() {
+			close(busy)
+			<-gate
+			record(0)
+		})
+	}()
+	<-busy
+	fn(func(i int) bool {
+		return l.Do(func() { record(i) })
+	})
+	close(gate)
+	wg.Wait()
+	return ran
+}
+
+func TestLimiter(t *testing.T) {
+	assert.NotNil(t, Config{Overlap: "latest"}.Validate())
+	assert.Nil(t, Config{Overlap: OverlapDropOldest}.Validate())
+
+	// allow 不限制
+	l := NewLimiter(Config{}, nil)
+	ran := runBlocked(l, func(submit func(i int) bool) {
+		assert.True(t, l.Admit())
+		assert.True(t, submit(1))
+	})
+	assert.Equal(t, []int{1, 0}, ran)
+
+	l = NewLimiter(Config{Overlap: OverlapSkip}, nil)
+	ran = runBlocked(l, func(submit func(i int) bool) {
+		assert.False(t, l.Admit())
+		assert.False(t, submit(1))
+	})
+	assert.Equal(t, []int{0}, ran)
+	assert.Equal(t, uint64(2), l.Stats().Dropped)
+	assert.True(t, l.Admit())
+
+	l = NewLimiter(Config{Overlap: OverlapQueue, QueueSize: 2}, nil)
+	ran = runBlocked(l, func(submit func(i int) bool) {
+		assert.True(t, l.Admit())
+		assert.True(t, submit(1))
+		assert.True(t, submit(2))
+		assert.False(t, submit(3))
+		assert.Equal(t, Stats{InFlight: 1, Pending: 2, Dropped: 1}, l.Stats())
+	})
+	assert.Equal(t, []int{0, 1, 2}, ran)
+	assert.Equal(t, Stats{Dropped: 1}, l.Stats())
+
+	dropped := 0
+	l = NewLimiter(Config{Overlap: OverlapDropOldest, QueueSize: 2}, func(n int) {
+		dropped += n
+	})
+	ran = runBlocked(l, func(submit func(i int) bool) {
+		assert.True(t, submit(1))
+		assert.True(t, submit(2))
+		assert.True(t, submit(3))
+	})
+	assert.Equal(t, []int{0, 2, 3}, ran)
+	assert.Equal(t, uint64(1), l.Stats().Dropped)
+	assert.Equal(t, 1, dropped)
+
+	// 两个并发处理
+	l = NewLimiter(Config{Overlap: OverlapSkip, MaxInFlight: 2}, nil)
+	ran = runBlocked(l, func(submit func(i int) bool) {
+		assert.True(t, submit(1))
+	})
+	assert.Equal(t, []int{1, 0}, ran)
+
+	l = NewLimiter(Config{Overlap: OverlapQueue}, nil)
+	ran = runBlocked(l, func(submit func(i int) bool) {
+		assert.True(t, submit(1))
+		l.Close()
+		assert.False(t, submit(2))
+	})
+	assert.Equal(t, []int{0}, ran)
+	assert.Equal(t, Stats{Dropped: 2}, l.Stats())
+	assert.False(t, l.Admit())
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package poll
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 规则链控制轮询端点的消息类型，见 x/pollControl
+const (
+	// MsgTypePause 暂停轮询
+	MsgTypePause = "POLL_PAUSE"
+	// MsgTypeResume 恢复轮询
+	MsgTypeResume = "POLL_RESUME"
+)
+
+// Pausable 可以暂停和恢复的轮询端点
+// 暂停期间定时任务照常触发但不读取设备，配置和连接保持不变，用于维护窗口期间停止访问设备
+type Pausable interface {
+	// Pause 暂停轮询，正在进行的读取不受影响
+	Pause()
+	// Resume 恢复轮询，下一次触发时开始读取
+	Resume()
+	// Paused 是否已经暂停
+	Paused() bool
+}
+
+// Pauser 暂停状态，嵌入到轮询端点中，端点在每次触发时检查 Paused
+type Pauser struct {
+	paused atomic.Bool
+}
+
+// Pause 暂停轮询
+func (p *Pauser) Pause() {
+	p.paused.Store(true)
+}
+
+// Resume 恢复轮询
+func (p *Pauser) Resume() {
+	p.paused.Store(false)
+}
+
+// Paused 是否已经暂停
+func (p *Pauser) Paused() bool {
+	return p.paused.Load()
+}
+
+// pausables 已添加路由的轮询端点，key 为路由 Id
+var pausables sync.Map
+
+// Register 注册轮询端点，添加路由时调用，规则链通过路由 Id 暂停和恢复端点
+func Register(routerId string, p Pausable) {
+	pausables.Store(routerId, p)
+}
+
+// Unregister 移除路由时取消注册，路由 Id 已被其他端点使用时不处理
+func Unregister(routerId string, p Pausable) {
+	pausables.CompareAndDelete(routerId, p)
+}
+
+// Lookup 根据路由 Id 查找轮询端点
+func Lookup(routerId string) (Pausable, bool) {
+	if v, ok := pausables.Load(routerId); ok {
+		return v.(Pausable), true
+	}
+	return nil, false
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package poll
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestPauser(t *testing.T) {
+	p := &Pauser{}
+	assert.False(t, p.Paused())
+	p.Pause()
+	assert.True(t, p.Paused())
+	p.Pause()
+	assert.True(t, p.Paused())
+	p.Resume()
+	assert.False(t, p.Paused())
+}
+
+func TestPausableRegistry(t *testing.T) {
+	p1, p2 := &Pauser{}, &Pauser{}
+	_, ok := Lookup("poll-1")
+	assert.False(t, ok)
+
+	Register("poll-1", p1)
+	found, ok := Lookup("poll-1")
+	assert.True(t, ok)
+	found.Pause()
+	assert.True(t, p1.Paused())
+
+	// 路由 Id 已被其他端点使用时不取消注册
+	Register("poll-1", p2)
+	Unregister("poll-1", p1)
+	found, ok = Lookup("poll-1")
+	assert.True(t, ok)
+	assert.True(t, found == Pausable(p2))
+
+	Unregister("poll-1", p2)
+	_, ok = Lookup("poll-1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MinInterval 固定频率读取的最小间隔
+const MinInterval = 10 * time.Millisecond
+
+// ScheduleOptions 定时读取的对齐和抖动，用于把大量端点的读取分散开，避免相同间隔的端点同时读取服务器
+type ScheduleOptions struct {
+	// Align @every 间隔按整点对齐，eg. @every 30s 在每分钟的 :00 和 :30 触发，否则从启动时间开始计算
+	Align bool `json:"align" label:"Align" desc:"Align @every intervals to wall clock multiples, eg. @every 30s fires at :00 and :30"`
+	// Jitter 最大随机偏移，eg. 5s。启动时随机选择一个 [0, jitter) 的固定偏移，之后每次触发都延后相同的偏移，保持读取间隔不变
+	Jitter string `json:"jitter" label:"Jitter" desc:"Max random offset chosen once at start, eg. 5s. Every run is delayed by the same offset so the interval is kept"`
+}
+
+// Validate 检查抖动配置
+func (o ScheduleOptions) Validate() error {
+	_, err := o.jitter()
+	return err
+}
+
+func (o ScheduleOptions) jitter() (time.Duration, error) {
+	if o.Jitter == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(o.Jitter)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("jitter can not be negative")
+	}
+	return d, nil
+}
+
+// ParseSchedule 解析 cron 表达式（与 cron.New 默认的解析器相同，支持 @every 1m 等描述符），并按配置增加对齐和随机偏移
+// 固定周期（eg. 200ms、1.5s）以及带毫秒的 @every（cron 只支持到秒）使用固定频率调度，见 fixedRateSchedule
+func ParseSchedule(spec string, options ScheduleOptions) (cron.Schedule, error) {
+	jitter, err := options.jitter()
+	if err != nil {
+		return nil, err
+	}
+	rate, isRate, err := parseRate(spec)
+	if err != nil {
+		return nil, err
+	}
+	var schedule cron.Schedule
+	var every cron.ConstantDelaySchedule
+	var isEvery bool
+	if isRate {
+		every, isEvery = cron.ConstantDelaySchedule{Delay: rate}, true
+		schedule = fixedRateSchedule{every: rate, start: time.Now()}
+	} else {
+		if schedule, err = cron.ParseStandard(spec); err != nil {
+			return nil, err
+		}
+		every, isEvery = schedule.(cron.ConstantDelaySchedule)
+	}
+	if isEvery && options.Align {
+		schedule = alignedSchedule{every: every.Delay}
+	}
+	if jitter <= 0 {
+		return schedule, nil
+	}
+	// 偏移不超过间隔，避免跳过一个周期，cron 表达式按最近两次触发的间隔估算
+	interval := every.Delay
+	if !isEvery {
+		next := schedule.Next(time.Now())
+		interval = schedule.Next(next).Sub(next)
+	}
+	if interval > 0 && jitter > interval {
+		jitter = interval
+	}
+	return offsetSchedule{
+		schedule: schedule,
+		offset:   time.Duration(rand.Int63n(int64(jitter))),
+	}, nil
+}
+
+// parseRate 解析固定周期，ok 为 false 表示不是固定周期。整秒的 @every 仍然由 cron 解析，保持原有行为
+func parseRate(spec string) (time.Duration, bool, error) {
+	spec = strings.TrimSpace(spec)
+	every := strings.HasPrefix(spec, "@every ")
+	d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+	if err != nil || (every && d >= time.Second && d%time.Second == 0) {
+		return 0, false, nil
+	}
+	if d < MinInterval {
+		return 0, false, fmt.Errorf("interval %s is less than %s", d, MinInterval)
+	}
+	return d, true, nil
+}
+
+// fixedRateSchedule 固定频率调度，触发时间为 start 加上间隔的整数倍。
+// 每次按开始时间计算，读取耗时和定时器延迟不会累积漂移；错过的周期直接跳过，不会连续补读
+type fixedRateSchedule struct {
+	every time.Duration
+	start time.Time
+}
+
+// Next t 之后的第一个周期
+func (s fixedRateSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.start) {
+		return s.start
+	}
+	return s.start.Add((t.Sub(s.start)/s.every + 1) * s.every)
+}
+
+// alignedSchedule 按间隔的整数倍触发
+type alignedSchedule struct {
+	every time.Duration
+}
+
+// Next 下一个间隔整数倍的时间
+func (s alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.every).Add(s.every)
+}
+
+// offsetSchedule 在原有调度的每次触发时间上延后固定偏移
+type offsetSchedule struct {
+	schedule cron.Schedule
+	offset   time.Duration
+}
+
+// Next 去掉偏移后计算原有调度的下一次触发时间，再加上偏移
+func (s offsetSchedule) Next(t time.Time) time.Time {
+	next := s.schedule.Next(t.Add(-s.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(s.offset)
+}
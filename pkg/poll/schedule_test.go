@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package poll
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	_, err := ParseSchedule("@every 1m", ScheduleOptions{Jitter: "soon"})
+	assert.True(t, err != nil)
+	_, err = ParseSchedule("@every 1m", ScheduleOptions{Jitter: "-1s"})
+	assert.True(t, err != nil)
+	_, err = ParseSchedule("@sometimes", ScheduleOptions{})
+	assert.True(t, err != nil)
+
+	start := time.Date(2025, 1, 1, 10, 0, 17, 0, time.UTC)
+	// 不对齐时从当前时间开始计算
+	s, err := ParseSchedule("@every 30s", ScheduleOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, start.Add(30*time.Second), s.Next(start))
+
+	s, err = ParseSchedule("@every 30s", ScheduleOptions{Align: true})
+	assert.Nil(t, err)
+	next := s.Next(start)
+	assert.Equal(t, time.Date(2025, 1, 1, 10, 0, 30, 0, time.UTC), next)
+	assert.Equal(t, time.Date(2025, 1, 1, 10, 1, 0, 0, time.UTC), s.Next(next))
+
+	// 对齐后增加固定偏移，间隔保持不变
+	s, err = ParseSchedule("@every 30s", ScheduleOptions{Align: true, Jitter: "10s"})
+	assert.Nil(t, err)
+	offset := s.(offsetSchedule).offset
+	assert.True(t, offset >= 0 && offset < 10*time.Second)
+	next = s.Next(start)
+	assert.True(t, next.After(start))
+	assert.Equal(t, offset, next.Sub(next.Truncate(30*time.Second)))
+	assert.Equal(t, next.Add(30*time.Second), s.Next(next))
+
+	// cron 表达式不受 align 影响，偏移不超过间隔
+	s, err = ParseSchedule("*/5 * * * *", ScheduleOptions{Align: true, Jitter: "1h"})
+	assert.Nil(t, err)
+	offset = s.(offsetSchedule).offset
+	assert.True(t, offset < 5*time.Minute)
+	next = s.Next(start)
+	assert.True(t, next.After(start))
+	assert.Equal(t, time.Duration(0), next.Add(-offset).Sub(next.Add(-offset).Truncate(5*time.Minute)))
+
+	s, err = ParseSchedule("@every 2s", ScheduleOptions{Jitter: "1h"})
+	assert.Nil(t, err)
+	assert.True(t, s.(offsetSchedule).offset < 2*time.Second)
+}
+
+func TestFixedRateSchedule(t *testing.T) {
+	_, err := ParseSchedule("5ms", ScheduleOptions{})
+	assert.True(t, err != nil)
+	_, err = ParseSchedule("@every 1ms", ScheduleOptions{})
+	assert.True(t, err != nil)
+
+	// 整秒的 @every 仍然由 cron 解析
+	s, err := ParseSchedule("@every 2s", ScheduleOptions{})
+	assert.Nil(t, err)
+	_, ok := s.(cron.ConstantDelaySchedule)
+	assert.True(t, ok)
+
+	for _, spec := range []string{"200ms", "@every 200ms", "@every 1.5s", "2s"} {
+		s, err = ParseSchedule(spec, ScheduleOptions{})
+		assert.Nil(t, err)
+		_, ok = s.(fixedRateSchedule)
+		assert.True(t, ok)
+	}
+
+	// 按开始时间计算触发时间，延迟触发不会累积漂移，错过的周期直接跳过
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	rate := fixedRateSchedule{every: 200 * time.Millisecond, start: start}
+	assert.Equal(t, start, rate.Next(start.Add(-time.Second)))
+	assert.Equal(t, start.Add(200*time.Millisecond), rate.Next(start))
+	assert.Equal(t, start.Add(400*time.Millisecond), rate.Next(start.Add(230*time.Millisecond)))
+	assert.Equal(t, start.Add(1200*time.Millisecond), rate.Next(start.Add(1050*time.Millisecond)))
+
+	// 对齐到间隔的整数倍
+	s, err = ParseSchedule("250ms", ScheduleOptions{Align: true})
+	assert.Nil(t, err)
+	assert.Equal(t, start.Add(250*time.Millisecond), s.Next(start.Add(10*time.Millisecond)))
+
+	// 偏移不超过间隔
+	s, err = ParseSchedule("100ms", ScheduleOptions{Jitter: "1s"})
+	assert.Nil(t, err)
+	assert.True(t, s.(offsetSchedule).offset < 100*time.Millisecond)
+}
+
+func TestFixedRateCron(t *testing.T) {
+	s, err := ParseSchedule("50ms", ScheduleOptions{})
+	assert.Nil(t, err)
+	var count atomic.Int32
+	c := cron.New()
+	c.Schedule(s, cron.FuncJob(func() {
+		count.Add(1)
+	}))
+	c.Start()
+	time.Sleep(520 * time.Millisecond)
+	<-c.Stop().Done()
+	n := count.Load()
+	assert.True(t, n >= 8 && n <= 11)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package quality 把各协议的数据质量归一化为 GOOD、UNCERTAIN、BAD，
+// 同时保留协议原始的质量码，eg. OPC UA StatusCode、Modbus 异常码、BACnet reliability。
+// 输出值统一使用 qualityLevel 和 qualityCode 字段，规则链可以不区分协议过滤坏值
+package quality
+
+import "strings"
+
+// Level 归一化的质量等级
+type Level string
+
+const (
+	// Good 值可信
+	Good Level = "GOOD"
+	// Uncertain 值可用但不确定，eg. 设备停用、值被覆盖、使用最后可用值
+	Uncertain Level = "UNCERTAIN"
+	// Bad 值不可用
+	Bad Level = "BAD"
+)
+
+// 元数据 key，没有值输出时（eg. 读取失败）质量放在元数据
+const (
+	KeyLevel = "qualityLevel"
+	KeyCode  = "qualityCode"
+)
+
+// Quality 归一化的质量和协议原始质量码
+type Quality struct {
+	// Level 归一化的质量等级
+	Level Level `json:"qualityLevel"`
+	// Code 协议原始质量码，OPC UA 为 StatusCode，OPC DA 为质量字，Modbus 为异常码，BACnet 为 reliability
+	Code uint32 `json:"qualityCode"`
+}
+
+// GoodQuality 质量码为 0 的 GOOD
+var GoodQuality = Quality{Level: Good}
+
+// IsGood 是否为 GOOD
+func (q Quality) IsGood() bool {
+	return q.Level == Good
+}
+
+// IsBad 是否为 BAD
+func (q Quality) IsBad() bool {
+	return q.Level == Bad
+}
+
+// ParseLevel 解析质量等级，不区分大小写，无法识别返回 false
+func ParseLevel(s string) (Level, bool) {
+	switch l := Level(strings.ToUpper(strings.TrimSpace(s))); l {
+	case Good, Uncertain, Bad:
+		return l, true
+	default:
+		return "", false
+	}
+}
+
+// Worst 返回较差的质量，用于合并多个值的质量，相同等级保留第一个
+func Worst(a, b Quality) Quality {
+	if rank(b.Level) > rank(a.Level) {
+		return b
+	}
+	return a
+}
+
+func rank(l Level) int {
+	switch l {
+	case Good:
+		return 0
+	case Uncertain:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// FromOPCUA OPC UA StatusCode，按最高 2 位的严重程度：00 GOOD，01 UNCERTAIN，10/11 BAD
+func FromOPCUA(status uint32) Quality {
+	q := Quality{Code: status}
+	switch status >> 30 {
+	case 0:
+		q.Level = Good
+	case 1:
+		q.Level = Uncertain
+	default:
+		q.Level = Bad
+	}
+	return q
+}
+
+// Modbus 异常码
+const (
+	ModbusIllegalFunction         = 0x01
+	ModbusIllegalDataAddress      = 0x02
+	ModbusIllegalDataValue        = 0x03
+	ModbusServerDeviceFailure     = 0x04
+	ModbusAcknowledge             = 0x05
+	ModbusServerDeviceBusy        = 0x06
+	ModbusMemoryParityError       = 0x08
+	ModbusGatewayPathUnavailable  = 0x0a
+	ModbusGatewayTargetNoResponse = 0x0b
+)
+
+// FromModbusException Modbus 异常码，0 为 GOOD，其他异常响应都没有返回值，为 BAD
+func FromModbusException(code uint8) Quality {
+	if code == 0 {
+		return GoodQuality
+	}
+	return Quality{Level: Bad, Code: uint32(code)}
+}
+
+// BACnet status-flags 位
+const (
+	BACnetInAlarm = iota
+	BACnetFault
+	BACnetOverridden
+	BACnetOutOfService
+)
+
+// BACnetNoFaultDetected reliability 无故障
+const BACnetNoFaultDetected = 0
+
+// FromBACnet BACnet reliability 和 status-flags，statusFlags 为空时只使用 reliability
+// reliability 不为 no-fault-detected 或者 fault 置位为 BAD，out-of-service 或者 overridden 置位为 UNCERTAIN，
+// in-alarm 只表示报警状态，不影响质量
+func FromBACnet(reliability uint32, statusFlags []bool) Quality {
+	q := Quality{Level: Good, Code: reliability}
+	flag := func(i int) bool {
+		return i < len(statusFlags) && statusFlags[i]
+	}
+	switch {
+	case reliability != BACnetNoFaultDetected || flag(BACnetFault):
+		q.Level = Bad
+	case flag(BACnetOutOfService) || flag(BACnetOverridden):
+		q.Level = Uncertain
+	}
+	return q
+}
+
+// IEC 60870-5-101/104 品质描述位
+const (
+	IEC104Overflow    = 0x01
+	IEC104Blocked     = 0x10
+	IEC104Substituted = 0x20
+	IEC104NotTopical  = 0x40
+	IEC104Invalid     = 0x80
+)
+
+// FromIEC104 IEC 60870-5-104 品质描述，IV 置位为 BAD，NT、SB、BL 或者 OV 置位为 UNCERTAIN
+func FromIEC104(q uint8) Quality {
+	switch {
+	case q&IEC104Invalid != 0:
+		return Quality{Level: Bad, Code: uint32(q)}
+	case q&(IEC104NotTopical|IEC104Substituted|IEC104Blocked|IEC104Overflow) != 0:
+		return Quality{Level: Uncertain, Code: uint32(q)}
+	default:
+		return Quality{Level: Good, Code: uint32(q)}
+	}
+}
+
+// OPC DA 质量字的质量位（bit 7-6）
+const (
+	OPCDAQualityMask = 0xc0
+	OPCDABad         = 0x00
+	OPCDAUncertain   = 0x40
+	OPCDAGood        = 0xc0
+)
+
+// FromOPCDA OPC DA 质量字，按质量位：11 GOOD，01 UNCERTAIN，00 和 10 为 BAD，子状态和限制位保留在 Code
+func FromOPCDA(q uint16) Quality {
+	switch q & OPCDAQualityMask {
+	case OPCDAGood:
+		return Quality{Level: Good, Code: uint32(q)}
+	case OPCDAUncertain:
+		return Quality{Level: Uncertain, Code: uint32(q)}
+	default:
+		return Quality{Level: Bad, Code: uint32(q)}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quality
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestFromOPCUA(t *testing.T) {
+	assert.Equal(t, Good, FromOPCUA(0).Level)
+	// BadNodeIdUnknown
+	q := FromOPCUA(0x80340000)
+	assert.Equal(t, Bad, q.Level)
+	assert.Equal(t, uint32(0x80340000), q.Code)
+	// UncertainLastUsableValue
+	assert.Equal(t, Uncertain, FromOPCUA(0x40900000).Level)
+	// GoodClamped
+	assert.Equal(t, Good, FromOPCUA(0x00300000).Level)
+}
+
+func TestFromModbusException(t *testing.T) {
+	assert.Equal(t, GoodQuality, FromModbusException(0))
+	q := FromModbusException(ModbusIllegalDataAddress)
+	assert.True(t, q.IsBad())
+	assert.Equal(t, uint32(2), q.Code)
+}
+
+func TestFromBACnet(t *testing.T) {
+	assert.True(t, FromBACnet(0, nil).IsGood())
+	// in-alarm 不影响质量
+	assert.True(t, FromBACnet(0, []bool{true, false, false, false}).IsGood())
+	assert.Equal(t, Uncertain, FromBACnet(0, []bool{false, false, false, true}).Level)
+	assert.Equal(t, Uncertain, FromBACnet(0, []bool{false, false, true, false}).Level)
+	assert.Equal(t, Bad, FromBACnet(0, []bool{false, true, false, true}).Level)
+	// over-range
+	q := FromBACnet(2, nil)
+	assert.Equal(t, Bad, q.Level)
+	assert.Equal(t, uint32(2), q.Code)
+}
+
+func TestFromIEC104(t *testing.T) {
+	assert.True(t, FromIEC104(0).IsGood())
+	assert.Equal(t, Uncertain, FromIEC104(IEC104NotTopical).Level)
+	assert.Equal(t, Uncertain, FromIEC104(IEC104Blocked|IEC104Substituted).Level)
+	q := FromIEC104(IEC104Invalid | IEC104NotTopical)
+	assert.True(t, q.IsBad())
+	assert.Equal(t, uint32(0xc0), q.Code)
+}
+
+func TestFromOPCDA(t *testing.T) {
+	assert.True(t, FromOPCDA(0xc0).IsGood())
+	// 最后可用值
+	assert.Equal(t, Quality{Level: Uncertain, Code: 0x44}, FromOPCDA(0x44))
+	// 通信失败和 N/A
+	assert.True(t, FromOPCDA(0x18).IsBad())
+	assert.True(t, FromOPCDA(0x80).IsBad())
+}
+
+func TestLevel(t *testing.T) {
+	l, ok := ParseLevel(" uncertain")
+	assert.True(t, ok)
+	assert.Equal(t, Uncertain, l)
+	_, ok = ParseLevel("ok")
+	assert.False(t, ok)
+
+	bad := Quality{Level: Bad, Code: 4}
+	assert.Equal(t, bad, Worst(GoodQuality, bad))
+	assert.Equal(t, bad, Worst(bad, Quality{Level: Uncertain}))
+	assert.Equal(t, GoodQuality, Worst(GoodQuality, Quality{Level: Good, Code: 1}))
+
+	b, err := json.Marshal(FromOPCUA(0x40900000))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"qualityLevel":"UNCERTAIN","qualityCode":1083179008}`, string(b))
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sharedconn
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/health"
+)
+
+// Client 共享的设备连接
+type Client interface {
+	// Closed 连接是否已经断开，断开后下一次使用时重新连接
+	Closed() bool
+	Close() error
+}
+
+// Pool 按连接目标共享的连接池
+// 相同目标的多个节点共用一个连接，PLC、CNC 等设备允许的连接数量有限，避免每个节点占用一个连接
+type Pool[C Client] struct {
+	// component 上报健康状态和熔断器使用的组件名称
+	component string
+	// errClosed 连接已经释放后使用时返回的错误
+	errClosed error
+	mu        sync.Mutex
+	conns     map[string]*Conn[C]
+}
+
+// NewPool 创建连接池，component 为上报健康状态使用的组件名称
+func NewPool[C Client](component string, errClosed error) *Pool[C] {
+	return &Pool[C]{component: component, errClosed: errClosed, conns: map[string]*Conn[C]{}}
+}
+
+// Acquire 获取 key 对应的共享连接，不存在则创建，引用计数加1
+// 连接在第一次使用时通过 dial 打开，不再使用时需要调用 Release。
+// 连接的配置以第一个获取连接的组件为准，之后获取时 dial 不再使用
+func (p *Pool[C]) Acquire(key string, dial func() (C, error)) *Conn[C] {
+	return p.acquire(key, dial, nil)
+}
+
+// AcquireWithBreaker 获取 key 对应的共享连接并启用熔断器，设备不可用时快速失败
+// 连接存在期间熔断器的引用计数大于 0，再次获取返回同一个熔断器，只增加引用计数并应用后续节点启用的熔断配置
+func (p *Pool[C]) AcquireWithBreaker(key string, dial func() (C, error), config breaker.Config) *Conn[C] {
+	return p.acquire(key, dial, func() *breaker.Breaker {
+		return breaker.Acquire(p.component, key, config)
+	})
+}
+
+func (p *Pool[C]) acquire(key string, dial func() (C, error), acquireBreaker func() *breaker.Breaker) *Conn[C] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// 熔断器只在创建时设置，Do/Open 不加锁读取
+	var b *breaker.Breaker
+	if acquireBreaker != nil {
+		b = acquireBreaker()
+	}
+	c, ok := p.conns[key]
+	if !ok {
+		c = &Conn[C]{pool: p, key: key, dial: dial, breaker: b}
+		p.conns[key] = c
+	}
+	c.mu.Lock()
+	c.refs++
+	c.mu.Unlock()
+	return c
+}
+
+// Conn 连接池中的共享连接
+type Conn[C Client] struct {
+	pool *Pool[C]
+	key  string
+	dial func() (C, error)
+	mu   sync.Mutex
+	// breaker 熔断器，nil 表示不启用
+	breaker *breaker.Breaker
+	// client 当前连接，opened 为 false 表示尚未打开或者需要重建
+	client C
+	opened bool
+	// refs 引用计数，为 0 时关闭连接
+	refs   int
+	closed bool
+}
+
+// Key 连接目标
+func (c *Conn[C]) Key() string {
+	return c.key
+}
+
+// Client 获取连接，未打开或者已经断开时重新连接
+func (c *Conn[C]) Client() (C, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero C
+	if c.closed {
+		return zero, c.pool.errClosed
+	}
+	if c.opened && !c.client.Closed() {
+		return c.client, nil
+	}
+	client, err := c.dial()
+	if err != nil {
+		health.Disconnected(c.pool.component, c.key, err)
+		return zero, err
+	}
+	c.client, c.opened = client, true
+	health.Connected(c.pool.component, c.key)
+	return client, nil
+}
+
+// Open 熔断器允许时获取连接，连接结果上报到熔断器，熔断器断开时返回 breaker.OpenError
+func (c *Conn[C]) Open() (C, error) {
+	if err := c.breaker.Allow(); err != nil {
+		var zero C
+		return zero, err
+	}
+	client, err := c.Client()
+	c.breaker.Done(err)
+	return client, err
+}
+
+// Do 使用连接执行 fn，连接在执行过程中断开时重新连接并重试一次
+// 执行结果和耗时上报到健康状态监控。熔断器断开时不执行 fn，直接返回 breaker.OpenError，
+// 连接失败或者连接断开计为熔断器的失败，设备返回的错误（eg. 地址不存在）说明设备可用，不计为失败
+func (c *Conn[C]) Do(fn func(client C) error) error {
+	if err := c.breaker.Allow(); err != nil {
+		return err
+	}
+	client, err := c.Client()
+	if err != nil {
+		c.breaker.Done(err)
+		return err
+	}
+	start := time.Now()
+	if err = fn(client); err != nil && client.Closed() {
+		health.Disconnected(c.pool.component, c.key, err)
+		if client, err = c.Client(); err != nil {
+			c.breaker.Done(err)
+			return err
+		}
+		start = time.Now()
+		err = fn(client)
+	}
+	health.Report(c.pool.component, c.key, time.Since(start), err)
+	if err != nil && client.Closed() {
+		c.breaker.Done(err)
+	} else {
+		c.breaker.Done(nil)
+	}
+	return err
+}
+
+// Release 引用计数减1，为 0 时关闭连接并从连接池移除
+func (c *Conn[C]) Release() error {
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.breaker.Release()
+	c.refs--
+	if c.refs > 0 {
+		return nil
+	}
+	c.closed = true
+	if p.conns[c.key] == c {
+		delete(p.conns, c.key)
+	}
+	health.Remove(p.component, c.key)
+	if c.opened {
+		client := c.client
+		var zero C
+		c.client, c.opened = zero, false
+		return client.Close()
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sharedconn
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rulego/rulego-components-iot/pkg/breaker"
+	"github.com/rulego/rulego-components-iot/pkg/health"
+	"github.com/rulego/rulego/test/assert"
+)
+
+var errClosed = errors.New("test connection is closed")
+
+type testClient struct {
+	closed bool
+}
+
+func (c *testClient) Closed() bool {
+	return c.closed
+}
+
+func (c *testClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestPool(t *testing.T) {
+	pool := NewPool[*testClient]("test", errClosed)
+	var dials int
+	dial := func() (*testClient, error) {
+		dials++
+		return &testClient{}, nil
+	}
+	conn := pool.Acquire("plc1", dial)
+	// 相同目标共用一个连接
+	assert.True(t, pool.Acquire("plc1", dial) == conn)
+	assert.True(t, pool.Acquire("plc2", dial) != conn)
+	assert.Equal(t, "plc1", conn.Key())
+	assert.Equal(t, 0, dials)
+
+	client, err := conn.Client()
+	assert.Nil(t, err)
+	same, _ := conn.Client()
+	assert.True(t, same == client)
+	assert.Equal(t, 1, dials)
+	assert.Equal(t, health.StateConnected, health.List("test")[0].State)
+
+	// 执行过程中连接断开，重新连接后重试一次
+	var calls int
+	err = conn.Do(func(c *testClient) error {
+		calls++
+		if c == client {
+			c.closed = true
+			return errors.New("broken pipe")
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, dials)
+
+	// 设备返回的错误不重试
+	calls = 0
+	err = conn.Do(func(c *testClient) error {
+		calls++
+		return errors.New("address not found")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+
+	// 引用计数为 0 时关闭连接并从连接池移除
+	client, _ = conn.Client()
+	assert.Nil(t, conn.Release())
+	assert.False(t, client.Closed())
+	assert.Nil(t, conn.Release())
+	assert.True(t, client.Closed())
+	_, err = conn.Client()
+	assert.Equal(t, errClosed, err)
+	assert.Nil(t, conn.Release())
+	assert.True(t, pool.Acquire("plc1", dial) != conn)
+}
+
+func TestPoolBreaker(t *testing.T) {
+	pool := NewPool[*testClient]("test-breaker", errClosed)
+	refused := errors.New("connection refused")
+	dial := func() (*testClient, error) {
+		return nil, refused
+	}
+	conn := pool.AcquireWithBreaker("plc1", dial, breaker.Config{FailureThreshold: 2})
+	var calls int
+	fn := func(c *testClient) error {
+		calls++
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, refused, conn.Do(fn))
+	}
+	// 连续失败达到阈值后快速失败
+	assert.True(t, breaker.IsOpen(conn.Do(fn)))
+	_, err := conn.Open()
+	assert.True(t, breaker.IsOpen(err))
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, health.StateError, health.List("test-breaker")[0].State)
+
+	assert.Nil(t, conn.Release())
+	assert.True(t, breaker.Get("test-breaker", "plc1") == nil)
+
+	// 不启用熔断器时每次都尝试连接
+	conn = pool.Acquire("plc1", dial)
+	defer conn.Release()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, refused, conn.Do(fn))
+	}
+}
@@ -0,0 +1,489 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// MQTT 3.1.1 控制报文类型
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetPubrec      = 5
+	packetPubrel      = 6
+	packetPubcomp     = 7
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// Message 代理收到的发布消息
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Broker 嵌入式 MQTT 3.1.1 代理，用于测试 MQTT、Sparkplug 等组件。
+// 支持订阅通配符、保留消息和遗嘱消息，接收 QoS 0/1/2 的发布，统一按 QoS 0 转发给订阅者，不支持持久会话和认证
+type Broker struct {
+	listener net.Listener
+	mu       sync.RWMutex
+	clients  map[*brokerClient]struct{}
+	retained map[string]Message
+	// hooks 代理内部的订阅
+	hooks  []brokerHook
+	wg     sync.WaitGroup
+	closed bool
+}
+
+type brokerHook struct {
+	filter string
+	fn     func(Message)
+}
+
+type brokerClient struct {
+	conn    net.Conn
+	id      string
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	filters map[string]struct{}
+	will    *Message
+}
+
+// NewBroker 在 addr 上启动代理，addr 为空使用 127.0.0.1 的随机端口
+func NewBroker(addr string) (*Broker, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	b := &Broker{
+		listener: l,
+		clients:  make(map[*brokerClient]struct{}),
+		retained: make(map[string]Message),
+	}
+	b.wg.Add(1)
+	go b.serve()
+	return b, nil
+}
+
+// Addr 代理的监听地址，格式：host:port
+func (b *Broker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+// URL 代理的地址，格式：tcp://host:port
+func (b *Broker) URL() string {
+	return "tcp://" + b.Addr()
+}
+
+// Publish 向订阅者发布消息，retain 为 true 时保存为保留消息，空负荷删除保留消息
+func (b *Broker) Publish(topic string, payload []byte, retain bool) {
+	b.route(Message{Topic: topic, Payload: payload, Retain: retain})
+}
+
+// Subscribe 在代理内部订阅主题，收到匹配的发布消息时调用 fn，用于断言组件发布的消息
+func (b *Broker) Subscribe(filter string, fn func(msg Message)) {
+	b.mu.Lock()
+	b.hooks = append(b.hooks, brokerHook{filter: filter, fn: fn})
+	retained := b.matchRetained(filter)
+	b.mu.Unlock()
+	for _, msg := range retained {
+		fn(msg)
+	}
+}
+
+// Clients 已连接的客户端 Id
+func (b *Broker) Clients() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	ids := make([]string, 0, len(b.clients))
+	for c := range b.clients {
+		ids = append(ids, c.id)
+	}
+	return ids
+}
+
+// Close 关闭代理并断开所有客户端，不发布遗嘱消息
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	for c := range b.clients {
+		c.mu.Lock()
+		c.will = nil
+		c.mu.Unlock()
+		_ = c.conn.Close()
+	}
+	b.mu.Unlock()
+	err := b.listener.Close()
+	b.wg.Wait()
+	return err
+}
+
+func (b *Broker) serve() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.handle(conn)
+		}()
+	}
+}
+
+// handle 处理客户端连接，连接异常断开时发布遗嘱消息
+func (b *Broker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	header, body, err := readPacket(r)
+	if err != nil || header>>4 != packetConnect {
+		return
+	}
+	c := &brokerClient{conn: conn, filters: make(map[string]struct{})}
+	if err = c.parseConnect(body); err != nil {
+		_ = c.write(packetConnack<<4, []byte{0, 1})
+		return
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	// 相同客户端 Id 的旧连接被踢下线
+	for old := range b.clients {
+		if c.id != "" && old.id == c.id {
+			_ = old.conn.Close()
+		}
+	}
+	b.clients[c] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, c)
+		b.mu.Unlock()
+		c.mu.Lock()
+		will := c.will
+		c.mu.Unlock()
+		if will != nil {
+			b.route(*will)
+		}
+	}()
+	if err = c.write(packetConnack<<4, []byte{0, 0}); err != nil {
+		return
+	}
+	for {
+		header, body, err = readPacket(r)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case packetPublish:
+			msg, packetId, qos, err := parsePublish(header, body)
+			if err != nil {
+				return
+			}
+			switch qos {
+			case 1:
+				_ = c.write(packetPuback<<4, packetId)
+			case 2:
+				_ = c.write(packetPubrec<<4, packetId)
+			}
+			b.route(msg)
+		case packetPubrel:
+			_ = c.write(packetPubcomp<<4, body)
+		case packetSubscribe:
+			if err = b.subscribe(c, body); err != nil {
+				return
+			}
+		case packetUnsubscribe:
+			if len(body) < 2 {
+				return
+			}
+			for rest := body[2:]; len(rest) > 0; {
+				var filter string
+				if filter, rest, err = readString(rest); err != nil {
+					return
+				}
+				c.mu.Lock()
+				delete(c.filters, filter)
+				c.mu.Unlock()
+			}
+			_ = c.write(packetUnsuback<<4, body[:2])
+		case packetPingreq:
+			_ = c.write(packetPingresp<<4, nil)
+		case packetDisconnect:
+			c.mu.Lock()
+			c.will = nil
+			c.mu.Unlock()
+			return
+		}
+	}
+}
+
+// subscribe 处理订阅，授予 QoS 0 并发送匹配的保留消息
+func (b *Broker) subscribe(c *brokerClient, body []byte) error {
+	if len(body) < 2 {
+		return errors.New("invalid subscribe packet")
+	}
+	var filters []string
+	granted := append([]byte{}, body[:2]...)
+	for rest := body[2:]; len(rest) > 0; {
+		var filter string
+		var err error
+		if filter, rest, err = readString(rest); err != nil || len(rest) < 1 {
+			return errors.New("invalid subscribe packet")
+		}
+		rest = rest[1:]
+		filters = append(filters, filter)
+		granted = append(granted, 0)
+	}
+	c.mu.Lock()
+	for _, filter := range filters {
+		c.filters[filter] = struct{}{}
+	}
+	c.mu.Unlock()
+	if err := c.write(packetSuback<<4, granted); err != nil {
+		return err
+	}
+	b.mu.RLock()
+	var retained []Message
+	for _, filter := range filters {
+		retained = append(retained, b.matchRetained(filter)...)
+	}
+	b.mu.RUnlock()
+	for _, msg := range retained {
+		_ = c.publish(msg)
+	}
+	return nil
+}
+
+// route 保存保留消息并转发给匹配的订阅者
+func (b *Broker) route(msg Message) {
+	b.mu.Lock()
+	if msg.Retain {
+		if len(msg.Payload) == 0 {
+			delete(b.retained, msg.Topic)
+		} else {
+			b.retained[msg.Topic] = msg
+		}
+	}
+	clients := make([]*brokerClient, 0, len(b.clients))
+	for c := range b.clients {
+		clients = append(clients, c)
+	}
+	var hooks []func(Message)
+	for _, h := range b.hooks {
+		if MatchTopic(h.filter, msg.Topic) {
+			hooks = append(hooks, h.fn)
+		}
+	}
+	b.mu.Unlock()
+	// 转发的消息不保留
+	msg.Retain = false
+	for _, c := range clients {
+		if c.matches(msg.Topic) {
+			_ = c.publish(msg)
+		}
+	}
+	for _, fn := range hooks {
+		fn(msg)
+	}
+}
+
+// matchRetained 匹配订阅的保留消息，调用方持有锁
+func (b *Broker) matchRetained(filter string) []Message {
+	var result []Message
+	for topic, msg := range b.retained {
+		if MatchTopic(filter, topic) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// parseConnect 解析 CONNECT 报文的客户端 Id 和遗嘱消息
+func (c *brokerClient) parseConnect(body []byte) error {
+	protocol, rest, err := readString(body)
+	if err != nil || (protocol != "MQTT" && protocol != "MQIsdp") || len(rest) < 4 {
+		return errors.New("unsupported protocol")
+	}
+	flags := rest[1]
+	rest = rest[4:]
+	if c.id, rest, err = readString(rest); err != nil {
+		return err
+	}
+	if flags&0x04 != 0 {
+		var topic, payload string
+		if topic, rest, err = readString(rest); err != nil {
+			return err
+		}
+		if payload, _, err = readString(rest); err != nil {
+			return err
+		}
+		c.will = &Message{Topic: topic, Payload: []byte(payload), Retain: flags&0x20 != 0}
+	}
+	return nil
+}
+
+func (c *brokerClient) matches(topic string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for filter := range c.filters {
+		if MatchTopic(filter, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *brokerClient) publish(msg Message) error {
+	body := appendString(nil, msg.Topic)
+	body = append(body, msg.Payload...)
+	header := byte(packetPublish << 4)
+	if msg.Retain {
+		header |= 0x01
+	}
+	return c.write(header, body)
+}
+
+func (c *brokerClient) write(header byte, body []byte) error {
+	buf := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	buf = append(buf, body...)
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// readPacket 读取一个控制报文，返回固定报头的第一个字节和剩余部分
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7F) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err = io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// parsePublish 解析 PUBLISH 报文，返回消息、报文标识符和 QoS
+func parsePublish(header byte, body []byte) (Message, []byte, byte, error) {
+	qos := header >> 1 & 0x03
+	topic, rest, err := readString(body)
+	if err != nil {
+		return Message{}, nil, 0, err
+	}
+	var packetId []byte
+	if qos > 0 {
+		if len(rest) < 2 {
+			return Message{}, nil, 0, errors.New("invalid publish packet")
+		}
+		packetId, rest = rest[:2], rest[2:]
+	}
+	return Message{Topic: topic, Payload: append([]byte{}, rest...), Retain: header&0x01 != 0}, packetId, qos, nil
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("malformed string")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, fmt.Errorf("malformed string, length %d", n)
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// MatchTopic 主题是否匹配订阅，支持 + 和 # 通配符，通配符不匹配 $ 开头的主题
+func MatchTopic(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") && !strings.HasPrefix(filter, "$") {
+		return false
+	}
+	fs := strings.Split(filter, "/")
+	ts := strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) {
+			return false
+		}
+		if f != "+" && f != ts[i] {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/simonvetter/modbus"
+)
+
+// Modbus 数据区，同 x/modbusRead 的 area
+const (
+	AreaCoil            = "coil"
+	AreaDiscreteInput   = "discreteInput"
+	AreaHoldingRegister = "holdingRegister"
+	AreaInputRegister   = "inputRegister"
+)
+
+// ModbusWrite 主站写入的数据，Values 为 []bool 或者 []uint16
+type ModbusWrite struct {
+	UnitId  uint8
+	Area    string
+	Address uint16
+	Values  interface{}
+}
+
+// ModbusServer Modbus TCP 从站模拟器，四个数据区各 65536 个地址，响应任意从机编号。
+// 主站写入的线圈和保持寄存器通过 OnWrite 通知
+type ModbusServer struct {
+	server *modbus.ModbusServer
+	url    string
+	mu     sync.RWMutex
+	coils  []bool
+	inputs []bool
+	// holding 保持寄存器
+	holding []uint16
+	// registers 输入寄存器
+	registers []uint16
+	// OnWrite 主站写入后调用，需要在 Start 之前设置
+	OnWrite func(write ModbusWrite)
+}
+
+// NewModbusServer 创建从站，addr 格式：host:port，为空使用 127.0.0.1 的随机端口
+func NewModbusServer(addr string) (*ModbusServer, error) {
+	if addr == "" {
+		var err error
+		if addr, err = freeAddr(); err != nil {
+			return nil, err
+		}
+	}
+	s := &ModbusServer{
+		url:       "tcp://" + addr,
+		coils:     make([]bool, 65536),
+		inputs:    make([]bool, 65536),
+		holding:   make([]uint16, 65536),
+		registers: make([]uint16, 65536),
+	}
+	server, err := modbus.NewServer(&modbus.ServerConfiguration{
+		URL:        s.url,
+		Timeout:    30 * time.Second,
+		MaxClients: 10,
+	}, &modbusHandler{s: s})
+	if err != nil {
+		return nil, err
+	}
+	s.server = server
+	return s, nil
+}
+
+// Start 开始监听
+func (s *ModbusServer) Start() error {
+	return s.server.Start()
+}
+
+// Close 停止监听并断开所有主站
+func (s *ModbusServer) Close() error {
+	return s.server.Stop()
+}
+
+// URL 从站地址，格式：tcp://host:port，可以直接作为 x/modbusRead 的 server
+func (s *ModbusServer) URL() string {
+	return s.url
+}
+
+// SetCoils 设置从 addr 开始的线圈
+func (s *ModbusServer) SetCoils(addr uint16, values ...bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.coils[addr:], values)
+}
+
+// SetDiscreteInputs 设置从 addr 开始的离散输入
+func (s *ModbusServer) SetDiscreteInputs(addr uint16, values ...bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.inputs[addr:], values)
+}
+
+// SetHoldingRegisters 设置从 addr 开始的保持寄存器
+func (s *ModbusServer) SetHoldingRegisters(addr uint16, values ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.holding[addr:], values)
+}
+
+// SetInputRegisters 设置从 addr 开始的输入寄存器
+func (s *ModbusServer) SetInputRegisters(addr uint16, values ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.registers[addr:], values)
+}
+
+// SetFloat32 按 ABCD 字节序把浮点数写入 addr 和 addr+1 两个寄存器，area 为 holdingRegister 或 inputRegister
+func (s *ModbusServer) SetFloat32(area string, addr uint16, v float32) {
+	bits := math.Float32bits(v)
+	if area == AreaInputRegister {
+		s.SetInputRegisters(addr, uint16(bits>>16), uint16(bits))
+	} else {
+		s.SetHoldingRegisters(addr, uint16(bits>>16), uint16(bits))
+	}
+}
+
+// Coils 读取从 addr 开始的 n 个线圈
+func (s *ModbusServer) Coils(addr uint16, n int) []bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]bool{}, s.coils[addr:int(addr)+n]...)
+}
+
+// HoldingRegisters 读取从 addr 开始的 n 个保持寄存器
+func (s *ModbusServer) HoldingRegisters(addr uint16, n int) []uint16 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]uint16{}, s.holding[addr:int(addr)+n]...)
+}
+
+// modbusHandler 处理主站请求
+type modbusHandler struct {
+	s *ModbusServer
+}
+
+func (h *modbusHandler) HandleCoils(req *modbus.CoilsRequest) ([]bool, error) {
+	s := h.s
+	if int(req.Addr)+int(req.Quantity) > len(s.coils) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	if !req.IsWrite {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return append([]bool{}, s.coils[req.Addr:int(req.Addr)+int(req.Quantity)]...), nil
+	}
+	s.mu.Lock()
+	copy(s.coils[req.Addr:], req.Args)
+	s.mu.Unlock()
+	s.onWrite(ModbusWrite{UnitId: req.UnitId, Area: AreaCoil, Address: req.Addr, Values: append([]bool{}, req.Args...)})
+	return nil, nil
+}
+
+func (h *modbusHandler) HandleDiscreteInputs(req *modbus.DiscreteInputsRequest) ([]bool, error) {
+	s := h.s
+	if int(req.Addr)+int(req.Quantity) > len(s.inputs) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]bool{}, s.inputs[req.Addr:int(req.Addr)+int(req.Quantity)]...), nil
+}
+
+func (h *modbusHandler) HandleHoldingRegisters(req *modbus.HoldingRegistersRequest) ([]uint16, error) {
+	s := h.s
+	if int(req.Addr)+int(req.Quantity) > len(s.holding) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	if !req.IsWrite {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return append([]uint16{}, s.holding[req.Addr:int(req.Addr)+int(req.Quantity)]...), nil
+	}
+	s.mu.Lock()
+	copy(s.holding[req.Addr:], req.Args)
+	s.mu.Unlock()
+	s.onWrite(ModbusWrite{UnitId: req.UnitId, Area: AreaHoldingRegister, Address: req.Addr, Values: append([]uint16{}, req.Args...)})
+	return nil, nil
+}
+
+func (h *modbusHandler) HandleInputRegisters(req *modbus.InputRegistersRequest) ([]uint16, error) {
+	s := h.s
+	if int(req.Addr)+int(req.Quantity) > len(s.registers) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]uint16{}, s.registers[req.Addr:int(req.Addr)+int(req.Quantity)]...), nil
+}
+
+func (s *ModbusServer) onWrite(write ModbusWrite) {
+	if s.OnWrite != nil {
+		s.OnWrite(write)
+	}
+}
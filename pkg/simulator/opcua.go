@@ -0,0 +1,246 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/server/attrs"
+	"github.com/gopcua/opcua/ua"
+
+	opcuaClient "github.com/rulego/rulego-components-iot/pkg/opcua_client"
+)
+
+// DefaultNamespace OPC UA 模拟器默认的命名空间
+const DefaultNamespace = "urn:rulego:simulator"
+
+// OPCUAVariable OPC UA 模拟器的变量
+type OPCUAVariable struct {
+	// Name 变量名称，作为字符串 NodeId 的标识符
+	Name string
+	// DataType 数据类型：Boolean, Int16, Int32, Float, Double, String 等，为空按 Value 推断
+	DataType string
+	// Value 初始值
+	Value interface{}
+	// Writable 是否允许客户端写入
+	Writable bool
+}
+
+// OPCUAWrite 客户端写入的变量
+type OPCUAWrite struct {
+	NodeId string
+	Name   string
+	Value  interface{}
+}
+
+// OPCUAServer 最小的 OPC UA 服务器模拟器，只支持 None 安全策略和匿名登录，
+// 变量挂在 Objects 下，NodeId 为 ns=<命名空间>;s=<名称>，客户端写入通过 OnWrite 通知
+type OPCUAServer struct {
+	srv       *server.Server
+	nodeNS    *server.NodeNameSpace
+	endpoint  string
+	cancel    context.CancelFunc
+	mu        sync.RWMutex
+	variables map[string]*server.Node
+	dataTypes map[string]string
+	// OnWrite 客户端写入后调用，需要在 Start 之前设置
+	OnWrite func(write OPCUAWrite)
+	done    chan struct{}
+}
+
+// NewOPCUAServer 创建服务器，addr 格式：host:port，为空使用 127.0.0.1 的随机端口
+func NewOPCUAServer(addr string, variables ...OPCUAVariable) (*OPCUAServer, error) {
+	if addr == "" {
+		var err error
+		if addr, err = freeAddr(); err != nil {
+			return nil, err
+		}
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+	s := &OPCUAServer{
+		endpoint:  fmt.Sprintf("opc.tcp://%s:%d", host, port),
+		variables: make(map[string]*server.Node, len(variables)),
+		dataTypes: make(map[string]string, len(variables)),
+	}
+	s.srv = server.New(
+		server.EndPoint(host, port),
+		server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+		server.ServerName("RuleGo Simulator"),
+	)
+	s.nodeNS = server.NewNodeNameSpace(s.srv, DefaultNamespace)
+	rootNS, err := s.srv.Namespace(0)
+	if err != nil {
+		return nil, err
+	}
+	rootNS.Objects().AddRef(s.nodeNS.Objects(), id.HasComponent, true)
+	for _, v := range variables {
+		if err := s.AddVariable(v); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// AddVariable 添加变量
+func (s *OPCUAServer) AddVariable(v OPCUAVariable) error {
+	if v.Name == "" {
+		return errors.New("opcua variable name cannot be empty")
+	}
+	variant, err := opcuaClient.NewVariant(v.Value, v.DataType)
+	if err != nil {
+		return fmt.Errorf("variable %s: %w", v.Name, err)
+	}
+	access := byte(ua.AccessLevelTypeCurrentRead)
+	if v.Writable {
+		access |= byte(ua.AccessLevelTypeCurrentWrite)
+	}
+	dv := &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp | ua.DataValueServerTimestamp,
+		Value:           variant,
+		SourceTimestamp: time.Now(),
+		ServerTimestamp: time.Now(),
+	}
+	n := server.NewNode(
+		ua.NewStringNodeID(s.nodeNS.ID(), v.Name),
+		map[ua.AttributeID]*ua.DataValue{
+			ua.AttributeIDNodeClass:       server.DataValueFromValue(uint32(ua.NodeClassVariable)),
+			ua.AttributeIDBrowseName:      server.DataValueFromValue(attrs.BrowseName(v.Name)),
+			ua.AttributeIDDisplayName:     server.DataValueFromValue(attrs.DisplayName(v.Name, "")),
+			ua.AttributeIDDataType:        server.DataValueFromValue(ua.NewNumericExpandedNodeID(0, uint32(variant.Type()))),
+			ua.AttributeIDAccessLevel:     server.DataValueFromValue(access),
+			ua.AttributeIDUserAccessLevel: server.DataValueFromValue(access),
+		},
+		nil,
+		func() *ua.DataValue { return dv },
+	)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodeNS.AddNode(n)
+	s.nodeNS.Objects().AddRef(n, id.HasComponent, true)
+	s.variables[v.Name] = n
+	s.dataTypes[v.Name] = v.DataType
+	return nil
+}
+
+// Start 开始监听
+func (s *OPCUAServer) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.srv.Start(ctx); err != nil {
+		cancel()
+		return err
+	}
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.watchWrites(ctx)
+	return nil
+}
+
+// Close 停止服务器
+func (s *OPCUAServer) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+	return s.srv.Close()
+}
+
+// Endpoint 服务器地址，格式：opc.tcp://host:port，可以直接作为 x/opcuaRead 的 server
+func (s *OPCUAServer) Endpoint() string {
+	return s.endpoint
+}
+
+// NodeId 变量的 NodeId，例如：ns=1;s=Temperature
+func (s *OPCUAServer) NodeId(name string) string {
+	return ua.NewStringNodeID(s.nodeNS.ID(), name).String()
+}
+
+// SetValue 更新变量值并通知订阅的客户端
+func (s *OPCUAServer) SetValue(name string, value interface{}) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.variables[name]
+	if !ok {
+		return fmt.Errorf("variable not found: %s", name)
+	}
+	v, err := opcuaClient.NewVariant(value, s.dataTypes[name])
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_ = n.SetAttribute(ua.AttributeIDValue, &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp | ua.DataValueServerTimestamp,
+		Value:           v,
+		SourceTimestamp: now,
+		ServerTimestamp: now,
+	})
+	s.nodeNS.ChangeNotification(n.ID())
+	return nil
+}
+
+// Value 变量的当前值
+func (s *OPCUAServer) Value(name string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.variables[name]
+	if !ok {
+		return nil, fmt.Errorf("variable not found: %s", name)
+	}
+	if dv := n.Value(); dv != nil && dv.Value != nil {
+		return dv.Value.Value(), nil
+	}
+	return nil, nil
+}
+
+// watchWrites 监听客户端写入
+func (s *OPCUAServer) watchWrites(ctx context.Context) {
+	defer close(s.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case nid := <-s.nodeNS.ExternalNotification:
+			if nid == nil || s.OnWrite == nil {
+				continue
+			}
+			n := s.nodeNS.Node(nid)
+			if n == nil {
+				continue
+			}
+			write := OPCUAWrite{NodeId: nid.String(), Name: nid.StringID()}
+			if dv := n.Value(); dv != nil && dv.Value != nil {
+				write.Value = dv.Value.Value()
+			}
+			s.OnWrite(write)
+		}
+	}
+}
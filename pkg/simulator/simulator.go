@@ -0,0 +1,181 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package simulator 提供协议模拟器，用于集成测试：嵌入式 MQTT 代理、Modbus TCP 从站、
+// 可写变量的 OPC UA 服务器和 Sparkplug B 边缘节点，不需要真实设备就可以测试规则链。
+// StartXxx 辅助函数在测试结束时自动关闭模拟器：
+//
+//	func TestChain(t *testing.T) {
+//		slave := simulator.StartModbus(t)
+//		slave.SetHoldingRegisters(0, 215)
+//		// 规则链中 x/modbusRead 的 server 配置为 slave.URL()
+//	}
+package simulator
+
+import (
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/external/sparkplug"
+)
+
+// Signal 模拟信号，返回 t 时刻的值
+type Signal func(t time.Time) float64
+
+// Sine 正弦信号：offset + amplitude * sin(2π t / period)
+func Sine(offset, amplitude float64, period time.Duration) Signal {
+	return func(t time.Time) float64 {
+		return offset + amplitude*math.Sin(2*math.Pi*phase(t, period))
+	}
+}
+
+// Ramp 锯齿信号，每个周期从 min 线性增加到 max
+func Ramp(min, max float64, period time.Duration) Signal {
+	return func(t time.Time) float64 {
+		return min + (max-min)*phase(t, period)
+	}
+}
+
+// Square 方波信号，前半周期为 high，后半周期为 low
+func Square(low, high float64, period time.Duration) Signal {
+	return func(t time.Time) float64 {
+		if phase(t, period) < 0.5 {
+			return high
+		}
+		return low
+	}
+}
+
+// Noise 在 min 和 max 之间均匀分布的随机信号
+func Noise(min, max float64) Signal {
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return func(t time.Time) float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return min + (max-min)*r.Float64()
+	}
+}
+
+// Add 叠加信号，例如 Add(Sine(20, 5, time.Minute), Noise(-0.1, 0.1))
+func Add(signals ...Signal) Signal {
+	return func(t time.Time) float64 {
+		v := 0.0
+		for _, s := range signals {
+			v += s(t)
+		}
+		return v
+	}
+}
+
+// phase t 在周期中的位置 [0, 1)
+func phase(t time.Time, period time.Duration) float64 {
+	if period <= 0 {
+		return 0
+	}
+	return float64(t.UnixNano()%int64(period)) / float64(period)
+}
+
+// Drive 每隔 interval 调用 fn 更新模拟器的值，例如根据 Signal 设置寄存器，返回停止函数
+func Drive(interval time.Duration, fn func(now time.Time)) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				fn(now)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// StartBroker 启动 MQTT 代理，测试结束时关闭
+func StartBroker(t testing.TB) *Broker {
+	t.Helper()
+	b, err := NewBroker("")
+	if err != nil {
+		t.Fatalf("start mqtt broker: %v", err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+// StartModbus 启动 Modbus TCP 从站，测试结束时关闭
+func StartModbus(t testing.TB) *ModbusServer {
+	t.Helper()
+	s, err := NewModbusServer("")
+	if err == nil {
+		err = s.Start()
+	}
+	if err != nil {
+		t.Fatalf("start modbus server: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// StartOPCUA 启动 OPC UA 服务器，测试结束时关闭
+func StartOPCUA(t testing.TB, variables ...OPCUAVariable) *OPCUAServer {
+	t.Helper()
+	s, err := NewOPCUAServer("", variables...)
+	if err == nil {
+		err = s.Start()
+	}
+	if err != nil {
+		t.Fatalf("start opcua server: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// StartSparkplug 启动 Sparkplug B 边缘节点，测试结束时关闭
+func StartSparkplug(t testing.TB, conf sparkplug.EdgeConfig) *SparkplugPublisher {
+	t.Helper()
+	p, err := NewSparkplugPublisher(conf)
+	if err != nil {
+		t.Fatalf("start sparkplug publisher: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+// freeAddr 获取 127.0.0.1 上的空闲端口
+func freeAddr() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
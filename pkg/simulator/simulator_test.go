@@ -0,0 +1,257 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/simonvetter/modbus"
+
+	"github.com/rulego/rulego-components-iot/external/sparkplug"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func connectMQTT(t *testing.T, b *Broker, id string, will bool) paho.Client {
+	opts := paho.NewClientOptions().AddBroker(b.URL()).SetClientID(id).SetAutoReconnect(false)
+	if will {
+		opts.SetWill("devices/"+id+"/status", "offline", 0, true)
+	}
+	c := paho.NewClient(opts)
+	token := c.Connect()
+	assert.True(t, token.WaitTimeout(2*time.Second))
+	assert.Nil(t, token.Error())
+	return c
+}
+
+func TestBroker(t *testing.T) {
+	b := StartBroker(t)
+	received := make(chan Message, 10)
+	b.Subscribe("devices/+/status", func(msg Message) {
+		received <- msg
+	})
+
+	sub := connectMQTT(t, b, "sub", false)
+	defer sub.Disconnect(0)
+	messages := make(chan paho.Message, 10)
+	token := sub.Subscribe("plant/#", 1, func(c paho.Client, m paho.Message) {
+		messages <- m
+	})
+	assert.True(t, token.WaitTimeout(2*time.Second))
+
+	pub := connectMQTT(t, b, "pub", true)
+	token = pub.Publish("plant/line1/temp", 1, false, "21.5")
+	assert.True(t, token.WaitTimeout(2*time.Second))
+	assert.Nil(t, token.Error())
+	select {
+	case m := <-messages:
+		assert.Equal(t, "plant/line1/temp", m.Topic())
+		assert.Equal(t, "21.5", string(m.Payload()))
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not received")
+	}
+	pub.Publish("other/topic", 0, false, "x").WaitTimeout(time.Second)
+
+	// 保留消息发送给新的订阅者
+	b.Publish("plant/state", []byte("online"), true)
+	late := connectMQTT(t, b, "late", false)
+	defer late.Disconnect(0)
+	retained := make(chan paho.Message, 1)
+	late.Subscribe("plant/state", 0, func(c paho.Client, m paho.Message) {
+		retained <- m
+	}).WaitTimeout(time.Second)
+	select {
+	case m := <-retained:
+		assert.Equal(t, "online", string(m.Payload()))
+		assert.True(t, m.Retained())
+	case <-time.After(2 * time.Second):
+		t.Fatal("retained message not received")
+	}
+
+	// 连接异常断开发布遗嘱消息
+	b.mu.RLock()
+	for c := range b.clients {
+		if c.id == "pub" {
+			_ = c.conn.Close()
+		}
+	}
+	b.mu.RUnlock()
+	select {
+	case msg := <-received:
+		assert.Equal(t, "devices/pub/status", msg.Topic)
+		assert.Equal(t, "offline", string(msg.Payload))
+	case <-time.After(2 * time.Second):
+		t.Fatal("will message not received")
+	}
+
+	assert.True(t, MatchTopic("a/+/c", "a/b/c"))
+	assert.True(t, MatchTopic("a/#", "a"))
+	assert.True(t, MatchTopic("#", "a/b"))
+	assert.False(t, MatchTopic("a/+", "a/b/c"))
+	assert.False(t, MatchTopic("#", "$SYS/uptime"))
+}
+
+func TestModbusServer(t *testing.T) {
+	s := StartModbus(t)
+	writes := make(chan ModbusWrite, 1)
+	s.OnWrite = func(write ModbusWrite) {
+		writes <- write
+	}
+	s.SetHoldingRegisters(0, 215)
+	s.SetFloat32(AreaInputRegister, 10, 1.5)
+	s.SetCoils(3, true)
+
+	client, err := modbus.NewClient(&modbus.ClientConfiguration{URL: s.URL(), Timeout: time.Second})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Open())
+	defer client.Close()
+
+	regs, err := client.ReadRegisters(0, 1, modbus.HOLDING_REGISTER)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(215), regs[0])
+	f, err := client.ReadFloat32(10, modbus.INPUT_REGISTER)
+	assert.Nil(t, err)
+	assert.Equal(t, float32(1.5), f)
+	coil, err := client.ReadCoil(3)
+	assert.Nil(t, err)
+	assert.True(t, coil)
+
+	assert.Nil(t, client.WriteRegisters(100, []uint16{1, 2}))
+	select {
+	case write := <-writes:
+		assert.Equal(t, AreaHoldingRegister, write.Area)
+		assert.Equal(t, uint16(100), write.Address)
+		assert.Equal(t, []uint16{1, 2}, write.Values)
+	case <-time.After(time.Second):
+		t.Fatal("write not received")
+	}
+	assert.Equal(t, []uint16{1, 2}, s.HoldingRegisters(100, 2))
+}
+
+func TestOPCUAServer(t *testing.T) {
+	s := StartOPCUA(t,
+		OPCUAVariable{Name: "Temperature", DataType: "Double", Value: 21.5},
+		OPCUAVariable{Name: "Setpoint", DataType: "Double", Value: 20.0, Writable: true},
+	)
+	writes := make(chan OPCUAWrite, 1)
+	s.OnWrite = func(write OPCUAWrite) {
+		writes <- write
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := opcua.NewClient(s.Endpoint(), opcua.SecurityMode(ua.MessageSecurityModeNone))
+	assert.Nil(t, err)
+	assert.Nil(t, client.Connect(ctx))
+	defer client.Close(ctx)
+
+	assert.Nil(t, s.SetValue("Temperature", 22.5))
+	resp, err := client.Read(ctx, &ua.ReadRequest{NodesToRead: []*ua.ReadValueID{
+		{NodeID: ua.MustParseNodeID(s.NodeId("Temperature")), AttributeID: ua.AttributeIDValue},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, 22.5, resp.Results[0].Value.Value())
+
+	wresp, err := client.Write(ctx, &ua.WriteRequest{NodesToWrite: []*ua.WriteValue{{
+		NodeID:      ua.MustParseNodeID(s.NodeId("Setpoint")),
+		AttributeID: ua.AttributeIDValue,
+		Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(25.0)},
+	}}})
+	assert.Nil(t, err)
+	assert.Equal(t, ua.StatusOK, wresp.Results[0])
+	select {
+	case write := <-writes:
+		assert.Equal(t, "Setpoint", write.Name)
+		assert.Equal(t, 25.0, write.Value)
+	case <-time.After(2 * time.Second):
+		t.Fatal("write not received")
+	}
+	v, err := s.Value("Setpoint")
+	assert.Nil(t, err)
+	assert.Equal(t, 25.0, v)
+	assert.NotNil(t, s.SetValue("Unknown", 1))
+}
+
+func TestSparkplugPublisher(t *testing.T) {
+	b := StartBroker(t)
+	births := make(chan *sparkplug.Payload, 10)
+	data := make(chan *sparkplug.Payload, 10)
+	b.Subscribe("spBv1.0/plant/+/edge1/#", func(msg Message) {
+		p, err := sparkplug.DecodePayload(msg.Payload)
+		if err != nil {
+			return
+		}
+		switch {
+		case MatchTopic("spBv1.0/plant/DBIRTH/edge1/#", msg.Topic):
+			births <- p
+		case MatchTopic("spBv1.0/plant/DDATA/edge1/#", msg.Topic):
+			data <- p
+		}
+	})
+	p := StartSparkplug(t, sparkplug.EdgeConfig{
+		Server:     b.Addr(),
+		GroupId:    "plant",
+		EdgeNodeId: "edge1",
+		Devices: []sparkplug.EdgeDevice{{
+			DeviceId: "boiler",
+			Metrics:  []sparkplug.EdgeMetric{{Name: "temperature", DataType: sparkplug.Double, Value: 20.0}},
+		}},
+	})
+	select {
+	case <-births:
+	case <-time.After(2 * time.Second):
+		t.Fatal("DBIRTH not received")
+	}
+	signal := Sine(20, 5, time.Second)
+	p.Run(50*time.Millisecond, "boiler", func(now time.Time) map[string]interface{} {
+		return map[string]interface{}{"temperature": signal(now)}
+	})
+	select {
+	case payload := <-data:
+		v := payload.Metrics[0].Value.(float64)
+		assert.True(t, v >= 15 && v <= 25)
+	case <-time.After(2 * time.Second):
+		t.Fatal("DDATA not received")
+	}
+}
+
+func TestSignals(t *testing.T) {
+	start := time.Unix(0, 0)
+	assert.True(t, math.Abs(Sine(10, 2, time.Second)(start.Add(250*time.Millisecond))-12) < 1e-9)
+	assert.Equal(t, 5.0, Ramp(0, 10, time.Second)(start.Add(500*time.Millisecond)))
+	assert.Equal(t, 1.0, Square(0, 1, time.Second)(start.Add(100*time.Millisecond)))
+	assert.Equal(t, 0.0, Square(0, 1, time.Second)(start.Add(600*time.Millisecond)))
+	for i := 0; i < 100; i++ {
+		v := Add(Ramp(0, 10, time.Second), Noise(-1, 1))(start.Add(500 * time.Millisecond))
+		assert.True(t, v >= 4 && v < 6)
+	}
+
+	var ticks int
+	done := make(chan struct{})
+	stop := Drive(10*time.Millisecond, func(now time.Time) {
+		if ticks++; ticks == 3 {
+			close(done)
+		}
+	})
+	<-done
+	stop()
+	stop()
+}
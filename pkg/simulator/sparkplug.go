@@ -0,0 +1,70 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/external/sparkplug"
+)
+
+// SparkplugPublisher Sparkplug B 边缘节点模拟器，连接 MQTT 服务器后发布出生证明，
+// 按周期发布生成的节点和设备指标，断开时发布死亡证明，响应主应用的 Rebirth 命令
+type SparkplugPublisher struct {
+	*sparkplug.EdgeNode
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewSparkplugPublisher 创建边缘节点并连接 conf.Server，可以使用 Broker.Addr
+func NewSparkplugPublisher(conf sparkplug.EdgeConfig) (*SparkplugPublisher, error) {
+	edge, err := sparkplug.NewEdgeNode(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &SparkplugPublisher{EdgeNode: edge, stop: make(chan struct{})}, nil
+}
+
+// Run 每隔 interval 调用 values 生成指标值并发布，deviceId 为空发布节点数据（NDATA），否则发布设备数据（DDATA），
+// 发布失败的周期忽略。可以对不同的设备多次调用
+func (p *SparkplugPublisher) Run(interval time.Duration, deviceId string, values func(now time.Time) map[string]interface{}) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case now := <-ticker.C:
+				_ = p.Publish(deviceId, values(now))
+			}
+		}
+	}()
+}
+
+// Close 停止周期发布，发布死亡证明并断开连接
+func (p *SparkplugPublisher) Close() error {
+	p.once.Do(func() {
+		close(p.stop)
+	})
+	p.wg.Wait()
+	return p.EdgeNode.Close()
+}
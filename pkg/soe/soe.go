@@ -0,0 +1,98 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package soe 提供事件顺序记录（Sequence of Events）缓存，主站把突发上送和事件轮询收到的带时标事件写入缓存，
+// 读取时按设备原始时标排序输出，链路中断期间子站缓存的事件在重新连接后补齐，不会因为轮询间隔或者报文交错而乱序
+package soe
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity 默认最多缓存的事件数量
+const DefaultCapacity = 10000
+
+// event 缓存的事件
+type event[T any] struct {
+	value T
+	// at 排序时间，设备时标，没有时标为接收时间，Unix 毫秒
+	at int64
+	// seq 接收顺序
+	seq uint64
+}
+
+// Buffer 有界的事件缓存，满时丢弃最早的事件并计数，可以并发调用
+type Buffer[T any] struct {
+	mu       sync.Mutex
+	events   []event[T]
+	capacity int
+	seq      uint64
+	dropped  int
+}
+
+// NewBuffer 创建事件缓存，capacity 小于等于 0 使用 DefaultCapacity
+func NewBuffer[T any](capacity int) *Buffer[T] {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Buffer[T]{capacity: capacity}
+}
+
+// Add 添加事件，deviceTime 为设备时标（Unix 毫秒），0 表示没有时标，使用接收时间 received 排序
+func (b *Buffer[T]) Add(value T, deviceTime int64, received time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if deviceTime <= 0 {
+		deviceTime = received.UnixMilli()
+	}
+	if len(b.events) >= b.capacity {
+		n := len(b.events) - b.capacity + 1
+		b.events = append(b.events[:0], b.events[n:]...)
+		b.dropped += n
+	}
+	b.seq++
+	b.events = append(b.events, event[T]{value: value, at: deviceTime, seq: b.seq})
+}
+
+// Len 缓存的事件数量
+func (b *Buffer[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.events)
+}
+
+// Drain 取出所有事件，按时标排序，时标相同保持接收顺序。dropped 为上一次取出以来因为缓存已满丢弃的事件数量
+func (b *Buffer[T]) Drain() (values []T, dropped int) {
+	b.mu.Lock()
+	events := b.events
+	dropped = b.dropped
+	b.events, b.dropped = nil, 0
+	b.mu.Unlock()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].at != events[j].at {
+			return events[i].at < events[j].at
+		}
+		return events[i].seq < events[j].seq
+	})
+	values = make([]T, 0, len(events))
+	for _, e := range events {
+		values = append(values, e.value)
+	}
+	return values, dropped
+}
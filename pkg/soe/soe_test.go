@@ -0,0 +1,44 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package soe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	b := NewBuffer[string](3)
+	received := time.UnixMilli(5000)
+	b.Add("c", 3000, received)
+	b.Add("a", 1000, received)
+	// 没有时标使用接收时间
+	b.Add("late", 0, received)
+	b.Add("b", 1000, received)
+	assert.Equal(t, 3, b.Len())
+
+	values, dropped := b.Drain()
+	assert.Equal(t, []string{"a", "b", "late"}, values)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 0, b.Len())
+
+	values, dropped = b.Drain()
+	assert.Equal(t, 0, len(values))
+	assert.Equal(t, 0, dropped)
+}
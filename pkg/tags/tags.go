@@ -0,0 +1,343 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RefPrefix 点位引用前缀，eg. tag:boiler.temperature
+const RefPrefix = "tag:"
+
+// 协议
+const (
+	ProtocolOPCUA  = "opcua"
+	ProtocolModbus = "modbus"
+	ProtocolS7     = "s7"
+	ProtocolBACnet = "bacnet"
+)
+
+// ErrTagNotFound 点位不存在
+var ErrTagNotFound = errors.New("tag not found")
+
+// Tag 逻辑点位
+type Tag struct {
+	// Name 点位名称，全局唯一
+	Name string `json:"name"`
+	// Protocol 协议：opcua、modbus、s7 等
+	Protocol string `json:"protocol"`
+	// Address 协议地址，eg. OPC UA：ns=2;s=Boiler.Temp，Modbus：holdingRegister:100、coil:5，S7：DB1.DBD20:REAL，
+	// BACnet：analog-output:1[:present-value]
+	Address string `json:"address"`
+	// DataType 数据类型，由协议组件解释，eg. Modbus 的 float32
+	DataType string `json:"dataType,omitempty"`
+	// Unit 工程单位
+	Unit string `json:"unit,omitempty"`
+	// Description 描述
+	Description string `json:"description,omitempty"`
+	// Scale 缩放系数，工程值 = 原始值 * scale + offset，scale 和 offset 都为 0 时输出原始值
+	Scale float64 `json:"scale,omitempty"`
+	// Offset 偏移量
+	Offset float64 `json:"offset,omitempty"`
+	// Deadband 死区，工程值与上一次上报的值之差的绝对值超过死区才上报，0 表示任意变化都上报
+	Deadband float64 `json:"deadband,omitempty"`
+	// Writable 是否允许通过 x/tagWrite 写入
+	Writable bool `json:"writable,omitempty"`
+	// Route x/tagWrite 输出写入消息使用的关系类型，用于区分同一协议的不同设备，为空使用协议名称
+	Route string `json:"route,omitempty"`
+	// Priority BACnet 命令优先级 1-16，0 使用写入节点配置的优先级
+	Priority int `json:"priority,omitempty"`
+}
+
+// Validate 校验点位
+func (t Tag) Validate() error {
+	if t.Name == "" {
+		return errors.New("tag name cannot be empty")
+	}
+	if t.Protocol == "" {
+		return fmt.Errorf("tag %s: protocol cannot be empty", t.Name)
+	}
+	if t.Address == "" {
+		return fmt.Errorf("tag %s: address cannot be empty", t.Name)
+	}
+	if t.Deadband < 0 {
+		return fmt.Errorf("tag %s: deadband cannot be negative", t.Name)
+	}
+	if t.Priority < 0 || t.Priority > 16 {
+		return fmt.Errorf("tag %s: priority must be 1-16, got %d", t.Name, t.Priority)
+	}
+	return nil
+}
+
+// WriteRoute 写入消息的关系类型，为空使用小写的协议名称
+func (t Tag) WriteRoute() string {
+	if t.Route != "" {
+		return t.Route
+	}
+	return strings.ToLower(t.Protocol)
+}
+
+// Scaled 按缩放系数和偏移量换算工程值，只对数值有效，其他类型原样返回
+func (t Tag) Scaled(v interface{}) interface{} {
+	if t.Scale == 0 && t.Offset == 0 {
+		return v
+	}
+	f, ok := toFloat64(v)
+	if !ok {
+		return v
+	}
+	scale := t.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return f*scale + t.Offset
+}
+
+// Unscaled 把工程值换算为原始值，用于写入，只对数值有效，其他类型原样返回
+func (t Tag) Unscaled(v interface{}) interface{} {
+	if t.Scale == 0 && t.Offset == 0 {
+		return v
+	}
+	f, ok := toFloat64(v)
+	if !ok {
+		return v
+	}
+	scale := t.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return (f - t.Offset) / scale
+}
+
+// Exceeds 工程值相对上一次上报的值是否超过死区，非数值比较是否相等
+func (t Tag) Exceeds(last, v interface{}) bool {
+	a, ok1 := toFloat64(last)
+	b, ok2 := toFloat64(v)
+	if !ok1 || !ok2 {
+		return last != v
+	}
+	if t.Deadband == 0 {
+		return a != b
+	}
+	return math.Abs(b-a) > t.Deadband
+}
+
+// Registry 点位注册表，把逻辑点位名称映射为各协议的地址（OPC UA NodeId、Modbus 寄存器、S7 地址等），
+// 并定义每个点位的缩放、单位和死区。组件的地址使用 tag:<名称> 引用全局注册表中的点位，使规则链与具体协议无关。
+// 并发安全
+type Registry struct {
+	mu   sync.RWMutex
+	tags map[string]Tag
+	// addresses 协议和地址 -> 点位名称，用于把地址反查为点位
+	addresses map[string]string
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{tags: make(map[string]Tag), addresses: make(map[string]string)}
+}
+
+// Register 注册点位，已经存在的同名点位被替换，任意点位校验失败则不注册
+func (r *Registry) Register(tags ...Tag) error {
+	for _, t := range tags {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range tags {
+		if old, ok := r.tags[t.Name]; ok {
+			delete(r.addresses, addressKey(old.Protocol, old.Address))
+		}
+		r.tags[t.Name] = t
+		r.addresses[addressKey(t.Protocol, t.Address)] = t.Name
+	}
+	return nil
+}
+
+// Remove 删除点位
+func (r *Registry) Remove(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		if t, ok := r.tags[name]; ok {
+			delete(r.addresses, addressKey(t.Protocol, t.Address))
+			delete(r.tags, name)
+		}
+	}
+}
+
+// Get 获取点位
+func (r *Registry) Get(name string) (Tag, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tags[name]
+	return t, ok
+}
+
+// Names 所有点位名称，按名称排序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.tags))
+	for name := range r.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup 按协议和地址反查点位
+func (r *Registry) Lookup(protocol, address string) (Tag, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name, ok := r.addresses[addressKey(protocol, address)]
+	if !ok {
+		return Tag{}, false
+	}
+	return r.tags[name], true
+}
+
+// Resolve 解析地址，tag:<名称> 形式的引用返回注册表中的点位，点位不存在或者协议不一致返回错误；
+// 其他地址原样返回，点位为 nil
+func (r *Registry) Resolve(protocol, ref string) (string, *Tag, error) {
+	name, ok := ParseRef(ref)
+	if !ok {
+		return ref, nil, nil
+	}
+	t, ok := r.Get(name)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrTagNotFound, name)
+	}
+	if !strings.EqualFold(t.Protocol, protocol) {
+		return "", nil, fmt.Errorf("tag %s is a %s tag, expected %s", name, t.Protocol, protocol)
+	}
+	return t.Address, &t, nil
+}
+
+// Load 加载 JSON 格式的点位：点位数组或者 {"tags": [...]}
+func (r *Registry) Load(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	var list []Tag
+	if err := json.Unmarshal(data, &list); err != nil {
+		var doc struct {
+			Tags []Tag `json:"tags"`
+		}
+		if json.Unmarshal(data, &doc) != nil {
+			return err
+		}
+		list = doc.Tags
+	}
+	return r.Register(list...)
+}
+
+// LoadFile 从文件加载点位，见 Load
+func (r *Registry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := r.Load(f); err != nil {
+		return fmt.Errorf("load tags %s: %w", path, err)
+	}
+	return nil
+}
+
+// ParseRef 解析 tag:<名称> 形式的点位引用
+func ParseRef(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if !strings.HasPrefix(ref, RefPrefix) {
+		return "", false
+	}
+	name := strings.TrimSpace(ref[len(RefPrefix):])
+	return name, name != ""
+}
+
+var (
+	defaultRegistry     = NewRegistry()
+	defaultRegistryLock sync.RWMutex
+)
+
+// SetDefault 设置组件使用的全局注册表
+func SetDefault(r *Registry) {
+	defaultRegistryLock.Lock()
+	defer defaultRegistryLock.Unlock()
+	defaultRegistry = r
+}
+
+// Default 获取组件使用的全局注册表
+func Default() *Registry {
+	defaultRegistryLock.RLock()
+	defer defaultRegistryLock.RUnlock()
+	return defaultRegistry
+}
+
+// Resolve 使用全局注册表解析地址，见 Registry.Resolve
+func Resolve(protocol, ref string) (string, *Tag, error) {
+	return Default().Resolve(protocol, ref)
+}
+
+func addressKey(protocol, address string) string {
+	return strings.ToLower(protocol) + "\x00" + address
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tags
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestTag(t *testing.T) {
+	tag := Tag{Name: "temp", Protocol: ProtocolS7, Address: "DB1.DBW0:INT", Scale: 0.1, Offset: -10, Deadband: 0.5}
+	assert.Nil(t, tag.Validate())
+	assert.Equal(t, 2.0, tag.Scaled(int16(120)))
+	assert.Equal(t, true, tag.Scaled(true))
+	assert.Equal(t, 120.0, tag.Unscaled(2.0))
+	assert.Equal(t, "x", tag.Unscaled("x"))
+	assert.Equal(t, int16(5), Tag{}.Scaled(int16(5)))
+	assert.Equal(t, 15.0, Tag{Offset: 10}.Scaled(5))
+	assert.True(t, tag.Exceeds(20.0, 20.6))
+	assert.False(t, tag.Exceeds(20.0, 19.5))
+	assert.True(t, tag.Exceeds(nil, 20.0))
+	assert.True(t, Tag{}.Exceeds(1, 2))
+	assert.False(t, Tag{}.Exceeds(2, 2.0))
+	assert.True(t, Tag{}.Exceeds("on", "off"))
+	assert.Equal(t, "opcua", Tag{Protocol: "OPCUA"}.WriteRoute())
+	assert.Equal(t, "boiler", Tag{Protocol: ProtocolModbus, Route: "boiler"}.WriteRoute())
+
+	for _, invalid := range []Tag{
+		{Protocol: ProtocolS7, Address: "M0.0"},
+		{Name: "a", Address: "M0.0"},
+		{Name: "a", Protocol: ProtocolS7},
+		{Name: "a", Protocol: ProtocolS7, Address: "M0.0", Deadband: -1},
+		{Name: "a", Protocol: ProtocolBACnet, Address: "analog-output:1", Priority: 17},
+	} {
+		assert.NotNil(t, invalid.Validate())
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Load(strings.NewReader(`[
+		{"name": "boiler.temp", "protocol": "opcua", "address": "ns=2;s=Boiler.Temp", "unit": "°C"},
+		{"name": "boiler.pressure", "protocol": "modbus", "address": "holdingRegister:100", "dataType": "float32"}
+	]`)))
+	assert.Equal(t, []string{"boiler.pressure", "boiler.temp"}, r.Names())
+
+	address, tag, err := r.Resolve(ProtocolOPCUA, "tag:boiler.temp")
+	assert.Nil(t, err)
+	assert.Equal(t, "ns=2;s=Boiler.Temp", address)
+	assert.Equal(t, "°C", tag.Unit)
+	address, tag, err = r.Resolve(ProtocolOPCUA, "ns=2;i=1")
+	assert.Nil(t, err)
+	assert.Equal(t, "ns=2;i=1", address)
+	assert.Nil(t, tag)
+	_, _, err = r.Resolve(ProtocolOPCUA, "tag:missing")
+	assert.True(t, errors.Is(err, ErrTagNotFound))
+	_, _, err = r.Resolve(ProtocolS7, "tag:boiler.pressure")
+	assert.NotNil(t, err)
+
+	found, ok := r.Lookup("OPCUA", "ns=2;s=Boiler.Temp")
+	assert.True(t, ok)
+	assert.Equal(t, "boiler.temp", found.Name)
+
+	// 替换点位后旧地址不再反查
+	assert.Nil(t, r.Register(Tag{Name: "boiler.temp", Protocol: ProtocolOPCUA, Address: "ns=2;s=Boiler.T"}))
+	_, ok = r.Lookup(ProtocolOPCUA, "ns=2;s=Boiler.Temp")
+	assert.False(t, ok)
+	r.Remove("boiler.temp")
+	_, ok = r.Get("boiler.temp")
+	assert.False(t, ok)
+	_, ok = r.Lookup(ProtocolOPCUA, "ns=2;s=Boiler.T")
+	assert.False(t, ok)
+
+	assert.NotNil(t, r.Register(Tag{Name: "ok", Protocol: "s7", Address: "M0.0"}, Tag{Name: "bad"}))
+	_, ok = r.Get("ok")
+	assert.False(t, ok)
+
+	path := filepath.Join(t.TempDir(), "tags.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"tags": [{"name": "run", "protocol": "s7", "address": "M0.1"}]}`), 0644))
+	assert.Nil(t, r.LoadFile(path))
+	_, ok = r.Get("run")
+	assert.True(t, ok)
+	assert.NotNil(t, r.Load(strings.NewReader(`{"tags": "x"}`)))
+	assert.NotNil(t, r.LoadFile(filepath.Join(t.TempDir(), "missing.json")))
+
+	name, ok := ParseRef(" tag:run ")
+	assert.True(t, ok)
+	assert.Equal(t, "run", name)
+	_, ok = ParseRef("tag:")
+	assert.False(t, ok)
+
+	old := Default()
+	defer SetDefault(old)
+	SetDefault(r)
+	address, _, err = Resolve(ProtocolS7, "tag:run")
+	assert.Nil(t, err)
+	assert.Equal(t, "M0.1", address)
+}
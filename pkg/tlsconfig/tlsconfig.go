@@ -0,0 +1,392 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tlsconfig 提供网络客户端和服务端共用的 TLS 配置
+// 统一 CA 证书、客户端证书、跳过校验、最低版本、加密套件和 SNI 的配置方式，
+// 开启 reload 后证书文件修改时在下一次握手使用新证书，不需要重启组件；也可以通过 certName 使用证书管理器中的证书
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/certmgr"
+)
+
+// reloadCheckInterval 检查证书文件是否修改的最小间隔
+var reloadCheckInterval = time.Second
+
+// Config TLS 配置
+type Config struct {
+	// CAFile CA 证书文件，PEM 格式可以包含多个证书。客户端用于校验服务端证书，服务端用于校验客户端证书（双向认证）
+	CAFile string `json:"caFile" label:"CA File" desc:"PEM CA bundle. Verifies the server certificate on clients, enables mutual TLS on servers" ref:"shared"`
+	// CertFile 证书文件，客户端为客户端证书，服务端为服务端证书
+	CertFile string `json:"certFile" label:"Cert File" desc:"PEM certificate file, the client certificate on clients and the server certificate on servers" ref:"shared"`
+	// KeyFile 证书私钥文件
+	KeyFile string `json:"keyFile" label:"Key File" desc:"PEM private key file of the certificate" ref:"shared"`
+	// InsecureSkipVerify 客户端不校验服务端证书，仅用于测试
+	InsecureSkipVerify bool `json:"insecureSkipVerify" label:"Insecure Skip Verify" desc:"Skip verification of the server certificate, for testing only"`
+	// MinVersion 最低 TLS 版本：1.0、1.1、1.2、1.3，默认 1.2
+	MinVersion string `json:"minVersion" label:"Min Version" desc:"Minimum TLS version: 1.0, 1.1, 1.2, 1.3. Default 1.2"`
+	// CipherSuites 允许的加密套件，eg. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256，为空使用默认值，TLS 1.3 不支持配置
+	CipherSuites []string `json:"cipherSuites" label:"Cipher Suites" desc:"Allowed cipher suites, eg. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Empty uses the defaults, not configurable for TLS 1.3"`
+	// ServerName 校验服务端证书和 SNI 使用的主机名，为空使用连接地址的主机名
+	ServerName string `json:"serverName" label:"Server Name" desc:"Host name for SNI and server certificate verification, empty uses the host of the address"`
+	// Reload 证书文件修改后重新加载
+	Reload bool `json:"reload" label:"Reload" desc:"Reload the certificate and CA files when they change"`
+	// CertName 证书管理器（endpoint/certManager）中的证书名称，代替 certFile 和 keyFile，证书更新或者续期后在下一次握手使用新证书
+	CertName string `json:"certName" label:"Managed Cert" desc:"Name of a certificate of the certificate manager (endpoint/certManager) used instead of certFile and keyFile, swapped on the next handshake after reload or renewal"`
+}
+
+// Enabled 是否配置了 TLS
+func (c Config) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" || c.CertName != "" || c.InsecureSkipVerify ||
+		c.MinVersion != "" || len(c.CipherSuites) > 0 || c.ServerName != ""
+}
+
+// WithFiles 证书文件为空时使用组件原有的证书配置，用于兼容组件已有的 caFile、certFile 等配置
+func (c Config) WithFiles(caFile, certFile, keyFile string) Config {
+	if c.CAFile == "" {
+		c.CAFile = caFile
+	}
+	if c.CertFile == "" && c.KeyFile == "" && c.CertName == "" {
+		c.CertFile, c.KeyFile = certFile, keyFile
+	}
+	return c
+}
+
+// Validate 检查版本、加密套件和证书文件配置
+func (c Config) Validate() error {
+	if _, err := ParseVersion(c.MinVersion); err != nil {
+		return err
+	}
+	if _, err := ParseCipherSuites(c.CipherSuites); err != nil {
+		return err
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("tls: certFile and keyFile must be set together")
+	}
+	if c.CertName != "" && c.CertFile != "" {
+		return errors.New("tls: certName and certFile can not be set together")
+	}
+	return nil
+}
+
+// ParseVersion 解析 TLS 版本，为空返回 TLS 1.2
+func ParseVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(version), "tls") {
+	case "":
+		return tls.VersionTLS12, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("tls: unsupported version %s", version)
+	}
+}
+
+// ParseCipherSuites 按名称解析加密套件，名称见 tls.CipherSuiteName，包括不安全的加密套件
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		known[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		known[s.Name] = s.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("tls: unsupported cipher suite %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// LoadCertificate 加载证书和私钥，配置了 certName 时返回证书管理器中的当前证书，没有配置证书返回 nil
+func (c Config) LoadCertificate() (*tls.Certificate, error) {
+	if c.CertName != "" {
+		return certmgr.Certificate(c.CertName)
+	}
+	if c.CertFile == "" && c.KeyFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: load key pair: %w", err)
+	}
+	return &cert, nil
+}
+
+// LoadCAPool 加载 CA 证书，没有配置 CA 证书返回 nil
+func (c Config) LoadCAPool() (*x509.CertPool, error) {
+	if c.CAFile == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: load ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("tls: no certificates found in %s", c.CAFile)
+	}
+	return pool, nil
+}
+
+// base 版本、加密套件和 SNI
+func (c Config) base() (*tls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	minVersion, _ := ParseVersion(c.MinVersion)
+	cipherSuites, _ := ParseCipherSuites(c.CipherSuites)
+	return &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		ServerName:   c.ServerName,
+	}, nil
+}
+
+// ClientConfig 客户端 TLS 配置
+// 开启 reload 时，客户端证书通过 GetClientCertificate 获取，服务端证书在 VerifyConnection 中使用最新的 CA 证书校验。
+// 配置了 certName 时客户端证书总是通过 GetClientCertificate 从证书管理器获取，证书没有注册时握手失败
+func (c Config) ClientConfig() (*tls.Config, error) {
+	config, err := c.base()
+	if err != nil {
+		return nil, err
+	}
+	config.InsecureSkipVerify = c.InsecureSkipVerify
+	if c.CertName != "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certmgr.Certificate(c.CertName)
+		}
+	}
+	if !c.Reload {
+		if c.CertName == "" {
+			cert, err := c.LoadCertificate()
+			if err != nil {
+				return nil, err
+			}
+			if cert != nil {
+				config.Certificates = []tls.Certificate{*cert}
+			}
+		}
+		if config.RootCAs, err = c.LoadCAPool(); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+	w, err := newWatcher(c)
+	if err != nil {
+		return nil, err
+	}
+	if c.CertName == "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := w.certificate(); cert != nil {
+				return cert, nil
+			}
+			// 没有证书时不发送证书
+			return &tls.Certificate{}, nil
+		}
+	}
+	if c.CAFile != "" && !c.InsecureSkipVerify {
+		// 由 VerifyConnection 使用最新的 CA 证书校验
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verify(cs.PeerCertificates, w.pool(), cs.ServerName, x509.ExtKeyUsageServerAuth)
+		}
+	}
+	return config, nil
+}
+
+// ServerConfig 服务端 TLS 配置，必须配置证书，配置了 CA 证书时要求客户端提供证书（双向认证）
+// 开启 reload 时，服务端证书通过 GetCertificate 获取，客户端证书在 VerifyConnection 中使用最新的 CA 证书校验。
+// 配置了 certName 时服务端证书总是通过 GetCertificate 从证书管理器获取，证书没有注册时握手失败
+func (c Config) ServerConfig() (*tls.Config, error) {
+	if c.CertFile == "" && c.CertName == "" {
+		return nil, errors.New("tls: server requires certFile and keyFile")
+	}
+	config, err := c.base()
+	if err != nil {
+		return nil, err
+	}
+	if c.CertName != "" {
+		config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certmgr.Certificate(c.CertName)
+		}
+	}
+	if !c.Reload {
+		if c.CertName == "" {
+			cert, err := c.LoadCertificate()
+			if err != nil {
+				return nil, err
+			}
+			config.Certificates = []tls.Certificate{*cert}
+		}
+		if config.ClientCAs, err = c.LoadCAPool(); err != nil {
+			return nil, err
+		}
+		if config.ClientCAs != nil {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		return config, nil
+	}
+	w, err := newWatcher(c)
+	if err != nil {
+		return nil, err
+	}
+	if c.CertName == "" {
+		config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return w.certificate(), nil
+		}
+	}
+	if c.CAFile != "" {
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verify(cs.PeerCertificates, w.pool(), "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+	return config, nil
+}
+
+// verify 校验证书链，serverName 为空不校验主机名
+func verify(certs []*x509.Certificate, pool *x509.CertPool, serverName string, usage x509.ExtKeyUsage) error {
+	if len(certs) == 0 {
+		return errors.New("tls: no peer certificate")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         pool,
+		DNSName:       serverName,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// watcher 证书文件修改后重新加载，加载失败时继续使用原有的证书
+type watcher struct {
+	config Config
+	mu     sync.Mutex
+	// checked 最后一次检查文件的时间
+	checked time.Time
+	// modTimes 文件的修改时间
+	modTimes map[string]time.Time
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+}
+
+func newWatcher(c Config) (*watcher, error) {
+	w := &watcher{config: c, modTimes: make(map[string]time.Time)}
+	var err error
+	if w.cert, err = c.LoadCertificate(); err != nil {
+		return nil, err
+	}
+	if w.caPool, err = c.LoadCAPool(); err != nil {
+		return nil, err
+	}
+	w.checked = time.Now()
+	for _, file := range []string{c.CertFile, c.KeyFile, c.CAFile} {
+		if file != "" {
+			w.modTimes[file] = modTime(file)
+		}
+	}
+	return w, nil
+}
+
+func (w *watcher) certificate() *tls.Certificate {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.check()
+	return w.cert
+}
+
+func (w *watcher) pool() *x509.CertPool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.check()
+	return w.caPool
+}
+
+// check 文件修改时间变化时重新加载，加载成功后才记录修改时间，证书和私钥没有同时更新完成时下次检查重试
+func (w *watcher) check() {
+	if time.Since(w.checked) < reloadCheckInterval {
+		return
+	}
+	w.checked = time.Now()
+	if times, ok := w.changed(w.config.CertFile, w.config.KeyFile); ok {
+		if cert, err := w.config.LoadCertificate(); err == nil {
+			w.cert = cert
+			w.record(times)
+		}
+	}
+	if times, ok := w.changed(w.config.CAFile); ok {
+		if pool, err := w.config.LoadCAPool(); err == nil {
+			w.caPool = pool
+			w.record(times)
+		}
+	}
+}
+
+// changed 返回文件当前的修改时间，以及是否有文件被修改
+func (w *watcher) changed(files ...string) (map[string]time.Time, bool) {
+	times := make(map[string]time.Time, len(files))
+	result := false
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		times[file] = modTime(file)
+		if !times[file].Equal(w.modTimes[file]) {
+			result = true
+		}
+	}
+	return times, result
+}
+
+func (w *watcher) record(times map[string]time.Time) {
+	for file, t := range times {
+		w.modTimes[file] = t
+	}
+}
+
+func modTime(file string) time.Time {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego-components-iot/pkg/certmgr"
+	"github.com/rulego/rulego/test/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return testCA{cert: cert, key: key}
+}
+
+// writeCA 写入 CA 证书
+func (ca testCA) writeCA(t *testing.T, file string) {
+	assert.Nil(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600))
+}
+
+// issue 签发证书并写入文件
+func (ca testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+// handshake 建立 TLS 连接，返回服务端证书序号
+func handshake(addr string, config *tls.Config) (int64, error) {
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// TLS 1.3 客户端证书在第一次读取时才被服务端校验
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err = conn.Read(make([]byte, 1)); err != nil {
+		return 0, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestConfig(t *testing.T) {
+	assert.False(t, Config{}.Enabled())
+	assert.True(t, Config{InsecureSkipVerify: true}.Enabled())
+	assert.NotNil(t, Config{MinVersion: "2.0"}.Validate())
+	assert.NotNil(t, Config{CipherSuites: []string{"TLS_UNKNOWN"}}.Validate())
+	assert.NotNil(t, Config{CertFile: "client.pem"}.Validate())
+	assert.NotNil(t, Config{CertName: "opcua", CertFile: "a.pem", KeyFile: "a.key"}.Validate())
+	assert.True(t, Config{CertName: "opcua"}.Enabled())
+	assert.Nil(t, Config{MinVersion: "1.3", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}.Validate())
+
+	c := Config{CertFile: "a.pem", KeyFile: "a.key"}.WithFiles("ca.pem", "b.pem", "b.key")
+	assert.Equal(t, Config{CAFile: "ca.pem", CertFile: "a.pem", KeyFile: "a.key"}, c)
+
+	_, err := Config{}.ServerConfig()
+	assert.NotNil(t, err)
+	_, err = Config{CAFile: "missing.pem"}.ClientConfig()
+	assert.NotNil(t, err)
+}
+
+func TestReload(t *testing.T) {
+	reloadCheckInterval = 0
+	defer func() { reloadCheckInterval = time.Second }()
+
+	dir := t.TempDir()
+	file := func(name string) string { return filepath.Join(dir, name) }
+	ca := newTestCA(t)
+	ca.writeCA(t, file("ca.pem"))
+	ca.issue(t, 10, x509.ExtKeyUsageServerAuth, file("server.pem"), file("server.key"))
+	ca.issue(t, 20, x509.ExtKeyUsageClientAuth, file("client.pem"), file("client.key"))
+
+	serverConfig, err := Config{CAFile: file("ca.pem"), CertFile: file("server.pem"), KeyFile: file("server.key"), Reload: true}.ServerConfig()
+	assert.Nil(t, err)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if conn.(*tls.Conn).Handshake() == nil {
+					_, _ = conn.Write([]byte{1})
+				}
+			}()
+		}
+	}()
+	addr := l.Addr().String()
+
+	client := Config{CAFile: file("ca.pem"), CertFile: file("client.pem"), KeyFile: file("client.key"), ServerName: "localhost", Reload: true}
+	clientConfig, err := client.ClientConfig()
+	assert.Nil(t, err)
+	serial, err := handshake(addr, clientConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), serial)
+
+	// 不提供客户端证书
+	noCert, err := Config{CAFile: file("ca.pem"), ServerName: "localhost"}.ClientConfig()
+	assert.Nil(t, err)
+	_, err = handshake(addr, noCert)
+	assert.True(t, err != nil)
+
+	// 更换服务端证书后新的连接使用新证书
+	ca.issue(t, 11, x509.ExtKeyUsageServerAuth, file("server.pem"), file("server.key"))
+	future := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(file("server.pem"), future, future))
+	assert.Nil(t, os.Chtimes(file("server.key"), future, future))
+	serial, err = handshake(addr, clientConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), serial)
+
+	// 更换 CA 后，原有的服务端证书不再被信任
+	other := newTestCA(t)
+	other.writeCA(t, file("ca.pem"))
+	assert.Nil(t, os.Chtimes(file("ca.pem"), future, future))
+	_, err = handshake(addr, clientConfig)
+	assert.True(t, err != nil)
+
+	// 不开启 reload 时使用静态证书
+	static, err := Config{InsecureSkipVerify: true, MinVersion: "1.2"}.ClientConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), static.MinVersion)
+	assert.True(t, static.InsecureSkipVerify)
+}
+
+func TestManagedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	file := func(name string) string { return filepath.Join(dir, name) }
+	ca := newTestCA(t)
+	ca.writeCA(t, file("ca.pem"))
+	ca.issue(t, 30, x509.ExtKeyUsageServerAuth, file("server.pem"), file("server.key"))
+
+	serverConfig, err := Config{CertName: "tlsconfig-test"}.ServerConfig()
+	assert.Nil(t, err)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if conn.(*tls.Conn).Handshake() == nil {
+					_, _ = conn.Write([]byte{1})
+				}
+			}()
+		}
+	}()
+	clientConfig, err := Config{CAFile: file("ca.pem"), ServerName: "localhost"}.ClientConfig()
+	assert.Nil(t, err)
+
+	// 证书没有注册时握手失败
+	_, err = handshake(l.Addr().String(), clientConfig)
+	assert.NotNil(t, err)
+
+	manager := certmgr.Default()
+	assert.Nil(t, manager.Add(certmgr.Config{Name: "tlsconfig-test", CertFile: file("server.pem"), KeyFile: file("server.key")}))
+	defer manager.Remove("tlsconfig-test")
+	serial, err := handshake(l.Addr().String(), clientConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), serial)
+
+	// 证书管理器重新加载后新的连接使用新证书
+	ca.issue(t, 31, x509.ExtKeyUsageServerAuth, file("server.pem"), file("server.key"))
+	future := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(file("server.pem"), future, future))
+	assert.Nil(t, os.Chtimes(file("server.key"), future, future))
+	manager.Check()
+	serial, err = handshake(l.Addr().String(), clientConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(31), serial)
+}